@@ -28,6 +28,12 @@ func NewCommand() *cobra.Command {
 		newDelete(),
 		newCheck(),
 		newList(),
+		newValidate(),
+		newIAMPolicy(),
+		newImages(),
+		newConfig(),
+		newEnvHelp(),
+		newMulti(),
 	)
 	return cmd
 }
@@ -0,0 +1,97 @@
+package eks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/pkg/configfile"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var migrateDryRun bool
+
+func newConfigMigrate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrades the configuration to the current schema version",
+		Long: `Upgrades the configuration written by the older aws-k8s-tester
+(or without "apiVersion") to the current schema version, in place.
+The original configuration is kept with the ".bak" suffix.
+HCL is not rewritten, and the migrated configuration is written
+to "<path>.yaml" to update the template with.
+
+aws-k8s-tester eks config migrate -p config.yaml
+`,
+		Run: configMigrateFunc,
+	}
+	cmd.PersistentFlags().BoolVar(&migrateDryRun, "dry-run", false, "'true' to print the migrated configuration without writing")
+	return cmd
+}
+
+func configMigrateFunc(cmd *cobra.Command, args []string) {
+	if path == "" {
+		fmt.Fprintln(os.Stderr, "'--path' flag is not specified")
+		os.Exit(1)
+	}
+	d, err := configfile.Read(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+	out, applied, err := eksconfig.Migrate(d)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to migrate configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+	if len(applied) == 0 {
+		fmt.Printf("configuration %q is already at %q\n", path, eksconfig.APIVersion)
+		return
+	}
+	if err = yaml.Unmarshal(out, new(eksconfig.Config), yaml.DisallowUnknownFields); err != nil {
+		fmt.Fprintf(os.Stderr, "migrated configuration %q is not valid for %q (%v); fix or remove the field manually\n", path, eksconfig.APIVersion, err)
+		os.Exit(1)
+	}
+
+	// write in the same format as the original
+	dst := configfile.SyncPath(path)
+	var m map[string]interface{}
+	if err = yaml.Unmarshal(out, &m); err == nil {
+		out, err = configfile.Marshal(dst, m)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode migrated configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+
+	for _, a := range applied {
+		fmt.Printf("migrated %s\n", a)
+	}
+	if migrateDryRun {
+		fmt.Printf("\n%s\n", string(out))
+		return
+	}
+	if dst != path {
+		if err = ioutil.WriteFile(dst, out, 0600); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write configuration %q (%v)\n", dst, err)
+			os.Exit(1)
+		}
+		fmt.Printf("\n'aws-k8s-tester eks config migrate' success %q (update %q with the migrated fields)\n", dst, path)
+		return
+	}
+	orig, err := ioutil.ReadFile(path)
+	if err == nil {
+		err = ioutil.WriteFile(path+".bak", orig, 0600)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to back up configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+	if err = ioutil.WriteFile(path, out, 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+	fmt.Printf("\n'aws-k8s-tester eks config migrate' success %q (original %q)\n", path, path+".bak")
+}
@@ -0,0 +1,14 @@
+package eks
+
+import "github.com/spf13/cobra"
+
+func newConfig() *cobra.Command {
+	ac := &cobra.Command{
+		Use:   "config <subcommand>",
+		Short: "Configuration commands",
+	}
+	ac.AddCommand(
+		newConfigMigrate(),
+	)
+	return ac
+}
@@ -6,23 +6,43 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-k8s-tester/eks"
 	"github.com/aws/aws-k8s-tester/eksconfig"
 	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+	"github.com/aws/aws-k8s-tester/pkg/logutil"
 	"github.com/aws/aws-k8s-tester/version"
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	dryRun       bool
+	dryRunDir    string
+	soakDuration time.Duration
 )
 
 func newCreateCluster() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "cluster",
 		Short: "Create an eks cluster",
-		Long:  "Configuration values are overwritten by environment variables.",
-		Run:   createClusterFunc,
+		Long: `Configuration values are overwritten by environment variables.
+
+With "--dry-run", renders the CloudFormation templates, EKS API request payloads,
+and IAM policy documents to disk without calling AWS APIs, for review before the creation.
+
+With "--soak-duration", keeps the cluster after the creation, re-running the health
+checks and lightweight workloads every "soak-interval" for the duration, streaming
+the results to S3, and then deletes the cluster.
+`,
+		Run: createClusterFunc,
 	}
+	cmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "'true' to render the resources to create without calling AWS APIs")
+	cmd.PersistentFlags().StringVar(&dryRunDir, "dry-run-dir", "", "Directory to render the resources with '--dry-run' (default '[CONFIG-PATH]-plan')")
+	cmd.PersistentFlags().DurationVar(&soakDuration, "soak-duration", 0, "Duration to soak the cluster after the creation before deleting it, overwrites 'soak-duration' in the configuration (0 to use the configuration)")
 	return cmd
 }
 
@@ -60,6 +80,9 @@ func createClusterFunc(cmd *cobra.Command, args []string) {
 		fmt.Fprintf(os.Stderr, "failed to load configuration from environment variables: %v\n", err)
 		os.Exit(1)
 	}
+	if soakDuration > 0 {
+		cfg.SoakDuration = soakDuration
+	}
 
 	if err = cfg.ValidateAndSetDefaults(); err != nil {
 		fmt.Fprintf(os.Stderr, "failed to validate configuration %q (%v)\n", path, err)
@@ -87,6 +110,29 @@ func createClusterFunc(cmd *cobra.Command, args []string) {
 		fmt.Printf("AddOnManagedNodeGroups:\n\n%s\n\n\n", string(body))
 	}
 
+	if dryRun {
+		if dryRunDir == "" {
+			dryRunDir = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + "-plan"
+		}
+		lcfg := logutil.GetDefaultZapLoggerConfig()
+		lcfg.Level = zap.NewAtomicLevelAt(logutil.ConvertToZapLevel(cfg.LogLevel))
+		lg, err := lcfg.Build()
+		if err != nil {
+			panic(err)
+		}
+		paths, err := eks.Plan(lg, cfg, dryRunDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to render resources %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\n'--dry-run' specified; rendered %d file(s) without calling AWS APIs:\n\n", len(paths))
+		for _, p := range paths {
+			fmt.Println(p)
+		}
+		fmt.Println()
+		return
+	}
+
 	if enablePrompt {
 		prompt := promptui.Select{
 			Label: "Ready to create EKS resources, should we continue?",
@@ -120,6 +166,21 @@ func createClusterFunc(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	if cfg.SoakDuration > 0 {
+		soakErr := tester.Soak()
+		if err = tester.Down(); err != nil {
+			fmt.Fprintf(logWriter, cfg.Colorize(fmt.Sprintf("[default]aws-k8s-tester eks delete cluster after soak [light_magenta]FAIL [default](%v)\n", err)))
+		}
+		if soakErr != nil {
+			fmt.Fprintf(logWriter, cfg.Colorize("\n\n\n[yellow]*********************************\n"))
+			fmt.Fprintf(logWriter, cfg.Colorize(fmt.Sprintf("[default]aws-k8s-tester eks create cluster soak [light_magenta]FAIL [default](%v)\n", soakErr)))
+			os.Exit(1)
+		}
+		if err != nil {
+			os.Exit(1)
+		}
+	}
+
 	fmt.Fprintf(logWriter, cfg.Colorize("\n\n\n[yellow]*********************************\n"))
 	fmt.Fprintf(logWriter, cfg.Colorize("[default]aws-k8s-tester eks create cluster [light_green]SUCCESS\n"))
 }
@@ -22,12 +22,16 @@ func newDelete() *cobra.Command {
 	return ac
 }
 
+var deleteForce bool
+
 func newDeleteCluster() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "cluster",
 		Short: "Delete eks cluster",
 		Run:   deleteClusterFunc,
 	}
+	cmd.PersistentFlags().BoolVar(&deleteForce, "force", false, "'true' to force-delete all resources left behind on a best-effort basis (e.g. ENIs and load balancers pinning the VPC), even if the cluster deletion fails")
+	return cmd
 }
 
 func deleteClusterFunc(cmd *cobra.Command, args []string) {
@@ -76,7 +80,12 @@ func deleteClusterFunc(cmd *cobra.Command, args []string) {
 	}
 	logWriter := tester.LogWriter()
 
-	if err = tester.Down(); err != nil {
+	if deleteForce {
+		err = tester.ForceDown()
+	} else {
+		err = tester.Down()
+	}
+	if err != nil {
 		fmt.Fprintf(logWriter, cfg.Colorize("\n\n\n[yellow]*********************************\n"))
 		fmt.Fprintf(logWriter, cfg.Colorize(fmt.Sprintf("[default]aws-k8s-tester eks delete cluster [light_magenta]FAIL [default](%v)\n", err)))
 		os.Exit(1)
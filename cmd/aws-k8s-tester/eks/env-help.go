@@ -0,0 +1,74 @@
+package eks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var envHelpJSON bool
+
+func newEnvHelp() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "env-help [filter]",
+		Short: "Lists the supported 'AWS_K8S_TESTER_EKS_*' environment variables",
+		Long: `Lists the environment variables that overwrite the configuration,
+with the field, the type, and the default value. The optional filter
+matches the variable or field names (case-insensitive).
+Set "AWS_K8S_TESTER_EKS_ENV_STRICT=true" to fail on unknown variables.
+
+aws-k8s-tester eks env-help
+aws-k8s-tester eks env-help vpc
+aws-k8s-tester eks env-help --json ADD_ON_MANAGED_NODE_GROUPS
+`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  envHelpFunc,
+	}
+	cmd.PersistentFlags().BoolVar(&envHelpJSON, "json", false, "'true' to print in JSON")
+	return cmd
+}
+
+func envHelpFunc(cmd *cobra.Command, args []string) {
+	filter := ""
+	if len(args) > 0 {
+		filter = strings.ToLower(args[0])
+	}
+	var evs []eksconfig.EnvVar
+	for _, ev := range eksconfig.EnvVars() {
+		if filter != "" &&
+			!strings.Contains(strings.ToLower(ev.Name), filter) &&
+			!strings.Contains(strings.ToLower(ev.Field), filter) {
+			continue
+		}
+		evs = append(evs, ev)
+	}
+
+	if envHelpJSON {
+		b, err := json.MarshalIndent(evs, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to encode %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(b))
+		return
+	}
+
+	buf := bytes.NewBuffer(nil)
+	tb := tablewriter.NewWriter(buf)
+	tb.SetAutoWrapText(false)
+	tb.SetAlignment(tablewriter.ALIGN_LEFT)
+	tb.SetColWidth(1500)
+	tb.SetCenterSeparator("*")
+	tb.SetHeader([]string{"environmental variable", "read only", "field", "type", "default"})
+	for _, ev := range evs {
+		tb.Append([]string{ev.Name, fmt.Sprint(ev.ReadOnly), ev.Field, ev.Type, ev.Default})
+	}
+	tb.Render()
+	fmt.Printf("%s\n%d environment variable(s)\n", buf.String(), len(evs))
+}
@@ -0,0 +1,77 @@
+package eks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	callerpolicy "github.com/aws/aws-k8s-tester/eks/caller-policy"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/pkg/configfile"
+	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+	"github.com/spf13/cobra"
+)
+
+func newIAMPolicy() *cobra.Command {
+	return &cobra.Command{
+		Use:   "iam-policy",
+		Short: "Print the IAM policy required to run the configuration",
+		Long: `Configuration values are overwritten by environment variables, the same as "create cluster".
+The configuration file is not modified.
+
+Prints the IAM policy document that the caller (e.g. the CI role) requires
+to create and delete the cluster with the enabled features, so that the
+tester does not need an administrator role. The statement groups included
+are printed to stderr.
+
+aws-k8s-tester eks iam-policy -p config.yaml > policy.json
+aws iam create-policy --policy-name aws-k8s-tester --policy-document file://policy.json
+`,
+		Run: iamPolicyFunc,
+	}
+}
+
+func iamPolicyFunc(cmd *cobra.Command, args []string) {
+	if !fileutil.Exist(path) {
+		fmt.Fprintf(os.Stderr, "cannot find configuration %q\n", path)
+		os.Exit(1)
+	}
+	// load and validate the copy, since both write the configuration back
+	d, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+	// keep the extension for the format (see "configfile.Format")
+	cp := filepath.Join(os.TempDir(), "iam-policy-"+filepath.Base(path))
+	if err = ioutil.WriteFile(cp, d, 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to copy configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+	cleanup := func() {
+		os.RemoveAll(cp)
+		os.RemoveAll(configfile.SyncPath(cp))
+	}
+	defer cleanup()
+	cfg, err := eksconfig.Load(cp)
+	if err != nil {
+		cleanup()
+		fmt.Fprintf(os.Stderr, "failed to load configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+	if err = cfg.UpdateFromEnvs(); err != nil {
+		cleanup()
+		fmt.Fprintf(os.Stderr, "failed to update configuration from environment variables (%v)\n", err)
+		os.Exit(1)
+	}
+	if err = cfg.ValidateAndSetDefaults(); err != nil {
+		cleanup()
+		fmt.Fprintf(os.Stderr, "failed to validate configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "IAM policy groups for %q: %s\n", path, strings.Join(callerpolicy.Groups(cfg), ", "))
+	fmt.Println(callerpolicy.Document(cfg))
+}
@@ -0,0 +1,77 @@
+package eks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/pkg/configfile"
+	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+	"github.com/spf13/cobra"
+)
+
+func newImages() *cobra.Command {
+	return &cobra.Command{
+		Use:   "images",
+		Short: "Print the container images referenced by the configuration",
+		Long: `Configuration values are overwritten by environment variables, the same as "create cluster".
+The configuration file is not modified.
+
+Prints the container images referenced by the enabled add-ons, one per line,
+which are mirrored to the private ECR repositories with "image-mirror.enable".
+The images already mirrored are printed with their mirrors to stderr.
+
+aws-k8s-tester eks images -p config.yaml
+`,
+		Run: imagesFunc,
+	}
+}
+
+func imagesFunc(cmd *cobra.Command, args []string) {
+	if !fileutil.Exist(path) {
+		fmt.Fprintf(os.Stderr, "cannot find configuration %q\n", path)
+		os.Exit(1)
+	}
+	// load and validate the copy, since both write the configuration back
+	d, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+	// keep the extension for the format (see "configfile.Format")
+	cp := filepath.Join(os.TempDir(), "images-"+filepath.Base(path))
+	if err = ioutil.WriteFile(cp, d, 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to copy configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+	cleanup := func() {
+		os.RemoveAll(cp)
+		os.RemoveAll(configfile.SyncPath(cp))
+	}
+	defer cleanup()
+	cfg, err := eksconfig.Load(cp)
+	if err != nil {
+		cleanup()
+		fmt.Fprintf(os.Stderr, "failed to load configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+	if err = cfg.UpdateFromEnvs(); err != nil {
+		cleanup()
+		fmt.Fprintf(os.Stderr, "failed to update configuration from environment variables (%v)\n", err)
+		os.Exit(1)
+	}
+	if err = cfg.ValidateAndSetDefaults(); err != nil {
+		cleanup()
+		fmt.Fprintf(os.Stderr, "failed to validate configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+
+	for _, img := range cfg.Images() {
+		if mirrored := cfg.Image(img); mirrored != img {
+			fmt.Fprintf(os.Stderr, "%s -> %s\n", img, mirrored)
+		}
+		fmt.Println(img)
+	}
+}
@@ -0,0 +1,99 @@
+package eks
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-k8s-tester/eks/multi"
+	"github.com/aws/aws-k8s-tester/pkg/logutil"
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+)
+
+func newMulti() *cobra.Command {
+	ac := &cobra.Command{
+		Use:   "multi <subcommand>",
+		Short: "Multi-cluster commands",
+		Long: `Creates or deletes multiple clusters in one run, from the multi-cluster
+configuration with the cluster configuration template (see "eks/multi").
+
+template: cluster.yaml   # rendered per cluster (e.g. "name: {{ .Name }}")
+concurrency: 2
+clusters:
+- name: mcs-1
+  region: us-west-2
+- name: mcs-2
+  region: us-east-1
+  vars: {role: consumer}
+command-after-create-clusters: ./test-mcs.sh   # with MULTI_CLUSTER_KUBECONFIGS
+failover:                      # kills mcs-1 nodes, measures DNS failover to mcs-2
+  enable: true
+  hosted-zone-id: Z0123456789
+  record-name: failover.example.com
+
+aws-k8s-tester eks multi create -p multi.yaml
+aws-k8s-tester eks multi delete -p multi.yaml
+`,
+	}
+	ac.AddCommand(
+		&cobra.Command{
+			Use:   "create",
+			Short: "Create the clusters and their add-ons",
+			Run:   func(cmd *cobra.Command, args []string) { multiFunc("create") },
+		},
+		&cobra.Command{
+			Use:   "delete",
+			Short: "Delete the clusters",
+			Run:   func(cmd *cobra.Command, args []string) { multiFunc("delete") },
+		},
+	)
+	return ac
+}
+
+func multiFunc(op string) {
+	if path == "" {
+		fmt.Fprintln(os.Stderr, "'--path' flag is not specified")
+		os.Exit(1)
+	}
+	cfg, err := multi.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load multi-cluster configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+
+	if enablePrompt {
+		prompt := promptui.Select{
+			Label: fmt.Sprintf("Ready to %s %d EKS clusters, should we continue?", op, len(cfg.Clusters)),
+			Items: []string{
+				"No, cancel it!",
+				fmt.Sprintf("Yes, let's %s!", op),
+			},
+		}
+		idx, answer, err := prompt.Run()
+		if err != nil {
+			panic(err)
+		}
+		if idx != 1 {
+			fmt.Printf("returning '%s' [index %d, answer %q]\n", op, idx, answer)
+			return
+		}
+	}
+
+	lg, err := logutil.GetDefaultZapLogger()
+	if err != nil {
+		panic(err)
+	}
+	m := multi.New(lg, cfg)
+	if op == "create" {
+		_, err = m.Up()
+	} else {
+		_, err = m.Down()
+	}
+	if err != nil {
+		fmt.Printf("\n*********************************\n")
+		fmt.Printf("'aws-k8s-tester eks multi %s' FAIL (%v)\n", op, err)
+		os.Exit(1)
+	}
+	fmt.Printf("\n*********************************\n")
+	fmt.Printf("'aws-k8s-tester eks multi %s' SUCCESS\n", op)
+}
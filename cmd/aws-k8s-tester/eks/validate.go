@@ -0,0 +1,173 @@
+package eks
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-k8s-tester/eks/preflight"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	pkg_aws "github.com/aws/aws-k8s-tester/pkg/aws"
+	"github.com/aws/aws-k8s-tester/pkg/configfile"
+	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+	"github.com/aws/aws-k8s-tester/pkg/logutil"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var validateSkipAWS bool
+
+func newValidate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate the configuration and check the AWS account before creating resources",
+		Long: `Configuration values are overwritten by environment variables, the same as "create cluster".
+The configuration file is not modified.
+
+Checks the AWS account and region for the problems that would fail the creation
+midway (e.g. VPC and Elastic IP quotas, instance type offerings, AMIs),
+and exits with the code 1 if any error is found.
+
+aws-k8s-tester eks validate -p config.yaml
+`,
+		Run: validateFunc,
+	}
+	cmd.PersistentFlags().BoolVar(&validateSkipAWS, "skip-aws", false, "'true' to only validate the configuration without calling AWS APIs")
+	return cmd
+}
+
+func validateFunc(cmd *cobra.Command, args []string) {
+	if !fileutil.Exist(path) {
+		fmt.Fprintf(os.Stderr, "cannot find configuration %q\n", path)
+		os.Exit(1)
+	}
+	// load and validate the copy, since both write the configuration back
+	d, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+	// keep the extension for the format (see "configfile.Format")
+	cp := filepath.Join(os.TempDir(), "validate-"+filepath.Base(path))
+	if err = ioutil.WriteFile(cp, d, 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to copy configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+	cleanup := func() {
+		os.RemoveAll(cp)
+		os.RemoveAll(configfile.SyncPath(cp))
+	}
+	defer cleanup()
+	cfg, err := eksconfig.Load(cp)
+	if err != nil {
+		cleanup()
+		fmt.Fprintf(os.Stderr, "failed to load configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+
+	var ps []preflight.Problem
+	if err = cfg.UpdateFromEnvs(); err != nil {
+		ps = append(ps, preflight.Problem{
+			Severity: preflight.SeverityError,
+			Check:    "config",
+			Field:    "environment variables",
+			Message:  err.Error(),
+			Fix:      "fix or unset the '" + eksconfig.AWS_K8S_TESTER_EKS_PREFIX + "*' environment variable",
+		})
+	}
+
+	if err = cfg.ValidateAndSetDefaults(); err != nil {
+		ps = append(ps, preflight.Problem{
+			Severity: preflight.SeverityError,
+			Check:    "config",
+			Field:    configField(err),
+			Message:  err.Error(),
+			Fix:      "fix the field in the configuration file or the environment variable (see 'eksconfig/README.md')",
+		})
+	}
+
+	if len(ps) == 0 && !validateSkipAWS {
+		ps = append(ps, checkAWS(cfg)...)
+	}
+
+	if len(ps) == 0 {
+		fmt.Printf("\n'aws-k8s-tester eks validate' found no problem in %q\n", path)
+		return
+	}
+	buf := bytes.NewBuffer(nil)
+	tb := tablewriter.NewWriter(buf)
+	tb.SetAutoWrapText(false)
+	tb.SetAlignment(tablewriter.ALIGN_LEFT)
+	tb.SetCenterSeparator("*")
+	tb.SetHeader([]string{"severity", "check", "field", "problem", "fix"})
+	for _, p := range ps {
+		tb.Append([]string{p.Severity, p.Check, p.Field, p.Message, p.Fix})
+	}
+	tb.Render()
+	fmt.Printf("\n%s\n'aws-k8s-tester eks validate' found %d problem(s) in %q\n", buf.String(), len(ps), path)
+	if preflight.HasError(ps) {
+		cleanup()
+		os.Exit(1)
+	}
+}
+
+func checkAWS(cfg *eksconfig.Config) []preflight.Problem {
+	lcfg := logutil.GetDefaultZapLoggerConfig()
+	lcfg.Level = zap.NewAtomicLevelAt(logutil.ConvertToZapLevel(cfg.LogLevel))
+	lg, err := lcfg.Build()
+	if err != nil {
+		panic(err)
+	}
+	awsCfg := pkg_aws.Config{
+		Logger:     lg,
+		Partition:  cfg.Partition,
+		Region:     cfg.Region,
+		Endpoints:  cfg.ServiceEndpoints.AWSEndpoints(),
+		AssumeRole: cfg.AssumeRole.AWSAssumeRole(),
+	}
+	ss, _, _, err := pkg_aws.New(&awsCfg)
+	if err != nil {
+		return []preflight.Problem{credentialsProblem(fmt.Errorf("failed to create AWS session (%v)", err))}
+	}
+	awsCfgV2, err := pkg_aws.NewV2(&awsCfg)
+	if err != nil {
+		return []preflight.Problem{credentialsProblem(fmt.Errorf("failed to create AWS SDK v2 config (%v)", err))}
+	}
+	return preflight.Check(preflight.Config{
+		Logger:    lg,
+		EKSConfig: cfg,
+		EC2APIV2:  aws_ec2_v2.NewFromConfig(awsCfgV2),
+		SSMAPI:    ssm.New(ss),
+		QuotasAPI: servicequotas.New(ss),
+	})
+}
+
+func credentialsProblem(err error) preflight.Problem {
+	return preflight.Problem{
+		Severity: preflight.SeverityError,
+		Check:    "credentials",
+		Field:    "Region",
+		Message:  err.Error(),
+		Fix:      "set the AWS credentials (e.g. 'AWS_PROFILE') and the region",
+	}
+}
+
+// configField returns the field named in the validation error
+// (e.g. "validateConfig failed [unknown ControlPlaneLogging.Types ...]"),
+// or "config" if not found.
+func configField(err error) string {
+	for _, w := range strings.Fields(err.Error()) {
+		w = strings.Trim(w, "[]()\"',:")
+		if i := strings.Index(w, "."); i > 0 && w[0] >= 'A' && w[0] <= 'Z' && !strings.HasSuffix(w, ".") {
+			return w
+		}
+	}
+	return "config"
+}
@@ -0,0 +1,121 @@
+// Package janitor implements "aws-k8s-tester janitor" command.
+package janitor
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eks/janitor"
+	pkg_aws "github.com/aws/aws-k8s-tester/pkg/aws"
+	"github.com/aws/aws-k8s-tester/pkg/logutil"
+	aws_cfn_v2 "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	logLevel  string
+	partition string
+	region    string
+	ttl       time.Duration
+	dryRun    bool
+)
+
+// NewCommand implements "aws-k8s-tester janitor" command.
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "janitor",
+		Short: "Delete stale AWS resources created by aws-k8s-tester",
+		Long: `Finds the EKS clusters, CloudFormation stacks, EC2 key pairs, S3 buckets,
+and CloudWatch log groups tagged "Kind=aws-k8s-tester" that are older than the TTL,
+and deletes them. Clusters with node groups or Fargate profiles are deleted
+asynchronously, so re-run the janitor until nothing is left.
+
+aws-k8s-tester janitor --region us-west-2 --ttl 24h --dry-run
+
+aws-k8s-tester janitor --region us-west-2 --ttl 24h
+`,
+		Run: janitorFunc,
+	}
+	cmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "Log level (debug, info, warn, error, dpanic, panic, fatal)")
+	cmd.PersistentFlags().StringVar(&partition, "partition", "aws", "AWS partition")
+	cmd.PersistentFlags().StringVar(&region, "region", "us-west-2", "AWS region")
+	cmd.PersistentFlags().DurationVar(&ttl, "ttl", 24*time.Hour, "Minimum age of the resources to delete")
+	cmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "'true' to only list the resources to delete")
+	return cmd
+}
+
+func janitorFunc(cmd *cobra.Command, args []string) {
+	if ttl <= 0 {
+		fmt.Fprintf(os.Stderr, "invalid --ttl %v\n", ttl)
+		os.Exit(1)
+	}
+
+	lcfg := logutil.GetDefaultZapLoggerConfig()
+	lcfg.Level = zap.NewAtomicLevelAt(logutil.ConvertToZapLevel(logLevel))
+	lg, err := lcfg.Build()
+	if err != nil {
+		panic(err)
+	}
+	ss, _, _, err := pkg_aws.New(&pkg_aws.Config{
+		Logger:        lg,
+		DebugAPICalls: logLevel == "debug",
+		Partition:     partition,
+		Region:        region,
+	})
+	if ss == nil {
+		lg.Fatal("failed to create AWS session", zap.Error(err))
+	}
+	if err != nil {
+		lg.Warn("failed to create AWS session or get sts caller identity", zap.Error(err))
+	}
+	awsCfgV2, err := pkg_aws.NewV2(&pkg_aws.Config{
+		Logger:        lg,
+		DebugAPICalls: logLevel == "debug",
+		Partition:     partition,
+		Region:        region,
+		RetryMode:     pkg_aws.RetryModeAdaptive,
+	})
+	if err != nil {
+		lg.Fatal("failed to create AWS SDK v2 config", zap.Error(err))
+	}
+	jcfg := janitor.Config{
+		Logger:    lg,
+		TTL:       ttl,
+		EKSAPI:    eks.New(ss),
+		CFNAPIV2:  aws_cfn_v2.NewFromConfig(awsCfgV2),
+		EC2APIV2:  aws_ec2_v2.NewFromConfig(awsCfgV2),
+		S3API:     s3.New(ss),
+		CWLogsAPI: cloudwatchlogs.New(ss),
+	}
+
+	now := time.Now()
+	rs, err := janitor.Scan(jcfg, now)
+	if err != nil {
+		lg.Fatal("failed to scan resources", zap.Error(err))
+	}
+	if len(rs) == 0 {
+		fmt.Printf("\nno resource older than %v in %q\n", ttl, region)
+		return
+	}
+	fmt.Printf("\n%d resource(s) older than %v in %q:\n%s\n", len(rs), ttl, region, janitor.Table(rs, now))
+	if dryRun {
+		fmt.Printf("\n'--dry-run' specified; skipping deletion\n")
+		return
+	}
+
+	errs := janitor.Delete(jcfg, rs)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "failed to delete %v\n", err)
+	}
+	if len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "\nfailed to delete %d resource(s); some resources are deleted asynchronously, retry the janitor\n", len(errs))
+		os.Exit(1)
+	}
+	fmt.Printf("\ndeleted %d resource(s)\n", len(rs))
+}
@@ -0,0 +1,124 @@
+// Package leakcheck implements "aws-k8s-tester leak-check" command.
+package leakcheck
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-k8s-tester/eks/leak"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	pkg_aws "github.com/aws/aws-k8s-tester/pkg/aws"
+	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+	"github.com/aws/aws-k8s-tester/pkg/logutil"
+	aws_cfn_v2 "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	path     string
+	logLevel string
+	del      bool
+)
+
+// NewCommand implements "aws-k8s-tester leak-check" command.
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "leak-check",
+		Short: "Check AWS resources left behind after the cluster deletion",
+		Long: `Scans the AWS resources tagged with the cluster name (or prefixed with the cluster name
+for the untaggable resources), and reports anything left behind after the cluster deletion.
+Exits with non-zero code if any resource is left behind.
+
+aws-k8s-tester leak-check --path /tmp/config.yaml
+
+aws-k8s-tester leak-check --path /tmp/config.yaml --delete
+`,
+		Run: leakCheckFunc,
+	}
+	cmd.PersistentFlags().StringVarP(&path, "path", "p", "", "aws-k8s-tester EKS configuration file path")
+	cmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "Log level (debug, info, warn, error, dpanic, panic, fatal)")
+	cmd.PersistentFlags().BoolVar(&del, "delete", false, "'true' to delete the resources left behind")
+	return cmd
+}
+
+func leakCheckFunc(cmd *cobra.Command, args []string) {
+	if !fileutil.Exist(path) {
+		fmt.Fprintf(os.Stderr, "cannot find configuration %q\n", path)
+		os.Exit(1)
+	}
+	cfg, err := eksconfig.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load configuration %q (%v)\n", path, err)
+		os.Exit(1)
+	}
+
+	lcfg := logutil.GetDefaultZapLoggerConfig()
+	lcfg.Level = zap.NewAtomicLevelAt(logutil.ConvertToZapLevel(logLevel))
+	lg, err := lcfg.Build()
+	if err != nil {
+		panic(err)
+	}
+	ss, _, _, err := pkg_aws.New(&pkg_aws.Config{
+		Logger:        lg,
+		DebugAPICalls: logLevel == "debug",
+		Partition:     cfg.Partition,
+		Region:        cfg.Region,
+	})
+	if ss == nil {
+		lg.Fatal("failed to create AWS session", zap.Error(err))
+	}
+	if err != nil {
+		lg.Warn("failed to create AWS session or get sts caller identity", zap.Error(err))
+	}
+	awsCfgV2, err := pkg_aws.NewV2(&pkg_aws.Config{
+		Logger:        lg,
+		DebugAPICalls: logLevel == "debug",
+		Partition:     cfg.Partition,
+		Region:        cfg.Region,
+		RetryMode:     pkg_aws.RetryModeAdaptive,
+	})
+	if err != nil {
+		lg.Fatal("failed to create AWS SDK v2 config", zap.Error(err))
+	}
+	leakCfg := leak.Config{
+		Logger:     lg,
+		EKSConfig:  cfg,
+		TaggingAPI: resourcegroupstaggingapi.New(ss),
+		EC2APIV2:   aws_ec2_v2.NewFromConfig(awsCfgV2),
+		ELBV2API:   elbv2.New(ss),
+		CFNAPIV2:   aws_cfn_v2.NewFromConfig(awsCfgV2),
+		IAMAPI:     iam.New(ss),
+		S3API:      s3.New(ss),
+		ELBAPI:     elb.New(ss),
+	}
+
+	rs, err := leak.Scan(leakCfg)
+	if err != nil {
+		lg.Fatal("failed to scan resources", zap.Error(err))
+	}
+	if len(rs) == 0 {
+		fmt.Printf("\nno resource left behind for %q\n", cfg.Name)
+		return
+	}
+	fmt.Printf("\n%d resource(s) left behind for %q:\n%s\n", len(rs), cfg.Name, leak.Table(rs))
+	if !del {
+		os.Exit(1)
+	}
+
+	errs := leak.Delete(leakCfg, rs)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "failed to delete %v\n", err)
+	}
+	if len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "\nfailed to delete %d resource(s); some resources are deleted asynchronously, retry the leak check\n", len(errs))
+		os.Exit(1)
+	}
+	fmt.Printf("\ndeleted %d resource(s)\n", len(rs))
+}
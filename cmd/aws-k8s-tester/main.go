@@ -6,6 +6,9 @@ import (
 	"os"
 
 	"github.com/aws/aws-k8s-tester/cmd/aws-k8s-tester/eks"
+	"github.com/aws/aws-k8s-tester/cmd/aws-k8s-tester/janitor"
+	"github.com/aws/aws-k8s-tester/cmd/aws-k8s-tester/leakcheck"
+	"github.com/aws/aws-k8s-tester/cmd/aws-k8s-tester/s3"
 	"github.com/aws/aws-k8s-tester/cmd/aws-k8s-tester/version"
 	"github.com/spf13/cobra"
 )
@@ -23,6 +26,9 @@ func init() {
 func init() {
 	rootCmd.AddCommand(
 		eks.NewCommand(),
+		janitor.NewCommand(),
+		leakcheck.NewCommand(),
+		s3.NewCommand(),
 		version.NewCommand(),
 	)
 }
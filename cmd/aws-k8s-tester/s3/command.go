@@ -0,0 +1,31 @@
+// Package s3 implements S3 related commands.
+package s3
+
+import "github.com/spf13/cobra"
+
+func init() {
+	cobra.EnablePrefixMatching = true
+}
+
+var (
+	logLevel  string
+	partition string
+	region    string
+	s3Bucket  string
+)
+
+// NewCommand implements "aws-k8s-tester s3" command.
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "s3",
+		Short: "s3 commands",
+	}
+	cmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "Log level (debug, info, warn, error, dpanic, panic, fatal)")
+	cmd.PersistentFlags().StringVar(&partition, "partition", "aws", "AWS partition")
+	cmd.PersistentFlags().StringVar(&region, "region", "us-west-2", "AWS region")
+	cmd.PersistentFlags().StringVar(&s3Bucket, "s3-bucket", "", "S3 bucket name")
+	cmd.AddCommand(
+		newHistory(),
+	)
+	return cmd
+}
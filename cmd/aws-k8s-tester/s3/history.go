@@ -0,0 +1,84 @@
+package s3
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	pkg_aws "github.com/aws/aws-k8s-tester/pkg/aws"
+	pkg_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
+	"github.com/aws/aws-k8s-tester/pkg/logutil"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/dustin/go-humanize"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var historyS3KeyPrefix string
+
+func newHistory() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List all versions of the test artifacts in a versioned S3 bucket",
+		Long:  "List all versions of the test artifacts in a versioned S3 bucket, in descending order of last modified timestamps, to compare artifacts across runs.",
+		Run:   historyFunc,
+	}
+	cmd.PersistentFlags().StringVar(&historyS3KeyPrefix, "s3-key-prefix", "", "S3 key prefix to list (e.g. cluster name)")
+	return cmd
+}
+
+func historyFunc(cmd *cobra.Command, args []string) {
+	if s3Bucket == "" {
+		fmt.Fprintln(os.Stderr, "empty --s3-bucket")
+		os.Exit(1)
+	}
+
+	lcfg := logutil.GetDefaultZapLoggerConfig()
+	lcfg.Level = zap.NewAtomicLevelAt(logutil.ConvertToZapLevel(logLevel))
+	lg, err := lcfg.Build()
+	if err != nil {
+		panic(err)
+	}
+	ss, _, _, err := pkg_aws.New(&pkg_aws.Config{
+		Logger:        lg,
+		DebugAPICalls: logLevel == "debug",
+		Partition:     partition,
+		Region:        region,
+	})
+	if ss == nil {
+		lg.Fatal("failed to create AWS session", zap.Error(err))
+	}
+	if err != nil {
+		lg.Warn("failed to create AWS session or get sts caller identity", zap.Error(err))
+	}
+
+	versions, err := pkg_s3.ListVersions(lg, s3.New(ss), s3Bucket, historyS3KeyPrefix)
+	if err != nil {
+		lg.Fatal("failed to list S3 object versions",
+			zap.String("s3-bucket", s3Bucket),
+			zap.String("s3-key-prefix", historyS3KeyPrefix),
+			zap.Error(err),
+		)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	tb := tablewriter.NewWriter(buf)
+	tb.SetAutoWrapText(false)
+	tb.SetAlignment(tablewriter.ALIGN_LEFT)
+	tb.SetCenterSeparator("*")
+	tb.SetHeader([]string{"s3 key", "version id", "latest", "last modified", "size"})
+	for _, v := range versions {
+		tb.Append([]string{
+			aws.StringValue(v.Key),
+			aws.StringValue(v.VersionId),
+			fmt.Sprintf("%v", aws.BoolValue(v.IsLatest)),
+			aws.TimeValue(v.LastModified).Format(time.RFC3339),
+			humanize.Bytes(uint64(aws.Int64Value(v.Size))),
+		})
+	}
+	tb.Render()
+	fmt.Printf("\n%s\n'aws-k8s-tester s3 history' listed %d versions in %q (prefix %q)\n", buf.String(), len(versions), s3Bucket, historyS3KeyPrefix)
+}
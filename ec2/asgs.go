@@ -74,8 +74,6 @@ func (ts *Tester) _createASGs() (tss tupleTimes, err error) {
 					Arn: aws_v2.String(ts.cfg.Role.InstanceProfileARN),
 				},
 
-				KeyName: aws_v2.String(ts.cfg.RemoteAccessKeyName),
-
 				ImageId:      aws_v2.String(imgID),
 				InstanceType: aws_ec2_v2_types.InstanceType(cur.InstanceType),
 
@@ -118,7 +116,12 @@ func (ts *Tester) _createASGs() (tss tupleTimes, err error) {
 			},
 		}
 
-		userData, err := ts.generateUserData(ts.cfg.Region, cur.AMIType)
+		if ts.cfg.RemoteAccessKeyName != "" {
+			// empty with EC2 Instance Connect and no key pair
+			input.LaunchTemplateData.KeyName = aws_v2.String(ts.cfg.RemoteAccessKeyName)
+		}
+
+		userData, err := ts.generateUserData(ts.cfg.Region, cur.AMIFamily, cur.AMIType)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create user data for %q (%v)", asgName, err)
 		}
@@ -396,16 +399,74 @@ func (ts *Tester) fetchImageID(ssmParam string) (img string, err error) {
 
 // MUST install SSM agent, otherwise, it will "InvalidInstanceId:"
 // ref. https://docs.aws.amazon.com/systems-manager/latest/userguide/agent-install-al2.html
-func (ts *Tester) generateUserData(region string, amiType string) (d string, err error) {
-	if amiType == ec2config.AMITypeBottleRocketCPU {
+func (ts *Tester) generateUserData(region string, amiFamily string, amiType string) (d string, err error) {
+	arch := "amd64"
+	if strings.Contains(strings.ToUpper(amiType), "ARM") {
+		arch = "arm64"
+	}
+
+	switch amiFamily {
+	case ec2config.AMIFamilyBottlerocket:
 		// BottleRocket comes with SSM agent
 		return "", nil
-	}
 
-	arch := "amd64"
-	if amiType == ec2config.AMITypeAL2ARM64 {
-		arch = "arm64"
+	case ec2config.AMIFamilyAL2023:
+		// AL2023 comes with SSM agent
+		return `#!/bin/bash
+set -xeu
+
+sudo dnf install -y \
+  git \
+  wget \
+  jq \
+  tar \
+  unzip \
+  conntrack \
+  nfs-utils \
+  socat \
+  docker
+
+sudo systemctl daemon-reload
+sudo systemctl enable --now docker || true
+sudo systemctl status docker --full --no-pager || true
+sudo usermod -aG docker ec2-user || true
+
+sudo docker version
+sudo docker info
+`, nil
+
+	case ec2config.AMIFamilyUbuntu:
+		// Ubuntu comes with SSM agent as a snap
+		return `#!/bin/bash
+set -xeu
+
+export DEBIAN_FRONTEND=noninteractive
+sudo apt-get update -y
+sudo apt-get install -y \
+  git \
+  wget \
+  jq \
+  tar \
+  unzip \
+  conntrack \
+  nfs-common \
+  socat \
+  docker.io
+
+sudo systemctl daemon-reload
+sudo systemctl enable --now docker || true
+sudo systemctl status docker --full --no-pager || true
+sudo usermod -aG docker ubuntu || true
+
+sudo docker version
+sudo docker info
+`, nil
+
+	case ec2config.AMIFamilyAL2:
+	default:
+		return "", fmt.Errorf("unsupported AMIFamily %q", amiFamily)
 	}
+
 	d = fmt.Sprintf(`#!/bin/bash
 set -xeu
 
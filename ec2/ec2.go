@@ -28,6 +28,8 @@ import (
 	aws_ssm_v2 "github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2instanceconnect"
+	"github.com/aws/aws-sdk-go/service/ec2instanceconnect/ec2instanceconnectiface"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/aws/aws-sdk-go/service/sts"
@@ -56,6 +58,9 @@ type Tester struct {
 
 	awsSession *session.Session
 
+	// nil unless "RemoteAccessInstanceConnect" is enabled
+	ec2InstanceConnectAPI ec2instanceconnectiface.EC2InstanceConnectAPI
+
 	iamAPIV2   *aws_iam_v2.Client
 	kmsAPIV2   *aws_kms_v2.Client
 	ssmAPIV2   *aws_ssm_v2.Client
@@ -115,6 +120,9 @@ func New(cfg *ec2config.Config) (*Tester, error) {
 	ts.cfg.Sync()
 
 	ts.s3API = s3.New(ts.awsSession)
+	if ts.cfg.RemoteAccessInstanceConnect {
+		ts.ec2InstanceConnectAPI = ec2instanceconnect.New(ts.awsSession)
+	}
 
 	awsCfgV2, err := pkg_aws.NewV2(awsCfg)
 	if err != nil {
@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-k8s-tester/ec2config"
 	"github.com/aws/aws-k8s-tester/pkg/fileutil"
@@ -141,11 +142,12 @@ func (ts *Tester) fetchLogs(qps float32, burst int, commandToFileName map[string
 			zap.Int("instances", len(cur.Instances)),
 		)
 		waits += len(cur.Instances)
+		logCollection := ec2config.LogCollection(cur.AMIFamily)
 
 		for instID, iv := range cur.Instances {
 			pfx := instID + "-"
 
-			go func(instID, logsDir, pfx string, iv ec2config.Instance) {
+			go func(instID, logsDir, pfx string, iv ec2config.Instance, logCollection string) {
 				select {
 				case <-ts.stopCreationCh:
 					ts.lg.Warn("exiting fetch logger", zap.String("prefix", pfx))
@@ -173,6 +175,12 @@ func (ts *Tester) fetchLogs(qps float32, burst int, commandToFileName map[string
 					PublicIP:      iv.PublicIP,
 					PublicDNSName: iv.PublicDNSName,
 					UserName:      iv.RemoteAccessUserName,
+					InstanceConnect: ssh.NewInstanceConnect(
+						ts.ec2InstanceConnectAPI,
+						instID,
+						iv.Placement.AvailabilityZone,
+					),
+					InsecureSkipVerify: true,
 				})
 				if err != nil {
 					rch <- instanceLogs{asgName: name, errs: []string{err.Error()}}
@@ -185,6 +193,12 @@ func (ts *Tester) fetchLogs(qps float32, burst int, commandToFileName map[string
 				}
 
 				data := instanceLogs{asgName: name, instanceID: instID}
+				if logCollection == ec2config.LogCollectionLogdog {
+					data.paths, data.errs = fetchLogdog(ts.lg, sh, instID, logsDir, pfx, sshOpt)
+					rch <- data
+					return
+				}
+
 				// fetch default logs
 				for cmd, fileName := range commandToFileName {
 					if !rateLimiter.Allow() {
@@ -321,61 +335,38 @@ func (ts *Tester) fetchLogs(qps float32, burst int, commandToFileName map[string
 						oerr,
 					))
 				} else {
-					varLogCmdToFileName := make(map[string]string)
+					varLogPaths := make(map[string]string)
 					for _, line := range strings.Split(string(out), "\n") {
 						if len(line) == 0 {
 							// last value
 							continue
 						}
-						logCmd := "sudo cat " + line
-						logName := filepath.Base(line)
-						varLogCmdToFileName[logCmd] = logName
+						varLogPaths[line] = filepath.Base(line)
 					}
-					for cmd, fileName := range varLogCmdToFileName {
+					for remotePath, fileName := range varLogPaths {
 						if !rateLimiter.Allow() {
 							ts.lg.Debug("waiting for rate limiter before fetching file")
 							werr := rateLimiter.Wait(context.Background())
 							ts.lg.Debug("waited for rate limiter", zap.Error(werr))
 						}
-						out, oerr := sh.Run(cmd, sshOpt)
-						if oerr != nil {
-							data.errs = append(data.errs, fmt.Sprintf(
-								"failed to run command %q for %q (error %v)",
-								cmd,
-								instID,
-								oerr,
-							))
-							continue
-						}
-
+						// download over SFTP to preserve binary files (e.g. "wtmp"),
+						// and to resume partial downloads on retries
 						fpath := filepath.Join(logsDir, shorten(ts.lg, pfx+fileName))
-						f, err := os.Create(fpath)
-						if err != nil {
-							data.errs = append(data.errs, fmt.Sprintf(
-								"failed to create a file %q for %q (error %v)",
-								fpath,
-								instID,
-								err,
-							))
-							continue
-						}
-						if _, err = f.Write(out); err != nil {
+						if derr := sh.Download(remotePath, fpath, sshOpt, ssh.WithSudo(true)); derr != nil {
 							data.errs = append(data.errs, fmt.Sprintf(
-								"failed to write to a file %q for %q (error %v)",
-								fpath,
+								"failed to download %q for %q (error %v)",
+								remotePath,
 								instID,
-								err,
+								derr,
 							))
-							f.Close()
 							continue
 						}
-						f.Close()
 						ts.lg.Debug("wrote", zap.String("file-path", fpath))
 						data.paths = append(data.paths, fpath)
 					}
 				}
 				rch <- data
-			}(instID, logsDir, pfx, iv)
+			}(instID, logsDir, pfx, iv, logCollection)
 		}
 	}
 
@@ -447,6 +438,31 @@ func (ts *Tester) fetchLogs(qps float32, burst int, commandToFileName map[string
 	return ts.cfg.Sync()
 }
 
+// fetchLogdog collects the Bottlerocket host logs with "logdog",
+// and downloads the archive.
+func fetchLogdog(lg *zap.Logger, sh ssh.SSH, instID string, logsDir string, pfx string, opts ...ssh.OpOption) (paths []string, errs []string) {
+	lg.Info("running logdog", zap.String("instance-id", instID))
+	if _, err := sh.Run(ec2config.BottlerocketLogdogCommand, append(opts, ssh.WithTimeout(3*time.Minute))...); err != nil {
+		return nil, []string{fmt.Sprintf(
+			"failed to run command %q for %q (error %v)",
+			ec2config.BottlerocketLogdogCommand,
+			instID,
+			err,
+		)}
+	}
+	fpath := filepath.Join(logsDir, shorten(lg, pfx+filepath.Base(ec2config.BottlerocketLogdogArchivePath)))
+	if err := sh.Download(ec2config.BottlerocketLogdogArchivePath, fpath, append(opts, ssh.WithSudo(true), ssh.WithRetry(2, 3*time.Second))...); err != nil {
+		return nil, []string{fmt.Sprintf(
+			"failed to download %q for %q (error %v)",
+			ec2config.BottlerocketLogdogArchivePath,
+			instID,
+			err,
+		)}
+	}
+	lg.Debug("wrote", zap.String("file-path", fpath))
+	return []string{fpath}, nil
+}
+
 type instanceLogs struct {
 	asgName    string
 	instanceID string
@@ -15,7 +15,16 @@ func (ts *Tester) createS3() (err error) {
 		if ts.cfg.S3.BucketName == "" {
 			return errors.New("empty S3 bucket name")
 		}
-		if err = aws_s3.CreateBucket(ts.lg, ts.s3API, ts.cfg.S3.BucketName, ts.cfg.Region, ts.cfg.Name, ts.cfg.S3.BucketLifecycleExpirationDays); err != nil {
+		if err = aws_s3.CreateBucket(
+			ts.lg,
+			ts.s3API,
+			ts.cfg.S3.BucketName,
+			ts.cfg.Region,
+			ts.cfg.Name,
+			ts.cfg.S3.BucketLifecycleExpirationDays,
+			aws_s3.WithVersioning(ts.cfg.S3.BucketVersioning),
+			aws_s3.WithObjectLock(ts.cfg.S3.BucketObjectLock, ts.cfg.S3.BucketObjectLockRetentionDays),
+		); err != nil {
 			return err
 		}
 	} else {
@@ -0,0 +1,161 @@
+package ec2config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AMI families. The AMI family determines the user data to bootstrap
+// the node, the remote access user name, how to collect the node logs,
+// and the default instance types.
+const (
+	// AMIFamilyAL2 is the Amazon Linux 2 AMI family, bootstrapped with "/etc/eks/bootstrap.sh".
+	AMIFamilyAL2 = "AL2"
+	// AMIFamilyAL2023 is the Amazon Linux 2023 AMI family, bootstrapped with "nodeadm".
+	// ref. https://awslabs.github.io/amazon-eks-ami/nodeadm/
+	AMIFamilyAL2023 = "AL2023"
+	// AMIFamilyBottlerocket is the Bottlerocket OS AMI family, configured with TOML settings.
+	// ref. https://github.com/bottlerocket-os/bottlerocket
+	AMIFamilyBottlerocket = "Bottlerocket"
+	// AMIFamilyUbuntu is the Ubuntu EKS AMI family, bootstrapped with "/etc/eks/bootstrap.sh".
+	// ref. https://cloud-images.ubuntu.com/aws-eks/
+	AMIFamilyUbuntu = "Ubuntu"
+	// AMIFamilyWindows is the Windows Server AMI family, bootstrapped with "Start-EKSBootstrap.ps1".
+	AMIFamilyWindows = "Windows"
+)
+
+const (
+	// AMITypeAL2023X8664 is the AMI type for Amazon Linux 2023 AMI.
+	AMITypeAL2023X8664 = "AL2023_x86_64_STANDARD"
+	// AMITypeAL2023ARM64 is the AMI type for Amazon Linux 2023 AMI on ARM.
+	AMITypeAL2023ARM64 = "AL2023_ARM_64_STANDARD"
+)
+
+// Log collection strategies of the AMI families.
+const (
+	// LogCollectionJournald collects the systemd journal and "/var/log" files.
+	LogCollectionJournald = "journald"
+	// LogCollectionLogdog collects the Bottlerocket "logdog" archive
+	// from the host, through the admin container.
+	LogCollectionLogdog = "logdog"
+	// LogCollectionPowerShell collects the Windows event logs
+	// and kubelet log files with PowerShell.
+	LogCollectionPowerShell = "powershell"
+)
+
+// AMIFamilyFromAMIType returns the AMI family of the AMI type.
+// It returns an empty string for custom AMI types (e.g. "OTHER").
+func AMIFamilyFromAMIType(amiType string) string {
+	switch {
+	case strings.HasPrefix(amiType, "AL2023_"):
+		return AMIFamilyAL2023
+	case strings.HasPrefix(amiType, "AL2_"):
+		return AMIFamilyAL2
+	case strings.HasPrefix(amiType, "BOTTLEROCKET_"):
+		return AMIFamilyBottlerocket
+	case strings.HasPrefix(amiType, "WINDOWS_"):
+		return AMIFamilyWindows
+	}
+	return ""
+}
+
+// ResolveAMIFamily validates the AMI family against the AMI type.
+// If the AMI family is empty, it is derived from the AMI type,
+// defaulting to "AL2" for custom AMI types.
+func ResolveAMIFamily(amiFamily string, amiType string) (string, error) {
+	derived := AMIFamilyFromAMIType(amiType)
+	switch amiFamily {
+	case "":
+		if derived == "" {
+			return AMIFamilyAL2, nil
+		}
+		return derived, nil
+	case AMIFamilyAL2, AMIFamilyAL2023, AMIFamilyBottlerocket, AMIFamilyUbuntu, AMIFamilyWindows:
+	default:
+		return "", fmt.Errorf("unknown AMIFamily %q", amiFamily)
+	}
+	if derived != "" && derived != amiFamily {
+		return "", fmt.Errorf("AMIFamily %q does not match AMIType %q", amiFamily, amiType)
+	}
+	return amiFamily, nil
+}
+
+// DefaultRemoteAccessUserName returns the SSH user name of the AMI family.
+func DefaultRemoteAccessUserName(amiFamily string) string {
+	switch amiFamily {
+	case AMIFamilyUbuntu:
+		return "ubuntu"
+	case AMIFamilyWindows:
+		return "Administrator"
+	default:
+		// Bottlerocket admin container also uses "ec2-user"
+		return "ec2-user"
+	}
+}
+
+// LogCollection returns the log collection strategy of the AMI family.
+func LogCollection(amiFamily string) string {
+	switch amiFamily {
+	case AMIFamilyBottlerocket:
+		return LogCollectionLogdog
+	case AMIFamilyWindows:
+		return LogCollectionPowerShell
+	default:
+		return LogCollectionJournald
+	}
+}
+
+// DefaultInstanceType returns the default EC2 instance type
+// for the AMI family and the architecture of the AMI type.
+func DefaultInstanceType(amiFamily string, amiType string) string {
+	upper := strings.ToUpper(amiType)
+	switch {
+	case amiFamily == AMIFamilyWindows:
+		return DefaultNodeInstanceTypeCPU
+	case strings.Contains(upper, "ARM"):
+		return DefaultNodeInstanceTypeCPUARM
+	case strings.Contains(upper, "GPU"), strings.Contains(upper, "NVIDIA"):
+		return DefaultNodeInstanceTypeGPU
+	}
+	return DefaultNodeInstanceTypeCPU
+}
+
+// Node architectures, as in the "kubernetes.io/arch" node label.
+const (
+	// ArchitectureAMD64 is the x86_64 architecture.
+	ArchitectureAMD64 = "amd64"
+	// ArchitectureARM64 is the ARM64 (e.g. Graviton) architecture.
+	ArchitectureARM64 = "arm64"
+)
+
+// ArchitectureFromAMIType returns the node architecture of the AMI type.
+func ArchitectureFromAMIType(amiType string) string {
+	if strings.Contains(strings.ToUpper(amiType), "ARM") {
+		return ArchitectureARM64
+	}
+	return ArchitectureAMD64
+}
+
+// ArchitectureFromEC2 returns the node architecture of
+// the EC2 instance architecture (e.g. "x86_64", "arm64").
+// It returns an empty string for unknown architectures.
+func ArchitectureFromEC2(arch string) string {
+	switch arch {
+	case "x86_64", "x86_64_mac":
+		return ArchitectureAMD64
+	case "arm64", "arm64_mac":
+		return ArchitectureARM64
+	}
+	return ""
+}
+
+const (
+	// BottlerocketLogdogCommand collects the Bottlerocket host logs into
+	// an archive, from the admin container with "sheltie" (a root shell
+	// on the host).
+	// ref. https://github.com/bottlerocket-os/bottlerocket#logs
+	BottlerocketLogdogCommand = "sudo sheltie logdog"
+	// BottlerocketLogdogArchivePath is the "logdog" archive path,
+	// as seen from the admin container.
+	BottlerocketLogdogArchivePath = "/.bottlerocket/rootfs/var/log/support/bottlerocket-logs.tar.gz"
+)
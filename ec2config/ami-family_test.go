@@ -0,0 +1,51 @@
+package ec2config
+
+import "testing"
+
+func TestResolveAMIFamily(t *testing.T) {
+	tt := []struct {
+		amiFamily string
+		amiType   string
+		expected  string
+		err       bool
+	}{
+		{"", AMITypeAL2X8664, AMIFamilyAL2, false},
+		{"", AMITypeAL2023ARM64, AMIFamilyAL2023, false},
+		{"", AMITypeBottleRocketCPU, AMIFamilyBottlerocket, false},
+		{"", AMITypeWindowsServerCore2019X8664, AMIFamilyWindows, false},
+		{"", AMITypeOther, AMIFamilyAL2, false},
+		{AMIFamilyUbuntu, AMITypeOther, AMIFamilyUbuntu, false},
+		{AMIFamilyAL2023, AMITypeAL2023X8664, AMIFamilyAL2023, false},
+		{AMIFamilyUbuntu, AMITypeAL2X8664, "", true},
+		{AMIFamilyAL2, AMITypeBottleRocketCPU, "", true},
+		{"Debian", AMITypeOther, "", true},
+	}
+	for i, tv := range tt {
+		family, err := ResolveAMIFamily(tv.amiFamily, tv.amiType)
+		if tv.err != (err != nil) {
+			t.Fatalf("#%d: expected error %v, got %v", i, tv.err, err)
+		}
+		if family != tv.expected {
+			t.Fatalf("#%d: expected %q, got %q", i, tv.expected, family)
+		}
+	}
+}
+
+func TestArchitectureFromAMIType(t *testing.T) {
+	tt := []struct {
+		amiType  string
+		expected string
+	}{
+		{AMITypeAL2X8664, ArchitectureAMD64},
+		{AMITypeAL2X8664GPU, ArchitectureAMD64},
+		{AMITypeAL2ARM64, ArchitectureARM64},
+		{AMITypeAL2023ARM64, ArchitectureARM64},
+		{"BOTTLEROCKET_ARM_64", ArchitectureARM64},
+		{AMITypeWindowsServerCore2019X8664, ArchitectureAMD64},
+	}
+	for i, tv := range tt {
+		if arch := ArchitectureFromAMIType(tv.amiType); arch != tv.expected {
+			t.Fatalf("#%d: expected %q, got %q", i, tv.expected, arch)
+		}
+	}
+}
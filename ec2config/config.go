@@ -5,10 +5,12 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"sync"
 	"time"
 
+	"github.com/aws/aws-k8s-tester/pkg/configfile"
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
 	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
 	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
@@ -128,6 +130,11 @@ type Config struct {
 	RemoteAccessKeyName string `json:"remote-access-key-name"`
 	// RemoteAccessPrivateKeyPath is the remote SSH access private key path.
 	RemoteAccessPrivateKeyPath string `json:"remote-access-private-key-path"`
+	// RemoteAccessInstanceConnect is true to push an ephemeral SSH public key
+	// via EC2 Instance Connect right before connecting.
+	// Set "RemoteAccessKeyCreate" to false to not create any EC2 key pair.
+	// ref. https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/Connect-using-EC2-Instance-Connect.html
+	RemoteAccessInstanceConnect bool `json:"remote-access-instance-connect"`
 	// RemoteAccessCommandsOutputPath is the output path for ssh commands.
 	RemoteAccessCommandsOutputPath string `json:"remote-access-commands-output-path,omitempty"`
 
@@ -153,6 +160,15 @@ type S3 struct {
 	BucketName string `json:"bucket-name"`
 	// BucketLifecycleExpirationDays is expiration in days for the lifecycle of the object.
 	BucketLifecycleExpirationDays int64 `json:"bucket-lifecycle-expiration-days"`
+	// BucketVersioning is true to enable versioning on the auto-created S3 bucket,
+	// so artifacts overwritten by later runs remain retrievable.
+	BucketVersioning bool `json:"bucket-versioning"`
+	// BucketObjectLock is true to enable object lock on the auto-created S3 bucket.
+	// Object lock can only be enabled at bucket creation, and implies versioning.
+	BucketObjectLock bool `json:"bucket-object-lock"`
+	// BucketObjectLockRetentionDays is the default retention in days
+	// for new objects, in "GOVERNANCE" mode.
+	BucketObjectLockRetentionDays int64 `json:"bucket-object-lock-retention-days"`
 	// Dir is the S3 directory to store all test results.
 	// It is under the bucket "eksconfig.Config.S3BucketName".
 	Dir string `json:"dir"`
@@ -335,6 +351,11 @@ type ASG struct {
 	// ref. https://docs.aws.amazon.com/eks/latest/userguide/launch-workers.html
 	// ref. https://github.com/awslabs/amazon-eks-ami/blob/master/amazon-eks-nodegroup.yaml
 	AMIType string `json:"ami-type,omitempty"`
+	// AMIFamily is the AMI family (e.g. "AL2", "AL2023", "Bottlerocket", "Ubuntu"),
+	// which determines the user data, remote access user name, log collection,
+	// and default instance type. Derived from "AMIType" if empty, and required
+	// for custom AMI types other than AL2 (e.g. Ubuntu with "OTHER").
+	AMIFamily string `json:"ami-family,omitempty"`
 	// ImageID is the Amazon Machine Image (AMI).
 	// This value overrides any AWS Systems Manager Parameter Store value.
 	// NOTE: THIS FIELD IS SET TO EMPTY IF "ImageIDSSMParameter" IS NOT EMPTY.
@@ -474,8 +495,10 @@ type SecurityGroup struct {
 	GroupID   string `json:"group-id"`
 }
 
-// Load loads configuration from YAML.
-// Useful when injecting shared configuration via ConfigMap.
+// Load loads configuration from YAML, JSON (".json"), or HCL (".hcl"),
+// with the same validation. Useful when injecting shared configuration
+// via ConfigMap. The HCL configuration is not written back, and the
+// configuration and status are written to "<path>.yaml" instead.
 //
 // Example usage:
 //
@@ -488,7 +511,7 @@ type SecurityGroup struct {
 // to prevent overwriting previous data when loaded from disks.
 func Load(p string) (cfg *Config, err error) {
 	var d []byte
-	d, err = ioutil.ReadFile(p)
+	d, err = configfile.Read(p)
 	if err != nil {
 		return nil, err
 	}
@@ -506,7 +529,10 @@ func Load(p string) (cfg *Config, err error) {
 	if err != nil {
 		return nil, err
 	}
-	cfg.ConfigPath = ap
+	cfg.ConfigPath = configfile.SyncPath(ap)
+	if cfg.ConfigPath != ap {
+		fmt.Fprintf(os.Stderr, "[WARN] writing configuration %q to %q\n", p, cfg.ConfigPath)
+	}
 	cfg.unsafeSync()
 
 	return cfg, nil
@@ -529,9 +555,9 @@ func (cfg *Config) unsafeSync() (err error) {
 		cfg.ConfigPath = p
 	}
 	var d []byte
-	d, err = yaml.Marshal(cfg)
+	d, err = configfile.Marshal(cfg.ConfigPath, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to 'yaml.Marshal' %v", err)
+		return fmt.Errorf("failed to marshal configuration %v", err)
 	}
 
 	err = ioutil.WriteFile(cfg.ConfigPath, d, 0600)
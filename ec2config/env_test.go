@@ -26,6 +26,10 @@ func TestEnv(t *testing.T) {
 	defer os.Unsetenv("AWS_K8S_TESTER_EC2_S3_BUCKET_NAME")
 	os.Setenv("AWS_K8S_TESTER_EC2_S3_BUCKET_LIFECYCLE_EXPIRATION_DAYS", `10`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EC2_S3_BUCKET_LIFECYCLE_EXPIRATION_DAYS")
+	os.Setenv("AWS_K8S_TESTER_EC2_S3_BUCKET_OBJECT_LOCK", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EC2_S3_BUCKET_OBJECT_LOCK")
+	os.Setenv("AWS_K8S_TESTER_EC2_S3_BUCKET_OBJECT_LOCK_RETENTION_DAYS", `7`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EC2_S3_BUCKET_OBJECT_LOCK_RETENTION_DAYS")
 	os.Setenv("AWS_K8S_TESTER_EC2_ROLE_CREATE", `false`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EC2_ROLE_CREATE")
 	os.Setenv("AWS_K8S_TESTER_EC2_ROLE_ARN", `role-arn`)
@@ -36,6 +40,8 @@ func TestEnv(t *testing.T) {
 	defer os.Unsetenv("AWS_K8S_TESTER_EC2_VPC_ID")
 	os.Setenv("AWS_K8S_TESTER_EC2_REMOTE_ACCESS_KEY_CREATE", `true`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EC2_REMOTE_ACCESS_KEY_CREATE")
+	os.Setenv("AWS_K8S_TESTER_EC2_REMOTE_ACCESS_INSTANCE_CONNECT", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EC2_REMOTE_ACCESS_INSTANCE_CONNECT")
 	os.Setenv("AWS_K8S_TESTER_EC2_VPC_DHCP_OPTIONS_DOMAIN_NAME", `hello.com`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EC2_VPC_DHCP_OPTIONS_DOMAIN_NAME")
 	os.Setenv("AWS_K8S_TESTER_EC2_VPC_DHCP_OPTIONS_DOMAIN_NAME_SERVERS", `1.2.3.0,4.5.6.7`)
@@ -73,6 +79,12 @@ func TestEnv(t *testing.T) {
 	if cfg.S3.BucketLifecycleExpirationDays != 10 {
 		t.Fatalf("unexpected cfg.S3.BucketLifecycleExpirationDays %d", cfg.S3.BucketLifecycleExpirationDays)
 	}
+	if !cfg.S3.BucketObjectLock {
+		t.Fatalf("unexpected cfg.S3.BucketObjectLock %v", cfg.S3.BucketObjectLock)
+	}
+	if cfg.S3.BucketObjectLockRetentionDays != 7 {
+		t.Fatalf("unexpected cfg.S3.BucketObjectLockRetentionDays %d", cfg.S3.BucketObjectLockRetentionDays)
+	}
 
 	if cfg.Role.Create {
 		t.Fatalf("unexpected cfg.Role.Create %v", cfg.Role.Create)
@@ -96,6 +108,9 @@ func TestEnv(t *testing.T) {
 	if !cfg.RemoteAccessKeyCreate {
 		t.Fatalf("unexpected cfg.RemoteAccessKeyCreate %v", cfg.RemoteAccessKeyCreate)
 	}
+	if !cfg.RemoteAccessInstanceConnect {
+		t.Fatalf("unexpected cfg.RemoteAccessInstanceConnect %v", cfg.RemoteAccessInstanceConnect)
+	}
 	if cfg.RemoteAccessKeyName != "my-key" {
 		t.Fatalf("unexpected cfg.RemoteAccessKeyName %q", cfg.RemoteAccessKeyName)
 	}
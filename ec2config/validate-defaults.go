@@ -201,10 +201,23 @@ func (cfg *Config) validateConfig() error {
 		if cfg.S3.BucketLifecycleExpirationDays > 0 && cfg.S3.BucketLifecycleExpirationDays < 3 {
 			cfg.S3.BucketLifecycleExpirationDays = 3
 		}
+		if cfg.S3.BucketObjectLock {
+			// object lock requires versioning
+			cfg.S3.BucketVersioning = true
+		}
 	case false: // use existing one
 		if cfg.S3.BucketName == "" {
 			return errors.New("empty S3BucketName")
 		}
+		if cfg.S3.BucketVersioning || cfg.S3.BucketObjectLock {
+			return errors.New("S3 BucketVersioning or BucketObjectLock requires BucketCreate")
+		}
+	}
+	if cfg.S3.BucketObjectLockRetentionDays < 0 {
+		return fmt.Errorf("invalid S3 BucketObjectLockRetentionDays %d", cfg.S3.BucketObjectLockRetentionDays)
+	}
+	if !cfg.S3.BucketObjectLock && cfg.S3.BucketObjectLockRetentionDays > 0 {
+		return fmt.Errorf("S3 BucketObjectLockRetentionDays %d requires BucketObjectLock", cfg.S3.BucketObjectLockRetentionDays)
 	}
 	if cfg.S3.Dir == "" {
 		cfg.S3.Dir = cfg.Name
@@ -270,6 +283,10 @@ func (cfg *Config) validateConfig() error {
 		}
 
 	case false: // use existing one
+		if cfg.RemoteAccessInstanceConnect {
+			// no key pair needed, ephemeral keys are pushed before connecting
+			break
+		}
 		if cfg.RemoteAccessKeyName == "" {
 			return fmt.Errorf("RemoteAccessKeyCreate false; expect non-empty RemoteAccessKeyName but got %q", cfg.RemoteAccessKeyName)
 		}
@@ -317,8 +334,28 @@ func (cfg *Config) validateASGs() error {
 		if cur.VolumeSize == 0 {
 			cur.VolumeSize = DefaultNodeVolumeSize
 		}
+		switch cur.AMIType {
+		case AMITypeAL2ARM64,
+			AMITypeAL2X8664,
+			AMITypeAL2X8664GPU,
+			AMITypeAL2023X8664,
+			AMITypeAL2023ARM64,
+			AMITypeBottleRocketCPU,
+			AMITypeOther:
+		default:
+			return fmt.Errorf("unknown ASGs[%q].AMIType %q", k, cur.AMIType)
+		}
+		family, err := ResolveAMIFamily(cur.AMIFamily, cur.AMIType)
+		if err != nil {
+			return fmt.Errorf("ASGs[%q] %v", k, err)
+		}
+		if family == AMIFamilyWindows {
+			return fmt.Errorf("ASGs[%q].AMIFamily %q not supported", k, family)
+		}
+		cur.AMIFamily = family
+
 		if cur.RemoteAccessUserName == "" {
-			cur.RemoteAccessUserName = "ec2-user"
+			cur.RemoteAccessUserName = DefaultRemoteAccessUserName(cur.AMIFamily)
 		}
 
 		if cur.ImageID == "" && cur.ImageIDSSMParameter == "" {
@@ -332,48 +369,12 @@ func (cfg *Config) validateASGs() error {
 			cur.LaunchTemplateName = cur.Name + "-launch-template"
 		}
 
-		switch cur.AMIType {
-		case AMITypeAL2ARM64:
-			if cur.RemoteAccessUserName != "ec2-user" {
-				return fmt.Errorf("AMIType %q but unexpected RemoteAccessUserName %q", cur.AMIType, cur.RemoteAccessUserName)
-			}
-		case AMITypeBottleRocketCPU:
-			if cur.RemoteAccessUserName != "ec2-user" {
-				return fmt.Errorf("AMIType %q but unexpected RemoteAccessUserName %q", cur.AMIType, cur.RemoteAccessUserName)
-			}
-		case AMITypeAL2X8664:
-			if cur.RemoteAccessUserName != "ec2-user" {
-				return fmt.Errorf("AMIType %q but unexpected RemoteAccessUserName %q", cur.AMIType, cur.RemoteAccessUserName)
-			}
-		case AMITypeAL2X8664GPU:
-			if cur.RemoteAccessUserName != "ec2-user" {
-				return fmt.Errorf("AMIType %q but unexpected RemoteAccessUserName %q", cur.AMIType, cur.RemoteAccessUserName)
-			}
-		case AMITypeOther:
-		default:
-			return fmt.Errorf("unknown ASGs[%q].AMIType %q", k, cur.AMIType)
+		// custom AMIs may have any user name
+		if cur.AMIType != AMITypeOther && cur.RemoteAccessUserName != DefaultRemoteAccessUserName(cur.AMIFamily) {
+			return fmt.Errorf("AMIType %q but unexpected RemoteAccessUserName %q", cur.AMIType, cur.RemoteAccessUserName)
 		}
-
-		switch cur.AMIType {
-		case AMITypeAL2ARM64:
-			if cur.InstanceType == "" {
-				cur.InstanceType = DefaultNodeInstanceTypeCPUARM
-			}
-		case AMITypeBottleRocketCPU:
-			if cur.InstanceType == "" {
-				cur.InstanceType = DefaultNodeInstanceTypeCPU
-			}
-		case AMITypeAL2X8664:
-			if cur.InstanceType == "" {
-				cur.InstanceType = DefaultNodeInstanceTypeCPU
-			}
-		case AMITypeAL2X8664GPU:
-			if cur.InstanceType == "" {
-				cur.InstanceType = DefaultNodeInstanceTypeGPU
-			}
-		case AMITypeOther:
-		default:
-			return fmt.Errorf("unknown ASGs[%q].AMIType %q", k, cur.AMIType)
+		if cur.InstanceType == "" {
+			cur.InstanceType = DefaultInstanceType(cur.AMIFamily, cur.AMIType)
 		}
 
 		if cur.ASGMinSize == 0 {
@@ -0,0 +1,317 @@
+// Package access manages the cluster access, mapping the IAM principals
+// (e.g. self-managed node roles, CI debugging roles) to the Kubernetes
+// identities via EKS access entries, or the "aws-auth" ConfigMap on the
+// clusters without the access entry support.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/access-entries.html
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/add-user-role.html
+package access
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eks/cluster/wait"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	pkg_aws "github.com/aws/aws-k8s-tester/pkg/aws"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	aws_eks "github.com/aws/aws-sdk-go/service/eks"
+	"go.uber.org/zap"
+)
+
+// Config defines the access manager configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+	// EKSAPI sends the access entry operations (see "api.go").
+	EKSAPI *aws_eks.EKS
+}
+
+// Manager maps the IAM principals to the cluster.
+type Manager interface {
+	// Create maps the entries in "Access.Entries" and verifies them.
+	Create() error
+	// MapNodeRole maps the role of the self-managed nodes,
+	// for the nodes to join the cluster.
+	MapNodeRole(roleARN string, windows bool) error
+	// UnmapNodeRole removes the node role mapping.
+	UnmapNodeRole(roleARN string) error
+}
+
+// New creates a new access manager.
+func New(cfg Config) Manager {
+	cfg.Logger.Info("creating access manager")
+	return &manager{cfg: cfg}
+}
+
+type manager struct {
+	cfg Config
+}
+
+// node RBAC groups, set by EKS for the "EC2_LINUX" and "EC2_WINDOWS" access entries
+var (
+	linuxNodeGroups   = []string{"system:bootstrappers", "system:nodes"}
+	windowsNodeGroups = []string{"system:bootstrappers", "system:nodes", "eks:kube-proxy-windows"}
+)
+
+const nodeUsername = "system:node:{{EC2PrivateDNSName}}"
+
+func (m *manager) Create() error {
+	if len(m.cfg.EKSConfig.Access.Entries) == 0 {
+		return nil
+	}
+	if err := m.resolve(); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(m.cfg.EKSConfig.Access.Entries))
+	for name := range m.cfg.EKSConfig.Access.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		e := m.cfg.EKSConfig.Access.Entries[name]
+		m.cfg.Logger.Info("mapping access entry",
+			zap.String("name", name),
+			zap.String("principal-arn", e.PrincipalARN),
+			zap.String("mode", m.cfg.EKSConfig.Access.ResolvedMode),
+		)
+		if err := m.mapEntry(e); err != nil {
+			return fmt.Errorf("failed to map Access.Entries[%q] (%v)", name, err)
+		}
+		if e.Verify && !e.Verified {
+			if err := m.verify(e); err != nil {
+				return fmt.Errorf("failed to verify Access.Entries[%q] (%v)", name, err)
+			}
+			e.Verified = true
+			m.cfg.EKSConfig.Access.Entries[name] = e
+			m.cfg.EKSConfig.Sync()
+		}
+	}
+	return nil
+}
+
+func (m *manager) MapNodeRole(roleARN string, windows bool) error {
+	if err := m.resolve(); err != nil {
+		return err
+	}
+	m.cfg.Logger.Info("mapping node role",
+		zap.String("role-arn", roleARN),
+		zap.Bool("windows", windows),
+		zap.String("mode", m.cfg.EKSConfig.Access.ResolvedMode),
+	)
+	if m.cfg.EKSConfig.Access.ResolvedMode == eksconfig.AccessModeAccessEntry {
+		typ := accessEntryTypeEC2Linux
+		if windows {
+			typ = accessEntryTypeEC2Windows
+		}
+		return m.createAccessEntry(&createAccessEntryInput{
+			ClusterName:  aws.String(m.cfg.EKSConfig.Name),
+			PrincipalArn: aws.String(roleARN),
+			Type:         aws.String(typ),
+		})
+	}
+	groups := linuxNodeGroups
+	if windows {
+		groups = windowsNodeGroups
+	}
+	return m.mapAWSAuth(mapping{arn: roleARN, username: nodeUsername, groups: groups})
+}
+
+func (m *manager) UnmapNodeRole(roleARN string) error {
+	if m.cfg.EKSConfig.Access.ResolvedMode == "" {
+		return nil
+	}
+	m.cfg.Logger.Info("unmapping node role", zap.String("role-arn", roleARN))
+	return m.unmap(roleARN)
+}
+
+// resolve sets "Access.ResolvedMode" once per cluster, enabling the
+// access entries in the cluster authentication mode if needed.
+func (m *manager) resolve() error {
+	acs := m.cfg.EKSConfig.Access
+	if acs.ResolvedMode != "" {
+		return nil
+	}
+	if acs.Mode == eksconfig.AccessModeAWSAuth ||
+		(acs.Mode == eksconfig.AccessModeAuto && m.cfg.EKSConfig.VersionValue < eksconfig.AccessEntryMinVersion) {
+		m.setResolvedMode(eksconfig.AccessModeAWSAuth)
+		return nil
+	}
+
+	authMode, err := describeAuthenticationMode(m.cfg.EKSAPI, m.cfg.EKSConfig.Name)
+	if err != nil {
+		return fmt.Errorf("failed to describe cluster authentication mode (%v)", err)
+	}
+	m.cfg.Logger.Info("described cluster authentication mode", zap.String("authentication-mode", authMode))
+	switch authMode {
+	case authenticationModeAPI, authenticationModeAPIAndConfigMap:
+	case authenticationModeConfigMap:
+		// one-way update, "aws-auth" keeps working for the existing mappings
+		if err = m.enableAccessEntries(); err != nil {
+			return err
+		}
+	default:
+		// e.g. the regions without the access entry support
+		if acs.Mode == eksconfig.AccessModeAccessEntry {
+			return fmt.Errorf("cluster %q does not support access entries (authentication mode %q)", m.cfg.EKSConfig.Name, authMode)
+		}
+		m.setResolvedMode(eksconfig.AccessModeAWSAuth)
+		return nil
+	}
+	m.setResolvedMode(eksconfig.AccessModeAccessEntry)
+	return nil
+}
+
+func (m *manager) setResolvedMode(mode string) {
+	m.cfg.EKSConfig.Access.ResolvedMode = mode
+	m.cfg.Logger.Info("resolved access mode", zap.String("mode", mode))
+	m.cfg.EKSConfig.Sync()
+}
+
+func (m *manager) enableAccessEntries() error {
+	m.cfg.Logger.Info("updating cluster authentication mode", zap.String("authentication-mode", authenticationModeAPIAndConfigMap))
+	updateID, err := updateAuthenticationMode(m.cfg.EKSAPI, m.cfg.EKSConfig.Name, authenticationModeAPIAndConfigMap)
+	if err != nil {
+		return fmt.Errorf("failed to update cluster authentication mode (%v)", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	updateCh := wait.PollUpdate(
+		ctx,
+		m.cfg.Stopc,
+		m.cfg.Logger,
+		m.cfg.LogWriter,
+		m.cfg.EKSAPI,
+		m.cfg.EKSConfig.Name,
+		updateID,
+		aws_eks.UpdateStatusSuccessful,
+		30*time.Second,
+		20*time.Second,
+	)
+	for v := range updateCh {
+		err = v.Error
+	}
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to wait for cluster authentication mode update %q (%v)", updateID, err)
+	}
+	m.cfg.Logger.Info("updated cluster authentication mode", zap.String("update-id", updateID))
+	return nil
+}
+
+func (m *manager) mapEntry(e eksconfig.AccessEntry) error {
+	if m.cfg.EKSConfig.Access.ResolvedMode == eksconfig.AccessModeAWSAuth {
+		username := e.Username
+		if username == "" {
+			username = e.PrincipalARN
+		}
+		return m.mapAWSAuth(mapping{arn: e.PrincipalARN, username: username, groups: e.Groups})
+	}
+
+	input := &createAccessEntryInput{
+		ClusterName:  aws.String(m.cfg.EKSConfig.Name),
+		PrincipalArn: aws.String(e.PrincipalARN),
+		Type:         aws.String(accessEntryTypeStandard),
+	}
+	if e.Username != "" {
+		input.Username = aws.String(e.Username)
+	}
+	if len(e.Groups) > 0 {
+		input.KubernetesGroups = aws.StringSlice(e.Groups)
+	}
+	if err := m.createAccessEntry(input); err != nil {
+		return err
+	}
+	for _, policyARN := range e.AccessPolicyARNs {
+		m.cfg.Logger.Info("associating access policy", zap.String("principal-arn", e.PrincipalARN), zap.String("policy-arn", policyARN))
+		if err := associateAccessPolicy(m.cfg.EKSAPI, m.cfg.EKSConfig.Name, e.PrincipalARN, policyARN); err != nil {
+			return fmt.Errorf("failed to associate access policy %q (%v)", policyARN, err)
+		}
+	}
+	return nil
+}
+
+func (m *manager) createAccessEntry(input *createAccessEntryInput) error {
+	err := createAccessEntry(m.cfg.EKSAPI, input)
+	if isErrCode(err, aws_eks.ErrCodeResourceInUseException) {
+		m.cfg.Logger.Info("access entry already exists", zap.String("principal-arn", aws.StringValue(input.PrincipalArn)))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create access entry (%v)", err)
+	}
+	m.cfg.Logger.Info("created access entry",
+		zap.String("principal-arn", aws.StringValue(input.PrincipalArn)),
+		zap.String("type", aws.StringValue(input.Type)),
+	)
+	return nil
+}
+
+func (m *manager) unmap(principalARN string) error {
+	if m.cfg.EKSConfig.Access.ResolvedMode == eksconfig.AccessModeAWSAuth {
+		return m.unmapAWSAuth(principalARN)
+	}
+	err := deleteAccessEntry(m.cfg.EKSAPI, m.cfg.EKSConfig.Name, principalARN)
+	if isErrCode(err, aws_eks.ErrCodeResourceNotFoundException) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete access entry (%v)", err)
+	}
+	m.cfg.Logger.Info("deleted access entry", zap.String("principal-arn", principalARN))
+	return nil
+}
+
+// verify assumes the role, and lists the nodes as the mapped identity.
+func (m *manager) verify(e eksconfig.AccessEntry) error {
+	if m.cfg.EKSConfig.Endpoint != nil && m.cfg.EKSConfig.Endpoint.IsPrivateOnly() {
+		m.cfg.Logger.Warn("skipping access verification for private-only endpoint", zap.String("principal-arn", e.PrincipalARN))
+		return nil
+	}
+	m.cfg.Logger.Info("verifying access", zap.String("principal-arn", e.PrincipalARN))
+	cli, err := k8s_client.NewEKS(&k8s_client.EKSConfig{
+		Logger:                   m.cfg.Logger,
+		Region:                   m.cfg.EKSConfig.Region,
+		ClusterName:              m.cfg.EKSConfig.Name,
+		ClusterAPIServerEndpoint: m.cfg.EKSConfig.Status.ClusterAPIServerEndpoint,
+		ClusterCADecoded:         m.cfg.EKSConfig.Status.ClusterCADecoded,
+		ClientTimeout:            m.cfg.EKSConfig.ClientTimeout,
+		AssumeRole: &pkg_aws.AssumeRole{
+			RoleARN:     e.PrincipalARN,
+			SessionName: "aws-k8s-tester-access-verify",
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// access entries and "aws-auth" updates take a few seconds to propagate
+	retryStart := time.Now()
+	for time.Since(retryStart) < 3*time.Minute {
+		select {
+		case <-m.cfg.Stopc:
+			return errors.New("access verification aborted")
+		case <-time.After(10 * time.Second):
+		}
+		nodes, lerr := cli.ListNodes(1000, 5*time.Second)
+		if lerr == nil {
+			m.cfg.Logger.Info("verified access", zap.String("principal-arn", e.PrincipalARN), zap.Int("nodes", len(nodes)))
+			return nil
+		}
+		err = lerr
+		m.cfg.Logger.Warn("failed to list nodes as principal; retrying", zap.String("principal-arn", e.PrincipalARN), zap.Error(err))
+	}
+	return fmt.Errorf("%q cannot list nodes (%v)", e.PrincipalARN, err)
+}
+
+func isErrCode(err error, code string) bool {
+	var aerr awserr.Error
+	return errors.As(err, &aerr) && aerr.Code() == code
+}
@@ -0,0 +1,137 @@
+package access
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	aws_eks "github.com/aws/aws-sdk-go/service/eks"
+)
+
+func TestUpsertMapping(t *testing.T) {
+	data := `- rolearn: arn:aws:iam::123:role/mng
+  username: system:node:{{EC2PrivateDNSName}}
+  groups:
+  - system:bootstrappers
+  - system:nodes
+`
+	mp := mapping{arn: "arn:aws:iam::123:role/ci", username: "ci", groups: []string{"ci-debug"}}
+	out, changed, err := upsertMapping(data, mp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || !strings.Contains(out, "arn:aws:iam::123:role/mng") || !strings.Contains(out, "arn:aws:iam::123:role/ci") {
+		t.Fatalf("unexpected mapRoles (changed %v):\n%s", changed, out)
+	}
+	if _, changed, err = upsertMapping(out, mp); err != nil || changed {
+		t.Fatalf("expected no change for the same mapping (changed %v, error %v)", changed, err)
+	}
+
+	mp.groups = []string{"ci-admin"}
+	out, changed, err = upsertMapping(out, mp)
+	if err != nil || !changed || strings.Contains(out, "ci-debug") || strings.Count(out, "role/ci\n") != 1 {
+		t.Fatalf("expected the mapping replaced (changed %v, error %v):\n%s", changed, err, out)
+	}
+
+	out, changed, err = removeMapping(out, "arn:aws:iam::123:role/ci")
+	if err != nil || !changed || strings.Contains(out, "role/ci\n") || !strings.Contains(out, "role/mng") {
+		t.Fatalf("expected the mapping removed (changed %v, error %v):\n%s", changed, err, out)
+	}
+	if _, changed, _ = removeMapping(out, "arn:aws:iam::123:role/ci"); changed {
+		t.Fatal("expected no change for the unmapped principal")
+	}
+
+	if dataKey, arnKey := mappingKeys("arn:aws:iam::123:user/ci"); dataKey != "mapUsers" || arnKey != "userarn" {
+		t.Fatalf("unexpected keys %q %q", dataKey, arnKey)
+	}
+}
+
+func TestAPI(t *testing.T) {
+	type call struct {
+		method, path string
+		body         map[string]interface{}
+	}
+	var calls []call
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := ioutil.ReadAll(req.Body)
+		c := call{method: req.Method, path: req.URL.EscapedPath()}
+		if len(b) > 0 {
+			if err := json.Unmarshal(b, &c.body); err != nil {
+				t.Errorf("invalid body %q (%v)", string(b), err)
+			}
+		}
+		calls = append(calls, c)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case req.Method == http.MethodGet:
+			w.Write([]byte(`{"cluster":{"name":"test","accessConfig":{"authenticationMode":"CONFIG_MAP"}}}`))
+		case strings.HasSuffix(req.URL.Path, "/update-config"):
+			w.Write([]byte(`{"update":{"id":"update-id","status":"InProgress"}}`))
+		case strings.HasSuffix(req.URL.Path, "/access-entries"):
+			w.Header().Set("X-Amzn-ErrorType", "ResourceInUseException")
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"message":"already exists"}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Endpoint:    aws.String(srv.URL),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	}))
+	api := aws_eks.New(sess)
+
+	mode, err := describeAuthenticationMode(api, "test")
+	if err != nil || mode != authenticationModeConfigMap {
+		t.Fatalf("unexpected authentication mode %q (%v)", mode, err)
+	}
+	id, err := updateAuthenticationMode(api, "test", authenticationModeAPIAndConfigMap)
+	if err != nil || id != "update-id" {
+		t.Fatalf("unexpected update ID %q (%v)", id, err)
+	}
+	err = createAccessEntry(api, &createAccessEntryInput{
+		ClusterName:      aws.String("test"),
+		PrincipalArn:     aws.String("arn:aws:iam::123:role/ci"),
+		KubernetesGroups: aws.StringSlice([]string{"ci-debug"}),
+		Type:             aws.String(accessEntryTypeStandard),
+	})
+	if !isErrCode(err, aws_eks.ErrCodeResourceInUseException) {
+		t.Fatalf("expected ResourceInUseException, got %v", err)
+	}
+	if err = associateAccessPolicy(api, "test", "arn:aws:iam::123:role/ci", "arn:aws:eks::aws:cluster-access-policy/AmazonEKSAdminViewPolicy"); err != nil {
+		t.Fatal(err)
+	}
+	if err = deleteAccessEntry(api, "test", "arn:aws:iam::123:role/ci"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(calls) != 5 {
+		t.Fatalf("expected 5 calls, got %+v", calls)
+	}
+	if calls[0].method != http.MethodGet || calls[0].path != "/clusters/test" {
+		t.Fatalf("unexpected DescribeCluster %+v", calls[0])
+	}
+	if am := calls[1].body["accessConfig"].(map[string]interface{})["authenticationMode"]; calls[1].path != "/clusters/test/update-config" || am != authenticationModeAPIAndConfigMap {
+		t.Fatalf("unexpected UpdateClusterConfig %+v", calls[1])
+	}
+	if calls[2].path != "/clusters/test/access-entries" || calls[2].body["principalArn"] != "arn:aws:iam::123:role/ci" || calls[2].body["type"] != "STANDARD" || calls[2].body["clusterName"] != nil {
+		t.Fatalf("unexpected CreateAccessEntry %+v", calls[2])
+	}
+	escaped := "arn%3Aaws%3Aiam%3A%3A123%3Arole%2Fci"
+	if calls[3].path != "/clusters/test/access-entries/"+escaped+"/access-policies" || calls[3].body["accessScope"].(map[string]interface{})["type"] != "cluster" {
+		t.Fatalf("unexpected AssociateAccessPolicy %+v", calls[3])
+	}
+	if calls[4].method != http.MethodDelete || calls[4].path != "/clusters/test/access-entries/"+escaped {
+		t.Fatalf("unexpected DeleteAccessEntry %+v", calls[4])
+	}
+}
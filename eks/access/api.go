@@ -0,0 +1,159 @@
+package access
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	aws_eks "github.com/aws/aws-sdk-go/service/eks"
+)
+
+// The pinned "aws-sdk-go" predates the EKS access entry API, so the
+// operations are sent with the EKS client (same endpoint, signer, retries,
+// and throttling), using the REST-JSON shapes from the EKS API reference.
+// ref. https://docs.aws.amazon.com/eks/latest/APIReference/API_CreateAccessEntry.html
+
+const (
+	authenticationModeConfigMap       = "CONFIG_MAP"
+	authenticationModeAPIAndConfigMap = "API_AND_CONFIG_MAP"
+	authenticationModeAPI             = "API"
+
+	accessEntryTypeStandard   = "STANDARD"
+	accessEntryTypeEC2Linux   = "EC2_LINUX"
+	accessEntryTypeEC2Windows = "EC2_WINDOWS"
+)
+
+type accessConfig struct {
+	_                  struct{} `type:"structure"`
+	AuthenticationMode *string  `locationName:"authenticationMode" type:"string"`
+}
+
+type describeClusterInput struct {
+	_    struct{} `type:"structure"`
+	Name *string  `location:"uri" locationName:"name" type:"string" required:"true"`
+}
+
+type describeClusterOutput struct {
+	_       struct{} `type:"structure"`
+	Cluster *struct {
+		_            struct{}      `type:"structure"`
+		AccessConfig *accessConfig `locationName:"accessConfig" type:"structure"`
+	} `locationName:"cluster" type:"structure"`
+}
+
+type updateClusterConfigInput struct {
+	_            struct{}      `type:"structure"`
+	Name         *string       `location:"uri" locationName:"name" type:"string" required:"true"`
+	AccessConfig *accessConfig `locationName:"accessConfig" type:"structure"`
+}
+
+type updateClusterConfigOutput struct {
+	_      struct{}        `type:"structure"`
+	Update *aws_eks.Update `locationName:"update" type:"structure"`
+}
+
+type createAccessEntryInput struct {
+	_                struct{}  `type:"structure"`
+	ClusterName      *string   `location:"uri" locationName:"name" type:"string" required:"true"`
+	PrincipalArn     *string   `locationName:"principalArn" type:"string" required:"true"`
+	KubernetesGroups []*string `locationName:"kubernetesGroups" type:"list"`
+	Username         *string   `locationName:"username" type:"string"`
+	Type             *string   `locationName:"type" type:"string"`
+}
+
+type accessEntryOutput struct {
+	_ struct{} `type:"structure"`
+}
+
+type deleteAccessEntryInput struct {
+	_            struct{} `type:"structure"`
+	ClusterName  *string  `location:"uri" locationName:"name" type:"string" required:"true"`
+	PrincipalArn *string  `location:"uri" locationName:"principalArn" type:"string" required:"true"`
+}
+
+type accessScope struct {
+	_    struct{} `type:"structure"`
+	Type *string  `locationName:"type" type:"string"`
+}
+
+type associateAccessPolicyInput struct {
+	_            struct{}     `type:"structure"`
+	ClusterName  *string      `location:"uri" locationName:"name" type:"string" required:"true"`
+	PrincipalArn *string      `location:"uri" locationName:"principalArn" type:"string" required:"true"`
+	PolicyArn    *string      `locationName:"policyArn" type:"string" required:"true"`
+	AccessScope  *accessScope `locationName:"accessScope" type:"structure"`
+}
+
+// describeAuthenticationMode returns the cluster authentication mode,
+// or empty if the cluster does not report one (no access entry support).
+func describeAuthenticationMode(api *aws_eks.EKS, clusterName string) (string, error) {
+	out := &describeClusterOutput{}
+	req := api.NewRequest(&request.Operation{
+		Name:       "DescribeCluster",
+		HTTPMethod: "GET",
+		HTTPPath:   "/clusters/{name}",
+	}, &describeClusterInput{Name: aws.String(clusterName)}, out)
+	if err := req.Send(); err != nil {
+		return "", err
+	}
+	if out.Cluster == nil || out.Cluster.AccessConfig == nil {
+		return "", nil
+	}
+	return aws.StringValue(out.Cluster.AccessConfig.AuthenticationMode), nil
+}
+
+// updateAuthenticationMode starts the cluster update of the
+// authentication mode, and returns the update ID.
+func updateAuthenticationMode(api *aws_eks.EKS, clusterName string, mode string) (string, error) {
+	out := &updateClusterConfigOutput{}
+	req := api.NewRequest(&request.Operation{
+		Name:       "UpdateClusterConfig",
+		HTTPMethod: "POST",
+		HTTPPath:   "/clusters/{name}/update-config",
+	}, &updateClusterConfigInput{
+		Name:         aws.String(clusterName),
+		AccessConfig: &accessConfig{AuthenticationMode: aws.String(mode)},
+	}, out)
+	if err := req.Send(); err != nil {
+		return "", err
+	}
+	if out.Update == nil {
+		return "", nil
+	}
+	return aws.StringValue(out.Update.Id), nil
+}
+
+func createAccessEntry(api *aws_eks.EKS, input *createAccessEntryInput) error {
+	req := api.NewRequest(&request.Operation{
+		Name:       "CreateAccessEntry",
+		HTTPMethod: "POST",
+		HTTPPath:   "/clusters/{name}/access-entries",
+	}, input, &accessEntryOutput{})
+	return req.Send()
+}
+
+func deleteAccessEntry(api *aws_eks.EKS, clusterName string, principalARN string) error {
+	req := api.NewRequest(&request.Operation{
+		Name:       "DeleteAccessEntry",
+		HTTPMethod: "DELETE",
+		HTTPPath:   "/clusters/{name}/access-entries/{principalArn}",
+	}, &deleteAccessEntryInput{
+		ClusterName:  aws.String(clusterName),
+		PrincipalArn: aws.String(principalARN),
+	}, &accessEntryOutput{})
+	return req.Send()
+}
+
+// associateAccessPolicy associates the access policy
+// with the access entry, scoped to the whole cluster.
+func associateAccessPolicy(api *aws_eks.EKS, clusterName string, principalARN string, policyARN string) error {
+	req := api.NewRequest(&request.Operation{
+		Name:       "AssociateAccessPolicy",
+		HTTPMethod: "POST",
+		HTTPPath:   "/clusters/{name}/access-entries/{principalArn}/access-policies",
+	}, &associateAccessPolicyInput{
+		ClusterName:  aws.String(clusterName),
+		PrincipalArn: aws.String(principalARN),
+		PolicyArn:    aws.String(policyARN),
+		AccessScope:  &accessScope{Type: aws.String("cluster")},
+	}, &accessEntryOutput{})
+	return req.Send()
+}
@@ -0,0 +1,202 @@
+package access
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	awsAuthName      = "aws-auth"
+	awsAuthNamespace = "kube-system"
+)
+
+// mapping is the "mapRoles" or "mapUsers" entry in the "aws-auth" ConfigMap.
+type mapping struct {
+	arn      string
+	username string
+	groups   []string
+}
+
+// keys returns the ConfigMap data key and the ARN field of the principal.
+func (mp mapping) keys() (dataKey string, arnKey string) {
+	return mappingKeys(mp.arn)
+}
+
+func mappingKeys(arn string) (dataKey string, arnKey string) {
+	if strings.Contains(arn, ":user/") {
+		return "mapUsers", "userarn"
+	}
+	return "mapRoles", "rolearn"
+}
+
+// upsertMapping adds or replaces the principal mapping in the "mapRoles"
+// or "mapUsers" data, keeping the other mappings in place.
+// It returns false if the mapping is unchanged.
+func upsertMapping(data string, mp mapping) (string, bool, error) {
+	_, arnKey := mp.keys()
+	var entries []map[string]interface{}
+	if err := yaml.Unmarshal([]byte(data), &entries); err != nil {
+		return "", false, err
+	}
+	entry := map[string]interface{}{
+		arnKey:     mp.arn,
+		"username": mp.username,
+		"groups":   mp.groups,
+	}
+	// normalize for comparison with the unmarshaled entries
+	b, err := yaml.Marshal(entry)
+	if err != nil {
+		return "", false, err
+	}
+	var normalized map[string]interface{}
+	if err = yaml.Unmarshal(b, &normalized); err != nil {
+		return "", false, err
+	}
+
+	found := false
+	for i, v := range entries {
+		if v[arnKey] != mp.arn {
+			continue
+		}
+		if reflect.DeepEqual(v, normalized) {
+			return data, false, nil
+		}
+		entries[i] = normalized
+		found = true
+	}
+	if !found {
+		entries = append(entries, normalized)
+	}
+	b, err = yaml.Marshal(entries)
+	if err != nil {
+		return "", false, err
+	}
+	return string(b), true, nil
+}
+
+// removeMapping removes the principal mapping from the "mapRoles"
+// or "mapUsers" data. It returns false if the principal is not mapped.
+func removeMapping(data string, arn string) (string, bool, error) {
+	_, arnKey := mappingKeys(arn)
+	var entries []map[string]interface{}
+	if err := yaml.Unmarshal([]byte(data), &entries); err != nil {
+		return "", false, err
+	}
+	kept := make([]map[string]interface{}, 0, len(entries))
+	for _, v := range entries {
+		if v[arnKey] == arn {
+			continue
+		}
+		kept = append(kept, v)
+	}
+	if len(kept) == len(entries) {
+		return data, false, nil
+	}
+	b, err := yaml.Marshal(kept)
+	if err != nil {
+		return "", false, err
+	}
+	return string(b), true, nil
+}
+
+// mapAWSAuth maps the principal in the "aws-auth" ConfigMap,
+// creating the ConfigMap if not found.
+func (m *manager) mapAWSAuth(mp mapping) error {
+	dataKey, _ := mp.keys()
+	cli := m.cfg.K8SClient.KubernetesClientSet().CoreV1().ConfigMaps(awsAuthNamespace)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	cm, err := cli.Get(ctx, awsAuthName, metav1.GetOptions{})
+	cancel()
+	notFound := apierrs.IsNotFound(err)
+	if err != nil && !notFound {
+		return fmt.Errorf("failed to get ConfigMap aws-auth (%v)", err)
+	}
+	if notFound {
+		cm = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      awsAuthName,
+				Namespace: awsAuthNamespace,
+			},
+		}
+	}
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+
+	data, changed, err := upsertMapping(cm.Data[dataKey], mp)
+	if err != nil {
+		return fmt.Errorf("failed to parse ConfigMap aws-auth %s (%v)", dataKey, err)
+	}
+	if !changed {
+		m.cfg.Logger.Info("principal already mapped in ConfigMap", zap.String("arn", mp.arn))
+		return nil
+	}
+	cm.Data[dataKey] = data
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	if notFound {
+		_, err = cli.Create(ctx, cm, metav1.CreateOptions{})
+	} else {
+		_, err = cli.Update(ctx, cm, metav1.UpdateOptions{})
+	}
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to update ConfigMap aws-auth (%v)", err)
+	}
+
+	m.cfg.Logger.Info("mapped principal in ConfigMap", zap.String("arn", mp.arn), zap.Strings("groups", mp.groups))
+	fmt.Fprintf(m.cfg.LogWriter, "\naws-auth ConfigMap %s:\n\n%s\n", dataKey, data)
+	return nil
+}
+
+// unmapAWSAuth removes the principal from the "aws-auth" ConfigMap,
+// keeping the other mappings (e.g. managed node groups) in place.
+func (m *manager) unmapAWSAuth(arn string) error {
+	if m.cfg.K8SClient == nil {
+		m.cfg.Logger.Warn("empty K8SClient; skipping ConfigMap update")
+		return nil
+	}
+	dataKey, _ := mappingKeys(arn)
+	cli := m.cfg.K8SClient.KubernetesClientSet().CoreV1().ConfigMaps(awsAuthNamespace)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	cm, err := cli.Get(ctx, awsAuthName, metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		if apierrs.IsNotFound(err) {
+			m.cfg.Logger.Info("ConfigMap not found; skipping")
+			return nil
+		}
+		return fmt.Errorf("failed to get ConfigMap aws-auth (%v)", err)
+	}
+
+	data, changed, err := removeMapping(cm.Data[dataKey], arn)
+	if err != nil {
+		return fmt.Errorf("failed to parse ConfigMap aws-auth %s (%v)", dataKey, err)
+	}
+	if !changed {
+		m.cfg.Logger.Info("principal not found in ConfigMap; skipping", zap.String("arn", arn))
+		return nil
+	}
+	cm.Data[dataKey] = data
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = cli.Update(ctx, cm, metav1.UpdateOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to update ConfigMap aws-auth (%v)", err)
+	}
+
+	m.cfg.Logger.Info("removed principal from ConfigMap", zap.String("arn", arn))
+	return nil
+}
@@ -0,0 +1,72 @@
+package eks
+
+// Register the in-tree add-ons (see "eks/tester.Register").
+// Out-of-tree add-ons are registered by importing their packages
+// from the main package of the custom tester binary.
+import (
+	_ "github.com/aws/aws-k8s-tester/eks/alb"
+	_ "github.com/aws/aws-k8s-tester/eks/alb-2048"
+	_ "github.com/aws/aws-k8s-tester/eks/amazon-eks-ami-issue-454"
+	_ "github.com/aws/aws-k8s-tester/eks/app-mesh"
+	_ "github.com/aws/aws-k8s-tester/eks/chaos"
+	_ "github.com/aws/aws-k8s-tester/eks/cluster-loader/churn"
+	_ "github.com/aws/aws-k8s-tester/eks/cluster-loader/clusterloader2"
+	_ "github.com/aws/aws-k8s-tester/eks/cluster-loader/local"
+	_ "github.com/aws/aws-k8s-tester/eks/cluster-loader/remote"
+	_ "github.com/aws/aws-k8s-tester/eks/clusterautoscaler"
+	_ "github.com/aws/aws-k8s-tester/eks/cni-version-matrix"
+	_ "github.com/aws/aws-k8s-tester/eks/configmaps/local"
+	_ "github.com/aws/aws-k8s-tester/eks/configmaps/remote"
+	_ "github.com/aws/aws-k8s-tester/eks/conformance"
+	_ "github.com/aws/aws-k8s-tester/eks/container-insights"
+	_ "github.com/aws/aws-k8s-tester/eks/coredns-scale"
+	_ "github.com/aws/aws-k8s-tester/eks/cron-jobs"
+	_ "github.com/aws/aws-k8s-tester/eks/csi-ebs"
+	_ "github.com/aws/aws-k8s-tester/eks/csi-ebs/churn"
+	_ "github.com/aws/aws-k8s-tester/eks/csi-efs"
+	_ "github.com/aws/aws-k8s-tester/eks/csrs/local"
+	_ "github.com/aws/aws-k8s-tester/eks/csrs/remote"
+	_ "github.com/aws/aws-k8s-tester/eks/cuda-vector-add"
+	_ "github.com/aws/aws-k8s-tester/eks/custom-manifests"
+	_ "github.com/aws/aws-k8s-tester/eks/custom-networking"
+	_ "github.com/aws/aws-k8s-tester/eks/cw-agent"
+	_ "github.com/aws/aws-k8s-tester/eks/ecr"
+	_ "github.com/aws/aws-k8s-tester/eks/fargate"
+	_ "github.com/aws/aws-k8s-tester/eks/fluentd"
+	_ "github.com/aws/aws-k8s-tester/eks/fsx-lustre"
+	_ "github.com/aws/aws-k8s-tester/eks/gpu/device-plugin"
+	_ "github.com/aws/aws-k8s-tester/eks/hpa"
+	_ "github.com/aws/aws-k8s-tester/eks/ipv6"
+	_ "github.com/aws/aws-k8s-tester/eks/irsa"
+	_ "github.com/aws/aws-k8s-tester/eks/irsa-fargate"
+	_ "github.com/aws/aws-k8s-tester/eks/jobs-echo"
+	_ "github.com/aws/aws-k8s-tester/eks/jobs-pi"
+	_ "github.com/aws/aws-k8s-tester/eks/jobs-throughput"
+	_ "github.com/aws/aws-k8s-tester/eks/jupyter-hub"
+	_ "github.com/aws/aws-k8s-tester/eks/karpenter"
+	_ "github.com/aws/aws-k8s-tester/eks/kube-proxy-modes"
+	_ "github.com/aws/aws-k8s-tester/eks/kubeflow"
+	_ "github.com/aws/aws-k8s-tester/eks/kubernetes-dashboard"
+	_ "github.com/aws/aws-k8s-tester/eks/managed-add-ons"
+	_ "github.com/aws/aws-k8s-tester/eks/metrics-server"
+	_ "github.com/aws/aws-k8s-tester/eks/multi-arch"
+	_ "github.com/aws/aws-k8s-tester/eks/network-policy"
+	_ "github.com/aws/aws-k8s-tester/eks/nlb-guestbook"
+	_ "github.com/aws/aws-k8s-tester/eks/nlb-hello-world"
+	_ "github.com/aws/aws-k8s-tester/eks/node-fault"
+	_ "github.com/aws/aws-k8s-tester/eks/oidc-identity-provider"
+	_ "github.com/aws/aws-k8s-tester/eks/overprovisioning"
+	_ "github.com/aws/aws-k8s-tester/eks/php-apache"
+	_ "github.com/aws/aws-k8s-tester/eks/pod-density"
+	_ "github.com/aws/aws-k8s-tester/eks/pod-identity"
+	_ "github.com/aws/aws-k8s-tester/eks/prometheus-grafana"
+	_ "github.com/aws/aws-k8s-tester/eks/secrets/local"
+	_ "github.com/aws/aws-k8s-tester/eks/secrets/remote"
+	_ "github.com/aws/aws-k8s-tester/eks/service-churn"
+	_ "github.com/aws/aws-k8s-tester/eks/spot-interruption"
+	_ "github.com/aws/aws-k8s-tester/eks/stresser/local"
+	_ "github.com/aws/aws-k8s-tester/eks/stresser/remote"
+	_ "github.com/aws/aws-k8s-tester/eks/stresser2"
+	_ "github.com/aws/aws-k8s-tester/eks/version-skew"
+	_ "github.com/aws/aws-k8s-tester/eks/wordpress"
+)
@@ -19,7 +19,6 @@ import (
 	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
 	"github.com/aws/aws-sdk-go/service/elbv2"
 	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
 	"go.uber.org/zap"
@@ -39,7 +38,6 @@ type Config struct {
 	LogWriter io.Writer
 	Stopc     chan struct{}
 	EKSConfig *eksconfig.Config
-	CFNAPI    cloudformationiface.CloudFormationAPI
 	K8SClient k8s_client.EKS
 	ELB2API   elbv2iface.ELBV2API
 }
@@ -54,6 +52,22 @@ func New(cfg Config) eks_tester.Tester {
 	return &tester{cfg: cfg}
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "alb-2048",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				ELB2API:   cfg.ELBV2API,
+			}), cfg.EKSConfig.IsEnabledAddOnALB2048)
+		},
+	})
+}
+
 type tester struct {
 	cfg              Config
 	policyCFNStackID string // TODO: persist
@@ -89,13 +103,15 @@ func (ts *tester) Create() error {
 	}
 
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
-	ts.cfg.EKSConfig.AddOnALB2048.Created = true
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnALB2048.Created = true
+	})
 	createStart := time.Now()
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnALB2048.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnALB2048.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
 	if err := k8s_client.CreateNamespace(
@@ -151,8 +167,9 @@ func (ts *tester) Delete() error {
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnALB2048.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnALB2048.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
 	}()
 
 	var errs []string
@@ -242,8 +259,9 @@ func (ts *tester) Delete() error {
 		return errors.New(strings.Join(errs, ", "))
 	}
 
-	ts.cfg.EKSConfig.AddOnALB2048.Created = false
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnALB2048.Created = false
+	})
 	return nil
 }
 
@@ -999,10 +1017,13 @@ func (ts *tester) create2048Ingress() error {
 
 	fields := strings.Split(hostName, "-")
 	if len(fields) >= 3 {
-		ts.cfg.EKSConfig.AddOnALB2048.ALBName = strings.Join(fields[:3], "-")
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnALB2048.ALBName = strings.Join(fields[:3], "-")
+		})
 	}
-	ts.cfg.EKSConfig.AddOnALB2048.URL = "http://" + hostName
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnALB2048.URL = "http://" + hostName
+	})
 
 	if ts.cfg.EKSConfig.AddOnALB2048.ALBName == "" {
 		return errors.New("failed to create 2048 Ingress; got empty ALB name")
@@ -1025,7 +1046,9 @@ func (ts *tester) create2048Ingress() error {
 		return err
 	}
 	for _, lb := range do.LoadBalancers {
-		ts.cfg.EKSConfig.AddOnALB2048.ALBARN = aws.StringValue(lb.LoadBalancerArn)
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnALB2048.ALBARN = aws.StringValue(lb.LoadBalancerArn)
+		})
 		break
 	}
 
@@ -0,0 +1,275 @@
+// Package alb installs the AWS Load Balancer Controller, and tests
+// an Ingress (ALB) and a Service of type LoadBalancer (NLB) end-to-end,
+// measuring the load balancer provisioning latency.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/aws-load-balancer-controller.html
+// ref. https://github.com/aws/eks-charts/tree/master/stable/aws-load-balancer-controller
+package alb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/pkg/helm"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	aws_elbv2_v2 "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	aws_iam_v2 "github.com/aws/aws-sdk-go-v2/service/iam"
+	"go.uber.org/zap"
+	"k8s.io/utils/exec"
+)
+
+// Config defines AWS Load Balancer Controller configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+
+	IAMAPIV2   *aws_iam_v2.Client
+	ELBV2APIV2 *aws_elbv2_v2.Client
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new AWS Load Balancer Controller tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "alb",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:     cfg.Logger,
+				LogWriter:  cfg.LogWriter,
+				Stopc:      cfg.Stopc,
+				EKSConfig:  cfg.EKSConfig,
+				K8SClient:  cfg.K8SClient,
+				IAMAPIV2:   cfg.IAMAPIV2,
+				ELBV2APIV2: cfg.ELBV2APIV2,
+			}), cfg.EKSConfig.IsEnabledAddOnALB)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+const (
+	chartRepoName = "eks"
+	chartName     = "aws-load-balancer-controller"
+
+	// serviceAccountName is the controller service account name created by the chart.
+	serviceAccountName = "aws-load-balancer-controller"
+)
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnALB() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnALB.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnALB.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnALB.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnALB.Namespace,
+	); err != nil {
+		return err
+	}
+	if err = ts.createOIDCProvider(); err != nil {
+		return err
+	}
+	if err = ts.createRole(); err != nil {
+		return err
+	}
+	if err = ts.createHelmController(); err != nil {
+		return err
+	}
+	if err = ts.createDeployment(); err != nil {
+		return err
+	}
+	if err = ts.createIngress(); err != nil {
+		return err
+	}
+	if err = ts.createService(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ts.cfg.LogWriter, "\nALB URL: %s (provisioned in %s, served in %s)\n",
+		ts.cfg.EKSConfig.AddOnALB.ALB.URL,
+		ts.cfg.EKSConfig.AddOnALB.ALB.TimeFrameProvision.TookString,
+		ts.cfg.EKSConfig.AddOnALB.ALB.TimeFrameServe.TookString,
+	)
+	fmt.Fprintf(ts.cfg.LogWriter, "NLB URL: %s (provisioned in %s, served in %s)\n\n",
+		ts.cfg.EKSConfig.AddOnALB.NLB.URL,
+		ts.cfg.EKSConfig.AddOnALB.NLB.TimeFrameProvision.TookString,
+		ts.cfg.EKSConfig.AddOnALB.NLB.TimeFrameServe.TookString,
+	)
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnALB() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnALB.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnALB.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	// delete the Ingress and the Service while the controller is still running,
+	// for the controller to delete the load balancers and target groups
+	var errs []string
+	if err := ts.deleteIngress(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteService(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.waitLoadBalancerDeleted(&ts.cfg.EKSConfig.AddOnALB.ALB); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.waitLoadBalancerDeleted(&ts.cfg.EKSConfig.AddOnALB.NLB); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteHelmController(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnALB.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete ALB namespace (%v)", err))
+	}
+	if err := ts.deleteRole(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteOIDCProvider(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnALB.Created = false
+	})
+	return nil
+}
+
+// https://github.com/aws/eks-charts/blob/master/stable/aws-load-balancer-controller/values.yaml
+func (ts *tester) createHelmController() error {
+	if err := helm.RepoAdd(ts.cfg.Logger, chartRepoName, ts.cfg.EKSConfig.AddOnALB.ChartRepoURL); err != nil {
+		return err
+	}
+
+	// set the region and VPC ID, since the controller Pods
+	// may not reach the IMDS with the hop limit 1
+	values := map[string]interface{}{
+		"clusterName": ts.cfg.EKSConfig.Name,
+		"region":      ts.cfg.EKSConfig.Region,
+		"vpcId":       ts.cfg.EKSConfig.VPC.ID,
+		"serviceAccount": map[string]interface{}{
+			"name": serviceAccountName,
+			"annotations": map[string]interface{}{
+				"eks.amazonaws.com/role-arn": ts.cfg.EKSConfig.AddOnALB.RoleARN,
+			},
+		},
+	}
+
+	getAllArgs := []string{
+		ts.cfg.EKSConfig.KubectlPath,
+		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+		"--namespace=" + ts.cfg.EKSConfig.AddOnALB.Namespace,
+		"get",
+		"all",
+	}
+	getAllCmd := strings.Join(getAllArgs, " ")
+
+	return helm.Install(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Stopc:          ts.cfg.Stopc,
+		Timeout:        15 * time.Minute,
+		KubeConfigPath: ts.cfg.EKSConfig.KubeConfigPath,
+		Namespace:      ts.cfg.EKSConfig.AddOnALB.Namespace,
+		ChartRepoURL:   ts.cfg.EKSConfig.AddOnALB.ChartRepoURL,
+		ChartName:      chartName,
+		ChartVersion:   ts.cfg.EKSConfig.AddOnALB.ChartVersion,
+		ReleaseName:    chartName,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
+		Values:         values,
+		LogFunc: func(format string, v ...interface{}) {
+			ts.cfg.Logger.Info(fmt.Sprintf("[install] "+format, v...))
+		},
+		QueryFunc: func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			output, err := exec.New().CommandContext(ctx, getAllArgs[0], getAllArgs[1:]...).CombinedOutput()
+			cancel()
+			out := strings.TrimSpace(string(output))
+			if err != nil {
+				ts.cfg.Logger.Warn("'kubectl get all' failed", zap.Error(err))
+			}
+			fmt.Fprintf(ts.cfg.LogWriter, "\n\n'%s' output:\n\n%s\n\n", getAllCmd, out)
+		},
+		QueryInterval: 30 * time.Second,
+	})
+}
+
+func (ts *tester) deleteHelmController() error {
+	return helm.Uninstall(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Timeout:        15 * time.Minute,
+		KubeConfigPath: ts.cfg.EKSConfig.KubeConfigPath,
+		Namespace:      ts.cfg.EKSConfig.AddOnALB.Namespace,
+		ChartName:      chartName,
+		ReleaseName:    chartName,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
+	})
+}
@@ -0,0 +1,450 @@
+package alb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/pkg/httputil"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_elbv2_v2 "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	networking_v1 "k8s.io/api/networking/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	appName = "alb-backend"
+	// backendServiceName is the ClusterIP Service for the Ingress backend.
+	backendServiceName = "alb-backend"
+	ingressName        = "alb-ingress"
+	nlbServiceName     = "nlb-service"
+
+	// ingressClassName is the IngressClass created by the chart.
+	ingressClassName = "alb"
+)
+
+var appLabels = map[string]string{
+	"app.kubernetes.io/name": appName,
+}
+
+func (ts *tester) createDeployment() error {
+	cur := ts.cfg.EKSConfig.AddOnALB
+	ts.cfg.Logger.Info("creating backend Deployment", zap.String("image", cur.DeploymentImage))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(cur.Namespace).
+		Create(
+			ctx,
+			&appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      appName,
+					Namespace: cur.Namespace,
+					Labels:    appLabels,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: aws_v2.Int32(cur.DeploymentReplicas),
+					Selector: &metav1.LabelSelector{
+						MatchLabels: appLabels,
+					},
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: appLabels,
+						},
+						Spec: v1.PodSpec{
+							RestartPolicy: v1.RestartPolicyAlways,
+							Containers: []v1.Container{
+								{
+									Name:            appName,
+									Image:           cur.DeploymentImage,
+									ImagePullPolicy: v1.PullIfNotPresent,
+									Ports: []v1.ContainerPort{
+										{
+											Protocol:      v1.ProtocolTCP,
+											ContainerPort: 80,
+										},
+									},
+								},
+							},
+							NodeSelector: map[string]string{
+								"kubernetes.io/os": "linux",
+							},
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create backend Deployment (%v)", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Minute)
+	_, err = k8s_client.WaitForDeploymentCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.K8SClient,
+		10*time.Second,
+		5*time.Second,
+		cur.Namespace,
+		appName,
+		cur.DeploymentReplicas,
+	)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("creating backend Service")
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Services(cur.Namespace).
+		Create(
+			ctx,
+			&v1.Service{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Service",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      backendServiceName,
+					Namespace: cur.Namespace,
+				},
+				Spec: v1.ServiceSpec{
+					Selector: appLabels,
+					Type:     v1.ServiceTypeClusterIP,
+					Ports: []v1.ServicePort{
+						{
+							Protocol:   v1.ProtocolTCP,
+							Port:       80,
+							TargetPort: intstr.FromInt(80),
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create backend Service (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created backend Deployment and Service")
+	return nil
+}
+
+// https://kubernetes-sigs.github.io/aws-load-balancer-controller/v2.6/guide/ingress/annotations/
+func (ts *tester) createIngress() error {
+	cur := ts.cfg.EKSConfig.AddOnALB
+	ts.cfg.Logger.Info("creating Ingress", zap.String("ingress-name", ingressName))
+	pathType := networking_v1.PathTypePrefix
+	createStart := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		NetworkingV1().
+		Ingresses(cur.Namespace).
+		Create(
+			ctx,
+			&networking_v1.Ingress{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "networking.k8s.io/v1",
+					Kind:       "Ingress",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ingressName,
+					Namespace: cur.Namespace,
+					Annotations: map[string]string{
+						"alb.ingress.kubernetes.io/scheme":      "internet-facing",
+						"alb.ingress.kubernetes.io/target-type": "ip",
+					},
+				},
+				Spec: networking_v1.IngressSpec{
+					IngressClassName: aws_v2.String(ingressClassName),
+					Rules: []networking_v1.IngressRule{
+						{
+							IngressRuleValue: networking_v1.IngressRuleValue{
+								HTTP: &networking_v1.HTTPIngressRuleValue{
+									Paths: []networking_v1.HTTPIngressPath{
+										{
+											Path:     "/",
+											PathType: &pathType,
+											Backend: networking_v1.IngressBackend{
+												Service: &networking_v1.IngressServiceBackend{
+													Name: backendServiceName,
+													Port: networking_v1.ServiceBackendPort{Number: 80},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create Ingress (%v)", err)
+	}
+
+	lb := &cur.ALB
+	if err = ts.waitLoadBalancer(lb, createStart, func() ([]v1.LoadBalancerIngress, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		ing, err := ts.cfg.K8SClient.KubernetesClientSet().
+			NetworkingV1().
+			Ingresses(cur.Namespace).
+			Get(ctx, ingressName, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		return ing.Status.LoadBalancer.Ingress, nil
+	}); err != nil {
+		return fmt.Errorf("ALB for Ingress %q failed (%v)", ingressName, err)
+	}
+	return nil
+}
+
+// https://kubernetes-sigs.github.io/aws-load-balancer-controller/v2.6/guide/service/nlb/
+func (ts *tester) createService() error {
+	cur := ts.cfg.EKSConfig.AddOnALB
+	ts.cfg.Logger.Info("creating NLB Service", zap.String("service-name", nlbServiceName))
+	createStart := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Services(cur.Namespace).
+		Create(
+			ctx,
+			&v1.Service{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Service",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      nlbServiceName,
+					Namespace: cur.Namespace,
+					Annotations: map[string]string{
+						// "external" for the controller, instead of the in-tree cloud provider
+						"service.beta.kubernetes.io/aws-load-balancer-type":            "external",
+						"service.beta.kubernetes.io/aws-load-balancer-nlb-target-type": "ip",
+						"service.beta.kubernetes.io/aws-load-balancer-scheme":          "internet-facing",
+					},
+				},
+				Spec: v1.ServiceSpec{
+					Selector: appLabels,
+					Type:     v1.ServiceTypeLoadBalancer,
+					Ports: []v1.ServicePort{
+						{
+							Protocol:   v1.ProtocolTCP,
+							Port:       80,
+							TargetPort: intstr.FromInt(80),
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create NLB Service (%v)", err)
+	}
+
+	lb := &cur.NLB
+	if err = ts.waitLoadBalancer(lb, createStart, func() ([]v1.LoadBalancerIngress, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		svc, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Services(cur.Namespace).
+			Get(ctx, nlbServiceName, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		return svc.Status.LoadBalancer.Ingress, nil
+	}); err != nil {
+		return fmt.Errorf("NLB for Service %q failed (%v)", nlbServiceName, err)
+	}
+	return nil
+}
+
+// waitLoadBalancer waits for the load balancer DNS name to be assigned
+// to the Kubernetes object status, and then for the load balancer
+// to serve the backend response, recording the time taken for each.
+func (ts *tester) waitLoadBalancer(lb *eksconfig.ALBLoadBalancer, createStart time.Time, getStatus func() ([]v1.LoadBalancerIngress, error)) error {
+	timeout := ts.cfg.EKSConfig.AddOnALB.ProvisionTimeout
+	deadline := createStart.Add(timeout)
+	for lb.DNSName == "" && time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait for load balancer aborted")
+		case <-time.After(5 * time.Second):
+		}
+		ingress, err := getStatus()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get load balancer status; retrying", zap.Error(err))
+			continue
+		}
+		for _, ing := range ingress {
+			if ing.Hostname != "" {
+				ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+					lb.DNSName = ing.Hostname
+				})
+				break
+			}
+		}
+	}
+	if lb.DNSName == "" {
+		return fmt.Errorf("load balancer DNS name not assigned within %v", timeout)
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		lb.TimeFrameProvision = timeutil.NewTimeFrame(createStart, time.Now())
+		lb.URL = "http://" + lb.DNSName
+	})
+	ts.cfg.Logger.Info("load balancer provisioned",
+		zap.String("dns-name", lb.DNSName),
+		zap.String("took", lb.TimeFrameProvision.TookString),
+	)
+
+	arn, err := ts.findLoadBalancerARN(lb.DNSName)
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		lb.ARN = arn
+	})
+
+	// the DNS name resolves and the targets become healthy after provisioning
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait for load balancer aborted")
+		case <-time.After(5 * time.Second):
+		}
+		out, err := httputil.ReadInsecure(ts.cfg.Logger, ioutil.Discard, lb.URL)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to read load balancer URL; retrying", zap.String("url", lb.URL), zap.Error(err))
+			continue
+		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			lb.TimeFrameServe = timeutil.NewTimeFrame(createStart, time.Now())
+		})
+		fmt.Fprintf(ts.cfg.LogWriter, "\n%q output:\n%s\n", lb.URL, string(out))
+		ts.cfg.Logger.Info("load balancer served traffic",
+			zap.String("url", lb.URL),
+			zap.String("took", lb.TimeFrameServe.TookString),
+		)
+		return nil
+	}
+	return fmt.Errorf("load balancer %q did not serve traffic within %v", lb.URL, timeout)
+}
+
+func (ts *tester) findLoadBalancerARN(dnsName string) (string, error) {
+	p := aws_elbv2_v2.NewDescribeLoadBalancersPaginator(ts.cfg.ELBV2APIV2, &aws_elbv2_v2.DescribeLoadBalancersInput{})
+	for p.HasMorePages() {
+		out, err := p.NextPage(context.Background())
+		if err != nil {
+			return "", fmt.Errorf("failed to describe load balancers (%v)", err)
+		}
+		for _, lb := range out.LoadBalancers {
+			if aws_v2.ToString(lb.DNSName) == dnsName {
+				return aws_v2.ToString(lb.LoadBalancerArn), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("load balancer %q not found", dnsName)
+}
+
+func (ts *tester) deleteIngress() error {
+	ts.cfg.Logger.Info("deleting Ingress", zap.String("ingress-name", ingressName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.K8SClient.KubernetesClientSet().
+		NetworkingV1().
+		Ingresses(ts.cfg.EKSConfig.AddOnALB.Namespace).
+		Delete(ctx, ingressName, metav1.DeleteOptions{})
+	cancel()
+	if err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to delete Ingress (%v)", err)
+	}
+	ts.cfg.Logger.Info("deleted Ingress")
+	return nil
+}
+
+func (ts *tester) deleteService() error {
+	ts.cfg.Logger.Info("deleting NLB Service", zap.String("service-name", nlbServiceName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Services(ts.cfg.EKSConfig.AddOnALB.Namespace).
+		Delete(ctx, nlbServiceName, metav1.DeleteOptions{})
+	cancel()
+	if err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to delete NLB Service (%v)", err)
+	}
+	ts.cfg.Logger.Info("deleted NLB Service")
+	return nil
+}
+
+// waitLoadBalancerDeleted waits for the controller to delete the load balancer,
+// and deletes the load balancer directly if the controller failed to.
+func (ts *tester) waitLoadBalancerDeleted(lb *eksconfig.ALBLoadBalancer) error {
+	if lb.ARN == "" || lb.Deleted {
+		return nil
+	}
+	ts.cfg.Logger.Info("waiting for load balancer deletion", zap.String("arn", lb.ARN))
+	deadline := time.Now().Add(5 * time.Minute)
+	for time.Now().Before(deadline) {
+		_, err := ts.cfg.ELBV2APIV2.DescribeLoadBalancers(
+			context.Background(),
+			&aws_elbv2_v2.DescribeLoadBalancersInput{
+				LoadBalancerArns: []string{lb.ARN},
+			},
+		)
+		if isNotFound(err) {
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				lb.Deleted = true
+			})
+			ts.cfg.Logger.Info("load balancer deleted", zap.String("arn", lb.ARN))
+			return nil
+		}
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe load balancer", zap.Error(err))
+		}
+		time.Sleep(10 * time.Second)
+	}
+
+	ts.cfg.Logger.Warn("load balancer not deleted by controller; deleting", zap.String("arn", lb.ARN))
+	_, err := ts.cfg.ELBV2APIV2.DeleteLoadBalancer(
+		context.Background(),
+		&aws_elbv2_v2.DeleteLoadBalancerInput{
+			LoadBalancerArn: aws_v2.String(lb.ARN),
+		},
+	)
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to delete load balancer %q (%v)", lb.ARN, err)
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		lb.Deleted = true
+	})
+	return nil
+}
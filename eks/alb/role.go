@@ -0,0 +1,248 @@
+package alb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	aws_iam "github.com/aws/aws-k8s-tester/pkg/aws/iam"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_iam_v2 "github.com/aws/aws-sdk-go-v2/service/iam"
+	smithy "github.com/aws/smithy-go"
+	"go.uber.org/zap"
+)
+
+const controllerPolicyName = "aws-load-balancer-controller"
+
+func isNotFound(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return strings.Contains(apiErr.ErrorCode(), "NotFound") || apiErr.ErrorCode() == "NoSuchEntity"
+	}
+	return false
+}
+
+func (ts *tester) createOIDCProvider() error {
+	if ts.cfg.EKSConfig.Status.ClusterOIDCIssuerURL == "" {
+		return errors.New("EKSConfig.Status.ClusterOIDCIssuerURL is empty")
+	}
+
+	ts.cfg.Logger.Info("checking existing IAM Open ID Connect provider",
+		zap.String("provider-arn", ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN),
+	)
+	_, err := ts.cfg.IAMAPIV2.GetOpenIDConnectProvider(
+		context.Background(),
+		&aws_iam_v2.GetOpenIDConnectProviderInput{
+			OpenIDConnectProviderArn: aws_v2.String(ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN),
+		},
+	)
+	if err == nil {
+		ts.cfg.Logger.Info("IAM Open ID Connect provider already exists")
+		return nil
+	}
+	if !isNotFound(err) {
+		return fmt.Errorf("failed to get IAM Open ID Connect provider (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("creating IAM Open ID Connect provider")
+	out, err := ts.cfg.IAMAPIV2.CreateOpenIDConnectProvider(
+		context.Background(),
+		&aws_iam_v2.CreateOpenIDConnectProviderInput{
+			Url:            aws_v2.String(ts.cfg.EKSConfig.Status.ClusterOIDCIssuerURL),
+			ThumbprintList: []string{ts.cfg.EKSConfig.Status.ClusterOIDCIssuerCAThumbprint},
+			ClientIDList:   []string{"sts.amazonaws.com"},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN = aws_v2.ToString(out.OpenIDConnectProviderArn)
+		ts.cfg.EKSConfig.AddOnALB.OIDCProviderCreated = true
+	})
+	ts.cfg.Logger.Info("created IAM Open ID Connect provider", zap.String("provider-arn", ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN))
+	return nil
+}
+
+func (ts *tester) deleteOIDCProvider() error {
+	if !ts.cfg.EKSConfig.AddOnALB.OIDCProviderCreated {
+		ts.cfg.Logger.Info("IAM Open ID Connect provider not created by ALB tester; skipping deletion")
+		return nil
+	}
+
+	ts.cfg.Logger.Info("deleting IAM Open ID Connect provider",
+		zap.String("provider-arn", ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN),
+	)
+	_, err := ts.cfg.IAMAPIV2.DeleteOpenIDConnectProvider(
+		context.Background(),
+		&aws_iam_v2.DeleteOpenIDConnectProviderInput{
+			OpenIDConnectProviderArn: aws_v2.String(ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN),
+		},
+	)
+	if err != nil && !isNotFound(err) {
+		ts.cfg.Logger.Warn("failed to delete IAM Open ID Connect provider", zap.Error(err))
+		return err
+	}
+
+	ts.cfg.Logger.Info("deleted IAM Open ID Connect provider")
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnALB.OIDCProviderCreated = false
+	})
+	return nil
+}
+
+func (ts *tester) createRole() error {
+	cur := ts.cfg.EKSConfig.AddOnALB
+	if cur.RoleARN != "" {
+		ts.cfg.Logger.Info("controller role already created; no need to create a new one")
+		return nil
+	}
+
+	issuer := ts.cfg.EKSConfig.Status.ClusterOIDCIssuerHostPath
+	ts.cfg.Logger.Info("creating controller role", zap.String("name", cur.RoleName))
+	out, err := ts.cfg.IAMAPIV2.CreateRole(
+		context.Background(),
+		&aws_iam_v2.CreateRoleInput{
+			RoleName: aws_v2.String(cur.RoleName),
+			Path:     aws_v2.String("/"),
+			AssumeRolePolicyDocument: aws_v2.String(toJSON(aws_iam.PolicyDocument{
+				Version: "2012-10-17",
+				Statement: []aws_iam.StatementEntry{
+					{
+						Effect:    "Allow",
+						Principal: &aws_iam.PrincipalEntry{Federated: ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN},
+						Action:    []string{"sts:AssumeRoleWithWebIdentity"},
+						Condition: map[string]map[string]string{
+							"StringEquals": {
+								issuer + ":sub": "system:serviceaccount:" + cur.Namespace + ":" + serviceAccountName,
+								issuer + ":aud": "sts.amazonaws.com",
+							},
+						},
+					},
+				},
+			})),
+		},
+	)
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.RoleARN = aws_v2.ToString(out.Role.Arn)
+	})
+
+	if _, err = ts.cfg.IAMAPIV2.PutRolePolicy(
+		context.Background(),
+		&aws_iam_v2.PutRolePolicyInput{
+			RoleName:       aws_v2.String(cur.RoleName),
+			PolicyName:     aws_v2.String(controllerPolicyName),
+			PolicyDocument: aws_v2.String(toJSON(controllerPolicyDocument())),
+		},
+	); err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("created controller role", zap.String("role-arn", cur.RoleARN))
+	return nil
+}
+
+// controllerPolicyDocument returns the controller policy,
+// without the tag conditions of the upstream policy, since
+// the tester role is scoped to the test cluster lifetime.
+// ref. https://github.com/kubernetes-sigs/aws-load-balancer-controller/blob/v2.6.2/docs/install/iam_policy.json
+func controllerPolicyDocument() aws_iam.PolicyDocument {
+	return aws_iam.PolicyDocument{
+		Version: "2012-10-17",
+		Statement: []aws_iam.StatementEntry{
+			{
+				Effect:   "Allow",
+				Resource: "*",
+				Action: []string{
+					"iam:CreateServiceLinkedRole",
+					"ec2:DescribeAccountAttributes",
+					"ec2:DescribeAddresses",
+					"ec2:DescribeAvailabilityZones",
+					"ec2:DescribeInternetGateways",
+					"ec2:DescribeVpcs",
+					"ec2:DescribeVpcPeeringConnections",
+					"ec2:DescribeSubnets",
+					"ec2:DescribeSecurityGroups",
+					"ec2:DescribeInstances",
+					"ec2:DescribeNetworkInterfaces",
+					"ec2:DescribeTags",
+					"ec2:GetCoipPoolUsage",
+					"ec2:DescribeCoipPools",
+					"ec2:CreateSecurityGroup",
+					"ec2:DeleteSecurityGroup",
+					"ec2:AuthorizeSecurityGroupIngress",
+					"ec2:RevokeSecurityGroupIngress",
+					"ec2:CreateTags",
+					"ec2:DeleteTags",
+					"elasticloadbalancing:*",
+					"cognito-idp:DescribeUserPoolClient",
+					"acm:ListCertificates",
+					"acm:DescribeCertificate",
+					"iam:ListServerCertificates",
+					"iam:GetServerCertificate",
+					"waf-regional:GetWebACL",
+					"waf-regional:GetWebACLForResource",
+					"waf-regional:AssociateWebACL",
+					"waf-regional:DisassociateWebACL",
+					"wafv2:GetWebACL",
+					"wafv2:GetWebACLForResource",
+					"wafv2:AssociateWebACL",
+					"wafv2:DisassociateWebACL",
+					"shield:GetSubscriptionState",
+					"shield:DescribeProtection",
+					"shield:CreateProtection",
+					"shield:DeleteProtection",
+				},
+			},
+		},
+	}
+}
+
+func (ts *tester) deleteRole() error {
+	cur := ts.cfg.EKSConfig.AddOnALB
+	if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[cur.RoleName]; ok {
+		return nil
+	}
+	ts.cfg.Logger.Info("deleting controller role", zap.String("name", cur.RoleName))
+
+	_, err := ts.cfg.IAMAPIV2.DeleteRolePolicy(
+		context.Background(),
+		&aws_iam_v2.DeleteRolePolicyInput{
+			RoleName:   aws_v2.String(cur.RoleName),
+			PolicyName: aws_v2.String(controllerPolicyName),
+		},
+	)
+	if err != nil && !isNotFound(err) {
+		ts.cfg.Logger.Warn("failed to delete controller role policy", zap.Error(err))
+	}
+
+	_, err = ts.cfg.IAMAPIV2.DeleteRole(
+		context.Background(),
+		&aws_iam_v2.DeleteRoleInput{
+			RoleName: aws_v2.String(cur.RoleName),
+		},
+	)
+	if err != nil && !isNotFound(err) {
+		ts.cfg.Logger.Warn("failed to delete controller role", zap.Error(err))
+		return err
+	}
+
+	ts.cfg.Logger.Info("deleted controller role")
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.Status.DeletedResources[cur.RoleName] = "AddOnALB.RoleName"
+	})
+	return nil
+}
+
+func toJSON(p aws_iam.PolicyDocument) string {
+	b, err := json.Marshal(p)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
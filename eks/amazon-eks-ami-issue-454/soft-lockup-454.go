@@ -48,6 +48,21 @@ func New(cfg Config) eks_tester.Tester {
 	return &tester{cfg: cfg}
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "amazon-eks-ami-issue-454",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+			}), cfg.EKSConfig.IsEnabledAddOnAmiSoftLockupIssue454)
+		},
+	})
+}
+
 type tester struct {
 	cfg Config
 }
@@ -72,11 +87,14 @@ func (ts *tester) Create() error {
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
 	createStart := time.Now()
 
-	ts.cfg.EKSConfig.AddOnAmiSoftLockupIssue454.Created = true
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnAmiSoftLockupIssue454.Created = true
+	})
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnAmiSoftLockupIssue454.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnAmiSoftLockupIssue454.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
 	if err := k8s_client.CreateNamespace(
@@ -114,8 +132,9 @@ func (ts *tester) Delete() error {
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnAmiSoftLockupIssue454.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnAmiSoftLockupIssue454.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
 	}()
 
 	var errs []string
@@ -141,7 +160,9 @@ func (ts *tester) Delete() error {
 		return errors.New(strings.Join(errs, ", "))
 	}
 
-	ts.cfg.EKSConfig.AddOnAmiSoftLockupIssue454.Created = false
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnAmiSoftLockupIssue454.Created = false
+	})
 	return ts.cfg.EKSConfig.Sync()
 }
 
@@ -189,7 +210,7 @@ func (ts *tester) createDeployment() error {
 							Containers: []v1.Container{
 								{
 									Name:    deploymentName,
-									Image:   "centos:7",
+									Image:   ts.cfg.EKSConfig.Image("centos:7"),
 									Command: []string{"bash"},
 									Args:    []string{"-c", decompressionLoopCommand},
 								},
@@ -3,6 +3,7 @@ package appmesh
 
 import (
 	"context"
+	_ "embed"
 	"errors"
 	"fmt"
 	"io"
@@ -11,18 +12,19 @@ import (
 	"strings"
 	"time"
 
-	"github.com/aws/aws-k8s-tester/eks/helm"
+	"github.com/aws/aws-k8s-tester/eks/plan"
 	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
 	"github.com/aws/aws-k8s-tester/eksconfig"
 	"github.com/aws/aws-k8s-tester/pkg/aws/cfn"
 	aws_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
+	"github.com/aws/aws-k8s-tester/pkg/helm"
 	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
 	"github.com/aws/aws-k8s-tester/pkg/user"
 	"github.com/aws/aws-k8s-tester/version"
+	aws_cfn_v2 "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	aws_cfn_v2_types "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/cloudformation"
-	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"go.uber.org/zap"
 	v1 "k8s.io/api/apps/v1"
@@ -39,7 +41,7 @@ type Config struct {
 	EKSConfig *eksconfig.Config
 	K8SClient k8s_client.EKS
 	S3API     s3iface.S3API
-	CFNAPI    cloudformationiface.CloudFormationAPI
+	CFNAPIV2  cfn.APIV2
 }
 
 var pkgName = reflect.TypeOf(tester{}).PkgPath()
@@ -51,6 +53,23 @@ func New(cfg Config) eks_tester.Tester {
 	return &tester{cfg: cfg}
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "app-mesh",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				S3API:     cfg.S3API,
+				CFNAPIV2:  cfg.CFNAPIV2,
+			}), cfg.EKSConfig.IsEnabledAddOnAppMesh)
+		},
+	})
+}
+
 type tester struct {
 	cfg Config
 }
@@ -66,13 +85,15 @@ func (ts *tester) Create() error {
 	}
 
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
-	ts.cfg.EKSConfig.AddOnAppMesh.Created = true
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnAppMesh.Created = true
+	})
 	createStart := time.Now()
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnAppMesh.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnAppMesh.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
 	if err := ts.createPolicy(); err != nil {
@@ -112,8 +133,9 @@ func (ts *tester) Delete() error {
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnAppMesh.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnAppMesh.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
 	}()
 
 	var errs []string
@@ -167,56 +189,32 @@ func (ts *tester) Delete() error {
 		return errors.New(strings.Join(errs, ", "))
 	}
 
-	ts.cfg.EKSConfig.AddOnAppMesh.Created = false
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnAppMesh.Created = false
+	})
 	return nil
 }
 
-const templatePolicy = `
----
-AWSTemplateFormatVersion: '2010-09-09'
-Description: 'Amazon EKS AppMesh Controller policy'
-
-Parameters:
-
-  PolicyName:
-    Type: String
-    Description: The policy name for AppMesh Controller
-
-  RoleNames:
-    Type: CommaDelimitedList
-    Description: The list of node instance roles
-
-Resources:
-
-  AppMeshControllerPolicy:
-    Type: AWS::IAM::Policy
-    Metadata:
-      Comment: Minimal policy to allow worker node instance profile that allows the AppMesh Controller to make calls to AWS APIs on your behalf
-    Properties:
-      PolicyName: !Ref PolicyName
-      PolicyDocument:
-        Version: "2012-10-17"
-        Statement:
-          - Effect: Allow
-            Action:
-            - appmesh:*
-            - servicediscovery:CreateService
-            - servicediscovery:GetService
-            - servicediscovery:RegisterInstance
-            - servicediscovery:DeregisterInstance
-            - servicediscovery:ListInstances
-            - servicediscovery:ListNamespaces
-            - servicediscovery:ListServices
-            - route53:GetHealthCheck
-            - route53:CreateHealthCheck
-            - route53:UpdateHealthCheck
-            - route53:ChangeResourceRecordSets
-            - route53:DeleteHealthCheck
-            Resource: "*"
-      Roles: !Ref RoleNames
-
-`
+// templatePolicy is the CloudFormation template for the App Mesh controller policy.
+//
+//go:embed templates/policy.yaml
+var templatePolicy string
+
+// templatePolicyPath is the path of templatePolicy in the plan
+// and the template override directory.
+const templatePolicyPath = "add-on-app-mesh/policy.yaml"
+
+// Plan renders the controller policy CloudFormation template, without calling AWS APIs.
+func Plan(cfg *eksconfig.Config) ([]plan.Document, error) {
+	if !cfg.IsEnabledAddOnAppMesh() {
+		return nil, nil
+	}
+	body, err := cfn.LoadTemplate(cfg.CFNTemplateDir, templatePolicyPath, templatePolicy)
+	if err != nil {
+		return nil, err
+	}
+	return []plan.Document{plan.NewTemplate(templatePolicyPath, body)}, nil
+}
 
 func (ts *tester) createPolicy() error {
 	if ts.cfg.EKSConfig.AddOnAppMesh.PolicyCFNStackID != "" {
@@ -234,8 +232,12 @@ func (ts *tester) createPolicy() error {
 		return errors.New("roles not found from node group or managed node group")
 	}
 
+	body, err := cfn.LoadTemplate(ts.cfg.EKSConfig.CFNTemplateDir, templatePolicyPath, templatePolicy)
+	if err != nil {
+		return err
+	}
 	// grant write permission in case of overwrites
-	if err := ioutil.WriteFile(ts.cfg.EKSConfig.AddOnAppMesh.PolicyCFNStackYAMLPath, []byte(templatePolicy), 0600); err != nil {
+	if err := ioutil.WriteFile(ts.cfg.EKSConfig.AddOnAppMesh.PolicyCFNStackYAMLPath, []byte(body), 0600); err != nil {
 		return err
 	}
 	if err := aws_s3.Upload(
@@ -253,18 +255,18 @@ func (ts *tester) createPolicy() error {
 		zap.String("policy-name", policyName),
 		zap.String("policy-cfn-file-path", ts.cfg.EKSConfig.AddOnAppMesh.PolicyCFNStackYAMLPath),
 	)
-	stackOutput, err := ts.cfg.CFNAPI.CreateStack(&cloudformation.CreateStackInput{
+	stackID, err := cfn.CreateStackV2(context.Background(), ts.cfg.Logger, ts.cfg.CFNAPIV2, &aws_cfn_v2.CreateStackInput{
 		StackName:    aws.String(policyName),
-		Capabilities: aws.StringSlice([]string{"CAPABILITY_NAMED_IAM"}),
-		OnFailure:    aws.String(cloudformation.OnFailureDelete),
-		TemplateBody: aws.String(templatePolicy),
-		Tags: cfn.NewTags(map[string]string{
+		Capabilities: []aws_cfn_v2_types.Capability{aws_cfn_v2_types.CapabilityCapabilityNamedIam},
+		OnFailure:    aws_cfn_v2_types.OnFailureDelete,
+		TemplateBody: aws.String(body),
+		Tags: cfn.NewTagsV2(map[string]string{
 			"Kind":                   "aws-k8s-tester",
 			"Name":                   ts.cfg.EKSConfig.Name,
 			"aws-k8s-tester-version": version.ReleaseVersion,
 			"User":                   user.Get(),
 		}),
-		Parameters: []*cloudformation.Parameter{
+		Parameters: []aws_cfn_v2_types.Parameter{
 			{
 				ParameterKey:   aws.String("PolicyName"),
 				ParameterValue: aws.String(policyName),
@@ -278,20 +280,22 @@ func (ts *tester) createPolicy() error {
 	if err != nil {
 		return err
 	}
-	ts.cfg.EKSConfig.AddOnAppMesh.PolicyCFNStackID = aws.StringValue(stackOutput.StackId)
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnAppMesh.PolicyCFNStackID = stackID
+	})
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
-	ch := cfn.Poll(
+	ch := cfn.PollV2(
 		ctx,
 		ts.cfg.Stopc,
 		ts.cfg.Logger,
 		ts.cfg.LogWriter,
-		ts.cfg.CFNAPI,
+		ts.cfg.CFNAPIV2,
 		ts.cfg.EKSConfig.AddOnAppMesh.PolicyCFNStackID,
-		cloudformation.ResourceStatusCreateComplete,
+		aws_cfn_v2_types.StackStatusCreateComplete,
 		25*time.Second,
 		10*time.Second,
 	)
-	var st cfn.StackStatus
+	var st cfn.StackStatusV2
 	for st = range ch {
 		if st.Error != nil {
 			cancel()
@@ -323,25 +327,25 @@ func (ts *tester) deletePolicy() error {
 		zap.String("cfn-stack-id", ts.cfg.EKSConfig.AddOnAppMesh.PolicyCFNStackID),
 	)
 
-	_, err := ts.cfg.CFNAPI.DeleteStack(&cloudformation.DeleteStackInput{
+	_, err := ts.cfg.CFNAPIV2.DeleteStack(context.Background(), &aws_cfn_v2.DeleteStackInput{
 		StackName: aws.String(ts.cfg.EKSConfig.AddOnAppMesh.PolicyCFNStackID),
 	})
 	if err != nil {
 		return err
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
-	ch := cfn.Poll(
+	ch := cfn.PollV2(
 		ctx,
 		make(chan struct{}), // do not exit on stop
 		ts.cfg.Logger,
 		ts.cfg.LogWriter,
-		ts.cfg.CFNAPI,
+		ts.cfg.CFNAPIV2,
 		ts.cfg.EKSConfig.AddOnAppMesh.PolicyCFNStackID,
-		cloudformation.ResourceStatusDeleteComplete,
+		aws_cfn_v2_types.StackStatusDeleteComplete,
 		25*time.Second,
 		10*time.Second,
 	)
-	var st cfn.StackStatus
+	var st cfn.StackStatusV2
 	for st = range ch {
 		if st.Error != nil {
 			cancel()
@@ -356,7 +360,9 @@ func (ts *tester) deletePolicy() error {
 	ts.cfg.Logger.Info("app mesh controller policy",
 		zap.String("cfn-stack-id", ts.cfg.EKSConfig.AddOnAppMesh.PolicyCFNStackID),
 	)
-	ts.cfg.EKSConfig.AddOnAppMesh.PolicyCFNStackID = ""
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnAppMesh.PolicyCFNStackID = ""
+	})
 
 	ts.cfg.EKSConfig.Sync()
 	return nil
@@ -394,6 +400,7 @@ func (ts *tester) createController() error {
 		ChartRepoURL:   chartRepoURL,
 		ChartName:      chartNameController,
 		ReleaseName:    chartNameController,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
 		Values:         values,
 		QueryFunc:      nil,
 		QueryInterval:  30 * time.Second,
@@ -468,6 +475,7 @@ func (ts *tester) deleteController() (err error) {
 		Namespace:      ts.cfg.EKSConfig.AddOnAppMesh.Namespace,
 		ChartName:      chartNameController,
 		ReleaseName:    chartNameController,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
 	})
 }
 
@@ -494,6 +502,7 @@ func (ts *tester) createInjector() error {
 		ChartRepoURL:   chartRepoURL,
 		ChartName:      chartNameInjector,
 		ReleaseName:    chartNameInjector,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
 		Values:         values,
 		QueryFunc:      nil,
 		QueryInterval:  30 * time.Second,
@@ -608,6 +617,7 @@ func (ts *tester) deleteInjector() (err error) {
 		Namespace:      ts.cfg.EKSConfig.AddOnAppMesh.Namespace,
 		ChartName:      chartNameInjector,
 		ReleaseName:    chartNameInjector,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
 	})
 }
 
@@ -0,0 +1,41 @@
+package eks
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// startReadyzSampler samples kube-apiserver "/readyz" in the background
+// with the current client, and returns the function to stop the sampling
+// and record the availability in the status.
+func (ts *Tester) startReadyzSampler() (stop func()) {
+	if ts.k8sClient == nil || ts.cfg.ClientReadyzInterval < 0 {
+		return func() {}
+	}
+	cli := ts.k8sClient
+	stopc := make(chan struct{})
+	donec := make(chan struct{})
+	go func() {
+		defer close(donec)
+		cli.SampleReadyz(stopc, ts.cfg.ClientReadyzInterval)
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stopc)
+			<-donec
+			av := cli.Availability()
+			ts.cfg.Status.APIServerAvailability = &av
+			ts.cfg.Sync()
+			ts.lg.Info("recorded kube-apiserver availability",
+				zap.Int64("samples", av.Samples),
+				zap.Float64("availability-percent", av.AvailabilityPercent),
+				zap.String("longest-outage", av.LongestOutageString),
+				zap.Int64("throttled-requests", av.ThrottledRequests),
+				zap.Int64("throttled-requests-exhausted", av.ThrottledRequestsExhausted),
+			)
+		})
+	}
+}
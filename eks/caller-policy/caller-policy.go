@@ -0,0 +1,849 @@
+// Package callerpolicy composes the IAM policy document required by the
+// caller of "aws-k8s-tester eks" from the features enabled in the
+// configuration, so that the tester can run with a scoped role instead
+// of an administrator.
+package callerpolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_iam "github.com/aws/aws-k8s-tester/pkg/aws/iam"
+)
+
+// group is the set of statements required by a feature.
+type group struct {
+	name string
+	// enabled returns true if the feature is enabled.
+	// nil to always include the statements.
+	enabled    func(cfg *eksconfig.Config) bool
+	statements func(cfg *eksconfig.Config) []aws_iam.StatementEntry
+}
+
+var groups = []group{
+	{name: "cluster", statements: clusterStatements},
+	{
+		name: "s3-bucket",
+		enabled: func(cfg *eksconfig.Config) bool {
+			return cfg.S3.BucketCreate
+		},
+		statements: s3BucketStatements,
+	},
+	{
+		name:       "roles",
+		enabled:    createsRoles,
+		statements: roleStatements,
+	},
+	{
+		name: "vpc",
+		enabled: func(cfg *eksconfig.Config) bool {
+			return cfg.VPC.Create
+		},
+		statements: vpcStatements,
+	},
+	{
+		name: "encryption",
+		enabled: func(cfg *eksconfig.Config) bool {
+			return cfg.Encryption.CMKCreate || cfg.Encryption.CMKARN != ""
+		},
+		statements: encryptionStatements,
+	},
+	{
+		name: "endpoint-tunnel",
+		enabled: func(cfg *eksconfig.Config) bool {
+			return cfg.Endpoint != nil && cfg.Endpoint.IsPrivateOnly()
+		},
+		statements: instanceStatements,
+	},
+	{
+		name:       "node-groups",
+		enabled:    (*eksconfig.Config).IsEnabledAddOnNodeGroups,
+		statements: nodeGroupStatements,
+	},
+	{
+		name:       "managed-node-groups",
+		enabled:    (*eksconfig.Config).IsEnabledAddOnManagedNodeGroups,
+		statements: managedNodeGroupStatements,
+	},
+	{
+		name:       "fargate",
+		enabled:    (*eksconfig.Config).IsEnabledAddOnFargate,
+		statements: fargateStatements,
+	},
+	{
+		name: "cloudformation",
+		enabled: func(cfg *eksconfig.Config) bool {
+			return cfg.IsEnabledAddOnFargate() ||
+				cfg.IsEnabledAddOnIRSA() ||
+				cfg.IsEnabledAddOnIRSAFargate() ||
+				cfg.IsEnabledAddOnAppMesh()
+		},
+		statements: cloudFormationStatements,
+	},
+	{
+		name: "oidc-provider",
+		enabled: func(cfg *eksconfig.Config) bool {
+			return cfg.IsEnabledAddOnIRSA() ||
+				cfg.IsEnabledAddOnIRSAFargate() ||
+				cfg.IsEnabledAddOnALB() ||
+				cfg.IsEnabledAddOnCSIEFS() ||
+				cfg.IsEnabledAddOnKarpenter()
+		},
+		statements: oidcProviderStatements,
+	},
+	{
+		name: "access-entries",
+		enabled: func(cfg *eksconfig.Config) bool {
+			if cfg.Access != nil && cfg.Access.Mode == eksconfig.AccessModeAWSAuth {
+				return false
+			}
+			return (cfg.Access != nil && len(cfg.Access.Entries) > 0) ||
+				cfg.IsEnabledAddOnNodeGroups() ||
+				cfg.IsEnabledAddOnKarpenter()
+		},
+		statements: accessEntryStatements,
+	},
+	{
+		name: "access-verify",
+		enabled: func(cfg *eksconfig.Config) bool {
+			if cfg.Access == nil {
+				return false
+			}
+			for _, e := range cfg.Access.Entries {
+				if e.Verify {
+					return true
+				}
+			}
+			return false
+		},
+		statements: accessVerifyStatements,
+	},
+	{
+		name:       "managed-add-ons",
+		enabled:    (*eksconfig.Config).IsEnabledAddOnManagedAddOns,
+		statements: managedAddOnStatements,
+	},
+	{
+		name:       "csi-efs",
+		enabled:    (*eksconfig.Config).IsEnabledAddOnCSIEFS,
+		statements: efsStatements,
+	},
+	{
+		name:       "fsx-lustre",
+		enabled:    (*eksconfig.Config).IsEnabledAddOnFSxLustre,
+		statements: fsxStatements,
+	},
+	{
+		name:       "oidc-identity-provider",
+		enabled:    (*eksconfig.Config).IsEnabledAddOnOIDCIdentityProvider,
+		statements: oidcIdentityProviderStatements,
+	},
+	{
+		name:       "pod-identity",
+		enabled:    (*eksconfig.Config).IsEnabledAddOnPodIdentity,
+		statements: podIdentityStatements,
+	},
+	{
+		name:       "spot-interruption",
+		enabled:    (*eksconfig.Config).IsEnabledAddOnSpotInterruption,
+		statements: fisStatements,
+	},
+	{
+		name:       "chaos",
+		enabled:    (*eksconfig.Config).IsEnabledAddOnChaos,
+		statements: chaosStatements,
+	},
+	{
+		name: "node-fault",
+		enabled: func(cfg *eksconfig.Config) bool {
+			return cfg.IsEnabledAddOnNodeFault() && cfg.AddOnNodeFault.Transport == eksconfig.NodeFaultTransportSSM
+		},
+		statements: nodeFaultStatements,
+	},
+	{
+		name:       "ecr",
+		enabled:    (*eksconfig.Config).IsEnabledAddOnECR,
+		statements: ecrStatements,
+	},
+	{
+		name: "image-mirror",
+		enabled: func(cfg *eksconfig.Config) bool {
+			return cfg.ImageMirror != nil && cfg.ImageMirror.Enable
+		},
+		statements: ecrStatements,
+	},
+	{
+		name: "custom-manifests",
+		enabled: func(cfg *eksconfig.Config) bool {
+			return len(customManifestsS3Objects(cfg)) > 0
+		},
+		statements: customManifestsStatements,
+	},
+	{
+		name: "hooks",
+		enabled: func(cfg *eksconfig.Config) bool {
+			return cfg.Hooks.HasNodeScript(eksconfig.NodeTransportSSM)
+		},
+		statements: nodeFaultStatements,
+	},
+	{
+		name: "notifications",
+		enabled: func(cfg *eksconfig.Config) bool {
+			return cfg.Notifications != nil && cfg.Notifications.SNSTopicARN != ""
+		},
+		statements: snsStatements,
+	},
+}
+
+// createsRoles returns true if the tester creates any IAM role.
+func createsRoles(cfg *eksconfig.Config) bool {
+	if cfg.Role != nil && cfg.Role.Create {
+		return true
+	}
+	if cfg.IsEnabledAddOnNodeGroups() && cfg.AddOnNodeGroups.Role != nil && cfg.AddOnNodeGroups.Role.Create {
+		return true
+	}
+	if cfg.IsEnabledAddOnManagedNodeGroups() && cfg.AddOnManagedNodeGroups.Role != nil && cfg.AddOnManagedNodeGroups.Role.Create {
+		return true
+	}
+	// the add-on roles are always created by the tester
+	return (cfg.Endpoint != nil && cfg.Endpoint.IsPrivateOnly()) ||
+		cfg.IsEnabledAddOnFargate() ||
+		cfg.IsEnabledAddOnIRSA() ||
+		cfg.IsEnabledAddOnIRSAFargate() ||
+		cfg.IsEnabledAddOnAppMesh() ||
+		cfg.IsEnabledAddOnALB() ||
+		cfg.IsEnabledAddOnCSIEFS() ||
+		cfg.IsEnabledAddOnKarpenter() ||
+		cfg.IsEnabledAddOnSpotInterruption() ||
+		cfg.IsEnabledAddOnPodIdentity() ||
+		(cfg.IsEnabledAddOnChaos() && cfg.AddOnChaos.IsFIS())
+}
+
+// Groups returns the names of the statement groups in the policy.
+func Groups(cfg *eksconfig.Config) (names []string) {
+	for _, g := range groups {
+		if g.enabled == nil || g.enabled(cfg) {
+			names = append(names, g.name)
+		}
+	}
+	return names
+}
+
+// Statements returns the policy statements required by the enabled features.
+func Statements(cfg *eksconfig.Config) (ss []aws_iam.StatementEntry) {
+	for _, g := range groups {
+		if g.enabled == nil || g.enabled(cfg) {
+			ss = append(ss, g.statements(cfg)...)
+		}
+	}
+	return ss
+}
+
+// Document returns the caller policy document in indented JSON.
+func Document(cfg *eksconfig.Config) string {
+	p := aws_iam.PolicyDocument{
+		Version:   "2012-10-17",
+		Statement: Statements(cfg),
+	}
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+// clusterStatements are required by every run: the cluster lifecycle,
+// the availability checks on start, the artifact uploads, the leak check,
+// and the cost summary.
+func clusterStatements(cfg *eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"sts:GetCallerIdentity",
+				"eks:CreateCluster",
+				"eks:DeleteCluster",
+				"eks:DescribeCluster",
+				"eks:DescribeUpdate",
+				"eks:ListClusters",
+				"eks:ListUpdates",
+				"eks:TagResource",
+				"eks:UpdateClusterConfig",
+				"eks:UpdateClusterVersion",
+			},
+		},
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"ec2:Describe*",
+				"ecr:DescribeRepositories",
+				"ssm:GetParameter",
+				"ssm:GetParameters",
+				"servicequotas:GetServiceQuota",
+				"tag:GetResources",
+				"pricing:GetProducts",
+			},
+		},
+		{ // to check and clean up the leaked load balancers on VPC deletion
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"elasticloadbalancing:DeleteLoadBalancer",
+				"elasticloadbalancing:DeleteTargetGroup",
+				"elasticloadbalancing:DescribeLoadBalancers",
+				"elasticloadbalancing:DescribeTargetGroups",
+			},
+		},
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"cloudwatch:GetMetricWidgetImage",
+				"cloudwatch:ListMetrics",
+				"cloudwatch:PutMetricData",
+				"logs:DeleteLogGroup",
+				"logs:DescribeLogGroups",
+				"logs:FilterLogEvents",
+				"logs:ListTagsLogGroup",
+			},
+		},
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"iam:GetRole",
+				"iam:ListAttachedRolePolicies",
+			},
+		},
+		{
+			Effect:   "Allow",
+			Resource: fmt.Sprintf("arn:%s:s3:::%s", cfg.Partition, cfg.S3.BucketName),
+			Action: []string{
+				"s3:ListBucket",
+			},
+		},
+		{
+			Effect:   "Allow",
+			Resource: fmt.Sprintf("arn:%s:s3:::%s/*", cfg.Partition, cfg.S3.BucketName),
+			Action: []string{
+				"s3:DeleteObject",
+				"s3:GetObject",
+				"s3:PutObject",
+			},
+		},
+	}
+}
+
+func s3BucketStatements(cfg *eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: fmt.Sprintf("arn:%s:s3:::%s", cfg.Partition, cfg.S3.BucketName),
+			Action: []string{
+				"s3:CreateBucket",
+				"s3:DeleteBucket",
+				"s3:PutBucketAcl",
+				"s3:PutBucketPolicy",
+				"s3:PutBucketPublicAccessBlock",
+				"s3:PutBucketTagging",
+				"s3:PutLifecycleConfiguration",
+			},
+		},
+	}
+}
+
+// roleStatements grant the role lifecycle. "iam:PassRole" is required to
+// hand the cluster, node, and add-on roles to the AWS services.
+func roleStatements(cfg *eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"iam:AttachRolePolicy",
+				"iam:CreatePolicy",
+				"iam:CreateRole",
+				"iam:CreateServiceLinkedRole",
+				"iam:DeletePolicy",
+				"iam:DeletePolicyVersion",
+				"iam:DeleteRole",
+				"iam:DeleteRolePolicy",
+				"iam:DetachRolePolicy",
+				"iam:ListInstanceProfilesForRole",
+				"iam:ListPolicies",
+				"iam:ListPolicyVersions",
+				"iam:ListRolePolicies",
+				"iam:ListRoles",
+				"iam:PutRolePermissionsBoundary",
+				"iam:PutRolePolicy",
+				"iam:TagRole",
+			},
+		},
+		{
+			Effect:   "Allow",
+			Resource: fmt.Sprintf("arn:%s:iam::*:role/*", cfg.Partition),
+			Action: []string{
+				"iam:PassRole",
+			},
+		},
+	}
+}
+
+func vpcStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"ec2:AllocateAddress",
+				"ec2:AssociateDhcpOptions",
+				"ec2:AssociateRouteTable",
+				"ec2:AssociateSubnetCidrBlock",
+				"ec2:AssociateVpcCidrBlock",
+				"ec2:AttachInternetGateway",
+				"ec2:AuthorizeSecurityGroupEgress",
+				"ec2:AuthorizeSecurityGroupIngress",
+				"ec2:CreateDhcpOptions",
+				"ec2:CreateEgressOnlyInternetGateway",
+				"ec2:CreateInternetGateway",
+				"ec2:CreateNatGateway",
+				"ec2:CreateRoute",
+				"ec2:CreateRouteTable",
+				"ec2:CreateSecurityGroup",
+				"ec2:CreateSubnet",
+				"ec2:CreateTags",
+				"ec2:CreateVpc",
+				"ec2:CreateVpcEndpoint",
+				"ec2:DeleteDhcpOptions",
+				"ec2:DeleteEgressOnlyInternetGateway",
+				"ec2:DeleteInternetGateway",
+				"ec2:DeleteNatGateway",
+				"ec2:DeleteNetworkInterface",
+				"ec2:DeleteRoute",
+				"ec2:DeleteRouteTable",
+				"ec2:DeleteSecurityGroup",
+				"ec2:DeleteSubnet",
+				"ec2:DeleteVpc",
+				"ec2:DeleteVpcEndpoints",
+				"ec2:DetachInternetGateway",
+				"ec2:DisassociateRouteTable",
+				"ec2:ModifySubnetAttribute",
+				"ec2:ModifyVpcAttribute",
+				"ec2:ReleaseAddress",
+				"ec2:RevokeSecurityGroupEgress",
+				"ec2:RevokeSecurityGroupIngress",
+			},
+		},
+	}
+}
+
+func encryptionStatements(cfg *eksconfig.Config) []aws_iam.StatementEntry {
+	actions := []string{
+		"kms:CreateGrant",
+		"kms:DescribeKey",
+	}
+	if cfg.Encryption.CMKCreate {
+		actions = append(actions,
+			"kms:CreateKey",
+			"kms:ScheduleKeyDeletion",
+			"kms:TagResource",
+		)
+	}
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action:   actions,
+		},
+	}
+}
+
+// instanceStatements grant the in-VPC runner instance lifecycle
+// for the private-only cluster endpoint.
+func instanceStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"ec2:CreateTags",
+				"ec2:RunInstances",
+				"ec2:TerminateInstances",
+				"iam:AddRoleToInstanceProfile",
+				"iam:CreateInstanceProfile",
+				"iam:DeleteInstanceProfile",
+				"iam:RemoveRoleFromInstanceProfile",
+			},
+		},
+	}
+}
+
+func nodeGroupStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"autoscaling:CreateAutoScalingGroup",
+				"autoscaling:DeleteAutoScalingGroup",
+				"autoscaling:DescribeAutoScalingGroups",
+				"autoscaling:UpdateAutoScalingGroup",
+				"ec2:CreateLaunchTemplate",
+				"ec2:CreateTags",
+				"ec2:DeleteLaunchTemplate",
+				"ec2:RunInstances",
+				"ec2:TerminateInstances",
+				"iam:AddRoleToInstanceProfile",
+				"iam:CreateInstanceProfile",
+				"iam:DeleteInstanceProfile",
+				"iam:RemoveRoleFromInstanceProfile",
+			},
+		},
+		{ // to run the SSM documents on the nodes
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"ssm:CreateDocument",
+				"ssm:DeleteDocument",
+				"ssm:DescribeInstanceInformation",
+				"ssm:ListCommandInvocations",
+				"ssm:SendCommand",
+			},
+		},
+	}
+}
+
+func managedNodeGroupStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"eks:CreateNodegroup",
+				"eks:DeleteNodegroup",
+				"eks:DescribeNodegroup",
+				"eks:ListNodegroups",
+				"eks:UpdateNodegroupConfig",
+				"eks:UpdateNodegroupVersion",
+				"autoscaling:DescribeAutoScalingGroups",
+				"autoscaling:UpdateAutoScalingGroup",
+				"ec2:AuthorizeSecurityGroupIngress",
+				"ec2:CreateLaunchTemplate",
+				"ec2:CreateSecurityGroup",
+				"ec2:CreateTags",
+				"ec2:DeleteLaunchTemplate",
+				"ec2:DeleteSecurityGroup",
+				"ec2:RunInstances",
+			},
+		},
+	}
+}
+
+func fargateStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"eks:CreateFargateProfile",
+				"eks:DeleteFargateProfile",
+				"eks:DescribeFargateProfile",
+				"eks:ListFargateProfiles",
+			},
+		},
+	}
+}
+
+// cloudFormationStatements grant the add-on stacks, and the drift
+// detection after "Up".
+func cloudFormationStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"cloudformation:CreateStack",
+				"cloudformation:DeleteStack",
+				"cloudformation:DescribeStackDriftDetectionStatus",
+				"cloudformation:DescribeStackEvents",
+				"cloudformation:DescribeStackResourceDrifts",
+				"cloudformation:DescribeStacks",
+				"cloudformation:DetectStackDrift",
+				"cloudformation:DetectStackResourceDrift",
+			},
+		},
+	}
+}
+
+func oidcProviderStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"iam:CreateOpenIDConnectProvider",
+				"iam:DeleteOpenIDConnectProvider",
+				"iam:GetOpenIDConnectProvider",
+			},
+		},
+	}
+}
+
+func accessEntryStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"eks:AssociateAccessPolicy",
+				"eks:CreateAccessEntry",
+				"eks:DeleteAccessEntry",
+			},
+		},
+	}
+}
+
+// accessVerifyStatements grant assuming the mapped roles
+// to list the nodes as the principals.
+func accessVerifyStatements(cfg *eksconfig.Config) []aws_iam.StatementEntry {
+	names := make([]string, 0, len(cfg.Access.Entries))
+	for name := range cfg.Access.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ss := make([]aws_iam.StatementEntry, 0, len(names))
+	for _, name := range names {
+		e := cfg.Access.Entries[name]
+		if !e.Verify {
+			continue
+		}
+		ss = append(ss, aws_iam.StatementEntry{
+			Effect:   "Allow",
+			Resource: e.PrincipalARN,
+			Action: []string{
+				"sts:AssumeRole",
+			},
+		})
+	}
+	return ss
+}
+
+func managedAddOnStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"eks:CreateAddon",
+				"eks:DeleteAddon",
+				"eks:DescribeAddon",
+				"eks:DescribeAddonVersions",
+				"eks:UpdateAddon",
+			},
+		},
+	}
+}
+
+func efsStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"elasticfilesystem:CreateFileSystem",
+				"elasticfilesystem:CreateMountTarget",
+				"elasticfilesystem:DeleteFileSystem",
+				"elasticfilesystem:DeleteMountTarget",
+				"elasticfilesystem:DescribeAccessPoints",
+				"elasticfilesystem:DescribeFileSystems",
+				"elasticfilesystem:DescribeMountTargets",
+			},
+		},
+	}
+}
+
+func fsxStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"fsx:CreateFileSystem",
+				"fsx:DeleteFileSystem",
+				"fsx:DescribeFileSystems",
+				"iam:CreateServiceLinkedRole",
+			},
+		},
+	}
+}
+
+func oidcIdentityProviderStatements(cfg *eksconfig.Config) []aws_iam.StatementEntry {
+	ss := []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"eks:AssociateIdentityProviderConfig",
+				"eks:DescribeIdentityProviderConfig",
+				"eks:DisassociateIdentityProviderConfig",
+				"eks:TagResource",
+			},
+		},
+	}
+	if cfg.AddOnOIDCIdentityProvider.IsCognito() {
+		ss = append(ss, aws_iam.StatementEntry{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"cognito-idp:AdminAddUserToGroup",
+				"cognito-idp:AdminCreateUser",
+				"cognito-idp:AdminInitiateAuth",
+				"cognito-idp:AdminSetUserPassword",
+				"cognito-idp:CreateGroup",
+				"cognito-idp:CreateUserPool",
+				"cognito-idp:CreateUserPoolClient",
+				"cognito-idp:DeleteUserPool",
+				"cognito-idp:TagResource",
+			},
+		})
+	}
+	return ss
+}
+
+func podIdentityStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"eks:CreateAddon",
+				"eks:CreatePodIdentityAssociation",
+				"eks:DeleteAddon",
+				"eks:DeletePodIdentityAssociation",
+				"eks:DescribeAddon",
+				"eks:TagResource",
+			},
+		},
+	}
+}
+
+func fisStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"fis:CreateExperimentTemplate",
+				"fis:DeleteExperimentTemplate",
+				"fis:GetExperiment",
+				"fis:StartExperiment",
+				"fis:TagResource",
+			},
+		},
+	}
+}
+
+func chaosStatements(cfg *eksconfig.Config) []aws_iam.StatementEntry {
+	switch {
+	case cfg.AddOnChaos.IsFIS():
+		ss := fisStatements(cfg)
+		ss[0].Action = append(ss[0].Action, "fis:StopExperiment")
+		return ss
+	case cfg.AddOnChaos.IsNetem():
+		if cfg.AddOnChaos.Transport != eksconfig.NodeTransportSSM {
+			// "tc" commands over SSH need no AWS permissions
+			return nil
+		}
+		return nodeFaultStatements(cfg)
+	}
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"ec2:DescribeInstances",
+				"ec2:TerminateInstances",
+			},
+		},
+	}
+}
+
+func nodeFaultStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{ // to run the commands on the nodes with SSM Run Command
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"ssm:GetCommandInvocation",
+				"ssm:SendCommand",
+			},
+		},
+	}
+}
+
+func ecrStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{ // to create the repository, and push and check the images
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"ecr:BatchCheckLayerAvailability",
+				"ecr:BatchGetImage",
+				"ecr:CompleteLayerUpload",
+				"ecr:CreateRepository",
+				"ecr:DeleteRepository",
+				"ecr:DescribeImages",
+				"ecr:GetAuthorizationToken",
+				"ecr:GetDownloadUrlForLayer",
+				"ecr:InitiateLayerUpload",
+				"ecr:PutImage",
+				"ecr:SetRepositoryPolicy",
+				"ecr:TagResource",
+				"ecr:UploadLayerPart",
+			},
+		},
+	}
+}
+
+// customManifestsS3Objects returns the ARNs of the S3 manifest sources.
+func customManifestsS3Objects(cfg *eksconfig.Config) (arns []string) {
+	if !cfg.IsEnabledAddOnCustomManifests() {
+		return nil
+	}
+	for _, src := range cfg.AddOnCustomManifests.Sources {
+		if bucket, key, err := eksconfig.ParseS3URL(src); err == nil {
+			arns = append(arns, fmt.Sprintf("arn:%s:s3:::%s/%s", cfg.Partition, bucket, key))
+		}
+	}
+	return arns
+}
+
+func customManifestsStatements(cfg *eksconfig.Config) (ss []aws_iam.StatementEntry) {
+	for _, arn := range customManifestsS3Objects(cfg) {
+		ss = append(ss, aws_iam.StatementEntry{
+			Effect:   "Allow",
+			Resource: arn,
+			Action: []string{
+				"s3:GetObject",
+			},
+		})
+	}
+	return ss
+}
+
+func snsStatements(cfg *eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: cfg.Notifications.SNSTopicARN,
+			Action: []string{
+				"sns:Publish",
+			},
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package callerpolicy
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_iam "github.com/aws/aws-k8s-tester/pkg/aws/iam"
+)
+
+func TestGroups(t *testing.T) {
+	cfg := eksconfig.NewDefault()
+	cfg.AddOnNodeGroups.Enable = false
+	cfg.AddOnManagedNodeGroups.Enable = false
+	cfg.Role.Create = false
+	cfg.VPC.Create = false
+	cfg.S3.BucketCreate = false
+	cfg.Encryption.CMKCreate = false
+	if got := Groups(cfg); !reflect.DeepEqual(got, []string{"cluster"}) {
+		t.Fatalf("unexpected groups %q", got)
+	}
+
+	cfg.AddOnIRSA = &eksconfig.AddOnIRSA{Enable: true}
+	cfg.Notifications.SNSTopicARN = "arn:aws:sns:us-west-2:123:topic"
+	exp := []string{"cluster", "roles", "cloudformation", "oidc-provider", "notifications"}
+	if got := Groups(cfg); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected %q, got %q", exp, got)
+	}
+}
+
+func TestDocument(t *testing.T) {
+	cfg := eksconfig.NewDefault()
+	cfg.S3.BucketName = "my-bucket"
+	cfg.AddOnManagedNodeGroups.Enable = true
+
+	doc := Document(cfg)
+	var p aws_iam.PolicyDocument
+	if err := json.Unmarshal([]byte(doc), &p); err != nil {
+		t.Fatal(err)
+	}
+	for _, action := range []string{"eks:CreateCluster", "ec2:CreateVpc", "kms:CreateKey", "iam:PassRole", "eks:CreateNodegroup"} {
+		if !strings.Contains(doc, action) {
+			t.Fatalf("expected %q in the policy: %s", action, doc)
+		}
+	}
+	for _, action := range []string{"fis:StartExperiment", "cloudformation:CreateStack", "sns:Publish", `"*:*"`} {
+		if strings.Contains(doc, action) {
+			t.Fatalf("policy unexpectedly has %q: %s", action, doc)
+		}
+	}
+	if !strings.Contains(doc, "arn:aws:s3:::my-bucket/*") {
+		t.Fatalf("expected the artifact bucket access: %s", doc)
+	}
+}
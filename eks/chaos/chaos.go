@@ -0,0 +1,208 @@
+// Package chaos injects the node failures while a canary workload runs,
+// by terminating the worker instances or by impairing an availability zone
+// via AWS Fault Injection Simulator (FIS), and measures the Pod rescheduling
+// time and the Service downtime after each injection. Or, degrades the node
+// network with "tc netem" and probes the Pod connectivity and throughput.
+// ref. https://docs.aws.amazon.com/fis/latest/userguide/fis-actions-reference.html
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"github.com/aws/aws-k8s-tester/ssh"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_iam_v2 "github.com/aws/aws-sdk-go-v2/service/iam"
+	aws_ssm_v2 "github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2instanceconnect/ec2instanceconnectiface"
+	"github.com/aws/aws-sdk-go/service/fis"
+	"github.com/aws/aws-sdk-go/service/fis/fisiface"
+	"go.uber.org/zap"
+)
+
+// Config defines chaos configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+
+	IAMAPIV2 *aws_iam_v2.Client
+	EC2APIV2 *aws_ec2_v2.Client
+	FISAPI   fisiface.FISAPI
+
+	SSMAPIV2              *aws_ssm_v2.Client
+	EC2InstanceConnectAPI ec2instanceconnectiface.EC2InstanceConnectAPI
+	SSHHostKeys           *ssh.HostKeys
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new chaos tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:      "chaos",
+		Exclusive: true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:                cfg.Logger,
+				LogWriter:             cfg.LogWriter,
+				Stopc:                 cfg.Stopc,
+				EKSConfig:             cfg.EKSConfig,
+				K8SClient:             cfg.K8SClient,
+				IAMAPIV2:              cfg.IAMAPIV2,
+				EC2APIV2:              cfg.EC2APIV2,
+				FISAPI:                fis.New(cfg.AWSSession, aws.NewConfig().WithRegion(cfg.EKSConfig.Region)),
+				SSMAPIV2:              cfg.SSMAPIV2,
+				EC2InstanceConnectAPI: cfg.EC2InstanceConnectAPI,
+				SSHHostKeys:           cfg.SSHHostKeys,
+			}), cfg.EKSConfig.IsEnabledAddOnChaos)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnChaos() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnChaos.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnChaos.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnChaos.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	cur := ts.cfg.EKSConfig.AddOnChaos
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		cur.Namespace,
+	); err != nil {
+		return err
+	}
+	if err = ts.createCanary(); err != nil {
+		return err
+	}
+	if err = ts.waitCanary(); err != nil {
+		return err
+	}
+	if cur.IsFIS() {
+		if err = ts.createRole(); err != nil {
+			return err
+		}
+	}
+
+	var errs []string
+	for i := 0; i < cur.Injections; i++ {
+		if i > 0 {
+			ts.cfg.Logger.Info("waiting before next injection", zap.String("interval", cur.InjectionIntervalString))
+			select {
+			case <-ts.cfg.Stopc:
+				return errors.New("chaos aborted")
+			case <-time.After(cur.InjectionInterval):
+			}
+		}
+		rs, err := ts.inject()
+		if err != nil {
+			return err
+		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.Results = append(cur.Results, rs)
+		})
+		if cur.IsNetem() {
+			fmt.Fprintf(ts.cfg.LogWriter, "\nchaos injection %d/%d (%s %s): %s\n",
+				i+1, cur.Injections, cur.Mode, rs.Target, probeSummary(rs))
+		} else {
+			fmt.Fprintf(ts.cfg.LogWriter, "\nchaos injection %d/%d (%s %s): reschedule time %s, service downtime %s\n",
+				i+1, cur.Injections, cur.Mode, rs.Target, rs.RescheduleTimeString, rs.ServiceDowntimeString)
+		}
+		if rs.Error != "" {
+			errs = append(errs, rs.Error)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnChaos() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnChaos.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnChaos.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+	if ts.cfg.EKSConfig.AddOnChaos.IsFIS() {
+		if err := ts.deleteExperimentTemplate(); err != nil {
+			errs = append(errs, err.Error())
+		}
+		if err := ts.deleteRole(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnChaos.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete chaos namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnChaos.Created = false
+	})
+	return nil
+}
@@ -0,0 +1,300 @@
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	aws_iam "github.com/aws/aws-k8s-tester/pkg/aws/iam"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_iam_v2 "github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/fis"
+	smithy "github.com/aws/smithy-go"
+	"go.uber.org/zap"
+)
+
+const (
+	rolePolicyName = "chaos"
+	// targetName is the experiment template target for the impaired subnets.
+	targetName = "Subnets"
+)
+
+func isNotFound(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return strings.Contains(apiErr.ErrorCode(), "NotFound") || apiErr.ErrorCode() == "NoSuchEntity"
+	}
+	if awsErr, ok := err.(awserr.Error); ok {
+		return awsErr.Code() == fis.ErrCodeResourceNotFoundException
+	}
+	return false
+}
+
+// createRole creates the IAM role for FIS to disrupt the subnet connectivity.
+// ref. https://docs.aws.amazon.com/fis/latest/userguide/security-iam-awsmanpol.html#AWSFaultInjectionSimulatorNetworkAccess
+func (ts *tester) createRole() error {
+	cur := ts.cfg.EKSConfig.AddOnChaos
+	if cur.RoleARN != "" {
+		ts.cfg.Logger.Info("FIS role already created; no need to create a new one")
+		return nil
+	}
+
+	ts.cfg.Logger.Info("creating FIS role", zap.String("name", cur.RoleName))
+	out, err := ts.cfg.IAMAPIV2.CreateRole(
+		context.Background(),
+		&aws_iam_v2.CreateRoleInput{
+			RoleName: aws_v2.String(cur.RoleName),
+			Path:     aws_v2.String("/"),
+			AssumeRolePolicyDocument: aws_v2.String(toJSON(aws_iam.PolicyDocument{
+				Version: "2012-10-17",
+				Statement: []aws_iam.StatementEntry{
+					{
+						Effect:    "Allow",
+						Principal: &aws_iam.PrincipalEntry{Service: []string{"fis.amazonaws.com"}},
+						Action:    []string{"sts:AssumeRole"},
+					},
+				},
+			})),
+		},
+	)
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.RoleARN = aws_v2.ToString(out.Role.Arn)
+	})
+
+	if _, err = ts.cfg.IAMAPIV2.PutRolePolicy(
+		context.Background(),
+		&aws_iam_v2.PutRolePolicyInput{
+			RoleName:   aws_v2.String(cur.RoleName),
+			PolicyName: aws_v2.String(rolePolicyName),
+			PolicyDocument: aws_v2.String(toJSON(aws_iam.PolicyDocument{
+				Version: "2012-10-17",
+				Statement: []aws_iam.StatementEntry{
+					{
+						Effect: "Allow",
+						Action: []string{
+							"ec2:CreateNetworkAcl",
+							"ec2:CreateNetworkAclEntry",
+							"ec2:CreateTags",
+							"ec2:DeleteNetworkAcl",
+							"ec2:DescribeManagedPrefixLists",
+							"ec2:DescribeNetworkAcls",
+							"ec2:DescribeSubnets",
+							"ec2:DescribeVpcs",
+							"ec2:GetManagedPrefixListEntries",
+							"ec2:ReplaceNetworkAclAssociation",
+						},
+						Resource: "*",
+					},
+				},
+			})),
+		},
+	); err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("created FIS role", zap.String("role-arn", cur.RoleARN))
+	return nil
+}
+
+func (ts *tester) deleteRole() error {
+	cur := ts.cfg.EKSConfig.AddOnChaos
+	if cur.RoleARN == "" {
+		return nil
+	}
+	if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[cur.RoleName]; ok {
+		return nil
+	}
+	ts.cfg.Logger.Info("deleting FIS role", zap.String("name", cur.RoleName))
+
+	_, err := ts.cfg.IAMAPIV2.DeleteRolePolicy(
+		context.Background(),
+		&aws_iam_v2.DeleteRolePolicyInput{
+			RoleName:   aws_v2.String(cur.RoleName),
+			PolicyName: aws_v2.String(rolePolicyName),
+		},
+	)
+	if err != nil && !isNotFound(err) {
+		ts.cfg.Logger.Warn("failed to delete FIS role policy", zap.Error(err))
+	}
+
+	_, err = ts.cfg.IAMAPIV2.DeleteRole(
+		context.Background(),
+		&aws_iam_v2.DeleteRoleInput{
+			RoleName: aws_v2.String(cur.RoleName),
+		},
+	)
+	if err != nil && !isNotFound(err) {
+		ts.cfg.Logger.Warn("failed to delete FIS role", zap.Error(err))
+		return err
+	}
+
+	ts.cfg.Logger.Info("deleted FIS role")
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.Status.DeletedResources[cur.RoleName] = "AddOnChaos.RoleName"
+	})
+	return nil
+}
+
+// createExperimentTemplate creates the experiment template to disrupt
+// the network connectivity of the VPC subnets in the availability zone.
+// ref. https://docs.aws.amazon.com/fis/latest/userguide/fis-actions-reference.html#network-actions-reference
+func (ts *tester) createExperimentTemplate() error {
+	cur := ts.cfg.EKSConfig.AddOnChaos
+	if cur.ExperimentTemplateID != "" {
+		ts.cfg.Logger.Info("FIS experiment template already created; no need to create a new one")
+		return nil
+	}
+
+	var subnetARNs []string
+	for _, id := range append(ts.cfg.EKSConfig.VPC.PublicSubnetIDs, ts.cfg.EKSConfig.VPC.PrivateSubnetIDs...) {
+		subnetARNs = append(subnetARNs, fmt.Sprintf("arn:%s:ec2:%s:%s:subnet/%s",
+			ts.cfg.EKSConfig.Partition,
+			ts.cfg.EKSConfig.Region,
+			ts.cfg.EKSConfig.Status.AWSAccountID,
+			id,
+		))
+	}
+	if len(subnetARNs) == 0 {
+		return errors.New("no VPC subnet found")
+	}
+
+	ts.cfg.Logger.Info("creating FIS experiment template",
+		zap.String("availability-zone", cur.AvailabilityZone),
+		zap.Strings("subnet-arns", subnetARNs),
+	)
+	out, err := ts.cfg.FISAPI.CreateExperimentTemplate(&fis.CreateExperimentTemplateInput{
+		Description: aws.String(fmt.Sprintf("aws-k8s-tester AZ impairment for %s", ts.cfg.EKSConfig.Name)),
+		RoleArn:     aws.String(cur.RoleARN),
+		Targets: map[string]*fis.CreateExperimentTemplateTargetInput{
+			targetName: {
+				ResourceType: aws.String("aws:ec2:subnet"),
+				ResourceArns: aws.StringSlice(subnetARNs),
+				Filters: []*fis.ExperimentTemplateTargetInputFilter{
+					{
+						Path:   aws.String("AvailabilityZone"),
+						Values: aws.StringSlice([]string{cur.AvailabilityZone}),
+					},
+				},
+				SelectionMode: aws.String("ALL"),
+			},
+		},
+		Actions: map[string]*fis.CreateExperimentTemplateActionInput{
+			"disrupt": {
+				ActionId: aws.String("aws:network:disrupt-connectivity"),
+				Parameters: map[string]*string{
+					// ISO 8601 duration
+					"duration": aws.String(fmt.Sprintf("PT%dS", int(cur.ImpairmentDuration.Seconds()))),
+					"scope":    aws.String("all"),
+				},
+				Targets: map[string]*string{
+					targetName: aws.String(targetName),
+				},
+			},
+		},
+		StopConditions: []*fis.CreateExperimentTemplateStopConditionInput{
+			{Source: aws.String("none")},
+		},
+		Tags: map[string]*string{
+			"Name": aws.String(ts.cfg.EKSConfig.Name),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create FIS experiment template (%v)", err)
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.ExperimentTemplateID = aws.StringValue(out.ExperimentTemplate.Id)
+	})
+
+	ts.cfg.Logger.Info("created FIS experiment template", zap.String("experiment-template-id", cur.ExperimentTemplateID))
+	return nil
+}
+
+func (ts *tester) deleteExperimentTemplate() error {
+	cur := ts.cfg.EKSConfig.AddOnChaos
+	if cur.ExperimentTemplateID == "" {
+		return nil
+	}
+	if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[cur.ExperimentTemplateID]; ok {
+		return nil
+	}
+
+	ts.cfg.Logger.Info("deleting FIS experiment template", zap.String("experiment-template-id", cur.ExperimentTemplateID))
+	_, err := ts.cfg.FISAPI.DeleteExperimentTemplate(&fis.DeleteExperimentTemplateInput{
+		Id: aws.String(cur.ExperimentTemplateID),
+	})
+	if err != nil && !isNotFound(err) {
+		ts.cfg.Logger.Warn("failed to delete FIS experiment template", zap.Error(err))
+		return err
+	}
+
+	ts.cfg.Logger.Info("deleted FIS experiment template")
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.Status.DeletedResources[cur.ExperimentTemplateID] = "AddOnChaos.ExperimentTemplateID"
+	})
+	return nil
+}
+
+func (ts *tester) startExperiment() (string, error) {
+	out, err := ts.cfg.FISAPI.StartExperiment(&fis.StartExperimentInput{
+		ExperimentTemplateId: aws.String(ts.cfg.EKSConfig.AddOnChaos.ExperimentTemplateID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to start FIS experiment (%v)", err)
+	}
+	id := aws.StringValue(out.Experiment.Id)
+	ts.cfg.Logger.Info("started FIS experiment",
+		zap.String("experiment-id", id),
+		zap.String("availability-zone", ts.cfg.EKSConfig.AddOnChaos.AvailabilityZone),
+		zap.String("duration", ts.cfg.EKSConfig.AddOnChaos.ImpairmentDurationString),
+	)
+	return id, nil
+}
+
+// waitExperiment waits until the experiment ends, so that the next
+// injection starts with the availability zone restored.
+// Returns an error if the experiment failed or was stopped.
+func (ts *tester) waitExperiment(id string, timeout time.Duration) error {
+	ts.cfg.Logger.Info("waiting for FIS experiment", zap.String("experiment-id", id))
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("FIS experiment wait aborted")
+		case <-time.After(10 * time.Second):
+		}
+		out, err := ts.cfg.FISAPI.GetExperiment(&fis.GetExperimentInput{Id: aws.String(id)})
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get FIS experiment", zap.Error(err))
+			continue
+		}
+		if out.Experiment == nil || out.Experiment.State == nil {
+			continue
+		}
+		switch status := aws.StringValue(out.Experiment.State.Status); status {
+		case fis.ExperimentStatusCompleted:
+			ts.cfg.Logger.Info("FIS experiment completed", zap.String("experiment-id", id))
+			return nil
+		case fis.ExperimentStatusFailed, fis.ExperimentStatusStopped:
+			return fmt.Errorf("FIS experiment %q %s (%s)", id, status, aws.StringValue(out.Experiment.State.Reason))
+		default:
+			ts.cfg.Logger.Info("FIS experiment running", zap.String("experiment-id", id), zap.String("status", status))
+		}
+	}
+	return fmt.Errorf("FIS experiment %q not completed in %v", id, timeout)
+}
+
+func toJSON(p aws_iam.PolicyDocument) string {
+	b, err := json.Marshal(p)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
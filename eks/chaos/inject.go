@@ -0,0 +1,158 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// inject injects a single failure, and waits for the canary to recover.
+// Returns an error only if the failure could not be injected;
+// the recovery failure is recorded in the result.
+func (ts *tester) inject() (rs eksconfig.ChaosResult, err error) {
+	cur := ts.cfg.EKSConfig.AddOnChaos
+	pods, err := ts.listPods()
+	if err != nil {
+		return rs, fmt.Errorf("failed to list canary Pods (%v)", err)
+	}
+	nodes, err := ts.listNodes()
+	if err != nil {
+		return rs, fmt.Errorf("failed to list nodes (%v)", err)
+	}
+
+	if cur.IsNetem() {
+		return ts.injectNetem(pods, nodes)
+	}
+
+	impaired := make(map[string]struct{})
+	var injected time.Time
+	if cur.IsFIS() {
+		if cur.AvailabilityZone == "" {
+			zones := make(map[string]string)
+			for _, node := range nodes {
+				zones[node.Name] = node.Labels[v1.LabelTopologyZone]
+			}
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				cur.AvailabilityZone = selectZone(pods, zones)
+			})
+			if cur.AvailabilityZone == "" {
+				return rs, errors.New("no availability zone found running the canary")
+			}
+		}
+		if err = ts.createExperimentTemplate(); err != nil {
+			return rs, err
+		}
+		for _, node := range nodes {
+			if node.Labels[v1.LabelTopologyZone] == cur.AvailabilityZone {
+				impaired[node.Name] = struct{}{}
+			}
+		}
+		injected = time.Now()
+		if rs.ExperimentID, err = ts.startExperiment(); err != nil {
+			return rs, err
+		}
+		rs.Target = cur.AvailabilityZone
+	} else {
+		node, ok := selectNode(pods, nodes)
+		if !ok {
+			return rs, errors.New("no node found running the canary")
+		}
+		// e.g. "aws:///us-west-2a/i-0123456789abcdef0"
+		instanceID := node.Spec.ProviderID[strings.LastIndex(node.Spec.ProviderID, "/")+1:]
+		ts.cfg.Logger.Info("terminating instance", zap.String("node-name", node.Name), zap.String("instance-id", instanceID))
+		injected = time.Now()
+		if _, err = ts.cfg.EC2APIV2.TerminateInstances(
+			context.Background(),
+			&aws_ec2_v2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			},
+		); err != nil {
+			return rs, fmt.Errorf("failed to terminate instance %q (%v)", instanceID, err)
+		}
+		impaired[node.Name] = struct{}{}
+		rs.Target = instanceID
+	}
+	rs.InjectedUTC = injected.UTC()
+
+	rescheduled, downtime, err := ts.waitRecovery(injected, impaired)
+	rs.RescheduleTime, rs.RescheduleTimeString = rescheduled, rescheduled.String()
+	rs.ServiceDowntime, rs.ServiceDowntimeString = downtime, downtime.String()
+	if err != nil {
+		rs.Error = err.Error()
+	}
+
+	if cur.IsFIS() {
+		// the experiment must end before the next injection or the deletion
+		if err = ts.waitExperiment(rs.ExperimentID, time.Until(injected.Add(cur.ImpairmentDuration))+10*time.Minute); err != nil && rs.Error == "" {
+			rs.Error = err.Error()
+		}
+	}
+	return rs, nil
+}
+
+// listNodes lists the worker nodes, in the node group if specified.
+func (ts *tester) listNodes() ([]v1.Node, error) {
+	opts := metav1.ListOptions{}
+	if ngName := ts.cfg.EKSConfig.AddOnChaos.NodeGroupName; ngName != "" {
+		opts.LabelSelector = "NGName=" + ngName
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	nodes, err := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Nodes().List(ctx, opts)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	return nodes.Items, nil
+}
+
+// selectNode selects a random node running the canary Pods.
+func selectNode(pods []v1.Pod, nodes []v1.Node) (v1.Node, bool) {
+	running := make(map[string]struct{})
+	for _, pod := range pods {
+		running[pod.Spec.NodeName] = struct{}{}
+	}
+	var candidates []v1.Node
+	for _, node := range nodes {
+		if _, ok := running[node.Name]; ok && node.Spec.ProviderID != "" {
+			candidates = append(candidates, node)
+		}
+	}
+	if len(candidates) == 0 {
+		return v1.Node{}, false
+	}
+	return candidates[rand.Intn(len(candidates))], true
+}
+
+// selectZone returns the availability zone running the most canary Pods,
+// given the map of node names to zones.
+func selectZone(pods []v1.Pod, zones map[string]string) string {
+	counts := make(map[string]int)
+	for _, pod := range pods {
+		if zone := zones[pod.Spec.NodeName]; zone != "" {
+			counts[zone]++
+		}
+	}
+	names := make([]string, 0, len(counts))
+	for zone := range counts {
+		names = append(names, zone)
+	}
+	// deterministic on ties
+	sort.Strings(names)
+	selected := ""
+	for _, zone := range names {
+		if selected == "" || counts[zone] > counts[selected] {
+			selected = zone
+		}
+	}
+	return selected
+}
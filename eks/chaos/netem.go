@@ -0,0 +1,498 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/ssh"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	probeServerName = "chaos-probe-server"
+	probeClientName = "chaos-probe-client"
+	probeImage      = eksconfig.DefaultBusyboxImage
+	probePort       = 8080
+	// probeBlobMB is the size of the blob the client downloads from the server,
+	// to measure the throughput.
+	probeBlobMB = 64
+
+	// netemRevertUnit is the transient systemd unit to delete the "tc netem" rules,
+	// in case the tester fails to clean up (e.g. interrupted, lost the connection).
+	netemRevertUnit = "aws-k8s-tester-netem-revert"
+	// netemRevertMargin is the time after the impairment until the rules are deleted
+	// by the revert unit, if not cleaned up by the tester.
+	netemRevertMargin = 10 * time.Minute
+)
+
+// netemInterfacesCmd lists the ENI interfaces, if "NetemInterface" is not specified.
+const netemInterfacesCmd = `ls /sys/class/net | grep -E '^(eth|ens)[0-9]+$'`
+
+// injectNetem degrades the network of a node running the canary with "tc netem",
+// and probes a Pod on the node from another node, before and during the degradation.
+// Returns an error only if the degradation could not be applied;
+// the probe failure is recorded in the result.
+func (ts *tester) injectNetem(pods []v1.Pod, nodes []v1.Node) (rs eksconfig.ChaosResult, err error) {
+	cur := ts.cfg.EKSConfig.AddOnChaos
+	if len(nodes) < 2 {
+		return rs, fmt.Errorf("%d node(s) found, network degradation requires at least 2 nodes to probe across", len(nodes))
+	}
+	node, ok := selectNode(pods, nodes)
+	if !ok {
+		return rs, errors.New("no node found running the canary")
+	}
+	// e.g. "aws:///us-west-2a/i-0123456789abcdef0"
+	instanceID := node.Spec.ProviderID[strings.LastIndex(node.Spec.ProviderID, "/")+1:]
+	rs.Target = instanceID
+
+	pool := ssh.NewPool(ts.cfg.Logger)
+	defer pool.Close()
+	n, err := ts.newNode(pool, instanceID)
+	if err != nil {
+		return rs, fmt.Errorf("failed to connect to %q (%v)", instanceID, err)
+	}
+	ifaces, err := ts.netemInterfaces(n)
+	if err != nil {
+		return rs, err
+	}
+
+	// unique per injection, since the previous probe Pods may still be terminating
+	suffix := fmt.Sprintf("-%d", len(cur.Results))
+	serverName := probeServerName + suffix
+	defer ts.deletePod(serverName)
+	serverIP, err := ts.createProbeServer(serverName, node.Name)
+	if err != nil {
+		return rs, err
+	}
+	baseline, err := ts.runProbe(probeClientName+suffix+"-baseline", node.Name, serverIP)
+	if err != nil {
+		return rs, fmt.Errorf("baseline probe failed (%v)", err)
+	}
+	rs.ProbeBaseline = &baseline
+
+	ts.cfg.Logger.Info("applying netem",
+		zap.String("node-name", node.Name),
+		zap.String("instance-id", instanceID),
+		zap.Strings("interfaces", ifaces),
+		zap.String("latency", cur.NetemLatencyString),
+		zap.Float64("loss-percent", cur.NetemLossPercent),
+	)
+	injected := time.Now()
+	rs.InjectedUTC = injected.UTC()
+	cmd := netemApplyCmd(ifaces, cur.NetemLatency, cur.NetemLossPercent, cur.ImpairmentDuration+netemRevertMargin)
+	if out, err := n.Exec(cmd, ssh.WithSudo(true), ssh.WithTimeout(2*time.Minute)); err != nil {
+		if rerr := ts.revertNetem(n, ifaces); rerr != nil {
+			ts.cfg.Logger.Warn("failed to revert netem", zap.Error(rerr))
+		}
+		return rs, fmt.Errorf("failed to apply netem on %q (%v, output %q)", instanceID, err, string(out))
+	}
+
+	degraded, err := ts.runProbe(probeClientName+suffix+"-degraded", node.Name, serverIP)
+	if err != nil {
+		rs.Error = fmt.Sprintf("degraded probe failed (%v)", err)
+	} else {
+		rs.ProbeDegraded = &degraded
+		rs.Error = checkDegraded(baseline, degraded, cur.NetemLatency)
+	}
+
+	ts.cfg.Logger.Info("holding netem", zap.String("impairment-duration", cur.ImpairmentDurationString))
+	select {
+	case <-ts.cfg.Stopc:
+		ts.cfg.Logger.Warn("netem hold aborted")
+	case <-time.After(time.Until(injected.Add(cur.ImpairmentDuration))):
+	}
+	if err = ts.revertNetem(n, ifaces); err != nil {
+		if rs.Error != "" {
+			rs.Error += ", "
+		}
+		rs.Error += err.Error()
+	}
+	return rs, nil
+}
+
+// newNode returns the node to run the "tc" commands on, with the configured transport.
+func (ts *tester) newNode(pool *ssh.Pool, instanceID string) (ssh.Node, error) {
+	if ts.cfg.EKSConfig.AddOnChaos.Transport == eksconfig.NodeTransportSSM {
+		return ssh.NewSSMNode(ts.cfg.SSMAPIV2, instanceID), nil
+	}
+
+	inst, ok := ts.cfg.EKSConfig.Instance(instanceID)
+	if !ok {
+		return nil, fmt.Errorf("instance %q not found in the node groups", instanceID)
+	}
+	sh, err := pool.Get(instanceID, ssh.Config{
+		Logger:        ts.cfg.Logger,
+		KeyPath:       ts.cfg.EKSConfig.RemoteAccessPrivateKeyPath,
+		PublicIP:      inst.PublicIP,
+		PublicDNSName: inst.PublicDNSName,
+		PrivateIP:     inst.PrivateIP,
+		UserName:      inst.RemoteAccessUserName,
+		InstanceConnect: ssh.NewInstanceConnect(
+			ts.cfg.EC2InstanceConnectAPI,
+			instanceID,
+			inst.Placement.AvailabilityZone,
+		),
+		InstanceID:         instanceID,
+		HostKeys:           ts.cfg.SSHHostKeys,
+		InsecureSkipVerify: ts.cfg.SSHHostKeys == nil,
+		ProxyJump: ssh.NewProxyJump(
+			ts.cfg.EKSConfig.RemoteAccessBastionHost,
+			ts.cfg.EKSConfig.RemoteAccessBastionUserName,
+			ts.cfg.EKSConfig.RemoteAccessBastionPrivateKeyPath,
+		),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewSSHNode(instanceID, sh), nil
+}
+
+// netemInterfaces returns the interfaces to degrade on the node.
+func (ts *tester) netemInterfaces(n ssh.Node) ([]string, error) {
+	if iface := ts.cfg.EKSConfig.AddOnChaos.NetemInterface; iface != "" {
+		return []string{iface}, nil
+	}
+	out, err := n.Exec(netemInterfacesCmd, ssh.WithTimeout(time.Minute))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interfaces on %q (%v)", n.InstanceID(), err)
+	}
+	ifaces := strings.Fields(string(out))
+	if len(ifaces) == 0 {
+		return nil, fmt.Errorf("no ENI interface found on %q", n.InstanceID())
+	}
+	return ifaces, nil
+}
+
+// netemApplyCmd returns the command to replace the root qdisc of the interfaces
+// with "netem", and to schedule the revert unit to delete them after "revertAfter".
+func netemApplyCmd(ifaces []string, latency time.Duration, lossPercent float64, revertAfter time.Duration) string {
+	netem := "netem"
+	if latency > 0 {
+		netem += fmt.Sprintf(" delay %dus", latency.Microseconds())
+	}
+	if lossPercent > 0 {
+		netem += " loss " + strconv.FormatFloat(lossPercent, 'f', -1, 64) + "%"
+	}
+	var sb strings.Builder
+	sb.WriteString("set -e; ")
+	sb.WriteString(netemRevertStopCmd)
+	sb.WriteString("; ")
+	for _, iface := range ifaces {
+		fmt.Fprintf(&sb, "tc qdisc replace dev %s root %s; ", iface, netem)
+	}
+	fmt.Fprintf(&sb, "systemd-run --unit=%s --on-active=%ds /bin/sh -c '", netemRevertUnit, int64(revertAfter.Seconds()))
+	for _, iface := range ifaces {
+		fmt.Fprintf(&sb, "tc qdisc del dev %s root; ", iface)
+	}
+	sb.WriteString("true'")
+	return "sh -c " + strconv.Quote(sb.String())
+}
+
+// netemRevertStopCmd stops the revert unit scheduled by the previous injection,
+// so that it does not delete the rules of the next one.
+var netemRevertStopCmd = fmt.Sprintf(
+	"systemctl stop %[1]s.timer %[1]s.service 2>/dev/null || true; systemctl reset-failed %[1]s.timer %[1]s.service 2>/dev/null || true",
+	netemRevertUnit,
+)
+
+// revertNetem deletes the "tc netem" rules, and verifies none remain.
+func (ts *tester) revertNetem(n ssh.Node, ifaces []string) error {
+	ts.cfg.Logger.Info("reverting netem", zap.String("instance-id", n.InstanceID()), zap.Strings("interfaces", ifaces))
+	var sb strings.Builder
+	sb.WriteString(netemRevertStopCmd)
+	sb.WriteString("; ")
+	for _, iface := range ifaces {
+		// fails if the qdisc was not replaced
+		fmt.Fprintf(&sb, "tc qdisc del dev %s root 2>/dev/null || true; ", iface)
+	}
+	for _, iface := range ifaces {
+		fmt.Fprintf(&sb, "tc qdisc show dev %s; ", iface)
+	}
+	out, err := n.Exec("sh -c "+strconv.Quote(sb.String()), ssh.WithSudo(true), ssh.WithTimeout(2*time.Minute))
+	if err != nil {
+		return fmt.Errorf("failed to revert netem on %q (%v)", n.InstanceID(), err)
+	}
+	if strings.Contains(string(out), "netem") {
+		return fmt.Errorf("netem not reverted on %q (qdisc %q)", n.InstanceID(), string(out))
+	}
+	ts.cfg.Logger.Info("reverted netem", zap.String("instance-id", n.InstanceID()))
+	return nil
+}
+
+// createProbeServer creates the Pod serving the probe blob on the node,
+// and returns its Pod IP once ready.
+func (ts *tester) createProbeServer(name string, nodeName string) (string, error) {
+	cur := ts.cfg.EKSConfig.AddOnChaos
+	ts.cfg.Logger.Info("creating probe server Pod", zap.String("name", name), zap.String("node-name", nodeName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(cur.Namespace).
+		Create(
+			ctx,
+			&v1.Pod{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Pod",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: cur.Namespace,
+				},
+				Spec: v1.PodSpec{
+					NodeName:                      nodeName,
+					RestartPolicy:                 v1.RestartPolicyAlways,
+					TerminationGracePeriodSeconds: aws_v2.Int64(0),
+					// tolerate the degraded node
+					Tolerations: []v1.Toleration{{Operator: v1.TolerationOpExists}},
+					Containers: []v1.Container{
+						{
+							Name:            probeServerName,
+							Image:           ts.cfg.EKSConfig.Image(probeImage),
+							ImagePullPolicy: v1.PullIfNotPresent,
+							Command: []string{"/bin/sh", "-c", fmt.Sprintf(
+								"mkdir -p /www && dd if=/dev/zero of=/www/blob bs=1M count=%d && exec httpd -f -p %d -h /www",
+								probeBlobMB, probePort,
+							)},
+							Ports: []v1.ContainerPort{{ContainerPort: probePort}},
+							ReadinessProbe: &v1.Probe{
+								ProbeHandler: v1.ProbeHandler{
+									TCPSocket: &v1.TCPSocketAction{Port: intstr.FromInt(probePort)},
+								},
+								PeriodSeconds: 2,
+							},
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return "", fmt.Errorf("failed to create probe server Pod (%v)", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Minute)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return "", errors.New("probe server Pod wait aborted")
+		case <-time.After(5 * time.Second):
+		}
+		ctx, cancel = context.WithTimeout(context.Background(), 15*time.Second)
+		pod, err := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Pods(cur.Namespace).Get(ctx, name, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get probe server Pod", zap.Error(err))
+			continue
+		}
+		if isReady(*pod) && pod.Status.PodIP != "" {
+			ts.cfg.Logger.Info("created probe server Pod", zap.String("pod-ip", pod.Status.PodIP))
+			return pod.Status.PodIP, nil
+		}
+	}
+	return "", errors.New("probe server Pod not ready")
+}
+
+// probeScript pings the server Pod and downloads the blob,
+// printing the elapsed nanoseconds of the download.
+var probeScript = fmt.Sprintf(`ping -c 20 -i 0.2 -W 2 "${PROBE_TARGET}" || true
+start=$(date +%%s%%N)
+if wget -q -O /dev/null -T 120 "http://${PROBE_TARGET}:%d/blob"; then
+  echo "download-ns $(( $(date +%%s%%N) - start ))"
+else
+  echo "download-failed"
+fi
+`, probePort)
+
+// runProbe runs the probe client Pod on another node than the server's,
+// and parses its output.
+func (ts *tester) runProbe(name string, serverNodeName string, serverIP string) (eksconfig.ChaosProbe, error) {
+	cur := ts.cfg.EKSConfig.AddOnChaos
+	defer ts.deletePod(name)
+
+	ts.cfg.Logger.Info("creating probe client Pod", zap.String("name", name), zap.String("target", serverIP))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(cur.Namespace).
+		Create(
+			ctx,
+			&v1.Pod{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Pod",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: cur.Namespace,
+				},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Containers: []v1.Container{
+						{
+							Name:            probeClientName,
+							Image:           ts.cfg.EKSConfig.Image(probeImage),
+							ImagePullPolicy: v1.PullIfNotPresent,
+							Command:         []string{"/bin/sh", "-c", probeScript},
+							Env: []v1.EnvVar{
+								{
+									Name:  "PROBE_TARGET",
+									Value: serverIP,
+								},
+							},
+						},
+					},
+					NodeSelector: map[string]string{
+						"kubernetes.io/os": "linux",
+					},
+					// probe across the nodes
+					Affinity: &v1.Affinity{
+						NodeAffinity: &v1.NodeAffinity{
+							RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+								NodeSelectorTerms: []v1.NodeSelectorTerm{
+									{
+										MatchFields: []v1.NodeSelectorRequirement{
+											{
+												Key:      "metadata.name",
+												Operator: v1.NodeSelectorOpNotIn,
+												Values:   []string{serverNodeName},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return eksconfig.ChaosProbe{}, fmt.Errorf("failed to create probe client Pod (%v)", err)
+	}
+
+	deadline := time.Now().Add(10 * time.Minute)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return eksconfig.ChaosProbe{}, errors.New("probe client Pod wait aborted")
+		case <-time.After(5 * time.Second):
+		}
+		ctx, cancel = context.WithTimeout(context.Background(), 15*time.Second)
+		pod, err := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Pods(cur.Namespace).Get(ctx, name, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get probe client Pod", zap.Error(err))
+			continue
+		}
+		if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+			continue
+		}
+
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		b, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Pods(cur.Namespace).
+			GetLogs(name, &v1.PodLogOptions{}).
+			DoRaw(ctx)
+		cancel()
+		if err != nil {
+			return eksconfig.ChaosProbe{}, fmt.Errorf("failed to get probe client Pod logs (%v)", err)
+		}
+		out := string(b)
+		fmt.Fprintf(ts.cfg.LogWriter, "\nprobe client Pod %q output:\n%s\n", name, out)
+		if pod.Status.Phase == v1.PodFailed {
+			return eksconfig.ChaosProbe{}, fmt.Errorf("probe client Pod failed (output %q)", out)
+		}
+		return parseProbe(out)
+	}
+	return eksconfig.ChaosProbe{}, errors.New("probe client Pod not completed")
+}
+
+func (ts *tester) deletePod(name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(ts.cfg.EKSConfig.AddOnChaos.Namespace).
+		Delete(ctx, name, metav1.DeleteOptions{GracePeriodSeconds: aws_v2.Int64(0)})
+	cancel()
+	if err != nil && !apierrs.IsNotFound(err) {
+		ts.cfg.Logger.Warn("failed to delete Pod", zap.String("name", name), zap.Error(err))
+	}
+}
+
+var (
+	// e.g. "20 packets transmitted, 19 packets received, 5% packet loss"
+	probeLossRegex = regexp.MustCompile(`([0-9.]+)% packet loss`)
+	// e.g. "round-trip min/avg/max = 0.058/0.081/0.110 ms"
+	probeRTTRegex = regexp.MustCompile(`round-trip min/avg/max = [0-9.]+/([0-9.]+)/[0-9.]+ ms`)
+)
+
+// parseProbe parses the probe client Pod output.
+// The latency is zero if all packets were lost,
+// and the throughput is zero if the download failed.
+func parseProbe(out string) (p eksconfig.ChaosProbe, err error) {
+	m := probeLossRegex.FindStringSubmatch(out)
+	if m == nil {
+		return p, fmt.Errorf("no ping statistics in probe output %q", out)
+	}
+	if p.PacketLossPercent, err = strconv.ParseFloat(m[1], 64); err != nil {
+		return p, fmt.Errorf("invalid packet loss %q (%v)", m[1], err)
+	}
+	if m = probeRTTRegex.FindStringSubmatch(out); m != nil {
+		ms, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return p, fmt.Errorf("invalid round-trip %q (%v)", m[1], err)
+		}
+		p.LatencyAvg = time.Duration(ms * float64(time.Millisecond))
+	}
+	p.LatencyAvgString = p.LatencyAvg.String()
+
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "download-ns ") {
+			continue
+		}
+		ns, err := strconv.ParseInt(strings.TrimPrefix(line, "download-ns "), 10, 64)
+		if err != nil || ns <= 0 {
+			return p, fmt.Errorf("invalid download time %q", line)
+		}
+		p.ThroughputMbps = float64(probeBlobMB*1024*1024*8) / (float64(ns) / float64(time.Second)) / 1e6
+	}
+	return p, nil
+}
+
+// checkDegraded returns the error message if the degraded probe
+// did not observe at least half of the injected latency.
+func checkDegraded(baseline eksconfig.ChaosProbe, degraded eksconfig.ChaosProbe, latency time.Duration) string {
+	if latency <= 0 || degraded.LatencyAvg == 0 {
+		return ""
+	}
+	if degraded.LatencyAvg < baseline.LatencyAvg+latency/2 {
+		return fmt.Sprintf("netem latency %v not observed (baseline %v, degraded %v)", latency, baseline.LatencyAvg, degraded.LatencyAvg)
+	}
+	return ""
+}
+
+func probeSummary(rs eksconfig.ChaosResult) string {
+	summary := func(p *eksconfig.ChaosProbe) string {
+		if p == nil {
+			return "n/a"
+		}
+		return fmt.Sprintf("latency %s, loss %.1f%%, throughput %.1f Mbps", p.LatencyAvgString, p.PacketLossPercent, p.ThroughputMbps)
+	}
+	return fmt.Sprintf("baseline %s; degraded %s", summary(rs.ProbeBaseline), summary(rs.ProbeDegraded))
+}
@@ -0,0 +1,78 @@
+package chaos
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+)
+
+func TestParseProbe(t *testing.T) {
+	out := `PING 10.0.1.23 (10.0.1.23): 56 data bytes
+64 bytes from 10.0.1.23: seq=0 ttl=254 time=100.512 ms
+
+--- 10.0.1.23 ping statistics ---
+20 packets transmitted, 19 packets received, 5% packet loss
+round-trip min/avg/max = 100.123/100.500/101.000 ms
+download-ns 2000000000
+`
+	p, err := parseProbe(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.PacketLossPercent != 5 {
+		t.Fatalf("unexpected packet loss %v", p.PacketLossPercent)
+	}
+	if p.LatencyAvg != 100500*time.Microsecond {
+		t.Fatalf("unexpected latency %v", p.LatencyAvg)
+	}
+	// 64 MiB in 2 seconds
+	if p.ThroughputMbps < 268.4 || p.ThroughputMbps > 268.5 {
+		t.Fatalf("unexpected throughput %v", p.ThroughputMbps)
+	}
+
+	p, err = parseProbe(`20 packets transmitted, 0 packets received, 100% packet loss
+download-failed
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.PacketLossPercent != 100 || p.LatencyAvg != 0 || p.ThroughputMbps != 0 {
+		t.Fatalf("unexpected probe %+v", p)
+	}
+
+	if _, err = parseProbe("ping: bad address"); err == nil {
+		t.Fatal("expected error for no ping statistics")
+	}
+}
+
+func TestCheckDegraded(t *testing.T) {
+	baseline := eksconfig.ChaosProbe{LatencyAvg: time.Millisecond}
+	if msg := checkDegraded(baseline, eksconfig.ChaosProbe{LatencyAvg: 101 * time.Millisecond}, 100*time.Millisecond); msg != "" {
+		t.Fatalf("unexpected %q", msg)
+	}
+	if msg := checkDegraded(baseline, eksconfig.ChaosProbe{LatencyAvg: 2 * time.Millisecond}, 100*time.Millisecond); msg == "" {
+		t.Fatal("expected latency not observed")
+	}
+	if msg := checkDegraded(baseline, eksconfig.ChaosProbe{LatencyAvg: 2 * time.Millisecond}, 0); msg != "" {
+		t.Fatalf("unexpected %q", msg)
+	}
+}
+
+func TestNetemApplyCmd(t *testing.T) {
+	cmd := netemApplyCmd([]string{"eth0", "eth1"}, 100*time.Millisecond, 2.5, 15*time.Minute)
+	for _, exp := range []string{
+		"tc qdisc replace dev eth0 root netem delay 100000us loss 2.5%",
+		"tc qdisc replace dev eth1 root netem delay 100000us loss 2.5%",
+		"--on-active=900s",
+		"tc qdisc del dev eth1 root",
+	} {
+		if !strings.Contains(cmd, exp) {
+			t.Fatalf("expected %q in %q", exp, cmd)
+		}
+	}
+	if cmd = netemApplyCmd([]string{"ens5"}, 0, 1, time.Minute); strings.Contains(cmd, "delay") {
+		t.Fatalf("unexpected delay in %q", cmd)
+	}
+}
@@ -0,0 +1,88 @@
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// downtimeTracker accumulates the time the canary Service was unavailable.
+type downtimeTracker struct {
+	since time.Time
+	total time.Duration
+}
+
+// observe records whether the Service was available at the time.
+func (d *downtimeTracker) observe(available bool, now time.Time) {
+	if !available {
+		if d.since.IsZero() {
+			d.since = now
+		}
+		return
+	}
+	if !d.since.IsZero() {
+		d.total += now.Sub(d.since)
+		d.since = time.Time{}
+	}
+}
+
+// downtime returns the total downtime, including the ongoing one.
+func (d *downtimeTracker) downtime(now time.Time) time.Duration {
+	if d.since.IsZero() {
+		return d.total
+	}
+	return d.total + now.Sub(d.since)
+}
+
+// waitRecovery polls the canary until all replicas are ready
+// outside of the impaired nodes, and returns the time from the injection
+// to the recovery and the Service downtime observed in the meantime.
+func (ts *tester) waitRecovery(injected time.Time, impaired map[string]struct{}) (rescheduled time.Duration, downtime time.Duration, err error) {
+	cur := ts.cfg.EKSConfig.AddOnChaos
+	ts.cfg.Logger.Info("waiting for canary recovery",
+		zap.Int("impaired-nodes", len(impaired)),
+		zap.String("recovery-timeout", cur.RecoveryTimeoutString),
+	)
+
+	tracker := &downtimeTracker{}
+	deadline := injected.Add(cur.RecoveryTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return time.Since(injected), tracker.downtime(time.Now()), errors.New("canary recovery wait aborted")
+		case <-time.After(2 * time.Second):
+		}
+
+		endpoints, err := ts.readyEndpoints()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get canary endpoints", zap.Error(err))
+			continue
+		}
+		now := time.Now()
+		tracker.observe(endpoints > 0, now)
+
+		pods, err := ts.listPods()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list canary Pods", zap.Error(err))
+			continue
+		}
+		healthy := countHealthy(pods, impaired)
+		ts.cfg.Logger.Info("checked canary",
+			zap.Int("ready-endpoints", endpoints),
+			zap.Int32("healthy-replicas", healthy),
+			zap.Int32("replicas", cur.CanaryReplicas),
+		)
+		if healthy >= cur.CanaryReplicas && endpoints > 0 {
+			rescheduled = now.Sub(injected)
+			ts.cfg.Logger.Info("canary recovered",
+				zap.Duration("reschedule-time", rescheduled),
+				zap.Duration("service-downtime", tracker.downtime(now)),
+			)
+			return rescheduled, tracker.downtime(now), nil
+		}
+	}
+	now := time.Now()
+	return now.Sub(injected), tracker.downtime(now), fmt.Errorf("canary not recovered in %v", cur.RecoveryTimeout)
+}
@@ -0,0 +1,65 @@
+package chaos
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDowntimeTracker(t *testing.T) {
+	start := time.Now()
+	d := &downtimeTracker{}
+	d.observe(true, start)
+	d.observe(false, start.Add(2*time.Second))
+	d.observe(false, start.Add(4*time.Second))
+	d.observe(true, start.Add(6*time.Second))
+	d.observe(true, start.Add(8*time.Second))
+	d.observe(false, start.Add(10*time.Second))
+	if dt := d.downtime(start.Add(11 * time.Second)); dt != 5*time.Second {
+		t.Fatalf("expected downtime 5s, got %v", dt)
+	}
+	d.observe(true, start.Add(12*time.Second))
+	if dt := d.downtime(start.Add(20 * time.Second)); dt != 6*time.Second {
+		t.Fatalf("expected downtime 6s, got %v", dt)
+	}
+}
+
+func TestCountHealthy(t *testing.T) {
+	ready := v1.PodStatus{
+		Phase:      v1.PodRunning,
+		Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}},
+	}
+	deleted := metav1.Now()
+	pods := []v1.Pod{
+		{Spec: v1.PodSpec{NodeName: "a"}, Status: ready},
+		{Spec: v1.PodSpec{NodeName: "b"}, Status: ready},
+		{Spec: v1.PodSpec{NodeName: "b"}, Status: v1.PodStatus{Phase: v1.PodPending}},
+		{Spec: v1.PodSpec{NodeName: "c"}, Status: ready},
+		{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &deleted}, Spec: v1.PodSpec{NodeName: "b"}, Status: ready},
+	}
+	if n := countHealthy(pods, map[string]struct{}{"c": {}}); n != 2 {
+		t.Fatalf("expected 2 healthy, got %d", n)
+	}
+}
+
+func TestSelectZone(t *testing.T) {
+	pods := []v1.Pod{
+		{Spec: v1.PodSpec{NodeName: "a"}},
+		{Spec: v1.PodSpec{NodeName: "b"}},
+		{Spec: v1.PodSpec{NodeName: "c"}},
+		{Spec: v1.PodSpec{NodeName: "unknown"}},
+	}
+	zones := map[string]string{"a": "us-west-2a", "b": "us-west-2b", "c": "us-west-2b"}
+	if zone := selectZone(pods, zones); zone != "us-west-2b" {
+		t.Fatalf("expected us-west-2b, got %q", zone)
+	}
+	zones["c"] = "us-west-2c"
+	if zone := selectZone(pods, zones); zone != "us-west-2a" {
+		t.Fatalf("expected us-west-2a on ties, got %q", zone)
+	}
+	if zone := selectZone(nil, zones); zone != "" {
+		t.Fatalf("expected no zone, got %q", zone)
+	}
+}
@@ -0,0 +1,238 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	canaryName = "chaos-canary"
+	appName    = "chaos-canary"
+	pauseImage = eksconfig.DefaultPauseImage
+
+	// notReadyTolerationSeconds evicts the canary Pods from the failed nodes
+	// sooner than the default 5 minutes, to measure the rescheduling.
+	notReadyTolerationSeconds = 30
+)
+
+func (ts *tester) createCanary() error {
+	cur := ts.cfg.EKSConfig.AddOnChaos
+	labels := map[string]string{
+		"app.kubernetes.io/name": appName,
+	}
+	var nodeSelector map[string]string
+	if cur.NodeGroupName != "" {
+		nodeSelector = map[string]string{"NGName": cur.NodeGroupName}
+	}
+
+	ts.cfg.Logger.Info("creating canary Deployment", zap.Int32("replicas", cur.CanaryReplicas))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(cur.Namespace).
+		Create(
+			ctx,
+			&appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      canaryName,
+					Namespace: cur.Namespace,
+					Labels:    labels,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: aws_v2.Int32(cur.CanaryReplicas),
+					Selector: &metav1.LabelSelector{
+						MatchLabels: labels,
+					},
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: labels,
+						},
+						Spec: v1.PodSpec{
+							RestartPolicy:                 v1.RestartPolicyAlways,
+							TerminationGracePeriodSeconds: aws_v2.Int64(0),
+							Containers: []v1.Container{
+								{
+									Name:            appName,
+									Image:           ts.cfg.EKSConfig.Image(pauseImage),
+									ImagePullPolicy: v1.PullIfNotPresent,
+								},
+							},
+							NodeSelector: nodeSelector,
+							// spread across the nodes and zones, so that a single failure
+							// does not take down all replicas
+							TopologySpreadConstraints: []v1.TopologySpreadConstraint{
+								{
+									MaxSkew:           1,
+									TopologyKey:       v1.LabelHostname,
+									WhenUnsatisfiable: v1.ScheduleAnyway,
+									LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+								},
+								{
+									MaxSkew:           1,
+									TopologyKey:       v1.LabelTopologyZone,
+									WhenUnsatisfiable: v1.ScheduleAnyway,
+									LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+								},
+							},
+							Tolerations: []v1.Toleration{
+								{
+									Key:               v1.TaintNodeNotReady,
+									Operator:          v1.TolerationOpExists,
+									Effect:            v1.TaintEffectNoExecute,
+									TolerationSeconds: aws_v2.Int64(notReadyTolerationSeconds),
+								},
+								{
+									Key:               v1.TaintNodeUnreachable,
+									Operator:          v1.TolerationOpExists,
+									Effect:            v1.TaintEffectNoExecute,
+									TolerationSeconds: aws_v2.Int64(notReadyTolerationSeconds),
+								},
+							},
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create canary Deployment (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("creating canary Service")
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Services(cur.Namespace).
+		Create(
+			ctx,
+			&v1.Service{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Service",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      canaryName,
+					Namespace: cur.Namespace,
+				},
+				Spec: v1.ServiceSpec{
+					Selector: labels,
+					Ports: []v1.ServicePort{
+						{
+							Protocol:   v1.ProtocolTCP,
+							Port:       80,
+							TargetPort: intstr.FromInt(80),
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create canary Service (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created canary")
+	return nil
+}
+
+func (ts *tester) waitCanary() error {
+	cur := ts.cfg.EKSConfig.AddOnChaos
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	_, err := k8s_client.WaitForDeploymentCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.K8SClient,
+		20*time.Second,
+		10*time.Second,
+		cur.Namespace,
+		canaryName,
+		cur.CanaryReplicas,
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("canary Deployment not ready (%v)", err)
+	}
+	return nil
+}
+
+func (ts *tester) listPods() ([]v1.Pod, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	pods, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(ts.cfg.EKSConfig.AddOnChaos.Namespace).
+		List(ctx, metav1.ListOptions{
+			LabelSelector: "app.kubernetes.io/name=" + appName,
+		})
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+// readyEndpoints returns the number of ready endpoints of the canary Service.
+func (ts *tester) readyEndpoints() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ep, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Endpoints(ts.cfg.EKSConfig.AddOnChaos.Namespace).
+		Get(ctx, canaryName, metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, ss := range ep.Subsets {
+		n += len(ss.Addresses)
+	}
+	return n, nil
+}
+
+// countHealthy returns the number of ready Pods
+// not running on the impaired nodes.
+func countHealthy(pods []v1.Pod, impaired map[string]struct{}) int32 {
+	n := int32(0)
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if _, ok := impaired[pod.Spec.NodeName]; ok {
+			continue
+		}
+		if isReady(pod) {
+			n++
+		}
+	}
+	return n
+}
+
+func isReady(pod v1.Pod) bool {
+	if pod.Status.Phase != v1.PodRunning {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == v1.PodReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
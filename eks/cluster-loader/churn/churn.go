@@ -0,0 +1,231 @@
+// Package churn implements the built-in cluster loader, which churns
+// namespaces, Deployments, Secrets, and ConfigMaps at the target QPS,
+// similar to clusterloader2 but without the external binary.
+// ref. https://github.com/kubernetes/perf-tests/tree/master/clusterloader2
+package churn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/metrics"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Config defines cluster loader configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new built-in cluster loader tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:      "cluster-loader-churn",
+		Exclusive: true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+			}), cfg.EKSConfig.IsEnabledAddOnClusterLoader)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+// managedByLabel selects the churned namespaces,
+// to clean up the ones left over from an aborted run.
+const (
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedByValue = "aws-k8s-tester-cluster-loader"
+)
+
+func (ts *tester) Create() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnClusterLoader() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnClusterLoader.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnClusterLoader.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnClusterLoader.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	cur := ts.cfg.EKSConfig.AddOnClusterLoader
+	rec := metrics.NewRecorder()
+	limiter := rate.NewLimiter(rate.Limit(cur.QPS), 1)
+	ctx, cancel := context.WithTimeout(context.Background(), cur.Duration)
+	go func() {
+		select {
+		case <-ts.cfg.Stopc:
+			ts.cfg.Logger.Warn("cluster loader aborted")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ts.cfg.Logger.Info("starting churn",
+		zap.Int("namespaces", cur.Namespaces),
+		zap.Float64("qps", cur.QPS),
+		zap.String("duration", cur.DurationString),
+	)
+	var wg sync.WaitGroup
+	iterations := make(chan struct{}, cur.Namespaces)
+	for i := 0; i < cur.Namespaces; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			w := &worker{ts: ts, id: id, limiter: limiter, rec: rec}
+			for iter := 0; ctx.Err() == nil; iter++ {
+				if err := w.churn(ctx, iter); err != nil {
+					ts.cfg.Logger.Warn("churn iteration failed", zap.Int("worker", id), zap.Int("iteration", iter), zap.Error(err))
+					continue
+				}
+				iterations <- struct{}{}
+			}
+		}(i)
+	}
+	go func() {
+		wg.Wait()
+		close(iterations)
+	}()
+	completed := 0
+	for range iterations {
+		completed++
+	}
+	aborted := false
+	select {
+	case <-ts.cfg.Stopc:
+		aborted = true
+	default:
+	}
+	cancel()
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.Iterations += completed
+		cur.RequestsSummaries = rec.Summaries(time.Now().UTC().Format(time.RFC3339Nano))
+	})
+	if err := ts.writeSummaries(); err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("completed churn", zap.Int("iterations", cur.Iterations))
+	if aborted {
+		return errors.New("cluster loader aborted")
+	}
+	return nil
+}
+
+func (ts *tester) writeSummaries() error {
+	cur := ts.cfg.EKSConfig.AddOnClusterLoader
+	b, err := json.MarshalIndent(cur.RequestsSummaries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(cur.RequestsSummariesJSONPath, b, 0600); err != nil {
+		return err
+	}
+	for op, s := range cur.RequestsSummaries {
+		fmt.Fprintf(ts.cfg.LogWriter, "\n\n%q:\n%s\n", op, s.Table())
+	}
+	ts.cfg.Logger.Info("wrote requests summaries", zap.String("path", cur.RequestsSummariesJSONPath))
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnClusterLoader() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnClusterLoader.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnClusterLoader.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+
+	// churned namespaces are deleted in each iteration,
+	// clean up the ones left over from failed or aborted iterations
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	nss, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Namespaces().
+		List(ctx, metav1.ListOptions{LabelSelector: managedByLabel + "=" + managedByValue})
+	cancel()
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("failed to list namespaces (%v)", err))
+	} else {
+		for _, ns := range nss.Items {
+			if err := k8s_client.DeleteNamespaceAndWait(
+				ts.cfg.Logger,
+				ts.cfg.K8SClient.KubernetesClientSet(),
+				ns.Name,
+				k8s_client.DefaultNamespaceDeletionInterval,
+				k8s_client.DefaultNamespaceDeletionTimeout,
+				k8s_client.WithForceDelete(true),
+			); err != nil {
+				errs = append(errs, fmt.Sprintf("failed to delete namespace %q (%v)", ns.Name, err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnClusterLoader.Created = false
+	})
+	return nil
+}
@@ -0,0 +1,177 @@
+package churn
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/metrics"
+	"github.com/aws/aws-k8s-tester/pkg/randutil"
+	"github.com/aws/aws-sdk-go/aws"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// worker churns one namespace at a time, sharing the rate limiter
+// and the latency recorder with the other workers.
+type worker struct {
+	ts      *tester
+	id      int
+	limiter *rate.Limiter
+	rec     *metrics.Recorder
+}
+
+// do waits for the rate limiter, and records the request latency.
+// The request itself is not bound to the churn context,
+// so that the in-flight requests complete at the end of the churn.
+func (w *worker) do(ctx context.Context, op string, f func(ctx context.Context) error) error {
+	if err := w.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	rctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	start := time.Now()
+	err := f(rctx)
+	took := time.Since(start)
+	cancel()
+	w.rec.Observe(op, took, err)
+	if err != nil {
+		return fmt.Errorf("%s failed (%v)", op, err)
+	}
+	return nil
+}
+
+// churn creates a namespace with its objects, and deletes them all.
+func (w *worker) churn(ctx context.Context, iter int) (err error) {
+	cur := w.ts.cfg.EKSConfig.AddOnClusterLoader
+	cli := w.ts.cfg.K8SClient.KubernetesClientSet()
+	ns := fmt.Sprintf("%s-%d-%d", cur.NamespacePrefix, w.id, iter)
+
+	if err = w.do(ctx, "create-namespace", func(rctx context.Context) error {
+		_, err := cli.CoreV1().Namespaces().Create(rctx, &v1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   ns,
+				Labels: map[string]string{managedByLabel: managedByValue},
+			},
+		}, metav1.CreateOptions{})
+		return err
+	}); err != nil {
+		return err
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		// best-effort, "Delete" cleans up the rest
+		dctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		derr := cli.CoreV1().Namespaces().Delete(dctx, ns, metav1.DeleteOptions{})
+		cancel()
+		if derr != nil {
+			w.ts.cfg.Logger.Warn("failed to delete namespace", zap.String("namespace", ns), zap.Error(derr))
+		}
+	}()
+
+	val := randutil.String(cur.ObjectSize)
+	for i := 0; i < cur.ConfigMapsPerNamespace; i++ {
+		name := fmt.Sprintf("configmap-%d", i)
+		if err = w.do(ctx, "create-configmap", func(rctx context.Context) error {
+			_, err := cli.CoreV1().ConfigMaps(ns).Create(rctx, &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+				Data:       map[string]string{"data": val},
+			}, metav1.CreateOptions{})
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < cur.SecretsPerNamespace; i++ {
+		name := fmt.Sprintf("secret-%d", i)
+		if err = w.do(ctx, "create-secret", func(rctx context.Context) error {
+			_, err := cli.CoreV1().Secrets(ns).Create(rctx, &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+				Type:       v1.SecretTypeOpaque,
+				Data:       map[string][]byte{"data": []byte(val)},
+			}, metav1.CreateOptions{})
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < cur.DeploymentsPerNamespace; i++ {
+		name := fmt.Sprintf("deployment-%d", i)
+		if err = w.do(ctx, "create-deployment", func(rctx context.Context) error {
+			_, err := cli.AppsV1().Deployments(ns).Create(rctx, newDeployment(ns, name, cur.PodImage, cur.DeploymentReplicas), metav1.CreateOptions{})
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err = w.deleteObjects(ctx, cli, ns); err != nil {
+		return err
+	}
+
+	return w.do(ctx, "delete-namespace", func(rctx context.Context) error {
+		return cli.CoreV1().Namespaces().Delete(rctx, ns, metav1.DeleteOptions{})
+	})
+}
+
+func (w *worker) deleteObjects(ctx context.Context, cli kubernetes.Interface, ns string) error {
+	cur := w.ts.cfg.EKSConfig.AddOnClusterLoader
+	for i := 0; i < cur.DeploymentsPerNamespace; i++ {
+		name := fmt.Sprintf("deployment-%d", i)
+		if err := w.do(ctx, "delete-deployment", func(rctx context.Context) error {
+			return cli.AppsV1().Deployments(ns).Delete(rctx, name, metav1.DeleteOptions{})
+		}); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < cur.SecretsPerNamespace; i++ {
+		name := fmt.Sprintf("secret-%d", i)
+		if err := w.do(ctx, "delete-secret", func(rctx context.Context) error {
+			return cli.CoreV1().Secrets(ns).Delete(rctx, name, metav1.DeleteOptions{})
+		}); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < cur.ConfigMapsPerNamespace; i++ {
+		name := fmt.Sprintf("configmap-%d", i)
+		if err := w.do(ctx, "delete-configmap", func(rctx context.Context) error {
+			return cli.CoreV1().ConfigMaps(ns).Delete(rctx, name, metav1.DeleteOptions{})
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newDeployment(ns string, name string, image string, replicas int32) *appsv1.Deployment {
+	labels := map[string]string{"app.kubernetes.io/name": name}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: aws.Int32(replicas),
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: v1.PodSpec{
+					// pods are deleted with the Deployment right away
+					TerminationGracePeriodSeconds: aws.Int64(0),
+					Containers: []v1.Container{
+						{
+							Name:  name,
+							Image: image,
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"path"
 	"strings"
 
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
 	"github.com/aws/aws-k8s-tester/eksconfig"
 	k8sclient "github.com/aws/aws-k8s-tester/pkg/k8s-client"
 	gotemplate "github.com/aws/aws-k8s-tester/pkg/util"
@@ -23,6 +24,15 @@ type ClusterLoader struct {
 	K8sClient k8sclient.EKS
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "cluster-loader",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return &ClusterLoader{Config: cfg.EKSConfig, K8sClient: cfg.K8SClient}
+		},
+	})
+}
+
 // IsEnabled returns true if enabled
 func (c *ClusterLoader) IsEnabled() bool {
 	return c.Config.Spec.ClusterLoader != nil
@@ -58,12 +68,14 @@ func (c *ClusterLoader) Apply() (err error) {
 	if err := c.K8sClient.Apply(template.String()); err != nil {
 		return fmt.Errorf("while applying resources, %v", err)
 	}
-	c.Config.Status.ClusterLoader = &eksconfig.ClusterLoaderStatus{
-		AddonStatus: eksconfig.AddonStatus{
-			Installed: true,
-			Ready:     true,
-		},
-	}
+	c.Config.RecordAddOnStatus(func() {
+		c.Config.Status.ClusterLoader = &eksconfig.ClusterLoaderStatus{
+			AddonStatus: eksconfig.AddonStatus{
+				Installed: true,
+				Ready:     true,
+			},
+		}
+	})
 	return nil
 }
 
@@ -76,12 +88,14 @@ func (c *ClusterLoader) Delete() (err error) {
 	if err := c.K8sClient.Delete(template.String()); err != nil {
 		return fmt.Errorf("while deleting resources, %v", err)
 	}
-	c.Config.Status.ClusterLoader = &eksconfig.ClusterLoaderStatus{
-		AddonStatus: eksconfig.AddonStatus{
-			Installed: false,
-			Ready:     false,
-		},
-	}
+	c.Config.RecordAddOnStatus(func() {
+		c.Config.Status.ClusterLoader = &eksconfig.ClusterLoaderStatus{
+			AddonStatus: eksconfig.AddonStatus{
+				Installed: false,
+				Ready:     false,
+			},
+		}
+	})
 	return nil
 }
 
@@ -44,6 +44,24 @@ func New(cfg Config) eks_tester.Tester {
 	return &tester{cfg: cfg}
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:      "cluster-loader-local",
+		Exclusive: true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				S3API:     cfg.S3API,
+				CWAPI:     cfg.CWAPI,
+			}), cfg.EKSConfig.IsEnabledAddOnClusterLoaderLocal)
+		},
+	})
+}
+
 type tester struct {
 	cfg    Config
 	loader cluster_loader.Loader
@@ -106,24 +124,28 @@ func (ts *tester) Create() (err error) {
 		EnableSystemPodMetrics:    ts.cfg.EKSConfig.AddOnClusterLoaderLocal.EnableSystemPodMetrics,
 	})
 
-	ts.cfg.EKSConfig.AddOnClusterLoaderLocal.Created = true
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnClusterLoaderLocal.Created = true
+	})
 	createStart := time.Now()
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnClusterLoaderLocal.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnClusterLoaderLocal.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
 	if err = ts.loader.Start(); err != nil {
 		return err
 	}
 
-	ts.cfg.EKSConfig.AddOnClusterLoaderLocal.PodStartupLatency, err = cluster_loader.ParsePodStartupLatency(ts.cfg.EKSConfig.AddOnClusterLoaderLocal.PodStartupLatencyPath)
+	podStartupLatency, err := cluster_loader.ParsePodStartupLatency(ts.cfg.EKSConfig.AddOnClusterLoaderLocal.PodStartupLatencyPath)
 	if err != nil {
 		return fmt.Errorf("failed to read PodStartupLatency %q (%v)", ts.cfg.EKSConfig.AddOnClusterLoaderLocal.PodStartupLatencyPath, err)
 	}
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnClusterLoaderLocal.PodStartupLatency = podStartupLatency
+	})
 
 	if err = ts.publishResults(); err != nil {
 		return err
@@ -147,8 +169,9 @@ func (ts *tester) Delete() (err error) {
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnClusterLoaderLocal.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnClusterLoaderLocal.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
 	}()
 
 	var errs []string
@@ -161,8 +184,9 @@ func (ts *tester) Delete() (err error) {
 		return errors.New(strings.Join(errs, ", "))
 	}
 
-	ts.cfg.EKSConfig.AddOnClusterLoaderLocal.Created = false
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnClusterLoaderLocal.Created = false
+	})
 	return nil
 }
 
@@ -24,6 +24,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/ecr"
 	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/dustin/go-humanize"
@@ -57,6 +58,25 @@ func New(cfg Config) eks_tester.Tester {
 	return &tester{cfg: cfg}
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:      "cluster-loader-remote",
+		Exclusive: true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				S3API:     cfg.S3API,
+				CWAPI:     cfg.CWAPI,
+				ECRAPI:    ecr.New(cfg.AWSSession, aws.NewConfig().WithRegion(cfg.EKSConfig.GetAddOnClusterLoaderRemoteRepositoryRegion())),
+			}), cfg.EKSConfig.IsEnabledAddOnClusterLoaderRemote)
+		},
+	})
+}
+
 type tester struct {
 	cfg      Config
 	ecrImage string
@@ -73,13 +93,15 @@ func (ts *tester) Create() (err error) {
 	}
 
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
-	ts.cfg.EKSConfig.AddOnClusterLoaderRemote.Created = true
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnClusterLoaderRemote.Created = true
+	})
 	createStart := time.Now()
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnClusterLoaderRemote.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnClusterLoaderRemote.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
 	if ts.ecrImage, _, err = aws_ecr.Check(
@@ -166,8 +188,9 @@ func (ts *tester) Delete() (err error) {
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnClusterLoaderRemote.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnClusterLoaderRemote.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
 	}()
 
 	var errs []string
@@ -205,8 +228,9 @@ func (ts *tester) Delete() (err error) {
 		return errors.New(strings.Join(errs, ", "))
 	}
 
-	ts.cfg.EKSConfig.AddOnClusterLoaderRemote.Created = false
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnClusterLoaderRemote.Created = false
+	})
 	return nil
 }
 
@@ -716,11 +740,13 @@ func (ts *tester) checkResults() (err error) {
 		return err
 	}
 
-	ts.cfg.EKSConfig.AddOnClusterLoaderRemote.PodStartupLatency, err = cluster_loader.ParsePodStartupLatency(ts.cfg.EKSConfig.AddOnClusterLoaderRemote.PodStartupLatencyPath)
+	podStartupLatency, err := cluster_loader.ParsePodStartupLatency(ts.cfg.EKSConfig.AddOnClusterLoaderRemote.PodStartupLatencyPath)
 	if err != nil {
 		return fmt.Errorf("failed to read PodStartupLatency %q (%v)", "", err)
 	}
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnClusterLoaderRemote.PodStartupLatency = podStartupLatency
+	})
 	return nil
 }
 
@@ -13,6 +13,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"os"
 	"path"
 	"path/filepath"
 	"reflect"
@@ -25,6 +26,7 @@ import (
 	"github.com/aws/aws-k8s-tester/eks/cluster/wait"
 	wait_v2 "github.com/aws/aws-k8s-tester/eks/cluster/wait-v2"
 	"github.com/aws/aws-k8s-tester/eksconfig"
+	pkg_aws "github.com/aws/aws-k8s-tester/pkg/aws"
 	aws_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
 	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
 	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
@@ -34,7 +36,8 @@ import (
 	aws_iam_v2 "github.com/aws/aws-sdk-go-v2/service/iam"
 	aws_kms_v2 "github.com/aws/aws-sdk-go-v2/service/kms"
 	aws_s3_v2 "github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	aws_ssm_v2 "github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
 	"github.com/aws/aws-sdk-go/service/eks"
 	"github.com/aws/aws-sdk-go/service/eks/eksiface"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
@@ -60,12 +63,14 @@ type Config struct {
 
 	EC2APIV2 *aws_ec2_v2.Client
 
+	SSMAPIV2 *aws_ssm_v2.Client
+
 	EKSAPI   eksiface.EKSAPI
 	EKSAPIV2 *aws_eks_v2.Client
 
 	ELBV2APIV2 *aws_elbv2_v2.Client
 
-	CFNAPI cloudformationiface.CloudFormationAPI
+	CWLogsAPI cloudwatchlogsiface.CloudWatchLogsAPI
 }
 
 type Tester interface {
@@ -78,6 +83,9 @@ type Tester interface {
 	CheckHealth() error
 	// Delete deletes all EKS cluster resources.
 	Delete() error
+	// DownloadControlPlaneLogs downloads the control plane logs
+	// from CloudWatch to the directory.
+	DownloadControlPlaneLogs(dir string) error
 }
 
 var pkgName = reflect.TypeOf(tester{}).PkgPath()
@@ -98,6 +106,10 @@ type tester struct {
 	cfg       Config
 	k8sClient k8s_client.EKS
 
+	// tunnelCmd is the SSM port forwarding session
+	// to the private-only API server endpoint.
+	tunnelCmd exec.Cmd
+
 	checkHealthMu *sync.Mutex
 }
 
@@ -116,6 +128,9 @@ func (ts *tester) Create() (err error) {
 	if err = ts.createEKS(); err != nil {
 		return err
 	}
+	if err = ts.createEndpointTunnel(); err != nil {
+		return err
+	}
 
 	ts.k8sClient, err = ts.createClient()
 	if err != nil {
@@ -124,6 +139,9 @@ func (ts *tester) Create() (err error) {
 	if err = ts.CheckHealth(); err != nil {
 		return err
 	}
+	if err = ts.checkEncryption(); err != nil {
+		return err
+	}
 
 	ts.cfg.EKSConfig.Sync()
 	return nil
@@ -217,6 +235,9 @@ func (ts *tester) Delete() error {
 		if err := ts.deleteRole(); err != nil {
 			errs = append(errs, err.Error())
 		}
+		if err := ts.deleteEndpointTunnel(); err != nil {
+			errs = append(errs, err.Error())
+		}
 		if err := ts.deleteVPC(); err != nil {
 			errs = append(errs, err.Error())
 		}
@@ -250,6 +271,9 @@ func (ts *tester) updateClusterStatusV1(v1 wait.ClusterStatus, desired string) {
 		if v1.Cluster.Endpoint != nil {
 			ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint = aws_v2.ToString(v1.Cluster.Endpoint)
 		}
+		if v1.Cluster.KubernetesNetworkConfig != nil && v1.Cluster.KubernetesNetworkConfig.ServiceIpv6Cidr != nil {
+			ts.cfg.EKSConfig.Status.ClusterServiceIPv6CIDR = aws_v2.ToString(v1.Cluster.KubernetesNetworkConfig.ServiceIpv6Cidr)
+		}
 
 		if v1.Cluster.Identity != nil &&
 			v1.Cluster.Identity.Oidc != nil &&
@@ -272,10 +296,12 @@ func (ts *tester) updateClusterStatusV1(v1 wait.ClusterStatus, desired string) {
 			}
 			ts.cfg.EKSConfig.Status.ClusterOIDCIssuerURL = u.String()
 			ts.cfg.EKSConfig.Status.ClusterOIDCIssuerHostPath = u.Hostname() + u.Path
-			ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN = fmt.Sprintf(
-				"arn:aws:iam::%s:oidc-provider/%s",
+			ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN = pkg_aws.ARN(
+				ts.cfg.EKSConfig.Partition,
+				"iam",
+				"",
 				ts.cfg.EKSConfig.Status.AWSAccountID,
-				ts.cfg.EKSConfig.Status.ClusterOIDCIssuerHostPath,
+				"oidc-provider/"+ts.cfg.EKSConfig.Status.ClusterOIDCIssuerHostPath,
 			)
 
 			ts.cfg.Logger.Info("fetching OIDC CA thumbprint", zap.String("url", ts.cfg.EKSConfig.Status.ClusterOIDCIssuerURL))
@@ -338,6 +364,7 @@ func (ts *tester) updateClusterStatusV1(v1 wait.ClusterStatus, desired string) {
 		ts.cfg.EKSConfig.Status.ClusterOIDCIssuerCAThumbprint = ""
 		ts.cfg.EKSConfig.Status.ClusterCA = ""
 		ts.cfg.EKSConfig.Status.ClusterCADecoded = ""
+		ts.cfg.EKSConfig.Status.ClusterServiceIPv6CIDR = ""
 
 	}
 
@@ -386,10 +413,12 @@ func (ts *tester) updateClusterStatusV2(v2 wait_v2.ClusterStatus, desired string
 			}
 			ts.cfg.EKSConfig.Status.ClusterOIDCIssuerURL = u.String()
 			ts.cfg.EKSConfig.Status.ClusterOIDCIssuerHostPath = u.Hostname() + u.Path
-			ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN = fmt.Sprintf(
-				"arn:aws:iam::%s:oidc-provider/%s",
+			ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN = pkg_aws.ARN(
+				ts.cfg.EKSConfig.Partition,
+				"iam",
+				"",
 				ts.cfg.EKSConfig.Status.AWSAccountID,
-				ts.cfg.EKSConfig.Status.ClusterOIDCIssuerHostPath,
+				"oidc-provider/"+ts.cfg.EKSConfig.Status.ClusterOIDCIssuerHostPath,
 			)
 
 			ts.cfg.Logger.Info("fetching OIDC CA thumbprint", zap.String("url", ts.cfg.EKSConfig.Status.ClusterOIDCIssuerURL))
@@ -464,6 +493,10 @@ type kubeconfig struct {
 	AWSIAMAuthenticatorPath  string
 	ClusterName              string
 	AuthenticationAPIVersion string
+	RoleARN                  string
+	WebIdentityTokenFile     string
+	WebIdentityRoleARN       string
+	SessionName              string
 }
 
 const tmplKUBECONFIG = `
@@ -491,6 +524,19 @@ users:
       - token
       - -i
       - {{ .ClusterName }}
+{{- if .RoleARN }}
+      - -r
+      - {{ .RoleARN }}
+{{- end }}
+{{- if .WebIdentityTokenFile }}
+      env:
+      - name: AWS_WEB_IDENTITY_TOKEN_FILE
+        value: {{ .WebIdentityTokenFile }}
+      - name: AWS_ROLE_ARN
+        value: {{ .WebIdentityRoleARN }}
+      - name: AWS_ROLE_SESSION_NAME
+        value: {{ .SessionName }}
+{{- end }}
 `
 
 // https://docs.aws.amazon.com/cli/latest/reference/eks/update-kubeconfig.html
@@ -501,6 +547,12 @@ func (ts *tester) createClient() (cli k8s_client.EKS, err error) {
 	fmt.Printf(ts.cfg.EKSConfig.Colorize("[light_green]createClient [default](%q)\n"), ts.cfg.EKSConfig.ConfigPath)
 	ts.cfg.EKSConfig.AuthenticationAPIVersion ="client.authentication.k8s.io/v1alpha1"
 
+	var kubeconfigCluster string
+	ar := ts.cfg.EKSConfig.AssumeRole
+	if ar == nil {
+		ar = &eksconfig.AssumeRole{}
+	}
+
 	if ts.cfg.EKSConfig.AWSIAMAuthenticatorPath != "" && ts.cfg.EKSConfig.AWSIAMAuthenticatorDownloadURL != "" {
 		tpl := template.Must(template.New("tmplKUBECONFIG").Parse(tmplKUBECONFIG))
 		buf := bytes.NewBuffer(nil)
@@ -510,6 +562,10 @@ func (ts *tester) createClient() (cli k8s_client.EKS, err error) {
 			AWSIAMAuthenticatorPath:  ts.cfg.EKSConfig.AWSIAMAuthenticatorPath,
 			ClusterName:              ts.cfg.EKSConfig.Name,
 			AuthenticationAPIVersion: ts.cfg.EKSConfig.AuthenticationAPIVersion,
+			RoleARN:                  ar.RoleARN,
+			WebIdentityTokenFile:     ar.WebIdentityTokenFile,
+			WebIdentityRoleARN:       ar.WebIdentityRoleARN,
+			SessionName:              ar.SessionName,
 		}); err != nil {
 			return nil, err
 		}
@@ -527,6 +583,7 @@ func (ts *tester) createClient() (cli k8s_client.EKS, err error) {
 			return nil, err
 		}
 		ts.cfg.Logger.Info("wrote KUBECONFIG with aws-iam-authenticator", zap.String("kubeconfig-path", ts.cfg.EKSConfig.KubeConfigPath))
+		kubeconfigCluster = "kubernetes"
 	} else {
 		args := []string{
 			ts.cfg.EKSConfig.AWSCLIPath,
@@ -540,6 +597,10 @@ func (ts *tester) createClient() (cli k8s_client.EKS, err error) {
 		if ts.cfg.EKSConfig.ResolverURL != "" {
 			args = append(args, fmt.Sprintf("--endpoint=%s", ts.cfg.EKSConfig.ResolverURL))
 		}
+		if ar.RoleARN != "" {
+			// "aws eks get-token" in KUBECONFIG assumes the same role
+			args = append(args, fmt.Sprintf("--role-arn=%s", ar.RoleARN))
+		}
 		cmd := strings.Join(args, " ")
 		ts.cfg.Logger.Info("writing KUBECONFIG with 'aws eks update-kubeconfig'",
 			zap.String("kubeconfig-path", ts.cfg.EKSConfig.KubeConfigPath),
@@ -554,7 +615,15 @@ func (ts *tester) createClient() (cli k8s_client.EKS, err error) {
 			case <-time.After(5 * time.Second):
 			}
 			ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
-			output, err = exec.New().CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+			c := exec.New().CommandContext(ctx, args[0], args[1:]...)
+			if ar.WebIdentityTokenFile != "" {
+				c.SetEnv(append(os.Environ(),
+					"AWS_WEB_IDENTITY_TOKEN_FILE="+ar.WebIdentityTokenFile,
+					"AWS_ROLE_ARN="+ar.WebIdentityRoleARN,
+					"AWS_ROLE_SESSION_NAME="+ar.SessionName,
+				))
+			}
+			output, err = c.CombinedOutput()
 			cancel()
 			out := string(output)
 			fmt.Fprintf(ts.cfg.LogWriter, "\n'%s' output:\n\n%s\n\n", cmd, out)
@@ -584,6 +653,19 @@ func (ts *tester) createClient() (cli k8s_client.EKS, err error) {
 
 		ts.cfg.Logger.Info("ran 'aws eks update-kubeconfig'")
 		fmt.Fprintf(ts.cfg.LogWriter, "\n\n'%s' output:\n\n%s\n\n", cmd, strings.TrimSpace(string(output)))
+		kubeconfigCluster = ts.cfg.EKSConfig.Status.ClusterARN
+	}
+
+	// the uploaded KUBECONFIG keeps the private endpoint for in-VPC clients
+	var tunnelServer, tunnelServerName string
+	if ts.cfg.EKSConfig.Endpoint.IsPrivateOnly() {
+		tunnelServer, tunnelServerName, err = ts.startEndpointTunnel()
+		if err != nil {
+			return nil, err
+		}
+		if err = ts.updateKubeConfigEndpointTunnel(kubeconfigCluster, tunnelServer, tunnelServerName); err != nil {
+			return nil, err
+		}
 	}
 
 	ts.cfg.Logger.Info("creating k8s client")
@@ -603,6 +685,8 @@ func (ts *tester) createClient() (cli k8s_client.EKS, err error) {
 		ClientQPS:                          ts.cfg.EKSConfig.ClientQPS,
 		ClientBurst:                        ts.cfg.EKSConfig.ClientBurst,
 		ClientTimeout:                      ts.cfg.EKSConfig.ClientTimeout,
+		ClientMaxRetries:                   ts.cfg.EKSConfig.ClientMaxRetries,
+		AssumeRole:                         ts.cfg.EKSConfig.AssumeRole.AWSAssumeRole(),
 	}
 	if ts.cfg.EKSConfig.IsEnabledAddOnClusterVersionUpgrade() {
 		kcfg.UpgradeServerVersion = ts.cfg.EKSConfig.AddOnClusterVersionUpgrade.Version
@@ -611,6 +695,10 @@ func (ts *tester) createClient() (cli k8s_client.EKS, err error) {
 		kcfg.ClusterAPIServerEndpoint = ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint
 		kcfg.ClusterCADecoded = ts.cfg.EKSConfig.Status.ClusterCADecoded
 	}
+	if tunnelServer != "" {
+		kcfg.ClusterAPIServerEndpoint = tunnelServer
+		kcfg.ServerName = tunnelServerName
+	}
 	cli, err = k8s_client.NewEKS(kcfg)
 	if err != nil {
 		ts.cfg.Logger.Warn("failed to create k8s client", zap.Error(err))
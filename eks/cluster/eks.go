@@ -31,10 +31,10 @@ func (ts *tester) createEKS() (err error) {
 	fmt.Print(ts.cfg.EKSConfig.Colorize("\n\n[yellow]*********************************\n"))
 	fmt.Printf(ts.cfg.EKSConfig.Colorize("[light_green]createEKS [default](%q)\n"), ts.cfg.EKSConfig.ConfigPath)
 
-	if ts.cfg.EKSConfig.Status.ClusterARN != "" ||
-		ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint != "" ||
-		ts.cfg.EKSConfig.Status.ClusterCA != "" ||
-		ts.cfg.EKSConfig.Status.ClusterCADecoded != "" {
+	// cluster ARN is persisted while creating, so check the endpoint and CA
+	// to resume the creation interrupted before the cluster is active
+	if ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint != "" &&
+		(ts.cfg.EKSConfig.Status.ClusterCA != "" || ts.cfg.EKSConfig.Status.ClusterCADecoded != "") {
 		ts.cfg.Logger.Info("non-empty cluster given; no need to create a new one", zap.String("status", ts.cfg.EKSConfig.Status.ClusterStatusCurrent))
 		return nil
 	}
@@ -43,10 +43,17 @@ func (ts *tester) createEKS() (err error) {
 		return nil
 	}
 
+	// adopt the cluster of the same name, created by the previous run
+	// that crashed before persisting the cluster status
 	ts.describeCluster()
-	if ts.cfg.EKSConfig.Status.ClusterStatusCurrent == fmt.Sprint(aws_eks_v2_types.ClusterStatusActive) {
-		ts.cfg.Logger.Info("cluster status is active; no need to create cluster", zap.String("status", ts.cfg.EKSConfig.Status.ClusterStatusCurrent))
-		return nil
+	adopt := false
+	switch ts.cfg.EKSConfig.Status.ClusterStatusCurrent {
+	case fmt.Sprint(aws_eks_v2_types.ClusterStatusActive), fmt.Sprint(aws_eks_v2_types.ClusterStatusCreating):
+		ts.cfg.Logger.Info("cluster already exists; adopting instead of creating a new one",
+			zap.String("name", ts.cfg.EKSConfig.Name),
+			zap.String("status", ts.cfg.EKSConfig.Status.ClusterStatusCurrent),
+		)
+		adopt = true
 	}
 
 	createStart := time.Now()
@@ -56,12 +63,85 @@ func (ts *tester) createEKS() (err error) {
 		ts.cfg.EKSConfig.Sync()
 	}()
 	initialWait := 9 * time.Minute
+	if adopt {
+		initialWait = 10 * time.Second
+	} else if err = ts.requestCreateCluster(); err != nil {
+		return err
+	}
 
-	subnets := make([]string, len(ts.cfg.EKSConfig.VPC.PublicSubnetIDs))
-	copy(subnets, ts.cfg.EKSConfig.VPC.PublicSubnetIDs)
-	if len(ts.cfg.EKSConfig.VPC.PrivateSubnetIDs) > 0 {
-		subnets = append(subnets, ts.cfg.EKSConfig.VPC.PrivateSubnetIDs...)
+	ctx, cancel := context.WithTimeout(context.Background(), ClusterCreateTimeout)
+	if ts.useV2SDK {
+		ch := wait_v2.Poll(
+			ctx,
+			ts.cfg.Stopc,
+			ts.cfg.Logger,
+			ts.cfg.LogWriter,
+			ts.cfg.EKSAPIV2,
+			ts.cfg.EKSConfig.Name,
+			aws_eks.ClusterStatusActive,
+			initialWait,
+			30*time.Second,
+		)
+		for sv := range ch {
+			ts.updateClusterStatusV2(sv, aws_eks.ClusterStatusActive)
+			err = sv.Error
+		}
+	} else {
+		ch := wait.Poll(
+			ctx,
+			ts.cfg.Stopc,
+			ts.cfg.Logger,
+			ts.cfg.LogWriter,
+			ts.cfg.EKSAPI,
+			ts.cfg.EKSConfig.Name,
+			aws_eks.ClusterStatusActive,
+			initialWait,
+			30*time.Second,
+		)
+		for sv := range ch {
+			ts.updateClusterStatusV1(sv, aws_eks.ClusterStatusActive)
+			err = sv.Error
+		}
 	}
+	cancel()
+
+	switch err {
+	case nil:
+		ts.cfg.Logger.Info("created a cluster",
+			zap.String("cluster-arn", ts.cfg.EKSConfig.Status.ClusterARN),
+			zap.String("cluster-api-server-endpoint", ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint),
+			zap.Int("cluster-ca-bytes", len(ts.cfg.EKSConfig.Status.ClusterCA)),
+			zap.String("config-path", ts.cfg.EKSConfig.ConfigPath),
+			zap.String("started", humanize.RelTime(createStart, time.Now(), "ago", "from now")),
+		)
+
+	case context.DeadlineExceeded:
+		ts.cfg.Logger.Warn("cluster creation took too long",
+			zap.String("cluster-arn", ts.cfg.EKSConfig.Status.ClusterARN),
+			zap.String("cluster-api-server-endpoint", ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint),
+			zap.String("config-path", ts.cfg.EKSConfig.ConfigPath),
+			zap.String("started", humanize.RelTime(createStart, time.Now(), "ago", "from now")),
+			zap.Error(err),
+		)
+		return err
+
+	default:
+		ts.cfg.Logger.Warn("failed to create cluster",
+			zap.String("cluster-arn", ts.cfg.EKSConfig.Status.ClusterARN),
+			zap.String("cluster-api-server-endpoint", ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint),
+			zap.String("config-path", ts.cfg.EKSConfig.ConfigPath),
+			zap.String("started", humanize.RelTime(createStart, time.Now(), "ago", "from now")),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+func (ts *tester) requestCreateCluster() (err error) {
+	subnets := clusterSubnetIDs(ts.cfg.EKSConfig)
 
 	ts.cfg.Logger.Info("creating a cluster using EKS API",
 		zap.String("name", ts.cfg.EKSConfig.Name),
@@ -70,21 +150,31 @@ func (ts *tester) createEKS() (err error) {
 		zap.String("signing-name", ts.cfg.EKSConfig.SigningName),
 		zap.String("request-header-key", ts.cfg.EKSConfig.RequestHeaderKey),
 		zap.String("request-header-value", ts.cfg.EKSConfig.RequestHeaderValue),
+		zap.String("ip-family", ts.cfg.EKSConfig.IPFamily),
+		zap.Bool("endpoint-public-access", ts.cfg.EKSConfig.Endpoint.PublicAccess),
+		zap.Bool("endpoint-private-access", ts.cfg.EKSConfig.Endpoint.PrivateAccess),
 	)
 
 	if ts.useV2SDK {
+		// TODO: remove once aws-sdk-go-v2 EKS supports "ipFamily"
+		if ts.cfg.EKSConfig.IPFamily == eksconfig.IPFamilyIPv6 {
+			return fmt.Errorf("IPFamily %q not supported with EKS v2 SDK", ts.cfg.EKSConfig.IPFamily)
+		}
 		createInput := &aws_eks_v2.CreateClusterInput{
 			Name:    aws_v2.String(ts.cfg.EKSConfig.Name),
 			Version: aws_v2.String(ts.cfg.EKSConfig.Version),
 			RoleArn: aws_v2.String(ts.cfg.EKSConfig.Role.ARN),
 			ResourcesVpcConfig: &aws_eks_v2_types.VpcConfigRequest{
-				SubnetIds:        subnets,
-				SecurityGroupIds: []string{ts.cfg.EKSConfig.VPC.SecurityGroupID},
+				SubnetIds:             subnets,
+				SecurityGroupIds:      []string{ts.cfg.EKSConfig.VPC.SecurityGroupID},
+				EndpointPublicAccess:  aws_v2.Bool(ts.cfg.EKSConfig.Endpoint.PublicAccess),
+				EndpointPrivateAccess: aws_v2.Bool(ts.cfg.EKSConfig.Endpoint.PrivateAccess),
 			},
 			Tags: map[string]string{
 				"Kind":                   "aws-k8s-tester",
 				"aws-k8s-tester-version": version.ReleaseVersion,
 				"User":                   user.Get(),
+				eksconfig.RunTagKey:      ts.cfg.EKSConfig.Name,
 			},
 		}
 		for k, v := range ts.cfg.EKSConfig.Tags {
@@ -107,6 +197,23 @@ func (ts *tester) createEKS() (err error) {
 				},
 			}
 		}
+		if ts.cfg.EKSConfig.ControlPlaneLogging.IsEnabled() {
+			ts.cfg.Logger.Info("added control plane logging to EKS API request",
+				zap.Strings("types", ts.cfg.EKSConfig.ControlPlaneLogging.Types),
+			)
+			types := make([]aws_eks_v2_types.LogType, 0, len(ts.cfg.EKSConfig.ControlPlaneLogging.Types))
+			for _, typ := range ts.cfg.EKSConfig.ControlPlaneLogging.Types {
+				types = append(types, aws_eks_v2_types.LogType(typ))
+			}
+			createInput.Logging = &aws_eks_v2_types.Logging{
+				ClusterLogging: []aws_eks_v2_types.LogSetup{
+					{
+						Enabled: aws_v2.Bool(true),
+						Types:   types,
+					},
+				},
+			}
+		}
 		opts := make([]func(*aws_eks_v2.Options), 0)
 		if ts.cfg.EKSConfig.RequestHeaderKey != "" && ts.cfg.EKSConfig.RequestHeaderValue != "" {
 			ts.cfg.Logger.Info("set request header for EKS create request",
@@ -126,40 +233,7 @@ func (ts *tester) createEKS() (err error) {
 			return err
 		}
 	} else {
-		createInput := &aws_eks.CreateClusterInput{
-			Name:    aws_v2.String(ts.cfg.EKSConfig.Name),
-			Version: aws_v2.String(ts.cfg.EKSConfig.Version),
-			RoleArn: aws_v2.String(ts.cfg.EKSConfig.Role.ARN),
-			ResourcesVpcConfig: &aws_eks.VpcConfigRequest{
-				SubnetIds:        aws_v2.StringSlice(subnets),
-				SecurityGroupIds: aws_v2.StringSlice([]string{ts.cfg.EKSConfig.VPC.SecurityGroupID}),
-			},
-			Tags: map[string]*string{
-				"Kind":                   aws_v2.String("aws-k8s-tester"),
-				"aws-k8s-tester-version": aws_v2.String(version.ReleaseVersion),
-				"User":                   aws_v2.String(user.Get()),
-			},
-		}
-		for k, v := range ts.cfg.EKSConfig.Tags {
-			createInput.Tags[k] = aws_v2.String(v)
-			ts.cfg.Logger.Info("added EKS tag to EKS API request",
-				zap.String("key", k),
-				zap.String("value", v),
-			)
-		}
-		if ts.cfg.EKSConfig.Encryption.CMKARN != "" {
-			ts.cfg.Logger.Info("added encryption to EKS API request",
-				zap.String("cmk-arn", ts.cfg.EKSConfig.Encryption.CMKARN),
-			)
-			createInput.EncryptionConfig = []*aws_eks.EncryptionConfig{
-				{
-					Resources: aws_v2.StringSlice([]string{"secrets"}),
-					Provider: &aws_eks.Provider{
-						KeyArn: aws_v2.String(ts.cfg.EKSConfig.Encryption.CMKARN),
-					},
-				},
-			}
-		}
+		createInput := newCreateClusterInput(ts.cfg.Logger, ts.cfg.EKSConfig, subnets)
 		req, _ := ts.cfg.EKSAPI.CreateClusterRequest(createInput)
 		if ts.cfg.EKSConfig.RequestHeaderKey != "" && ts.cfg.EKSConfig.RequestHeaderValue != "" {
 			req.HTTPRequest.Header[ts.cfg.EKSConfig.RequestHeaderKey] = []string{ts.cfg.EKSConfig.RequestHeaderValue}
@@ -175,75 +249,78 @@ func (ts *tester) createEKS() (err error) {
 	}
 
 	ts.cfg.Logger.Info("sent create cluster request")
-	ctx, cancel := context.WithTimeout(context.Background(), ClusterCreateTimeout)
-	if ts.useV2SDK {
-		ch := wait_v2.Poll(
-			ctx,
-			ts.cfg.Stopc,
-			ts.cfg.Logger,
-			ts.cfg.LogWriter,
-			ts.cfg.EKSAPIV2,
-			ts.cfg.EKSConfig.Name,
-			aws_eks.ClusterStatusActive,
-			initialWait,
-			30*time.Second,
-		)
-		for sv := range ch {
-			ts.updateClusterStatusV2(sv, aws_eks.ClusterStatusActive)
-			err = sv.Error
-		}
-	} else {
-		ch := wait.Poll(
-			ctx,
-			ts.cfg.Stopc,
-			ts.cfg.Logger,
-			ts.cfg.LogWriter,
-			ts.cfg.EKSAPI,
-			ts.cfg.EKSConfig.Name,
-			aws_eks.ClusterStatusActive,
-			initialWait,
-			30*time.Second,
-		)
-		for sv := range ch {
-			ts.updateClusterStatusV1(sv, aws_eks.ClusterStatusActive)
-			err = sv.Error
-		}
+	return nil
+}
+
+// clusterSubnetIDs returns the public and private subnet IDs for the cluster.
+func clusterSubnetIDs(cfg *eksconfig.Config) []string {
+	subnets := make([]string, len(cfg.VPC.PublicSubnetIDs))
+	copy(subnets, cfg.VPC.PublicSubnetIDs)
+	if len(cfg.VPC.PrivateSubnetIDs) > 0 {
+		subnets = append(subnets, cfg.VPC.PrivateSubnetIDs...)
 	}
-	cancel()
+	return subnets
+}
 
-	switch err {
-	case nil:
-		ts.cfg.Logger.Info("created a cluster",
-			zap.String("cluster-arn", ts.cfg.EKSConfig.Status.ClusterARN),
-			zap.String("cluster-api-server-endpoint", ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint),
-			zap.Int("cluster-ca-bytes", len(ts.cfg.EKSConfig.Status.ClusterCA)),
-			zap.String("config-path", ts.cfg.EKSConfig.ConfigPath),
-			zap.String("started", humanize.RelTime(createStart, time.Now(), "ago", "from now")),
+// newCreateClusterInput returns the EKS create cluster request.
+func newCreateClusterInput(lg *zap.Logger, cfg *eksconfig.Config, subnets []string) *aws_eks.CreateClusterInput {
+	createInput := &aws_eks.CreateClusterInput{
+		Name:    aws_v2.String(cfg.Name),
+		Version: aws_v2.String(cfg.Version),
+		RoleArn: aws_v2.String(cfg.Role.ARN),
+		ResourcesVpcConfig: &aws_eks.VpcConfigRequest{
+			SubnetIds:             aws_v2.StringSlice(subnets),
+			SecurityGroupIds:      aws_v2.StringSlice([]string{cfg.VPC.SecurityGroupID}),
+			EndpointPublicAccess:  aws_v2.Bool(cfg.Endpoint.PublicAccess),
+			EndpointPrivateAccess: aws_v2.Bool(cfg.Endpoint.PrivateAccess),
+		},
+		Tags: map[string]*string{
+			"Kind":                   aws_v2.String("aws-k8s-tester"),
+			"aws-k8s-tester-version": aws_v2.String(version.ReleaseVersion),
+			"User":                   aws_v2.String(user.Get()),
+			eksconfig.RunTagKey:      aws_v2.String(cfg.Name),
+		},
+	}
+	for k, v := range cfg.Tags {
+		createInput.Tags[k] = aws_v2.String(v)
+		lg.Info("added EKS tag to EKS API request",
+			zap.String("key", k),
+			zap.String("value", v),
 		)
-
-	case context.DeadlineExceeded:
-		ts.cfg.Logger.Warn("cluster creation took too long",
-			zap.String("cluster-arn", ts.cfg.EKSConfig.Status.ClusterARN),
-			zap.String("cluster-api-server-endpoint", ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint),
-			zap.String("config-path", ts.cfg.EKSConfig.ConfigPath),
-			zap.String("started", humanize.RelTime(createStart, time.Now(), "ago", "from now")),
-			zap.Error(err),
+	}
+	if cfg.Encryption.CMKARN != "" {
+		lg.Info("added encryption to EKS API request",
+			zap.String("cmk-arn", cfg.Encryption.CMKARN),
 		)
-		return err
-
-	default:
-		ts.cfg.Logger.Warn("failed to create cluster",
-			zap.String("cluster-arn", ts.cfg.EKSConfig.Status.ClusterARN),
-			zap.String("cluster-api-server-endpoint", ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint),
-			zap.String("config-path", ts.cfg.EKSConfig.ConfigPath),
-			zap.String("started", humanize.RelTime(createStart, time.Now(), "ago", "from now")),
-			zap.Error(err),
+		createInput.EncryptionConfig = []*aws_eks.EncryptionConfig{
+			{
+				Resources: aws_v2.StringSlice([]string{"secrets"}),
+				Provider: &aws_eks.Provider{
+					KeyArn: aws_v2.String(cfg.Encryption.CMKARN),
+				},
+			},
+		}
+	}
+	if cfg.ControlPlaneLogging.IsEnabled() {
+		lg.Info("added control plane logging to EKS API request",
+			zap.Strings("types", cfg.ControlPlaneLogging.Types),
 		)
-		return err
+		createInput.Logging = &aws_eks.Logging{
+			ClusterLogging: []*aws_eks.LogSetup{
+				{
+					Enabled: aws_v2.Bool(true),
+					Types:   aws_v2.StringSlice(cfg.ControlPlaneLogging.Types),
+				},
+			},
+		}
 	}
-
-	ts.cfg.EKSConfig.Sync()
-	return nil
+	if cfg.IPFamily == eksconfig.IPFamilyIPv6 {
+		lg.Info("added IPv6 family to EKS API request")
+		createInput.KubernetesNetworkConfig = &aws_eks.KubernetesNetworkConfigRequest{
+			IpFamily: aws_v2.String(aws_eks.IpFamilyIpv6),
+		}
+	}
+	return createInput
 }
 
 type httpClientWithRequestHeader struct {
@@ -1,18 +1,24 @@
 package cluster
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/aws/aws-k8s-tester/pkg/randutil"
 	"github.com/aws/aws-k8s-tester/pkg/user"
 	"github.com/aws/aws-k8s-tester/version"
 	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
 	aws_kms_v2 "github.com/aws/aws-sdk-go-v2/service/kms"
 	aws_kms_v2_types "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	aws_eks "github.com/aws/aws-sdk-go/service/eks"
 	smithy "github.com/aws/smithy-go"
 	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func (ts *tester) createEncryption() error {
@@ -110,12 +116,13 @@ func (ts *tester) deleteEncryption() error {
 	ts.cfg.Logger.Info("deleting KMS CMK",
 		zap.String("cmk-arn", keyARN),
 		zap.String("cmk-id", keyID),
+		zap.Int("pending-window-in-days", ts.cfg.EKSConfig.Encryption.CMKPendingWindowInDays),
 	)
 	dresp, err := ts.cfg.KMSAPIV2.ScheduleKeyDeletion(
 		context.Background(),
 		&aws_kms_v2.ScheduleKeyDeletionInput{
 			KeyId:               aws_v2.String(keyID),
-			PendingWindowInDays: aws_v2.Int32(7),
+			PendingWindowInDays: aws_v2.Int32(int32(ts.cfg.EKSConfig.Encryption.CMKPendingWindowInDays)),
 		})
 	if err != nil {
 		var apiErr smithy.APIError
@@ -151,3 +158,82 @@ func getIDFromKeyARN(arn string) string {
 	}
 	return arn
 }
+
+// checkEncryption verifies the cluster envelope-encrypts "secrets" with the
+// CMK, and that a Secret can be written and read back through the API server.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/enable-kms.html
+func (ts *tester) checkEncryption() error {
+	fmt.Print(ts.cfg.EKSConfig.Colorize("\n\n[yellow]*********************************\n"))
+	fmt.Printf(ts.cfg.EKSConfig.Colorize("[light_green]checkEncryption [default](%q)\n"), ts.cfg.EKSConfig.ConfigPath)
+
+	keyARN := ts.cfg.EKSConfig.Encryption.CMKARN
+	if keyARN == "" {
+		ts.cfg.Logger.Info("empty Encryption.CMKARN; no need to check encryption")
+		return nil
+	}
+
+	dout, err := ts.cfg.EKSAPI.DescribeCluster(&aws_eks.DescribeClusterInput{
+		Name: aws_v2.String(ts.cfg.EKSConfig.Name),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe cluster (%v)", err)
+	}
+	found := false
+	for _, cfg := range dout.Cluster.EncryptionConfig {
+		if cfg.Provider == nil || aws_v2.ToString(cfg.Provider.KeyArn) != keyARN {
+			continue
+		}
+		for _, r := range cfg.Resources {
+			if aws_v2.ToString(r) == "secrets" {
+				found = true
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("cluster %q has no \"secrets\" encryption config with CMK %q", ts.cfg.EKSConfig.Name, keyARN)
+	}
+
+	name := ts.cfg.EKSConfig.Name + "-encryption-check"
+	data := []byte(randutil.String(32))
+	secrets := ts.k8sClient.KubernetesClientSet().CoreV1().Secrets("default")
+
+	ts.cfg.Logger.Info("writing secret", zap.String("name", name))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err = secrets.Create(
+		ctx,
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Type:       v1.SecretTypeOpaque,
+			Data:       map[string][]byte{"data": data},
+		},
+		metav1.CreateOptions{},
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create secret %q (%v)", name, err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		derr := secrets.Delete(ctx, name, metav1.DeleteOptions{})
+		cancel()
+		if derr != nil {
+			ts.cfg.Logger.Warn("failed to delete secret", zap.String("name", name), zap.Error(derr))
+		}
+	}()
+
+	ts.cfg.Logger.Info("reading secret", zap.String("name", name))
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	sec, err := secrets.Get(ctx, name, metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get secret %q (%v)", name, err)
+	}
+	if !bytes.Equal(sec.Data["data"], data) {
+		return fmt.Errorf("secret %q data mismatch", name)
+	}
+
+	ts.cfg.EKSConfig.Encryption.SecretsEncryptionVerified = true
+	ts.cfg.EKSConfig.Sync()
+	ts.cfg.Logger.Info("checked encryption", zap.String("cmk-arn", keyARN))
+	return nil
+}
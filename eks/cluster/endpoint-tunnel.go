@@ -0,0 +1,442 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	aws_iam_v2 "github.com/aws/aws-sdk-go-v2/service/iam"
+	aws_ssm_v2 "github.com/aws/aws-sdk-go-v2/service/ssm"
+	aws_ssm_v2_types "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	aws_eks "github.com/aws/aws-sdk-go/service/eks"
+	smithy "github.com/aws/smithy-go"
+	"go.uber.org/zap"
+	"k8s.io/utils/exec"
+)
+
+// The private-only API server endpoint is only reachable from within the VPC.
+// An in-VPC runner instance is registered with SSM, and all kubectl and
+// Kubernetes client requests are forwarded through an SSM port forwarding
+// session to the runner.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/cluster-endpoint.html
+// ref. https://docs.aws.amazon.com/systems-manager/latest/userguide/session-manager-working-with-sessions-start.html#sessions-remote-port-forwarding
+
+func (ts *tester) createEndpointTunnel() error {
+	if !ts.cfg.EKSConfig.Endpoint.IsPrivateOnly() {
+		ts.cfg.Logger.Info("Endpoint.PublicAccess true; skipping endpoint tunnel creation")
+		return nil
+	}
+	fmt.Print(ts.cfg.EKSConfig.Colorize("\n\n[yellow]*********************************\n"))
+	fmt.Printf(ts.cfg.EKSConfig.Colorize("[light_green]createEndpointTunnel [default](%q)\n"), ts.cfg.EKSConfig.ConfigPath)
+
+	if ts.cfg.EKSConfig.Endpoint.TunnelInstanceID != "" {
+		ts.cfg.Logger.Info("endpoint tunnel instance already created", zap.String("instance-id", ts.cfg.EKSConfig.Endpoint.TunnelInstanceID))
+		return nil
+	}
+	if _, err := exec.New().LookPath("session-manager-plugin"); err != nil {
+		return fmt.Errorf("'session-manager-plugin' not found; required for the private-only endpoint (%v)", err)
+	}
+
+	if err := ts.createTunnelRole(); err != nil {
+		return err
+	}
+	if err := ts.createTunnelInstance(); err != nil {
+		return err
+	}
+	return ts.waitTunnelInstanceSSM()
+}
+
+func (ts *tester) deleteEndpointTunnel() error {
+	if !ts.cfg.EKSConfig.Endpoint.IsPrivateOnly() {
+		return nil
+	}
+	fmt.Print(ts.cfg.EKSConfig.Colorize("\n\n[yellow]*********************************\n"))
+	fmt.Printf(ts.cfg.EKSConfig.Colorize("[light_blue]deleteEndpointTunnel [default](%q)\n"), ts.cfg.EKSConfig.ConfigPath)
+
+	ts.stopEndpointTunnel()
+
+	var errs []string
+	if err := ts.deleteTunnelInstance(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteTunnelRole(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	ts.cfg.Logger.Info("deleted endpoint tunnel")
+	return nil
+}
+
+func (ts *tester) createTunnelRole() error {
+	cur := ts.cfg.EKSConfig.Endpoint
+	ts.cfg.Logger.Info("creating endpoint tunnel role", zap.String("name", cur.TunnelRoleName))
+	_, err := ts.cfg.IAMAPIV2.CreateRole(
+		context.Background(),
+		&aws_iam_v2.CreateRoleInput{
+			RoleName:                 aws_v2.String(cur.TunnelRoleName),
+			Path:                     aws_v2.String("/"),
+			AssumeRolePolicyDocument: aws_v2.String(createAssumeRolePolicyDocument([]string{"ec2.amazonaws.com"})),
+		},
+	)
+	if err != nil && !isAlreadyExists(err) {
+		return err
+	}
+
+	arn := fmt.Sprintf("arn:%s:iam::aws:policy/AmazonSSMManagedInstanceCore", ts.cfg.EKSConfig.Partition)
+	if _, err = ts.cfg.IAMAPIV2.AttachRolePolicy(
+		context.Background(),
+		&aws_iam_v2.AttachRolePolicyInput{
+			RoleName:  aws_v2.String(cur.TunnelRoleName),
+			PolicyArn: aws_v2.String(arn),
+		},
+	); err != nil {
+		ts.cfg.Logger.Warn("failed to attach policy", zap.String("arn", arn), zap.Error(err))
+		return err
+	}
+
+	_, err = ts.cfg.IAMAPIV2.CreateInstanceProfile(
+		context.Background(),
+		&aws_iam_v2.CreateInstanceProfileInput{
+			InstanceProfileName: aws_v2.String(cur.TunnelInstanceProfileName),
+			Path:                aws_v2.String("/"),
+		},
+	)
+	if err != nil && !isAlreadyExists(err) {
+		return err
+	}
+	_, err = ts.cfg.IAMAPIV2.AddRoleToInstanceProfile(
+		context.Background(),
+		&aws_iam_v2.AddRoleToInstanceProfileInput{
+			InstanceProfileName: aws_v2.String(cur.TunnelInstanceProfileName),
+			RoleName:            aws_v2.String(cur.TunnelRoleName),
+		},
+	)
+	if err != nil && !strings.Contains(err.Error(), "LimitExceeded") {
+		return err
+	}
+
+	ts.cfg.EKSConfig.Sync()
+	ts.cfg.Logger.Info("created endpoint tunnel role", zap.String("name", cur.TunnelRoleName))
+	return nil
+}
+
+func (ts *tester) deleteTunnelRole() error {
+	cur := ts.cfg.EKSConfig.Endpoint
+	ts.cfg.Logger.Info("deleting endpoint tunnel role", zap.String("name", cur.TunnelRoleName))
+	if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[cur.TunnelRoleName]; ok {
+		return nil
+	}
+
+	if _, err := ts.cfg.IAMAPIV2.RemoveRoleFromInstanceProfile(
+		context.Background(),
+		&aws_iam_v2.RemoveRoleFromInstanceProfileInput{
+			InstanceProfileName: aws_v2.String(cur.TunnelInstanceProfileName),
+			RoleName:            aws_v2.String(cur.TunnelRoleName),
+		},
+	); err != nil && !isNotFound(err) {
+		return err
+	}
+	if _, err := ts.cfg.IAMAPIV2.DeleteInstanceProfile(
+		context.Background(),
+		&aws_iam_v2.DeleteInstanceProfileInput{
+			InstanceProfileName: aws_v2.String(cur.TunnelInstanceProfileName),
+		},
+	); err != nil && !isNotFound(err) {
+		return err
+	}
+	if _, err := ts.cfg.IAMAPIV2.DetachRolePolicy(
+		context.Background(),
+		&aws_iam_v2.DetachRolePolicyInput{
+			RoleName:  aws_v2.String(cur.TunnelRoleName),
+			PolicyArn: aws_v2.String(fmt.Sprintf("arn:%s:iam::aws:policy/AmazonSSMManagedInstanceCore", ts.cfg.EKSConfig.Partition)),
+		},
+	); err != nil && !isNotFound(err) {
+		return err
+	}
+	if _, err := ts.cfg.IAMAPIV2.DeleteRole(
+		context.Background(),
+		&aws_iam_v2.DeleteRoleInput{
+			RoleName: aws_v2.String(cur.TunnelRoleName),
+		},
+	); err != nil && !isNotFound(err) {
+		return err
+	}
+
+	ts.cfg.EKSConfig.Status.DeletedResources[cur.TunnelRoleName] = "Endpoint.TunnelRoleName"
+	ts.cfg.EKSConfig.Sync()
+	ts.cfg.Logger.Info("deleted endpoint tunnel role")
+	return nil
+}
+
+func (ts *tester) createTunnelInstance() error {
+	cur := ts.cfg.EKSConfig.Endpoint
+
+	pout, err := ts.cfg.SSMAPIV2.GetParameter(
+		context.Background(),
+		&aws_ssm_v2.GetParameterInput{
+			Name: aws_v2.String(cur.TunnelImageIDSSMParameter),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get SSM parameter %q (%v)", cur.TunnelImageIDSSMParameter, err)
+	}
+	imageID := aws_v2.ToString(pout.Parameter.Value)
+
+	// EKS allows all traffic within the cluster security group,
+	// including the private endpoint ENIs
+	dout, err := ts.cfg.EKSAPI.DescribeCluster(&aws_eks.DescribeClusterInput{
+		Name: aws_v2.String(ts.cfg.EKSConfig.Name),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe cluster (%v)", err)
+	}
+	sgIDs := []string{ts.cfg.EKSConfig.VPC.SecurityGroupID}
+	if dout.Cluster.ResourcesVpcConfig != nil && aws_v2.ToString(dout.Cluster.ResourcesVpcConfig.ClusterSecurityGroupId) != "" {
+		sgIDs = append(sgIDs, aws_v2.ToString(dout.Cluster.ResourcesVpcConfig.ClusterSecurityGroupId))
+	}
+
+	// private subnets reach the SSM endpoints via NAT gateways
+	ni := aws_ec2_v2_types.InstanceNetworkInterfaceSpecification{
+		DeviceIndex: aws_v2.Int32(0),
+		Groups:      sgIDs,
+	}
+	if len(ts.cfg.EKSConfig.VPC.PrivateSubnetIDs) > 0 {
+		ni.SubnetId = aws_v2.String(ts.cfg.EKSConfig.VPC.PrivateSubnetIDs[0])
+	} else {
+		ni.SubnetId = aws_v2.String(ts.cfg.EKSConfig.VPC.PublicSubnetIDs[0])
+		ni.AssociatePublicIpAddress = aws_v2.Bool(true)
+	}
+
+	ts.cfg.Logger.Info("creating endpoint tunnel instance",
+		zap.String("image-id", imageID),
+		zap.String("instance-type", cur.TunnelInstanceType),
+		zap.String("subnet-id", aws_v2.ToString(ni.SubnetId)),
+		zap.Strings("security-group-ids", sgIDs),
+	)
+	// newly created instance profile may not be propagated yet
+	var rout *aws_ec2_v2.RunInstancesOutput
+	retryStart := time.Now()
+	for time.Since(retryStart) < 3*time.Minute {
+		rout, err = ts.cfg.EC2APIV2.RunInstances(
+			context.Background(),
+			&aws_ec2_v2.RunInstancesInput{
+				ImageId:            aws_v2.String(imageID),
+				InstanceType:       aws_ec2_v2_types.InstanceType(cur.TunnelInstanceType),
+				MinCount:           aws_v2.Int32(1),
+				MaxCount:           aws_v2.Int32(1),
+				IamInstanceProfile: &aws_ec2_v2_types.IamInstanceProfileSpecification{Name: aws_v2.String(cur.TunnelInstanceProfileName)},
+				NetworkInterfaces:  []aws_ec2_v2_types.InstanceNetworkInterfaceSpecification{ni},
+				TagSpecifications: []aws_ec2_v2_types.TagSpecification{
+					{
+						ResourceType: aws_ec2_v2_types.ResourceTypeInstance,
+						Tags: []aws_ec2_v2_types.Tag{
+							{Key: aws_v2.String("Name"), Value: aws_v2.String(ts.cfg.EKSConfig.Name + "-endpoint-tunnel")},
+							{Key: aws_v2.String("Kind"), Value: aws_v2.String("aws-k8s-tester")},
+						},
+					},
+				},
+			},
+		)
+		if err == nil || !strings.Contains(err.Error(), "Invalid IAM Instance Profile") {
+			break
+		}
+		ts.cfg.Logger.Warn("instance profile not ready; retrying", zap.Error(err))
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("endpoint tunnel instance creation aborted")
+		case <-time.After(10 * time.Second):
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create endpoint tunnel instance (%v)", err)
+	}
+
+	cur.TunnelInstanceID = aws_v2.ToString(rout.Instances[0].InstanceId)
+	ts.cfg.EKSConfig.Sync()
+	ts.cfg.Logger.Info("created endpoint tunnel instance", zap.String("instance-id", cur.TunnelInstanceID))
+	return nil
+}
+
+func (ts *tester) waitTunnelInstanceSSM() error {
+	cur := ts.cfg.EKSConfig.Endpoint
+	ts.cfg.Logger.Info("waiting for endpoint tunnel instance SSM registration", zap.String("instance-id", cur.TunnelInstanceID))
+	waitStart := time.Now()
+	for time.Since(waitStart) < 10*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("endpoint tunnel instance wait aborted")
+		case <-time.After(15 * time.Second):
+		}
+		out, err := ts.cfg.SSMAPIV2.DescribeInstanceInformation(
+			context.Background(),
+			&aws_ssm_v2.DescribeInstanceInformationInput{
+				Filters: []aws_ssm_v2_types.InstanceInformationStringFilter{
+					{Key: aws_v2.String("InstanceIds"), Values: []string{cur.TunnelInstanceID}},
+				},
+			},
+		)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe instance information", zap.Error(err))
+			continue
+		}
+		if len(out.InstanceInformationList) > 0 && out.InstanceInformationList[0].PingStatus == aws_ssm_v2_types.PingStatusOnline {
+			ts.cfg.Logger.Info("endpoint tunnel instance registered with SSM", zap.String("took", time.Since(waitStart).String()))
+			return nil
+		}
+		ts.cfg.Logger.Info("endpoint tunnel instance not registered with SSM yet", zap.String("instance-id", cur.TunnelInstanceID))
+	}
+	return fmt.Errorf("endpoint tunnel instance %q not registered with SSM", cur.TunnelInstanceID)
+}
+
+func (ts *tester) deleteTunnelInstance() error {
+	cur := ts.cfg.EKSConfig.Endpoint
+	if cur.TunnelInstanceID == "" {
+		return nil
+	}
+	if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[cur.TunnelInstanceID]; ok {
+		return nil
+	}
+
+	ts.cfg.Logger.Info("terminating endpoint tunnel instance", zap.String("instance-id", cur.TunnelInstanceID))
+	_, err := ts.cfg.EC2APIV2.TerminateInstances(
+		context.Background(),
+		&aws_ec2_v2.TerminateInstancesInput{InstanceIds: []string{cur.TunnelInstanceID}},
+	)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+
+	// instance ENI must be released before deleting security groups and subnets
+	waitStart := time.Now()
+	for time.Since(waitStart) < 10*time.Minute {
+		out, err := ts.cfg.EC2APIV2.DescribeInstances(
+			context.Background(),
+			&aws_ec2_v2.DescribeInstancesInput{InstanceIds: []string{cur.TunnelInstanceID}},
+		)
+		if err != nil {
+			if isNotFound(err) {
+				break
+			}
+			ts.cfg.Logger.Warn("failed to describe instance", zap.Error(err))
+		} else if len(out.Reservations) == 0 || len(out.Reservations[0].Instances) == 0 ||
+			out.Reservations[0].Instances[0].State.Name == aws_ec2_v2_types.InstanceStateNameTerminated {
+			break
+		}
+		time.Sleep(10 * time.Second)
+	}
+
+	ts.cfg.EKSConfig.Status.DeletedResources[cur.TunnelInstanceID] = "Endpoint.TunnelInstanceID"
+	ts.cfg.EKSConfig.Sync()
+	ts.cfg.Logger.Info("terminated endpoint tunnel instance")
+	return nil
+}
+
+// startEndpointTunnel starts the SSM port forwarding session in the
+// background, if not running, and returns the local endpoint and the
+// API server host name to verify TLS against.
+func (ts *tester) startEndpointTunnel() (server string, serverName string, err error) {
+	cur := ts.cfg.EKSConfig.Endpoint
+	u, err := url.Parse(ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse ClusterAPIServerEndpoint (%v)", err)
+	}
+	serverName = u.Hostname()
+	local := fmt.Sprintf("127.0.0.1:%d", cur.TunnelLocalPort)
+	server = "https://" + local
+
+	if ts.tunnelCmd != nil && dialable(local) {
+		return server, serverName, nil
+	}
+	if cur.TunnelInstanceID == "" {
+		return "", "", errors.New("empty Endpoint.TunnelInstanceID")
+	}
+
+	args := []string{
+		ts.cfg.EKSConfig.AWSCLIPath,
+		"ssm",
+		fmt.Sprintf("--region=%s", ts.cfg.EKSConfig.Region),
+		"start-session",
+		"--target=" + cur.TunnelInstanceID,
+		"--document-name=AWS-StartPortForwardingSessionToRemoteHost",
+		fmt.Sprintf("--parameters=host=%s,portNumber=443,localPortNumber=%d", serverName, cur.TunnelLocalPort),
+	}
+	ts.cfg.Logger.Info("starting endpoint tunnel", zap.String("cmd", strings.Join(args, " ")))
+	cmd := exec.New().Command(args[0], args[1:]...)
+	cmd.SetStdout(ts.cfg.LogWriter)
+	cmd.SetStderr(ts.cfg.LogWriter)
+	if err = cmd.Start(); err != nil {
+		return "", "", fmt.Errorf("failed to start endpoint tunnel (%v)", err)
+	}
+	ts.tunnelCmd = cmd
+
+	waitStart := time.Now()
+	for time.Since(waitStart) < time.Minute {
+		if dialable(local) {
+			cur.TunnelServer = server
+			ts.cfg.EKSConfig.Sync()
+			ts.cfg.Logger.Info("started endpoint tunnel", zap.String("server", server), zap.String("server-name", serverName))
+			return server, serverName, nil
+		}
+		time.Sleep(2 * time.Second)
+	}
+	ts.stopEndpointTunnel()
+	return "", "", fmt.Errorf("endpoint tunnel %q not ready", local)
+}
+
+func (ts *tester) stopEndpointTunnel() {
+	if ts.tunnelCmd == nil {
+		return
+	}
+	ts.cfg.Logger.Info("stopping endpoint tunnel")
+	ts.tunnelCmd.Stop()
+	ts.tunnelCmd = nil
+}
+
+// updateKubeConfigEndpointTunnel points the KUBECONFIG cluster at the
+// local tunnel, verifying the API server certificate with its host name.
+func (ts *tester) updateKubeConfigEndpointTunnel(cluster string, server string, serverName string) error {
+	args := []string{
+		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+		"config",
+		"set-cluster",
+		cluster,
+		"--server=" + server,
+		"--tls-server-name=" + serverName,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	output, err := exec.New().CommandContext(ctx, ts.cfg.EKSConfig.KubectlPath, args...).CombinedOutput()
+	cancel()
+	if err != nil {
+		return fmt.Errorf("'kubectl config set-cluster' failed (output %q, error %v)", strings.TrimSpace(string(output)), err)
+	}
+	ts.cfg.Logger.Info("updated KUBECONFIG with endpoint tunnel", zap.String("cluster", cluster), zap.String("server", server))
+	return nil
+}
+
+func dialable(addr string) bool {
+	conn, err := net.DialTimeout("tcp", addr, 2*time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func isAlreadyExists(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && strings.Contains(apiErr.ErrorCode(), "EntityAlreadyExists")
+}
+
+func isNotFound(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && (strings.Contains(apiErr.ErrorCode(), "NotFound") || strings.Contains(apiErr.ErrorCode(), "NoSuchEntity"))
+}
@@ -0,0 +1,101 @@
+package cluster
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"go.uber.org/zap"
+)
+
+// DownloadControlPlaneLogs exports the control plane log streams from
+// CloudWatch, from cluster creation to now, writing one file per stream
+// (e.g. "kube-apiserver-audit-[ID].log") under "dir".
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/control-plane-logs.html
+func (ts *tester) DownloadControlPlaneLogs(dir string) error {
+	cur := ts.cfg.EKSConfig.ControlPlaneLogging
+	if !cur.IsEnabled() || !cur.FetchLogs {
+		ts.cfg.Logger.Info("control plane logging disabled; skipping log download")
+		return nil
+	}
+	if ts.cfg.CWLogsAPI == nil {
+		return fmt.Errorf("no CloudWatch Logs API for %q", cur.LogGroupName)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	input := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName: aws_v2.String(cur.LogGroupName),
+		EndTime:      aws_v2.Int64(time.Now().UnixNano() / int64(time.Millisecond)),
+	}
+	if start := ts.cfg.EKSConfig.Status.TimeFrameCreate.StartUTC; !start.IsZero() {
+		input.StartTime = aws_v2.Int64(start.UnixNano() / int64(time.Millisecond))
+	}
+	ts.cfg.Logger.Info("downloading control plane logs",
+		zap.String("log-group-name", cur.LogGroupName),
+		zap.Strings("types", cur.Types),
+		zap.String("dir", dir),
+	)
+
+	files := make(map[string]*os.File)
+	writers := make(map[string]*bufio.Writer)
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	var werr error
+	events := 0
+	err := ts.cfg.CWLogsAPI.FilterLogEventsPages(input, func(out *cloudwatchlogs.FilterLogEventsOutput, _ bool) bool {
+		for _, ev := range out.Events {
+			stream := aws_v2.ToString(ev.LogStreamName)
+			w, ok := writers[stream]
+			if !ok {
+				f, err := os.OpenFile(filepath.Join(dir, stream+".log"), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+				if err != nil {
+					werr = err
+					return false
+				}
+				files[stream] = f
+				w = bufio.NewWriter(f)
+				writers[stream] = w
+			}
+			if _, werr = fmt.Fprintln(w, aws_v2.ToString(ev.Message)); werr != nil {
+				return false
+			}
+			events++
+		}
+		select {
+		case <-ts.cfg.Stopc:
+			ts.cfg.Logger.Warn("control plane log download aborted")
+			return false
+		default:
+			return true
+		}
+	})
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to filter log events", zap.String("log-group-name", cur.LogGroupName), zap.Error(err))
+		return err
+	}
+	if werr != nil {
+		return werr
+	}
+	for _, w := range writers {
+		if err = w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	ts.cfg.Logger.Info("downloaded control plane logs",
+		zap.String("log-group-name", cur.LogGroupName),
+		zap.Int("streams", len(files)),
+		zap.Int("events", events),
+	)
+	return nil
+}
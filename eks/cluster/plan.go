@@ -0,0 +1,41 @@
+package cluster
+
+import (
+	"github.com/aws/aws-k8s-tester/eks/plan"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"go.uber.org/zap"
+)
+
+// Plan renders the cluster role policy documents and
+// the EKS create cluster request, without calling AWS APIs.
+func Plan(lg *zap.Logger, cfg *eksconfig.Config) (docs []plan.Document, err error) {
+	if cfg.Role.Create {
+		docs, err = planRole(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	doc, err := plan.NewPayload("cluster/create-cluster.json", newCreateClusterInput(lg, cfg, clusterSubnetIDs(cfg)))
+	if err != nil {
+		return nil, err
+	}
+	return append(docs, doc), nil
+}
+
+func planRole(cfg *eksconfig.Config) (docs []plan.Document, err error) {
+	doc, err := plan.NewPolicy("cluster/role-assume-role-policy.json", createAssumeRolePolicyDocument(cfg.Role.ServicePrincipals))
+	if err != nil {
+		return nil, err
+	}
+	docs = append(docs, doc)
+	doc, err = plan.NewPolicy("cluster/role-policy.json", createRolePolicyDocument(cfg.Partition, cfg.S3.BucketName))
+	if err != nil {
+		return nil, err
+	}
+	docs = append(docs, doc)
+	doc, err = plan.NewJSON("cluster/role-managed-policy-arns.json", cfg.Role.ManagedPolicyARNs)
+	if err != nil {
+		return nil, err
+	}
+	return append(docs, doc), nil
+}
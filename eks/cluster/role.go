@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"strings"
 	"time"
 
+	pkg_aws "github.com/aws/aws-k8s-tester/pkg/aws"
 	aws_iam "github.com/aws/aws-k8s-tester/pkg/aws/iam"
 	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
 	aws_iam_v2 "github.com/aws/aws-sdk-go-v2/service/iam"
@@ -31,7 +33,7 @@ func (ts *tester) createRole() error {
 			[]string{
 				// Prior to April 16, 2020, AmazonEKSServicePolicy was also required and the suggested name was eksServiceRole. With the AWSServiceRoleForAmazonEKS service-linked role, that policy is no longer required for clusters created on or after April 16, 2020.
 				// ref. https://docs.aws.amazon.com/eks/latest/userguide/service_IAM_role.html
-				"arn:aws:iam::aws:policy/AmazonEKSClusterPolicy",
+				pkg_aws.PolicyARN(ts.cfg.EKSConfig.Partition, "AmazonEKSClusterPolicy"),
 			},
 		)
 	}
@@ -95,14 +97,15 @@ func (ts *tester) deleteRole() error {
 
 func (ts *tester) _createRole() error {
 	ts.cfg.Logger.Info("creating role", zap.String("name", ts.cfg.EKSConfig.Role.Name))
-	out, err := ts.cfg.IAMAPIV2.CreateRole(
-		context.Background(),
-		&aws_iam_v2.CreateRoleInput{
-			RoleName:                 aws_v2.String(ts.cfg.EKSConfig.Role.Name),
-			Path:                     aws_v2.String("/"),
-			AssumeRolePolicyDocument: aws_v2.String(createAssumeRolePolicyDocument(ts.cfg.EKSConfig.Role.ServicePrincipals)),
-		},
-	)
+	input := &aws_iam_v2.CreateRoleInput{
+		RoleName:                 aws_v2.String(ts.cfg.EKSConfig.Role.Name),
+		Path:                     aws_v2.String("/"),
+		AssumeRolePolicyDocument: aws_v2.String(createAssumeRolePolicyDocument(ts.cfg.EKSConfig.Role.ServicePrincipals)),
+	}
+	if ts.cfg.EKSConfig.Role.PermissionsBoundaryARN != "" {
+		input.PermissionsBoundary = aws_v2.String(ts.cfg.EKSConfig.Role.PermissionsBoundaryARN)
+	}
+	out, err := ts.cfg.IAMAPIV2.CreateRole(context.Background(), input)
 	if err != nil {
 		return err
 	}
@@ -151,12 +154,20 @@ func (ts *tester) createPolicy() error {
 	if ts.cfg.EKSConfig.Role.PolicyName == "" {
 		return errors.New("emtpy PolicyName")
 	}
-	ts.cfg.Logger.Info("creating policy", zap.String("name", ts.cfg.EKSConfig.Role.PolicyName))
+	doc := createRolePolicyDocument(ts.cfg.EKSConfig.Partition, ts.cfg.EKSConfig.S3.BucketName)
+	// grant write permission in case of overwrites
+	if err := ioutil.WriteFile(ts.cfg.EKSConfig.Role.PolicyPath, []byte(doc), 0600); err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("creating policy",
+		zap.String("name", ts.cfg.EKSConfig.Role.PolicyName),
+		zap.String("policy-path", ts.cfg.EKSConfig.Role.PolicyPath),
+	)
 	pout, err := ts.cfg.IAMAPIV2.CreatePolicy(
 		context.Background(),
 		&aws_iam_v2.CreatePolicyInput{
 			PolicyName:     aws_v2.String(ts.cfg.EKSConfig.Role.PolicyName),
-			PolicyDocument: aws_v2.String(createRolePolicyDocument(ts.cfg.EKSConfig.Partition, ts.cfg.EKSConfig.S3.BucketName)),
+			PolicyDocument: aws_v2.String(doc),
 		},
 	)
 	if err != nil {
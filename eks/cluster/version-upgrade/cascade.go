@@ -0,0 +1,165 @@
+package versionupgrade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"go.uber.org/zap"
+)
+
+// upgradeMNGs upgrades all managed node groups to the target version.
+// Node groups with "VersionUpgrade" already enabled keep their settings.
+func (ts *tester) upgradeMNGs(targetVersion string) error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnManagedNodeGroups not enabled")
+	}
+	if ts.cfg.MNGUpgrader == nil {
+		return errors.New("MNGUpgrader not defined")
+	}
+
+	names := make([]string, 0, len(ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs))
+	for name := range ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cur := ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs[name]
+		if cur.VersionUpgrade != nil && cur.VersionUpgrade.Enable {
+			continue
+		}
+		ts.cfg.Logger.Info("enabling MNG version upgrade", zap.String("mng-name", name), zap.String("target-version", targetVersion))
+		cur.VersionUpgrade = &eksconfig.MNGVersionUpgrade{
+			Enable:               true,
+			Version:              targetVersion,
+			VersionValue:         ts.cfg.EKSConfig.AddOnClusterVersionUpgrade.VersionValue,
+			WorkloadReplicas:     eksconfig.DefaultMNGVersionUpgradeWorkloadReplicas,
+			WorkloadMinAvailable: eksconfig.DefaultMNGVersionUpgradeWorkloadReplicas - 1,
+		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs[name] = cur
+		})
+	}
+	ts.cfg.EKSConfig.Sync()
+
+	return ts.cfg.MNGUpgrader.Upgrade()
+}
+
+// errAddOnNotInstalled is returned when the add-on is not
+// installed via EKS add-on API, thus cannot be upgraded.
+var errAddOnNotInstalled = errors.New("not installed as EKS managed add-on")
+
+// describeAddOnVersion returns the current version of the EKS managed add-on.
+func (ts *tester) describeAddOnVersion(addOnName string) (string, error) {
+	out, err := ts.cfg.EKSAPI.DescribeAddon(&eks.DescribeAddonInput{
+		ClusterName: aws.String(ts.cfg.EKSConfig.Name),
+		AddonName:   aws.String(addOnName),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == eks.ErrCodeResourceNotFoundException {
+			return "", errAddOnNotInstalled
+		}
+		return "", err
+	}
+	if out.Addon == nil {
+		return "", errAddOnNotInstalled
+	}
+	return aws.StringValue(out.Addon.AddonVersion), nil
+}
+
+// upgradeAddOn upgrades the EKS managed add-on to the default version
+// for the target cluster version, and returns the new add-on version.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/managing-add-ons.html
+func (ts *tester) upgradeAddOn(addOnName string, targetVersion string) (string, error) {
+	from, err := ts.describeAddOnVersion(addOnName)
+	if err != nil {
+		return "", err
+	}
+
+	vout, err := ts.cfg.EKSAPI.DescribeAddonVersions(&eks.DescribeAddonVersionsInput{
+		AddonName:         aws.String(addOnName),
+		KubernetesVersion: aws.String(targetVersion),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe add-on %q versions (%v)", addOnName, err)
+	}
+	to := ""
+	for _, info := range vout.Addons {
+		for _, v := range info.AddonVersions {
+			if to == "" {
+				// latest version is listed first
+				to = aws.StringValue(v.AddonVersion)
+			}
+			for _, c := range v.Compatibilities {
+				if aws.StringValue(c.ClusterVersion) == targetVersion && aws.BoolValue(c.DefaultVersion) {
+					to = aws.StringValue(v.AddonVersion)
+				}
+			}
+		}
+	}
+	if to == "" {
+		return "", fmt.Errorf("no add-on %q version found for %q", addOnName, targetVersion)
+	}
+	if to == from {
+		ts.cfg.Logger.Info("add-on already at target version", zap.String("add-on", addOnName), zap.String("version", to))
+		return to, nil
+	}
+
+	ts.cfg.Logger.Info("updating add-on",
+		zap.String("add-on", addOnName),
+		zap.String("from", from),
+		zap.String("to", to),
+	)
+	uout, err := ts.cfg.EKSAPI.UpdateAddon(&eks.UpdateAddonInput{
+		ClusterName:      aws.String(ts.cfg.EKSConfig.Name),
+		AddonName:        aws.String(addOnName),
+		AddonVersion:     aws.String(to),
+		ResolveConflicts: aws.String(eks.ResolveConflictsOverwrite),
+	})
+	if err != nil {
+		return to, fmt.Errorf("failed to update add-on %q (%v)", addOnName, err)
+	}
+	reqID := ""
+	if uout.Update != nil {
+		reqID = aws.StringValue(uout.Update.Id)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Minute)
+	defer cancel()
+	for {
+		select {
+		case <-ts.cfg.Stopc:
+			return to, errors.New("add-on update aborted")
+		case <-ctx.Done():
+			return to, fmt.Errorf("add-on %q update timed out (%v)", addOnName, ctx.Err())
+		case <-time.After(15 * time.Second):
+		}
+
+		dout, err := ts.cfg.EKSAPI.DescribeUpdate(&eks.DescribeUpdateInput{
+			Name:      aws.String(ts.cfg.EKSConfig.Name),
+			AddonName: aws.String(addOnName),
+			UpdateId:  aws.String(reqID),
+		})
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe add-on update", zap.String("add-on", addOnName), zap.Error(err))
+			continue
+		}
+		if dout.Update == nil {
+			continue
+		}
+		status := aws.StringValue(dout.Update.Status)
+		ts.cfg.Logger.Info("polled add-on update", zap.String("add-on", addOnName), zap.String("status", status))
+		switch status {
+		case eks.UpdateStatusSuccessful:
+			return to, nil
+		case eks.UpdateStatusFailed, eks.UpdateStatusCancelled:
+			return to, fmt.Errorf("add-on %q update %s %+v", addOnName, status, dout.Update.Errors)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package versionupgrade
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"go.uber.org/zap"
+)
+
+// writeRollbackReport writes the per-phase results with the steps to
+// roll back each completed phase. EKS does not support downgrading the
+// control plane or node group Kubernetes versions, so those must be recreated.
+func (ts *tester) writeRollbackReport() error {
+	cur := ts.cfg.EKSConfig.AddOnClusterVersionUpgrade
+
+	buf := bytes.NewBuffer(nil)
+	fmt.Fprintf(buf, "cluster %q version upgrade to %q failed\n\n", ts.cfg.EKSConfig.Name, cur.Version)
+	for _, phase := range cur.Phases {
+		fmt.Fprintf(buf, "[%s] %s (%q -> %q)\n", phase.Status, phase.Name, phase.From, phase.To)
+		if phase.Error != "" {
+			fmt.Fprintf(buf, "  error: %s\n", phase.Error)
+		}
+		if phase.Status != eksconfig.ClusterVersionUpgradePhaseStatusSuccessful {
+			continue
+		}
+		switch {
+		case phase.Name == "control-plane":
+			fmt.Fprintf(buf, "  rollback: control plane cannot be downgraded; recreate the cluster with version %q\n", phase.From)
+		case phase.Name == "managed-node-groups":
+			fmt.Fprintf(buf, "  rollback: node groups cannot be downgraded; recreate the node groups with version %q\n", phase.From)
+		case phase.From != "" && phase.From != phase.To:
+			fmt.Fprintf(buf, "  rollback: aws eks update-addon --cluster-name %s --addon-name %s --addon-version %s --resolve-conflicts OVERWRITE\n",
+				ts.cfg.EKSConfig.Name,
+				phase.Name[len("add-on/"):],
+				phase.From,
+			)
+		}
+	}
+
+	fmt.Fprintf(ts.cfg.LogWriter, "\n\nrollback report:\n%s\n", buf.String())
+	if err := ioutil.WriteFile(cur.RollbackReportPath, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("wrote rollback report", zap.String("path", cur.RollbackReportPath))
+	return nil
+}
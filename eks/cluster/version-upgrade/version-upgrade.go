@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-k8s-tester/eks/cluster/wait"
@@ -21,6 +22,20 @@ import (
 	"go.uber.org/zap"
 )
 
+// Upgrader defines EKS cluster version upgrade interface.
+type Upgrader interface {
+	eks_tester.Tester
+	// Upgrade upgrades the EKS control plane to the target version,
+	// and optionally cascades the upgrade to managed node groups and
+	// EKS managed add-ons. If any phase fails, it writes a rollback report.
+	Upgrade(targetVersion string) error
+}
+
+// MNGUpgrader upgrades EKS managed node groups.
+type MNGUpgrader interface {
+	Upgrade() error
+}
+
 // Config defines version upgrade configuration.
 type Config struct {
 	Logger    *zap.Logger
@@ -29,18 +44,39 @@ type Config struct {
 	EKSConfig *eksconfig.Config
 	K8SClient k8s_client.EKS
 	EKSAPI    eksiface.EKSAPI
+	// MNGUpgrader is used to cascade the upgrade to managed node groups.
+	// Can be nil if "CascadeManagedNodeGroups" is false.
+	MNGUpgrader MNGUpgrader
 }
 
 var pkgName = reflect.TypeOf(tester{}).PkgPath()
 
 func (ts *tester) Name() string { return pkgName }
 
-// New creates a new Job tester.
-func New(cfg Config) eks_tester.Tester {
+// New creates a new Upgrader.
+func New(cfg Config) Upgrader {
 	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
 	return &tester{cfg: cfg}
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:      "cluster-version-upgrade",
+		Exclusive: true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:      cfg.Logger,
+				LogWriter:   cfg.LogWriter,
+				Stopc:       cfg.Stopc,
+				EKSConfig:   cfg.EKSConfig,
+				K8SClient:   cfg.K8SClient,
+				EKSAPI:      cfg.EKSAPI,
+				MNGUpgrader: cfg.MNGUpgrader,
+			}), cfg.EKSConfig.IsEnabledAddOnClusterVersionUpgrade)
+		},
+	})
+}
+
 type tester struct {
 	cfg Config
 }
@@ -69,24 +105,111 @@ func (ts *tester) Create() (err error) {
 	}
 
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
-	ts.cfg.EKSConfig.AddOnClusterVersionUpgrade.Created = true
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnClusterVersionUpgrade.Created = true
+	})
 	createStart := time.Now()
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnClusterVersionUpgrade.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnClusterVersionUpgrade.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
+	return ts.Upgrade(ts.cfg.EKSConfig.AddOnClusterVersionUpgrade.Version)
+}
+
+func (ts *tester) Upgrade(targetVersion string) (err error) {
+	if ts.cfg.EKSConfig.AddOnClusterVersionUpgrade == nil {
+		return errors.New("AddOnClusterVersionUpgrade not defined")
+	}
+	cur := ts.cfg.EKSConfig.AddOnClusterVersionUpgrade
+	cur.VersionValue, err = strconv.ParseFloat(targetVersion, 64)
+	if err != nil {
+		return fmt.Errorf("cannot parse target version %q (%v)", targetVersion, err)
+	}
+	cur.Version = targetVersion
+	cur.Phases = nil
+	ts.cfg.EKSConfig.Sync()
+
+	// control plane must be upgraded before node groups and add-ons,
+	// so any failure skips the remaining phases
+	failed := false
+	runPhase := func(name string, from string, run func() (to string, err error)) {
+		phase := eksconfig.ClusterVersionUpgradePhase{Name: name, From: from}
+		if failed {
+			phase.Status = eksconfig.ClusterVersionUpgradePhaseStatusSkipped
+			phase.Error = "previous phase failed"
+			cur.Phases = append(cur.Phases, phase)
+			ts.cfg.EKSConfig.Sync()
+			return
+		}
+		ts.cfg.Logger.Info("starting cluster version upgrade phase", zap.String("phase", name))
+		start := time.Now()
+		to, perr := run()
+		phase.TimeFrame = timeutil.NewTimeFrame(start, time.Now())
+		phase.To = to
+		switch {
+		case perr == errAddOnNotInstalled:
+			phase.Status = eksconfig.ClusterVersionUpgradePhaseStatusSkipped
+			phase.Error = perr.Error()
+		case perr != nil:
+			phase.Status = eksconfig.ClusterVersionUpgradePhaseStatusFailed
+			phase.Error = perr.Error()
+			failed = true
+			err = fmt.Errorf("cluster version upgrade phase %q failed (%v)", name, perr)
+		default:
+			phase.Status = eksconfig.ClusterVersionUpgradePhaseStatusSuccessful
+		}
+		ts.cfg.Logger.Info("completed cluster version upgrade phase",
+			zap.String("phase", name),
+			zap.String("status", phase.Status),
+			zap.String("took", phase.TimeFrame.TookString),
+			zap.Error(perr),
+		)
+		cur.Phases = append(cur.Phases, phase)
+		ts.cfg.EKSConfig.Sync()
+	}
+
+	runPhase("control-plane", ts.cfg.EKSConfig.Version, func() (string, error) {
+		return targetVersion, ts.upgradeControlPlane(targetVersion)
+	})
+	if cur.CascadeManagedNodeGroups {
+		runPhase("managed-node-groups", ts.cfg.EKSConfig.Version, func() (string, error) {
+			return targetVersion, ts.upgradeMNGs(targetVersion)
+		})
+	}
+	if cur.CascadeAddOns {
+		for _, name := range cur.AddOnNames {
+			addOnName := name
+			from, _ := ts.describeAddOnVersion(addOnName)
+			runPhase("add-on/"+addOnName, from, func() (string, error) {
+				return ts.upgradeAddOn(addOnName, targetVersion)
+			})
+		}
+	}
+
+	if failed {
+		if rerr := ts.writeRollbackReport(); rerr != nil {
+			ts.cfg.Logger.Warn("failed to write rollback report", zap.Error(rerr))
+		}
+		return err
+	}
+	return nil
+}
+
+// upgradeControlPlane upgrades the EKS control plane to the target version,
+// and waits for the API server to report the new version and be healthy.
+func (ts *tester) upgradeControlPlane(targetVersion string) (err error) {
 	ts.cfg.Logger.Info("starting cluster version upgrade",
 		zap.String("name", ts.cfg.EKSConfig.Name),
 		zap.String("from", ts.cfg.EKSConfig.Version),
-		zap.String("to", ts.cfg.EKSConfig.AddOnClusterVersionUpgrade.Version),
+		zap.String("to", targetVersion),
 	)
 	var updateOut *eks.UpdateClusterVersionOutput
 	updateOut, err = ts.cfg.EKSAPI.UpdateClusterVersion(&eks.UpdateClusterVersionInput{
 		Name:    aws.String(ts.cfg.EKSConfig.Name),
-		Version: aws.String(ts.cfg.EKSConfig.AddOnClusterVersionUpgrade.Version),
+		Version: aws.String(targetVersion),
 	})
 	if err != nil {
 		ts.cfg.Logger.Warn("cluster version upgrade request failed", zap.String("name", ts.cfg.EKSConfig.Name), zap.Error(err))
@@ -127,13 +250,13 @@ func (ts *tester) Create() (err error) {
 	}
 
 	// may take a while to shut down the last master instance with old cluster version
-	ts.cfg.Logger.Info("checking EKS server version after cluster version upgrade", zap.String("target-version", ts.cfg.EKSConfig.AddOnClusterVersionUpgrade.Version))
+	ts.cfg.Logger.Info("checking EKS server version after cluster version upgrade", zap.String("target-version", targetVersion))
 	waitDur, retryStart := 5*time.Minute, time.Now()
 	for time.Since(retryStart) < waitDur {
 		select {
 		case <-ts.cfg.Stopc:
 			ts.cfg.Logger.Warn("version check aborted")
-			return nil
+			return errors.New("version check aborted")
 		case <-time.After(5 * time.Second):
 		}
 
@@ -168,7 +291,7 @@ func (ts *tester) Create() (err error) {
 		select {
 		case <-ts.cfg.Stopc:
 			ts.cfg.Logger.Warn("health check aborted")
-			return nil
+			return errors.New("health check aborted")
 		case <-time.After(5 * time.Second):
 		}
 		err = ts.cfg.K8SClient.CheckHealth()
@@ -184,7 +307,7 @@ func (ts *tester) Create() (err error) {
 
 	ts.cfg.Logger.Info("completed cluster version upgrade",
 		zap.String("from", ts.cfg.EKSConfig.Version),
-		zap.String("to", ts.cfg.EKSConfig.AddOnClusterVersionUpgrade.Version),
+		zap.String("to", targetVersion),
 	)
 	return nil
 }
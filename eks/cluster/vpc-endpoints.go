@@ -0,0 +1,267 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	smithy "github.com/aws/smithy-go"
+	"go.uber.org/zap"
+)
+
+// VPC endpoints let nodes in the private subnets reach AWS services
+// without NAT gateways.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/private-clusters.html
+
+// gatewayEndpointServices are the services with gateway endpoints,
+// routed from the private route tables.
+var gatewayEndpointServices = map[string]struct{}{
+	"s3":       {},
+	"dynamodb": {},
+}
+
+// AWS::EC2::VPCEndpoint
+func (ts *tester) createVPCEndpoints() error {
+	ts.cfg.Logger.Info("creating VPC endpoints", zap.Strings("services", ts.cfg.EKSConfig.VPC.EndpointServices))
+	if len(ts.cfg.EKSConfig.VPC.EndpointIDs) > 0 {
+		ts.cfg.Logger.Info("VPC endpoints already created", zap.Strings("endpoint-ids", ts.cfg.EKSConfig.VPC.EndpointIDs))
+		return nil
+	}
+	if len(ts.cfg.EKSConfig.VPC.PrivateSubnetIDs) == 0 {
+		return errors.New("VPC endpoints require private subnets")
+	}
+	if err := ts.createVPCEndpointSecurityGroup(); err != nil {
+		return err
+	}
+
+	for _, svc := range ts.cfg.EKSConfig.VPC.EndpointServices {
+		input := &aws_ec2_v2.CreateVpcEndpointInput{
+			VpcId:       aws_v2.String(ts.cfg.EKSConfig.VPC.ID),
+			ServiceName: aws_v2.String(fmt.Sprintf("com.amazonaws.%s.%s", ts.cfg.EKSConfig.Region, svc)),
+			TagSpecifications: []aws_ec2_v2_types.TagSpecification{
+				{
+					ResourceType: aws_ec2_v2_types.ResourceType("vpc-endpoint"),
+					Tags: []aws_ec2_v2_types.Tag{
+						{
+							Key:   aws_v2.String("Name"),
+							Value: aws_v2.String(fmt.Sprintf("%s-vpc-endpoint-%s", ts.cfg.EKSConfig.Name, svc)),
+						},
+						{
+							Key:   aws_v2.String(eksconfig.RunTagKey),
+							Value: aws_v2.String(ts.cfg.EKSConfig.Name),
+						},
+					},
+				},
+			},
+		}
+		if _, ok := gatewayEndpointServices[svc]; ok {
+			input.VpcEndpointType = aws_ec2_v2_types.VpcEndpointTypeGateway
+			input.RouteTableIds = ts.cfg.EKSConfig.VPC.PrivateRouteTableIDs
+		} else {
+			input.VpcEndpointType = aws_ec2_v2_types.VpcEndpointTypeInterface
+			input.SubnetIds = ts.cfg.EKSConfig.VPC.PrivateSubnetIDs
+			input.SecurityGroupIds = []string{ts.cfg.EKSConfig.VPC.EndpointSecurityGroupID}
+			input.PrivateDnsEnabled = aws_v2.Bool(true)
+		}
+		out, err := ts.cfg.EC2APIV2.CreateVpcEndpoint(context.Background(), input)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to create VPC endpoint", zap.String("service", svc), zap.Error(err))
+			return err
+		}
+		id := aws_v2.ToString(out.VpcEndpoint.VpcEndpointId)
+		ts.cfg.EKSConfig.VPC.EndpointIDs = append(ts.cfg.EKSConfig.VPC.EndpointIDs, id)
+		ts.cfg.EKSConfig.Sync()
+		ts.cfg.Logger.Info("created VPC endpoint",
+			zap.String("service", svc),
+			zap.String("type", string(input.VpcEndpointType)),
+			zap.String("endpoint-id", id),
+		)
+	}
+
+	if err := ts.waitVPCEndpoints(false); err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("created VPC endpoints", zap.Strings("endpoint-ids", ts.cfg.EKSConfig.VPC.EndpointIDs))
+	return nil
+}
+
+// createVPCEndpointSecurityGroup allows HTTPS from the VPC
+// to the interface endpoints.
+func (ts *tester) createVPCEndpointSecurityGroup() error {
+	if ts.cfg.EKSConfig.VPC.EndpointSecurityGroupID != "" {
+		return nil
+	}
+	ts.cfg.Logger.Info("creating VPC endpoint security group")
+	sout, err := ts.cfg.EC2APIV2.CreateSecurityGroup(
+		context.Background(),
+		&aws_ec2_v2.CreateSecurityGroupInput{
+			GroupName:   aws_v2.String(fmt.Sprintf("%s-vpc-endpoint-security-group", ts.cfg.EKSConfig.Name)),
+			Description: aws_v2.String("HTTPS from VPC to interface endpoints"),
+			VpcId:       aws_v2.String(ts.cfg.EKSConfig.VPC.ID),
+		},
+	)
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to create VPC endpoint security group", zap.Error(err))
+		return err
+	}
+	ts.cfg.EKSConfig.VPC.EndpointSecurityGroupID = aws_v2.ToString(sout.GroupId)
+	ts.cfg.EKSConfig.Sync()
+
+	ipRanges := make([]aws_ec2_v2_types.IpRange, 0, len(ts.cfg.EKSConfig.VPC.CIDRs))
+	for _, cidr := range ts.cfg.EKSConfig.VPC.CIDRs {
+		ipRanges = append(ipRanges, aws_ec2_v2_types.IpRange{CidrIp: aws_v2.String(cidr)})
+	}
+	_, err = ts.cfg.EC2APIV2.AuthorizeSecurityGroupIngress(
+		context.Background(),
+		&aws_ec2_v2.AuthorizeSecurityGroupIngressInput{
+			GroupId: aws_v2.String(ts.cfg.EKSConfig.VPC.EndpointSecurityGroupID),
+			IpPermissions: []aws_ec2_v2_types.IpPermission{
+				{
+					IpProtocol: aws_v2.String("tcp"),
+					FromPort:   aws_v2.Int32(443),
+					ToPort:     aws_v2.Int32(443),
+					IpRanges:   ipRanges,
+				},
+			},
+		},
+	)
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to authorize ingress", zap.Error(err))
+		return err
+	}
+	ts.cfg.Logger.Info("created VPC endpoint security group", zap.String("security-group-id", ts.cfg.EKSConfig.VPC.EndpointSecurityGroupID))
+	return nil
+}
+
+// waitVPCEndpoints waits for all endpoints to be available,
+// or to be deleted.
+func (ts *tester) waitVPCEndpoints(deleted bool) error {
+	ids := make([]string, 0, len(ts.cfg.EKSConfig.VPC.EndpointIDs))
+	for _, id := range ts.cfg.EKSConfig.VPC.EndpointIDs {
+		if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	waitStart := time.Now()
+	for time.Since(waitStart) < 10*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("VPC endpoint wait aborted")
+		case <-time.After(10 * time.Second):
+		}
+		out, err := ts.cfg.EC2APIV2.DescribeVpcEndpoints(
+			context.Background(),
+			&aws_ec2_v2.DescribeVpcEndpointsInput{VpcEndpointIds: ids},
+		)
+		if err != nil {
+			var apiErr smithy.APIError
+			if deleted && errors.As(err, &apiErr) && strings.Contains(apiErr.ErrorCode(), "NotFound") {
+				return nil
+			}
+			ts.cfg.Logger.Warn("failed to describe VPC endpoints", zap.Error(err))
+			continue
+		}
+		ready := 0
+		for _, ep := range out.VpcEndpoints {
+			state := string(ep.State)
+			switch {
+			case deleted && strings.EqualFold(state, "deleted"):
+				ready++
+			case !deleted && strings.EqualFold(state, "available"):
+				ready++
+			case strings.EqualFold(state, "failed"):
+				return fmt.Errorf("VPC endpoint %q failed", aws_v2.ToString(ep.VpcEndpointId))
+			}
+		}
+		if deleted {
+			// deleted endpoints eventually disappear from the response
+			ready += len(ids) - len(out.VpcEndpoints)
+		}
+		ts.cfg.Logger.Info("polled VPC endpoints", zap.Bool("deleted", deleted), zap.Int("ready", ready), zap.Int("total", len(ids)))
+		if ready == len(ids) {
+			return nil
+		}
+	}
+	return fmt.Errorf("VPC endpoints %v not ready", ids)
+}
+
+func (ts *tester) deleteVPCEndpoints() error {
+	ts.cfg.Logger.Info("deleting VPC endpoints")
+	if ts.cfg.EKSConfig.VPC.ID == "" {
+		return nil
+	}
+
+	var ids []string
+	for _, id := range ts.cfg.EKSConfig.VPC.EndpointIDs {
+		if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > 0 {
+		out, err := ts.cfg.EC2APIV2.DeleteVpcEndpoints(
+			context.Background(),
+			&aws_ec2_v2.DeleteVpcEndpointsInput{VpcEndpointIds: ids},
+		)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to delete VPC endpoints", zap.Error(err))
+			return err
+		}
+		for _, item := range out.Unsuccessful {
+			if item.Error != nil && !strings.Contains(aws_v2.ToString(item.Error.Code), "NotFound") {
+				return fmt.Errorf("failed to delete VPC endpoint %q (%s)", aws_v2.ToString(item.ResourceId), aws_v2.ToString(item.Error.Message))
+			}
+		}
+		// interface endpoint network interfaces must be released
+		// before deleting the subnets and the security group
+		if err = ts.waitVPCEndpoints(true); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			ts.cfg.EKSConfig.Status.DeletedResources[id] = "VPC.EndpointID"
+		}
+		ts.cfg.EKSConfig.Sync()
+	}
+
+	sgID := ts.cfg.EKSConfig.VPC.EndpointSecurityGroupID
+	if sgID == "" {
+		return nil
+	}
+	if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[sgID]; ok {
+		return nil
+	}
+	var err error
+	for i := 0; i < 10; i++ {
+		_, err = ts.cfg.EC2APIV2.DeleteSecurityGroup(
+			context.Background(),
+			&aws_ec2_v2.DeleteSecurityGroupInput{GroupId: aws_v2.String(sgID)},
+		)
+		if err == nil {
+			break
+		}
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && strings.Contains(apiErr.ErrorCode(), "NotFound") {
+			err = nil
+			break
+		}
+		// e.g. DependencyViolation until the endpoint ENIs are released
+		ts.cfg.Logger.Warn("failed to delete VPC endpoint security group; retrying", zap.Error(err))
+		time.Sleep(10 * time.Second)
+	}
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.Status.DeletedResources[sgID] = "VPC.EndpointSecurityGroupID"
+	ts.cfg.EKSConfig.Sync()
+	ts.cfg.Logger.Info("deleted VPC endpoints")
+	return nil
+}
@@ -0,0 +1,278 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	aws_elbv2_v2 "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	"go.uber.org/zap"
+)
+
+// describeExistingVPC loads the subnets and the security group of the
+// existing VPC, either created from the previous run or provided by the
+// user with "VPC.Create" false. User-provided resources are validated,
+// and never deleted (see "deleteVPC").
+func (ts *tester) describeExistingVPC() error {
+	ts.cfg.Logger.Info("querying ELBv2", zap.String("vpc-id", ts.cfg.EKSConfig.VPC.ID))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	output, err := ts.cfg.ELBV2APIV2.DescribeLoadBalancers(
+		ctx,
+		&aws_elbv2_v2.DescribeLoadBalancersInput{},
+	)
+	cancel()
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to describe ELBv2", zap.Error(err))
+	} else {
+		for _, ev := range output.LoadBalancers {
+			arn := aws_v2.ToString(ev.LoadBalancerArn)
+			vpcID := aws_v2.ToString(ev.VpcId)
+			if vpcID == ts.cfg.EKSConfig.VPC.ID {
+				ts.cfg.Logger.Warn("found ELBv2 for this VPC; may overlap with the other cluster",
+					zap.String("vpc-id", ts.cfg.EKSConfig.VPC.ID),
+					zap.String("elb-arn", arn),
+				)
+			} else {
+				ts.cfg.Logger.Info("found ELBv2 for other VPCs", zap.String("vpc-id", vpcID), zap.String("elb-arn", arn))
+			}
+		}
+	}
+
+	userSubnets := !ts.cfg.EKSConfig.VPC.Create && len(ts.cfg.EKSConfig.VPC.PublicSubnetIDs) > 0
+	var subnets []aws_ec2_v2_types.Subnet
+	if userSubnets {
+		subnets, err = ts.describeUserSubnets()
+	} else {
+		subnets, err = ts.discoverSubnets()
+	}
+	if err != nil {
+		return err
+	}
+
+	if !ts.cfg.EKSConfig.VPC.Create && ts.cfg.EKSConfig.VPC.SecurityGroupID != "" {
+		err = ts.describeUserSecurityGroup()
+	} else {
+		err = ts.discoverSecurityGroup()
+	}
+	if err != nil {
+		return err
+	}
+
+	if !ts.cfg.EKSConfig.VPC.Create {
+		if err = ts.validateExistingSubnets(subnets); err != nil {
+			return err
+		}
+	}
+
+	ts.cfg.Logger.Info("using existing VPC",
+		zap.String("vpc-id", ts.cfg.EKSConfig.VPC.ID),
+		zap.Strings("public-subnet-ids", ts.cfg.EKSConfig.VPC.PublicSubnetIDs),
+		zap.Strings("private-subnet-ids", ts.cfg.EKSConfig.VPC.PrivateSubnetIDs),
+		zap.String("control-plane-security-group-id", ts.cfg.EKSConfig.VPC.SecurityGroupID),
+	)
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+// discoverSubnets finds the subnets in the VPC by the "Network" tag.
+func (ts *tester) discoverSubnets() ([]aws_ec2_v2_types.Subnet, error) {
+	ts.cfg.Logger.Info("querying subnet IDs for given VPC",
+		zap.String("vpc-id", ts.cfg.EKSConfig.VPC.ID),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	sresp, err := ts.cfg.EC2APIV2.DescribeSubnets(
+		ctx,
+		&aws_ec2_v2.DescribeSubnetsInput{
+			Filters: []aws_ec2_v2_types.Filter{
+				{
+					Name:   aws_v2.String("vpc-id"),
+					Values: []string{ts.cfg.EKSConfig.VPC.ID},
+				},
+			},
+		})
+	cancel()
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to subnets", zap.Error(err))
+		return nil, err
+	}
+
+	ts.cfg.EKSConfig.VPC.PublicSubnetIDs = make([]string, 0, len(sresp.Subnets))
+	ts.cfg.EKSConfig.VPC.PrivateSubnetIDs = make([]string, 0, len(sresp.Subnets))
+	for _, sv := range sresp.Subnets {
+		id := aws_v2.ToString(sv.SubnetId)
+		networkTagValue := subnetTag(sv, "Network")
+		ts.cfg.Logger.Info("found subnet",
+			zap.String("id", id),
+			zap.String("availability-zone", aws_v2.ToString(sv.AvailabilityZone)),
+			zap.String("network-tag", networkTagValue),
+		)
+		switch networkTagValue {
+		case "Public":
+			ts.cfg.EKSConfig.VPC.PublicSubnetIDs = append(ts.cfg.EKSConfig.VPC.PublicSubnetIDs, id)
+		case "Private":
+			ts.cfg.EKSConfig.VPC.PrivateSubnetIDs = append(ts.cfg.EKSConfig.VPC.PrivateSubnetIDs, id)
+		default:
+			return nil, fmt.Errorf("'Network' tag not found in subnet %q", id)
+		}
+	}
+	if len(ts.cfg.EKSConfig.VPC.PublicSubnetIDs) == 0 {
+		return nil, fmt.Errorf("no subnet found for VPC ID %q", ts.cfg.EKSConfig.VPC.ID)
+	}
+	return sresp.Subnets, nil
+}
+
+// describeUserSubnets describes the user-provided subnets,
+// and checks that they belong to the VPC.
+func (ts *tester) describeUserSubnets() ([]aws_ec2_v2_types.Subnet, error) {
+	ids := append(append([]string{}, ts.cfg.EKSConfig.VPC.PublicSubnetIDs...), ts.cfg.EKSConfig.VPC.PrivateSubnetIDs...)
+	ts.cfg.Logger.Info("querying user-provided subnets", zap.Strings("subnet-ids", ids))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	sresp, err := ts.cfg.EC2APIV2.DescribeSubnets(
+		ctx,
+		&aws_ec2_v2.DescribeSubnetsInput{
+			SubnetIds: ids,
+		})
+	cancel()
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to describe subnets", zap.Error(err))
+		return nil, err
+	}
+	for _, sv := range sresp.Subnets {
+		if vpcID := aws_v2.ToString(sv.VpcId); vpcID != ts.cfg.EKSConfig.VPC.ID {
+			return nil, fmt.Errorf("subnet %q belongs to VPC %q, not VPC.ID %q", aws_v2.ToString(sv.SubnetId), vpcID, ts.cfg.EKSConfig.VPC.ID)
+		}
+	}
+	return sresp.Subnets, nil
+}
+
+// discoverSecurityGroup uses a non-default security group in the VPC.
+func (ts *tester) discoverSecurityGroup() error {
+	ts.cfg.Logger.Info("querying security IDs", zap.String("vpc-id", ts.cfg.EKSConfig.VPC.ID))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	gresp, err := ts.cfg.EC2APIV2.DescribeSecurityGroups(
+		ctx,
+		&aws_ec2_v2.DescribeSecurityGroupsInput{
+			Filters: []aws_ec2_v2_types.Filter{
+				{
+					Name:   aws_v2.String("vpc-id"),
+					Values: []string{ts.cfg.EKSConfig.VPC.ID},
+				},
+			},
+		})
+	cancel()
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to security groups", zap.Error(err))
+		return err
+	}
+	for _, sg := range gresp.SecurityGroups {
+		id, name := aws_v2.ToString(sg.GroupId), aws_v2.ToString(sg.GroupName)
+		ts.cfg.Logger.Info("found security group", zap.String("id", id), zap.String("name", name))
+		if name != "default" {
+			ts.cfg.EKSConfig.VPC.SecurityGroupID = id
+		}
+	}
+	if ts.cfg.EKSConfig.VPC.SecurityGroupID == "" {
+		return fmt.Errorf("no security group found for VPC ID %q", ts.cfg.EKSConfig.VPC.ID)
+	}
+	return nil
+}
+
+func (ts *tester) describeUserSecurityGroup() error {
+	ts.cfg.Logger.Info("querying user-provided security group", zap.String("security-group-id", ts.cfg.EKSConfig.VPC.SecurityGroupID))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	gresp, err := ts.cfg.EC2APIV2.DescribeSecurityGroups(
+		ctx,
+		&aws_ec2_v2.DescribeSecurityGroupsInput{
+			GroupIds: []string{ts.cfg.EKSConfig.VPC.SecurityGroupID},
+		})
+	cancel()
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to describe security group", zap.Error(err))
+		return err
+	}
+	if len(gresp.SecurityGroups) != 1 {
+		return fmt.Errorf("security group %q not found", ts.cfg.EKSConfig.VPC.SecurityGroupID)
+	}
+	if vpcID := aws_v2.ToString(gresp.SecurityGroups[0].VpcId); vpcID != ts.cfg.EKSConfig.VPC.ID {
+		return fmt.Errorf("security group %q belongs to VPC %q, not VPC.ID %q", ts.cfg.EKSConfig.VPC.SecurityGroupID, vpcID, ts.cfg.EKSConfig.VPC.ID)
+	}
+	return nil
+}
+
+const (
+	// subnet tags for the load balancer subnet auto-discovery
+	// ref. https://docs.aws.amazon.com/eks/latest/userguide/network-load-balancing.html
+	subnetTagPublicELB   = "kubernetes.io/role/elb"
+	subnetTagInternalELB = "kubernetes.io/role/internal-elb"
+)
+
+// validateExistingSubnets checks the EKS subnet requirements.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/network_reqs.html
+func (ts *tester) validateExistingSubnets(subnets []aws_ec2_v2_types.Subnet) error {
+	public := make(map[string]struct{})
+	for _, id := range ts.cfg.EKSConfig.VPC.PublicSubnetIDs {
+		public[id] = struct{}{}
+	}
+
+	var errs []string
+	azs := make(map[string]struct{})
+	for _, sv := range subnets {
+		id := aws_v2.ToString(sv.SubnetId)
+		azs[aws_v2.ToString(sv.AvailabilityZone)] = struct{}{}
+
+		if ips := int(aws_v2.ToInt32(sv.AvailableIpAddressCount)); ips < ts.cfg.EKSConfig.VPC.SubnetMinAvailableIPs {
+			errs = append(errs, fmt.Sprintf("subnet %q has %d available IPs (expected at least %d)", id, ips, ts.cfg.EKSConfig.VPC.SubnetMinAvailableIPs))
+		}
+
+		tag := subnetTagInternalELB
+		if _, ok := public[id]; ok {
+			tag = subnetTagPublicELB
+		}
+		if subnetTag(sv, tag) != "1" {
+			errs = append(errs, fmt.Sprintf("subnet %q missing tag %q=1", id, tag))
+		}
+
+		if ts.cfg.EKSConfig.IPFamily == eksconfig.IPFamilyIPv6 && !hasIPv6CIDRBlock(sv) {
+			errs = append(errs, fmt.Sprintf("subnet %q has no IPv6 CIDR block (required for IPFamily %q)", id, ts.cfg.EKSConfig.IPFamily))
+		}
+	}
+	if len(azs) < 2 {
+		names := make([]string, 0, len(azs))
+		for az := range azs {
+			names = append(names, az)
+		}
+		sort.Strings(names)
+		errs = append(errs, fmt.Sprintf("subnets span availability zones %v (expected at least 2)", names))
+	}
+
+	if len(errs) > 0 {
+		return errors.New("invalid existing VPC subnets: " + strings.Join(errs, ", "))
+	}
+	ts.cfg.Logger.Info("validated existing VPC subnets", zap.Int("subnets", len(subnets)), zap.Int("availability-zones", len(azs)))
+	return nil
+}
+
+func subnetTag(sv aws_ec2_v2_types.Subnet, key string) string {
+	for _, tg := range sv.Tags {
+		if aws_v2.ToString(tg.Key) == key {
+			return aws_v2.ToString(tg.Value)
+		}
+	}
+	return ""
+}
+
+func hasIPv6CIDRBlock(sv aws_ec2_v2_types.Subnet) bool {
+	for _, assoc := range sv.Ipv6CidrBlockAssociationSet {
+		if assoc.Ipv6CidrBlockState != nil && assoc.Ipv6CidrBlockState.State == aws_ec2_v2_types.SubnetCidrBlockStateCodeAssociated {
+			return true
+		}
+	}
+	return false
+}
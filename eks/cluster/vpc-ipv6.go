@@ -0,0 +1,247 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	smithy "github.com/aws/smithy-go"
+	"go.uber.org/zap"
+)
+
+// IPv6 clusters require dual-stack subnets, where nodes keep their IPv4 addresses
+// and Pods are assigned the IPv6 addresses from the node subnets.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/cni-ipv6.html
+// ref. https://docs.aws.amazon.com/vpc/latest/userguide/vpc-migrate-ipv6.html
+
+// AWS::EC2::VPCCidrBlock
+func (ts *tester) associateVPCIPv6CIDRBlock() error {
+	ts.cfg.Logger.Info("associating Amazon-provided VPC IPv6 CIDR block")
+	_, err := ts.cfg.EC2APIV2.AssociateVpcCidrBlock(
+		context.Background(),
+		&aws_ec2_v2.AssociateVpcCidrBlockInput{
+			VpcId:                       aws_v2.String(ts.cfg.EKSConfig.VPC.ID),
+			AmazonProvidedIpv6CidrBlock: aws_v2.Bool(true),
+		},
+	)
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to associate VPC IPv6 CIDR block", zap.Error(err))
+		return err
+	}
+
+	retryStart := time.Now()
+	for time.Since(retryStart) < 5*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("associate VPC IPv6 CIDR block aborted")
+		case <-time.After(5 * time.Second):
+		}
+		out, err := ts.cfg.EC2APIV2.DescribeVpcs(
+			context.Background(),
+			&aws_ec2_v2.DescribeVpcsInput{VpcIds: []string{ts.cfg.EKSConfig.VPC.ID}},
+		)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe VPC", zap.Error(err))
+			continue
+		}
+		if len(out.Vpcs) != 1 {
+			return fmt.Errorf("VPC %q not found", ts.cfg.EKSConfig.VPC.ID)
+		}
+		for _, assoc := range out.Vpcs[0].Ipv6CidrBlockAssociationSet {
+			if assoc.Ipv6CidrBlockState == nil {
+				continue
+			}
+			ts.cfg.Logger.Info("polled VPC IPv6 CIDR block",
+				zap.String("ipv6-cidr-block", aws_v2.ToString(assoc.Ipv6CidrBlock)),
+				zap.String("state", string(assoc.Ipv6CidrBlockState.State)),
+			)
+			switch assoc.Ipv6CidrBlockState.State {
+			case aws_ec2_v2_types.VpcCidrBlockStateCodeAssociated:
+				ts.cfg.EKSConfig.VPC.IPv6CIDR = aws_v2.ToString(assoc.Ipv6CidrBlock)
+				ts.cfg.EKSConfig.Sync()
+				ts.cfg.Logger.Info("associated VPC IPv6 CIDR block", zap.String("ipv6-cidr-block", ts.cfg.EKSConfig.VPC.IPv6CIDR))
+				return nil
+			case aws_ec2_v2_types.VpcCidrBlockStateCodeFailed:
+				return errors.New("failed to associate VPC IPv6 CIDR block")
+			}
+		}
+	}
+	return errors.New("VPC IPv6 CIDR block not associated")
+}
+
+// assignSubnetIPv6CIDRBlocks assigns a /64 block to each public and private subnet,
+// and enables IPv6 address assignment on the new network interfaces.
+func (ts *tester) assignSubnetIPv6CIDRBlocks() error {
+	subnetIDs := make([]string, 0, len(ts.cfg.EKSConfig.VPC.PublicSubnetIDs)+len(ts.cfg.EKSConfig.VPC.PrivateSubnetIDs))
+	subnetIDs = append(subnetIDs, ts.cfg.EKSConfig.VPC.PublicSubnetIDs...)
+	subnetIDs = append(subnetIDs, ts.cfg.EKSConfig.VPC.PrivateSubnetIDs...)
+	ts.cfg.Logger.Info("assigning subnet IPv6 CIDR blocks", zap.Strings("subnet-ids", subnetIDs))
+
+	for idx, subnetID := range subnetIDs {
+		cidr, err := subnetIPv6CIDR(ts.cfg.EKSConfig.VPC.IPv6CIDR, idx)
+		if err != nil {
+			return err
+		}
+		_, err = ts.cfg.EC2APIV2.AssociateSubnetCidrBlock(
+			context.Background(),
+			&aws_ec2_v2.AssociateSubnetCidrBlockInput{
+				SubnetId:      aws_v2.String(subnetID),
+				Ipv6CidrBlock: aws_v2.String(cidr),
+			},
+		)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to associate subnet IPv6 CIDR block", zap.String("subnet-id", subnetID), zap.Error(err))
+			return err
+		}
+
+		_, err = ts.cfg.EC2APIV2.ModifySubnetAttribute(
+			context.Background(),
+			&aws_ec2_v2.ModifySubnetAttributeInput{
+				SubnetId:                    aws_v2.String(subnetID),
+				AssignIpv6AddressOnCreation: &aws_ec2_v2_types.AttributeBooleanValue{Value: aws_v2.Bool(true)},
+			},
+		)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to modify subnet attribute", zap.String("subnet-id", subnetID), zap.Error(err))
+			return err
+		}
+		ts.cfg.Logger.Info("assigned subnet IPv6 CIDR block", zap.String("subnet-id", subnetID), zap.String("ipv6-cidr-block", cidr))
+	}
+
+	ts.cfg.Logger.Info("assigned subnet IPv6 CIDR blocks")
+	return nil
+}
+
+// subnetIPv6CIDR returns the idx-th /64 block of the VPC IPv6 CIDR block
+// (Amazon-provided blocks are /56, which leaves 256 subnets).
+func subnetIPv6CIDR(vpcCIDR string, idx int) (string, error) {
+	_, ipNet, err := net.ParseCIDR(vpcCIDR)
+	if err != nil {
+		return "", fmt.Errorf("invalid VPC IPv6 CIDR block %q (%v)", vpcCIDR, err)
+	}
+	ones, bits := ipNet.Mask.Size()
+	if bits != 128 || ones > 64 {
+		return "", fmt.Errorf("unexpected VPC IPv6 CIDR block %q", vpcCIDR)
+	}
+	if idx < 0 || idx >= 1<<uint(64-ones) {
+		return "", fmt.Errorf("subnet index %d out of range for VPC IPv6 CIDR block %q", idx, vpcCIDR)
+	}
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, ipNet.IP.To16())
+	for i, v := 7, idx; i >= 0 && v > 0; i, v = i-1, v>>8 {
+		ip[i] |= byte(v)
+	}
+	return (&net.IPNet{IP: ip, Mask: net.CIDRMask(64, 128)}).String(), nil
+}
+
+// AWS::EC2::Route, AWS::EC2::EgressOnlyInternetGateway
+func (ts *tester) createIPv6Routes() error {
+	ts.cfg.Logger.Info("creating public IPv6 route")
+	_, err := ts.cfg.EC2APIV2.CreateRoute(
+		context.Background(),
+		&aws_ec2_v2.CreateRouteInput{
+			RouteTableId:             aws_v2.String(ts.cfg.EKSConfig.VPC.PublicRouteTableID),
+			GatewayId:                aws_v2.String(ts.cfg.EKSConfig.VPC.InternetGatewayID),
+			DestinationIpv6CidrBlock: aws_v2.String("::/0"),
+		},
+	)
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to create public IPv6 route", zap.Error(err))
+		return err
+	}
+	ts.cfg.Logger.Info("created public IPv6 route")
+
+	if len(ts.cfg.EKSConfig.VPC.PrivateRouteTableIDs) == 0 {
+		return nil
+	}
+
+	ts.cfg.Logger.Info("creating egress-only internet gateway")
+	out, err := ts.cfg.EC2APIV2.CreateEgressOnlyInternetGateway(
+		context.Background(),
+		&aws_ec2_v2.CreateEgressOnlyInternetGatewayInput{
+			VpcId: aws_v2.String(ts.cfg.EKSConfig.VPC.ID),
+			TagSpecifications: []aws_ec2_v2_types.TagSpecification{
+				{
+					ResourceType: aws_ec2_v2_types.ResourceTypeEgressOnlyInternetGateway,
+					Tags: []aws_ec2_v2_types.Tag{
+						{
+							Key:   aws_v2.String("Name"),
+							Value: aws_v2.String(fmt.Sprintf("%s-egress-only-igw", ts.cfg.EKSConfig.Name)),
+						},
+						{
+							Key:   aws_v2.String(eksconfig.RunTagKey),
+							Value: aws_v2.String(ts.cfg.EKSConfig.Name),
+						},
+					},
+				},
+			},
+		},
+	)
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to create egress-only internet gateway", zap.Error(err))
+		return err
+	}
+	ts.cfg.EKSConfig.VPC.EgressOnlyInternetGatewayID = aws_v2.ToString(out.EgressOnlyInternetGateway.EgressOnlyInternetGatewayId)
+	ts.cfg.EKSConfig.Sync()
+	ts.cfg.Logger.Info("created egress-only internet gateway", zap.String("egress-only-internet-gateway-id", ts.cfg.EKSConfig.VPC.EgressOnlyInternetGatewayID))
+
+	for _, route := range ts.cfg.EKSConfig.VPC.PrivateRouteTableIDs {
+		_, err = ts.cfg.EC2APIV2.CreateRoute(
+			context.Background(),
+			&aws_ec2_v2.CreateRouteInput{
+				RouteTableId:                aws_v2.String(route),
+				EgressOnlyInternetGatewayId: aws_v2.String(ts.cfg.EKSConfig.VPC.EgressOnlyInternetGatewayID),
+				DestinationIpv6CidrBlock:    aws_v2.String("::/0"),
+			},
+		)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to create private IPv6 route", zap.String("route-table-id", route), zap.Error(err))
+			return err
+		}
+	}
+
+	ts.cfg.Logger.Info("created private IPv6 routes")
+	return nil
+}
+
+func (ts *tester) deleteEgressOnlyInternetGateway() (err error) {
+	ts.cfg.Logger.Info("deleting egress-only internet gateway")
+	if ts.cfg.EKSConfig.VPC.EgressOnlyInternetGatewayID == "" {
+		return nil
+	}
+	if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[ts.cfg.EKSConfig.VPC.EgressOnlyInternetGatewayID]; ok {
+		return nil
+	}
+
+	_, err = ts.cfg.EC2APIV2.DeleteEgressOnlyInternetGateway(
+		context.Background(),
+		&aws_ec2_v2.DeleteEgressOnlyInternetGatewayInput{
+			EgressOnlyInternetGatewayId: aws_v2.String(ts.cfg.EKSConfig.VPC.EgressOnlyInternetGatewayID),
+		},
+	)
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to delete egress-only internet gateway", zap.Error(err))
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) {
+			if strings.Contains(apiErr.ErrorCode(), "NotFound") {
+				ts.cfg.EKSConfig.Status.DeletedResources[ts.cfg.EKSConfig.VPC.EgressOnlyInternetGatewayID] = "VPC.EgressOnlyInternetGatewayID"
+				ts.cfg.EKSConfig.Sync()
+				return nil
+			}
+		}
+		return err
+	}
+
+	ts.cfg.EKSConfig.Status.DeletedResources[ts.cfg.EKSConfig.VPC.EgressOnlyInternetGatewayID] = "VPC.EgressOnlyInternetGatewayID"
+	ts.cfg.EKSConfig.Sync()
+	ts.cfg.Logger.Info("deleted egress-only internet gateway")
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aws/aws-k8s-tester/eksconfig"
 	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
 	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
 	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
@@ -22,112 +23,7 @@ func (ts *tester) createVPC() error {
 	fmt.Printf(ts.cfg.EKSConfig.Colorize("[light_green]createVPC [default](%q)\n"), ts.cfg.EKSConfig.ConfigPath)
 
 	if ts.cfg.EKSConfig.VPC.ID != "" {
-		ts.cfg.Logger.Info("querying ELBv2", zap.String("vpc-id", ts.cfg.EKSConfig.VPC.ID))
-		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
-		output, err := ts.cfg.ELBV2APIV2.DescribeLoadBalancers(
-			ctx,
-			&aws_elbv2_v2.DescribeLoadBalancersInput{},
-		)
-		cancel()
-		if err != nil {
-			ts.cfg.Logger.Warn("failed to describe ELBv2", zap.Error(err))
-		} else {
-			for _, ev := range output.LoadBalancers {
-				arn := aws_v2.ToString(ev.LoadBalancerArn)
-				vpcID := aws_v2.ToString(ev.VpcId)
-				if vpcID == ts.cfg.EKSConfig.VPC.ID {
-					ts.cfg.Logger.Warn("found ELBv2 for this VPC; may overlap with the other cluster",
-						zap.String("vpc-id", ts.cfg.EKSConfig.VPC.ID),
-						zap.String("elb-arn", arn),
-					)
-				} else {
-					ts.cfg.Logger.Info("found ELBv2 for other VPCs", zap.String("vpc-id", vpcID), zap.String("elb-arn", arn))
-				}
-			}
-		}
-
-		ts.cfg.Logger.Info("querying subnet IDs for given VPC",
-			zap.String("vpc-id", ts.cfg.EKSConfig.VPC.ID),
-		)
-		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
-		sresp, err := ts.cfg.EC2APIV2.DescribeSubnets(
-			ctx,
-			&aws_ec2_v2.DescribeSubnetsInput{
-				Filters: []aws_ec2_v2_types.Filter{
-					{
-						Name:   aws_v2.String("vpc-id"),
-						Values: []string{ts.cfg.EKSConfig.VPC.ID},
-					},
-				},
-			})
-		cancel()
-		if err != nil {
-			ts.cfg.Logger.Warn("failed to subnets", zap.Error(err))
-			return err
-		}
-
-		ts.cfg.EKSConfig.VPC.PublicSubnetIDs = make([]string, 0, len(sresp.Subnets))
-		ts.cfg.EKSConfig.VPC.PrivateSubnetIDs = make([]string, 0, len(sresp.Subnets))
-		for _, sv := range sresp.Subnets {
-			id := aws_v2.ToString(sv.SubnetId)
-			networkTagValue := ""
-			for _, tg := range sv.Tags {
-				switch aws_v2.ToString(tg.Key) {
-				case "Network":
-					networkTagValue = aws_v2.ToString(tg.Value)
-				}
-				if networkTagValue != "" {
-					break
-				}
-			}
-			ts.cfg.Logger.Info("found subnet",
-				zap.String("id", id),
-				zap.String("availability-zone", aws_v2.ToString(sv.AvailabilityZone)),
-				zap.String("network-tag", networkTagValue),
-			)
-			switch networkTagValue {
-			case "Public":
-				ts.cfg.EKSConfig.VPC.PublicSubnetIDs = append(ts.cfg.EKSConfig.VPC.PublicSubnetIDs, id)
-			case "Private":
-				ts.cfg.EKSConfig.VPC.PrivateSubnetIDs = append(ts.cfg.EKSConfig.VPC.PrivateSubnetIDs, id)
-			default:
-				return fmt.Errorf("'Network' tag not found in subnet %q", id)
-			}
-		}
-		if len(ts.cfg.EKSConfig.VPC.PublicSubnetIDs) == 0 {
-			return fmt.Errorf("no subnet found for VPC ID %q", ts.cfg.EKSConfig.VPC.ID)
-		}
-
-		ts.cfg.Logger.Info("querying security IDs", zap.String("vpc-id", ts.cfg.EKSConfig.VPC.ID))
-		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
-		gresp, err := ts.cfg.EC2APIV2.DescribeSecurityGroups(
-			ctx,
-			&aws_ec2_v2.DescribeSecurityGroupsInput{
-				Filters: []aws_ec2_v2_types.Filter{
-					{
-						Name:   aws_v2.String("vpc-id"),
-						Values: []string{ts.cfg.EKSConfig.VPC.ID},
-					},
-				},
-			})
-		cancel()
-		if err != nil {
-			ts.cfg.Logger.Warn("failed to security groups", zap.Error(err))
-			return err
-		}
-		for _, sg := range gresp.SecurityGroups {
-			id, name := aws_v2.ToString(sg.GroupId), aws_v2.ToString(sg.GroupName)
-			ts.cfg.Logger.Info("found security group", zap.String("id", id), zap.String("name", name))
-			if name != "default" {
-				ts.cfg.EKSConfig.VPC.SecurityGroupID = id
-			}
-		}
-		if ts.cfg.EKSConfig.VPC.SecurityGroupID == "" {
-			return fmt.Errorf("no security group found for VPC ID %q", ts.cfg.EKSConfig.VPC.ID)
-		}
-
-		ts.cfg.EKSConfig.Sync()
-		return nil
+		return ts.describeExistingVPC()
 	}
 	if !ts.cfg.EKSConfig.VPC.Create {
 		ts.cfg.Logger.Info("VPC.Create false; skipping creation")
@@ -152,6 +48,11 @@ func (ts *tester) createVPC() error {
 	if err := ts.associateVPCCIDRBlocks(); err != nil { // AWS::EC2::VPCCidrBlock
 		return err
 	}
+	if ts.cfg.EKSConfig.IPFamily == eksconfig.IPFamilyIPv6 {
+		if err := ts.associateVPCIPv6CIDRBlock(); err != nil { // AWS::EC2::VPCCidrBlock
+			return err
+		}
+	}
 
 	if err := ts.createInternetGateway(); err != nil { // AWS::EC2::InternetGateway
 		return err
@@ -173,11 +74,15 @@ func (ts *tester) createVPC() error {
 		return err
 	}
 
-	if err := ts.createPublicEIPs(); err != nil { // AWS::EC2::EIP
-		return err
-	}
-	if err := ts.createPublicNATGateways(); err != nil { // AWS::EC2::NatGateway
-		return err
+	if ts.cfg.EKSConfig.VPC.DisableNATGateways {
+		ts.cfg.Logger.Info("VPC.DisableNATGateways true; skipping NAT gateway creation")
+	} else {
+		if err := ts.createPublicEIPs(); err != nil { // AWS::EC2::EIP
+			return err
+		}
+		if err := ts.createPublicNATGateways(); err != nil { // AWS::EC2::NatGateway
+			return err
+		}
 	}
 
 	if err := ts.createPrivateSubnets(); err != nil { // AWS::EC2::Subnet
@@ -186,13 +91,30 @@ func (ts *tester) createVPC() error {
 	if err := ts.createPrivateRouteTables(); err != nil { // AWS::EC2::RouteTable
 		return err
 	}
-	if err := ts.createPrivateRoutes(); err != nil { // AWS::EC2::Route
-		return err
+	if !ts.cfg.EKSConfig.VPC.DisableNATGateways {
+		if err := ts.createPrivateRoutes(); err != nil { // AWS::EC2::Route
+			return err
+		}
 	}
 	if err := ts.createPrivateSubnetRouteTableAssociation(); err != nil { // AWS::EC2::SubnetRouteTableAssociation
 		return err
 	}
 
+	if ts.cfg.EKSConfig.IPFamily == eksconfig.IPFamilyIPv6 {
+		if err := ts.assignSubnetIPv6CIDRBlocks(); err != nil { // AWS::EC2::SubnetCidrBlock
+			return err
+		}
+		if err := ts.createIPv6Routes(); err != nil { // AWS::EC2::Route, AWS::EC2::EgressOnlyInternetGateway
+			return err
+		}
+	}
+
+	if ts.cfg.EKSConfig.VPC.CreateEndpoints {
+		if err := ts.createVPCEndpoints(); err != nil { // AWS::EC2::VPCEndpoint, AWS::EC2::SecurityGroup
+			return err
+		}
+	}
+
 	if err := ts.createDHCPOptions(); err != nil { // AWS::EC2::DHCPOptions, AWS::EC2::VPCDHCPOptionsAssociation
 		return err
 	}
@@ -200,9 +122,11 @@ func (ts *tester) createVPC() error {
 	ts.cfg.Logger.Info("created a VPC",
 		zap.String("vpc-id", ts.cfg.EKSConfig.VPC.ID),
 		zap.Strings("vpc-cidr-blocks", ts.cfg.EKSConfig.VPC.CIDRs),
+		zap.String("vpc-ipv6-cidr-block", ts.cfg.EKSConfig.VPC.IPv6CIDR),
 		zap.Strings("public-subnet-ids", ts.cfg.EKSConfig.VPC.PublicSubnetIDs),
 		zap.Strings("private-subnet-ids", ts.cfg.EKSConfig.VPC.PrivateSubnetIDs),
 		zap.String("control-plane-security-group-id", ts.cfg.EKSConfig.VPC.SecurityGroupID),
+		zap.Strings("endpoint-ids", ts.cfg.EKSConfig.VPC.EndpointIDs),
 	)
 
 	ts.cfg.EKSConfig.Sync()
@@ -235,6 +159,10 @@ func (ts *tester) deleteVPC() error {
 		ts.cfg.Logger.Warn("failed to delete DHCP options", zap.Error(err))
 		errs = append(errs, err.Error())
 	}
+	if err := ts.deleteVPCEndpoints(); err != nil {
+		ts.cfg.Logger.Warn("failed to delete VPC endpoints", zap.Error(err))
+		errs = append(errs, err.Error())
+	}
 
 	if err := ts.deletePrivateSubnetRouteTableAssociation(); err != nil {
 		ts.cfg.Logger.Warn("failed to delete subnet route table association", zap.Error(err))
@@ -325,6 +253,10 @@ func (ts *tester) deleteVPC() error {
 		return errors.New("stopped")
 	}
 
+	if err := ts.deleteEgressOnlyInternetGateway(); err != nil {
+		ts.cfg.Logger.Warn("failed to delete egress-only internet gateway", zap.Error(err))
+		errs = append(errs, err.Error())
+	}
 	if err := ts.deleteVPCGatewayAttachment(); err != nil {
 		ts.cfg.Logger.Warn("failed to VPC gateway attachment", zap.Error(err))
 		errs = append(errs, err.Error())
@@ -397,6 +329,10 @@ func (ts *tester) _createVPC() error {
 							Key:   aws_v2.String("Name"),
 							Value: aws_v2.String(fmt.Sprintf("%s-vpc", ts.cfg.EKSConfig.Name)),
 						},
+						{
+							Key:   aws_v2.String(eksconfig.RunTagKey),
+							Value: aws_v2.String(ts.cfg.EKSConfig.Name),
+						},
 					},
 				},
 			},
@@ -502,7 +438,23 @@ func (ts *tester) createInternetGateway() error {
 	ts.cfg.Logger.Info("creating internet gateway")
 	out, err := ts.cfg.EC2APIV2.CreateInternetGateway(
 		context.Background(),
-		&aws_ec2_v2.CreateInternetGatewayInput{},
+		&aws_ec2_v2.CreateInternetGatewayInput{
+			TagSpecifications: []aws_ec2_v2_types.TagSpecification{
+				{
+					ResourceType: aws_ec2_v2_types.ResourceTypeInternetGateway,
+					Tags: []aws_ec2_v2_types.Tag{
+						{
+							Key:   aws_v2.String("Name"),
+							Value: aws_v2.String(fmt.Sprintf("%s-igw", ts.cfg.EKSConfig.Name)),
+						},
+						{
+							Key:   aws_v2.String(eksconfig.RunTagKey),
+							Value: aws_v2.String(ts.cfg.EKSConfig.Name),
+						},
+					},
+				},
+			},
+		},
 	)
 	if err != nil {
 		ts.cfg.Logger.Warn("failed to create internet gateway", zap.Error(err))
@@ -629,6 +581,10 @@ func (ts *tester) createPublicSubnets() error {
 								Key:   aws_v2.String("Name"),
 								Value: aws_v2.String(fmt.Sprintf("%s-public-subnet-%d", ts.cfg.EKSConfig.Name, idx+1)),
 							},
+							{
+								Key:   aws_v2.String(eksconfig.RunTagKey),
+								Value: aws_v2.String(ts.cfg.EKSConfig.Name),
+							},
 							{
 								Key:   aws_v2.String("Network"),
 								Value: aws_v2.String("Public"),
@@ -739,6 +695,10 @@ func (ts *tester) createPublicRouteTable() error {
 							Key:   aws_v2.String("Name"),
 							Value: aws_v2.String(fmt.Sprintf("%s-public-route-table", ts.cfg.EKSConfig.Name)),
 						},
+						{
+							Key:   aws_v2.String(eksconfig.RunTagKey),
+							Value: aws_v2.String(ts.cfg.EKSConfig.Name),
+						},
 						{
 							Key:   aws_v2.String("Network"),
 							Value: aws_v2.String("Public"),
@@ -902,6 +862,10 @@ func (ts *tester) createPublicEIPs() error {
 							Key:   aws_v2.String("Name"),
 							Value: aws_v2.String(fmt.Sprintf("%s-eip-%d", ts.cfg.EKSConfig.Name, idx+1)),
 						},
+						{
+							Key:   aws_v2.String(eksconfig.RunTagKey),
+							Value: aws_v2.String(ts.cfg.EKSConfig.Name),
+						},
 					},
 				},
 			}
@@ -990,6 +954,10 @@ func (ts *tester) createPublicNATGateways() error {
 								Key:   aws_v2.String("Name"),
 								Value: aws_v2.String(fmt.Sprintf("%s-nat-gateway-%d", ts.cfg.EKSConfig.Name, idx+1)),
 							},
+							{
+								Key:   aws_v2.String(eksconfig.RunTagKey),
+								Value: aws_v2.String(ts.cfg.EKSConfig.Name),
+							},
 						},
 					},
 				},
@@ -1172,6 +1140,10 @@ func (ts *tester) createPrivateSubnets() error {
 								Key:   aws_v2.String("Name"),
 								Value: aws_v2.String(fmt.Sprintf("%s-private-subnet-%d", ts.cfg.EKSConfig.Name, idx+1)),
 							},
+							{
+								Key:   aws_v2.String(eksconfig.RunTagKey),
+								Value: aws_v2.String(ts.cfg.EKSConfig.Name),
+							},
 							{
 								Key:   aws_v2.String("Network"),
 								Value: aws_v2.String("Private"),
@@ -1273,6 +1245,10 @@ func (ts *tester) createPrivateRouteTables() error {
 								Key:   aws_v2.String("Name"),
 								Value: aws_v2.String(fmt.Sprintf("%s-private-route-table-%d", ts.cfg.EKSConfig.Name, idx+1)),
 							},
+							{
+								Key:   aws_v2.String(eksconfig.RunTagKey),
+								Value: aws_v2.String(ts.cfg.EKSConfig.Name),
+							},
 							{
 								Key:   aws_v2.String("Network"),
 								Value: aws_v2.String("private"),
@@ -1458,6 +1434,21 @@ func (ts *tester) createDHCPOptions() error {
 		context.Background(),
 		&aws_ec2_v2.CreateDhcpOptionsInput{
 			DhcpConfigurations: dhcpConfigs,
+			TagSpecifications: []aws_ec2_v2_types.TagSpecification{
+				{
+					ResourceType: aws_ec2_v2_types.ResourceTypeDhcpOptions,
+					Tags: []aws_ec2_v2_types.Tag{
+						{
+							Key:   aws_v2.String("Name"),
+							Value: aws_v2.String(fmt.Sprintf("%s-dhcp-options", ts.cfg.EKSConfig.Name)),
+						},
+						{
+							Key:   aws_v2.String(eksconfig.RunTagKey),
+							Value: aws_v2.String(ts.cfg.EKSConfig.Name),
+						},
+					},
+				},
+			},
 		},
 	)
 	if err != nil {
@@ -3,10 +3,13 @@ package clusterautoscaler
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
 	"github.com/aws/aws-k8s-tester/eksconfig"
 	k8sclient "github.com/aws/aws-k8s-tester/pkg/k8s-client"
 	gotemplate "github.com/aws/aws-k8s-tester/pkg/util"
+	"go.uber.org/zap"
 )
 
 // Constants
@@ -17,10 +20,31 @@ const (
 
 // ClusterAutoscaler is an addon that installs Cluster Autoscaler
 type ClusterAutoscaler struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
 	K8sClient k8sclient.EKS
 	Config    *eksconfig.Config
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "cluster-autoscaler",
+		// the scale test adds and removes nodes,
+		// which the concurrent add-ons would be scheduled on
+		Exclusive: true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return &ClusterAutoscaler{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				Config:    cfg.EKSConfig,
+				K8sClient: cfg.K8SClient,
+			}
+		},
+	})
+}
+
 // IsEnabled returns true if enabled
 func (c *ClusterAutoscaler) IsEnabled() bool {
 	return c.Config.Spec.ClusterAutoscaler != nil
@@ -41,17 +65,34 @@ func (c *ClusterAutoscaler) Apply() (err error) {
 	if err := c.K8sClient.Apply(template.String()); err != nil {
 		return fmt.Errorf("while applying resources, %v", err)
 	}
-	c.Config.Status.ClusterAutoscaler = &eksconfig.ClusterAutoscalerStatus{
-		AddonStatus: eksconfig.AddonStatus{
-			Installed: true,
-			Ready:     true,
-		},
+	c.Config.RecordAddOnStatus(func() {
+		c.Config.Status.ClusterAutoscaler = &eksconfig.ClusterAutoscalerStatus{
+			AddonStatus: eksconfig.AddonStatus{
+				Installed: true,
+				Ready:     c.Config.Spec.ClusterAutoscaler.ScaleTest == nil,
+			},
+		}
+	})
+	if c.Config.Spec.ClusterAutoscaler.ScaleTest == nil {
+		return nil
 	}
+
+	if err = c.scaleTest(); err != nil {
+		return err
+	}
+	c.Config.RecordAddOnStatus(func() {
+		c.Config.Status.ClusterAutoscaler.Ready = true
+	})
 	return nil
 }
 
 // Delete removes the addon
 func (c *ClusterAutoscaler) Delete() (err error) {
+	if c.Config.Spec.ClusterAutoscaler.ScaleTest != nil {
+		if err := c.deleteScaleTest(); err != nil {
+			return err
+		}
+	}
 	template, err := gotemplate.FromLocalDirectory(struct {
 		*eksconfig.ClusterAutoscalerSpec
 		Command string
@@ -65,12 +106,14 @@ func (c *ClusterAutoscaler) Delete() (err error) {
 	if err := c.K8sClient.Delete(template.String()); err != nil {
 		return fmt.Errorf("while deleting resources, %v", err)
 	}
-	c.Config.Status.ClusterAutoscaler = &eksconfig.ClusterAutoscalerStatus{
-		AddonStatus: eksconfig.AddonStatus{
-			Installed: false,
-			Ready:     false,
-		},
-	}
+	c.Config.RecordAddOnStatus(func() {
+		c.Config.Status.ClusterAutoscaler = &eksconfig.ClusterAutoscalerStatus{
+			AddonStatus: eksconfig.AddonStatus{
+				Installed: false,
+				Ready:     false,
+			},
+		}
+	})
 	return nil
 }
 
@@ -110,7 +153,11 @@ func (c *ClusterAutoscaler) buildNodeGroupArguments() (args []string) {
 		}
 		if c.Config.AddOnManagedNodeGroups != nil {
 			for _, mng := range c.Config.AddOnManagedNodeGroups.MNGs {
-				args = append(args, fmt.Sprintf(NodeGroupArgumentFormatter, spec.MinNodes, spec.MaxNodes, mng.Name))
+				// the managed node group name is not its ASG name
+				if mng.ASGName == "" {
+					continue
+				}
+				args = append(args, fmt.Sprintf(NodeGroupArgumentFormatter, spec.MinNodes, spec.MaxNodes, mng.ASGName))
 			}
 		}
 	}
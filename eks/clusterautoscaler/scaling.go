@@ -0,0 +1,329 @@
+package clusterautoscaler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/exec"
+)
+
+const (
+	clusterAutoscalerDeploymentName = "cluster-autoscaler"
+
+	deploymentName = "cluster-autoscaler-inflate"
+	appName        = "cluster-autoscaler-inflate"
+	pauseImage     = eksconfig.DefaultPauseImage
+)
+
+// scaleTest creates a workload that cannot be scheduled on the current
+// nodes, waits for Cluster Autoscaler to add nodes, and then scales in
+// the workload to wait for the node count to return to the baseline.
+func (c *ClusterAutoscaler) scaleTest() error {
+	spec := c.Config.Spec.ClusterAutoscaler.ScaleTest
+	c.Config.RecordAddOnStatus(func() {
+		c.Config.Status.ClusterAutoscaler.ScaleTest = &eksconfig.ClusterAutoscalerScaleTestStatus{}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 7*time.Minute)
+	_, err := k8s_client.WaitForDeploymentCompletes(
+		ctx,
+		c.Logger,
+		c.LogWriter,
+		c.Stopc,
+		c.K8sClient,
+		time.Minute,
+		20*time.Second,
+		"kube-system",
+		clusterAutoscalerDeploymentName,
+		1,
+	)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	if err = k8s_client.CreateNamespace(
+		c.Logger,
+		c.K8sClient.KubernetesClientSet(),
+		spec.Namespace,
+	); err != nil {
+		return err
+	}
+	if err = c.checkScaleUp(); err != nil {
+		return err
+	}
+	return c.checkScaleDown()
+}
+
+// deleteScaleTest deletes the scale-out workload and its namespace.
+func (c *ClusterAutoscaler) deleteScaleTest() error {
+	var errs []string
+	if err := c.deleteDeployment(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := k8s_client.DeleteNamespaceAndWait(
+		c.Logger,
+		c.K8sClient.KubernetesClientSet(),
+		c.Config.Spec.ClusterAutoscaler.ScaleTest.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Cluster Autoscaler scale test namespace (%v)", err))
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// checkScaleUp creates a workload that cannot be scheduled on the
+// current nodes, and waits for Cluster Autoscaler to add nodes.
+func (c *ClusterAutoscaler) checkScaleUp() error {
+	spec := c.Config.Spec.ClusterAutoscaler.ScaleTest
+	rs := c.Config.Status.ClusterAutoscaler.ScaleTest
+	baseline, err := c.countNodes()
+	if err != nil {
+		return err
+	}
+	c.Config.RecordAddOnStatus(func() {
+		rs.NodesBaseline = baseline
+	})
+	c.Logger.Info("counted baseline nodes", zap.Int("nodes", baseline))
+
+	// validated on "ValidateAndSetDefaults"
+	scaleUpTimeout, _ := time.ParseDuration(spec.ScaleUpTimeout)
+
+	scaleUpStart := time.Now()
+	if err = c.createDeployment(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), scaleUpTimeout)
+	_, err = k8s_client.WaitForDeploymentCompletes(
+		ctx,
+		c.Logger,
+		c.LogWriter,
+		c.Stopc,
+		c.K8sClient,
+		time.Minute,
+		20*time.Second,
+		spec.Namespace,
+		deploymentName,
+		spec.Replicas,
+		k8s_client.WithQueryFunc(func() {
+			getArgs := []string{
+				c.Config.KubectlPath,
+				"--kubeconfig=" + c.Config.KubeConfigPath,
+				"get",
+				"nodes",
+			}
+			getCmd := strings.Join(getArgs, " ")
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			output, err := exec.New().CommandContext(ctx, getArgs[0], getArgs[1:]...).CombinedOutput()
+			cancel()
+			if err != nil {
+				c.Logger.Warn("'kubectl get nodes' failed", zap.Error(err))
+			}
+			fmt.Fprintf(c.LogWriter, "\n\n\"%s\" output:\n%s\n\n", getCmd, string(output))
+		}),
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("scale-out Deployment not ready (%v)", err)
+	}
+	c.Config.RecordAddOnStatus(func() {
+		rs.TimeFrameScaleUp = timeutil.NewTimeFrame(scaleUpStart, time.Now())
+	})
+
+	peak, err := c.countNodes()
+	if err != nil {
+		return err
+	}
+	c.Config.RecordAddOnStatus(func() {
+		rs.NodesPeak = peak
+	})
+	if peak <= baseline {
+		return fmt.Errorf("Cluster Autoscaler did not scale up (baseline %d nodes, peak %d nodes)", baseline, peak)
+	}
+
+	c.Logger.Info("Cluster Autoscaler scaled up",
+		zap.Int("baseline-nodes", baseline),
+		zap.Int("peak-nodes", peak),
+		zap.String("took", rs.TimeFrameScaleUp.TookString),
+	)
+	return nil
+}
+
+// checkScaleDown scales in the workload to zero, and waits for the
+// node count to return to the baseline.
+func (c *ClusterAutoscaler) checkScaleDown() error {
+	spec := c.Config.Spec.ClusterAutoscaler.ScaleTest
+	rs := c.Config.Status.ClusterAutoscaler.ScaleTest
+	c.Logger.Info("scaling in Deployment", zap.String("scale-down-timeout", spec.ScaleDownTimeout))
+	scaleDownTimeout, _ := time.ParseDuration(spec.ScaleDownTimeout)
+	scaleDownStart := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := c.K8sClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(spec.Namespace).
+		Patch(
+			ctx,
+			deploymentName,
+			types.MergePatchType,
+			[]byte(`{"spec":{"replicas":0}}`),
+			metav1.PatchOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to scale in Deployment (%v)", err)
+	}
+
+	deadline := scaleDownStart.Add(scaleDownTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-c.Stopc:
+			return errors.New("wait for scale-down aborted")
+		case <-time.After(20 * time.Second):
+		}
+
+		n, err := c.countNodes()
+		if err != nil {
+			c.Logger.Warn("failed to count nodes", zap.Error(err))
+			continue
+		}
+		c.Logger.Info("counted nodes",
+			zap.Int("nodes", n),
+			zap.Int("baseline-nodes", rs.NodesBaseline),
+			zap.String("elapsed", time.Since(scaleDownStart).String()),
+		)
+		if n <= rs.NodesBaseline {
+			c.Config.RecordAddOnStatus(func() {
+				rs.TimeFrameScaleDown = timeutil.NewTimeFrame(scaleDownStart, time.Now())
+			})
+			c.Logger.Info("Cluster Autoscaler scaled down", zap.String("took", rs.TimeFrameScaleDown.TookString))
+			return nil
+		}
+	}
+	return fmt.Errorf("Cluster Autoscaler did not scale down to %d nodes within %v", rs.NodesBaseline, scaleDownTimeout)
+}
+
+func (c *ClusterAutoscaler) countNodes() (int, error) {
+	nodes, err := k8s_client.ListNodes(c.K8sClient.KubernetesClientSet())
+	if err != nil {
+		return 0, fmt.Errorf("failed to list nodes (%v)", err)
+	}
+	return len(nodes), nil
+}
+
+func (c *ClusterAutoscaler) createDeployment() error {
+	spec := c.Config.Spec.ClusterAutoscaler.ScaleTest
+	cpu, err := resource.ParseQuantity(spec.CPURequest)
+	if err != nil {
+		return fmt.Errorf("invalid Spec.ClusterAutoscaler.ScaleTest.CPURequest %q (%v)", spec.CPURequest, err)
+	}
+
+	c.Logger.Info("creating scale-out Deployment", zap.Int32("replicas", spec.Replicas))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err = c.K8sClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(spec.Namespace).
+		Create(
+			ctx,
+			&appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      deploymentName,
+					Namespace: spec.Namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": appName,
+					},
+				},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: aws_v2.Int32(spec.Replicas),
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							"app.kubernetes.io/name": appName,
+						},
+					},
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{
+								"app.kubernetes.io/name": appName,
+							},
+						},
+						Spec: v1.PodSpec{
+							RestartPolicy:                 v1.RestartPolicyAlways,
+							TerminationGracePeriodSeconds: aws_v2.Int64(0),
+							Containers: []v1.Container{
+								{
+									Name:            appName,
+									Image:           c.Config.Image(pauseImage),
+									ImagePullPolicy: v1.PullIfNotPresent,
+									Resources: v1.ResourceRequirements{
+										Requests: v1.ResourceList{
+											v1.ResourceCPU: cpu,
+										},
+									},
+								},
+							},
+							NodeSelector: map[string]string{
+								"kubernetes.io/os": "linux",
+							},
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create scale-out Deployment (%v)", err)
+	}
+
+	c.Logger.Info("created scale-out Deployment")
+	return nil
+}
+
+func (c *ClusterAutoscaler) deleteDeployment() error {
+	c.Logger.Info("deleting scale-out Deployment")
+	foreground := metav1.DeletePropagationForeground
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := c.K8sClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(c.Config.Spec.ClusterAutoscaler.ScaleTest.Namespace).
+		Delete(
+			ctx,
+			deploymentName,
+			metav1.DeleteOptions{
+				GracePeriodSeconds: aws_v2.Int64(0),
+				PropagationPolicy:  &foreground,
+			},
+		)
+	cancel()
+	if err != nil && !apierrs.IsNotFound(err) {
+		c.Logger.Warn("failed to delete", zap.Error(err))
+		return fmt.Errorf("failed to delete scale-out Deployment (%v)", err)
+	}
+
+	c.Logger.Info("deleted scale-out Deployment")
+	return nil
+}
@@ -0,0 +1,108 @@
+package eks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// applyCNIConfig sets the "CNIConfig" environment variables on the
+// "aws-node" container, and waits for the rollout, before creating
+// the node groups.
+func (ts *Tester) applyCNIConfig() error {
+	if !ts.cfg.CNIConfig.IsSet() {
+		ts.lg.Info("skipping VPC CNI config")
+		return nil
+	}
+	if ts.k8sClient == nil {
+		return errors.New("empty Kubernetes client")
+	}
+	envs := ts.cfg.CNIConfig.Envs()
+	ts.lg.Info("applying VPC CNI config", zap.Any("envs", envs))
+
+	dsCli := ts.k8sClient.KubernetesClientSet().AppsV1().DaemonSets("kube-system")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	ds, err := dsCli.Get(ctx, "aws-node", metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get aws-node DaemonSet (%v)", err)
+	}
+	found := false
+	for i := range ds.Spec.Template.Spec.Containers {
+		c := &ds.Spec.Template.Spec.Containers[i]
+		if c.Name != "aws-node" {
+			continue
+		}
+		found = true
+		c.Env = setContainerEnvs(c.Env, envs)
+	}
+	if !found {
+		return errors.New("aws-node container not found in aws-node DaemonSet")
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = dsCli.Update(ctx, ds, metav1.UpdateOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to update aws-node DaemonSet (%v)", err)
+	}
+
+	// no node to roll out to, if the node groups are not created yet
+	waitStart := time.Now()
+	for time.Since(waitStart) < 10*time.Minute {
+		select {
+		case <-ts.stopCreationCh:
+			return errors.New("aws-node rollout aborted")
+		case <-time.After(5 * time.Second):
+		}
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		ds, err = dsCli.Get(ctx, "aws-node", metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.lg.Warn("failed to get aws-node DaemonSet", zap.Error(err))
+			continue
+		}
+		st := ds.Status
+		ts.lg.Info("polled aws-node DaemonSet",
+			zap.Int32("desired", st.DesiredNumberScheduled),
+			zap.Int32("updated", st.UpdatedNumberScheduled),
+			zap.Int32("available", st.NumberAvailable),
+		)
+		if st.ObservedGeneration >= ds.Generation &&
+			st.UpdatedNumberScheduled == st.DesiredNumberScheduled &&
+			st.NumberAvailable == st.DesiredNumberScheduled {
+			ts.lg.Info("applied VPC CNI config")
+			return nil
+		}
+	}
+	return errors.New("aws-node DaemonSet not rolled out")
+}
+
+// setContainerEnvs sets the environment variables in place,
+// appending the new ones in the name order.
+func setContainerEnvs(envs []v1.EnvVar, kvs map[string]string) []v1.EnvVar {
+	set := make(map[string]struct{}, len(kvs))
+	for i := range envs {
+		if v, ok := kvs[envs[i].Name]; ok {
+			envs[i].Value = v
+			envs[i].ValueFrom = nil
+			set[envs[i].Name] = struct{}{}
+		}
+	}
+	names := make([]string, 0, len(kvs))
+	for k := range kvs {
+		if _, ok := set[k]; !ok {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		envs = append(envs, v1.EnvVar{Name: k, Value: kvs[k]})
+	}
+	return envs
+}
@@ -0,0 +1,31 @@
+package eks
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestSetContainerEnvs(t *testing.T) {
+	envs := []v1.EnvVar{
+		{Name: "AWS_VPC_K8S_CNI_LOGLEVEL", Value: "DEBUG"},
+		{Name: "WARM_ENI_TARGET", Value: "1"},
+		{Name: "ENABLE_PREFIX_DELEGATION", ValueFrom: &v1.EnvVarSource{}},
+	}
+	envs = setContainerEnvs(envs, map[string]string{
+		"ENABLE_PREFIX_DELEGATION": "true",
+		"WARM_PREFIX_TARGET":       "1",
+		"MINIMUM_IP_TARGET":        "30",
+	})
+	expected := []v1.EnvVar{
+		{Name: "AWS_VPC_K8S_CNI_LOGLEVEL", Value: "DEBUG"},
+		{Name: "WARM_ENI_TARGET", Value: "1"},
+		{Name: "ENABLE_PREFIX_DELEGATION", Value: "true"},
+		{Name: "MINIMUM_IP_TARGET", Value: "30"},
+		{Name: "WARM_PREFIX_TARGET", Value: "1"},
+	}
+	if !reflect.DeepEqual(envs, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, envs)
+	}
+}
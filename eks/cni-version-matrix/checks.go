@@ -0,0 +1,354 @@
+package cniversionmatrix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/exec"
+)
+
+const serverPort = 8080
+
+func densityDeploymentName(idx int) string { return fmt.Sprintf("cni-density-%02d", idx) }
+func serverPodName(idx int) string         { return fmt.Sprintf("cni-server-%02d", idx) }
+func clientPodName(idx int) string         { return fmt.Sprintf("cni-client-%02d", idx) }
+
+// checkPodDensity schedules "PodsPerNode" Pods per ready node, and waits
+// for all Pods to be assigned IPs and available within "CheckTimeout".
+func (ts *tester) checkPodDensity(idx int) (int, error) {
+	cur := ts.cfg.EKSConfig.AddOnCNIVersionMatrix
+	nodes, err := ts.countReadyNodes()
+	if err != nil {
+		return 0, err
+	}
+	replicas := int32(cur.PodsPerNode * nodes)
+	name := densityDeploymentName(idx)
+
+	ts.cfg.Logger.Info("creating pod density Deployment",
+		zap.String("name", name),
+		zap.Int("nodes", nodes),
+		zap.Int32("replicas", replicas),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.K8SClient.KubernetesClientSet().AppsV1().Deployments(cur.Namespace).Create(ctx, &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cur.Namespace,
+			Labels:    map[string]string{"app.kubernetes.io/name": name},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app.kubernetes.io/name": name},
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app.kubernetes.io/name": name},
+				},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyAlways,
+					Containers: []v1.Container{
+						{
+							Name:            name,
+							Image:           ts.cfg.EKSConfig.Image(eksconfig.DefaultPauseImage),
+							ImagePullPolicy: v1.PullIfNotPresent,
+						},
+					},
+					NodeSelector: map[string]string{
+						"kubernetes.io/os": "linux",
+					},
+				},
+			},
+		},
+	}, metav1.CreateOptions{})
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return 0, fmt.Errorf("failed to create Deployment %q (%v)", name, err)
+	}
+
+	ready := 0
+	waitStart := time.Now()
+	for time.Since(waitStart) < cur.CheckTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return ready, errors.New("pod density check aborted")
+		case <-time.After(10 * time.Second):
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		dp, err := ts.cfg.K8SClient.KubernetesClientSet().AppsV1().Deployments(cur.Namespace).Get(ctx, name, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get Deployment", zap.String("name", name), zap.Error(err))
+			continue
+		}
+		ready = int(dp.Status.AvailableReplicas)
+		ts.cfg.Logger.Info("polled Deployment",
+			zap.String("name", name),
+			zap.Int32("desired", replicas),
+			zap.Int("available", ready),
+		)
+		if dp.Status.ObservedGeneration >= dp.Generation && int32(ready) == replicas {
+			return ready, nil
+		}
+	}
+	return ready, fmt.Errorf("%d of %d Pods available in %s", ready, replicas, cur.CheckTimeoutString)
+}
+
+func (ts *tester) countReadyNodes() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	nodes, err := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Nodes().List(ctx, metav1.ListOptions{
+		LabelSelector: "kubernetes.io/os=linux",
+	})
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list nodes (%v)", err)
+	}
+	n := 0
+	for _, node := range nodes.Items {
+		for _, cond := range node.Status.Conditions {
+			if cond.Type == v1.NodeReady && cond.Status == v1.ConditionTrue {
+				n++
+				break
+			}
+		}
+	}
+	if n == 0 {
+		return 0, errors.New("no ready node found")
+	}
+	return n, nil
+}
+
+// checkConnectivity checks the pod-to-pod connectivity between
+// the Pods preferably on different nodes.
+func (ts *tester) checkConnectivity(idx int) error {
+	cur := ts.cfg.EKSConfig.AddOnCNIVersionMatrix
+	server, client := serverPodName(idx), clientPodName(idx)
+	img := ts.cfg.EKSConfig.Image(eksconfig.DefaultBusyboxImage)
+
+	if err := ts.createPod(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      server,
+			Namespace: cur.Namespace,
+			Labels:    map[string]string{"app.kubernetes.io/name": server},
+		},
+		Spec: v1.PodSpec{
+			RestartPolicy: v1.RestartPolicyAlways,
+			Containers: []v1.Container{
+				{
+					Name:            server,
+					Image:           img,
+					ImagePullPolicy: v1.PullIfNotPresent,
+					Command: []string{
+						"/bin/sh",
+						"-c",
+						fmt.Sprintf("mkdir -p /www && echo ok > /www/index.html && httpd -f -p %d -h /www", serverPort),
+					},
+					Ports: []v1.ContainerPort{{ContainerPort: serverPort}},
+				},
+			},
+			NodeSelector: map[string]string{
+				"kubernetes.io/os": "linux",
+			},
+		},
+	}); err != nil {
+		return err
+	}
+	if err := ts.createPod(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      client,
+			Namespace: cur.Namespace,
+			Labels:    map[string]string{"app.kubernetes.io/name": client},
+		},
+		Spec: v1.PodSpec{
+			RestartPolicy: v1.RestartPolicyAlways,
+			Containers: []v1.Container{
+				{
+					Name:            client,
+					Image:           img,
+					ImagePullPolicy: v1.PullIfNotPresent,
+					Command:         []string{"/bin/sh", "-c", "sleep 3600"},
+				},
+			},
+			NodeSelector: map[string]string{
+				"kubernetes.io/os": "linux",
+			},
+			// prefer a different node from the server, to test cross-node traffic
+			Affinity: &v1.Affinity{
+				PodAntiAffinity: &v1.PodAntiAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{
+						{
+							Weight: 100,
+							PodAffinityTerm: v1.PodAffinityTerm{
+								LabelSelector: &metav1.LabelSelector{
+									MatchLabels: map[string]string{"app.kubernetes.io/name": server},
+								},
+								TopologyKey: "kubernetes.io/hostname",
+							},
+						},
+					},
+				},
+			},
+		},
+	}); err != nil {
+		return err
+	}
+
+	serverIP, err := ts.waitPodIP(server)
+	if err != nil {
+		return err
+	}
+	if _, err = ts.waitPodIP(client); err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("http://%s:%d/", serverIP, serverPort)
+	ts.cfg.Logger.Info("checking pod-to-pod connectivity", zap.String("url", url))
+	out, err := ts.wget(client, url)
+	if err != nil {
+		return fmt.Errorf("pod-to-pod connectivity failed (%v)", err)
+	}
+	if strings.TrimSpace(out) != "ok" {
+		return fmt.Errorf("unexpected response from %q (%q)", url, out)
+	}
+	ts.cfg.Logger.Info("checked pod-to-pod connectivity")
+	return nil
+}
+
+func (ts *tester) createPod(pod *v1.Pod) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(pod.Namespace).
+		Create(ctx, pod, metav1.CreateOptions{})
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Pod %q (%v)", pod.Name, err)
+	}
+	ts.cfg.Logger.Info("created Pod", zap.String("pod", pod.Name))
+	return nil
+}
+
+// waitPodIP waits for the Pod to be running with an IP within "CheckTimeout".
+func (ts *tester) waitPodIP(name string) (string, error) {
+	cur := ts.cfg.EKSConfig.AddOnCNIVersionMatrix
+	waitStart := time.Now()
+	for time.Since(waitStart) < cur.CheckTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return "", errors.New("Pod wait aborted")
+		case <-time.After(5 * time.Second):
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		pod, err := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Pods(cur.Namespace).Get(ctx, name, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get Pod", zap.String("pod", name), zap.Error(err))
+			continue
+		}
+		ts.cfg.Logger.Info("polled Pod",
+			zap.String("pod", name),
+			zap.String("phase", string(pod.Status.Phase)),
+			zap.String("node", pod.Spec.NodeName),
+			zap.String("pod-ip", pod.Status.PodIP),
+		)
+		if pod.Status.Phase == v1.PodRunning && pod.Status.PodIP != "" {
+			return pod.Status.PodIP, nil
+		}
+	}
+	return "", fmt.Errorf("Pod %q not running in %s", name, cur.CheckTimeoutString)
+}
+
+// wget requests the URL from the client Pod, with retries.
+func (ts *tester) wget(client string, url string) (string, error) {
+	args := []string{
+		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+		"--namespace=" + ts.cfg.EKSConfig.AddOnCNIVersionMatrix.Namespace,
+		"exec",
+		client,
+		"--",
+		"wget",
+		"-q",
+		"-O", "-",
+		"-T", "5",
+		url,
+	}
+	cmd := strings.Join(append([]string{ts.cfg.EKSConfig.KubectlPath}, args...), " ")
+
+	var lastErr error
+	for i := 0; i < 10; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		output, err := exec.New().CommandContext(ctx, ts.cfg.EKSConfig.KubectlPath, args...).CombinedOutput()
+		cancel()
+		out := string(output)
+		if err == nil {
+			return out, nil
+		}
+		lastErr = fmt.Errorf("%v (%q)", err, strings.TrimSpace(out))
+		ts.cfg.Logger.Warn("failed to wget", zap.String("command", cmd), zap.String("output", out), zap.Error(err))
+
+		select {
+		case <-ts.cfg.Stopc:
+			return "", errors.New("wget aborted")
+		case <-time.After(5 * time.Second):
+		}
+	}
+	return "", lastErr
+}
+
+// deleteCheckPods deletes the check Pods of the version, and waits
+// for the Pods to be gone, to release the IPs before the next version.
+func (ts *tester) deleteCheckPods(idx int) error {
+	cur := ts.cfg.EKSConfig.AddOnCNIVersionMatrix
+	cli := ts.cfg.K8SClient.KubernetesClientSet()
+	foreground := metav1.DeletePropagationForeground
+	opts := metav1.DeleteOptions{PropagationPolicy: &foreground}
+
+	var errs []string
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := cli.AppsV1().Deployments(cur.Namespace).Delete(ctx, densityDeploymentName(idx), opts)
+	cancel()
+	if err != nil && !apierrs.IsNotFound(err) {
+		errs = append(errs, fmt.Sprintf("failed to delete Deployment %q (%v)", densityDeploymentName(idx), err))
+	}
+	for _, name := range []string{serverPodName(idx), clientPodName(idx)} {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		err = cli.CoreV1().Pods(cur.Namespace).Delete(ctx, name, opts)
+		cancel()
+		if err != nil && !apierrs.IsNotFound(err) {
+			errs = append(errs, fmt.Sprintf("failed to delete Pod %q (%v)", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	waitStart := time.Now()
+	for time.Since(waitStart) < cur.CheckTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("Pod deletion aborted")
+		case <-time.After(5 * time.Second):
+		}
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		pods, err := cli.CoreV1().Pods(cur.Namespace).List(ctx, metav1.ListOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list Pods", zap.Error(err))
+			continue
+		}
+		ts.cfg.Logger.Info("polled Pods", zap.Int("remaining", len(pods.Items)))
+		if len(pods.Items) == 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("Pods not deleted in %s", cur.CheckTimeoutString)
+}
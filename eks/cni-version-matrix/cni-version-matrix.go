@@ -0,0 +1,241 @@
+// Package cniversionmatrix rolls the VPC CNI "aws-node" DaemonSet to each
+// version in the matrix, runs the pod density and the pod-to-pod
+// connectivity checks, and reports the pass or fail per version.
+// ref. https://github.com/aws/amazon-vpc-cni-k8s
+package cniversionmatrix
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"go.uber.org/zap"
+)
+
+// Config defines CNI version matrix configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+	S3API     s3iface.S3API
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new CNI version matrix tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:      "cni-version-matrix",
+		Exclusive: true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				S3API:     cfg.S3API,
+			}), cfg.EKSConfig.IsEnabledAddOnCNIVersionMatrix)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnCNIVersionMatrix() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnCNIVersionMatrix.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnCNIVersionMatrix.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnCNIVersionMatrix.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	cur := ts.cfg.EKSConfig.AddOnCNIVersionMatrix
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		cur.Namespace,
+	); err != nil {
+		return err
+	}
+	if err = ts.recordOriginalImages(); err != nil {
+		return err
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.Results = nil
+	})
+	for idx, version := range cur.Versions {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("CNI version matrix aborted")
+		default:
+		}
+		fmt.Fprintf(ts.cfg.LogWriter, "\n[%d/%d] testing VPC CNI %q\n", idx+1, len(cur.Versions), version)
+		rs := ts.testVersion(idx, version)
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.Results = append(cur.Results, rs)
+		})
+		fmt.Fprintf(ts.cfg.LogWriter, "\nVPC CNI %q passed %v (rollout %s, pod density %s, %d Pods ready, connectivity %v) %s\n",
+			rs.Version, rs.Passed, rs.RolloutTimeString, rs.PodDensityTimeString, rs.PodsReady, rs.Connectivity, rs.Error)
+	}
+
+	// restore before reporting, so that the following add-ons run with the original version
+	rerr := ts.restoreOriginalImages()
+	if err = ts.writeResults(); err != nil {
+		return err
+	}
+	if rerr != nil {
+		return rerr
+	}
+
+	var failed []string
+	for _, rs := range cur.Results {
+		if !rs.Passed {
+			failed = append(failed, fmt.Sprintf("%q (%s)", rs.Version, rs.Error))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d VPC CNI version(s) failed: %s", len(failed), len(cur.Results), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// testVersion rolls "aws-node" to the version and runs the checks.
+// The Pods are created per version, to be assigned the IPs by the version.
+func (ts *tester) testVersion(idx int, version string) (rs eksconfig.CNIVersionResult) {
+	cur := ts.cfg.EKSConfig.AddOnCNIVersionMatrix
+	rs = eksconfig.CNIVersionResult{Version: version}
+	defer func() {
+		rs.Passed = rs.Error == ""
+		if err := ts.deleteCheckPods(idx); err != nil {
+			ts.cfg.Logger.Warn("failed to delete check Pods", zap.String("version", version), zap.Error(err))
+		}
+	}()
+
+	start := time.Now()
+	err := ts.setVersion(version)
+	rs.RolloutTime = time.Since(start)
+	rs.RolloutTimeString = rs.RolloutTime.String()
+	if err != nil {
+		rs.Error = err.Error()
+		return rs
+	}
+
+	start = time.Now()
+	rs.PodsReady, err = ts.checkPodDensity(idx)
+	rs.PodDensityTime = time.Since(start)
+	rs.PodDensityTimeString = rs.PodDensityTime.String()
+	if err != nil {
+		rs.Error = err.Error()
+		return rs
+	}
+
+	if err = ts.checkConnectivity(idx); err != nil {
+		rs.Error = err.Error()
+		return rs
+	}
+	rs.Connectivity = true
+
+	ts.cfg.Logger.Info("VPC CNI version passed",
+		zap.String("version", version),
+		zap.String("rollout-time", rs.RolloutTimeString),
+		zap.String("pod-density-time", rs.PodDensityTimeString),
+		zap.Int("pods-ready", rs.PodsReady),
+		zap.String("check-timeout", cur.CheckTimeoutString),
+	)
+	return rs
+}
+
+func (ts *tester) writeResults() error {
+	cur := ts.cfg.EKSConfig.AddOnCNIVersionMatrix
+	if err := writeJUnit(cur.ResultJUnitXMLPath, toJUnit(pkgName, cur.Results)); err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("wrote VPC CNI version matrix results", zap.String("path", cur.ResultJUnitXMLPath))
+	return aws_s3.Upload(
+		ts.cfg.Logger,
+		ts.cfg.S3API,
+		ts.cfg.EKSConfig.S3.BucketName,
+		cur.ResultJUnitXMLS3Key,
+		cur.ResultJUnitXMLPath,
+	)
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnCNIVersionMatrix() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnCNIVersionMatrix.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnCNIVersionMatrix.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnCNIVersionMatrix.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete CNI version matrix namespace (%v)", err))
+	}
+	// no-op if restored on "Create"
+	if err := ts.restoreOriginalImages(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnCNIVersionMatrix.Created = false
+	})
+	return nil
+}
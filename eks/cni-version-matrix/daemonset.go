@@ -0,0 +1,180 @@
+package cniversionmatrix
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	aws_ecr "github.com/aws/aws-k8s-tester/pkg/aws/ecr"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	cniNamespace     = "kube-system"
+	cniDaemonSetName = "aws-node"
+)
+
+// cniContainers are the "aws-node" containers of the amazon-vpc-cni-k8s
+// release images. The network policy agent is versioned separately.
+var cniContainers = map[string]struct{}{
+	"aws-node":         {},
+	"aws-vpc-cni-init": {},
+}
+
+func (ts *tester) getDaemonSet() (*appsv1.DaemonSet, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	ds, err := ts.cfg.K8SClient.KubernetesClientSet().AppsV1().DaemonSets(cniNamespace).Get(ctx, cniDaemonSetName, metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %q DaemonSet (%v)", cniDaemonSetName, err)
+	}
+	return ds, nil
+}
+
+// recordOriginalImages records the "aws-node" images before the matrix,
+// unless recorded by the previous run.
+func (ts *tester) recordOriginalImages() error {
+	cur := ts.cfg.EKSConfig.AddOnCNIVersionMatrix
+	if len(cur.OriginalImages) > 0 {
+		return nil
+	}
+	ds, err := ts.getDaemonSet()
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.OriginalImages = cniImages(ds.Spec.Template.Spec)
+	})
+	if len(cur.OriginalImages) == 0 {
+		return fmt.Errorf("no VPC CNI container found in %q DaemonSet", cniDaemonSetName)
+	}
+	ts.cfg.EKSConfig.Sync()
+	ts.cfg.Logger.Info("recorded original VPC CNI images", zap.Any("images", cur.OriginalImages))
+	return nil
+}
+
+// setVersion rolls "aws-node" to the version.
+func (ts *tester) setVersion(version string) error {
+	cur := ts.cfg.EKSConfig.AddOnCNIVersionMatrix
+	images := make(map[string]string)
+	for name, img := range cur.OriginalImages {
+		vimg, err := versionImage(img, cur.ImageRegistry, version)
+		if err != nil {
+			return err
+		}
+		images[name] = vimg
+	}
+	ts.cfg.Logger.Info("rolling VPC CNI version", zap.String("version", version), zap.Any("images", images))
+	return ts.updateImages(images)
+}
+
+// restoreOriginalImages rolls "aws-node" back to the original images.
+func (ts *tester) restoreOriginalImages() error {
+	cur := ts.cfg.EKSConfig.AddOnCNIVersionMatrix
+	if len(cur.OriginalImages) == 0 {
+		return nil
+	}
+	ts.cfg.Logger.Info("restoring original VPC CNI images", zap.Any("images", cur.OriginalImages))
+	if err := ts.updateImages(cur.OriginalImages); err != nil {
+		return fmt.Errorf("failed to restore original VPC CNI images (%v)", err)
+	}
+	return nil
+}
+
+// updateImages updates the "aws-node" container images if changed,
+// and waits for the rollout.
+func (ts *tester) updateImages(images map[string]string) error {
+	ds, err := ts.getDaemonSet()
+	if err != nil {
+		return err
+	}
+	if !setCNIImages(&ds.Spec.Template.Spec, images) {
+		ts.cfg.Logger.Info("VPC CNI images unchanged")
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.K8SClient.KubernetesClientSet().AppsV1().DaemonSets(cniNamespace).Update(ctx, ds, metav1.UpdateOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to update %q DaemonSet (%v)", cniDaemonSetName, err)
+	}
+	return ts.waitRollout()
+}
+
+// waitRollout waits for the "aws-node" rollout within "RolloutTimeout".
+func (ts *tester) waitRollout() error {
+	cur := ts.cfg.EKSConfig.AddOnCNIVersionMatrix
+	waitStart := time.Now()
+	for time.Since(waitStart) < cur.RolloutTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return fmt.Errorf("%q DaemonSet rollout aborted", cniDaemonSetName)
+		case <-time.After(10 * time.Second):
+		}
+		ds, err := ts.getDaemonSet()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get DaemonSet", zap.Error(err))
+			continue
+		}
+		st := ds.Status
+		ts.cfg.Logger.Info("polled DaemonSet",
+			zap.String("name", cniDaemonSetName),
+			zap.Int32("desired", st.DesiredNumberScheduled),
+			zap.Int32("updated", st.UpdatedNumberScheduled),
+			zap.Int32("available", st.NumberAvailable),
+		)
+		if st.DesiredNumberScheduled > 0 &&
+			st.ObservedGeneration >= ds.Generation &&
+			st.UpdatedNumberScheduled == st.DesiredNumberScheduled &&
+			st.NumberAvailable == st.DesiredNumberScheduled {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q DaemonSet not rolled out in %s", cniDaemonSetName, cur.RolloutTimeoutString)
+}
+
+// cniImages returns the images of the VPC CNI containers by the container names.
+func cniImages(spec v1.PodSpec) map[string]string {
+	images := make(map[string]string)
+	for _, cs := range [][]v1.Container{spec.InitContainers, spec.Containers} {
+		for _, c := range cs {
+			if _, ok := cniContainers[c.Name]; ok {
+				images[c.Name] = c.Image
+			}
+		}
+	}
+	return images
+}
+
+// setCNIImages sets the container images by the container names,
+// and returns true if any image changed.
+func setCNIImages(spec *v1.PodSpec, images map[string]string) (changed bool) {
+	for _, cs := range [][]v1.Container{spec.InitContainers, spec.Containers} {
+		for i := range cs {
+			if img, ok := images[cs[i].Name]; ok && cs[i].Image != img {
+				cs[i].Image = img
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// versionImage returns the image of the same repository with the version tag,
+// in the registry if not empty.
+// e.g. "602401143452.dkr.ecr.us-west-2.amazonaws.com/amazon-k8s-cni:v1.15.1-eksbuild.1"
+// to "602401143452.dkr.ecr.us-west-2.amazonaws.com/amazon-k8s-cni:v1.16.0"
+func versionImage(img string, registry string, version string) (string, error) {
+	ref, err := aws_ecr.ParseReference(img)
+	if err != nil {
+		return "", err
+	}
+	ref.Tag, ref.Digest = version, ""
+	if registry != "" {
+		ref.Host = registry
+	}
+	return ref.String(), nil
+}
@@ -0,0 +1,69 @@
+package cniversionmatrix
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestVersionImage(t *testing.T) {
+	tt := []struct {
+		img      string
+		registry string
+		version  string
+		exp      string
+	}{
+		{
+			img:     "602401143452.dkr.ecr.us-west-2.amazonaws.com/amazon-k8s-cni:v1.15.1-eksbuild.1",
+			version: "v1.16.0",
+			exp:     "602401143452.dkr.ecr.us-west-2.amazonaws.com/amazon-k8s-cni:v1.16.0",
+		},
+		{
+			img:      "602401143452.dkr.ecr.us-west-2.amazonaws.com/amazon-k8s-cni-init:v1.15.1",
+			registry: "public.ecr.aws/eks",
+			version:  "v1.16.0",
+			exp:      "public.ecr.aws/eks/amazon-k8s-cni-init:v1.16.0",
+		},
+		{
+			img:     "602401143452.dkr.ecr.us-west-2.amazonaws.com/amazon-k8s-cni@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			version: "v1.16.0",
+			exp:     "602401143452.dkr.ecr.us-west-2.amazonaws.com/amazon-k8s-cni:v1.16.0",
+		},
+	}
+	for i, tv := range tt {
+		img, err := versionImage(tv.img, tv.registry, tv.version)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if img != tv.exp {
+			t.Fatalf("#%d: expected %q, got %q", i, tv.exp, img)
+		}
+	}
+	if _, err := versionImage("", "", "v1.16.0"); err == nil {
+		t.Fatal("expected error for empty image")
+	}
+}
+
+func TestSetCNIImages(t *testing.T) {
+	spec := v1.PodSpec{
+		InitContainers: []v1.Container{{Name: "aws-vpc-cni-init", Image: "a-init:v1"}},
+		Containers: []v1.Container{
+			{Name: "aws-node", Image: "a:v1"},
+			{Name: "aws-eks-nodeagent", Image: "agent:v1"},
+		},
+	}
+	images := cniImages(spec)
+	if len(images) != 2 || images["aws-node"] != "a:v1" || images["aws-vpc-cni-init"] != "a-init:v1" {
+		t.Fatalf("unexpected images %v", images)
+	}
+
+	if !setCNIImages(&spec, map[string]string{"aws-node": "a:v2", "aws-vpc-cni-init": "a-init:v2"}) {
+		t.Fatal("expected images changed")
+	}
+	if spec.InitContainers[0].Image != "a-init:v2" || spec.Containers[0].Image != "a:v2" || spec.Containers[1].Image != "agent:v1" {
+		t.Fatalf("unexpected spec %+v", spec)
+	}
+	if setCNIImages(&spec, map[string]string{"aws-node": "a:v2", "aws-vpc-cni-init": "a-init:v2"}) {
+		t.Fatal("expected images unchanged")
+	}
+}
@@ -0,0 +1,65 @@
+package cniversionmatrix
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+)
+
+// JUnit XML as consumed by the CI test result reporters.
+// ref. https://llg.cubic.org/docs/junit/
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func toJUnit(name string, results []eksconfig.CNIVersionResult) junitTestSuite {
+	suite := junitTestSuite{Name: name, Tests: len(results)}
+	total := 0.0
+	for _, rs := range results {
+		took := (rs.RolloutTime + rs.PodDensityTime).Seconds()
+		tc := junitTestCase{
+			Name:      fmt.Sprintf("VPC CNI %s", rs.Version),
+			ClassName: "cni-version-matrix",
+			Time:      fmt.Sprintf("%.3f", took),
+		}
+		if !rs.Passed {
+			suite.Failures++
+			tc.Failure = &junitFailure{
+				Message: rs.Error,
+				Text: fmt.Sprintf("rollout %s, pod density %s, %d Pods ready, connectivity %v",
+					rs.RolloutTimeString, rs.PodDensityTimeString, rs.PodsReady, rs.Connectivity),
+			}
+		}
+		total += took
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	suite.Time = fmt.Sprintf("%.3f", total)
+	return suite
+}
+
+func writeJUnit(p string, suite junitTestSuite) error {
+	b, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p, append([]byte(xml.Header), b...), 0600)
+}
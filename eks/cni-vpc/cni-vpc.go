@@ -580,6 +580,16 @@ func (ts *tester) updateCNIDaemonSet() (err error) {
 			Value: "1",
 		})
 	}
+	if ts.cfg.EKSConfig.IPFamily == eksconfig.IPFamilyIPv6 {
+		// IPv6 mode assigns /80 prefixes to the node ENIs
+		// ref. https://github.com/aws/amazon-vpc-cni-k8s#enable_ipv6
+		envVars = append(envVars,
+			v1.EnvVar{Name: "ENABLE_IPv4", Value: "false"},
+			v1.EnvVar{Name: "ENABLE_IPv6", Value: "true"},
+			v1.EnvVar{Name: "ENABLE_PREFIX_DELEGATION", Value: "true"},
+			v1.EnvVar{Name: "WARM_PREFIX_TARGET", Value: "1"},
+		)
+	}
 
 	dirOrCreate := v1.HostPathDirectoryOrCreate
 	podSpec := v1.PodTemplateSpec{
@@ -0,0 +1,51 @@
+package configmaps
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	metricStorageObjects = "apiserver_storage_objects"
+	// deprecated in 1.21 for "apiserver_storage_objects"
+	metricEtcdObjectCounts = "etcd_object_counts"
+)
+
+// FetchStorageObjects returns the number of "ConfigMap" objects
+// stored in etcd, reported by the kube-apiserver "/metrics".
+func FetchStorageObjects(cli kubernetes.Interface) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	b, err := cli.CoreV1().RESTClient().Get().RequestURI("/metrics").Do(ctx).Raw()
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch /metrics (%v)", err)
+	}
+	return parseStorageObjects(b, "configmaps")
+}
+
+func parseStorageObjects(b []byte, resource string) (int64, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(b))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse /metrics (%v)", err)
+	}
+	for _, name := range []string{metricStorageObjects, metricEtcdObjectCounts} {
+		mf, ok := families[name]
+		if !ok {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "resource" && l.GetValue() == resource {
+					return int64(m.GetGauge().GetValue()), nil
+				}
+			}
+		}
+	}
+	return 0, fmt.Errorf("%q storage objects not found", resource)
+}
@@ -0,0 +1,42 @@
+package configmaps
+
+import "testing"
+
+func TestParseStorageObjects(t *testing.T) {
+	tt := []struct {
+		metrics string
+		objects int64
+		err     bool
+	}{
+		{
+			metrics: `# HELP apiserver_storage_objects [STABLE] Number of stored objects at the time of last check split by kind.
+# TYPE apiserver_storage_objects gauge
+apiserver_storage_objects{resource="configmaps"} 1042
+apiserver_storage_objects{resource="secrets"} 17
+`,
+			objects: 1042,
+		},
+		{
+			metrics: `# HELP etcd_object_counts [ALPHA] Number of stored objects at the time of last check split by kind.
+# TYPE etcd_object_counts gauge
+etcd_object_counts{resource="configmaps"} 55
+`,
+			objects: 55,
+		},
+		{
+			metrics: `# TYPE apiserver_storage_objects gauge
+apiserver_storage_objects{resource="secrets"} 17
+`,
+			err: true,
+		},
+	}
+	for i, tv := range tt {
+		objects, err := parseStorageObjects([]byte(tv.metrics), "configmaps")
+		if (err != nil) != tv.err {
+			t.Fatalf("#%d: unexpected error %v", i, err)
+		}
+		if objects != tv.objects {
+			t.Fatalf("#%d: expected %d, got %d", i, tv.objects, objects)
+		}
+	}
+}
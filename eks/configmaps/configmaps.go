@@ -45,6 +45,24 @@ var (
 			Name:      "write_request_latency_milliseconds",
 			Help:      "Bucketed histogram of client-side write request and response latency.",
 
+			// lowest bucket start of upper bound 0.5 ms with factor 2
+			// highest bucket start of 0.5 ms * 2^13 == 4.096 sec
+			Buckets: prometheus.ExponentialBuckets(0.5, 2, 14),
+		})
+	watchEventsTotal = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "configmaps",
+			Subsystem: "client",
+			Name:      "watch_events_total",
+			Help:      "Total number of received watch events for the written objects.",
+		})
+	watchLatencyMs = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "configmaps",
+			Subsystem: "client",
+			Name:      "watch_latency_milliseconds",
+			Help:      "Bucketed histogram of latency from write request to watch event.",
+
 			// lowest bucket start of upper bound 0.5 ms with factor 2
 			// highest bucket start of 0.5 ms * 2^13 == 4.096 sec
 			Buckets: prometheus.ExponentialBuckets(0.5, 2, 14),
@@ -55,6 +73,8 @@ func init() {
 	prometheus.MustRegister(writeRequestsSuccessTotal)
 	prometheus.MustRegister(writeRequestsFailureTotal)
 	prometheus.MustRegister(writeRequestLatencyMs)
+	prometheus.MustRegister(watchEventsTotal)
+	prometheus.MustRegister(watchLatencyMs)
 }
 
 // Config configures configmap loader.
@@ -80,6 +100,19 @@ type Config struct {
 	RequestsSummaryWritesJSONS3Key  string
 	RequestsSummaryWritesTablePath  string
 	RequestsSummaryWritesTableS3Key string
+
+	// Watchers is the number of concurrent watchers on the namespace.
+	// Zero disables the watch latency measurement.
+	Watchers int
+
+	RequestsRawWatchesJSONPath       string
+	RequestsRawWatchesJSONS3Key      string
+	RequestsRawWatchesCSVPath        string
+	RequestsRawWatchesCSVS3Key       string
+	RequestsSummaryWatchesJSONPath   string
+	RequestsSummaryWatchesJSONS3Key  string
+	RequestsSummaryWatchesTablePath  string
+	RequestsSummaryWatchesTableS3Key string
 }
 
 // Loader defines configmap loader operations.
@@ -87,6 +120,9 @@ type Loader interface {
 	Start()
 	Stop()
 	CollectMetrics() (writeLatencies metrics.Durations, writesSummary metrics.RequestsSummary, err error)
+	// CollectWatchMetrics returns the latencies from write requests
+	// to watch events. Only valid when "Watchers" > 0.
+	CollectWatchMetrics() (watchLatencies metrics.Durations, watchesSummary metrics.RequestsSummary, err error)
 }
 
 type loader struct {
@@ -95,6 +131,9 @@ type loader struct {
 	donecCloseOnce *sync.Once
 
 	writeLatencies metrics.Durations
+	watchLatencies metrics.Durations
+	// written is the number of successful writes.
+	written int
 }
 
 func New(cfg Config) Loader {
@@ -107,8 +146,20 @@ func New(cfg Config) Loader {
 
 func (ld *loader) Start() {
 	ld.cfg.Logger.Info("starting write function", zap.String("namespace-write", ld.cfg.Namespace))
-	ld.writeLatencies = startWrites(ld.cfg.Logger, ld.cfg.Client.KubernetesClientSet(), ld.cfg.ClientTimeout, ld.cfg.Namespace, ld.cfg.Objects, ld.cfg.ObjectSize, ld.cfg.Stopc, ld.donec)
-	ld.cfg.Logger.Info("completed write function", zap.String("namespace-write", ld.cfg.Namespace))
+	var ws *watchers
+	if ld.cfg.Watchers > 0 {
+		ws = newWatchers(ld.cfg.Logger, ld.cfg.Client.KubernetesClientSet(), ld.cfg.Namespace, ld.cfg.Watchers)
+		if err := ws.start(); err != nil {
+			ld.cfg.Logger.Warn("failed to start watchers; skipping watch latency", zap.Error(err))
+			ws = nil
+		}
+	}
+	ld.writeLatencies, ld.written = startWrites(ld.cfg.Logger, ld.cfg.Client.KubernetesClientSet(), ld.cfg.ClientTimeout, ld.cfg.Namespace, ld.cfg.Objects, ld.cfg.ObjectSize, ws, ld.cfg.Stopc, ld.donec)
+	if ws != nil {
+		ws.wait(ld.written, time.Minute)
+		ld.watchLatencies = ws.stop()
+	}
+	ld.cfg.Logger.Info("completed write function", zap.String("namespace-write", ld.cfg.Namespace), zap.Int("written", ld.written))
 }
 
 func (ld *loader) Stop() {
@@ -211,7 +262,109 @@ func (ts *loader) CollectMetrics() (writeLatencies metrics.Durations, writesSumm
 	return ts.writeLatencies, writesSummary, nil
 }
 
-func startWrites(lg *zap.Logger, cli *kubernetes.Clientset, timeout time.Duration, namespace string, objects int, objectSize int, stopc chan struct{}, donec chan struct{}) (ds metrics.Durations) {
+// CollectWatchMetrics writes the watch latencies in JSON and CSV,
+// and the watch latency summary.
+func (ts *loader) CollectWatchMetrics() (watchLatencies metrics.Durations, watchesSummary metrics.RequestsSummary, err error) {
+	curTS := time.Now().UTC().Format(time.RFC3339Nano)
+	watchesSummary = metrics.RequestsSummary{TestID: curTS}
+
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to gather prometheus metrics", zap.Error(err))
+		return nil, metrics.RequestsSummary{}, err
+	}
+	for _, mf := range mfs {
+		if mf == nil {
+			continue
+		}
+		switch *mf.Name {
+		case "configmaps_client_watch_events_total":
+			gg := mf.Metric[0].GetGauge()
+			watchesSummary.SuccessTotal = gg.GetValue()
+		case "configmaps_client_watch_latency_milliseconds":
+			watchesSummary.LatencyHistogram, err = metrics.ParseHistogram("milliseconds", mf.Metric[0].GetHistogram())
+			if err != nil {
+				return nil, metrics.RequestsSummary{}, err
+			}
+		}
+	}
+	// watchers that missed events
+	watchesSummary.FailureTotal = float64(ts.cfg.Watchers*ts.written) - watchesSummary.SuccessTotal
+	if watchesSummary.FailureTotal < 0 {
+		watchesSummary.FailureTotal = 0
+	}
+
+	sort.Sort(ts.watchLatencies)
+	watchesSummary.LantencyP50 = ts.watchLatencies.PickLantencyP50()
+	watchesSummary.LantencyP90 = ts.watchLatencies.PickLantencyP90()
+	watchesSummary.LantencyP99 = ts.watchLatencies.PickLantencyP99()
+	watchesSummary.LantencyP999 = ts.watchLatencies.PickLantencyP999()
+	watchesSummary.LantencyP9999 = ts.watchLatencies.PickLantencyP9999()
+
+	ts.cfg.Logger.Info("writing watch latency results in JSON to disk", zap.String("path", ts.cfg.RequestsRawWatchesJSONPath))
+	wb, err := json.Marshal(ts.watchLatencies)
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to encode latency results in JSON", zap.Error(err))
+		return nil, metrics.RequestsSummary{}, err
+	}
+	if err = ioutil.WriteFile(ts.cfg.RequestsRawWatchesJSONPath, wb, 0600); err != nil {
+		ts.cfg.Logger.Warn("failed to write latency results in JSON to disk", zap.String("path", ts.cfg.RequestsRawWatchesJSONPath), zap.Error(err))
+		return nil, metrics.RequestsSummary{}, err
+	}
+	if err = aws_s3.Upload(
+		ts.cfg.Logger,
+		ts.cfg.S3API,
+		ts.cfg.S3BucketName,
+		ts.cfg.RequestsRawWatchesJSONS3Key,
+		ts.cfg.RequestsRawWatchesJSONPath,
+	); err != nil {
+		return nil, metrics.RequestsSummary{}, err
+	}
+	if err = metrics.LabelDurations(ts.watchLatencies, curTS).CSV(ts.cfg.RequestsRawWatchesCSVPath); err != nil {
+		return nil, metrics.RequestsSummary{}, err
+	}
+	if err = aws_s3.Upload(
+		ts.cfg.Logger,
+		ts.cfg.S3API,
+		ts.cfg.S3BucketName,
+		ts.cfg.RequestsRawWatchesCSVS3Key,
+		ts.cfg.RequestsRawWatchesCSVPath,
+	); err != nil {
+		return nil, metrics.RequestsSummary{}, err
+	}
+
+	if err = ioutil.WriteFile(ts.cfg.RequestsSummaryWatchesJSONPath, []byte(watchesSummary.JSON()), 0600); err != nil {
+		ts.cfg.Logger.Warn("failed to write file", zap.Error(err))
+		return nil, metrics.RequestsSummary{}, err
+	}
+	if err = aws_s3.Upload(
+		ts.cfg.Logger,
+		ts.cfg.S3API,
+		ts.cfg.S3BucketName,
+		ts.cfg.RequestsSummaryWatchesJSONS3Key,
+		ts.cfg.RequestsSummaryWatchesJSONPath,
+	); err != nil {
+		return nil, metrics.RequestsSummary{}, err
+	}
+	if err = ioutil.WriteFile(ts.cfg.RequestsSummaryWatchesTablePath, []byte(watchesSummary.Table()), 0600); err != nil {
+		ts.cfg.Logger.Warn("failed to write file", zap.Error(err))
+		return nil, metrics.RequestsSummary{}, err
+	}
+	if err = aws_s3.Upload(
+		ts.cfg.Logger,
+		ts.cfg.S3API,
+		ts.cfg.S3BucketName,
+		ts.cfg.RequestsSummaryWatchesTableS3Key,
+		ts.cfg.RequestsSummaryWatchesTablePath,
+	); err != nil {
+		return nil, metrics.RequestsSummary{}, err
+	}
+	fmt.Fprintf(ts.cfg.LogWriter, "\n\nSummaryWatchesTable:\n%s\n", watchesSummary.Table())
+
+	return ts.watchLatencies, watchesSummary, nil
+}
+
+func startWrites(lg *zap.Logger, cli *kubernetes.Clientset, timeout time.Duration, namespace string, objects int, objectSize int, ws *watchers, stopc chan struct{}, donec chan struct{}) (ds metrics.Durations, written int) {
 	lg.Info("starting startWrites", zap.Int("objects", objects), zap.Int("object-size", objectSize))
 	ds = make(metrics.Durations, 0, 20000)
 
@@ -230,6 +383,9 @@ func startWrites(lg *zap.Logger, cli *kubernetes.Clientset, timeout time.Duratio
 		key := fmt.Sprintf("configmap%d%s", i, randutil.String(7))
 
 		start := time.Now()
+		if ws != nil {
+			ws.observeWrite(key, start)
+		}
 		ctx, cancel := context.WithTimeout(context.Background(), timeout)
 		_, err := cli.
 			CoreV1().
@@ -258,10 +414,11 @@ func startWrites(lg *zap.Logger, cli *kubernetes.Clientset, timeout time.Duratio
 			lg.Warn("write configmap failed", zap.String("namespace", namespace), zap.Error(err))
 		} else {
 			writeRequestsSuccessTotal.Inc()
+			written++
 			if i%20 == 0 {
 				lg.Info("wrote configmap", zap.Int("iteration", i), zap.String("namespace", namespace))
 			}
 		}
 	}
-	return ds
+	return ds, written
 }
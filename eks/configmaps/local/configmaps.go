@@ -50,6 +50,24 @@ func New(cfg Config) eks_tester.Tester {
 	return &tester{cfg: cfg}
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:      "configmaps-local",
+		Exclusive: true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				S3API:     cfg.S3API,
+				CWAPI:     cfg.CWAPI,
+			}), cfg.EKSConfig.IsEnabledAddOnConfigmapsLocal)
+		},
+	})
+}
+
 type tester struct {
 	cfg Config
 }
@@ -65,13 +83,15 @@ func (ts *tester) Create() (err error) {
 	}
 
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
-	ts.cfg.EKSConfig.AddOnConfigmapsLocal.Created = true
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnConfigmapsLocal.Created = true
+	})
 	createStart := time.Now()
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnConfigmapsLocal.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnConfigmapsLocal.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
 	if err := k8s_client.CreateNamespace(
@@ -99,18 +119,57 @@ func (ts *tester) Create() (err error) {
 		RequestsSummaryWritesJSONS3Key:  ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsSummaryWritesJSONS3Key,
 		RequestsSummaryWritesTablePath:  ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsSummaryWritesTablePath,
 		RequestsSummaryWritesTableS3Key: ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsSummaryWritesTableS3Key,
+
+		Watchers:                         ts.cfg.EKSConfig.AddOnConfigmapsLocal.Watchers,
+		RequestsRawWatchesJSONPath:       ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsRawWatchesJSONPath,
+		RequestsRawWatchesJSONS3Key:      ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsRawWatchesJSONS3Key,
+		RequestsRawWatchesCSVPath:        ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsRawWatchesCSVPath,
+		RequestsRawWatchesCSVS3Key:       ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsRawWatchesCSVS3Key,
+		RequestsSummaryWatchesJSONPath:   ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsSummaryWatchesJSONPath,
+		RequestsSummaryWatchesJSONS3Key:  ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsSummaryWatchesJSONS3Key,
+		RequestsSummaryWatchesTablePath:  ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsSummaryWatchesTablePath,
+		RequestsSummaryWatchesTableS3Key: ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsSummaryWatchesTableS3Key,
 	})
+
+	// best-effort, older clusters may not report the storage objects
+	etcdObjectsBefore, err := config_maps.FetchStorageObjects(ts.cfg.K8SClient.KubernetesClientSet())
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to fetch etcd objects", zap.Error(err))
+	}
 	loader.Start()
 	loader.Stop()
+	etcdObjectsAfter, err := config_maps.FetchStorageObjects(ts.cfg.K8SClient.KubernetesClientSet())
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to fetch etcd objects", zap.Error(err))
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnConfigmapsLocal.EtcdObjectsBefore = etcdObjectsBefore
+		ts.cfg.EKSConfig.AddOnConfigmapsLocal.EtcdObjectsAfter = etcdObjectsAfter
+	})
+	ts.cfg.Logger.Info("etcd ConfigMap objects",
+		zap.Int64("before", etcdObjectsBefore),
+		zap.Int64("after", etcdObjectsAfter),
+	)
 
 	ts.cfg.Logger.Info("completing configmaps local tester")
-	var curWriteLatencies metrics.Durations
-	curWriteLatencies, ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsSummaryWrites, err = loader.CollectMetrics()
-	ts.cfg.EKSConfig.Sync()
+	curWriteLatencies, writes, err := loader.CollectMetrics()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsSummaryWrites = writes
+	})
 	if err != nil {
 		ts.cfg.Logger.Warn("failed to get metrics", zap.Error(err))
 		return err
 	}
+	if ts.cfg.EKSConfig.AddOnConfigmapsLocal.Watchers > 0 {
+		_, watches, err := loader.CollectWatchMetrics()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsSummaryWatches = watches
+		})
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get watch metrics", zap.Error(err))
+			return err
+		}
+	}
 
 	if err = ts.checkResults(curWriteLatencies); err != nil {
 		return err
@@ -137,8 +196,9 @@ func (ts *tester) Delete() error {
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnConfigmapsLocal.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnConfigmapsLocal.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
 	}()
 
 	var errs []string
@@ -158,8 +218,9 @@ func (ts *tester) Delete() error {
 		return errors.New(strings.Join(errs, ", "))
 	}
 
-	ts.cfg.EKSConfig.AddOnConfigmapsLocal.Created = false
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnConfigmapsLocal.Created = false
+	})
 	return nil
 }
 
@@ -189,11 +250,15 @@ func (ts *tester) checkResults(curWriteLatencies metrics.Durations) (err error)
 			ts.cfg.Logger.Warn("failed to download results", zap.Error(err))
 			return err
 		}
-		ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsSummaryWritesCompare, err = metrics.CompareRequestsSummary(prevSummary, ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsSummaryWrites)
+		var compare metrics.RequestsCompare
+		compare, err = metrics.CompareRequestsSummary(prevSummary, ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsSummaryWrites)
 		if err != nil {
 			ts.cfg.Logger.Warn("failed to compare results", zap.Error(err))
 			return err
 		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsSummaryWritesCompare = compare
+		})
 		if err = ioutil.WriteFile(ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsSummaryWritesCompareJSONPath, []byte(ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsSummaryWritesCompare.JSON()), 0600); err != nil {
 			ts.cfg.Logger.Warn("failed to write file", zap.Error(err))
 			return err
@@ -332,5 +397,25 @@ func (ts *tester) publishResults() (err error) {
 		Unit:       aws.String(cloudwatch.StandardUnitMilliseconds),
 		Value:      aws.Float64(float64(ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsSummaryWrites.LantencyP9999.Milliseconds())),
 	})
+	if ts.cfg.EKSConfig.AddOnConfigmapsLocal.Watchers > 0 {
+		datums = append(datums, &cloudwatch.MetricDatum{
+			Timestamp:  tv,
+			MetricName: aws.String("add-on-configmaps-local-watches-latency-p50"),
+			Unit:       aws.String(cloudwatch.StandardUnitMilliseconds),
+			Value:      aws.Float64(float64(ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsSummaryWatches.LantencyP50.Milliseconds())),
+		})
+		datums = append(datums, &cloudwatch.MetricDatum{
+			Timestamp:  tv,
+			MetricName: aws.String("add-on-configmaps-local-watches-latency-p99"),
+			Unit:       aws.String(cloudwatch.StandardUnitMilliseconds),
+			Value:      aws.Float64(float64(ts.cfg.EKSConfig.AddOnConfigmapsLocal.RequestsSummaryWatches.LantencyP99.Milliseconds())),
+		})
+	}
+	datums = append(datums, &cloudwatch.MetricDatum{
+		Timestamp:  tv,
+		MetricName: aws.String("add-on-configmaps-local-etcd-objects-growth"),
+		Unit:       aws.String(cloudwatch.StandardUnitCount),
+		Value:      aws.Float64(float64(ts.cfg.EKSConfig.AddOnConfigmapsLocal.EtcdObjectsAfter - ts.cfg.EKSConfig.AddOnConfigmapsLocal.EtcdObjectsBefore)),
+	})
 	return cw.PutData(ts.cfg.Logger, ts.cfg.CWAPI, ts.cfg.EKSConfig.CWNamespace, 20, datums...)
 }
@@ -0,0 +1,124 @@
+package configmaps
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/metrics"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// watchers measures the time from a "ConfigMap" create request
+// to its "ADDED" event delivered to each watcher.
+type watchers struct {
+	lg        *zap.Logger
+	cli       kubernetes.Interface
+	namespace string
+
+	// writes maps the ConfigMap name to its create request start time.
+	writes sync.Map
+
+	mu        sync.Mutex
+	latencies metrics.Durations
+	// events is the number of received "ADDED" events per watcher.
+	events []int
+
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+}
+
+func newWatchers(lg *zap.Logger, cli kubernetes.Interface, namespace string, n int) *watchers {
+	return &watchers{
+		lg:        lg,
+		cli:       cli,
+		namespace: namespace,
+		latencies: make(metrics.Durations, 0, 20000),
+		events:    make([]int, n),
+	}
+}
+
+// start opens all watches before returning, so that no write is missed.
+func (ws *watchers) start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	ws.cancel = cancel
+	for i := range ws.events {
+		w, err := ws.cli.CoreV1().ConfigMaps(ws.namespace).Watch(ctx, metav1.ListOptions{})
+		if err != nil {
+			cancel()
+			return err
+		}
+		ws.wg.Add(1)
+		go ws.receive(i, w)
+	}
+	ws.lg.Info("started watchers", zap.Int("watchers", len(ws.events)))
+	return nil
+}
+
+func (ws *watchers) observeWrite(name string, start time.Time) {
+	ws.writes.Store(name, start)
+}
+
+func (ws *watchers) receive(idx int, w watch.Interface) {
+	defer ws.wg.Done()
+	defer w.Stop()
+	for ev := range w.ResultChan() {
+		now := time.Now()
+		if ev.Type != watch.Added {
+			continue
+		}
+		cm, ok := ev.Object.(*v1.ConfigMap)
+		if !ok {
+			continue
+		}
+		v, ok := ws.writes.Load(cm.Name)
+		if !ok {
+			continue
+		}
+		took := now.Sub(v.(time.Time))
+		watchLatencyMs.Observe(float64(took / time.Millisecond))
+		watchEventsTotal.Inc()
+
+		ws.mu.Lock()
+		ws.latencies = append(ws.latencies, took)
+		ws.events[idx]++
+		ws.mu.Unlock()
+	}
+}
+
+// wait waits until every watcher receives the events of all
+// successful writes, or the timeout elapses.
+func (ws *watchers) wait(writes int, timeout time.Duration) {
+	start := time.Now()
+	for time.Since(start) < timeout {
+		ws.mu.Lock()
+		done := true
+		for _, n := range ws.events {
+			if n < writes {
+				done = false
+				break
+			}
+		}
+		ws.mu.Unlock()
+		if done {
+			ws.lg.Info("watchers received all events", zap.Int("writes", writes))
+			return
+		}
+		time.Sleep(time.Second)
+	}
+	ws.mu.Lock()
+	ws.lg.Warn("watchers missing events", zap.Int("writes", writes), zap.Ints("events", ws.events))
+	ws.mu.Unlock()
+}
+
+func (ws *watchers) stop() metrics.Durations {
+	ws.cancel()
+	ws.wg.Wait()
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return ws.latencies
+}
@@ -49,6 +49,23 @@ func New(cfg Config) eks_tester.Tester {
 	return &tester{cfg: cfg, donec: make(chan struct{})}
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:      "conformance",
+		Exclusive: true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				S3API:     cfg.S3API,
+			}), cfg.EKSConfig.IsEnabledAddOnConformance)
+		},
+	})
+}
+
 type tester struct {
 	cfg   Config
 	donec chan struct{}
@@ -65,15 +82,27 @@ func (ts *tester) Create() error {
 	}
 
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
-	ts.cfg.EKSConfig.AddOnConformance.Created = true
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnConformance.Created = true
+	})
 	createStart := time.Now()
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnConformance.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnConformance.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
+	if ts.cfg.EKSConfig.AddOnConformance.Runner == eksconfig.ConformanceRunnerHydrophone {
+		if err := ts.downloadInstallHydrophone(); err != nil {
+			return err
+		}
+		if err := ts.runHydrophone(); err != nil {
+			return err
+		}
+		return ts.checkJUnitResults()
+	}
+
 	if err := ts.downloadInstallSonobuoy(); err != nil {
 		return err
 	}
@@ -90,7 +119,7 @@ func (ts *tester) Create() error {
 		return err
 	}
 
-	return nil
+	return ts.checkJUnitResults()
 }
 
 func (ts *tester) Delete() error {
@@ -107,13 +136,18 @@ func (ts *tester) Delete() error {
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnConformance.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnConformance.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
 	}()
 
 	var errs []string
 
-	if err := ts.deleteSonobuoy(); err != nil {
+	if ts.cfg.EKSConfig.AddOnConformance.Runner == eksconfig.ConformanceRunnerHydrophone {
+		if err := ts.deleteHydrophone(); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete hydrophone %q", err))
+		}
+	} else if err := ts.deleteSonobuoy(); err != nil {
 		errs = append(errs, fmt.Sprintf("failed to delete sonobuoy %q", err))
 	}
 
@@ -121,8 +155,9 @@ func (ts *tester) Delete() error {
 		return errors.New(strings.Join(errs, ", "))
 	}
 
-	ts.cfg.EKSConfig.AddOnConformance.Created = false
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnConformance.Created = false
+	})
 	return nil
 }
 
@@ -455,7 +490,7 @@ func (ts *tester) checkResults() (err error) {
 		select {
 		case <-ts.cfg.Stopc:
 			ts.cfg.Logger.Warn("sonobuoy retrieve stopped")
-			return nil
+			return errors.New("sonobuoy retrieve aborted")
 		default:
 		}
 		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
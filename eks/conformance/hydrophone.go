@@ -0,0 +1,210 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	aws_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
+	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+	"github.com/aws/aws-k8s-tester/pkg/httputil"
+	"github.com/mholt/archiver/v3"
+	"go.uber.org/zap"
+	"k8s.io/utils/exec"
+)
+
+func (ts *tester) downloadInstallHydrophone() (err error) {
+	cur := ts.cfg.EKSConfig.AddOnConformance
+	ts.cfg.Logger.Info("mkdir", zap.String("hydrophone-path-dir", filepath.Dir(cur.HydrophonePath)))
+	if err = os.MkdirAll(filepath.Dir(cur.HydrophonePath), 0700); err != nil {
+		return fmt.Errorf("could not create %q (%v)", filepath.Dir(cur.HydrophonePath), err)
+	}
+
+	if !fileutil.Exist(cur.HydrophonePath) {
+		tarPath := filepath.Join(os.TempDir(), fmt.Sprintf("hydrophone-%x.tar.gz", time.Now().UnixNano()))
+		if err = httputil.Download(ts.cfg.Logger, os.Stderr, cur.HydrophoneDownloadURL, tarPath); err != nil {
+			return err
+		}
+		tmpDir, err := ioutil.TempDir(os.TempDir(), "hydrophone")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpDir)
+		if err = archiver.Unarchive(tarPath, tmpDir); err != nil {
+			return fmt.Errorf("failed to decompress hydrophone tar file %v", err)
+		}
+		if err = fileutil.Copy(filepath.Join(tmpDir, "hydrophone"), cur.HydrophonePath); err != nil {
+			return fmt.Errorf("failed to copy file %v", err)
+		}
+	} else {
+		ts.cfg.Logger.Info("skipping hydrophone download; already exist", zap.String("hydrophone-path", cur.HydrophonePath))
+	}
+
+	if err = fileutil.EnsureExecutable(cur.HydrophonePath); err != nil {
+		// file may be already executable while the process does not own the file/directory
+		// ref. https://github.com/aws/aws-k8s-tester/issues/66
+		ts.cfg.Logger.Warn("failed to ensure executable", zap.Error(err))
+		err = nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	output, err := exec.New().CommandContext(ctx, cur.HydrophonePath, "--version").CombinedOutput()
+	cancel()
+	out := strings.TrimSpace(string(output))
+	if err != nil {
+		return fmt.Errorf("'hydrophone --version' failed (output %q, error %v)", out, err)
+	}
+	ts.cfg.Logger.Info(
+		"hydrophone version",
+		zap.String("hydrophone-path", cur.HydrophonePath),
+		zap.String("hydrophone-version", out),
+	)
+	return nil
+}
+
+// runHydrophone runs the conformance tests until completion,
+// streaming the test logs, and writes the results to the output directory.
+// hydrophone exits with non-zero code on test failures, which are
+// reported from the JUnit results instead.
+func (ts *tester) runHydrophone() error {
+	cur := ts.cfg.EKSConfig.AddOnConformance
+	os.RemoveAll(cur.SonobuoyResultDir)
+	if err := os.MkdirAll(cur.SonobuoyResultDir, 0700); err != nil {
+		return err
+	}
+
+	args := []string{
+		cur.HydrophonePath,
+		"--conformance",
+		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+		"--namespace=" + cur.Namespace,
+		"--output-dir=" + cur.SonobuoyResultDir,
+		"--conformance-image=" + cur.SonobuoyRunKubeConformanceImage,
+		fmt.Sprintf("--parallel=%d", cur.HydrophoneParallel),
+	}
+	if cur.SonobuoyRunE2eFocus != "" {
+		args = append(args, "--focus="+cur.SonobuoyRunE2eFocus)
+	}
+	if cur.SonobuoyRunE2eSkip != "" {
+		args = append(args, "--skip="+cur.SonobuoyRunE2eSkip)
+	}
+	cmd := strings.Join(args, " ")
+	ts.cfg.Logger.Info("running hydrophone",
+		zap.String("command", cmd),
+		zap.String("timeout", cur.SonobuoyRunTimeoutString),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cur.SonobuoyRunTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-ts.cfg.Stopc:
+			ts.cfg.Logger.Warn("hydrophone run stopped")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	c := exec.New().CommandContext(ctx, args[0], args[1:]...)
+	c.SetStdout(ts.cfg.LogWriter)
+	c.SetStderr(ts.cfg.LogWriter)
+	err := c.Run()
+	if ctx.Err() != nil {
+		return fmt.Errorf("hydrophone run aborted or took too long (%v)", ctx.Err())
+	}
+	if err != nil {
+		ts.cfg.Logger.Warn("hydrophone exited with error", zap.String("command", cmd), zap.Error(err))
+	}
+	ts.cfg.Logger.Info("ran hydrophone", zap.String("command", cmd))
+
+	return ts.collectHydrophoneResults()
+}
+
+func (ts *tester) collectHydrophoneResults() error {
+	cur := ts.cfg.EKSConfig.AddOnConformance
+	logPath := filepath.Join(cur.SonobuoyResultDir, "e2e.log")
+	if !fileutil.Exist(logPath) {
+		return fmt.Errorf("result dir %q does not have e2e.log %q", cur.SonobuoyResultDir, logPath)
+	}
+	xmlPath := filepath.Join(cur.SonobuoyResultDir, "junit_01.xml")
+	if !fileutil.Exist(xmlPath) {
+		return fmt.Errorf("result dir %q does not have junit_01.xml %q", cur.SonobuoyResultDir, xmlPath)
+	}
+	if err := fileutil.Copy(logPath, cur.SonobuoyResultE2eLogPath); err != nil {
+		return err
+	}
+	if err := fileutil.Copy(xmlPath, cur.SonobuoyResultJunitXMLPath); err != nil {
+		return err
+	}
+	os.RemoveAll(cur.SonobuoyResultTarGzPath)
+	if err := archiver.Archive([]string{cur.SonobuoyResultDir}, cur.SonobuoyResultTarGzPath); err != nil {
+		return fmt.Errorf("failed to archive hydrophone results (%v)", err)
+	}
+	ts.cfg.Logger.Info("archived hydrophone results", zap.String("path", cur.SonobuoyResultTarGzPath))
+
+	for _, kv := range [][2]string{
+		{cur.SonobuoyResultTarGzS3Key, cur.SonobuoyResultTarGzPath},
+		{cur.SonobuoyResultE2eLogS3Key, cur.SonobuoyResultE2eLogPath},
+		{cur.SonobuoyResultJunitXMLS3Key, cur.SonobuoyResultJunitXMLPath},
+	} {
+		if err := aws_s3.Upload(
+			ts.cfg.Logger,
+			ts.cfg.S3API,
+			ts.cfg.EKSConfig.S3.BucketName,
+			kv[0],
+			kv[1],
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ts *tester) deleteHydrophone() error {
+	cur := ts.cfg.EKSConfig.AddOnConformance
+	if !fileutil.Exist(cur.HydrophonePath) {
+		return errors.New("hydrophone not found; skipping cleanup")
+	}
+	args := []string{
+		cur.HydrophonePath,
+		"--cleanup",
+		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+		"--namespace=" + cur.Namespace,
+	}
+	cmd := strings.Join(args, " ")
+	ts.cfg.Logger.Info("deleting hydrophone", zap.String("command", cmd))
+
+	ctx, cancel := context.WithTimeout(context.Background(), cur.SonobuoyDeleteTimeout)
+	output, err := exec.New().CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	cancel()
+	out := strings.TrimSpace(string(output))
+	fmt.Fprintf(ts.cfg.LogWriter, "\n'%s' output:\n\n%s\n\n", cmd, out)
+	if err != nil {
+		return fmt.Errorf("'%s' failed (%v)", cmd, err)
+	}
+	ts.cfg.Logger.Info("deleted hydrophone", zap.String("command", cmd))
+	return nil
+}
+
+// checkJUnitResults fails on any failed test in the JUnit results.
+func (ts *tester) checkJUnitResults() error {
+	cur := ts.cfg.EKSConfig.AddOnConformance
+	tests, failed, err := readJUnitResults(cur.SonobuoyResultJunitXMLPath)
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.ResultTests, cur.ResultFailures = tests, len(failed)
+	})
+
+	ts.cfg.Logger.Info("read JUnit results", zap.Int("tests", tests), zap.Int("failures", len(failed)))
+	if len(failed) == 0 {
+		return nil
+	}
+	fmt.Fprintf(ts.cfg.LogWriter, "\nFailed tests:\n%s\n\n", strings.Join(failed, "\n"))
+	return fmt.Errorf("%d of %d conformance tests failed", len(failed), tests)
+}
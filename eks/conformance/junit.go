@@ -0,0 +1,53 @@
+package conformance
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+)
+
+// e2e JUnit results, with a single "testsuite" root element,
+// or "testsuites" root element since ginkgo v2.
+type junitTestSuites struct {
+	XMLName xml.Name
+	Suites  []junitTestSuite `xml:"testsuite"`
+	// only set for a single "testsuite" root element
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestSuite struct {
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name    string    `xml:"name,attr"`
+	Skipped *struct{} `xml:"skipped"`
+	Failure *struct{} `xml:"failure"`
+}
+
+// readJUnitResults returns the number of run tests (excluding the skipped),
+// and the names of the failed tests.
+func readJUnitResults(xmlPath string) (tests int, failed []string, err error) {
+	b, err := ioutil.ReadFile(xmlPath)
+	if err != nil {
+		return 0, nil, err
+	}
+	var rs junitTestSuites
+	if err = xml.Unmarshal(b, &rs); err != nil {
+		return 0, nil, fmt.Errorf("failed to parse JUnit results %q (%v)", xmlPath, err)
+	}
+	tcs := rs.TestCases
+	for _, s := range rs.Suites {
+		tcs = append(tcs, s.TestCases...)
+	}
+	for _, tc := range tcs {
+		if tc.Skipped != nil {
+			continue
+		}
+		tests++
+		if tc.Failure != nil {
+			failed = append(failed, tc.Name)
+		}
+	}
+	return tests, failed, nil
+}
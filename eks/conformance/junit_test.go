@@ -0,0 +1,58 @@
+package conformance
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadJUnitResults(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "junit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tt := []struct {
+		xml    string
+		tests  int
+		failed []string
+	}{
+		{
+			xml: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="Kubernetes e2e suite" tests="3" failures="1">
+  <testcase name="a [Conformance]"></testcase>
+  <testcase name="b [Conformance]"><failure type="Failure">timeout</failure></testcase>
+  <testcase name="c"><skipped></skipped></testcase>
+</testsuite>`,
+			tests:  2,
+			failed: []string{"b [Conformance]"},
+		},
+		{
+			xml: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="3" failures="0">
+  <testsuite name="Kubernetes e2e suite" tests="3">
+    <testcase name="a [Conformance]"></testcase>
+    <testcase name="b [Conformance]"></testcase>
+    <testcase name="c"><skipped message="skipped"></skipped></testcase>
+  </testsuite>
+</testsuites>`,
+			tests: 2,
+		},
+	}
+	for i, tv := range tt {
+		p := filepath.Join(dir, "junit_01.xml")
+		if err = ioutil.WriteFile(p, []byte(tv.xml), 0600); err != nil {
+			t.Fatal(err)
+		}
+		tests, failed, err := readJUnitResults(p)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if tests != tv.tests || !reflect.DeepEqual(failed, tv.failed) {
+			t.Fatalf("#%d: unexpected results %d %v", i, tests, failed)
+		}
+	}
+}
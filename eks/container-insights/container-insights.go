@@ -0,0 +1,187 @@
+// Package containerinsights installs CloudWatch Container Insights with
+// the CloudWatch agent and Fluent Bit, generates log traffic, and verifies
+// the logs and metrics are ingested into CloudWatch.
+// ref. https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/Container-Insights-setup-EKS-quickstart.html
+package containerinsights
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+	"go.uber.org/zap"
+)
+
+// Config defines Container Insights configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+	CWAPI     cloudwatchiface.CloudWatchAPI
+	CWLogsAPI cloudwatchlogsiface.CloudWatchLogsAPI
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new Container Insights tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "container-insights",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				CWAPI:     cfg.CWAPI,
+				CWLogsAPI: cfg.CWLogsAPI,
+			}), cfg.EKSConfig.IsEnabledAddOnContainerInsights)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnContainerInsights() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnContainerInsights.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnContainerInsights.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnContainerInsights.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	if err = ts.installAgents(); err != nil {
+		return err
+	}
+
+	genStart := time.Now()
+	marker, err := ts.generateLogs()
+	if err != nil {
+		return err
+	}
+	if err = ts.verifyLogs(marker, genStart); err != nil {
+		return err
+	}
+	if err = ts.verifyMetrics(); err != nil {
+		return err
+	}
+
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnContainerInsights() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnContainerInsights.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnContainerInsights.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+	if err := ts.deleteAgents(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if ts.cfg.EKSConfig.AddOnContainerInsights.DeleteLogGroups {
+		if err := ts.deleteLogGroups(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnContainerInsights.Created = false
+	})
+	return nil
+}
+
+func (ts *tester) manifest() (string, error) {
+	cur := ts.cfg.EKSConfig.AddOnContainerInsights
+	return render(manifest, manifestData{
+		Namespace:      cur.Namespace,
+		Region:         ts.cfg.EKSConfig.Region,
+		ClusterName:    ts.cfg.EKSConfig.Name,
+		CWAgentImage:   cur.CWAgentImage,
+		FluentBitImage: cur.FluentBitImage,
+	})
+}
+
+func (ts *tester) installAgents() error {
+	ts.cfg.Logger.Info("installing CloudWatch agent and Fluent Bit")
+	data, err := ts.manifest()
+	if err != nil {
+		return err
+	}
+	if err = ts.cfg.K8SClient.Apply(data); err != nil {
+		return fmt.Errorf("failed to apply Container Insights manifest (%v)", err)
+	}
+	ns := ts.cfg.EKSConfig.AddOnContainerInsights.Namespace
+	for _, name := range []string{"cloudwatch-agent", "fluent-bit"} {
+		if err = ts.waitDaemonSet(ns, name); err != nil {
+			return err
+		}
+	}
+	ts.cfg.Logger.Info("installed CloudWatch agent and Fluent Bit")
+	return nil
+}
+
+func (ts *tester) deleteAgents() error {
+	ts.cfg.Logger.Info("deleting CloudWatch agent and Fluent Bit")
+	data, err := ts.manifest()
+	if err != nil {
+		return err
+	}
+	if err = ts.cfg.K8SClient.Delete(data); err != nil && !strings.Contains(err.Error(), "NotFound") {
+		return fmt.Errorf("failed to delete Container Insights manifest (%v)", err)
+	}
+	ts.cfg.Logger.Info("deleted CloudWatch agent and Fluent Bit")
+	return nil
+}
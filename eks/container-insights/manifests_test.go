@@ -0,0 +1,50 @@
+package containerinsights
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderManifest(t *testing.T) {
+	s, err := render(manifest, manifestData{
+		Namespace:      "amazon-cloudwatch",
+		Region:         "us-west-2",
+		ClusterName:    "test-cluster",
+		CWAgentImage:   "cw-agent:1",
+		FluentBitImage: "fluent-bit:2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"  namespace: amazon-cloudwatch\n",
+		`"region": "us-west-2"`,
+		`"cluster_name": "test-cluster"`,
+		"image: cw-agent:1\n",
+		"image: fluent-bit:2\n",
+		"value: test-cluster\n",
+	} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("expected %q in manifest %s", want, s)
+		}
+	}
+	if strings.Contains(s, "{{") {
+		t.Fatalf("unrendered manifest %s", s)
+	}
+}
+
+func TestRenderLogGeneratorJob(t *testing.T) {
+	s, err := render(logGeneratorJob, logGeneratorData{
+		JobName:   logGeneratorJobName,
+		Namespace: "ns",
+		Image:     "busybox:1.36",
+		Lines:     5,
+		Marker:    "marker",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(s, `for i in $(seq 1 5); do echo "marker $i"; sleep 0.1; done`) {
+		t.Fatalf("unexpected Job %s", s)
+	}
+}
@@ -0,0 +1,208 @@
+package containerinsights
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/randutil"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	logGeneratorJobName = "container-insights-log-generator"
+	metricsNamespace    = "ContainerInsights"
+)
+
+// generateLogs runs the log generator Job to completion,
+// and returns the marker of the log lines.
+func (ts *tester) generateLogs() (string, error) {
+	cur := ts.cfg.EKSConfig.AddOnContainerInsights
+	marker := "aws-k8s-tester-" + randutil.String(10)
+	data, err := render(logGeneratorJob, logGeneratorData{
+		JobName:   logGeneratorJobName,
+		Namespace: cur.Namespace,
+		Image:     cur.LogGeneratorImage,
+		Lines:     cur.LogGeneratorLines,
+		Marker:    marker,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	ts.cfg.Logger.Info("creating log generator Job", zap.String("marker", marker), zap.Int("lines", cur.LogGeneratorLines))
+	if err = ts.cfg.K8SClient.Apply(data); err != nil {
+		return "", fmt.Errorf("failed to create log generator Job (%v)", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	_, _, err = k8s_client.WaitForJobCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.K8SClient,
+		10*time.Second,
+		5*time.Second,
+		cur.Namespace,
+		logGeneratorJobName,
+		1,
+	)
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("log generator Job failed (%v)", err)
+	}
+	ts.cfg.Logger.Info("completed log generator Job", zap.String("marker", marker))
+	return marker, nil
+}
+
+// verifyLogs waits until all generated log lines are queryable
+// in the application log group.
+func (ts *tester) verifyLogs(marker string, since time.Time) error {
+	cur := ts.cfg.EKSConfig.AddOnContainerInsights
+	logGroupName := cur.LogGroupNames[0]
+	ts.cfg.Logger.Info("verifying logs", zap.String("log-group-name", logGroupName), zap.String("marker", marker))
+
+	input := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName:  aws.String(logGroupName),
+		FilterPattern: aws.String(fmt.Sprintf("%q", marker)),
+		StartTime:     aws.Int64(since.Add(-time.Minute).UnixNano() / int64(time.Millisecond)),
+	}
+	waitStart := time.Now()
+	for time.Since(waitStart) < cur.VerifyTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("log verification aborted")
+		case <-time.After(15 * time.Second):
+		}
+
+		found := 0
+		err := ts.cfg.CWLogsAPI.FilterLogEventsPages(input, func(out *cloudwatchlogs.FilterLogEventsOutput, _ bool) bool {
+			found += len(out.Events)
+			return true
+		})
+		if err != nil {
+			// the log group is created on the first flush
+			ts.cfg.Logger.Warn("failed to filter log events", zap.String("log-group-name", logGroupName), zap.Error(err))
+			continue
+		}
+		ts.cfg.Logger.Info("polled log events", zap.Int("found", found), zap.Int("expected", cur.LogGeneratorLines))
+		if found >= cur.LogGeneratorLines {
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				cur.LogEventsFound = found
+			})
+			ts.cfg.Logger.Info("verified logs", zap.String("log-group-name", logGroupName), zap.Int("found", found))
+			return nil
+		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.LogEventsFound = found
+		})
+	}
+	ts.cfg.EKSConfig.Sync()
+	return fmt.Errorf("found %d of %d log lines in %q", cur.LogEventsFound, cur.LogGeneratorLines, logGroupName)
+}
+
+// verifyMetrics waits until the CloudWatch agent publishes
+// cluster metrics.
+func (ts *tester) verifyMetrics() error {
+	cur := ts.cfg.EKSConfig.AddOnContainerInsights
+	ts.cfg.Logger.Info("verifying metrics", zap.String("namespace", metricsNamespace))
+
+	input := &cloudwatch.ListMetricsInput{
+		Namespace: aws.String(metricsNamespace),
+		Dimensions: []*cloudwatch.DimensionFilter{
+			{
+				Name:  aws.String("ClusterName"),
+				Value: aws.String(ts.cfg.EKSConfig.Name),
+			},
+		},
+	}
+	waitStart := time.Now()
+	for time.Since(waitStart) < cur.VerifyTimeout {
+		found := 0
+		err := ts.cfg.CWAPI.ListMetricsPages(input, func(out *cloudwatch.ListMetricsOutput, _ bool) bool {
+			found += len(out.Metrics)
+			return true
+		})
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list metrics", zap.Error(err))
+		} else {
+			ts.cfg.Logger.Info("polled metrics", zap.Int("found", found))
+			if found > 0 {
+				ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+					cur.MetricsFound = found
+				})
+				ts.cfg.Logger.Info("verified metrics", zap.Int("found", found))
+				return nil
+			}
+		}
+
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("metrics verification aborted")
+		case <-time.After(30 * time.Second):
+		}
+	}
+	return fmt.Errorf("no %q metrics found for cluster %q", metricsNamespace, ts.cfg.EKSConfig.Name)
+}
+
+func (ts *tester) deleteLogGroups() error {
+	var errs []string
+	for _, name := range ts.cfg.EKSConfig.AddOnContainerInsights.LogGroupNames {
+		ts.cfg.Logger.Info("deleting log group", zap.String("log-group-name", name))
+		_, err := ts.cfg.CWLogsAPI.DeleteLogGroup(&cloudwatchlogs.DeleteLogGroupInput{
+			LogGroupName: aws.String(name),
+		})
+		if err != nil {
+			if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == cloudwatchlogs.ErrCodeResourceNotFoundException {
+				ts.cfg.Logger.Info("log group already deleted", zap.String("log-group-name", name))
+				continue
+			}
+			errs = append(errs, fmt.Sprintf("failed to delete log group %q (%v)", name, err))
+			continue
+		}
+		ts.cfg.Logger.Info("deleted log group", zap.String("log-group-name", name))
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// waitDaemonSet waits for the DaemonSet rollout.
+func (ts *tester) waitDaemonSet(namespace string, name string) error {
+	dsCli := ts.cfg.K8SClient.KubernetesClientSet().AppsV1().DaemonSets(namespace)
+	waitStart := time.Now()
+	for time.Since(waitStart) < 10*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return fmt.Errorf("%q DaemonSet rollout aborted", name)
+		case <-time.After(10 * time.Second):
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		ds, err := dsCli.Get(ctx, name, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get DaemonSet", zap.String("namespace", namespace), zap.String("name", name), zap.Error(err))
+			continue
+		}
+		st := ds.Status
+		ts.cfg.Logger.Info("polled DaemonSet",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Int32("desired", st.DesiredNumberScheduled),
+			zap.Int32("available", st.NumberAvailable),
+		)
+		if st.DesiredNumberScheduled > 0 && st.NumberAvailable == st.DesiredNumberScheduled {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q DaemonSet not rolled out", name)
+}
@@ -0,0 +1,222 @@
+package corednsscale
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-sdk-go/aws"
+	"go.uber.org/zap"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	corednsNamespace  = "kube-system"
+	corednsDeployment = "coredns"
+	hpaName           = "coredns-scale"
+)
+
+func (ts *tester) getReplicas() (int32, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	dp, err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(corednsNamespace).
+		Get(ctx, corednsDeployment, metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get CoreDNS Deployment (%v)", err)
+	}
+	return dp.Status.Replicas, nil
+}
+
+// startAutoscale records the original CoreDNS replicas, and creates
+// the HPA for the CoreDNS Deployment. It fails if another HPA already
+// targets CoreDNS, since two HPAs on the same Deployment fight each other.
+func (ts *tester) startAutoscale() (err error) {
+	cur := ts.cfg.EKSConfig.AddOnCoreDNSScale
+	if cur.OriginalReplicas == 0 {
+		replicas, err := ts.getReplicas()
+		if err != nil {
+			return err
+		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.OriginalReplicas = replicas
+		})
+	}
+	if cur.AutoscaleMaxReplicas <= cur.OriginalReplicas {
+		return fmt.Errorf("AddOnCoreDNSScale.AutoscaleMaxReplicas %d must be greater than the CoreDNS replicas %d", cur.AutoscaleMaxReplicas, cur.OriginalReplicas)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	hpas, err := ts.cfg.K8SClient.KubernetesClientSet().
+		AutoscalingV2().
+		HorizontalPodAutoscalers(corednsNamespace).
+		List(ctx, metav1.ListOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to list HPAs (%v)", err)
+	}
+	for _, hpa := range hpas.Items {
+		if hpa.Name != hpaName && hpa.Spec.ScaleTargetRef.Kind == "Deployment" && hpa.Spec.ScaleTargetRef.Name == corednsDeployment {
+			return fmt.Errorf("HPA %q already targets CoreDNS", hpa.Name)
+		}
+	}
+
+	ts.cfg.Logger.Info("creating CoreDNS HPA",
+		zap.Int32("original-replicas", cur.OriginalReplicas),
+		zap.Int32("max-replicas", cur.AutoscaleMaxReplicas),
+	)
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.K8SClient.KubernetesClientSet().
+		AutoscalingV2().
+		HorizontalPodAutoscalers(corednsNamespace).
+		Create(ctx, newHPA(cur), metav1.CreateOptions{})
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create CoreDNS HPA (%v)", err)
+	}
+	return ts.waitScalingActive()
+}
+
+// newHPA returns the HPA for the CoreDNS Deployment, from the original
+// replicas so that the HPA only scales up under the load.
+func newHPA(cur *eksconfig.AddOnCoreDNSScale) *autoscalingv2.HorizontalPodAutoscaler {
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      hpaName,
+			Namespace: corednsNamespace,
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       corednsDeployment,
+			},
+			MinReplicas: aws.Int32(cur.OriginalReplicas),
+			MaxReplicas: cur.AutoscaleMaxReplicas,
+			Metrics: []autoscalingv2.MetricSpec{
+				{
+					Type: autoscalingv2.ResourceMetricSourceType,
+					Resource: &autoscalingv2.ResourceMetricSource{
+						Name: v1.ResourceCPU,
+						Target: autoscalingv2.MetricTarget{
+							Type:               autoscalingv2.UtilizationMetricType,
+							AverageUtilization: aws.Int32(cur.AutoscaleTargetCPUUtilizationPercentage),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// waitScalingActive waits until the HPA can compute the CoreDNS CPU
+// utilization from metrics-server.
+func (ts *tester) waitScalingActive() error {
+	ts.cfg.Logger.Info("waiting for CoreDNS HPA scaling active")
+	waitStart := time.Now()
+	for time.Since(waitStart) < 5*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("CoreDNS HPA scaling active wait aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		hpa, err := ts.cfg.K8SClient.KubernetesClientSet().
+			AutoscalingV2().
+			HorizontalPodAutoscalers(corednsNamespace).
+			Get(ctx, hpaName, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get CoreDNS HPA", zap.Error(err))
+			continue
+		}
+		for _, cond := range hpa.Status.Conditions {
+			if cond.Type == autoscalingv2.ScalingActive && cond.Status == v1.ConditionTrue {
+				ts.cfg.Logger.Info("CoreDNS HPA scaling active", zap.Duration("took", time.Since(waitStart)))
+				return nil
+			}
+		}
+		ts.cfg.Logger.Info("CoreDNS HPA scaling not active yet", zap.Int("conditions", len(hpa.Status.Conditions)))
+	}
+	return errors.New("CoreDNS HPA scaling not active; is metrics-server running and does CoreDNS have CPU requests?")
+}
+
+// watchReplicas polls the CoreDNS replicas until "donec" is closed,
+// and records the maximum replicas and the first scale up since
+// "loadStart". The config is synced by the caller after "donec".
+func (ts *tester) watchReplicas(loadStart time.Time, donec chan struct{}) {
+	cur := ts.cfg.EKSConfig.AddOnCoreDNSScale
+	for {
+		select {
+		case <-donec:
+			return
+		case <-time.After(5 * time.Second):
+		}
+
+		replicas, err := ts.getReplicas()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get CoreDNS replicas", zap.Error(err))
+			continue
+		}
+		if replicas > cur.MaxObservedReplicas {
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				cur.MaxObservedReplicas = replicas
+			})
+			ts.cfg.Logger.Info("CoreDNS replicas increased", zap.Int32("replicas", replicas))
+		}
+		if replicas > cur.OriginalReplicas && cur.ScaleUpLatency == time.Duration(0) && time.Now().After(loadStart) {
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				cur.ScaleUpLatency = time.Since(loadStart)
+				cur.ScaleUpLatencyString = cur.ScaleUpLatency.String()
+			})
+			ts.cfg.Logger.Info("CoreDNS scaled up", zap.Int32("replicas", replicas), zap.Duration("took", cur.ScaleUpLatency))
+		}
+	}
+}
+
+// stopAutoscale deletes the CoreDNS HPA, and restores the original
+// replicas. No-op if the HPA is not found and the replicas are restored.
+func (ts *tester) stopAutoscale() error {
+	cur := ts.cfg.EKSConfig.AddOnCoreDNSScale
+	if cur.OriginalReplicas == 0 {
+		return nil
+	}
+
+	ts.cfg.Logger.Info("deleting CoreDNS HPA")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.K8SClient.KubernetesClientSet().
+		AutoscalingV2().
+		HorizontalPodAutoscalers(corednsNamespace).
+		Delete(ctx, hpaName, metav1.DeleteOptions{})
+	cancel()
+	if err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to delete CoreDNS HPA (%v)", err)
+	}
+
+	dpCli := ts.cfg.K8SClient.KubernetesClientSet().AppsV1().Deployments(corednsNamespace)
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	scale, err := dpCli.GetScale(ctx, corednsDeployment, metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get CoreDNS scale (%v)", err)
+	}
+	if scale.Spec.Replicas == cur.OriginalReplicas {
+		return nil
+	}
+	ts.cfg.Logger.Info("restoring CoreDNS replicas", zap.Int32("from", scale.Spec.Replicas), zap.Int32("to", cur.OriginalReplicas))
+	scale.Spec.Replicas = cur.OriginalReplicas
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = dpCli.UpdateScale(ctx, corednsDeployment, scale, metav1.UpdateOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to restore CoreDNS replicas (%v)", err)
+	}
+	return nil
+}
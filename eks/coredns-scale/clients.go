@@ -0,0 +1,235 @@
+package corednsscale
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/pkg/metrics"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	clientName = "coredns-client"
+	// clientStartDelay is the delay before the client Pods start the load
+	// at the same time, to absorb the scheduling and the image pulls.
+	clientStartDelay = time.Minute
+	// queryTimeoutSeconds is the timeout of each DNS query.
+	queryTimeoutSeconds = 2
+)
+
+// createClients creates the client Pods, which start the load at
+// "loadStart" and stop after "Duration".
+func (ts *tester) createClients(loadStart time.Time) error {
+	cur := ts.cfg.EKSConfig.AddOnCoreDNSScale
+	script := clientScript(cur, loadStart)
+	labels := map[string]string{"app.kubernetes.io/name": clientName}
+
+	ts.cfg.Logger.Info("creating DNS client Pods",
+		zap.Int("clients", cur.Clients),
+		zap.Int("qps-per-client", cur.QPSPerClient),
+		zap.Time("load-start", loadStart),
+	)
+	for i := 0; i < cur.Clients; i++ {
+		name := fmt.Sprintf("%s-%02d", clientName, i)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		_, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Pods(cur.Namespace).
+			Create(
+				ctx,
+				&v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      name,
+						Namespace: cur.Namespace,
+						Labels:    labels,
+					},
+					Spec: v1.PodSpec{
+						RestartPolicy: v1.RestartPolicyNever,
+						Containers: []v1.Container{
+							{
+								Name:            clientName,
+								Image:           ts.cfg.EKSConfig.Image(eksconfig.DefaultBusyboxImage),
+								ImagePullPolicy: v1.PullIfNotPresent,
+								Command:         []string{"/bin/sh", "-c", script},
+								Resources: v1.ResourceRequirements{
+									Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("200m")},
+								},
+							},
+						},
+					},
+				},
+				metav1.CreateOptions{},
+			)
+		cancel()
+		if err != nil && !apierrs.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create DNS client Pod %q (%v)", name, err)
+		}
+	}
+	ts.cfg.Logger.Info("created DNS client Pods")
+	return nil
+}
+
+// clientScript returns the client shell script, which sends "QPSPerClient"
+// queries every second in the background regardless of the pending
+// queries, and prints one "dns <ok|fail> <latency-microseconds>" line
+// per query.
+func clientScript(cur *eksconfig.AddOnCoreDNSScale, loadStart time.Time) string {
+	names := make([]string, cur.QPSPerClient)
+	for i := range names {
+		names[i] = fqdn(cur.QueryNames[i%len(cur.QueryNames)])
+	}
+	return fmt.Sprintf(`start=%d; end=%d
+while [ "$(date +%%s)" -lt "$start" ]; do sleep 1; done
+while [ "$(date +%%s)" -lt "$end" ]; do
+  for n in %s; do
+    (s=$(date +%%s%%N); if nslookup -type=a -timeout=%d "$n" >/dev/null 2>&1; then r=ok; else r=fail; fi; echo "dns $r $(( ($(date +%%s%%N) - s) / 1000 ))") &
+  done
+  sleep 1
+done
+wait
+`,
+		loadStart.Unix(),
+		loadStart.Add(cur.Duration).Unix(),
+		strings.Join(names, " "),
+		queryTimeoutSeconds,
+	)
+}
+
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// waitClients waits for all client Pods to complete.
+func (ts *tester) waitClients(timeout time.Duration) error {
+	cur := ts.cfg.EKSConfig.AddOnCoreDNSScale
+	ts.cfg.Logger.Info("waiting for DNS client Pods", zap.Duration("timeout", timeout))
+	waitStart := time.Now()
+	for time.Since(waitStart) < timeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("DNS client Pods wait aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		pods, err := ts.listClients()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list DNS client Pods", zap.Error(err))
+			continue
+		}
+		succeeded := 0
+		for _, pod := range pods {
+			switch pod.Status.Phase {
+			case v1.PodSucceeded:
+				succeeded++
+			case v1.PodFailed:
+				return fmt.Errorf("DNS client Pod %q failed (%s)", pod.Name, pod.Status.Message)
+			}
+		}
+		ts.cfg.Logger.Info("polled DNS client Pods", zap.Int("succeeded", succeeded), zap.Int("clients", cur.Clients))
+		if succeeded == cur.Clients {
+			return nil
+		}
+	}
+	return fmt.Errorf("DNS client Pods did not complete in %v", timeout)
+}
+
+func (ts *tester) listClients() ([]v1.Pod, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	pods, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(ts.cfg.EKSConfig.AddOnCoreDNSScale.Namespace).
+		List(ctx, metav1.ListOptions{LabelSelector: "app.kubernetes.io/name=" + clientName})
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+// collect reads the client Pod logs, and returns the latencies of
+// the successful queries and the number of the failed queries.
+func (ts *tester) collect() (ds metrics.Durations, failures int, err error) {
+	pods, err := ts.listClients()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list DNS client Pods (%v)", err)
+	}
+	podCli := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Pods(ts.cfg.EKSConfig.AddOnCoreDNSScale.Namespace)
+	for _, pod := range pods {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		b, err := podCli.GetLogs(pod.Name, &v1.PodLogOptions{}).DoRaw(ctx)
+		cancel()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get DNS client Pod %q logs (%v)", pod.Name, err)
+		}
+		pds, pf := parseResults(b)
+		ts.cfg.Logger.Info("collected DNS client results",
+			zap.String("pod", pod.Name),
+			zap.Int("successes", len(pds)),
+			zap.Int("failures", pf),
+		)
+		ds = append(ds, pds...)
+		failures += pf
+	}
+	return ds, failures, nil
+}
+
+// parseResults parses the "dns <ok|fail> <latency-microseconds>" lines.
+func parseResults(b []byte) (ds metrics.Durations, failures int) {
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || fields[0] != "dns" {
+			continue
+		}
+		switch fields[1] {
+		case "ok":
+			us, err := strconv.ParseInt(fields[2], 10, 64)
+			if err != nil || us < 0 {
+				continue
+			}
+			ds = append(ds, time.Duration(us)*time.Microsecond)
+		case "fail":
+			failures++
+		}
+	}
+	return ds, failures
+}
+
+// summarize returns the latency summary of the successful queries.
+func summarize(testID string, ds metrics.Durations, failures int) metrics.RequestsSummary {
+	sort.Sort(ds)
+	return metrics.RequestsSummary{
+		TestID:        testID,
+		SuccessTotal:  float64(len(ds)),
+		FailureTotal:  float64(failures),
+		LantencyP50:   ds.PickLantencyP50(),
+		LantencyP90:   ds.PickLantencyP90(),
+		LantencyP99:   ds.PickLantencyP99(),
+		LantencyP999:  ds.PickLantencyP999(),
+		LantencyP9999: ds.PickLantencyP9999(),
+	}
+}
+
+// errorRate returns the ratio of the failed queries.
+func errorRate(rs metrics.RequestsSummary) float64 {
+	total := rs.SuccessTotal + rs.FailureTotal
+	if total == 0 {
+		return 1
+	}
+	return rs.FailureTotal / total
+}
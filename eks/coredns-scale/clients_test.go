@@ -0,0 +1,61 @@
+package corednsscale
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+)
+
+func TestClientScript(t *testing.T) {
+	cur := &eksconfig.AddOnCoreDNSScale{
+		QPSPerClient: 3,
+		Duration:     time.Minute,
+		QueryNames:   []string{"kubernetes.default.svc.cluster.local", "amazon.com."},
+	}
+	loadStart := time.Unix(1700000000, 0)
+	script := clientScript(cur, loadStart)
+	if !strings.HasPrefix(script, "start=1700000000; end=1700000060\n") {
+		t.Fatalf("unexpected load window %q", script)
+	}
+	if !strings.Contains(script, "for n in kubernetes.default.svc.cluster.local. amazon.com. kubernetes.default.svc.cluster.local.; do") {
+		t.Fatalf("unexpected query names %q", script)
+	}
+	if !strings.Contains(script, `nslookup -type=a -timeout=2 "$n"`) || !strings.Contains(script, "date +%s%N") {
+		t.Fatalf("unexpected query %q", script)
+	}
+}
+
+func TestParseResults(t *testing.T) {
+	logs := `dns ok 1500
+dns ok 800
+dns fail 2000123
+;; connection timed out
+dns ok -1
+dns ok 3000
+`
+	ds, failures := parseResults([]byte(logs))
+	if len(ds) != 3 || failures != 1 {
+		t.Fatalf("unexpected results %v, %d", ds, failures)
+	}
+	if ds[0] != 1500*time.Microsecond || ds[1] != 800*time.Microsecond {
+		t.Fatalf("unexpected latencies %v", ds)
+	}
+
+	rs := summarize("test", ds, failures)
+	if rs.SuccessTotal != 3 || rs.FailureTotal != 1 {
+		t.Fatalf("unexpected totals %v, %v", rs.SuccessTotal, rs.FailureTotal)
+	}
+	if rs.LantencyP50 != 1500*time.Microsecond {
+		t.Fatalf("unexpected p50 %v", rs.LantencyP50)
+	}
+	if v := errorRate(rs); v != 0.25 {
+		t.Fatalf("unexpected error rate %v", v)
+	}
+
+	rs = summarize("empty", nil, 0)
+	if v := errorRate(rs); v != 1 {
+		t.Fatalf("unexpected error rate %v for no queries", v)
+	}
+}
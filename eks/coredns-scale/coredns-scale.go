@@ -0,0 +1,280 @@
+// Package corednsscale generates DNS queries at a fixed rate from
+// the client Pods, records the CoreDNS resolution latencies and the error
+// rate, and optionally asserts CoreDNS scales up under the load.
+// The latency summary is compared with the one from the previous run.
+// ref. https://github.com/coredns/deployment/blob/master/kubernetes/Scaling_CoreDNS.md
+package corednsscale
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/metrics"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"go.uber.org/zap"
+)
+
+// Config defines CoreDNS scale tester configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+	S3API     s3iface.S3API
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new CoreDNS scale tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:         "coredns-scale",
+		Dependencies: []string{"metrics-server"},
+		Exclusive:    true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				S3API:     cfg.S3API,
+			}), cfg.EKSConfig.IsEnabledAddOnCoreDNSScale)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnCoreDNSScale() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnCoreDNSScale.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnCoreDNSScale.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnCoreDNSScale.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	cur := ts.cfg.EKSConfig.AddOnCoreDNSScale
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		cur.Namespace,
+	); err != nil {
+		return err
+	}
+
+	autoscale := cur.AutoscaleMaxReplicas > 0
+	if autoscale {
+		if err = ts.startAutoscale(); err != nil {
+			return err
+		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.MaxObservedReplicas = cur.OriginalReplicas
+			cur.ScaleUpLatency, cur.ScaleUpLatencyString = 0, ""
+		})
+	}
+
+	loadStart := time.Now().Add(clientStartDelay)
+	if err = ts.createClients(loadStart); err != nil {
+		return err
+	}
+	donec := make(chan struct{})
+	watchc := make(chan struct{})
+	if autoscale {
+		go func() {
+			ts.watchReplicas(loadStart, donec)
+			close(watchc)
+		}()
+	} else {
+		close(watchc)
+	}
+	fmt.Fprintf(ts.cfg.LogWriter, "\nsending %d DNS queries per second from %d clients for %s\n", cur.Clients*cur.QPSPerClient, cur.Clients, cur.DurationString)
+	err = ts.waitClients(clientStartDelay + cur.Duration + 5*time.Minute)
+	close(donec)
+	<-watchc
+	ts.cfg.EKSConfig.Sync()
+	if err != nil {
+		return err
+	}
+
+	ds, failures, err := ts.collect()
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.RequestsSummary = summarize(time.Now().UTC().Format(time.RFC3339Nano), ds, failures)
+		cur.ErrorRate = errorRate(cur.RequestsSummary)
+	})
+	fmt.Fprintf(ts.cfg.LogWriter, "\n\nRequestsSummary (error rate %.3f %%):\n%s\n", cur.ErrorRate*100, cur.RequestsSummary.Table())
+
+	if err = ioutil.WriteFile(cur.RequestsSummaryJSONPath, []byte(cur.RequestsSummary.JSON()), 0600); err != nil {
+		return err
+	}
+	if err = aws_s3.Upload(
+		ts.cfg.Logger,
+		ts.cfg.S3API,
+		ts.cfg.EKSConfig.S3.BucketName,
+		cur.RequestsSummaryJSONS3Key,
+		cur.RequestsSummaryJSONPath,
+	); err != nil {
+		return err
+	}
+	if err = ts.checkResults(); err != nil {
+		return err
+	}
+
+	if autoscale {
+		if err = ts.stopAutoscale(); err != nil {
+			return err
+		}
+		fmt.Fprintf(ts.cfg.LogWriter, "\nCoreDNS replicas %d -> %d (scale up latency %q)\n", cur.OriginalReplicas, cur.MaxObservedReplicas, cur.ScaleUpLatencyString)
+		if cur.MaxObservedReplicas <= cur.OriginalReplicas {
+			return fmt.Errorf("CoreDNS did not scale up from %d replicas with %d queries per second", cur.OriginalReplicas, cur.Clients*cur.QPSPerClient)
+		}
+	}
+	if cur.ErrorRate > cur.MaxErrorRate {
+		return fmt.Errorf("DNS query error rate %.3f %% > %.3f %%", cur.ErrorRate*100, cur.MaxErrorRate*100)
+	}
+	return nil
+}
+
+// 1. if previous summary exists, download and compare
+// 2. upload new summary as the latest
+func (ts *tester) checkResults() (err error) {
+	cur := ts.cfg.EKSConfig.AddOnCoreDNSScale
+	curTS := time.Now().UTC().Format(time.RFC3339Nano)
+	ts.cfg.Logger.Info("checking results", zap.String("timestamp", curTS))
+
+	s3Objects := make([]*s3.Object, 0)
+	if cur.RequestsSummaryCompareS3Dir != "" {
+		s3Objects, err = aws_s3.ListInDescendingLastModified(
+			ts.cfg.Logger,
+			ts.cfg.S3API,
+			ts.cfg.EKSConfig.S3.BucketName,
+			path.Clean(cur.RequestsSummaryCompareS3Dir)+"/",
+		)
+	}
+	if len(s3Objects) > 0 && err == nil {
+		var prevSummary metrics.RequestsSummary
+		prevSummary, err = metrics.DownloadRequestsSummaryFromS3(ts.cfg.Logger, ts.cfg.S3API, ts.cfg.EKSConfig.S3.BucketName, aws.StringValue(s3Objects[0].Key))
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to download results", zap.Error(err))
+			return err
+		}
+		var compare metrics.RequestsCompare
+		compare, err = metrics.CompareRequestsSummary(prevSummary, cur.RequestsSummary)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to compare results", zap.Error(err))
+			return err
+		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.RequestsSummaryCompare = compare
+		})
+		if err = ioutil.WriteFile(cur.RequestsSummaryCompareJSONPath, []byte(cur.RequestsSummaryCompare.JSON()), 0600); err != nil {
+			ts.cfg.Logger.Warn("failed to write file", zap.Error(err))
+			return err
+		}
+		if err = aws_s3.Upload(
+			ts.cfg.Logger,
+			ts.cfg.S3API,
+			ts.cfg.EKSConfig.S3.BucketName,
+			cur.RequestsSummaryCompareJSONS3Key,
+			cur.RequestsSummaryCompareJSONPath,
+		); err != nil {
+			return err
+		}
+		fmt.Fprintf(ts.cfg.LogWriter, "\n\nRequestsSummaryCompare:\n%s\n", cur.RequestsSummaryCompare.Table())
+	} else {
+		ts.cfg.Logger.Warn("previous summary not found; skipping comparison", zap.Error(err))
+	}
+
+	ts.cfg.Logger.Info("uploading new summary to s3 bucket as the latest")
+	return aws_s3.Upload(
+		ts.cfg.Logger,
+		ts.cfg.S3API,
+		ts.cfg.EKSConfig.S3.BucketName,
+		path.Join(cur.RequestsSummaryCompareS3Dir, curTS),
+		cur.RequestsSummaryJSONPath,
+	)
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnCoreDNSScale() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnCoreDNSScale.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnCoreDNSScale.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnCoreDNSScale.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete CoreDNS scale namespace (%v)", err))
+	}
+	// no-op if stopped on "Create"
+	if err := ts.stopAutoscale(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnCoreDNSScale.Created = false
+	})
+	return nil
+}
@@ -0,0 +1,269 @@
+// Package cost estimates the cost of the AWS resources created by the tester,
+// using the on-demand prices from the AWS Price List API.
+package cost
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go/service/pricing/pricingiface"
+	"github.com/olekukonko/tablewriter"
+	"go.uber.org/zap"
+)
+
+// PricingRegion is the region of the AWS Price List API endpoint.
+const PricingRegion = "us-east-1"
+
+// Built-in prices in USD, used when the price lookup fails
+// (e.g. missing "pricing:GetProducts" permission).
+// ref. https://aws.amazon.com/eks/pricing/
+// ref. https://aws.amazon.com/vpc/pricing/
+const (
+	defaultClusterHourlyUSD    = 0.10
+	defaultNATGatewayHourlyUSD = 0.045
+)
+
+const (
+	sourcePricingAPI  = "pricing-api"
+	sourceDefault     = "default"
+	sourceUnavailable = "unavailable"
+)
+
+// Config defines cost estimator configuration.
+type Config struct {
+	Logger     *zap.Logger
+	EKSConfig  *eksconfig.Config
+	PricingAPI pricingiface.PricingAPI
+}
+
+// Estimate estimates the cost of the cluster, NAT gateways, and node group
+// instances, billed until "now" for the resources not yet deleted.
+func Estimate(cfg Config, now time.Time) *eksconfig.CostStatus {
+	e := &estimator{cfg: cfg, instancePrices: make(map[string]float64)}
+	st := &eksconfig.CostStatus{EstimatedUTC: now.UTC()}
+
+	if cfg.EKSConfig.Status != nil && !cfg.EKSConfig.Status.TimeFrameCreate.StartUTC.IsZero() {
+		hours := billedHours(cfg.EKSConfig.Status.TimeFrameCreate.StartUTC, cfg.EKSConfig.Status.TimeFrameDelete.EndUTC, now)
+		price, src := e.clusterPrice()
+		st.Items = append(st.Items, newItem("cluster", "EKS cluster", 1, hours, price, src))
+
+		// NAT gateways are created with the VPC, before the cluster,
+		// and deleted after the cluster, so this is a lower bound
+		if n := len(cfg.EKSConfig.VPC.NATGatewayIDs); n > 0 {
+			price, src = e.natGatewayPrice()
+			st.Items = append(st.Items, newItem("cluster", "NAT gateway", n, hours, price, src))
+		}
+	}
+
+	if cfg.EKSConfig.IsEnabledAddOnNodeGroups() {
+		names := make([]string, 0, len(cfg.EKSConfig.AddOnNodeGroups.ASGs))
+		for name := range cfg.EKSConfig.AddOnNodeGroups.ASGs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			cur := cfg.EKSConfig.AddOnNodeGroups.ASGs[name]
+			if cur.TimeFrameCreate.StartUTC.IsZero() || cur.InstanceType == "" {
+				continue
+			}
+			hours := billedHours(cur.TimeFrameCreate.StartUTC, cur.TimeFrameDelete.EndUTC, now)
+			price, src := e.instancePrice(cur.InstanceType)
+			st.Items = append(st.Items, newItem("node-groups/"+name, cur.InstanceType, int(cur.ASGDesiredCapacity), hours, price, src))
+		}
+	}
+
+	if cfg.EKSConfig.IsEnabledAddOnManagedNodeGroups() {
+		names := make([]string, 0, len(cfg.EKSConfig.AddOnManagedNodeGroups.MNGs))
+		for name := range cfg.EKSConfig.AddOnManagedNodeGroups.MNGs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			cur := cfg.EKSConfig.AddOnManagedNodeGroups.MNGs[name]
+			if cur.TimeFrameCreate.StartUTC.IsZero() || len(cur.InstanceTypes) == 0 {
+				continue
+			}
+			// spot instances are priced on-demand, as an upper bound
+			hours := billedHours(cur.TimeFrameCreate.StartUTC, cur.TimeFrameDelete.EndUTC, now)
+			price, src := e.instancePrice(cur.InstanceTypes[0])
+			st.Items = append(st.Items, newItem("managed-node-groups/"+name, cur.InstanceTypes[0], cur.ASGDesiredCapacity, hours, price, src))
+		}
+	}
+
+	for _, item := range st.Items {
+		st.TotalUSD += item.USD
+	}
+	return st
+}
+
+// Table returns the cost summary in table format.
+func Table(st *eksconfig.CostStatus) string {
+	buf := bytes.NewBuffer(nil)
+	tb := tablewriter.NewWriter(buf)
+	tb.SetAutoWrapText(false)
+	tb.SetAlignment(tablewriter.ALIGN_LEFT)
+	tb.SetCenterSeparator("*")
+	tb.SetHeader([]string{"phase", "resource", "units", "hours", "hourly USD", "price source", "USD"})
+	for _, v := range st.Items {
+		tb.Append([]string{
+			v.Phase,
+			v.Resource,
+			fmt.Sprintf("%d", v.Units),
+			fmt.Sprintf("%.2f", v.Hours),
+			fmt.Sprintf("%.4f", v.HourlyUSD),
+			v.PriceSource,
+			fmt.Sprintf("%.2f", v.USD),
+		})
+	}
+	tb.SetFooter([]string{"", "", "", "", "", "total", fmt.Sprintf("%.2f", st.TotalUSD)})
+	tb.Render()
+	return buf.String()
+}
+
+func newItem(phase string, resource string, units int, hours float64, price float64, src string) eksconfig.CostItem {
+	return eksconfig.CostItem{
+		Phase:       phase,
+		Resource:    resource,
+		Units:       units,
+		Hours:       hours,
+		HourlyUSD:   price,
+		PriceSource: src,
+		USD:         float64(units) * hours * price,
+	}
+}
+
+// billedHours returns the hours from the start until the end,
+// or until "now" if not yet deleted.
+func billedHours(start time.Time, end time.Time, now time.Time) float64 {
+	if end.IsZero() || end.Before(start) {
+		end = now
+	}
+	return end.Sub(start).Hours()
+}
+
+type estimator struct {
+	cfg            Config
+	instancePrices map[string]float64
+}
+
+func (e *estimator) clusterPrice() (float64, string) {
+	price, err := e.lookup("AmazonEKS", "AmazonEKS-Hours:perCluster", nil)
+	if err != nil {
+		e.cfg.Logger.Warn("failed to look up EKS cluster price; using default", zap.Error(err))
+		return defaultClusterHourlyUSD, sourceDefault
+	}
+	return price, sourcePricingAPI
+}
+
+func (e *estimator) natGatewayPrice() (float64, string) {
+	price, err := e.lookup("AmazonEC2", "NatGateway-Hours", map[string]string{"productFamily": "NAT Gateway"})
+	if err != nil {
+		e.cfg.Logger.Warn("failed to look up NAT gateway price; using default", zap.Error(err))
+		return defaultNATGatewayHourlyUSD, sourceDefault
+	}
+	return price, sourcePricingAPI
+}
+
+func (e *estimator) instancePrice(instanceType string) (float64, string) {
+	if price, ok := e.instancePrices[instanceType]; ok {
+		return price, sourcePricingAPI
+	}
+	price, err := e.lookup("AmazonEC2", "BoxUsage:"+instanceType, map[string]string{
+		"instanceType":    instanceType,
+		"operatingSystem": "Linux",
+		"tenancy":         "Shared",
+		"preInstalledSw":  "NA",
+		"capacitystatus":  "Used",
+	})
+	if err != nil {
+		e.cfg.Logger.Warn("failed to look up instance price", zap.String("instance-type", instanceType), zap.Error(err))
+		return 0, sourceUnavailable
+	}
+	e.instancePrices[instanceType] = price
+	return price, sourcePricingAPI
+}
+
+// lookup returns the on-demand hourly price of the products
+// in the region with the matching usage type suffix.
+func (e *estimator) lookup(serviceCode string, usageTypeSuffix string, attrs map[string]string) (float64, error) {
+	if e.cfg.PricingAPI == nil {
+		return 0, errors.New("no pricing API client")
+	}
+	filters := []*pricing.Filter{{
+		Type:  aws.String(pricing.FilterTypeTermMatch),
+		Field: aws.String("regionCode"),
+		Value: aws.String(e.cfg.EKSConfig.Region),
+	}}
+	for k, v := range attrs {
+		filters = append(filters, &pricing.Filter{
+			Type:  aws.String(pricing.FilterTypeTermMatch),
+			Field: aws.String(k),
+			Value: aws.String(v),
+		})
+	}
+
+	var (
+		price float64
+		found bool
+	)
+	err := e.cfg.PricingAPI.GetProductsPages(
+		&pricing.GetProductsInput{
+			ServiceCode: aws.String(serviceCode),
+			Filters:     filters,
+			MaxResults:  aws.Int64(100),
+		},
+		func(out *pricing.GetProductsOutput, lastPage bool) bool {
+			price, found = hourlyUSD(out.PriceList, usageTypeSuffix)
+			return !found
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, fmt.Errorf("no %q price found for %q in %q", serviceCode, usageTypeSuffix, e.cfg.EKSConfig.Region)
+	}
+	return price, nil
+}
+
+// hourlyUSD returns the on-demand hourly price in USD, from the first
+// product with the matching usage type suffix (e.g. "USW2-BoxUsage:c5.xlarge").
+// ref. https://docs.aws.amazon.com/awsaccountbilling/latest/aboutv2/reading-an-offer.html
+func hourlyUSD(priceList []aws.JSONValue, usageTypeSuffix string) (float64, bool) {
+	for _, p := range priceList {
+		product, _ := p["product"].(map[string]interface{})
+		attrs, _ := product["attributes"].(map[string]interface{})
+		usageType, _ := attrs["usagetype"].(string)
+		// "USE1-BoxUsage:c5.xlarge", or "BoxUsage:c5.xlarge" in us-east-1
+		if usageType != usageTypeSuffix && !strings.HasSuffix(usageType, "-"+usageTypeSuffix) {
+			continue
+		}
+		terms, _ := p["terms"].(map[string]interface{})
+		onDemand, _ := terms["OnDemand"].(map[string]interface{})
+		for _, term := range onDemand {
+			tm, _ := term.(map[string]interface{})
+			dims, _ := tm["priceDimensions"].(map[string]interface{})
+			for _, dim := range dims {
+				dm, _ := dim.(map[string]interface{})
+				if unit, _ := dm["unit"].(string); unit != "Hrs" && unit != "Hours" {
+					continue
+				}
+				ppu, _ := dm["pricePerUnit"].(map[string]interface{})
+				usd, _ := ppu["USD"].(string)
+				v, err := strconv.ParseFloat(usd, 64)
+				if err == nil && v > 0 {
+					return v, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,41 @@
+package cost
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestHourlyUSD(t *testing.T) {
+	var priceList []aws.JSONValue
+	if err := json.Unmarshal([]byte(`[
+{"product":{"attributes":{"usagetype":"USW2-DedicatedUsage:c5.xlarge"}},"terms":{"OnDemand":{"A.B":{"priceDimensions":{"A.B.C":{"unit":"Hrs","pricePerUnit":{"USD":"0.1870000000"}}}}}}},
+{"product":{"attributes":{"usagetype":"USW2-BoxUsage:c5.xlarge"}},"terms":{"OnDemand":{"D.E":{"priceDimensions":{"D.E.F":{"unit":"Hrs","pricePerUnit":{"USD":"0.1700000000"}}}}}}}
+]`), &priceList); err != nil {
+		t.Fatal(err)
+	}
+	v, ok := hourlyUSD(priceList, "BoxUsage:c5.xlarge")
+	if !ok || v != 0.17 {
+		t.Fatalf("unexpected price %v (found %v)", v, ok)
+	}
+	if _, ok = hourlyUSD(priceList, "BoxUsage:m5.xlarge"); ok {
+		t.Fatal("unexpected price for m5.xlarge")
+	}
+}
+
+func TestBilledHours(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start.Add(3 * time.Hour)
+	if v := billedHours(start, time.Time{}, now); v != 3 {
+		t.Fatalf("unexpected hours %v", v)
+	}
+	if v := billedHours(start, start.Add(90*time.Minute), now); v != 1.5 {
+		t.Fatalf("unexpected hours %v", v)
+	}
+	// re-created after the previous deletion
+	if v := billedHours(start, start.Add(-time.Hour), now); v != 3 {
+		t.Fatalf("unexpected hours %v", v)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/aws/aws-k8s-tester/pkg/randutil"
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
 	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
 	"github.com/dustin/go-humanize"
 	"go.uber.org/zap"
@@ -47,6 +48,23 @@ func New(cfg Config) eks_tester.Tester {
 	return &tester{cfg: cfg, busyboxImg: "busybox"}
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:         "cron-jobs",
+		Dependencies: []string{"ecr"},
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				ECRAPI:    ecr.New(cfg.AWSSession, aws.NewConfig().WithRegion(cfg.EKSConfig.GetAddOnCronJobsRepositoryBusyboxRegion())),
+			}), cfg.EKSConfig.IsEnabledAddOnCronJobs)
+		},
+	})
+}
+
 type tester struct {
 	cfg Config
 
@@ -64,15 +82,18 @@ func (ts *tester) Create() (err error) {
 	}
 
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
-	ts.cfg.EKSConfig.AddOnCronJobs.Created = true
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnCronJobs.Created = true
+	})
 	createStart := time.Now()
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnCronJobs.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnCronJobs.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
+	ts.busyboxImg = ts.cfg.EKSConfig.Image(ts.busyboxImg)
 	if ts.cfg.EKSConfig.AddOnCronJobs.RepositoryBusyboxAccountID != "" &&
 		ts.cfg.EKSConfig.AddOnCronJobs.RepositoryBusyboxRegion != "" &&
 		ts.cfg.EKSConfig.AddOnCronJobs.RepositoryBusyboxName != "" &&
@@ -147,8 +168,9 @@ func (ts *tester) Delete() error {
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnCronJobs.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnCronJobs.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
 	}()
 
 	var errs []string
@@ -188,8 +210,9 @@ func (ts *tester) Delete() error {
 		return errors.New(strings.Join(errs, ", "))
 	}
 
-	ts.cfg.EKSConfig.AddOnCronJobs.Created = false
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnCronJobs.Created = false
+	})
 	return nil
 }
 
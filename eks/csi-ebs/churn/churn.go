@@ -0,0 +1,291 @@
+// Package churn implements the EBS CSI volume churn, which repeatedly
+// provisions, attaches, detaches, and deletes EBS-backed
+// PersistentVolumeClaims at the target rate and concurrency.
+package churn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/metrics"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"github.com/aws/aws-sdk-go/aws"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Config defines EBS CSI volume churn configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new EBS CSI volume churn tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:         "csi-ebs-churn",
+		Dependencies: []string{"csi-ebs"},
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+			}), cfg.EKSConfig.IsEnabledAddOnCSIEBSChurn)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+const provisionerName = "ebs.csi.aws.com"
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnCSIEBSChurn() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnCSIEBSChurn.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnCSIEBSChurn.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnCSIEBSChurn.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	cur := ts.cfg.EKSConfig.AddOnCSIEBSChurn
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		cur.Namespace,
+	); err != nil {
+		return err
+	}
+	if err = ts.createStorageClass(); err != nil {
+		return err
+	}
+
+	rec := metrics.NewRecorder()
+	limiter := rate.NewLimiter(rate.Limit(cur.CyclesPerMinute/60), 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-ts.cfg.Stopc:
+			ts.cfg.Logger.Warn("volume churn aborted")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ts.cfg.Logger.Info("starting volume churn",
+		zap.Int("cycles", cur.Cycles),
+		zap.Int("concurrency", cur.Concurrency),
+		zap.Float64("cycles-per-minute", cur.CyclesPerMinute),
+	)
+	cycles := make(chan int, cur.Cycles)
+	for i := 0; i < cur.Cycles; i++ {
+		cycles <- i
+	}
+	close(cycles)
+
+	var wg sync.WaitGroup
+	completed := make(chan struct{}, cur.Cycles)
+	for i := 0; i < cur.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range cycles {
+				if err := limiter.Wait(ctx); err != nil {
+					return
+				}
+				c := &cycle{ts: ts, idx: idx, rec: rec}
+				if err := c.run(ctx); err != nil {
+					ts.cfg.Logger.Warn("volume churn cycle failed", zap.Int("cycle", idx), zap.Error(err))
+					continue
+				}
+				completed <- struct{}{}
+			}
+		}()
+	}
+	wg.Wait()
+	close(completed)
+	for range completed {
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.CompletedCycles++
+		})
+	}
+	aborted := ctx.Err() != nil
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.LatencySummaries = rec.Summaries(time.Now().UTC().Format(time.RFC3339Nano))
+	})
+	if err = ts.writeSummaries(); err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("completed volume churn", zap.Int("completed-cycles", cur.CompletedCycles), zap.Int("cycles", cur.Cycles))
+	if aborted {
+		return errors.New("volume churn aborted")
+	}
+	if cur.CompletedCycles == 0 {
+		return errors.New("no volume churn cycle completed")
+	}
+	return nil
+}
+
+func (ts *tester) writeSummaries() error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEBSChurn
+	b, err := json.MarshalIndent(cur.LatencySummaries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(cur.LatencySummariesJSONPath, b, 0600); err != nil {
+		return err
+	}
+	for _, op := range []string{opProvision, opAttach, opDetach, opDelete} {
+		if s, ok := cur.LatencySummaries[op]; ok {
+			fmt.Fprintf(ts.cfg.LogWriter, "\n\n%q:\n%s\n", op, s.Table())
+		}
+	}
+	ts.cfg.Logger.Info("wrote latency summaries", zap.String("path", cur.LatencySummariesJSONPath))
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnCSIEBSChurn() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnCSIEBSChurn.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnCSIEBSChurn.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+
+	// PVCs left over from failed cycles are deleted with the namespace,
+	// and their volumes by the "Delete" reclaim policy
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnCSIEBSChurn.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil && !apierrs.IsNotFound(err) && !strings.Contains(err.Error(), "not found") {
+		errs = append(errs, fmt.Sprintf("failed to delete volume churn namespace (%v)", err))
+	}
+	if err := ts.deleteStorageClass(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnCSIEBSChurn.Created = false
+	})
+	return nil
+}
+
+func (ts *tester) createStorageClass() error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEBSChurn
+	ts.cfg.Logger.Info("creating StorageClass", zap.String("name", cur.StorageClassName))
+	reclaimPolicy := v1.PersistentVolumeReclaimDelete
+	// bind on the Pod creation, to provision in the Pod's zone
+	bindingMode := storagev1.VolumeBindingWaitForFirstConsumer
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		StorageV1().
+		StorageClasses().
+		Create(
+			ctx,
+			&storagev1.StorageClass{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "storage.k8s.io/v1",
+					Kind:       "StorageClass",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: cur.StorageClassName,
+				},
+				Provisioner: provisionerName,
+				Parameters: map[string]string{
+					"type": cur.VolumeType,
+				},
+				ReclaimPolicy:        &reclaimPolicy,
+				AllowVolumeExpansion: aws.Bool(false),
+				VolumeBindingMode:    &bindingMode,
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create StorageClass (%v)", err)
+	}
+	ts.cfg.Logger.Info("created StorageClass")
+	return nil
+}
+
+func (ts *tester) deleteStorageClass() error {
+	ts.cfg.Logger.Info("deleting StorageClass", zap.String("name", ts.cfg.EKSConfig.AddOnCSIEBSChurn.StorageClassName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.K8SClient.KubernetesClientSet().
+		StorageV1().
+		StorageClasses().
+		Delete(ctx, ts.cfg.EKSConfig.AddOnCSIEBSChurn.StorageClassName, metav1.DeleteOptions{})
+	cancel()
+	if err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to delete StorageClass (%v)", err)
+	}
+	ts.cfg.Logger.Info("deleted StorageClass")
+	return nil
+}
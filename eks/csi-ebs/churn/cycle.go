@@ -0,0 +1,239 @@
+package churn
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/metrics"
+	"github.com/aws/aws-sdk-go/aws"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// operations recorded per cycle
+const (
+	// opProvision is from the PVC and Pod creation to the PVC bound.
+	opProvision = "provision"
+	// opAttach is from the PVC bound to the VolumeAttachment attached.
+	opAttach = "attach"
+	// opDetach is from the Pod deletion to the VolumeAttachment deleted.
+	opDetach = "detach"
+	// opDelete is from the PVC deletion to the PV deleted.
+	opDelete = "delete"
+)
+
+// pollInterval bounds the latency resolution.
+const pollInterval = time.Second
+
+// cycle provisions, attaches, detaches, and deletes one volume.
+type cycle struct {
+	ts  *tester
+	idx int
+	rec *metrics.Recorder
+}
+
+func (c *cycle) run(ctx context.Context) (err error) {
+	cur := c.ts.cfg.EKSConfig.AddOnCSIEBSChurn
+	cli := c.ts.cfg.K8SClient.KubernetesClientSet()
+	name := fmt.Sprintf("csi-ebs-churn-%d", c.idx)
+
+	start := time.Now()
+	if err = c.createPVC(ctx, cli, name); err != nil {
+		return err
+	}
+	if err = c.createPod(ctx, cli, name); err != nil {
+		return err
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		// best-effort, "Delete" cleans up the rest with the namespace
+		dctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		cli.CoreV1().Pods(cur.Namespace).Delete(dctx, name, metav1.DeleteOptions{GracePeriodSeconds: aws.Int64(0)})
+		cli.CoreV1().PersistentVolumeClaims(cur.Namespace).Delete(dctx, name, metav1.DeleteOptions{})
+		cancel()
+	}()
+
+	var pvName string
+	if err = c.step(ctx, opProvision, start, func(sctx context.Context) (bool, error) {
+		pvc, err := cli.CoreV1().PersistentVolumeClaims(cur.Namespace).Get(sctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		pvName = pvc.Spec.VolumeName
+		return pvc.Status.Phase == v1.ClaimBound, nil
+	}); err != nil {
+		return err
+	}
+
+	if err = c.step(ctx, opAttach, time.Now(), func(sctx context.Context) (bool, error) {
+		attached, found, err := volumeAttached(sctx, cli, pvName)
+		return found && attached, err
+	}); err != nil {
+		return err
+	}
+
+	start = time.Now()
+	dctx, cancel := context.WithTimeout(ctx, time.Minute)
+	err = cli.CoreV1().Pods(cur.Namespace).Delete(dctx, name, metav1.DeleteOptions{GracePeriodSeconds: aws.Int64(0)})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to delete Pod %q (%v)", name, err)
+	}
+	if err = c.step(ctx, opDetach, start, func(sctx context.Context) (bool, error) {
+		_, found, err := volumeAttached(sctx, cli, pvName)
+		return !found, err
+	}); err != nil {
+		return err
+	}
+
+	start = time.Now()
+	dctx, cancel = context.WithTimeout(ctx, time.Minute)
+	err = cli.CoreV1().PersistentVolumeClaims(cur.Namespace).Delete(dctx, name, metav1.DeleteOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to delete PersistentVolumeClaim %q (%v)", name, err)
+	}
+	return c.step(ctx, opDelete, start, func(sctx context.Context) (bool, error) {
+		_, err := cli.CoreV1().PersistentVolumes().Get(sctx, pvName, metav1.GetOptions{})
+		if apierrs.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+}
+
+// step polls "done" until true, and records the latency since "start".
+func (c *cycle) step(ctx context.Context, op string, start time.Time, done func(ctx context.Context) (bool, error)) error {
+	timeout := c.ts.cfg.EKSConfig.AddOnCSIEBSChurn.CycleTimeout
+	for time.Since(start) < timeout {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+		sctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		ok, err := done(sctx)
+		cancel()
+		if err != nil {
+			c.ts.cfg.Logger.Warn("failed to poll", zap.String("op", op), zap.Int("cycle", c.idx), zap.Error(err))
+			continue
+		}
+		if ok {
+			took := time.Since(start)
+			c.rec.Observe(op, took, nil)
+			c.ts.cfg.Logger.Info("completed step", zap.String("op", op), zap.Int("cycle", c.idx), zap.Duration("took", took))
+			return nil
+		}
+	}
+	err := fmt.Errorf("%q not completed within %v", op, timeout)
+	c.rec.Observe(op, timeout, err)
+	return err
+}
+
+// volumeAttached returns whether the VolumeAttachment for the PV
+// exists, and whether it is attached.
+func volumeAttached(ctx context.Context, cli kubernetes.Interface, pvName string) (attached bool, found bool, err error) {
+	vas, err := cli.StorageV1().VolumeAttachments().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return false, false, err
+	}
+	for _, va := range vas.Items {
+		if va.Spec.Attacher != provisionerName || aws.StringValue(va.Spec.Source.PersistentVolumeName) != pvName {
+			continue
+		}
+		return va.Status.Attached, true, nil
+	}
+	return false, false, nil
+}
+
+func (c *cycle) createPVC(ctx context.Context, cli kubernetes.Interface, name string) error {
+	cur := c.ts.cfg.EKSConfig.AddOnCSIEBSChurn
+	cctx, cancel := context.WithTimeout(ctx, time.Minute)
+	_, err := cli.CoreV1().
+		PersistentVolumeClaims(cur.Namespace).
+		Create(
+			cctx,
+			&v1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: cur.Namespace,
+				},
+				Spec: v1.PersistentVolumeClaimSpec{
+					StorageClassName: aws.String(cur.StorageClassName),
+					AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{
+							v1.ResourceStorage: *resource.NewQuantity(cur.VolumeSizeGiB<<30, resource.BinarySI),
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create PersistentVolumeClaim %q (%v)", name, err)
+	}
+	return nil
+}
+
+func (c *cycle) createPod(ctx context.Context, cli kubernetes.Interface, name string) error {
+	cur := c.ts.cfg.EKSConfig.AddOnCSIEBSChurn
+	cctx, cancel := context.WithTimeout(ctx, time.Minute)
+	_, err := cli.CoreV1().
+		Pods(cur.Namespace).
+		Create(
+			cctx,
+			&v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: cur.Namespace,
+				},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					// detach starts right after the deletion
+					TerminationGracePeriodSeconds: aws.Int64(0),
+					Containers: []v1.Container{
+						{
+							Name:            name,
+							Image:           cur.PodImage,
+							ImagePullPolicy: v1.PullIfNotPresent,
+							Command:         []string{"/bin/sh", "-c", "sleep 3600"},
+							VolumeMounts: []v1.VolumeMount{
+								{
+									Name:      "data",
+									MountPath: "/data",
+								},
+							},
+						},
+					},
+					Volumes: []v1.Volume{
+						{
+							Name: "data",
+							VolumeSource: v1.VolumeSource{
+								PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+									ClaimName: name,
+								},
+							},
+						},
+					},
+					NodeSelector: map[string]string{
+						"kubernetes.io/os": "linux",
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create Pod %q (%v)", name, err)
+	}
+	return nil
+}
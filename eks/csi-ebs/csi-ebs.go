@@ -1,4 +1,5 @@
-// Package csiebs installs "aws-ebs-csi-driver".
+// Package csiebs installs "aws-ebs-csi-driver", and tests dynamic
+// provisioning, volume expansion, and snapshots with the driver.
 // ref. https://github.com/kubernetes-sigs/aws-ebs-csi-driver
 // ref. https://github.com/kubernetes-sigs/aws-ebs-csi-driver/blob/master/aws-ebs-csi-driver/values.yaml
 package csiebs
@@ -12,11 +13,13 @@ import (
 	"strings"
 	"time"
 
-	"github.com/aws/aws-k8s-tester/eks/helm"
 	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
 	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/pkg/helm"
 	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
 	"go.uber.org/zap"
 	"k8s.io/utils/exec"
 )
@@ -28,6 +31,8 @@ type Config struct {
 	Stopc     chan struct{}
 	EKSConfig *eksconfig.Config
 	K8SClient k8s_client.EKS
+	EKSAPI    eksiface.EKSAPI
+	EC2APIV2  *aws_ec2_v2.Client
 }
 
 var pkgName = reflect.TypeOf(tester{}).PkgPath()
@@ -39,6 +44,23 @@ func New(cfg Config) eks_tester.Tester {
 	return &tester{cfg: cfg}
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "csi-ebs",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				EKSAPI:    cfg.EKSAPI,
+				EC2APIV2:  cfg.EC2APIV2,
+			}), cfg.EKSConfig.IsEnabledAddOnCSIEBS)
+		},
+	})
+}
+
 type tester struct {
 	cfg Config
 }
@@ -56,17 +78,39 @@ func (ts *tester) Create() error {
 	}
 
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
-	ts.cfg.EKSConfig.AddOnCSIEBS.Created = true
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnCSIEBS.Created = true
+	})
 	createStart := time.Now()
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnCSIEBS.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnCSIEBS.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
-	if err := ts.createHelmCSI(); err != nil {
-		return err
+	if !ts.cfg.EKSConfig.AddOnCSIEBS.SkipVolumeTest {
+		// the snapshot CRDs must exist before the driver snapshotter sidecar starts
+		if err := ts.createSnapshotController(); err != nil {
+			return err
+		}
+	}
+
+	switch ts.cfg.EKSConfig.AddOnCSIEBS.InstallMethod {
+	case eksconfig.CSIEBSInstallMethodEKSAddOn:
+		if err := ts.createEKSAddOn(); err != nil {
+			return err
+		}
+	default:
+		if err := ts.createHelmCSI(); err != nil {
+			return err
+		}
+	}
+
+	if !ts.cfg.EKSConfig.AddOnCSIEBS.SkipVolumeTest {
+		if err := ts.createVolumeTest(); err != nil {
+			return err
+		}
 	}
 
 	ts.cfg.EKSConfig.Sync()
@@ -87,22 +131,45 @@ func (ts *tester) Delete() error {
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnCSIEBS.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnCSIEBS.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
 	}()
 
 	var errs []string
 
-	if err := ts.deleteHelmCSI(); err != nil {
-		errs = append(errs, err.Error())
+	// delete the volumes while the driver is still running,
+	// for the driver to delete the EBS volumes and snapshots
+	if !ts.cfg.EKSConfig.AddOnCSIEBS.SkipVolumeTest {
+		if err := ts.deleteVolumeTest(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	switch ts.cfg.EKSConfig.AddOnCSIEBS.InstallMethod {
+	case eksconfig.CSIEBSInstallMethodEKSAddOn:
+		if err := ts.deleteEKSAddOn(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	default:
+		if err := ts.deleteHelmCSI(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if !ts.cfg.EKSConfig.AddOnCSIEBS.SkipVolumeTest {
+		if err := ts.deleteSnapshotController(); err != nil {
+			errs = append(errs, err.Error())
+		}
 	}
 
 	if len(errs) > 0 {
 		return errors.New(strings.Join(errs, ", "))
 	}
 
-	ts.cfg.EKSConfig.AddOnCSIEBS.Created = false
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnCSIEBS.Created = false
+	})
 	return nil
 }
 
@@ -171,7 +238,9 @@ func (ts *tester) createHelmCSI() error {
 		Namespace:      "kube-system",
 		ChartRepoURL:   ts.cfg.EKSConfig.AddOnCSIEBS.ChartRepoURL,
 		ChartName:      chartName,
+		ChartVersion:   ts.cfg.EKSConfig.AddOnCSIEBS.ChartVersion,
 		ReleaseName:    chartName,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
 		Values:         values,
 		LogFunc: func(format string, v ...interface{}) {
 			ts.cfg.Logger.Info(fmt.Sprintf("[install] "+format, v...))
@@ -235,5 +304,6 @@ func (ts *tester) deleteHelmCSI() error {
 		Namespace:      "kube-system",
 		ChartName:      chartName,
 		ReleaseName:    chartName,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
 	})
 }
@@ -0,0 +1,123 @@
+package csiebs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"go.uber.org/zap"
+)
+
+// https://docs.aws.amazon.com/eks/latest/userguide/managing-ebs-csi.html
+func (ts *tester) createEKSAddOn() error {
+	ts.cfg.Logger.Info("creating EKS managed add-on",
+		zap.String("add-on", chartName),
+		zap.String("add-on-version", ts.cfg.EKSConfig.AddOnCSIEBS.AddOnVersion),
+	)
+	input := &eks.CreateAddonInput{
+		ClusterName:      aws.String(ts.cfg.EKSConfig.Name),
+		AddonName:        aws.String(chartName),
+		ResolveConflicts: aws.String(eks.ResolveConflictsOverwrite),
+	}
+	if ts.cfg.EKSConfig.AddOnCSIEBS.AddOnVersion != "" {
+		input.AddonVersion = aws.String(ts.cfg.EKSConfig.AddOnCSIEBS.AddOnVersion)
+	}
+	_, err := ts.cfg.EKSAPI.CreateAddon(input)
+	if err != nil {
+		awsErr, ok := err.(awserr.Error)
+		if !ok || awsErr.Code() != eks.ErrCodeResourceInUseException {
+			return fmt.Errorf("failed to create add-on %q (%v)", chartName, err)
+		}
+		ts.cfg.Logger.Info("EKS managed add-on already exists", zap.String("add-on", chartName))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Minute)
+	defer cancel()
+	for {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("add-on creation aborted")
+		case <-ctx.Done():
+			return fmt.Errorf("add-on %q creation timed out (%v)", chartName, ctx.Err())
+		case <-time.After(15 * time.Second):
+		}
+
+		out, err := ts.cfg.EKSAPI.DescribeAddon(&eks.DescribeAddonInput{
+			ClusterName: aws.String(ts.cfg.EKSConfig.Name),
+			AddonName:   aws.String(chartName),
+		})
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe add-on", zap.Error(err))
+			continue
+		}
+		status := aws.StringValue(out.Addon.Status)
+		ts.cfg.Logger.Info("polled add-on",
+			zap.String("add-on", chartName),
+			zap.String("add-on-version", aws.StringValue(out.Addon.AddonVersion)),
+			zap.String("status", status),
+		)
+		switch status {
+		case eks.AddonStatusActive:
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				ts.cfg.EKSConfig.AddOnCSIEBS.AddOnVersion = aws.StringValue(out.Addon.AddonVersion)
+			})
+			ts.cfg.Logger.Info("created EKS managed add-on", zap.String("add-on", chartName))
+			return nil
+		case eks.AddonStatusCreateFailed:
+			issues := ""
+			if out.Addon.Health != nil {
+				issues = fmt.Sprintf("%+v", out.Addon.Health.Issues)
+			}
+			return fmt.Errorf("add-on %q creation failed (issues %s)", chartName, issues)
+		}
+	}
+}
+
+func (ts *tester) deleteEKSAddOn() error {
+	ts.cfg.Logger.Info("deleting EKS managed add-on", zap.String("add-on", chartName))
+	_, err := ts.cfg.EKSAPI.DeleteAddon(&eks.DeleteAddonInput{
+		ClusterName: aws.String(ts.cfg.EKSConfig.Name),
+		AddonName:   aws.String(chartName),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == eks.ErrCodeResourceNotFoundException {
+			ts.cfg.Logger.Info("EKS managed add-on already deleted", zap.String("add-on", chartName))
+			return nil
+		}
+		return fmt.Errorf("failed to delete add-on %q (%v)", chartName, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	defer cancel()
+	for {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("add-on deletion aborted")
+		case <-ctx.Done():
+			return fmt.Errorf("add-on %q deletion timed out (%v)", chartName, ctx.Err())
+		case <-time.After(15 * time.Second):
+		}
+
+		out, err := ts.cfg.EKSAPI.DescribeAddon(&eks.DescribeAddonInput{
+			ClusterName: aws.String(ts.cfg.EKSConfig.Name),
+			AddonName:   aws.String(chartName),
+		})
+		if err != nil {
+			if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == eks.ErrCodeResourceNotFoundException {
+				ts.cfg.Logger.Info("deleted EKS managed add-on", zap.String("add-on", chartName))
+				return nil
+			}
+			ts.cfg.Logger.Warn("failed to describe add-on", zap.Error(err))
+			continue
+		}
+		status := aws.StringValue(out.Addon.Status)
+		ts.cfg.Logger.Info("polled add-on", zap.String("add-on", chartName), zap.String("status", status))
+		if status == eks.AddonStatusDeleteFailed {
+			return fmt.Errorf("add-on %q deletion failed", chartName)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package csiebs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+	"go.uber.org/zap"
+	"k8s.io/utils/exec"
+)
+
+// snapshotControllerURLs returns the snapshot CRD and controller manifests,
+// in the order to apply.
+// ref. https://github.com/kubernetes-csi/external-snapshotter#usage
+func (ts *tester) snapshotControllerURLs() []string {
+	base := "https://raw.githubusercontent.com/kubernetes-csi/external-snapshotter/" + ts.cfg.EKSConfig.AddOnCSIEBS.SnapshotControllerVersion
+	return []string{
+		base + "/client/config/crd/snapshot.storage.k8s.io_volumesnapshotclasses.yaml",
+		base + "/client/config/crd/snapshot.storage.k8s.io_volumesnapshotcontents.yaml",
+		base + "/client/config/crd/snapshot.storage.k8s.io_volumesnapshots.yaml",
+		base + "/deploy/kubernetes/snapshot-controller/rbac-snapshot-controller.yaml",
+		base + "/deploy/kubernetes/snapshot-controller/setup-snapshot-controller.yaml",
+	}
+}
+
+func (ts *tester) createSnapshotController() error {
+	ts.cfg.Logger.Info("applying snapshot controller", zap.String("version", ts.cfg.EKSConfig.AddOnCSIEBS.SnapshotControllerVersion))
+	for _, u := range ts.snapshotControllerURLs() {
+		var output []byte
+		var err error
+		retryStart := time.Now()
+		for time.Since(retryStart) < 3*time.Minute {
+			select {
+			case <-ts.cfg.Stopc:
+				return errors.New("create snapshot controller aborted")
+			default:
+			}
+			output, err = ts.kubectl(time.Minute, "apply", "--filename="+u)
+			if err == nil {
+				break
+			}
+			ts.cfg.Logger.Warn("'kubectl apply' failed; retrying", zap.String("url", u), zap.Error(err))
+			time.Sleep(5 * time.Second)
+		}
+		fmt.Fprintf(ts.cfg.LogWriter, "\n\"kubectl apply --filename=%s\" output:\n%s\n", u, string(output))
+		if err != nil {
+			return fmt.Errorf("'kubectl apply' failed %v (output %q)", err, string(output))
+		}
+	}
+	ts.cfg.Logger.Info("applied snapshot controller")
+	return nil
+}
+
+func (ts *tester) deleteSnapshotController() error {
+	ts.cfg.Logger.Info("deleting snapshot controller")
+	urls := ts.snapshotControllerURLs()
+	var errs []string
+	for i := len(urls) - 1; i >= 0; i-- {
+		output, err := ts.kubectl(5*time.Minute, "delete", "--ignore-not-found", "--filename="+urls[i])
+		fmt.Fprintf(ts.cfg.LogWriter, "\n\"kubectl delete --filename=%s\" output:\n%s\n", urls[i], string(output))
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("'kubectl delete' failed %v (output %q)", err, string(output)))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	ts.cfg.Logger.Info("deleted snapshot controller")
+	return nil
+}
+
+const snapshotName = "csi-ebs-snapshot"
+
+const snapshotTmpl = `---
+apiVersion: snapshot.storage.k8s.io/v1
+kind: VolumeSnapshotClass
+metadata:
+  name: {{ .ClassName }}
+driver: ebs.csi.aws.com
+deletionPolicy: Delete
+---
+apiVersion: snapshot.storage.k8s.io/v1
+kind: VolumeSnapshot
+metadata:
+  name: {{ .Name }}
+  namespace: {{ .Namespace }}
+spec:
+  volumeSnapshotClassName: {{ .ClassName }}
+  source:
+    persistentVolumeClaimName: {{ .PVCName }}
+`
+
+type snapshotTemplate struct {
+	ClassName string
+	Name      string
+	Namespace string
+	PVCName   string
+}
+
+// createSnapshot snapshots the test volume, and waits for the snapshot
+// to be ready to use, recording the EBS snapshot ID.
+func (ts *tester) createSnapshot() error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEBS
+	tpl := template.Must(template.New("snapshotTmpl").Parse(snapshotTmpl))
+	buf := bytes.NewBuffer(nil)
+	if err := tpl.Execute(buf, snapshotTemplate{
+		ClassName: cur.StorageClassName,
+		Name:      snapshotName,
+		Namespace: cur.Namespace,
+		PVCName:   pvcName,
+	}); err != nil {
+		return err
+	}
+	fpath, err := fileutil.WriteTempFile(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("creating VolumeSnapshot", zap.String("name", snapshotName))
+	output, err := ts.kubectl(time.Minute, "apply", "--filename="+fpath)
+	fmt.Fprintf(ts.cfg.LogWriter, "\n\"kubectl apply\" output:\n%s\n", string(output))
+	if err != nil {
+		return fmt.Errorf("'kubectl apply' failed %v (output %q)", err, string(output))
+	}
+
+	ready := false
+	retryStart := time.Now()
+	for time.Since(retryStart) < cur.VolumeTestTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("create VolumeSnapshot aborted")
+		case <-time.After(10 * time.Second):
+		}
+		output, err = ts.kubectl(time.Minute,
+			"--namespace="+cur.Namespace,
+			"get", "volumesnapshot", snapshotName,
+			"--output=jsonpath={.status.readyToUse}",
+		)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get VolumeSnapshot", zap.String("output", string(output)), zap.Error(err))
+			continue
+		}
+		ts.cfg.Logger.Info("polled VolumeSnapshot", zap.String("ready-to-use", string(output)))
+		if strings.TrimSpace(string(output)) == "true" {
+			ready = true
+			break
+		}
+	}
+	if !ready {
+		return fmt.Errorf("VolumeSnapshot %q not ready within %v", snapshotName, cur.VolumeTestTimeout)
+	}
+
+	output, err = ts.kubectl(time.Minute,
+		"--namespace="+cur.Namespace,
+		"get", "volumesnapshot", snapshotName,
+		"--output=jsonpath={.status.boundVolumeSnapshotContentName}",
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get VolumeSnapshot content name %v (output %q)", err, string(output))
+	}
+	contentName := strings.TrimSpace(string(output))
+	output, err = ts.kubectl(time.Minute,
+		"get", "volumesnapshotcontent", contentName,
+		"--output=jsonpath={.status.snapshotHandle}",
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get VolumeSnapshotContent %q %v (output %q)", contentName, err, string(output))
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.SnapshotHandle = strings.TrimSpace(string(output))
+	})
+
+	ts.cfg.Logger.Info("created VolumeSnapshot", zap.String("snapshot-handle", cur.SnapshotHandle))
+	return nil
+}
+
+func (ts *tester) deleteSnapshotClass() error {
+	ts.cfg.Logger.Info("deleting VolumeSnapshotClass", zap.String("name", ts.cfg.EKSConfig.AddOnCSIEBS.StorageClassName))
+	output, err := ts.kubectl(time.Minute,
+		"delete", "volumesnapshotclass", ts.cfg.EKSConfig.AddOnCSIEBS.StorageClassName,
+		"--ignore-not-found",
+	)
+	out := string(output)
+	fmt.Fprintf(ts.cfg.LogWriter, "\n\"kubectl delete volumesnapshotclass\" output:\n%s\n", out)
+	if err != nil && !strings.Contains(out, "the server doesn't have a resource type") {
+		return fmt.Errorf("'kubectl delete' failed %v (output %q)", err, out)
+	}
+	ts.cfg.Logger.Info("deleted VolumeSnapshotClass")
+	return nil
+}
+
+func (ts *tester) kubectl(timeout time.Duration, args ...string) ([]byte, error) {
+	args = append([]string{"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath}, args...)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return exec.New().CommandContext(ctx, ts.cfg.EKSConfig.KubectlPath, args...).CombinedOutput()
+}
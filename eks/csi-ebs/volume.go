@@ -0,0 +1,512 @@
+package csiebs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	smithy "github.com/aws/smithy-go"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	provisionerName = "ebs.csi.aws.com"
+
+	pvcName        = "csi-ebs-pvc"
+	pvcRestoreName = "csi-ebs-pvc-restore"
+
+	mountPath = "/data"
+	dataFile  = mountPath + "/token"
+)
+
+// createVolumeTest provisions a volume with the driver, writes and reads
+// data, expands the volume, and restores the data from a snapshot.
+func (ts *tester) createVolumeTest() error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEBS
+	if err := k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		cur.Namespace,
+	); err != nil {
+		return err
+	}
+	if err := ts.createStorageClass(); err != nil {
+		return err
+	}
+
+	token := ts.cfg.EKSConfig.Name + "-" + time.Now().UTC().Format("20060102150405")
+	if err := ts.createPVC(pvcName, cur.VolumeSizeGiB, nil); err != nil {
+		return err
+	}
+	if _, err := ts.runPod("csi-ebs-writer", pvcName, fmt.Sprintf("echo %s > %s && sync && cat %s", token, dataFile, dataFile)); err != nil {
+		return err
+	}
+	if err := ts.recordVolumeID(pvcName); err != nil {
+		return err
+	}
+
+	if err := ts.resizePVC(pvcName, cur.VolumeResizeGiB); err != nil {
+		return err
+	}
+	// the file system is expanded when the volume is mounted again
+	out, err := ts.runPod("csi-ebs-reader", pvcName, fmt.Sprintf("cat %s && df -k %s", dataFile, mountPath))
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(out, token) {
+		return fmt.Errorf("data %q not found after resize (output %q)", token, out)
+	}
+	if err = ts.waitPVCCapacity(pvcName, cur.VolumeResizeGiB); err != nil {
+		return err
+	}
+
+	if err = ts.createSnapshot(); err != nil {
+		return err
+	}
+	if err = ts.createPVC(pvcRestoreName, cur.VolumeResizeGiB, &v1.TypedLocalObjectReference{
+		APIGroup: aws_v2.String("snapshot.storage.k8s.io"),
+		Kind:     "VolumeSnapshot",
+		Name:     snapshotName,
+	}); err != nil {
+		return err
+	}
+	out, err = ts.runPod("csi-ebs-restore-reader", pvcRestoreName, fmt.Sprintf("cat %s", dataFile))
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(out, token) {
+		return fmt.Errorf("data %q not found in volume restored from snapshot (output %q)", token, out)
+	}
+	if err = ts.recordVolumeID(pvcRestoreName); err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("volume test succeeded",
+		zap.Strings("volume-ids", cur.VolumeIDs),
+		zap.String("snapshot-handle", cur.SnapshotHandle),
+	)
+	return nil
+}
+
+// deleteVolumeTest deletes the test objects, and waits for
+// the EBS volumes and snapshot to be deleted from EC2.
+func (ts *tester) deleteVolumeTest() error {
+	var errs []string
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnCSIEBS.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete CSI EBS namespace (%v)", err))
+	}
+	if err := ts.deleteSnapshotClass(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteStorageClass(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.waitEC2Deleted(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func (ts *tester) createStorageClass() error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEBS
+	ts.cfg.Logger.Info("creating StorageClass", zap.String("name", cur.StorageClassName))
+	reclaimPolicy := v1.PersistentVolumeReclaimDelete
+	bindingMode := storagev1.VolumeBindingWaitForFirstConsumer
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		StorageV1().
+		StorageClasses().
+		Create(
+			ctx,
+			&storagev1.StorageClass{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "storage.k8s.io/v1",
+					Kind:       "StorageClass",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: cur.StorageClassName,
+				},
+				Provisioner: provisionerName,
+				Parameters: map[string]string{
+					"type": cur.VolumeType,
+				},
+				ReclaimPolicy:        &reclaimPolicy,
+				AllowVolumeExpansion: aws_v2.Bool(true),
+				VolumeBindingMode:    &bindingMode,
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create StorageClass (%v)", err)
+	}
+	ts.cfg.Logger.Info("created StorageClass")
+	return nil
+}
+
+func (ts *tester) deleteStorageClass() error {
+	ts.cfg.Logger.Info("deleting StorageClass", zap.String("name", ts.cfg.EKSConfig.AddOnCSIEBS.StorageClassName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.K8SClient.KubernetesClientSet().
+		StorageV1().
+		StorageClasses().
+		Delete(ctx, ts.cfg.EKSConfig.AddOnCSIEBS.StorageClassName, metav1.DeleteOptions{})
+	cancel()
+	if err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to delete StorageClass (%v)", err)
+	}
+	ts.cfg.Logger.Info("deleted StorageClass")
+	return nil
+}
+
+func (ts *tester) createPVC(name string, sizeGiB int64, dataSource *v1.TypedLocalObjectReference) error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEBS
+	ts.cfg.Logger.Info("creating PersistentVolumeClaim", zap.String("name", name), zap.Int64("size-gib", sizeGiB))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		PersistentVolumeClaims(cur.Namespace).
+		Create(
+			ctx,
+			&v1.PersistentVolumeClaim{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "PersistentVolumeClaim",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: cur.Namespace,
+				},
+				Spec: v1.PersistentVolumeClaimSpec{
+					StorageClassName: aws_v2.String(cur.StorageClassName),
+					AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{
+							v1.ResourceStorage: *resource.NewQuantity(sizeGiB<<30, resource.BinarySI),
+						},
+					},
+					DataSource: dataSource,
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create PersistentVolumeClaim %q (%v)", name, err)
+	}
+	ts.cfg.Logger.Info("created PersistentVolumeClaim", zap.String("name", name))
+	return nil
+}
+
+func (ts *tester) resizePVC(name string, sizeGiB int64) error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEBS
+	ts.cfg.Logger.Info("resizing PersistentVolumeClaim", zap.String("name", name), zap.Int64("size-gib", sizeGiB))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	pvc, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		PersistentVolumeClaims(cur.Namespace).
+		Get(ctx, name, metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get PersistentVolumeClaim %q (%v)", name, err)
+	}
+	pvc.Spec.Resources.Requests[v1.ResourceStorage] = *resource.NewQuantity(sizeGiB<<30, resource.BinarySI)
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		PersistentVolumeClaims(cur.Namespace).
+		Update(ctx, pvc, metav1.UpdateOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to resize PersistentVolumeClaim %q (%v)", name, err)
+	}
+	return nil
+}
+
+func (ts *tester) waitPVCCapacity(name string, sizeGiB int64) error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEBS
+	expected := resource.NewQuantity(sizeGiB<<30, resource.BinarySI)
+	retryStart := time.Now()
+	for time.Since(retryStart) < cur.VolumeTestTimeout {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		pvc, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			PersistentVolumeClaims(cur.Namespace).
+			Get(ctx, name, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get PersistentVolumeClaim", zap.Error(err))
+		} else {
+			capacity := pvc.Status.Capacity[v1.ResourceStorage]
+			ts.cfg.Logger.Info("polled PersistentVolumeClaim capacity",
+				zap.String("name", name),
+				zap.String("capacity", capacity.String()),
+				zap.String("expected", expected.String()),
+			)
+			if capacity.Cmp(*expected) >= 0 {
+				return nil
+			}
+		}
+
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait for PersistentVolumeClaim resize aborted")
+		case <-time.After(10 * time.Second):
+		}
+	}
+	return fmt.Errorf("PersistentVolumeClaim %q not resized to %s within %v", name, expected.String(), cur.VolumeTestTimeout)
+}
+
+// recordVolumeID records the EBS volume ID bound to the claim.
+func (ts *tester) recordVolumeID(name string) error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEBS
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	pvc, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		PersistentVolumeClaims(cur.Namespace).
+		Get(ctx, name, metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get PersistentVolumeClaim %q (%v)", name, err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	pv, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		PersistentVolumes().
+		Get(ctx, pvc.Spec.VolumeName, metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get PersistentVolume %q (%v)", pvc.Spec.VolumeName, err)
+	}
+	if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != provisionerName {
+		return fmt.Errorf("PersistentVolume %q not provisioned by %q", pv.Name, provisionerName)
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.VolumeIDs = append(cur.VolumeIDs, pv.Spec.CSI.VolumeHandle)
+	})
+	ts.cfg.Logger.Info("provisioned EBS volume",
+		zap.String("pvc-name", name),
+		zap.String("pv-name", pv.Name),
+		zap.String("volume-id", pv.Spec.CSI.VolumeHandle),
+	)
+	return nil
+}
+
+// runPod runs the command in a Pod mounting the claim, waits
+// for the Pod to succeed, and returns the Pod logs.
+func (ts *tester) runPod(name string, claimName string, command string) (string, error) {
+	cur := ts.cfg.EKSConfig.AddOnCSIEBS
+	ts.cfg.Logger.Info("creating Pod", zap.String("name", name), zap.String("pvc-name", claimName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(cur.Namespace).
+		Create(
+			ctx,
+			&v1.Pod{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Pod",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: cur.Namespace,
+				},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Containers: []v1.Container{
+						{
+							Name:            name,
+							Image:           cur.PodImage,
+							ImagePullPolicy: v1.PullIfNotPresent,
+							Command:         []string{"/bin/sh", "-c", command},
+							VolumeMounts: []v1.VolumeMount{
+								{
+									Name:      "data",
+									MountPath: mountPath,
+								},
+							},
+						},
+					},
+					Volumes: []v1.Volume{
+						{
+							Name: "data",
+							VolumeSource: v1.VolumeSource{
+								PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+									ClaimName: claimName,
+								},
+							},
+						},
+					},
+					NodeSelector: map[string]string{
+						"kubernetes.io/os": "linux",
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("failed to create Pod %q (%v)", name, err)
+	}
+
+	succeeded := false
+	retryStart := time.Now()
+	for time.Since(retryStart) < cur.VolumeTestTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return "", errors.New("wait for Pod aborted")
+		case <-time.After(5 * time.Second):
+		}
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		pod, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Pods(cur.Namespace).
+			Get(ctx, name, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get Pod", zap.String("name", name), zap.Error(err))
+			continue
+		}
+		ts.cfg.Logger.Info("polled Pod", zap.String("name", name), zap.String("phase", string(pod.Status.Phase)))
+		if pod.Status.Phase == v1.PodFailed {
+			out, _ := ts.podLogs(name)
+			return "", fmt.Errorf("Pod %q failed (logs %q)", name, out)
+		}
+		if pod.Status.Phase == v1.PodSucceeded {
+			succeeded = true
+			break
+		}
+	}
+	if !succeeded {
+		return "", fmt.Errorf("Pod %q not succeeded within %v", name, cur.VolumeTestTimeout)
+	}
+
+	out, err := ts.podLogs(name)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(ts.cfg.LogWriter, "\nPod %q output:\n%s\n", name, out)
+
+	// delete the Pod to detach the volume for the next step
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	err = ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(cur.Namespace).
+		Delete(ctx, name, metav1.DeleteOptions{GracePeriodSeconds: aws_v2.Int64(0)})
+	cancel()
+	if err != nil && !apierrs.IsNotFound(err) {
+		return "", fmt.Errorf("failed to delete Pod %q (%v)", name, err)
+	}
+	return out, nil
+}
+
+func (ts *tester) podLogs(name string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	b, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(ts.cfg.EKSConfig.AddOnCSIEBS.Namespace).
+		GetLogs(name, &v1.PodLogOptions{}).
+		DoRaw(ctx)
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("failed to get Pod %q logs (%v)", name, err)
+	}
+	return string(b), nil
+}
+
+// waitEC2Deleted waits for the EBS volumes and snapshot created
+// by the volume test to be deleted from EC2.
+func (ts *tester) waitEC2Deleted() error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEBS
+	remaining := make(map[string]struct{})
+	for _, id := range cur.VolumeIDs {
+		remaining[id] = struct{}{}
+	}
+	snapshotDeleted := cur.SnapshotHandle == ""
+	if len(remaining) == 0 && snapshotDeleted {
+		return nil
+	}
+
+	ts.cfg.Logger.Info("waiting for EBS volumes and snapshot deletion",
+		zap.Strings("volume-ids", cur.VolumeIDs),
+		zap.String("snapshot-handle", cur.SnapshotHandle),
+	)
+	retryStart := time.Now()
+	for time.Since(retryStart) < cur.VolumeTestTimeout {
+		for id := range remaining {
+			out, err := ts.cfg.EC2APIV2.DescribeVolumes(
+				context.Background(),
+				&aws_ec2_v2.DescribeVolumesInput{VolumeIds: []string{id}},
+			)
+			if isErrCode(err, "InvalidVolume.NotFound") ||
+				(err == nil && (len(out.Volumes) == 0 || out.Volumes[0].State == aws_ec2_v2_types.VolumeStateDeleted)) {
+				ts.cfg.Logger.Info("EBS volume deleted", zap.String("volume-id", id))
+				delete(remaining, id)
+				continue
+			}
+			if err != nil {
+				ts.cfg.Logger.Warn("failed to describe EBS volume", zap.String("volume-id", id), zap.Error(err))
+			}
+		}
+		if !snapshotDeleted {
+			out, err := ts.cfg.EC2APIV2.DescribeSnapshots(
+				context.Background(),
+				&aws_ec2_v2.DescribeSnapshotsInput{SnapshotIds: []string{cur.SnapshotHandle}},
+			)
+			if isErrCode(err, "InvalidSnapshot.NotFound") || (err == nil && len(out.Snapshots) == 0) {
+				ts.cfg.Logger.Info("EBS snapshot deleted", zap.String("snapshot-handle", cur.SnapshotHandle))
+				snapshotDeleted = true
+			} else if err != nil {
+				ts.cfg.Logger.Warn("failed to describe EBS snapshot", zap.Error(err))
+			}
+		}
+		if len(remaining) == 0 && snapshotDeleted {
+			return nil
+		}
+
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait for EBS deletion aborted")
+		case <-time.After(10 * time.Second):
+		}
+	}
+
+	var left []string
+	for id := range remaining {
+		left = append(left, id)
+	}
+	if !snapshotDeleted {
+		left = append(left, cur.SnapshotHandle)
+	}
+	return fmt.Errorf("EBS resources %q not deleted within %v", left, cur.VolumeTestTimeout)
+}
+
+func isErrCode(err error, code string) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode() == code
+	}
+	return false
+}
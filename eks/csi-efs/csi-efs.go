@@ -0,0 +1,249 @@
+// Package csiefs creates an EFS file system in the cluster VPC, installs
+// "aws-efs-csi-driver", and tests ReadWriteMany volumes across nodes
+// with dynamically provisioned access points.
+// ref. https://github.com/kubernetes-sigs/aws-efs-csi-driver
+// ref. https://github.com/kubernetes-sigs/aws-efs-csi-driver/blob/master/charts/aws-efs-csi-driver/values.yaml
+package csiefs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/pkg/helm"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_iam_v2 "github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go/service/efs"
+	"github.com/aws/aws-sdk-go/service/efs/efsiface"
+	"go.uber.org/zap"
+	"k8s.io/utils/exec"
+)
+
+// Config defines AWS EFS CSI Driver configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+
+	IAMAPIV2 *aws_iam_v2.Client
+	EC2APIV2 *aws_ec2_v2.Client
+	EFSAPI   efsiface.EFSAPI
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new AWS EFS CSI Driver tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "csi-efs",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				IAMAPIV2:  cfg.IAMAPIV2,
+				EC2APIV2:  cfg.EC2APIV2,
+				EFSAPI:    efs.New(cfg.AWSSession),
+			}), cfg.EKSConfig.IsEnabledAddOnCSIEFS)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+const (
+	chartName = "aws-efs-csi-driver"
+
+	// serviceAccountName is the controller service account name created by the chart.
+	serviceAccountName = "efs-csi-controller-sa"
+)
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnCSIEFS() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnCSIEFS.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnCSIEFS.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnCSIEFS.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	if err = ts.createOIDCProvider(); err != nil {
+		return err
+	}
+	if err = ts.createRole(); err != nil {
+		return err
+	}
+	if err = ts.createSecurityGroup(); err != nil {
+		return err
+	}
+	if err = ts.createFileSystem(); err != nil {
+		return err
+	}
+	if err = ts.createMountTargets(); err != nil {
+		return err
+	}
+	if err = ts.createHelmCSI(); err != nil {
+		return err
+	}
+	if err = ts.createVolumeTest(); err != nil {
+		return err
+	}
+
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnCSIEFS() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnCSIEFS.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnCSIEFS.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	// delete the volumes while the driver is still running,
+	// for the driver to delete the access points
+	var errs []string
+	if err := ts.deleteVolumeTest(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteHelmCSI(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteMountTargets(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteFileSystem(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteSecurityGroup(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteRole(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteOIDCProvider(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnCSIEFS.Created = false
+	})
+	return nil
+}
+
+// https://github.com/kubernetes-sigs/aws-efs-csi-driver/blob/master/charts/aws-efs-csi-driver/values.yaml
+func (ts *tester) createHelmCSI() error {
+	values := map[string]interface{}{
+		"controller": map[string]interface{}{
+			"serviceAccount": map[string]interface{}{
+				"create": true,
+				"name":   serviceAccountName,
+				"annotations": map[string]interface{}{
+					"eks.amazonaws.com/role-arn": ts.cfg.EKSConfig.AddOnCSIEFS.RoleARN,
+				},
+			},
+		},
+	}
+
+	getAllArgs := []string{
+		ts.cfg.EKSConfig.KubectlPath,
+		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+		"--namespace=kube-system",
+		"get",
+		"all",
+		"--selector=app.kubernetes.io/name=" + chartName,
+	}
+	getAllCmd := strings.Join(getAllArgs, " ")
+
+	return helm.Install(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Stopc:          ts.cfg.Stopc,
+		Timeout:        15 * time.Minute,
+		KubeConfigPath: ts.cfg.EKSConfig.KubeConfigPath,
+		Namespace:      "kube-system",
+		ChartRepoURL:   ts.cfg.EKSConfig.AddOnCSIEFS.ChartRepoURL,
+		ChartName:      chartName,
+		ChartVersion:   ts.cfg.EKSConfig.AddOnCSIEFS.ChartVersion,
+		ReleaseName:    chartName,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
+		Values:         values,
+		LogFunc: func(format string, v ...interface{}) {
+			ts.cfg.Logger.Info(fmt.Sprintf("[install] "+format, v...))
+		},
+		QueryFunc: func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			output, err := exec.New().CommandContext(ctx, getAllArgs[0], getAllArgs[1:]...).CombinedOutput()
+			cancel()
+			out := strings.TrimSpace(string(output))
+			if err != nil {
+				ts.cfg.Logger.Warn("'kubectl get all' failed", zap.Error(err))
+			}
+			fmt.Fprintf(ts.cfg.LogWriter, "\n\n'%s' output:\n\n%s\n\n", getAllCmd, out)
+		},
+		QueryInterval: 30 * time.Second,
+	})
+}
+
+func (ts *tester) deleteHelmCSI() error {
+	return helm.Uninstall(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Timeout:        15 * time.Minute,
+		KubeConfigPath: ts.cfg.EKSConfig.KubeConfigPath,
+		Namespace:      "kube-system",
+		ChartName:      chartName,
+		ReleaseName:    chartName,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
+	})
+}
@@ -0,0 +1,320 @@
+package csiefs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/efs"
+	"go.uber.org/zap"
+)
+
+// createSecurityGroup creates the mount target security group,
+// allowing NFS from the VPC CIDR blocks.
+func (ts *tester) createSecurityGroup() error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEFS
+	if cur.SecurityGroupID != "" {
+		ts.cfg.Logger.Info("mount target security group already created", zap.String("security-group-id", cur.SecurityGroupID))
+		return nil
+	}
+
+	vout, err := ts.cfg.EC2APIV2.DescribeVpcs(
+		context.Background(),
+		&aws_ec2_v2.DescribeVpcsInput{VpcIds: []string{ts.cfg.EKSConfig.VPC.ID}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to describe VPC %q (%v)", ts.cfg.EKSConfig.VPC.ID, err)
+	}
+	if len(vout.Vpcs) != 1 {
+		return fmt.Errorf("VPC %q not found", ts.cfg.EKSConfig.VPC.ID)
+	}
+	var ranges []aws_ec2_v2_types.IpRange
+	for _, assoc := range vout.Vpcs[0].CidrBlockAssociationSet {
+		ranges = append(ranges, aws_ec2_v2_types.IpRange{CidrIp: assoc.CidrBlock})
+	}
+
+	sgName := ts.cfg.EKSConfig.Name + "-csi-efs-mount-target"
+	ts.cfg.Logger.Info("creating mount target security group", zap.String("name", sgName))
+	sout, err := ts.cfg.EC2APIV2.CreateSecurityGroup(
+		context.Background(),
+		&aws_ec2_v2.CreateSecurityGroupInput{
+			GroupName:   aws_v2.String(sgName),
+			Description: aws_v2.String("EFS mount targets for " + ts.cfg.EKSConfig.Name),
+			VpcId:       aws_v2.String(ts.cfg.EKSConfig.VPC.ID),
+			TagSpecifications: []aws_ec2_v2_types.TagSpecification{
+				{
+					ResourceType: aws_ec2_v2_types.ResourceTypeSecurityGroup,
+					Tags: []aws_ec2_v2_types.Tag{
+						{Key: aws_v2.String("Name"), Value: aws_v2.String(sgName)},
+					},
+				},
+			},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create security group (%v)", err)
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.SecurityGroupID = aws_v2.ToString(sout.GroupId)
+	})
+
+	_, err = ts.cfg.EC2APIV2.AuthorizeSecurityGroupIngress(
+		context.Background(),
+		&aws_ec2_v2.AuthorizeSecurityGroupIngressInput{
+			GroupId: aws_v2.String(cur.SecurityGroupID),
+			IpPermissions: []aws_ec2_v2_types.IpPermission{
+				{
+					IpProtocol: aws_v2.String("tcp"),
+					FromPort:   aws_v2.Int32(2049),
+					ToPort:     aws_v2.Int32(2049),
+					IpRanges:   ranges,
+				},
+			},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to authorize NFS ingress (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created mount target security group", zap.String("security-group-id", cur.SecurityGroupID))
+	return nil
+}
+
+func (ts *tester) deleteSecurityGroup() error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEFS
+	if cur.SecurityGroupID == "" {
+		return nil
+	}
+	if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[cur.SecurityGroupID]; ok {
+		return nil
+	}
+
+	ts.cfg.Logger.Info("deleting mount target security group", zap.String("security-group-id", cur.SecurityGroupID))
+	var err error
+	// mount target network interfaces may take a while to be released
+	retryStart := time.Now()
+	for time.Since(retryStart) < 5*time.Minute {
+		_, err = ts.cfg.EC2APIV2.DeleteSecurityGroup(
+			context.Background(),
+			&aws_ec2_v2.DeleteSecurityGroupInput{GroupId: aws_v2.String(cur.SecurityGroupID)},
+		)
+		if err == nil || isErrCode(err, "InvalidGroup.NotFound") {
+			err = nil
+			break
+		}
+		ts.cfg.Logger.Warn("failed to delete security group; retrying", zap.Error(err))
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("delete security group aborted")
+		case <-time.After(10 * time.Second):
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete security group %q (%v)", cur.SecurityGroupID, err)
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.Status.DeletedResources[cur.SecurityGroupID] = "AddOnCSIEFS.SecurityGroupID"
+	})
+	ts.cfg.Logger.Info("deleted mount target security group")
+	return nil
+}
+
+func (ts *tester) createFileSystem() error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEFS
+	if cur.FileSystemID == "" {
+		ts.cfg.Logger.Info("creating EFS file system", zap.String("performance-mode", cur.PerformanceMode))
+		out, err := ts.cfg.EFSAPI.CreateFileSystem(&efs.CreateFileSystemInput{
+			// idempotent with the same creation token
+			CreationToken:   aws.String(ts.cfg.EKSConfig.Name + "-csi-efs"),
+			PerformanceMode: aws.String(cur.PerformanceMode),
+			Encrypted:       aws.Bool(true),
+			Tags: []*efs.Tag{
+				{Key: aws.String("Name"), Value: aws.String(ts.cfg.EKSConfig.Name + "-csi-efs")},
+			},
+		})
+		if err != nil {
+			awsErr, ok := err.(awserr.Error)
+			if !ok || awsErr.Code() != efs.ErrCodeFileSystemAlreadyExists {
+				return fmt.Errorf("failed to create EFS file system (%v)", err)
+			}
+			dout, derr := ts.cfg.EFSAPI.DescribeFileSystems(&efs.DescribeFileSystemsInput{
+				CreationToken: aws.String(ts.cfg.EKSConfig.Name + "-csi-efs"),
+			})
+			if derr != nil || len(dout.FileSystems) != 1 {
+				return fmt.Errorf("failed to describe existing EFS file system (%v)", derr)
+			}
+			out = dout.FileSystems[0]
+		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.FileSystemID = aws.StringValue(out.FileSystemId)
+		})
+	}
+
+	retryStart := time.Now()
+	for time.Since(retryStart) < 5*time.Minute {
+		out, err := ts.cfg.EFSAPI.DescribeFileSystems(&efs.DescribeFileSystemsInput{
+			FileSystemId: aws.String(cur.FileSystemID),
+		})
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe EFS file system", zap.Error(err))
+		} else if len(out.FileSystems) == 1 {
+			state := aws.StringValue(out.FileSystems[0].LifeCycleState)
+			ts.cfg.Logger.Info("polled EFS file system", zap.String("file-system-id", cur.FileSystemID), zap.String("state", state))
+			if state == efs.LifeCycleStateAvailable {
+				ts.cfg.Logger.Info("created EFS file system", zap.String("file-system-id", cur.FileSystemID))
+				return nil
+			}
+		}
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("create EFS file system aborted")
+		case <-time.After(10 * time.Second):
+		}
+	}
+	return fmt.Errorf("EFS file system %q not available", cur.FileSystemID)
+}
+
+func (ts *tester) deleteFileSystem() error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEFS
+	if cur.FileSystemID == "" {
+		return nil
+	}
+	if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[cur.FileSystemID]; ok {
+		return nil
+	}
+
+	ts.cfg.Logger.Info("deleting EFS file system", zap.String("file-system-id", cur.FileSystemID))
+	_, err := ts.cfg.EFSAPI.DeleteFileSystem(&efs.DeleteFileSystemInput{
+		FileSystemId: aws.String(cur.FileSystemID),
+	})
+	if err != nil && !isEFSErrCode(err, efs.ErrCodeFileSystemNotFound) {
+		return fmt.Errorf("failed to delete EFS file system %q (%v)", cur.FileSystemID, err)
+	}
+
+	retryStart := time.Now()
+	for time.Since(retryStart) < 5*time.Minute {
+		_, err = ts.cfg.EFSAPI.DescribeFileSystems(&efs.DescribeFileSystemsInput{
+			FileSystemId: aws.String(cur.FileSystemID),
+		})
+		if isEFSErrCode(err, efs.ErrCodeFileSystemNotFound) {
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				ts.cfg.EKSConfig.Status.DeletedResources[cur.FileSystemID] = "AddOnCSIEFS.FileSystemID"
+			})
+			ts.cfg.Logger.Info("deleted EFS file system")
+			return nil
+		}
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("delete EFS file system aborted")
+		case <-time.After(10 * time.Second):
+		}
+	}
+	return fmt.Errorf("EFS file system %q not deleted", cur.FileSystemID)
+}
+
+// createMountTargets creates a mount target in each node subnet.
+func (ts *tester) createMountTargets() error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEFS
+	if len(cur.MountTargetIDs) > 0 {
+		ts.cfg.Logger.Info("mount targets already created", zap.Strings("mount-target-ids", cur.MountTargetIDs))
+		return nil
+	}
+
+	for _, subnetID := range ts.cfg.EKSConfig.VPC.PublicSubnetIDs {
+		ts.cfg.Logger.Info("creating mount target", zap.String("subnet-id", subnetID))
+		out, err := ts.cfg.EFSAPI.CreateMountTarget(&efs.CreateMountTargetInput{
+			FileSystemId:   aws.String(cur.FileSystemID),
+			SubnetId:       aws.String(subnetID),
+			SecurityGroups: aws.StringSlice([]string{cur.SecurityGroupID}),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create mount target in %q (%v)", subnetID, err)
+		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.MountTargetIDs = append(cur.MountTargetIDs, aws.StringValue(out.MountTargetId))
+		})
+	}
+
+	retryStart := time.Now()
+	for time.Since(retryStart) < 10*time.Minute {
+		out, err := ts.cfg.EFSAPI.DescribeMountTargets(&efs.DescribeMountTargetsInput{
+			FileSystemId: aws.String(cur.FileSystemID),
+		})
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe mount targets", zap.Error(err))
+		} else {
+			available := 0
+			for _, mt := range out.MountTargets {
+				if aws.StringValue(mt.LifeCycleState) == efs.LifeCycleStateAvailable {
+					available++
+				}
+			}
+			ts.cfg.Logger.Info("polled mount targets", zap.Int("available", available), zap.Int("total", len(cur.MountTargetIDs)))
+			if available == len(cur.MountTargetIDs) {
+				ts.cfg.Logger.Info("created mount targets", zap.Strings("mount-target-ids", cur.MountTargetIDs))
+				return nil
+			}
+		}
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("create mount targets aborted")
+		case <-time.After(10 * time.Second):
+		}
+	}
+	return fmt.Errorf("mount targets %q not available", cur.MountTargetIDs)
+}
+
+func (ts *tester) deleteMountTargets() error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEFS
+	if cur.FileSystemID == "" {
+		return nil
+	}
+	if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[cur.FileSystemID]; ok {
+		return nil
+	}
+
+	for _, id := range cur.MountTargetIDs {
+		ts.cfg.Logger.Info("deleting mount target", zap.String("mount-target-id", id))
+		_, err := ts.cfg.EFSAPI.DeleteMountTarget(&efs.DeleteMountTargetInput{
+			MountTargetId: aws.String(id),
+		})
+		if err != nil && !isEFSErrCode(err, efs.ErrCodeMountTargetNotFound) {
+			return fmt.Errorf("failed to delete mount target %q (%v)", id, err)
+		}
+	}
+
+	// the file system cannot be deleted until its mount targets are gone
+	retryStart := time.Now()
+	for time.Since(retryStart) < 10*time.Minute {
+		out, err := ts.cfg.EFSAPI.DescribeMountTargets(&efs.DescribeMountTargetsInput{
+			FileSystemId: aws.String(cur.FileSystemID),
+		})
+		if isEFSErrCode(err, efs.ErrCodeFileSystemNotFound) || (err == nil && len(out.MountTargets) == 0) {
+			ts.cfg.Logger.Info("deleted mount targets")
+			return nil
+		}
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe mount targets", zap.Error(err))
+		} else {
+			ts.cfg.Logger.Info("polled mount targets", zap.Int("remaining", len(out.MountTargets)))
+		}
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("delete mount targets aborted")
+		case <-time.After(10 * time.Second):
+		}
+	}
+	return errors.New("mount targets not deleted")
+}
+
+func isEFSErrCode(err error, code string) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == code
+}
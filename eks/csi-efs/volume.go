@@ -0,0 +1,525 @@
+package csiefs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/efs"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	provisionerName = "efs.csi.aws.com"
+
+	pvcName        = "csi-efs-pvc"
+	deploymentName = "csi-efs-writer"
+	readerPodName  = "csi-efs-reader"
+
+	mountPath = "/data"
+)
+
+var appLabels = map[string]string{
+	"app.kubernetes.io/name": deploymentName,
+}
+
+// createVolumeTest provisions a ReadWriteMany volume, writes a file per node
+// from Pods on different nodes, and reads all files from one of the nodes.
+func (ts *tester) createVolumeTest() error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEFS
+	if err := k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		cur.Namespace,
+	); err != nil {
+		return err
+	}
+	if err := ts.createStorageClass(); err != nil {
+		return err
+	}
+	if err := ts.createPVC(); err != nil {
+		return err
+	}
+	if err := ts.createDeployment(); err != nil {
+		return err
+	}
+	if err := ts.recordAccessPoint(); err != nil {
+		return err
+	}
+	return ts.checkConsistency()
+}
+
+// deleteVolumeTest deletes the test objects, and waits
+// for the driver to delete the access points.
+func (ts *tester) deleteVolumeTest() error {
+	var errs []string
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnCSIEFS.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete CSI EFS namespace (%v)", err))
+	}
+	if err := ts.waitAccessPointsDeleted(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteStorageClass(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// https://github.com/kubernetes-sigs/aws-efs-csi-driver/tree/master/examples/kubernetes/dynamic_provisioning
+func (ts *tester) createStorageClass() error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEFS
+	ts.cfg.Logger.Info("creating StorageClass", zap.String("name", cur.StorageClassName))
+	reclaimPolicy := v1.PersistentVolumeReclaimDelete
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		StorageV1().
+		StorageClasses().
+		Create(
+			ctx,
+			&storagev1.StorageClass{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "storage.k8s.io/v1",
+					Kind:       "StorageClass",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: cur.StorageClassName,
+				},
+				Provisioner: provisionerName,
+				Parameters: map[string]string{
+					"provisioningMode": "efs-ap",
+					"fileSystemId":     cur.FileSystemID,
+					"directoryPerms":   "700",
+				},
+				ReclaimPolicy: &reclaimPolicy,
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create StorageClass (%v)", err)
+	}
+	ts.cfg.Logger.Info("created StorageClass")
+	return nil
+}
+
+func (ts *tester) deleteStorageClass() error {
+	ts.cfg.Logger.Info("deleting StorageClass", zap.String("name", ts.cfg.EKSConfig.AddOnCSIEFS.StorageClassName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.K8SClient.KubernetesClientSet().
+		StorageV1().
+		StorageClasses().
+		Delete(ctx, ts.cfg.EKSConfig.AddOnCSIEFS.StorageClassName, metav1.DeleteOptions{})
+	cancel()
+	if err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to delete StorageClass (%v)", err)
+	}
+	ts.cfg.Logger.Info("deleted StorageClass")
+	return nil
+}
+
+func (ts *tester) createPVC() error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEFS
+	ts.cfg.Logger.Info("creating PersistentVolumeClaim", zap.String("name", pvcName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		PersistentVolumeClaims(cur.Namespace).
+		Create(
+			ctx,
+			&v1.PersistentVolumeClaim{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "PersistentVolumeClaim",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      pvcName,
+					Namespace: cur.Namespace,
+				},
+				Spec: v1.PersistentVolumeClaimSpec{
+					StorageClassName: aws_v2.String(cur.StorageClassName),
+					AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteMany},
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{
+							// EFS is elastic, but the field is required
+							v1.ResourceStorage: resource.MustParse("5Gi"),
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create PersistentVolumeClaim (%v)", err)
+	}
+	ts.cfg.Logger.Info("created PersistentVolumeClaim")
+	return nil
+}
+
+// createDeployment creates the writer Pods, one per node,
+// each writing its Pod name to a file named after its node.
+func (ts *tester) createDeployment() error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEFS
+	ts.cfg.Logger.Info("creating writer Deployment", zap.Int32("replicas", cur.DeploymentReplicas))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(cur.Namespace).
+		Create(
+			ctx,
+			&appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      deploymentName,
+					Namespace: cur.Namespace,
+					Labels:    appLabels,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: aws_v2.Int32(cur.DeploymentReplicas),
+					Selector: &metav1.LabelSelector{
+						MatchLabels: appLabels,
+					},
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: appLabels,
+						},
+						Spec: v1.PodSpec{
+							RestartPolicy: v1.RestartPolicyAlways,
+							Containers: []v1.Container{
+								{
+									Name:            deploymentName,
+									Image:           cur.PodImage,
+									ImagePullPolicy: v1.PullIfNotPresent,
+									Command: []string{
+										"/bin/sh",
+										"-c",
+										fmt.Sprintf("echo ${POD_NAME} > %s/${NODE_NAME} && while true; do sleep 30; done", mountPath),
+									},
+									Env: []v1.EnvVar{
+										{
+											Name: "POD_NAME",
+											ValueFrom: &v1.EnvVarSource{
+												FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.name"},
+											},
+										},
+										{
+											Name: "NODE_NAME",
+											ValueFrom: &v1.EnvVarSource{
+												FieldRef: &v1.ObjectFieldSelector{FieldPath: "spec.nodeName"},
+											},
+										},
+									},
+									VolumeMounts: []v1.VolumeMount{
+										{
+											Name:      "data",
+											MountPath: mountPath,
+										},
+									},
+								},
+							},
+							Volumes: []v1.Volume{
+								{
+									Name: "data",
+									VolumeSource: v1.VolumeSource{
+										PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+											ClaimName: pvcName,
+										},
+									},
+								},
+							},
+							// one writer per node
+							Affinity: &v1.Affinity{
+								PodAntiAffinity: &v1.PodAntiAffinity{
+									RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{
+										{
+											LabelSelector: &metav1.LabelSelector{
+												MatchLabels: appLabels,
+											},
+											TopologyKey: "kubernetes.io/hostname",
+										},
+									},
+								},
+							},
+							NodeSelector: map[string]string{
+								"kubernetes.io/os": "linux",
+							},
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create writer Deployment (%v)", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), cur.VolumeTestTimeout)
+	_, err = k8s_client.WaitForDeploymentCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.K8SClient,
+		10*time.Second,
+		5*time.Second,
+		cur.Namespace,
+		deploymentName,
+		cur.DeploymentReplicas,
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("writer Deployment not ready (%v)", err)
+	}
+	ts.cfg.Logger.Info("created writer Deployment")
+	return nil
+}
+
+// recordAccessPoint records the access point provisioned for the claim,
+// from the volume handle in the format "[FileSystemId]::[AccessPointId]".
+func (ts *tester) recordAccessPoint() error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEFS
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	pvc, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		PersistentVolumeClaims(cur.Namespace).
+		Get(ctx, pvcName, metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get PersistentVolumeClaim (%v)", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	pv, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		PersistentVolumes().
+		Get(ctx, pvc.Spec.VolumeName, metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get PersistentVolume %q (%v)", pvc.Spec.VolumeName, err)
+	}
+	if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != provisionerName {
+		return fmt.Errorf("PersistentVolume %q not provisioned by %q", pv.Name, provisionerName)
+	}
+	ss := strings.Split(pv.Spec.CSI.VolumeHandle, "::")
+	if len(ss) != 2 || ss[0] != cur.FileSystemID {
+		return fmt.Errorf("unexpected volume handle %q (expected file system %q)", pv.Spec.CSI.VolumeHandle, cur.FileSystemID)
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.AccessPointIDs = append(cur.AccessPointIDs, ss[1])
+	})
+	ts.cfg.Logger.Info("provisioned EFS access point",
+		zap.String("pv-name", pv.Name),
+		zap.String("access-point-id", ss[1]),
+	)
+	return nil
+}
+
+// checkConsistency reads the files written by all writers
+// from the node of one writer, to check that writes from
+// other nodes are visible.
+func (ts *tester) checkConsistency() error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEFS
+	pods, err := ts.cfg.K8SClient.ListPods(cur.Namespace, 100, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to list Pods (%v)", err)
+	}
+	expected := make(map[string]string)
+	for _, pod := range pods {
+		if pod.Labels["app.kubernetes.io/name"] != deploymentName || pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+		expected[pod.Spec.NodeName] = pod.Name
+	}
+	if len(expected) < 2 {
+		return fmt.Errorf("expected writers on at least 2 nodes, got %v", expected)
+	}
+	nodes := make([]string, 0, len(expected))
+	for node := range expected {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	ts.cfg.Logger.Info("creating reader Pod", zap.String("node-name", nodes[0]), zap.Strings("writer-nodes", nodes))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(cur.Namespace).
+		Create(
+			ctx,
+			&v1.Pod{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Pod",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      readerPodName,
+					Namespace: cur.Namespace,
+				},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					NodeName:      nodes[0],
+					Containers: []v1.Container{
+						{
+							Name:            readerPodName,
+							Image:           cur.PodImage,
+							ImagePullPolicy: v1.PullIfNotPresent,
+							Command: []string{
+								"/bin/sh",
+								"-c",
+								fmt.Sprintf("for f in %s/*; do echo \"$(basename $f)=$(cat $f)\"; done", mountPath),
+							},
+							VolumeMounts: []v1.VolumeMount{
+								{
+									Name:      "data",
+									MountPath: mountPath,
+								},
+							},
+						},
+					},
+					Volumes: []v1.Volume{
+						{
+							Name: "data",
+							VolumeSource: v1.VolumeSource{
+								PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+									ClaimName: pvcName,
+								},
+							},
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create reader Pod (%v)", err)
+	}
+
+	retryStart := time.Now()
+	for time.Since(retryStart) < cur.VolumeTestTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait for reader Pod aborted")
+		case <-time.After(5 * time.Second):
+		}
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		pod, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Pods(cur.Namespace).
+			Get(ctx, readerPodName, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get reader Pod", zap.Error(err))
+			continue
+		}
+		ts.cfg.Logger.Info("polled reader Pod", zap.String("phase", string(pod.Status.Phase)))
+		if pod.Status.Phase == v1.PodFailed {
+			return errors.New("reader Pod failed")
+		}
+		if pod.Status.Phase != v1.PodSucceeded {
+			continue
+		}
+
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		b, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Pods(cur.Namespace).
+			GetLogs(readerPodName, &v1.PodLogOptions{}).
+			DoRaw(ctx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to get reader Pod logs (%v)", err)
+		}
+		out := string(b)
+		fmt.Fprintf(ts.cfg.LogWriter, "\nreader Pod output on %q:\n%s\n", nodes[0], out)
+		return checkReaderOutput(expected, out)
+	}
+	return fmt.Errorf("reader Pod not succeeded within %v", cur.VolumeTestTimeout)
+}
+
+// checkReaderOutput checks the "[node name]=[pod name]" lines
+// have the Pod name written from each node.
+func checkReaderOutput(expected map[string]string, out string) error {
+	found := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		ss := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(ss) != 2 {
+			continue
+		}
+		found[ss[0]] = ss[1]
+	}
+	for node, pod := range expected {
+		if found[node] != pod {
+			return fmt.Errorf("write from %q on node %q not visible (found %q)", pod, node, found[node])
+		}
+	}
+	return nil
+}
+
+func (ts *tester) waitAccessPointsDeleted() error {
+	cur := ts.cfg.EKSConfig.AddOnCSIEFS
+	if len(cur.AccessPointIDs) == 0 || cur.FileSystemID == "" {
+		return nil
+	}
+
+	ts.cfg.Logger.Info("waiting for access points deletion", zap.Strings("access-point-ids", cur.AccessPointIDs))
+	retryStart := time.Now()
+	for time.Since(retryStart) < cur.VolumeTestTimeout {
+		out, err := ts.cfg.EFSAPI.DescribeAccessPoints(&efs.DescribeAccessPointsInput{
+			FileSystemId: aws.String(cur.FileSystemID),
+		})
+		if isEFSErrCode(err, efs.ErrCodeFileSystemNotFound) {
+			return nil
+		}
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe access points", zap.Error(err))
+		} else {
+			existing := make(map[string]struct{})
+			for _, ap := range out.AccessPoints {
+				existing[aws.StringValue(ap.AccessPointId)] = struct{}{}
+			}
+			remaining := 0
+			for _, id := range cur.AccessPointIDs {
+				if _, ok := existing[id]; ok {
+					remaining++
+				}
+			}
+			ts.cfg.Logger.Info("polled access points", zap.Int("remaining", remaining))
+			if remaining == 0 {
+				ts.cfg.Logger.Info("deleted access points")
+				return nil
+			}
+		}
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait for access points deletion aborted")
+		case <-time.After(10 * time.Second):
+		}
+	}
+	return fmt.Errorf("access points %q not deleted within %v", cur.AccessPointIDs, cur.VolumeTestTimeout)
+}
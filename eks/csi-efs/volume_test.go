@@ -0,0 +1,29 @@
+package csiefs
+
+import "testing"
+
+func Test_checkReaderOutput(t *testing.T) {
+	expected := map[string]string{
+		"ip-192-168-1-10.us-west-2.compute.internal": "csi-efs-writer-7d4b9-abcde",
+		"ip-192-168-2-20.us-west-2.compute.internal": "csi-efs-writer-7d4b9-fghij",
+	}
+	out := `ip-192-168-1-10.us-west-2.compute.internal=csi-efs-writer-7d4b9-abcde
+ip-192-168-2-20.us-west-2.compute.internal=csi-efs-writer-7d4b9-fghij
+`
+	if err := checkReaderOutput(expected, out); err != nil {
+		t.Fatal(err)
+	}
+
+	out = `ip-192-168-1-10.us-west-2.compute.internal=csi-efs-writer-7d4b9-abcde
+`
+	if err := checkReaderOutput(expected, out); err == nil {
+		t.Fatal("expected missing write error")
+	}
+
+	out = `ip-192-168-1-10.us-west-2.compute.internal=csi-efs-writer-7d4b9-abcde
+ip-192-168-2-20.us-west-2.compute.internal=csi-efs-writer-old
+`
+	if err := checkReaderOutput(expected, out); err == nil {
+		t.Fatal("expected stale write error")
+	}
+}
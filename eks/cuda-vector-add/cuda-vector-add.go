@@ -45,6 +45,21 @@ func New(cfg Config) eks_tester.Tester {
 	return &tester{cfg: cfg}
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "cuda-vector-add",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+			}), cfg.EKSConfig.IsEnabledAddOnCUDAVectorAdd)
+		},
+	})
+}
+
 type tester struct {
 	cfg Config
 }
@@ -59,13 +74,15 @@ func (ts *tester) Create() (err error) {
 		return nil
 	}
 
-	ts.cfg.EKSConfig.AddOnCUDAVectorAdd.Created = true
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnCUDAVectorAdd.Created = true
+	})
 	createStart := time.Now()
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnCUDAVectorAdd.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnCUDAVectorAdd.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
 	if err = k8s_client.CreateNamespace(
@@ -102,7 +119,7 @@ func (ts *tester) createPod() (err error) {
 				{
 					Name: appName,
 					// https://github.com/kubernetes/kubernetes/blob/v1.7.11/test/images/nvidia-cuda/Dockerfile
-					Image: "k8s.gcr.io/cuda-vector-add:v0.1",
+					Image: ts.cfg.EKSConfig.Image("k8s.gcr.io/cuda-vector-add:v0.1"),
 					Resources: v1.ResourceRequirements{
 						Limits: map[v1.ResourceName]resource.Quantity{
 							v1.ResourceName("nvidia.com/gpu"): resource.MustParse("1"),
@@ -269,8 +286,9 @@ func (ts *tester) Delete() error {
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnCUDAVectorAdd.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnCUDAVectorAdd.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
 	}()
 
 	var errs []string
@@ -308,7 +326,8 @@ func (ts *tester) Delete() error {
 		return errors.New(strings.Join(errs, ", "))
 	}
 
-	ts.cfg.EKSConfig.AddOnCUDAVectorAdd.Created = false
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnCUDAVectorAdd.Created = false
+	})
 	return nil
 }
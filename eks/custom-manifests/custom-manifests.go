@@ -0,0 +1,323 @@
+// Package custommanifests applies the user-provided Kubernetes YAML manifests
+// with server-side apply, waits for the applied workloads to be ready,
+// and deletes the applied objects on teardown.
+package custommanifests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
+	"github.com/aws/aws-k8s-tester/pkg/httputil"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"go.uber.org/zap"
+	"k8s.io/utils/exec"
+)
+
+// Config defines custom manifests configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+	S3API     s3iface.S3API
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new custom manifests tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "custom-manifests",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				S3API:     cfg.S3API,
+			}), cfg.EKSConfig.IsEnabledAddOnCustomManifests)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnCustomManifests() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnCustomManifests.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnCustomManifests.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnCustomManifests.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	cur := ts.cfg.EKSConfig.AddOnCustomManifests
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		cur.Namespace,
+	); err != nil {
+		return err
+	}
+
+	dir := filepath.Join(filepath.Dir(ts.cfg.EKSConfig.ConfigPath), ts.cfg.EKSConfig.Name+"-custom-manifests")
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create custom manifests directory (%v)", err)
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.Objects = nil
+	})
+	for i, src := range cur.Sources {
+		fpath := filepath.Join(dir, fmt.Sprintf("%02d.yaml", i))
+		if err = ts.fetch(src, fpath); err != nil {
+			return err
+		}
+		d, err := ioutil.ReadFile(fpath)
+		if err != nil {
+			return err
+		}
+		objs, err := parseObjects(src, d)
+		if err != nil {
+			return err
+		}
+		if err = ts.apply(src, fpath); err != nil {
+			return err
+		}
+		// record before the waits, to delete on failed readiness
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.Objects = append(cur.Objects, objs...)
+		})
+	}
+
+	return ts.waitReady()
+}
+
+// fetch copies the manifest source to the local file.
+func (ts *tester) fetch(src string, fpath string) error {
+	ts.cfg.Logger.Info("fetching manifest", zap.String("source", src), zap.String("path", fpath))
+	switch {
+	case strings.HasPrefix(src, "http://"), strings.HasPrefix(src, "https://"):
+		d, err := httputil.Read(ts.cfg.Logger, ts.cfg.LogWriter, src)
+		if err != nil {
+			return fmt.Errorf("failed to download manifest %q (%v)", src, err)
+		}
+		return ioutil.WriteFile(fpath, d, 0600)
+	case strings.HasPrefix(src, "s3://"):
+		bucket, key, err := eksconfig.ParseS3URL(src)
+		if err != nil {
+			return err
+		}
+		if err = aws_s3.Download(ts.cfg.Logger, ts.cfg.S3API, bucket, key, fpath, aws_s3.WithOverwrite(true)); err != nil {
+			return fmt.Errorf("failed to download manifest %q (%v)", src, err)
+		}
+		return nil
+	}
+	d, err := ioutil.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest %q (%v)", src, err)
+	}
+	return ioutil.WriteFile(fpath, d, 0600)
+}
+
+// apply server-side applies the manifest, defaulting the namespaced
+// objects without one to "AddOnCustomManifests.Namespace".
+func (ts *tester) apply(src string, fpath string) (err error) {
+	cur := ts.cfg.EKSConfig.AddOnCustomManifests
+	args := []string{
+		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+		"apply",
+		"--server-side",
+		"--field-manager=" + cur.FieldManager,
+		"--namespace=" + cur.Namespace,
+		"--filename=" + fpath,
+	}
+	if cur.ForceConflicts {
+		args = append(args, "--force-conflicts")
+	}
+
+	ts.cfg.Logger.Info("applying manifest", zap.String("source", src))
+	var output []byte
+	// the webhooks or the CRDs applied by the previous manifests might not be ready yet
+	waitDur := 5 * time.Minute
+	retryStart := time.Now()
+	for time.Since(retryStart) < waitDur {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		output, err = exec.New().CommandContext(ctx, ts.cfg.EKSConfig.KubectlPath, args...).CombinedOutput()
+		cancel()
+		fmt.Fprintf(ts.cfg.LogWriter, "\n\"kubectl apply\" output:\n%s\n", string(output))
+		if err == nil {
+			break
+		}
+		ts.cfg.Logger.Warn("apply manifest failed", zap.String("source", src), zap.Error(err))
+
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("apply manifest aborted")
+		case <-time.After(5 * time.Second):
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("'kubectl apply' %q failed %v (output %q)", src, err, string(output))
+	}
+
+	ts.cfg.Logger.Info("applied manifest", zap.String("source", src))
+	return nil
+}
+
+// waitReady waits for the rollouts and the Job completions
+// of the applied objects, bounded by "ReadinessTimeout" in total.
+func (ts *tester) waitReady() error {
+	cur := ts.cfg.EKSConfig.AddOnCustomManifests
+	deadline := time.Now().Add(cur.ReadinessTimeout)
+	for _, obj := range cur.Objects {
+		var args []string
+		switch {
+		case cur.WaitRollout && isRolloutKind(obj):
+			args = []string{"rollout", "status"}
+		case cur.WaitJobComplete && obj.Kind == "Job" && apiGroup(obj.APIVersion) == "batch":
+			args = []string{"wait", "--for=condition=complete"}
+		default:
+			continue
+		}
+
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("readiness wait aborted")
+		default:
+		}
+		timeout := time.Until(deadline)
+		if timeout <= 0 {
+			return fmt.Errorf("readiness timeout %s exceeded before %s", cur.ReadinessTimeoutString, resourceRef(obj))
+		}
+		args = append([]string{"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath}, args...)
+		args = append(args,
+			"--namespace="+objectNamespace(cur, obj),
+			"--timeout="+timeout.Round(time.Second).String(),
+			resourceRef(obj),
+		)
+
+		ts.cfg.Logger.Info("waiting for object", zap.String("object", resourceRef(obj)), zap.String("timeout", timeout.String()))
+		ctx, cancel := context.WithTimeout(context.Background(), timeout+time.Minute)
+		output, err := exec.New().CommandContext(ctx, ts.cfg.EKSConfig.KubectlPath, args...).CombinedOutput()
+		cancel()
+		fmt.Fprintf(ts.cfg.LogWriter, "\n\"kubectl %s\" output:\n%s\n", args[1], string(output))
+		if err != nil {
+			return fmt.Errorf("%s not ready (%v, output %q)", resourceRef(obj), err, string(output))
+		}
+	}
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnCustomManifests() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnCustomManifests.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnCustomManifests.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	cur := ts.cfg.EKSConfig.AddOnCustomManifests
+	var errs []string
+	remaining := make([]eksconfig.CustomManifestObject, 0)
+	// reverse order, to delete the custom resources before their CRDs
+	for i := len(cur.Objects) - 1; i >= 0; i-- {
+		obj := cur.Objects[i]
+		if err := ts.deleteObject(obj); err != nil {
+			errs = append(errs, err.Error())
+			remaining = append([]eksconfig.CustomManifestObject{obj}, remaining...)
+		}
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.Objects = remaining
+	})
+
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		cur.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete custom manifests namespace (%v)", err))
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnCustomManifests.Created = false
+	})
+	return nil
+}
+
+func (ts *tester) deleteObject(obj eksconfig.CustomManifestObject) error {
+	ref := resourceRef(obj)
+	ts.cfg.Logger.Info("deleting object", zap.String("object", ref), zap.String("namespace", obj.Namespace))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	output, err := exec.New().CommandContext(
+		ctx,
+		ts.cfg.EKSConfig.KubectlPath,
+		"--kubeconfig="+ts.cfg.EKSConfig.KubeConfigPath,
+		"delete",
+		"--ignore-not-found",
+		"--namespace="+objectNamespace(ts.cfg.EKSConfig.AddOnCustomManifests, obj),
+		ref,
+	).CombinedOutput()
+	cancel()
+	out := string(output)
+	fmt.Fprintf(ts.cfg.LogWriter, "\n\"kubectl delete\" output:\n%s\n", out)
+	// the CRD might have been deleted with its custom resources
+	if err != nil && !strings.Contains(out, "the server doesn't have a resource type") {
+		return fmt.Errorf("'kubectl delete' %s failed %v (output %q)", ref, err, out)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package custommanifests
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// parseObjects returns the objects in the multi-document YAML or JSON
+// manifest, in order, expanding the "List" kinds.
+func parseObjects(src string, d []byte) (objs []eksconfig.CustomManifestObject, err error) {
+	dec := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(d), 4096)
+	for {
+		var raw map[string]interface{}
+		if err = dec.Decode(&raw); err != nil {
+			if err == io.EOF {
+				return objs, nil
+			}
+			return nil, fmt.Errorf("failed to parse manifest %q (%v)", src, err)
+		}
+		if len(raw) == 0 {
+			// empty document (e.g. "---" at the end)
+			continue
+		}
+		u := &unstructured.Unstructured{Object: raw}
+		if !u.IsList() {
+			obj, err := toObject(src, u)
+			if err != nil {
+				return nil, err
+			}
+			objs = append(objs, obj)
+			continue
+		}
+		ul, err := u.ToList()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse manifest %q list (%v)", src, err)
+		}
+		if err = ul.EachListItem(func(ro runtime.Object) error {
+			obj, err := toObject(src, ro.(*unstructured.Unstructured))
+			if err != nil {
+				return err
+			}
+			objs = append(objs, obj)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func toObject(src string, u *unstructured.Unstructured) (eksconfig.CustomManifestObject, error) {
+	obj := eksconfig.CustomManifestObject{
+		Source:     src,
+		APIVersion: u.GetAPIVersion(),
+		Kind:       u.GetKind(),
+		Namespace:  u.GetNamespace(),
+		Name:       u.GetName(),
+	}
+	if obj.APIVersion == "" || obj.Kind == "" || obj.Name == "" {
+		return eksconfig.CustomManifestObject{}, fmt.Errorf("manifest %q has an object without apiVersion, kind, or name (%+v)", src, obj)
+	}
+	return obj, nil
+}
+
+// resourceRef returns the fully-qualified "kubectl" resource reference
+// (e.g. "deployment.v1.apps/name", "configmap/name").
+func resourceRef(obj eksconfig.CustomManifestObject) string {
+	kind := strings.ToLower(obj.Kind)
+	group := apiGroup(obj.APIVersion)
+	if group == "" {
+		return kind + "/" + obj.Name
+	}
+	version := obj.APIVersion[strings.Index(obj.APIVersion, "/")+1:]
+	return kind + "." + version + "." + group + "/" + obj.Name
+}
+
+// apiGroup returns the API group of the "apiVersion", empty for the core group.
+func apiGroup(apiVersion string) string {
+	if idx := strings.Index(apiVersion, "/"); idx > 0 {
+		return apiVersion[:idx]
+	}
+	return ""
+}
+
+func isRolloutKind(obj eksconfig.CustomManifestObject) bool {
+	if apiGroup(obj.APIVersion) != "apps" {
+		return false
+	}
+	switch obj.Kind {
+	case "Deployment", "DaemonSet", "StatefulSet":
+		return true
+	}
+	return false
+}
+
+// objectNamespace returns the namespace of the object, defaulting to
+// "AddOnCustomManifests.Namespace" (ignored for the cluster-scoped objects).
+func objectNamespace(cur *eksconfig.AddOnCustomManifests, obj eksconfig.CustomManifestObject) string {
+	if obj.Namespace != "" {
+		return obj.Namespace
+	}
+	return cur.Namespace
+}
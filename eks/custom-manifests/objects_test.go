@@ -0,0 +1,74 @@
+package custommanifests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+)
+
+func TestParseObjects(t *testing.T) {
+	d := []byte(`---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: app
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: rbac.authorization.k8s.io/v1
+  kind: ClusterRole
+  metadata:
+    name: app-reader
+- apiVersion: batch/v1
+  kind: Job
+  metadata:
+    name: app-migrate
+---
+`)
+	objs, err := parseObjects("app.yaml", d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []eksconfig.CustomManifestObject{
+		{Source: "app.yaml", APIVersion: "v1", Kind: "ConfigMap", Name: "app-config"},
+		{Source: "app.yaml", APIVersion: "apps/v1", Kind: "Deployment", Namespace: "app", Name: "app"},
+		{Source: "app.yaml", APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "app-reader"},
+		{Source: "app.yaml", APIVersion: "batch/v1", Kind: "Job", Name: "app-migrate"},
+	}
+	if !reflect.DeepEqual(objs, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, objs)
+	}
+
+	if _, err = parseObjects("bad.yaml", []byte("apiVersion: v1\nkind: ConfigMap\n")); err == nil {
+		t.Fatal("expected error for object without name")
+	}
+}
+
+func TestResourceRef(t *testing.T) {
+	tt := []struct {
+		obj     eksconfig.CustomManifestObject
+		ref     string
+		rollout bool
+	}{
+		{eksconfig.CustomManifestObject{APIVersion: "v1", Kind: "ConfigMap", Name: "a"}, "configmap/a", false},
+		{eksconfig.CustomManifestObject{APIVersion: "apps/v1", Kind: "Deployment", Name: "a"}, "deployment.v1.apps/a", true},
+		{eksconfig.CustomManifestObject{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "a"}, "replicaset.v1.apps/a", false},
+		{eksconfig.CustomManifestObject{APIVersion: "karpenter.sh/v1alpha5", Kind: "Provisioner", Name: "a"}, "provisioner.v1alpha5.karpenter.sh/a", false},
+	}
+	for i, tv := range tt {
+		if ref := resourceRef(tv.obj); ref != tv.ref {
+			t.Fatalf("#%d: expected %q, got %q", i, tv.ref, ref)
+		}
+		if rollout := isRolloutKind(tv.obj); rollout != tv.rollout {
+			t.Fatalf("#%d: expected rollout %v, got %v", i, tv.rollout, rollout)
+		}
+	}
+}
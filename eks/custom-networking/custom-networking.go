@@ -0,0 +1,181 @@
+// Package customnetworking tests VPC CNI custom networking: it attaches
+// a secondary VPC CIDR block, creates an ENIConfig for each availability
+// zone, enables "AWS_VPC_K8S_CNI_CUSTOM_NETWORK_CFG" on "aws-node",
+// replaces the nodes, and verifies Pods get IPs from the secondary CIDR.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/cni-custom-network.html
+// ref. https://github.com/aws/amazon-vpc-cni-k8s#eniconfig
+package customnetworking
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"go.uber.org/zap"
+)
+
+// Config defines VPC CNI custom networking configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+
+	EKSAPI   eksiface.EKSAPI
+	EC2APIV2 *aws_ec2_v2.Client
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new VPC CNI custom networking tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:      "custom-networking",
+		Exclusive: true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				EKSAPI:    cfg.EKSAPI,
+				EC2APIV2:  cfg.EC2APIV2,
+			}), cfg.EKSConfig.IsEnabledAddOnCustomNetworking)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnCustomNetworking() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnCustomNetworking.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnCustomNetworking.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnCustomNetworking.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	if err = ts.associateCIDR(); err != nil {
+		return err
+	}
+	if err = ts.createSubnets(); err != nil {
+		return err
+	}
+	if err = ts.createENIConfigs(); err != nil {
+		return err
+	}
+	if err = ts.setCustomNetworkConfig(true); err != nil {
+		return err
+	}
+	if err = ts.cycleNodes(); err != nil {
+		return err
+	}
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnCustomNetworking.Namespace,
+	); err != nil {
+		return err
+	}
+	if err = ts.createDeployment(); err != nil {
+		return err
+	}
+	if err = ts.checkPodIPs(); err != nil {
+		return err
+	}
+
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnCustomNetworking() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnCustomNetworking.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnCustomNetworking.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnCustomNetworking.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete custom networking namespace (%v)", err))
+	}
+
+	// the secondary subnets cannot be deleted while the node ENIs are in use,
+	// so replace the nodes again with the custom network configuration disabled
+	if err := ts.setCustomNetworkConfig(false); err != nil {
+		errs = append(errs, err.Error())
+	} else if err = ts.cycleNodes(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteENIConfigs(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteSubnets(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.disassociateCIDR(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnCustomNetworking.Created = false
+	})
+	return nil
+}
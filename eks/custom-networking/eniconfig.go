@@ -0,0 +1,189 @@
+package customnetworking
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ENIConfig names match the zone label on the nodes,
+// so that each node picks the Pod subnet in its zone.
+const (
+	envCustomNetworkConfig = "AWS_VPC_K8S_CNI_CUSTOM_NETWORK_CFG"
+	envENIConfigLabelDef   = "ENI_CONFIG_LABEL_DEF"
+	zoneLabel              = "topology.kubernetes.io/zone"
+)
+
+const eniConfigTemplate = `{{ range $az, $subnet := .SubnetIDs }}---
+apiVersion: crd.k8s.amazonaws.com/v1alpha1
+kind: ENIConfig
+metadata:
+  name: {{ $az }}
+spec:
+  subnet: {{ $subnet }}
+  securityGroups:
+{{- range $.SecurityGroupIDs }}
+  - {{ . }}
+{{- end }}
+{{ end }}`
+
+type eniConfigs struct {
+	SubnetIDs        map[string]string
+	SecurityGroupIDs []string
+}
+
+func (ts *tester) renderENIConfigs() (string, error) {
+	// Pod ENIs join the node security groups
+	var sgIDs []string
+	out, err := ts.cfg.EKSAPI.DescribeCluster(&eks.DescribeClusterInput{
+		Name: aws.String(ts.cfg.EKSConfig.Name),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe cluster (%v)", err)
+	}
+	if out.Cluster.ResourcesVpcConfig != nil && aws.StringValue(out.Cluster.ResourcesVpcConfig.ClusterSecurityGroupId) != "" {
+		sgIDs = append(sgIDs, aws.StringValue(out.Cluster.ResourcesVpcConfig.ClusterSecurityGroupId))
+	}
+	if ts.cfg.EKSConfig.VPC.NodeGroupSecurityGroupID != "" {
+		sgIDs = append(sgIDs, ts.cfg.EKSConfig.VPC.NodeGroupSecurityGroupID)
+	}
+	if len(sgIDs) == 0 {
+		return "", errors.New("no node security group found for ENIConfig")
+	}
+	sort.Strings(sgIDs)
+
+	tpl := template.Must(template.New("eniConfigTemplate").Parse(eniConfigTemplate))
+	buf := bytes.NewBuffer(nil)
+	if err = tpl.Execute(buf, eniConfigs{
+		SubnetIDs:        ts.cfg.EKSConfig.AddOnCustomNetworking.SubnetIDs,
+		SecurityGroupIDs: sgIDs,
+	}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (ts *tester) createENIConfigs() error {
+	ts.cfg.Logger.Info("creating ENIConfigs")
+	data, err := ts.renderENIConfigs()
+	if err != nil {
+		return err
+	}
+	if err = ts.cfg.K8SClient.Apply(data); err != nil {
+		return fmt.Errorf("failed to create ENIConfigs (%v)", err)
+	}
+	ts.cfg.Logger.Info("created ENIConfigs")
+	return nil
+}
+
+func (ts *tester) deleteENIConfigs() error {
+	ts.cfg.Logger.Info("deleting ENIConfigs")
+	data, err := ts.renderENIConfigs()
+	if err != nil {
+		return err
+	}
+	if err = ts.cfg.K8SClient.Delete(data); err != nil {
+		return fmt.Errorf("failed to delete ENIConfigs (%v)", err)
+	}
+	ts.cfg.Logger.Info("deleted ENIConfigs")
+	return nil
+}
+
+// setCustomNetworkConfig updates the "aws-node" environment variables,
+// and waits for the DaemonSet rollout.
+func (ts *tester) setCustomNetworkConfig(enable bool) error {
+	ts.cfg.Logger.Info("updating aws-node custom network config", zap.Bool("enable", enable))
+	dsCli := ts.cfg.K8SClient.KubernetesClientSet().AppsV1().DaemonSets("kube-system")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	ds, err := dsCli.Get(ctx, "aws-node", metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get aws-node DaemonSet (%v)", err)
+	}
+
+	found := false
+	for i := range ds.Spec.Template.Spec.Containers {
+		c := &ds.Spec.Template.Spec.Containers[i]
+		if c.Name != "aws-node" {
+			continue
+		}
+		found = true
+		c.Env = setEnv(c.Env, envCustomNetworkConfig, strconv.FormatBool(enable))
+		if enable {
+			c.Env = setEnv(c.Env, envENIConfigLabelDef, zoneLabel)
+		} else {
+			c.Env = unsetEnv(c.Env, envENIConfigLabelDef)
+		}
+	}
+	if !found {
+		return errors.New("aws-node container not found in aws-node DaemonSet")
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = dsCli.Update(ctx, ds, metav1.UpdateOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to update aws-node DaemonSet (%v)", err)
+	}
+
+	waitStart := time.Now()
+	for time.Since(waitStart) < 10*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("aws-node rollout aborted")
+		case <-time.After(10 * time.Second):
+		}
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		ds, err = dsCli.Get(ctx, "aws-node", metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get aws-node DaemonSet", zap.Error(err))
+			continue
+		}
+		st := ds.Status
+		ts.cfg.Logger.Info("polled aws-node DaemonSet",
+			zap.Int32("desired", st.DesiredNumberScheduled),
+			zap.Int32("updated", st.UpdatedNumberScheduled),
+			zap.Int32("available", st.NumberAvailable),
+		)
+		if st.ObservedGeneration >= ds.Generation &&
+			st.UpdatedNumberScheduled == st.DesiredNumberScheduled &&
+			st.NumberAvailable == st.DesiredNumberScheduled {
+			ts.cfg.Logger.Info("updated aws-node custom network config", zap.Bool("enable", enable))
+			return nil
+		}
+	}
+	return errors.New("aws-node DaemonSet not rolled out")
+}
+
+func setEnv(envs []v1.EnvVar, name string, value string) []v1.EnvVar {
+	for i := range envs {
+		if envs[i].Name == name {
+			envs[i].Value = value
+			envs[i].ValueFrom = nil
+			return envs
+		}
+	}
+	return append(envs, v1.EnvVar{Name: name, Value: value})
+}
+
+func unsetEnv(envs []v1.EnvVar, name string) []v1.EnvVar {
+	out := envs[:0]
+	for _, env := range envs {
+		if env.Name != name {
+			out = append(out, env)
+		}
+	}
+	return out
+}
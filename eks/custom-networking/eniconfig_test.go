@@ -0,0 +1,42 @@
+package customnetworking
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+)
+
+func Test_eniConfigTemplate(t *testing.T) {
+	tpl := template.Must(template.New("eniConfigTemplate").Parse(eniConfigTemplate))
+	buf := bytes.NewBuffer(nil)
+	if err := tpl.Execute(buf, eniConfigs{
+		SubnetIDs:        map[string]string{"us-west-2b": "subnet-2", "us-west-2a": "subnet-1"},
+		SecurityGroupIDs: []string{"sg-1", "sg-2"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `---
+apiVersion: crd.k8s.amazonaws.com/v1alpha1
+kind: ENIConfig
+metadata:
+  name: us-west-2a
+spec:
+  subnet: subnet-1
+  securityGroups:
+  - sg-1
+  - sg-2
+---
+apiVersion: crd.k8s.amazonaws.com/v1alpha1
+kind: ENIConfig
+metadata:
+  name: us-west-2b
+spec:
+  subnet: subnet-2
+  securityGroups:
+  - sg-1
+  - sg-2
+`
+	if buf.String() != expected {
+		t.Fatalf("unexpected ENIConfigs:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,106 @@
+package customnetworking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/exec"
+)
+
+// cycleNodes drains and terminates all Linux nodes, and waits for
+// the node groups to replace them, since the custom network configuration
+// only applies to the ENIs attached after "aws-node" is updated.
+func (ts *tester) cycleNodes() error {
+	cur := ts.cfg.EKSConfig.AddOnCustomNetworking
+	nodeCli := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Nodes()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	nodes, err := nodeCli.List(ctx, metav1.ListOptions{LabelSelector: "kubernetes.io/os=linux"})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to list nodes (%v)", err)
+	}
+	if len(nodes.Items) == 0 {
+		return errors.New("no Linux node found")
+	}
+
+	old := make(map[string]struct{})
+	var instanceIDs []string
+	for _, node := range nodes.Items {
+		old[node.Name] = struct{}{}
+		instanceIDs = append(instanceIDs, node.Spec.ProviderID[strings.LastIndex(node.Spec.ProviderID, "/")+1:])
+	}
+	ts.cfg.Logger.Info("cycling nodes", zap.Int("nodes", len(old)), zap.Strings("instance-ids", instanceIDs))
+
+	for name := range old {
+		ts.cfg.Logger.Info("draining node", zap.String("node", name))
+		args := []string{
+			"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+			"drain",
+			name,
+			"--ignore-daemonsets",
+			"--delete-emptydir-data",
+			"--force",
+			"--timeout=5m",
+		}
+		ctx, cancel = context.WithTimeout(context.Background(), 6*time.Minute)
+		output, err := exec.New().CommandContext(ctx, ts.cfg.EKSConfig.KubectlPath, args...).CombinedOutput()
+		cancel()
+		if err != nil {
+			// terminate anyway; pods are rescheduled on the new nodes
+			ts.cfg.Logger.Warn("failed to drain node", zap.String("node", name), zap.String("output", string(output)), zap.Error(err))
+		}
+	}
+
+	_, err = ts.cfg.EC2APIV2.TerminateInstances(
+		context.Background(),
+		&aws_ec2_v2.TerminateInstancesInput{InstanceIds: instanceIDs},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to terminate instances (%v)", err)
+	}
+
+	waitStart := time.Now()
+	for time.Since(waitStart) < cur.NodeCycleTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("node cycle aborted")
+		case <-time.After(30 * time.Second):
+		}
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		nodes, err = nodeCli.List(ctx, metav1.ListOptions{LabelSelector: "kubernetes.io/os=linux"})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list nodes", zap.Error(err))
+			continue
+		}
+		ready := 0
+		for _, node := range nodes.Items {
+			if _, ok := old[node.Name]; ok {
+				continue
+			}
+			for _, cond := range node.Status.Conditions {
+				if cond.Type == v1.NodeReady && cond.Status == v1.ConditionTrue {
+					ready++
+					break
+				}
+			}
+		}
+		ts.cfg.Logger.Info("polled replacement nodes",
+			zap.Int("ready", ready),
+			zap.Int("expected", len(old)),
+			zap.String("took", time.Since(waitStart).String()),
+		)
+		if ready >= len(old) {
+			ts.cfg.Logger.Info("cycled nodes")
+			return nil
+		}
+	}
+	return fmt.Errorf("replacement nodes not ready after %v", cur.NodeCycleTimeout)
+}
@@ -0,0 +1,142 @@
+package customnetworking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	deploymentName = "custom-networking-deployment"
+	appName        = "custom-networking"
+)
+
+func (ts *tester) createDeployment() error {
+	cur := ts.cfg.EKSConfig.AddOnCustomNetworking
+	ts.cfg.Logger.Info("creating Deployment", zap.Int32("replicas", cur.DeploymentReplicas))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(cur.Namespace).
+		Create(
+			ctx,
+			&appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      deploymentName,
+					Namespace: cur.Namespace,
+					Labels:    map[string]string{"app.kubernetes.io/name": appName},
+				},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: &cur.DeploymentReplicas,
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"app.kubernetes.io/name": appName},
+					},
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{"app.kubernetes.io/name": appName},
+						},
+						Spec: v1.PodSpec{
+							RestartPolicy: v1.RestartPolicyAlways,
+							Containers: []v1.Container{
+								{
+									Name:            appName,
+									Image:           cur.PodImage,
+									ImagePullPolicy: v1.PullIfNotPresent,
+									Command:         []string{"/bin/sh", "-c", "sleep 3600"},
+								},
+							},
+							NodeSelector: map[string]string{
+								"kubernetes.io/os": "linux",
+							},
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Deployment (%v)", err)
+	}
+	ts.cfg.Logger.Info("created Deployment")
+	return nil
+}
+
+// checkPodIPs waits for the Pods to be running, and checks
+// all Pod IPs are from the secondary CIDR block.
+func (ts *tester) checkPodIPs() error {
+	cur := ts.cfg.EKSConfig.AddOnCustomNetworking
+	waitStart := time.Now()
+	for time.Since(waitStart) < 10*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("Pod IP check aborted")
+		case <-time.After(10 * time.Second):
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		pods, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Pods(cur.Namespace).
+			List(ctx, metav1.ListOptions{LabelSelector: "app.kubernetes.io/name=" + appName})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list Pods", zap.Error(err))
+			continue
+		}
+		var ips []string
+		for _, pod := range pods.Items {
+			if pod.Status.Phase == v1.PodRunning && pod.Status.PodIP != "" {
+				ips = append(ips, pod.Status.PodIP)
+			}
+		}
+		ts.cfg.Logger.Info("polled Pods", zap.Int("running", len(ips)), zap.Int32("expected", cur.DeploymentReplicas))
+		if int32(len(ips)) < cur.DeploymentReplicas {
+			continue
+		}
+
+		outside, err := ipsOutside(ips, cur.SecondaryCIDR)
+		if err != nil {
+			return err
+		}
+		if len(outside) > 0 {
+			return fmt.Errorf("Pod IPs %v not within secondary CIDR %q", outside, cur.SecondaryCIDR)
+		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.PodIPs = ips
+		})
+		ts.cfg.Logger.Info("Pod IPs within secondary CIDR",
+			zap.String("secondary-cidr", cur.SecondaryCIDR),
+			zap.Strings("pod-ips", ips),
+		)
+		return nil
+	}
+	return errors.New("Pods not running")
+}
+
+// ipsOutside returns the IPs outside of the CIDR block.
+func ipsOutside(ips []string, cidr string) ([]string, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	var outside []string
+	for _, s := range ips {
+		ip := net.ParseIP(s)
+		if ip == nil || !ipNet.Contains(ip) {
+			outside = append(outside, s)
+		}
+	}
+	return outside, nil
+}
@@ -0,0 +1,19 @@
+package customnetworking
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ipsOutside(t *testing.T) {
+	outside, err := ipsOutside([]string{"100.64.3.4", "10.0.1.2", "100.64.255.1", "bad-ip"}, "100.64.0.0/16")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(outside, []string{"10.0.1.2", "bad-ip"}) {
+		t.Fatalf("unexpected outside IPs %v", outside)
+	}
+	if _, err = ipsOutside(nil, "100.64.0.0"); err == nil {
+		t.Fatal("expected CIDR parse error")
+	}
+}
@@ -0,0 +1,264 @@
+package customnetworking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	smithy "github.com/aws/smithy-go"
+	"go.uber.org/zap"
+)
+
+func (ts *tester) associateCIDR() error {
+	cur := ts.cfg.EKSConfig.AddOnCustomNetworking
+	if cur.CIDRAssociationID != "" {
+		ts.cfg.Logger.Info("secondary VPC CIDR block already associated", zap.String("association-id", cur.CIDRAssociationID))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("associating secondary VPC CIDR block", zap.String("cidr-block", cur.SecondaryCIDR))
+	out, err := ts.cfg.EC2APIV2.AssociateVpcCidrBlock(
+		context.Background(),
+		&aws_ec2_v2.AssociateVpcCidrBlockInput{
+			VpcId:     aws_v2.String(ts.cfg.EKSConfig.VPC.ID),
+			CidrBlock: aws_v2.String(cur.SecondaryCIDR),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to associate VPC CIDR block %q (%v)", cur.SecondaryCIDR, err)
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.CIDRAssociationID = aws_v2.ToString(out.CidrBlockAssociation.AssociationId)
+	})
+
+	retryStart := time.Now()
+	for time.Since(retryStart) < 5*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("associate VPC CIDR block aborted")
+		case <-time.After(5 * time.Second):
+		}
+		state, err := ts.describeCIDRState()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe VPC", zap.Error(err))
+			continue
+		}
+		ts.cfg.Logger.Info("polled VPC CIDR block", zap.String("state", string(state)))
+		switch state {
+		case aws_ec2_v2_types.VpcCidrBlockStateCodeAssociated:
+			ts.cfg.Logger.Info("associated secondary VPC CIDR block", zap.String("association-id", cur.CIDRAssociationID))
+			return nil
+		case aws_ec2_v2_types.VpcCidrBlockStateCodeFailed:
+			return fmt.Errorf("failed to associate VPC CIDR block %q", cur.SecondaryCIDR)
+		}
+	}
+	return fmt.Errorf("VPC CIDR block %q not associated", cur.SecondaryCIDR)
+}
+
+func (ts *tester) disassociateCIDR() error {
+	cur := ts.cfg.EKSConfig.AddOnCustomNetworking
+	if cur.CIDRAssociationID == "" {
+		return nil
+	}
+	if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[cur.CIDRAssociationID]; ok {
+		return nil
+	}
+
+	ts.cfg.Logger.Info("disassociating secondary VPC CIDR block", zap.String("association-id", cur.CIDRAssociationID))
+	_, err := ts.cfg.EC2APIV2.DisassociateVpcCidrBlock(
+		context.Background(),
+		&aws_ec2_v2.DisassociateVpcCidrBlockInput{
+			AssociationId: aws_v2.String(cur.CIDRAssociationID),
+		},
+	)
+	if err != nil && !isErrCode(err, "InvalidVpcCidrBlockAssociationID.NotFound") {
+		return fmt.Errorf("failed to disassociate VPC CIDR block %q (%v)", cur.CIDRAssociationID, err)
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.Status.DeletedResources[cur.CIDRAssociationID] = "AddOnCustomNetworking.CIDRAssociationID"
+	})
+	ts.cfg.Logger.Info("disassociated secondary VPC CIDR block")
+	return nil
+}
+
+func (ts *tester) describeCIDRState() (aws_ec2_v2_types.VpcCidrBlockStateCode, error) {
+	out, err := ts.cfg.EC2APIV2.DescribeVpcs(
+		context.Background(),
+		&aws_ec2_v2.DescribeVpcsInput{VpcIds: []string{ts.cfg.EKSConfig.VPC.ID}},
+	)
+	if err != nil {
+		return "", err
+	}
+	if len(out.Vpcs) != 1 {
+		return "", fmt.Errorf("VPC %q not found", ts.cfg.EKSConfig.VPC.ID)
+	}
+	for _, assoc := range out.Vpcs[0].CidrBlockAssociationSet {
+		if aws_v2.ToString(assoc.AssociationId) != ts.cfg.EKSConfig.AddOnCustomNetworking.CIDRAssociationID {
+			continue
+		}
+		if assoc.CidrBlockState == nil {
+			return "", nil
+		}
+		return assoc.CidrBlockState.State, nil
+	}
+	return "", fmt.Errorf("VPC CIDR block association %q not found", ts.cfg.EKSConfig.AddOnCustomNetworking.CIDRAssociationID)
+}
+
+// createSubnets creates a Pod subnet for each availability zone of the node subnets.
+func (ts *tester) createSubnets() error {
+	cur := ts.cfg.EKSConfig.AddOnCustomNetworking
+	sout, err := ts.cfg.EC2APIV2.DescribeSubnets(
+		context.Background(),
+		&aws_ec2_v2.DescribeSubnetsInput{SubnetIds: ts.cfg.EKSConfig.VPC.PublicSubnetIDs},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to describe node subnets (%v)", err)
+	}
+	azs := make(map[string]struct{})
+	for _, sub := range sout.Subnets {
+		azs[aws_v2.ToString(sub.AvailabilityZone)] = struct{}{}
+	}
+	zones := make([]string, 0, len(azs))
+	for az := range azs {
+		zones = append(zones, az)
+	}
+	sort.Strings(zones)
+	if len(zones) > len(cur.SubnetCIDRs) {
+		return fmt.Errorf("%d availability zones %v but only %d AddOnCustomNetworking.SubnetCIDRs", len(zones), zones, len(cur.SubnetCIDRs))
+	}
+
+	if cur.SubnetIDs == nil {
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.SubnetIDs = make(map[string]string)
+		})
+	}
+	for idx, az := range zones {
+		if id, ok := cur.SubnetIDs[az]; ok {
+			ts.cfg.Logger.Info("Pod subnet already created", zap.String("availability-zone", az), zap.String("subnet-id", id))
+			continue
+		}
+		name := fmt.Sprintf("%s-custom-networking-subnet-%d", ts.cfg.EKSConfig.Name, idx+1)
+		out, err := ts.cfg.EC2APIV2.CreateSubnet(
+			context.Background(),
+			&aws_ec2_v2.CreateSubnetInput{
+				VpcId:            aws_v2.String(ts.cfg.EKSConfig.VPC.ID),
+				AvailabilityZone: aws_v2.String(az),
+				CidrBlock:        aws_v2.String(cur.SubnetCIDRs[idx]),
+				TagSpecifications: []aws_ec2_v2_types.TagSpecification{
+					{
+						ResourceType: aws_ec2_v2_types.ResourceTypeSubnet,
+						Tags: []aws_ec2_v2_types.Tag{
+							{Key: aws_v2.String("Name"), Value: aws_v2.String(name)},
+						},
+					},
+				},
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create Pod subnet in %q (%v)", az, err)
+		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.SubnetIDs[az] = aws_v2.ToString(out.Subnet.SubnetId)
+		})
+		ts.cfg.Logger.Info("created Pod subnet",
+			zap.String("availability-zone", az),
+			zap.String("cidr-block", cur.SubnetCIDRs[idx]),
+			zap.String("subnet-id", cur.SubnetIDs[az]),
+		)
+	}
+	return nil
+}
+
+// deleteSubnets deletes the Pod subnets, after the network interfaces
+// in the subnets are released.
+func (ts *tester) deleteSubnets() error {
+	cur := ts.cfg.EKSConfig.AddOnCustomNetworking
+	var errs []string
+	for az, subnetID := range cur.SubnetIDs {
+		if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[subnetID]; ok {
+			continue
+		}
+		if err := ts.deleteENIs(subnetID); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		ts.cfg.Logger.Info("deleting Pod subnet", zap.String("availability-zone", az), zap.String("subnet-id", subnetID))
+		_, err := ts.cfg.EC2APIV2.DeleteSubnet(
+			context.Background(),
+			&aws_ec2_v2.DeleteSubnetInput{SubnetId: aws_v2.String(subnetID)},
+		)
+		if err != nil && !isErrCode(err, "InvalidSubnetID.NotFound") {
+			errs = append(errs, fmt.Sprintf("failed to delete Pod subnet %q (%v)", subnetID, err))
+			continue
+		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.Status.DeletedResources[subnetID] = "AddOnCustomNetworking.SubnetIDs"
+		})
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to delete Pod subnets (%v)", errs)
+	}
+	ts.cfg.Logger.Info("deleted Pod subnets")
+	return nil
+}
+
+// deleteENIs waits for the network interfaces in the subnet to be detached,
+// and deletes the ones left behind.
+func (ts *tester) deleteENIs(subnetID string) error {
+	retryStart := time.Now()
+	for time.Since(retryStart) < 10*time.Minute {
+		out, err := ts.cfg.EC2APIV2.DescribeNetworkInterfaces(
+			context.Background(),
+			&aws_ec2_v2.DescribeNetworkInterfacesInput{
+				Filters: []aws_ec2_v2_types.Filter{
+					{Name: aws_v2.String("subnet-id"), Values: []string{subnetID}},
+				},
+			},
+		)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe network interfaces", zap.Error(err))
+		} else {
+			if len(out.NetworkInterfaces) == 0 {
+				return nil
+			}
+			for _, eni := range out.NetworkInterfaces {
+				eniID := aws_v2.ToString(eni.NetworkInterfaceId)
+				ts.cfg.Logger.Info("found network interface in Pod subnet",
+					zap.String("subnet-id", subnetID),
+					zap.String("network-interface-id", eniID),
+					zap.String("status", string(eni.Status)),
+				)
+				if eni.Status != aws_ec2_v2_types.NetworkInterfaceStatusAvailable {
+					continue
+				}
+				_, err = ts.cfg.EC2APIV2.DeleteNetworkInterface(
+					context.Background(),
+					&aws_ec2_v2.DeleteNetworkInterfaceInput{NetworkInterfaceId: aws_v2.String(eniID)},
+				)
+				if err != nil && !isErrCode(err, "InvalidNetworkInterfaceID.NotFound") {
+					ts.cfg.Logger.Warn("failed to delete network interface", zap.String("network-interface-id", eniID), zap.Error(err))
+				}
+			}
+		}
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("delete network interfaces aborted")
+		case <-time.After(15 * time.Second):
+		}
+	}
+	return fmt.Errorf("network interfaces in Pod subnet %q not released", subnetID)
+}
+
+func isErrCode(err error, code string) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode() == code
+	}
+	return false
+}
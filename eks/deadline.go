@@ -0,0 +1,128 @@
+package eks
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// cancelGracePeriod is the time to wait for the cancelled creation
+// to return, before abandoning it and proceeding to the teardown.
+const cancelGracePeriod = 3 * time.Minute
+
+// startRunDeadline cancels the creation when "RunTimeout" expires,
+// and returns the function to stop the deadline.
+func (ts *Tester) startRunDeadline() (stop func()) {
+	if ts.cfg.RunTimeout <= 0 {
+		return func() {}
+	}
+	ts.lg.Info("starting run deadline", zap.Duration("run-timeout", ts.cfg.RunTimeout))
+	t := time.AfterFunc(ts.cfg.RunTimeout, func() {
+		ts.exceedDeadline(fmt.Sprintf("run timed out after %v", ts.cfg.RunTimeout))
+	})
+	return func() { t.Stop() }
+}
+
+// withTimeout returns the function that cancels the creation if "fn"
+// does not return within the timeout (zero to disable), or if the creation
+// is cancelled by the run deadline. The in-flight AWS waits are cancelled
+// by closing the stop channel, and "fn" is abandoned if it does not return
+// within the grace period.
+func (ts *Tester) withTimeout(name string, timeout time.Duration, fn func() error) func() error {
+	if timeout <= 0 && ts.cfg.RunTimeout <= 0 {
+		return fn
+	}
+	return func() error {
+		stopc := ts.stopChannel()
+		errc := make(chan error, 1)
+		go func() {
+			errc <- fn()
+		}()
+
+		var timec <-chan time.Time
+		if timeout > 0 {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			timec = timer.C
+		}
+		select {
+		case err := <-errc:
+			return err
+		case <-timec:
+			ts.exceedDeadline(fmt.Sprintf("%q timed out after %v", name, timeout))
+		case <-stopc:
+		}
+
+		select {
+		case err := <-errc:
+			ts.lg.Warn("cancelled creation returned", zap.String("name", name), zap.Error(err))
+		case <-time.After(cancelGracePeriod):
+			ts.lg.Warn("cancelled creation did not return; abandoning", zap.String("name", name), zap.Duration("grace-period", cancelGracePeriod))
+		}
+		if reason := ts.deadlineExceeded(); reason != "" {
+			return fmt.Errorf("%q cancelled (%s)", name, reason)
+		}
+		return fmt.Errorf("%q cancelled", name)
+	}
+}
+
+// exceedDeadline records the reason, and cancels the in-flight creation.
+func (ts *Tester) exceedDeadline(reason string) {
+	ts.deadlineMu.Lock()
+	if ts.deadlineReason == "" {
+		ts.deadlineReason = reason
+	}
+	ts.deadlineMu.Unlock()
+
+	ts.lg.Warn("deadline exceeded; cancelling creation", zap.String("reason", reason))
+	ts.stopCreation()
+}
+
+// stopCreation closes the stop channel to cancel the in-flight creation.
+func (ts *Tester) stopCreation() {
+	ts.stopMu.Lock()
+	defer ts.stopMu.Unlock()
+	ts.stopCreationChOnce.Do(func() { close(ts.stopCreationCh) })
+}
+
+// stopChannel returns the current stop channel, which is replaced
+// by "recoverStopped" after the stopped creation.
+func (ts *Tester) stopChannel() chan struct{} {
+	ts.stopMu.Lock()
+	defer ts.stopMu.Unlock()
+	return ts.stopCreationCh
+}
+
+// deadlineExceeded returns the reason if the creation is cancelled by the deadline.
+func (ts *Tester) deadlineExceeded() string {
+	ts.deadlineMu.Lock()
+	defer ts.deadlineMu.Unlock()
+	return ts.deadlineReason
+}
+
+// afterDeadline collects logs after the creation is cancelled by the deadline.
+func (ts *Tester) afterDeadline() {
+	fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
+	fmt.Fprintf(ts.logWriter, ts.color("[light_magenta]DEADLINE EXCEEDED [default](%s, %q)\n"), ts.deadlineExceeded(), ts.cfg.ConfigPath)
+	ts.recoverStopped()
+}
+
+// recoverStopped replaces the closed stop channel, and re-creates the testers
+// with the new channel, so that the log collection and the teardown
+// are not cancelled along with the stopped creation. And collects logs.
+func (ts *Tester) recoverStopped() {
+	ts.stopMu.Lock()
+	ts.stopCreationCh = make(chan struct{})
+	ts.stopCreationChOnce = new(sync.Once)
+	ts.stopMu.Unlock()
+
+	if err := ts.createTesters(); err != nil {
+		ts.lg.Warn("failed to re-create testers after stop", zap.Error(err))
+		return
+	}
+	if err := ts.DumpClusterLogs(); err != nil {
+		ts.lg.Warn("failed to collect logs after stop", zap.Error(err))
+	}
+}
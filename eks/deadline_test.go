@@ -0,0 +1,87 @@
+package eks
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"go.uber.org/zap"
+)
+
+func TestWithTimeout(t *testing.T) {
+	ts := &Tester{
+		lg:                 zap.NewNop(),
+		cfg:                &eksconfig.Config{},
+		stopCreationCh:     make(chan struct{}),
+		stopCreationChOnce: new(sync.Once),
+	}
+	stopc := ts.stopCreationCh
+
+	if err := ts.withTimeout("fast", time.Minute, func() error { return nil })(); err != nil {
+		t.Fatal(err)
+	}
+
+	// in-flight waits return on the closed stop channel
+	err := ts.withTimeout("slow", 10*time.Millisecond, func() error {
+		<-stopc
+		return errors.New("stopped")
+	})()
+	if err == nil || !strings.Contains(err.Error(), `"slow" timed out after 10ms`) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	select {
+	case <-stopc:
+	default:
+		t.Fatal("expected stop channel closed")
+	}
+	if ts.deadlineExceeded() == "" {
+		t.Fatal("expected deadline exceeded")
+	}
+}
+
+func TestWithTimeoutRecoverStopped(t *testing.T) {
+	ts := &Tester{
+		lg:                 zap.NewNop(),
+		cfg:                &eksconfig.Config{RunTimeout: time.Hour},
+		stopCreationCh:     make(chan struct{}),
+		stopCreationChOnce: new(sync.Once),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := ts.withTimeout("fast", time.Minute, func() error { return nil })(); err != nil {
+				t.Error(err)
+			}
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// same as "recoverStopped", without re-creating the testers
+			ts.stopMu.Lock()
+			ts.stopCreationCh = make(chan struct{})
+			ts.stopCreationChOnce = new(sync.Once)
+			ts.stopMu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// waiters after the recovery observe the new stop channel
+	stopc := ts.stopChannel()
+	donec := make(chan error, 1)
+	go func() {
+		donec <- ts.withTimeout("slow", 0, func() error {
+			<-stopc
+			return errors.New("stopped")
+		})()
+	}()
+	ts.stopCreation()
+	if err := <-donec; err == nil || !strings.Contains(err.Error(), `"slow" cancelled`) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package diagnose
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Category is the class of a node bootstrap failure.
+type Category string
+
+const (
+	// CategoryIAM is the failure due to missing or invalid
+	// node role, instance profile, or "aws-auth" mapping.
+	CategoryIAM Category = "IAM"
+	// CategoryNetworking is the failure due to unreachable
+	// API server endpoints, DNS, subnets, or security groups.
+	CategoryNetworking Category = "networking"
+	// CategoryUserData is the failure due to invalid user data
+	// or bootstrap script (e.g. cloud-init, bootstrap.sh, nodeadm).
+	CategoryUserData Category = "user-data"
+	// CategoryUnknown is the failure that does not match any known pattern.
+	CategoryUnknown Category = "unknown"
+)
+
+// categories are in the order of precedence when match counts are tied.
+var categories = []Category{CategoryUserData, CategoryIAM, CategoryNetworking}
+
+var patterns = map[Category][]*regexp.Regexp{
+	CategoryIAM: {
+		regexp.MustCompile(`(?i)access ?denied`),
+		regexp.MustCompile(`(?i)unauthorized`),
+		regexp.MustCompile(`(?i)not authorized to perform`),
+		regexp.MustCompile(`(?i)InvalidClientTokenId`),
+		regexp.MustCompile(`(?i)IamInstanceProfileNotFound|IamNodeRoleNotFound|IamLimitExceeded`),
+		regexp.MustCompile(`(?i)You must be logged in to the server`),
+		regexp.MustCompile(`(?i)no credentials? (provider|found)`),
+	},
+	CategoryNetworking: {
+		regexp.MustCompile(`(?i)i/o timeout`),
+		regexp.MustCompile(`(?i)connection (timed out|refused)`),
+		regexp.MustCompile(`(?i)no route to host`),
+		regexp.MustCompile(`(?i)network is unreachable`),
+		regexp.MustCompile(`(?i)could not resolve host|temporary failure in name resolution|no such host`),
+		regexp.MustCompile(`(?i)TLS handshake timeout`),
+		regexp.MustCompile(`(?i)ClusterUnreachable|InsufficientFreeAddresses|Ec2SubnetInvalidConfiguration|Ec2SubnetNotFound|Ec2SecurityGroupNotFound`),
+	},
+	CategoryUserData: {
+		regexp.MustCompile(`(?i)Failed to run module scripts[-_]user`),
+		regexp.MustCompile(`(?i)cloud-init.*(Traceback|failed|error)`),
+		regexp.MustCompile(`(?i)syntax error`),
+		regexp.MustCompile(`(?i)unexpected EOF`),
+		regexp.MustCompile(`(?i)bootstrap\.sh.*(unknown|invalid|not found)`),
+		regexp.MustCompile(`(?i)nodeadm.*(invalid|failed to parse|unmarshal)`),
+		regexp.MustCompile(`(?i)yaml: |mapping values are not allowed|could not find expected`),
+		regexp.MustCompile(`(?i)MIME.*(multipart|boundary)`),
+	},
+}
+
+// maxEvidence is the maximum number of matched lines kept per diagnosis.
+const maxEvidence = 5
+
+// Classify classifies the bootstrap failure from the log outputs,
+// and returns the category with the matched lines as evidence.
+func Classify(outputs ...string) (Category, []string) {
+	counts := make(map[Category]int)
+	evidence := make(map[Category][]string)
+	for _, out := range outputs {
+		for _, line := range strings.Split(out, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			for _, c := range categories {
+				for _, re := range patterns[c] {
+					if !re.MatchString(line) {
+						continue
+					}
+					counts[c]++
+					if len(evidence[c]) < maxEvidence {
+						evidence[c] = append(evidence[c], line)
+					}
+					break
+				}
+			}
+		}
+	}
+
+	cat, max := CategoryUnknown, 0
+	for _, c := range categories {
+		if counts[c] > max {
+			cat, max = c, counts[c]
+		}
+	}
+	return cat, evidence[cat]
+}
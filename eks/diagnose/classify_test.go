@@ -0,0 +1,48 @@
+package diagnose
+
+import (
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		outputs  []string
+		category Category
+		evidence int
+	}{
+		{
+			outputs: []string{`Jan 02 15:04:05 ip-192-168-1-1 kubelet[3012]: E0102 15:04:05.000000    3012 kubelet_node_status.go:93] Unable to register node with API server: Unauthorized
+Jan 02 15:04:15 ip-192-168-1-1 kubelet[3012]: E0102 15:04:15.000000    3012 kubelet_node_status.go:93] Unable to register node with API server: Unauthorized`},
+			category: CategoryIAM,
+			evidence: 2,
+		},
+		{
+			outputs: []string{
+				`Jan 02 15:04:05 ip-192-168-1-1 kubelet[3012]: Get "https://ABC.gr7.us-west-2.eks.amazonaws.com/api/v1/nodes": dial tcp 10.0.0.1:443: i/o timeout`,
+				`NodeCreationFailure: Instances failed to join the kubernetes cluster; ClusterUnreachable`,
+			},
+			category: CategoryNetworking,
+			evidence: 2,
+		},
+		{
+			outputs: []string{`/var/lib/cloud/instance/scripts/part-001: line 7: syntax error: unexpected end of file
+2021-01-02 15:04:05,000 - util.py[WARNING]: Failed to run module scripts-user (scripts in /var/lib/cloud/instance/scripts)`},
+			category: CategoryUserData,
+			evidence: 2,
+		},
+		{
+			outputs:  []string{"Cloud-init v. 19.3 finished", ""},
+			category: CategoryUnknown,
+			evidence: 0,
+		},
+	}
+	for i, tv := range tests {
+		cat, evidence := Classify(tv.outputs...)
+		if cat != tv.category {
+			t.Fatalf("#%d: expected %q, got %q (evidence %q)", i, tv.category, cat, evidence)
+		}
+		if len(evidence) != tv.evidence {
+			t.Fatalf("#%d: expected %d evidence, got %q", i, tv.evidence, evidence)
+		}
+	}
+}
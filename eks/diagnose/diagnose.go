@@ -0,0 +1,309 @@
+// Package diagnose implements node bootstrap failure diagnostics.
+// It collects cloud-init, bootstrap, and nodeadm logs from the failed
+// instances (via SSH or EC2 console output), and classifies the failure.
+package diagnose
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/ssh"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aws_asg_v2 "github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2instanceconnect/ec2instanceconnectiface"
+	"go.uber.org/zap"
+)
+
+// Config defines node bootstrap diagnostics configuration.
+type Config struct {
+	Logger   *zap.Logger
+	EC2APIV2 *aws_ec2_v2.Client
+	ASGAPIV2 *aws_asg_v2.Client
+
+	// SSHKeyPath is the private key path to SSH into the instances.
+	// Leave empty to only use EC2 console outputs.
+	SSHKeyPath string
+	// SSHUserName is the user name to SSH into the instances.
+	SSHUserName string
+	// SSHInstanceConnectAPI is set to SSH into the instances with
+	// ephemeral keys pushed via EC2 Instance Connect, instead of "SSHKeyPath".
+	SSHInstanceConnectAPI ec2instanceconnectiface.EC2InstanceConnectAPI
+	// SSHHostKeys is set to verify the instance host keys.
+	// Leave nil to skip host key verification.
+	SSHHostKeys *ssh.HostKeys
+	// SSHProxyJump is the bastion host to reach the instances
+	// in private subnets. Leave nil to SSH into the public IPs.
+	SSHProxyJump *ssh.ProxyJump
+	// LogsDir is the directory to write the collected logs.
+	// Leave empty to skip writing logs to disk.
+	LogsDir string
+	// MaxInstances is the maximum number of instances to diagnose.
+	// Defaults to 5.
+	MaxInstances int
+}
+
+// bootstrapLogs maps commands to collect bootstrap logs to file name suffixes.
+var bootstrapLogs = map[string]string{
+	// user data outputs (e.g. bootstrap.sh)
+	"sudo cat /var/log/cloud-init-output.log":   "cloud-init-output.log",
+	"sudo tail -n 1000 /var/log/cloud-init.log": "cloud-init.log",
+
+	// AL2023 node bootstrap
+	"sudo journalctl --no-pager --output=short-precise -u nodeadm-config -u nodeadm-run": "nodeadm.out.log",
+
+	// node registration errors (e.g. "Unauthorized")
+	"sudo journalctl --no-pager --output=short-precise -u kubelet | tail -n 1000": "kubelet.out.log",
+}
+
+// Diagnosis is the diagnosis of a single instance.
+type Diagnosis struct {
+	InstanceID string
+	Category   Category
+	Evidence   []string
+	// LogPaths is the list of collected log file paths.
+	LogPaths []string
+	// Errors is the list of errors while collecting logs.
+	Errors []string
+}
+
+// Report is the diagnosis of a node group.
+type Report struct {
+	ASGName string
+	// Issues is the list of health issues reported by the node group.
+	Issues    []string
+	Diagnoses []Diagnosis
+}
+
+// Category returns the most common failure category of the report.
+func (rp Report) Category() Category {
+	counts := make(map[Category]int)
+	for _, d := range rp.Diagnoses {
+		counts[d.Category]++
+	}
+	cat, max := CategoryUnknown, 0
+	for _, c := range categories {
+		if counts[c] > max {
+			cat, max = c, counts[c]
+		}
+	}
+	if cat == CategoryUnknown {
+		// e.g. instances were never launched
+		cat, _ = Classify(rp.Issues...)
+	}
+	return cat
+}
+
+// String returns the human-readable report.
+func (rp Report) String() string {
+	buf := bytes.NewBuffer(nil)
+	fmt.Fprintf(buf, "node bootstrap diagnosis for ASG %q: %s failure\n", rp.ASGName, rp.Category())
+	for _, is := range rp.Issues {
+		fmt.Fprintf(buf, "  issue: %s\n", is)
+	}
+	for _, d := range rp.Diagnoses {
+		fmt.Fprintf(buf, "  instance %s: %s\n", d.InstanceID, d.Category)
+		for _, ev := range d.Evidence {
+			fmt.Fprintf(buf, "    > %s\n", ev)
+		}
+		for _, e := range d.Errors {
+			fmt.Fprintf(buf, "    (collect error: %s)\n", e)
+		}
+		if len(d.LogPaths) > 0 {
+			fmt.Fprintf(buf, "    logs: %s\n", strings.Join(d.LogPaths, ", "))
+		}
+	}
+	return buf.String()
+}
+
+// Diagnose collects bootstrap logs from the instances of the ASG
+// and classifies the failure. It never fails, and records
+// collection errors in the report instead.
+func Diagnose(cfg Config, asgName string, issues ...string) (rp Report) {
+	rp = Report{ASGName: asgName, Issues: issues}
+	if cfg.MaxInstances == 0 {
+		cfg.MaxInstances = 5
+	}
+	if cfg.LogsDir != "" {
+		if err := os.MkdirAll(cfg.LogsDir, 0700); err != nil {
+			cfg.Logger.Warn("failed to mkdir", zap.Error(err))
+			cfg.LogsDir = ""
+		}
+	}
+
+	cfg.Logger.Info("diagnosing node bootstrap failure", zap.String("asg-name", asgName), zap.Strings("issues", issues))
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	aout, err := cfg.ASGAPIV2.DescribeAutoScalingGroups(
+		ctx,
+		&aws_asg_v2.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: []string{asgName},
+		},
+	)
+	cancel()
+	if err != nil {
+		cfg.Logger.Warn("failed to describe ASG", zap.String("asg-name", asgName), zap.Error(err))
+		return rp
+	}
+	instanceIDs := make([]string, 0)
+	for _, av := range aout.AutoScalingGroups {
+		for _, iv := range av.Instances {
+			instanceIDs = append(instanceIDs, aws.ToString(iv.InstanceId))
+		}
+	}
+	sort.Strings(instanceIDs)
+	if len(instanceIDs) > cfg.MaxInstances {
+		instanceIDs = instanceIDs[:cfg.MaxInstances]
+	}
+	if len(instanceIDs) == 0 {
+		cfg.Logger.Warn("no instance found to diagnose", zap.String("asg-name", asgName))
+		return rp
+	}
+
+	publicIPs, publicDNSNames, privateIPs, azs := make(map[string]string), make(map[string]string), make(map[string]string), make(map[string]string)
+	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
+	dout, err := cfg.EC2APIV2.DescribeInstances(
+		ctx,
+		&aws_ec2_v2.DescribeInstancesInput{
+			InstanceIds: instanceIDs,
+		},
+	)
+	cancel()
+	if err != nil {
+		cfg.Logger.Warn("failed to describe instances", zap.Error(err))
+	} else {
+		for _, rsrv := range dout.Reservations {
+			for _, iv := range rsrv.Instances {
+				id := aws.ToString(iv.InstanceId)
+				publicIPs[id] = aws.ToString(iv.PublicIpAddress)
+				publicDNSNames[id] = aws.ToString(iv.PublicDnsName)
+				privateIPs[id] = aws.ToString(iv.PrivateIpAddress)
+				if iv.Placement != nil {
+					azs[id] = aws.ToString(iv.Placement.AvailabilityZone)
+				}
+			}
+		}
+	}
+
+	for _, id := range instanceIDs {
+		d := Diagnosis{InstanceID: id}
+		outputs := make([]string, 0, len(bootstrapLogs)+1)
+
+		out, err := consoleOutput(cfg, id)
+		if err != nil {
+			d.Errors = append(d.Errors, fmt.Sprintf("console output: %v", err))
+		} else if out != "" {
+			outputs = append(outputs, out)
+			d.LogPaths = appendLog(cfg, d.LogPaths, id, "console.log", out)
+		}
+
+		reachable := publicIPs[id] != "" || (cfg.SSHProxyJump != nil && privateIPs[id] != "")
+		if (cfg.SSHKeyPath != "" || cfg.SSHInstanceConnectAPI != nil) && reachable {
+			logs, err := sshLogs(cfg, id, azs[id], publicIPs[id], publicDNSNames[id], privateIPs[id])
+			if err != nil {
+				d.Errors = append(d.Errors, fmt.Sprintf("ssh: %v", err))
+			}
+			suffixes := make([]string, 0, len(logs))
+			for suffix := range logs {
+				suffixes = append(suffixes, suffix)
+			}
+			sort.Strings(suffixes)
+			for _, suffix := range suffixes {
+				outputs = append(outputs, logs[suffix])
+				d.LogPaths = appendLog(cfg, d.LogPaths, id, suffix, logs[suffix])
+			}
+		}
+
+		d.Category, d.Evidence = Classify(outputs...)
+		cfg.Logger.Info("diagnosed instance",
+			zap.String("instance-id", id),
+			zap.String("category", string(d.Category)),
+			zap.Strings("evidence", d.Evidence),
+		)
+		rp.Diagnoses = append(rp.Diagnoses, d)
+	}
+	return rp
+}
+
+func consoleOutput(cfg Config, instanceID string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	out, err := cfg.EC2APIV2.GetConsoleOutput(
+		ctx,
+		&aws_ec2_v2.GetConsoleOutputInput{
+			InstanceId: aws.String(instanceID),
+			Latest:     aws.Bool(true),
+		},
+	)
+	cancel()
+	if err != nil {
+		return "", err
+	}
+	b, err := base64.StdEncoding.DecodeString(aws.ToString(out.Output))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// sshLogs returns the bootstrap logs keyed by file name suffix.
+func sshLogs(cfg Config, instanceID string, az string, publicIP string, publicDNSName string, privateIP string) (map[string]string, error) {
+	sh, err := ssh.New(ssh.Config{
+		Logger:             cfg.Logger,
+		KeyPath:            cfg.SSHKeyPath,
+		InstanceConnect:    ssh.NewInstanceConnect(cfg.SSHInstanceConnectAPI, instanceID, az),
+		InstanceID:         instanceID,
+		HostKeys:           cfg.SSHHostKeys,
+		InsecureSkipVerify: cfg.SSHHostKeys == nil,
+		PublicIP:           publicIP,
+		PublicDNSName:      publicDNSName,
+		PrivateIP:          privateIP,
+		UserName:           cfg.SSHUserName,
+		ProxyJump:          cfg.SSHProxyJump,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = sh.Connect(); err != nil {
+		return nil, err
+	}
+	defer sh.Close()
+
+	logs := make(map[string]string)
+	var errs []string
+	for cmd, suffix := range bootstrapLogs {
+		out, oerr := sh.Run(
+			cmd,
+			ssh.WithVerbose(false),
+			ssh.WithRetry(2, 5*time.Second),
+			ssh.WithTimeout(time.Minute),
+		)
+		if oerr != nil {
+			errs = append(errs, fmt.Sprintf("%q: %v", cmd, oerr))
+			continue
+		}
+		logs[suffix] = string(out)
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return logs, fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+	return logs, nil
+}
+
+func appendLog(cfg Config, paths []string, instanceID string, suffix string, data string) []string {
+	if cfg.LogsDir == "" {
+		return paths
+	}
+	p := filepath.Join(cfg.LogsDir, instanceID+"-diagnose-"+suffix)
+	if err := os.WriteFile(p, []byte(data), 0600); err != nil {
+		cfg.Logger.Warn("failed to write log", zap.String("path", p), zap.Error(err))
+		return paths
+	}
+	return append(paths, p)
+}
@@ -0,0 +1,91 @@
+package eks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/pkg/aws/cfn"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/olekukonko/tablewriter"
+	"go.uber.org/zap"
+)
+
+// cfnStackIDs returns the CloudFormation stacks created by the add-ons.
+func (ts *Tester) cfnStackIDs() (ids []string) {
+	if ts.cfg.AddOnFargate != nil && ts.cfg.AddOnFargate.RoleCFNStackID != "" {
+		ids = append(ids, ts.cfg.AddOnFargate.RoleCFNStackID)
+	}
+	if ts.cfg.AddOnIRSA != nil && ts.cfg.AddOnIRSA.RoleCFNStackID != "" {
+		ids = append(ids, ts.cfg.AddOnIRSA.RoleCFNStackID)
+	}
+	if ts.cfg.AddOnIRSAFargate != nil && ts.cfg.AddOnIRSAFargate.RoleCFNStackID != "" {
+		ids = append(ids, ts.cfg.AddOnIRSAFargate.RoleCFNStackID)
+	}
+	if ts.cfg.AddOnAppMesh != nil && ts.cfg.AddOnAppMesh.PolicyCFNStackID != "" {
+		ids = append(ids, ts.cfg.AddOnAppMesh.PolicyCFNStackID)
+	}
+	return ids
+}
+
+// detectStackDrifts records the drift of the created CloudFormation stacks
+// in the status, so that the manual changes (e.g. edited in the console
+// while debugging) are visible. The detection failures are only logged.
+func (ts *Tester) detectStackDrifts() {
+	ids := ts.cfnStackIDs()
+	if len(ids) == 0 || ts.cfnAPIV2 == nil {
+		return
+	}
+	drifts := make([]eksconfig.CFNStackDrift, 0, len(ids))
+	for _, id := range ids {
+		d := eksconfig.CFNStackDrift{StackID: id, DetectedAt: time.Now().UTC()}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		sd, err := cfn.DetectDriftV2(ctx, ts.lg, ts.cfnAPIV2, id, 5*time.Second)
+		cancel()
+		if err != nil {
+			ts.lg.Warn("failed to detect stack drift", zap.String("stack-id", id), zap.Error(err))
+			d.Error = err.Error()
+			drifts = append(drifts, d)
+			continue
+		}
+		d.DriftStatus = string(sd.Status)
+		for _, r := range sd.DriftedResources {
+			d.DriftedResources = append(d.DriftedResources, eksconfig.CFNResourceDrift{
+				LogicalResourceID:  aws_v2.ToString(r.LogicalResourceId),
+				PhysicalResourceID: aws_v2.ToString(r.PhysicalResourceId),
+				ResourceType:       aws_v2.ToString(r.ResourceType),
+				DriftStatus:        string(r.StackResourceDriftStatus),
+			})
+		}
+		drifts = append(drifts, d)
+	}
+	ts.cfg.Status.CFNStackDrifts = drifts
+	ts.cfg.Sync()
+	fmt.Fprintf(ts.logWriter, "\n\nCloudFormation stack drifts:\n%s\n", stackDriftTable(drifts))
+}
+
+func stackDriftTable(drifts []eksconfig.CFNStackDrift) string {
+	buf := bytes.NewBuffer(nil)
+	tb := tablewriter.NewWriter(buf)
+	tb.SetAutoWrapText(false)
+	tb.SetAlignment(tablewriter.ALIGN_LEFT)
+	tb.SetCenterSeparator("*")
+	tb.SetHeader([]string{"stack", "drift-status", "resource", "type", "resource-drift-status"})
+	for _, d := range drifts {
+		status := d.DriftStatus
+		if d.Error != "" {
+			status = "ERROR: " + d.Error
+		}
+		if len(d.DriftedResources) == 0 {
+			tb.Append([]string{d.StackID, status, "", "", ""})
+			continue
+		}
+		for _, r := range d.DriftedResources {
+			tb.Append([]string{d.StackID, status, r.LogicalResourceID, r.ResourceType, r.DriftStatus})
+		}
+	}
+	tb.Render()
+	return buf.String()
+}
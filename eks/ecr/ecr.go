@@ -0,0 +1,266 @@
+// Package ecr creates a private ECR repository, pushes a test image
+// (built locally or replicated from a public registry), allows the worker
+// nodes to pull, and verifies the private pull path and the image tag
+// immutability settings. Other add-ons may pull their images from it.
+package ecr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_ecr "github.com/aws/aws-k8s-tester/pkg/aws/ecr"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
+	"go.uber.org/zap"
+)
+
+// Config defines ECR configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+	// ECRAPI is the ECR client in the cluster region.
+	ECRAPI ecriface.ECRAPI
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new ECR tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "ecr",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				ECRAPI:    cfg.ECRAPI,
+			}), cfg.EKSConfig.IsEnabledAddOnECR)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnECR() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnECR.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnECR.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnECR.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	cur := ts.cfg.EKSConfig.AddOnECR
+	accountID := ts.cfg.EKSConfig.Status.AWSAccountID
+	if accountID == "" {
+		return errors.New("empty AWS account ID")
+	}
+
+	policy, err := aws_ecr.PullPolicy(ts.nodeRoleARNs())
+	if err != nil {
+		return fmt.Errorf("failed to create repository policy (%v)", err)
+	}
+	repositoryURI, err := aws_ecr.Create(
+		ts.cfg.Logger,
+		ts.cfg.ECRAPI,
+		accountID,
+		ts.cfg.EKSConfig.Region,
+		cur.RepositoryName,
+		cur.ImageScanOnPush,
+		cur.ImageTagMutability,
+		policy,
+		true,
+	)
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.RepositoryURI = repositoryURI
+	})
+
+	if cur.BuildContextDir != "" {
+		if _, err = aws_ecr.BuildAndPush(
+			ts.cfg.Logger,
+			ts.cfg.ECRAPI,
+			"docker",
+			cur.BuildContextDir,
+			accountID,
+			cur.RepositoryName,
+			cur.ImageTag,
+		); err != nil {
+			return err
+		}
+	} else {
+		imageDigest, err := aws_ecr.Replicate(
+			ts.cfg.Logger,
+			ts.cfg.ECRAPI,
+			cur.SourceImage,
+			accountID,
+			cur.RepositoryName,
+			cur.ImageTag,
+		)
+		if err != nil {
+			return err
+		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.ImageDigest = imageDigest
+		})
+	}
+	image, _, err := aws_ecr.Check(
+		ts.cfg.Logger,
+		ts.cfg.ECRAPI,
+		ts.cfg.EKSConfig.Partition,
+		accountID,
+		ts.cfg.EKSConfig.Region,
+		cur.RepositoryName,
+		cur.ImageTag,
+	)
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.Image = image
+	})
+
+	if err = aws_ecr.CheckTagMutability(
+		ts.cfg.Logger,
+		ts.cfg.ECRAPI,
+		accountID,
+		cur.RepositoryName,
+		cur.ImageTag,
+		cur.ImageTagMutability,
+	); err != nil {
+		return err
+	}
+
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		cur.Namespace,
+	); err != nil {
+		return err
+	}
+	if err = ts.verifyPull(); err != nil {
+		return err
+	}
+	fmt.Fprintf(ts.cfg.LogWriter, "\npulled %q from the private repository in %s\n", cur.Image, cur.PullTimeString)
+
+	if cur.ReferenceFromAddOns {
+		ts.referenceFromAddOns(accountID)
+	}
+	return nil
+}
+
+// nodeRoleARNs returns the worker node role ARNs to allow the pulls.
+func (ts *tester) nodeRoleARNs() (arns []string) {
+	if ts.cfg.EKSConfig.IsEnabledAddOnNodeGroups() && ts.cfg.EKSConfig.AddOnNodeGroups.Role.ARN != "" {
+		arns = append(arns, ts.cfg.EKSConfig.AddOnNodeGroups.Role.ARN)
+	}
+	if ts.cfg.EKSConfig.IsEnabledAddOnManagedNodeGroups() && ts.cfg.EKSConfig.AddOnManagedNodeGroups.Role.ARN != "" {
+		arns = append(arns, ts.cfg.EKSConfig.AddOnManagedNodeGroups.Role.ARN)
+	}
+	return arns
+}
+
+// referenceFromAddOns sets the repository account ID of the add-ons
+// referencing this repository (set on validation), so that they pull
+// their busybox images from it.
+func (ts *tester) referenceFromAddOns(accountID string) {
+	cfg, cur := ts.cfg.EKSConfig, ts.cfg.EKSConfig.AddOnECR
+	cfg.RecordAddOnStatus(func() {
+		if cfg.IsEnabledAddOnFluentd() && cfg.AddOnFluentd.RepositoryBusyboxName == cur.RepositoryName {
+			cfg.AddOnFluentd.RepositoryBusyboxAccountID = accountID
+		}
+		if cfg.IsEnabledAddOnJobsEcho() && cfg.AddOnJobsEcho.RepositoryBusyboxName == cur.RepositoryName {
+			cfg.AddOnJobsEcho.RepositoryBusyboxAccountID = accountID
+		}
+		if cfg.IsEnabledAddOnCronJobs() && cfg.AddOnCronJobs.RepositoryBusyboxName == cur.RepositoryName {
+			cfg.AddOnCronJobs.RepositoryBusyboxAccountID = accountID
+		}
+	})
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnECR() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnECR.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnECR.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnECR.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete ECR namespace (%v)", err))
+	}
+	// force to delete with the images
+	if err := aws_ecr.Delete(
+		ts.cfg.Logger,
+		ts.cfg.ECRAPI,
+		ts.cfg.EKSConfig.Status.AWSAccountID,
+		ts.cfg.EKSConfig.Region,
+		ts.cfg.EKSConfig.AddOnECR.RepositoryName,
+		true,
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete ECR repository (%v)", err))
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnECR.Created = false
+	})
+	return nil
+}
@@ -0,0 +1,103 @@
+package ecr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const pullPodName = "ecr-pull"
+
+// verifyPull runs a Pod with the pushed image, always pulled from the
+// private repository with the node credentials, and waits until pulled.
+func (ts *tester) verifyPull() error {
+	cur := ts.cfg.EKSConfig.AddOnECR
+	ts.cfg.Logger.Info("creating pull verification Pod", zap.String("image", cur.Image))
+	created := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(cur.Namespace).
+		Create(
+			ctx,
+			&v1.Pod{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Pod",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      pullPodName,
+					Namespace: cur.Namespace,
+				},
+				Spec: v1.PodSpec{
+					RestartPolicy:                 v1.RestartPolicyNever,
+					TerminationGracePeriodSeconds: aws_v2.Int64(0),
+					Containers: []v1.Container{
+						{
+							Name:            pullPodName,
+							Image:           cur.Image,
+							ImagePullPolicy: v1.PullAlways,
+						},
+					},
+					NodeSelector: map[string]string{
+						"kubernetes.io/os": "linux",
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create pull verification Pod (%v)", err)
+	}
+
+	deadline := time.Now().Add(10 * time.Minute)
+	var lastMsg string
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("pull verification aborted")
+		case <-time.After(5 * time.Second):
+		}
+		ctx, cancel = context.WithTimeout(context.Background(), 15*time.Second)
+		pod, err := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Pods(cur.Namespace).Get(ctx, pullPodName, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get pull verification Pod", zap.Error(err))
+			continue
+		}
+		pulled, msg := pullStatus(*pod)
+		ts.cfg.Logger.Info("polled pull verification Pod", zap.Bool("pulled", pulled), zap.String("message", msg))
+		if pulled {
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				cur.PullTime = time.Since(created)
+				cur.PullTimeString = cur.PullTime.String()
+			})
+			return nil
+		}
+		lastMsg = msg
+	}
+	return fmt.Errorf("image %q not pulled (%s)", cur.Image, lastMsg)
+}
+
+// pullStatus returns true if the container image has been pulled
+// (i.e. the container has started), or the waiting reason otherwise.
+func pullStatus(pod v1.Pod) (bool, string) {
+	for _, st := range pod.Status.ContainerStatuses {
+		if st.State.Running != nil || st.State.Terminated != nil || st.ImageID != "" {
+			return true, ""
+		}
+		if st.State.Waiting != nil {
+			// e.g. "ErrImagePull", "ImagePullBackOff"
+			return false, fmt.Sprintf("%s: %s", st.State.Waiting.Reason, st.State.Waiting.Message)
+		}
+	}
+	return false, fmt.Sprintf("phase %q", pod.Status.Phase)
+}
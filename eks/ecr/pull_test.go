@@ -0,0 +1,30 @@
+package ecr
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestPullStatus(t *testing.T) {
+	pod := func(st v1.ContainerStatus) v1.Pod {
+		return v1.Pod{Status: v1.PodStatus{Phase: v1.PodPending, ContainerStatuses: []v1.ContainerStatus{st}}}
+	}
+	tt := []struct {
+		pod    v1.Pod
+		pulled bool
+		msg    string
+	}{
+		{v1.Pod{Status: v1.PodStatus{Phase: v1.PodPending}}, false, "Pending"},
+		{pod(v1.ContainerStatus{State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "403 Forbidden"}}}), false, "ImagePullBackOff: 403 Forbidden"},
+		{pod(v1.ContainerStatus{State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ContainerCreating"}}, ImageID: "sha256:abc"}), true, ""},
+		{pod(v1.ContainerStatus{State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 0}}}), true, ""},
+	}
+	for i, tv := range tt {
+		pulled, msg := pullStatus(tv.pod)
+		if pulled != tv.pulled || !strings.Contains(msg, tv.msg) {
+			t.Fatalf("#%d: expected (%v, %q), got (%v, %q)", i, tv.pulled, tv.msg, pulled, msg)
+		}
+	}
+}
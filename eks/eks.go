@@ -19,64 +19,35 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/aws/aws-k8s-tester/ec2config"
-	alb_2048 "github.com/aws/aws-k8s-tester/eks/alb-2048"
-	ami_soft_lockup_issue_454 "github.com/aws/aws-k8s-tester/eks/amazon-eks-ami-issue-454"
-	app_mesh "github.com/aws/aws-k8s-tester/eks/app-mesh"
+	"github.com/aws/aws-k8s-tester/eks/access"
 	"github.com/aws/aws-k8s-tester/eks/cluster"
-	"github.com/aws/aws-k8s-tester/eks/cluster-loader/clusterloader2"
-	cluster_loader_local "github.com/aws/aws-k8s-tester/eks/cluster-loader/local"
-	cluster_loader_remote "github.com/aws/aws-k8s-tester/eks/cluster-loader/remote"
 	cluster_version_upgrade "github.com/aws/aws-k8s-tester/eks/cluster/version-upgrade"
-	"github.com/aws/aws-k8s-tester/eks/clusterautoscaler"
 	cni_vpc "github.com/aws/aws-k8s-tester/eks/cni-vpc"
-	config_maps_local "github.com/aws/aws-k8s-tester/eks/configmaps/local"
-	config_maps_remote "github.com/aws/aws-k8s-tester/eks/configmaps/remote"
-	"github.com/aws/aws-k8s-tester/eks/conformance"
-	cron_jobs "github.com/aws/aws-k8s-tester/eks/cron-jobs"
-	csi_ebs "github.com/aws/aws-k8s-tester/eks/csi-ebs"
-	csrs_local "github.com/aws/aws-k8s-tester/eks/csrs/local"
-	csrs_remote "github.com/aws/aws-k8s-tester/eks/csrs/remote"
-	cuda_vector_add "github.com/aws/aws-k8s-tester/eks/cuda-vector-add"
-	cw_agent "github.com/aws/aws-k8s-tester/eks/cw-agent"
-	"github.com/aws/aws-k8s-tester/eks/fargate"
-	"github.com/aws/aws-k8s-tester/eks/fluentd"
+	"github.com/aws/aws-k8s-tester/eks/cost"
 	"github.com/aws/aws-k8s-tester/eks/gpu"
-	"github.com/aws/aws-k8s-tester/eks/irsa"
-	irsa_fargate "github.com/aws/aws-k8s-tester/eks/irsa-fargate"
-	jobs_echo "github.com/aws/aws-k8s-tester/eks/jobs-echo"
-	jobs_pi "github.com/aws/aws-k8s-tester/eks/jobs-pi"
-	jupyter_hub "github.com/aws/aws-k8s-tester/eks/jupyter-hub"
-	"github.com/aws/aws-k8s-tester/eks/kubeflow"
-	kubernetes_dashboard "github.com/aws/aws-k8s-tester/eks/kubernetes-dashboard"
-	metrics_server "github.com/aws/aws-k8s-tester/eks/metrics-server"
 	"github.com/aws/aws-k8s-tester/eks/mng"
 	"github.com/aws/aws-k8s-tester/eks/neuron"
 	"github.com/aws/aws-k8s-tester/eks/ng"
-	nlb_guestbook "github.com/aws/aws-k8s-tester/eks/nlb-guestbook"
-	nlb_hello_world "github.com/aws/aws-k8s-tester/eks/nlb-hello-world"
-	"github.com/aws/aws-k8s-tester/eks/overprovisioning"
-	php_apache "github.com/aws/aws-k8s-tester/eks/php-apache"
-	prometheus_grafana "github.com/aws/aws-k8s-tester/eks/prometheus-grafana"
-	secrets_local "github.com/aws/aws-k8s-tester/eks/secrets/local"
-	secrets_remote "github.com/aws/aws-k8s-tester/eks/secrets/remote"
-	stresser_local "github.com/aws/aws-k8s-tester/eks/stresser/local"
-	stresser_remote "github.com/aws/aws-k8s-tester/eks/stresser/remote"
-	stresser_remote_v2 "github.com/aws/aws-k8s-tester/eks/stresser2"
+	"github.com/aws/aws-k8s-tester/eks/notify"
+	"github.com/aws/aws-k8s-tester/eks/prometheus"
 	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
 	"github.com/aws/aws-k8s-tester/eks/trainium"
-	"github.com/aws/aws-k8s-tester/eks/wordpress"
 	"github.com/aws/aws-k8s-tester/eksconfig"
 	pkg_aws "github.com/aws/aws-k8s-tester/pkg/aws"
 	"github.com/aws/aws-k8s-tester/pkg/aws/awscurl"
+	aws_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
 	"github.com/aws/aws-k8s-tester/pkg/fileutil"
 	"github.com/aws/aws-k8s-tester/pkg/httputil"
 	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
 	"github.com/aws/aws-k8s-tester/pkg/logutil"
+	"github.com/aws/aws-k8s-tester/pkg/report"
 	"github.com/aws/aws-k8s-tester/pkg/user"
+	"github.com/aws/aws-k8s-tester/ssh"
 	"github.com/aws/aws-k8s-tester/version"
 	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
 	aws_asg_v2 "github.com/aws/aws-sdk-go-v2/service/autoscaling"
@@ -93,27 +64,35 @@ import (
 	aws_ssm_v2 "github.com/aws/aws-sdk-go-v2/service/ssm"
 	aws_sts_v2 "github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
-	"github.com/aws/aws-sdk-go/service/cloudformation"
-	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+	"github.com/aws/aws-sdk-go/service/ec2instanceconnect"
+	"github.com/aws/aws-sdk-go/service/ec2instanceconnect/ec2instanceconnectiface"
 	"github.com/aws/aws-sdk-go/service/ecr"
 	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
 	aws_eks "github.com/aws/aws-sdk-go/service/eks"
 	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
 	"github.com/aws/aws-sdk-go/service/elbv2"
 	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/iam/iamiface"
 	"github.com/aws/aws-sdk-go/service/kms"
 	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go/service/pricing/pricingiface"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/sns"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
 	"github.com/aws/aws-sdk-go/service/sts"
@@ -124,6 +103,33 @@ import (
 	"k8s.io/utils/exec"
 )
 
+// awsMaxAttempts is the maximum number of attempts of each AWS SDK Go v2
+// API call, higher than the SDK default to ride out the throttling
+// while the add-ons are created in parallel. The attempts are retried
+// in the "adaptive" mode, paced by the shared "getAWSThrottler".
+const awsMaxAttempts = 10
+
+var (
+	awsThrottlerOnce sync.Once
+	awsThrottler     *pkg_aws.Throttler
+)
+
+// getAWSThrottler returns the client-side AWS API rate limiter shared by
+// all the testers in this process (e.g. the clusters of "eks multi"),
+// so that the add-ons created in parallel back off together on throttling.
+func getAWSThrottler(lg *zap.Logger) *pkg_aws.Throttler {
+	awsThrottlerOnce.Do(func() {
+		awsThrottler = pkg_aws.NewThrottler(pkg_aws.ThrottlerConfig{
+			Logger:   lg,
+			MaxRate:  20,
+			MinRate:  1,
+			Burst:    20,
+			Observer: observeAPIThrottle,
+		})
+	})
+	return awsThrottler
+}
+
 var useTwoAZs = map[string]bool{
 	"cn-north-1":     true,
 	"us-isob-east-1": true,
@@ -135,13 +141,30 @@ var useTwoAZs = map[string]bool{
 type Tester struct {
 	color func(string) string
 
+	// stopMu protects the stop channel replaced after the stopped creation
+	stopMu             sync.Mutex
 	stopCreationCh     chan struct{}
 	stopCreationChOnce *sync.Once
 
+	// osSig forwards the signals to the in-flight "Up" phase
 	osSig chan os.Signal
+	// upRunning is 1 while "Up" is running, to clean up on return
+	upRunning int32
+	// ctx is the context of the embedder (see "NewTester"),
+	// nil if created from the configuration
+	ctx context.Context
+
+	signalMu    sync.Mutex
+	signal      os.Signal
+	signalHooks []SignalHook
 
 	downMu *sync.Mutex
 
+	// deadlineReason is set when the creation is cancelled by
+	// "RunTimeout" or the per-phase timeouts
+	deadlineMu     sync.Mutex
+	deadlineReason string
+
 	lg        *zap.Logger
 	logWriter io.Writer
 	logFile   *os.File
@@ -161,49 +184,78 @@ type Tester struct {
 	ssmAPI   ssmiface.SSMAPI
 	ssmAPIV2 *aws_ssm_v2.Client
 
-	cfnAPI   cloudformationiface.CloudFormationAPI
+	// nil unless "RemoteAccessInstanceConnect" is enabled
+	ec2InstanceConnectAPI ec2instanceconnectiface.EC2InstanceConnectAPI
+	// nil if "RemoteAccessHostKeyCheck" is "insecure-skip-verify"
+	sshHostKeys *ssh.HostKeys
+
 	cfnAPIV2 *aws_cfn_v2.Client
 
-	ec2API   ec2iface.EC2API
 	ec2APIV2 *aws_ec2_v2.Client
 
 	s3API   s3iface.S3API
 	s3APIV2 *aws_s3_v2.Client
+	// s3Client is the context-aware S3 client for the artifact bucket
+	s3Client aws_s3.Client
 
 	cwAPI   cloudwatchiface.CloudWatchAPI
 	cwAPIV2 *aws_cw_v2.Client
 
+	cwLogsAPI cloudwatchlogsiface.CloudWatchLogsAPI
+
 	asgAPI   autoscalingiface.AutoScalingAPI
 	asgAPIV2 *aws_asg_v2.Client
 
 	elbv2API   elbv2iface.ELBV2API
 	elbv2APIV2 *aws_elbv2_v2.Client
+	// elbAPI deletes the classic ELBs left behind by the Kubernetes Services
+	elbAPI elbiface.ELBAPI
+
+	// pricingAPI looks up on-demand prices for the cost summary
+	pricingAPI pricingiface.PricingAPI
+	// taggingAPI finds the created resources by the run tag
+	taggingAPI resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI
 
 	ecrAPISameRegion ecriface.ECRAPI
 	ecrAPIV2         *aws_ecr_v2.Client
 
 	// used for EKS + EKS MNG API calls
+	// TODO: move the v1 EKS clients (and the packages taking "eksiface.EKSAPI")
+	// to aws-sdk-go-v2 behind an interface, then drop the v1 clients
 	eksAPIForCluster   eksiface.EKSAPI
 	eksAPIForClusterV2 *aws_eks_v2.Client
 	eksAPIForMNG       eksiface.EKSAPI
 	eksAPIForMNGV2     *aws_eks_v2.Client
+	// eksClientForCluster is "eksAPIForCluster" to send the operations
+	// not modeled in the SDK (see "eks/access")
+	eksClientForCluster *aws_eks.EKS
 
 	s3Uploaded bool
 
+	// report records each test phase as a test case
+	report *report.Report
+	// notifier sends the run events to the configured sinks
+	notifier *notify.Notifier
+
 	clusterTester cluster.Tester
 	k8sClient     k8s_client.EKS
 
 	// only create/install, no need delete
 	cniTester eks_tester.Tester
 
+	accessManager  access.Manager
 	ngTester       ng.Tester
 	mngTester      mng.Tester
 	gpuTester      gpu.Tester
 	neuronTester   neuron.Tester
 	trainiumTester trainium.Tester
 
-	// TODO, Shift to "Addon" api for ordered installation
-	testers []eks_tester.Tester
+	// prometheusTester snapshots metrics at test end
+	prometheusTester prometheus.Tester
+
+	// clusterVersionUpgrader upgrades the control plane,
+	// and cascades to node groups and add-ons
+	clusterVersionUpgrader cluster_version_upgrade.Upgrader
 
 	// Addons constructs a dependency ordering of Addons
 	addons [][]eks_tester.Addon
@@ -336,7 +388,19 @@ func New(cfg *eksconfig.Config) (ts *Tester, err error) {
 		logFile:            logFile,
 		cfg:                cfg,
 	}
-	signal.Notify(ts.osSig, syscall.SIGTERM, syscall.SIGINT)
+	// append to the phases of previous runs (e.g. "Down" after "Up")
+	ts.report, err = report.Load(cfg.Name, cfg.ReportJSONPath)
+	if err != nil {
+		lg.Warn("failed to load report; starting a new report", zap.Error(err))
+		ts.report, err = report.New(cfg.Name), nil
+	}
+	ts.report.SetObserver(phaseMetrics{})
+	if err = ts.startMetricsServer(); err != nil {
+		return nil, fmt.Errorf("failed to serve metrics (%v)", err)
+	}
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGTERM, syscall.SIGINT)
+	go ts.watchSignals(sigc)
 
 	defer ts.cfg.Sync()
 
@@ -345,6 +409,15 @@ func New(cfg *eksconfig.Config) (ts *Tester, err error) {
 		DebugAPICalls: ts.cfg.LogLevel == "debug",
 		Partition:     ts.cfg.Partition,
 		Region:        ts.cfg.Region,
+
+		Endpoints:        ts.cfg.ServiceEndpoints.AWSEndpoints(),
+		S3ForcePathStyle: ts.cfg.ServiceEndpoints.S3ForcePathStyle,
+		MaxAttempts:      awsMaxAttempts,
+		RetryMode:        pkg_aws.RetryModeAdaptive,
+
+		APICallObserver: observeAPICall,
+		Throttler:       getAWSThrottler(ts.lg),
+		AssumeRole:      ts.cfg.AssumeRole.AWSAssumeRole(),
 	}
 	var stsOutput *sts.GetCallerIdentityOutput
 	ts.awsSession, stsOutput, ts.cfg.Status.AWSCredentialPath, err = pkg_aws.New(&awsCfg)
@@ -388,14 +461,11 @@ func New(cfg *eksconfig.Config) (ts *Tester, err error) {
 	ts.ssmAPI = ssm.New(ts.awsSession)
 	ts.ssmAPIV2 = aws_ssm_v2.NewFromConfig(awsCfgV2)
 
-	ts.cfnAPI = cloudformation.New(ts.awsSession)
-	ts.cfnAPIV2 = aws_cfn_v2.NewFromConfig(awsCfgV2)
-
-	ts.ec2API = ec2.New(ts.awsSession)
-	if _, err = ts.ec2API.DescribeInstances(&ec2.DescribeInstancesInput{MaxResults: aws.Int64(5)}); err != nil {
-		return nil, fmt.Errorf("failed to describe instances using EC2 API v1 (%v)", err)
+	if ts.cfg.RemoteAccessInstanceConnect {
+		ts.ec2InstanceConnectAPI = ec2instanceconnect.New(ts.awsSession)
 	}
-	fmt.Fprintln(ts.logWriter, "EC2 API v1 available!")
+
+	ts.cfnAPIV2 = aws_cfn_v2.NewFromConfig(awsCfgV2)
 
 	ts.ec2APIV2 = aws_ec2_v2.NewFromConfig(awsCfgV2)
 	ctx, cancel = context.WithTimeout(context.Background(), 15*time.Second)
@@ -406,6 +476,16 @@ func New(cfg *eksconfig.Config) (ts *Tester, err error) {
 	}
 	fmt.Fprintln(ts.logWriter, "EC2 API v2 available!")
 
+	switch ts.cfg.RemoteAccessHostKeyCheck {
+	case eksconfig.RemoteAccessHostKeyCheckConsoleOutput:
+		ts.sshHostKeys, err = ssh.NewHostKeys(ts.lg, ssh.NewConsoleOutputHostKeyFetcher(ts.ec2APIV2), ts.cfg.RemoteAccessKnownHostsPath)
+	case eksconfig.RemoteAccessHostKeyCheckSSM:
+		ts.sshHostKeys, err = ssh.NewHostKeys(ts.lg, ssh.NewSSMHostKeyFetcher(ts.ssmAPIV2), ts.cfg.RemoteAccessKnownHostsPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pinned host keys (%v)", err)
+	}
+
 	// endpoints package no longer exists in the AWS SDK for Go V2
 	// "github.com/aws/aws-sdk-go/aws/endpoints" is deprecated...
 	// the check will be done in "eks" with AWS API call
@@ -500,17 +580,65 @@ func New(cfg *eksconfig.Config) (ts *Tester, err error) {
 	}
 	ts.cfg.Sync()
 
-	ts.s3API = s3.New(ts.awsSession)
-	ts.s3APIV2 = aws_s3_v2.NewFromConfig(awsCfgV2)
+	// only S3 requests use the S3 role, other clients keep the session credentials
+	s3Cfgs := []*aws.Config{}
+	s3OptFns := []func(*aws_s3_v2.Options){}
+	if ts.cfg.ServiceEndpoints.S3ForcePathStyle {
+		s3OptFns = append(s3OptFns, func(o *aws_s3_v2.Options) { o.UsePathStyle = true })
+	}
+	if ts.cfg.S3.RetryMaxAttempts > 0 || ts.cfg.S3.RetryMaxBackoff > 0 {
+		s3OptFns = append(s3OptFns, aws_s3.WithRetryerV2(ts.cfg.S3.RetryMaxAttempts, ts.cfg.S3.RetryMaxBackoff))
+	}
+	if ts.cfg.S3.RoleARN != "" {
+		ts.lg.Info("assuming role for S3 requests", zap.String("role-arn", ts.cfg.S3.RoleARN))
+		s3Cfgs = append(s3Cfgs, &aws.Config{
+			Credentials: stscreds.NewCredentials(ts.awsSession, ts.cfg.S3.RoleARN, func(p *stscreds.AssumeRoleProvider) {
+				if ts.cfg.S3.RoleExternalID != "" {
+					p.ExternalID = aws.String(ts.cfg.S3.RoleExternalID)
+				}
+			}),
+		})
+		s3OptFns = append(s3OptFns, aws_s3.WithAssumeRoleV2(ts.stsAPIV2, ts.cfg.S3.RoleARN, ts.cfg.S3.RoleExternalID))
+	}
+	s3BucketAccessOpts := []aws_s3.OpOption{
+		aws_s3.WithRequestPayer(ts.cfg.S3.RequesterPays),
+		aws_s3.WithExpectedBucketOwner(ts.cfg.S3.ExpectedBucketOwner),
+	}
+	// add-ons upload with the aws-sdk-go client
+	s3API := s3.New(ts.awsSession, s3Cfgs...)
+	aws_s3.WithBucketAccessHandlers(s3API, s3BucketAccessOpts...)
+	ts.s3API = s3API
+	ts.s3APIV2 = aws_s3_v2.NewFromConfig(awsCfgV2, s3OptFns...)
+	ts.s3Client = aws_s3.NewClientV2(ts.lg, ts.s3APIV2, s3BucketAccessOpts...)
 
 	ts.cwAPI = cloudwatch.New(ts.awsSession)
 	ts.cwAPIV2 = aws_cw_v2.NewFromConfig(awsCfgV2)
+	ts.cwLogsAPI = cloudwatchlogs.New(ts.awsSession)
 
 	ts.asgAPI = autoscaling.New(ts.awsSession)
 	ts.asgAPIV2 = aws_asg_v2.NewFromConfig(awsCfgV2)
 
 	ts.elbv2API = elbv2.New(ts.awsSession)
 	ts.elbv2APIV2 = aws_elbv2_v2.NewFromConfig(awsCfgV2)
+	ts.elbAPI = elb.New(ts.awsSession)
+
+	ts.pricingAPI = pricing.New(ts.awsSession, aws.NewConfig().WithRegion(cost.PricingRegion))
+	ts.taggingAPI = resourcegroupstaggingapi.New(ts.awsSession)
+
+	notifyCfg := notify.Config{Logger: ts.lg, EKSConfig: ts.cfg}
+	if arn := ts.cfg.Notifications.SNSTopicARN; arn != "" {
+		// the topic may be in another region
+		// e.g. "arn:aws:sns:us-west-2:123456789012:topic"
+		snsRegion := ts.cfg.Region
+		if ss := strings.Split(arn, ":"); len(ss) > 3 && ss[3] != "" {
+			snsRegion = ss[3]
+		}
+		notifyCfg.SNSAPI = sns.New(ts.awsSession, aws.NewConfig().WithRegion(snsRegion))
+	}
+	ts.notifier, err = notify.New(notifyCfg)
+	if err != nil {
+		return nil, err
+	}
 
 	ts.lg.Info("checking ECR API v1 availability; listing repositories")
 	ts.ecrAPISameRegion = ecr.New(ts.awsSession, aws.NewConfig().WithRegion(ts.cfg.Region))
@@ -551,11 +679,21 @@ func New(cfg *eksconfig.Config) (ts *Tester, err error) {
 		Region:        ts.cfg.Region,
 		ResolverURL:   ts.cfg.ResolverURL,
 		SigningName:   ts.cfg.SigningName,
+
+		Endpoints:        ts.cfg.ServiceEndpoints.AWSEndpoints(),
+		S3ForcePathStyle: ts.cfg.ServiceEndpoints.S3ForcePathStyle,
+		MaxAttempts:      awsMaxAttempts,
+		RetryMode:        pkg_aws.RetryModeAdaptive,
+
+		APICallObserver: observeAPICall,
+		Throttler:       getAWSThrottler(ts.lg),
+		AssumeRole:      ts.cfg.AssumeRole.AWSAssumeRole(),
 	})
 	if err != nil {
 		return nil, err
 	}
-	ts.eksAPIForCluster = aws_eks.New(eksSessionForCluster)
+	ts.eksClientForCluster = aws_eks.New(eksSessionForCluster)
+	ts.eksAPIForCluster = ts.eksClientForCluster
 
 	awsCfgV2EKS, err := pkg_aws.NewV2(&pkg_aws.Config{
 		Logger:        ts.lg,
@@ -564,6 +702,15 @@ func New(cfg *eksconfig.Config) (ts *Tester, err error) {
 		Region:        ts.cfg.Region,
 		ResolverURL:   ts.cfg.ResolverURL,
 		SigningName:   ts.cfg.SigningName,
+
+		Endpoints:        ts.cfg.ServiceEndpoints.AWSEndpoints(),
+		S3ForcePathStyle: ts.cfg.ServiceEndpoints.S3ForcePathStyle,
+		MaxAttempts:      awsMaxAttempts,
+		RetryMode:        pkg_aws.RetryModeAdaptive,
+
+		APICallObserver: observeAPICall,
+		Throttler:       getAWSThrottler(ts.lg),
+		AssumeRole:      ts.cfg.AssumeRole.AWSAssumeRole(),
 	})
 	if err != nil {
 		return nil, err
@@ -579,6 +726,15 @@ func New(cfg *eksconfig.Config) (ts *Tester, err error) {
 			Region:        ts.cfg.Region,
 			ResolverURL:   ts.cfg.AddOnManagedNodeGroups.ResolverURL,
 			SigningName:   ts.cfg.AddOnManagedNodeGroups.SigningName,
+
+			Endpoints:        ts.cfg.ServiceEndpoints.AWSEndpoints(),
+			S3ForcePathStyle: ts.cfg.ServiceEndpoints.S3ForcePathStyle,
+			MaxAttempts:      awsMaxAttempts,
+			RetryMode:        pkg_aws.RetryModeAdaptive,
+
+			APICallObserver: observeAPICall,
+			Throttler:       getAWSThrottler(ts.lg),
+			AssumeRole:      ts.cfg.AssumeRole.AWSAssumeRole(),
 		})
 		if err != nil {
 			return nil, err
@@ -592,6 +748,15 @@ func New(cfg *eksconfig.Config) (ts *Tester, err error) {
 			Region:        ts.cfg.Region,
 			ResolverURL:   ts.cfg.AddOnManagedNodeGroups.ResolverURL,
 			SigningName:   ts.cfg.AddOnManagedNodeGroups.SigningName,
+
+			Endpoints:        ts.cfg.ServiceEndpoints.AWSEndpoints(),
+			S3ForcePathStyle: ts.cfg.ServiceEndpoints.S3ForcePathStyle,
+			MaxAttempts:      awsMaxAttempts,
+			RetryMode:        pkg_aws.RetryModeAdaptive,
+
+			APICallObserver: observeAPICall,
+			Throttler:       getAWSThrottler(ts.lg),
+			AssumeRole:      ts.cfg.AssumeRole.AWSAssumeRole(),
 		})
 		if err != nil {
 			return nil, err
@@ -650,6 +815,8 @@ func New(cfg *eksconfig.Config) (ts *Tester, err error) {
 		ClientQPS:                          ts.cfg.ClientQPS,
 		ClientBurst:                        ts.cfg.ClientBurst,
 		ClientTimeout:                      ts.cfg.ClientTimeout,
+		ClientMaxRetries:                   ts.cfg.ClientMaxRetries,
+		AssumeRole:                         ts.cfg.AssumeRole.AWSAssumeRole(),
 	}
 	if ts.cfg.IsEnabledAddOnClusterVersionUpgrade() {
 		kcfg.UpgradeServerVersion = ts.cfg.AddOnClusterVersionUpgrade.Version
@@ -692,11 +859,12 @@ func (ts *Tester) createTesters() (err error) {
 		S3APIV2:    ts.s3APIV2,
 		IAMAPIV2:   ts.iamAPIV2,
 		KMSAPIV2:   ts.kmsAPIV2,
-		CFNAPI:     ts.cfnAPI,
 		EC2APIV2:   ts.ec2APIV2,
+		SSMAPIV2:   ts.ssmAPIV2,
 		EKSAPI:     ts.eksAPIForCluster,
 		EKSAPIV2:   ts.eksAPIForClusterV2,
 		ELBV2APIV2: ts.elbv2APIV2,
+		CWLogsAPI:  ts.cwLogsAPI,
 	})
 
 	ts.cniTester = cni_vpc.New(cni_vpc.Config{
@@ -708,17 +876,30 @@ func (ts *Tester) createTesters() (err error) {
 		ECRAPI:    ecr.New(ts.awsSession, aws.NewConfig().WithRegion(ts.cfg.GetAddOnCNIVPCRepositoryRegion())),
 	})
 
+	ts.accessManager = access.New(access.Config{
+		Logger:    ts.lg,
+		LogWriter: ts.logWriter,
+		Stopc:     ts.stopCreationCh,
+		EKSConfig: ts.cfg,
+		K8SClient: ts.k8sClient,
+		EKSAPI:    ts.eksClientForCluster,
+	})
+
 	ts.ngTester = ng.New(ng.Config{
 		Logger:    ts.lg,
 		LogWriter: ts.logWriter,
 		Stopc:     ts.stopCreationCh,
 		EKSConfig: ts.cfg,
 		K8SClient: ts.k8sClient,
+		Access:    ts.accessManager,
 
 		IAMAPIV2: ts.iamAPIV2,
 		SSMAPIV2: ts.ssmAPIV2,
 		EC2APIV2: ts.ec2APIV2,
 		ASGAPIV2: ts.asgAPIV2,
+
+		EC2InstanceConnectAPI: ts.ec2InstanceConnectAPI,
+		SSHHostKeys:           ts.sshHostKeys,
 	})
 	ts.mngTester = mng.New(mng.Config{
 		Logger:    ts.lg,
@@ -733,7 +914,8 @@ func (ts *Tester) createTesters() (err error) {
 		EKSAPI:   ts.eksAPIForMNG,
 		EKSAPIV2: ts.eksAPIForMNGV2,
 
-		CFNAPI: ts.cfnAPI,
+		EC2InstanceConnectAPI: ts.ec2InstanceConnectAPI,
+		SSHHostKeys:           ts.sshHostKeys,
 	})
 	ts.gpuTester = gpu.New(gpu.Config{
 		Logger:    ts.lg,
@@ -756,328 +938,68 @@ func (ts *Tester) createTesters() (err error) {
 		EKSConfig: ts.cfg,
 		K8SClient: ts.k8sClient,
 	})
+	ts.prometheusTester = prometheus.New(prometheus.Config{
+		Logger:    ts.lg,
+		LogWriter: ts.logWriter,
+		Stopc:     ts.stopCreationCh,
+		EKSConfig: ts.cfg,
+		K8SClient: ts.k8sClient,
+	})
 
-	// Groups of installable addons. Addons are installed in groups, where each group installs all components in parallel
-	ts.addons = [][]eks_tester.Addon{{
-		&clusterautoscaler.ClusterAutoscaler{
-			Config:    ts.cfg,
-			K8sClient: ts.k8sClient,
-		},
-		&overprovisioning.Overprovisioning{
-			Config:    ts.cfg,
-			K8sClient: ts.k8sClient,
-		},
-		&metrics_server.MetricsServer{
-			Config:    ts.cfg,
-			K8sClient: ts.k8sClient,
-		},
-		&clusterloader2.ClusterLoader{
-			Config:    ts.cfg,
-			K8sClient: ts.k8sClient,
-		},
-	}}
-
-	ts.testers = []eks_tester.Tester{
-		cw_agent.New(cw_agent.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-		}),
-		fluentd.New(fluentd.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			ECRAPI:    ecr.New(ts.awsSession, aws.NewConfig().WithRegion(ts.cfg.GetAddOnFluentdRepositoryBusyboxRegion())),
-		}),
-		metrics_server.New(metrics_server.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-		}),
-		conformance.New(conformance.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			S3API:     ts.s3API,
-		}),
-		app_mesh.New(app_mesh.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			S3API:     ts.s3API,
-			CFNAPI:    ts.cfnAPI,
-		}),
-		csi_ebs.New(csi_ebs.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-		}),
-		kubernetes_dashboard.New(kubernetes_dashboard.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-		}),
-		prometheus_grafana.New(prometheus_grafana.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			ELB2API:   ts.elbv2API,
-		}),
-		php_apache.New(php_apache.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			ECRAPI:    ecr.New(ts.awsSession, aws.NewConfig().WithRegion(ts.cfg.GetAddOnPHPApacheRepositoryRegion())),
-		}),
-		nlb_hello_world.New(nlb_hello_world.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			ELB2API:   ts.elbv2API,
-		}),
-		nlb_guestbook.New(nlb_guestbook.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			ELB2API:   ts.elbv2API,
-		}),
-		alb_2048.New(alb_2048.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			CFNAPI:    ts.cfnAPI,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			ELB2API:   ts.elbv2API,
-		}),
-		jobs_pi.New(jobs_pi.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-		}),
-		jobs_echo.New(jobs_echo.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			ECRAPI:    ecr.New(ts.awsSession, aws.NewConfig().WithRegion(ts.cfg.GetAddOnJobsEchoRepositoryBusyboxRegion())),
-		}),
-		cron_jobs.New(cron_jobs.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			ECRAPI:    ecr.New(ts.awsSession, aws.NewConfig().WithRegion(ts.cfg.GetAddOnCronJobsRepositoryBusyboxRegion())),
-		}),
-		csrs_local.New(csrs_local.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			S3API:     ts.s3API,
-			CWAPI:     ts.cwAPI,
-		}),
-		csrs_remote.New(csrs_remote.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			S3API:     ts.s3API,
-			CWAPI:     ts.cwAPI,
-			ECRAPI:    ecr.New(ts.awsSession, aws.NewConfig().WithRegion(ts.cfg.GetAddOnCSRsRemoteRepositoryRegion())),
-		}),
-		config_maps_local.New(config_maps_local.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			S3API:     ts.s3API,
-			CWAPI:     ts.cwAPI,
-		}),
-		config_maps_remote.New(config_maps_remote.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			S3API:     ts.s3API,
-			CWAPI:     ts.cwAPI,
-			ECRAPI:    ts.ecrAPISameRegion,
-		}),
-		secrets_local.New(secrets_local.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			S3API:     ts.s3API,
-			CWAPI:     ts.cwAPI,
-		}),
-		secrets_remote.New(secrets_remote.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			S3API:     ts.s3API,
-			CWAPI:     ts.cwAPI,
-			ECRAPI:    ecr.New(ts.awsSession, aws.NewConfig().WithRegion(ts.cfg.GetAddOnSecretsRemoteRepositoryRegion())),
-		}),
-		fargate.New(fargate.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			S3API:     ts.s3API,
-			IAMAPI:    ts.iamAPI,
-			CFNAPI:    ts.cfnAPI,
-			EKSAPI:    ts.eksAPIForCluster,
-			ECRAPI:    ecr.New(ts.awsSession, aws.NewConfig().WithRegion(ts.cfg.GetAddOnFargateRepositoryRegion())),
-		}),
-		irsa.New(irsa.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			S3API:     ts.s3API,
-			CFNAPI:    ts.cfnAPI,
-			IAMAPI:    ts.iamAPI,
-			ECRAPI:    ecr.New(ts.awsSession, aws.NewConfig().WithRegion(ts.cfg.GetAddOnIRSARepositoryRegion())),
-		}),
-		irsa_fargate.New(irsa_fargate.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			S3API:     ts.s3API,
-			IAMAPI:    ts.iamAPI,
-			CFNAPI:    ts.cfnAPI,
-			EKSAPI:    ts.eksAPIForCluster,
-			ECRAPI:    ecr.New(ts.awsSession, aws.NewConfig().WithRegion(ts.cfg.GetAddOnIRSAFargateRepositoryRegion())),
-		}),
-		wordpress.New(wordpress.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			ELB2API:   ts.elbv2API,
-		}),
-		jupyter_hub.New(jupyter_hub.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			ELB2API:   ts.elbv2API,
-		}),
-		kubeflow.New(kubeflow.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-		}),
-		cuda_vector_add.New(cuda_vector_add.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-		}),
-		cluster_loader_local.New(cluster_loader_local.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			S3API:     ts.s3API,
-			CWAPI:     ts.cwAPI,
-		}),
-		cluster_loader_remote.New(cluster_loader_remote.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			S3API:     ts.s3API,
-			CWAPI:     ts.cwAPI,
-			ECRAPI:    ecr.New(ts.awsSession, aws.NewConfig().WithRegion(ts.cfg.GetAddOnClusterLoaderRemoteRepositoryRegion())),
-		}),
-		stresser_local.New(stresser_local.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			S3API:     ts.s3API,
-			CWAPI:     ts.cwAPI,
-		}),
-		stresser_remote.New(stresser_remote.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			S3API:     ts.s3API,
-			CWAPI:     ts.cwAPI,
-			ECRAPI:    ecr.New(ts.awsSession, aws.NewConfig().WithRegion(ts.cfg.GetAddOnStresserRemoteRepositoryRegion())),
-		}),
-		stresser_remote_v2.New(stresser_remote_v2.Config{
-			Logger:    ts.lg,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			ECRAPI:    ecr.New(ts.awsSession, aws.NewConfig().WithRegion(ts.cfg.GetAddOnStresserRemoteV2RepositoryRegion())),
-		}),
-		cluster_version_upgrade.New(cluster_version_upgrade.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-			EKSAPI:    ts.eksAPIForCluster,
-		}),
-		ami_soft_lockup_issue_454.New(ami_soft_lockup_issue_454.Config{
-			Logger:    ts.lg,
-			LogWriter: ts.logWriter,
-			Stopc:     ts.stopCreationCh,
-			EKSConfig: ts.cfg,
-			K8SClient: ts.k8sClient,
-		}),
+	// Groups of registered addons in the dependency order.
+	// Addons are installed in groups, where each group installs all components in parallel
+	groups, err := eks_tester.Order(eks_tester.Registered())
+	if err != nil {
+		return err
 	}
+	addonCfg := eks_tester.AddonConfig{
+		Logger:    ts.lg,
+		LogWriter: ts.logWriter,
+		Stopc:     ts.stopCreationCh,
+		EKSConfig: ts.cfg,
+		K8SClient: ts.k8sClient,
+
+		EKSAPI:     ts.eksAPIForCluster,
+		EC2APIV2:   ts.ec2APIV2,
+		IAMAPIV2:   ts.iamAPIV2,
+		ELBV2APIV2: ts.elbv2APIV2,
+
+		AWSSession: ts.awsSession,
+		ECRAPI:     ts.ecrAPISameRegion,
+		S3API:      ts.s3API,
+		CWAPI:      ts.cwAPI,
+		CWLogsAPI:  ts.cwLogsAPI,
+		ELBV2API:   ts.elbv2API,
+		IAMAPI:     ts.iamAPI,
+		CFNAPIV2:   ts.cfnAPIV2,
+		EKSClient:  ts.eksClientForCluster,
+
+		SSMAPIV2:              ts.ssmAPIV2,
+		EC2InstanceConnectAPI: ts.ec2InstanceConnectAPI,
+		SSHHostKeys:           ts.sshHostKeys,
+
+		Access:      ts.accessManager,
+		MNGUpgrader: ts.mngTester,
+	}
+	ts.addons = make([][]eks_tester.Addon, 0, len(groups))
+	for _, group := range groups {
+		addons := make([]eks_tester.Addon, 0, len(group))
+		for _, r := range group {
+			addons = append(addons, r.New(addonCfg))
+		}
+		ts.addons = append(ts.addons, addons)
+	}
+
+	ts.clusterVersionUpgrader = cluster_version_upgrade.New(cluster_version_upgrade.Config{
+		Logger:      ts.lg,
+		LogWriter:   ts.logWriter,
+		Stopc:       ts.stopCreationCh,
+		EKSConfig:   ts.cfg,
+		K8SClient:   ts.k8sClient,
+		EKSAPI:      ts.eksAPIForCluster,
+		MNGUpgrader: ts.mngTester,
+	})
+
 	if serr := ts.cfg.Sync(); serr != nil {
 		fmt.Fprintf(ts.logWriter, ts.color("[light_magenta]cfg.Sync failed [default]%v\n"), serr)
 	}
@@ -1092,19 +1014,52 @@ func (ts *Tester) Up() (err error) {
 	fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
 	fmt.Fprintf(ts.logWriter, ts.color("[light_green]UP START [default](%q, %q)\n"), ts.cfg.ConfigPath, user.Get())
 
+	if ts.ctx != nil && ts.ctx.Err() != nil {
+		return fmt.Errorf("not creating cluster (%v)", ts.ctx.Err())
+	}
+
 	now := time.Now()
 
+	atomic.StoreInt32(&ts.upRunning, 1)
+	stopDeadline := ts.startRunDeadline()
+	stopReadyz := func() {}
 	defer func() {
+		defer atomic.StoreInt32(&ts.upRunning, 0)
+		stopDeadline()
+		stopReadyz()
+		cleanupOnSignal := false
+		if sig := ts.Signaled(); sig != nil && ts.cfg.OnSignalCleanup {
+			fmt.Fprintf(ts.logWriter, ts.color("\n\n[light_magenta]received %v; cleaning up [default](%q)\n"), sig, ts.cfg.ConfigPath)
+			ts.runSignalHooks(sig)
+			ts.recoverStopped()
+			cleanupOnSignal = true
+			if err == nil {
+				err = fmt.Errorf("received %v", sig)
+			}
+		} else if err != nil && ts.deadlineExceeded() != "" {
+			ts.afterDeadline()
+		}
+
 		fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
 		fmt.Fprintf(ts.logWriter, ts.color("[light_green]UP DEFER START [default](%q)\n"), ts.cfg.ConfigPath)
 		fmt.Fprintf(ts.logWriter, "\n\n# to delete cluster\naws-k8s-tester eks delete cluster --path %s\n\n", ts.cfg.ConfigPath)
 		ts.logFile.Sync()
+		ts.writeReport()
+		ts.recordResources()
+		if err == nil {
+			ts.detectStackDrifts()
+		}
 
 		if serr := ts.uploadToS3(); serr != nil {
 			ts.lg.Warn("failed to upload artifacts to S3", zap.Error(serr))
 		} else {
 			ts.s3Uploaded = true
 		}
+		if err != nil {
+			ts.notifier.Notify(eksconfig.NotificationEventUpFailed, fmt.Sprintf("up failed after %v", time.Since(now).Round(time.Second)), err)
+		} else if ts.cfg.Status.Up {
+			ts.notifier.Notify(eksconfig.NotificationEventUpSucceeded, fmt.Sprintf("up succeeded in %v", time.Since(now).Round(time.Second)), nil)
+		}
 
 		if err == nil {
 			if ts.cfg.Status.Up {
@@ -1135,7 +1090,7 @@ func (ts *Tester) Up() (err error) {
 			return
 		}
 
-		if !ts.cfg.OnFailureDelete {
+		if !ts.cfg.OnFailureDelete && !cleanupOnSignal {
 			if ts.cfg.Status.Up {
 				if ts.cfg.TotalNodes < 10 {
 					fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
@@ -1182,7 +1137,7 @@ func (ts *Tester) Up() (err error) {
 			zap.Error(err),
 		)
 		waitDur := time.Duration(ts.cfg.OnFailureDeleteWaitSeconds) * time.Second
-		if waitDur > 0 {
+		if waitDur > 0 && !cleanupOnSignal {
 			ts.lg.Info("waiting before clean up", zap.Duration("wait", waitDur))
 			select {
 			case <-ts.stopCreationCh:
@@ -1214,6 +1169,17 @@ func (ts *Tester) Up() (err error) {
 	)
 	defer ts.cfg.Sync()
 
+	if err := catchInterrupt(
+		ts.lg,
+		ts.stopCreationCh,
+		ts.stopCreationChOnce,
+		ts.osSig,
+		func() error { return ts.runHooks(eksconfig.HookPhasePreCreate) },
+		"hooks."+eksconfig.HookPhasePreCreate,
+	); err != nil {
+		return err
+	}
+
 	fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
 	fmt.Fprintf(ts.logWriter, ts.color("[light_green]createS3 [default](%q)\n"), ts.cfg.ConfigPath)
 	if err := catchInterrupt(
@@ -1221,7 +1187,7 @@ func (ts *Tester) Up() (err error) {
 		ts.stopCreationCh,
 		ts.stopCreationChOnce,
 		ts.osSig,
-		ts.createS3,
+		ts.report.Wrap("up", "createS3", ts.resumable("createS3", ts.createS3)),
 		"createS3",
 	); err != nil {
 		return err
@@ -1234,12 +1200,25 @@ func (ts *Tester) Up() (err error) {
 		ts.stopCreationCh,
 		ts.stopCreationChOnce,
 		ts.osSig,
-		ts.createKeyPair,
+		ts.report.Wrap("up", "createKeyPair", ts.resumable("createKeyPair", ts.createKeyPair)),
 		"createKeyPair",
 	); err != nil {
 		return err
 	}
 
+	fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
+	fmt.Fprintf(ts.logWriter, ts.color("[light_green]mirrorImages [default](%q)\n"), ts.cfg.ConfigPath)
+	if err := catchInterrupt(
+		ts.lg,
+		ts.stopCreationCh,
+		ts.stopCreationChOnce,
+		ts.osSig,
+		ts.report.Wrap("up", "mirrorImages", ts.mirrorImages),
+		"mirrorImages",
+	); err != nil {
+		return err
+	}
+
 	fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
 	fmt.Fprintf(ts.logWriter, ts.color("[light_green]createCluster [default](%q, %q)\n"), ts.cfg.ConfigPath, ts.cfg.KubectlCommand())
 	if err := catchInterrupt(
@@ -1247,15 +1226,17 @@ func (ts *Tester) Up() (err error) {
 		ts.stopCreationCh,
 		ts.stopCreationChOnce,
 		ts.osSig,
-		ts.clusterTester.Create,
+		ts.report.Wrap("up", ts.clusterTester.Name()+".Create", ts.withTimeout(ts.clusterTester.Name()+".Create", ts.cfg.ClusterCreateTimeout, ts.clusterTester.Create)),
 		ts.clusterTester.Name(),
 	); err != nil {
 		return err
 	}
 	ts.k8sClient = ts.clusterTester.Client()
+	stopReadyz = ts.startReadyzSampler()
 	if err := ts.createTesters(); err != nil {
 		return err
 	}
+	ts.notifier.Notify(eksconfig.NotificationEventClusterReady, fmt.Sprintf("cluster %q is ready (%s)", ts.cfg.Name, ts.cfg.Status.ClusterAPIServerEndpoint), nil)
 
 	if ts.cfg.KubeControllerManagerQPS != "" &&
 		ts.cfg.KubeControllerManagerBurst != "" &&
@@ -1313,6 +1294,21 @@ func (ts *Tester) Up() (err error) {
 		ts.lg.Warn("failed to upload artifacts to S3", zap.Error(serr))
 	}
 
+	if len(ts.cfg.Access.Entries) > 0 {
+		fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
+		fmt.Fprintf(ts.logWriter, ts.color("[light_green]accessManager.Create [default](%q, mode %q)\n"), ts.cfg.ConfigPath, ts.cfg.Access.Mode)
+		if err := catchInterrupt(
+			ts.lg,
+			ts.stopCreationCh,
+			ts.stopCreationChOnce,
+			ts.osSig,
+			ts.report.Wrap("up", "accessManager.Create", ts.resumable("accessManager.Create", ts.accessManager.Create)),
+			"accessManager",
+		); err != nil {
+			return err
+		}
+	}
+
 	if ts.cfg.IsEnabledAddOnCNIVPC() {
 		if ts.cniTester == nil {
 			return errors.New("ts.cniTester == nil when AddOnCNIVPC.Enable == true")
@@ -1325,13 +1321,28 @@ func (ts *Tester) Up() (err error) {
 			ts.stopCreationCh,
 			ts.stopCreationChOnce,
 			ts.osSig,
-			ts.cniTester.Create,
+			ts.report.Wrap("up", ts.cniTester.Name()+".Create", ts.resumable(ts.cniTester.Name()+".Create", ts.cniTester.Create)),
 			ts.cniTester.Name(),
 		); err != nil {
 			return err
 		}
 	}
 
+	if ts.cfg.CNIConfig.IsSet() {
+		fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
+		fmt.Fprintf(ts.logWriter, ts.color("[light_green]applyCNIConfig [default](%q, %v)\n"), ts.cfg.ConfigPath, ts.cfg.CNIConfig.Envs())
+		if err := catchInterrupt(
+			ts.lg,
+			ts.stopCreationCh,
+			ts.stopCreationChOnce,
+			ts.osSig,
+			ts.report.Wrap("up", "applyCNIConfig", ts.resumable("applyCNIConfig", ts.applyCNIConfig)),
+			"applyCNIConfig",
+		); err != nil {
+			return err
+		}
+	}
+
 	if ts.cfg.IsEnabledAddOnNodeGroups() {
 		if ts.ngTester == nil {
 			return errors.New("ts.ngTester == nil when AddOnNodeGroups.Enable == true")
@@ -1345,7 +1356,7 @@ func (ts *Tester) Up() (err error) {
 			ts.stopCreationCh,
 			ts.stopCreationChOnce,
 			ts.osSig,
-			ts.ngTester.Create,
+			ts.report.Wrap("up", ts.ngTester.Name()+".Create", ts.resumable(ts.ngTester.Name()+".Create", ts.withTimeout(ts.ngTester.Name()+".Create", ts.cfg.NodeGroupsCreateTimeout, ts.ngTester.Create))),
 			ts.ngTester.Name(),
 		); err != nil {
 			return err
@@ -1364,15 +1375,24 @@ func (ts *Tester) Up() (err error) {
 			ts.stopCreationCh,
 			ts.stopCreationChOnce,
 			ts.osSig,
-			ts.mngTester.Create,
+			ts.report.Wrap("up", ts.mngTester.Name()+".Create", ts.resumable(ts.mngTester.Name()+".Create", ts.withTimeout(ts.mngTester.Name()+".Create", ts.cfg.ManagedNodeGroupsCreateTimeout, ts.mngTester.Create))),
 			ts.mngTester.Name(),
 		); err != nil {
 			return err
 		}
 	}
 
+	if ts.cfg.VPC.DisableNATGateways && (ts.cfg.IsEnabledAddOnNodeGroups() || ts.cfg.IsEnabledAddOnManagedNodeGroups()) {
+		fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
+		fmt.Fprintf(ts.logWriter, ts.color("[light_green]verifyPrivateNodes [default](%q, %q)\n"), ts.cfg.ConfigPath, ts.cfg.KubectlCommand())
+		if err := ts.verifyPrivateNodes(); err != nil {
+			return err
+		}
+	}
+
+	// AddOnGPU installs its own device plugin on the GPU node group
 	needGPU := false
-	if ts.cfg.IsEnabledAddOnNodeGroups() {
+	if ts.cfg.IsEnabledAddOnNodeGroups() && !ts.cfg.IsEnabledAddOnGPU() {
 	gpuFound1:
 		for _, mv := range ts.cfg.AddOnNodeGroups.ASGs {
 			switch mv.AMIType {
@@ -1382,7 +1402,7 @@ func (ts *Tester) Up() (err error) {
 			}
 		}
 	}
-	if !needGPU && ts.cfg.IsEnabledAddOnManagedNodeGroups() {
+	if !needGPU && ts.cfg.IsEnabledAddOnManagedNodeGroups() && !ts.cfg.IsEnabledAddOnGPU() {
 	gpuFound2:
 		for _, mv := range ts.cfg.AddOnManagedNodeGroups.MNGs {
 			switch mv.AMIType {
@@ -1400,7 +1420,7 @@ func (ts *Tester) Up() (err error) {
 			ts.stopCreationCh,
 			ts.stopCreationChOnce,
 			ts.osSig,
-			ts.gpuTester.DeployMPIOperator,
+			ts.report.Wrap("up", ts.gpuTester.Name()+".DeployMPIOperator", ts.resumable(ts.gpuTester.Name()+".DeployMPIOperator", ts.gpuTester.DeployMPIOperator)),
 			ts.gpuTester.Name(),
 		); err != nil {
 			ts.lg.Warn("failed to deploy MPI", zap.Error(err))
@@ -1414,7 +1434,7 @@ func (ts *Tester) Up() (err error) {
 			ts.stopCreationCh,
 			ts.stopCreationChOnce,
 			ts.osSig,
-			ts.gpuTester.InstallNvidiaDriver,
+			ts.report.Wrap("up", ts.gpuTester.Name()+".InstallNvidiaDriver", ts.resumable(ts.gpuTester.Name()+".InstallNvidiaDriver", ts.gpuTester.InstallNvidiaDriver)),
 			ts.gpuTester.Name(),
 		); err != nil {
 			ts.lg.Warn("failed to install nvidia driver", zap.Error(err))
@@ -1428,7 +1448,7 @@ func (ts *Tester) Up() (err error) {
 			ts.stopCreationCh,
 			ts.stopCreationChOnce,
 			ts.osSig,
-			ts.gpuTester.CreateMPIJob,
+			ts.report.Wrap("up", ts.gpuTester.Name()+".CreateMPIJob", ts.resumable(ts.gpuTester.Name()+".CreateMPIJob", ts.gpuTester.CreateMPIJob)),
 			ts.gpuTester.Name(),
 		); err != nil {
 			ts.lg.Warn("failed to create MPI job", zap.Error(err))
@@ -1442,7 +1462,7 @@ func (ts *Tester) Up() (err error) {
 			ts.stopCreationCh,
 			ts.stopCreationChOnce,
 			ts.osSig,
-			ts.neuronTester.InstallNeuronDriver,
+			ts.report.Wrap("up", ts.neuronTester.Name()+".InstallNeuronDriver", ts.resumable(ts.neuronTester.Name()+".InstallNeuronDriver", ts.neuronTester.InstallNeuronDriver)),
 			ts.neuronTester.Name(),
 		); err != nil {
 			ts.lg.Warn("failed to install neuron driver", zap.Error(err))
@@ -1456,7 +1476,7 @@ func (ts *Tester) Up() (err error) {
 			ts.stopCreationCh,
 			ts.stopCreationChOnce,
 			ts.osSig,
-			ts.neuronTester.InstallBertService,
+			ts.report.Wrap("up", ts.neuronTester.Name()+".InstallBertService", ts.resumable(ts.neuronTester.Name()+".InstallBertService", ts.neuronTester.InstallBertService)),
 			ts.neuronTester.Name(),
 		); err != nil {
 			ts.lg.Warn("failed to install bert service", zap.Error(err))
@@ -1470,7 +1490,7 @@ func (ts *Tester) Up() (err error) {
 			ts.stopCreationCh,
 			ts.stopCreationChOnce,
 			ts.osSig,
-			ts.neuronTester.CreateBertJob,
+			ts.report.Wrap("up", ts.neuronTester.Name()+".CreateBertJob", ts.resumable(ts.neuronTester.Name()+".CreateBertJob", ts.neuronTester.CreateBertJob)),
 			ts.neuronTester.Name(),
 		); err != nil {
 			ts.lg.Warn("failed to create bert job", zap.Error(err))
@@ -1484,7 +1504,7 @@ func (ts *Tester) Up() (err error) {
 			ts.stopCreationCh,
 			ts.stopCreationChOnce,
 			ts.osSig,
-			ts.trainiumTester.InstallNeuronDriver,
+			ts.report.Wrap("up", ts.trainiumTester.Name()+".InstallNeuronDriver", ts.resumable(ts.trainiumTester.Name()+".InstallNeuronDriver", ts.trainiumTester.InstallNeuronDriver)),
 			ts.trainiumTester.Name(),
 		); err != nil {
 			ts.lg.Warn("failed to install neuron driver", zap.Error(err))
@@ -1498,7 +1518,7 @@ func (ts *Tester) Up() (err error) {
 			ts.stopCreationCh,
 			ts.stopCreationChOnce,
 			ts.osSig,
-			ts.trainiumTester.CreateTrainiumJob,
+			ts.report.Wrap("up", ts.trainiumTester.Name()+".CreateTrainiumJob", ts.resumable(ts.trainiumTester.Name()+".CreateTrainiumJob", ts.trainiumTester.CreateTrainiumJob)),
 			ts.trainiumTester.Name(),
 		); err != nil {
 			ts.lg.Warn("failed to create trainium job", zap.Error(err))
@@ -1517,7 +1537,7 @@ func (ts *Tester) Up() (err error) {
 		ts.stopCreationCh,
 		ts.stopCreationChOnce,
 		ts.osSig,
-		ts.clusterTester.CheckHealth,
+		ts.report.Wrap("up", ts.clusterTester.Name()+".CheckHealth", ts.clusterTester.CheckHealth),
 		ts.clusterTester.Name(),
 	); err != nil {
 		return err
@@ -1526,21 +1546,64 @@ func (ts *Tester) Up() (err error) {
 		ts.lg.Warn("failed to upload artifacts to S3", zap.Error(serr))
 	}
 
-	for idx, cur := range ts.testers {
+	// Generic installation of ordered addons. Register your addon with "eks/tester.Register"
+	// add-ons in the same group do not depend on each other, so apply concurrently
+	for idx, order := range ts.addons {
 		fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
-		fmt.Fprintf(ts.logWriter, ts.color("[light_green]testers[%02d].Create [cyan]%q [default](%q, %q)\n"), idx, cur.Name(), ts.cfg.ConfigPath, ts.cfg.KubectlCommand())
+		fmt.Fprintf(ts.logWriter, ts.color("[light_green]addons[%02d].Apply [default](%q, concurrency %d)\n"), idx, ts.cfg.ConfigPath, ts.cfg.CreateConcurrency)
+		steps := make([]string, 0, len(order))
+		fns := make([]func() error, 0, len(order))
+		for _, addon := range order {
+			if !addon.IsEnabled() {
+				klog.Infof("Skipping disabled addon %s", addonName(addon))
+				continue
+			}
+			a := addon
+			step := addonName(a) + ".Apply"
+			steps = append(steps, step)
+			fns = append(fns, func() error {
+				ts.lg.Info("applying addon", zap.String("addon", addonName(a)))
+				if err := ts.report.Wrap("up", step, ts.resumable(step, ts.withTimeout(step, ts.cfg.AddOnCreateTimeout, a.Apply)))(); err != nil {
+					ts.notifier.Notify(eksconfig.NotificationEventAddOnFailed, fmt.Sprintf("add-on %q failed", addonName(a)), err)
+					return fmt.Errorf("failed to apply addon %s (%v)", addonName(a), err)
+				}
+				return nil
+			})
+		}
+		if len(fns) == 0 {
+			continue
+		}
+
+		health := ts.takeHealthSnapshot()
 		err := catchInterrupt(
 			ts.lg,
 			ts.stopCreationCh,
 			ts.stopCreationChOnce,
 			ts.osSig,
-			cur.Create,
-			cur.Name(),
+			func() error {
+				errs := runBounded(ts.cfg.CreateConcurrency, fns...)
+				if len(errs) == 0 {
+					return nil
+				}
+				ss := make([]string, 0, len(errs))
+				for _, err := range errs {
+					ss = append(ss, err.Error())
+				}
+				sort.Strings(ss)
+				return fmt.Errorf("while applying addons, %d addon(s) failed: %s", len(errs), strings.Join(ss, ", "))
+			},
+			fmt.Sprintf("addons[%02d]", idx),
 		)
+		// exclusive add-ons run alone, so the diff is attributed exactly;
+		// add-ons applied concurrently share the diff of their group
+		for _, step := range steps {
+			ts.annotateHealthDiff("up", step, health)
+		}
+		ts.cfg.Sync()
 
 		if idx%10 == 0 {
 			fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
-			fmt.Fprintf(ts.logWriter, ts.color("[light_green]testers[%02d] [cyan]%q.CheckHealth [default](%q, %q)\n"), idx, ts.clusterTester.Name(), ts.cfg.ConfigPath, ts.cfg.KubectlCommand())
+			fmt.Fprintf(ts.logWriter, ts.color("[light_green]addons[%02d] [cyan]%q.CheckHealth [default](%q, %q)\n"), idx, ts.clusterTester.Name(), ts.cfg.ConfigPath, ts.cfg.KubectlCommand())
 			if ts.k8sClient == nil {
 				// TODO: investigate why "ts.k8sClient == nil"
 				ts.lg.Warn("[TODO] unexpected nil k8s client after cluster creation")
@@ -1550,14 +1613,14 @@ func (ts *Tester) Up() (err error) {
 				ts.stopCreationCh,
 				ts.stopCreationChOnce,
 				ts.osSig,
-				ts.clusterTester.CheckHealth,
+				ts.report.Wrap("up", ts.clusterTester.Name()+".CheckHealth", ts.clusterTester.CheckHealth),
 				ts.clusterTester.Name(),
 			); err != nil {
 				return err
 			}
 
 			fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
-			fmt.Fprintf(ts.logWriter, ts.color("[light_green]testers[%02d] uploadToS3 [cyan]%q [default](%q, %q)\n"), idx, cur.Name(), ts.cfg.ConfigPath, ts.cfg.KubectlCommand())
+			fmt.Fprintf(ts.logWriter, ts.color("[light_green]addons[%02d] uploadToS3 [default](%q, %q)\n"), idx, ts.cfg.ConfigPath, ts.cfg.KubectlCommand())
 			if serr := ts.uploadToS3(); serr != nil {
 				ts.lg.Warn("failed to upload artifacts to S3", zap.Error(serr))
 			}
@@ -1584,7 +1647,7 @@ func (ts *Tester) Up() (err error) {
 			ts.stopCreationCh,
 			ts.stopCreationChOnce,
 			ts.osSig,
-			ts.ngTester.FetchLogs,
+			ts.report.Wrap("up", ts.ngTester.Name()+".FetchLogs", ts.ngTester.FetchLogs),
 			ts.ngTester.Name(),
 		); err != nil {
 			return err
@@ -1606,7 +1669,7 @@ func (ts *Tester) Up() (err error) {
 			ts.stopCreationCh,
 			ts.stopCreationChOnce,
 			ts.osSig,
-			ts.mngTester.FetchLogs,
+			ts.report.Wrap("up", ts.mngTester.Name()+".FetchLogs", ts.mngTester.FetchLogs),
 			ts.mngTester.Name(),
 		); err != nil {
 			return err
@@ -1624,7 +1687,7 @@ func (ts *Tester) Up() (err error) {
 		ts.stopCreationCh,
 		ts.stopCreationChOnce,
 		ts.osSig,
-		ts.clusterTester.CheckHealth,
+		ts.report.Wrap("up", ts.clusterTester.Name()+".CheckHealth", ts.clusterTester.CheckHealth),
 		ts.clusterTester.Name(),
 	); err != nil {
 		return err
@@ -1682,20 +1745,20 @@ func (ts *Tester) Up() (err error) {
 			ts.stopCreationCh,
 			ts.stopCreationChOnce,
 			ts.osSig,
-			ts.mngTester.Scale,
+			ts.report.Wrap("up", ts.mngTester.Name()+".Scale", ts.resumable(ts.mngTester.Name()+".Scale", ts.mngTester.Scale)),
 			ts.mngTester.Name(),
 		); err != nil {
 			return err
 		}
 
 		fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
-		fmt.Fprintf(ts.logWriter, ts.color("[light_green]mngTester.UpgradeVersion [default](%q, logFetchAgain %v)\n"), ts.cfg.ConfigPath, logFetchAgain)
+		fmt.Fprintf(ts.logWriter, ts.color("[light_green]mngTester.Upgrade [default](%q, logFetchAgain %v)\n"), ts.cfg.ConfigPath, logFetchAgain)
 		if err := catchInterrupt(
 			ts.lg,
 			ts.stopCreationCh,
 			ts.stopCreationChOnce,
 			ts.osSig,
-			ts.mngTester.UpgradeVersion,
+			ts.report.Wrap("up", ts.mngTester.Name()+".Upgrade", ts.resumable(ts.mngTester.Name()+".Upgrade", ts.mngTester.Upgrade)),
 			ts.mngTester.Name(),
 		); err != nil {
 			return err
@@ -1717,7 +1780,7 @@ func (ts *Tester) Up() (err error) {
 			ts.stopCreationCh,
 			ts.stopCreationChOnce,
 			ts.osSig,
-			ts.mngTester.FetchLogs,
+			ts.report.Wrap("up", ts.mngTester.Name()+".FetchLogs", ts.mngTester.FetchLogs),
 			ts.mngTester.Name(),
 		); err != nil {
 			return err
@@ -1746,15 +1809,15 @@ func (ts *Tester) Up() (err error) {
 		fmt.Fprintf(ts.logWriter, "\nrunCommand output:\n\n%s\n", string(out))
 	}
 
-	// Generic installation of ordered addons. Add your addon to ts.addons
-	for _, order := range ts.addons {
-		if err := ts.runAsync(order, func(a eks_tester.Addon) error {
-			zap.S().Infof("Applying addon %s", reflect.TypeOf(a))
-			return a.Apply()
-		}); err != nil {
-			return fmt.Errorf("while applying addons, %w", err)
-		}
-		ts.cfg.Sync()
+	if err := catchInterrupt(
+		ts.lg,
+		ts.stopCreationCh,
+		ts.stopCreationChOnce,
+		ts.osSig,
+		func() error { return ts.runHooks(eksconfig.HookPhasePostCreate) },
+		"hooks."+eksconfig.HookPhasePostCreate,
+	); err != nil {
+		return err
 	}
 	if serr := ts.cfg.Sync(); serr != nil {
 		fmt.Fprintf(ts.logWriter, ts.color("[light_magenta]cfg.Sync failed [default]%v\n"), serr)
@@ -1763,6 +1826,27 @@ func (ts *Tester) Up() (err error) {
 	return nil
 }
 
+// Upgrade upgrades the EKS cluster control plane to the target version,
+// and cascades the upgrade to managed node groups and EKS managed add-ons
+// as configured in "AddOnClusterVersionUpgrade". If any phase fails,
+// the rollback report is written to "AddOnClusterVersionUpgrade.RollbackReportPath".
+func (ts *Tester) Upgrade(targetVersion string) error {
+	if !ts.cfg.IsEnabledAddOnClusterVersionUpgrade() {
+		return errors.New("AddOnClusterVersionUpgrade not enabled")
+	}
+	if ts.clusterVersionUpgrader == nil {
+		return errors.New("ts.clusterVersionUpgrader == nil when AddOnClusterVersionUpgrade.Enable == true")
+	}
+	return catchInterrupt(
+		ts.lg,
+		ts.stopCreationCh,
+		ts.stopCreationChOnce,
+		ts.osSig,
+		ts.report.Wrap("upgrade", ts.clusterVersionUpgrader.Name(), func() error { return ts.clusterVersionUpgrader.Upgrade(targetVersion) }),
+		ts.clusterVersionUpgrader.Name(),
+	)
+}
+
 // Down cancels the cluster creation and destroy the test cluster if any.
 // ref. https://pkg.go.dev/k8s.io/test-infra/kubetest2/pkg/types?tab=doc#Deployer
 // ref. https://pkg.go.dev/k8s.io/test-infra/kubetest2/pkg/types?tab=doc#Options
@@ -1782,15 +1866,36 @@ func (ts *Tester) down() (err error) {
 		zap.String("name", ts.cfg.Name),
 		zap.String("cluster-arn", ts.cfg.Status.ClusterARN),
 	)
-	if !ts.s3Uploaded {
+	// once deletion starts, the next "Up" must not skip any step
+	ts.cfg.ResetCompletedSteps()
+
+	// run before uploading the artifacts to include the hook outputs
+	// hook failures do not block the deletion
+	var errs []string
+	if err := ts.runHooks(eksconfig.HookPhasePreDelete); err != nil {
+		ts.lg.Warn("failed pre-delete hooks", zap.Error(err))
+		errs = append(errs, err.Error())
+	}
+
+	// upload artifacts while deleting resources
+	// wait before deleting the S3 bucket
+	uploadDonec := make(chan struct{})
+	go func() {
+		defer close(uploadDonec)
+		if ts.s3Uploaded {
+			return
+		}
 		if serr := ts.uploadToS3(); serr != nil {
 			ts.lg.Warn("failed to upload artifacts to S3", zap.Error(serr))
 		}
-	}
+	}()
 
 	defer func() {
+		<-uploadDonec
 		ts.logFile.Sync()
 		ts.cfg.Sync()
+		ts.writeReport()
+		ts.notifier.Notify(eksconfig.NotificationEventTeardownComplete, fmt.Sprintf("teardown completed in %v", time.Since(now).Round(time.Second)), err)
 
 		if err == nil {
 			fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
@@ -1813,29 +1918,50 @@ func (ts *Tester) down() (err error) {
 		}
 	}()
 
-	var errs []string
-
 	if ts.cfg.SkipDeleteClusterAndNodes {
 		fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
 		fmt.Fprintf(ts.logWriter, ts.color("[light_yellow]SKIP [light_blue]deleteKeyPair [default](SkipDeleteClusterAndNodes 'true', %q)\n"), ts.cfg.ConfigPath)
 	} else {
 		fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
 		fmt.Fprintf(ts.logWriter, ts.color("[light_blue]deleteKeyPair [default](%q)\n"), ts.cfg.ConfigPath)
-		if err := ts.deleteKeyPair(); err != nil {
+		if err := ts.report.Wrap("down", "deleteKeyPair", ts.deleteKeyPair)(); err != nil {
 			ts.lg.Warn("failed to delete key pair", zap.Error(err))
 			errs = append(errs, err.Error())
 		}
 	}
 
-	testersN := len(ts.testers)
-	for idx := range ts.testers {
-		idx = testersN - idx - 1
-		cur := ts.testers[idx]
-		fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
-		fmt.Fprintf(ts.logWriter, ts.color("[light_blue]testers[%02d].Delete [cyan]%q [default](%q, %q)\n"), idx, cur.Name(), ts.cfg.ConfigPath, ts.cfg.KubectlCommand())
-		if err := cur.Delete(); err != nil {
-			ts.lg.Warn("failed tester.Delete", zap.Error(err))
-			errs = append(errs, err.Error())
+	// the private-only endpoint is only reachable through the tunnel,
+	// which is not running when loaded from previous states
+	if ts.k8sClient != nil && ts.cfg.Endpoint.IsPrivateOnly() && ts.clusterTester != nil {
+		if err := ts.clusterTester.CheckHealth(); err != nil {
+			ts.lg.Warn("failed to reach cluster through endpoint tunnel", zap.Error(err))
+		}
+	}
+
+	// delete in the reverse order of creation
+	// add-ons in the same group do not depend on each other
+	if ts.k8sClient != nil {
+		for idx := len(ts.addons) - 1; idx >= 0; idx-- {
+			fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
+			fmt.Fprintf(ts.logWriter, ts.color("[light_blue]addons[%02d].Delete [default](%q, concurrency %d)\n"), idx, ts.cfg.ConfigPath, ts.cfg.DeleteConcurrency)
+			fns := make([]func() error, 0, len(ts.addons[idx]))
+			for _, addon := range ts.addons[idx] {
+				if !addon.IsEnabled() {
+					continue
+				}
+				a := addon
+				fns = append(fns, func() error {
+					ts.lg.Info("deleting addon", zap.String("addon", addonName(a)))
+					if err := ts.report.Wrap("down", addonName(a)+".Delete", a.Delete)(); err != nil {
+						return fmt.Errorf("failed to delete addon %s (%v)", addonName(a), err)
+					}
+					return nil
+				})
+			}
+			for _, err := range runBounded(ts.cfg.DeleteConcurrency, fns...) {
+				ts.lg.Warn("failed addon.Delete", zap.Error(err))
+				errs = append(errs, err.Error())
+			}
 		}
 	}
 
@@ -1868,24 +1994,32 @@ func (ts *Tester) down() (err error) {
 
 		// following need to be run in order to resolve delete dependency
 		// e.g. cluster must be deleted before VPC delete
+		// managed node groups and node groups do not depend on each other
+		nodeGroupDeletes := make([]func() error, 0, 2)
 		if ts.cfg.IsEnabledAddOnManagedNodeGroups() && ts.mngTester != nil {
-			fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
-			fmt.Fprintf(ts.logWriter, ts.color("[light_blue]mngTester.Delete [default](%q)\n"), ts.cfg.ConfigPath)
-			if err := ts.mngTester.Delete(); err != nil {
-				ts.lg.Warn("failed mngTester.Delete", zap.Error(err))
-				errs = append(errs, err.Error())
-			}
-
-			waitDur := 10 * time.Second
-			ts.lg.Info("sleeping before cluster deletion", zap.Duration("wait", waitDur))
-			time.Sleep(waitDur)
+			nodeGroupDeletes = append(nodeGroupDeletes, func() error {
+				fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
+				fmt.Fprintf(ts.logWriter, ts.color("[light_blue]mngTester.Delete [default](%q)\n"), ts.cfg.ConfigPath)
+				if err := ts.report.Wrap("down", ts.mngTester.Name()+".Delete", ts.mngTester.Delete)(); err != nil {
+					ts.lg.Warn("failed mngTester.Delete", zap.Error(err))
+					return err
+				}
+				return nil
+			})
 		}
-
 		if ts.cfg.IsEnabledAddOnNodeGroups() && ts.ngTester != nil {
-			fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
-			fmt.Fprintf(ts.logWriter, ts.color("[light_blue]ngTester.Delete [default](%q)\n"), ts.cfg.ConfigPath)
-			if err := ts.ngTester.Delete(); err != nil {
-				ts.lg.Warn("failed ngTester.Delete", zap.Error(err))
+			nodeGroupDeletes = append(nodeGroupDeletes, func() error {
+				fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
+				fmt.Fprintf(ts.logWriter, ts.color("[light_blue]ngTester.Delete [default](%q)\n"), ts.cfg.ConfigPath)
+				if err := ts.report.Wrap("down", ts.ngTester.Name()+".Delete", ts.ngTester.Delete)(); err != nil {
+					ts.lg.Warn("failed ngTester.Delete", zap.Error(err))
+					return err
+				}
+				return nil
+			})
+		}
+		if len(nodeGroupDeletes) > 0 {
+			for _, err := range runBounded(ts.cfg.DeleteConcurrency, nodeGroupDeletes...) {
 				errs = append(errs, err.Error())
 			}
 
@@ -1896,17 +2030,30 @@ func (ts *Tester) down() (err error) {
 
 		fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
 		fmt.Fprintf(ts.logWriter, ts.color("[light_blue]clusterTester.Delete [default](%q)\n"), ts.cfg.ConfigPath)
-		if err := ts.clusterTester.Delete(); err != nil {
+		if err := ts.report.Wrap("down", ts.clusterTester.Name()+".Delete", ts.clusterTester.Delete)(); err != nil {
 			ts.lg.Warn("failed clusterTester.Delete", zap.Error(err))
 			errs = append(errs, err.Error())
 		}
 
+		<-uploadDonec
 		fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
 		fmt.Fprintf(ts.logWriter, ts.color("[light_blue]deleteS3 [default](%q)\n"), ts.cfg.ConfigPath)
-		if err := ts.deleteS3(); err != nil {
+		if err := ts.report.Wrap("down", "deleteS3", ts.deleteS3)(); err != nil {
 			ts.lg.Warn("failed deleteS3", zap.Error(err))
 			errs = append(errs, err.Error())
 		}
+
+		fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
+		fmt.Fprintf(ts.logWriter, ts.color("[light_blue]deleteMirroredImages [default](%q)\n"), ts.cfg.ConfigPath)
+		if err := ts.report.Wrap("down", "deleteMirroredImages", ts.deleteMirroredImages)(); err != nil {
+			ts.lg.Warn("failed deleteMirroredImages", zap.Error(err))
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if err := ts.runHooks(eksconfig.HookPhasePostDelete); err != nil {
+		ts.lg.Warn("failed post-delete hooks", zap.Error(err))
+		errs = append(errs, err.Error())
 	}
 
 	if len(errs) > 0 {
@@ -1956,6 +2103,16 @@ func (ts *Tester) DumpClusterLogs() error {
 // ref. https://pkg.go.dev/k8s.io/test-infra/kubetest2/pkg/types?tab=doc#Deployer
 // ref. https://pkg.go.dev/k8s.io/test-infra/kubetest2/pkg/types?tab=doc#Options
 func (ts *Tester) DownloadClusterLogs(artifactDir, _ string) error {
+	if ts.cfg.ControlPlaneLogging.IsEnabled() {
+		if err := ts.clusterTester.DownloadControlPlaneLogs(filepath.Join(artifactDir, "control-plane")); err != nil {
+			return err
+		}
+	}
+	if ts.cfg.IsEnabledAddOnPrometheus() {
+		if err := ts.prometheusTester.Snapshot(artifactDir); err != nil {
+			return err
+		}
+	}
 	if ts.cfg.IsEnabledAddOnNodeGroups() {
 		if err := ts.mngTester.DownloadClusterLogs(artifactDir); err != nil {
 			return err
@@ -2066,20 +2223,21 @@ func (ts *Tester) ArtifactsDir() string {
 }
 
 func catchInterrupt(lg *zap.Logger, stopc chan struct{}, stopcCloseOnce *sync.Once, osSigCh chan os.Signal, run func() error, name string) (err error) {
-	errc := make(chan error)
+	// buffered to not block the abandoned run function
+	errc := make(chan error, 1)
 	go func() {
 		errc <- run()
 	}()
 
 	select {
 	case _, ok := <-stopc:
-		rerr := <-errc
+		rerr := waitStopped(errc)
 		lg.Info("interrupted; stopc received, errc received", zap.Error(rerr))
 		err = fmt.Errorf("stopc returned, stopc open %v, run function returned %v (%q)", ok, rerr, name)
 
 	case osSig := <-osSigCh:
 		stopcCloseOnce.Do(func() { close(stopc) })
-		rerr := <-errc
+		rerr := waitStopped(errc)
 		lg.Info("OS signal received, errc received", zap.String("signal", osSig.String()), zap.Error(rerr))
 		err = fmt.Errorf("received os signal %v, closed stopc, run function returned %v (%q)", osSig, rerr, name)
 
@@ -2091,43 +2249,54 @@ func catchInterrupt(lg *zap.Logger, stopc chan struct{}, stopcCloseOnce *sync.On
 	return err
 }
 
-// runAsync asynchronously executes a function over a slice of addons.
-// If any function errors, the function will return with the error after all addons have executed
-func (ts *Tester) runAsync(addons []eks_tester.Addon, execute func(a eks_tester.Addon) error) error {
-	errors := make(chan error)
-	done := make(chan bool)
-	var wg sync.WaitGroup
-
-	// Fire off addon functions
-	for _, addon := range addons {
-		if !addon.IsEnabled() {
-			klog.Infof("Skipping disabled addon %s", reflect.TypeOf(addon))
-			continue
-		}
+// waitStopped waits for the stopped run function to return,
+// up to the grace period (e.g. AWS waits not watching the stop channel).
+func waitStopped(errc <-chan error) error {
+	select {
+	case err := <-errc:
+		return err
+	case <-time.After(cancelGracePeriod):
+		return fmt.Errorf("run function did not return within %v after stop", cancelGracePeriod)
+	}
+}
+
+// runBounded runs the functions concurrently, with at most "limit" functions
+// in flight, and returns all errors once every function returns.
+// It does not stop on the first error, so that deletion proceeds as far as
+// possible, and all failed add-ons are reported at once on creation.
+func runBounded(limit int, fns ...func() error) (errs []error) {
+	if limit < 1 {
+		limit = 1
+	}
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		sem = make(chan struct{}, limit)
+	)
+	for _, fn := range fns {
 		wg.Add(1)
-		// Take a copy for the goroutine since addon will be mutated before it executes
-		a := addon
-		go func() {
-			defer wg.Done()
-			if err := execute(a); err != nil {
-				errors <- err
+		sem <- struct{}{}
+		go func(f func() error) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := f(); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
 			}
-		}()
+		}(fn)
 	}
+	wg.Wait()
+	return errs
+}
 
-	// Wait for all routines to exit and signal done
-	go func() {
-		wg.Wait()
-		close(done)
-	}()
-
-	// Wait for done or an error to occur
-	select {
-	case <-done:
-		break
-	case err := <-errors:
-		close(errors)
-		return err
+// addonName returns the name of the add-on, or the add-on type
+// if the add-on does not have a name (see "eks/tester.Addon").
+func addonName(a eks_tester.Addon) string {
+	if n, ok := a.(interface{ Name() string }); ok {
+		return n.Name()
 	}
-	return nil
+	return reflect.TypeOf(a).String()
 }
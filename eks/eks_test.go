@@ -0,0 +1,190 @@
+package eks
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func Test_runBounded(t *testing.T) {
+	var inflight, maxInflight int32
+	fns := make([]func() error, 0, 10)
+	for i := 0; i < 10; i++ {
+		i := i
+		fns = append(fns, func() error {
+			cur := atomic.AddInt32(&inflight, 1)
+			for {
+				prev := atomic.LoadInt32(&maxInflight)
+				if cur <= prev || atomic.CompareAndSwapInt32(&maxInflight, prev, cur) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&inflight, -1)
+			if i%3 == 0 {
+				return errors.New("fail")
+			}
+			return nil
+		})
+	}
+
+	errs := runBounded(3, fns...)
+	if len(errs) != 4 {
+		t.Fatalf("expected 4 errors, got %v", errs)
+	}
+	if maxInflight > 3 {
+		t.Fatalf("expected at most 3 in-flight, got %d", maxInflight)
+	}
+}
+
+// fakeAddon records its status the way the add-ons do,
+// while applying concurrently with the other add-ons in the same group.
+type fakeAddon struct {
+	cfg    *eksconfig.Config
+	record func(start time.Time)
+}
+
+func (fa *fakeAddon) Apply() error {
+	for i := 0; i < 50; i++ {
+		start := time.Now()
+		fa.cfg.RecordAddOnStatus(func() { fa.record(start) })
+	}
+	return nil
+}
+func (fa *fakeAddon) Delete() error   { return nil }
+func (fa *fakeAddon) IsEnabled() bool { return true }
+
+func Test_runBoundedAddOnStatus(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(p, []byte("name: test\nregion: us-east-1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := eksconfig.Load(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.AddOnALB = &eksconfig.AddOnALB{Enable: true}
+	cfg.AddOnALB2048 = &eksconfig.AddOnALB2048{Enable: true}
+	cfg.Status = &eksconfig.Status{DeletedResources: make(map[string]string)}
+
+	as := []*fakeAddon{
+		{cfg: cfg, record: func(start time.Time) {
+			cfg.AddOnALB.Created = true
+			cfg.AddOnALB.TimeFrameCreate = timeutil.NewTimeFrame(start, time.Now())
+			cfg.Status.DeletedResources["alb"] = start.String()
+		}},
+		{cfg: cfg, record: func(start time.Time) {
+			cfg.AddOnALB2048.Created = true
+			cfg.AddOnALB2048.TimeFrameCreate = timeutil.NewTimeFrame(start, time.Now())
+			cfg.Status.DeletedResources["alb-2048"] = start.String()
+		}},
+	}
+	fns := make([]func() error, 0, len(as)+1)
+	for _, a := range as {
+		fns = append(fns, a.Apply)
+	}
+	// the other add-ons in the group keep syncing the configuration
+	fns = append(fns, func() error {
+		for i := 0; i < 50; i++ {
+			if err := cfg.Sync(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errs := runBounded(len(fns), fns...); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if !cfg.AddOnALB.Created || !cfg.AddOnALB2048.Created || len(cfg.Status.DeletedResources) != 2 {
+		t.Fatalf("unexpected status %+v, %+v, %v", cfg.AddOnALB, cfg.AddOnALB2048, cfg.Status.DeletedResources)
+	}
+}
+
+// stubEKS serves the Kubernetes API from "host",
+// which answers every request with "AlreadyExists".
+type stubEKS struct {
+	k8s_client.EKS
+	host string
+}
+
+func (se *stubEKS) KubernetesClientSet() *kubernetes.Clientset {
+	return kubernetes.NewForConfigOrDie(&rest.Config{Host: se.host})
+}
+
+func Test_runBoundedRegisteredAddOnStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"AlreadyExists","code":409}`)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	p := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(p, []byte("name: test\nregion: us-east-1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := eksconfig.Load(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// both fail after recording their status, without the AWS credentials
+	cfg.AddOnECR = &eksconfig.AddOnECR{Enable: true}
+	cfg.AddOnCustomManifests = &eksconfig.AddOnCustomManifests{
+		Enable:    true,
+		Namespace: "custom-manifests",
+		Sources:   []string{filepath.Join(dir, "missing.yaml")},
+	}
+	cfg.Status = &eksconfig.Status{}
+
+	addonCfg := eks_tester.AddonConfig{
+		Logger:    zap.NewNop(),
+		LogWriter: ioutil.Discard,
+		Stopc:     make(chan struct{}),
+		EKSConfig: cfg,
+		K8SClient: &stubEKS{host: srv.URL},
+	}
+	fns := make([]func() error, 0, 3)
+	for _, r := range eks_tester.Registered() {
+		if r.Name == "ecr" || r.Name == "custom-manifests" {
+			fns = append(fns, r.New(addonCfg).Apply)
+		}
+	}
+	if len(fns) != 2 {
+		t.Fatalf("expected 2 registered add-ons, got %d", len(fns))
+	}
+	// the other add-ons in the group keep syncing the configuration
+	fns = append(fns, func() error {
+		for i := 0; i < 50; i++ {
+			if err := cfg.Sync(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	errs := runBounded(len(fns), fns...)
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors from the add-ons, got %v", errs)
+	}
+	if !cfg.AddOnECR.Created || cfg.AddOnECR.TimeFrameCreate.StartUTC.IsZero() {
+		t.Fatalf("unexpected ECR status %+v", cfg.AddOnECR)
+	}
+	if !cfg.AddOnCustomManifests.Created || cfg.AddOnCustomManifests.TimeFrameCreate.StartUTC.IsZero() {
+		t.Fatalf("unexpected custom manifests status %+v", cfg.AddOnCustomManifests)
+	}
+}
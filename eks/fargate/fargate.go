@@ -3,17 +3,22 @@ package fargate
 
 import (
 	"context"
+	_ "embed"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-k8s-tester/eks/fargate/wait"
+	"github.com/aws/aws-k8s-tester/eks/plan"
 	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
 	"github.com/aws/aws-k8s-tester/eksconfig"
+	pkg_aws "github.com/aws/aws-k8s-tester/pkg/aws"
 	"github.com/aws/aws-k8s-tester/pkg/aws/cfn"
 	aws_ecr "github.com/aws/aws-k8s-tester/pkg/aws/ecr"
 	aws_iam "github.com/aws/aws-k8s-tester/pkg/aws/iam"
@@ -22,9 +27,10 @@ import (
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
 	"github.com/aws/aws-k8s-tester/pkg/user"
 	"github.com/aws/aws-k8s-tester/version"
+	aws_cfn_v2 "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	aws_cfn_v2_types "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/cloudformation"
-	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/aws/aws-sdk-go/service/ecr"
 	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
 	"github.com/aws/aws-sdk-go/service/eks"
 	"github.com/aws/aws-sdk-go/service/eks/eksiface"
@@ -45,7 +51,7 @@ type Config struct {
 	EKSConfig *eksconfig.Config
 	K8SClient k8s_client.EKS
 	S3API     s3iface.S3API
-	CFNAPI    cloudformationiface.CloudFormationAPI
+	CFNAPIV2  cfn.APIV2
 	EKSAPI    eksiface.EKSAPI
 	IAMAPI    iamiface.IAMAPI
 	ECRAPI    ecriface.ECRAPI
@@ -61,6 +67,26 @@ func New(cfg Config) eks_tester.Tester {
 	return &tester{cfg: cfg}
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "fargate",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				S3API:     cfg.S3API,
+				IAMAPI:    cfg.IAMAPI,
+				CFNAPIV2:  cfg.CFNAPIV2,
+				EKSAPI:    cfg.EKSAPI,
+				ECRAPI:    ecr.New(cfg.AWSSession, aws.NewConfig().WithRegion(cfg.EKSConfig.GetAddOnFargateRepositoryRegion())),
+			}), cfg.EKSConfig.IsEnabledAddOnFargate)
+		},
+	})
+}
+
 type tester struct {
 	cfg      Config
 	ecrImage string
@@ -77,13 +103,15 @@ func (ts *tester) Create() (err error) {
 	}
 
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
-	ts.cfg.EKSConfig.AddOnFargate.Created = true
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnFargate.Created = true
+	})
 	createStart := time.Now()
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnFargate.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnFargate.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
 	if ts.cfg.EKSConfig.AddOnFargate.RepositoryName != "" {
@@ -115,12 +143,18 @@ func (ts *tester) Create() (err error) {
 	if err = ts.createProfile(); err != nil {
 		return err
 	}
-	if err = ts.createPod(); err != nil {
+	if err = ts.createPods(); err != nil {
+		return err
+	}
+	if err = ts.checkScheduling(); err != nil {
 		return err
 	}
 	if err = ts.checkPod(); err != nil {
 		return err
 	}
+	if err = ts.fetchLogs(); err != nil {
+		return err
+	}
 	if err = ts.checkNodeReadiness(); err != nil {
 		return err
 	}
@@ -143,16 +177,17 @@ func (ts *tester) Delete() error {
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnFargate.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnFargate.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
 	}()
 
 	var errs []string
 
-	if err := ts.deletePod(); err != nil {
-		errs = append(errs, fmt.Sprintf("failed to delete Fargate Pod (%v)", err))
+	if err := ts.deletePods(); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Fargate Pods (%v)", err))
 	}
-	ts.cfg.Logger.Info("wait after deleting Fargate Pod")
+	ts.cfg.Logger.Info("wait after deleting Fargate Pods")
 
 	if err := ts.deleteProfile(); err != nil {
 		errs = append(errs, fmt.Sprintf("failed to delete Fargate profile (%v)", err))
@@ -185,57 +220,32 @@ func (ts *tester) Delete() error {
 		return errors.New(strings.Join(errs, ", "))
 	}
 
-	ts.cfg.EKSConfig.AddOnFargate.Created = false
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnFargate.Created = false
+	})
 	return nil
 }
 
 // TemplateRole is the CloudFormation template for EKS Fargate role.
-const TemplateRole = `
----
-AWSTemplateFormatVersion: '2010-09-09'
-Description: 'Amazon EKS Cluster Fargate Role'
-
-Parameters:
-
-  FargateRoleName:
-    Type: String
-    Description: The name of the Fargate role
-
-  FargateRoleServicePrincipals:
-    Type: CommaDelimitedList
-    Default: 'eks.amazonaws.com,eks-fargate-pods.amazonaws.com'
-    Description: EKS Fargate Role Service Principals
-
-  FargateRoleManagedPolicyARNs:
-    Type: CommaDelimitedList
-    Default: 'arn:aws:iam::aws:policy/AmazonEKSFargatePodExecutionRolePolicy'
-    Description: EKS Fargate policy ARNs
-
-Resources:
-
-  FargateRole:
-    Type: AWS::IAM::Role
-    Properties:
-      RoleName: !Ref FargateRoleName
-      AssumeRolePolicyDocument:
-        Version: '2012-10-17'
-        Statement:
-        - Effect: Allow
-          Principal:
-            Service: !Ref FargateRoleServicePrincipals
-          Action:
-          - sts:AssumeRole
-      ManagedPolicyArns: !Ref FargateRoleManagedPolicyARNs
-      Path: /
-
-Outputs:
-
-  FargateRoleARN:
-    Value: !GetAtt FargateRole.Arn
-    Description: The Fargate role ARN
-
-`
+//
+//go:embed templates/role.yaml
+var TemplateRole string
+
+// templateRolePath is the path of TemplateRole in the plan
+// and the template override directory.
+const templateRolePath = "add-on-fargate/role.yaml"
+
+// Plan renders the role CloudFormation template, without calling AWS APIs.
+func Plan(cfg *eksconfig.Config) ([]plan.Document, error) {
+	if !cfg.IsEnabledAddOnFargate() || !cfg.AddOnFargate.RoleCreate {
+		return nil, nil
+	}
+	body, err := cfn.LoadTemplate(cfg.CFNTemplateDir, templateRolePath, TemplateRole)
+	if err != nil {
+		return nil, err
+	}
+	return []plan.Document{plan.NewTemplate(templateRolePath, body)}, nil
+}
 
 func (ts *tester) createRole() error {
 	if !ts.cfg.EKSConfig.AddOnFargate.RoleCreate {
@@ -249,7 +259,7 @@ func (ts *tester) createRole() error {
 				"eks-fargate-pods.amazonaws.com",
 			},
 			[]string{
-				"arn:aws:iam::aws:policy/AmazonEKSFargatePodExecutionRolePolicy",
+				pkg_aws.PolicyARN(ts.cfg.EKSConfig.Partition, "AmazonEKSFargatePodExecutionRolePolicy"),
 			},
 		)
 	}
@@ -262,8 +272,12 @@ func (ts *tester) createRole() error {
 		return errors.New("cannot create a cluster role with an empty AddOnFargate.RoleName")
 	}
 
+	body, err := cfn.LoadTemplate(ts.cfg.EKSConfig.CFNTemplateDir, templateRolePath, TemplateRole)
+	if err != nil {
+		return err
+	}
 	// grant write permission in case of overwrites
-	if err := ioutil.WriteFile(ts.cfg.EKSConfig.AddOnFargate.RoleCFNStackYAMLPath, []byte(TemplateRole), 0600); err != nil {
+	if err := ioutil.WriteFile(ts.cfg.EKSConfig.AddOnFargate.RoleCFNStackYAMLPath, []byte(body), 0600); err != nil {
 		return err
 	}
 	if err := aws_s3.Upload(
@@ -279,18 +293,18 @@ func (ts *tester) createRole() error {
 		zap.String("role-name", ts.cfg.EKSConfig.AddOnFargate.RoleName),
 		zap.String("role-cfn-file-path", ts.cfg.EKSConfig.AddOnFargate.RoleCFNStackYAMLPath),
 	)
-	stackInput := &cloudformation.CreateStackInput{
+	stackInput := &aws_cfn_v2.CreateStackInput{
 		StackName:    aws.String(ts.cfg.EKSConfig.AddOnFargate.RoleName),
-		Capabilities: aws.StringSlice([]string{"CAPABILITY_NAMED_IAM"}),
-		OnFailure:    aws.String(cloudformation.OnFailureDelete),
-		TemplateBody: aws.String(TemplateRole),
-		Tags: cfn.NewTags(map[string]string{
+		Capabilities: []aws_cfn_v2_types.Capability{aws_cfn_v2_types.CapabilityCapabilityNamedIam},
+		OnFailure:    aws_cfn_v2_types.OnFailureDelete,
+		TemplateBody: aws.String(body),
+		Tags: cfn.NewTagsV2(map[string]string{
 			"Kind":                   "aws-k8s-tester",
 			"Name":                   ts.cfg.EKSConfig.Name,
 			"aws-k8s-tester-version": version.ReleaseVersion,
 			"User":                   user.Get(),
 		}),
-		Parameters: []*cloudformation.Parameter{
+		Parameters: []aws_cfn_v2_types.Parameter{
 			{
 				ParameterKey:   aws.String("FargateRoleName"),
 				ParameterValue: aws.String(ts.cfg.EKSConfig.AddOnFargate.RoleName),
@@ -301,7 +315,7 @@ func (ts *tester) createRole() error {
 		ts.cfg.Logger.Info("creating a new Fargate role with custom service principals",
 			zap.Strings("service-principals", ts.cfg.EKSConfig.AddOnFargate.RoleServicePrincipals),
 		)
-		stackInput.Parameters = append(stackInput.Parameters, &cloudformation.Parameter{
+		stackInput.Parameters = append(stackInput.Parameters, aws_cfn_v2_types.Parameter{
 			ParameterKey:   aws.String("FargateRoleServicePrincipals"),
 			ParameterValue: aws.String(strings.Join(ts.cfg.EKSConfig.AddOnFargate.RoleServicePrincipals, ",")),
 		})
@@ -310,31 +324,39 @@ func (ts *tester) createRole() error {
 		ts.cfg.Logger.Info("creating a new Fargate role with custom managed role policies",
 			zap.Strings("policy-arns", ts.cfg.EKSConfig.AddOnFargate.RoleManagedPolicyARNs),
 		)
-		stackInput.Parameters = append(stackInput.Parameters, &cloudformation.Parameter{
+		stackInput.Parameters = append(stackInput.Parameters, aws_cfn_v2_types.Parameter{
 			ParameterKey:   aws.String("FargateRoleManagedPolicyARNs"),
 			ParameterValue: aws.String(strings.Join(ts.cfg.EKSConfig.AddOnFargate.RoleManagedPolicyARNs, ",")),
 		})
+	} else if ts.cfg.EKSConfig.Partition != "aws" {
+		// the template default is the "aws" partition policy
+		stackInput.Parameters = append(stackInput.Parameters, aws_cfn_v2_types.Parameter{
+			ParameterKey:   aws.String("FargateRoleManagedPolicyARNs"),
+			ParameterValue: aws.String(pkg_aws.PolicyARN(ts.cfg.EKSConfig.Partition, "AmazonEKSFargatePodExecutionRolePolicy")),
+		})
 	}
 
-	stackOutput, err := ts.cfg.CFNAPI.CreateStack(stackInput)
+	stackID, err := cfn.CreateStackV2(context.Background(), ts.cfg.Logger, ts.cfg.CFNAPIV2, stackInput)
 	if err != nil {
 		return err
 	}
-	ts.cfg.EKSConfig.AddOnFargate.RoleCFNStackID = aws.StringValue(stackOutput.StackId)
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnFargate.RoleCFNStackID = stackID
+	})
 
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
-	ch := cfn.Poll(
+	ch := cfn.PollV2(
 		ctx,
 		ts.cfg.Stopc,
 		ts.cfg.Logger,
 		ts.cfg.LogWriter,
-		ts.cfg.CFNAPI,
+		ts.cfg.CFNAPIV2,
 		ts.cfg.EKSConfig.AddOnFargate.RoleCFNStackID,
-		cloudformation.ResourceStatusCreateComplete,
+		aws_cfn_v2_types.StackStatusCreateComplete,
 		time.Minute,
 		10*time.Second,
 	)
-	var st cfn.StackStatus
+	var st cfn.StackStatusV2
 	for st = range ch {
 		if st.Error != nil {
 			cancel()
@@ -347,7 +369,9 @@ func (ts *tester) createRole() error {
 	for _, o := range st.Stack.Outputs {
 		switch k := aws.StringValue(o.OutputKey); k {
 		case "FargateRoleARN":
-			ts.cfg.EKSConfig.AddOnFargate.RoleARN = aws.StringValue(o.OutputValue)
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				ts.cfg.EKSConfig.AddOnFargate.RoleARN = aws.StringValue(o.OutputValue)
+			})
 		default:
 			return fmt.Errorf("unexpected OutputKey %q from %q", k, ts.cfg.EKSConfig.AddOnFargate.RoleCFNStackID)
 		}
@@ -371,25 +395,25 @@ func (ts *tester) deleteRole() error {
 	ts.cfg.Logger.Info("deleting Fargate role CFN stack",
 		zap.String("role-cfn-stack-id", ts.cfg.EKSConfig.AddOnFargate.RoleCFNStackID),
 	)
-	_, err := ts.cfg.CFNAPI.DeleteStack(&cloudformation.DeleteStackInput{
+	_, err := ts.cfg.CFNAPIV2.DeleteStack(context.Background(), &aws_cfn_v2.DeleteStackInput{
 		StackName: aws.String(ts.cfg.EKSConfig.AddOnFargate.RoleCFNStackID),
 	})
 	if err != nil {
 		return err
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
-	ch := cfn.Poll(
+	ch := cfn.PollV2(
 		ctx,
 		make(chan struct{}), // do not exit on stop
 		ts.cfg.Logger,
 		ts.cfg.LogWriter,
-		ts.cfg.CFNAPI,
+		ts.cfg.CFNAPIV2,
 		ts.cfg.EKSConfig.AddOnFargate.RoleCFNStackID,
-		cloudformation.ResourceStatusDeleteComplete,
+		aws_cfn_v2_types.StackStatusDeleteComplete,
 		time.Minute,
 		10*time.Second,
 	)
-	var st cfn.StackStatus
+	var st cfn.StackStatusV2
 	for st = range ch {
 		if st.Error != nil {
 			cancel()
@@ -481,6 +505,9 @@ func (ts *tester) createProfile() error {
 		Selectors: []*eks.FargateProfileSelector{
 			{
 				Namespace: aws.String(ts.cfg.EKSConfig.AddOnFargate.Namespace),
+				Labels: aws.StringMap(map[string]string{
+					fargateSelectorLabelKey: fargateSelectorLabelValue,
+				}),
 			},
 		},
 	})
@@ -505,6 +532,7 @@ func (ts *tester) createProfile() error {
 	)
 	for sv := range ch {
 		err = sv.Error
+		ts.updateProfileStatus(sv)
 	}
 	cancel()
 	if err != nil {
@@ -555,6 +583,7 @@ func (ts *tester) deleteProfile() error {
 	)
 	for sv := range ch {
 		err = sv.Error
+		ts.updateProfileStatus(sv)
 	}
 	cancel()
 	if err != nil {
@@ -566,107 +595,161 @@ func (ts *tester) deleteProfile() error {
 	return nil
 }
 
+// updateProfileStatus persists the polled Fargate profile status.
+func (ts *tester) updateProfileStatus(sv wait.FargateProfileStatus) {
+	switch {
+	case sv.FargateProfile != nil:
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnFargate.ProfileARN = aws.StringValue(sv.FargateProfile.FargateProfileArn)
+			ts.cfg.EKSConfig.AddOnFargate.ProfileStatus = aws.StringValue(sv.FargateProfile.Status)
+		})
+	case sv.Error != nil:
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnFargate.ProfileStatus = fmt.Sprintf("failed with error %v", sv.Error)
+		})
+	default:
+		// profile not found
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnFargate.ProfileStatus = wait.FargateProfileStatusDELETEDORNOTEXIST
+		})
+	}
+	ts.cfg.EKSConfig.Sync()
+}
+
 const (
 	fargatePodName       = "fargate-pod"
 	fargateContainerName = "fargate-container"
+
+	// fargateSelectorLabelKey is the Pod label selected into the Fargate profile.
+	fargateSelectorLabelKey   = "aws-k8s-tester.fargate"
+	fargateSelectorLabelValue = "true"
 )
 
-func (ts *tester) createPod() error {
+func (ts *tester) podName(i int) string {
+	return fmt.Sprintf("%s-%d", fargatePodName, i)
+}
+
+func (ts *tester) createPods() error {
 	if err := ts.listPods(ts.cfg.EKSConfig.AddOnFargate.Namespace); err != nil {
 		ts.cfg.Logger.Warn("listing pods failed", zap.Error(err))
 	}
 
-	image := "amazonlinux:latest"
+	image := ts.cfg.EKSConfig.Image("amazonlinux:latest")
 	if ts.cfg.EKSConfig.AddOnFargate.RepositoryName != "" {
 		image = ts.ecrImage
 	}
-	ts.cfg.Logger.Info("creating Fargate Pod", zap.String("image", image))
+	ts.cfg.Logger.Info("creating Fargate Pods",
+		zap.String("image", image),
+		zap.Int("replicas", ts.cfg.EKSConfig.AddOnFargate.PodReplicas),
+	)
 
-	pod := &v1.Pod{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "v1",
-			Kind:       "Pod",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      fargatePodName,
-			Namespace: ts.cfg.EKSConfig.AddOnFargate.Namespace,
-		},
-		Spec: v1.PodSpec{
-			RestartPolicy: v1.RestartPolicyOnFailure,
-			Containers: []v1.Container{
-				{
-					Name:            fargateContainerName,
-					Image:           image,
-					ImagePullPolicy: v1.PullIfNotPresent,
-					Command: []string{
-						"/bin/sh",
-						"-c",
-					},
-					Args: []string{
-						fmt.Sprintf("cat /tmp/%s && sleep 10000", ts.cfg.EKSConfig.AddOnFargate.SecretName),
-					},
+	if ts.cfg.EKSConfig.AddOnFargate.Pods == nil {
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnFargate.Pods = make(map[string]eksconfig.FargatePod)
+		})
+	}
+	for i := 0; i < ts.cfg.EKSConfig.AddOnFargate.PodReplicas; i++ {
+		podName := ts.podName(i)
+		pod := &v1.Pod{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Pod",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      podName,
+				Namespace: ts.cfg.EKSConfig.AddOnFargate.Namespace,
+				Labels: map[string]string{
+					fargateSelectorLabelKey: fargateSelectorLabelValue,
+				},
+			},
+			Spec: v1.PodSpec{
+				RestartPolicy: v1.RestartPolicyOnFailure,
+				Containers: []v1.Container{
+					{
+						Name:            fargateContainerName,
+						Image:           image,
+						ImagePullPolicy: v1.PullIfNotPresent,
+						Command: []string{
+							"/bin/sh",
+							"-c",
+						},
+						Args: []string{
+							fmt.Sprintf("cat /tmp/%s && sleep 10000", ts.cfg.EKSConfig.AddOnFargate.SecretName),
+						},
 
-					// ref. https://kubernetes.io/docs/concepts/cluster-administration/logging/
-					VolumeMounts: []v1.VolumeMount{
-						{
-							Name:      "secret-volume",
-							MountPath: "/tmp",
-							ReadOnly:  true,
+						// ref. https://kubernetes.io/docs/concepts/cluster-administration/logging/
+						VolumeMounts: []v1.VolumeMount{
+							{
+								Name:      "secret-volume",
+								MountPath: "/tmp",
+								ReadOnly:  true,
+							},
 						},
 					},
 				},
-			},
 
-			// ref. https://kubernetes.io/docs/concepts/cluster-administration/logging/
-			Volumes: []v1.Volume{
-				{ // to read
-					Name: "secret-volume",
-					VolumeSource: v1.VolumeSource{
-						Secret: &v1.SecretVolumeSource{
-							SecretName: ts.cfg.EKSConfig.AddOnFargate.SecretName,
+				// ref. https://kubernetes.io/docs/concepts/cluster-administration/logging/
+				Volumes: []v1.Volume{
+					{ // to read
+						Name: "secret-volume",
+						VolumeSource: v1.VolumeSource{
+							Secret: &v1.SecretVolumeSource{
+								SecretName: ts.cfg.EKSConfig.AddOnFargate.SecretName,
+							},
 						},
 					},
 				},
 			},
-		},
-	}
-	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
-	_, err := ts.cfg.K8SClient.KubernetesClientSet().
-		CoreV1().
-		Pods(ts.cfg.EKSConfig.AddOnFargate.Namespace).
-		Create(ctx, pod, metav1.CreateOptions{})
-	cancel()
-	if err != nil {
-		return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		_, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Pods(ts.cfg.EKSConfig.AddOnFargate.Namespace).
+			Create(ctx, pod, metav1.CreateOptions{})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to create Pod %q (%v)", podName, err)
+		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnFargate.Pods[podName] = eksconfig.FargatePod{Phase: string(v1.PodPending)}
+		})
+		ts.cfg.Logger.Info("created Pod", zap.String("name", podName))
 	}
 
-	ts.cfg.Logger.Info("created Pod")
 	ts.cfg.EKSConfig.Sync()
 	return nil
 }
 
-func (ts *tester) deletePod() error {
-	ts.cfg.Logger.Info("deleting Pod", zap.String("name", fargatePodName))
-	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
-	err := ts.cfg.
-		K8SClient.KubernetesClientSet().
-		CoreV1().
-		Pods(ts.cfg.EKSConfig.AddOnFargate.Namespace).
-		Delete(
-			ctx,
-			fargatePodName,
-			metav1.DeleteOptions{
-				GracePeriodSeconds: aws.Int64(0),
-				PropagationPolicy:  &propagationBackground,
-			},
-		)
-	cancel()
-	if err != nil && !apierrs.IsNotFound(err) && !strings.Contains(err.Error(), "not found") {
-		ts.cfg.Logger.Warn("failed to delete", zap.Error(err))
-		return fmt.Errorf("failed to delete Pod %q (%v)", fargatePodName, err)
+func (ts *tester) deletePods() error {
+	var errs []string
+	for i := 0; i < ts.cfg.EKSConfig.AddOnFargate.PodReplicas; i++ {
+		podName := ts.podName(i)
+		ts.cfg.Logger.Info("deleting Pod", zap.String("name", podName))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		err := ts.cfg.
+			K8SClient.KubernetesClientSet().
+			CoreV1().
+			Pods(ts.cfg.EKSConfig.AddOnFargate.Namespace).
+			Delete(
+				ctx,
+				podName,
+				metav1.DeleteOptions{
+					GracePeriodSeconds: aws.Int64(0),
+					PropagationPolicy:  &propagationBackground,
+				},
+			)
+		cancel()
+		if err != nil && !apierrs.IsNotFound(err) && !strings.Contains(err.Error(), "not found") {
+			ts.cfg.Logger.Warn("failed to delete", zap.Error(err))
+			errs = append(errs, fmt.Sprintf("failed to delete Pod %q (%v)", podName, err))
+			continue
+		}
+		ts.cfg.Logger.Info("deleted Pod", zap.String("name", podName))
 	}
-	ts.cfg.Logger.Info("deleted Pod", zap.String("name", fargatePodName))
 	ts.cfg.EKSConfig.Sync()
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
 	return nil
 }
 
@@ -690,14 +773,88 @@ func (ts *tester) getPods(ns string) (*v1.PodList, error) {
 	return ps, err
 }
 
+// checkScheduling waits until all Pods are running, and
+// validates that each Pod is scheduled onto a Fargate node.
+func (ts *tester) checkScheduling() error {
+	ts.cfg.Logger.Info("checking Pod scheduling", zap.Int("replicas", ts.cfg.EKSConfig.AddOnFargate.PodReplicas))
+
+	selector := fargateSelectorLabelKey + "=" + fargateSelectorLabelValue
+	retryStart, waitDur := time.Now(), 10*time.Minute
+	for time.Since(retryStart) < waitDur {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("Pod scheduling check aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		pods, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Pods(ts.cfg.EKSConfig.AddOnFargate.Namespace).
+			List(ctx, metav1.ListOptions{LabelSelector: selector})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("list pods failed", zap.Error(err))
+			continue
+		}
+
+		running := 0
+		for _, pod := range pods.Items {
+			cur := ts.cfg.EKSConfig.AddOnFargate.Pods[pod.Name]
+			cur.Phase = string(pod.Status.Phase)
+			cur.NodeName = pod.Spec.NodeName
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				ts.cfg.EKSConfig.AddOnFargate.Pods[pod.Name] = cur
+			})
+			if pod.Status.Phase != v1.PodRunning {
+				continue
+			}
+			if err = ts.checkFargateNode(pod.Spec.NodeName); err != nil {
+				ts.cfg.EKSConfig.Sync()
+				return fmt.Errorf("Pod %q not scheduled onto Fargate (%v)", pod.Name, err)
+			}
+			running++
+		}
+		ts.cfg.EKSConfig.Sync()
+
+		ts.cfg.Logger.Info("polled Pods",
+			zap.Int("running", running),
+			zap.Int("desired", ts.cfg.EKSConfig.AddOnFargate.PodReplicas),
+		)
+		if running >= ts.cfg.EKSConfig.AddOnFargate.PodReplicas {
+			ts.cfg.Logger.Info("checked Pod scheduling")
+			return nil
+		}
+	}
+	return fmt.Errorf("Pods not running on Fargate after %v", waitDur)
+}
+
+// checkFargateNode returns an error if the node is not a Fargate node.
+func (ts *tester) checkFargateNode(nodeName string) error {
+	if !strings.HasPrefix(nodeName, "fargate-") {
+		return fmt.Errorf("unexpected node name %q", nodeName)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	node, err := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		return err
+	}
+	if v := node.GetLabels()["eks.amazonaws.com/compute-type"]; v != "fargate" {
+		return fmt.Errorf("unexpected node %q compute type %q", nodeName, v)
+	}
+	return nil
+}
+
 func (ts *tester) checkPod() error {
+	podName := ts.podName(0)
 	execArgs := []string{
 		ts.cfg.EKSConfig.KubectlPath,
 		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
 		"--namespace=" + ts.cfg.EKSConfig.AddOnFargate.Namespace,
 		"exec",
 		"-it",
-		fargatePodName,
+		podName,
 		"--",
 		"cat",
 		fmt.Sprintf("/tmp/%s", ts.cfg.EKSConfig.AddOnFargate.SecretName),
@@ -736,7 +893,7 @@ func (ts *tester) checkPod() error {
 
 		succeeded = true
 		ts.cfg.Logger.Info("successfully checked Pod exec",
-			zap.String("pod-name", fargatePodName),
+			zap.String("pod-name", podName),
 			zap.String("container-name", fargateContainerName),
 		)
 		break
@@ -746,87 +903,67 @@ func (ts *tester) checkPod() error {
 		return errors.New("failed to find expected output from kubectl exec")
 	}
 
-	descArgsPods := []string{
-		ts.cfg.EKSConfig.KubectlPath,
-		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
-		"--namespace=" + ts.cfg.EKSConfig.AddOnFargate.Namespace,
-		"describe",
-		"pods/" + fargatePodName,
-	}
-	descCmdPods := strings.Join(descArgsPods, " ")
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
 
-	logArgs := []string{
-		ts.cfg.EKSConfig.KubectlPath,
-		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
-		"--namespace=" + ts.cfg.EKSConfig.AddOnFargate.Namespace,
-		"logs",
-		"pods/" + fargatePodName,
-		"--all-containers=true",
-		"--timestamps",
+// fetchLogs fetches the logs of all Pods via the Kubernetes API,
+// and validates that each Pod logged the secret it read.
+func (ts *tester) fetchLogs() error {
+	if err := os.MkdirAll(ts.cfg.EKSConfig.AddOnFargate.LogsDir, 0700); err != nil {
+		return err
 	}
-	logsCmd := strings.Join(logArgs, " ")
-
-	ts.cfg.Logger.Info("checking Pod",
-		zap.String("pod-name", fargatePodName),
-		zap.String("container-name", fargateContainerName),
-		zap.String("command-describe", descCmdPods),
-		zap.String("command-logs", logsCmd),
-	)
-
-	succeeded = false
-	retryStart, waitDur = time.Now(), 2*time.Minute
-	for time.Since(retryStart) < waitDur {
-		select {
-		case <-ts.cfg.Stopc:
-			ts.cfg.Logger.Warn("aborted")
-			return nil
-		case <-time.After(5 * time.Second):
-		}
-
-		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-		output, err := exec.New().CommandContext(ctx, descArgsPods[0], descArgsPods[1:]...).CombinedOutput()
-		cancel()
-		out := string(output)
-		if err != nil {
-			ts.cfg.Logger.Warn("'kubectl describe' failed", zap.Error(err))
+	for i := 0; i < ts.cfg.EKSConfig.AddOnFargate.PodReplicas; i++ {
+		podName := ts.podName(i)
+		logsPath := filepath.Join(ts.cfg.EKSConfig.AddOnFargate.LogsDir, podName+".log")
+		ts.cfg.Logger.Info("fetching Pod logs", zap.String("pod-name", podName), zap.String("path", logsPath))
+
+		var out []byte
+		var err error
+		succeeded := false
+		retryStart, waitDur := time.Now(), 2*time.Minute
+		for time.Since(retryStart) < waitDur {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			out, err = ts.cfg.K8SClient.KubernetesClientSet().
+				CoreV1().
+				Pods(ts.cfg.EKSConfig.AddOnFargate.Namespace).
+				GetLogs(podName, &v1.PodLogOptions{Container: fargateContainerName, Timestamps: true}).
+				DoRaw(ctx)
+			cancel()
+			if err == nil && strings.Contains(string(out), secretReadTxt) {
+				succeeded = true
+				break
+			}
+			ts.cfg.Logger.Warn("unexpected Pod logs; retrying", zap.String("pod-name", podName), zap.Error(err))
+			select {
+			case <-ts.cfg.Stopc:
+				return errors.New("Pod logs fetch aborted")
+			case <-time.After(5 * time.Second):
+			}
 		}
-		fmt.Fprintf(ts.cfg.LogWriter, "\n'%s' output:\n\n%s\n\n", descCmdPods, out)
-
-		ctx, cancel = context.WithTimeout(context.Background(), 15*time.Second)
-		output, err = exec.New().CommandContext(ctx, logArgs[0], logArgs[1:]...).CombinedOutput()
-		cancel()
-		out = string(output)
-		if err != nil {
-			ts.cfg.Logger.Warn("'kubectl logs' failed", zap.Error(err))
+		if len(out) > 0 {
+			if werr := ioutil.WriteFile(logsPath, out, 0600); werr != nil {
+				return werr
+			}
+			cur := ts.cfg.EKSConfig.AddOnFargate.Pods[podName]
+			cur.LogsPath = logsPath
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				ts.cfg.EKSConfig.AddOnFargate.Pods[podName] = cur
+			})
 		}
-		fmt.Fprintf(ts.cfg.LogWriter, "\n'%s' output:\n\n%s\n\n", logsCmd, out)
-
-		if !strings.Contains(out, secretReadTxt) {
-			ts.cfg.Logger.Warn("unexpected logs output", zap.String("output", out))
-			time.Sleep(5 * time.Second)
-			continue
+		if !succeeded {
+			return fmt.Errorf("failed to find expected output %q from Pod %q logs (%v)", secretReadTxt, podName, err)
 		}
-
-		succeeded = true
-		ts.cfg.Logger.Info("successfully checked Pod logs",
-			zap.String("pod-name", fargatePodName),
-			zap.String("container-name", fargateContainerName),
-		)
-		break
-	}
-	if !succeeded {
-		// TODO: expected output not found, fail the whole tester
-		ts.cfg.Logger.Warn("failed to find expected output from kubectl logs; fail!", zap.String("expected", secretReadTxt))
+		ts.cfg.Logger.Info("fetched Pod logs", zap.String("pod-name", podName), zap.String("path", logsPath))
 	}
-
-	ts.cfg.EKSConfig.Sync()
 	return nil
 }
 
 func (ts *tester) checkNodeReadiness() error {
 	ts.cfg.Logger.Info("checking node")
 
-	desired := 1
+	// each Fargate Pod runs on its own node
+	desired := ts.cfg.EKSConfig.AddOnFargate.PodReplicas
 	retryStart, waitDur := time.Now(), 3*time.Minute
 	for time.Since(retryStart) < waitDur {
 		select {
@@ -25,6 +25,8 @@ import (
 	aws_ecr "github.com/aws/aws-k8s-tester/pkg/aws/ecr"
 	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
 	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
 	"go.uber.org/zap"
 	"k8s.io/utils/exec"
@@ -52,6 +54,7 @@ func New(cfg Config) eks_tester.Tester {
 	}
 	ts.creates = []func() error{
 		func() (err error) {
+			ts.busyboxImg = ts.cfg.EKSConfig.Image(ts.busyboxImg)
 			if ts.cfg.EKSConfig.AddOnFluentd.RepositoryBusyboxAccountID != "" &&
 				ts.cfg.EKSConfig.AddOnFluentd.RepositoryBusyboxRegion != "" &&
 				ts.cfg.EKSConfig.AddOnFluentd.RepositoryBusyboxName != "" &&
@@ -128,6 +131,23 @@ func New(cfg Config) eks_tester.Tester {
 	return ts
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:         "fluentd",
+		Dependencies: []string{"ecr"},
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				ECRAPI:    ecr.New(cfg.AWSSession, aws.NewConfig().WithRegion(cfg.EKSConfig.GetAddOnFluentdRepositoryBusyboxRegion())),
+			}), cfg.EKSConfig.IsEnabledAddOnFluentd)
+		},
+	})
+}
+
 type tester struct {
 	cfg Config
 
@@ -150,13 +170,15 @@ func (ts *tester) Create() (err error) {
 	}
 
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
-	ts.cfg.EKSConfig.AddOnFluentd.Created = true
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnFluentd.Created = true
+	})
 	createStart := time.Now()
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnFluentd.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnFluentd.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
 	for _, createFunc := range ts.creates {
@@ -182,8 +204,9 @@ func (ts *tester) Delete() error {
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnFluentd.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnFluentd.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
 	}()
 
 	var errs []string
@@ -196,7 +219,8 @@ func (ts *tester) Delete() error {
 		return errors.New(strings.Join(errs, ", "))
 	}
 
-	ts.cfg.EKSConfig.AddOnFluentd.Created = false
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnFluentd.Created = false
+	})
 	return nil
 }
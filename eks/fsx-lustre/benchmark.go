@@ -0,0 +1,297 @@
+package fsxlustre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	driverName = "fsx.csi.aws.com"
+
+	pvcName          = "fsx-lustre-pvc"
+	benchmarkPodName = "fsx-lustre-benchmark"
+
+	mountPath = "/data"
+)
+
+func (ts *tester) pvName() string {
+	return ts.cfg.EKSConfig.Name + "-fsx-lustre"
+}
+
+// createVolume statically provisions the file system as a PersistentVolume.
+// ref. https://github.com/kubernetes-sigs/aws-fsx-csi-driver/tree/master/examples/kubernetes/static_provisioning
+func (ts *tester) createVolume() error {
+	cur := ts.cfg.EKSConfig.AddOnFSxLustre
+	capacity := *resource.NewQuantity(cur.StorageCapacityGiB<<30, resource.BinarySI)
+
+	ts.cfg.Logger.Info("creating PersistentVolume", zap.String("name", ts.pvName()))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		PersistentVolumes().
+		Create(
+			ctx,
+			&v1.PersistentVolume{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "PersistentVolume",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: ts.pvName(),
+				},
+				Spec: v1.PersistentVolumeSpec{
+					Capacity:                      v1.ResourceList{v1.ResourceStorage: capacity},
+					AccessModes:                   []v1.PersistentVolumeAccessMode{v1.ReadWriteMany},
+					PersistentVolumeReclaimPolicy: v1.PersistentVolumeReclaimRetain,
+					StorageClassName:              "",
+					PersistentVolumeSource: v1.PersistentVolumeSource{
+						CSI: &v1.CSIPersistentVolumeSource{
+							Driver:       driverName,
+							VolumeHandle: cur.FileSystemID,
+							VolumeAttributes: map[string]string{
+								"dnsname":   cur.DNSName,
+								"mountname": cur.MountName,
+							},
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create PersistentVolume (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("creating PersistentVolumeClaim", zap.String("name", pvcName))
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		PersistentVolumeClaims(cur.Namespace).
+		Create(
+			ctx,
+			&v1.PersistentVolumeClaim{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "PersistentVolumeClaim",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      pvcName,
+					Namespace: cur.Namespace,
+				},
+				Spec: v1.PersistentVolumeClaimSpec{
+					StorageClassName: aws_v2.String(""),
+					VolumeName:       ts.pvName(),
+					AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteMany},
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{v1.ResourceStorage: capacity},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create PersistentVolumeClaim (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created PersistentVolume and PersistentVolumeClaim")
+	return nil
+}
+
+func (ts *tester) deleteVolume() error {
+	ts.cfg.Logger.Info("deleting PersistentVolume", zap.String("name", ts.pvName()))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		PersistentVolumes().
+		Delete(ctx, ts.pvName(), metav1.DeleteOptions{})
+	cancel()
+	if err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to delete PersistentVolume (%v)", err)
+	}
+	ts.cfg.Logger.Info("deleted PersistentVolume")
+	return nil
+}
+
+// runBenchmark writes and reads a file with direct I/O,
+// and records the throughput in the status.
+func (ts *tester) runBenchmark() error {
+	cur := ts.cfg.EKSConfig.AddOnFSxLustre
+	script := fmt.Sprintf(`set -eo pipefail
+f=%s/benchmark-$(hostname)
+dd if=/dev/zero of=${f} bs=1M count=%d oflag=direct 2>&1 | tail -1 | sed 's/^/WRITE: /'
+dd if=${f} of=/dev/null bs=1M iflag=direct 2>&1 | tail -1 | sed 's/^/READ: /'
+rm -f ${f}
+`, mountPath, cur.BenchmarkSizeMiB)
+
+	ts.cfg.Logger.Info("creating benchmark Pod", zap.Int64("size-mib", cur.BenchmarkSizeMiB))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(cur.Namespace).
+		Create(
+			ctx,
+			&v1.Pod{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Pod",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      benchmarkPodName,
+					Namespace: cur.Namespace,
+				},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Containers: []v1.Container{
+						{
+							Name:            benchmarkPodName,
+							Image:           cur.BenchmarkImage,
+							ImagePullPolicy: v1.PullIfNotPresent,
+							Command:         []string{"/bin/bash", "-c", script},
+							VolumeMounts: []v1.VolumeMount{
+								{
+									Name:      "data",
+									MountPath: mountPath,
+								},
+							},
+						},
+					},
+					Volumes: []v1.Volume{
+						{
+							Name: "data",
+							VolumeSource: v1.VolumeSource{
+								PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+									ClaimName: pvcName,
+								},
+							},
+						},
+					},
+					NodeSelector: map[string]string{
+						"kubernetes.io/os": "linux",
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create benchmark Pod (%v)", err)
+	}
+
+	benchStart := time.Now()
+	for time.Since(benchStart) < 20*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("benchmark aborted")
+		case <-time.After(10 * time.Second):
+		}
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		pod, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Pods(cur.Namespace).
+			Get(ctx, benchmarkPodName, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get benchmark Pod", zap.Error(err))
+			continue
+		}
+		ts.cfg.Logger.Info("polled benchmark Pod", zap.String("phase", string(pod.Status.Phase)))
+		if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+			continue
+		}
+
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		b, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Pods(cur.Namespace).
+			GetLogs(benchmarkPodName, &v1.PodLogOptions{}).
+			DoRaw(ctx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to get benchmark Pod logs (%v)", err)
+		}
+		out := string(b)
+		fmt.Fprintf(ts.cfg.LogWriter, "\nbenchmark Pod output:\n%s\n", out)
+		if pod.Status.Phase == v1.PodFailed {
+			return fmt.Errorf("benchmark Pod failed (output %q)", out)
+		}
+
+		write, read, err := parseDDOutput(out)
+		if err != nil {
+			return err
+		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.Status.FSxLustre = &eksconfig.FSxLustreStatus{
+				FileSystemID:        cur.FileSystemID,
+				DeploymentType:      cur.DeploymentType,
+				StorageCapacityGiB:  cur.StorageCapacityGiB,
+				BenchmarkSizeMiB:    cur.BenchmarkSizeMiB,
+				WriteThroughputMBps: write,
+				ReadThroughputMBps:  read,
+				TimeFrame:           timeutil.NewTimeFrame(benchStart, time.Now()),
+			}
+		})
+		ts.cfg.Logger.Info("benchmark completed",
+			zap.Float64("write-throughput-mbps", write),
+			zap.Float64("read-throughput-mbps", read),
+		)
+		return nil
+	}
+	return errors.New("benchmark Pod not completed")
+}
+
+// e.g. "1073741824 bytes (1.1 GB, 1.0 GiB) copied, 2.34 s, 459 MB/s"
+var ddCopiedRegex = regexp.MustCompile(`(\d+) bytes .*copied, ([0-9.]+) s`)
+
+// parseDDOutput parses the "WRITE: " and "READ: " prefixed "dd" summary
+// lines, and returns the write and read throughput in MB/s.
+func parseDDOutput(out string) (write float64, read float64, err error) {
+	var foundWrite, foundRead bool
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		var dst *float64
+		switch {
+		case strings.HasPrefix(line, "WRITE: "):
+			dst, foundWrite = &write, true
+		case strings.HasPrefix(line, "READ: "):
+			dst, foundRead = &read, true
+		default:
+			continue
+		}
+		ms := ddCopiedRegex.FindStringSubmatch(line)
+		if len(ms) != 3 {
+			return 0, 0, fmt.Errorf("unexpected dd output %q", line)
+		}
+		bytes, err := strconv.ParseFloat(ms[1], 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		secs, err := strconv.ParseFloat(ms[2], 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		if secs <= 0 {
+			return 0, 0, fmt.Errorf("unexpected dd duration %q", line)
+		}
+		*dst = bytes / secs / 1e6
+	}
+	if !foundWrite || !foundRead {
+		return 0, 0, fmt.Errorf("dd write or read result not found in %q", out)
+	}
+	return write, read, nil
+}
@@ -0,0 +1,29 @@
+package fsxlustre
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_parseDDOutput(t *testing.T) {
+	out := `WRITE: 1073741824 bytes (1.1 GB, 1.0 GiB) copied, 2 s, 537 MB/s
+READ: 1073741824 bytes (1.1 GB, 1.0 GiB) copied, 0.5 s, 2.1 GB/s
+`
+	write, read, err := parseDDOutput(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(write-536.870912) > 0.001 {
+		t.Fatalf("unexpected write throughput %f", write)
+	}
+	if math.Abs(read-2147.483648) > 0.001 {
+		t.Fatalf("unexpected read throughput %f", read)
+	}
+
+	if _, _, err = parseDDOutput("WRITE: 1073741824 bytes (1.1 GB, 1.0 GiB) copied, 2 s, 537 MB/s\n"); err == nil {
+		t.Fatal("expected missing read error")
+	}
+	if _, _, err = parseDDOutput("WRITE: dd: error writing\nREAD: dd: error reading\n"); err == nil {
+		t.Fatal("expected unexpected output error")
+	}
+}
@@ -0,0 +1,279 @@
+package fsxlustre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/fsx"
+	smithy "github.com/aws/smithy-go"
+	"go.uber.org/zap"
+)
+
+// createSecurityGroup creates the file system security group,
+// allowing Lustre traffic from the VPC CIDR blocks, which
+// covers both the nodes and the file servers.
+// ref. https://docs.aws.amazon.com/fsx/latest/LustreGuide/limit-access-security-groups.html
+func (ts *tester) createSecurityGroup() error {
+	cur := ts.cfg.EKSConfig.AddOnFSxLustre
+	if cur.SecurityGroupID != "" {
+		ts.cfg.Logger.Info("file system security group already created", zap.String("security-group-id", cur.SecurityGroupID))
+		return nil
+	}
+
+	vout, err := ts.cfg.EC2APIV2.DescribeVpcs(
+		context.Background(),
+		&aws_ec2_v2.DescribeVpcsInput{VpcIds: []string{ts.cfg.EKSConfig.VPC.ID}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to describe VPC %q (%v)", ts.cfg.EKSConfig.VPC.ID, err)
+	}
+	if len(vout.Vpcs) != 1 {
+		return fmt.Errorf("VPC %q not found", ts.cfg.EKSConfig.VPC.ID)
+	}
+	var ranges []aws_ec2_v2_types.IpRange
+	for _, assoc := range vout.Vpcs[0].CidrBlockAssociationSet {
+		ranges = append(ranges, aws_ec2_v2_types.IpRange{CidrIp: assoc.CidrBlock})
+	}
+
+	sgName := ts.cfg.EKSConfig.Name + "-fsx-lustre"
+	ts.cfg.Logger.Info("creating file system security group", zap.String("name", sgName))
+	sout, err := ts.cfg.EC2APIV2.CreateSecurityGroup(
+		context.Background(),
+		&aws_ec2_v2.CreateSecurityGroupInput{
+			GroupName:   aws_v2.String(sgName),
+			Description: aws_v2.String("FSx for Lustre for " + ts.cfg.EKSConfig.Name),
+			VpcId:       aws_v2.String(ts.cfg.EKSConfig.VPC.ID),
+			TagSpecifications: []aws_ec2_v2_types.TagSpecification{
+				{
+					ResourceType: aws_ec2_v2_types.ResourceTypeSecurityGroup,
+					Tags: []aws_ec2_v2_types.Tag{
+						{Key: aws_v2.String("Name"), Value: aws_v2.String(sgName)},
+					},
+				},
+			},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create security group (%v)", err)
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.SecurityGroupID = aws_v2.ToString(sout.GroupId)
+	})
+
+	_, err = ts.cfg.EC2APIV2.AuthorizeSecurityGroupIngress(
+		context.Background(),
+		&aws_ec2_v2.AuthorizeSecurityGroupIngressInput{
+			GroupId: aws_v2.String(cur.SecurityGroupID),
+			IpPermissions: []aws_ec2_v2_types.IpPermission{
+				{
+					IpProtocol: aws_v2.String("tcp"),
+					FromPort:   aws_v2.Int32(988),
+					ToPort:     aws_v2.Int32(988),
+					IpRanges:   ranges,
+				},
+				{
+					IpProtocol: aws_v2.String("tcp"),
+					FromPort:   aws_v2.Int32(1018),
+					ToPort:     aws_v2.Int32(1023),
+					IpRanges:   ranges,
+				},
+			},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to authorize Lustre ingress (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created file system security group", zap.String("security-group-id", cur.SecurityGroupID))
+	return nil
+}
+
+func (ts *tester) deleteSecurityGroup() error {
+	cur := ts.cfg.EKSConfig.AddOnFSxLustre
+	if cur.SecurityGroupID == "" {
+		return nil
+	}
+	if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[cur.SecurityGroupID]; ok {
+		return nil
+	}
+
+	ts.cfg.Logger.Info("deleting file system security group", zap.String("security-group-id", cur.SecurityGroupID))
+	var err error
+	// file server network interfaces may take a while to be released
+	retryStart := time.Now()
+	for time.Since(retryStart) < 5*time.Minute {
+		_, err = ts.cfg.EC2APIV2.DeleteSecurityGroup(
+			context.Background(),
+			&aws_ec2_v2.DeleteSecurityGroupInput{GroupId: aws_v2.String(cur.SecurityGroupID)},
+		)
+		if err == nil || isErrCode(err, "InvalidGroup.NotFound") {
+			err = nil
+			break
+		}
+		ts.cfg.Logger.Warn("failed to delete security group; retrying", zap.Error(err))
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("delete security group aborted")
+		case <-time.After(10 * time.Second):
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete security group %q (%v)", cur.SecurityGroupID, err)
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.Status.DeletedResources[cur.SecurityGroupID] = "AddOnFSxLustre.SecurityGroupID"
+	})
+	ts.cfg.Logger.Info("deleted file system security group")
+	return nil
+}
+
+// createFileSystem creates the file system in the first node subnet,
+// importing from the artifact S3 bucket prefix.
+// ref. https://docs.aws.amazon.com/fsx/latest/LustreGuide/create-dra-linked-data-repo.html
+func (ts *tester) createFileSystem() error {
+	cur := ts.cfg.EKSConfig.AddOnFSxLustre
+	if cur.FileSystemID == "" {
+		importPath := fmt.Sprintf("s3://%s/%s", ts.cfg.EKSConfig.S3.BucketName, cur.S3ImportPrefix)
+		ts.cfg.Logger.Info("creating FSx for Lustre file system",
+			zap.String("deployment-type", cur.DeploymentType),
+			zap.Int64("storage-capacity-gib", cur.StorageCapacityGiB),
+			zap.String("import-path", importPath),
+		)
+		out, err := ts.cfg.FSxAPI.CreateFileSystem(&fsx.CreateFileSystemInput{
+			// idempotent with the same request token
+			ClientRequestToken: aws.String(ts.cfg.EKSConfig.Name + "-fsx-lustre"),
+			FileSystemType:     aws.String(fsx.FileSystemTypeLustre),
+			StorageCapacity:    aws.Int64(cur.StorageCapacityGiB),
+			SubnetIds:          aws.StringSlice([]string{ts.cfg.EKSConfig.VPC.PublicSubnetIDs[0]}),
+			SecurityGroupIds:   aws.StringSlice([]string{cur.SecurityGroupID}),
+			LustreConfiguration: &fsx.CreateFileSystemLustreConfiguration{
+				DeploymentType: aws.String(cur.DeploymentType),
+				ImportPath:     aws.String(importPath),
+			},
+			Tags: []*fsx.Tag{
+				{Key: aws.String("Name"), Value: aws.String(ts.cfg.EKSConfig.Name + "-fsx-lustre")},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create FSx for Lustre file system (%v)", err)
+		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.FileSystemID = aws.StringValue(out.FileSystem.FileSystemId)
+		})
+	}
+
+	// creation takes 5 to 10 minutes
+	retryStart := time.Now()
+	for time.Since(retryStart) < 30*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("create FSx for Lustre file system aborted")
+		case <-time.After(30 * time.Second):
+		}
+
+		out, err := ts.cfg.FSxAPI.DescribeFileSystems(&fsx.DescribeFileSystemsInput{
+			FileSystemIds: aws.StringSlice([]string{cur.FileSystemID}),
+		})
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe FSx file system", zap.Error(err))
+			continue
+		}
+		if len(out.FileSystems) != 1 {
+			continue
+		}
+		fs := out.FileSystems[0]
+		lifecycle := aws.StringValue(fs.Lifecycle)
+		ts.cfg.Logger.Info("polled FSx file system",
+			zap.String("file-system-id", cur.FileSystemID),
+			zap.String("lifecycle", lifecycle),
+			zap.String("took", time.Since(retryStart).String()),
+		)
+		switch lifecycle {
+		case fsx.FileSystemLifecycleAvailable:
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				cur.DNSName = aws.StringValue(fs.DNSName)
+			})
+			if fs.LustreConfiguration != nil {
+				ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+					cur.MountName = aws.StringValue(fs.LustreConfiguration.MountName)
+				})
+			}
+			ts.cfg.EKSConfig.Sync()
+			ts.cfg.Logger.Info("created FSx for Lustre file system",
+				zap.String("file-system-id", cur.FileSystemID),
+				zap.String("dns-name", cur.DNSName),
+				zap.String("mount-name", cur.MountName),
+			)
+			return nil
+		case fsx.FileSystemLifecycleFailed, fsx.FileSystemLifecycleMisconfigured:
+			msg := ""
+			if fs.FailureDetails != nil {
+				msg = aws.StringValue(fs.FailureDetails.Message)
+			}
+			return fmt.Errorf("FSx file system %q %s (%s)", cur.FileSystemID, lifecycle, msg)
+		}
+	}
+	return fmt.Errorf("FSx file system %q not available", cur.FileSystemID)
+}
+
+func (ts *tester) deleteFileSystem() error {
+	cur := ts.cfg.EKSConfig.AddOnFSxLustre
+	if cur.FileSystemID == "" {
+		return nil
+	}
+	if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[cur.FileSystemID]; ok {
+		return nil
+	}
+
+	ts.cfg.Logger.Info("deleting FSx for Lustre file system", zap.String("file-system-id", cur.FileSystemID))
+	_, err := ts.cfg.FSxAPI.DeleteFileSystem(&fsx.DeleteFileSystemInput{
+		FileSystemId: aws.String(cur.FileSystemID),
+	})
+	if err != nil && !isFSxErrCode(err, fsx.ErrCodeFileSystemNotFound) {
+		return fmt.Errorf("failed to delete FSx file system %q (%v)", cur.FileSystemID, err)
+	}
+
+	retryStart := time.Now()
+	for time.Since(retryStart) < 20*time.Minute {
+		out, err := ts.cfg.FSxAPI.DescribeFileSystems(&fsx.DescribeFileSystemsInput{
+			FileSystemIds: aws.StringSlice([]string{cur.FileSystemID}),
+		})
+		if isFSxErrCode(err, fsx.ErrCodeFileSystemNotFound) || (err == nil && len(out.FileSystems) == 0) {
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				ts.cfg.EKSConfig.Status.DeletedResources[cur.FileSystemID] = "AddOnFSxLustre.FileSystemID"
+			})
+			ts.cfg.Logger.Info("deleted FSx for Lustre file system")
+			return nil
+		}
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe FSx file system", zap.Error(err))
+		}
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("delete FSx for Lustre file system aborted")
+		case <-time.After(30 * time.Second):
+		}
+	}
+	return fmt.Errorf("FSx file system %q not deleted", cur.FileSystemID)
+}
+
+func isFSxErrCode(err error, code string) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == code
+}
+
+func isErrCode(err error, code string) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode() == code
+	}
+	return false
+}
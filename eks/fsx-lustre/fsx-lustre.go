@@ -0,0 +1,230 @@
+// Package fsxlustre creates an FSx for Lustre file system linked to
+// the artifact S3 bucket, installs "aws-fsx-csi-driver", and benchmarks
+// sequential I/O on the file system from a Pod.
+// ref. https://github.com/kubernetes-sigs/aws-fsx-csi-driver
+// ref. https://github.com/kubernetes-sigs/aws-fsx-csi-driver/blob/master/charts/aws-fsx-csi-driver/values.yaml
+package fsxlustre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/pkg/helm"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go/service/fsx"
+	"github.com/aws/aws-sdk-go/service/fsx/fsxiface"
+	"go.uber.org/zap"
+	"k8s.io/utils/exec"
+)
+
+// Config defines FSx for Lustre configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+
+	EC2APIV2 *aws_ec2_v2.Client
+	FSxAPI   fsxiface.FSxAPI
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new FSx for Lustre tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "fsx-lustre",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				EC2APIV2:  cfg.EC2APIV2,
+				FSxAPI:    fsx.New(cfg.AWSSession),
+			}), cfg.EKSConfig.IsEnabledAddOnFSxLustre)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+const chartName = "aws-fsx-csi-driver"
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnFSxLustre() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnFSxLustre.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnFSxLustre.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnFSxLustre.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	if err = ts.createSecurityGroup(); err != nil {
+		return err
+	}
+	if err = ts.createFileSystem(); err != nil {
+		return err
+	}
+	if err = ts.createHelmCSI(); err != nil {
+		return err
+	}
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnFSxLustre.Namespace,
+	); err != nil {
+		return err
+	}
+	if err = ts.createVolume(); err != nil {
+		return err
+	}
+	if err = ts.runBenchmark(); err != nil {
+		return err
+	}
+
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnFSxLustre() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnFSxLustre.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnFSxLustre.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	// unmount from the nodes before deleting the file system
+	var errs []string
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnFSxLustre.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete FSx Lustre namespace (%v)", err))
+	}
+	if err := ts.deleteVolume(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteHelmCSI(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteFileSystem(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteSecurityGroup(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnFSxLustre.Created = false
+	})
+	return nil
+}
+
+// https://github.com/kubernetes-sigs/aws-fsx-csi-driver/blob/master/charts/aws-fsx-csi-driver/values.yaml
+func (ts *tester) createHelmCSI() error {
+	getAllArgs := []string{
+		ts.cfg.EKSConfig.KubectlPath,
+		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+		"--namespace=kube-system",
+		"get",
+		"all",
+		"--selector=app.kubernetes.io/name=" + chartName,
+	}
+	getAllCmd := strings.Join(getAllArgs, " ")
+
+	return helm.Install(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Stopc:          ts.cfg.Stopc,
+		Timeout:        15 * time.Minute,
+		KubeConfigPath: ts.cfg.EKSConfig.KubeConfigPath,
+		Namespace:      "kube-system",
+		ChartRepoURL:   ts.cfg.EKSConfig.AddOnFSxLustre.ChartRepoURL,
+		ChartName:      chartName,
+		ChartVersion:   ts.cfg.EKSConfig.AddOnFSxLustre.ChartVersion,
+		ReleaseName:    chartName,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
+		Values:         map[string]interface{}{},
+		LogFunc: func(format string, v ...interface{}) {
+			ts.cfg.Logger.Info(fmt.Sprintf("[install] "+format, v...))
+		},
+		QueryFunc: func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			output, err := exec.New().CommandContext(ctx, getAllArgs[0], getAllArgs[1:]...).CombinedOutput()
+			cancel()
+			out := strings.TrimSpace(string(output))
+			if err != nil {
+				ts.cfg.Logger.Warn("'kubectl get all' failed", zap.Error(err))
+			}
+			fmt.Fprintf(ts.cfg.LogWriter, "\n\n'%s' output:\n\n%s\n\n", getAllCmd, out)
+		},
+		QueryInterval: 30 * time.Second,
+	})
+}
+
+func (ts *tester) deleteHelmCSI() error {
+	return helm.Uninstall(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Timeout:        15 * time.Minute,
+		KubeConfigPath: ts.cfg.EKSConfig.KubeConfigPath,
+		Namespace:      "kube-system",
+		ChartName:      chartName,
+		ReleaseName:    chartName,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
+	})
+}
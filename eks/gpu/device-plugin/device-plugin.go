@@ -0,0 +1,311 @@
+// Package deviceplugin installs the NVIDIA device plugin on a GPU managed
+// node group, verifies the GPUs are allocatable, and runs a CUDA smoke test
+// Job to validate the accelerated AMI.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/eks-optimized-ami.html#gpu-ami
+// ref. https://github.com/NVIDIA/k8s-device-plugin
+package deviceplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Config defines GPU device plugin configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+}
+
+const (
+	daemonSetName = "nvidia-device-plugin"
+	// resourceNameGPU is the extended resource advertised by the device plugin.
+	resourceNameGPU = v1.ResourceName("nvidia.com/gpu")
+)
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new GPU device plugin tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "gpu-device-plugin",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+			}), cfg.EKSConfig.IsEnabledAddOnGPU)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnGPU() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnGPU.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnGPU.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnGPU.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnGPU.Namespace,
+	); err != nil {
+		return err
+	}
+	if err = ts.createDaemonSet(); err != nil {
+		return err
+	}
+	if err = ts.waitAllocatable(); err != nil {
+		return err
+	}
+	if err = ts.createJob(); err != nil {
+		return err
+	}
+	if err = ts.checkJob(); err != nil {
+		return err
+	}
+
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnGPU() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnGPU.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnGPU.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnGPU.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete GPU namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnGPU.Created = false
+	})
+	return nil
+}
+
+// createDaemonSet creates the NVIDIA device plugin DaemonSet
+// on the GPU node group.
+// ref. https://github.com/NVIDIA/k8s-device-plugin/blob/main/nvidia-device-plugin.yml
+func (ts *tester) createDaemonSet() error {
+	cur := ts.cfg.EKSConfig.AddOnGPU
+	labels := map[string]string{
+		"app.kubernetes.io/name": daemonSetName,
+	}
+	dirOrCreate := v1.HostPathDirectoryOrCreate
+
+	ts.cfg.Logger.Info("creating NVIDIA device plugin DaemonSet", zap.String("image", cur.DevicePluginImage))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		DaemonSets(cur.Namespace).
+		Create(
+			ctx,
+			&appsv1.DaemonSet{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "DaemonSet",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      daemonSetName,
+					Namespace: cur.Namespace,
+					Labels:    labels,
+				},
+				Spec: appsv1.DaemonSetSpec{
+					Selector: &metav1.LabelSelector{
+						MatchLabels: labels,
+					},
+					UpdateStrategy: appsv1.DaemonSetUpdateStrategy{
+						Type: appsv1.RollingUpdateDaemonSetStrategyType,
+					},
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: labels,
+						},
+						Spec: v1.PodSpec{
+							PriorityClassName: "system-node-critical",
+							Tolerations: []v1.Toleration{
+								{
+									Key:      "CriticalAddonsOnly",
+									Operator: v1.TolerationOpExists,
+								},
+								{
+									Key:      string(resourceNameGPU),
+									Operator: v1.TolerationOpExists,
+									Effect:   v1.TaintEffectNoSchedule,
+								},
+							},
+							NodeSelector: map[string]string{
+								"NGName": cur.MNGName,
+							},
+							Containers: []v1.Container{
+								{
+									Name:  daemonSetName,
+									Image: cur.DevicePluginImage,
+									Args:  []string{"--fail-on-init-error=false"},
+									SecurityContext: &v1.SecurityContext{
+										AllowPrivilegeEscalation: aws_v2.Bool(false),
+										Capabilities: &v1.Capabilities{
+											Drop: []v1.Capability{"ALL"},
+										},
+									},
+									VolumeMounts: []v1.VolumeMount{
+										{
+											Name:      "device-plugin",
+											MountPath: "/var/lib/kubelet/device-plugins",
+										},
+									},
+								},
+							},
+							Volumes: []v1.Volume{
+								{
+									Name: "device-plugin",
+									VolumeSource: v1.VolumeSource{
+										HostPath: &v1.HostPathVolumeSource{
+											Path: "/var/lib/kubelet/device-plugins",
+											Type: &dirOrCreate,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create NVIDIA device plugin DaemonSet (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created NVIDIA device plugin DaemonSet")
+	return nil
+}
+
+// waitAllocatable waits for all GPU nodes to advertise the allocatable GPUs.
+func (ts *tester) waitAllocatable() error {
+	cur := ts.cfg.EKSConfig.AddOnGPU
+	mng, ok := ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs[cur.MNGName]
+	if !ok {
+		return fmt.Errorf("MNGs[%q] not found", cur.MNGName)
+	}
+
+	ts.cfg.Logger.Info("waiting for allocatable GPUs",
+		zap.String("mng-name", cur.MNGName),
+		zap.Int("nodes", mng.ASGDesiredCapacity),
+	)
+	deadline := time.Now().Add(cur.SmokeTestTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait for allocatable GPUs aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		nodes, err := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Nodes().List(ctx, metav1.ListOptions{
+			LabelSelector: "NGName=" + cur.MNGName,
+		})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list nodes", zap.Error(err))
+			continue
+		}
+
+		ready, gpus := 0, int64(0)
+		for _, node := range nodes.Items {
+			q, ok := node.Status.Allocatable[resourceNameGPU]
+			if !ok || q.Value() == 0 {
+				continue
+			}
+			ready++
+			gpus += q.Value()
+		}
+		ts.cfg.Logger.Info("checked allocatable GPUs",
+			zap.Int("nodes", len(nodes.Items)),
+			zap.Int("gpu-nodes", ready),
+			zap.Int64("gpus", gpus),
+		)
+		if ready >= mng.ASGDesiredCapacity {
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				cur.AllocatableGPUs = gpus
+			})
+			return nil
+		}
+	}
+	return fmt.Errorf("GPUs not allocatable on MNG %q within %v", cur.MNGName, cur.SmokeTestTimeout)
+}
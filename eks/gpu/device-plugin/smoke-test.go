@@ -0,0 +1,151 @@
+package deviceplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	jobName = "nvidia-smi"
+	// smokeTestMarker is the "nvidia-smi" output header,
+	// printed only when the driver and the device are usable.
+	smokeTestMarker = "NVIDIA-SMI"
+)
+
+// createJob creates the Job to run "nvidia-smi" with a GPU.
+func (ts *tester) createJob() error {
+	cur := ts.cfg.EKSConfig.AddOnGPU
+	ts.cfg.Logger.Info("creating smoke test Job", zap.String("image", cur.SmokeTestImage))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		BatchV1().
+		Jobs(cur.Namespace).
+		Create(
+			ctx,
+			&batchv1.Job{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "batch/v1",
+					Kind:       "Job",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      jobName,
+					Namespace: cur.Namespace,
+				},
+				Spec: batchv1.JobSpec{
+					Completions:  aws_v2.Int32(1),
+					Parallelism:  aws_v2.Int32(1),
+					BackoffLimit: aws_v2.Int32(3),
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{
+								"job-name": jobName,
+							},
+						},
+						Spec: v1.PodSpec{
+							RestartPolicy: v1.RestartPolicyNever,
+							Tolerations: []v1.Toleration{
+								{
+									Key:      string(resourceNameGPU),
+									Operator: v1.TolerationOpExists,
+									Effect:   v1.TaintEffectNoSchedule,
+								},
+							},
+							NodeSelector: map[string]string{
+								"NGName": cur.MNGName,
+							},
+							Containers: []v1.Container{
+								{
+									Name:    jobName,
+									Image:   cur.SmokeTestImage,
+									Command: []string{"nvidia-smi"},
+									Resources: v1.ResourceRequirements{
+										Limits: v1.ResourceList{
+											resourceNameGPU: resource.MustParse("1"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create smoke test Job (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created smoke test Job")
+	return nil
+}
+
+// checkJob waits for the Job to complete, and checks the "nvidia-smi" output.
+func (ts *tester) checkJob() error {
+	cur := ts.cfg.EKSConfig.AddOnGPU
+	jobStart := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), cur.SmokeTestTimeout)
+	_, pods, err := k8s_client.WaitForJobCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.K8SClient,
+		30*time.Second,
+		10*time.Second,
+		cur.Namespace,
+		jobName,
+		1,
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("smoke test Job failed (%v)", err)
+	}
+
+	for _, pod := range pods {
+		if pod.Status.Phase != v1.PodSucceeded {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		b, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Pods(cur.Namespace).
+			GetLogs(pod.Name, &v1.PodLogOptions{}).
+			DoRaw(ctx)
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get smoke test Pod logs", zap.String("pod-name", pod.Name), zap.Error(err))
+			continue
+		}
+		out := string(b)
+		fmt.Fprintf(ts.cfg.LogWriter, "\n\n'nvidia-smi' output from %q on %q:\n\n%s\n\n", pod.Name, pod.Spec.NodeName, out)
+		if !strings.Contains(out, smokeTestMarker) {
+			return fmt.Errorf("unexpected 'nvidia-smi' output from %q (missing %q)", pod.Name, smokeTestMarker)
+		}
+
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.SmokeTestOutput = out
+			cur.TimeFrameSmokeTest = timeutil.NewTimeFrame(jobStart, time.Now())
+		})
+		ts.cfg.Logger.Info("checked smoke test Job",
+			zap.String("pod-name", pod.Name),
+			zap.String("node-name", pod.Spec.NodeName),
+			zap.String("took", cur.TimeFrameSmokeTest.TookString),
+		)
+		return nil
+	}
+	return errors.New("no succeeded smoke test Pod found")
+}
@@ -0,0 +1,37 @@
+package eks
+
+import (
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"go.uber.org/zap"
+)
+
+// takeHealthSnapshot takes the cluster health snapshot, and persists it
+// as the latest in the status. Returns nil if the cluster is not created yet.
+func (ts *Tester) takeHealthSnapshot() *k8s_client.HealthSnapshot {
+	if ts.k8sClient == nil {
+		return nil
+	}
+	s := ts.k8sClient.HealthCheck()
+	ts.cfg.Status.HealthSnapshot = &s
+	ts.cfg.Sync()
+	return &s
+}
+
+// annotateHealthDiff takes the cluster health snapshot after the phase,
+// and attaches the health changes since "before" to the phase in the report,
+// so that the regressions are attributed to the phase that caused them.
+func (ts *Tester) annotateHealthDiff(class string, name string, before *k8s_client.HealthSnapshot) {
+	if before == nil {
+		return
+	}
+	after := ts.takeHealthSnapshot()
+	if after == nil {
+		return
+	}
+	ds := k8s_client.HealthDiff(*before, *after)
+	if len(ds) == 0 {
+		return
+	}
+	ts.lg.Warn("cluster health changed", zap.String("phase", name), zap.Strings("diff", ds))
+	ts.report.Annotate(class, name, ds...)
+}
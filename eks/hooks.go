@@ -0,0 +1,222 @@
+package eks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/ssh"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/exec"
+)
+
+// runHooks runs the hooks of the lifecycle phase in order, and records
+// each hook in the report. On "Up", the first failed hook stops the run.
+// On "Down", all hooks run and the failures are returned together.
+func (ts *Tester) runHooks(phase string) error {
+	hooks := ts.cfg.Hooks.Phase(phase)
+	if len(hooks) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(ts.cfg.Hooks.OutputDir, 0700); err != nil {
+		return fmt.Errorf("failed to create hooks output directory (%v)", err)
+	}
+
+	class, create := "down", false
+	if phase == eksconfig.HookPhasePreCreate || phase == eksconfig.HookPhasePostCreate {
+		class, create = "up", true
+	}
+	var errs []string
+	for _, h := range hooks {
+		fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
+		fmt.Fprintf(ts.logWriter, ts.color("[light_green]hooks.%s [cyan]%q [default](%q)\n"), phase, h.Name, ts.cfg.ConfigPath)
+
+		hook, step := h, "hooks."+phase+"."+h.Name
+		fn := func() error { return ts.runHook(hook) }
+		if create {
+			fn = ts.resumable(step, fn)
+		}
+		err := ts.report.Wrap(class, step, fn)()
+		switch {
+		case err == nil:
+		case hook.IgnoreFailure:
+			ts.lg.Warn("hook failed; ignoring", zap.String("phase", phase), zap.String("name", hook.Name), zap.Error(err))
+		case create:
+			return fmt.Errorf("hook %s %q failed (%v)", phase, hook.Name, err)
+		default:
+			errs = append(errs, fmt.Sprintf("hook %s %q failed (%v)", phase, hook.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// runHook runs the hook and writes the output to "Hook.OutputPath".
+func (ts *Tester) runHook(h eksconfig.Hook) (err error) {
+	ts.lg.Info("running hook",
+		zap.String("name", h.Name),
+		zap.String("command", h.Command),
+		zap.String("node-group-name", h.NodeGroupName),
+		zap.String("timeout", h.TimeoutString),
+	)
+	var out []byte
+	desc := h.Command
+	if h.NodeScript != "" {
+		desc = h.NodeScript
+		out, err = ts.runHookNodeScript(h)
+	} else {
+		out, err = ts.runHookCommand(h)
+	}
+	fmt.Fprintf(ts.logWriter, "\nhook %q output:\n\n%s\n", h.Name, string(out))
+
+	if werr := writeHookOutput(h.OutputPath, desc, out, err); werr != nil {
+		ts.lg.Warn("failed to write hook output", zap.String("path", h.OutputPath), zap.Error(werr))
+	}
+	if err != nil {
+		return err
+	}
+	ts.lg.Info("ran hook", zap.String("name", h.Name), zap.String("output-path", h.OutputPath))
+	return nil
+}
+
+// runHookCommand runs the local command with the cluster environment variables.
+func (ts *Tester) runHookCommand(h eksconfig.Hook) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.Timeout)
+	defer cancel()
+	cmd := exec.New().CommandContext(ctx, "sh", "-c", h.Command)
+	cmd.SetEnv(append(os.Environ(), hookEnvs(ts.cfg)...))
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", h.TimeoutString)
+	}
+	return out, err
+}
+
+func hookEnvs(cfg *eksconfig.Config) []string {
+	return []string{
+		"KUBECONFIG=" + cfg.KubeConfigPath,
+		"AWS_K8S_TESTER_EKS_CONFIG_PATH=" + cfg.ConfigPath,
+		"AWS_K8S_TESTER_EKS_CLUSTER_NAME=" + cfg.Name,
+	}
+}
+
+// runHookNodeScript runs the script as root on each Ready node,
+// and returns the outputs headed by the node names.
+func (ts *Tester) runHookNodeScript(h eksconfig.Hook) ([]byte, error) {
+	if ts.k8sClient == nil {
+		return nil, errors.New("no Kubernetes client to list the nodes")
+	}
+	opts := metav1.ListOptions{}
+	if h.NodeGroupName != "" {
+		opts.LabelSelector = "NGName=" + h.NodeGroupName
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	nodes, err := ts.k8sClient.KubernetesClientSet().CoreV1().Nodes().List(ctx, opts)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes (%v)", err)
+	}
+
+	pool := ssh.NewPool(ts.lg)
+	defer pool.Close()
+	var buf bytes.Buffer
+	var errs []string
+	ran := 0
+	for _, node := range nodes.Items {
+		if !isNodeReady(node) || node.Spec.ProviderID == "" {
+			continue
+		}
+		ran++
+		// e.g. "aws:///us-west-2a/i-0123456789abcdef0"
+		id := node.Spec.ProviderID[strings.LastIndex(node.Spec.ProviderID, "/")+1:]
+		fmt.Fprintf(&buf, "## %s (%s)\n", node.Name, id)
+		n, err := ts.hookNode(pool, h.Transport, id)
+		if err != nil {
+			fmt.Fprintf(&buf, "%v\n\n", err)
+			errs = append(errs, fmt.Sprintf("%q (%v)", node.Name, err))
+			continue
+		}
+		out, err := n.Exec(nodeScriptCmd(h.NodeScript), ssh.WithSudo(true), ssh.WithTimeout(h.Timeout))
+		fmt.Fprintf(&buf, "%s\n", out)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%q (%v)", node.Name, err))
+		}
+	}
+	if ran == 0 {
+		return buf.Bytes(), errors.New("no Ready node found")
+	}
+	if len(errs) > 0 {
+		return buf.Bytes(), fmt.Errorf("failed on %d of %d node(s): %s", len(errs), ran, strings.Join(errs, ", "))
+	}
+	return buf.Bytes(), nil
+}
+
+// hookNode returns the node to run the script on, with the transport.
+func (ts *Tester) hookNode(pool *ssh.Pool, transport string, instanceID string) (ssh.Node, error) {
+	if transport == eksconfig.NodeTransportSSM {
+		return ssh.NewSSMNode(ts.ssmAPIV2, instanceID), nil
+	}
+
+	inst, ok := ts.cfg.Instance(instanceID)
+	if !ok {
+		return nil, fmt.Errorf("instance %q not found in the node groups", instanceID)
+	}
+	sh, err := pool.Get(instanceID, ssh.Config{
+		Logger:        ts.lg,
+		KeyPath:       ts.cfg.RemoteAccessPrivateKeyPath,
+		PublicIP:      inst.PublicIP,
+		PublicDNSName: inst.PublicDNSName,
+		PrivateIP:     inst.PrivateIP,
+		UserName:      inst.RemoteAccessUserName,
+		InstanceConnect: ssh.NewInstanceConnect(
+			ts.ec2InstanceConnectAPI,
+			instanceID,
+			inst.Placement.AvailabilityZone,
+		),
+		InstanceID:         instanceID,
+		HostKeys:           ts.sshHostKeys,
+		InsecureSkipVerify: ts.sshHostKeys == nil,
+		ProxyJump: ssh.NewProxyJump(
+			ts.cfg.RemoteAccessBastionHost,
+			ts.cfg.RemoteAccessBastionUserName,
+			ts.cfg.RemoteAccessBastionPrivateKeyPath,
+		),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewSSHNode(instanceID, sh), nil
+}
+
+// nodeScriptCmd returns the command to run the multi-line script
+// with "sh", single-quoting the script.
+func nodeScriptCmd(script string) string {
+	return "sh -c '" + strings.ReplaceAll(script, "'", `'\''`) + "'"
+}
+
+func isNodeReady(node v1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == v1.NodeReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func writeHookOutput(fpath string, desc string, out []byte, err error) error {
+	body := desc + "\n\n# output\n" + string(out)
+	if err != nil {
+		body += "\n\n# error\n" + err.Error()
+	}
+	return ioutil.WriteFile(fpath, []byte(body), 0600)
+}
@@ -0,0 +1,64 @@
+package eks
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"go.uber.org/zap"
+)
+
+func TestRunHookCommand(t *testing.T) {
+	dir := t.TempDir()
+	ts := &Tester{
+		lg:        zap.NewExample(),
+		logWriter: &bytes.Buffer{},
+		cfg:       &eksconfig.Config{Name: "test-cluster", KubeConfigPath: "/tmp/kubeconfig"},
+	}
+
+	h := eksconfig.Hook{
+		Name:          "env",
+		Command:       `echo "$AWS_K8S_TESTER_EKS_CLUSTER_NAME $KUBECONFIG"`,
+		Timeout:       time.Minute,
+		TimeoutString: time.Minute.String(),
+		OutputPath:    filepath.Join(dir, "post-create-env.out.log"),
+	}
+	if err := ts.runHook(h); err != nil {
+		t.Fatal(err)
+	}
+	d, err := ioutil.ReadFile(h.OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(d), "test-cluster /tmp/kubeconfig") {
+		t.Fatalf("unexpected output %q", string(d))
+	}
+
+	h.Command, h.Timeout, h.TimeoutString = "sleep 5", 100*time.Millisecond, "100ms"
+	if err = ts.runHook(h); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	d, err = ioutil.ReadFile(h.OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(d), "# error\ntimed out after 100ms") {
+		t.Fatalf("unexpected output %q", string(d))
+	}
+}
+
+func TestNodeScriptCmd(t *testing.T) {
+	script := "echo 'ok'\nexit 3"
+	out, err := exec.Command("sh", "-c", nodeScriptCmd(script)).CombinedOutput()
+	if string(out) != "ok\n" {
+		t.Fatalf("unexpected output %q", string(out))
+	}
+	if ee, ok := err.(*exec.ExitError); !ok || ee.ExitCode() != 3 {
+		t.Fatalf("expected exit code 3, got %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+// Package hpa implements the Horizontal Pod Autoscaler functional test,
+// which deploys a CPU-bound workload with an HPA, generates load, and
+// measures how long the replicas take to scale up and back down.
+// ref. https://kubernetes.io/docs/tasks/run-application/horizontal-pod-autoscale-walkthrough/
+package hpa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"go.uber.org/zap"
+)
+
+// Config defines HPA tester configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new HPA tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:         "hpa",
+		Dependencies: []string{"metrics-server"},
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+			}), cfg.EKSConfig.IsEnabledAddOnHPA)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+func (ts *tester) Create() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnHPA() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnHPA.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnHPA.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnHPA.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	if err := k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnHPA.Namespace,
+	); err != nil {
+		return err
+	}
+	if err := ts.createApp(); err != nil {
+		return err
+	}
+	if err := ts.createHPA(); err != nil {
+		return err
+	}
+	if err := ts.waitScalingActive(); err != nil {
+		return err
+	}
+	if err := ts.scaleUp(); err != nil {
+		return err
+	}
+	if err := ts.scaleDown(); err != nil {
+		return err
+	}
+
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnHPA() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnHPA.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnHPA.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnHPA.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete HPA namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnHPA.Created = false
+	})
+	return nil
+}
+
+// scaleUp starts the load generators, and waits for the replicas
+// to exceed "MinReplicas".
+func (ts *tester) scaleUp() error {
+	cur := ts.cfg.EKSConfig.AddOnHPA
+	if err := ts.createLoadGenerator(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	_, err := k8s_client.WaitForDeploymentCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.K8SClient,
+		5*time.Second,
+		5*time.Second,
+		cur.Namespace,
+		loadGeneratorName,
+		cur.LoadGenerators,
+	)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	latency, err := ts.waitReplicas("scale up", cur.ScaleUpTimeout, func(replicas int32) bool {
+		return replicas > cur.MinReplicas
+	})
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.ScaleUpLatency = latency
+		cur.ScaleUpLatencyString = latency.String()
+	})
+	return nil
+}
+
+// scaleDown stops the load generators, and waits for the replicas
+// to go back to "MinReplicas".
+func (ts *tester) scaleDown() error {
+	cur := ts.cfg.EKSConfig.AddOnHPA
+	if err := ts.deleteLoadGenerator(); err != nil {
+		return err
+	}
+
+	latency, err := ts.waitReplicas("scale down", cur.ScaleDownTimeout, func(replicas int32) bool {
+		return replicas == cur.MinReplicas
+	})
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.ScaleDownLatency = latency
+		cur.ScaleDownLatencyString = latency.String()
+	})
+	return nil
+}
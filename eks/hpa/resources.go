@@ -0,0 +1,323 @@
+package hpa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-sdk-go/aws"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	appName           = "php-apache"
+	loadGeneratorName = "load-generator"
+	hpaName           = appName
+)
+
+// createApp creates the CPU-bound Deployment and its Service.
+// The CPU requests are required for the utilization target.
+func (ts *tester) createApp() error {
+	cur := ts.cfg.EKSConfig.AddOnHPA
+	labels := map[string]string{"app.kubernetes.io/name": appName}
+
+	ts.cfg.Logger.Info("creating HPA target Deployment", zap.String("image", cur.Image))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(cur.Namespace).
+		Create(
+			ctx,
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      appName,
+					Namespace: cur.Namespace,
+					Labels:    labels,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: aws.Int32(cur.MinReplicas),
+					Selector: &metav1.LabelSelector{MatchLabels: labels},
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						Spec: v1.PodSpec{
+							Containers: []v1.Container{
+								{
+									Name:  appName,
+									Image: cur.Image,
+									Ports: []v1.ContainerPort{{ContainerPort: 80}},
+									Resources: v1.ResourceRequirements{
+										Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("200m")},
+										Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create HPA target Deployment (%v)", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Services(cur.Namespace).
+		Create(
+			ctx,
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      appName,
+					Namespace: cur.Namespace,
+					Labels:    labels,
+				},
+				Spec: v1.ServiceSpec{
+					Selector: labels,
+					Ports: []v1.ServicePort{
+						{Port: 80, TargetPort: intstr.FromInt(80), Protocol: v1.ProtocolTCP},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create HPA target Service (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created HPA target Deployment and Service")
+	return nil
+}
+
+func (ts *tester) createHPA() error {
+	ts.cfg.Logger.Info("creating HPA")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		AutoscalingV2().
+		HorizontalPodAutoscalers(ts.cfg.EKSConfig.AddOnHPA.Namespace).
+		Create(ctx, newHPA(ts.cfg.EKSConfig.AddOnHPA), metav1.CreateOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create HPA (%v)", err)
+	}
+	ts.cfg.Logger.Info("created HPA")
+	return nil
+}
+
+// newHPA returns the HPA for the target Deployment, with a shorter
+// scale down stabilization window than the default 5-minute.
+func newHPA(cur *eksconfig.AddOnHPA) *autoscalingv2.HorizontalPodAutoscaler {
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      hpaName,
+			Namespace: cur.Namespace,
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       appName,
+			},
+			MinReplicas: aws.Int32(cur.MinReplicas),
+			MaxReplicas: cur.MaxReplicas,
+			Metrics: []autoscalingv2.MetricSpec{
+				{
+					Type: autoscalingv2.ResourceMetricSourceType,
+					Resource: &autoscalingv2.ResourceMetricSource{
+						Name: v1.ResourceCPU,
+						Target: autoscalingv2.MetricTarget{
+							Type:               autoscalingv2.UtilizationMetricType,
+							AverageUtilization: aws.Int32(cur.TargetCPUUtilizationPercentage),
+						},
+					},
+				},
+			},
+			Behavior: &autoscalingv2.HorizontalPodAutoscalerBehavior{
+				ScaleDown: &autoscalingv2.HPAScalingRules{
+					StabilizationWindowSeconds: aws.Int32(int32(cur.ScaleDownStabilizationWindow.Seconds())),
+				},
+			},
+		},
+	}
+}
+
+// createLoadGenerator creates the Deployment that requests
+// the target Service in a loop.
+func (ts *tester) createLoadGenerator() error {
+	cur := ts.cfg.EKSConfig.AddOnHPA
+	labels := map[string]string{"app.kubernetes.io/name": loadGeneratorName}
+
+	ts.cfg.Logger.Info("creating load generator Deployment", zap.Int32("load-generators", cur.LoadGenerators))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(cur.Namespace).
+		Create(
+			ctx,
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      loadGeneratorName,
+					Namespace: cur.Namespace,
+					Labels:    labels,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: aws.Int32(cur.LoadGenerators),
+					Selector: &metav1.LabelSelector{MatchLabels: labels},
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						Spec: v1.PodSpec{
+							Containers: []v1.Container{
+								{
+									Name:    loadGeneratorName,
+									Image:   cur.LoadGeneratorImage,
+									Command: []string{"/bin/sh", "-c", fmt.Sprintf("while true; do wget -q -O- http://%s.%s.svc; done", appName, cur.Namespace)},
+								},
+							},
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create load generator Deployment (%v)", err)
+	}
+	ts.cfg.Logger.Info("created load generator Deployment")
+	return nil
+}
+
+func (ts *tester) deleteLoadGenerator() error {
+	ts.cfg.Logger.Info("deleting load generator Deployment")
+	foreground := metav1.DeletePropagationForeground
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(ts.cfg.EKSConfig.AddOnHPA.Namespace).
+		Delete(
+			ctx,
+			loadGeneratorName,
+			metav1.DeleteOptions{
+				GracePeriodSeconds: aws.Int64(0),
+				PropagationPolicy:  &foreground,
+			},
+		)
+	cancel()
+	if err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to delete load generator Deployment (%v)", err)
+	}
+	ts.cfg.Logger.Info("deleted load generator Deployment")
+	return nil
+}
+
+func (ts *tester) getHPA() (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	hpa, err := ts.cfg.K8SClient.KubernetesClientSet().
+		AutoscalingV2().
+		HorizontalPodAutoscalers(ts.cfg.EKSConfig.AddOnHPA.Namespace).
+		Get(ctx, hpaName, metav1.GetOptions{})
+	cancel()
+	return hpa, err
+}
+
+// waitScalingActive waits until the HPA can compute the CPU utilization
+// from metrics-server, so that the scale up latency does not include
+// the metrics-server warm-up.
+func (ts *tester) waitScalingActive() error {
+	ts.cfg.Logger.Info("waiting for HPA scaling active")
+	waitStart := time.Now()
+	for time.Since(waitStart) < 5*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("HPA scaling active wait aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		hpa, err := ts.getHPA()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get HPA", zap.Error(err))
+			continue
+		}
+		if isScalingActive(hpa.Status) {
+			ts.cfg.Logger.Info("HPA scaling active", zap.Duration("took", time.Since(waitStart)))
+			return nil
+		}
+		ts.cfg.Logger.Info("HPA scaling not active yet", zap.Int("conditions", len(hpa.Status.Conditions)))
+	}
+	return errors.New("HPA scaling not active; is metrics-server running?")
+}
+
+// isScalingActive returns true if the HPA reports "ScalingActive"
+// and the current CPU utilization.
+func isScalingActive(status autoscalingv2.HorizontalPodAutoscalerStatus) bool {
+	active := false
+	for _, cond := range status.Conditions {
+		if cond.Type == autoscalingv2.ScalingActive && cond.Status == v1.ConditionTrue {
+			active = true
+		}
+	}
+	if !active {
+		return false
+	}
+	for _, m := range status.CurrentMetrics {
+		if m.Resource != nil && m.Resource.Name == v1.ResourceCPU && m.Resource.Current.AverageUtilization != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// waitReplicas polls the HPA current replicas until "done" returns true,
+// and returns the elapsed time. It fails if the replicas ever exceed
+// "MaxReplicas".
+func (ts *tester) waitReplicas(desc string, timeout time.Duration, done func(replicas int32) bool) (time.Duration, error) {
+	cur := ts.cfg.EKSConfig.AddOnHPA
+	ts.cfg.Logger.Info("waiting for HPA replicas", zap.String("desc", desc), zap.Duration("timeout", timeout))
+
+	waitStart := time.Now()
+	for time.Since(waitStart) < timeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return 0, fmt.Errorf("HPA %s wait aborted", desc)
+		case <-time.After(5 * time.Second):
+		}
+
+		hpa, err := ts.getHPA()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get HPA", zap.Error(err))
+			continue
+		}
+		replicas := hpa.Status.CurrentReplicas
+		if replicas > cur.MaxObservedReplicas {
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				cur.MaxObservedReplicas = replicas
+			})
+		}
+		if replicas > cur.MaxReplicas {
+			return 0, fmt.Errorf("HPA replicas %d exceeded max replicas %d", replicas, cur.MaxReplicas)
+		}
+		ts.cfg.Logger.Info("polled HPA",
+			zap.String("desc", desc),
+			zap.Int32("current-replicas", replicas),
+			zap.Int32("desired-replicas", hpa.Status.DesiredReplicas),
+		)
+		if done(replicas) {
+			took := time.Since(waitStart)
+			ts.cfg.Logger.Info("HPA replicas reached", zap.String("desc", desc), zap.Int32("replicas", replicas), zap.Duration("took", took))
+			return took, nil
+		}
+	}
+	return 0, fmt.Errorf("HPA %s did not complete in %v", desc, timeout)
+}
@@ -0,0 +1,69 @@
+package hpa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-sdk-go/aws"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestNewHPA(t *testing.T) {
+	hpa := newHPA(&eksconfig.AddOnHPA{
+		Namespace:                      "test-hpa",
+		MinReplicas:                    1,
+		MaxReplicas:                    5,
+		TargetCPUUtilizationPercentage: 50,
+		ScaleDownStabilizationWindow:   90 * time.Second,
+	})
+	if hpa.Namespace != "test-hpa" || hpa.Spec.ScaleTargetRef.Name != appName {
+		t.Fatalf("unexpected HPA %+v", hpa.ObjectMeta)
+	}
+	if *hpa.Spec.MinReplicas != 1 || hpa.Spec.MaxReplicas != 5 {
+		t.Fatalf("unexpected replicas %d-%d", *hpa.Spec.MinReplicas, hpa.Spec.MaxReplicas)
+	}
+	if v := *hpa.Spec.Metrics[0].Resource.Target.AverageUtilization; v != 50 {
+		t.Fatalf("unexpected target utilization %d", v)
+	}
+	if v := *hpa.Spec.Behavior.ScaleDown.StabilizationWindowSeconds; v != 90 {
+		t.Fatalf("unexpected stabilization window %d", v)
+	}
+}
+
+func TestIsScalingActive(t *testing.T) {
+	active := []autoscalingv2.HorizontalPodAutoscalerCondition{
+		{Type: autoscalingv2.AbleToScale, Status: v1.ConditionTrue},
+		{Type: autoscalingv2.ScalingActive, Status: v1.ConditionTrue},
+	}
+	cpu := []autoscalingv2.MetricStatus{
+		{
+			Type: autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricStatus{
+				Name:    v1.ResourceCPU,
+				Current: autoscalingv2.MetricValueStatus{AverageUtilization: aws.Int32(3)},
+			},
+		},
+	}
+	tt := []struct {
+		status   autoscalingv2.HorizontalPodAutoscalerStatus
+		expected bool
+	}{
+		{autoscalingv2.HorizontalPodAutoscalerStatus{}, false},
+		{autoscalingv2.HorizontalPodAutoscalerStatus{Conditions: active}, false},
+		{autoscalingv2.HorizontalPodAutoscalerStatus{CurrentMetrics: cpu}, false},
+		{autoscalingv2.HorizontalPodAutoscalerStatus{
+			Conditions: []autoscalingv2.HorizontalPodAutoscalerCondition{
+				{Type: autoscalingv2.ScalingActive, Status: v1.ConditionFalse, Reason: "FailedGetResourceMetric"},
+			},
+			CurrentMetrics: cpu,
+		}, false},
+		{autoscalingv2.HorizontalPodAutoscalerStatus{Conditions: active, CurrentMetrics: cpu}, true},
+	}
+	for i, tv := range tt {
+		if v := isScalingActive(tv.status); v != tv.expected {
+			t.Fatalf("#%d: expected %v, got %v", i, tv.expected, v)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package eks
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	aws_ecr "github.com/aws/aws-k8s-tester/pkg/aws/ecr"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"go.uber.org/zap"
+)
+
+// mirrorImages replicates the images of the enabled add-ons to the private
+// ECR repositories, and rewrites the add-on images to the mirrored ones.
+// Replication is a no-op for the images already mirrored by the previous runs.
+func (ts *Tester) mirrorImages() error {
+	if !ts.cfg.ImageMirror.Enable {
+		ts.lg.Info("skipping image mirroring")
+		return nil
+	}
+	accountID := ts.cfg.Status.AWSAccountID
+	if accountID == "" {
+		return errors.New("empty AWS account ID")
+	}
+	cur := ts.cfg.ImageMirror
+	if cur.Images == nil {
+		cur.Images = make(map[string]string)
+	}
+
+	imgs := ts.cfg.Images()
+	ts.lg.Info("mirroring images", zap.Int("images", len(imgs)), zap.String("repository-prefix", cur.RepositoryPrefix))
+	for _, img := range imgs {
+		mirrored, err := ts.mirrorImage(accountID, img)
+		if err != nil {
+			return err
+		}
+		cur.Images[img] = mirrored
+		ts.cfg.Sync()
+	}
+	ts.cfg.RewriteImages()
+	ts.cfg.Sync()
+
+	fmt.Fprintf(ts.logWriter, "\nmirrored %d images with the prefix %q\n", len(imgs), cur.RepositoryPrefix)
+	for _, img := range imgs {
+		fmt.Fprintf(ts.logWriter, "%s -> %s\n", img, cur.Images[img])
+	}
+	return nil
+}
+
+// mirrorImage replicates the image to the repository named after the
+// source repository path, and returns the mirrored image with the same
+// tag, or the same digest if referenced by digest.
+func (ts *Tester) mirrorImage(accountID string, img string) (string, error) {
+	src, err := aws_ecr.ParseReference(img)
+	if err != nil {
+		return "", err
+	}
+	repoName := ts.cfg.ImageMirror.RepositoryPrefix + "/" + src.Repository
+	repoURI, err := aws_ecr.Create(
+		ts.lg,
+		ts.ecrAPISameRegion,
+		accountID,
+		ts.cfg.Region,
+		repoName,
+		false,
+		ecr.ImageTagMutabilityMutable,
+		"",
+		false,
+	)
+	if err != nil {
+		return "", err
+	}
+	found := false
+	for _, name := range ts.cfg.ImageMirror.Repositories {
+		if name == repoName {
+			found = true
+			break
+		}
+	}
+	if !found {
+		ts.cfg.ImageMirror.Repositories = append(ts.cfg.ImageMirror.Repositories, repoName)
+		ts.cfg.Sync()
+	}
+
+	digest, err := aws_ecr.Replicate(ts.lg, ts.ecrAPISameRegion, img, accountID, repoName, src.Tag)
+	if err != nil {
+		return "", err
+	}
+	if src.Tag == "" {
+		return repoURI + "@" + digest, nil
+	}
+	return repoURI + ":" + src.Tag, nil
+}
+
+// deleteMirroredImages deletes the image mirror repositories with the images.
+func (ts *Tester) deleteMirroredImages() error {
+	if ts.cfg.ImageMirror == nil || len(ts.cfg.ImageMirror.Repositories) == 0 {
+		ts.lg.Info("skipping image mirror deletion")
+		return nil
+	}
+	var errs []string
+	remaining := make([]string, 0)
+	for _, repoName := range ts.cfg.ImageMirror.Repositories {
+		if err := aws_ecr.Delete(
+			ts.lg,
+			ts.ecrAPISameRegion,
+			ts.cfg.Status.AWSAccountID,
+			ts.cfg.Region,
+			repoName,
+			true,
+		); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete image mirror repository %q (%v)", repoName, err))
+			remaining = append(remaining, repoName)
+		}
+	}
+	// keep the mirrored images, since the add-on images remain rewritten
+	ts.cfg.ImageMirror.Repositories = remaining
+	ts.cfg.Sync()
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+// Package ipv6 tests Pod networking on IPv6 clusters: it checks the Pods
+// are assigned IPv6 addresses, and verifies pod-to-pod connectivity across
+// nodes and pod-to-internet connectivity over IPv6.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/cni-ipv6.html
+package ipv6
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"go.uber.org/zap"
+)
+
+// Config defines IPv6 connectivity tester configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new IPv6 connectivity tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "ipv6",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+			}), func() bool { return cfg.EKSConfig.IPFamily == eksconfig.IPFamilyIPv6 })
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+func (ts *tester) namespace() string {
+	return ts.cfg.EKSConfig.Name + "-ipv6"
+}
+
+func (ts *tester) Create() (err error) {
+	if ts.cfg.EKSConfig.IPFamily != eksconfig.IPFamilyIPv6 {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName), zap.String("ip-family", ts.cfg.EKSConfig.IPFamily))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	createStart := time.Now()
+	defer func() {
+		ts.cfg.Logger.Info("completed tester.Create", zap.String("tester", pkgName), zap.String("took", time.Since(createStart).String()))
+	}()
+
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.namespace(),
+	); err != nil {
+		return err
+	}
+	if err = ts.createServer(); err != nil {
+		return err
+	}
+	if err = ts.createClient(); err != nil {
+		return err
+	}
+	server, client, err := ts.waitPods()
+	if err != nil {
+		return err
+	}
+	if server.Spec.NodeName == client.Spec.NodeName {
+		ts.cfg.Logger.Warn("server and client Pods scheduled on the same node; pod-to-pod check stays within the node",
+			zap.String("node", server.Spec.NodeName),
+		)
+	}
+	if err = ts.checkPodToPod(server.Status.PodIP); err != nil {
+		return err
+	}
+	return ts.checkPodToInternet()
+}
+
+func (ts *tester) Delete() error {
+	if ts.cfg.EKSConfig.IPFamily != eksconfig.IPFamilyIPv6 {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName), zap.String("ip-family", ts.cfg.EKSConfig.IPFamily))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	var errs []string
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.namespace(),
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete IPv6 namespace (%v)", err))
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
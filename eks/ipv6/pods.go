@@ -0,0 +1,240 @@
+package ipv6
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/exec"
+)
+
+const (
+	serverPodName = "ipv6-server"
+	clientPodName = "ipv6-client"
+	serverPort    = 8080
+
+	serverImage = eksconfig.DefaultBusyboxImage
+	clientImage = eksconfig.DefaultCurlImage
+
+	// internetURL only resolves to IPv6 addresses
+	internetURL = "https://ipv6.google.com"
+)
+
+func (ts *tester) createServer() error {
+	ts.cfg.Logger.Info("creating server Pod", zap.String("image", ts.cfg.EKSConfig.Image(serverImage)))
+	return ts.createPod(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serverPodName,
+			Namespace: ts.namespace(),
+			Labels:    map[string]string{"app.kubernetes.io/name": serverPodName},
+		},
+		Spec: v1.PodSpec{
+			RestartPolicy: v1.RestartPolicyAlways,
+			Containers: []v1.Container{
+				{
+					Name:            serverPodName,
+					Image:           ts.cfg.EKSConfig.Image(serverImage),
+					ImagePullPolicy: v1.PullIfNotPresent,
+					Command: []string{
+						"/bin/sh",
+						"-c",
+						fmt.Sprintf("mkdir -p /www && echo ok > /www/index.html && httpd -f -p %d -h /www", serverPort),
+					},
+					Ports: []v1.ContainerPort{{ContainerPort: serverPort}},
+				},
+			},
+			NodeSelector: map[string]string{
+				"kubernetes.io/os": "linux",
+			},
+		},
+	})
+}
+
+func (ts *tester) createClient() error {
+	ts.cfg.Logger.Info("creating client Pod", zap.String("image", ts.cfg.EKSConfig.Image(clientImage)))
+	return ts.createPod(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clientPodName,
+			Namespace: ts.namespace(),
+			Labels:    map[string]string{"app.kubernetes.io/name": clientPodName},
+		},
+		Spec: v1.PodSpec{
+			RestartPolicy: v1.RestartPolicyAlways,
+			Containers: []v1.Container{
+				{
+					Name:            clientPodName,
+					Image:           ts.cfg.EKSConfig.Image(clientImage),
+					ImagePullPolicy: v1.PullIfNotPresent,
+					Command:         []string{"/bin/sh", "-c", "sleep 3600"},
+				},
+			},
+			NodeSelector: map[string]string{
+				"kubernetes.io/os": "linux",
+			},
+			// prefer a different node from the server, to test cross-node traffic
+			Affinity: &v1.Affinity{
+				PodAntiAffinity: &v1.PodAntiAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{
+						{
+							Weight: 100,
+							PodAffinityTerm: v1.PodAffinityTerm{
+								LabelSelector: &metav1.LabelSelector{
+									MatchLabels: map[string]string{"app.kubernetes.io/name": serverPodName},
+								},
+								TopologyKey: "kubernetes.io/hostname",
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+func (ts *tester) createPod(pod *v1.Pod) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(ts.namespace()).
+		Create(ctx, pod, metav1.CreateOptions{})
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Pod %q (%v)", pod.Name, err)
+	}
+	ts.cfg.Logger.Info("created Pod", zap.String("pod", pod.Name))
+	return nil
+}
+
+// waitPods waits for the server and client Pods to be running,
+// and checks both Pods are assigned IPv6 addresses.
+func (ts *tester) waitPods() (server *v1.Pod, client *v1.Pod, err error) {
+	podCli := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Pods(ts.namespace())
+	waitStart := time.Now()
+	for time.Since(waitStart) < 10*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return nil, nil, errors.New("Pod wait aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		running := make(map[string]*v1.Pod)
+		for _, name := range []string{serverPodName, clientPodName} {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			pod, err := podCli.Get(ctx, name, metav1.GetOptions{})
+			cancel()
+			if err != nil {
+				ts.cfg.Logger.Warn("failed to get Pod", zap.String("pod", name), zap.Error(err))
+				continue
+			}
+			ts.cfg.Logger.Info("polled Pod",
+				zap.String("pod", name),
+				zap.String("phase", string(pod.Status.Phase)),
+				zap.String("node", pod.Spec.NodeName),
+				zap.String("pod-ip", pod.Status.PodIP),
+			)
+			if pod.Status.Phase == v1.PodRunning && pod.Status.PodIP != "" {
+				running[name] = pod
+			}
+		}
+		if len(running) < 2 {
+			continue
+		}
+
+		for name, pod := range running {
+			if !isIPv6(pod.Status.PodIP) {
+				return nil, nil, fmt.Errorf("Pod %q IP %q is not IPv6", name, pod.Status.PodIP)
+			}
+		}
+		ts.cfg.Logger.Info("Pods running with IPv6 addresses",
+			zap.String("server-ip", running[serverPodName].Status.PodIP),
+			zap.String("client-ip", running[clientPodName].Status.PodIP),
+		)
+		return running[serverPodName], running[clientPodName], nil
+	}
+	return nil, nil, errors.New("IPv6 Pods not running")
+}
+
+func (ts *tester) checkPodToPod(serverIP string) error {
+	url := podURL(serverIP, serverPort)
+	ts.cfg.Logger.Info("checking pod-to-pod connectivity over IPv6", zap.String("url", url))
+	out, err := ts.curl(url)
+	if err != nil {
+		return fmt.Errorf("pod-to-pod connectivity over IPv6 failed (%v)", err)
+	}
+	if strings.TrimSpace(out) != "ok" {
+		return fmt.Errorf("unexpected response from %q (%q)", url, out)
+	}
+	ts.cfg.Logger.Info("checked pod-to-pod connectivity over IPv6")
+	return nil
+}
+
+func (ts *tester) checkPodToInternet() error {
+	ts.cfg.Logger.Info("checking pod-to-internet connectivity over IPv6", zap.String("url", internetURL))
+	if _, err := ts.curl(internetURL); err != nil {
+		return fmt.Errorf("pod-to-internet connectivity over IPv6 failed (%v)", err)
+	}
+	ts.cfg.Logger.Info("checked pod-to-internet connectivity over IPv6")
+	return nil
+}
+
+// curl requests the URL from the client Pod over IPv6, with retries.
+func (ts *tester) curl(url string) (string, error) {
+	args := []string{
+		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+		"--namespace=" + ts.namespace(),
+		"exec",
+		clientPodName,
+		"--",
+		"curl",
+		"-6",
+		"--globoff",
+		"--silent",
+		"--show-error",
+		"--fail",
+		"--location",
+		"--max-time", "10",
+		url,
+	}
+	cmd := strings.Join(append([]string{ts.cfg.EKSConfig.KubectlPath}, args...), " ")
+
+	var lastErr error
+	for i := 0; i < 10; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		output, err := exec.New().CommandContext(ctx, ts.cfg.EKSConfig.KubectlPath, args...).CombinedOutput()
+		cancel()
+		out := string(output)
+		if err == nil {
+			return out, nil
+		}
+		lastErr = fmt.Errorf("%v (%q)", err, strings.TrimSpace(out))
+		ts.cfg.Logger.Warn("failed to curl", zap.String("command", cmd), zap.String("output", out), zap.Error(err))
+
+		select {
+		case <-ts.cfg.Stopc:
+			return "", errors.New("curl aborted")
+		case <-time.After(5 * time.Second):
+		}
+	}
+	return "", lastErr
+}
+
+// isIPv6 returns true if the address is a valid IPv6 address
+// (IPv4-mapped addresses excluded).
+func isIPv6(s string) bool {
+	ip := net.ParseIP(s)
+	return ip != nil && ip.To4() == nil
+}
+
+// podURL returns the HTTP URL for the Pod IP, with IPv6 literals bracketed.
+func podURL(ip string, port int) string {
+	return "http://" + net.JoinHostPort(ip, strconv.Itoa(port)) + "/"
+}
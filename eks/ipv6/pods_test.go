@@ -0,0 +1,39 @@
+package ipv6
+
+import "testing"
+
+func TestIsIPv6(t *testing.T) {
+	tt := []struct {
+		ip  string
+		exp bool
+	}{
+		{"2600:1f14:abc:de00::1", true},
+		{"fd00:ec2::10", true},
+		{"::1", true},
+		{"10.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"", false},
+		{"invalid", false},
+	}
+	for i, tv := range tt {
+		if v := isIPv6(tv.ip); v != tv.exp {
+			t.Fatalf("#%d: isIPv6(%q) expected %v, got %v", i, tv.ip, tv.exp, v)
+		}
+	}
+}
+
+func TestPodURL(t *testing.T) {
+	tt := []struct {
+		ip   string
+		port int
+		exp  string
+	}{
+		{"2600:1f14:abc:de00::1", 8080, "http://[2600:1f14:abc:de00::1]:8080/"},
+		{"10.0.0.1", 80, "http://10.0.0.1:80/"},
+	}
+	for i, tv := range tt {
+		if v := podURL(tv.ip, tv.port); v != tv.exp {
+			t.Fatalf("#%d: expected %q, got %q", i, tv.exp, v)
+		}
+	}
+}
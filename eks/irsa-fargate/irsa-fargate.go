@@ -5,6 +5,7 @@ package irsafargate
 import (
 	"bytes"
 	"context"
+	_ "embed"
 	"errors"
 	"fmt"
 	"io"
@@ -15,8 +16,10 @@ import (
 	"time"
 
 	fargate_wait "github.com/aws/aws-k8s-tester/eks/fargate/wait"
+	"github.com/aws/aws-k8s-tester/eks/plan"
 	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
 	"github.com/aws/aws-k8s-tester/eksconfig"
+	pkg_aws "github.com/aws/aws-k8s-tester/pkg/aws"
 	"github.com/aws/aws-k8s-tester/pkg/aws/cfn"
 	aws_ecr "github.com/aws/aws-k8s-tester/pkg/aws/ecr"
 	aws_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
@@ -25,10 +28,11 @@ import (
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
 	"github.com/aws/aws-k8s-tester/pkg/user"
 	"github.com/aws/aws-k8s-tester/version"
+	aws_cfn_v2 "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	aws_cfn_v2_types "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/service/cloudformation"
-	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/aws/aws-sdk-go/service/ecr"
 	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
 	"github.com/aws/aws-sdk-go/service/eks"
 	"github.com/aws/aws-sdk-go/service/eks/eksiface"
@@ -51,7 +55,7 @@ type Config struct {
 	EKSConfig *eksconfig.Config
 	K8SClient k8s_client.EKS
 	S3API     s3iface.S3API
-	CFNAPI    cloudformationiface.CloudFormationAPI
+	CFNAPIV2  cfn.APIV2
 	EKSAPI    eksiface.EKSAPI
 	IAMAPI    iamiface.IAMAPI
 	ECRAPI    ecriface.ECRAPI
@@ -67,6 +71,26 @@ func New(cfg Config) eks_tester.Tester {
 	return &tester{cfg: cfg}
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "irsa-fargate",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				S3API:     cfg.S3API,
+				IAMAPI:    cfg.IAMAPI,
+				CFNAPIV2:  cfg.CFNAPIV2,
+				EKSAPI:    cfg.EKSAPI,
+				ECRAPI:    ecr.New(cfg.AWSSession, aws.NewConfig().WithRegion(cfg.EKSConfig.GetAddOnIRSAFargateRepositoryRegion())),
+			}), cfg.EKSConfig.IsEnabledAddOnIRSAFargate)
+		},
+	})
+}
+
 type tester struct {
 	cfg          Config
 	ecrImage     string
@@ -85,13 +109,15 @@ func (ts *tester) Create() (err error) {
 	}
 
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
-	ts.cfg.EKSConfig.AddOnIRSAFargate.Created = true
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnIRSAFargate.Created = true
+	})
 	createStart := time.Now()
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnIRSAFargate.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnIRSAFargate.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
 	if ts.ecrImage, _, err = aws_ecr.Check(
@@ -158,8 +184,9 @@ func (ts *tester) Delete() error {
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnIRSAFargate.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnIRSAFargate.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
 	}()
 
 	var errs []string
@@ -214,8 +241,9 @@ func (ts *tester) Delete() error {
 		return errors.New(strings.Join(errs, ", "))
 	}
 
-	ts.cfg.EKSConfig.AddOnIRSAFargate.Created = false
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnIRSAFargate.Created = false
+	})
 	return nil
 }
 
@@ -272,8 +300,9 @@ func (ts *tester) createOIDCProvider() error {
 		if err != nil {
 			return err
 		}
-		ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN = aws.StringValue(output.OpenIDConnectProviderArn)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN = aws.StringValue(output.OpenIDConnectProviderArn)
+		})
 		ts.cfg.Logger.Info("created IAM Open ID Connect provider", zap.String("provider-arn", ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN))
 	}
 	ts.cfg.EKSConfig.Sync()
@@ -304,83 +333,13 @@ func (ts *tester) deleteOIDCProvider() error {
 }
 
 // TemplateRole is the CloudFormation template for EKS IRSA Fargate role.
-const TemplateRole = `
----
-AWSTemplateFormatVersion: '2010-09-09'
-Description: 'Amazon EKS Cluster IRSA Fargate Role'
-
-Parameters:
-
-  RoleName:
-    Type: String
-    Description: The name of the IRSA Fargate role
-
-  IssuerARN:
-    Type: String
-    Description: EKS IRSA Fargate Provider ARN
-
-  Namespace:
-    Type: String
-    Description: The namespace for the IRSA Fargate role
-
-  ServiceAccountName:
-    Type: String
-    Description: The ServiceAccount name for the IRSA Fargate role
-
-  RoleServicePrincipals:
-    Type: CommaDelimitedList
-    Default: 'eks.amazonaws.com,eks-fargate-pods.amazonaws.com'
-    Description: EKS Fargate Role Service Principals
-
-  RoleManagedPolicyARNs:
-    Type: CommaDelimitedList
-    Default: 'arn:aws:iam::aws:policy/AmazonEKSFargatePodExecutionRolePolicy'
-    Description: EKS IRSA Fargate policy ARNs
-
-Resources:
-
-  Role:
-    Type: AWS::IAM::Role
-    Properties:
-      RoleName: !Ref RoleName
-      AssumeRolePolicyDocument:
-        Version: '2012-10-17'
-        Statement:
-        - Effect: Allow
-          Principal:
-            Federated: !Ref IssuerARN
-          Action:
-          - sts:AssumeRoleWithWebIdentity
-          Condition:
-            StringEquals:
-              {{ .IRSAIssuerHostPath }}:sub: !Join [':', ['system:serviceaccount', !Ref Namespace, !Ref ServiceAccountName]]
-        - Effect: Allow
-          Principal:
-            Service: !Ref RoleServicePrincipals
-          Action:
-          - sts:AssumeRole
-      ManagedPolicyArns: !Ref RoleManagedPolicyARNs
-      Path: /
-      Policies:
-      - PolicyName: !Join ['-', [!Ref RoleName, 's3-policy']]
-        PolicyDocument:
-          Version: '2012-10-17'
-          Statement:
-          - Effect: Allow
-            Action:
-            - s3:ListBucket
-            - s3:GetObject
-            Resource:
-            - !Join ['', [!Sub 'arn:${AWS::Partition}:s3:::', '{{.S3BucketName}}']]
-            - !Join ['', [!Sub 'arn:${AWS::Partition}:s3:::', '{{.S3BucketName}}', '/', '{{.ClusterName}}', '/*']]
-
-Outputs:
-
-  RoleARN:
-    Description: The IRSA Fargate role ARN
-    Value: !GetAtt Role.Arn
+//
+//go:embed templates/role.yaml
+var TemplateRole string
 
-`
+// templateRolePath is the path of TemplateRole in the plan
+// and the template override directory.
+const templateRolePath = "add-on-irsa-fargate/role.yaml"
 
 type irsaTemplate struct {
 	IRSAIssuerHostPath string
@@ -388,6 +347,38 @@ type irsaTemplate struct {
 	ClusterName        string
 }
 
+func renderTemplateRole(cfg *eksconfig.Config) (*bytes.Buffer, error) {
+	body, err := cfn.LoadTemplate(cfg.CFNTemplateDir, templateRolePath, TemplateRole)
+	if err != nil {
+		return nil, err
+	}
+	tpl, err := template.New("TemplateRole").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %q (%v)", templateRolePath, err)
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := tpl.Execute(buf, irsaTemplate{
+		IRSAIssuerHostPath: cfg.Status.ClusterOIDCIssuerHostPath,
+		S3BucketName:       cfg.S3.BucketName,
+		ClusterName:        cfg.Name,
+	}); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Plan renders the role CloudFormation template, without calling AWS APIs.
+func Plan(cfg *eksconfig.Config) ([]plan.Document, error) {
+	if !cfg.IsEnabledAddOnIRSAFargate() {
+		return nil, nil
+	}
+	buf, err := renderTemplateRole(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return []plan.Document{plan.NewTemplate(templateRolePath, buf.String())}, nil
+}
+
 func (ts *tester) createRole() error {
 	if ts.cfg.EKSConfig.AddOnIRSAFargate.RoleName == "" {
 		return errors.New("empty AddOnIRSAFargate.RoleName")
@@ -398,13 +389,8 @@ func (ts *tester) createRole() error {
 		return nil
 	}
 
-	tpl := template.Must(template.New("TemplateRole").Parse(TemplateRole))
-	buf := bytes.NewBuffer(nil)
-	if err := tpl.Execute(buf, irsaTemplate{
-		IRSAIssuerHostPath: ts.cfg.EKSConfig.Status.ClusterOIDCIssuerHostPath,
-		S3BucketName:       ts.cfg.EKSConfig.S3.BucketName,
-		ClusterName:        ts.cfg.EKSConfig.Name,
-	}); err != nil {
+	buf, err := renderTemplateRole(ts.cfg.EKSConfig)
+	if err != nil {
 		return err
 	}
 
@@ -425,18 +411,18 @@ func (ts *tester) createRole() error {
 		zap.String("role-name", ts.cfg.EKSConfig.AddOnIRSAFargate.RoleName),
 		zap.String("role-cfn-file-path", ts.cfg.EKSConfig.AddOnIRSAFargate.RoleCFNStackYAMLPath),
 	)
-	stackInput := &cloudformation.CreateStackInput{
+	stackInput := &aws_cfn_v2.CreateStackInput{
 		StackName:    aws.String(ts.cfg.EKSConfig.AddOnIRSAFargate.RoleName),
-		Capabilities: aws.StringSlice([]string{"CAPABILITY_NAMED_IAM"}),
-		OnFailure:    aws.String(cloudformation.OnFailureDelete),
+		Capabilities: []aws_cfn_v2_types.Capability{aws_cfn_v2_types.CapabilityCapabilityNamedIam},
+		OnFailure:    aws_cfn_v2_types.OnFailureDelete,
 		TemplateBody: aws.String(buf.String()),
-		Tags: cfn.NewTags(map[string]string{
+		Tags: cfn.NewTagsV2(map[string]string{
 			"Kind":                   "aws-k8s-tester",
 			"Name":                   ts.cfg.EKSConfig.Name,
 			"aws-k8s-tester-version": version.ReleaseVersion,
 			"User":                   user.Get(),
 		}),
-		Parameters: []*cloudformation.Parameter{
+		Parameters: []aws_cfn_v2_types.Parameter{
 			{
 				ParameterKey:   aws.String("RoleName"),
 				ParameterValue: aws.String(ts.cfg.EKSConfig.AddOnIRSAFargate.RoleName),
@@ -459,7 +445,7 @@ func (ts *tester) createRole() error {
 		ts.cfg.Logger.Info("creating a new IRSA Fargate role with role service principals",
 			zap.Strings("role-service-principals", ts.cfg.EKSConfig.AddOnIRSAFargate.RoleServicePrincipals),
 		)
-		stackInput.Parameters = append(stackInput.Parameters, &cloudformation.Parameter{
+		stackInput.Parameters = append(stackInput.Parameters, aws_cfn_v2_types.Parameter{
 			ParameterKey:   aws.String("RoleServicePrincipals"),
 			ParameterValue: aws.String(strings.Join(ts.cfg.EKSConfig.AddOnIRSAFargate.RoleServicePrincipals, ",")),
 		})
@@ -468,31 +454,39 @@ func (ts *tester) createRole() error {
 		ts.cfg.Logger.Info("creating a new IRSA Fargate role with custom managed role policies",
 			zap.Strings("policy-arns", ts.cfg.EKSConfig.AddOnIRSAFargate.RoleManagedPolicyARNs),
 		)
-		stackInput.Parameters = append(stackInput.Parameters, &cloudformation.Parameter{
+		stackInput.Parameters = append(stackInput.Parameters, aws_cfn_v2_types.Parameter{
 			ParameterKey:   aws.String("RoleManagedPolicyARNs"),
 			ParameterValue: aws.String(strings.Join(ts.cfg.EKSConfig.AddOnIRSAFargate.RoleManagedPolicyARNs, ",")),
 		})
+	} else if ts.cfg.EKSConfig.Partition != "aws" {
+		// the template default is the "aws" partition policy
+		stackInput.Parameters = append(stackInput.Parameters, aws_cfn_v2_types.Parameter{
+			ParameterKey:   aws.String("RoleManagedPolicyARNs"),
+			ParameterValue: aws.String(pkg_aws.PolicyARN(ts.cfg.EKSConfig.Partition, "AmazonEKSFargatePodExecutionRolePolicy")),
+		})
 	}
 
-	stackOutput, err := ts.cfg.CFNAPI.CreateStack(stackInput)
+	stackID, err := cfn.CreateStackV2(context.Background(), ts.cfg.Logger, ts.cfg.CFNAPIV2, stackInput)
 	if err != nil {
 		return err
 	}
-	ts.cfg.EKSConfig.AddOnIRSAFargate.RoleCFNStackID = aws.StringValue(stackOutput.StackId)
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnIRSAFargate.RoleCFNStackID = stackID
+	})
 
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
-	ch := cfn.Poll(
+	ch := cfn.PollV2(
 		ctx,
 		ts.cfg.Stopc,
 		ts.cfg.Logger,
 		ts.cfg.LogWriter,
-		ts.cfg.CFNAPI,
+		ts.cfg.CFNAPIV2,
 		ts.cfg.EKSConfig.AddOnIRSAFargate.RoleCFNStackID,
-		cloudformation.ResourceStatusCreateComplete,
+		aws_cfn_v2_types.StackStatusCreateComplete,
 		time.Minute,
 		10*time.Second,
 	)
-	var st cfn.StackStatus
+	var st cfn.StackStatusV2
 	for st = range ch {
 		if st.Error != nil {
 			cancel()
@@ -505,7 +499,9 @@ func (ts *tester) createRole() error {
 	for _, o := range st.Stack.Outputs {
 		switch k := aws.StringValue(o.OutputKey); k {
 		case "RoleARN":
-			ts.cfg.EKSConfig.AddOnIRSAFargate.RoleARN = aws.StringValue(o.OutputValue)
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				ts.cfg.EKSConfig.AddOnIRSAFargate.RoleARN = aws.StringValue(o.OutputValue)
+			})
 		default:
 			return fmt.Errorf("unexpected OutputKey %q from %q", k, ts.cfg.EKSConfig.AddOnIRSAFargate.RoleCFNStackID)
 		}
@@ -529,25 +525,25 @@ func (ts *tester) deleteRole() error {
 	ts.cfg.Logger.Info("deleting IRSA role CFN stack",
 		zap.String("role-cfn-stack-id", ts.cfg.EKSConfig.AddOnIRSAFargate.RoleCFNStackID),
 	)
-	_, err := ts.cfg.CFNAPI.DeleteStack(&cloudformation.DeleteStackInput{
+	_, err := ts.cfg.CFNAPIV2.DeleteStack(context.Background(), &aws_cfn_v2.DeleteStackInput{
 		StackName: aws.String(ts.cfg.EKSConfig.AddOnIRSAFargate.RoleCFNStackID),
 	})
 	if err != nil {
 		return err
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
-	ch := cfn.Poll(
+	ch := cfn.PollV2(
 		ctx,
 		make(chan struct{}), // do not exit on stop
 		ts.cfg.Logger,
 		ts.cfg.LogWriter,
-		ts.cfg.CFNAPI,
+		ts.cfg.CFNAPIV2,
 		ts.cfg.EKSConfig.AddOnIRSAFargate.RoleCFNStackID,
-		cloudformation.ResourceStatusDeleteComplete,
+		aws_cfn_v2_types.StackStatusDeleteComplete,
 		time.Minute,
 		10*time.Second,
 	)
-	var st cfn.StackStatus
+	var st cfn.StackStatusV2
 	for st = range ch {
 		if st.Error != nil {
 			cancel()
@@ -4,6 +4,7 @@ package irsa
 import (
 	"bytes"
 	"context"
+	_ "embed"
 	"errors"
 	"fmt"
 	"io"
@@ -14,6 +15,7 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/aws/aws-k8s-tester/eks/plan"
 	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
 	"github.com/aws/aws-k8s-tester/eksconfig"
 	"github.com/aws/aws-k8s-tester/pkg/aws/cfn"
@@ -24,10 +26,11 @@ import (
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
 	"github.com/aws/aws-k8s-tester/pkg/user"
 	"github.com/aws/aws-k8s-tester/version"
+	aws_cfn_v2 "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	aws_cfn_v2_types "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/service/cloudformation"
-	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/aws/aws-sdk-go/service/ecr"
 	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/iam/iamiface"
@@ -49,7 +52,7 @@ type Config struct {
 	EKSConfig *eksconfig.Config
 	K8SClient k8s_client.EKS
 	S3API     s3iface.S3API
-	CFNAPI    cloudformationiface.CloudFormationAPI
+	CFNAPIV2  cfn.APIV2
 	IAMAPI    iamiface.IAMAPI
 	ECRAPI    ecriface.ECRAPI
 }
@@ -64,6 +67,25 @@ func New(cfg Config) eks_tester.Tester {
 	return &tester{cfg: cfg}
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "irsa",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				S3API:     cfg.S3API,
+				CFNAPIV2:  cfg.CFNAPIV2,
+				IAMAPI:    cfg.IAMAPI,
+				ECRAPI:    ecr.New(cfg.AWSSession, aws.NewConfig().WithRegion(cfg.EKSConfig.GetAddOnIRSARepositoryRegion())),
+			}), cfg.EKSConfig.IsEnabledAddOnIRSA)
+		},
+	})
+}
+
 type tester struct {
 	cfg               Config
 	ecrImage          string
@@ -83,13 +105,15 @@ func (ts *tester) Create() (err error) {
 	}
 
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
-	ts.cfg.EKSConfig.AddOnIRSA.Created = true
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnIRSA.Created = true
+	})
 	createStart := time.Now()
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnIRSA.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnIRSA.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
 	if ts.ecrImage, _, err = aws_ecr.Check(
@@ -138,9 +162,10 @@ func (ts *tester) Create() (err error) {
 		return err
 	}
 
-	ts.cfg.EKSConfig.AddOnIRSA.DeploymentTook = time.Since(ts.deploymentCreated)
-	ts.cfg.EKSConfig.AddOnIRSA.DeploymentTookString = ts.cfg.EKSConfig.AddOnIRSA.DeploymentTook.String()
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnIRSA.DeploymentTook = time.Since(ts.deploymentCreated)
+		ts.cfg.EKSConfig.AddOnIRSA.DeploymentTookString = ts.cfg.EKSConfig.AddOnIRSA.DeploymentTook.String()
+	})
 	return nil
 }
 
@@ -158,8 +183,9 @@ func (ts *tester) Delete() error {
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnIRSA.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnIRSA.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
 	}()
 
 	var errs []string
@@ -208,8 +234,9 @@ func (ts *tester) Delete() error {
 		return errors.New(strings.Join(errs, ", "))
 	}
 
-	ts.cfg.EKSConfig.AddOnIRSA.Created = false
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnIRSA.Created = false
+	})
 	return nil
 }
 
@@ -266,8 +293,9 @@ func (ts *tester) createOIDCProvider() error {
 		if err != nil {
 			return err
 		}
-		ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN = aws.StringValue(output.OpenIDConnectProviderArn)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN = aws.StringValue(output.OpenIDConnectProviderArn)
+		})
 		ts.cfg.Logger.Info("created IAM Open ID Connect provider", zap.String("provider-arn", ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN))
 	}
 	ts.cfg.EKSConfig.Sync()
@@ -298,66 +326,13 @@ func (ts *tester) deleteOIDCProvider() error {
 }
 
 // TemplateRole is the CloudFormation template for EKS IRSA role.
-const TemplateRole = `
----
-AWSTemplateFormatVersion: '2010-09-09'
-Description: 'Amazon EKS Cluster IRSA Role'
-
-Parameters:
-
-  RoleName:
-    Type: String
-    Description: The name of the IRSA role
-
-  IssuerARN:
-    Type: String
-    Description: EKS IRSA Provider ARN
-
-  Namespace:
-    Type: String
-    Description: The namespace for the IRSA role
-
-  ServiceAccountName:
-    Type: String
-    Description: The ServiceAccount name for the IRSA role
-
-Resources:
-
-  IRSARole:
-    Type: AWS::IAM::Role
-    Properties:
-      RoleName: !Ref RoleName
-      AssumeRolePolicyDocument:
-        Version: '2012-10-17'
-        Statement:
-        - Effect: Allow
-          Principal:
-            Federated: !Ref IssuerARN
-          Action:
-          - sts:AssumeRoleWithWebIdentity
-          Condition:
-            StringEquals:
-              {{ .IRSAIssuerHostPath }}:sub: !Join [':', ['system:serviceaccount', !Ref Namespace, !Ref ServiceAccountName]]
-      Policies:
-      - PolicyName: !Join ['-', [!Ref RoleName, 's3-policy']]
-        PolicyDocument:
-          Version: '2012-10-17'
-          Statement:
-          - Effect: Allow
-            Action:
-            - s3:ListBucket
-            - s3:GetObject
-            Resource:
-            - !Join ['', [!Sub 'arn:${AWS::Partition}:s3:::', '{{.S3BucketName}}']]
-            - !Join ['', [!Sub 'arn:${AWS::Partition}:s3:::', '{{.S3BucketName}}', '/', '{{.ClusterName}}', '/*']]
-
-Outputs:
-
-  IRSARoleARN:
-    Description: The IRSA role ARN
-    Value: !GetAtt IRSARole.Arn
+//
+//go:embed templates/role.yaml
+var TemplateRole string
 
-`
+// templateRolePath is the path of TemplateRole in the plan
+// and the template override directory.
+const templateRolePath = "add-on-irsa/role.yaml"
 
 type irsaTemplate struct {
 	IRSAIssuerHostPath string
@@ -365,6 +340,38 @@ type irsaTemplate struct {
 	ClusterName        string
 }
 
+func renderTemplateRole(cfg *eksconfig.Config) (*bytes.Buffer, error) {
+	body, err := cfn.LoadTemplate(cfg.CFNTemplateDir, templateRolePath, TemplateRole)
+	if err != nil {
+		return nil, err
+	}
+	tpl, err := template.New("TemplateRole").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %q (%v)", templateRolePath, err)
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := tpl.Execute(buf, irsaTemplate{
+		IRSAIssuerHostPath: cfg.Status.ClusterOIDCIssuerHostPath,
+		S3BucketName:       cfg.S3.BucketName,
+		ClusterName:        cfg.Name,
+	}); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Plan renders the role CloudFormation template, without calling AWS APIs.
+func Plan(cfg *eksconfig.Config) ([]plan.Document, error) {
+	if !cfg.IsEnabledAddOnIRSA() {
+		return nil, nil
+	}
+	buf, err := renderTemplateRole(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return []plan.Document{plan.NewTemplate(templateRolePath, buf.String())}, nil
+}
+
 func (ts *tester) createRole() error {
 	if ts.cfg.EKSConfig.AddOnIRSA.RoleName == "" {
 		return errors.New("empty AddOnIRSA.RoleName")
@@ -375,13 +382,8 @@ func (ts *tester) createRole() error {
 		return nil
 	}
 
-	tpl := template.Must(template.New("TemplateRole").Parse(TemplateRole))
-	buf := bytes.NewBuffer(nil)
-	if err := tpl.Execute(buf, irsaTemplate{
-		IRSAIssuerHostPath: ts.cfg.EKSConfig.Status.ClusterOIDCIssuerHostPath,
-		S3BucketName:       ts.cfg.EKSConfig.S3.BucketName,
-		ClusterName:        ts.cfg.EKSConfig.Name,
-	}); err != nil {
+	buf, err := renderTemplateRole(ts.cfg.EKSConfig)
+	if err != nil {
 		return err
 	}
 
@@ -402,18 +404,18 @@ func (ts *tester) createRole() error {
 		zap.String("role-name", ts.cfg.EKSConfig.AddOnIRSA.RoleName),
 		zap.String("role-cfn-file-path", ts.cfg.EKSConfig.AddOnIRSA.RoleCFNStackYAMLPath),
 	)
-	stackInput := &cloudformation.CreateStackInput{
+	stackInput := &aws_cfn_v2.CreateStackInput{
 		StackName:    aws.String(ts.cfg.EKSConfig.AddOnIRSA.RoleName),
-		Capabilities: aws.StringSlice([]string{"CAPABILITY_NAMED_IAM"}),
-		OnFailure:    aws.String(cloudformation.OnFailureDelete),
+		Capabilities: []aws_cfn_v2_types.Capability{aws_cfn_v2_types.CapabilityCapabilityNamedIam},
+		OnFailure:    aws_cfn_v2_types.OnFailureDelete,
 		TemplateBody: aws.String(buf.String()),
-		Tags: cfn.NewTags(map[string]string{
+		Tags: cfn.NewTagsV2(map[string]string{
 			"Kind":                   "aws-k8s-tester",
 			"Name":                   ts.cfg.EKSConfig.Name,
 			"aws-k8s-tester-version": version.ReleaseVersion,
 			"User":                   user.Get(),
 		}),
-		Parameters: []*cloudformation.Parameter{
+		Parameters: []aws_cfn_v2_types.Parameter{
 			{
 				ParameterKey:   aws.String("RoleName"),
 				ParameterValue: aws.String(ts.cfg.EKSConfig.AddOnIRSA.RoleName),
@@ -432,25 +434,27 @@ func (ts *tester) createRole() error {
 			},
 		},
 	}
-	stackOutput, err := ts.cfg.CFNAPI.CreateStack(stackInput)
+	stackID, err := cfn.CreateStackV2(context.Background(), ts.cfg.Logger, ts.cfg.CFNAPIV2, stackInput)
 	if err != nil {
 		return err
 	}
-	ts.cfg.EKSConfig.AddOnIRSA.RoleCFNStackID = aws.StringValue(stackOutput.StackId)
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnIRSA.RoleCFNStackID = stackID
+	})
 
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
-	ch := cfn.Poll(
+	ch := cfn.PollV2(
 		ctx,
 		ts.cfg.Stopc,
 		ts.cfg.Logger,
 		ts.cfg.LogWriter,
-		ts.cfg.CFNAPI,
+		ts.cfg.CFNAPIV2,
 		ts.cfg.EKSConfig.AddOnIRSA.RoleCFNStackID,
-		cloudformation.ResourceStatusCreateComplete,
+		aws_cfn_v2_types.StackStatusCreateComplete,
 		time.Minute,
 		10*time.Second,
 	)
-	var st cfn.StackStatus
+	var st cfn.StackStatusV2
 	for st = range ch {
 		if st.Error != nil {
 			cancel()
@@ -463,7 +467,9 @@ func (ts *tester) createRole() error {
 	for _, o := range st.Stack.Outputs {
 		switch k := aws.StringValue(o.OutputKey); k {
 		case "IRSARoleARN":
-			ts.cfg.EKSConfig.AddOnIRSA.RoleARN = aws.StringValue(o.OutputValue)
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				ts.cfg.EKSConfig.AddOnIRSA.RoleARN = aws.StringValue(o.OutputValue)
+			})
 		default:
 			return fmt.Errorf("unexpected OutputKey %q from %q", k, ts.cfg.EKSConfig.AddOnIRSA.RoleCFNStackID)
 		}
@@ -487,25 +493,25 @@ func (ts *tester) deleteRole() error {
 	ts.cfg.Logger.Info("deleting IRSA role CFN stack",
 		zap.String("role-cfn-stack-id", ts.cfg.EKSConfig.AddOnIRSA.RoleCFNStackID),
 	)
-	_, err := ts.cfg.CFNAPI.DeleteStack(&cloudformation.DeleteStackInput{
+	_, err := ts.cfg.CFNAPIV2.DeleteStack(context.Background(), &aws_cfn_v2.DeleteStackInput{
 		StackName: aws.String(ts.cfg.EKSConfig.AddOnIRSA.RoleCFNStackID),
 	})
 	if err != nil {
 		return err
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
-	ch := cfn.Poll(
+	ch := cfn.PollV2(
 		ctx,
 		make(chan struct{}), // do not exit on stop
 		ts.cfg.Logger,
 		ts.cfg.LogWriter,
-		ts.cfg.CFNAPI,
+		ts.cfg.CFNAPIV2,
 		ts.cfg.EKSConfig.AddOnIRSA.RoleCFNStackID,
-		cloudformation.ResourceStatusDeleteComplete,
+		aws_cfn_v2_types.StackStatusDeleteComplete,
 		time.Minute,
 		10*time.Second,
 	)
-	var st cfn.StackStatus
+	var st cfn.StackStatusV2
 	for st = range ch {
 		if st.Error != nil {
 			cancel()
@@ -627,6 +633,16 @@ echo {{ .S3Key }} contents:
 cat /var/log/$HOSTNAME.s3.output;
 printf "\n\nSUCCESS IRSA TEST: S3 FILE DOWNLOADED!\n\n"
 
+printf "\n's3-utils cp' outside the role policy scope (expects AccessDenied):\n"
+if /s3-utils cp --log-level info --partition {{.Partition}} --region {{.Region}} --s3-bucket {{ .S3BucketName }} --s3-key {{ .S3KeyOutOfScope }} --local-path /var/log/$HOSTNAME.s3.denied.output --timeout 10s > /var/log/$HOSTNAME.s3.denied.log 2>&1; then
+  cat /var/log/$HOSTNAME.s3.denied.log
+  printf "\n\nFAILED IRSA TEST: S3 FILE DOWNLOADED OUTSIDE ROLE POLICY SCOPE!\n\n"
+  exit 1
+fi
+cat /var/log/$HOSTNAME.s3.denied.log
+grep -q AccessDenied /var/log/$HOSTNAME.s3.denied.log
+printf "\n\nSUCCESS IRSA TEST: S3 ACCESS DENIED OUTSIDE ROLE POLICY SCOPE!\n\n"
+
 printf "\n'sts-utils get-caller-identity' expected role ARN:\n"
 /sts-utils get-caller-identity --partition {{.Partition}} --region {{.Region}} --match-contain-role-arn {{ .RoleName }}
 printf "\nSUCCESS IRSA TEST: CALLER_ROLE_ARN FOUND!\n\n"
@@ -662,7 +678,10 @@ type configMapTemplate struct {
 	RoleName     string
 	S3BucketName string
 	S3Key        string
-	SleepMessage string
+	// S3KeyOutOfScope is the S3 key outside the role policy scope,
+	// to check that the role credentials are scoped down.
+	S3KeyOutOfScope string
+	SleepMessage    string
 }
 
 func (ts *tester) createConfigMap() error {
@@ -681,6 +700,11 @@ func (ts *tester) createConfigMap() error {
 
 		S3BucketName: ts.cfg.EKSConfig.S3.BucketName,
 		S3Key:        ts.cfg.EKSConfig.AddOnIRSA.S3Key,
+
+		// the role policy only allows "[S3_BUCKET]/[CLUSTER_NAME]/*", and
+		// "s3:GetObject" returns "AccessDenied" whether or not the object exists
+		S3KeyOutOfScope: ts.cfg.EKSConfig.Name + "-irsa-out-of-scope",
+
 		SleepMessage: ts.sleepMessage,
 	}); err != nil {
 		return err
@@ -0,0 +1,434 @@
+// Package janitor finds the AWS resources created by the tester
+// (tagged "Kind=aws-k8s-tester") that are older than the TTL,
+// and optionally deletes them. Unlike the leak check, it does not
+// require the configuration of the test run, so it can clean up
+// the resources of the aborted runs (e.g. CI job timeouts).
+package janitor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/pkg/aws/cfn"
+	aws_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_cfn_v2 "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	aws_cfn_v2_types "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/olekukonko/tablewriter"
+	"go.uber.org/zap"
+)
+
+// Resource types, in the deletion order.
+const (
+	TypeCluster  = "eks-cluster"
+	TypeStack    = "cfn-stack"
+	TypeKeyPair  = "key-pair"
+	TypeLogGroup = "log-group"
+	TypeBucket   = "s3-bucket"
+)
+
+var deleteOrder = map[string]int{
+	TypeCluster:  0,
+	TypeStack:    1,
+	TypeKeyPair:  2,
+	TypeLogGroup: 3,
+	TypeBucket:   4,
+}
+
+// eksLogGroupPrefix is the name prefix of the EKS control plane log groups
+// (e.g. "/aws/eks/[CLUSTER-NAME]/cluster").
+const eksLogGroupPrefix = "/aws/eks/"
+
+// Config defines janitor configuration.
+type Config struct {
+	Logger *zap.Logger
+	// TTL is the minimum age of the resources to delete.
+	TTL time.Duration
+
+	EKSAPI    eksiface.EKSAPI
+	CFNAPIV2  cfn.APIV2
+	EC2APIV2  *aws_ec2_v2.Client
+	S3API     s3iface.S3API
+	CWLogsAPI cloudwatchlogsiface.CloudWatchLogsAPI
+}
+
+// Resource is a stale resource created by the tester.
+type Resource struct {
+	Type    string
+	Name    string
+	Created time.Time
+}
+
+// Scan returns the resources tagged "Kind=aws-k8s-tester"
+// that are created more than TTL before "now".
+func Scan(cfg Config, now time.Time) (rs []Resource, err error) {
+	clusters, err := scanClusters(cfg, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan EKS clusters (%v)", err)
+	}
+	rs = append(rs, clusters...)
+
+	stacks, err := scanStacks(cfg, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan CloudFormation stacks (%v)", err)
+	}
+	rs = append(rs, stacks...)
+
+	keyPairs, err := scanKeyPairs(cfg, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan EC2 key pairs (%v)", err)
+	}
+	rs = append(rs, keyPairs...)
+
+	// control plane log groups are not tagged,
+	// so delete the ones of the stale clusters
+	staleClusters := make(map[string]bool)
+	for _, r := range clusters {
+		staleClusters[r.Name] = true
+	}
+	logGroups, err := scanLogGroups(cfg, now, staleClusters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan CloudWatch log groups (%v)", err)
+	}
+	rs = append(rs, logGroups...)
+
+	buckets, err := scanBuckets(cfg, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan S3 buckets (%v)", err)
+	}
+	rs = append(rs, buckets...)
+
+	sortResources(rs)
+	return rs, nil
+}
+
+func scanClusters(cfg Config, now time.Time) (rs []Resource, err error) {
+	var names []string
+	err = cfg.EKSAPI.ListClustersPages(
+		&eks.ListClustersInput{},
+		func(out *eks.ListClustersOutput, lastPage bool) bool {
+			names = append(names, aws.StringValueSlice(out.Clusters)...)
+			return true
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		out, err := cfg.EKSAPI.DescribeCluster(&eks.DescribeClusterInput{Name: aws.String(name)})
+		if err != nil {
+			cfg.Logger.Warn("failed to describe cluster", zap.String("name", name), zap.Error(err))
+			continue
+		}
+		c := out.Cluster
+		if aws.StringValue(c.Status) == eks.ClusterStatusDeleting {
+			continue
+		}
+		created := aws.TimeValue(c.CreatedAt)
+		if !isStale(aws.StringValueMap(c.Tags), created, now, cfg.TTL) {
+			continue
+		}
+		rs = append(rs, Resource{Type: TypeCluster, Name: name, Created: created})
+	}
+	return rs, nil
+}
+
+func scanStacks(cfg Config, now time.Time) (rs []Resource, err error) {
+	// the pinned SDK ships no paginators for CloudFormation
+	input := &aws_cfn_v2.DescribeStacksInput{}
+	for {
+		out, err := cfg.CFNAPIV2.DescribeStacks(context.Background(), input)
+		if err != nil {
+			return nil, err
+		}
+		for _, st := range out.Stacks {
+			if st.StackStatus == aws_cfn_v2_types.StackStatusDeleteInProgress {
+				continue
+			}
+			tags := make(map[string]string, len(st.Tags))
+			for _, t := range st.Tags {
+				tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+			}
+			created := aws.TimeValue(st.CreationTime)
+			if !isStale(tags, created, now, cfg.TTL) {
+				continue
+			}
+			rs = append(rs, Resource{Type: TypeStack, Name: aws.StringValue(st.StackName), Created: created})
+		}
+		if aws.StringValue(out.NextToken) == "" {
+			return rs, nil
+		}
+		input.NextToken = out.NextToken
+	}
+}
+
+func scanKeyPairs(cfg Config, now time.Time) (rs []Resource, err error) {
+	out, err := cfg.EC2APIV2.DescribeKeyPairs(
+		context.Background(),
+		&aws_ec2_v2.DescribeKeyPairsInput{
+			Filters: []aws_ec2_v2_types.Filter{
+				{
+					Name:   aws_v2.String("tag:" + eksconfig.KindTagKey),
+					Values: []string{eksconfig.KindTagValue},
+				},
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, kp := range out.KeyPairs {
+		tags := make(map[string]string, len(kp.Tags))
+		for _, t := range kp.Tags {
+			tags[aws_v2.ToString(t.Key)] = aws_v2.ToString(t.Value)
+		}
+		// key pairs have no creation timestamp
+		created, ok := createdFromTags(tags)
+		if !ok {
+			cfg.Logger.Warn("skipping key pair without creation time tag",
+				zap.String("key-name", aws_v2.ToString(kp.KeyName)),
+				zap.String("tag-key", eksconfig.CreatedTagKey),
+			)
+			continue
+		}
+		if !isStale(tags, created, now, cfg.TTL) {
+			continue
+		}
+		rs = append(rs, Resource{Type: TypeKeyPair, Name: aws_v2.ToString(kp.KeyName), Created: created})
+	}
+	return rs, nil
+}
+
+func scanLogGroups(cfg Config, now time.Time, staleClusters map[string]bool) (rs []Resource, err error) {
+	var groups []*cloudwatchlogs.LogGroup
+	err = cfg.CWLogsAPI.DescribeLogGroupsPages(
+		&cloudwatchlogs.DescribeLogGroupsInput{LogGroupNamePrefix: aws.String(eksLogGroupPrefix)},
+		func(out *cloudwatchlogs.DescribeLogGroupsOutput, lastPage bool) bool {
+			groups = append(groups, out.LogGroups...)
+			return true
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		name := aws.StringValue(g.LogGroupName)
+		created := time.Unix(0, aws.Int64Value(g.CreationTime)*int64(time.Millisecond))
+		if staleClusters[clusterNameFromLogGroup(name)] {
+			rs = append(rs, Resource{Type: TypeLogGroup, Name: name, Created: created})
+			continue
+		}
+		out, err := cfg.CWLogsAPI.ListTagsLogGroup(&cloudwatchlogs.ListTagsLogGroupInput{LogGroupName: g.LogGroupName})
+		if err != nil {
+			cfg.Logger.Warn("failed to list log group tags", zap.String("name", name), zap.Error(err))
+			continue
+		}
+		if !isStale(aws.StringValueMap(out.Tags), created, now, cfg.TTL) {
+			continue
+		}
+		rs = append(rs, Resource{Type: TypeLogGroup, Name: name, Created: created})
+	}
+	return rs, nil
+}
+
+func scanBuckets(cfg Config, now time.Time) (rs []Resource, err error) {
+	out, err := cfg.S3API.ListBuckets(&s3.ListBucketsInput{})
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range out.Buckets {
+		created := aws.TimeValue(b.CreationDate)
+		if created.Add(cfg.TTL).After(now) {
+			continue
+		}
+		// fails for the untagged buckets, or the buckets in the other regions
+		tout, err := cfg.S3API.GetBucketTagging(&s3.GetBucketTaggingInput{Bucket: b.Name})
+		if err != nil {
+			cfg.Logger.Debug("skipping bucket", zap.String("bucket", aws.StringValue(b.Name)), zap.Error(err))
+			continue
+		}
+		tags := make(map[string]string, len(tout.TagSet))
+		for _, t := range tout.TagSet {
+			tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+		}
+		if !isStale(tags, created, now, cfg.TTL) {
+			continue
+		}
+		rs = append(rs, Resource{Type: TypeBucket, Name: aws.StringValue(b.Name), Created: created})
+	}
+	return rs, nil
+}
+
+// isStale returns true if the resource is created by the tester,
+// and created more than TTL before "now".
+func isStale(tags map[string]string, created time.Time, now time.Time, ttl time.Duration) bool {
+	if tags[eksconfig.KindTagKey] != eksconfig.KindTagValue {
+		return false
+	}
+	if created.IsZero() {
+		return false
+	}
+	return now.Sub(created) > ttl
+}
+
+// createdFromTags returns the creation time from the "eksconfig.CreatedTagKey" tag.
+func createdFromTags(tags map[string]string) (time.Time, bool) {
+	v, ok := tags[eksconfig.CreatedTagKey]
+	if !ok {
+		return time.Time{}, false
+	}
+	created, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return created, true
+}
+
+// clusterNameFromLogGroup returns the cluster name of the EKS control plane log group,
+// or empty string if the log group is not the control plane log group.
+func clusterNameFromLogGroup(name string) string {
+	if !strings.HasPrefix(name, eksLogGroupPrefix) || !strings.HasSuffix(name, "/cluster") {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(name, eksLogGroupPrefix), "/cluster")
+}
+
+// sortResources sorts the resources in the deletion order,
+// and then by name.
+func sortResources(rs []Resource) {
+	sort.SliceStable(rs, func(i, j int) bool {
+		oi, oj := deleteOrder[rs[i].Type], deleteOrder[rs[j].Type]
+		if oi != oj {
+			return oi < oj
+		}
+		return rs[i].Name < rs[j].Name
+	})
+}
+
+// Delete deletes the resources, and returns the errors of the failed deletions.
+// Some resources are deleted asynchronously (e.g. the clusters with node groups),
+// so the janitor needs to be re-run to delete them.
+func Delete(cfg Config, rs []Resource) (errs []error) {
+	for _, r := range rs {
+		cfg.Logger.Info("deleting resource", zap.String("type", r.Type), zap.String("name", r.Name))
+		if err := deleteResource(cfg, r); err != nil {
+			errs = append(errs, fmt.Errorf("%s %q (%v)", r.Type, r.Name, err))
+			continue
+		}
+		cfg.Logger.Info("deleted resource", zap.String("type", r.Type), zap.String("name", r.Name))
+	}
+	return errs
+}
+
+func deleteResource(cfg Config, r Resource) (err error) {
+	switch r.Type {
+	case TypeCluster:
+		err = deleteCluster(cfg, r.Name)
+	case TypeStack:
+		_, err = cfg.CFNAPIV2.DeleteStack(context.Background(), &aws_cfn_v2.DeleteStackInput{StackName: aws.String(r.Name)})
+	case TypeKeyPair:
+		_, err = cfg.EC2APIV2.DeleteKeyPair(context.Background(), &aws_ec2_v2.DeleteKeyPairInput{KeyName: aws_v2.String(r.Name)})
+	case TypeLogGroup:
+		_, err = cfg.CWLogsAPI.DeleteLogGroup(&cloudwatchlogs.DeleteLogGroupInput{LogGroupName: aws.String(r.Name)})
+	case TypeBucket:
+		if err = aws_s3.EmptyBucket(cfg.Logger, cfg.S3API, r.Name); err == nil {
+			err = aws_s3.DeleteBucket(cfg.Logger, cfg.S3API, r.Name)
+		}
+	default:
+		err = errors.New("unsupported resource type")
+	}
+	return err
+}
+
+// deleteCluster deletes the node groups and Fargate profiles first,
+// since the cluster cannot be deleted with the attached ones.
+func deleteCluster(cfg Config, name string) error {
+	var ngs []string
+	err := cfg.EKSAPI.ListNodegroupsPages(
+		&eks.ListNodegroupsInput{ClusterName: aws.String(name)},
+		func(out *eks.ListNodegroupsOutput, lastPage bool) bool {
+			ngs = append(ngs, aws.StringValueSlice(out.Nodegroups)...)
+			return true
+		},
+	)
+	if err != nil {
+		return err
+	}
+	for _, ng := range ngs {
+		cfg.Logger.Info("deleting node group", zap.String("cluster-name", name), zap.String("node-group-name", ng))
+		_, err = cfg.EKSAPI.DeleteNodegroup(&eks.DeleteNodegroupInput{
+			ClusterName:   aws.String(name),
+			NodegroupName: aws.String(ng),
+		})
+		if err != nil && !isResourceInUse(err) {
+			return err
+		}
+	}
+
+	var fps []string
+	err = cfg.EKSAPI.ListFargateProfilesPages(
+		&eks.ListFargateProfilesInput{ClusterName: aws.String(name)},
+		func(out *eks.ListFargateProfilesOutput, lastPage bool) bool {
+			fps = append(fps, aws.StringValueSlice(out.FargateProfileNames)...)
+			return true
+		},
+	)
+	if err != nil {
+		return err
+	}
+	for _, fp := range fps {
+		cfg.Logger.Info("deleting fargate profile", zap.String("cluster-name", name), zap.String("fargate-profile-name", fp))
+		_, err = cfg.EKSAPI.DeleteFargateProfile(&eks.DeleteFargateProfileInput{
+			ClusterName:        aws.String(name),
+			FargateProfileName: aws.String(fp),
+		})
+		if err != nil && !isResourceInUse(err) {
+			return err
+		}
+	}
+
+	if len(ngs) > 0 || len(fps) > 0 {
+		return fmt.Errorf("deleting %d node group(s) and %d fargate profile(s) first; retry after deletion", len(ngs), len(fps))
+	}
+	_, err = cfg.EKSAPI.DeleteCluster(&eks.DeleteClusterInput{Name: aws.String(name)})
+	return err
+}
+
+func isResourceInUse(err error) bool {
+	return err != nil && strings.Contains(err.Error(), eks.ErrCodeResourceInUseException)
+}
+
+// Table returns the resources in table format.
+func Table(rs []Resource, now time.Time) string {
+	buf := bytes.NewBuffer(nil)
+	tb := tablewriter.NewWriter(buf)
+	tb.SetAutoWrapText(false)
+	tb.SetAlignment(tablewriter.ALIGN_LEFT)
+	tb.SetCenterSeparator("*")
+	tb.SetHeader([]string{"type", "name", "created", "age"})
+	for _, r := range rs {
+		tb.Append([]string{
+			r.Type,
+			r.Name,
+			r.Created.UTC().Format(time.RFC3339),
+			now.Sub(r.Created).Round(time.Minute).String(),
+		})
+	}
+	tb.Render()
+	return buf.String()
+}
@@ -0,0 +1,71 @@
+package janitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+)
+
+func TestIsStale(t *testing.T) {
+	now := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	tags := map[string]string{eksconfig.KindTagKey: eksconfig.KindTagValue}
+	tt := []struct {
+		tags    map[string]string
+		created time.Time
+		exp     bool
+	}{
+		{tags: tags, created: now.Add(-25 * time.Hour), exp: true},
+		{tags: tags, created: now.Add(-23 * time.Hour), exp: false},
+		{tags: tags, created: time.Time{}, exp: false},
+		{tags: map[string]string{eksconfig.KindTagKey: "other"}, created: now.Add(-25 * time.Hour), exp: false},
+		{tags: nil, created: now.Add(-25 * time.Hour), exp: false},
+	}
+	for i, tv := range tt {
+		if v := isStale(tv.tags, tv.created, now, 24*time.Hour); v != tv.exp {
+			t.Fatalf("#%d: expected %v, got %v", i, tv.exp, v)
+		}
+	}
+}
+
+func TestCreatedFromTags(t *testing.T) {
+	created, ok := createdFromTags(map[string]string{eksconfig.CreatedTagKey: "2020-01-02T03:04:05Z"})
+	if !ok || !created.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Fatalf("unexpected creation time %v (found %v)", created, ok)
+	}
+	if _, ok = createdFromTags(map[string]string{eksconfig.CreatedTagKey: "yesterday"}); ok {
+		t.Fatal("expected invalid creation time")
+	}
+	if _, ok = createdFromTags(nil); ok {
+		t.Fatal("expected no creation time")
+	}
+}
+
+func TestClusterNameFromLogGroup(t *testing.T) {
+	if v := clusterNameFromLogGroup("/aws/eks/test-cluster/cluster"); v != "test-cluster" {
+		t.Fatalf("unexpected cluster name %q", v)
+	}
+	if v := clusterNameFromLogGroup("/aws/lambda/test"); v != "" {
+		t.Fatalf("unexpected cluster name %q", v)
+	}
+}
+
+func TestSortResources(t *testing.T) {
+	rs := []Resource{
+		{Type: TypeBucket, Name: "b"},
+		{Type: TypeStack, Name: "s2"},
+		{Type: TypeCluster, Name: "c"},
+		{Type: TypeStack, Name: "s1"},
+		{Type: TypeLogGroup, Name: "l"},
+	}
+	sortResources(rs)
+	var names []string
+	for _, r := range rs {
+		names = append(names, r.Name)
+	}
+	exp := []string{"c", "s1", "s2", "l", "b"}
+	if !reflect.DeepEqual(names, exp) {
+		t.Fatalf("expected %v, got %v", exp, names)
+	}
+}
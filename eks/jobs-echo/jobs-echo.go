@@ -17,6 +17,7 @@ import (
 	"github.com/aws/aws-k8s-tester/pkg/randutil"
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
 	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
 	"github.com/dustin/go-humanize"
 	"go.uber.org/zap"
@@ -46,6 +47,23 @@ func New(cfg Config) eks_tester.Tester {
 	return &tester{cfg: cfg, busyboxImg: "busybox"}
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:         "jobs-echo",
+		Dependencies: []string{"ecr"},
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				ECRAPI:    ecr.New(cfg.AWSSession, aws.NewConfig().WithRegion(cfg.EKSConfig.GetAddOnJobsEchoRepositoryBusyboxRegion())),
+			}), cfg.EKSConfig.IsEnabledAddOnJobsEcho)
+		},
+	})
+}
+
 type tester struct {
 	cfg Config
 
@@ -63,15 +81,18 @@ func (ts *tester) Create() (err error) {
 	}
 
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
-	ts.cfg.EKSConfig.AddOnJobsEcho.Created = true
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnJobsEcho.Created = true
+	})
 	createStart := time.Now()
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnJobsEcho.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnJobsEcho.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
+	ts.busyboxImg = ts.cfg.EKSConfig.Image(ts.busyboxImg)
 	if ts.cfg.EKSConfig.AddOnJobsEcho.RepositoryBusyboxAccountID != "" &&
 		ts.cfg.EKSConfig.AddOnJobsEcho.RepositoryBusyboxRegion != "" &&
 		ts.cfg.EKSConfig.AddOnJobsEcho.RepositoryBusyboxName != "" &&
@@ -144,8 +165,9 @@ func (ts *tester) Delete() (err error) {
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnJobsEcho.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnJobsEcho.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
 	}()
 
 	var errs []string
@@ -169,8 +191,9 @@ func (ts *tester) Delete() (err error) {
 		return errors.New(strings.Join(errs, ", "))
 	}
 
-	ts.cfg.EKSConfig.AddOnJobsEcho.Created = false
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnJobsEcho.Created = false
+	})
 	return nil
 }
 
@@ -0,0 +1,328 @@
+// Package jobsthroughput creates large numbers of short-lived Jobs and
+// CronJobs, and measures the Pod startup latencies.
+package jobsthroughput
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/metrics"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"github.com/aws/aws-sdk-go/aws"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	batch_v1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Config defines Job throughput configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new Job throughput tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:      "jobs-throughput",
+		Exclusive: true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+			}), cfg.EKSConfig.IsEnabledAddOnJobsThroughput)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+const (
+	// kindLabel distinguishes the Pods of Jobs and CronJobs.
+	kindLabel   = "jobs-throughput-kind"
+	kindJob     = "job"
+	kindCronJob = "cron-job"
+
+	// finished Jobs are cleaned up right away, to not slow down
+	// kube-controller-manager, the Pod watch records the latencies first
+	jobTTLSeconds = 30
+)
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnJobsThroughput() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnJobsThroughput.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnJobsThroughput.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnJobsThroughput.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	cur := ts.cfg.EKSConfig.AddOnJobsThroughput
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		cur.Namespace,
+	); err != nil {
+		return err
+	}
+
+	rec := metrics.NewRecorder()
+	pw := newPodWatcher(ts.cfg.Logger, rec)
+	ctx, cancel := context.WithCancel(context.Background())
+	w, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(cur.Namespace).
+		Watch(ctx, metav1.ListOptions{LabelSelector: kindLabel})
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to watch Pods (%v)", err)
+	}
+	go pw.run(ctx, w)
+	defer func() {
+		cancel()
+		<-pw.donec
+	}()
+
+	if err = ts.createObjects(); err != nil {
+		return err
+	}
+	if err = ts.waitPods(pw); err != nil {
+		return err
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.StartedPods = pw.count(kindJob) + pw.count(kindCronJob)
+		cur.LatencySummaries = rec.Summaries(time.Now().UTC().Format(time.RFC3339Nano))
+	})
+	return ts.writeSummaries()
+}
+
+// createObjects creates the Jobs and CronJobs at the target QPS.
+func (ts *tester) createObjects() error {
+	cur := ts.cfg.EKSConfig.AddOnJobsThroughput
+	limiter := rate.NewLimiter(rate.Limit(cur.QPS), 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-ts.cfg.Stopc:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ts.cfg.Logger.Info("creating Jobs and CronJobs", zap.Int("jobs", cur.Jobs), zap.Int("cron-jobs", cur.CronJobs), zap.Float64("qps", cur.QPS))
+	cli := ts.cfg.K8SClient.KubernetesClientSet()
+	for i := 0; i < cur.Jobs; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			return errors.New("Jobs creation aborted")
+		}
+		cctx, ccancel := context.WithTimeout(ctx, time.Minute)
+		_, err := cli.BatchV1().Jobs(cur.Namespace).Create(cctx, ts.newJob(fmt.Sprintf("job-%d", i)), metav1.CreateOptions{})
+		ccancel()
+		if err != nil {
+			return fmt.Errorf("failed to create Job (%v)", err)
+		}
+	}
+	for i := 0; i < cur.CronJobs; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			return errors.New("CronJobs creation aborted")
+		}
+		cctx, ccancel := context.WithTimeout(ctx, time.Minute)
+		_, err := cli.BatchV1().CronJobs(cur.Namespace).Create(cctx, ts.newCronJob(fmt.Sprintf("cron-job-%d", i)), metav1.CreateOptions{})
+		ccancel()
+		if err != nil {
+			return fmt.Errorf("failed to create CronJob (%v)", err)
+		}
+	}
+	ts.cfg.Logger.Info("created Jobs and CronJobs")
+	return nil
+}
+
+// waitPods waits until all Job Pods start, and the CronJobs run
+// for the configured duration with at least one Pod per CronJob.
+func (ts *tester) waitPods(pw *podWatcher) error {
+	cur := ts.cfg.EKSConfig.AddOnJobsThroughput
+	waitStart := time.Now()
+	for {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("Job Pods wait aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		jobs, cronJobs := pw.count(kindJob), pw.count(kindCronJob)
+		ts.cfg.Logger.Info("polled started Pods",
+			zap.Int("job-pods", jobs),
+			zap.Int("jobs", cur.Jobs),
+			zap.Int("cron-job-pods", cronJobs),
+			zap.Int("cron-jobs", cur.CronJobs),
+			zap.String("elapsed", time.Since(waitStart).String()),
+		)
+		cronJobsDone := cur.CronJobs == 0 || (cronJobs >= cur.CronJobs && time.Since(waitStart) >= cur.CronJobsDuration)
+		if jobs >= cur.Jobs && cronJobsDone {
+			return nil
+		}
+		if time.Since(waitStart) >= cur.Timeout {
+			return fmt.Errorf("Job Pods not started within %v (job Pods %d/%d, CronJob Pods %d/%d)", cur.Timeout, jobs, cur.Jobs, cronJobs, cur.CronJobs)
+		}
+	}
+}
+
+func (ts *tester) writeSummaries() error {
+	cur := ts.cfg.EKSConfig.AddOnJobsThroughput
+	b, err := json.MarshalIndent(cur.LatencySummaries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(cur.LatencySummariesJSONPath, b, 0600); err != nil {
+		return err
+	}
+	for _, phase := range []string{phaseCreatedToScheduled, phaseScheduledToRunning} {
+		if s, ok := cur.LatencySummaries[phase]; ok {
+			fmt.Fprintf(ts.cfg.LogWriter, "\n\n%q:\n%s\n", phase, s.Table())
+		}
+	}
+	ts.cfg.Logger.Info("wrote latency summaries", zap.Int("started-pods", cur.StartedPods), zap.String("path", cur.LatencySummariesJSONPath))
+	return nil
+}
+
+func (ts *tester) newPodTemplate(kind string) v1.PodTemplateSpec {
+	return v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{kindLabel: kind},
+		},
+		Spec: v1.PodSpec{
+			RestartPolicy: v1.RestartPolicyNever,
+			Containers: []v1.Container{
+				{
+					Name:            "job",
+					Image:           ts.cfg.EKSConfig.AddOnJobsThroughput.Image,
+					ImagePullPolicy: v1.PullIfNotPresent,
+					Command:         []string{"/bin/sh", "-c", "true"},
+				},
+			},
+		},
+	}
+}
+
+func (ts *tester) newJob(name string) *batch_v1.Job {
+	return &batch_v1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ts.cfg.EKSConfig.AddOnJobsThroughput.Namespace,
+		},
+		Spec: batch_v1.JobSpec{
+			Completions:             aws.Int32(1),
+			BackoffLimit:            aws.Int32(0),
+			TTLSecondsAfterFinished: aws.Int32(jobTTLSeconds),
+			Template:                ts.newPodTemplate(kindJob),
+		},
+	}
+}
+
+func (ts *tester) newCronJob(name string) *batch_v1.CronJob {
+	return &batch_v1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ts.cfg.EKSConfig.AddOnJobsThroughput.Namespace,
+		},
+		Spec: batch_v1.CronJobSpec{
+			Schedule:                   "*/1 * * * *",
+			ConcurrencyPolicy:          batch_v1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: aws.Int32(1),
+			FailedJobsHistoryLimit:     aws.Int32(1),
+			JobTemplate: batch_v1.JobTemplateSpec{
+				Spec: batch_v1.JobSpec{
+					Completions:  aws.Int32(1),
+					BackoffLimit: aws.Int32(0),
+					Template:     ts.newPodTemplate(kindCronJob),
+				},
+			},
+		},
+	}
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnJobsThroughput() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnJobsThroughput.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnJobsThroughput.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnJobsThroughput.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil && !apierrs.IsNotFound(err) && !strings.Contains(err.Error(), "not found") {
+		errs = append(errs, fmt.Sprintf("failed to delete Jobs throughput namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnJobsThroughput.Created = false
+	})
+	return nil
+}
@@ -0,0 +1,127 @@
+package jobsthroughput
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/metrics"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// Pod startup phases recorded per Pod.
+const (
+	// phaseCreatedToScheduled is from the Pod creation to the PodScheduled condition.
+	phaseCreatedToScheduled = "created-to-scheduled"
+	// phaseScheduledToRunning is from the PodScheduled condition to the container start.
+	phaseScheduledToRunning = "scheduled-to-running"
+)
+
+// podStartupLatencies returns the Pod startup latencies from the Pod
+// status timestamps, once the container has started.
+func podStartupLatencies(pod *v1.Pod) (toScheduled time.Duration, toRunning time.Duration, ok bool) {
+	var scheduled time.Time
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == v1.PodScheduled && cond.Status == v1.ConditionTrue {
+			scheduled = cond.LastTransitionTime.Time
+			break
+		}
+	}
+	if scheduled.IsZero() {
+		return 0, 0, false
+	}
+
+	var started time.Time
+	for _, cs := range pod.Status.ContainerStatuses {
+		switch {
+		case cs.State.Running != nil:
+			started = cs.State.Running.StartedAt.Time
+		case cs.State.Terminated != nil:
+			started = cs.State.Terminated.StartedAt.Time
+		}
+		if !started.IsZero() {
+			break
+		}
+	}
+	if started.IsZero() {
+		return 0, 0, false
+	}
+
+	return scheduled.Sub(pod.CreationTimestamp.Time), started.Sub(scheduled), true
+}
+
+// podWatcher records the startup latencies of each Job Pod once,
+// before the Pods are cleaned up by the Job TTL or the CronJob history limit.
+type podWatcher struct {
+	lg  *zap.Logger
+	rec *metrics.Recorder
+
+	mu sync.Mutex
+	// started maps the Pod UID to its Job kind label.
+	started map[types.UID]string
+
+	donec chan struct{}
+}
+
+func newPodWatcher(lg *zap.Logger, rec *metrics.Recorder) *podWatcher {
+	return &podWatcher{
+		lg:      lg,
+		rec:     rec,
+		started: make(map[types.UID]string),
+		donec:   make(chan struct{}),
+	}
+}
+
+func (pw *podWatcher) run(ctx context.Context, w watch.Interface) {
+	defer close(pw.donec)
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				pw.lg.Warn("Pod watch closed")
+				return
+			}
+			if ev.Type != watch.Added && ev.Type != watch.Modified {
+				continue
+			}
+			pod, ok := ev.Object.(*v1.Pod)
+			if !ok {
+				continue
+			}
+			pw.observe(pod)
+		}
+	}
+}
+
+func (pw *podWatcher) observe(pod *v1.Pod) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	if _, ok := pw.started[pod.UID]; ok {
+		return
+	}
+	toScheduled, toRunning, ok := podStartupLatencies(pod)
+	if !ok {
+		return
+	}
+	pw.started[pod.UID] = pod.Labels[kindLabel]
+	pw.rec.Observe(phaseCreatedToScheduled, toScheduled, nil)
+	pw.rec.Observe(phaseScheduledToRunning, toRunning, nil)
+}
+
+// count returns the number of started Pods of the Job kind.
+func (pw *podWatcher) count(kind string) (n int) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	for _, k := range pw.started {
+		if k == kind {
+			n++
+		}
+	}
+	return n
+}
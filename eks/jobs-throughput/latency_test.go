@@ -0,0 +1,38 @@
+package jobsthroughput
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodStartupLatencies(t *testing.T) {
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)},
+	}
+	if _, _, ok := podStartupLatencies(pod); ok {
+		t.Fatal("expected not started for pending Pod")
+	}
+
+	pod.Status.Conditions = []v1.PodCondition{
+		{Type: v1.PodInitialized, Status: v1.ConditionTrue, LastTransitionTime: metav1.NewTime(created)},
+		{Type: v1.PodScheduled, Status: v1.ConditionTrue, LastTransitionTime: metav1.NewTime(created.Add(2 * time.Second))},
+	}
+	if _, _, ok := podStartupLatencies(pod); ok {
+		t.Fatal("expected not started without container status")
+	}
+
+	pod.Status.ContainerStatuses = []v1.ContainerStatus{
+		{State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{StartedAt: metav1.NewTime(created.Add(7 * time.Second))}}},
+	}
+	toScheduled, toRunning, ok := podStartupLatencies(pod)
+	if !ok {
+		t.Fatal("expected started")
+	}
+	if toScheduled != 2*time.Second || toRunning != 5*time.Second {
+		t.Fatalf("unexpected latencies %v, %v", toScheduled, toRunning)
+	}
+}
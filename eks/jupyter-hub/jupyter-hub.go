@@ -13,10 +13,11 @@ import (
 	"time"
 
 	"github.com/aws/aws-k8s-tester/ec2config"
-	"github.com/aws/aws-k8s-tester/eks/helm"
 	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
 	"github.com/aws/aws-k8s-tester/eksconfig"
+	pkg_aws "github.com/aws/aws-k8s-tester/pkg/aws"
 	"github.com/aws/aws-k8s-tester/pkg/aws/elb"
+	"github.com/aws/aws-k8s-tester/pkg/helm"
 	"github.com/aws/aws-k8s-tester/pkg/httputil"
 	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
@@ -50,6 +51,22 @@ func New(cfg Config) eks_tester.Tester {
 	return &tester{cfg: cfg}
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "jupyter-hub",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				ELB2API:   cfg.ELBV2API,
+			}), cfg.EKSConfig.IsEnabledAddOnJupyterHub)
+		},
+	})
+}
+
 type tester struct {
 	cfg Config
 }
@@ -71,13 +88,15 @@ func (ts *tester) Create() error {
 	}
 
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
-	ts.cfg.EKSConfig.AddOnJupyterHub.Created = true
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnJupyterHub.Created = true
+	})
 	createStart := time.Now()
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnJupyterHub.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnJupyterHub.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
 	// https://zero-to-jupyterhub.readthedocs.io/en/latest/setup-jupyterhub/setup-helm.html
@@ -122,8 +141,9 @@ func (ts *tester) Delete() error {
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnJupyterHub.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnJupyterHub.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
 	}()
 
 	var errs []string
@@ -182,8 +202,9 @@ func (ts *tester) Delete() error {
 		return errors.New(strings.Join(errs, ", "))
 	}
 
-	ts.cfg.EKSConfig.AddOnJupyterHub.Created = false
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnJupyterHub.Created = false
+	})
 	return nil
 }
 
@@ -398,6 +419,7 @@ func (ts *tester) createHelmJupyterHub() error {
 		ChartRepoURL:   chartRepoURL,
 		ChartName:      chartName,
 		ReleaseName:    chartName,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
 		Values:         values,
 		QueryFunc:      nil,
 		QueryInterval:  30 * time.Second,
@@ -413,6 +435,7 @@ func (ts *tester) deleteHelmJupyterHub() error {
 		Namespace:      ts.cfg.EKSConfig.AddOnJupyterHub.Namespace,
 		ChartName:      chartName,
 		ReleaseName:    chartName,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
 	})
 }
 
@@ -491,18 +514,25 @@ func (ts *tester) waitService() error {
 		return errors.New("failed to find host name")
 	}
 
-	ts.cfg.EKSConfig.AddOnJupyterHub.URL = "http://" + hostName
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnJupyterHub.URL = "http://" + hostName
+	})
 
 	// TODO: is there any better way to find out the NLB name?
-	ts.cfg.EKSConfig.AddOnJupyterHub.NLBName = strings.Split(hostName, "-")[0]
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnJupyterHub.NLBName = strings.Split(hostName, "-")[0]
+	})
 	ss := strings.Split(hostName, ".")[0]
 	ss = strings.Replace(ss, "-", "/", -1)
-	ts.cfg.EKSConfig.AddOnJupyterHub.NLBARN = fmt.Sprintf(
-		"arn:aws:elasticloadbalancing:%s:%s:loadbalancer/net/%s",
-		ts.cfg.EKSConfig.Region,
-		ts.cfg.EKSConfig.Status.AWSAccountID,
-		ss,
-	)
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnJupyterHub.NLBARN = pkg_aws.ARN(
+			ts.cfg.EKSConfig.Partition,
+			"elasticloadbalancing",
+			ts.cfg.EKSConfig.Region,
+			ts.cfg.EKSConfig.Status.AWSAccountID,
+			"loadbalancer/net/"+ss,
+		)
+	})
 
 	fmt.Fprintf(ts.cfg.LogWriter, "\nNLB JupyterHub ARN: %s\n", ts.cfg.EKSConfig.AddOnJupyterHub.NLBARN)
 	fmt.Fprintf(ts.cfg.LogWriter, "NLB JupyterHub Name: %s\n", ts.cfg.EKSConfig.AddOnJupyterHub.NLBName)
@@ -0,0 +1,432 @@
+// Package karpenter installs Karpenter, and tests node provisioning
+// and consolidation with a scale-out workload.
+// ref. https://karpenter.sh/v0.16.3/getting-started/
+// ref. https://github.com/aws/karpenter/tree/v0.16.3/charts/karpenter
+package karpenter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eks/access"
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/pkg/helm"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	aws_iam_v2 "github.com/aws/aws-sdk-go-v2/service/iam"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/exec"
+)
+
+// Config defines Karpenter configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+	// Access maps the node role for the provisioned nodes to join.
+	Access access.Manager
+
+	IAMAPIV2 *aws_iam_v2.Client
+	EC2APIV2 *aws_ec2_v2.Client
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new Karpenter tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "karpenter",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				Access:    cfg.Access,
+				IAMAPIV2:  cfg.IAMAPIV2,
+				EC2APIV2:  cfg.EC2APIV2,
+			}), cfg.EKSConfig.IsEnabledAddOnKarpenter)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+const (
+	chartRepoName = "karpenter"
+	chartName     = "karpenter"
+
+	// discoveryTagKey is the tag on the subnets and security groups
+	// for the AWSNodeTemplate to select.
+	discoveryTagKey = "karpenter.sh/discovery"
+)
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnKarpenter() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnKarpenter.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnKarpenter.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnKarpenter.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnKarpenter.Namespace,
+	); err != nil {
+		return err
+	}
+	if err = ts.createOIDCProvider(); err != nil {
+		return err
+	}
+	if err = ts.createNodeRole(); err != nil {
+		return err
+	}
+	if err = ts.createControllerRole(); err != nil {
+		return err
+	}
+	if err = ts.createAuthMapping(); err != nil {
+		return err
+	}
+	if err = ts.createDiscoveryTags(); err != nil {
+		return err
+	}
+	if err = ts.createHelmKarpenter(); err != nil {
+		return err
+	}
+	if err = ts.createProvisioner(); err != nil {
+		return err
+	}
+	if err = ts.createDeployment(); err != nil {
+		return err
+	}
+	if err = ts.checkScaleOut(); err != nil {
+		return err
+	}
+	if err = ts.checkConsolidation(); err != nil {
+		return err
+	}
+	if err = ts.fetchLogs(); err != nil {
+		return err
+	}
+
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnKarpenter() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnKarpenter.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnKarpenter.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	// fetch again, in case the creation failed before fetching logs
+	if err := ts.fetchLogs(); err != nil {
+		ts.cfg.Logger.Warn("failed to fetch Karpenter logs", zap.Error(err))
+	}
+
+	// delete in the reverse order of creation, while the controller
+	// is still running to terminate the nodes it provisioned
+	var errs []string
+	if err := ts.deleteDeployment(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteProvisioner(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteHelmKarpenter(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnKarpenter.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Karpenter namespace (%v)", err))
+	}
+	if err := ts.deleteDiscoveryTags(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteAuthMapping(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteControllerRole(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteNodeRole(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteOIDCProvider(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnKarpenter.Created = false
+	})
+	return nil
+}
+
+// createAuthMapping maps the node role (access entry or "aws-auth"
+// ConfigMap), for the nodes provisioned by Karpenter to join the cluster.
+func (ts *tester) createAuthMapping() error {
+	if ts.cfg.Access == nil {
+		return errors.New("empty Access")
+	}
+	return ts.cfg.Access.MapNodeRole(ts.cfg.EKSConfig.AddOnKarpenter.NodeRoleARN, false)
+}
+
+func (ts *tester) deleteAuthMapping() error {
+	roleARN := ts.cfg.EKSConfig.AddOnKarpenter.NodeRoleARN
+	if roleARN == "" || ts.cfg.Access == nil {
+		return nil
+	}
+	return ts.cfg.Access.UnmapNodeRole(roleARN)
+}
+
+// discoveryResources returns the subnets and security groups
+// for the nodes provisioned by Karpenter.
+func (ts *tester) discoveryResources() []string {
+	vpc := ts.cfg.EKSConfig.VPC
+	rs := append([]string{}, vpc.PrivateSubnetIDs...)
+	if len(rs) == 0 {
+		rs = append(rs, vpc.PublicSubnetIDs...)
+	}
+	if vpc.SecurityGroupID != "" {
+		rs = append(rs, vpc.SecurityGroupID)
+	}
+	if vpc.NodeGroupSecurityGroupID != "" {
+		rs = append(rs, vpc.NodeGroupSecurityGroupID)
+	}
+	return rs
+}
+
+func (ts *tester) createDiscoveryTags() error {
+	rs := ts.discoveryResources()
+	ts.cfg.Logger.Info("tagging subnets and security groups for discovery", zap.Strings("resources", rs))
+	_, err := ts.cfg.EC2APIV2.CreateTags(
+		context.Background(),
+		&aws_ec2_v2.CreateTagsInput{
+			Resources: rs,
+			Tags: []aws_ec2_v2_types.Tag{
+				{
+					Key:   aws_v2.String(discoveryTagKey),
+					Value: aws_v2.String(ts.cfg.EKSConfig.Name),
+				},
+			},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to tag %q (%v)", rs, err)
+	}
+	ts.cfg.Logger.Info("tagged subnets and security groups for discovery")
+	return nil
+}
+
+func (ts *tester) deleteDiscoveryTags() error {
+	rs := ts.discoveryResources()
+	ts.cfg.Logger.Info("untagging subnets and security groups for discovery", zap.Strings("resources", rs))
+	_, err := ts.cfg.EC2APIV2.DeleteTags(
+		context.Background(),
+		&aws_ec2_v2.DeleteTagsInput{
+			Resources: rs,
+			Tags: []aws_ec2_v2_types.Tag{
+				{Key: aws_v2.String(discoveryTagKey)},
+			},
+		},
+	)
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to untag", zap.Error(err))
+		return fmt.Errorf("failed to untag %q (%v)", rs, err)
+	}
+	ts.cfg.Logger.Info("untagged subnets and security groups for discovery")
+	return nil
+}
+
+// https://github.com/aws/karpenter/blob/v0.16.3/charts/karpenter/values.yaml
+func (ts *tester) createHelmKarpenter() error {
+	if err := helm.RepoAdd(ts.cfg.Logger, chartRepoName, ts.cfg.EKSConfig.AddOnKarpenter.ChartRepoURL); err != nil {
+		return err
+	}
+
+	values := map[string]interface{}{
+		"serviceAccount": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				"eks.amazonaws.com/role-arn": ts.cfg.EKSConfig.AddOnKarpenter.ControllerRoleARN,
+			},
+		},
+		"clusterName":     ts.cfg.EKSConfig.Name,
+		"clusterEndpoint": ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint,
+		"aws": map[string]interface{}{
+			"defaultInstanceProfile": ts.cfg.EKSConfig.AddOnKarpenter.NodeInstanceProfileName,
+		},
+	}
+
+	getAllArgs := []string{
+		ts.cfg.EKSConfig.KubectlPath,
+		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+		"--namespace=" + ts.cfg.EKSConfig.AddOnKarpenter.Namespace,
+		"get",
+		"all",
+	}
+	getAllCmd := strings.Join(getAllArgs, " ")
+
+	descArgsPods := []string{
+		ts.cfg.EKSConfig.KubectlPath,
+		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+		"--namespace=" + ts.cfg.EKSConfig.AddOnKarpenter.Namespace,
+		"describe",
+		"pods",
+	}
+	descCmdPods := strings.Join(descArgsPods, " ")
+
+	return helm.Install(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Stopc:          ts.cfg.Stopc,
+		Timeout:        15 * time.Minute,
+		KubeConfigPath: ts.cfg.EKSConfig.KubeConfigPath,
+		Namespace:      ts.cfg.EKSConfig.AddOnKarpenter.Namespace,
+		ChartRepoURL:   ts.cfg.EKSConfig.AddOnKarpenter.ChartRepoURL,
+		ChartName:      chartName,
+		ChartVersion:   ts.cfg.EKSConfig.AddOnKarpenter.ChartVersion,
+		ReleaseName:    chartName,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
+		Values:         values,
+		LogFunc: func(format string, v ...interface{}) {
+			ts.cfg.Logger.Info(fmt.Sprintf("[install] "+format, v...))
+		},
+		QueryFunc: func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			output, err := exec.New().CommandContext(ctx, getAllArgs[0], getAllArgs[1:]...).CombinedOutput()
+			cancel()
+			out := strings.TrimSpace(string(output))
+			if err != nil {
+				ts.cfg.Logger.Warn("'kubectl get all' failed", zap.Error(err))
+			}
+			fmt.Fprintf(ts.cfg.LogWriter, "\n\n'%s' output:\n\n%s\n\n", getAllCmd, out)
+
+			ctx, cancel = context.WithTimeout(context.Background(), 15*time.Second)
+			output, err = exec.New().CommandContext(ctx, descArgsPods[0], descArgsPods[1:]...).CombinedOutput()
+			cancel()
+			out = strings.TrimSpace(string(output))
+			if err != nil {
+				ts.cfg.Logger.Warn("'kubectl describe pods' failed", zap.Error(err))
+			}
+			fmt.Fprintf(ts.cfg.LogWriter, "\n\n'%s' output:\n\n%s\n\n", descCmdPods, out)
+		},
+		QueryInterval: 30 * time.Second,
+	})
+}
+
+func (ts *tester) deleteHelmKarpenter() error {
+	return helm.Uninstall(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Timeout:        15 * time.Minute,
+		KubeConfigPath: ts.cfg.EKSConfig.KubeConfigPath,
+		Namespace:      ts.cfg.EKSConfig.AddOnKarpenter.Namespace,
+		ChartName:      chartName,
+		ReleaseName:    chartName,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
+	})
+}
+
+// fetchLogs writes the logs of each Karpenter controller Pod container
+// to the logs directory, via the Kubernetes API.
+func (ts *tester) fetchLogs() error {
+	logsDir := ts.cfg.EKSConfig.AddOnKarpenter.LogsDir
+	if err := os.MkdirAll(logsDir, 0700); err != nil {
+		return err
+	}
+
+	pods, err := k8s_client.ListPodsWithOptions(
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnKarpenter.Namespace,
+		metav1.ListOptions{LabelSelector: "app.kubernetes.io/name=" + chartName},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to list Karpenter Pods (%v)", err)
+	}
+	ts.cfg.Logger.Info("fetching Karpenter logs", zap.Int("pods", len(pods)), zap.String("logs-dir", logsDir))
+
+	for _, pod := range pods {
+		for _, c := range pod.Spec.Containers {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			out, err := ts.cfg.K8SClient.KubernetesClientSet().
+				CoreV1().
+				Pods(pod.Namespace).
+				GetLogs(pod.Name, &v1.PodLogOptions{Container: c.Name, Timestamps: true}).
+				DoRaw(ctx)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("failed to fetch logs for %q/%q (%v)", pod.Name, c.Name, err)
+			}
+			fpath := filepath.Join(logsDir, pod.Name+"-"+c.Name+".log")
+			if err = ioutil.WriteFile(fpath, out, 0600); err != nil {
+				return err
+			}
+			ts.cfg.Logger.Info("wrote Karpenter logs", zap.String("file-path", fpath))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,384 @@
+package karpenter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/exec"
+)
+
+const (
+	deploymentName       = "inflate"
+	appName              = "inflate"
+	pauseImage           = eksconfig.DefaultPauseImage
+	provisionerNameLabel = "karpenter.sh/provisioner-name"
+)
+
+// https://karpenter.sh/v0.16.3/provisioner/
+// https://karpenter.sh/v0.16.3/aws/provisioning/
+const provisionerTempl = `---
+apiVersion: karpenter.sh/v1alpha5
+kind: Provisioner
+metadata:
+  name: {{ .Name }}
+spec:
+  requirements:
+  - key: node.kubernetes.io/instance-type
+    operator: In
+    values: [{{ .InstanceTypes }}]
+  - key: karpenter.sh/capacity-type
+    operator: In
+    values: ["on-demand"]
+  limits:
+    resources:
+      cpu: "{{ .CPULimit }}"
+  providerRef:
+    name: {{ .Name }}
+  consolidation:
+    enabled: true
+---
+apiVersion: karpenter.k8s.aws/v1alpha1
+kind: AWSNodeTemplate
+metadata:
+  name: {{ .Name }}
+spec:
+  subnetSelector:
+    {{ .DiscoveryTagKey }}: {{ .ClusterName }}
+  securityGroupSelector:
+    {{ .DiscoveryTagKey }}: {{ .ClusterName }}
+`
+
+type provisioner struct {
+	Name            string
+	InstanceTypes   string
+	CPULimit        int
+	DiscoveryTagKey string
+	ClusterName     string
+}
+
+func (ts *tester) writeProvisioner() (fpath string, err error) {
+	cur := ts.cfg.EKSConfig.AddOnKarpenter
+	its := make([]string, 0, len(cur.InstanceTypes))
+	for _, it := range cur.InstanceTypes {
+		its = append(its, fmt.Sprintf("%q", it))
+	}
+	tpl := template.Must(template.New("provisionerTempl").Parse(provisionerTempl))
+	buf := bytes.NewBuffer(nil)
+	if err = tpl.Execute(buf, provisioner{
+		Name:            cur.ProvisionerName,
+		InstanceTypes:   strings.Join(its, ", "),
+		CPULimit:        cur.CPULimit,
+		DiscoveryTagKey: discoveryTagKey,
+		ClusterName:     ts.cfg.EKSConfig.Name,
+	}); err != nil {
+		return "", err
+	}
+	fmt.Fprintf(ts.cfg.LogWriter, "\nKarpenter Provisioner:\n\n%s\n", buf.String())
+	return fileutil.WriteTempFile(buf.Bytes())
+}
+
+func (ts *tester) createProvisioner() error {
+	fpath, err := ts.writeProvisioner()
+	if err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("applying Karpenter Provisioner", zap.String("name", ts.cfg.EKSConfig.AddOnKarpenter.ProvisionerName))
+	var output []byte
+	// the webhook might not be ready right after the chart install
+	waitDur := 5 * time.Minute
+	retryStart := time.Now()
+	for time.Since(retryStart) < waitDur {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("create Karpenter Provisioner aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		output, err = exec.New().CommandContext(
+			ctx,
+			ts.cfg.EKSConfig.KubectlPath,
+			"--kubeconfig="+ts.cfg.EKSConfig.KubeConfigPath,
+			"apply",
+			"--filename="+fpath,
+		).CombinedOutput()
+		cancel()
+		fmt.Fprintf(ts.cfg.LogWriter, "\n\"kubectl apply\" output:\n%s\n", string(output))
+		if err == nil {
+			break
+		}
+		ts.cfg.Logger.Warn("create Karpenter Provisioner failed", zap.Error(err))
+	}
+	if err != nil {
+		return fmt.Errorf("'kubectl apply' failed %v (output %q)", err, string(output))
+	}
+
+	ts.cfg.Logger.Info("created Karpenter Provisioner")
+	return nil
+}
+
+// deleteProvisioner deletes the Provisioner and waits for
+// Karpenter to terminate the nodes it provisioned.
+func (ts *tester) deleteProvisioner() error {
+	fpath, err := ts.writeProvisioner()
+	if err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("deleting Karpenter Provisioner", zap.String("name", ts.cfg.EKSConfig.AddOnKarpenter.ProvisionerName))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	output, err := exec.New().CommandContext(
+		ctx,
+		ts.cfg.EKSConfig.KubectlPath,
+		"--kubeconfig="+ts.cfg.EKSConfig.KubeConfigPath,
+		"delete",
+		"--ignore-not-found",
+		"--filename="+fpath,
+	).CombinedOutput()
+	cancel()
+	out := string(output)
+	fmt.Fprintf(ts.cfg.LogWriter, "\n\"kubectl delete\" output:\n%s\n", out)
+	if err != nil && !strings.Contains(out, "the server doesn't have a resource type") {
+		return fmt.Errorf("'kubectl delete' failed %v (output %q)", err, out)
+	}
+
+	if err = ts.waitForNoNodes(ts.cfg.EKSConfig.AddOnKarpenter.ConsolidationTimeout); err != nil {
+		return fmt.Errorf("Karpenter nodes not terminated (%v)", err)
+	}
+	ts.cfg.Logger.Info("deleted Karpenter Provisioner")
+	return nil
+}
+
+func (ts *tester) createDeployment() error {
+	cur := ts.cfg.EKSConfig.AddOnKarpenter
+	cpu, err := resource.ParseQuantity(cur.DeploymentCPURequest)
+	if err != nil {
+		return fmt.Errorf("invalid AddOnKarpenter.DeploymentCPURequest %q (%v)", cur.DeploymentCPURequest, err)
+	}
+
+	ts.cfg.Logger.Info("creating scale-out Deployment", zap.Int32("replicas", cur.DeploymentReplicas))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(cur.Namespace).
+		Create(
+			ctx,
+			&appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      deploymentName,
+					Namespace: cur.Namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": appName,
+					},
+				},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: aws_v2.Int32(cur.DeploymentReplicas),
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							"app.kubernetes.io/name": appName,
+						},
+					},
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{
+								"app.kubernetes.io/name": appName,
+							},
+						},
+						Spec: v1.PodSpec{
+							RestartPolicy:                 v1.RestartPolicyAlways,
+							TerminationGracePeriodSeconds: aws_v2.Int64(0),
+							Containers: []v1.Container{
+								{
+									Name:            appName,
+									Image:           ts.cfg.EKSConfig.Image(pauseImage),
+									ImagePullPolicy: v1.PullIfNotPresent,
+									Resources: v1.ResourceRequirements{
+										Requests: v1.ResourceList{
+											v1.ResourceCPU: cpu,
+										},
+									},
+								},
+							},
+							// only schedule on the nodes provisioned by Karpenter
+							NodeSelector: map[string]string{
+								provisionerNameLabel: cur.ProvisionerName,
+							},
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create scale-out Deployment (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created scale-out Deployment")
+	return nil
+}
+
+func (ts *tester) deleteDeployment() error {
+	ts.cfg.Logger.Info("deleting scale-out Deployment")
+	foreground := metav1.DeletePropagationForeground
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(ts.cfg.EKSConfig.AddOnKarpenter.Namespace).
+		Delete(
+			ctx,
+			deploymentName,
+			metav1.DeleteOptions{
+				GracePeriodSeconds: aws_v2.Int64(0),
+				PropagationPolicy:  &foreground,
+			},
+		)
+	cancel()
+	if err != nil && !apierrs.IsNotFound(err) {
+		ts.cfg.Logger.Warn("failed to delete", zap.Error(err))
+		return fmt.Errorf("failed to delete scale-out Deployment (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("deleted scale-out Deployment")
+	return nil
+}
+
+// checkScaleOut waits for the scale-out Deployment to be ready,
+// which requires Karpenter to provision new nodes.
+func (ts *tester) checkScaleOut() error {
+	cur := ts.cfg.EKSConfig.AddOnKarpenter
+	ctx, cancel := context.WithTimeout(context.Background(), cur.ScaleOutTimeout)
+	_, err := k8s_client.WaitForDeploymentCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.K8SClient,
+		time.Minute,
+		20*time.Second,
+		cur.Namespace,
+		deploymentName,
+		cur.DeploymentReplicas,
+		k8s_client.WithQueryFunc(func() {
+			descArgs := []string{
+				ts.cfg.EKSConfig.KubectlPath,
+				"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+				"get",
+				"nodes",
+				"--show-labels",
+				"--selector=" + provisionerNameLabel,
+			}
+			descCmd := strings.Join(descArgs, " ")
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			output, err := exec.New().CommandContext(ctx, descArgs[0], descArgs[1:]...).CombinedOutput()
+			cancel()
+			if err != nil {
+				ts.cfg.Logger.Warn("'kubectl get nodes' failed", zap.Error(err))
+			}
+			fmt.Fprintf(ts.cfg.LogWriter, "\n\n\"%s\" output:\n%s\n\n", descCmd, string(output))
+		}),
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("scale-out Deployment not ready (%v)", err)
+	}
+
+	nodes, err := ts.listNodes()
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return errors.New("no node provisioned by Karpenter")
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.NodesProvisioned = nodes
+	})
+	ts.cfg.Logger.Info("Karpenter provisioned nodes", zap.Strings("nodes", nodes))
+	return nil
+}
+
+// checkConsolidation scales in the workload to zero, and waits
+// for Karpenter to remove the empty nodes.
+func (ts *tester) checkConsolidation() error {
+	ts.cfg.Logger.Info("scaling in Deployment to check consolidation")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(ts.cfg.EKSConfig.AddOnKarpenter.Namespace).
+		Patch(
+			ctx,
+			deploymentName,
+			types.MergePatchType,
+			[]byte(`{"spec":{"replicas":0}}`),
+			metav1.PatchOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to scale in Deployment (%v)", err)
+	}
+
+	if err = ts.waitForNoNodes(ts.cfg.EKSConfig.AddOnKarpenter.ConsolidationTimeout); err != nil {
+		return fmt.Errorf("Karpenter did not consolidate nodes (%v)", err)
+	}
+	ts.cfg.Logger.Info("Karpenter consolidated nodes")
+	return nil
+}
+
+func (ts *tester) listNodes() ([]string, error) {
+	nodes, err := k8s_client.ListNodesWithOptions(
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		metav1.ListOptions{LabelSelector: provisionerNameLabel + "=" + ts.cfg.EKSConfig.AddOnKarpenter.ProvisionerName},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes (%v)", err)
+	}
+	names := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		names = append(names, n.Name)
+	}
+	return names, nil
+}
+
+// waitForNoNodes waits until no node is labeled with the Provisioner name.
+func (ts *tester) waitForNoNodes(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		nodes, err := ts.listNodes()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list nodes", zap.Error(err))
+		} else {
+			ts.cfg.Logger.Info("listed Karpenter nodes", zap.Strings("nodes", nodes))
+			if len(nodes) == 0 {
+				return nil
+			}
+		}
+
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait for Karpenter nodes aborted")
+		case <-time.After(20 * time.Second):
+		}
+	}
+	return fmt.Errorf("timed out after %v", timeout)
+}
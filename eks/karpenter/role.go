@@ -0,0 +1,372 @@
+package karpenter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	aws_iam "github.com/aws/aws-k8s-tester/pkg/aws/iam"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_iam_v2 "github.com/aws/aws-sdk-go-v2/service/iam"
+	smithy "github.com/aws/smithy-go"
+	"go.uber.org/zap"
+)
+
+const controllerPolicyName = "karpenter-controller"
+
+// serviceAccountName is the service account name created by the chart.
+const serviceAccountName = "karpenter"
+
+func isNotFound(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return strings.Contains(apiErr.ErrorCode(), "NotFound") || apiErr.ErrorCode() == "NoSuchEntity"
+	}
+	return false
+}
+
+func (ts *tester) createOIDCProvider() error {
+	if ts.cfg.EKSConfig.Status.ClusterOIDCIssuerURL == "" {
+		return errors.New("EKSConfig.Status.ClusterOIDCIssuerURL is empty")
+	}
+
+	ts.cfg.Logger.Info("checking existing IAM Open ID Connect provider",
+		zap.String("provider-arn", ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN),
+	)
+	_, err := ts.cfg.IAMAPIV2.GetOpenIDConnectProvider(
+		context.Background(),
+		&aws_iam_v2.GetOpenIDConnectProviderInput{
+			OpenIDConnectProviderArn: aws_v2.String(ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN),
+		},
+	)
+	if err == nil {
+		ts.cfg.Logger.Info("IAM Open ID Connect provider already exists")
+		return nil
+	}
+	if !isNotFound(err) {
+		return fmt.Errorf("failed to get IAM Open ID Connect provider (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("creating IAM Open ID Connect provider")
+	out, err := ts.cfg.IAMAPIV2.CreateOpenIDConnectProvider(
+		context.Background(),
+		&aws_iam_v2.CreateOpenIDConnectProviderInput{
+			Url:            aws_v2.String(ts.cfg.EKSConfig.Status.ClusterOIDCIssuerURL),
+			ThumbprintList: []string{ts.cfg.EKSConfig.Status.ClusterOIDCIssuerCAThumbprint},
+			ClientIDList:   []string{"sts.amazonaws.com"},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN = aws_v2.ToString(out.OpenIDConnectProviderArn)
+		ts.cfg.EKSConfig.AddOnKarpenter.OIDCProviderCreated = true
+	})
+	ts.cfg.Logger.Info("created IAM Open ID Connect provider", zap.String("provider-arn", ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN))
+	return nil
+}
+
+func (ts *tester) deleteOIDCProvider() error {
+	if !ts.cfg.EKSConfig.AddOnKarpenter.OIDCProviderCreated {
+		ts.cfg.Logger.Info("IAM Open ID Connect provider not created by Karpenter tester; skipping deletion")
+		return nil
+	}
+
+	ts.cfg.Logger.Info("deleting IAM Open ID Connect provider",
+		zap.String("provider-arn", ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN),
+	)
+	_, err := ts.cfg.IAMAPIV2.DeleteOpenIDConnectProvider(
+		context.Background(),
+		&aws_iam_v2.DeleteOpenIDConnectProviderInput{
+			OpenIDConnectProviderArn: aws_v2.String(ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN),
+		},
+	)
+	if err != nil && !isNotFound(err) {
+		ts.cfg.Logger.Warn("failed to delete IAM Open ID Connect provider", zap.Error(err))
+		return err
+	}
+
+	ts.cfg.Logger.Info("deleted IAM Open ID Connect provider")
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnKarpenter.OIDCProviderCreated = false
+	})
+	return nil
+}
+
+func (ts *tester) nodePolicyARNs() []string {
+	pfx := "arn:" + ts.cfg.EKSConfig.Partition + ":iam::aws:policy/"
+	return []string{
+		pfx + "AmazonEKSWorkerNodePolicy",
+		pfx + "AmazonEKS_CNI_Policy",
+		pfx + "AmazonEC2ContainerRegistryReadOnly",
+		pfx + "AmazonSSMManagedInstanceCore",
+	}
+}
+
+func (ts *tester) createNodeRole() error {
+	cur := ts.cfg.EKSConfig.AddOnKarpenter
+	if cur.NodeRoleARN != "" {
+		ts.cfg.Logger.Info("node role already created; no need to create a new one")
+		return nil
+	}
+
+	ts.cfg.Logger.Info("creating node role", zap.String("name", cur.NodeRoleName))
+	out, err := ts.cfg.IAMAPIV2.CreateRole(
+		context.Background(),
+		&aws_iam_v2.CreateRoleInput{
+			RoleName: aws_v2.String(cur.NodeRoleName),
+			Path:     aws_v2.String("/"),
+			AssumeRolePolicyDocument: aws_v2.String(toJSON(aws_iam.PolicyDocument{
+				Version: "2012-10-17",
+				Statement: []aws_iam.StatementEntry{
+					{
+						Effect:    "Allow",
+						Principal: &aws_iam.PrincipalEntry{Service: []string{"ec2.amazonaws.com"}},
+						Action:    []string{"sts:AssumeRole"},
+					},
+				},
+			})),
+		},
+	)
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.NodeRoleARN = aws_v2.ToString(out.Role.Arn)
+	})
+
+	for _, arn := range ts.nodePolicyARNs() {
+		if _, err = ts.cfg.IAMAPIV2.AttachRolePolicy(
+			context.Background(),
+			&aws_iam_v2.AttachRolePolicyInput{
+				RoleName:  aws_v2.String(cur.NodeRoleName),
+				PolicyArn: aws_v2.String(arn),
+			},
+		); err != nil {
+			return fmt.Errorf("failed to attach %q (%v)", arn, err)
+		}
+	}
+
+	if _, err = ts.cfg.IAMAPIV2.CreateInstanceProfile(
+		context.Background(),
+		&aws_iam_v2.CreateInstanceProfileInput{
+			InstanceProfileName: aws_v2.String(cur.NodeInstanceProfileName),
+			Path:                aws_v2.String("/"),
+		},
+	); err != nil {
+		return err
+	}
+	if _, err = ts.cfg.IAMAPIV2.AddRoleToInstanceProfile(
+		context.Background(),
+		&aws_iam_v2.AddRoleToInstanceProfileInput{
+			InstanceProfileName: aws_v2.String(cur.NodeInstanceProfileName),
+			RoleName:            aws_v2.String(cur.NodeRoleName),
+		},
+	); err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("created node role and instance profile",
+		zap.String("role-arn", cur.NodeRoleARN),
+		zap.String("instance-profile-name", cur.NodeInstanceProfileName),
+	)
+	return nil
+}
+
+func (ts *tester) deleteNodeRole() error {
+	cur := ts.cfg.EKSConfig.AddOnKarpenter
+	if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[cur.NodeRoleName]; ok {
+		return nil
+	}
+	ts.cfg.Logger.Info("deleting node role", zap.String("name", cur.NodeRoleName))
+
+	// "Cannot delete entity, must remove roles from instance profile first"
+	_, err := ts.cfg.IAMAPIV2.RemoveRoleFromInstanceProfile(
+		context.Background(),
+		&aws_iam_v2.RemoveRoleFromInstanceProfileInput{
+			InstanceProfileName: aws_v2.String(cur.NodeInstanceProfileName),
+			RoleName:            aws_v2.String(cur.NodeRoleName),
+		},
+	)
+	if err != nil && !isNotFound(err) {
+		ts.cfg.Logger.Warn("failed to remove role from instance profile", zap.Error(err))
+	}
+	_, err = ts.cfg.IAMAPIV2.DeleteInstanceProfile(
+		context.Background(),
+		&aws_iam_v2.DeleteInstanceProfileInput{
+			InstanceProfileName: aws_v2.String(cur.NodeInstanceProfileName),
+		},
+	)
+	if err != nil && !isNotFound(err) {
+		ts.cfg.Logger.Warn("failed to delete instance profile", zap.Error(err))
+	}
+
+	for _, arn := range ts.nodePolicyARNs() {
+		_, err = ts.cfg.IAMAPIV2.DetachRolePolicy(
+			context.Background(),
+			&aws_iam_v2.DetachRolePolicyInput{
+				RoleName:  aws_v2.String(cur.NodeRoleName),
+				PolicyArn: aws_v2.String(arn),
+			},
+		)
+		if err != nil && !isNotFound(err) {
+			ts.cfg.Logger.Warn("failed to detach policy", zap.String("policy-arn", arn), zap.Error(err))
+		}
+	}
+
+	_, err = ts.cfg.IAMAPIV2.DeleteRole(
+		context.Background(),
+		&aws_iam_v2.DeleteRoleInput{
+			RoleName: aws_v2.String(cur.NodeRoleName),
+		},
+	)
+	if err != nil && !isNotFound(err) {
+		ts.cfg.Logger.Warn("failed to delete node role", zap.Error(err))
+		return err
+	}
+
+	ts.cfg.Logger.Info("deleted node role")
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.Status.DeletedResources[cur.NodeRoleName] = "AddOnKarpenter.NodeRoleName"
+	})
+	return nil
+}
+
+// https://karpenter.sh/v0.16.3/getting-started/getting-started-with-eksctl/
+func (ts *tester) createControllerRole() error {
+	cur := ts.cfg.EKSConfig.AddOnKarpenter
+	if cur.ControllerRoleARN != "" {
+		ts.cfg.Logger.Info("controller role already created; no need to create a new one")
+		return nil
+	}
+
+	issuer := ts.cfg.EKSConfig.Status.ClusterOIDCIssuerHostPath
+	ts.cfg.Logger.Info("creating controller role", zap.String("name", cur.ControllerRoleName))
+	out, err := ts.cfg.IAMAPIV2.CreateRole(
+		context.Background(),
+		&aws_iam_v2.CreateRoleInput{
+			RoleName: aws_v2.String(cur.ControllerRoleName),
+			Path:     aws_v2.String("/"),
+			AssumeRolePolicyDocument: aws_v2.String(toJSON(aws_iam.PolicyDocument{
+				Version: "2012-10-17",
+				Statement: []aws_iam.StatementEntry{
+					{
+						Effect:    "Allow",
+						Principal: &aws_iam.PrincipalEntry{Federated: ts.cfg.EKSConfig.Status.ClusterOIDCIssuerARN},
+						Action:    []string{"sts:AssumeRoleWithWebIdentity"},
+						Condition: map[string]map[string]string{
+							"StringEquals": {
+								issuer + ":sub": "system:serviceaccount:" + cur.Namespace + ":" + serviceAccountName,
+								issuer + ":aud": "sts.amazonaws.com",
+							},
+						},
+					},
+				},
+			})),
+		},
+	)
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.ControllerRoleARN = aws_v2.ToString(out.Role.Arn)
+	})
+
+	if _, err = ts.cfg.IAMAPIV2.PutRolePolicy(
+		context.Background(),
+		&aws_iam_v2.PutRolePolicyInput{
+			RoleName:       aws_v2.String(cur.ControllerRoleName),
+			PolicyName:     aws_v2.String(controllerPolicyName),
+			PolicyDocument: aws_v2.String(toJSON(ts.controllerPolicyDocument())),
+		},
+	); err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("created controller role", zap.String("role-arn", cur.ControllerRoleARN))
+	return nil
+}
+
+func (ts *tester) controllerPolicyDocument() aws_iam.PolicyDocument {
+	return aws_iam.PolicyDocument{
+		Version: "2012-10-17",
+		Statement: []aws_iam.StatementEntry{
+			{
+				Effect:   "Allow",
+				Resource: "*",
+				Action: []string{
+					"ec2:CreateLaunchTemplate",
+					"ec2:CreateFleet",
+					"ec2:RunInstances",
+					"ec2:CreateTags",
+					"ec2:TerminateInstances",
+					"ec2:DeleteLaunchTemplate",
+					"ec2:DescribeLaunchTemplates",
+					"ec2:DescribeInstances",
+					"ec2:DescribeSecurityGroups",
+					"ec2:DescribeSubnets",
+					"ec2:DescribeImages",
+					"ec2:DescribeInstanceTypes",
+					"ec2:DescribeInstanceTypeOfferings",
+					"ec2:DescribeAvailabilityZones",
+					"ec2:DescribeSpotPriceHistory",
+					"ssm:GetParameter",
+					"pricing:GetProducts",
+					"eks:DescribeCluster",
+				},
+			},
+			{
+				Effect:   "Allow",
+				Resource: ts.cfg.EKSConfig.AddOnKarpenter.NodeRoleARN,
+				Action:   []string{"iam:PassRole"},
+			},
+		},
+	}
+}
+
+func (ts *tester) deleteControllerRole() error {
+	cur := ts.cfg.EKSConfig.AddOnKarpenter
+	if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[cur.ControllerRoleName]; ok {
+		return nil
+	}
+	ts.cfg.Logger.Info("deleting controller role", zap.String("name", cur.ControllerRoleName))
+
+	_, err := ts.cfg.IAMAPIV2.DeleteRolePolicy(
+		context.Background(),
+		&aws_iam_v2.DeleteRolePolicyInput{
+			RoleName:   aws_v2.String(cur.ControllerRoleName),
+			PolicyName: aws_v2.String(controllerPolicyName),
+		},
+	)
+	if err != nil && !isNotFound(err) {
+		ts.cfg.Logger.Warn("failed to delete controller role policy", zap.Error(err))
+	}
+
+	_, err = ts.cfg.IAMAPIV2.DeleteRole(
+		context.Background(),
+		&aws_iam_v2.DeleteRoleInput{
+			RoleName: aws_v2.String(cur.ControllerRoleName),
+		},
+	)
+	if err != nil && !isNotFound(err) {
+		ts.cfg.Logger.Warn("failed to delete controller role", zap.Error(err))
+		return err
+	}
+
+	ts.cfg.Logger.Info("deleted controller role")
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.Status.DeletedResources[cur.ControllerRoleName] = "AddOnKarpenter.ControllerRoleName"
+	})
+	return nil
+}
+
+func toJSON(p aws_iam.PolicyDocument) string {
+	b, err := json.Marshal(p)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
@@ -11,10 +11,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aws/aws-k8s-tester/eksconfig"
 	"github.com/aws/aws-k8s-tester/pkg/fileutil"
 	"github.com/aws/aws-k8s-tester/pkg/user"
 	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
 	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	aws_s3_v2 "github.com/aws/aws-sdk-go-v2/service/s3"
 	aws_s3_v2_types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/aws-sdk-go/aws"
@@ -47,6 +49,25 @@ func (ts *Tester) createKeyPair() (err error) {
 		context.Background(),
 		&aws_ec2_v2.CreateKeyPairInput{
 			KeyName: aws_v2.String(ts.cfg.RemoteAccessKeyName),
+			TagSpecifications: []aws_ec2_v2_types.TagSpecification{
+				{
+					ResourceType: aws_ec2_v2_types.ResourceTypeKeyPair,
+					Tags: []aws_ec2_v2_types.Tag{
+						{
+							Key:   aws_v2.String(eksconfig.KindTagKey),
+							Value: aws_v2.String(eksconfig.KindTagValue),
+						},
+						{
+							Key:   aws_v2.String(eksconfig.RunTagKey),
+							Value: aws_v2.String(ts.cfg.Name),
+						},
+						{
+							Key:   aws_v2.String(eksconfig.CreatedTagKey),
+							Value: aws_v2.String(now.UTC().Format(time.RFC3339)),
+						},
+					},
+				},
+			},
 		})
 	if err != nil {
 		var apiErr smithy.APIError
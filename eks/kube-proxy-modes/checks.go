@@ -0,0 +1,259 @@
+package kubeproxymodes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/exec"
+)
+
+const (
+	backendName = "kube-proxy-backend"
+	clientName  = "kube-proxy-client"
+	serverPort  = 8080
+	servicePort = 80
+
+	// failedResponse is printed by the client for each failed request.
+	failedResponse = "__failed__"
+)
+
+// createWorkloads creates the backend Deployment serving the Pod names,
+// the ClusterIP Service, and the client Pod.
+func (ts *tester) createWorkloads() error {
+	cur := ts.cfg.EKSConfig.AddOnKubeProxyModes
+	cli := ts.cfg.K8SClient.KubernetesClientSet()
+	img := ts.cfg.EKSConfig.Image(eksconfig.DefaultBusyboxImage)
+	replicas := int32(cur.Backends)
+	labels := map[string]string{"app.kubernetes.io/name": backendName}
+
+	ts.cfg.Logger.Info("creating backend Deployment", zap.Int32("replicas", replicas))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := cli.AppsV1().Deployments(cur.Namespace).Create(ctx, &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backendName,
+			Namespace: cur.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyAlways,
+					Containers: []v1.Container{
+						{
+							Name:            backendName,
+							Image:           img,
+							ImagePullPolicy: v1.PullIfNotPresent,
+							Command: []string{
+								"/bin/sh",
+								"-c",
+								fmt.Sprintf("mkdir -p /www && hostname > /www/index.html && httpd -f -p %d -h /www", serverPort),
+							},
+							Ports: []v1.ContainerPort{{ContainerPort: serverPort}},
+							ReadinessProbe: &v1.Probe{
+								ProbeHandler: v1.ProbeHandler{
+									HTTPGet: &v1.HTTPGetAction{Path: "/", Port: intstr.FromInt(serverPort)},
+								},
+								PeriodSeconds: 5,
+							},
+						},
+					},
+					NodeSelector: map[string]string{
+						"kubernetes.io/os": "linux",
+					},
+					// spread the backends across the nodes
+					Affinity: &v1.Affinity{
+						PodAntiAffinity: &v1.PodAntiAffinity{
+							PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{
+								{
+									Weight: 100,
+									PodAffinityTerm: v1.PodAffinityTerm{
+										LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+										TopologyKey:   "kubernetes.io/hostname",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, metav1.CreateOptions{})
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Deployment %q (%v)", backendName, err)
+	}
+
+	ts.cfg.Logger.Info("creating backend Service")
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = cli.CoreV1().Services(cur.Namespace).Create(ctx, &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backendName,
+			Namespace: cur.Namespace,
+		},
+		Spec: v1.ServiceSpec{
+			Type:     v1.ServiceTypeClusterIP,
+			Selector: labels,
+			Ports: []v1.ServicePort{
+				{
+					Protocol:   v1.ProtocolTCP,
+					Port:       servicePort,
+					TargetPort: intstr.FromInt(serverPort),
+				},
+			},
+		},
+	}, metav1.CreateOptions{})
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Service %q (%v)", backendName, err)
+	}
+
+	ts.cfg.Logger.Info("creating client Pod")
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = cli.CoreV1().Pods(cur.Namespace).Create(ctx, &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clientName,
+			Namespace: cur.Namespace,
+			Labels:    map[string]string{"app.kubernetes.io/name": clientName},
+		},
+		Spec: v1.PodSpec{
+			RestartPolicy: v1.RestartPolicyAlways,
+			Containers: []v1.Container{
+				{
+					Name:            clientName,
+					Image:           img,
+					ImagePullPolicy: v1.PullIfNotPresent,
+					Command:         []string{"/bin/sh", "-c", "sleep 86400"},
+				},
+			},
+			NodeSelector: map[string]string{
+				"kubernetes.io/os": "linux",
+			},
+		},
+	}, metav1.CreateOptions{})
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Pod %q (%v)", clientName, err)
+	}
+	return nil
+}
+
+// waitWorkloads waits for the backend and client Pods to be ready,
+// and returns the backend Pod names and the Service ClusterIP.
+func (ts *tester) waitWorkloads() (backends []string, clusterIP string, err error) {
+	cur := ts.cfg.EKSConfig.AddOnKubeProxyModes
+	cli := ts.cfg.K8SClient.KubernetesClientSet()
+	waitStart := time.Now()
+	for time.Since(waitStart) < 10*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return nil, "", errors.New("workloads wait aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		pods, err := cli.CoreV1().Pods(cur.Namespace).List(ctx, metav1.ListOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list Pods", zap.Error(err))
+			continue
+		}
+		backends = backends[:0]
+		clientReady := false
+		for _, pod := range pods.Items {
+			if pod.DeletionTimestamp != nil || !isPodReady(pod) {
+				continue
+			}
+			switch pod.Labels["app.kubernetes.io/name"] {
+			case backendName:
+				backends = append(backends, pod.Name)
+			case clientName:
+				clientReady = true
+			}
+		}
+		ts.cfg.Logger.Info("polled Pods",
+			zap.Int("backends-ready", len(backends)),
+			zap.Int("backends", cur.Backends),
+			zap.Bool("client-ready", clientReady),
+		)
+		if len(backends) < cur.Backends || !clientReady {
+			continue
+		}
+
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		svc, err := cli.CoreV1().Services(cur.Namespace).Get(ctx, backendName, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get Service", zap.Error(err))
+			continue
+		}
+		return backends, svc.Spec.ClusterIP, nil
+	}
+	return nil, "", errors.New("workloads not ready")
+}
+
+// waitService waits for the first successful request to the Service,
+// until the restarted kube-proxy programs the Service rules.
+func (ts *tester) waitService(clusterIP string) error {
+	var lastErr error
+	for i := 0; i < 12; i++ {
+		out, err := ts.request(clusterIP, 1, time.Minute)
+		if err == nil && !strings.Contains(out, failedResponse) {
+			return nil
+		}
+		lastErr = fmt.Errorf("%v (%q)", err, strings.TrimSpace(out))
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("Service wait aborted")
+		case <-time.After(5 * time.Second):
+		}
+	}
+	return fmt.Errorf("Service %q not reachable (%v)", clusterIP, lastErr)
+}
+
+// request sends the requests to the Service from the client Pod,
+// each on a new connection, and returns the responses, one per line.
+func (ts *tester) request(clusterIP string, n int, timeout time.Duration) (string, error) {
+	script := fmt.Sprintf(
+		"i=0; while [ $i -lt %d ]; do wget -q -T 2 -O - http://%s:%d/ 2>/dev/null || echo %s; i=$((i+1)); done",
+		n,
+		clusterIP,
+		servicePort,
+		failedResponse,
+	)
+	args := []string{
+		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+		"--namespace=" + ts.cfg.EKSConfig.AddOnKubeProxyModes.Namespace,
+		"exec",
+		clientName,
+		"--",
+		"/bin/sh",
+		"-c",
+		script,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	output, err := exec.New().CommandContext(ctx, ts.cfg.EKSConfig.KubectlPath, args...).CombinedOutput()
+	cancel()
+	return string(output), err
+}
+
+func isPodReady(pod v1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == v1.PodReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,265 @@
+// Package kubeproxymodes switches the kube-proxy mode for each mode in
+// the matrix, runs the Service connectivity and the load balancing
+// distribution checks, and reports the differences between the modes.
+package kubeproxymodes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"go.uber.org/zap"
+)
+
+// Config defines kube-proxy mode matrix configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new kube-proxy mode matrix tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:      "kube-proxy-modes",
+		Exclusive: true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+			}), cfg.EKSConfig.IsEnabledAddOnKubeProxyModes)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnKubeProxyModes() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnKubeProxyModes.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnKubeProxyModes.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnKubeProxyModes.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	cur := ts.cfg.EKSConfig.AddOnKubeProxyModes
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		cur.Namespace,
+	); err != nil {
+		return err
+	}
+	if cur.OriginalMode == "" {
+		mode, err := ts.getMode()
+		if err != nil {
+			return err
+		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.OriginalMode = mode
+		})
+		ts.cfg.Logger.Info("recorded original kube-proxy mode", zap.String("mode", cur.OriginalMode))
+	}
+	if err = ts.createWorkloads(); err != nil {
+		return err
+	}
+	backends, clusterIP, err := ts.waitWorkloads()
+	if err != nil {
+		return err
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.Results = nil
+	})
+	for idx, mode := range cur.Modes {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("kube-proxy mode matrix aborted")
+		default:
+		}
+		fmt.Fprintf(ts.cfg.LogWriter, "\n[%d/%d] testing kube-proxy mode %q\n", idx+1, len(cur.Modes), mode)
+		rs := ts.testMode(mode, backends, clusterIP)
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.Results = append(cur.Results, rs)
+		})
+		fmt.Fprintf(ts.cfg.LogWriter, "\nkube-proxy mode %q passed %v (rollout %s, %d failed of %d requests, max deviation %.1f%%) %s\n%s",
+			rs.Mode, rs.Passed, rs.RolloutTimeString, rs.Failures, rs.Requests, rs.MaxDeviation*100, rs.Error, hitsTable(rs.BackendHits))
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.Differences = compareResults(cur.Results)
+	})
+	for _, diff := range cur.Differences {
+		fmt.Fprintf(ts.cfg.LogWriter, "kube-proxy mode difference: %s\n", diff)
+	}
+
+	// restore before reporting, so that the following add-ons run with the original mode
+	rerr := ts.restoreMode()
+	if err = ts.writeResults(); err != nil {
+		return err
+	}
+	if rerr != nil {
+		return rerr
+	}
+
+	var failed []string
+	for _, rs := range cur.Results {
+		if !rs.Passed {
+			failed = append(failed, fmt.Sprintf("%q (%s)", rs.Mode, rs.Error))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d kube-proxy mode(s) failed: %s", len(failed), len(cur.Results), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// testMode switches kube-proxy to the mode and runs the checks.
+func (ts *tester) testMode(mode string, backends []string, clusterIP string) (rs eksconfig.KubeProxyModeResult) {
+	cur := ts.cfg.EKSConfig.AddOnKubeProxyModes
+	rs = eksconfig.KubeProxyModeResult{Mode: mode}
+	defer func() {
+		rs.Passed = rs.Error == ""
+	}()
+
+	start := time.Now()
+	err := ts.setMode(mode)
+	rs.RolloutTime = time.Since(start)
+	rs.RolloutTimeString = rs.RolloutTime.String()
+	if err != nil {
+		rs.Error = err.Error()
+		return rs
+	}
+	if err = ts.waitService(clusterIP); err != nil {
+		rs.Error = err.Error()
+		return rs
+	}
+
+	ts.cfg.Logger.Info("sending requests to Service", zap.String("mode", mode), zap.String("cluster-ip", clusterIP), zap.Int("requests", cur.Requests))
+	out, err := ts.request(clusterIP, cur.Requests, time.Minute+time.Duration(cur.Requests)*3*time.Second)
+	if err != nil {
+		rs.Error = fmt.Sprintf("failed to send requests (%v, %q)", err, strings.TrimSpace(out))
+		return rs
+	}
+	rs.Requests = cur.Requests
+	rs.BackendHits, rs.Failures = tally(out, backends)
+	rs.MaxDeviation = maxDeviation(rs.BackendHits)
+	switch {
+	case rs.Failures > 0:
+		rs.Error = fmt.Sprintf("%d of %d requests failed", rs.Failures, rs.Requests)
+	case hitBackends(rs.BackendHits) < len(rs.BackendHits):
+		rs.Error = fmt.Sprintf("%d of %d backends hit", hitBackends(rs.BackendHits), len(rs.BackendHits))
+	case rs.MaxDeviation > cur.MaxDeviation:
+		rs.Error = fmt.Sprintf("max deviation %.1f%% > %.1f%%", rs.MaxDeviation*100, cur.MaxDeviation*100)
+	}
+	return rs
+}
+
+// restoreMode switches kube-proxy back to the original mode,
+// no-op if unchanged.
+func (ts *tester) restoreMode() error {
+	cur := ts.cfg.EKSConfig.AddOnKubeProxyModes
+	if cur.OriginalMode == "" {
+		return nil
+	}
+	ts.cfg.Logger.Info("restoring original kube-proxy mode", zap.String("mode", cur.OriginalMode))
+	if err := ts.setMode(cur.OriginalMode); err != nil {
+		return fmt.Errorf("failed to restore original kube-proxy mode (%v)", err)
+	}
+	return nil
+}
+
+func (ts *tester) writeResults() error {
+	cur := ts.cfg.EKSConfig.AddOnKubeProxyModes
+	b, err := json.MarshalIndent(cur.Results, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(cur.ResultsJSONPath, b, 0600); err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("wrote kube-proxy mode matrix results", zap.String("path", cur.ResultsJSONPath))
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnKubeProxyModes() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnKubeProxyModes.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnKubeProxyModes.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnKubeProxyModes.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete kube-proxy mode matrix namespace (%v)", err))
+	}
+	// no-op if restored on "Create"
+	if err := ts.restoreMode(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnKubeProxyModes.Created = false
+	})
+	return nil
+}
@@ -0,0 +1,221 @@
+package kubeproxymodes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	kubeProxyNamespace = "kube-system"
+	kubeProxyName      = "kube-proxy"
+	kubeProxySelector  = "k8s-app=kube-proxy"
+	// kubeProxyConfigMap is the EKS kube-proxy "KubeProxyConfiguration".
+	kubeProxyConfigMap = "kube-proxy-config"
+	kubeProxyConfigKey = "config"
+)
+
+// getMode returns the kube-proxy mode in the ConfigMap,
+// "iptables" if not set as the Linux default.
+func (ts *tester) getMode() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	cm, err := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().ConfigMaps(kubeProxyNamespace).Get(ctx, kubeProxyConfigMap, metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("failed to get %q ConfigMap (%v)", kubeProxyConfigMap, err)
+	}
+	var kc map[string]interface{}
+	if err = yaml.Unmarshal([]byte(cm.Data[kubeProxyConfigKey]), &kc); err != nil {
+		return "", fmt.Errorf("failed to parse %q ConfigMap (%v)", kubeProxyConfigMap, err)
+	}
+	if mode, _ := kc["mode"].(string); mode != "" {
+		return mode, nil
+	}
+	return eksconfig.KubeProxyModeIPTables, nil
+}
+
+// setMode updates the kube-proxy mode in the ConfigMap if changed,
+// restarts the "kube-proxy" DaemonSet, and verifies the mode.
+func (ts *tester) setMode(mode string) error {
+	cur := ts.cfg.EKSConfig.AddOnKubeProxyModes
+	cmCli := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().ConfigMaps(kubeProxyNamespace)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	cm, err := cmCli.Get(ctx, kubeProxyConfigMap, metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get %q ConfigMap (%v)", kubeProxyConfigMap, err)
+	}
+	data, changed, err := setProxyMode(cm.Data[kubeProxyConfigKey], mode, cur.IPVSScheduler)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		ts.cfg.Logger.Info("kube-proxy mode unchanged", zap.String("mode", mode))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("switching kube-proxy mode", zap.String("mode", mode))
+	cm.Data[kubeProxyConfigKey] = data
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = cmCli.Update(ctx, cm, metav1.UpdateOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to update %q ConfigMap (%v)", kubeProxyConfigMap, err)
+	}
+	if err = ts.restartKubeProxy(); err != nil {
+		return err
+	}
+	if err = ts.waitRollout(); err != nil {
+		return err
+	}
+	return ts.verifyMode(mode)
+}
+
+// restartKubeProxy restarts the "kube-proxy" Pods to reload the
+// ConfigMap, as "kubectl rollout restart".
+func (ts *tester) restartKubeProxy() error {
+	dsCli := ts.cfg.K8SClient.KubernetesClientSet().AppsV1().DaemonSets(kubeProxyNamespace)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	ds, err := dsCli.Get(ctx, kubeProxyName, metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get %q DaemonSet (%v)", kubeProxyName, err)
+	}
+	if ds.Spec.Template.Annotations == nil {
+		ds.Spec.Template.Annotations = make(map[string]string)
+	}
+	ds.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"] = time.Now().Format(time.RFC3339)
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = dsCli.Update(ctx, ds, metav1.UpdateOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to restart %q DaemonSet (%v)", kubeProxyName, err)
+	}
+	return nil
+}
+
+// waitRollout waits for the "kube-proxy" rollout within "RolloutTimeout".
+func (ts *tester) waitRollout() error {
+	cur := ts.cfg.EKSConfig.AddOnKubeProxyModes
+	dsCli := ts.cfg.K8SClient.KubernetesClientSet().AppsV1().DaemonSets(kubeProxyNamespace)
+	waitStart := time.Now()
+	for time.Since(waitStart) < cur.RolloutTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("kube-proxy rollout aborted")
+		case <-time.After(10 * time.Second):
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		ds, err := dsCli.Get(ctx, kubeProxyName, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get kube-proxy DaemonSet", zap.Error(err))
+			continue
+		}
+		st := ds.Status
+		ts.cfg.Logger.Info("polled kube-proxy DaemonSet",
+			zap.Int32("desired", st.DesiredNumberScheduled),
+			zap.Int32("updated", st.UpdatedNumberScheduled),
+			zap.Int32("available", st.NumberAvailable),
+		)
+		if st.DesiredNumberScheduled > 0 &&
+			st.ObservedGeneration >= ds.Generation &&
+			st.UpdatedNumberScheduled == st.DesiredNumberScheduled &&
+			st.NumberAvailable == st.DesiredNumberScheduled {
+			return nil
+		}
+	}
+	return fmt.Errorf("kube-proxy DaemonSet not rolled out in %s", cur.RolloutTimeoutString)
+}
+
+// verifyMode checks the mode in the "kube-proxy" Pod logs, since the
+// kube-proxy "/proxyMode" endpoint only listens on the node localhost.
+// The Pods without the proxier log line are skipped.
+func (ts *tester) verifyMode(mode string) error {
+	podCli := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Pods(kubeProxyNamespace)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	pods, err := podCli.List(ctx, metav1.ListOptions{LabelSelector: kubeProxySelector})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to list kube-proxy Pods (%v)", err)
+	}
+	verified := 0
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		b, err := podCli.GetLogs(pod.Name, &v1.PodLogOptions{}).DoRaw(ctx)
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get kube-proxy logs", zap.String("pod", pod.Name), zap.Error(err))
+			continue
+		}
+		got := detectMode(b)
+		if got == "" {
+			ts.cfg.Logger.Warn("kube-proxy mode not found in logs", zap.String("pod", pod.Name))
+			continue
+		}
+		if got != mode {
+			return fmt.Errorf("kube-proxy Pod %q running in %q mode, expected %q", pod.Name, got, mode)
+		}
+		verified++
+	}
+	ts.cfg.Logger.Info("verified kube-proxy mode", zap.String("mode", mode), zap.Int("pods", verified))
+	return nil
+}
+
+// detectMode returns the proxier mode logged by kube-proxy on start
+// (e.g. "Using iptables proxy", "Using ipvs Proxier"), or empty if not found.
+func detectMode(logs []byte) string {
+	logs = bytes.ToLower(logs)
+	for _, mode := range []string{eksconfig.KubeProxyModeIPVS, eksconfig.KubeProxyModeIPTables} {
+		if bytes.Contains(logs, []byte("using "+mode+" prox")) {
+			return mode
+		}
+	}
+	return ""
+}
+
+// setProxyMode returns the "KubeProxyConfiguration" with the mode,
+// and the IPVS scheduler in "ipvs" mode if not empty.
+func setProxyMode(data string, mode string, scheduler string) (string, bool, error) {
+	var kc map[string]interface{}
+	if err := yaml.Unmarshal([]byte(data), &kc); err != nil {
+		return "", false, fmt.Errorf("failed to parse KubeProxyConfiguration (%v)", err)
+	}
+	if kc == nil {
+		return "", false, errors.New("empty KubeProxyConfiguration")
+	}
+	before, err := yaml.Marshal(kc)
+	if err != nil {
+		return "", false, err
+	}
+
+	kc["mode"] = mode
+	if mode == eksconfig.KubeProxyModeIPVS && scheduler != "" {
+		ipvs, _ := kc["ipvs"].(map[string]interface{})
+		if ipvs == nil {
+			ipvs = make(map[string]interface{})
+		}
+		ipvs["scheduler"] = scheduler
+		kc["ipvs"] = ipvs
+	}
+	after, err := yaml.Marshal(kc)
+	if err != nil {
+		return "", false, err
+	}
+	if bytes.Equal(before, after) {
+		return data, false, nil
+	}
+	return strings.TrimSpace(string(after)) + "\n", true, nil
+}
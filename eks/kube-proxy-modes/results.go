@@ -0,0 +1,96 @@
+package kubeproxymodes
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+)
+
+// tally counts the responses per backend, and the failed requests.
+// The responses from the unknown backends (e.g. replaced Pods) are counted
+// as their own backends.
+func tally(out string, backends []string) (hits map[string]int, failures int) {
+	hits = make(map[string]int, len(backends))
+	for _, b := range backends {
+		hits[b] = 0
+	}
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		switch line {
+		case "":
+		case failedResponse:
+			failures++
+		default:
+			hits[line]++
+		}
+	}
+	return hits, failures
+}
+
+// maxDeviation returns the maximum deviation of the hits per backend
+// from the even distribution, 1 for a backend never hit.
+func maxDeviation(hits map[string]int) float64 {
+	total := 0
+	for _, n := range hits {
+		total += n
+	}
+	if total == 0 || len(hits) == 0 {
+		return 1
+	}
+	even := float64(total) / float64(len(hits))
+	dev := 0.0
+	for _, n := range hits {
+		dev = math.Max(dev, math.Abs(float64(n)-even)/even)
+	}
+	return dev
+}
+
+// compareResults returns the differences of each mode from the first mode.
+func compareResults(results []eksconfig.KubeProxyModeResult) (diffs []string) {
+	if len(results) < 2 {
+		return nil
+	}
+	base := results[0]
+	for _, rs := range results[1:] {
+		if base.Passed != rs.Passed {
+			diffs = append(diffs, fmt.Sprintf("passed %q %v vs %q %v", base.Mode, base.Passed, rs.Mode, rs.Passed))
+		}
+		if base.Failures != rs.Failures {
+			diffs = append(diffs, fmt.Sprintf("failed requests %q %d vs %q %d", base.Mode, base.Failures, rs.Mode, rs.Failures))
+		}
+		if bh, rh := hitBackends(base.BackendHits), hitBackends(rs.BackendHits); bh != rh {
+			diffs = append(diffs, fmt.Sprintf("backends hit %q %d vs %q %d", base.Mode, bh, rs.Mode, rh))
+		}
+		// ignore the noise of the random iptables distribution
+		if math.Abs(base.MaxDeviation-rs.MaxDeviation) >= 0.1 {
+			diffs = append(diffs, fmt.Sprintf("max deviation %q %.1f%% vs %q %.1f%%", base.Mode, base.MaxDeviation*100, rs.Mode, rs.MaxDeviation*100))
+		}
+	}
+	return diffs
+}
+
+func hitBackends(hits map[string]int) (n int) {
+	for _, v := range hits {
+		if v > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// hitsTable returns the hits per backend in the name order.
+func hitsTable(hits map[string]int) string {
+	names := make([]string, 0, len(hits))
+	for name := range hits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		sb.WriteString(fmt.Sprintf("  %s: %d\n", name, hits[name]))
+	}
+	return sb.String()
+}
@@ -0,0 +1,101 @@
+package kubeproxymodes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+)
+
+func TestTally(t *testing.T) {
+	out := "backend-a\nbackend-b\n__failed__\nbackend-a\n\nbackend-c\n"
+	hits, failures := tally(out, []string{"backend-a", "backend-b", "backend-d"})
+	expected := map[string]int{"backend-a": 2, "backend-b": 1, "backend-c": 1, "backend-d": 0}
+	if !reflect.DeepEqual(hits, expected) {
+		t.Fatalf("expected %v, got %v", expected, hits)
+	}
+	if failures != 1 {
+		t.Fatalf("unexpected failures %d", failures)
+	}
+}
+
+func TestMaxDeviation(t *testing.T) {
+	tt := []struct {
+		hits map[string]int
+		exp  float64
+	}{
+		{hits: map[string]int{"a": 100, "b": 100}, exp: 0},
+		{hits: map[string]int{"a": 150, "b": 50}, exp: 0.5},
+		{hits: map[string]int{"a": 200, "b": 0}, exp: 1},
+		{hits: map[string]int{}, exp: 1},
+	}
+	for i, tv := range tt {
+		if v := maxDeviation(tv.hits); v != tv.exp {
+			t.Fatalf("#%d: expected %v, got %v", i, tv.exp, v)
+		}
+	}
+}
+
+func TestCompareResults(t *testing.T) {
+	diffs := compareResults([]eksconfig.KubeProxyModeResult{
+		{Mode: "iptables", Passed: true, BackendHits: map[string]int{"a": 60, "b": 40}, MaxDeviation: 0.2},
+		{Mode: "ipvs", Passed: true, BackendHits: map[string]int{"a": 50, "b": 50}, MaxDeviation: 0},
+	})
+	if len(diffs) != 1 || !strings.HasPrefix(diffs[0], "max deviation") {
+		t.Fatalf("unexpected differences %v", diffs)
+	}
+
+	diffs = compareResults([]eksconfig.KubeProxyModeResult{
+		{Mode: "iptables", Passed: true, BackendHits: map[string]int{"a": 50, "b": 50}},
+		{Mode: "ipvs", Passed: false, Failures: 100, BackendHits: map[string]int{"a": 0, "b": 0}, MaxDeviation: 1},
+	})
+	if len(diffs) != 4 {
+		t.Fatalf("unexpected differences %v", diffs)
+	}
+}
+
+func TestSetProxyMode(t *testing.T) {
+	data := `apiVersion: kubeproxy.config.k8s.io/v1alpha1
+kind: KubeProxyConfiguration
+metricsBindAddress: 0.0.0.0:10249
+mode: "iptables"
+ipvs:
+  minSyncPeriod: 0s
+`
+	out, changed, err := setProxyMode(data, "iptables", "rr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed || out != data {
+		t.Fatalf("unexpected change %q", out)
+	}
+
+	out, changed, err = setProxyMode(data, "ipvs", "lc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || !strings.Contains(out, "mode: ipvs") || !strings.Contains(out, "scheduler: lc") || !strings.Contains(out, "minSyncPeriod: 0s") {
+		t.Fatalf("unexpected config %q", out)
+	}
+
+	if _, _, err = setProxyMode("", "ipvs", ""); err == nil {
+		t.Fatal("expected error for empty config")
+	}
+}
+
+func TestDetectMode(t *testing.T) {
+	tt := []struct {
+		logs string
+		exp  string
+	}{
+		{logs: `I1018 server_others.go:152] "Using iptables proxy"`, exp: "iptables"},
+		{logs: `I1018 server_others.go:269] "Using ipvs Proxier"`, exp: "ipvs"},
+		{logs: `I1018 server.go:656] "Version info"`, exp: ""},
+	}
+	for i, tv := range tt {
+		if v := detectMode([]byte(tv.logs)); v != tv.exp {
+			t.Fatalf("#%d: expected %q, got %q", i, tv.exp, v)
+		}
+	}
+}
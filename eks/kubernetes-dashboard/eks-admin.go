@@ -117,7 +117,9 @@ func (ts *tester) fetchAuthenticationToken() error {
 	}
 	ts.cfg.Logger.Info("fetched authentication token")
 
-	ts.cfg.EKSConfig.AddOnKubernetesDashboard.AuthenticationToken = string(token)
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnKubernetesDashboard.AuthenticationToken = string(token)
+	})
 	fmt.Fprintf(ts.cfg.LogWriter, "\n\n\nKubernetes Dashboard Token:\n%s\n\n\n", ts.cfg.EKSConfig.AddOnKubernetesDashboard.AuthenticationToken)
 
 	ts.cfg.EKSConfig.Sync()
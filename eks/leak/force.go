@@ -0,0 +1,277 @@
+package leak
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	smithy "github.com/aws/smithy-go"
+	"go.uber.org/zap"
+)
+
+// Op represents a forced deletion operation.
+type Op struct {
+	attempts       int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+// OpOption configures forced deletion.
+type OpOption func(*Op)
+
+// WithAttempts sets the maximum number of deletion rounds.
+func WithAttempts(n int) OpOption {
+	return func(op *Op) { op.attempts = n }
+}
+
+// WithBackoff sets the initial and the maximum wait between the deletion rounds.
+func WithBackoff(initial time.Duration, max time.Duration) OpOption {
+	return func(op *Op) {
+		op.initialBackoff = initial
+		op.maxBackoff = max
+	}
+}
+
+func (op *Op) applyOpts(opts []OpOption) {
+	for _, opt := range opts {
+		opt(op)
+	}
+}
+
+// backoff returns the wait before the next deletion round,
+// doubled every round up to the maximum.
+func backoff(initial time.Duration, max time.Duration, attempt int) time.Duration {
+	d := initial
+	for i := 0; i < attempt; i++ {
+		d *= 2
+		if d >= max {
+			return max
+		}
+	}
+	return d
+}
+
+// ForceDelete deletes the resources in the dependency order, and retries
+// the failed resources with exponential backoff, since some resources
+// (e.g. NAT gateways, instances) are deleted asynchronously and keep
+// pinning their VPC until gone. When a deletion fails with a dependency
+// violation, the stuck dependencies that are not part of the resources
+// (e.g. ENIs and load balancers created by Kubernetes Services) are found
+// and force-deleted before the next round. It returns the errors of
+// the resources that failed to delete in the last round.
+func ForceDelete(cfg Config, rs []eksconfig.AWSResource, opts ...OpOption) (errs []error) {
+	ret := Op{attempts: 10, initialBackoff: 10 * time.Second, maxBackoff: 2 * time.Minute}
+	ret.applyOpts(opts)
+
+	remaining := append([]eksconfig.AWSResource(nil), rs...)
+	sortResources(remaining)
+	for attempt := 0; ; attempt++ {
+		errs = nil
+		var failed []eksconfig.AWSResource
+		for _, r := range remaining {
+			cfg.Logger.Info("force-deleting resource",
+				zap.String("service", r.Service),
+				zap.String("type", r.Type),
+				zap.String("id", r.ID),
+				zap.Int("attempt", attempt),
+			)
+			err := forceDeleteResource(cfg, r)
+			if err == nil || isNotFound(err) {
+				continue
+			}
+			cfg.Logger.Warn("failed to force-delete resource", zap.String("id", r.ID), zap.Error(err))
+			if isDependencyViolation(err) {
+				forceDeleteBlockers(cfg, r)
+			}
+			failed = append(failed, r)
+			errs = append(errs, fmt.Errorf("%s/%s %q (%v)", r.Service, r.Type, r.ID, err))
+		}
+		if len(failed) == 0 || attempt+1 >= ret.attempts {
+			return errs
+		}
+
+		wait := backoff(ret.initialBackoff, ret.maxBackoff, attempt)
+		cfg.Logger.Info("retrying force deletion",
+			zap.Int("remaining", len(failed)),
+			zap.Duration("wait", wait),
+		)
+		time.Sleep(wait)
+		remaining = failed
+	}
+}
+
+func forceDeleteBlockers(cfg Config, r eksconfig.AWSResource) {
+	bs, err := Blockers(cfg, r)
+	if err != nil {
+		cfg.Logger.Warn("failed to find stuck dependencies", zap.String("id", r.ID), zap.Error(err))
+		return
+	}
+	for _, b := range bs {
+		cfg.Logger.Warn("force-deleting stuck dependency",
+			zap.String("id", r.ID),
+			zap.String("dependency-type", b.Service+"/"+b.Type),
+			zap.String("dependency-id", b.ID),
+		)
+		if err = forceDeleteResource(cfg, b); err != nil && !isNotFound(err) {
+			cfg.Logger.Warn("failed to force-delete stuck dependency", zap.String("dependency-id", b.ID), zap.Error(err))
+		}
+	}
+}
+
+// Blockers returns the resources that block the deletion of the VPC,
+// the subnet, or the security group: the load balancers created by
+// Kubernetes Services (which own ENIs and security groups), and then
+// the leftover ENIs (e.g. released by the CNI plugin on the terminated nodes).
+func Blockers(cfg Config, r eksconfig.AWSResource) (bs []eksconfig.AWSResource, err error) {
+	var filter aws_ec2_v2_types.Filter
+	switch r.Service + "/" + r.Type {
+	case "ec2/vpc":
+		filter = aws_ec2_v2_types.Filter{Name: aws_v2.String("vpc-id"), Values: []string{r.ID}}
+	case "ec2/subnet":
+		filter = aws_ec2_v2_types.Filter{Name: aws_v2.String("subnet-id"), Values: []string{r.ID}}
+	case "ec2/security-group":
+		filter = aws_ec2_v2_types.Filter{Name: aws_v2.String("group-id"), Values: []string{r.ID}}
+	default:
+		return nil, nil
+	}
+
+	var enis []aws_ec2_v2_types.NetworkInterface
+	p := aws_ec2_v2.NewDescribeNetworkInterfacesPaginator(
+		cfg.EC2APIV2,
+		&aws_ec2_v2.DescribeNetworkInterfacesInput{Filters: []aws_ec2_v2_types.Filter{filter}},
+	)
+	for p.HasMorePages() {
+		out, err := p.NextPage(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe network interfaces (%v)", err)
+		}
+		enis = append(enis, out.NetworkInterfaces...)
+	}
+
+	// load balancers are not deleted with their ENIs,
+	// find them by the ENI description
+	// e.g. "ELB app/k8s-default-ingress/0123", "ELB net/a1b2/0123", "ELB a1b2"
+	lbs := make(map[string]struct{})
+	for _, eni := range enis {
+		desc := aws_v2.ToString(eni.Description)
+		if !strings.HasPrefix(desc, "ELB ") {
+			continue
+		}
+		name := strings.TrimPrefix(desc, "ELB ")
+		if _, ok := lbs[name]; ok {
+			continue
+		}
+		lbs[name] = struct{}{}
+		bs = append(bs, eksconfig.AWSResource{Service: "elasticloadbalancing", Type: "loadbalancer", ID: name})
+	}
+	for _, eni := range enis {
+		if strings.HasPrefix(aws_v2.ToString(eni.Description), "ELB ") {
+			continue
+		}
+		bs = append(bs, eksconfig.AWSResource{Service: "ec2", Type: "network-interface", ID: aws_v2.ToString(eni.NetworkInterfaceId)})
+	}
+	return bs, nil
+}
+
+func forceDeleteResource(cfg Config, r eksconfig.AWSResource) error {
+	if r.Service+"/"+r.Type == "ec2/network-interface" {
+		return forceDeleteENI(cfg, r.ID)
+	}
+	return deleteResource(cfg, r)
+}
+
+// forceDeleteENI detaches the ENI if attached, and deletes.
+// The primary ENIs of the instances cannot be detached,
+// and are deleted with the instance termination.
+func forceDeleteENI(cfg Config, id string) error {
+	out, err := cfg.EC2APIV2.DescribeNetworkInterfaces(
+		context.Background(),
+		&aws_ec2_v2.DescribeNetworkInterfacesInput{NetworkInterfaceIds: []string{id}},
+	)
+	if err != nil {
+		return err
+	}
+	for _, eni := range out.NetworkInterfaces {
+		att := eni.Attachment
+		if att == nil || aws_v2.ToInt32(att.DeviceIndex) == 0 {
+			continue
+		}
+		if _, err = cfg.EC2APIV2.DetachNetworkInterface(
+			context.Background(),
+			&aws_ec2_v2.DetachNetworkInterfaceInput{
+				AttachmentId: att.AttachmentId,
+				Force:        aws_v2.Bool(true),
+			},
+		); err != nil && !isNotFound(err) {
+			return err
+		}
+	}
+	_, err = cfg.EC2APIV2.DeleteNetworkInterface(
+		context.Background(),
+		&aws_ec2_v2.DeleteNetworkInterfaceInput{NetworkInterfaceId: aws_v2.String(id)},
+	)
+	return err
+}
+
+// deleteLoadBalancer deletes the ELBv2 (e.g. "app/name/id", "net/name/id"),
+// or the classic ELB created by the Kubernetes Service of type LoadBalancer.
+func deleteLoadBalancer(cfg Config, r eksconfig.AWSResource) (err error) {
+	if !strings.Contains(r.ID, "/") {
+		if cfg.ELBAPI == nil {
+			return fmt.Errorf("no classic ELB API to delete %q", r.ID)
+		}
+		_, err = cfg.ELBAPI.DeleteLoadBalancer(&elb.DeleteLoadBalancerInput{LoadBalancerName: aws.String(r.ID)})
+		return err
+	}
+	arn := r.ARN
+	if arn == "" {
+		// found by the ENI description, with the name but without the ARN
+		parts := strings.Split(r.ID, "/")
+		out, derr := cfg.ELBV2API.DescribeLoadBalancers(&elbv2.DescribeLoadBalancersInput{Names: aws.StringSlice([]string{parts[1]})})
+		if derr != nil {
+			return derr
+		}
+		if len(out.LoadBalancers) == 0 {
+			return nil
+		}
+		arn = aws.StringValue(out.LoadBalancers[0].LoadBalancerArn)
+	}
+	_, err = cfg.ELBV2API.DeleteLoadBalancer(&elbv2.DeleteLoadBalancerInput{LoadBalancerArn: aws.String(arn)})
+	return err
+}
+
+// errorCode returns the error code of the v1 (e.g. ELB, IAM)
+// or the v2 (e.g. EC2) API error, or empty string.
+func errorCode(err error) string {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code()
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
+
+func isDependencyViolation(err error) bool {
+	switch errorCode(err) {
+	case "DependencyViolation", "InvalidNetworkInterface.InUse", "ResourceInUse", "DeleteConflict":
+		return true
+	}
+	return false
+}
+
+func isNotFound(err error) bool {
+	code := errorCode(err)
+	return strings.Contains(code, "NotFound") || code == "NoSuchEntity" || code == "NoSuchBucket"
+}
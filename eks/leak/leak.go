@@ -0,0 +1,413 @@
+// Package leak finds the AWS resources of a test run that are left behind
+// after the cluster deletion, and optionally deletes them.
+// The resources are found by the run tag "eksconfig.RunTagKey",
+// the Kubernetes cluster ownership tag (e.g. load balancers, volumes),
+// and by the cluster name prefix for the untaggable resources (e.g. IAM roles).
+package leak
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/pkg/aws/cfn"
+	aws_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_cfn_v2 "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	aws_cfn_v2_types "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/olekukonko/tablewriter"
+	"go.uber.org/zap"
+)
+
+// Config defines leak check configuration.
+type Config struct {
+	Logger     *zap.Logger
+	EKSConfig  *eksconfig.Config
+	TaggingAPI resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI
+	EC2APIV2   *aws_ec2_v2.Client
+	ELBV2API   elbv2iface.ELBV2API
+	CFNAPIV2   cfn.APIV2
+	IAMAPI     iamiface.IAMAPI
+	S3API      s3iface.S3API
+
+	// ELBAPI deletes the classic ELBs created by the Kubernetes Services.
+	ELBAPI elbiface.ELBAPI
+}
+
+// Scan returns the existing AWS resources of the test run.
+func Scan(cfg Config) ([]eksconfig.AWSResource, error) {
+	found := make(map[string]eksconfig.AWSResource)
+	add := func(r eksconfig.AWSResource) {
+		k := r.ARN
+		if k == "" {
+			k = r.Service + "/" + r.Type + "/" + r.ID
+		}
+		found[k] = r
+	}
+
+	for _, tf := range []*resourcegroupstaggingapi.TagFilter{
+		{Key: aws.String(eksconfig.RunTagKey), Values: aws.StringSlice([]string{cfg.EKSConfig.Name})},
+		{Key: aws.String("kubernetes.io/cluster/" + cfg.EKSConfig.Name)},
+	} {
+		err := cfg.TaggingAPI.GetResourcesPages(
+			&resourcegroupstaggingapi.GetResourcesInput{TagFilters: []*resourcegroupstaggingapi.TagFilter{tf}},
+			func(out *resourcegroupstaggingapi.GetResourcesOutput, lastPage bool) bool {
+				for _, m := range out.ResourceTagMappingList {
+					r, err := parseARN(aws.StringValue(m.ResourceARN))
+					if err != nil {
+						cfg.Logger.Warn("skipping unknown resource ARN", zap.Error(err))
+						continue
+					}
+					add(r)
+				}
+				return true
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get resources by tag %q (%v)", aws.StringValue(tf.Key), err)
+		}
+	}
+
+	// the tagging API keeps returning the recently terminated resources
+	rs := make([]eksconfig.AWSResource, 0, len(found))
+	for _, r := range found {
+		deleted, err := isDeleted(cfg, r)
+		if err != nil {
+			cfg.Logger.Warn("failed to check resource state", zap.String("arn", r.ARN), zap.Error(err))
+		}
+		if !deleted {
+			rs = append(rs, r)
+		}
+	}
+
+	// the pinned SDK ships no paginators for CloudFormation
+	stacksInput := &aws_cfn_v2.ListStacksInput{}
+	for {
+		out, err := cfg.CFNAPIV2.ListStacks(context.Background(), stacksInput)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list stacks (%v)", err)
+		}
+		for _, s := range out.StackSummaries {
+			if s.StackStatus == aws_cfn_v2_types.StackStatusDeleteComplete ||
+				!strings.HasPrefix(aws.StringValue(s.StackName), cfg.EKSConfig.Name) {
+				continue
+			}
+			r, err := parseARN(aws.StringValue(s.StackId))
+			if err != nil {
+				continue
+			}
+			if _, ok := found[r.ARN]; !ok {
+				found[r.ARN] = r
+				rs = append(rs, r)
+			}
+		}
+		if aws.StringValue(out.NextToken) == "" {
+			break
+		}
+		stacksInput.NextToken = out.NextToken
+	}
+
+	// IAM resources are global, and not supported by the tagging API
+	err := cfg.IAMAPI.ListRolesPages(
+		&iam.ListRolesInput{},
+		func(out *iam.ListRolesOutput, lastPage bool) bool {
+			for _, v := range out.Roles {
+				if strings.HasPrefix(aws.StringValue(v.RoleName), cfg.EKSConfig.Name) {
+					rs = append(rs, eksconfig.AWSResource{Service: "iam", Type: "role", ID: aws.StringValue(v.RoleName), ARN: aws.StringValue(v.Arn)})
+				}
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles (%v)", err)
+	}
+	err = cfg.IAMAPI.ListPoliciesPages(
+		&iam.ListPoliciesInput{Scope: aws.String(iam.PolicyScopeTypeLocal)},
+		func(out *iam.ListPoliciesOutput, lastPage bool) bool {
+			for _, v := range out.Policies {
+				if strings.HasPrefix(aws.StringValue(v.PolicyName), cfg.EKSConfig.Name) {
+					rs = append(rs, eksconfig.AWSResource{Service: "iam", Type: "policy", ID: aws.StringValue(v.PolicyName), ARN: aws.StringValue(v.Arn)})
+				}
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policies (%v)", err)
+	}
+
+	if cfg.EKSConfig.S3.BucketCreate && cfg.EKSConfig.S3.BucketName != "" {
+		_, err = cfg.S3API.HeadBucket(&s3.HeadBucketInput{Bucket: aws.String(cfg.EKSConfig.S3.BucketName)})
+		if err == nil {
+			rs = append(rs, eksconfig.AWSResource{Service: "s3", Type: "bucket", ID: cfg.EKSConfig.S3.BucketName})
+		}
+	}
+
+	sortResources(rs)
+	return rs, nil
+}
+
+// parseARN parses the resource ARN.
+// e.g. "arn:aws:ec2:us-west-2:123:vpc/vpc-123"
+// e.g. "arn:aws:elasticloadbalancing:us-west-2:123:loadbalancer/net/name/123"
+func parseARN(s string) (eksconfig.AWSResource, error) {
+	a, err := arn.Parse(s)
+	if err != nil {
+		return eksconfig.AWSResource{}, err
+	}
+	r := eksconfig.AWSResource{Service: a.Service, ARN: s}
+	idx := strings.IndexAny(a.Resource, "/:")
+	if idx == -1 {
+		r.ID = a.Resource
+		return r, nil
+	}
+	r.Type, r.ID = a.Resource[:idx], a.Resource[idx+1:]
+	return r, nil
+}
+
+func isDeleted(cfg Config, r eksconfig.AWSResource) (bool, error) {
+	if r.Service != "ec2" {
+		return false, nil
+	}
+	switch r.Type {
+	case "instance":
+		out, err := cfg.EC2APIV2.DescribeInstances(context.Background(), &aws_ec2_v2.DescribeInstancesInput{InstanceIds: []string{r.ID}})
+		if err != nil {
+			return strings.Contains(err.Error(), "NotFound"), err
+		}
+		for _, rv := range out.Reservations {
+			for _, v := range rv.Instances {
+				if v.State == nil || v.State.Name != aws_ec2_v2_types.InstanceStateNameTerminated {
+					return false, nil
+				}
+			}
+		}
+		return true, nil
+	case "natgateway":
+		out, err := cfg.EC2APIV2.DescribeNatGateways(context.Background(), &aws_ec2_v2.DescribeNatGatewaysInput{NatGatewayIds: []string{r.ID}})
+		if err != nil {
+			return strings.Contains(err.Error(), "NotFound"), err
+		}
+		for _, v := range out.NatGateways {
+			if v.State != aws_ec2_v2_types.NatGatewayStateDeleted {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// deleteOrder is the order of deletion to resolve the dependencies
+// (e.g. instances before security groups, subnets before VPC).
+var deleteOrder = []string{
+	"ec2/instance",
+	"elasticloadbalancing/loadbalancer",
+	"elasticloadbalancing/targetgroup",
+	"cloudformation/stack",
+	"ec2/natgateway",
+	"ec2/elastic-ip",
+	"ec2/network-interface",
+	"ec2/vpc-endpoint",
+	"ec2/security-group",
+	"ec2/subnet",
+	"ec2/route-table",
+	"ec2/internet-gateway",
+	"ec2/egress-only-internet-gateway",
+	"ec2/vpc",
+	"ec2/volume",
+	"ec2/launch-template",
+	"iam/role",
+	"iam/policy",
+	"s3/bucket",
+}
+
+func sortResources(rs []eksconfig.AWSResource) {
+	rank := func(r eksconfig.AWSResource) int {
+		for i, v := range deleteOrder {
+			if v == r.Service+"/"+r.Type {
+				return i
+			}
+		}
+		return len(deleteOrder)
+	}
+	sort.SliceStable(rs, func(i, j int) bool {
+		ri, rj := rank(rs[i]), rank(rs[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return rs[i].ID < rs[j].ID
+	})
+}
+
+// Delete deletes the resources in the dependency order, on a best-effort basis,
+// and returns the errors of the resources that failed to delete.
+// Deletion may need to be retried, since some resources
+// (e.g. NAT gateways) are deleted asynchronously.
+func Delete(cfg Config, rs []eksconfig.AWSResource) (errs []error) {
+	rs = append([]eksconfig.AWSResource(nil), rs...)
+	sortResources(rs)
+	for _, r := range rs {
+		cfg.Logger.Info("deleting leaked resource", zap.String("service", r.Service), zap.String("type", r.Type), zap.String("id", r.ID))
+		if err := deleteResource(cfg, r); err != nil {
+			cfg.Logger.Warn("failed to delete leaked resource", zap.String("id", r.ID), zap.Error(err))
+			errs = append(errs, fmt.Errorf("%s/%s %q (%v)", r.Service, r.Type, r.ID, err))
+		}
+	}
+	return errs
+}
+
+func deleteResource(cfg Config, r eksconfig.AWSResource) (err error) {
+	switch r.Service + "/" + r.Type {
+	case "ec2/instance":
+		_, err = cfg.EC2APIV2.TerminateInstances(context.Background(), &aws_ec2_v2.TerminateInstancesInput{InstanceIds: []string{r.ID}})
+	case "elasticloadbalancing/loadbalancer":
+		err = deleteLoadBalancer(cfg, r)
+	case "elasticloadbalancing/targetgroup":
+		_, err = cfg.ELBV2API.DeleteTargetGroup(&elbv2.DeleteTargetGroupInput{TargetGroupArn: aws.String(r.ARN)})
+	case "cloudformation/stack":
+		_, err = cfg.CFNAPIV2.DeleteStack(context.Background(), &aws_cfn_v2.DeleteStackInput{StackName: aws.String(r.ARN)})
+	case "ec2/natgateway":
+		_, err = cfg.EC2APIV2.DeleteNatGateway(context.Background(), &aws_ec2_v2.DeleteNatGatewayInput{NatGatewayId: aws_v2.String(r.ID)})
+	case "ec2/elastic-ip":
+		_, err = cfg.EC2APIV2.ReleaseAddress(context.Background(), &aws_ec2_v2.ReleaseAddressInput{AllocationId: aws_v2.String(r.ID)})
+	case "ec2/network-interface":
+		_, err = cfg.EC2APIV2.DeleteNetworkInterface(context.Background(), &aws_ec2_v2.DeleteNetworkInterfaceInput{NetworkInterfaceId: aws_v2.String(r.ID)})
+	case "ec2/vpc-endpoint":
+		_, err = cfg.EC2APIV2.DeleteVpcEndpoints(context.Background(), &aws_ec2_v2.DeleteVpcEndpointsInput{VpcEndpointIds: []string{r.ID}})
+	case "ec2/security-group":
+		_, err = cfg.EC2APIV2.DeleteSecurityGroup(context.Background(), &aws_ec2_v2.DeleteSecurityGroupInput{GroupId: aws_v2.String(r.ID)})
+	case "ec2/subnet":
+		_, err = cfg.EC2APIV2.DeleteSubnet(context.Background(), &aws_ec2_v2.DeleteSubnetInput{SubnetId: aws_v2.String(r.ID)})
+	case "ec2/route-table":
+		_, err = cfg.EC2APIV2.DeleteRouteTable(context.Background(), &aws_ec2_v2.DeleteRouteTableInput{RouteTableId: aws_v2.String(r.ID)})
+	case "ec2/internet-gateway":
+		err = deleteInternetGateway(cfg, r.ID)
+	case "ec2/egress-only-internet-gateway":
+		_, err = cfg.EC2APIV2.DeleteEgressOnlyInternetGateway(context.Background(), &aws_ec2_v2.DeleteEgressOnlyInternetGatewayInput{EgressOnlyInternetGatewayId: aws_v2.String(r.ID)})
+	case "ec2/vpc":
+		_, err = cfg.EC2APIV2.DeleteVpc(context.Background(), &aws_ec2_v2.DeleteVpcInput{VpcId: aws_v2.String(r.ID)})
+	case "ec2/volume":
+		_, err = cfg.EC2APIV2.DeleteVolume(context.Background(), &aws_ec2_v2.DeleteVolumeInput{VolumeId: aws_v2.String(r.ID)})
+	case "ec2/launch-template":
+		_, err = cfg.EC2APIV2.DeleteLaunchTemplate(context.Background(), &aws_ec2_v2.DeleteLaunchTemplateInput{LaunchTemplateId: aws_v2.String(r.ID)})
+	case "iam/role":
+		err = deleteRole(cfg, r.ID)
+	case "iam/policy":
+		err = deletePolicy(cfg, r.ARN)
+	case "s3/bucket":
+		if err = aws_s3.EmptyBucket(cfg.Logger, cfg.S3API, r.ID); err == nil {
+			err = aws_s3.DeleteBucket(cfg.Logger, cfg.S3API, r.ID)
+		}
+	default:
+		err = errors.New("unsupported resource type; delete manually")
+	}
+	return err
+}
+
+func deleteInternetGateway(cfg Config, id string) error {
+	out, err := cfg.EC2APIV2.DescribeInternetGateways(context.Background(), &aws_ec2_v2.DescribeInternetGatewaysInput{InternetGatewayIds: []string{id}})
+	if err != nil {
+		return err
+	}
+	for _, v := range out.InternetGateways {
+		for _, a := range v.Attachments {
+			if _, err = cfg.EC2APIV2.DetachInternetGateway(context.Background(), &aws_ec2_v2.DetachInternetGatewayInput{
+				InternetGatewayId: aws_v2.String(id),
+				VpcId:             a.VpcId,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = cfg.EC2APIV2.DeleteInternetGateway(context.Background(), &aws_ec2_v2.DeleteInternetGatewayInput{InternetGatewayId: aws_v2.String(id)})
+	return err
+}
+
+func deleteRole(cfg Config, name string) error {
+	attached, err := cfg.IAMAPI.ListAttachedRolePolicies(&iam.ListAttachedRolePoliciesInput{RoleName: aws.String(name)})
+	if err != nil {
+		return err
+	}
+	for _, p := range attached.AttachedPolicies {
+		if _, err = cfg.IAMAPI.DetachRolePolicy(&iam.DetachRolePolicyInput{RoleName: aws.String(name), PolicyArn: p.PolicyArn}); err != nil {
+			return err
+		}
+	}
+	inline, err := cfg.IAMAPI.ListRolePolicies(&iam.ListRolePoliciesInput{RoleName: aws.String(name)})
+	if err != nil {
+		return err
+	}
+	for _, p := range inline.PolicyNames {
+		if _, err = cfg.IAMAPI.DeleteRolePolicy(&iam.DeleteRolePolicyInput{RoleName: aws.String(name), PolicyName: p}); err != nil {
+			return err
+		}
+	}
+	profiles, err := cfg.IAMAPI.ListInstanceProfilesForRole(&iam.ListInstanceProfilesForRoleInput{RoleName: aws.String(name)})
+	if err != nil {
+		return err
+	}
+	for _, p := range profiles.InstanceProfiles {
+		if _, err = cfg.IAMAPI.RemoveRoleFromInstanceProfile(&iam.RemoveRoleFromInstanceProfileInput{
+			RoleName:            aws.String(name),
+			InstanceProfileName: p.InstanceProfileName,
+		}); err != nil {
+			return err
+		}
+		if _, err = cfg.IAMAPI.DeleteInstanceProfile(&iam.DeleteInstanceProfileInput{InstanceProfileName: p.InstanceProfileName}); err != nil {
+			return err
+		}
+	}
+	_, err = cfg.IAMAPI.DeleteRole(&iam.DeleteRoleInput{RoleName: aws.String(name)})
+	return err
+}
+
+func deletePolicy(cfg Config, policyARN string) error {
+	versions, err := cfg.IAMAPI.ListPolicyVersions(&iam.ListPolicyVersionsInput{PolicyArn: aws.String(policyARN)})
+	if err != nil {
+		return err
+	}
+	for _, v := range versions.Versions {
+		if aws.BoolValue(v.IsDefaultVersion) {
+			continue
+		}
+		if _, err = cfg.IAMAPI.DeletePolicyVersion(&iam.DeletePolicyVersionInput{PolicyArn: aws.String(policyARN), VersionId: v.VersionId}); err != nil {
+			return err
+		}
+	}
+	_, err = cfg.IAMAPI.DeletePolicy(&iam.DeletePolicyInput{PolicyArn: aws.String(policyARN)})
+	return err
+}
+
+// Table returns the resources in table format.
+func Table(rs []eksconfig.AWSResource) string {
+	buf := bytes.NewBuffer(nil)
+	tb := tablewriter.NewWriter(buf)
+	tb.SetAutoWrapText(false)
+	tb.SetAlignment(tablewriter.ALIGN_LEFT)
+	tb.SetCenterSeparator("*")
+	tb.SetHeader([]string{"service", "type", "id"})
+	for _, r := range rs {
+		tb.Append([]string{r.Service, r.Type, r.ID})
+	}
+	tb.Render()
+	return buf.String()
+}
@@ -0,0 +1,99 @@
+package leak
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	smithy "github.com/aws/smithy-go"
+)
+
+func TestParseARN(t *testing.T) {
+	tt := []struct {
+		arn string
+		exp eksconfig.AWSResource
+	}{
+		{
+			arn: "arn:aws:ec2:us-west-2:123456789012:vpc/vpc-0123",
+			exp: eksconfig.AWSResource{Service: "ec2", Type: "vpc", ID: "vpc-0123"},
+		},
+		{
+			arn: "arn:aws:elasticloadbalancing:us-west-2:123456789012:loadbalancer/net/a1b2/0123",
+			exp: eksconfig.AWSResource{Service: "elasticloadbalancing", Type: "loadbalancer", ID: "net/a1b2/0123"},
+		},
+		{
+			arn: "arn:aws:cloudformation:us-west-2:123456789012:stack/test-stack/0123",
+			exp: eksconfig.AWSResource{Service: "cloudformation", Type: "stack", ID: "test-stack/0123"},
+		},
+	}
+	for i, tv := range tt {
+		r, err := parseARN(tv.arn)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		tv.exp.ARN = tv.arn
+		if !reflect.DeepEqual(r, tv.exp) {
+			t.Fatalf("#%d: expected %+v, got %+v", i, tv.exp, r)
+		}
+	}
+	if _, err := parseARN("vpc-0123"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSortResources(t *testing.T) {
+	rs := []eksconfig.AWSResource{
+		{Service: "ec2", Type: "vpc", ID: "vpc-1"},
+		{Service: "iam", Type: "role", ID: "test-role"},
+		{Service: "ec2", Type: "subnet", ID: "subnet-2"},
+		{Service: "ec2", Type: "instance", ID: "i-1"},
+		{Service: "ec2", Type: "subnet", ID: "subnet-1"},
+	}
+	sortResources(rs)
+	var ids []string
+	for _, r := range rs {
+		ids = append(ids, r.ID)
+	}
+	exp := []string{"i-1", "subnet-1", "subnet-2", "vpc-1", "test-role"}
+	if !reflect.DeepEqual(ids, exp) {
+		t.Fatalf("expected %v, got %v", exp, ids)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	var ds []time.Duration
+	for i := 0; i < 6; i++ {
+		ds = append(ds, backoff(10*time.Second, time.Minute, i))
+	}
+	exp := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute, time.Minute}
+	if !reflect.DeepEqual(ds, exp) {
+		t.Fatalf("expected %v, got %v", exp, ds)
+	}
+}
+
+func TestIsDependencyViolation(t *testing.T) {
+	if !isDependencyViolation(awserr.New("DependencyViolation", "resource sg-1 has a dependent object", nil)) {
+		t.Fatal("expected dependency violation")
+	}
+	if isDependencyViolation(awserr.New("InvalidGroup.NotFound", "", nil)) {
+		t.Fatal("unexpected dependency violation")
+	}
+	if !isNotFound(awserr.New("InvalidGroup.NotFound", "", nil)) {
+		t.Fatal("expected not found")
+	}
+
+	// the v2 EC2 client returns the wrapped smithy errors
+	v2Err := fmt.Errorf("operation error EC2: DeleteSecurityGroup, %w", &smithy.GenericAPIError{Code: "DependencyViolation"})
+	if !isDependencyViolation(v2Err) {
+		t.Fatal("expected dependency violation")
+	}
+	if !isNotFound(&smithy.GenericAPIError{Code: "InvalidNetworkInterfaceID.NotFound"}) {
+		t.Fatal("expected not found")
+	}
+	if isNotFound(v2Err) {
+		t.Fatal("unexpected not found")
+	}
+}
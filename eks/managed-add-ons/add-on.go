@@ -0,0 +1,294 @@
+package managedaddons
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"go.uber.org/zap"
+)
+
+func (ts *tester) createAddOn(name string) error {
+	cur := ts.cfg.EKSConfig.AddOnManagedAddOns.AddOns[name]
+	ts.cfg.Logger.Info("creating EKS managed add-on",
+		zap.String("add-on", name),
+		zap.String("add-on-version", cur.Version),
+	)
+	input := &eks.CreateAddonInput{
+		ClusterName: aws.String(ts.cfg.EKSConfig.Name),
+		AddonName:   aws.String(name),
+		// take over the self-managed add-on installed with the cluster
+		ResolveConflicts: aws.String(eks.ResolveConflictsOverwrite),
+	}
+	if cur.Version != "" {
+		input.AddonVersion = aws.String(cur.Version)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.EKSAPI.CreateAddonWithContext(ctx, input, withConfigurationValues(cur.ConfigurationValues))
+	cancel()
+	if err != nil {
+		if !isErrCode(err, eks.ErrCodeResourceInUseException) {
+			return fmt.Errorf("failed to create add-on %q (%v)", name, err)
+		}
+		ts.cfg.Logger.Info("EKS managed add-on already exists", zap.String("add-on", name))
+		if cur.Version != "" {
+			if err = ts.update(name, cur.Version); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = ts.waitActive(name, 20*time.Minute); err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("created EKS managed add-on", zap.String("add-on", name))
+	return nil
+}
+
+// updateAddOn updates the add-on to "UpdateVersion", if any.
+func (ts *tester) updateAddOn(name string) error {
+	cur := ts.cfg.EKSConfig.AddOnManagedAddOns.AddOns[name]
+	version := cur.UpdateVersion
+	switch version {
+	case "":
+		ts.cfg.Logger.Info("skipping EKS managed add-on update", zap.String("add-on", name))
+		return nil
+	case eksconfig.ManagedAddOnLatestVersion:
+		version = cur.LatestVersion
+	}
+	if version == cur.InstalledVersion {
+		ts.cfg.Logger.Info("EKS managed add-on already at update version",
+			zap.String("add-on", name),
+			zap.String("add-on-version", version),
+		)
+		return nil
+	}
+
+	if err := ts.update(name, version); err != nil {
+		return err
+	}
+	if err := ts.waitActive(name, 20*time.Minute); err != nil {
+		return err
+	}
+	if err := ts.checkHealth(name); err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("updated EKS managed add-on", zap.String("add-on", name))
+	return nil
+}
+
+// update requests the add-on version update and waits for the update to complete.
+func (ts *tester) update(name string, version string) error {
+	cur := ts.cfg.EKSConfig.AddOnManagedAddOns.AddOns[name]
+	ts.cfg.Logger.Info("updating EKS managed add-on",
+		zap.String("add-on", name),
+		zap.String("from-version", cur.InstalledVersion),
+		zap.String("to-version", version),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	out, err := ts.cfg.EKSAPI.UpdateAddonWithContext(
+		ctx,
+		&eks.UpdateAddonInput{
+			ClusterName:      aws.String(ts.cfg.EKSConfig.Name),
+			AddonName:        aws.String(name),
+			AddonVersion:     aws.String(version),
+			ResolveConflicts: aws.String(eks.ResolveConflictsOverwrite),
+		},
+		withConfigurationValues(cur.ConfigurationValues),
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to update add-on %q to %q (%v)", name, version, err)
+	}
+	updateID := aws.StringValue(out.Update.Id)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Minute)
+	defer cancel()
+	for {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("add-on update aborted")
+		case <-ctx.Done():
+			return fmt.Errorf("add-on %q update %q timed out (%v)", name, updateID, ctx.Err())
+		case <-time.After(15 * time.Second):
+		}
+
+		dout, err := ts.cfg.EKSAPI.DescribeUpdate(&eks.DescribeUpdateInput{
+			Name:      aws.String(ts.cfg.EKSConfig.Name),
+			AddonName: aws.String(name),
+			UpdateId:  aws.String(updateID),
+		})
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe update", zap.Error(err))
+			continue
+		}
+		status := aws.StringValue(dout.Update.Status)
+		ts.cfg.Logger.Info("polled add-on update",
+			zap.String("add-on", name),
+			zap.String("update-id", updateID),
+			zap.String("status", status),
+		)
+		switch status {
+		case eks.UpdateStatusSuccessful:
+			return nil
+		case eks.UpdateStatusFailed, eks.UpdateStatusCancelled:
+			return fmt.Errorf("add-on %q update %q %s (errors %+v)", name, updateID, status, dout.Update.Errors)
+		}
+	}
+}
+
+// waitActive waits for the add-on to be active without health issues,
+// and records the installed version.
+func (ts *tester) waitActive(name string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	issues := ""
+	for {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("add-on wait aborted")
+		case <-ctx.Done():
+			return fmt.Errorf("add-on %q not active (%v, issues %s)", name, ctx.Err(), issues)
+		case <-time.After(15 * time.Second):
+		}
+
+		out, err := ts.cfg.EKSAPI.DescribeAddon(&eks.DescribeAddonInput{
+			ClusterName: aws.String(ts.cfg.EKSConfig.Name),
+			AddonName:   aws.String(name),
+		})
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe add-on", zap.Error(err))
+			continue
+		}
+		status := aws.StringValue(out.Addon.Status)
+		issues = ""
+		if out.Addon.Health != nil && len(out.Addon.Health.Issues) > 0 {
+			issues = fmt.Sprintf("%+v", out.Addon.Health.Issues)
+		}
+		ts.cfg.Logger.Info("polled add-on",
+			zap.String("add-on", name),
+			zap.String("add-on-version", aws.StringValue(out.Addon.AddonVersion)),
+			zap.String("status", status),
+			zap.String("issues", issues),
+		)
+		switch status {
+		case eks.AddonStatusActive:
+			if issues != "" {
+				continue
+			}
+			cur := ts.cfg.EKSConfig.AddOnManagedAddOns.AddOns[name]
+			cur.InstalledVersion = aws.StringValue(out.Addon.AddonVersion)
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				ts.cfg.EKSConfig.AddOnManagedAddOns.AddOns[name] = cur
+			})
+			return nil
+		case eks.AddonStatusCreateFailed:
+			return fmt.Errorf("add-on %q creation failed (issues %s)", name, issues)
+		}
+	}
+}
+
+func (ts *tester) deleteAddOn(name string) error {
+	cur := ts.cfg.EKSConfig.AddOnManagedAddOns.AddOns[name]
+	ts.cfg.Logger.Info("deleting EKS managed add-on",
+		zap.String("add-on", name),
+		zap.Bool("preserve", cur.Preserve),
+	)
+	_, err := ts.cfg.EKSAPI.DeleteAddon(&eks.DeleteAddonInput{
+		ClusterName: aws.String(ts.cfg.EKSConfig.Name),
+		AddonName:   aws.String(name),
+		Preserve:    aws.Bool(cur.Preserve),
+	})
+	if err != nil {
+		if isErrCode(err, eks.ErrCodeResourceNotFoundException) {
+			ts.cfg.Logger.Info("EKS managed add-on already deleted", zap.String("add-on", name))
+			return nil
+		}
+		return fmt.Errorf("failed to delete add-on %q (%v)", name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	defer cancel()
+	for {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("add-on deletion aborted")
+		case <-ctx.Done():
+			return fmt.Errorf("add-on %q deletion timed out (%v)", name, ctx.Err())
+		case <-time.After(15 * time.Second):
+		}
+
+		out, err := ts.cfg.EKSAPI.DescribeAddon(&eks.DescribeAddonInput{
+			ClusterName: aws.String(ts.cfg.EKSConfig.Name),
+			AddonName:   aws.String(name),
+		})
+		if err != nil {
+			if isErrCode(err, eks.ErrCodeResourceNotFoundException) {
+				ts.cfg.Logger.Info("deleted EKS managed add-on", zap.String("add-on", name))
+				return nil
+			}
+			ts.cfg.Logger.Warn("failed to describe add-on", zap.Error(err))
+			continue
+		}
+		status := aws.StringValue(out.Addon.Status)
+		ts.cfg.Logger.Info("polled add-on", zap.String("add-on", name), zap.String("status", status))
+		if status == eks.AddonStatusDeleteFailed {
+			return fmt.Errorf("add-on %q deletion failed", name)
+		}
+	}
+}
+
+// withConfigurationValues sets "configurationValues" in the request body,
+// which is not modeled by the aws-sdk-go version in "go.mod".
+// ref. https://docs.aws.amazon.com/eks/latest/APIReference/API_CreateAddon.html
+// ref. https://docs.aws.amazon.com/eks/latest/APIReference/API_UpdateAddon.html
+func withConfigurationValues(values string) request.Option {
+	return func(r *request.Request) {
+		if values == "" {
+			return
+		}
+		r.Handlers.Build.PushBackNamed(request.NamedHandler{
+			Name: "aws-k8s-tester.ConfigurationValues",
+			Fn: func(r *request.Request) {
+				if r.Error != nil {
+					return
+				}
+				body := make(map[string]interface{})
+				if r.Body != nil {
+					b, err := ioutil.ReadAll(r.Body)
+					if err != nil {
+						r.Error = err
+						return
+					}
+					if len(bytes.TrimSpace(b)) > 0 {
+						if err = json.Unmarshal(b, &body); err != nil {
+							r.Error = err
+							return
+						}
+					}
+				}
+				body["configurationValues"] = values
+				b, err := json.Marshal(body)
+				if err != nil {
+					r.Error = err
+					return
+				}
+				r.SetBufferBody(b)
+			},
+		})
+	}
+}
+
+func isErrCode(err error, code string) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == code
+}
@@ -0,0 +1,41 @@
+package managedaddons
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/eks"
+)
+
+func Test_withConfigurationValues(t *testing.T) {
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Credentials: credentials.AnonymousCredentials,
+	}))
+	req, _ := eks.New(sess).CreateAddonRequest(&eks.CreateAddonInput{
+		ClusterName: aws.String("test"),
+		AddonName:   aws.String("coredns"),
+	})
+	req.ApplyOptions(withConfigurationValues(`{"replicaCount":3}`))
+	if err := req.Build(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(req.GetBody())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := make(map[string]interface{})
+	if err = json.Unmarshal(b, &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["addonName"] != "coredns" {
+		t.Fatalf("unexpected addonName %v", body["addonName"])
+	}
+	if body["configurationValues"] != `{"replicaCount":3}` {
+		t.Fatalf("unexpected configurationValues %v", body["configurationValues"])
+	}
+}
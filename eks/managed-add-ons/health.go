@@ -0,0 +1,97 @@
+package managedaddons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// workload is the kube-system workload that runs the add-on software.
+type workload struct {
+	daemonSet  string
+	deployment string
+}
+
+// workloads maps the add-on names to their workloads,
+// to check readiness beyond the add-on health reported by EKS.
+var workloads = map[string]workload{
+	"vpc-cni":            {daemonSet: "aws-node"},
+	"kube-proxy":         {daemonSet: "kube-proxy"},
+	"coredns":            {deployment: "coredns"},
+	"aws-ebs-csi-driver": {deployment: "ebs-csi-controller"},
+}
+
+// checkHealth checks the add-on health after each operation.
+func (ts *tester) checkHealth(name string) error {
+	if err := ts.describeVersions(name); err != nil {
+		return err
+	}
+	cur := ts.cfg.EKSConfig.AddOnManagedAddOns.AddOns[name]
+	if !cur.Compatible {
+		return fmt.Errorf("add-on %q version %q does not support cluster version %q", name, cur.InstalledVersion, ts.cfg.EKSConfig.Version)
+	}
+	return ts.checkWorkload(name)
+}
+
+// checkWorkload waits for the add-on workload to be rolled out and ready.
+func (ts *tester) checkWorkload(name string) error {
+	w, ok := workloads[name]
+	if !ok {
+		ts.cfg.Logger.Info("skipping add-on workload check; unknown workload", zap.String("add-on", name))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("checking add-on workload", zap.String("add-on", name))
+	cli := ts.cfg.K8SClient.KubernetesClientSet()
+	waitStart := time.Now()
+	for time.Since(waitStart) < 10*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("add-on workload check aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		var ready bool
+		var desired, updated, available int32
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		switch {
+		case w.daemonSet != "":
+			ds, err := cli.AppsV1().DaemonSets("kube-system").Get(ctx, w.daemonSet, metav1.GetOptions{})
+			cancel()
+			if err != nil {
+				ts.cfg.Logger.Warn("failed to get DaemonSet", zap.String("name", w.daemonSet), zap.Error(err))
+				continue
+			}
+			desired, updated, available = ds.Status.DesiredNumberScheduled, ds.Status.UpdatedNumberScheduled, ds.Status.NumberAvailable
+			ready = ds.Status.ObservedGeneration >= ds.Generation
+		default:
+			dp, err := cli.AppsV1().Deployments("kube-system").Get(ctx, w.deployment, metav1.GetOptions{})
+			cancel()
+			if err != nil {
+				ts.cfg.Logger.Warn("failed to get Deployment", zap.String("name", w.deployment), zap.Error(err))
+				continue
+			}
+			desired, updated, available = dp.Status.Replicas, dp.Status.UpdatedReplicas, dp.Status.AvailableReplicas
+			if dp.Spec.Replicas != nil {
+				desired = *dp.Spec.Replicas
+			}
+			ready = dp.Status.ObservedGeneration >= dp.Generation
+		}
+		ready = ready && desired > 0 && updated == desired && available == desired
+		ts.cfg.Logger.Info("polled add-on workload",
+			zap.String("add-on", name),
+			zap.Int32("desired", desired),
+			zap.Int32("updated", updated),
+			zap.Int32("available", available),
+			zap.Bool("ready", ready),
+		)
+		if ready {
+			return nil
+		}
+	}
+	return fmt.Errorf("add-on %q workload not ready", name)
+}
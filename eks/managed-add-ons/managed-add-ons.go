@@ -0,0 +1,184 @@
+// Package managedaddons installs, updates, and removes EKS managed
+// add-ons (e.g. "vpc-cni", "coredns", "kube-proxy") with the EKS Addons API,
+// and reports their version skew against the control plane.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/eks-add-ons.html
+package managedaddons
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"go.uber.org/zap"
+)
+
+// Config defines EKS managed add-ons configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+	EKSAPI    eksiface.EKSAPI
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new EKS managed add-ons tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:      "managed-add-ons",
+		Exclusive: true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				EKSAPI:    cfg.EKSAPI,
+			}), cfg.EKSConfig.IsEnabledAddOnManagedAddOns)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnManagedAddOns() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnManagedAddOns.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnManagedAddOns.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnManagedAddOns.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	for _, name := range ts.names() {
+		if err = ts.describeVersions(name); err != nil {
+			return err
+		}
+		if err = ts.createAddOn(name); err != nil {
+			return err
+		}
+		if err = ts.checkHealth(name); err != nil {
+			return err
+		}
+		if err = ts.updateAddOn(name); err != nil {
+			return err
+		}
+	}
+	ts.reportSkew()
+
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnManagedAddOns() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnManagedAddOns.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnManagedAddOns.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+	for _, name := range ts.names() {
+		if err := ts.deleteAddOn(name); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		// preserved add-on software must keep running without the managed add-on
+		if ts.cfg.EKSConfig.AddOnManagedAddOns.AddOns[name].Preserve {
+			if err := ts.checkWorkload(name); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnManagedAddOns.Created = false
+	})
+	return nil
+}
+
+// names returns the add-on names in a deterministic order.
+func (ts *tester) names() []string {
+	names := make([]string, 0, len(ts.cfg.EKSConfig.AddOnManagedAddOns.AddOns))
+	for name := range ts.cfg.EKSConfig.AddOnManagedAddOns.AddOns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// reportSkew prints the installed add-on versions against
+// the versions supported by the cluster version.
+func (ts *tester) reportSkew() {
+	fmt.Fprintf(ts.cfg.LogWriter, "\nEKS managed add-on versions (cluster version %s):\n", ts.cfg.EKSConfig.Version)
+	for _, name := range ts.names() {
+		cur := ts.cfg.EKSConfig.AddOnManagedAddOns.AddOns[name]
+		skew := "up-to-date"
+		switch {
+		case !cur.Compatible:
+			skew = "INCOMPATIBLE"
+		case cur.InstalledVersion != cur.LatestVersion:
+			skew = "behind latest"
+		}
+		fmt.Fprintf(ts.cfg.LogWriter, "%-24s installed %-24s default %-24s latest %-24s %s\n",
+			name, cur.InstalledVersion, cur.DefaultVersion, cur.LatestVersion, skew)
+		ts.cfg.Logger.Info("EKS managed add-on version skew",
+			zap.String("add-on", name),
+			zap.String("cluster-version", ts.cfg.EKSConfig.Version),
+			zap.String("installed-version", cur.InstalledVersion),
+			zap.String("default-version", cur.DefaultVersion),
+			zap.String("latest-version", cur.LatestVersion),
+			zap.Bool("compatible", cur.Compatible),
+		)
+	}
+	fmt.Fprintf(ts.cfg.LogWriter, "\n")
+}
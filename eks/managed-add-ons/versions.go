@@ -0,0 +1,113 @@
+package managedaddons
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"go.uber.org/zap"
+)
+
+// describeVersions records the default and latest add-on versions
+// for the cluster version, and whether the installed version is compatible.
+func (ts *tester) describeVersions(name string) error {
+	var infos []*eks.AddonInfo
+	err := ts.cfg.EKSAPI.DescribeAddonVersionsPages(
+		&eks.DescribeAddonVersionsInput{
+			AddonName:         aws.String(name),
+			KubernetesVersion: aws.String(ts.cfg.EKSConfig.Version),
+		},
+		func(out *eks.DescribeAddonVersionsOutput, lastPage bool) bool {
+			infos = append(infos, out.Addons...)
+			return true
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to describe add-on %q versions (%v)", name, err)
+	}
+
+	cur := ts.cfg.EKSConfig.AddOnManagedAddOns.AddOns[name]
+	defaultVersion, latestVersion, versions := summarizeVersions(infos, ts.cfg.EKSConfig.Version)
+	if len(versions) == 0 {
+		return fmt.Errorf("no add-on %q version supports cluster version %q", name, ts.cfg.EKSConfig.Version)
+	}
+	cur.DefaultVersion, cur.LatestVersion = defaultVersion, latestVersion
+	cur.Compatible = false
+	for _, v := range versions {
+		if v == cur.InstalledVersion {
+			cur.Compatible = true
+			break
+		}
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnManagedAddOns.AddOns[name] = cur
+	})
+
+	ts.cfg.Logger.Info("described add-on versions",
+		zap.String("add-on", name),
+		zap.String("default-version", defaultVersion),
+		zap.String("latest-version", latestVersion),
+		zap.Int("versions", len(versions)),
+	)
+	return nil
+}
+
+// summarizeVersions returns the default version, the latest version, and
+// all the versions supporting the cluster version.
+func summarizeVersions(infos []*eks.AddonInfo, clusterVersion string) (defaultVersion string, latestVersion string, versions []string) {
+	for _, info := range infos {
+		for _, v := range info.AddonVersions {
+			version := aws.StringValue(v.AddonVersion)
+			for _, c := range v.Compatibilities {
+				if aws.StringValue(c.ClusterVersion) != clusterVersion {
+					continue
+				}
+				versions = append(versions, version)
+				if aws.BoolValue(c.DefaultVersion) {
+					defaultVersion = version
+				}
+				if latestVersion == "" || compareVersions(version, latestVersion) > 0 {
+					latestVersion = version
+				}
+				break
+			}
+		}
+	}
+	return defaultVersion, latestVersion, versions
+}
+
+// compareVersions compares add-on versions (e.g. "v1.12.6-eksbuild.2"),
+// returning a negative number if a is older than b, zero if equal,
+// and a positive number if a is newer than b.
+func compareVersions(a string, b string) int {
+	na, nb := versionNumbers(a), versionNumbers(b)
+	for i := 0; i < len(na) || i < len(nb); i++ {
+		var x, y int
+		if i < len(na) {
+			x = na[i]
+		}
+		if i < len(nb) {
+			y = nb[i]
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// versionNumbers returns all numbers in the version,
+// e.g. "v1.12.6-eksbuild.2" returns [1 12 6 2].
+func versionNumbers(version string) (ns []int) {
+	fields := strings.FieldsFunc(version, func(r rune) bool { return r < '0' || r > '9' })
+	for _, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			continue
+		}
+		ns = append(ns, n)
+	}
+	return ns
+}
@@ -0,0 +1,76 @@
+package managedaddons
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+)
+
+func Test_summarizeVersions(t *testing.T) {
+	infos := []*eks.AddonInfo{
+		{
+			AddonName: aws.String("vpc-cni"),
+			AddonVersions: []*eks.AddonVersionInfo{
+				{
+					AddonVersion: aws.String("v1.12.6-eksbuild.2"),
+					Compatibilities: []*eks.Compatibility{
+						{ClusterVersion: aws.String("1.27"), DefaultVersion: aws.Bool(false)},
+						{ClusterVersion: aws.String("1.26"), DefaultVersion: aws.Bool(false)},
+					},
+				},
+				{
+					AddonVersion: aws.String("v1.12.10-eksbuild.1"),
+					Compatibilities: []*eks.Compatibility{
+						{ClusterVersion: aws.String("1.26"), DefaultVersion: aws.Bool(false)},
+					},
+				},
+				{
+					AddonVersion: aws.String("v1.11.4-eksbuild.1"),
+					Compatibilities: []*eks.Compatibility{
+						{ClusterVersion: aws.String("1.26"), DefaultVersion: aws.Bool(true)},
+					},
+				},
+				{
+					AddonVersion: aws.String("v1.15.0-eksbuild.2"),
+					Compatibilities: []*eks.Compatibility{
+						{ClusterVersion: aws.String("1.28"), DefaultVersion: aws.Bool(true)},
+					},
+				},
+			},
+		},
+	}
+	defaultVersion, latestVersion, versions := summarizeVersions(infos, "1.26")
+	if defaultVersion != "v1.11.4-eksbuild.1" {
+		t.Fatalf("unexpected default version %q", defaultVersion)
+	}
+	if latestVersion != "v1.12.10-eksbuild.1" {
+		t.Fatalf("unexpected latest version %q", latestVersion)
+	}
+	if !reflect.DeepEqual(versions, []string{"v1.12.6-eksbuild.2", "v1.12.10-eksbuild.1", "v1.11.4-eksbuild.1"}) {
+		t.Fatalf("unexpected versions %v", versions)
+	}
+}
+
+func Test_compareVersions(t *testing.T) {
+	tt := []struct {
+		a, b string
+		cmp  int
+	}{
+		{"v1.12.6-eksbuild.2", "v1.12.6-eksbuild.2", 0},
+		{"v1.12.6-eksbuild.2", "v1.12.6-eksbuild.10", -1},
+		{"v1.12.10-eksbuild.1", "v1.12.6-eksbuild.2", 1},
+		{"v1.9.3-eksbuild.3", "v1.10.1-eksbuild.1", -1},
+		{"v1.2.0", "v1.2.0-eksbuild.1", -1},
+	}
+	for i, tv := range tt {
+		cmp := compareVersions(tv.a, tv.b)
+		switch {
+		case tv.cmp == 0 && cmp != 0,
+			tv.cmp < 0 && cmp >= 0,
+			tv.cmp > 0 && cmp <= 0:
+			t.Fatalf("#%d: compareVersions(%q, %q) expected %d, got %d", i, tv.a, tv.b, tv.cmp, cmp)
+		}
+	}
+}
@@ -5,6 +5,7 @@ package metricsserver
 import (
 	"fmt"
 
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
 	"github.com/aws/aws-k8s-tester/eksconfig"
 	k8sclient "github.com/aws/aws-k8s-tester/pkg/k8s-client"
 	gotemplate "github.com/aws/aws-k8s-tester/pkg/util"
@@ -16,6 +17,26 @@ type MetricsServer struct {
 	Config    *eksconfig.Config
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "metrics-server",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			if cfg.EKSConfig.Spec.MetricsServer != nil {
+				return &MetricsServer{Config: cfg.EKSConfig, K8sClient: cfg.K8SClient}
+			}
+			// "AddOnMetricsServer" installs with the tester,
+			// under the same name for the dependent add-ons
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+			}), cfg.EKSConfig.IsEnabledAddOnMetricsServer)
+		},
+	})
+}
+
 // IsEnabled returns true if enabled
 func (c *MetricsServer) IsEnabled() bool {
 	return c.Config.Spec.MetricsServer != nil
@@ -30,12 +51,14 @@ func (c *MetricsServer) Apply() (err error) {
 	if err := c.K8sClient.Apply(template.String()); err != nil {
 		return fmt.Errorf("while applying resources, %v", err)
 	}
-	c.Config.Status.MetricsServer = &eksconfig.MetricsServerStatus{
-		AddonStatus: eksconfig.AddonStatus{
-			Installed: true,
-			Ready:     true,
-		},
-	}
+	c.Config.RecordAddOnStatus(func() {
+		c.Config.Status.MetricsServer = &eksconfig.MetricsServerStatus{
+			AddonStatus: eksconfig.AddonStatus{
+				Installed: true,
+				Ready:     true,
+			},
+		}
+	})
 	return nil
 }
 
@@ -48,11 +71,13 @@ func (c *MetricsServer) Delete() (err error) {
 	if err := c.K8sClient.Delete(template.String()); err != nil {
 		return fmt.Errorf("while deleting resources, %v", err)
 	}
-	c.Config.Status.MetricsServer = &eksconfig.MetricsServerStatus{
-		AddonStatus: eksconfig.AddonStatus{
-			Installed: false,
-			Ready:     false,
-		},
-	}
+	c.Config.RecordAddOnStatus(func() {
+		c.Config.Status.MetricsServer = &eksconfig.MetricsServerStatus{
+			AddonStatus: eksconfig.AddonStatus{
+				Installed: false,
+				Ready:     false,
+			},
+		}
+	})
 	return nil
 }
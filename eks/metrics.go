@@ -0,0 +1,156 @@
+package eks
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/smithy-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+// the tester metrics are registered with the default registry,
+// along with the add-on metrics, and the SSH metrics (see "ssh" package)
+var (
+	phaseDurationSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "aws_k8s_tester",
+			Subsystem: "eks",
+			Name:      "phase_duration_seconds",
+			Help:      "Duration of the last run of the test phase in seconds.",
+		},
+		[]string{"class", "name"},
+	)
+	phaseFailuresTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "aws_k8s_tester",
+			Subsystem: "eks",
+			Name:      "phase_failures_total",
+			Help:      "Total number of failed test phases.",
+		},
+		[]string{"class", "name"},
+	)
+	phaseRunning = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "aws_k8s_tester",
+			Subsystem: "eks",
+			Name:      "phase_running",
+			Help:      "1 if the test phase is in progress (add-ons may be created concurrently).",
+		},
+		[]string{"class", "name"},
+	)
+	awsAPICallsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "aws_k8s_tester",
+			Subsystem: "aws",
+			Name:      "api_calls_total",
+			Help:      "Total number of AWS API calls, counting the retries as one call.",
+		},
+		[]string{"service", "operation"},
+	)
+	awsAPIErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "aws_k8s_tester",
+			Subsystem: "aws",
+			Name:      "api_errors_total",
+			Help:      "Total number of failed AWS API calls, by error code.",
+		},
+		[]string{"service", "operation", "code"},
+	)
+	awsAPIThrottlesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "aws_k8s_tester",
+			Subsystem: "aws",
+			Name:      "api_throttles_total",
+			Help:      "Total number of throttled AWS API call attempts, counting each retry.",
+		},
+		[]string{"service"},
+	)
+	awsAPIRateLimit = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "aws_k8s_tester",
+			Subsystem: "aws",
+			Name:      "api_rate_limit",
+			Help:      "Client-side rate limit of the AWS API calls in requests per second, after the last throttling.",
+		},
+		[]string{"service"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(phaseDurationSeconds)
+	prometheus.MustRegister(phaseFailuresTotal)
+	prometheus.MustRegister(phaseRunning)
+	prometheus.MustRegister(awsAPICallsTotal)
+	prometheus.MustRegister(awsAPIErrorsTotal)
+	prometheus.MustRegister(awsAPIThrottlesTotal)
+	prometheus.MustRegister(awsAPIRateLimit)
+}
+
+// phaseMetrics exports the test phases recorded in the report.
+type phaseMetrics struct{}
+
+func (phaseMetrics) Started(class string, name string) {
+	phaseRunning.WithLabelValues(class, name).Set(1)
+}
+
+func (phaseMetrics) Finished(class string, name string, took time.Duration, err error) {
+	phaseRunning.DeleteLabelValues(class, name)
+	phaseDurationSeconds.WithLabelValues(class, name).Set(took.Seconds())
+	if err != nil {
+		phaseFailuresTotal.WithLabelValues(class, name).Inc()
+	}
+}
+
+// observeAPICall counts the AWS API calls of both SDK v1 and v2.
+func observeAPICall(service string, operation string, err error) {
+	awsAPICallsTotal.WithLabelValues(service, operation).Inc()
+	if err != nil {
+		awsAPIErrorsTotal.WithLabelValues(service, operation, apiErrorCode(err)).Inc()
+	}
+}
+
+// observeAPIThrottle counts the throttled AWS API call attempts,
+// with the reduced client-side rate limit.
+func observeAPIThrottle(service string, limit float64) {
+	awsAPIThrottlesTotal.WithLabelValues(service).Inc()
+	awsAPIRateLimit.WithLabelValues(service).Set(limit)
+}
+
+func apiErrorCode(err error) string {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code()
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return "Unknown"
+}
+
+// startMetricsServer serves the Prometheus metrics at "/metrics",
+// if "MetricsListenAddress" is set. It fails if the address cannot
+// be listened, rather than silently running without metrics.
+func (ts *Tester) startMetricsServer() error {
+	if ts.cfg.MetricsListenAddress == "" {
+		return nil
+	}
+	ln, err := net.Listen("tcp", ts.cfg.MetricsListenAddress)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	srv := &http.Server{Handler: mux}
+	ts.lg.Info("serving metrics", zap.String("address", ln.Addr().String()))
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			ts.lg.Warn("metrics server failed", zap.Error(err))
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,41 @@
+package eks
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/smithy-go"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPhaseMetrics(t *testing.T) {
+	var m phaseMetrics
+	m.Started("up", "test-phase")
+	if v := testutil.ToFloat64(phaseRunning.WithLabelValues("up", "test-phase")); v != 1 {
+		t.Fatalf("expected running phase, got %v", v)
+	}
+	m.Finished("up", "test-phase", 3*time.Second, errors.New("timed out"))
+	if v := testutil.ToFloat64(phaseDurationSeconds.WithLabelValues("up", "test-phase")); v != 3 {
+		t.Fatalf("expected 3-second phase, got %v", v)
+	}
+	if v := testutil.ToFloat64(phaseFailuresTotal.WithLabelValues("up", "test-phase")); v != 1 {
+		t.Fatalf("expected 1 failure, got %v", v)
+	}
+}
+
+func TestObserveAPICall(t *testing.T) {
+	observeAPICall("ec2", "DescribeVpcs", nil)
+	observeAPICall("ec2", "DescribeVpcs", awserr.New("RequestLimitExceeded", "throttled", nil))
+	observeAPICall("EKS", "DescribeCluster", &smithy.GenericAPIError{Code: "ResourceNotFoundException"})
+	if v := testutil.ToFloat64(awsAPICallsTotal.WithLabelValues("ec2", "DescribeVpcs")); v != 2 {
+		t.Fatalf("expected 2 calls, got %v", v)
+	}
+	if v := testutil.ToFloat64(awsAPIErrorsTotal.WithLabelValues("ec2", "DescribeVpcs", "RequestLimitExceeded")); v != 1 {
+		t.Fatalf("expected 1 throttling error, got %v", v)
+	}
+	if v := testutil.ToFloat64(awsAPIErrorsTotal.WithLabelValues("EKS", "DescribeCluster", "ResourceNotFoundException")); v != 1 {
+		t.Fatalf("expected 1 not found error, got %v", v)
+	}
+}
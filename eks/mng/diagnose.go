@@ -0,0 +1,59 @@
+package mng
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-k8s-tester/eks/diagnose"
+	"github.com/aws/aws-k8s-tester/ssh"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_eks "github.com/aws/aws-sdk-go/service/eks"
+	"go.uber.org/zap"
+)
+
+// diagnose collects bootstrap logs from the failed managed node group
+// instances, and attaches the failure classification to the error.
+func (ts *tester) diagnose(mngName string, ng *aws_eks.Nodegroup, err error) error {
+	if !ts.cfg.EKSConfig.AddOnManagedNodeGroups.DiagnoseOnFailure {
+		return err
+	}
+	cur, ok := ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs[mngName]
+	if !ok {
+		return err
+	}
+
+	asgName, issues := cur.ASGName, []string(nil)
+	if ng != nil {
+		if ng.Resources != nil {
+			for _, asg := range ng.Resources.AutoScalingGroups {
+				asgName = aws_v2.ToString(asg.Name)
+			}
+		}
+		if ng.Health != nil {
+			for _, is := range ng.Health.Issues {
+				issues = append(issues, fmt.Sprintf("%s: %s", aws_v2.ToString(is.Code), aws_v2.ToString(is.Message)))
+			}
+		}
+	}
+	if asgName == "" {
+		ts.cfg.Logger.Warn("skipping diagnosis; empty ASG name", zap.String("mng-name", mngName))
+		return err
+	}
+
+	rp := diagnose.Diagnose(diagnose.Config{
+		Logger:                ts.cfg.Logger,
+		EC2APIV2:              ts.cfg.EC2APIV2,
+		ASGAPIV2:              ts.cfg.ASGAPIV2,
+		SSHKeyPath:            ts.cfg.EKSConfig.RemoteAccessPrivateKeyPath,
+		SSHUserName:           cur.RemoteAccessUserName,
+		SSHInstanceConnectAPI: ts.cfg.EC2InstanceConnectAPI,
+		SSHHostKeys:           ts.cfg.SSHHostKeys,
+		SSHProxyJump: ssh.NewProxyJump(
+			ts.cfg.EKSConfig.RemoteAccessBastionHost,
+			ts.cfg.EKSConfig.RemoteAccessBastionUserName,
+			ts.cfg.EKSConfig.RemoteAccessBastionPrivateKeyPath,
+		),
+		LogsDir: ts.cfg.EKSConfig.AddOnManagedNodeGroups.LogsDir,
+	}, asgName, issues...)
+	fmt.Fprintf(ts.cfg.LogWriter, "\n%s\n", rp)
+	return fmt.Errorf("%w\n\n%s", err, rp)
+}
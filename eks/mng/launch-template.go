@@ -0,0 +1,358 @@
+package mng
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	aws_eks "github.com/aws/aws-sdk-go/service/eks"
+	smithy "github.com/aws/smithy-go"
+	"go.uber.org/zap"
+)
+
+// createLaunchTemplate creates the custom launch template for the MNG.
+// EKS does not create the remote access security group for the launch template,
+// so the launch template security group is used as the MNG security group,
+// along with the EKS cluster security group.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/launch-templates.html
+func (ts *tester) createLaunchTemplate(mngName string) error {
+	cur, ok := ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs[mngName]
+	if !ok {
+		return fmt.Errorf("MNGs[%q] not found; cannot create launch template", mngName)
+	}
+	lt := cur.LaunchTemplate
+	if lt == nil || !lt.Enable {
+		return nil
+	}
+	if lt.ID != "" {
+		ts.cfg.Logger.Info("launch template already created; no need to create a new one", zap.String("launch-template-id", lt.ID))
+		return nil
+	}
+
+	if lt.SecurityGroupID == "" {
+		ts.cfg.Logger.Info("creating launch template security group", zap.String("mng-name", mngName))
+		sout, err := ts.cfg.EC2APIV2.CreateSecurityGroup(
+			context.Background(),
+			&aws_ec2_v2.CreateSecurityGroupInput{
+				GroupName:   aws_v2.String(lt.Name + "-security-group"),
+				Description: aws_v2.String(fmt.Sprintf("Security group for managed node group %q launch template", mngName)),
+				VpcId:       aws_v2.String(ts.cfg.EKSConfig.VPC.ID),
+				TagSpecifications: []aws_ec2_v2_types.TagSpecification{
+					{
+						ResourceType: aws_ec2_v2_types.ResourceTypeSecurityGroup,
+						Tags: []aws_ec2_v2_types.Tag{
+							{
+								Key:   aws_v2.String("Name"),
+								Value: aws_v2.String(lt.Name + "-security-group"),
+							},
+							{
+								Key:   aws_v2.String(eksconfig.RunTagKey),
+								Value: aws_v2.String(ts.cfg.EKSConfig.Name),
+							},
+						},
+					},
+				},
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create launch template security group for %q (%v)", mngName, err)
+		}
+		lt.SecurityGroupID = aws_v2.ToString(sout.GroupId)
+		ts.cfg.EKSConfig.Sync()
+		ts.cfg.Logger.Info("created launch template security group", zap.String("security-group-id", lt.SecurityGroupID))
+	}
+	cur.RemoteAccessSecurityGroupID = lt.SecurityGroupID
+	ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs[mngName] = cur
+	ts.cfg.EKSConfig.Sync()
+
+	sgIDs := []string{lt.SecurityGroupID}
+	dout, err := ts.cfg.EKSAPI.DescribeCluster(&aws_eks.DescribeClusterInput{
+		Name: aws_v2.String(ts.cfg.EKSConfig.Name),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe cluster for launch template (%v)", err)
+	}
+	if dout.Cluster.ResourcesVpcConfig != nil && aws_v2.ToString(dout.Cluster.ResourcesVpcConfig.ClusterSecurityGroupId) != "" {
+		sgIDs = append(sgIDs, aws_v2.ToString(dout.Cluster.ResourcesVpcConfig.ClusterSecurityGroupId))
+	}
+
+	userData, err := ts.generateLaunchTemplateUserData(cur)
+	if err != nil {
+		return fmt.Errorf("failed to create launch template user data for %q (%v)", mngName, err)
+	}
+
+	ebs := &aws_ec2_v2_types.LaunchTemplateEbsBlockDeviceRequest{
+		DeleteOnTermination: aws_v2.Bool(true),
+		Encrypted:           aws_v2.Bool(true),
+		VolumeType:          aws_ec2_v2_types.VolumeType(lt.VolumeType),
+		VolumeSize:          aws_v2.Int32(int32(cur.VolumeSize)),
+	}
+	if lt.VolumeIOPS > 0 {
+		ebs.Iops = aws_v2.Int32(lt.VolumeIOPS)
+	}
+	if lt.VolumeThroughput > 0 {
+		ebs.Throughput = aws_v2.Int32(lt.VolumeThroughput)
+	}
+	data := &aws_ec2_v2_types.RequestLaunchTemplateData{
+		BlockDeviceMappings: []aws_ec2_v2_types.LaunchTemplateBlockDeviceMappingRequest{
+			{
+				DeviceName: aws_v2.String("/dev/xvda"),
+				Ebs:        ebs,
+			},
+		},
+		MetadataOptions: &aws_ec2_v2_types.LaunchTemplateInstanceMetadataOptionsRequest{
+			HttpEndpoint:            aws_ec2_v2_types.LaunchTemplateInstanceMetadataEndpointStateEnabled,
+			HttpTokens:              aws_ec2_v2_types.LaunchTemplateHttpTokensState(lt.MetadataHTTPTokens),
+			HttpPutResponseHopLimit: aws_v2.Int32(lt.MetadataHTTPPutResponseHopLimit),
+		},
+		SecurityGroupIds: sgIDs,
+		TagSpecifications: []aws_ec2_v2_types.LaunchTemplateTagSpecificationRequest{
+			{
+				ResourceType: aws_ec2_v2_types.ResourceTypeInstance,
+				Tags: []aws_ec2_v2_types.Tag{
+					{
+						Key:   aws_v2.String("Name"),
+						Value: aws_v2.String(mngName),
+					},
+					{
+						Key:   aws_v2.String(eksconfig.RunTagKey),
+						Value: aws_v2.String(ts.cfg.EKSConfig.Name),
+					},
+				},
+			},
+		},
+	}
+	if ts.cfg.EKSConfig.RemoteAccessKeyName != "" {
+		// empty with EC2 Instance Connect and no key pair
+		data.KeyName = aws_v2.String(ts.cfg.EKSConfig.RemoteAccessKeyName)
+	}
+	if lt.ImageID != "" {
+		data.ImageId = aws_v2.String(lt.ImageID)
+	}
+	if userData != "" {
+		data.UserData = aws_v2.String(base64.StdEncoding.EncodeToString([]byte(userData)))
+	}
+
+	ts.cfg.Logger.Info("creating launch template",
+		zap.String("mng-name", mngName),
+		zap.String("launch-template-name", lt.Name),
+		zap.String("image-id", lt.ImageID),
+		zap.Strings("security-group-ids", sgIDs),
+	)
+	out, err := ts.cfg.EC2APIV2.CreateLaunchTemplate(
+		context.Background(),
+		&aws_ec2_v2.CreateLaunchTemplateInput{
+			LaunchTemplateName: aws_v2.String(lt.Name),
+			LaunchTemplateData: data,
+			TagSpecifications: []aws_ec2_v2_types.TagSpecification{
+				{
+					ResourceType: aws_ec2_v2_types.ResourceTypeLaunchTemplate,
+					Tags: []aws_ec2_v2_types.Tag{
+						{
+							Key:   aws_v2.String("Name"),
+							Value: aws_v2.String(lt.Name),
+						},
+						{
+							Key:   aws_v2.String(eksconfig.RunTagKey),
+							Value: aws_v2.String(ts.cfg.EKSConfig.Name),
+						},
+					},
+				},
+			},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create launch template for %q (%v)", mngName, err)
+	}
+	lt.ID = aws_v2.ToString(out.LaunchTemplate.LaunchTemplateId)
+	lt.Version = strconv.FormatInt(aws_v2.ToInt64(out.LaunchTemplate.LatestVersionNumber), 10)
+	ts.cfg.EKSConfig.Sync()
+
+	ts.cfg.Logger.Info("created launch template",
+		zap.String("launch-template-id", lt.ID),
+		zap.String("launch-template-version", lt.Version),
+	)
+	return nil
+}
+
+// deleteLaunchTemplate deletes the launch template and its security group.
+// Must be run after deleting the MNG.
+func (ts *tester) deleteLaunchTemplate(mngName string) error {
+	cur, ok := ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs[mngName]
+	if !ok {
+		return fmt.Errorf("MNGs[%q] not found; cannot delete launch template", mngName)
+	}
+	lt := cur.LaunchTemplate
+	if lt == nil || !lt.Enable {
+		return nil
+	}
+
+	if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[lt.Name]; !ok && lt.ID != "" {
+		ts.cfg.Logger.Info("deleting launch template", zap.String("launch-template-id", lt.ID))
+		_, err := ts.cfg.EC2APIV2.DeleteLaunchTemplate(
+			context.Background(),
+			&aws_ec2_v2.DeleteLaunchTemplateInput{
+				LaunchTemplateId: aws_v2.String(lt.ID),
+			},
+		)
+		if err != nil && !isNotFound(err) {
+			ts.cfg.Logger.Warn("failed to delete launch template", zap.String("launch-template-id", lt.ID), zap.Error(err))
+			return err
+		}
+		ts.cfg.Logger.Info("deleted launch template", zap.String("launch-template-id", lt.ID))
+		ts.cfg.EKSConfig.Status.DeletedResources[lt.Name] = "AddOnManagedNodeGroups.MNGs.LaunchTemplate.Name"
+		ts.cfg.EKSConfig.Sync()
+	}
+
+	if lt.SecurityGroupID == "" {
+		return nil
+	}
+	var err error
+	for i := 0; i < 5; i++ { // retry, leaky ENI may take awhile to be deleted
+		if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[lt.SecurityGroupID]; ok {
+			return nil
+		}
+		ts.cfg.Logger.Info("deleting launch template security group", zap.String("security-group-id", lt.SecurityGroupID))
+		_, err = ts.cfg.EC2APIV2.DeleteSecurityGroup(
+			context.Background(),
+			&aws_ec2_v2.DeleteSecurityGroupInput{
+				GroupId: aws_v2.String(lt.SecurityGroupID),
+			},
+		)
+		if err == nil || isNotFound(err) {
+			ts.cfg.Logger.Info("deleted launch template security group", zap.String("security-group-id", lt.SecurityGroupID))
+			ts.cfg.EKSConfig.Status.DeletedResources[lt.SecurityGroupID] = "AddOnManagedNodeGroups.MNGs.LaunchTemplate.SecurityGroupID"
+			ts.cfg.EKSConfig.Sync()
+			return nil
+		}
+		ts.cfg.Logger.Warn("failed to delete launch template security group; retrying", zap.String("security-group-id", lt.SecurityGroupID), zap.Error(err))
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("stopped")
+		case <-time.After(30 * time.Second):
+		}
+	}
+	return err
+}
+
+func isNotFound(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return strings.Contains(apiErr.ErrorCode(), "NotFound")
+	}
+	return false
+}
+
+const mimeBoundary = "BOUNDARY"
+
+// generateLaunchTemplateUserData returns the MIME multi-part user data,
+// which EKS merges with its own bootstrap user data. With a custom AMI,
+// EKS does not merge the user data, so the node bootstrap is added.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/launch-templates.html#launch-template-user-data
+func (ts *tester) generateLaunchTemplateUserData(cur eksconfig.MNG) (string, error) {
+	lt := cur.LaunchTemplate
+	if lt.UserData == "" && lt.KubeletExtraArgs == "" && lt.ImageID == "" {
+		return "", nil
+	}
+
+	// with a custom AMI, EKS does not apply the node group labels
+	labels := fmt.Sprintf("NodeType=regular,AMIType=%s,NGType=managed,NGName=%s", cur.AMIType, cur.Name)
+	if lt.ImageID != "" {
+		capacityType := cur.CapacityType
+		if capacityType == "" {
+			capacityType = eksconfig.CapacityTypeOnDemand
+		}
+		labels = fmt.Sprintf("eks.amazonaws.com/nodegroup=%s,eks.amazonaws.com/nodegroup-image=%s,eks.amazonaws.com/capacityType=%s,%s", cur.Name, lt.ImageID, capacityType, labels)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	fmt.Fprintf(buf, "MIME-Version: 1.0\nContent-Type: multipart/mixed; boundary=%q\n\n", mimeBoundary)
+
+	if lt.UserData != "" {
+		fmt.Fprintf(buf, "--%s\nContent-Type: text/x-shellscript; charset=\"us-ascii\"\n\n%s\n\n", mimeBoundary, lt.UserData)
+	}
+
+	switch cur.AMIFamily {
+	case ec2config.AMIFamilyAL2023:
+		if lt.KubeletExtraArgs == "" && lt.ImageID == "" {
+			break
+		}
+		// ref. https://awslabs.github.io/amazon-eks-ami/nodeadm/
+		flags := []string{}
+		for _, flag := range strings.Fields(lt.KubeletExtraArgs) {
+			flags = append(flags, fmt.Sprintf("%q", flag))
+		}
+		fmt.Fprintf(buf, "--%s\nContent-Type: application/node.eks.aws\n\n---\napiVersion: node.eks.aws/v1alpha1\nkind: NodeConfig\nspec:\n", mimeBoundary)
+		if lt.ImageID != "" {
+			flags = append([]string{fmt.Sprintf("%q", "--node-labels="+labels)}, flags...)
+			fmt.Fprintf(buf, "  cluster:\n    name: %s\n    apiServerEndpoint: %s\n    certificateAuthority: %s\n    cidr: %s\n",
+				ts.cfg.EKSConfig.Name,
+				ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint,
+				ts.cfg.EKSConfig.Status.ClusterCA,
+				ts.serviceCIDR(),
+			)
+		}
+		fmt.Fprintf(buf, "  kubelet:\n    flags: [%s]\n\n", strings.Join(flags, ", "))
+
+	case ec2config.AMIFamilyAL2:
+		if lt.ImageID == "" {
+			break
+		}
+		// ref. https://aws.amazon.com/blogs/opensource/improvements-eks-worker-node-provisioning/
+		dnsArgs := "--dns-cluster-ip " + ts.dnsClusterIP()
+		if ts.cfg.EKSConfig.IPFamily == eksconfig.IPFamilyIPv6 {
+			// bootstrap.sh derives the cluster DNS IP from the service IPv6 CIDR
+			dnsArgs = "--ip-family ipv6 --service-ipv6-cidr " + ts.serviceCIDR()
+		}
+		d := fmt.Sprintf("/etc/eks/bootstrap.sh %s --b64-cluster-ca %s --apiserver-endpoint %s %s --kubelet-extra-args '--node-labels=%s",
+			ts.cfg.EKSConfig.Name,
+			ts.cfg.EKSConfig.Status.ClusterCA,
+			ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint,
+			dnsArgs,
+			labels,
+		)
+		if lt.KubeletExtraArgs != "" {
+			d += " " + lt.KubeletExtraArgs
+		}
+		d += "'"
+		fmt.Fprintf(buf, "--%s\nContent-Type: text/x-shellscript; charset=\"us-ascii\"\n\n#!/bin/bash\nset -xeu\n\n%s\n\n", mimeBoundary, d)
+
+	default:
+		return "", fmt.Errorf("AMIFamily %q not supported for launch template user data", cur.AMIFamily)
+	}
+
+	fmt.Fprintf(buf, "--%s--\n", mimeBoundary)
+	return buf.String(), nil
+}
+
+// serviceCIDR returns the default service CIDR for the cluster VPC,
+// or the service IPv6 CIDR assigned by EKS for IPv6 clusters.
+func (ts *tester) serviceCIDR() string {
+	if ts.cfg.EKSConfig.IPFamily == eksconfig.IPFamilyIPv6 {
+		return ts.cfg.EKSConfig.Status.ClusterServiceIPv6CIDR
+	}
+	clusterVPCIP := ts.cfg.EKSConfig.VPC.CIDRs[0]
+	if clusterVPCIP[:strings.IndexByte(clusterVPCIP, '.')] == "10" {
+		return "172.20.0.0/16"
+	}
+	return "10.100.0.0/16"
+}
+
+// dnsClusterIP returns the default cluster DNS IP for the cluster VPC.
+func (ts *tester) dnsClusterIP() string {
+	clusterVPCIP := ts.cfg.EKSConfig.VPC.CIDRs[0]
+	if clusterVPCIP[:strings.IndexByte(clusterVPCIP, '.')] == "10" {
+		return "172.20.0.10"
+	}
+	return "10.100.0.10"
+}
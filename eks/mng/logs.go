@@ -3,16 +3,19 @@ package mng
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-k8s-tester/ec2config"
 	"github.com/aws/aws-k8s-tester/pkg/fileutil"
 	"github.com/aws/aws-k8s-tester/pkg/randutil"
 	"github.com/aws/aws-k8s-tester/ssh"
+	aws_eks "github.com/aws/aws-sdk-go/service/eks"
 	"github.com/dustin/go-humanize"
 	"github.com/mholt/archiver/v3"
 	"go.uber.org/zap"
@@ -30,6 +33,43 @@ var defaultLogs = map[string]string{
 	"sudo systemctl list-units -t service --no-pager --no-legend --all": "list-units-systemctl.out.log",
 }
 
+// gpuLogs are the NVIDIA driver and device plugin logs on GPU nodes.
+var gpuLogs = map[string]string{
+	// driver, device, and ECC error details
+	"nvidia-smi -q": "nvidia-smi.out.log",
+
+	// NVIDIA kernel driver messages and Xid errors
+	"sudo dmesg --ctime | grep -i -E 'nvrm|xid' || true": "nvidia-kernel.out.log",
+
+	// device plugin sockets registered with the kubelet
+	"sudo ls -la /var/lib/kubelet/device-plugins": "device-plugins.out.log",
+}
+
+// powershell wraps the command to run with PowerShell,
+// since the default shell of the Windows OpenSSH server is "cmd.exe".
+func powershell(cmd string) string {
+	return `powershell.exe -NoProfile -NonInteractive -Command "` + cmd + `"`
+}
+
+var defaultWindowsLogs = map[string]string{
+	// event logs
+	powershell("Get-EventLog -LogName System -Newest 10000 | Format-List"):      "eventlog-system.out.log",
+	powershell("Get-EventLog -LogName Application -Newest 10000 | Format-List"): "eventlog-application.out.log",
+
+	// services (e.g. kubelet, kube-proxy, containerd)
+	powershell("Get-Service | Format-Table -AutoSize | Out-String -Width 4096"): "list-services.out.log",
+
+	// network configuration
+	"ipconfig /all": "ipconfig.out.log",
+}
+
+// windowsLogDirs are the directories of the kubelet, kube-proxy,
+// and EKS bootstrap logs on Windows nodes.
+var windowsLogDirs = []string{
+	`C:\ProgramData\kubernetes\logs`,
+	`C:\ProgramData\Amazon\EKS\logs`,
+}
+
 // FetchLogs downloads logs from managed node group instances.
 func (ts *tester) FetchLogs() (err error) {
 	if !ts.cfg.EKSConfig.IsEnabledAddOnManagedNodeGroups() {
@@ -91,11 +131,17 @@ func (ts *tester) fetchLogs(qps float32, burst int) error {
 			zap.Int("nodes", len(nodeGroup.Instances)),
 		)
 		waits += len(nodeGroup.Instances)
+		logCollection := ec2config.LogCollection(nodeGroup.AMIFamily)
+		isGPU := nodeGroup.AMIType == aws_eks.AMITypesAl2X8664Gpu
 
 		for instID, cur := range nodeGroup.Instances {
+			// tag the log files with the architecture, to compare mixed architecture node groups
 			pfx := instID + "-"
+			if arch := ec2config.ArchitectureFromEC2(cur.Architecture); arch != "" {
+				pfx = instID + "-" + arch + "-"
+			}
 
-			go func(instID, logsDir, pfx string, cur ec2config.Instance) {
+			go func(instID, logsDir, pfx string, cur ec2config.Instance, logCollection string, isGPU bool) {
 				select {
 				case <-ts.cfg.Stopc:
 					ts.cfg.Logger.Warn("exiting fetch logger", zap.String("prefix", pfx))
@@ -117,61 +163,50 @@ func (ts *tester) fetchLogs(qps float32, burst int) error {
 					)
 				}
 
-				sh, err := ssh.New(ssh.Config{
+				sh, err := ts.sshPool.Get(instID, ssh.Config{
 					Logger:        ts.cfg.Logger,
 					KeyPath:       ts.cfg.EKSConfig.RemoteAccessPrivateKeyPath,
 					PublicIP:      cur.PublicIP,
 					PublicDNSName: cur.PublicDNSName,
+					PrivateIP:     cur.PrivateIP,
 					UserName:      cur.RemoteAccessUserName,
+					InstanceConnect: ssh.NewInstanceConnect(
+						ts.cfg.EC2InstanceConnectAPI,
+						instID,
+						cur.Placement.AvailabilityZone,
+					),
+					InstanceID:         instID,
+					HostKeys:           ts.cfg.SSHHostKeys,
+					InsecureSkipVerify: ts.cfg.SSHHostKeys == nil,
+					ProxyJump: ssh.NewProxyJump(
+						ts.cfg.EKSConfig.RemoteAccessBastionHost,
+						ts.cfg.EKSConfig.RemoteAccessBastionUserName,
+						ts.cfg.EKSConfig.RemoteAccessBastionPrivateKeyPath,
+					),
 				})
 				if err != nil {
 					rch <- instanceLogs{mngName: name, errs: []string{err.Error()}}
 					return
 				}
-				defer sh.Close()
-				if err = sh.Connect(); err != nil {
-					rch <- instanceLogs{mngName: name, errs: []string{err.Error()}}
+
+				data := instanceLogs{mngName: name, instanceID: instID}
+				switch logCollection {
+				case ec2config.LogCollectionPowerShell:
+					data.paths, data.errs = ts.fetchWindowsLogs(sh, rateLimiter, instID, pfx, sshOptLog)
+					rch <- data
+					return
+				case ec2config.LogCollectionLogdog:
+					data.paths, data.errs = ts.fetchLogdog(sh, instID, pfx, sshOptLog)
+					rch <- data
 					return
 				}
 
-				data := instanceLogs{mngName: name, instanceID: instID}
 				// fetch default logs
-				for cmd, fileName := range defaultLogs {
-					if !rateLimiter.Allow() {
-						ts.cfg.Logger.Debug("waiting for rate limiter before fetching file")
-						werr := rateLimiter.Wait(context.Background())
-						ts.cfg.Logger.Debug("waited for rate limiter", zap.Error(werr))
-					}
-					out, oerr := sh.Run(cmd, sshOptLog)
-					if oerr != nil {
-						data.errs = append(data.errs, fmt.Sprintf("failed to run command %q for %q (error %v)", cmd, instID, oerr))
-						continue
-					}
-
-					fpath := filepath.Join(logsDir, shorten(ts.cfg.Logger, pfx+fileName))
-					f, err := os.Create(fpath)
-					if err != nil {
-						data.errs = append(data.errs, fmt.Sprintf(
-							"failed to create a file %q for %q (error %v)",
-							fpath,
-							instID,
-							err,
-						))
-						continue
-					}
-					if _, err = f.Write(out); err != nil {
-						data.errs = append(data.errs, fmt.Sprintf(
-							"failed to write to a file %q for %q (error %v)",
-							fpath,
-							instID,
-							err,
-						))
-						f.Close()
-						continue
-					}
-					f.Close()
-					ts.cfg.Logger.Debug("wrote", zap.String("file-path", fpath))
-					data.paths = append(data.paths, fpath)
+				paths, errs := ts.runLogCommands(sh, rateLimiter, instID, pfx, defaultLogs, sshOptLog)
+				data.paths, data.errs = append(data.paths, paths...), append(data.errs, errs...)
+				if isGPU {
+					paths, errs = ts.runLogCommands(sh, rateLimiter, instID, pfx, gpuLogs, sshOptLog)
+					data.paths, data.errs = append(data.paths, paths...), append(data.errs, errs...)
 				}
 
 				if !rateLimiter.Allow() {
@@ -211,48 +246,8 @@ func (ts *tester) fetchLogs(qps float32, burst int) error {
 						svcFileName := svc + ".out.log"
 						svcCmdToFileName[svcCmd] = svcFileName
 					}
-					for cmd, fileName := range svcCmdToFileName {
-						if !rateLimiter.Allow() {
-							ts.cfg.Logger.Debug("waiting for rate limiter before fetching file")
-							werr := rateLimiter.Wait(context.Background())
-							ts.cfg.Logger.Debug("waited for rate limiter", zap.Error(werr))
-						}
-						out, oerr := sh.Run(cmd, sshOptLog)
-						if oerr != nil {
-							data.errs = append(data.errs, fmt.Sprintf(
-								"failed to run command %q for %q (error %v)",
-								listCmd,
-								instID,
-								oerr,
-							))
-							continue
-						}
-
-						fpath := filepath.Join(logsDir, shorten(ts.cfg.Logger, pfx+fileName))
-						f, err := os.Create(fpath)
-						if err != nil {
-							data.errs = append(data.errs, fmt.Sprintf(
-								"failed to create a file %q for %q (error %v)",
-								fpath,
-								instID,
-								err,
-							))
-							continue
-						}
-						if _, err = f.Write(out); err != nil {
-							data.errs = append(data.errs, fmt.Sprintf(
-								"failed to write to a file %q for %q (error %v)",
-								fpath,
-								instID,
-								err,
-							))
-							f.Close()
-							continue
-						}
-						f.Close()
-						ts.cfg.Logger.Debug("wrote", zap.String("file-path", fpath))
-						data.paths = append(data.paths, fpath)
-					}
+					paths, errs := ts.runLogCommands(sh, rateLimiter, instID, pfx, svcCmdToFileName, sshOptLog)
+					data.paths, data.errs = append(data.paths, paths...), append(data.errs, errs...)
 				}
 
 				if !rateLimiter.Allow() {
@@ -334,56 +329,32 @@ func (ts *tester) fetchLogs(qps float32, burst int) error {
 							// last value
 							continue
 						}
-						logCmd := "sudo cat " + line
-						logPath := filepath.Base(line)
-						varLogPaths[logCmd] = logPath
+						varLogPaths[line] = filepath.Base(line)
 					}
-					for cmd, logPath := range varLogPaths {
+					for remotePath, logPath := range varLogPaths {
 						if !rateLimiter.Allow() {
 							ts.cfg.Logger.Debug("waiting for rate limiter before fetching file")
 							werr := rateLimiter.Wait(context.Background())
 							ts.cfg.Logger.Debug("waited for rate limiter", zap.Error(werr))
 						}
-						// e.g. "read tcp 10.119.223.210:58688->54.184.39.156:22: read: connection timed out"
-						out, oerr := sh.Run(cmd, sshOptLog, ssh.WithRetry(2, 3*time.Second))
-						if oerr != nil {
-							data.errs = append(data.errs, fmt.Sprintf(
-								"failed to run command %q for %q (error %v)",
-								cmd,
-								instID,
-								oerr,
-							))
-							continue
-						}
-
+						// download over SFTP to preserve binary files (e.g. "wtmp"),
+						// and to resume partial downloads on retries
 						fpath := filepath.Join(logsDir, shorten(ts.cfg.Logger, pfx+logPath))
-						f, err := os.Create(fpath)
-						if err != nil {
+						if derr := sh.Download(remotePath, fpath, sshOptLog, ssh.WithSudo(true), ssh.WithRetry(2, 3*time.Second)); derr != nil {
 							data.errs = append(data.errs, fmt.Sprintf(
-								"failed to create a file %q for %q (error %v)",
-								fpath,
+								"failed to download %q for %q (error %v)",
+								remotePath,
 								instID,
-								err,
+								derr,
 							))
 							continue
 						}
-						if _, err = f.Write(out); err != nil {
-							data.errs = append(data.errs, fmt.Sprintf(
-								"failed to write to a file %q for %q (error %v)",
-								fpath,
-								instID,
-								err,
-							))
-							f.Close()
-							continue
-						}
-						f.Close()
 						ts.cfg.Logger.Debug("wrote", zap.String("file-path", fpath))
 						data.paths = append(data.paths, fpath)
 					}
 				}
 				rch <- data
-			}(instID, logsDir, pfx, cur)
+			}(instID, logsDir, pfx, cur, logCollection, isGPU)
 		}
 	}
 
@@ -458,6 +429,119 @@ func (ts *tester) fetchLogs(qps float32, burst int) error {
 	return nil
 }
 
+// fetchWindowsLogs fetches the event logs, and the files under the
+// Windows log directories with PowerShell.
+func (ts *tester) fetchWindowsLogs(sh ssh.SSH, rateLimiter *rate.Limiter, instID string, pfx string, opts ...ssh.OpOption) (paths []string, errs []string) {
+	paths, errs = ts.runLogCommands(sh, rateLimiter, instID, pfx, defaultWindowsLogs, opts...)
+
+	if !rateLimiter.Allow() {
+		ts.cfg.Logger.Debug("waiting for rate limiter before fetching file")
+		werr := rateLimiter.Wait(context.Background())
+		ts.cfg.Logger.Debug("waited for rate limiter", zap.Error(werr))
+	}
+	ts.cfg.Logger.Info("listing Windows log directories", zap.String("instance-id", instID), zap.Strings("dirs", windowsLogDirs))
+	listCmd := powershell(fmt.Sprintf(
+		"Get-ChildItem -Path %s -Recurse -File -ErrorAction SilentlyContinue | ForEach-Object { $_.FullName }",
+		strings.Join(windowsLogDirs, ","),
+	))
+	out, oerr := sh.Run(listCmd, append(opts, ssh.WithRetry(5, 3*time.Second))...)
+	if oerr != nil {
+		errs = append(errs, fmt.Sprintf(
+			"failed to run command %q for %q (error %v)",
+			listCmd,
+			instID,
+			oerr,
+		))
+		return paths, errs
+	}
+
+	// download with "Get-Content", since the SFTP paths
+	// and "sudo" differ on Windows
+	cmdToFileName := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		fileName := line[strings.LastIndex(line, `\`)+1:]
+		cmdToFileName[powershell("Get-Content -Raw -LiteralPath '"+line+"'")] = fileName
+	}
+	p, e := ts.runLogCommands(sh, rateLimiter, instID, pfx, cmdToFileName, opts...)
+	return append(paths, p...), append(errs, e...)
+}
+
+// fetchLogdog collects the Bottlerocket host logs with "logdog",
+// and downloads the archive.
+func (ts *tester) fetchLogdog(sh ssh.SSH, instID string, pfx string, opts ...ssh.OpOption) (paths []string, errs []string) {
+	ts.cfg.Logger.Info("running logdog", zap.String("instance-id", instID))
+	if _, err := sh.Run(ec2config.BottlerocketLogdogCommand, append(opts, ssh.WithTimeout(3*time.Minute))...); err != nil {
+		return nil, []string{fmt.Sprintf(
+			"failed to run command %q for %q (error %v)",
+			ec2config.BottlerocketLogdogCommand,
+			instID,
+			err,
+		)}
+	}
+	logsDir := ts.cfg.EKSConfig.AddOnManagedNodeGroups.LogsDir
+	fpath := filepath.Join(logsDir, shorten(ts.cfg.Logger, pfx+filepath.Base(ec2config.BottlerocketLogdogArchivePath)))
+	if err := sh.Download(ec2config.BottlerocketLogdogArchivePath, fpath, append(opts, ssh.WithSudo(true), ssh.WithRetry(2, 3*time.Second))...); err != nil {
+		return nil, []string{fmt.Sprintf(
+			"failed to download %q for %q (error %v)",
+			ec2config.BottlerocketLogdogArchivePath,
+			instID,
+			err,
+		)}
+	}
+	ts.cfg.Logger.Debug("wrote", zap.String("file-path", fpath))
+	return []string{fpath}, nil
+}
+
+// runLogCommands runs the commands concurrently in multiplexed sessions
+// over the same SSH connection, and writes the outputs to the logs directory.
+func (ts *tester) runLogCommands(sh ssh.SSH, rateLimiter *rate.Limiter, instID string, pfx string, cmdToFileName map[string]string, opts ...ssh.OpOption) (paths []string, errs []string) {
+	logsDir := ts.cfg.EKSConfig.AddOnManagedNodeGroups.LogsDir
+	fanOut := ts.cfg.EKSConfig.AddOnManagedNodeGroups.FetchLogsFanOut
+	if fanOut < 1 {
+		fanOut = 1
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sema := make(chan struct{}, fanOut)
+	for cmd, fileName := range cmdToFileName {
+		wg.Add(1)
+		sema <- struct{}{}
+		go func(cmd string, fileName string) {
+			defer func() {
+				<-sema
+				wg.Done()
+			}()
+			if !rateLimiter.Allow() {
+				ts.cfg.Logger.Debug("waiting for rate limiter before fetching file")
+				werr := rateLimiter.Wait(context.Background())
+				ts.cfg.Logger.Debug("waited for rate limiter", zap.Error(werr))
+			}
+			out, oerr := sh.Run(cmd, opts...)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if oerr != nil {
+				errs = append(errs, fmt.Sprintf("failed to run command %q for %q (error %v)", cmd, instID, oerr))
+				return
+			}
+			fpath := filepath.Join(logsDir, shorten(ts.cfg.Logger, pfx+fileName))
+			if err := ioutil.WriteFile(fpath, out, 0600); err != nil {
+				errs = append(errs, fmt.Sprintf("failed to write to a file %q for %q (error %v)", fpath, instID, err))
+				return
+			}
+			ts.cfg.Logger.Debug("wrote", zap.String("file-path", fpath))
+			paths = append(paths, fpath)
+		}(cmd, fileName)
+	}
+	wg.Wait()
+	return paths, errs
+}
+
 type instanceLogs struct {
 	mngName    string
 	instanceID string
@@ -16,11 +16,12 @@ import (
 	"github.com/aws/aws-k8s-tester/eksconfig"
 	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"github.com/aws/aws-k8s-tester/ssh"
 	aws_asg_v2 "github.com/aws/aws-sdk-go-v2/service/autoscaling"
 	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
 	aws_eks_v2 "github.com/aws/aws-sdk-go-v2/service/eks"
 	aws_iam_v2 "github.com/aws/aws-sdk-go-v2/service/iam"
-	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/aws/aws-sdk-go/service/ec2instanceconnect/ec2instanceconnectiface"
 	"github.com/aws/aws-sdk-go/service/eks/eksiface"
 	"go.uber.org/zap"
 )
@@ -39,7 +40,12 @@ type Config struct {
 	EKSAPI   eksiface.EKSAPI
 	EKSAPIV2 *aws_eks_v2.Client
 
-	CFNAPI cloudformationiface.CloudFormationAPI
+	// EC2InstanceConnectAPI is set to SSH into the nodes with
+	// ephemeral keys pushed via EC2 Instance Connect.
+	EC2InstanceConnectAPI ec2instanceconnectiface.EC2InstanceConnectAPI
+	// SSHHostKeys is set to verify the node host keys.
+	// Leave nil to skip host key verification.
+	SSHHostKeys *ssh.HostKeys
 }
 
 // Tester implements EKS "Managed Node Group" for "kubetest2" Deployer.
@@ -55,8 +61,9 @@ type Tester interface {
 	Delete() error
 	// Scale runs all scale up/down operations.
 	Scale() error
-	// UpgradeVersion upgrades EKS "Managed Node Group" version, and waits for completion.
-	UpgradeVersion() error
+	// Upgrade upgrades EKS "Managed Node Group" Kubernetes version or AMI release version,
+	// and waits for completion while verifying the workload availability.
+	Upgrade() error
 
 	// FetchLogs fetches logs from all worker nodes.
 	FetchLogs() error
@@ -101,6 +108,7 @@ func New(cfg Config) Tester {
 			EKSAPI:    cfg.EKSAPI,
 		}),
 		logsMu:          new(sync.RWMutex),
+		sshPool:         ssh.NewPool(cfg.Logger),
 		deleteRequested: make(map[string]struct{}),
 	}
 }
@@ -110,6 +118,8 @@ type tester struct {
 	nodeWaiter      wait.NodeWaiter
 	scaler          scale.Scaler
 	versionUpgrader version_upgrade.Upgrader
+	// sshPool reuses SSH connections to fetch logs from the same nodes
+	sshPool         *ssh.Pool
 	logsMu          *sync.RWMutex
 	deleteRequested map[string]struct{}
 }
@@ -123,8 +133,8 @@ func (ts *tester) Create() (err error) {
 		ts.cfg.Logger.Info("managed node group is already created; skipping creation")
 		return nil
 	}
-	if len(ts.cfg.EKSConfig.VPC.PublicSubnetIDs) == 0 {
-		return errors.New("empty EKSConfig.VPC.PublicSubnetIDs")
+	if len(ts.cfg.EKSConfig.VPC.NodeSubnetIDs()) == 0 {
+		return errors.New("empty EKSConfig.VPC node subnet IDs")
 	}
 
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
@@ -138,6 +148,11 @@ func (ts *tester) Create() (err error) {
 	if err = ts.createRole(); err != nil {
 		return err
 	}
+	if ts.hasWindowsMNG() {
+		if err = ts.enableWindowsIPAM(); err != nil {
+			return err
+		}
+	}
 	if err = ts.createMNGs(); err != nil {
 		return err
 	}
@@ -146,6 +161,12 @@ func (ts *tester) Create() (err error) {
 			return err
 		}
 	}
+	if err = ts.createWindowsSmokeTests(); err != nil {
+		return err
+	}
+	if err = ts.createNodeConfigSmokeTests(); err != nil {
+		return err
+	}
 
 	ts.cfg.EKSConfig.AddOnManagedNodeGroups.Created = true
 	ts.cfg.EKSConfig.Sync()
@@ -165,7 +186,7 @@ func (ts *tester) Scale() (err error) {
 	return nil
 }
 
-func (ts *tester) UpgradeVersion() (err error) {
+func (ts *tester) Upgrade() (err error) {
 	if !ts.cfg.EKSConfig.IsEnabledAddOnManagedNodeGroups() {
 		return nil
 	}
@@ -181,9 +202,12 @@ func (ts *tester) UpgradeVersion() (err error) {
 		if err = ts.versionUpgrader.Upgrade(mngName); err != nil {
 			return err
 		}
+		nodesReadyStart := time.Now()
 		if err = ts.nodeWaiter.Wait(mngName, 3); err != nil {
 			return err
 		}
+		cur.VersionUpgrade.TimeFrameNodesReady = timeutil.NewTimeFrame(nodesReadyStart, time.Now())
+		ts.cfg.EKSConfig.Sync()
 	}
 
 	ts.cfg.EKSConfig.Sync()
@@ -200,6 +224,7 @@ func (ts *tester) Delete() error {
 	}
 
 	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	ts.sshPool.Close()
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
@@ -210,6 +235,13 @@ func (ts *tester) Delete() error {
 	var errs []string
 	var err error
 
+	if err = ts.deleteWindowsSmokeTests(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err = ts.deleteNodeConfigSmokeTests(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
 	for name := range ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs {
 		err = ts.revokeSecurityGroups(name)
 		if err != nil {
@@ -279,6 +311,13 @@ func (ts *tester) Delete() error {
 		errs = append(errs, err.Error())
 	}
 
+	for name := range ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs {
+		if err := ts.deleteLaunchTemplate(name); err != nil {
+			ts.cfg.Logger.Warn("failed to delete mng launch template", zap.String("name", name), zap.Error(err))
+			errs = append(errs, err.Error())
+		}
+	}
+
 	// must be run after deleting node group
 	// otherwise, "Cannot delete entity, must remove roles from instance profile first. (Service: AmazonIdentityManagement; Status Code: 409; Error Code: DeleteConflict; Request ID: 197f795b-1003-4386-81cc-44a926c42be7)"
 	if err := ts.deleteRole(); err != nil {
@@ -10,10 +10,12 @@ import (
 
 	"github.com/aws/aws-k8s-tester/ec2config"
 	"github.com/aws/aws-k8s-tester/eks/mng/wait"
+	"github.com/aws/aws-k8s-tester/eksconfig"
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
 	"github.com/aws/aws-k8s-tester/pkg/user"
 	"github.com/aws/aws-k8s-tester/version"
 	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	aws_eks "github.com/aws/aws-sdk-go/service/eks"
 	smithy "github.com/aws/smithy-go"
 	"github.com/dustin/go-humanize"
@@ -111,46 +113,21 @@ func (ts *tester) _createMNGs() (tss tupleTimes, err error) {
 	ts.cfg.Logger.Info("creating MNGs")
 
 	for mngName, cur := range ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs {
-		ts.cfg.Logger.Info("requesting MNG creation", zap.String("mng-name", mngName))
-
-		createInput := aws_eks.CreateNodegroupInput{
-			ClusterName:   aws_v2.String(ts.cfg.EKSConfig.Name),
-			NodegroupName: aws_v2.String(cur.Name),
-			NodeRole:      aws_v2.String(ts.cfg.EKSConfig.AddOnManagedNodeGroups.Role.ARN),
-			AmiType:       aws_v2.String(cur.AMIType),
-			DiskSize:      aws_v2.Int64(int64(cur.VolumeSize)),
-			InstanceTypes: aws_v2.StringSlice(cur.InstanceTypes),
-			RemoteAccess: &aws_eks.RemoteAccessConfig{
-				Ec2SshKey: aws_v2.String(ts.cfg.EKSConfig.RemoteAccessKeyName),
-			},
-			ScalingConfig: &aws_eks.NodegroupScalingConfig{
-				MinSize:     aws_v2.Int64(int64(cur.ASGMinSize)),
-				DesiredSize: aws_v2.Int64(int64(cur.ASGDesiredCapacity)),
-				MaxSize:     aws_v2.Int64(int64(cur.ASGMaxSize)),
-			},
-			Subnets: aws_v2.StringSlice(ts.cfg.EKSConfig.VPC.PublicSubnetIDs),
-			Tags: map[string]*string{
-				"Kind":                   aws_v2.String("aws-k8s-tester"),
-				"aws-k8s-tester-version": aws_v2.String(version.ReleaseVersion),
-				"User":                   aws_v2.String(user.Get()),
-			},
-			Labels: map[string]*string{
-				"NodeType": aws_v2.String("regular"),
-				"AMIType":  aws_v2.String(cur.AMIType),
-				"NGType":   aws_v2.String("managed"),
-				"NGName":   aws_v2.String(cur.Name),
-			},
-		}
-		for k, v := range cur.Tags {
-			createInput.Tags[k] = aws_v2.String(v)
-			ts.cfg.Logger.Info("added EKS tag", zap.String("key", k), zap.String("value", v))
+		if cur.CreateRequested {
+			// requested by the previous run that exited before the creation completes
+			ts.cfg.Logger.Info("MNG creation already requested; waiting", zap.String("mng-name", mngName))
+			tss = append(tss, tupleTime{ts: time.Now(), name: mngName})
+			continue
 		}
-		if cur.ReleaseVersion != "" {
-			createInput.ReleaseVersion = aws_v2.String(cur.ReleaseVersion)
-			ts.cfg.Logger.Info("added EKS release version", zap.String("version", cur.ReleaseVersion))
+		ts.cfg.Logger.Info("requesting MNG creation", zap.String("mng-name", mngName))
+		if err = ts.createLaunchTemplate(mngName); err != nil {
+			return nil, err
 		}
+		cur = ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs[mngName]
+
+		createInput := newCreateNodegroupInput(ts.cfg.Logger, ts.cfg.EKSConfig, cur)
 		timeStart := time.Now()
-		req, _ := ts.cfg.EKSAPI.CreateNodegroupRequest(&createInput)
+		req, _ := ts.cfg.EKSAPI.CreateNodegroupRequest(createInput)
 		if ts.cfg.EKSConfig.AddOnManagedNodeGroups.RequestHeaderKey != "" && ts.cfg.EKSConfig.AddOnManagedNodeGroups.RequestHeaderValue != "" {
 			req.HTTPRequest.Header[ts.cfg.EKSConfig.AddOnManagedNodeGroups.RequestHeaderKey] = []string{ts.cfg.EKSConfig.AddOnManagedNodeGroups.RequestHeaderValue}
 			ts.cfg.Logger.Info("set request header for EKS managed node group create request",
@@ -161,8 +138,13 @@ func (ts *tester) _createMNGs() (tss tupleTimes, err error) {
 
 		err := req.Send()
 		if err != nil {
-			ts.cfg.Logger.Warn("failed to created MNG", zap.Error(err))
-			return nil, fmt.Errorf("MNGs[%q] create request failed (%v)", cur.Name, err)
+			var aerr awserr.Error
+			if !errors.As(err, &aerr) || aerr.Code() != aws_eks.ErrCodeResourceInUseException {
+				ts.cfg.Logger.Warn("failed to created MNG", zap.Error(err))
+				return nil, fmt.Errorf("MNGs[%q] create request failed (%v)", cur.Name, err)
+			}
+			// created by the previous run that exited before persisting the status
+			ts.cfg.Logger.Info("MNG already exists; adopting instead of creating a new one", zap.String("mng-name", cur.Name))
 		}
 
 		cur.TimeFrameCreate = timeutil.NewTimeFrame(timeStart, time.Now())
@@ -183,6 +165,87 @@ func (ts *tester) _createMNGs() (tss tupleTimes, err error) {
 	return tss, nil
 }
 
+// newCreateNodegroupInput returns the EKS create managed node group request.
+func newCreateNodegroupInput(lg *zap.Logger, cfg *eksconfig.Config, cur eksconfig.MNG) *aws_eks.CreateNodegroupInput {
+	createInput := &aws_eks.CreateNodegroupInput{
+		ClusterName:   aws_v2.String(cfg.Name),
+		NodegroupName: aws_v2.String(cur.Name),
+		NodeRole:      aws_v2.String(cfg.AddOnManagedNodeGroups.Role.ARN),
+		AmiType:       aws_v2.String(cur.AMIType),
+		DiskSize:      aws_v2.Int64(int64(cur.VolumeSize)),
+		InstanceTypes: aws_v2.StringSlice(cur.InstanceTypes),
+		ScalingConfig: &aws_eks.NodegroupScalingConfig{
+			MinSize:     aws_v2.Int64(int64(cur.ASGMinSize)),
+			DesiredSize: aws_v2.Int64(int64(cur.ASGDesiredCapacity)),
+			MaxSize:     aws_v2.Int64(int64(cur.ASGMaxSize)),
+		},
+		Subnets: aws_v2.StringSlice(cfg.VPC.NodeSubnetIDs()),
+		Tags: map[string]*string{
+			"Kind":                   aws_v2.String("aws-k8s-tester"),
+			"aws-k8s-tester-version": aws_v2.String(version.ReleaseVersion),
+			"User":                   aws_v2.String(user.Get()),
+			eksconfig.RunTagKey:      aws_v2.String(cfg.Name),
+		},
+		Labels: map[string]*string{
+			"NodeType": aws_v2.String("regular"),
+			"AMIType":  aws_v2.String(cur.AMIType),
+			"NGType":   aws_v2.String("managed"),
+			"NGName":   aws_v2.String(cur.Name),
+		},
+	}
+	for k, v := range cur.Tags {
+		createInput.Tags[k] = aws_v2.String(v)
+		lg.Info("added EKS tag", zap.String("key", k), zap.String("value", v))
+	}
+	for k, v := range cur.Labels {
+		createInput.Labels[k] = aws_v2.String(v)
+		lg.Info("added EKS label", zap.String("key", k), zap.String("value", v))
+	}
+	for _, t := range cur.Taints {
+		taint := &aws_eks.Taint{
+			Key:    aws_v2.String(t.Key),
+			Effect: aws_v2.String(t.EKSEffect()),
+		}
+		if t.Value != "" {
+			taint.Value = aws_v2.String(t.Value)
+		}
+		createInput.Taints = append(createInput.Taints, taint)
+		lg.Info("added EKS taint", zap.String("key", t.Key), zap.String("value", t.Value), zap.String("effect", t.Effect))
+	}
+	if cfg.RemoteAccessKeyName != "" {
+		// empty with EC2 Instance Connect and no key pair
+		createInput.RemoteAccess = &aws_eks.RemoteAccessConfig{
+			Ec2SshKey: aws_v2.String(cfg.RemoteAccessKeyName),
+		}
+	}
+	if cur.LaunchTemplate != nil && cur.LaunchTemplate.Enable {
+		// disk size and remote access are configured in the launch template
+		createInput.DiskSize = nil
+		createInput.RemoteAccess = nil
+		createInput.LaunchTemplate = &aws_eks.LaunchTemplateSpecification{
+			Id:      aws_v2.String(cur.LaunchTemplate.ID),
+			Version: aws_v2.String(cur.LaunchTemplate.Version),
+		}
+		if cur.LaunchTemplate.ImageID != "" {
+			// AMI type must be empty with a custom AMI
+			createInput.AmiType = nil
+		}
+		lg.Info("added EKS launch template",
+			zap.String("launch-template-id", cur.LaunchTemplate.ID),
+			zap.String("launch-template-version", cur.LaunchTemplate.Version),
+		)
+	}
+	if cur.CapacityType != "" {
+		createInput.CapacityType = aws_v2.String(cur.CapacityType)
+		lg.Info("added EKS capacity type", zap.String("capacity-type", cur.CapacityType))
+	}
+	if cur.ReleaseVersion != "" {
+		createInput.ReleaseVersion = aws_v2.String(cur.ReleaseVersion)
+		lg.Info("added EKS release version", zap.String("version", cur.ReleaseVersion))
+	}
+	return createInput
+}
+
 func (ts *tester) waitForMNGs(now time.Time, tss tupleTimes) (err error) {
 	ts.cfg.Logger.Info("waiting for MNGs")
 
@@ -215,6 +278,7 @@ func (ts *tester) waitForMNGs(now time.Time, tss tupleTimes) (err error) {
 			time.Minute,
 			20*time.Second,
 		)
+		var ng *aws_eks.Nodegroup
 		for sv := range ch {
 			serr := ts.setStatus(sv)
 			if serr != nil {
@@ -222,10 +286,13 @@ func (ts *tester) waitForMNGs(now time.Time, tss tupleTimes) (err error) {
 				return serr
 			}
 			err = sv.Error
+			if sv.NodeGroup != nil {
+				ng = sv.NodeGroup
+			}
 		}
 		cancel()
 		if err != nil {
-			return err
+			return ts.diagnose(mngName, ng, err)
 		}
 
 		cur, ok = ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs[mngName]
@@ -239,7 +306,7 @@ func (ts *tester) waitForMNGs(now time.Time, tss tupleTimes) (err error) {
 
 		timeStart = time.Now()
 		if err := ts.nodeWaiter.Wait(mngName, 10); err != nil {
-			return err
+			return ts.diagnose(mngName, ng, err)
 		}
 		timeEnd = time.Now()
 
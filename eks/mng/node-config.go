@@ -0,0 +1,310 @@
+package mng
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-sdk-go/aws"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const nodeConfigSmokeTestAppName = "node-config-smoke-test"
+
+func (ts *tester) nodeConfigSmokeTestNamespace() string {
+	return ts.cfg.EKSConfig.Name + "-mng-node-config"
+}
+
+func nodeConfigSmokeTestDeploymentName(mngName string) string {
+	return strings.ToLower(nodeConfigSmokeTestAppName + "-" + mngName)
+}
+
+// hasNodeConfig returns true if the managed node group configures
+// node labels, taints, or kubelet flags to be verified.
+func hasNodeConfig(cur eksconfig.MNG) bool {
+	if cur.AMIFamily == ec2config.AMIFamilyWindows {
+		// Windows nodes are verified by the Windows smoke test
+		return false
+	}
+	if len(cur.Labels) > 0 || len(cur.Taints) > 0 {
+		return true
+	}
+	return cur.LaunchTemplate != nil && cur.LaunchTemplate.KubeletExtraArgs != ""
+}
+
+// hasNodeConfigMNG returns true if any managed node group has the node config to verify.
+func (ts *tester) hasNodeConfigMNG() bool {
+	for _, cur := range ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs {
+		if hasNodeConfig(cur) {
+			return true
+		}
+	}
+	return false
+}
+
+// mngTolerations returns the tolerations for all taints of the managed node group.
+func mngTolerations(cur eksconfig.MNG) (tolerations []v1.Toleration) {
+	for _, t := range cur.Taints {
+		tolerations = append(tolerations, v1.Toleration{
+			Key:      t.Key,
+			Operator: v1.TolerationOpEqual,
+			Value:    t.Value,
+			Effect:   v1.TaintEffect(t.Effect),
+		})
+	}
+	return tolerations
+}
+
+// parseKubeletExtraArgs parses the "--flag=value" kubelet flags.
+// Flags without a value are mapped to "true".
+func parseKubeletExtraArgs(args string) map[string]string {
+	flags := make(map[string]string)
+	for _, f := range strings.Fields(args) {
+		if !strings.HasPrefix(f, "--") {
+			continue
+		}
+		f = strings.TrimPrefix(f, "--")
+		if idx := strings.Index(f, "="); idx > 0 {
+			flags[f[:idx]] = f[idx+1:]
+		} else {
+			flags[f] = "true"
+		}
+	}
+	return flags
+}
+
+// createNodeConfigSmokeTests runs a Deployment on each managed node group
+// with node labels, taints, or kubelet flags, selecting the nodes by the labels
+// and tolerating the taints, and checks that the nodes are configured as requested.
+func (ts *tester) createNodeConfigSmokeTests() error {
+	if !ts.hasNodeConfigMNG() {
+		return nil
+	}
+	if err := k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.nodeConfigSmokeTestNamespace(),
+	); err != nil {
+		return err
+	}
+	for mngName, cur := range ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs {
+		if !hasNodeConfig(cur) {
+			continue
+		}
+		if err := ts.createNodeConfigSmokeTest(cur); err != nil {
+			return fmt.Errorf("MNGs[%q] node config smoke test failed (%v)", mngName, err)
+		}
+		if err := ts.checkNodeConfigSmokeTest(cur); err != nil {
+			return fmt.Errorf("MNGs[%q] node config smoke test failed (%v)", mngName, err)
+		}
+	}
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+func (ts *tester) createNodeConfigSmokeTest(cur eksconfig.MNG) error {
+	dpName := nodeConfigSmokeTestDeploymentName(cur.Name)
+	replicas := int32(cur.ASGDesiredCapacity)
+	nodeSelector := map[string]string{
+		"eks.amazonaws.com/nodegroup": cur.Name,
+	}
+	for k, v := range cur.Labels {
+		nodeSelector[k] = v
+	}
+	ts.cfg.Logger.Info("creating node config smoke test Deployment",
+		zap.String("mng-name", cur.Name),
+		zap.String("deployment-name", dpName),
+		zap.Any("node-selector", nodeSelector),
+		zap.Int("taints", len(cur.Taints)),
+		zap.Int32("replicas", replicas),
+	)
+
+	labels := map[string]string{
+		"app.kubernetes.io/name":     nodeConfigSmokeTestAppName,
+		"app.kubernetes.io/instance": dpName,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(ts.nodeConfigSmokeTestNamespace()).
+		Create(
+			ctx,
+			&appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      dpName,
+					Namespace: ts.nodeConfigSmokeTestNamespace(),
+					Labels:    labels,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: aws.Int32(replicas),
+					Selector: &metav1.LabelSelector{
+						MatchLabels: labels,
+					},
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: labels,
+						},
+						Spec: v1.PodSpec{
+							RestartPolicy: v1.RestartPolicyAlways,
+							Containers: []v1.Container{
+								{
+									Name:            nodeConfigSmokeTestAppName,
+									Image:           eksconfig.DefaultMultiArchImage,
+									ImagePullPolicy: v1.PullIfNotPresent,
+									Command:         []string{"sleep", "infinity"},
+								},
+							},
+							// one Pod per node, to verify every node in the group
+							Affinity: &v1.Affinity{
+								PodAntiAffinity: &v1.PodAntiAffinity{
+									RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{
+										{
+											LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+											TopologyKey:   "kubernetes.io/hostname",
+										},
+									},
+								},
+							},
+							NodeSelector: nodeSelector,
+							Tolerations:  mngTolerations(cur),
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create Deployment %q (%v)", dpName, err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Minute)
+	_, err = k8s_client.WaitForDeploymentCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.K8SClient,
+		20*time.Second,
+		10*time.Second,
+		ts.nodeConfigSmokeTestNamespace(),
+		dpName,
+		replicas,
+	)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("created node config smoke test Deployment", zap.String("deployment-name", dpName))
+	return nil
+}
+
+// checkNodeConfigSmokeTest checks that each Pod is scheduled onto
+// a node with the labels, taints, and kubelet flags of the managed node group.
+func (ts *tester) checkNodeConfigSmokeTest(cur eksconfig.MNG) error {
+	dpName := nodeConfigSmokeTestDeploymentName(cur.Name)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	pods, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(ts.nodeConfigSmokeTestNamespace()).
+		List(ctx, metav1.ListOptions{LabelSelector: "app.kubernetes.io/instance=" + dpName})
+	cancel()
+	if err != nil {
+		return err
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("no Pod found for Deployment %q", dpName)
+	}
+
+	var flags map[string]string
+	if cur.LaunchTemplate != nil {
+		flags = parseKubeletExtraArgs(cur.LaunchTemplate.KubeletExtraArgs)
+	}
+	for _, pod := range pods.Items {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		node, err := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			return err
+		}
+		if err = checkNodeConfig(cur, flags, node); err != nil {
+			return fmt.Errorf("Pod %q on node %q: %v", pod.Name, node.Name, err)
+		}
+		ts.cfg.Logger.Info("checked node config smoke test Pod",
+			zap.String("pod-name", pod.Name),
+			zap.String("node-name", node.Name),
+		)
+	}
+	return nil
+}
+
+// checkNodeConfig checks the node labels, taints, and the kubelet flags
+// that are observable from the node object.
+func checkNodeConfig(cur eksconfig.MNG, flags map[string]string, node *v1.Node) error {
+	for k, v := range cur.Labels {
+		if nv, ok := node.Labels[k]; !ok || nv != v {
+			return fmt.Errorf("node label %q expected %q, got %q", k, v, nv)
+		}
+	}
+	for _, t := range cur.Taints {
+		found := false
+		for _, nt := range node.Spec.Taints {
+			if nt.Key == t.Key && nt.Value == t.Value && string(nt.Effect) == t.Effect {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("node taint %s=%s:%s not found", t.Key, t.Value, t.Effect)
+		}
+	}
+
+	if v, ok := flags["node-labels"]; ok {
+		for _, kv := range strings.Split(v, ",") {
+			ss := strings.SplitN(kv, "=", 2)
+			if len(ss) != 2 {
+				continue
+			}
+			if nv := node.Labels[ss[0]]; nv != ss[1] {
+				return fmt.Errorf("kubelet --node-labels %q expected %q, got %q", ss[0], ss[1], nv)
+			}
+		}
+	}
+	if v, ok := flags["max-pods"]; ok {
+		maxPods, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid kubelet --max-pods %q (%v)", v, err)
+		}
+		if np := node.Status.Capacity.Pods().Value(); np != maxPods {
+			return fmt.Errorf("kubelet --max-pods expected %d, got pod capacity %d", maxPods, np)
+		}
+	}
+	return nil
+}
+
+func (ts *tester) deleteNodeConfigSmokeTests() error {
+	if !ts.hasNodeConfigMNG() {
+		return nil
+	}
+	return k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.nodeConfigSmokeTestNamespace(),
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	)
+}
@@ -0,0 +1,63 @@
+package mng
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_parseKubeletExtraArgs(t *testing.T) {
+	flags := parseKubeletExtraArgs("--max-pods=110  --node-labels=a=b,c=d --enable-debugging-handlers ignored")
+	expected := map[string]string{
+		"max-pods":                  "110",
+		"node-labels":               "a=b,c=d",
+		"enable-debugging-handlers": "true",
+	}
+	if !reflect.DeepEqual(flags, expected) {
+		t.Fatalf("expected %v, got %v", expected, flags)
+	}
+}
+
+func Test_checkNodeConfig(t *testing.T) {
+	cur := eksconfig.MNG{
+		Labels: map[string]string{"team": "infra"},
+		Taints: []eksconfig.MNGTaint{{Key: "dedicated", Value: "infra", Effect: "NoSchedule"}},
+	}
+	flags := parseKubeletExtraArgs("--max-pods=110 --node-labels=tier=batch")
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node-1",
+			Labels: map[string]string{"team": "infra", "tier": "batch"},
+		},
+		Spec: v1.NodeSpec{
+			Taints: []v1.Taint{{Key: "dedicated", Value: "infra", Effect: v1.TaintEffectNoSchedule}},
+		},
+		Status: v1.NodeStatus{
+			Capacity: v1.ResourceList{v1.ResourcePods: resource.MustParse("110")},
+		},
+	}
+	if err := checkNodeConfig(cur, flags, node); err != nil {
+		t.Fatal(err)
+	}
+
+	node.Status.Capacity[v1.ResourcePods] = resource.MustParse("29")
+	if err := checkNodeConfig(cur, flags, node); err == nil {
+		t.Fatal("expected --max-pods mismatch error")
+	}
+	node.Status.Capacity[v1.ResourcePods] = resource.MustParse("110")
+
+	node.Spec.Taints[0].Effect = v1.TaintEffectNoExecute
+	if err := checkNodeConfig(cur, flags, node); err == nil {
+		t.Fatal("expected missing taint error")
+	}
+	node.Spec.Taints[0].Effect = v1.TaintEffectNoSchedule
+
+	delete(node.Labels, "team")
+	if err := checkNodeConfig(cur, flags, node); err == nil {
+		t.Fatal("expected missing label error")
+	}
+}
@@ -0,0 +1,56 @@
+package mng
+
+import (
+	"sort"
+
+	nodepolicy "github.com/aws/aws-k8s-tester/eks/node-policy"
+	"github.com/aws/aws-k8s-tester/eks/plan"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"go.uber.org/zap"
+)
+
+// Plan renders the managed node group role policy documents and
+// the EKS create node group requests, without calling AWS APIs.
+func Plan(lg *zap.Logger, cfg *eksconfig.Config) (docs []plan.Document, err error) {
+	if !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return nil, nil
+	}
+	if cfg.AddOnManagedNodeGroups.Role.Create {
+		docs, err = planRole(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	names := make([]string, 0, len(cfg.AddOnManagedNodeGroups.MNGs))
+	for name := range cfg.AddOnManagedNodeGroups.MNGs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cur := cfg.AddOnManagedNodeGroups.MNGs[name]
+		doc, err := plan.NewPayload("managed-node-groups/"+name+"/create-nodegroup.json", newCreateNodegroupInput(lg, cfg, cur))
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+func planRole(cfg *eksconfig.Config) (docs []plan.Document, err error) {
+	doc, err := plan.NewPolicy("managed-node-groups/role-assume-role-policy.json", createAssumeRolePolicyDocument(cfg.AddOnManagedNodeGroups.Role.ServicePrincipals))
+	if err != nil {
+		return nil, err
+	}
+	docs = append(docs, doc)
+	doc, err = plan.NewPolicy("managed-node-groups/role-policy.json", nodepolicy.Document(cfg, cfg.AddOnManagedNodeGroups.Role.PolicyLeastPrivilege))
+	if err != nil {
+		return nil, err
+	}
+	docs = append(docs, doc)
+	doc, err = plan.NewJSON("managed-node-groups/role-managed-policy-arns.json", cfg.AddOnManagedNodeGroups.Role.ManagedPolicyARNs)
+	if err != nil {
+		return nil, err
+	}
+	return append(docs, doc), nil
+}
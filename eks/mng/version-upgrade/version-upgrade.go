@@ -1,4 +1,4 @@
-// Package versionupgrade implements EKS cluster version upgrade tester.
+// Package versionupgrade implements EKS managed node group version upgrade tester.
 package versionupgrade
 
 import (
@@ -24,7 +24,8 @@ import (
 
 // Upgrader defines MNG version upgrade interface.
 type Upgrader interface {
-	// Upgrade starts MNG version upgrade process, and waits for its completion.
+	// Upgrade starts MNG version or AMI release version upgrade process,
+	// and waits for its completion while verifying the workload availability.
 	// ref. https://docs.aws.amazon.com/cli/latest/reference/eks/update-nodegroup-version.html
 	Upgrade(mngName string) error
 }
@@ -99,6 +100,7 @@ func (ts *tester) Upgrade(mngName string) (err error) {
 			zap.String("mng-name", mngName),
 			zap.Float64("cluster-version", ts.cfg.EKSConfig.Status.ServerVersionInfo.VersionValue),
 			zap.String("target-mng-version", cur.VersionUpgrade.Version),
+			zap.String("target-mng-release-version", cur.VersionUpgrade.ReleaseVersion),
 		)
 	case <-ts.cfg.Stopc:
 		sp.Stop()
@@ -106,14 +108,38 @@ func (ts *tester) Upgrade(mngName string) (err error) {
 		return errors.New("MNG veresion upgrade wait aborted")
 	}
 
+	if err = ts.updateConfig(mngName, cur); err != nil {
+		return err
+	}
+
+	if err = ts.createWorkload(mngName, cur); err != nil {
+		return err
+	}
+	defer func() {
+		if derr := ts.deleteWorkload(mngName); derr != nil {
+			ts.cfg.Logger.Warn("failed to delete upgrade workload", zap.String("mng-name", mngName), zap.Error(derr))
+		}
+	}()
+	watchStopc := make(chan struct{})
+	watchDonec := ts.watchWorkload(mngName, cur, watchStopc)
+
 	// ref. https://docs.aws.amazon.com/cli/latest/reference/eks/update-nodegroup-version.html
-	var updateOut *eks.UpdateNodegroupVersionOutput
-	updateOut, err = ts.cfg.EKSAPI.UpdateNodegroupVersion(&eks.UpdateNodegroupVersionInput{
+	updateIn := &eks.UpdateNodegroupVersionInput{
 		ClusterName:   aws.String(ts.cfg.EKSConfig.Name),
 		NodegroupName: aws.String(mngName),
-		Version:       aws.String(cur.VersionUpgrade.Version),
-	})
+	}
+	if cur.VersionUpgrade.Version != "" {
+		updateIn.Version = aws.String(cur.VersionUpgrade.Version)
+	}
+	if cur.VersionUpgrade.ReleaseVersion != "" {
+		updateIn.ReleaseVersion = aws.String(cur.VersionUpgrade.ReleaseVersion)
+	}
+	updateStart := time.Now()
+	var updateOut *eks.UpdateNodegroupVersionOutput
+	updateOut, err = ts.cfg.EKSAPI.UpdateNodegroupVersion(updateIn)
 	if err != nil {
+		close(watchStopc)
+		<-watchDonec
 		ts.cfg.Logger.Warn("MNG version upgrade request failed", zap.String("mng-name", mngName), zap.Error(err))
 		return err
 	}
@@ -185,13 +211,17 @@ func (ts *tester) Upgrade(mngName string) (err error) {
 		err = v.Error
 	}
 	cancel()
+	cur.VersionUpgrade.TimeFrameUpdate = timeutil.NewTimeFrame(updateStart, time.Now())
 	if err != nil {
+		close(watchStopc)
+		<-watchDonec
 		cur.Status = fmt.Sprintf("update failed %v", err)
 		ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs[mngName] = cur
 		ts.cfg.EKSConfig.Sync()
 		return fmt.Errorf("MNGs[%q] update failed %v", mngName, err)
 	}
 
+	activeStart := time.Now()
 	ctx, cancel = context.WithTimeout(context.Background(), totalWait)
 	nodesCh := wait.Poll(
 		ctx,
@@ -209,6 +239,9 @@ func (ts *tester) Upgrade(mngName string) (err error) {
 		err = sv.Error
 	}
 	cancel()
+	cur.VersionUpgrade.TimeFrameActive = timeutil.NewTimeFrame(activeStart, time.Now())
+	close(watchStopc)
+	<-watchDonec
 	if err != nil {
 		cur.Status = fmt.Sprintf("update failed %v", err)
 		ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs[mngName] = cur
@@ -216,6 +249,82 @@ func (ts *tester) Upgrade(mngName string) (err error) {
 		return fmt.Errorf("MNGs[%q] update failed %v", mngName, err)
 	}
 
+	ts.cfg.Logger.Info("MNG upgraded",
+		zap.String("mng-name", mngName),
+		zap.String("update-took", cur.VersionUpgrade.TimeFrameUpdate.TookString),
+		zap.String("active-took", cur.VersionUpgrade.TimeFrameActive.TookString),
+		zap.Int32("workload-min-available", cur.VersionUpgrade.WorkloadMinAvailable),
+		zap.Int32("workload-min-available-observed", cur.VersionUpgrade.WorkloadMinAvailableObserved),
+	)
+	if needWorkload(cur) && cur.VersionUpgrade.WorkloadMinAvailableObserved < cur.VersionUpgrade.WorkloadMinAvailable {
+		return fmt.Errorf("MNGs[%q] workload availability dropped to %d during upgrade (expected at least %d)",
+			mngName,
+			cur.VersionUpgrade.WorkloadMinAvailableObserved,
+			cur.VersionUpgrade.WorkloadMinAvailable,
+		)
+	}
+
 	ts.cfg.EKSConfig.Sync()
 	return nil
 }
+
+// updateConfig applies the max-unavailable update config before the upgrade.
+// ref. https://docs.aws.amazon.com/cli/latest/reference/eks/update-nodegroup-config.html
+func (ts *tester) updateConfig(mngName string, cur eksconfig.MNG) error {
+	if cur.VersionUpgrade.MaxUnavailable == 0 && cur.VersionUpgrade.MaxUnavailablePercentage == 0 {
+		ts.cfg.Logger.Info("no MNG update config; skipping", zap.String("mng-name", mngName))
+		return nil
+	}
+
+	updateConfig := &eks.NodegroupUpdateConfig{}
+	if cur.VersionUpgrade.MaxUnavailable > 0 {
+		updateConfig.MaxUnavailable = aws.Int64(cur.VersionUpgrade.MaxUnavailable)
+	} else {
+		updateConfig.MaxUnavailablePercentage = aws.Int64(cur.VersionUpgrade.MaxUnavailablePercentage)
+	}
+	ts.cfg.Logger.Info("updating MNG update config",
+		zap.String("mng-name", mngName),
+		zap.Int64("max-unavailable", cur.VersionUpgrade.MaxUnavailable),
+		zap.Int64("max-unavailable-percentage", cur.VersionUpgrade.MaxUnavailablePercentage),
+	)
+	updateConfigStart := time.Now()
+	out, err := ts.cfg.EKSAPI.UpdateNodegroupConfig(&eks.UpdateNodegroupConfigInput{
+		ClusterName:   aws.String(ts.cfg.EKSConfig.Name),
+		NodegroupName: aws.String(mngName),
+		UpdateConfig:  updateConfig,
+	})
+	if err != nil {
+		return fmt.Errorf("MNGs[%q] update config failed %v", mngName, err)
+	}
+	reqID := ""
+	if out.Update != nil {
+		reqID = aws.StringValue(out.Update.Id)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	updateCh := wait.PollUpdate(
+		ctx,
+		ts.cfg.Stopc,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.EKSAPI,
+		ts.cfg.EKSConfig.Name,
+		mngName,
+		reqID,
+		eks.UpdateStatusSuccessful,
+		10*time.Second,
+		10*time.Second,
+	)
+	for v := range updateCh {
+		err = v.Error
+	}
+	cancel()
+	cur.VersionUpgrade.TimeFrameUpdateConfig = timeutil.NewTimeFrame(updateConfigStart, time.Now())
+	ts.cfg.EKSConfig.Sync()
+	if err != nil {
+		return fmt.Errorf("MNGs[%q] update config failed %v", mngName, err)
+	}
+
+	ts.cfg.Logger.Info("updated MNG update config", zap.String("mng-name", mngName))
+	return nil
+}
@@ -0,0 +1,227 @@
+package versionupgrade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-sdk-go/aws"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// the workload runs in "default" namespace, named after the node group
+const (
+	workloadNamespace = "default"
+	workloadImage     = eksconfig.DefaultPauseImage
+)
+
+func workloadName(mngName string) string {
+	return mngName + "-upgrade-workload"
+}
+
+// needWorkload returns true if the workload can run on the node group.
+// The workload image is Linux only.
+func needWorkload(cur eksconfig.MNG) bool {
+	return cur.VersionUpgrade.WorkloadReplicas > 0 && cur.AMIFamily != ec2config.AMIFamilyWindows
+}
+
+// createWorkload creates the Deployment pinned to the node group with
+// a PodDisruptionBudget, so the node drains during the upgrade respect
+// the minimum availability, and waits for the Deployment to be ready.
+func (ts *tester) createWorkload(mngName string, cur eksconfig.MNG) error {
+	if !needWorkload(cur) {
+		ts.cfg.Logger.Info("skipping upgrade workload", zap.String("mng-name", mngName), zap.String("ami-family", cur.AMIFamily))
+		return nil
+	}
+	name := workloadName(mngName)
+	labels := map[string]string{"app.kubernetes.io/name": name}
+
+	ts.cfg.Logger.Info("creating upgrade workload",
+		zap.String("mng-name", mngName),
+		zap.Int32("replicas", cur.VersionUpgrade.WorkloadReplicas),
+		zap.Int32("min-available", cur.VersionUpgrade.WorkloadMinAvailable),
+	)
+	minAvailable := intstr.FromInt(int(cur.VersionUpgrade.WorkloadMinAvailable))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		PolicyV1().
+		PodDisruptionBudgets(workloadNamespace).
+		Create(
+			ctx,
+			&policyv1.PodDisruptionBudget{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: workloadNamespace,
+				},
+				Spec: policyv1.PodDisruptionBudgetSpec{
+					MinAvailable: &minAvailable,
+					Selector:     &metav1.LabelSelector{MatchLabels: labels},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create upgrade workload PodDisruptionBudget (%v)", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(workloadNamespace).
+		Create(
+			ctx,
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: workloadNamespace,
+					Labels:    labels,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: aws.Int32(cur.VersionUpgrade.WorkloadReplicas),
+					Selector: &metav1.LabelSelector{MatchLabels: labels},
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						Spec: v1.PodSpec{
+							RestartPolicy: v1.RestartPolicyAlways,
+							Containers: []v1.Container{
+								{
+									Name:            "pause",
+									Image:           ts.cfg.EKSConfig.Image(workloadImage),
+									ImagePullPolicy: v1.PullIfNotPresent,
+								},
+							},
+							NodeSelector: map[string]string{
+								"NGName": mngName,
+							},
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create upgrade workload Deployment (%v)", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Minute)
+	_, err = k8s_client.WaitForDeploymentCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.K8SClient,
+		10*time.Second,
+		10*time.Second,
+		workloadNamespace,
+		name,
+		cur.VersionUpgrade.WorkloadReplicas,
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("upgrade workload not ready (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created upgrade workload", zap.String("mng-name", mngName))
+	return nil
+}
+
+func (ts *tester) deleteWorkload(mngName string) error {
+	name := workloadName(mngName)
+	ts.cfg.Logger.Info("deleting upgrade workload", zap.String("mng-name", mngName))
+
+	var errs []string
+	foreground := metav1.DeletePropagationForeground
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(workloadNamespace).
+		Delete(
+			ctx,
+			name,
+			metav1.DeleteOptions{
+				GracePeriodSeconds: aws.Int64(0),
+				PropagationPolicy:  &foreground,
+			},
+		)
+	cancel()
+	if err != nil && !apierrs.IsNotFound(err) {
+		errs = append(errs, fmt.Sprintf("failed to delete upgrade workload Deployment (%v)", err))
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	err = ts.cfg.K8SClient.KubernetesClientSet().
+		PolicyV1().
+		PodDisruptionBudgets(workloadNamespace).
+		Delete(ctx, name, metav1.DeleteOptions{})
+	cancel()
+	if err != nil && !apierrs.IsNotFound(err) {
+		errs = append(errs, fmt.Sprintf("failed to delete upgrade workload PodDisruptionBudget (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	ts.cfg.Logger.Info("deleted upgrade workload", zap.String("mng-name", mngName))
+	return nil
+}
+
+// watchWorkload polls the workload available replicas until "stopc" is closed,
+// and records the lowest observed on exit. The returned channel is closed on exit.
+func (ts *tester) watchWorkload(mngName string, cur eksconfig.MNG, stopc chan struct{}) <-chan struct{} {
+	donec := make(chan struct{})
+	if !needWorkload(cur) {
+		close(donec)
+		return donec
+	}
+
+	name := workloadName(mngName)
+	go func() {
+		observed := cur.VersionUpgrade.WorkloadReplicas
+		defer func() {
+			cur.VersionUpgrade.WorkloadMinAvailableObserved = observed
+			close(donec)
+		}()
+		for {
+			select {
+			case <-stopc:
+				return
+			case <-ts.cfg.Stopc:
+				return
+			case <-time.After(5 * time.Second):
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			dp, err := ts.cfg.K8SClient.KubernetesClientSet().
+				AppsV1().
+				Deployments(workloadNamespace).
+				Get(ctx, name, metav1.GetOptions{})
+			cancel()
+			if err != nil {
+				ts.cfg.Logger.Warn("failed to get upgrade workload", zap.String("mng-name", mngName), zap.Error(err))
+				continue
+			}
+			if dp.Status.AvailableReplicas < observed {
+				observed = dp.Status.AvailableReplicas
+				ts.cfg.Logger.Info("upgrade workload availability dropped",
+					zap.String("mng-name", mngName),
+					zap.Int32("available-replicas", dp.Status.AvailableReplicas),
+					zap.Int32("min-available", cur.VersionUpgrade.WorkloadMinAvailable),
+				)
+			}
+		}
+	}()
+	return donec
+}
@@ -0,0 +1,302 @@
+package mng
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-sdk-go/aws"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// hasWindowsMNG returns true if any managed node group runs Windows.
+func (ts *tester) hasWindowsMNG() bool {
+	for _, cur := range ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs {
+		if cur.AMIFamily == ec2config.AMIFamilyWindows {
+			return true
+		}
+	}
+	return false
+}
+
+// enableWindowsIPAM enables the IP address management for Windows nodes
+// in the VPC resource controller, required to run Pods on Windows nodes.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/windows-support.html
+func (ts *tester) enableWindowsIPAM() error {
+	ts.cfg.Logger.Info("enabling Windows IPAM")
+	cli := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().ConfigMaps("kube-system")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	cm, err := cli.Get(ctx, "amazon-vpc-cni", metav1.GetOptions{})
+	cancel()
+	switch {
+	case apierrs.IsNotFound(err):
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		_, err = cli.Create(ctx, &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "amazon-vpc-cni",
+				Namespace: "kube-system",
+			},
+			Data: map[string]string{"enable-windows-ipam": "true"},
+		}, metav1.CreateOptions{})
+		cancel()
+	case err == nil:
+		if cm.Data["enable-windows-ipam"] == "true" {
+			ts.cfg.Logger.Info("Windows IPAM already enabled")
+			return nil
+		}
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data["enable-windows-ipam"] = "true"
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		_, err = cli.Update(ctx, cm, metav1.UpdateOptions{})
+		cancel()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to enable Windows IPAM (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("enabled Windows IPAM")
+	return nil
+}
+
+const (
+	windowsSmokeTestAppName = "windows-smoke-test"
+	windowsSmokeTestOutput  = "windows smoke test OK"
+)
+
+func (ts *tester) windowsSmokeTestNamespace() string {
+	return ts.cfg.EKSConfig.Name + "-mng-windows"
+}
+
+func windowsSmokeTestDeploymentName(mngName string) string {
+	return strings.ToLower(windowsSmokeTestAppName + "-" + mngName)
+}
+
+// windowsSmokeTestImage returns the container image that matches
+// the Windows Server version of the AMI, since Windows containers
+// require the same host OS version with process isolation.
+func windowsSmokeTestImage(amiType string) string {
+	switch amiType {
+	case eksconfig.MNGAMITypeWindowsCore2022X8664,
+		eksconfig.MNGAMITypeWindowsFull2022X8664:
+		return "mcr.microsoft.com/windows/servercore:ltsc2022"
+	default:
+		return "mcr.microsoft.com/windows/servercore:ltsc2019"
+	}
+}
+
+// createWindowsSmokeTests runs a Deployment on each Windows managed node group,
+// and checks that the Pods are running and logging on Windows nodes.
+func (ts *tester) createWindowsSmokeTests() error {
+	if !ts.hasWindowsMNG() {
+		return nil
+	}
+	if err := k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.windowsSmokeTestNamespace(),
+	); err != nil {
+		return err
+	}
+	for mngName, cur := range ts.cfg.EKSConfig.AddOnManagedNodeGroups.MNGs {
+		if cur.AMIFamily != ec2config.AMIFamilyWindows {
+			continue
+		}
+		if err := ts.createWindowsSmokeTest(cur); err != nil {
+			return fmt.Errorf("MNGs[%q] Windows smoke test failed (%v)", mngName, err)
+		}
+		if err := ts.checkWindowsSmokeTest(cur); err != nil {
+			return fmt.Errorf("MNGs[%q] Windows smoke test failed (%v)", mngName, err)
+		}
+	}
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+func (ts *tester) createWindowsSmokeTest(cur eksconfig.MNG) error {
+	dpName := windowsSmokeTestDeploymentName(cur.Name)
+	image := windowsSmokeTestImage(cur.AMIType)
+	replicas := int32(cur.ASGDesiredCapacity)
+	ts.cfg.Logger.Info("creating Windows smoke test Deployment",
+		zap.String("mng-name", cur.Name),
+		zap.String("deployment-name", dpName),
+		zap.String("image", image),
+		zap.Int32("replicas", replicas),
+	)
+
+	labels := map[string]string{
+		"app.kubernetes.io/name":     windowsSmokeTestAppName,
+		"app.kubernetes.io/instance": dpName,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(ts.windowsSmokeTestNamespace()).
+		Create(
+			ctx,
+			&appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      dpName,
+					Namespace: ts.windowsSmokeTestNamespace(),
+					Labels:    labels,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: aws.Int32(replicas),
+					Selector: &metav1.LabelSelector{
+						MatchLabels: labels,
+					},
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: labels,
+						},
+						Spec: v1.PodSpec{
+							RestartPolicy: v1.RestartPolicyAlways,
+							Containers: []v1.Container{
+								{
+									Name:            windowsSmokeTestAppName,
+									Image:           image,
+									ImagePullPolicy: v1.PullIfNotPresent,
+									Command: []string{
+										"powershell.exe",
+										"-Command",
+										fmt.Sprintf("Write-Output '%s'; while ($true) { Start-Sleep -Seconds 3600 }", windowsSmokeTestOutput),
+									},
+								},
+							},
+							// one Pod per node, to run on every Windows node
+							Affinity: &v1.Affinity{
+								PodAntiAffinity: &v1.PodAntiAffinity{
+									RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{
+										{
+											LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+											TopologyKey:   "kubernetes.io/hostname",
+										},
+									},
+								},
+							},
+							NodeSelector: map[string]string{
+								"kubernetes.io/os":            "windows",
+								"eks.amazonaws.com/nodegroup": cur.Name,
+							},
+							Tolerations: mngTolerations(cur),
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create Deployment %q (%v)", dpName, err)
+	}
+
+	// Windows container images take a while to pull
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Minute)
+	_, err = k8s_client.WaitForDeploymentCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.K8SClient,
+		time.Minute,
+		20*time.Second,
+		ts.windowsSmokeTestNamespace(),
+		dpName,
+		replicas,
+	)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("created Windows smoke test Deployment", zap.String("deployment-name", dpName))
+	return nil
+}
+
+// checkWindowsSmokeTest checks that each Pod is scheduled onto
+// a Windows node, and that its logs are available.
+func (ts *tester) checkWindowsSmokeTest(cur eksconfig.MNG) error {
+	dpName := windowsSmokeTestDeploymentName(cur.Name)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	pods, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(ts.windowsSmokeTestNamespace()).
+		List(ctx, metav1.ListOptions{LabelSelector: "app.kubernetes.io/instance=" + dpName})
+	cancel()
+	if err != nil {
+		return err
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("no Pod found for Deployment %q", dpName)
+	}
+
+	for _, pod := range pods.Items {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		node, err := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			return err
+		}
+		if osLabel := node.Labels["kubernetes.io/os"]; osLabel != "windows" {
+			return fmt.Errorf("Pod %q scheduled onto node %q with unexpected OS %q", pod.Name, node.Name, osLabel)
+		}
+
+		var out []byte
+		retryStart := time.Now()
+		for time.Since(retryStart) < 2*time.Minute {
+			ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
+			out, err = ts.cfg.K8SClient.KubernetesClientSet().
+				CoreV1().
+				Pods(ts.windowsSmokeTestNamespace()).
+				GetLogs(pod.Name, &v1.PodLogOptions{}).
+				DoRaw(ctx)
+			cancel()
+			if err == nil && strings.Contains(string(out), windowsSmokeTestOutput) {
+				break
+			}
+			ts.cfg.Logger.Warn("unexpected Windows smoke test Pod logs; retrying", zap.String("pod-name", pod.Name), zap.Error(err))
+			select {
+			case <-ts.cfg.Stopc:
+				return errors.New("Windows smoke test aborted")
+			case <-time.After(5 * time.Second):
+			}
+		}
+		if !strings.Contains(string(out), windowsSmokeTestOutput) {
+			return fmt.Errorf("Pod %q logs missing %q (%v)", pod.Name, windowsSmokeTestOutput, err)
+		}
+		ts.cfg.Logger.Info("checked Windows smoke test Pod",
+			zap.String("pod-name", pod.Name),
+			zap.String("node-name", node.Name),
+		)
+	}
+	return nil
+}
+
+func (ts *tester) deleteWindowsSmokeTests() error {
+	if !ts.hasWindowsMNG() {
+		return nil
+	}
+	return k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.windowsSmokeTestNamespace(),
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	)
+}
@@ -0,0 +1,321 @@
+// Package multiarch runs a multi-architecture image on every node
+// architecture in the cluster (e.g. Graviton arm64 and x86_64 amd64),
+// to catch architecture specific regressions in the same test run.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/eks-optimized-ami.html#arm-ami
+package multiarch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/ec2config"
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Config defines multi-architecture tester configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+}
+
+const (
+	jobNamePrefix = "multi-arch-"
+
+	// labelArch is the well-known node label for the node architecture.
+	labelArch = "kubernetes.io/arch"
+	labelOS   = "kubernetes.io/os"
+)
+
+// machines maps the node architecture to the expected "uname -m" output.
+var machines = map[string]string{
+	ec2config.ArchitectureAMD64: "x86_64",
+	ec2config.ArchitectureARM64: "aarch64",
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new multi-architecture tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "multi-arch",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+			}), cfg.EKSConfig.IsEnabledAddOnMultiArch)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnMultiArch() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnMultiArch.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnMultiArch.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnMultiArch.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnMultiArch.Namespace,
+	); err != nil {
+		return err
+	}
+
+	if ts.cfg.EKSConfig.AddOnMultiArch.Results == nil {
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnMultiArch.Results = make(map[string]eksconfig.MultiArchResult)
+		})
+	}
+	for _, arch := range ts.cfg.EKSConfig.AddOnMultiArch.Architectures {
+		if err = ts.createJob(arch); err != nil {
+			return err
+		}
+	}
+	// wait after creating all Jobs, so the architectures run in parallel
+	for _, arch := range ts.cfg.EKSConfig.AddOnMultiArch.Architectures {
+		if err = ts.checkJob(arch); err != nil {
+			return err
+		}
+	}
+
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnMultiArch() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnMultiArch.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnMultiArch.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnMultiArch.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete multi-arch namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnMultiArch.Created = false
+	})
+	return nil
+}
+
+// createJob creates the Job to run "uname -m" on the nodes of the architecture.
+func (ts *tester) createJob(arch string) error {
+	cur := ts.cfg.EKSConfig.AddOnMultiArch
+	jobName := jobNamePrefix + arch
+	ts.cfg.Logger.Info("creating multi-arch Job",
+		zap.String("job-name", jobName),
+		zap.String("arch", arch),
+		zap.String("image", cur.Image),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		BatchV1().
+		Jobs(cur.Namespace).
+		Create(
+			ctx,
+			&batchv1.Job{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "batch/v1",
+					Kind:       "Job",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      jobName,
+					Namespace: cur.Namespace,
+				},
+				Spec: batchv1.JobSpec{
+					Completions:  aws_v2.Int32(cur.Completes),
+					Parallelism:  aws_v2.Int32(cur.Completes),
+					BackoffLimit: aws_v2.Int32(3),
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{
+								"job-name": jobName,
+							},
+						},
+						Spec: v1.PodSpec{
+							RestartPolicy: v1.RestartPolicyNever,
+							NodeSelector: map[string]string{
+								labelArch: arch,
+								labelOS:   "linux",
+							},
+							Containers: []v1.Container{
+								{
+									Name:    jobName,
+									Image:   cur.Image,
+									Command: []string{"uname", "-m"},
+								},
+							},
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create multi-arch Job %q (%v)", jobName, err)
+	}
+
+	ts.cfg.Logger.Info("created multi-arch Job", zap.String("job-name", jobName))
+	return nil
+}
+
+// checkJob waits for the Job to complete, and checks that every Pod
+// ran on a node of the architecture with the expected "uname -m" output.
+func (ts *tester) checkJob(arch string) error {
+	cur := ts.cfg.EKSConfig.AddOnMultiArch
+	jobName := jobNamePrefix + arch
+	expected, ok := machines[arch]
+	if !ok {
+		return fmt.Errorf("unknown architecture %q", arch)
+	}
+
+	jobStart := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	_, pods, err := k8s_client.WaitForJobCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.K8SClient,
+		10*time.Second,
+		5*time.Second,
+		cur.Namespace,
+		jobName,
+		int(cur.Completes),
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("multi-arch Job %q failed (%v)", jobName, err)
+	}
+	jobEnd := time.Now()
+
+	nodes := make(map[string]struct{})
+	for _, pod := range pods {
+		if pod.Status.Phase != v1.PodSucceeded {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		node, err := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to get node %q (%v)", pod.Spec.NodeName, err)
+		}
+		if na := node.Labels[labelArch]; na != arch {
+			return fmt.Errorf("Pod %q scheduled on node %q with architecture %q (expected %q)", pod.Name, node.Name, na, arch)
+		}
+
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		b, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Pods(cur.Namespace).
+			GetLogs(pod.Name, &v1.PodLogOptions{}).
+			DoRaw(ctx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to get multi-arch Pod %q logs (%v)", pod.Name, err)
+		}
+		out := strings.TrimSpace(string(b))
+		fmt.Fprintf(ts.cfg.LogWriter, "\n'uname -m' output from %q on %q (%s): %s\n", pod.Name, node.Name, arch, out)
+		if out != expected {
+			return fmt.Errorf("unexpected 'uname -m' output %q from %q on %q (expected %q)", out, pod.Name, node.Name, expected)
+		}
+		nodes[node.Name] = struct{}{}
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("no succeeded multi-arch Pod found for %q", arch)
+	}
+
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.Results[arch] = eksconfig.MultiArchResult{
+			Machine:   expected,
+			NodeNames: names,
+			TimeFrame: timeutil.NewTimeFrame(jobStart, jobEnd),
+		}
+	})
+
+	ts.cfg.Logger.Info("checked multi-arch Job",
+		zap.String("job-name", jobName),
+		zap.String("arch", arch),
+		zap.Strings("node-names", names),
+	)
+	return nil
+}
@@ -0,0 +1,621 @@
+// Package failover implements the cross-region failover scenario for the
+// multi-cluster run. It deploys the same app to the primary and secondary
+// clusters behind Route53 failover records with health checks, kills the
+// primary's node groups, and measures how long the DNS takes to fail over.
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Spec defines the failover scenario in the multi-cluster configuration.
+type Spec struct {
+	// Enable is 'true' to run the scenario after all clusters are created.
+	// The primary cluster is left without nodes.
+	Enable bool `json:"enable"`
+	// HostedZoneID is the Route53 hosted zone to create the failover records in.
+	HostedZoneID string `json:"hosted-zone-id"`
+	// RecordName is the failover record name in the hosted zone
+	// (e.g. "failover.example.com").
+	RecordName string `json:"record-name"`
+	// Primary is the name of the cluster serving the record
+	// while healthy. Defaults to the first cluster.
+	Primary string `json:"primary,omitempty"`
+	// Secondary is the name of the cluster to fail over to.
+	// Defaults to the second cluster.
+	Secondary string `json:"secondary,omitempty"`
+	// Namespace is the namespace to deploy the app to in both clusters.
+	Namespace string `json:"namespace,omitempty"`
+	// Image is the app image, serving HTTP on port 80.
+	Image string `json:"image,omitempty"`
+	// FailureThreshold is the number of consecutive Route53 health checks
+	// to fail before the endpoint is considered unhealthy.
+	FailureThreshold int64 `json:"failure-threshold,omitempty"`
+	// Timeout is the timeout of the whole scenario.
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+const (
+	defaultNamespace        = "failover"
+	defaultImage            = "dockercloud/hello-world"
+	defaultFailureThreshold = 1
+	defaultTimeout          = 30 * time.Minute
+
+	appName  = "failover"
+	replicas = 2
+
+	// recordTTL is the TTL of the failover records in seconds,
+	// which adds up to the failover time observed by the clients.
+	recordTTL = 10
+	// healthCheckInterval is the Route53 "fast" health check interval in seconds.
+	healthCheckInterval = 10
+	// healthyRatio is the ratio of the Route53 health checkers to report
+	// success for the endpoint to be considered healthy.
+	healthyRatio = 0.18
+)
+
+// ValidateAndSetDefaults validates the scenario against the cluster names
+// in the order of the multi-cluster configuration.
+func (s *Spec) ValidateAndSetDefaults(clusters []string) error {
+	if !s.Enable {
+		return nil
+	}
+	if s.HostedZoneID == "" {
+		return errors.New("empty failover.hosted-zone-id")
+	}
+	if s.RecordName == "" {
+		return errors.New("empty failover.record-name")
+	}
+	if len(clusters) < 2 {
+		return fmt.Errorf("failover requires at least 2 clusters, got %d", len(clusters))
+	}
+	if s.Primary == "" {
+		s.Primary = clusters[0]
+	}
+	if s.Secondary == "" {
+		s.Secondary = clusters[1]
+	}
+	if s.Primary == s.Secondary {
+		return fmt.Errorf("failover.primary and failover.secondary are the same cluster %q", s.Primary)
+	}
+	for _, name := range []string{s.Primary, s.Secondary} {
+		found := false
+		for _, c := range clusters {
+			if c == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("failover cluster %q not found in clusters", name)
+		}
+	}
+	if s.Namespace == "" {
+		s.Namespace = defaultNamespace
+	}
+	if s.Image == "" {
+		s.Image = defaultImage
+	}
+	if s.FailureThreshold == 0 {
+		s.FailureThreshold = defaultFailureThreshold
+	}
+	if s.FailureThreshold < 1 || s.FailureThreshold > 10 {
+		return fmt.Errorf("failover.failure-threshold must be 1 to 10, got %d", s.FailureThreshold)
+	}
+	if s.Timeout == 0 {
+		s.Timeout = defaultTimeout
+	}
+	return nil
+}
+
+// Cluster is the cluster in the scenario, with the clients in its region.
+type Cluster struct {
+	EKSConfig *eksconfig.Config
+	Clientset kubernetes.Interface
+	ASGAPI    autoscalingiface.AutoScalingAPI
+	EC2APIV2  EC2APIV2
+}
+
+// EC2APIV2 is the subset of the v2 EC2 client used to kill the nodes.
+type EC2APIV2 interface {
+	TerminateInstances(ctx context.Context, params *aws_ec2_v2.TerminateInstancesInput, optFns ...func(*aws_ec2_v2.Options)) (*aws_ec2_v2.TerminateInstancesOutput, error)
+}
+
+// Config defines the failover scenario configuration.
+type Config struct {
+	Logger     *zap.Logger
+	Stopc      chan struct{}
+	Spec       Spec
+	Primary    Cluster
+	Secondary  Cluster
+	Route53API route53iface.Route53API
+}
+
+// Result is the result of the scenario.
+type Result struct {
+	RecordName        string `json:"record-name"`
+	RecordTTL         int64  `json:"record-ttl"`
+	PrimaryCluster    string `json:"primary-cluster"`
+	PrimaryHostname   string `json:"primary-hostname,omitempty"`
+	SecondaryCluster  string `json:"secondary-cluster"`
+	SecondaryHostname string `json:"secondary-hostname,omitempty"`
+	// KilledASGs are the primary's node group ASGs scaled down to zero.
+	KilledASGs []string `json:"killed-asgs,omitempty"`
+	// NodesKilled is the time when the primary's nodes were killed.
+	NodesKilled time.Time `json:"nodes-killed,omitempty"`
+	// HealthCheckFailedTook is the time from "NodesKilled"
+	// to the primary health check reported unhealthy.
+	HealthCheckFailedTook       time.Duration `json:"health-check-failed-took,omitempty"`
+	HealthCheckFailedTookString string        `json:"health-check-failed-took-string,omitempty"`
+	// FailoverTook is the time from "NodesKilled" to the Route53
+	// answering the secondary, excluding "RecordTTL".
+	FailoverTook       time.Duration `json:"failover-took,omitempty"`
+	FailoverTookString string        `json:"failover-took-string,omitempty"`
+	Succeeded          bool          `json:"succeeded"`
+	Error              string        `json:"error,omitempty"`
+}
+
+// pollInterval is the interval to poll the Kubernetes and Route53 APIs.
+var pollInterval = 5 * time.Second
+
+type tester struct {
+	cfg      Config
+	deadline time.Time
+	res      *Result
+
+	healthChecks map[string]string // host name to health check ID
+	records      []*route53.ResourceRecordSet
+}
+
+// Run runs the scenario and cleans up the app, records, and health checks.
+// The result is returned even on failure, with the measured fields set.
+func Run(cfg Config) (*Result, error) {
+	ts := &tester{
+		cfg:      cfg,
+		deadline: time.Now().Add(cfg.Spec.Timeout),
+		res: &Result{
+			RecordName:       cfg.Spec.RecordName,
+			RecordTTL:        recordTTL,
+			PrimaryCluster:   cfg.Primary.EKSConfig.Name,
+			SecondaryCluster: cfg.Secondary.EKSConfig.Name,
+		},
+		healthChecks: make(map[string]string),
+	}
+	err := ts.run()
+	if cerr := ts.cleanup(); cerr != nil {
+		if err == nil {
+			err = cerr
+		} else {
+			ts.cfg.Logger.Warn("failed to clean up failover scenario", zap.Error(cerr))
+		}
+	}
+	ts.res.Succeeded = err == nil
+	if err != nil {
+		ts.res.Error = err.Error()
+	}
+	return ts.res, err
+}
+
+func (ts *tester) run() (err error) {
+	ts.cfg.Logger.Info("starting failover scenario",
+		zap.String("record-name", ts.cfg.Spec.RecordName),
+		zap.String("primary", ts.res.PrimaryCluster),
+		zap.String("secondary", ts.res.SecondaryCluster),
+	)
+	if ts.res.PrimaryHostname, err = ts.deploy(ts.cfg.Primary); err != nil {
+		return err
+	}
+	if ts.res.SecondaryHostname, err = ts.deploy(ts.cfg.Secondary); err != nil {
+		return err
+	}
+	if err = ts.createRecords(); err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("waiting for health checks and primary answer")
+	if err = ts.poll("healthy primary and secondary", func() (bool, error) {
+		for _, host := range []string{ts.res.PrimaryHostname, ts.res.SecondaryHostname} {
+			healthy, _, err := ts.healthy(host)
+			if err != nil || !healthy {
+				return false, err
+			}
+		}
+		return ts.answers(ts.res.PrimaryHostname)
+	}); err != nil {
+		return err
+	}
+
+	if err = ts.killNodes(ts.cfg.Primary); err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("waiting for failover to secondary")
+	if err = ts.poll("failover to secondary", func() (bool, error) {
+		if ts.res.HealthCheckFailedTook == 0 {
+			healthy, observed, err := ts.healthy(ts.res.PrimaryHostname)
+			if err != nil {
+				return false, err
+			}
+			if observed && !healthy {
+				ts.res.HealthCheckFailedTook = time.Since(ts.res.NodesKilled)
+				ts.res.HealthCheckFailedTookString = ts.res.HealthCheckFailedTook.Round(time.Second).String()
+				ts.cfg.Logger.Info("primary health check failed", zap.String("took", ts.res.HealthCheckFailedTookString))
+			}
+		}
+		return ts.answers(ts.res.SecondaryHostname)
+	}); err != nil {
+		return err
+	}
+	ts.res.FailoverTook = time.Since(ts.res.NodesKilled)
+	ts.res.FailoverTookString = ts.res.FailoverTook.Round(time.Second).String()
+	ts.cfg.Logger.Info("failed over to secondary",
+		zap.String("record-name", ts.cfg.Spec.RecordName),
+		zap.String("took", ts.res.FailoverTookString),
+	)
+	return nil
+}
+
+// poll calls the function until done or the scenario times out.
+func (ts *tester) poll(desc string, fn func() (bool, error)) error {
+	for {
+		done, err := fn()
+		if err != nil {
+			ts.cfg.Logger.Warn("poll failed; retrying", zap.String("desc", desc), zap.Error(err))
+		} else if done {
+			return nil
+		}
+		if time.Now().After(ts.deadline) {
+			return fmt.Errorf("timed out waiting for %s", desc)
+		}
+		select {
+		case <-ts.cfg.Stopc:
+			return fmt.Errorf("waiting for %s aborted", desc)
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// deploy creates the app Deployment and the NLB Service,
+// and returns the load balancer host name.
+func (ts *tester) deploy(c Cluster) (host string, err error) {
+	ns := ts.cfg.Spec.Namespace
+	ts.cfg.Logger.Info("deploying failover app", zap.String("cluster", c.EKSConfig.Name), zap.String("namespace", ns))
+	if err = k8s_client.CreateNamespace(ts.cfg.Logger, c.Clientset, ns); err != nil {
+		return "", err
+	}
+
+	labels := map[string]string{"app.kubernetes.io/name": appName}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err = c.Clientset.AppsV1().Deployments(ns).Create(
+		ctx,
+		&appsv1.Deployment{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      appName,
+				Namespace: ns,
+				Labels:    labels,
+			},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: aws.Int32(replicas),
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: v1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec: v1.PodSpec{
+						RestartPolicy: v1.RestartPolicyAlways,
+						Containers: []v1.Container{
+							{
+								Name:            appName,
+								Image:           ts.cfg.Spec.Image,
+								ImagePullPolicy: v1.PullIfNotPresent,
+								Ports: []v1.ContainerPort{
+									{
+										Protocol:      v1.ProtocolTCP,
+										ContainerPort: 80,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		metav1.CreateOptions{},
+	)
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("failed to create failover Deployment in %q (%v)", c.EKSConfig.Name, err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = c.Clientset.CoreV1().Services(ns).Create(
+		ctx,
+		&v1.Service{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Service",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      appName,
+				Namespace: ns,
+				Annotations: map[string]string{
+					"service.beta.kubernetes.io/aws-load-balancer-type": "nlb",
+				},
+			},
+			Spec: v1.ServiceSpec{
+				Selector: labels,
+				Type:     v1.ServiceTypeLoadBalancer,
+				Ports: []v1.ServicePort{
+					{
+						Protocol:   v1.ProtocolTCP,
+						Port:       80,
+						TargetPort: intstr.FromInt(80),
+					},
+				},
+			},
+		},
+		metav1.CreateOptions{},
+	)
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("failed to create failover Service in %q (%v)", c.EKSConfig.Name, err)
+	}
+
+	err = ts.poll("failover Service load balancer in "+c.EKSConfig.Name, func() (bool, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		so, err := c.Clientset.CoreV1().Services(ns).Get(ctx, appName, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			return false, err
+		}
+		for _, ing := range so.Status.LoadBalancer.Ingress {
+			if ing.Hostname != "" {
+				host = ing.Hostname
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	ts.cfg.Logger.Info("deployed failover app", zap.String("cluster", c.EKSConfig.Name), zap.String("host-name", host))
+	return host, nil
+}
+
+// createRecords creates the health checks and the PRIMARY and SECONDARY
+// failover records for the load balancers.
+func (ts *tester) createRecords() error {
+	for _, host := range []string{ts.res.PrimaryHostname, ts.res.SecondaryHostname} {
+		out, err := ts.cfg.Route53API.CreateHealthCheck(&route53.CreateHealthCheckInput{
+			CallerReference: aws.String(fmt.Sprintf("aws-k8s-tester-%d", time.Now().UnixNano())),
+			HealthCheckConfig: &route53.HealthCheckConfig{
+				Type:                     aws.String(route53.HealthCheckTypeHttp),
+				FullyQualifiedDomainName: aws.String(host),
+				Port:                     aws.Int64(80),
+				ResourcePath:             aws.String("/"),
+				RequestInterval:          aws.Int64(healthCheckInterval),
+				FailureThreshold:         aws.Int64(ts.cfg.Spec.FailureThreshold),
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create health check for %q (%v)", host, err)
+		}
+		id := aws.StringValue(out.HealthCheck.Id)
+		ts.healthChecks[host] = id
+		ts.cfg.Logger.Info("created health check", zap.String("host-name", host), zap.String("health-check-id", id))
+	}
+
+	ts.records = []*route53.ResourceRecordSet{
+		ts.recordSet(route53.ResourceRecordSetFailoverPrimary, ts.res.PrimaryHostname),
+		ts.recordSet(route53.ResourceRecordSetFailoverSecondary, ts.res.SecondaryHostname),
+	}
+	if err := ts.changeRecords(route53.ChangeActionUpsert); err != nil {
+		ts.records = nil
+		return err
+	}
+	ts.cfg.Logger.Info("created failover records", zap.String("record-name", ts.cfg.Spec.RecordName))
+	return nil
+}
+
+func (ts *tester) recordSet(failover string, host string) *route53.ResourceRecordSet {
+	return &route53.ResourceRecordSet{
+		Name:            aws.String(ts.cfg.Spec.RecordName),
+		Type:            aws.String(route53.RRTypeCname),
+		SetIdentifier:   aws.String(strings.ToLower(failover)),
+		Failover:        aws.String(failover),
+		TTL:             aws.Int64(recordTTL),
+		HealthCheckId:   aws.String(ts.healthChecks[host]),
+		ResourceRecords: []*route53.ResourceRecord{{Value: aws.String(host)}},
+	}
+}
+
+func (ts *tester) changeRecords(action string) error {
+	changes := make([]*route53.Change, 0, len(ts.records))
+	for _, rs := range ts.records {
+		changes = append(changes, &route53.Change{Action: aws.String(action), ResourceRecordSet: rs})
+	}
+	out, err := ts.cfg.Route53API.ChangeResourceRecordSets(&route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(ts.cfg.Spec.HostedZoneID),
+		ChangeBatch: &route53.ChangeBatch{
+			Comment: aws.String("aws-k8s-tester failover scenario"),
+			Changes: changes,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to %s failover records (%v)", strings.ToLower(action), err)
+	}
+	return ts.cfg.Route53API.WaitUntilResourceRecordSetsChanged(&route53.GetChangeInput{Id: out.ChangeInfo.Id})
+}
+
+// healthy returns true if enough Route53 health checkers report success,
+// and false for "observed" if none has reported yet.
+func (ts *tester) healthy(host string) (healthy bool, observed bool, err error) {
+	out, err := ts.cfg.Route53API.GetHealthCheckStatus(&route53.GetHealthCheckStatusInput{
+		HealthCheckId: aws.String(ts.healthChecks[host]),
+	})
+	if err != nil {
+		return false, false, err
+	}
+	if len(out.HealthCheckObservations) == 0 {
+		return false, false, nil
+	}
+	succeeded := 0
+	for _, ob := range out.HealthCheckObservations {
+		if ob.StatusReport != nil && strings.HasPrefix(aws.StringValue(ob.StatusReport.Status), "Success") {
+			succeeded++
+		}
+	}
+	return float64(succeeded)/float64(len(out.HealthCheckObservations)) > healthyRatio, true, nil
+}
+
+// answers returns true if Route53 answers the record with the host name.
+func (ts *tester) answers(host string) (bool, error) {
+	out, err := ts.cfg.Route53API.TestDNSAnswer(&route53.TestDNSAnswerInput{
+		HostedZoneId: aws.String(ts.cfg.Spec.HostedZoneID),
+		RecordName:   aws.String(ts.cfg.Spec.RecordName),
+		RecordType:   aws.String(route53.RRTypeCname),
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, d := range out.RecordData {
+		if strings.EqualFold(strings.TrimSuffix(aws.StringValue(d), "."), host) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// killNodes scales the node group ASGs down to zero, so that they are not
+// replaced, and terminates their instances without draining.
+func (ts *tester) killNodes(c Cluster) error {
+	var asgs, ids []string
+	if c.EKSConfig.IsEnabledAddOnNodeGroups() {
+		for _, cur := range c.EKSConfig.AddOnNodeGroups.ASGs {
+			asgs = append(asgs, cur.Name)
+			for id := range cur.Instances {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if c.EKSConfig.IsEnabledAddOnManagedNodeGroups() {
+		for _, cur := range c.EKSConfig.AddOnManagedNodeGroups.MNGs {
+			if cur.ASGName != "" {
+				asgs = append(asgs, cur.ASGName)
+			}
+			for id := range cur.Instances {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(asgs) == 0 {
+		return fmt.Errorf("no node group found in %q", c.EKSConfig.Name)
+	}
+	sort.Strings(asgs)
+	sort.Strings(ids)
+
+	ts.cfg.Logger.Info("killing nodes", zap.String("cluster", c.EKSConfig.Name), zap.Strings("asgs", asgs), zap.Int("instances", len(ids)))
+	ts.res.NodesKilled = time.Now()
+	for _, name := range asgs {
+		if _, err := c.ASGAPI.UpdateAutoScalingGroup(&autoscaling.UpdateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String(name),
+			MinSize:              aws.Int64(0),
+			DesiredCapacity:      aws.Int64(0),
+		}); err != nil {
+			return fmt.Errorf("failed to scale down ASG %q (%v)", name, err)
+		}
+		ts.res.KilledASGs = append(ts.res.KilledASGs, name)
+	}
+	if len(ids) > 0 {
+		// ASG scale-in may wait for the lifecycle hooks (e.g. MNG draining)
+		if _, err := c.EC2APIV2.TerminateInstances(
+			context.Background(),
+			&aws_ec2_v2.TerminateInstancesInput{InstanceIds: ids},
+		); err != nil {
+			ts.cfg.Logger.Warn("failed to terminate instances", zap.Strings("instance-ids", ids), zap.Error(err))
+		}
+	}
+	ts.cfg.Logger.Info("killed nodes", zap.String("cluster", c.EKSConfig.Name))
+	return nil
+}
+
+// cleanup deletes the records, health checks, and the app. The Services
+// are deleted before the namespaces, to wait for the load balancers
+// to be deleted rather than left in the VPCs.
+func (ts *tester) cleanup() error {
+	var errs []string
+	if len(ts.records) > 0 {
+		if err := ts.changeRecords(route53.ChangeActionDelete); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	for host, id := range ts.healthChecks {
+		if _, err := ts.cfg.Route53API.DeleteHealthCheck(&route53.DeleteHealthCheckInput{
+			HealthCheckId: aws.String(id),
+		}); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete health check for %q (%v)", host, err))
+		}
+	}
+	for _, c := range []Cluster{ts.cfg.Primary, ts.cfg.Secondary} {
+		if err := ts.deleteApp(c); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func (ts *tester) deleteApp(c Cluster) error {
+	ns := ts.cfg.Spec.Namespace
+	ts.cfg.Logger.Info("deleting failover app", zap.String("cluster", c.EKSConfig.Name))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := c.Clientset.CoreV1().Services(ns).Delete(ctx, appName, metav1.DeleteOptions{})
+	cancel()
+	if err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to delete failover Service in %q (%v)", c.EKSConfig.Name, err)
+	}
+	deadline := time.Now().Add(10 * time.Minute)
+	for time.Now().Before(deadline) {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		_, err = c.Clientset.CoreV1().Services(ns).Get(ctx, appName, metav1.GetOptions{})
+		cancel()
+		if apierrs.IsNotFound(err) {
+			break
+		}
+		ts.cfg.Logger.Info("waiting for failover Service deletion", zap.String("cluster", c.EKSConfig.Name))
+		time.Sleep(pollInterval)
+	}
+	return k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		c.Clientset,
+		ns,
+		pollInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	)
+}
@@ -0,0 +1,216 @@
+package failover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8s_testing "k8s.io/client-go/testing"
+)
+
+// fakeRoute53 fails the health checks of the killed host names,
+// and answers the primary record while its health check passes.
+type fakeRoute53 struct {
+	route53iface.Route53API
+
+	mu      sync.Mutex
+	hosts   map[string]string // health check ID to host name
+	records map[string]*route53.ResourceRecordSet
+	killed  map[string]bool
+	deleted []string
+}
+
+func (f *fakeRoute53) CreateHealthCheck(in *route53.CreateHealthCheckInput) (*route53.CreateHealthCheckOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := fmt.Sprintf("hc-%d", len(f.hosts))
+	f.hosts[id] = aws.StringValue(in.HealthCheckConfig.FullyQualifiedDomainName)
+	return &route53.CreateHealthCheckOutput{HealthCheck: &route53.HealthCheck{Id: aws.String(id)}}, nil
+}
+
+func (f *fakeRoute53) DeleteHealthCheck(in *route53.DeleteHealthCheckInput) (*route53.DeleteHealthCheckOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = append(f.deleted, aws.StringValue(in.HealthCheckId))
+	return &route53.DeleteHealthCheckOutput{}, nil
+}
+
+func (f *fakeRoute53) ChangeResourceRecordSets(in *route53.ChangeResourceRecordSetsInput) (*route53.ChangeResourceRecordSetsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range in.ChangeBatch.Changes {
+		rs := c.ResourceRecordSet
+		switch aws.StringValue(c.Action) {
+		case route53.ChangeActionUpsert:
+			f.records[aws.StringValue(rs.Failover)] = rs
+		case route53.ChangeActionDelete:
+			delete(f.records, aws.StringValue(rs.Failover))
+		}
+	}
+	return &route53.ChangeResourceRecordSetsOutput{ChangeInfo: &route53.ChangeInfo{Id: aws.String("change")}}, nil
+}
+
+func (f *fakeRoute53) WaitUntilResourceRecordSetsChanged(*route53.GetChangeInput) error {
+	return nil
+}
+
+func (f *fakeRoute53) status(id string) string {
+	if f.killed[f.hosts[id]] {
+		return "Failure: Connection timed out"
+	}
+	return "Success: HTTP Status Code 200, OK"
+}
+
+func (f *fakeRoute53) GetHealthCheckStatus(in *route53.GetHealthCheckStatusInput) (*route53.GetHealthCheckStatusOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	st := f.status(aws.StringValue(in.HealthCheckId))
+	return &route53.GetHealthCheckStatusOutput{
+		HealthCheckObservations: []*route53.HealthCheckObservation{
+			{StatusReport: &route53.StatusReport{Status: aws.String(st)}},
+			{StatusReport: &route53.StatusReport{Status: aws.String(st)}},
+		},
+	}, nil
+}
+
+func (f *fakeRoute53) TestDNSAnswer(in *route53.TestDNSAnswerInput) (*route53.TestDNSAnswerOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rs := f.records[route53.ResourceRecordSetFailoverPrimary]
+	if strings.HasPrefix(f.status(aws.StringValue(rs.HealthCheckId)), "Failure") {
+		rs = f.records[route53.ResourceRecordSetFailoverSecondary]
+	}
+	return &route53.TestDNSAnswerOutput{RecordData: []*string{aws.String(aws.StringValue(rs.ResourceRecords[0].Value) + ".")}}, nil
+}
+
+type fakeASG struct {
+	autoscalingiface.AutoScalingAPI
+	onUpdate func(name string)
+}
+
+func (f *fakeASG) UpdateAutoScalingGroup(in *autoscaling.UpdateAutoScalingGroupInput) (*autoscaling.UpdateAutoScalingGroupOutput, error) {
+	if aws.Int64Value(in.DesiredCapacity) != 0 {
+		return nil, fmt.Errorf("unexpected desired capacity %d", aws.Int64Value(in.DesiredCapacity))
+	}
+	f.onUpdate(aws.StringValue(in.AutoScalingGroupName))
+	return &autoscaling.UpdateAutoScalingGroupOutput{}, nil
+}
+
+type fakeEC2 struct {
+	terminated []string
+}
+
+func (f *fakeEC2) TerminateInstances(ctx context.Context, in *aws_ec2_v2.TerminateInstancesInput, optFns ...func(*aws_ec2_v2.Options)) (*aws_ec2_v2.TerminateInstancesOutput, error) {
+	f.terminated = append(f.terminated, in.InstanceIds...)
+	return &aws_ec2_v2.TerminateInstancesOutput{}, nil
+}
+
+// newClientset returns the fake clientset, assigning the host name
+// to the LoadBalancer Service on creation.
+func newClientset(host string) *fake.Clientset {
+	cs := fake.NewSimpleClientset()
+	cs.PrependReactor("create", "services", func(action k8s_testing.Action) (bool, runtime.Object, error) {
+		svc := action.(k8s_testing.CreateAction).GetObject().(*v1.Service)
+		svc.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{Hostname: host}}
+		return false, nil, nil
+	})
+	return cs
+}
+
+func TestRun(t *testing.T) {
+	pollInterval = 10 * time.Millisecond
+
+	r53 := &fakeRoute53{
+		hosts:   make(map[string]string),
+		records: make(map[string]*route53.ResourceRecordSet),
+		killed:  make(map[string]bool),
+	}
+	primary := eksconfig.NewDefault()
+	primary.Name = "primary"
+	primary.AddOnNodeGroups.Enable = true
+	primary.AddOnNodeGroups.ASGs = map[string]eksconfig.ASG{
+		"ng": {ASG: ec2config.ASG{Name: "primary-ng", Instances: map[string]ec2config.Instance{"i-1": {}}}},
+	}
+	primary.AddOnManagedNodeGroups.Enable = true
+	primary.AddOnManagedNodeGroups.MNGs = map[string]eksconfig.MNG{
+		"mng": {Name: "mng", ASGName: "eks-mng-asg", Instances: map[string]ec2config.Instance{"i-2": {}}},
+	}
+	secondary := eksconfig.NewDefault()
+	secondary.Name = "secondary"
+
+	spec := Spec{Enable: true, HostedZoneID: "Z123", RecordName: "failover.example.com"}
+	if err := spec.ValidateAndSetDefaults([]string{"primary", "secondary"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ec2API := &fakeEC2{}
+	res, err := Run(Config{
+		Logger: zap.NewExample(),
+		Stopc:  make(chan struct{}),
+		Spec:   spec,
+		Primary: Cluster{
+			EKSConfig: primary,
+			Clientset: newClientset("primary-nlb.elb.us-west-2.amazonaws.com"),
+			ASGAPI: &fakeASG{onUpdate: func(name string) {
+				r53.mu.Lock()
+				r53.killed["primary-nlb.elb.us-west-2.amazonaws.com"] = true
+				r53.mu.Unlock()
+			}},
+			EC2APIV2: ec2API,
+		},
+		Secondary: Cluster{
+			EKSConfig: secondary,
+			Clientset: newClientset("secondary-nlb.elb.us-east-1.amazonaws.com"),
+		},
+		Route53API: r53,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Succeeded || res.FailoverTook == 0 || res.HealthCheckFailedTook == 0 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if res.PrimaryHostname != "primary-nlb.elb.us-west-2.amazonaws.com" || res.SecondaryHostname != "secondary-nlb.elb.us-east-1.amazonaws.com" {
+		t.Fatalf("unexpected host names %+v", res)
+	}
+	if strings.Join(res.KilledASGs, ",") != "eks-mng-asg,primary-ng" {
+		t.Fatalf("unexpected killed ASGs %v", res.KilledASGs)
+	}
+	if strings.Join(ec2API.terminated, ",") != "i-1,i-2" {
+		t.Fatalf("unexpected terminated instances %v", ec2API.terminated)
+	}
+	if len(r53.records) != 0 || len(r53.deleted) != 2 {
+		t.Fatalf("records or health checks not cleaned up %v %v", r53.records, r53.deleted)
+	}
+}
+
+func TestValidateAndSetDefaults(t *testing.T) {
+	for i, tv := range []struct {
+		spec Spec
+		err  string
+	}{
+		{Spec{Enable: true, RecordName: "a.example.com"}, "empty failover.hosted-zone-id"},
+		{Spec{Enable: true, HostedZoneID: "Z1"}, "empty failover.record-name"},
+		{Spec{Enable: true, HostedZoneID: "Z1", RecordName: "a.example.com", Secondary: "c-1"}, "same cluster"},
+		{Spec{Enable: true, HostedZoneID: "Z1", RecordName: "a.example.com", Primary: "c-3"}, `"c-3" not found`},
+	} {
+		if err := tv.spec.ValidateAndSetDefaults([]string{"c-1", "c-2"}); err == nil || !strings.Contains(err.Error(), tv.err) {
+			t.Fatalf("#%d: expected %q, got %v", i, tv.err, err)
+		}
+	}
+}
@@ -0,0 +1,465 @@
+// Package multi creates and deletes multiple EKS clusters in one run,
+// from the templated configurations, and aggregates the results
+// (e.g. for multi-cluster services testing).
+package multi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eks"
+	"github.com/aws/aws-k8s-tester/eks/multi/failover"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	pkg_aws "github.com/aws/aws-k8s-tester/pkg/aws"
+	"github.com/aws/aws-k8s-tester/pkg/configfile"
+	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/olekukonko/tablewriter"
+	"go.uber.org/zap"
+	"sigs.k8s.io/yaml"
+)
+
+// Config is the multi-cluster configuration.
+type Config struct {
+	// Template is the path to the cluster configuration template
+	// in YAML, JSON, or HCL, rendered per cluster with Go "text/template"
+	// (e.g. "name: {{ .Name }}", see "TemplateData").
+	Template string `json:"template"`
+	// Dir is the directory to write the rendered cluster configurations,
+	// which are reused by the following runs (e.g. delete).
+	// Defaults to the directory of the multi-cluster configuration.
+	Dir string `json:"dir,omitempty"`
+	// Concurrency is the number of clusters created or deleted at the same time.
+	// Zero means all clusters.
+	Concurrency int `json:"concurrency,omitempty"`
+	// Clusters is the list of clusters to create.
+	Clusters []Cluster `json:"clusters"`
+	// CommandAfterCreateClusters is the command to run after all clusters
+	// and add-ons are created (e.g. cross-cluster tests), with the
+	// "MULTI_CLUSTER_KUBECONFIGS" environment variable set to the
+	// comma-separated kubeconfig paths in the order of "Clusters".
+	CommandAfterCreateClusters string `json:"command-after-create-clusters,omitempty"`
+	// CommandAfterCreateClustersTimeout is the timeout of "CommandAfterCreateClusters".
+	CommandAfterCreateClustersTimeout time.Duration `json:"command-after-create-clusters-timeout,omitempty"`
+	// ReportPath is the output path for the aggregated results in JSON.
+	// Defaults to "<config>.multi-report.json".
+	ReportPath string `json:"report-path,omitempty"`
+	// Failover is the cross-region failover scenario, run last
+	// since it kills the primary cluster's nodes.
+	Failover *failover.Spec `json:"failover,omitempty"`
+
+	configPath string
+}
+
+// Cluster is the cluster to create from the template.
+type Cluster struct {
+	// Name is the cluster name.
+	Name string `json:"name"`
+	// Region is the AWS region of the cluster.
+	// If empty, the region of the template is used.
+	Region string `json:"region,omitempty"`
+	// Vars are the additional template variables.
+	Vars map[string]string `json:"vars,omitempty"`
+}
+
+// TemplateData is the data to render the cluster configuration template.
+type TemplateData struct {
+	// Index is the index of the cluster in "Clusters".
+	Index  int
+	Name   string
+	Region string
+	Vars   map[string]string
+}
+
+// Report is the aggregated results written to "ReportPath".
+type Report struct {
+	Clusters []Result         `json:"clusters"`
+	Failover *failover.Result `json:"failover,omitempty"`
+}
+
+// Result is the result of the cluster.
+type Result struct {
+	Name           string        `json:"name"`
+	Region         string        `json:"region"`
+	ConfigPath     string        `json:"config-path"`
+	KubeConfigPath string        `json:"kubeconfig-path,omitempty"`
+	ReportJSONPath string        `json:"report-json-path,omitempty"`
+	Succeeded      bool          `json:"succeeded"`
+	Error          string        `json:"error,omitempty"`
+	Took           time.Duration `json:"took"`
+	TookString     string        `json:"took-string"`
+}
+
+const defaultCommandAfterCreateClustersTimeout = time.Hour
+
+// Load loads the multi-cluster configuration.
+func Load(p string) (*Config, error) {
+	d, err := configfile.Read(p)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	if err = yaml.Unmarshal(d, cfg, yaml.DisallowUnknownFields); err != nil {
+		return nil, err
+	}
+	cfg.configPath, err = filepath.Abs(p)
+	if err != nil {
+		return nil, err
+	}
+	if err = cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (cfg *Config) validate() error {
+	if cfg.Template == "" {
+		return errors.New("empty template")
+	}
+	base := filepath.Dir(cfg.configPath)
+	if !filepath.IsAbs(cfg.Template) {
+		cfg.Template = filepath.Join(base, cfg.Template)
+	}
+	if !fileutil.Exist(cfg.Template) {
+		return fmt.Errorf("template %q not found", cfg.Template)
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = base
+	}
+	if len(cfg.Clusters) == 0 {
+		return errors.New("empty clusters")
+	}
+	names := make(map[string]struct{})
+	for i, c := range cfg.Clusters {
+		if c.Name == "" {
+			return fmt.Errorf("clusters[%d]: empty name", i)
+		}
+		if c.Name != strings.ToLower(c.Name) {
+			return fmt.Errorf("clusters[%d]: name %q must be in lower-case", i, c.Name)
+		}
+		if _, ok := names[c.Name]; ok {
+			return fmt.Errorf("clusters[%d]: duplicate name %q", i, c.Name)
+		}
+		names[c.Name] = struct{}{}
+	}
+	if cfg.Concurrency <= 0 || cfg.Concurrency > len(cfg.Clusters) {
+		cfg.Concurrency = len(cfg.Clusters)
+	}
+	if cfg.CommandAfterCreateClustersTimeout == 0 {
+		cfg.CommandAfterCreateClustersTimeout = defaultCommandAfterCreateClustersTimeout
+	}
+	if cfg.ReportPath == "" {
+		cfg.ReportPath = strings.TrimSuffix(cfg.configPath, filepath.Ext(cfg.configPath)) + ".multi-report.json"
+	}
+	if cfg.Failover != nil {
+		names := make([]string, 0, len(cfg.Clusters))
+		for _, c := range cfg.Clusters {
+			names = append(names, c.Name)
+		}
+		if err := cfg.Failover.ValidateAndSetDefaults(names); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tester is the cluster tester (see "eks.Tester").
+type tester interface {
+	Up() error
+	Down() error
+}
+
+// Multi creates and deletes the clusters.
+type Multi struct {
+	lg  *zap.Logger
+	cfg *Config
+	// configs are the loaded cluster configurations,
+	// in the order of "Clusters".
+	configs []*eksconfig.Config
+
+	newTester func(cfg *eksconfig.Config) (tester, error)
+}
+
+// New creates a new multi-cluster tester.
+func New(lg *zap.Logger, cfg *Config) *Multi {
+	return &Multi{
+		lg:      lg,
+		cfg:     cfg,
+		configs: make([]*eksconfig.Config, len(cfg.Clusters)),
+		newTester: func(cfg *eksconfig.Config) (tester, error) {
+			return eks.New(cfg)
+		},
+	}
+}
+
+// Up creates the clusters and their add-ons concurrently, up to "Concurrency",
+// runs "CommandAfterCreateClusters" and then the failover scenario
+// if all succeed, and writes the results.
+// Each cluster follows its own "OnFailureDelete".
+func (m *Multi) Up() ([]Result, error) {
+	rs := m.run("up", func(ts tester) error { return ts.Up() })
+	err := resultsError(rs)
+	if err == nil && m.cfg.CommandAfterCreateClusters != "" {
+		err = m.runCommandAfterCreateClusters(rs)
+	}
+	var fr *failover.Result
+	if err == nil && m.cfg.Failover != nil && m.cfg.Failover.Enable {
+		fr, err = m.runFailover()
+	}
+	m.writeReport(Report{Clusters: rs, Failover: fr})
+	return rs, err
+}
+
+// Down deletes the clusters concurrently, up to "Concurrency".
+func (m *Multi) Down() ([]Result, error) {
+	rs := m.run("down", func(ts tester) error { return ts.Down() })
+	m.writeReport(Report{Clusters: rs})
+	return rs, resultsError(rs)
+}
+
+func (m *Multi) run(op string, fn func(ts tester) error) []Result {
+	rs := make([]Result, len(m.cfg.Clusters))
+	sema := make(chan struct{}, m.cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i := range m.cfg.Clusters {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sema <- struct{}{}
+			defer func() { <-sema }()
+
+			c := m.cfg.Clusters[i]
+			m.lg.Info("starting cluster", zap.String("op", op), zap.String("name", c.Name), zap.String("region", c.Region))
+			start := time.Now()
+			rs[i] = Result{Name: c.Name, Region: c.Region}
+			err := m.runCluster(i, &rs[i], fn)
+			rs[i].Took = time.Since(start)
+			rs[i].TookString = rs[i].Took.Round(time.Second).String()
+			rs[i].Succeeded = err == nil
+			if err != nil {
+				rs[i].Error = err.Error()
+				m.lg.Warn("cluster failed", zap.String("op", op), zap.String("name", c.Name), zap.Error(err))
+				return
+			}
+			m.lg.Info("cluster succeeded", zap.String("op", op), zap.String("name", c.Name), zap.String("took", rs[i].TookString))
+		}(i)
+	}
+	wg.Wait()
+	return rs
+}
+
+func (m *Multi) runCluster(idx int, r *Result, fn func(ts tester) error) error {
+	p, err := m.render(idx)
+	if err != nil {
+		return err
+	}
+	r.ConfigPath = configfile.SyncPath(p)
+	cfg, err := eksconfig.Load(p)
+	if err != nil {
+		return fmt.Errorf("failed to load %q (%v)", p, err)
+	}
+	c := m.cfg.Clusters[idx]
+	cfg.Name = c.Name
+	if c.Region != "" {
+		cfg.Region = c.Region
+	}
+	r.Region = cfg.Region
+	if cfg.MetricsListenAddress != "" {
+		// all clusters run in this process
+		m.lg.Warn("ignoring metrics listen address", zap.String("name", c.Name), zap.String("address", cfg.MetricsListenAddress))
+		cfg.MetricsListenAddress = ""
+	}
+	if err = cfg.Sync(); err != nil {
+		return err
+	}
+	ts, err := m.newTester(cfg)
+	if err != nil {
+		return err
+	}
+	m.configs[idx] = cfg
+	r.KubeConfigPath, r.ReportJSONPath = cfg.KubeConfigPath, cfg.ReportJSONPath
+	return fn(ts)
+}
+
+// render writes the cluster configuration from the template,
+// unless written by the previous run, to keep its status.
+func (m *Multi) render(idx int) (string, error) {
+	c := m.cfg.Clusters[idx]
+	ext := filepath.Ext(m.cfg.Template)
+	p := filepath.Join(m.cfg.Dir, c.Name+ext)
+	if fileutil.Exist(configfile.SyncPath(p)) {
+		if configfile.SyncPath(p) != p {
+			// HCL is written back to YAML
+			return configfile.SyncPath(p), nil
+		}
+		return p, nil
+	}
+
+	d, err := ioutil.ReadFile(m.cfg.Template)
+	if err != nil {
+		return "", err
+	}
+	tpl, err := template.New(filepath.Base(m.cfg.Template)).Option("missingkey=error").Parse(string(d))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template %q (%v)", m.cfg.Template, err)
+	}
+	buf := bytes.NewBuffer(nil)
+	if err = tpl.Execute(buf, TemplateData{
+		Index:  idx,
+		Name:   c.Name,
+		Region: c.Region,
+		Vars:   c.Vars,
+	}); err != nil {
+		return "", fmt.Errorf("failed to render template %q for %q (%v)", m.cfg.Template, c.Name, err)
+	}
+	if err = os.MkdirAll(m.cfg.Dir, 0700); err != nil {
+		return "", err
+	}
+	if err = ioutil.WriteFile(p, buf.Bytes(), 0600); err != nil {
+		return "", err
+	}
+	m.lg.Info("rendered cluster configuration", zap.String("name", c.Name), zap.String("path", p))
+	return p, nil
+}
+
+func (m *Multi) runCommandAfterCreateClusters(rs []Result) error {
+	kcfgs := make([]string, 0, len(rs))
+	for _, r := range rs {
+		kcfgs = append(kcfgs, r.KubeConfigPath)
+	}
+	m.lg.Info("running command after create clusters",
+		zap.String("command", m.cfg.CommandAfterCreateClusters),
+		zap.Strings("kubeconfigs", kcfgs),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.CommandAfterCreateClustersTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "bash", "-c", m.cfg.CommandAfterCreateClusters)
+	cmd.Env = append(os.Environ(), "MULTI_CLUSTER_KUBECONFIGS="+strings.Join(kcfgs, ","))
+	out, err := cmd.CombinedOutput()
+	fmt.Printf("\n'%s' output:\n\n%s\n\n", m.cfg.CommandAfterCreateClusters, string(out))
+	if err != nil {
+		return fmt.Errorf("command after create clusters failed (%v)", err)
+	}
+	return nil
+}
+
+// runFailover runs the failover scenario between the created clusters,
+// with the clients in their regions.
+func (m *Multi) runFailover() (*failover.Result, error) {
+	fcfg := failover.Config{
+		Logger: m.lg,
+		Stopc:  make(chan struct{}),
+		Spec:   *m.cfg.Failover,
+	}
+	for i, c := range m.cfg.Clusters {
+		var dst *failover.Cluster
+		switch c.Name {
+		case m.cfg.Failover.Primary:
+			dst = &fcfg.Primary
+		case m.cfg.Failover.Secondary:
+			dst = &fcfg.Secondary
+		default:
+			continue
+		}
+		cfg := m.configs[i]
+		awsCfg := pkg_aws.Config{
+			Logger:     m.lg,
+			Partition:  cfg.Partition,
+			Region:     cfg.Region,
+			Endpoints:  cfg.ServiceEndpoints.AWSEndpoints(),
+			AssumeRole: cfg.AssumeRole.AWSAssumeRole(),
+		}
+		ss, _, _, err := pkg_aws.New(&awsCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AWS session for %q (%v)", c.Name, err)
+		}
+		awsCfgV2, err := pkg_aws.NewV2(&awsCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AWS SDK v2 config for %q (%v)", c.Name, err)
+		}
+		kcfg := &k8s_client.EKSConfig{
+			Logger:         m.lg,
+			Region:         cfg.Region,
+			ClusterName:    cfg.Name,
+			KubeConfigPath: cfg.KubeConfigPath,
+			KubectlPath:    cfg.KubectlPath,
+			ServerVersion:  cfg.Version,
+			AssumeRole:     cfg.AssumeRole.AWSAssumeRole(),
+		}
+		if cfg.Status != nil {
+			kcfg.ClusterAPIServerEndpoint = cfg.Status.ClusterAPIServerEndpoint
+			kcfg.ClusterCADecoded = cfg.Status.ClusterCADecoded
+		}
+		cli, err := k8s_client.NewEKS(kcfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create k8s client for %q (%v)", c.Name, err)
+		}
+		*dst = failover.Cluster{
+			EKSConfig: cfg,
+			Clientset: cli.KubernetesClientSet(),
+			ASGAPI:    autoscaling.New(ss),
+			EC2APIV2:  aws_ec2_v2.NewFromConfig(awsCfgV2),
+		}
+		if c.Name == m.cfg.Failover.Primary {
+			// Route53 is global
+			fcfg.Route53API = route53.New(ss)
+		}
+	}
+	return failover.Run(fcfg)
+}
+
+func (m *Multi) writeReport(rp Report) {
+	d, err := json.MarshalIndent(rp, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(m.cfg.ReportPath, d, 0600)
+	}
+	if err != nil {
+		m.lg.Warn("failed to write report", zap.Error(err))
+	}
+
+	buf := bytes.NewBuffer(nil)
+	tb := tablewriter.NewWriter(buf)
+	tb.SetAutoWrapText(false)
+	tb.SetAlignment(tablewriter.ALIGN_LEFT)
+	tb.SetCenterSeparator("*")
+	tb.SetHeader([]string{"cluster", "region", "took", "succeeded", "config"})
+	for _, r := range rp.Clusters {
+		tb.Append([]string{r.Name, r.Region, r.TookString, fmt.Sprintf("%v", r.Succeeded), r.ConfigPath})
+	}
+	tb.Render()
+	fmt.Printf("\n\nclusters:\n%s\n", buf.String())
+	if fr := rp.Failover; fr != nil {
+		fmt.Printf("failover %q from %q to %q: succeeded %v, health check failed in %q, DNS failed over in %q (+%ds TTL)\n\n",
+			fr.RecordName, fr.PrimaryCluster, fr.SecondaryCluster, fr.Succeeded,
+			fr.HealthCheckFailedTookString, fr.FailoverTookString, fr.RecordTTL,
+		)
+	}
+	fmt.Printf("report %q\n", m.cfg.ReportPath)
+}
+
+func resultsError(rs []Result) error {
+	var failed []string
+	for _, r := range rs {
+		if !r.Succeeded {
+			failed = append(failed, fmt.Sprintf("%s (%s)", r.Name, r.Error))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d clusters failed: %s", len(failed), len(rs), strings.Join(failed, ", "))
+}
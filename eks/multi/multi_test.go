@@ -0,0 +1,120 @@
+package multi
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"go.uber.org/zap"
+)
+
+type fakeTester struct {
+	cfg     *eksconfig.Config
+	running *int32
+	max     *int32
+}
+
+func (f *fakeTester) Up() error {
+	n := atomic.AddInt32(f.running, 1)
+	defer atomic.AddInt32(f.running, -1)
+	for {
+		m := atomic.LoadInt32(f.max)
+		if n <= m || atomic.CompareAndSwapInt32(f.max, m, n) {
+			break
+		}
+	}
+	if f.cfg.Name == "c-fail" {
+		return errors.New("injected")
+	}
+	return nil
+}
+
+func (f *fakeTester) Down() error { return nil }
+
+func TestMulti(t *testing.T) {
+	dir := t.TempDir()
+	tpl := "name: should-be-overwritten\nregion: us-west-2\nlog-outputs: [stderr]\ntags:\n  team: {{ .Vars.team }}\n  index: \"{{ .Index }}\"\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "template.yaml"), []byte(tpl), 0600); err != nil {
+		t.Fatal(err)
+	}
+	mp := filepath.Join(dir, "multi.yaml")
+	mc := `template: template.yaml
+concurrency: 2
+clusters:
+- name: c-1
+  vars: {team: a}
+- name: c-2
+  region: us-east-1
+  vars: {team: b}
+- name: c-fail
+  vars: {team: c}
+`
+	if err := ioutil.WriteFile(mp, []byte(mc), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(mp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var running, max int32
+	m := New(zap.NewExample(), cfg)
+	m.newTester = func(cfg *eksconfig.Config) (tester, error) {
+		cfg.KubeConfigPath = filepath.Join(dir, cfg.Name+".kubeconfig")
+		return &fakeTester{cfg: cfg, running: &running, max: &max}, nil
+	}
+	rs, err := m.Up()
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 clusters failed: c-fail (injected)") {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if max > 2 {
+		t.Fatalf("concurrency exceeded %d", max)
+	}
+	if !rs[0].Succeeded || !rs[1].Succeeded || rs[2].Succeeded {
+		t.Fatalf("unexpected results %+v", rs)
+	}
+	if rs[1].Region != "us-east-1" || rs[0].Region != "us-west-2" {
+		t.Fatalf("unexpected regions %+v", rs)
+	}
+
+	loaded, err := eksconfig.Load(filepath.Join(dir, "c-2.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Name != "c-2" || loaded.Region != "us-east-1" || loaded.Tags["team"] != "b" || loaded.Tags["index"] != "1" {
+		t.Fatalf("unexpected rendered configuration %+v", loaded)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "multi.multi-report.json")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "template.yaml"), []byte("name: test\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for i, tv := range []struct {
+		cfg string
+		err string
+	}{
+		{"clusters:\n- name: a\n", "empty template"},
+		{"template: template.yaml\n", "empty clusters"},
+		{"template: template.yaml\nclusters:\n- name: a\n- name: a\n", `duplicate name "a"`},
+		{"template: missing.yaml\nclusters:\n- name: a\n", "not found"},
+		{"template: template.yaml\nclusters:\n- name: a\nfailover: {enable: true, hosted-zone-id: Z1, record-name: a.example.com}\n", "at least 2 clusters"},
+	} {
+		p := filepath.Join(dir, "multi.yaml")
+		if err := ioutil.WriteFile(p, []byte(tv.cfg), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(p); err == nil || !strings.Contains(err.Error(), tv.err) {
+			t.Fatalf("#%d: expected %q, got %v", i, tv.err, err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package networkpolicy
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+)
+
+// JUnit XML as consumed by the CI test result reporters.
+// ref. https://llg.cubic.org/docs/junit/
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func toJUnit(name string, results []probeResult) junitTestSuite {
+	suite := junitTestSuite{Name: name, Tests: len(results)}
+	total := 0.0
+	for _, rs := range results {
+		tc := junitTestCase{
+			Name:      fmt.Sprintf("%s: %s %s server", rs.Phase, rs.From, connectivity(rs.Expect)),
+			ClassName: rs.Phase,
+			Time:      fmt.Sprintf("%.3f", rs.Took.Seconds()),
+		}
+		if !rs.passed() {
+			suite.Failures++
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("expected %q, got %q", connectivity(rs.Expect), connectivity(rs.Got)),
+				Text:    rs.Output,
+			}
+		}
+		total += rs.Took.Seconds()
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	suite.Time = fmt.Sprintf("%.3f", total)
+	return suite
+}
+
+func connectivity(connected bool) string {
+	if connected {
+		return "can reach"
+	}
+	return "cannot reach"
+}
+
+func writeJUnit(p string, suite junitTestSuite) error {
+	b, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p, append([]byte(xml.Header), b...), 0600)
+}
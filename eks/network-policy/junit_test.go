@@ -0,0 +1,78 @@
+package networkpolicy
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToJUnit(t *testing.T) {
+	suite := toJUnit("network-policy", []probeResult{
+		{Phase: "no-policy", From: "a", Expect: true, Got: true, Took: time.Second},
+		{Phase: "deny-all-ingress", From: "a", Expect: false, Got: true, Took: 2 * time.Minute, Output: "ok"},
+	})
+	if suite.Tests != 2 || suite.Failures != 1 {
+		t.Fatalf("unexpected tests %d, failures %d", suite.Tests, suite.Failures)
+	}
+	if suite.Time != "121.000" {
+		t.Fatalf("unexpected time %q", suite.Time)
+	}
+	if suite.TestCases[0].Failure != nil {
+		t.Fatalf("unexpected failure %+v", suite.TestCases[0].Failure)
+	}
+	if suite.TestCases[1].Failure == nil || suite.TestCases[1].Failure.Message != `expected "cannot reach", got "can reach"` {
+		t.Fatalf("unexpected failure %+v", suite.TestCases[1].Failure)
+	}
+	if suite.TestCases[1].Name != "deny-all-ingress: a cannot reach server" {
+		t.Fatalf("unexpected name %q", suite.TestCases[1].Name)
+	}
+
+	b, err := xml.Marshal(suite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(b)
+	if !strings.HasPrefix(s, `<testsuite name="network-policy" tests="2" failures="1" time="121.000">`) {
+		t.Fatalf("unexpected XML %s", s)
+	}
+	if strings.Count(s, "<failure ") != 1 {
+		t.Fatalf("unexpected XML %s", s)
+	}
+}
+
+func TestRenderPolicy(t *testing.T) {
+	s, err := renderPolicy(denyAllTemplate, policy{Namespace: "ns", Direction: "Egress"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(s, "name: deny-all-egress\n") || !strings.Contains(s, "  - Egress\n") {
+		t.Fatalf("unexpected policy %s", s)
+	}
+
+	s, err = renderPolicy(allowFromNamespaceTemplate, policy{Namespace: "srv", From: "cli", Server: "server", Port: 8080})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"name: allow-from-cli\n",
+		"namespace: srv\n",
+		"kubernetes.io/metadata.name: cli\n",
+		"port: 8080\n",
+	} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("policy missing %q:\n%s", want, s)
+		}
+	}
+}
+
+func TestSetFlag(t *testing.T) {
+	args := setFlag([]string{"--enable-ipv6=false", "--enable-network-policy=false"}, "--enable-network-policy", "true")
+	if args[1] != "--enable-network-policy=true" || len(args) != 2 {
+		t.Fatalf("unexpected args %v", args)
+	}
+	args = setFlag([]string{"--enable-ipv6=false"}, "--enable-network-policy", "true")
+	if args[1] != "--enable-network-policy=true" || len(args) != 2 {
+		t.Fatalf("unexpected args %v", args)
+	}
+}
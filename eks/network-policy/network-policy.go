@@ -0,0 +1,216 @@
+// Package networkpolicy tests network policy enforcement: it enables
+// the VPC CNI network policy agent (or installs Calico), applies
+// allow/deny policies, and probes connectivity between namespaces.
+// The probe results are written in JUnit XML.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/cni-network-policy.html
+// ref. https://kubernetes.io/docs/concepts/services-networking/network-policies/
+package networkpolicy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"go.uber.org/zap"
+)
+
+// Config defines network policy configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+	S3API     s3iface.S3API
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new network policy tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:      "network-policy",
+		Exclusive: true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				S3API:     cfg.S3API,
+			}), cfg.EKSConfig.IsEnabledAddOnNetworkPolicy)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnNetworkPolicy() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnNetworkPolicy.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnNetworkPolicy.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnNetworkPolicy.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	switch ts.cfg.EKSConfig.AddOnNetworkPolicy.Provider {
+	case eksconfig.NetworkPolicyProviderVPCCNI:
+		err = ts.setVPCCNINetworkPolicy(true)
+	case eksconfig.NetworkPolicyProviderCalico:
+		err = ts.installCalico()
+	default:
+		err = fmt.Errorf("unknown AddOnNetworkPolicy.Provider %q", ts.cfg.EKSConfig.AddOnNetworkPolicy.Provider)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range ts.namespaces() {
+		if err = k8s_client.CreateNamespace(
+			ts.cfg.Logger,
+			ts.cfg.K8SClient.KubernetesClientSet(),
+			ns,
+		); err != nil {
+			return err
+		}
+	}
+	if err = ts.createServer(); err != nil {
+		return err
+	}
+	if err = ts.createClients(); err != nil {
+		return err
+	}
+	if err = ts.waitPods(); err != nil {
+		return err
+	}
+
+	results, err := ts.runProbes()
+	if err != nil {
+		return err
+	}
+	if err = ts.writeResults(results); err != nil {
+		return err
+	}
+	if ts.cfg.EKSConfig.AddOnNetworkPolicy.ProbesFailed > 0 {
+		return fmt.Errorf("%d of %d network policy probes failed (see %q)",
+			ts.cfg.EKSConfig.AddOnNetworkPolicy.ProbesFailed,
+			len(results),
+			ts.cfg.EKSConfig.AddOnNetworkPolicy.ResultJUnitXMLPath,
+		)
+	}
+
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnNetworkPolicy() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnNetworkPolicy.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnNetworkPolicy.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+	for _, ns := range ts.namespaces() {
+		if err := k8s_client.DeleteNamespaceAndWait(
+			ts.cfg.Logger,
+			ts.cfg.K8SClient.KubernetesClientSet(),
+			ns,
+			k8s_client.DefaultNamespaceDeletionInterval,
+			k8s_client.DefaultNamespaceDeletionTimeout,
+			k8s_client.WithForceDelete(true),
+		); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete network policy namespace %q (%v)", ns, err))
+		}
+	}
+
+	switch ts.cfg.EKSConfig.AddOnNetworkPolicy.Provider {
+	case eksconfig.NetworkPolicyProviderVPCCNI:
+		if err := ts.setVPCCNINetworkPolicy(false); err != nil {
+			errs = append(errs, err.Error())
+		}
+	case eksconfig.NetworkPolicyProviderCalico:
+		if err := ts.deleteCalico(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnNetworkPolicy.Created = false
+	})
+	return nil
+}
+
+func (ts *tester) writeResults(results []probeResult) error {
+	cur := ts.cfg.EKSConfig.AddOnNetworkPolicy
+	suite := toJUnit(pkgName, results)
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.ProbesPassed = suite.Tests - suite.Failures
+		cur.ProbesFailed = suite.Failures
+	})
+
+	if err := writeJUnit(cur.ResultJUnitXMLPath, suite); err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("wrote network policy probe results",
+		zap.String("path", cur.ResultJUnitXMLPath),
+		zap.Int("passed", cur.ProbesPassed),
+		zap.Int("failed", cur.ProbesFailed),
+	)
+	return aws_s3.Upload(
+		ts.cfg.Logger,
+		ts.cfg.S3API,
+		ts.cfg.EKSConfig.S3.BucketName,
+		cur.ResultJUnitXMLS3Key,
+		cur.ResultJUnitXMLPath,
+	)
+}
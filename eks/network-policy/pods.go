@@ -0,0 +1,205 @@
+package networkpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	serverName = "server"
+	clientName = "client"
+	serverPort = 8080
+)
+
+func (ts *tester) serverNamespace() string {
+	return ts.cfg.EKSConfig.AddOnNetworkPolicy.Namespace + "-server"
+}
+
+// allowedNamespace is allowed ingress to the server
+// by the namespace selector policy.
+func (ts *tester) allowedNamespace() string {
+	return ts.cfg.EKSConfig.AddOnNetworkPolicy.Namespace + "-allowed"
+}
+
+func (ts *tester) deniedNamespace() string {
+	return ts.cfg.EKSConfig.AddOnNetworkPolicy.Namespace + "-denied"
+}
+
+func (ts *tester) namespaces() []string {
+	return []string{ts.serverNamespace(), ts.allowedNamespace(), ts.deniedNamespace()}
+}
+
+func (ts *tester) serverURL() string {
+	return fmt.Sprintf("http://%s.%s.svc.cluster.local:%d/", serverName, ts.serverNamespace(), serverPort)
+}
+
+func (ts *tester) createServer() error {
+	cur := ts.cfg.EKSConfig.AddOnNetworkPolicy
+	ns := ts.serverNamespace()
+	labels := map[string]string{"app.kubernetes.io/name": serverName}
+	replicas := int32(1)
+
+	ts.cfg.Logger.Info("creating server Deployment", zap.String("namespace", ns))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(ns).
+		Create(
+			ctx,
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      serverName,
+					Namespace: ns,
+					Labels:    labels,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: &replicas,
+					Selector: &metav1.LabelSelector{MatchLabels: labels},
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						Spec: v1.PodSpec{
+							RestartPolicy: v1.RestartPolicyAlways,
+							Containers: []v1.Container{
+								{
+									Name:            serverName,
+									Image:           cur.PodImage,
+									ImagePullPolicy: v1.PullIfNotPresent,
+									Command: []string{
+										"/bin/sh",
+										"-c",
+										fmt.Sprintf("mkdir -p /www && echo ok > /www/index.html && httpd -f -p %d -h /www", serverPort),
+									},
+									Ports: []v1.ContainerPort{{ContainerPort: serverPort}},
+								},
+							},
+							NodeSelector: map[string]string{
+								"kubernetes.io/os": "linux",
+							},
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create server Deployment (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("creating server Service", zap.String("namespace", ns))
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Services(ns).
+		Create(
+			ctx,
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      serverName,
+					Namespace: ns,
+				},
+				Spec: v1.ServiceSpec{
+					Selector: labels,
+					Ports: []v1.ServicePort{
+						{
+							Protocol:   v1.ProtocolTCP,
+							Port:       serverPort,
+							TargetPort: intstr.FromInt(serverPort),
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create server Service (%v)", err)
+	}
+	ts.cfg.Logger.Info("created server")
+	return nil
+}
+
+// createClients creates a client Pod in each client namespace.
+func (ts *tester) createClients() error {
+	cur := ts.cfg.EKSConfig.AddOnNetworkPolicy
+	for _, ns := range []string{ts.allowedNamespace(), ts.deniedNamespace()} {
+		ts.cfg.Logger.Info("creating client Pod", zap.String("namespace", ns))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		_, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Pods(ns).
+			Create(
+				ctx,
+				&v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      clientName,
+						Namespace: ns,
+						Labels:    map[string]string{"app.kubernetes.io/name": clientName},
+					},
+					Spec: v1.PodSpec{
+						RestartPolicy: v1.RestartPolicyAlways,
+						Containers: []v1.Container{
+							{
+								Name:            clientName,
+								Image:           cur.PodImage,
+								ImagePullPolicy: v1.PullIfNotPresent,
+								Command:         []string{"/bin/sh", "-c", "sleep 3600"},
+							},
+						},
+						NodeSelector: map[string]string{
+							"kubernetes.io/os": "linux",
+						},
+					},
+				},
+				metav1.CreateOptions{},
+			)
+		cancel()
+		if err != nil && !apierrs.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create client Pod in %q (%v)", ns, err)
+		}
+	}
+	ts.cfg.Logger.Info("created client Pods")
+	return nil
+}
+
+// waitPods waits for the server and client Pods to be running.
+func (ts *tester) waitPods() error {
+	waitStart := time.Now()
+	for time.Since(waitStart) < 10*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("Pod wait aborted")
+		case <-time.After(10 * time.Second):
+		}
+		running := 0
+		for _, ns := range ts.namespaces() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			pods, err := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+			cancel()
+			if err != nil {
+				ts.cfg.Logger.Warn("failed to list Pods", zap.String("namespace", ns), zap.Error(err))
+				continue
+			}
+			for _, pod := range pods.Items {
+				if pod.Status.Phase == v1.PodRunning && pod.Status.PodIP != "" {
+					running++
+					break
+				}
+			}
+		}
+		ts.cfg.Logger.Info("polled Pods", zap.Int("running", running), zap.Int("expected", len(ts.namespaces())))
+		if running == len(ts.namespaces()) {
+			return nil
+		}
+	}
+	return errors.New("network policy test Pods not running")
+}
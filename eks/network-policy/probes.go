@@ -0,0 +1,191 @@
+package networkpolicy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/utils/exec"
+)
+
+// denyAllTemplate denies all ingress or egress traffic in the namespace.
+const denyAllTemplate = `---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: deny-all-{{ .Direction | lower }}
+  namespace: {{ .Namespace }}
+spec:
+  podSelector: {}
+  policyTypes:
+  - {{ .Direction }}
+`
+
+// allowFromNamespaceTemplate allows ingress to the server
+// only from the Pods in the source namespace.
+const allowFromNamespaceTemplate = `---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: allow-from-{{ .From }}
+  namespace: {{ .Namespace }}
+spec:
+  podSelector:
+    matchLabels:
+      app.kubernetes.io/name: {{ .Server }}
+  policyTypes:
+  - Ingress
+  ingress:
+  - from:
+    - namespaceSelector:
+        matchLabels:
+          kubernetes.io/metadata.name: {{ .From }}
+    ports:
+    - protocol: TCP
+      port: {{ .Port }}
+`
+
+type policy struct {
+	Namespace string
+	Direction string
+	From      string
+	Server    string
+	Port      int
+}
+
+func renderPolicy(tpl string, p policy) (string, error) {
+	t, err := template.New("policy").Funcs(template.FuncMap{"lower": strings.ToLower}).Parse(tpl)
+	if err != nil {
+		return "", err
+	}
+	buf := bytes.NewBuffer(nil)
+	if err = t.Execute(buf, p); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// phase applies the policy, and probes the server from each client namespace.
+type phase struct {
+	name   string
+	policy func() (string, error)
+	// expect maps each client namespace to whether the server must be reachable.
+	expect map[string]bool
+}
+
+func (ts *tester) phases() []phase {
+	server, allowed, denied := ts.serverNamespace(), ts.allowedNamespace(), ts.deniedNamespace()
+	return []phase{
+		{
+			name:   "no-policy",
+			expect: map[string]bool{allowed: true, denied: true},
+		},
+		{
+			name: "deny-all-ingress",
+			policy: func() (string, error) {
+				return renderPolicy(denyAllTemplate, policy{Namespace: server, Direction: "Ingress"})
+			},
+			expect: map[string]bool{allowed: false, denied: false},
+		},
+		{
+			name: "allow-from-namespace",
+			policy: func() (string, error) {
+				return renderPolicy(allowFromNamespaceTemplate, policy{Namespace: server, From: allowed, Server: serverName, Port: serverPort})
+			},
+			expect: map[string]bool{allowed: true, denied: false},
+		},
+		{
+			name: "deny-all-egress",
+			policy: func() (string, error) {
+				return renderPolicy(denyAllTemplate, policy{Namespace: allowed, Direction: "Egress"})
+			},
+			expect: map[string]bool{allowed: false, denied: false},
+		},
+	}
+}
+
+// probeResult is the result of a connectivity probe.
+type probeResult struct {
+	Phase  string
+	From   string
+	Expect bool
+	Got    bool
+	Took   time.Duration
+	Output string
+}
+
+func (r probeResult) passed() bool { return r.Expect == r.Got }
+
+// runProbes applies the policies in order; each phase keeps the policies
+// from the previous phases.
+func (ts *tester) runProbes() (results []probeResult, err error) {
+	for _, ph := range ts.phases() {
+		if ph.policy != nil {
+			data, err := ph.policy()
+			if err != nil {
+				return nil, err
+			}
+			ts.cfg.Logger.Info("applying network policy", zap.String("phase", ph.name))
+			if err = ts.cfg.K8SClient.Apply(data); err != nil {
+				return nil, fmt.Errorf("failed to apply network policy %q (%v)", ph.name, err)
+			}
+		}
+		for _, from := range []string{ts.allowedNamespace(), ts.deniedNamespace()} {
+			rs, err := ts.probe(ph.name, from, ph.expect[from])
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, rs)
+		}
+	}
+	return results, nil
+}
+
+// probe polls the server from the client namespace until the expected
+// result is observed, or the probe timeout.
+func (ts *tester) probe(phase string, from string, expect bool) (probeResult, error) {
+	cur := ts.cfg.EKSConfig.AddOnNetworkPolicy
+	rs := probeResult{Phase: phase, From: from, Expect: expect}
+	args := []string{
+		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+		"--namespace=" + from,
+		"exec",
+		clientName,
+		"--",
+		"wget", "-q", "-O", "-", "-T", "3",
+		ts.serverURL(),
+	}
+
+	probeStart := time.Now()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		output, err := exec.New().CommandContext(ctx, ts.cfg.EKSConfig.KubectlPath, args...).CombinedOutput()
+		cancel()
+		rs.Got = err == nil && strings.TrimSpace(string(output)) == "ok"
+		rs.Output = strings.TrimSpace(string(output))
+		rs.Took = time.Since(probeStart)
+		if rs.passed() || rs.Took > cur.ProbeTimeout {
+			break
+		}
+		select {
+		case <-ts.cfg.Stopc:
+			return rs, errors.New("network policy probe aborted")
+		case <-time.After(5 * time.Second):
+		}
+	}
+
+	ts.cfg.Logger.Info("probed server",
+		zap.String("phase", phase),
+		zap.String("from", from),
+		zap.Bool("expect-connected", rs.Expect),
+		zap.Bool("connected", rs.Got),
+		zap.Bool("passed", rs.passed()),
+		zap.String("took", rs.Took.String()),
+	)
+	return rs, nil
+}
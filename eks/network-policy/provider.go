@@ -0,0 +1,231 @@
+package networkpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/exec"
+)
+
+const (
+	// vpcCNIConfigMapName enables the network policy controller
+	// in the EKS control plane.
+	vpcCNIConfigMapName      = "amazon-vpc-cni"
+	vpcCNIConfigMapPolicyKey = "enable-network-policy-controller"
+	// nodeAgentContainerName is the network policy agent container
+	// in "aws-node", shipped with VPC CNI v1.14.0 or later.
+	nodeAgentContainerName = "aws-eks-nodeagent"
+	nodeAgentPolicyFlag    = "--enable-network-policy"
+)
+
+// setVPCCNINetworkPolicy toggles the VPC CNI network policy controller
+// and node agent, and waits for the "aws-node" rollout.
+func (ts *tester) setVPCCNINetworkPolicy(enable bool) error {
+	ts.cfg.Logger.Info("updating VPC CNI network policy", zap.Bool("enable", enable))
+	cmCli := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().ConfigMaps("kube-system")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	cm, err := cmCli.Get(ctx, vpcCNIConfigMapName, metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get %q ConfigMap (%v)", vpcCNIConfigMapName, err)
+	}
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data[vpcCNIConfigMapPolicyKey] = strconv.FormatBool(enable)
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = cmCli.Update(ctx, cm, metav1.UpdateOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to update %q ConfigMap (%v)", vpcCNIConfigMapName, err)
+	}
+
+	dsCli := ts.cfg.K8SClient.KubernetesClientSet().AppsV1().DaemonSets("kube-system")
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	ds, err := dsCli.Get(ctx, "aws-node", metav1.GetOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get aws-node DaemonSet (%v)", err)
+	}
+	found := false
+	for i := range ds.Spec.Template.Spec.Containers {
+		c := &ds.Spec.Template.Spec.Containers[i]
+		if c.Name != nodeAgentContainerName {
+			continue
+		}
+		found = true
+		c.Args = setFlag(c.Args, nodeAgentPolicyFlag, strconv.FormatBool(enable))
+	}
+	if !found {
+		return fmt.Errorf("%q container not found in aws-node DaemonSet (requires VPC CNI v1.14.0 or later)", nodeAgentContainerName)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = dsCli.Update(ctx, ds, metav1.UpdateOptions{})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to update aws-node DaemonSet (%v)", err)
+	}
+
+	if err = ts.waitDaemonSet("kube-system", "aws-node"); err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("updated VPC CNI network policy", zap.Bool("enable", enable))
+	return nil
+}
+
+// setFlag sets the "--flag=value" argument, replacing the existing one.
+func setFlag(args []string, flag string, value string) []string {
+	for i, arg := range args {
+		if arg == flag || strings.HasPrefix(arg, flag+"=") {
+			args[i] = flag + "=" + value
+			return args
+		}
+	}
+	return append(args, flag+"="+value)
+}
+
+func (ts *tester) calicoOperatorURL() string {
+	return fmt.Sprintf(
+		"https://raw.githubusercontent.com/projectcalico/calico/%s/manifests/tigera-operator.yaml",
+		ts.cfg.EKSConfig.AddOnNetworkPolicy.CalicoVersion,
+	)
+}
+
+// calicoInstallation installs Calico in policy-only mode,
+// leaving Pod networking to the VPC CNI.
+const calicoInstallation = `---
+apiVersion: operator.tigera.io/v1
+kind: Installation
+metadata:
+  name: default
+spec:
+  kubernetesProvider: EKS
+  cni:
+    type: AmazonVPC
+  calicoNetwork:
+    bgp: Disabled
+`
+
+func (ts *tester) installCalico() error {
+	url := ts.calicoOperatorURL()
+	ts.cfg.Logger.Info("installing Calico operator", zap.String("url", url))
+	// "kubectl apply" exceeds the annotation size limit with the operator CRDs
+	if err := ts.kubectl("create", "-f", url); err != nil && !strings.Contains(err.Error(), "AlreadyExists") {
+		return fmt.Errorf("failed to install Calico operator (%v)", err)
+	}
+
+	// the Installation CRD may not be established yet
+	var err error
+	for i := 0; i < 10; i++ {
+		if err = ts.cfg.K8SClient.Apply(calicoInstallation); err == nil {
+			break
+		}
+		ts.cfg.Logger.Warn("failed to create Calico Installation; retrying", zap.Error(err))
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("Calico install aborted")
+		case <-time.After(10 * time.Second):
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create Calico Installation (%v)", err)
+	}
+
+	if err = ts.waitDaemonSet("calico-system", "calico-node"); err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("installed Calico", zap.String("version", ts.cfg.EKSConfig.AddOnNetworkPolicy.CalicoVersion))
+	return nil
+}
+
+func (ts *tester) deleteCalico() error {
+	ts.cfg.Logger.Info("deleting Calico")
+	var errs []string
+	if err := ts.cfg.K8SClient.Delete(calicoInstallation); err != nil && !strings.Contains(err.Error(), "NotFound") {
+		errs = append(errs, fmt.Sprintf("failed to delete Calico Installation (%v)", err))
+	}
+	// the operator removes "calico-system" once the Installation is deleted
+	if err := ts.waitNamespaceGone("calico-system"); err != nil {
+		ts.cfg.Logger.Warn("calico-system namespace still exists", zap.Error(err))
+	}
+	if err := ts.kubectl("delete", "--ignore-not-found", "-f", ts.calicoOperatorURL()); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Calico operator (%v)", err))
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	ts.cfg.Logger.Info("deleted Calico")
+	return nil
+}
+
+func (ts *tester) waitNamespaceGone(ns string) error {
+	nsCli := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Namespaces()
+	waitStart := time.Now()
+	for time.Since(waitStart) < 5*time.Minute {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		_, err := nsCli.Get(ctx, ns, metav1.GetOptions{})
+		cancel()
+		if apierrs.IsNotFound(err) {
+			return nil
+		}
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("namespace wait aborted")
+		case <-time.After(10 * time.Second):
+		}
+	}
+	return fmt.Errorf("namespace %q not deleted", ns)
+}
+
+func (ts *tester) kubectl(args ...string) error {
+	args = append([]string{"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath}, args...)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	output, err := exec.New().CommandContext(ctx, ts.cfg.EKSConfig.KubectlPath, args...).CombinedOutput()
+	cancel()
+	if err != nil {
+		return fmt.Errorf("%v (%q)", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// waitDaemonSet waits for the DaemonSet rollout.
+func (ts *tester) waitDaemonSet(namespace string, name string) error {
+	dsCli := ts.cfg.K8SClient.KubernetesClientSet().AppsV1().DaemonSets(namespace)
+	waitStart := time.Now()
+	for time.Since(waitStart) < 10*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return fmt.Errorf("%q DaemonSet rollout aborted", name)
+		case <-time.After(10 * time.Second):
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		ds, err := dsCli.Get(ctx, name, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get DaemonSet", zap.String("namespace", namespace), zap.String("name", name), zap.Error(err))
+			continue
+		}
+		st := ds.Status
+		ts.cfg.Logger.Info("polled DaemonSet",
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Int32("desired", st.DesiredNumberScheduled),
+			zap.Int32("updated", st.UpdatedNumberScheduled),
+			zap.Int32("available", st.NumberAvailable),
+		)
+		if st.DesiredNumberScheduled > 0 &&
+			st.ObservedGeneration >= ds.Generation &&
+			st.UpdatedNumberScheduled == st.DesiredNumberScheduled &&
+			st.NumberAvailable == st.DesiredNumberScheduled {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q DaemonSet not rolled out", name)
+}
@@ -10,13 +10,13 @@ import (
 	"time"
 
 	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-k8s-tester/eksconfig"
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
 	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
 	aws_asg_v2 "github.com/aws/aws-sdk-go-v2/service/autoscaling"
 	aws_asg_v2_types "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
 	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
 	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
-	aws_eks_v2_types "github.com/aws/aws-sdk-go-v2/service/eks/types"
 	aws_ssm_v2 "github.com/aws/aws-sdk-go-v2/service/ssm"
 	smithy "github.com/aws/smithy-go"
 	"go.uber.org/zap"
@@ -64,12 +64,46 @@ func (ts *tester) _createASGs() (tss tupleTimes, err error) {
 			zap.String("image-id", imgID),
 		)
 
-		userData, err := ts.generateUserData(asgName, cur.AMIType, cur.KubeletExtraArgs, cur.BootstrapArgs)
+		maxPods, err := ts.prefixDelegationMaxPods(cur.InstanceTypes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get max-pods for %q (%v)", asgName, err)
+		}
+		userData, err := ts.generateUserData(asgName, cur.AMIFamily, cur.AMIType, cur.KubeletExtraArgs, cur.BootstrapArgs, maxPods)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create user data for %q (%v)", asgName, err)
 		}
 		userData = base64.StdEncoding.EncodeToString([]byte(userData))
 
+		// for public DNS + SSH access
+		// (nodes in the private subnets have no internet access)
+		eni := aws_ec2_v2_types.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
+			AssociatePublicIpAddress: aws_v2.Bool(!ts.cfg.EKSConfig.VPC.DisableNATGateways),
+			DeleteOnTermination:      aws_v2.Bool(true),
+			DeviceIndex:              aws_v2.Int32(0),
+			Groups:                   []string{ts.cfg.EKSConfig.VPC.NodeGroupSecurityGroupID},
+		}
+		if cur.Placement != nil {
+			switch cur.Placement.Type {
+			case eksconfig.PlacementTypeWavelengthZone:
+				// Wavelength Zones reach the internet via the carrier gateway
+				eni.AssociatePublicIpAddress = nil
+				eni.AssociateCarrierIpAddress = aws_v2.Bool(true)
+			case eksconfig.PlacementTypeOutpost:
+				// Outpost subnets reach the region via the service link
+				eni.AssociatePublicIpAddress = aws_v2.Bool(false)
+			}
+			ts.cfg.Logger.Info("creating ASG with placement",
+				zap.String("asg-name", asgName),
+				zap.String("placement-type", cur.Placement.Type),
+				zap.Strings("subnet-ids", cur.Placement.SubnetIDs),
+			)
+		}
+
+		var keyName *string
+		if ts.cfg.EKSConfig.RemoteAccessKeyName != "" {
+			// empty with EC2 Instance Connect and no key pair
+			keyName = aws_v2.String(ts.cfg.EKSConfig.RemoteAccessKeyName)
+		}
 		_, err = ts.cfg.EC2APIV2.CreateLaunchTemplate(
 			context.Background(),
 			&aws_ec2_v2.CreateLaunchTemplateInput{
@@ -80,7 +114,7 @@ func (ts *tester) _createASGs() (tss tupleTimes, err error) {
 						Arn: aws_v2.String(ts.cfg.EKSConfig.AddOnNodeGroups.Role.InstanceProfileARN),
 					},
 
-					KeyName: aws_v2.String(ts.cfg.EKSConfig.RemoteAccessKeyName),
+					KeyName: keyName,
 
 					ImageId:      aws_v2.String(imgID),
 					InstanceType: aws_ec2_v2_types.InstanceType(cur.InstanceType),
@@ -97,15 +131,7 @@ func (ts *tester) _createASGs() (tss tupleTimes, err error) {
 						},
 					},
 
-					// for public DNS + SSH access
-					NetworkInterfaces: []aws_ec2_v2_types.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
-						{
-							AssociatePublicIpAddress: aws_v2.Bool(true),
-							DeleteOnTermination:      aws_v2.Bool(true),
-							DeviceIndex:              aws_v2.Int32(0),
-							Groups:                   []string{ts.cfg.EKSConfig.VPC.NodeGroupSecurityGroupID},
-						},
-					},
+					NetworkInterfaces: []aws_ec2_v2_types.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{eni},
 
 					UserData: aws_v2.String(userData),
 
@@ -121,6 +147,10 @@ func (ts *tester) _createASGs() (tss tupleTimes, err error) {
 								Key:   aws_v2.String("Name"),
 								Value: aws_v2.String(fmt.Sprintf("%s-instance-launch-template", cur.Name)),
 							},
+							{
+								Key:   aws_v2.String(eksconfig.RunTagKey),
+								Value: aws_v2.String(ts.cfg.EKSConfig.Name),
+							},
 						},
 					},
 				},
@@ -147,7 +177,7 @@ func (ts *tester) _createASGs() (tss tupleTimes, err error) {
 			AutoScalingGroupName:   aws_v2.String(asgName),
 			MaxSize:                aws_v2.Int32(cur.ASGMaxSize),
 			MinSize:                aws_v2.Int32(cur.ASGMinSize),
-			VPCZoneIdentifier:      aws_v2.String(strings.Join(ts.cfg.EKSConfig.VPC.PublicSubnetIDs, ",")),
+			VPCZoneIdentifier:      aws_v2.String(strings.Join(cur.SubnetIDs(ts.cfg.EKSConfig.VPC), ",")),
 			HealthCheckGracePeriod: aws_v2.Int32(300),
 			HealthCheckType:        aws_v2.String("EC2"),
 			LaunchTemplate: &aws_asg_v2_types.LaunchTemplateSpecification{
@@ -165,6 +195,11 @@ func (ts *tester) _createASGs() (tss tupleTimes, err error) {
 					Value:             aws_v2.String("owned"),
 					PropagateAtLaunch: aws_v2.Bool(true),
 				},
+				{
+					Key:               aws_v2.String(eksconfig.RunTagKey),
+					Value:             aws_v2.String(ts.cfg.EKSConfig.Name),
+					PropagateAtLaunch: aws_v2.Bool(true),
+				},
 				{
 					Key:               aws_v2.String(fmt.Sprintf("kubernetes.io/cluster-autoscaler/%s", ts.cfg.EKSConfig.Name)),
 					Value:             aws_v2.String("owned"),
@@ -180,6 +215,35 @@ func (ts *tester) _createASGs() (tss tupleTimes, err error) {
 		if cur.ASGDesiredCapacity > 0 {
 			asgInput.DesiredCapacity = aws_v2.Int32(cur.ASGDesiredCapacity)
 		}
+		if cur.IsMixedInstances() {
+			// launch template is set via mixed instances policy
+			lt := asgInput.LaunchTemplate
+			asgInput.LaunchTemplate = nil
+			overrides := make([]aws_asg_v2_types.LaunchTemplateOverrides, 0, len(cur.InstanceTypes))
+			for _, itp := range cur.InstanceTypes {
+				overrides = append(overrides, aws_asg_v2_types.LaunchTemplateOverrides{InstanceType: aws_v2.String(itp)})
+			}
+			asgInput.MixedInstancesPolicy = &aws_asg_v2_types.MixedInstancesPolicy{
+				LaunchTemplate: &aws_asg_v2_types.LaunchTemplate{
+					LaunchTemplateSpecification: lt,
+					Overrides:                   overrides,
+				},
+				InstancesDistribution: &aws_asg_v2_types.InstancesDistribution{
+					OnDemandAllocationStrategy:          aws_v2.String(cur.OnDemandAllocationStrategy),
+					OnDemandBaseCapacity:                aws_v2.Int32(cur.OnDemandBaseCapacity),
+					OnDemandPercentageAboveBaseCapacity: aws_v2.Int32(cur.OnDemandPercentageAboveBaseCapacity),
+					SpotAllocationStrategy:              aws_v2.String(cur.SpotAllocationStrategy),
+				},
+			}
+			// proactively replace Spot instances at elevated risk of interruption
+			asgInput.CapacityRebalance = aws_v2.Bool(cur.CapacityType == eksconfig.CapacityTypeSpot)
+			ts.cfg.Logger.Info("creating ASG with mixed instances policy",
+				zap.String("asg-name", asgName),
+				zap.String("capacity-type", cur.CapacityType),
+				zap.Strings("instance-types", cur.InstanceTypes),
+				zap.String("spot-allocation-strategy", cur.SpotAllocationStrategy),
+			)
+		}
 		_, err = ts.cfg.ASGAPIV2.CreateAutoScalingGroup(context.Background(), asgInput)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create ASG for %q (%v)", asgName, err)
@@ -336,7 +400,7 @@ func (ts *tester) waitForASGs(tss tupleTimes) (err error) {
 
 		timeStart := time.Now()
 		if err := ts.nodeWaiter.Wait(asgName, 10); err != nil {
-			return err
+			return ts.diagnose(asgName, err)
 		}
 		timeEnd := time.Now()
 
@@ -369,9 +433,12 @@ func (ts *tester) fetchImageID(ssmParam string) (img string, err error) {
 	return aws_v2.ToString(out.Parameter.Value), nil
 }
 
-func (ts *tester) generateUserData(asgName string, amiType string, kubeletExtraArgs string, bootstrapArgs string) (d string, err error) {
-	switch amiType {
-	case ec2config.AMITypeWindowsServerCore2019X8664:
+// generateUserData returns the node bootstrap user data.
+// Non-zero "maxPods" overrides the kubelet max-pods of the AMI
+// (e.g. with the VPC CNI prefix delegation).
+func (ts *tester) generateUserData(asgName string, amiFamily string, amiType string, kubeletExtraArgs string, bootstrapArgs string, maxPods int64) (d string, err error) {
+	switch amiFamily {
+	case ec2config.AMIFamilyWindows:
 		d = fmt.Sprintf(`
 <powershell>
 [string]$EKSBinDir = "$env:ProgramFiles\Amazon\EKS"
@@ -384,11 +451,11 @@ func (ts *tester) generateUserData(asgName string, amiType string, kubeletExtraA
 			ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint,
 			fmt.Sprintf(`"--node-labels=NodeType=regular,AMIType=%s,NGType=custom,NGName=%s %s"`, amiType, asgName, kubeletExtraArgs))
 
-	case ec2config.AMITypeBottleRocketCPU:
+	case ec2config.AMIFamilyBottlerocket:
 		d = fmt.Sprintf(`[settings.kubernetes]
 cluster-name = "%s"
 cluster-certificate = "%s"
-api-server = "%s"
+api-server = "%s"%s
 [settings.kubernetes.node-labels]
 NodeType = "regular"
 AMIType = "%s"
@@ -397,18 +464,66 @@ NGName = "%s"`,
 			ts.cfg.EKSConfig.Name,
 			ts.cfg.EKSConfig.Status.ClusterCA,
 			ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint,
-			ec2config.AMITypeBottleRocketCPU,
+			bottlerocketMaxPods(maxPods),
+			amiType,
 			asgName,
 		)
 
-	case fmt.Sprint(aws_eks_v2_types.AMITypesAl2X8664),
-		fmt.Sprint(aws_eks_v2_types.AMITypesAl2Arm64),
-		fmt.Sprint(aws_eks_v2_types.AMITypesAl2X8664Gpu):
+	case ec2config.AMIFamilyAL2023:
+		// ref. https://awslabs.github.io/amazon-eks-ami/nodeadm/
+		serviceCIDR := "10.100.0.0/16"
+		clusterVPCIP := ts.cfg.EKSConfig.VPC.CIDRs[0]
+		if clusterVPCIP[:strings.IndexByte(clusterVPCIP, '.')] == "10" {
+			serviceCIDR = "172.20.0.0/16"
+		}
+		if ts.cfg.EKSConfig.IPFamily == eksconfig.IPFamilyIPv6 {
+			serviceCIDR = ts.cfg.EKSConfig.Status.ClusterServiceIPv6CIDR
+		}
+		flags := fmt.Sprintf(`"--node-labels=NodeType=regular,AMIType=%s,NGType=custom,NGName=%s"`, amiType, asgName)
+		if maxPods > 0 {
+			flags += fmt.Sprintf(`, "--max-pods=%d"`, maxPods)
+		}
+		if kubeletExtraArgs != "" {
+			ts.cfg.Logger.Info("adding extra kubelet flags to user data",
+				zap.String("kubelet-extra-args", kubeletExtraArgs),
+			)
+			for _, flag := range strings.Fields(kubeletExtraArgs) {
+				flags += fmt.Sprintf(`, %q`, flag)
+			}
+		}
+		d = fmt.Sprintf(`MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="BOUNDARY"
+
+--BOUNDARY
+Content-Type: application/node.eks.aws
+
+---
+apiVersion: node.eks.aws/v1alpha1
+kind: NodeConfig
+spec:
+  cluster:
+    name: %s
+    apiServerEndpoint: %s
+    certificateAuthority: %s
+    cidr: %s
+  kubelet:
+    flags: [%s]
+
+--BOUNDARY--`,
+			ts.cfg.EKSConfig.Name,
+			ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint,
+			ts.cfg.EKSConfig.Status.ClusterCA,
+			serviceCIDR,
+			flags,
+		)
+
+	case ec2config.AMIFamilyAL2, ec2config.AMIFamilyUbuntu:
 		d = fmt.Sprintf(`#!/bin/bash
 set -xeu
 
 /etc/eks/bootstrap.sh %s`, ts.cfg.EKSConfig.Name)
-		if ts.cfg.EKSConfig.ResolverURL != "" {
+		// IPv6 clusters derive the cluster DNS IP from the service IPv6 CIDR
+		if ts.cfg.EKSConfig.ResolverURL != "" && ts.cfg.EKSConfig.IPFamily != eksconfig.IPFamilyIPv6 {
 			clusterVPCIP := ts.cfg.EKSConfig.VPC.CIDRs[0]
 			dnsClusterIP := "10.100.0.10"
 			if clusterVPCIP[:strings.IndexByte(clusterVPCIP, '.')] == "10" {
@@ -425,8 +540,24 @@ set -xeu
 				dnsClusterIP,
 			)
 		}
+		if ts.cfg.EKSConfig.IPFamily == eksconfig.IPFamilyIPv6 {
+			ts.cfg.Logger.Info("adding extra bootstrap arguments --b64-cluster-ca, --apiserver-endpoint, --ip-family and --service-ipv6-cidr to user data",
+				zap.String("b64-cluster-ca", ts.cfg.EKSConfig.Status.ClusterCA),
+				zap.String("apiserver-endpoint", ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint),
+				zap.String("service-ipv6-cidr", ts.cfg.EKSConfig.Status.ClusterServiceIPv6CIDR),
+			)
+			d += fmt.Sprintf(` --b64-cluster-ca %s --apiserver-endpoint %s --ip-family ipv6 --service-ipv6-cidr %s`,
+				ts.cfg.EKSConfig.Status.ClusterCA,
+				ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint,
+				ts.cfg.EKSConfig.Status.ClusterServiceIPv6CIDR,
+			)
+		}
 		// https://aws.amazon.com/blogs/opensource/improvements-eks-worker-node-provisioning/
 		d += fmt.Sprintf(` --kubelet-extra-args '--node-labels=NodeType=regular,AMIType=%s,NGType=custom,NGName=%s`, amiType, asgName)
+		if maxPods > 0 {
+			ts.cfg.Logger.Info("overriding kubelet max-pods in user data", zap.Int64("max-pods", maxPods))
+			d += fmt.Sprintf(` --max-pods=%d`, maxPods)
+		}
 		if kubeletExtraArgs != "" {
 			ts.cfg.Logger.Info("adding extra bootstrap arguments --kubelet-extra-args to user data",
 				zap.String("kubelet-extra-args", kubeletExtraArgs),
@@ -434,12 +565,18 @@ set -xeu
 			d += fmt.Sprintf(` %s`, kubeletExtraArgs)
 		}
 		d += "'"
+		if maxPods > 0 {
+			d += ` --use-max-pods false`
+		}
 		if bootstrapArgs != "" {
 			ts.cfg.Logger.Info("adding further additional bootstrap arguments to user data",
 				zap.String("bootstrap-args", bootstrapArgs),
 			)
 			d += fmt.Sprintf(` %s`, bootstrapArgs)
 		}
+
+	default:
+		return "", fmt.Errorf("unknown AMIFamily %q", amiFamily)
 	}
 
 	return d, nil
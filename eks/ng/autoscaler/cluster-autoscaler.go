@@ -225,6 +225,7 @@ type Config struct {
 // ClusterAutoscaler defines cluster autoscaler operation.
 type ClusterAutoscaler interface {
 	Create() error
+	Delete() error
 }
 
 var pkgName = reflect.TypeOf(tester{}).PkgPath()
@@ -242,38 +243,56 @@ type tester struct {
 }
 
 func (ts *tester) Create() error {
-	needInstall := false
-	for _, cur := range ts.cfg.EKSConfig.AddOnNodeGroups.ASGs {
-		if cur.ClusterAutoscaler != nil && cur.ClusterAutoscaler.Enable {
-			needInstall = true
-			break
-		}
-	}
-	if !needInstall {
+	if !ts.needInstall() {
 		ts.cfg.Logger.Info("no NG enables CA; skipping")
 		return nil
 	}
 	return ts.installCA()
 }
 
-func (ts *tester) installCA() error {
-	ts.cfg.Logger.Info("creating CA using kubectl", zap.String("name", ts.cfg.EKSConfig.Name))
+func (ts *tester) Delete() error {
+	if !ts.needInstall() {
+		ts.cfg.Logger.Info("no NG enables CA; skipping")
+		return nil
+	}
+	return ts.deleteCA()
+}
+
+func (ts *tester) needInstall() bool {
+	for _, cur := range ts.cfg.EKSConfig.AddOnNodeGroups.ASGs {
+		if cur.ClusterAutoscaler != nil && cur.ClusterAutoscaler.Enable {
+			return true
+		}
+	}
+	return false
+}
+
+func (ts *tester) writeYAML() (fpath string, err error) {
 	var ok bool
 	var caData = caSpecData{}
 	caData.ImageURI, ok = caImages[ts.cfg.EKSConfig.Version]
 	if !ok {
-		return fmt.Errorf("no CA found for %q", ts.cfg.EKSConfig.Version)
+		return "", fmt.Errorf("no CA found for %q", ts.cfg.EKSConfig.Version)
 	}
 	caData.NodeGroupAutoDiscovery = nodeGroupAuotDiscoveryData + ts.cfg.EKSConfig.Name
 	tpl := template.Must(template.New("TemplateCA").Parse(clusterAutoscalerYAML))
 	buf := bytes.NewBuffer(nil)
-	if err := tpl.Execute(buf, caData); err != nil {
-		return err
+	if err = tpl.Execute(buf, caData); err != nil {
+		return "", err
 	}
 	ts.cfg.Logger.Info("writing cluster autoscaler YAML")
-	fpath, err := fileutil.WriteTempFile(buf.Bytes())
+	fpath, err = fileutil.WriteTempFile(buf.Bytes())
 	if err != nil {
 		ts.cfg.Logger.Warn("failed to write cluster-autoscaler YAML", zap.Error(err))
+		return "", err
+	}
+	return fpath, nil
+}
+
+func (ts *tester) installCA() error {
+	ts.cfg.Logger.Info("creating CA using kubectl", zap.String("name", ts.cfg.EKSConfig.Name))
+	fpath, err := ts.writeYAML()
+	if err != nil {
 		return err
 	}
 
@@ -320,6 +339,34 @@ func (ts *tester) installCA() error {
 	return ts.waitDeploymentCA()
 }
 
+func (ts *tester) deleteCA() error {
+	ts.cfg.Logger.Info("deleting CA using kubectl", zap.String("name", ts.cfg.EKSConfig.Name))
+	fpath, err := ts.writeYAML()
+	if err != nil {
+		return err
+	}
+
+	deleteArgs := []string{
+		ts.cfg.EKSConfig.KubectlPath,
+		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+		"delete",
+		"--ignore-not-found",
+		"-f",
+		fpath,
+	}
+	deleteCmd := strings.Join(deleteArgs, " ")
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	output, err := exec.New().CommandContext(ctx, deleteArgs[0], deleteArgs[1:]...).CombinedOutput()
+	cancel()
+	out := string(output)
+	fmt.Fprintf(ts.cfg.LogWriter, "\n\n'%s' output:\n\n%s\n\n", deleteCmd, out)
+	if err != nil {
+		return fmt.Errorf("'kubectl delete' failed %v (output %q)", err, out)
+	}
+	ts.cfg.Logger.Info("deleted cluster autoscaler")
+	return nil
+}
+
 func (ts *tester) waitDeploymentCA() (err error) {
 	timeout := 7 * time.Minute
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
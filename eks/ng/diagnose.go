@@ -0,0 +1,38 @@
+package ng
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-k8s-tester/eks/diagnose"
+	"github.com/aws/aws-k8s-tester/ssh"
+)
+
+// diagnose collects bootstrap logs from the failed node group
+// instances, and attaches the failure classification to the error.
+func (ts *tester) diagnose(asgName string, err error) error {
+	if !ts.cfg.EKSConfig.AddOnNodeGroups.DiagnoseOnFailure {
+		return err
+	}
+	cur, ok := ts.cfg.EKSConfig.AddOnNodeGroups.ASGs[asgName]
+	if !ok {
+		return err
+	}
+
+	rp := diagnose.Diagnose(diagnose.Config{
+		Logger:                ts.cfg.Logger,
+		EC2APIV2:              ts.cfg.EC2APIV2,
+		ASGAPIV2:              ts.cfg.ASGAPIV2,
+		SSHKeyPath:            ts.cfg.EKSConfig.RemoteAccessPrivateKeyPath,
+		SSHUserName:           cur.RemoteAccessUserName,
+		SSHInstanceConnectAPI: ts.cfg.EC2InstanceConnectAPI,
+		SSHHostKeys:           ts.cfg.SSHHostKeys,
+		SSHProxyJump: ssh.NewProxyJump(
+			ts.cfg.EKSConfig.RemoteAccessBastionHost,
+			ts.cfg.EKSConfig.RemoteAccessBastionUserName,
+			ts.cfg.EKSConfig.RemoteAccessBastionPrivateKeyPath,
+		),
+		LogsDir: ts.cfg.EKSConfig.AddOnNodeGroups.LogsDir,
+	}, asgName)
+	fmt.Fprintf(ts.cfg.LogWriter, "\n%s\n", rp)
+	return fmt.Errorf("%w\n\n%s", err, rp)
+}
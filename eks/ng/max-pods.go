@@ -0,0 +1,67 @@
+package ng
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"go.uber.org/zap"
+)
+
+// prefixDelegationMaxPods returns the kubelet max-pods with the VPC CNI
+// prefix delegation, since the bootstrap otherwise sets the max-pods of
+// the secondary IPs from "eni-max-pods.txt". The ASGs with multiple
+// instance types use the minimum. Zero if prefix delegation is disabled.
+func (ts *tester) prefixDelegationMaxPods(instanceTypes []string) (int64, error) {
+	cni := ts.cfg.EKSConfig.CNIConfig
+	if cni == nil || !cni.EnablePrefixDelegation || len(instanceTypes) == 0 {
+		return 0, nil
+	}
+	tps := make([]aws_ec2_v2_types.InstanceType, 0, len(instanceTypes))
+	for _, tp := range instanceTypes {
+		tps = append(tps, aws_ec2_v2_types.InstanceType(tp))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	out, err := ts.cfg.EC2APIV2.DescribeInstanceTypes(ctx, &aws_ec2_v2.DescribeInstanceTypesInput{InstanceTypes: tps})
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to describe instance types (%v)", err)
+	}
+	maxPods := int64(0)
+	for _, info := range out.InstanceTypes {
+		if info.NetworkInfo == nil || info.VCpuInfo == nil ||
+			info.NetworkInfo.MaximumNetworkInterfaces == nil ||
+			info.NetworkInfo.Ipv4AddressesPerInterface == nil ||
+			info.VCpuInfo.DefaultVCpus == nil {
+			return 0, fmt.Errorf("no network info for instance type %q", info.InstanceType)
+		}
+		v := eksconfig.ExpectedMaxPods(
+			int64(*info.NetworkInfo.MaximumNetworkInterfaces),
+			int64(*info.NetworkInfo.Ipv4AddressesPerInterface),
+			int64(*info.VCpuInfo.DefaultVCpus),
+			true,
+			ts.cfg.EKSConfig.IsEnabledAddOnCustomNetworking(),
+		)
+		if maxPods == 0 || v < maxPods {
+			maxPods = v
+		}
+	}
+	ts.cfg.Logger.Info("prefix delegation max-pods",
+		zap.Strings("instance-types", instanceTypes),
+		zap.Int64("max-pods", maxPods),
+	)
+	return maxPods, nil
+}
+
+// bottlerocketMaxPods returns the "settings.kubernetes" max-pods line,
+// or empty to keep the Bottlerocket default.
+func bottlerocketMaxPods(maxPods int64) string {
+	if maxPods <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("\nmax-pods = %d", maxPods)
+}
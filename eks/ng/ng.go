@@ -9,15 +9,18 @@ import (
 	"sync"
 	"time"
 
+	"github.com/aws/aws-k8s-tester/eks/access"
 	"github.com/aws/aws-k8s-tester/eks/ng/autoscaler"
 	"github.com/aws/aws-k8s-tester/eks/ng/wait"
 	"github.com/aws/aws-k8s-tester/eksconfig"
 	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"github.com/aws/aws-k8s-tester/ssh"
 	aws_asg_v2 "github.com/aws/aws-sdk-go-v2/service/autoscaling"
 	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
 	aws_iam_v2 "github.com/aws/aws-sdk-go-v2/service/iam"
 	aws_ssm_v2 "github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ec2instanceconnect/ec2instanceconnectiface"
 	"go.uber.org/zap"
 )
 
@@ -28,11 +31,20 @@ type Config struct {
 	Stopc     chan struct{}
 	EKSConfig *eksconfig.Config
 	K8SClient k8s_client.EKS
+	// Access maps the instance role for the nodes to join.
+	Access access.Manager
 
 	IAMAPIV2 *aws_iam_v2.Client
 	EC2APIV2 *aws_ec2_v2.Client
 	SSMAPIV2 *aws_ssm_v2.Client
 	ASGAPIV2 *aws_asg_v2.Client
+
+	// EC2InstanceConnectAPI is set to SSH into the nodes with
+	// ephemeral keys pushed via EC2 Instance Connect.
+	EC2InstanceConnectAPI ec2instanceconnectiface.EC2InstanceConnectAPI
+	// SSHHostKeys is set to verify the node host keys.
+	// Leave nil to skip host key verification.
+	SSHHostKeys *ssh.HostKeys
 }
 
 // Tester implements EKS "Node Group" for "kubetest2" Deployer.
@@ -74,6 +86,7 @@ func New(cfg Config) Tester {
 			ASGAPIV2:  cfg.ASGAPIV2,
 		}),
 		logsMu:     new(sync.RWMutex),
+		sshPool:    ssh.NewPool(cfg.Logger),
 		failedOnce: false,
 		clusterAutoscaler: autoscaler.New(autoscaler.Config{
 			Logger:    cfg.Logger,
@@ -86,8 +99,10 @@ func New(cfg Config) Tester {
 }
 
 type tester struct {
-	cfg               Config
-	nodeWaiter        wait.NodeWaiter
+	cfg        Config
+	nodeWaiter wait.NodeWaiter
+	// sshPool reuses SSH connections to fetch logs from the same nodes
+	sshPool           *ssh.Pool
 	logsMu            *sync.RWMutex
 	failedOnce        bool
 	clusterAutoscaler autoscaler.ClusterAutoscaler
@@ -102,8 +117,8 @@ func (ts *tester) Create() (err error) {
 		ts.cfg.Logger.Info("node group is already created; skipping creation")
 		return nil
 	}
-	if len(ts.cfg.EKSConfig.VPC.PublicSubnetIDs) == 0 {
-		return errors.New("empty EKSConfig.VPC.PublicSubnetIDs")
+	if len(ts.cfg.EKSConfig.VPC.NodeSubnetIDs()) == 0 {
+		return errors.New("empty EKSConfig.VPC node subnet IDs")
 	}
 
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
@@ -146,11 +161,12 @@ func (ts *tester) Delete() error {
 		return nil
 	}
 	if !ts.cfg.EKSConfig.AddOnNodeGroups.Created {
-		ts.cfg.Logger.Info("ManagedNodeGroup is not created; skipping deletion")
+		ts.cfg.Logger.Info("node group is not created; skipping deletion")
 		return nil
 	}
 
 	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	ts.sshPool.Close()
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
@@ -158,23 +174,18 @@ func (ts *tester) Delete() error {
 		ts.cfg.EKSConfig.Sync()
 	}()
 
+	// delete in the reverse order of creation
 	var errs []string
-	if err := ts.deleteSSM(); err != nil {
-		ts.cfg.Logger.Warn("failed to delete SSM", zap.Error(err))
+	if err := ts.clusterAutoscaler.Delete(); err != nil {
+		ts.cfg.Logger.Warn("failed to delete cluster autoscaler", zap.Error(err))
 		errs = append(errs, err.Error())
 	}
 
-	if err := ts.deleteRole(); err != nil {
-		ts.cfg.Logger.Warn("failed to delete role", zap.Error(err))
+	if err := ts.deleteSSM(); err != nil {
+		ts.cfg.Logger.Warn("failed to delete SSM", zap.Error(err))
 		errs = append(errs, err.Error())
 	}
 
-	select {
-	case <-time.After(10 * time.Second):
-	case <-ts.cfg.Stopc:
-		return errors.New("stopped")
-	}
-
 	if err := ts.deleteASGs(); err != nil {
 		ts.cfg.Logger.Warn("failed to delete ASGs", zap.Error(err))
 		errs = append(errs, err.Error())
@@ -194,6 +205,18 @@ func (ts *tester) Delete() error {
 		time.Sleep(10 * time.Second)
 	}
 
+	if err := ts.deleteConfigMap(); err != nil {
+		ts.cfg.Logger.Warn("failed to delete ConfigMap", zap.Error(err))
+		errs = append(errs, err.Error())
+	}
+
+	// delete the role after the instances are gone,
+	// since the instance profile is attached to the instances
+	if err := ts.deleteRole(); err != nil {
+		ts.cfg.Logger.Warn("failed to delete role", zap.Error(err))
+		errs = append(errs, err.Error())
+	}
+
 	select {
 	case <-time.After(10 * time.Second):
 	case <-ts.cfg.Stopc:
@@ -0,0 +1,29 @@
+package ng
+
+import (
+	nodepolicy "github.com/aws/aws-k8s-tester/eks/node-policy"
+	"github.com/aws/aws-k8s-tester/eks/plan"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+)
+
+// Plan renders the node group role policy documents, without calling AWS APIs.
+func Plan(cfg *eksconfig.Config) (docs []plan.Document, err error) {
+	if !cfg.IsEnabledAddOnNodeGroups() || !cfg.AddOnNodeGroups.Role.Create {
+		return nil, nil
+	}
+	doc, err := plan.NewPolicy("node-groups/role-assume-role-policy.json", createAssumeRolePolicyDocument(cfg.AddOnNodeGroups.Role.ServicePrincipals))
+	if err != nil {
+		return nil, err
+	}
+	docs = append(docs, doc)
+	doc, err = plan.NewPolicy("node-groups/role-policy.json", nodepolicy.Document(cfg, cfg.AddOnNodeGroups.Role.PolicyLeastPrivilege))
+	if err != nil {
+		return nil, err
+	}
+	docs = append(docs, doc)
+	doc, err = plan.NewJSON("node-groups/role-managed-policy-arns.json", cfg.AddOnNodeGroups.Role.ManagedPolicyARNs)
+	if err != nil {
+		return nil, err
+	}
+	return append(docs, doc), nil
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
+	"io/ioutil"
 	"strings"
 	"time"
 
+	nodepolicy "github.com/aws/aws-k8s-tester/eks/node-policy"
+	pkg_aws "github.com/aws/aws-k8s-tester/pkg/aws"
 	aws_iam "github.com/aws/aws-k8s-tester/pkg/aws/iam"
 	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
 	aws_iam_v2 "github.com/aws/aws-sdk-go-v2/service/iam"
@@ -26,7 +28,7 @@ func (ts *tester) createRole() error {
 			ts.cfg.EKSConfig.AddOnNodeGroups.Role.Name,
 			[]string{"ec2.amazonaws.com"},
 			[]string{
-				"arn:aws:iam::aws:policy/AmazonEKS_CNI_Policy",
+				pkg_aws.PolicyARN(ts.cfg.EKSConfig.Partition, "AmazonEKS_CNI_Policy"),
 			},
 		)
 	}
@@ -99,14 +101,15 @@ func (ts *tester) deleteRole() error {
 
 func (ts *tester) _createRole() error {
 	ts.cfg.Logger.Info("creating role", zap.String("name", ts.cfg.EKSConfig.AddOnNodeGroups.Role.Name))
-	out, err := ts.cfg.IAMAPIV2.CreateRole(
-		context.Background(),
-		&aws_iam_v2.CreateRoleInput{
-			RoleName:                 aws_v2.String(ts.cfg.EKSConfig.AddOnNodeGroups.Role.Name),
-			Path:                     aws_v2.String("/"),
-			AssumeRolePolicyDocument: aws_v2.String(createAssumeRolePolicyDocument(ts.cfg.EKSConfig.AddOnNodeGroups.Role.ServicePrincipals)),
-		},
-	)
+	input := &aws_iam_v2.CreateRoleInput{
+		RoleName:                 aws_v2.String(ts.cfg.EKSConfig.AddOnNodeGroups.Role.Name),
+		Path:                     aws_v2.String("/"),
+		AssumeRolePolicyDocument: aws_v2.String(createAssumeRolePolicyDocument(ts.cfg.EKSConfig.AddOnNodeGroups.Role.ServicePrincipals)),
+	}
+	if ts.cfg.EKSConfig.AddOnNodeGroups.Role.PermissionsBoundaryARN != "" {
+		input.PermissionsBoundary = aws_v2.String(ts.cfg.EKSConfig.AddOnNodeGroups.Role.PermissionsBoundaryARN)
+	}
+	out, err := ts.cfg.IAMAPIV2.CreateRole(context.Background(), input)
 	if err != nil {
 		return err
 	}
@@ -155,12 +158,22 @@ func (ts *tester) createPolicy() error {
 	if ts.cfg.EKSConfig.AddOnNodeGroups.Role.PolicyName == "" {
 		return errors.New("emtpy PolicyName")
 	}
-	ts.cfg.Logger.Info("creating policy", zap.String("name", ts.cfg.EKSConfig.AddOnNodeGroups.Role.PolicyName))
+	doc := nodepolicy.Document(ts.cfg.EKSConfig, ts.cfg.EKSConfig.AddOnNodeGroups.Role.PolicyLeastPrivilege)
+	// grant write permission in case of overwrites
+	if err := ioutil.WriteFile(ts.cfg.EKSConfig.AddOnNodeGroups.Role.PolicyPath, []byte(doc), 0600); err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("creating policy",
+		zap.String("name", ts.cfg.EKSConfig.AddOnNodeGroups.Role.PolicyName),
+		zap.String("policy-path", ts.cfg.EKSConfig.AddOnNodeGroups.Role.PolicyPath),
+		zap.Bool("least-privilege", ts.cfg.EKSConfig.AddOnNodeGroups.Role.PolicyLeastPrivilege),
+		zap.Strings("statement-groups", nodepolicy.Groups(ts.cfg.EKSConfig, ts.cfg.EKSConfig.AddOnNodeGroups.Role.PolicyLeastPrivilege)),
+	)
 	pout, err := ts.cfg.IAMAPIV2.CreatePolicy(
 		context.Background(),
 		&aws_iam_v2.CreatePolicyInput{
 			PolicyName:     aws_v2.String(ts.cfg.EKSConfig.AddOnNodeGroups.Role.PolicyName),
-			PolicyDocument: aws_v2.String(createRolePolicyDocument(ts.cfg.EKSConfig.Partition, ts.cfg.EKSConfig.S3.BucketName)),
+			PolicyDocument: aws_v2.String(doc),
 		},
 	)
 	if err != nil {
@@ -285,18 +298,6 @@ func createAssumeRolePolicyDocument(sps []string) string {
 	return string(b)
 }
 
-func createRolePolicyDocument(partition string, bucketName string) string {
-	p := aws_iam.PolicyDocument{
-		Version:   "2012-10-17",
-		Statement: createStatementEntriesForRolePolicyDocument(partition, bucketName),
-	}
-	b, err := json.Marshal(p)
-	if err != nil {
-		panic(err)
-	}
-	return string(b)
-}
-
 func createStatementEntriesForAssumeRole(sps []string) []aws_iam.StatementEntry {
 	return []aws_iam.StatementEntry{
 		{
@@ -311,240 +312,6 @@ func createStatementEntriesForAssumeRole(sps []string) []aws_iam.StatementEntry
 	}
 }
 
-// TODO: update based on add-on setups
-// https://docs.aws.amazon.com/eks/latest/userguide/ebs-csi.html
-// https://github.com/kubernetes-sigs/aws-alb-ingress-controller/blob/master/docs/examples/iam-policy.json
-// https://github.com/aws/eks-charts/tree/master/stable/appmesh-controller
-func createStatementEntriesForRolePolicyDocument(partition string, bucketName string) []aws_iam.StatementEntry {
-	return []aws_iam.StatementEntry{
-		{
-			Effect:   "Allow",
-			Resource: "*",
-			Action: []string{
-				"acm:DescribeCertificate",
-				"acm:ListCertificates",
-				"acm:GetCertificate",
-			},
-		},
-		// arn:aws:iam::aws:policy/AmazonEKSWorkerNodePolicy
-		{
-			Effect:   "Allow",
-			Resource: "*",
-			Action: []string{
-				"ec2:AttachVolume",
-				"ec2:AuthorizeSecurityGroupIngress",
-				"ec2:CreateSecurityGroup",
-				"ec2:CreateSnapshot",
-				"ec2:CreateTags",
-				"ec2:CreateVolume",
-				"ec2:DeleteSecurityGroup",
-				"ec2:DeleteSnapshot",
-				"ec2:DeleteTags",
-				"ec2:DeleteVolume",
-				"ec2:DescribeAccountAttributes",
-				"ec2:DescribeAddresses",
-				"ec2:DescribeInstanceStatus",
-				"ec2:DescribeInstances",
-				"ec2:DescribeInternetGateways",
-				"ec2:DescribeNetworkInterfaces",
-				"ec2:DescribeRouteTables",
-				"ec2:DescribeSecurityGroups",
-				"ec2:DescribeSnapshots",
-				"ec2:DescribeSubnets",
-				"ec2:DescribeTags",
-				"ec2:DescribeVolumes",
-				"ec2:DescribeVolumes",
-				"ec2:DescribeVolumesModifications",
-				"ec2:DescribeVpcs",
-				"ec2:DetachVolume",
-				"ec2:ModifyInstanceAttribute",
-				"ec2:ModifyNetworkInterfaceAttribute",
-				"ec2:RevokeSecurityGroupIngress",
-				"eks:DescribeCluster",
-			},
-		},
-		{
-			Effect:   "Allow",
-			Resource: "*",
-			Action: []string{
-				"elasticloadbalancing:AddListenerCertificates",
-				"elasticloadbalancing:AddTags",
-				"elasticloadbalancing:CreateListener",
-				"elasticloadbalancing:CreateLoadBalancer",
-				"elasticloadbalancing:CreateRule",
-				"elasticloadbalancing:CreateTargetGroup",
-				"elasticloadbalancing:DeleteListener",
-				"elasticloadbalancing:DeleteLoadBalancer",
-				"elasticloadbalancing:DeleteRule",
-				"elasticloadbalancing:DeleteTargetGroup",
-				"elasticloadbalancing:DeregisterTargets",
-				"elasticloadbalancing:DescribeListenerCertificates",
-				"elasticloadbalancing:DescribeListeners",
-				"elasticloadbalancing:DescribeLoadBalancers",
-				"elasticloadbalancing:DescribeLoadBalancerAttributes",
-				"elasticloadbalancing:DescribeRules",
-				"elasticloadbalancing:DescribeSSLPolicies",
-				"elasticloadbalancing:DescribeTags",
-				"elasticloadbalancing:DescribeTargetGroups",
-				"elasticloadbalancing:DescribeTargetGroupAttributes",
-				"elasticloadbalancing:DescribeTargetHealth",
-				"elasticloadbalancing:ModifyListener",
-				"elasticloadbalancing:ModifyLoadBalancerAttributes",
-				"elasticloadbalancing:ModifyRule",
-				"elasticloadbalancing:ModifyTargetGroup",
-				"elasticloadbalancing:ModifyTargetGroupAttributes",
-				"elasticloadbalancing:RegisterTargets",
-				"elasticloadbalancing:RemoveListenerCertificates",
-				"elasticloadbalancing:RemoveTags",
-				"elasticloadbalancing:SetIpAddressType",
-				"elasticloadbalancing:SetSecurityGroups",
-				"elasticloadbalancing:SetSubnets",
-				"elasticloadbalancing:SetWebACL",
-			},
-		},
-		{
-			Effect:   "Allow",
-			Resource: "*",
-			Action: []string{
-				"iam:CreateServiceLinkedRole",
-				"iam:GetServerCertificate",
-				"iam:ListServerCertificates",
-			},
-		},
-		{
-			Effect:   "Allow",
-			Resource: "*",
-			Action: []string{
-				"cognito-idp:DescribeUserPoolClient",
-			},
-		},
-		{
-			Effect:   "Allow",
-			Resource: "*",
-			Action: []string{
-				"waf-regional:GetWebACLForResource",
-				"waf-regional:GetWebACL",
-				"waf-regional:AssociateWebACL",
-				"waf-regional:DisassociateWebACL",
-			},
-		},
-		{
-			Effect:   "Allow",
-			Resource: "*",
-			Action: []string{
-				"tag:GetResources",
-				"tag:TagResources",
-			},
-		},
-		{
-			Effect:   "Allow",
-			Resource: "*",
-			Action: []string{
-				"waf:GetWebACL",
-			},
-		},
-		{
-			Effect:   "Allow",
-			Resource: "*",
-			Action: []string{
-				"wafv2:GetWebACL",
-				"wafv2:GetWebACLForResource",
-				"wafv2:AssociateWebACL",
-				"wafv2:DisassociateWebACL",
-			},
-		},
-		{
-			Effect:   "Allow",
-			Resource: "*",
-			Action: []string{
-				"shield:DescribeProtection",
-				"shield:GetSubscriptionState",
-				"shield:DeleteProtection",
-				"shield:CreateProtection",
-				"shield:DescribeSubscription",
-				"shield:ListProtections",
-			},
-		},
-		{
-			Effect:   "Allow",
-			Resource: "*",
-			Action: []string{
-				"appmesh:*",
-				"servicediscovery:CreateService",
-				"servicediscovery:GetService",
-				"servicediscovery:RegisterInstance",
-				"servicediscovery:DeregisterInstance",
-				"servicediscovery:ListInstances",
-				"servicediscovery:ListNamespaces",
-				"servicediscovery:ListServices",
-				"route53:GetHealthCheck",
-				"route53:CreateHealthCheck",
-				"route53:UpdateHealthCheck",
-				"route53:ChangeResourceRecordSets",
-				"route53:DeleteHealthCheck",
-			},
-		},
-		{ // for fluentd add-on
-			Effect:   "Allow",
-			Resource: "*",
-			Action: []string{
-				"logs:CreateLogGroup",
-				"logs:CreateLogStream",
-				"logs:DescribeLogGroups",
-				"logs:DescribeLogStreams",
-				"logs:PutLogEvents",
-			},
-		},
-		{ // for cluster autoscaler
-			Effect:   "Allow",
-			Resource: "*",
-			Action: []string{
-				"autoscaling:DescribeAutoScalingGroups",
-				"autoscaling:DescribeAutoScalingInstances",
-				"autoscaling:DescribeLaunchConfigurations",
-				"autoscaling:DescribeTags",
-				"autoscaling:SetDesiredCapacity",
-				"autoscaling:TerminateInstanceInAutoScalingGroup",
-				"ec2:DescribeLaunchTemplateVersions",
-			},
-		},
-		{ // for artifact uploads from worker nodes
-			Effect:   "Allow",
-			Resource: fmt.Sprintf("arn:%s:s3:::%s/*", partition, bucketName),
-			Action: []string{
-				"s3:ListBucket",
-				"s3:GetObject",
-				"s3:PutObject",
-			},
-		},
-		{ // arn:aws:iam::aws:policy/AmazonS3FullAccess
-			Effect:   "Allow",
-			Resource: "*",
-			Action: []string{
-				"s3:*",
-			},
-		},
-		{ // arn:aws:iam::aws:policy/AmazonEC2ContainerRegistryReadOnly
-			Effect:   "Allow",
-			Resource: "*",
-			Action: []string{
-				"ecr:GetAuthorizationToken",
-				"ecr:BatchCheckLayerAvailability",
-				"ecr:GetDownloadUrlForLayer",
-				"ecr:GetRepositoryPolicy",
-				"ecr:DescribeRepositories",
-				"ecr:ListImages",
-				"ecr:DescribeImages",
-				"ecr:BatchGetImage",
-				"ecr:GetLifecyclePolicy",
-				"ecr:GetLifecyclePolicyPreview",
-				"ecr:ListTagsForResource",
-				"ecr:DescribeImageScanFindings",
-			},
-		},
-	}
-}
-
 func (ts *tester) createInstanceProfile() error {
 	ts.cfg.Logger.Info("creating instance profile")
 	out, err := ts.cfg.IAMAPIV2.CreateInstanceProfile(
@@ -0,0 +1,169 @@
+package nodefault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	canaryName = "node-fault-canary"
+	appName    = "node-fault-canary"
+	pauseImage = eksconfig.DefaultPauseImage
+)
+
+// createCanary creates a DaemonSet to run a canary Pod on every node,
+// which must keep running while the node services restart.
+func (ts *tester) createCanary() error {
+	cur := ts.cfg.EKSConfig.AddOnNodeFault
+	labels := map[string]string{
+		"app.kubernetes.io/name": appName,
+	}
+	var nodeSelector map[string]string
+	if cur.NodeGroupName != "" {
+		nodeSelector = map[string]string{"NGName": cur.NodeGroupName}
+	}
+
+	ts.cfg.Logger.Info("creating canary DaemonSet")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		DaemonSets(cur.Namespace).
+		Create(
+			ctx,
+			&appsv1.DaemonSet{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "DaemonSet",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      canaryName,
+					Namespace: cur.Namespace,
+					Labels:    labels,
+				},
+				Spec: appsv1.DaemonSetSpec{
+					Selector: &metav1.LabelSelector{
+						MatchLabels: labels,
+					},
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: labels,
+						},
+						Spec: v1.PodSpec{
+							RestartPolicy:                 v1.RestartPolicyAlways,
+							TerminationGracePeriodSeconds: aws_v2.Int64(0),
+							Containers: []v1.Container{
+								{
+									Name:            appName,
+									Image:           ts.cfg.EKSConfig.Image(pauseImage),
+									ImagePullPolicy: v1.PullIfNotPresent,
+								},
+							},
+							NodeSelector: nodeSelector,
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create canary DaemonSet (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created canary DaemonSet")
+	return nil
+}
+
+func (ts *tester) waitCanary() error {
+	cur := ts.cfg.EKSConfig.AddOnNodeFault
+	ts.cfg.Logger.Info("waiting for canary DaemonSet")
+	deadline := time.Now().Add(10 * time.Minute)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("canary DaemonSet wait aborted")
+		case <-time.After(10 * time.Second):
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		ds, err := ts.cfg.K8SClient.KubernetesClientSet().AppsV1().DaemonSets(cur.Namespace).Get(ctx, canaryName, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get canary DaemonSet", zap.Error(err))
+			continue
+		}
+		ts.cfg.Logger.Info("checked canary DaemonSet",
+			zap.Int32("desired", ds.Status.DesiredNumberScheduled),
+			zap.Int32("ready", ds.Status.NumberReady),
+		)
+		if ds.Status.DesiredNumberScheduled > 0 && ds.Status.NumberReady == ds.Status.DesiredNumberScheduled {
+			return nil
+		}
+	}
+	return errors.New("canary DaemonSet not ready")
+}
+
+// canaryPod returns the canary Pod on the node.
+func (ts *tester) canaryPod(nodeName string) (v1.Pod, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	pods, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(ts.cfg.EKSConfig.AddOnNodeFault.Namespace).
+		List(ctx, metav1.ListOptions{
+			LabelSelector: "app.kubernetes.io/name=" + appName,
+			FieldSelector: "spec.nodeName=" + nodeName,
+		})
+	cancel()
+	if err != nil {
+		return v1.Pod{}, err
+	}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp == nil {
+			return pod, nil
+		}
+	}
+	return v1.Pod{}, fmt.Errorf("no canary Pod on node %q", nodeName)
+}
+
+// checkCanary returns the error message if the canary Pod
+// did not keep running across the restart.
+func checkCanary(before v1.Pod, after v1.Pod) string {
+	if before.UID != after.UID {
+		return fmt.Sprintf("canary Pod %q replaced by %q", before.Name, after.Name)
+	}
+	if !isReady(after) {
+		return fmt.Sprintf("canary Pod %q not ready (phase %q)", after.Name, after.Status.Phase)
+	}
+	if restarts := restartCount(after) - restartCount(before); restarts > 0 {
+		return fmt.Sprintf("canary Pod %q containers restarted %d time(s)", after.Name, restarts)
+	}
+	return ""
+}
+
+func restartCount(pod v1.Pod) (n int32) {
+	for _, st := range pod.Status.ContainerStatuses {
+		n += st.RestartCount
+	}
+	return n
+}
+
+func isReady(pod v1.Pod) bool {
+	if pod.Status.Phase != v1.PodRunning {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == v1.PodReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package nodefault
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestCheckCanary(t *testing.T) {
+	pod := func(uid string, restarts int32, phase v1.PodPhase) v1.Pod {
+		return v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "canary-" + uid, UID: types.UID("uid-" + uid)},
+			Status: v1.PodStatus{
+				Phase:             phase,
+				Conditions:        []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}},
+				ContainerStatuses: []v1.ContainerStatus{{RestartCount: restarts}},
+			},
+		}
+	}
+	tt := []struct {
+		before, after v1.Pod
+		exp           string
+	}{
+		{pod("a", 1, v1.PodRunning), pod("a", 1, v1.PodRunning), ""},
+		{pod("a", 0, v1.PodRunning), pod("b", 0, v1.PodRunning), "replaced"},
+		{pod("a", 0, v1.PodRunning), pod("a", 0, v1.PodPending), "not ready"},
+		{pod("a", 0, v1.PodRunning), pod("a", 2, v1.PodRunning), "restarted 2 time(s)"},
+	}
+	for i, tv := range tt {
+		msg := checkCanary(tv.before, tv.after)
+		if tv.exp == "" && msg != "" || !strings.Contains(msg, tv.exp) {
+			t.Fatalf("#%d: expected %q, got %q", i, tv.exp, msg)
+		}
+	}
+}
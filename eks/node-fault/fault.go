@@ -0,0 +1,191 @@
+package nodefault
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/ssh"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type target struct {
+	nodeName   string
+	instanceID string
+}
+
+// selectTargets selects the random subset of the Ready nodes.
+func (ts *tester) selectTargets() ([]target, error) {
+	cur := ts.cfg.EKSConfig.AddOnNodeFault
+	opts := metav1.ListOptions{}
+	if cur.NodeGroupName != "" {
+		opts.LabelSelector = "NGName=" + cur.NodeGroupName
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	nodes, err := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Nodes().List(ctx, opts)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes (%v)", err)
+	}
+
+	var targets []target
+	for _, node := range nodes.Items {
+		if !isNodeReady(node) || node.Spec.ProviderID == "" {
+			continue
+		}
+		// e.g. "aws:///us-west-2a/i-0123456789abcdef0"
+		id := node.Spec.ProviderID[strings.LastIndex(node.Spec.ProviderID, "/")+1:]
+		targets = append(targets, target{nodeName: node.Name, instanceID: id})
+	}
+	if len(targets) < cur.Nodes {
+		return nil, fmt.Errorf("%d Ready node(s) found, fewer than %d", len(targets), cur.Nodes)
+	}
+	rand.Shuffle(len(targets), func(i, j int) { targets[i], targets[j] = targets[j], targets[i] })
+	targets = targets[:cur.Nodes]
+	for _, tg := range targets {
+		ts.cfg.Logger.Info("selected node", zap.String("node-name", tg.nodeName), zap.String("instance-id", tg.instanceID))
+	}
+	return targets, nil
+}
+
+// newNode returns the node to run the commands on, with the configured transport.
+func (ts *tester) newNode(pool *ssh.Pool, instanceID string) (ssh.Node, error) {
+	if ts.cfg.EKSConfig.AddOnNodeFault.Transport == eksconfig.NodeFaultTransportSSM {
+		return ssh.NewSSMNode(ts.cfg.SSMAPIV2, instanceID), nil
+	}
+
+	inst, ok := ts.cfg.EKSConfig.Instance(instanceID)
+	if !ok {
+		return nil, fmt.Errorf("instance %q not found in the node groups", instanceID)
+	}
+	sh, err := pool.Get(instanceID, ssh.Config{
+		Logger:        ts.cfg.Logger,
+		KeyPath:       ts.cfg.EKSConfig.RemoteAccessPrivateKeyPath,
+		PublicIP:      inst.PublicIP,
+		PublicDNSName: inst.PublicDNSName,
+		PrivateIP:     inst.PrivateIP,
+		UserName:      inst.RemoteAccessUserName,
+		InstanceConnect: ssh.NewInstanceConnect(
+			ts.cfg.EC2InstanceConnectAPI,
+			instanceID,
+			inst.Placement.AvailabilityZone,
+		),
+		InstanceID:         instanceID,
+		HostKeys:           ts.cfg.SSHHostKeys,
+		InsecureSkipVerify: ts.cfg.SSHHostKeys == nil,
+		ProxyJump: ssh.NewProxyJump(
+			ts.cfg.EKSConfig.RemoteAccessBastionHost,
+			ts.cfg.EKSConfig.RemoteAccessBastionUserName,
+			ts.cfg.EKSConfig.RemoteAccessBastionPrivateKeyPath,
+		),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewSSHNode(instanceID, sh), nil
+}
+
+// restart restarts the service on the node, waits for the node to report
+// Ready again, and verifies the canary Pod on the node kept running.
+func (ts *tester) restart(n ssh.Node, nodeName string, svc string) (rs eksconfig.NodeFaultResult) {
+	rs = eksconfig.NodeFaultResult{InstanceID: n.InstanceID(), NodeName: nodeName, Service: svc}
+	before, err := ts.canaryPod(nodeName)
+	if err != nil {
+		rs.Error = err.Error()
+		return rs
+	}
+
+	ts.cfg.Logger.Info("restarting service", zap.String("node-name", nodeName), zap.String("service", svc))
+	start := time.Now()
+	rs.RestartedUTC = start.UTC()
+	if err = ssh.RestartService(n, svc, ssh.WithTimeout(2*time.Minute)); err != nil {
+		rs.Error = err.Error()
+		return rs
+	}
+	restarted := time.Now()
+
+	err = ts.waitReady(nodeName, restarted)
+	rs.ReadyTime = time.Since(start)
+	rs.ReadyTimeString = rs.ReadyTime.String()
+	if err != nil {
+		rs.Error = err.Error()
+		return rs
+	}
+	active, err := ssh.IsServiceActive(n, svc, ssh.WithTimeout(time.Minute))
+	switch {
+	case err != nil:
+		rs.Error = fmt.Sprintf("failed to check %q on %q (%v)", svc, nodeName, err)
+		return rs
+	case !active:
+		rs.Error = fmt.Sprintf("%q not active on %q", svc, nodeName)
+		return rs
+	}
+
+	after, err := ts.canaryPod(nodeName)
+	if err != nil {
+		rs.Error = err.Error()
+		return rs
+	}
+	if msg := checkCanary(before, after); msg != "" {
+		rs.Error = fmt.Sprintf("%s after restarting %q on %q", msg, svc, nodeName)
+	}
+	ts.cfg.Logger.Info("restarted service",
+		zap.String("node-name", nodeName),
+		zap.String("service", svc),
+		zap.String("ready-time", rs.ReadyTimeString),
+		zap.String("error", rs.Error),
+	)
+	return rs
+}
+
+// waitReady waits until the node is Ready and the kubelet renewed
+// the node lease after the restart, within "ReadyTimeout".
+func (ts *tester) waitReady(nodeName string, restarted time.Time) error {
+	cur := ts.cfg.EKSConfig.AddOnNodeFault
+	cli := ts.cfg.K8SClient.KubernetesClientSet()
+	deadline := time.Now().Add(cur.ReadyTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return fmt.Errorf("node %q ready wait aborted", nodeName)
+		case <-time.After(2 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		lease, err := cli.CoordinationV1().Leases(v1.NamespaceNodeLease).Get(ctx, nodeName, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get node lease", zap.Error(err))
+			continue
+		}
+		if lease.Spec.RenewTime == nil || lease.Spec.RenewTime.Time.Before(restarted) {
+			continue
+		}
+
+		ctx, cancel = context.WithTimeout(context.Background(), 15*time.Second)
+		node, err := cli.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get node", zap.Error(err))
+			continue
+		}
+		if isNodeReady(*node) {
+			return nil
+		}
+	}
+	return fmt.Errorf("node %q not Ready within %v", nodeName, cur.ReadyTimeout)
+}
+
+func isNodeReady(node v1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == v1.NodeReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
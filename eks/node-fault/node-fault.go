@@ -0,0 +1,176 @@
+// Package nodefault restarts the node services (e.g. kubelet, containerd)
+// on a subset of nodes via SSM Run Command or SSH, and verifies
+// the Pods keep running and the nodes return Ready within a bound.
+package nodefault
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"github.com/aws/aws-k8s-tester/ssh"
+	aws_ssm_v2 "github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ec2instanceconnect/ec2instanceconnectiface"
+	"go.uber.org/zap"
+)
+
+// Config defines node fault configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+
+	SSMAPIV2              *aws_ssm_v2.Client
+	EC2InstanceConnectAPI ec2instanceconnectiface.EC2InstanceConnectAPI
+	SSHHostKeys           *ssh.HostKeys
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new node fault tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:      "node-fault",
+		Exclusive: true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:                cfg.Logger,
+				LogWriter:             cfg.LogWriter,
+				Stopc:                 cfg.Stopc,
+				EKSConfig:             cfg.EKSConfig,
+				K8SClient:             cfg.K8SClient,
+				SSMAPIV2:              cfg.SSMAPIV2,
+				EC2InstanceConnectAPI: cfg.EC2InstanceConnectAPI,
+				SSHHostKeys:           cfg.SSHHostKeys,
+			}), cfg.EKSConfig.IsEnabledAddOnNodeFault)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnNodeFault() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnNodeFault.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnNodeFault.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnNodeFault.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	cur := ts.cfg.EKSConfig.AddOnNodeFault
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		cur.Namespace,
+	); err != nil {
+		return err
+	}
+	if err = ts.createCanary(); err != nil {
+		return err
+	}
+	if err = ts.waitCanary(); err != nil {
+		return err
+	}
+	targets, err := ts.selectTargets()
+	if err != nil {
+		return err
+	}
+
+	pool := ssh.NewPool(ts.cfg.Logger)
+	defer pool.Close()
+
+	var errs []string
+	for _, tg := range targets {
+		n, err := ts.newNode(pool, tg.instanceID)
+		if err != nil {
+			return fmt.Errorf("failed to connect to %q (%v)", tg.instanceID, err)
+		}
+		for _, svc := range cur.Services {
+			select {
+			case <-ts.cfg.Stopc:
+				return errors.New("node fault aborted")
+			default:
+			}
+			rs := ts.restart(n, tg.nodeName, svc)
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				cur.Results = append(cur.Results, rs)
+			})
+			fmt.Fprintf(ts.cfg.LogWriter, "\nrestarted %q on %q (%s): ready in %s\n", svc, tg.nodeName, tg.instanceID, rs.ReadyTimeString)
+			if rs.Error != "" {
+				errs = append(errs, rs.Error)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnNodeFault() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnNodeFault.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnNodeFault.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnNodeFault.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		return fmt.Errorf("failed to delete node fault namespace (%v)", err)
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnNodeFault.Created = false
+	})
+	return nil
+}
@@ -0,0 +1,401 @@
+// Package nodepolicy composes the IAM policy document of the worker node
+// roles (node groups and managed node groups) from the statements each
+// add-on requires, so that the roles are granted only the permissions
+// of the enabled add-ons.
+package nodepolicy
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_iam "github.com/aws/aws-k8s-tester/pkg/aws/iam"
+)
+
+// group is the set of statements required by a component.
+type group struct {
+	name string
+	// enabled returns true if the component is enabled.
+	// nil to always include the statements.
+	enabled    func(cfg *eksconfig.Config) bool
+	statements func(cfg *eksconfig.Config) []aws_iam.StatementEntry
+}
+
+var groups = []group{
+	{name: "node", statements: nodeStatements},
+	{
+		name: "ipv6",
+		enabled: func(cfg *eksconfig.Config) bool {
+			return cfg.IPFamily == eksconfig.IPFamilyIPv6
+		},
+		statements: ipv6Statements,
+	},
+	{
+		name: "ebs",
+		enabled: func(cfg *eksconfig.Config) bool {
+			return cfg.IsEnabledAddOnCSIEBS() || cfg.IsEnabledAddOnCSIEBSChurn()
+		},
+		statements: ebsStatements,
+	},
+	{
+		name: "load-balancer",
+		enabled: func(cfg *eksconfig.Config) bool {
+			return cfg.IsEnabledAddOnALB() || cfg.IsEnabledAddOnALB2048()
+		},
+		statements: loadBalancerStatements,
+	},
+	{
+		name:       "app-mesh",
+		enabled:    (*eksconfig.Config).IsEnabledAddOnAppMesh,
+		statements: appMeshStatements,
+	},
+	{
+		name: "logs",
+		enabled: func(cfg *eksconfig.Config) bool {
+			return cfg.IsEnabledAddOnFluentd() || cfg.IsEnabledAddOnCWAgent() || cfg.IsEnabledAddOnContainerInsights()
+		},
+		statements: logsStatements,
+	},
+	{
+		name: "cluster-autoscaler",
+		enabled: func(cfg *eksconfig.Config) bool {
+			return cfg.Spec.ClusterAutoscaler != nil && cfg.Spec.ClusterAutoscaler.CloudProvider == eksconfig.CloudProviderAWS
+		},
+		statements: clusterAutoscalerStatements,
+	},
+	{
+		// no add-on requires more than the artifact bucket access
+		// (see "nodeStatements"), only kept for the broad policy
+		name:       "s3-full-access",
+		enabled:    func(*eksconfig.Config) bool { return false },
+		statements: s3FullAccessStatements,
+	},
+}
+
+// Groups returns the names of the statement groups in the policy.
+func Groups(cfg *eksconfig.Config, leastPrivilege bool) (names []string) {
+	for _, g := range groups {
+		if !leastPrivilege || g.enabled == nil || g.enabled(cfg) {
+			names = append(names, g.name)
+		}
+	}
+	return names
+}
+
+// Statements returns the node role policy statements. If "leastPrivilege"
+// is false, the statements of all add-ons are included regardless of
+// whether they are enabled.
+func Statements(cfg *eksconfig.Config, leastPrivilege bool) (ss []aws_iam.StatementEntry) {
+	for _, g := range groups {
+		if !leastPrivilege || g.enabled == nil || g.enabled(cfg) {
+			ss = append(ss, g.statements(cfg)...)
+		}
+	}
+	return ss
+}
+
+// Document returns the node role policy document in JSON.
+func Document(cfg *eksconfig.Config, leastPrivilege bool) string {
+	p := aws_iam.PolicyDocument{
+		Version:   "2012-10-17",
+		Statement: Statements(cfg, leastPrivilege),
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+// arn:aws:iam::aws:policy/AmazonEKSWorkerNodePolicy
+// arn:aws:iam::aws:policy/AmazonEC2ContainerRegistryReadOnly
+func nodeStatements(cfg *eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"ec2:DescribeAccountAttributes",
+				"ec2:DescribeAddresses",
+				"ec2:DescribeInstanceStatus",
+				"ec2:DescribeInstances",
+				"ec2:DescribeInternetGateways",
+				"ec2:DescribeNetworkInterfaces",
+				"ec2:DescribeRouteTables",
+				"ec2:DescribeSecurityGroups",
+				"ec2:DescribeSubnets",
+				"ec2:DescribeTags",
+				"ec2:DescribeVpcs",
+				"eks:DescribeCluster",
+			},
+		},
+		{ // for artifact uploads from worker nodes
+			Effect:   "Allow",
+			Resource: fmt.Sprintf("arn:%s:s3:::%s/*", cfg.Partition, cfg.S3.BucketName),
+			Action: []string{
+				"s3:ListBucket",
+				"s3:GetObject",
+				"s3:PutObject",
+			},
+		},
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"ecr:GetAuthorizationToken",
+				"ecr:BatchCheckLayerAvailability",
+				"ecr:GetDownloadUrlForLayer",
+				"ecr:GetRepositoryPolicy",
+				"ecr:DescribeRepositories",
+				"ecr:ListImages",
+				"ecr:DescribeImages",
+				"ecr:BatchGetImage",
+				"ecr:GetLifecyclePolicy",
+				"ecr:GetLifecyclePolicyPreview",
+				"ecr:ListTagsForResource",
+				"ecr:DescribeImageScanFindings",
+			},
+		},
+	}
+}
+
+// "AmazonEKS_CNI_Policy" only grants IPv4 address management
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/cni-iam-role.html#cni-iam-role-create-ipv6-policy
+func ipv6Statements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"ec2:AssignIpv6Addresses",
+				"ec2:DescribeInstanceTypes",
+			},
+		},
+	}
+}
+
+// https://docs.aws.amazon.com/eks/latest/userguide/ebs-csi.html
+func ebsStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"ec2:AttachVolume",
+				"ec2:CreateSnapshot",
+				"ec2:CreateTags",
+				"ec2:CreateVolume",
+				"ec2:DeleteSnapshot",
+				"ec2:DeleteTags",
+				"ec2:DeleteVolume",
+				"ec2:DescribeSnapshots",
+				"ec2:DescribeVolumes",
+				"ec2:DescribeVolumesModifications",
+				"ec2:DetachVolume",
+			},
+		},
+	}
+}
+
+// https://github.com/kubernetes-sigs/aws-alb-ingress-controller/blob/master/docs/examples/iam-policy.json
+func loadBalancerStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"acm:DescribeCertificate",
+				"acm:ListCertificates",
+				"acm:GetCertificate",
+			},
+		},
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"ec2:AuthorizeSecurityGroupIngress",
+				"ec2:CreateSecurityGroup",
+				"ec2:CreateTags",
+				"ec2:DeleteSecurityGroup",
+				"ec2:DeleteTags",
+				"ec2:ModifyInstanceAttribute",
+				"ec2:ModifyNetworkInterfaceAttribute",
+				"ec2:RevokeSecurityGroupIngress",
+			},
+		},
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"elasticloadbalancing:AddListenerCertificates",
+				"elasticloadbalancing:AddTags",
+				"elasticloadbalancing:CreateListener",
+				"elasticloadbalancing:CreateLoadBalancer",
+				"elasticloadbalancing:CreateRule",
+				"elasticloadbalancing:CreateTargetGroup",
+				"elasticloadbalancing:DeleteListener",
+				"elasticloadbalancing:DeleteLoadBalancer",
+				"elasticloadbalancing:DeleteRule",
+				"elasticloadbalancing:DeleteTargetGroup",
+				"elasticloadbalancing:DeregisterTargets",
+				"elasticloadbalancing:DescribeListenerCertificates",
+				"elasticloadbalancing:DescribeListeners",
+				"elasticloadbalancing:DescribeLoadBalancers",
+				"elasticloadbalancing:DescribeLoadBalancerAttributes",
+				"elasticloadbalancing:DescribeRules",
+				"elasticloadbalancing:DescribeSSLPolicies",
+				"elasticloadbalancing:DescribeTags",
+				"elasticloadbalancing:DescribeTargetGroups",
+				"elasticloadbalancing:DescribeTargetGroupAttributes",
+				"elasticloadbalancing:DescribeTargetHealth",
+				"elasticloadbalancing:ModifyListener",
+				"elasticloadbalancing:ModifyLoadBalancerAttributes",
+				"elasticloadbalancing:ModifyRule",
+				"elasticloadbalancing:ModifyTargetGroup",
+				"elasticloadbalancing:ModifyTargetGroupAttributes",
+				"elasticloadbalancing:RegisterTargets",
+				"elasticloadbalancing:RemoveListenerCertificates",
+				"elasticloadbalancing:RemoveTags",
+				"elasticloadbalancing:SetIpAddressType",
+				"elasticloadbalancing:SetSecurityGroups",
+				"elasticloadbalancing:SetSubnets",
+				"elasticloadbalancing:SetWebACL",
+			},
+		},
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"iam:CreateServiceLinkedRole",
+				"iam:GetServerCertificate",
+				"iam:ListServerCertificates",
+			},
+		},
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"cognito-idp:DescribeUserPoolClient",
+			},
+		},
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"waf-regional:GetWebACLForResource",
+				"waf-regional:GetWebACL",
+				"waf-regional:AssociateWebACL",
+				"waf-regional:DisassociateWebACL",
+			},
+		},
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"tag:GetResources",
+				"tag:TagResources",
+			},
+		},
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"waf:GetWebACL",
+			},
+		},
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"wafv2:GetWebACL",
+				"wafv2:GetWebACLForResource",
+				"wafv2:AssociateWebACL",
+				"wafv2:DisassociateWebACL",
+			},
+		},
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"shield:DescribeProtection",
+				"shield:GetSubscriptionState",
+				"shield:DeleteProtection",
+				"shield:CreateProtection",
+				"shield:DescribeSubscription",
+				"shield:ListProtections",
+			},
+		},
+	}
+}
+
+// https://github.com/aws/eks-charts/tree/master/stable/appmesh-controller
+func appMeshStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"appmesh:*",
+				"servicediscovery:CreateService",
+				"servicediscovery:GetService",
+				"servicediscovery:RegisterInstance",
+				"servicediscovery:DeregisterInstance",
+				"servicediscovery:ListInstances",
+				"servicediscovery:ListNamespaces",
+				"servicediscovery:ListServices",
+				"route53:GetHealthCheck",
+				"route53:CreateHealthCheck",
+				"route53:UpdateHealthCheck",
+				"route53:ChangeResourceRecordSets",
+				"route53:DeleteHealthCheck",
+			},
+		},
+	}
+}
+
+func logsStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"logs:CreateLogGroup",
+				"logs:CreateLogStream",
+				"logs:DescribeLogGroups",
+				"logs:DescribeLogStreams",
+				"logs:PutLogEvents",
+			},
+		},
+	}
+}
+
+func clusterAutoscalerStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"autoscaling:DescribeAutoScalingGroups",
+				"autoscaling:DescribeAutoScalingInstances",
+				"autoscaling:DescribeLaunchConfigurations",
+				"autoscaling:DescribeTags",
+				"autoscaling:SetDesiredCapacity",
+				"autoscaling:TerminateInstanceInAutoScalingGroup",
+				"ec2:DescribeLaunchTemplateVersions",
+			},
+		},
+	}
+}
+
+// arn:aws:iam::aws:policy/AmazonS3FullAccess
+func s3FullAccessStatements(*eksconfig.Config) []aws_iam.StatementEntry {
+	return []aws_iam.StatementEntry{
+		{
+			Effect:   "Allow",
+			Resource: "*",
+			Action: []string{
+				"s3:*",
+			},
+		},
+	}
+}
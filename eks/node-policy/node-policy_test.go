@@ -0,0 +1,50 @@
+package nodepolicy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+)
+
+func TestGroups(t *testing.T) {
+	cfg := eksconfig.NewDefault()
+
+	all := Groups(cfg, false)
+	if len(all) != len(groups) {
+		t.Fatalf("expected all %d groups, got %q", len(groups), all)
+	}
+	if got := Groups(cfg, true); !reflect.DeepEqual(got, []string{"node"}) {
+		t.Fatalf("unexpected groups %q", got)
+	}
+
+	cfg.AddOnCSIEBS = &eksconfig.AddOnCSIEBS{Enable: true}
+	cfg.AddOnALB2048 = &eksconfig.AddOnALB2048{Enable: true}
+	cfg.IPFamily = eksconfig.IPFamilyIPv6
+	if got := Groups(cfg, true); !reflect.DeepEqual(got, []string{"node", "ipv6", "ebs", "load-balancer"}) {
+		t.Fatalf("unexpected groups %q", got)
+	}
+}
+
+func TestDocument(t *testing.T) {
+	cfg := eksconfig.NewDefault()
+	cfg.S3.BucketName = "my-bucket"
+
+	doc := Document(cfg, true)
+	for _, action := range []string{"ec2:CreateVolume", "elasticloadbalancing:CreateLoadBalancer", `"s3:*"`} {
+		if strings.Contains(doc, action) {
+			t.Fatalf("least-privilege policy unexpectedly has %q: %s", action, doc)
+		}
+	}
+	if !strings.Contains(doc, "arn:aws:s3:::my-bucket/*") {
+		t.Fatalf("expected the artifact bucket access: %s", doc)
+	}
+
+	doc = Document(cfg, false)
+	for _, action := range []string{"ec2:CreateVolume", "elasticloadbalancing:CreateLoadBalancer", `"s3:*"`} {
+		if !strings.Contains(doc, action) {
+			t.Fatalf("expected %q in the policy: %s", action, doc)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Package notify sends the run events (e.g. cluster ready, add-on failed,
+// teardown complete) to Slack, SNS, or generic HTTP endpoints.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"go.uber.org/zap"
+)
+
+// Event is the run event, passed to the message and payload templates.
+type Event struct {
+	// Type is the event type (e.g. "cluster-ready", "add-on-failed").
+	Type string `json:"type"`
+	// Cluster is the name of the cluster.
+	Cluster string `json:"cluster"`
+	// Region is the AWS region of the cluster.
+	Region string `json:"region"`
+	// Message describes the event.
+	Message string `json:"message"`
+	// Error is the error message, if the event is a failure.
+	Error string `json:"error,omitempty"`
+	// Time is the time of the event.
+	Time time.Time `json:"time"`
+}
+
+// Config defines notifier configuration.
+type Config struct {
+	Logger    *zap.Logger
+	EKSConfig *eksconfig.Config
+	// SNSAPI publishes to the SNS topic, required if "SNSTopicARN" is set.
+	SNSAPI snsiface.SNSAPI
+	// HTTPClient posts to Slack and the HTTP endpoint.
+	// Defaults to the client with 15-second timeout.
+	HTTPClient *http.Client
+}
+
+// Notifier sends the events to the configured sinks.
+// The zero-value and nil notifiers drop all events.
+type Notifier struct {
+	cfg     Config
+	message *template.Template
+	payload *template.Template
+}
+
+// New creates a new notifier.
+func New(cfg Config) (*Notifier, error) {
+	n := &Notifier{cfg: cfg}
+	if !cfg.EKSConfig.Notifications.IsEnabled() {
+		return n, nil
+	}
+	ns := cfg.EKSConfig.Notifications
+	if ns.SNSTopicARN != "" && cfg.SNSAPI == nil {
+		return nil, fmt.Errorf("no SNS API to publish to %q", ns.SNSTopicARN)
+	}
+	if n.cfg.HTTPClient == nil {
+		n.cfg.HTTPClient = &http.Client{Timeout: 15 * time.Second}
+	}
+
+	var err error
+	n.message, err = template.New("message").Funcs(eksconfig.NotificationTemplateFuncs).Parse(ns.MessageTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message template (%v)", err)
+	}
+	n.payload, err = template.New("http-payload").Funcs(eksconfig.NotificationTemplateFuncs).Parse(ns.HTTPPayloadTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTTP payload template (%v)", err)
+	}
+	return n, nil
+}
+
+// Notify sends the event to all sinks, if the event type is enabled.
+// The failures are logged, and do not fail the run.
+func (n *Notifier) Notify(typ string, msg string, evErr error) {
+	if n == nil || n.message == nil || !n.cfg.EKSConfig.Notifications.IsEventEnabled(typ) {
+		return
+	}
+	ev := Event{
+		Type:    typ,
+		Cluster: n.cfg.EKSConfig.Name,
+		Region:  n.cfg.EKSConfig.Region,
+		Message: msg,
+		Time:    time.Now().UTC(),
+	}
+	if evErr != nil {
+		ev.Error = evErr.Error()
+	}
+	for _, err := range n.Send(ev) {
+		n.cfg.Logger.Warn("failed to send notification", zap.String("type", typ), zap.Error(err))
+	}
+}
+
+// Send renders and sends the event to all sinks,
+// and returns the errors of the failed sinks.
+func (n *Notifier) Send(ev Event) (errs []error) {
+	ns := n.cfg.EKSConfig.Notifications
+	if ns.SlackWebhookURL != "" {
+		if err := n.sendSlack(ns.SlackWebhookURL, ev); err != nil {
+			errs = append(errs, fmt.Errorf("slack (%v)", err))
+		}
+	}
+	if ns.SNSTopicARN != "" {
+		if err := n.sendSNS(ns.SNSTopicARN, ev); err != nil {
+			errs = append(errs, fmt.Errorf("sns %q (%v)", ns.SNSTopicARN, err))
+		}
+	}
+	if ns.HTTPEndpoint != "" {
+		if err := n.sendHTTP(ns.HTTPEndpoint, ev); err != nil {
+			errs = append(errs, fmt.Errorf("http %q (%v)", ns.HTTPEndpoint, err))
+		}
+	}
+	if len(errs) == 0 {
+		n.cfg.Logger.Info("sent notification", zap.String("type", ev.Type))
+	}
+	return errs
+}
+
+func (n *Notifier) sendSlack(url string, ev Event) error {
+	text, err := render(n.message, ev)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	return n.post(url, body)
+}
+
+// snsSubjectLimit is the maximum length of the SNS message subject.
+const snsSubjectLimit = 100
+
+func (n *Notifier) sendSNS(arn string, ev Event) error {
+	msg, err := render(n.message, ev)
+	if err != nil {
+		return err
+	}
+	subject := fmt.Sprintf("aws-k8s-tester %s %s", ev.Cluster, ev.Type)
+	if len(subject) > snsSubjectLimit {
+		subject = subject[:snsSubjectLimit]
+	}
+	_, err = n.cfg.SNSAPI.Publish(&sns.PublishInput{
+		TopicArn: aws.String(arn),
+		Subject:  aws.String(subject),
+		Message:  aws.String(msg),
+	})
+	return err
+}
+
+func (n *Notifier) sendHTTP(url string, ev Event) error {
+	body, err := render(n.payload, ev)
+	if err != nil {
+		return err
+	}
+	return n.post(url, []byte(body))
+}
+
+func (n *Notifier) post(url string, body []byte) error {
+	resp, err := n.cfg.HTTPClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %q (%s)", resp.Status, strings.TrimSpace(string(b)))
+	}
+	return nil
+}
+
+func render(tmpl *template.Template, ev Event) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, ev); err != nil {
+		return "", fmt.Errorf("failed to render %q template (%v)", tmpl.Name(), err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"go.uber.org/zap"
+)
+
+type fakeSNS struct {
+	snsiface.SNSAPI
+	published []*sns.PublishInput
+}
+
+func (f *fakeSNS) Publish(in *sns.PublishInput) (*sns.PublishOutput, error) {
+	f.published = append(f.published, in)
+	return &sns.PublishOutput{}, nil
+}
+
+func TestNotify(t *testing.T) {
+	var mu sync.Mutex
+	bodies := make(map[string][]byte)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		bodies[r.URL.Path] = b
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	cfg := &eksconfig.Config{
+		Name:   "test-cluster",
+		Region: "us-west-2",
+		Notifications: &eksconfig.Notifications{
+			SlackWebhookURL:     srv.URL + "/slack",
+			SNSTopicARN:         "arn:aws:sns:us-west-2:123:topic",
+			HTTPEndpoint:        srv.URL + "/http",
+			MessageTemplate:     eksconfig.DefaultNotificationMessageTemplate,
+			HTTPPayloadTemplate: eksconfig.DefaultNotificationHTTPPayloadTemplate,
+			Events:              []string{eksconfig.NotificationEventAddOnFailed},
+		},
+	}
+	snsAPI := &fakeSNS{}
+	n, err := New(Config{Logger: zap.NewExample(), EKSConfig: cfg, SNSAPI: snsAPI})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// not in the enabled events
+	n.Notify(eksconfig.NotificationEventClusterReady, "cluster is ready", nil)
+	if len(bodies) != 0 || len(snsAPI.published) != 0 {
+		t.Fatalf("unexpected notification for disabled event %q", bodies)
+	}
+
+	n.Notify(eksconfig.NotificationEventAddOnFailed, "add-on failed", errors.New("timed out"))
+
+	var slack map[string]string
+	if err = json.Unmarshal(bodies["/slack"], &slack); err != nil {
+		t.Fatal(err)
+	}
+	exp := "[test-cluster] add-on-failed: add-on failed (timed out)"
+	if slack["text"] != exp {
+		t.Fatalf("expected slack text %q, got %q", exp, slack["text"])
+	}
+
+	if len(snsAPI.published) != 1 || aws.StringValue(snsAPI.published[0].Message) != exp {
+		t.Fatalf("unexpected SNS messages %v", snsAPI.published)
+	}
+
+	var ev Event
+	if err = json.Unmarshal(bodies["/http"], &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.Type != eksconfig.NotificationEventAddOnFailed || ev.Cluster != "test-cluster" || ev.Region != "us-west-2" || ev.Error != "timed out" {
+		t.Fatalf("unexpected HTTP event %+v", ev)
+	}
+}
+
+func TestSendError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	cfg := &eksconfig.Config{
+		Name: "test-cluster",
+		Notifications: &eksconfig.Notifications{
+			SlackWebhookURL:     srv.URL,
+			MessageTemplate:     eksconfig.DefaultNotificationMessageTemplate,
+			HTTPPayloadTemplate: eksconfig.DefaultNotificationHTTPPayloadTemplate,
+		},
+	}
+	n, err := New(Config{Logger: zap.NewExample(), EKSConfig: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := n.Send(Event{Type: eksconfig.NotificationEventUpFailed})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "invalid_token") {
+		t.Fatalf("expected slack error, got %v", errs)
+	}
+}
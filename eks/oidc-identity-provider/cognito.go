@@ -0,0 +1,171 @@
+package oidcidentityprovider
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aws/aws-k8s-tester/pkg/randutil"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+	"go.uber.org/zap"
+)
+
+// cognitoIdentity is the Cognito user pool issuer and its test user.
+// The password is only kept in memory.
+type cognitoIdentity struct {
+	issuerURL string
+	clientID  string
+	username  string
+	password  string
+}
+
+// cognitoIssuerURL returns the OIDC issuer URL of the Cognito user pool.
+// ref. https://docs.aws.amazon.com/cognito/latest/developerguide/amazon-cognito-user-pools-using-tokens-verifying-a-jwt.html
+func cognitoIssuerURL(region string, userPoolID string) string {
+	return fmt.Sprintf("https://cognito-idp.%s.amazonaws.com/%s", region, userPoolID)
+}
+
+// newPassword returns a random password that satisfies
+// the default Cognito password policy (upper, lower, number, symbol).
+func newPassword() string {
+	return "A" + randutil.String(20) + "9!"
+}
+
+// createCognito creates the user pool, the app client, and the test user
+// in the identity group, with a permanent password.
+func (ts *tester) createCognito() (*cognitoIdentity, error) {
+	cfg := ts.cfg.EKSConfig.AddOnOIDCIdentityProvider
+	ts.cfg.Logger.Info("creating Cognito user pool", zap.String("name", cfg.ConfigName))
+	poolOut, err := ts.cfg.CognitoAPI.CreateUserPool(&cognitoidentityprovider.CreateUserPoolInput{
+		PoolName: aws.String(cfg.ConfigName),
+		UserPoolTags: map[string]*string{
+			"Kind": aws.String("aws-k8s-tester"),
+			"Name": aws.String(ts.cfg.EKSConfig.Name),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Cognito user pool (%v)", err)
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cfg.UserPoolID = aws.StringValue(poolOut.UserPool.Id)
+	})
+
+	clientOut, err := ts.cfg.CognitoAPI.CreateUserPoolClient(&cognitoidentityprovider.CreateUserPoolClientInput{
+		UserPoolId:     aws.String(cfg.UserPoolID),
+		ClientName:     aws.String(cfg.ConfigName),
+		GenerateSecret: aws.Bool(false),
+		ExplicitAuthFlows: aws.StringSlice([]string{
+			cognitoidentityprovider.ExplicitAuthFlowsTypeAllowAdminUserPasswordAuth,
+			cognitoidentityprovider.ExplicitAuthFlowsTypeAllowRefreshTokenAuth,
+		}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Cognito user pool client (%v)", err)
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cfg.UserPoolClientID = aws.StringValue(clientOut.UserPoolClient.ClientId)
+	})
+
+	if _, err = ts.cfg.CognitoAPI.CreateGroup(&cognitoidentityprovider.CreateGroupInput{
+		UserPoolId: aws.String(cfg.UserPoolID),
+		GroupName:  aws.String(cfg.Group),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create Cognito group %q (%v)", cfg.Group, err)
+	}
+
+	idp := &cognitoIdentity{
+		issuerURL: cognitoIssuerURL(ts.cfg.EKSConfig.Region, cfg.UserPoolID),
+		clientID:  cfg.UserPoolClientID,
+		username:  ts.cfg.EKSConfig.Name + "-user",
+		password:  newPassword(),
+	}
+	if _, err = ts.cfg.CognitoAPI.AdminCreateUser(&cognitoidentityprovider.AdminCreateUserInput{
+		UserPoolId:    aws.String(cfg.UserPoolID),
+		Username:      aws.String(idp.username),
+		MessageAction: aws.String(cognitoidentityprovider.MessageActionTypeSuppress),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create Cognito user (%v)", err)
+	}
+	if _, err = ts.cfg.CognitoAPI.AdminSetUserPassword(&cognitoidentityprovider.AdminSetUserPasswordInput{
+		UserPoolId: aws.String(cfg.UserPoolID),
+		Username:   aws.String(idp.username),
+		Password:   aws.String(idp.password),
+		Permanent:  aws.Bool(true),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to set Cognito user password (%v)", err)
+	}
+	if _, err = ts.cfg.CognitoAPI.AdminAddUserToGroup(&cognitoidentityprovider.AdminAddUserToGroupInput{
+		UserPoolId: aws.String(cfg.UserPoolID),
+		Username:   aws.String(idp.username),
+		GroupName:  aws.String(cfg.Group),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to add Cognito user to group %q (%v)", cfg.Group, err)
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cfg.Username = idp.username
+	})
+
+	ts.cfg.Logger.Info("created Cognito user pool",
+		zap.String("user-pool-id", cfg.UserPoolID),
+		zap.String("issuer-url", idp.issuerURL),
+		zap.String("username", idp.username),
+	)
+	return idp, nil
+}
+
+// getCognitoIDToken signs in as the test user, and returns the ID token.
+func (ts *tester) getCognitoIDToken(idp *cognitoIdentity) (string, error) {
+	out, err := ts.cfg.CognitoAPI.AdminInitiateAuth(&cognitoidentityprovider.AdminInitiateAuthInput{
+		UserPoolId: aws.String(ts.cfg.EKSConfig.AddOnOIDCIdentityProvider.UserPoolID),
+		ClientId:   aws.String(idp.clientID),
+		AuthFlow:   aws.String(cognitoidentityprovider.AuthFlowTypeAdminUserPasswordAuth),
+		AuthParameters: map[string]*string{
+			"USERNAME": aws.String(idp.username),
+			"PASSWORD": aws.String(idp.password),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to sign in Cognito user (%v)", err)
+	}
+	if out.AuthenticationResult == nil || aws.StringValue(out.AuthenticationResult.IdToken) == "" {
+		return "", fmt.Errorf("no ID token for Cognito user (challenge %q)", aws.StringValue(out.ChallengeName))
+	}
+	ts.cfg.Logger.Info("signed in Cognito user", zap.String("username", idp.username))
+	return aws.StringValue(out.AuthenticationResult.IdToken), nil
+}
+
+// deleteCognito deletes the user pool, with its app client, group, and user.
+func (ts *tester) deleteCognito() error {
+	cfg := ts.cfg.EKSConfig.AddOnOIDCIdentityProvider
+	if cfg.UserPoolID == "" {
+		ts.cfg.Logger.Info("empty Cognito user pool ID; skipping deletion")
+		return nil
+	}
+	ts.cfg.Logger.Info("deleting Cognito user pool", zap.String("user-pool-id", cfg.UserPoolID))
+	_, err := ts.cfg.CognitoAPI.DeleteUserPool(&cognitoidentityprovider.DeleteUserPoolInput{
+		UserPoolId: aws.String(cfg.UserPoolID),
+	})
+	if err != nil && !isErrCode(err, cognitoidentityprovider.ErrCodeResourceNotFoundException) {
+		return fmt.Errorf("failed to delete Cognito user pool %q (%v)", cfg.UserPoolID, err)
+	}
+	ts.cfg.Logger.Info("deleted Cognito user pool", zap.String("user-pool-id", cfg.UserPoolID))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cfg.UserPoolID, cfg.UserPoolClientID, cfg.Username = "", "", ""
+	})
+	return nil
+}
+
+// readIDToken reads the ID token issued by the external issuer.
+func readIDToken(p string) (string, error) {
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return "", fmt.Errorf("failed to read ID token (%v)", err)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", errors.New("empty ID token")
+	}
+	return token, nil
+}
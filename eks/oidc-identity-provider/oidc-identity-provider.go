@@ -0,0 +1,259 @@
+// Package oidcidentityprovider associates an OIDC identity provider with the
+// cluster, binds a read-only ClusterRole to the test identity group,
+// and verifies the "kubectl" access with the identity ID token.
+// If no external issuer is configured, creates a Cognito user pool as the issuer.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/authenticate-oidc-identity-provider.html
+package oidcidentityprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eks/cluster/wait"
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"github.com/aws/aws-k8s-tester/version"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go/service/cognitoidentityprovider/cognitoidentityprovideriface"
+	aws_eks "github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"go.uber.org/zap"
+)
+
+// Config defines OIDC identity provider configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+
+	EKSAPI     eksiface.EKSAPI
+	CognitoAPI cognitoidentityprovideriface.CognitoIdentityProviderAPI
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new OIDC identity provider tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "oidc-identity-provider",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:     cfg.Logger,
+				LogWriter:  cfg.LogWriter,
+				Stopc:      cfg.Stopc,
+				EKSConfig:  cfg.EKSConfig,
+				K8SClient:  cfg.K8SClient,
+				EKSAPI:     cfg.EKSAPI,
+				CognitoAPI: cognitoidentityprovider.New(cfg.AWSSession),
+			}), cfg.EKSConfig.IsEnabledAddOnOIDCIdentityProvider)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnOIDCIdentityProvider() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnOIDCIdentityProvider.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnOIDCIdentityProvider.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnOIDCIdentityProvider.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	issuerURL, clientID := ts.cfg.EKSConfig.AddOnOIDCIdentityProvider.IssuerURL, ts.cfg.EKSConfig.AddOnOIDCIdentityProvider.ClientID
+	var idp *cognitoIdentity
+	if ts.cfg.EKSConfig.AddOnOIDCIdentityProvider.IsCognito() {
+		idp, err = ts.createCognito()
+		if err != nil {
+			return err
+		}
+		issuerURL, clientID = idp.issuerURL, idp.clientID
+	}
+
+	if err = ts.associate(issuerURL, clientID); err != nil {
+		return err
+	}
+	if err = ts.createRBAC(); err != nil {
+		return err
+	}
+
+	var idToken string
+	if idp != nil {
+		idToken, err = ts.getCognitoIDToken(idp)
+	} else {
+		idToken, err = readIDToken(ts.cfg.EKSConfig.AddOnOIDCIdentityProvider.IDTokenPath)
+	}
+	if err != nil {
+		return err
+	}
+	if err = ts.verify(idToken); err != nil {
+		return err
+	}
+
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnOIDCIdentityProvider() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnOIDCIdentityProvider.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnOIDCIdentityProvider.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+	if err := ts.deleteRBAC(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.disassociate(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteCognito(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnOIDCIdentityProvider.Created = false
+		ts.cfg.EKSConfig.AddOnOIDCIdentityProvider.Verified = false
+	})
+	return nil
+}
+
+// associate associates the OIDC identity provider with the cluster,
+// and waits for the cluster update (takes 10+ minutes).
+func (ts *tester) associate(issuerURL string, clientID string) error {
+	cfg := ts.cfg.EKSConfig.AddOnOIDCIdentityProvider
+	ts.cfg.Logger.Info("associating identity provider config",
+		zap.String("config-name", cfg.ConfigName),
+		zap.String("issuer-url", issuerURL),
+	)
+	out, err := ts.cfg.EKSAPI.AssociateIdentityProviderConfig(&aws_eks.AssociateIdentityProviderConfigInput{
+		ClusterName: aws.String(ts.cfg.EKSConfig.Name),
+		Oidc: &aws_eks.OidcIdentityProviderConfigRequest{
+			IdentityProviderConfigName: aws.String(cfg.ConfigName),
+			IssuerUrl:                  aws.String(issuerURL),
+			ClientId:                   aws.String(clientID),
+			UsernameClaim:              aws.String(cfg.UsernameClaim),
+			UsernamePrefix:             aws.String(cfg.UsernamePrefix),
+			GroupsClaim:                aws.String(cfg.GroupsClaim),
+			GroupsPrefix:               aws.String(cfg.GroupsPrefix),
+		},
+		Tags: map[string]*string{
+			"Kind":                   aws.String("aws-k8s-tester"),
+			"aws-k8s-tester-version": aws.String(version.ReleaseVersion),
+			eksconfig.RunTagKey:      aws.String(ts.cfg.EKSConfig.Name),
+		},
+	})
+	if err != nil {
+		if isErrCode(err, aws_eks.ErrCodeResourceInUseException) {
+			ts.cfg.Logger.Info("identity provider config already associated", zap.String("config-name", cfg.ConfigName))
+			return nil
+		}
+		return fmt.Errorf("failed to associate identity provider config (%v)", err)
+	}
+	return ts.waitUpdate(out.Update, "associate")
+}
+
+func (ts *tester) disassociate() error {
+	cfg := ts.cfg.EKSConfig.AddOnOIDCIdentityProvider
+	ts.cfg.Logger.Info("disassociating identity provider config", zap.String("config-name", cfg.ConfigName))
+	out, err := ts.cfg.EKSAPI.DisassociateIdentityProviderConfig(&aws_eks.DisassociateIdentityProviderConfigInput{
+		ClusterName: aws.String(ts.cfg.EKSConfig.Name),
+		IdentityProviderConfig: &aws_eks.IdentityProviderConfig{
+			Name: aws.String(cfg.ConfigName),
+			Type: aws.String("oidc"),
+		},
+	})
+	if err != nil {
+		if isErrCode(err, aws_eks.ErrCodeResourceNotFoundException) {
+			ts.cfg.Logger.Info("identity provider config not found; skipping", zap.String("config-name", cfg.ConfigName))
+			return nil
+		}
+		return fmt.Errorf("failed to disassociate identity provider config (%v)", err)
+	}
+	return ts.waitUpdate(out.Update, "disassociate")
+}
+
+func (ts *tester) waitUpdate(update *aws_eks.Update, action string) (err error) {
+	if update == nil {
+		return nil
+	}
+	updateID := aws.StringValue(update.Id)
+	ctx, cancel := context.WithTimeout(context.Background(), 40*time.Minute)
+	updateCh := wait.PollUpdate(
+		ctx,
+		ts.cfg.Stopc,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.EKSAPI,
+		ts.cfg.EKSConfig.Name,
+		updateID,
+		aws_eks.UpdateStatusSuccessful,
+		time.Minute,
+		20*time.Second,
+	)
+	for v := range updateCh {
+		err = v.Error
+	}
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to wait for identity provider config %s update %q (%v)", action, updateID, err)
+	}
+	ts.cfg.Logger.Info("identity provider config updated", zap.String("action", action), zap.String("update-id", updateID))
+	return nil
+}
+
+func isErrCode(err error, code string) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == code
+}
@@ -0,0 +1,221 @@
+package oidcidentityprovider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+	"github.com/aws/aws-sdk-go/aws"
+	"go.uber.org/zap"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/exec"
+)
+
+// createRBAC binds the read-only ClusterRole to the identity group.
+func (ts *tester) createRBAC() error {
+	cfg := ts.cfg.EKSConfig.AddOnOIDCIdentityProvider
+	group := cfg.GroupsPrefix + cfg.Group
+	ts.cfg.Logger.Info("creating OIDC RBAC ClusterRole and ClusterRoleBinding", zap.String("group", group))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		RbacV1().
+		ClusterRoles().
+		Create(
+			ctx,
+			&rbacv1.ClusterRole{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "rbac.authorization.k8s.io/v1",
+					Kind:       "ClusterRole",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: cfg.ConfigName,
+				},
+				Rules: []rbacv1.PolicyRule{
+					{
+						APIGroups: []string{""},
+						Resources: []string{"nodes", "namespaces"},
+						Verbs:     []string{"get", "list", "watch"},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create OIDC RBAC ClusterRole (%v)", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.K8SClient.KubernetesClientSet().
+		RbacV1().
+		ClusterRoleBindings().
+		Create(
+			ctx,
+			&rbacv1.ClusterRoleBinding{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "rbac.authorization.k8s.io/v1",
+					Kind:       "ClusterRoleBinding",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: cfg.ConfigName,
+				},
+				RoleRef: rbacv1.RoleRef{
+					APIGroup: "rbac.authorization.k8s.io",
+					Kind:     "ClusterRole",
+					Name:     cfg.ConfigName,
+				},
+				Subjects: []rbacv1.Subject{
+					{
+						APIGroup: "rbac.authorization.k8s.io",
+						Kind:     "Group",
+						Name:     group,
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create OIDC RBAC ClusterRoleBinding (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created OIDC RBAC ClusterRole and ClusterRoleBinding")
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+func (ts *tester) deleteRBAC() error {
+	name := ts.cfg.EKSConfig.AddOnOIDCIdentityProvider.ConfigName
+	ts.cfg.Logger.Info("deleting OIDC RBAC ClusterRole and ClusterRoleBinding")
+	foreground := metav1.DeletePropagationForeground
+	opts := metav1.DeleteOptions{
+		GracePeriodSeconds: aws.Int64(0),
+		PropagationPolicy:  &foreground,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.K8SClient.KubernetesClientSet().RbacV1().ClusterRoleBindings().Delete(ctx, name, opts)
+	cancel()
+	if err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to delete OIDC RBAC ClusterRoleBinding (%v)", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	err = ts.cfg.K8SClient.KubernetesClientSet().RbacV1().ClusterRoles().Delete(ctx, name, opts)
+	cancel()
+	if err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to delete OIDC RBAC ClusterRole (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("deleted OIDC RBAC ClusterRole and ClusterRoleBinding")
+	return nil
+}
+
+type kubeconfig struct {
+	ClusterAPIServerEndpoint string
+	ClusterCA                string
+	Token                    string
+}
+
+// tmplKUBECONFIG authenticates with the OIDC ID token as the bearer token,
+// instead of the "aws-iam-authenticator" exec plugin.
+const tmplKUBECONFIG = `
+apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: {{ .ClusterAPIServerEndpoint }}
+    certificate-authority-data: {{ .ClusterCA }}
+  name: kubernetes
+contexts:
+- context:
+    cluster: kubernetes
+    user: oidc
+  name: oidc
+current-context: oidc
+preferences: {}
+users:
+- name: oidc
+  user:
+    token: {{ .Token }}
+`
+
+func renderKubeconfig(kc kubeconfig) ([]byte, error) {
+	tpl := template.Must(template.New("tmplKUBECONFIG").Parse(tmplKUBECONFIG))
+	buf := bytes.NewBuffer(nil)
+	if err := tpl.Execute(buf, kc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// verify runs "kubectl" with the ID token, expecting the identity
+// to list the nodes but not to delete them (bound to the read-only role).
+// Retries until the association is effective in the API server.
+func (ts *tester) verify(idToken string) error {
+	b, err := renderKubeconfig(kubeconfig{
+		ClusterAPIServerEndpoint: ts.cfg.EKSConfig.Status.ClusterAPIServerEndpoint,
+		ClusterCA:                ts.cfg.EKSConfig.Status.ClusterCA,
+		Token:                    idToken,
+	})
+	if err != nil {
+		return err
+	}
+	// the kubeconfig has the bearer token, never written to the artifacts
+	kubeconfigPath := fileutil.GetTempFilePath() + ".kubeconfig"
+	if err = ioutil.WriteFile(kubeconfigPath, b, 0600); err != nil {
+		return err
+	}
+	defer os.RemoveAll(kubeconfigPath)
+
+	kubectl := func(args ...string) (string, error) {
+		args = append([]string{ts.cfg.EKSConfig.KubectlPath, "--kubeconfig=" + kubeconfigPath}, args...)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		out, err := exec.New().CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+		cancel()
+		return strings.TrimSpace(string(out)), err
+	}
+
+	ts.cfg.Logger.Info("verifying OIDC identity access")
+	retryStart := time.Now()
+	for time.Since(retryStart) < 5*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return fmt.Errorf("OIDC identity verification aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		out, err := kubectl("auth", "can-i", "list", "nodes")
+		if err != nil || out != "yes" {
+			ts.cfg.Logger.Warn("OIDC identity cannot list nodes yet; retrying", zap.String("output", out), zap.Error(err))
+			continue
+		}
+		out, err = kubectl("get", "nodes")
+		if err != nil {
+			ts.cfg.Logger.Warn("'kubectl get nodes' failed with OIDC identity; retrying", zap.Error(err))
+			continue
+		}
+		fmt.Fprintf(ts.cfg.LogWriter, "\n'kubectl get nodes' as OIDC identity:\n\n%s\n\n", out)
+
+		// "kubectl auth can-i" exits 1 for "no"
+		out, _ = kubectl("auth", "can-i", "delete", "nodes")
+		if out != "no" {
+			return fmt.Errorf("OIDC identity expected read-only access, 'kubectl auth can-i delete nodes' returned %q", out)
+		}
+
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnOIDCIdentityProvider.Verified = true
+		})
+		ts.cfg.Logger.Info("verified OIDC identity access")
+		return nil
+	}
+	return fmt.Errorf("OIDC identity failed to list nodes after %v", time.Since(retryStart))
+}
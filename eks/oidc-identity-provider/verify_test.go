@@ -0,0 +1,45 @@
+package oidcidentityprovider
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestRenderKubeconfig(t *testing.T) {
+	b, err := renderKubeconfig(kubeconfig{
+		ClusterAPIServerEndpoint: "https://example.eks.amazonaws.com",
+		ClusterCA:                "Y2E=",
+		Token:                    "header.payload.signature",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kc struct {
+		Users []struct {
+			User map[string]interface{} `json:"user"`
+		} `json:"users"`
+	}
+	if err = yaml.Unmarshal(b, &kc); err != nil {
+		t.Fatal(err)
+	}
+	if len(kc.Users) != 1 || kc.Users[0].User["token"] != "header.payload.signature" {
+		t.Fatalf("unexpected users %+v", kc.Users)
+	}
+	if _, ok := kc.Users[0].User["exec"]; ok {
+		t.Fatal("unexpected exec plugin with OIDC token")
+	}
+}
+
+func TestCognito(t *testing.T) {
+	if u := cognitoIssuerURL("us-west-2", "us-west-2_abc"); u != "https://cognito-idp.us-west-2.amazonaws.com/us-west-2_abc" {
+		t.Fatalf("unexpected issuer URL %q", u)
+	}
+	p := newPassword()
+	if len(p) < 8 || !strings.ContainsAny(p, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") ||
+		!strings.ContainsAny(p, "abcdefghijklmnopqrstuvwxyz") ||
+		!strings.ContainsAny(p, "0123456789") || !strings.ContainsAny(p, "!") {
+		t.Fatalf("password %q does not satisfy the default policy", p)
+	}
+}
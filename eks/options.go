@@ -0,0 +1,155 @@
+package eks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+)
+
+// Option configures the tester created by "NewTester".
+type Option func(cfg *eksconfig.Config) error
+
+// WithName sets the cluster name, which also names the created resources.
+func WithName(name string) Option {
+	return func(cfg *eksconfig.Config) error {
+		cfg.Name = name
+		return nil
+	}
+}
+
+// WithPartition sets the AWS partition (e.g. "aws", "aws-cn").
+func WithPartition(partition string) Option {
+	return func(cfg *eksconfig.Config) error {
+		cfg.Partition = partition
+		return nil
+	}
+}
+
+// WithRegion sets the AWS region of the cluster.
+func WithRegion(region string) Option {
+	return func(cfg *eksconfig.Config) error {
+		cfg.Region = region
+		return nil
+	}
+}
+
+// WithVersion sets the Kubernetes version of the cluster (e.g. "1.27").
+func WithVersion(version string) Option {
+	return func(cfg *eksconfig.Config) error {
+		cfg.Version = version
+		return nil
+	}
+}
+
+// WithConfigPath sets the path to persist the configuration and the status,
+// to delete the cluster later with "aws-k8s-tester eks delete cluster --path".
+// Defaults to the file named after the cluster in the temporary directory.
+func WithConfigPath(p string) Option {
+	return func(cfg *eksconfig.Config) error {
+		cfg.ConfigPath = p
+		return nil
+	}
+}
+
+// WithLogLevel sets the log level (e.g. "debug", "info").
+func WithLogLevel(level string) Option {
+	return func(cfg *eksconfig.Config) error {
+		cfg.LogLevel = level
+		return nil
+	}
+}
+
+// WithLogOutputs sets the log outputs (e.g. "stderr", or the file paths).
+func WithLogOutputs(outputs ...string) Option {
+	return func(cfg *eksconfig.Config) error {
+		cfg.LogOutputs = outputs
+		return nil
+	}
+}
+
+// WithAddOn sets the add-on configuration, matched by its type to the
+// configuration field. For example,
+//
+//	eks.WithAddOn(&eksconfig.AddOnManagedNodeGroups{Enable: true, ...})
+//	eks.WithAddOn(&eksconfig.MetricsServerSpec{})
+//
+// The add-ons with "Enable" field must set it true to be created.
+func WithAddOn(addOn interface{}) Option {
+	return func(cfg *eksconfig.Config) error {
+		av := reflect.ValueOf(addOn)
+		if av.Kind() != reflect.Ptr || av.IsNil() {
+			return fmt.Errorf("add-on must be a non-nil pointer, got %T", addOn)
+		}
+		for _, sv := range []reflect.Value{
+			reflect.ValueOf(cfg).Elem(),
+			reflect.ValueOf(&cfg.Spec).Elem(),
+		} {
+			for i := 0; i < sv.NumField(); i++ {
+				if sv.Type().Field(i).Type == av.Type() && sv.Field(i).CanSet() {
+					sv.Field(i).Set(av)
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("unknown add-on type %T", addOn)
+	}
+}
+
+// WithConfig modifies the configuration directly, for the fields
+// without the dedicated option.
+func WithConfig(fn func(cfg *eksconfig.Config)) Option {
+	return func(cfg *eksconfig.Config) error {
+		fn(cfg)
+		return nil
+	}
+}
+
+// NewTester creates the tester from the default configuration and the
+// options, without the configuration file or the environment variables,
+// so that the other Go test harnesses can create the clusters
+// programmatically. Cancelling the context cancels the in-flight "Up",
+// the same as the run deadline (see "RunTimeout"), and "Up" collects logs
+// and deletes the resources if "OnFailureDelete" is true. "Up" fails
+// without creating any resource if the context is already cancelled.
+func NewTester(ctx context.Context, opts ...Option) (*Tester, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cfg, err := newConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ts.ctx = ctx
+	if ctx.Done() != nil {
+		go func() {
+			<-ctx.Done()
+			// do not cancel the deletion after "Up"
+			if atomic.LoadInt32(&ts.upRunning) == 1 {
+				ts.exceedDeadline(fmt.Sprintf("context cancelled (%v)", ctx.Err()))
+			}
+		}()
+	}
+	return ts, nil
+}
+
+func newConfig(opts ...Option) (*eksconfig.Config, error) {
+	cfg := eksconfig.NewDefault()
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.ConfigPath == "" {
+		cfg.ConfigPath = filepath.Join(os.TempDir(), cfg.Name+".yaml")
+	}
+	return cfg, nil
+}
@@ -0,0 +1,43 @@
+package eks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+)
+
+func TestNewConfig(t *testing.T) {
+	ms := &eksconfig.AddOnMetricsServer{Enable: true}
+	cfg, err := newConfig(
+		WithName("test-cluster"),
+		WithRegion("us-east-1"),
+		WithVersion("1.26"),
+		WithAddOn(ms),
+		WithAddOn(&eksconfig.MetricsServerSpec{}),
+		WithConfig(func(cfg *eksconfig.Config) { cfg.OnFailureDelete = false }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "test-cluster" || cfg.Region != "us-east-1" || cfg.Version != "1.26" || cfg.OnFailureDelete {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if cfg.ConfigPath != filepath.Join(os.TempDir(), "test-cluster.yaml") {
+		t.Fatalf("unexpected config path %q", cfg.ConfigPath)
+	}
+	if cfg.AddOnMetricsServer != ms {
+		t.Fatalf("unexpected metrics server add-on %+v", cfg.AddOnMetricsServer)
+	}
+	if cfg.Spec.MetricsServer == nil {
+		t.Fatal("expected metrics server spec")
+	}
+
+	if _, err = newConfig(WithAddOn(&eksconfig.Config{})); err == nil {
+		t.Fatal("expected error for unknown add-on type")
+	}
+	if _, err = newConfig(WithAddOn(eksconfig.AddOnManagedNodeGroups{})); err == nil {
+		t.Fatal("expected error for non-pointer add-on")
+	}
+}
@@ -3,6 +3,7 @@ package overprovisioning
 import (
 	"fmt"
 
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
 	"github.com/aws/aws-k8s-tester/eksconfig"
 	k8sclient "github.com/aws/aws-k8s-tester/pkg/k8s-client"
 	gotemplate "github.com/aws/aws-k8s-tester/pkg/util"
@@ -14,6 +15,17 @@ type Overprovisioning struct {
 	Config    *eksconfig.Config
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "overprovisioning",
+		// overprovisioning pods only trigger scale-ups with Cluster Autoscaler
+		Dependencies: []string{"cluster-autoscaler"},
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return &Overprovisioning{Config: cfg.EKSConfig, K8sClient: cfg.K8SClient}
+		},
+	})
+}
+
 // IsEnabled returns true if enabled
 func (c *Overprovisioning) IsEnabled() bool {
 	return c.Config.Spec.Overprovisioning != nil
@@ -28,12 +40,14 @@ func (c *Overprovisioning) Apply() (err error) {
 	if err := c.K8sClient.Apply(template.String()); err != nil {
 		return fmt.Errorf("while applying resources, %v", err)
 	}
-	c.Config.Status.Overprovisioning = &eksconfig.OverprovisioningStatus{
-		AddonStatus: eksconfig.AddonStatus{
-			Installed: true,
-			Ready:     true,
-		},
-	}
+	c.Config.RecordAddOnStatus(func() {
+		c.Config.Status.Overprovisioning = &eksconfig.OverprovisioningStatus{
+			AddonStatus: eksconfig.AddonStatus{
+				Installed: true,
+				Ready:     true,
+			},
+		}
+	})
 	return nil
 }
 
@@ -46,11 +60,13 @@ func (c *Overprovisioning) Delete() (err error) {
 	if err := c.K8sClient.Delete(template.String()); err != nil {
 		return fmt.Errorf("while deleting resources, %v", err)
 	}
-	c.Config.Status.Overprovisioning = &eksconfig.OverprovisioningStatus{
-		AddonStatus: eksconfig.AddonStatus{
-			Installed: false,
-			Ready:     false,
-		},
-	}
+	c.Config.RecordAddOnStatus(func() {
+		c.Config.Status.Overprovisioning = &eksconfig.OverprovisioningStatus{
+			AddonStatus: eksconfig.AddonStatus{
+				Installed: false,
+				Ready:     false,
+			},
+		}
+	})
 	return nil
 }
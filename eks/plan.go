@@ -0,0 +1,41 @@
+package eks
+
+import (
+	app_mesh "github.com/aws/aws-k8s-tester/eks/app-mesh"
+	"github.com/aws/aws-k8s-tester/eks/cluster"
+	"github.com/aws/aws-k8s-tester/eks/fargate"
+	"github.com/aws/aws-k8s-tester/eks/irsa"
+	irsa_fargate "github.com/aws/aws-k8s-tester/eks/irsa-fargate"
+	"github.com/aws/aws-k8s-tester/eks/mng"
+	"github.com/aws/aws-k8s-tester/eks/ng"
+	"github.com/aws/aws-k8s-tester/eks/plan"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"go.uber.org/zap"
+)
+
+// Plan renders the CloudFormation templates, EKS API request payloads,
+// and IAM policy documents of the cluster creation under the directory,
+// without calling AWS APIs, and returns the written file paths.
+// The resource IDs not known until creation (e.g. subnet IDs) are
+// rendered as placeholders, so the configuration must not be synced afterwards.
+func Plan(lg *zap.Logger, cfg *eksconfig.Config, dir string) ([]string, error) {
+	plan.SetPlaceholders(cfg)
+
+	var docs []plan.Document
+	for _, fn := range []func() ([]plan.Document, error){
+		func() ([]plan.Document, error) { return cluster.Plan(lg, cfg) },
+		func() ([]plan.Document, error) { return ng.Plan(cfg) },
+		func() ([]plan.Document, error) { return mng.Plan(lg, cfg) },
+		func() ([]plan.Document, error) { return fargate.Plan(cfg) },
+		func() ([]plan.Document, error) { return irsa.Plan(cfg) },
+		func() ([]plan.Document, error) { return irsa_fargate.Plan(cfg) },
+		func() ([]plan.Document, error) { return app_mesh.Plan(cfg) },
+	} {
+		ds, err := fn()
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, ds...)
+	}
+	return plan.Write(dir, docs)
+}
@@ -0,0 +1,143 @@
+// Package plan renders the AWS API request payloads, CloudFormation templates,
+// and IAM policy documents of the cluster creation to disk, without calling AWS APIs,
+// so that the infrastructure changes can be reviewed (and diffed) before running the tests.
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-sdk-go/private/protocol/json/jsonutil"
+)
+
+// Document is a rendered document.
+type Document struct {
+	// Path is the relative path in the plan directory
+	// (e.g. "cluster/create-cluster.json").
+	Path string
+	// Body is the rendered document.
+	Body []byte
+}
+
+// Placeholder returns the value, or the placeholder with the name
+// if the value is not known until the resource is created (e.g. role ARN).
+func Placeholder(v string, name string) string {
+	if v != "" {
+		return v
+	}
+	return "<" + name + ">"
+}
+
+// NewPayload renders the AWS API request input as the request body,
+// the same as the SDK sends over the wire (e.g. "roleArn", not "RoleArn").
+// The fields sent in the request URI or headers are not included.
+func NewPayload(path string, input interface{}) (Document, error) {
+	setIdempotencyTokens(input)
+	b, err := jsonutil.BuildJSON(input)
+	if err != nil {
+		return Document{}, fmt.Errorf("failed to render %q (%v)", path, err)
+	}
+	return newIndented(path, b)
+}
+
+// NewPolicy renders the IAM policy document in JSON.
+func NewPolicy(path string, doc string) (Document, error) {
+	return newIndented(path, []byte(doc))
+}
+
+// NewJSON renders the value in JSON.
+func NewJSON(path string, v interface{}) (Document, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return Document{}, fmt.Errorf("failed to render %q (%v)", path, err)
+	}
+	return Document{Path: path, Body: append(b, '\n')}, nil
+}
+
+// NewTemplate renders the CloudFormation template.
+func NewTemplate(path string, tmpl string) Document {
+	return Document{Path: path, Body: []byte(tmpl)}
+}
+
+// setIdempotencyTokens sets the placeholders for the empty idempotency tokens
+// (e.g. "clientRequestToken"), which are otherwise randomly generated
+// and make the rendered payloads differ in every run.
+func setIdempotencyTokens(input interface{}) {
+	v := reflect.ValueOf(input)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+	v = v.Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if v.Type().Field(i).Tag.Get("idempotencyToken") != "true" || !f.CanSet() || !f.IsNil() {
+			continue
+		}
+		token := Placeholder("", "idempotency-token")
+		f.Set(reflect.ValueOf(&token))
+	}
+}
+
+func newIndented(path string, b []byte) (Document, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := json.Indent(buf, b, "", "  "); err != nil {
+		return Document{}, fmt.Errorf("failed to render %q (%v)", path, err)
+	}
+	buf.WriteByte('\n')
+	return Document{Path: path, Body: buf.Bytes()}, nil
+}
+
+// SetPlaceholders sets the placeholders for the resource IDs
+// that are not known until the resources are created.
+// The configuration must not be synced to disk afterwards.
+func SetPlaceholders(cfg *eksconfig.Config) {
+	cfg.Role.ARN = Placeholder(cfg.Role.ARN, "cluster-role-arn")
+	if len(cfg.VPC.PublicSubnetIDs) == 0 {
+		for i := range cfg.VPC.PublicSubnetCIDRs {
+			cfg.VPC.PublicSubnetIDs = append(cfg.VPC.PublicSubnetIDs, Placeholder("", fmt.Sprintf("public-subnet-id-%d", i+1)))
+		}
+	}
+	if len(cfg.VPC.PrivateSubnetIDs) == 0 {
+		for i := range cfg.VPC.PrivateSubnetCIDRs {
+			cfg.VPC.PrivateSubnetIDs = append(cfg.VPC.PrivateSubnetIDs, Placeholder("", fmt.Sprintf("private-subnet-id-%d", i+1)))
+		}
+	}
+	cfg.VPC.SecurityGroupID = Placeholder(cfg.VPC.SecurityGroupID, "cluster-security-group-id")
+	if cfg.Encryption.CMKCreate {
+		cfg.Encryption.CMKARN = Placeholder(cfg.Encryption.CMKARN, "cmk-arn")
+	}
+	cfg.Status.ClusterOIDCIssuerHostPath = Placeholder(cfg.Status.ClusterOIDCIssuerHostPath, "oidc-issuer-host-path")
+	cfg.Status.ClusterOIDCIssuerARN = Placeholder(cfg.Status.ClusterOIDCIssuerARN, "oidc-issuer-arn")
+
+	if cfg.IsEnabledAddOnManagedNodeGroups() {
+		cfg.AddOnManagedNodeGroups.Role.ARN = Placeholder(cfg.AddOnManagedNodeGroups.Role.ARN, "managed-node-group-role-arn")
+		for name, cur := range cfg.AddOnManagedNodeGroups.MNGs {
+			if cur.LaunchTemplate != nil && cur.LaunchTemplate.Enable {
+				cur.LaunchTemplate.ID = Placeholder(cur.LaunchTemplate.ID, name+"-launch-template-id")
+				cur.LaunchTemplate.Version = Placeholder(cur.LaunchTemplate.Version, name+"-launch-template-version")
+			}
+			cfg.AddOnManagedNodeGroups.MNGs[name] = cur
+		}
+	}
+}
+
+// Write writes the documents under the directory, and returns the written file paths.
+func Write(dir string, docs []Document) (paths []string, err error) {
+	for _, doc := range docs {
+		p := filepath.Join(dir, doc.Path)
+		if err = os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			return nil, err
+		}
+		if err = ioutil.WriteFile(p, doc.Body, 0600); err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
@@ -0,0 +1,29 @@
+package plan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+)
+
+func TestNewPayload(t *testing.T) {
+	doc, err := NewPayload("cluster/create-cluster.json", &eks.CreateClusterInput{
+		Name:    aws.String("test"),
+		RoleArn: aws.String(Placeholder("", "cluster-role-arn")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := string(doc.Body)
+	for _, s := range []string{
+		`"name": "test"`,
+		`"roleArn": "<cluster-role-arn>"`,
+		`"clientRequestToken": "<idempotency-token>"`,
+	} {
+		if !strings.Contains(body, s) {
+			t.Fatalf("expected %q in %s", s, body)
+		}
+	}
+}
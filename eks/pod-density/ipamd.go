@@ -0,0 +1,73 @@
+package poddensity
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/prometheus/common/expfmt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ipamdMetricsPort is the VPC CNI ipamd metrics port on the "aws-node" Pods.
+	ipamdMetricsPort = "61678"
+	awsNodeSelector  = "k8s-app=aws-node"
+)
+
+// fetchIPAM returns the ipamd metrics of the node, from the "aws-node" Pod
+// on the node via the kube-apiserver Pod proxy.
+func (ts *tester) fetchIPAM(node string) (eksconfig.IPAMMetrics, error) {
+	cli := ts.cfg.K8SClient.KubernetesClientSet()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	pods, err := cli.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{
+		LabelSelector: awsNodeSelector,
+		FieldSelector: "spec.nodeName=" + node,
+	})
+	cancel()
+	if err != nil {
+		return eksconfig.IPAMMetrics{}, fmt.Errorf("failed to list aws-node Pods (%v)", err)
+	}
+	if len(pods.Items) == 0 {
+		return eksconfig.IPAMMetrics{}, fmt.Errorf("no aws-node Pod on node %q", node)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	b, err := cli.CoreV1().
+		Pods("kube-system").
+		ProxyGet("http", pods.Items[0].Name, ipamdMetricsPort, "metrics", nil).
+		DoRaw(ctx)
+	cancel()
+	if err != nil {
+		return eksconfig.IPAMMetrics{}, fmt.Errorf("failed to fetch ipamd /metrics (%v)", err)
+	}
+	return parseIPAMMetrics(b)
+}
+
+func parseIPAMMetrics(b []byte) (m eksconfig.IPAMMetrics, err error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(b))
+	if err != nil {
+		return m, fmt.Errorf("failed to parse ipamd /metrics (%v)", err)
+	}
+	for name, v := range map[string]*int{
+		"awscni_eni_allocated":         &m.ENIsAllocated,
+		"awscni_eni_max":               &m.ENIsMax,
+		"awscni_total_ip_addresses":    &m.IPsTotal,
+		"awscni_assigned_ip_addresses": &m.IPsAssigned,
+		"awscni_ip_max":                &m.IPsMax,
+	} {
+		mf, ok := families[name]
+		if !ok {
+			return m, fmt.Errorf("%q not found in ipamd /metrics", name)
+		}
+		sum := 0.0
+		for _, mt := range mf.GetMetric() {
+			sum += mt.GetGauge().GetValue()
+		}
+		*v = int(sum)
+	}
+	return m, nil
+}
@@ -0,0 +1,38 @@
+package poddensity
+
+import (
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+)
+
+func TestParseIPAMMetrics(t *testing.T) {
+	b := []byte(`# HELP awscni_eni_allocated The number of ENIs allocated
+# TYPE awscni_eni_allocated gauge
+awscni_eni_allocated{fn="init"} 3
+# HELP awscni_eni_max The maximum number of ENIs that can be attached to the instance
+# TYPE awscni_eni_max gauge
+awscni_eni_max 4
+# HELP awscni_total_ip_addresses The total number of IP addresses
+# TYPE awscni_total_ip_addresses gauge
+awscni_total_ip_addresses 42
+# HELP awscni_assigned_ip_addresses The number of IP addresses assigned to pods
+# TYPE awscni_assigned_ip_addresses gauge
+awscni_assigned_ip_addresses 27
+# HELP awscni_ip_max The maximum number of IP addresses that can be allocated to the instance
+# TYPE awscni_ip_max gauge
+awscni_ip_max 56
+`)
+	m, err := parseIPAMMetrics(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := eksconfig.IPAMMetrics{ENIsAllocated: 3, ENIsMax: 4, IPsTotal: 42, IPsAssigned: 27, IPsMax: 56}
+	if m != exp {
+		t.Fatalf("expected %+v, got %+v", exp, m)
+	}
+
+	if _, err = parseIPAMMetrics([]byte("awscni_eni_max 4\n")); err == nil {
+		t.Fatal("expected error for missing metrics")
+	}
+}
@@ -0,0 +1,152 @@
+package poddensity
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/metrics"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// Pod density phases recorded per Pod.
+const (
+	// phaseFill is the initial fill of the nodes.
+	phaseFill = "fill"
+	// phaseChurn is the recreation of the Pods in the churn rounds.
+	phaseChurn = "churn"
+)
+
+// Pod conditions of the Pod sandbox network ready,
+// "PodHasNetwork" renamed to "PodReadyToStartContainers" in 1.29.
+const (
+	podReadyToStartContainers v1.PodConditionType = "PodReadyToStartContainers"
+	podHasNetwork             v1.PodConditionType = "PodHasNetwork"
+)
+
+// timeToIP returns the latency from the Pod scheduling to the Pod sandbox
+// network ready, or to the container start if the condition is not reported.
+func timeToIP(pod *v1.Pod) (time.Duration, bool) {
+	if pod.Status.PodIP == "" {
+		return 0, false
+	}
+	var scheduled, network time.Time
+	for _, cond := range pod.Status.Conditions {
+		if cond.Status != v1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case v1.PodScheduled:
+			scheduled = cond.LastTransitionTime.Time
+		case podReadyToStartContainers, podHasNetwork:
+			network = cond.LastTransitionTime.Time
+		}
+	}
+	if network.IsZero() {
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.State.Running != nil {
+				network = cs.State.Running.StartedAt.Time
+				break
+			}
+		}
+	}
+	if scheduled.IsZero() || network.IsZero() {
+		return 0, false
+	}
+	return network.Sub(scheduled), true
+}
+
+// podWatcher records the time to IP of each pod density Pod once,
+// per instance type of the node.
+type podWatcher struct {
+	lg *zap.Logger
+	// nodeTypes maps the node name to its instance type.
+	nodeTypes map[string]string
+
+	mu    sync.Mutex
+	phase string
+	seen  map[types.UID]struct{}
+	recs  map[string]*metrics.Recorder
+
+	donec chan struct{}
+}
+
+func newPodWatcher(lg *zap.Logger, nodes []densityNode) *podWatcher {
+	pw := &podWatcher{
+		lg:        lg,
+		nodeTypes: make(map[string]string, len(nodes)),
+		seen:      make(map[types.UID]struct{}),
+		recs:      make(map[string]*metrics.Recorder),
+		donec:     make(chan struct{}),
+	}
+	for _, dn := range nodes {
+		pw.nodeTypes[dn.name] = dn.instanceType
+		if _, ok := pw.recs[dn.instanceType]; !ok {
+			pw.recs[dn.instanceType] = metrics.NewRecorder()
+		}
+	}
+	return pw
+}
+
+// setPhase sets the phase to record the following Pods in.
+func (pw *podWatcher) setPhase(phase string) {
+	pw.mu.Lock()
+	pw.phase = phase
+	pw.mu.Unlock()
+}
+
+func (pw *podWatcher) run(ctx context.Context, w watch.Interface) {
+	defer close(pw.donec)
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				pw.lg.Warn("Pod watch closed")
+				return
+			}
+			if ev.Type != watch.Added && ev.Type != watch.Modified {
+				continue
+			}
+			pod, ok := ev.Object.(*v1.Pod)
+			if !ok {
+				continue
+			}
+			pw.observe(pod)
+		}
+	}
+}
+
+func (pw *podWatcher) observe(pod *v1.Pod) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	if _, ok := pw.seen[pod.UID]; ok {
+		return
+	}
+	rec, ok := pw.recs[pw.nodeTypes[pod.Spec.NodeName]]
+	if !ok {
+		return
+	}
+	took, ok := timeToIP(pod)
+	if !ok {
+		return
+	}
+	pw.seen[pod.UID] = struct{}{}
+	rec.Observe(pw.phase, took, nil)
+}
+
+// summaries returns the time to IP summaries of the instance type.
+func (pw *podWatcher) summaries(instanceType string) map[string]metrics.RequestsSummary {
+	pw.mu.Lock()
+	rec, ok := pw.recs[instanceType]
+	pw.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return rec.Summaries(time.Now().UTC().Format(time.RFC3339Nano))
+}
@@ -0,0 +1,111 @@
+package poddensity
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"go.uber.org/zap"
+)
+
+// expectedMaxPodsByType returns the expected kubelet max-pods per instance
+// type with the "CNIConfig", or nil if "CNIConfig" is not set, since the
+// node groups may override the max-pods.
+func (ts *tester) expectedMaxPodsByType(nodes []densityNode) (map[string]int64, error) {
+	if !ts.cfg.EKSConfig.CNIConfig.IsSet() {
+		return nil, nil
+	}
+	var tps []aws_ec2_v2_types.InstanceType
+	seen := make(map[string]struct{})
+	for _, dn := range nodes {
+		if _, ok := seen[dn.instanceType]; ok || dn.instanceType == unknownType {
+			continue
+		}
+		seen[dn.instanceType] = struct{}{}
+		tps = append(tps, aws_ec2_v2_types.InstanceType(dn.instanceType))
+	}
+	if len(tps) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	out, err := ts.cfg.EC2APIV2.DescribeInstanceTypes(ctx, &aws_ec2_v2.DescribeInstanceTypesInput{InstanceTypes: tps})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instance types (%v)", err)
+	}
+	rs := make(map[string]int64)
+	for _, info := range out.InstanceTypes {
+		if info.NetworkInfo == nil || info.VCpuInfo == nil ||
+			info.NetworkInfo.MaximumNetworkInterfaces == nil ||
+			info.NetworkInfo.Ipv4AddressesPerInterface == nil ||
+			info.VCpuInfo.DefaultVCpus == nil {
+			continue
+		}
+		rs[string(info.InstanceType)] = eksconfig.ExpectedMaxPods(
+			int64(*info.NetworkInfo.MaximumNetworkInterfaces),
+			int64(*info.NetworkInfo.Ipv4AddressesPerInterface),
+			int64(*info.VCpuInfo.DefaultVCpus),
+			ts.cfg.EKSConfig.CNIConfig.EnablePrefixDelegation,
+			ts.cfg.EKSConfig.IsEnabledAddOnCustomNetworking(),
+		)
+	}
+	// the self-managed node groups with multiple instance types and
+	// prefix delegation set the minimum max-pods of the instance types
+	if ts.cfg.EKSConfig.CNIConfig.EnablePrefixDelegation && ts.cfg.EKSConfig.IsEnabledAddOnNodeGroups() {
+		for name, cur := range ts.cfg.EKSConfig.AddOnNodeGroups.ASGs {
+			if len(cur.InstanceTypes) < 2 {
+				continue
+			}
+			for _, tp := range cur.InstanceTypes {
+				if _, ok := rs[tp]; ok {
+					ts.cfg.Logger.Info("skipping expected max-pods of mixed instance types node group",
+						zap.String("asg-name", name),
+						zap.String("instance-type", tp),
+					)
+					delete(rs, tp)
+				}
+			}
+		}
+	}
+	ts.cfg.Logger.Info("expected max-pods", zap.Any("max-pods", rs))
+	return rs, nil
+}
+
+// checkIPAM checks the ipamd allocation with the "CNIConfig".
+// Only the lower bounds are checked, since the released IPs are kept
+// in the cooldown after the churn.
+func checkIPAM(cfg *eksconfig.CNIConfig, m eksconfig.IPAMMetrics) error {
+	if !cfg.IsSet() {
+		return nil
+	}
+	if m.IPsAssigned > m.IPsTotal {
+		return fmt.Errorf("ipamd assigned IPs %d > total IPs %d", m.IPsAssigned, m.IPsTotal)
+	}
+	if cfg.EnablePrefixDelegation && m.IPsTotal%16 != 0 {
+		return fmt.Errorf("ipamd total IPs %d not in /28 prefixes with prefix delegation", m.IPsTotal)
+	}
+	free, room := m.IPsTotal-m.IPsAssigned, m.IPsMax-m.IPsAssigned
+	if cfg.MinimumIPTarget > 0 && m.IPsTotal < minInt(cfg.MinimumIPTarget, m.IPsMax) {
+		return fmt.Errorf("ipamd total IPs %d < MINIMUM_IP_TARGET %d", m.IPsTotal, cfg.MinimumIPTarget)
+	}
+	if cfg.WarmIPTarget > 0 && free < minInt(cfg.WarmIPTarget, room) {
+		return fmt.Errorf("ipamd free IPs %d < WARM_IP_TARGET %d", free, cfg.WarmIPTarget)
+	}
+	// WARM_PREFIX_TARGET is ignored if WARM_IP_TARGET or MINIMUM_IP_TARGET is set
+	if cfg.WarmPrefixTarget > 0 && cfg.WarmIPTarget == 0 && cfg.MinimumIPTarget == 0 &&
+		free < minInt(cfg.WarmPrefixTarget*16, room) {
+		return fmt.Errorf("ipamd free IPs %d < WARM_PREFIX_TARGET %d prefix(es)", free, cfg.WarmPrefixTarget)
+	}
+	return nil
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
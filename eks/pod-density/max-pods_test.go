@@ -0,0 +1,30 @@
+package poddensity
+
+import (
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+)
+
+func TestCheckIPAM(t *testing.T) {
+	tt := []struct {
+		cfg *eksconfig.CNIConfig
+		m   eksconfig.IPAMMetrics
+		ok  bool
+	}{
+		{cfg: &eksconfig.CNIConfig{}, m: eksconfig.IPAMMetrics{IPsTotal: 1, IPsAssigned: 5}, ok: true},
+		{cfg: &eksconfig.CNIConfig{EnablePrefixDelegation: true}, m: eksconfig.IPAMMetrics{IPsTotal: 32, IPsAssigned: 20, IPsMax: 432}, ok: true},
+		{cfg: &eksconfig.CNIConfig{EnablePrefixDelegation: true}, m: eksconfig.IPAMMetrics{IPsTotal: 30, IPsAssigned: 20, IPsMax: 432}, ok: false},
+		{cfg: &eksconfig.CNIConfig{EnablePrefixDelegation: true, WarmPrefixTarget: 1}, m: eksconfig.IPAMMetrics{IPsTotal: 32, IPsAssigned: 20, IPsMax: 432}, ok: false},
+		{cfg: &eksconfig.CNIConfig{EnablePrefixDelegation: true, WarmPrefixTarget: 1}, m: eksconfig.IPAMMetrics{IPsTotal: 48, IPsAssigned: 20, IPsMax: 432}, ok: true},
+		{cfg: &eksconfig.CNIConfig{WarmIPTarget: 5}, m: eksconfig.IPAMMetrics{IPsTotal: 22, IPsAssigned: 20, IPsMax: 27}, ok: false},
+		{cfg: &eksconfig.CNIConfig{WarmIPTarget: 5}, m: eksconfig.IPAMMetrics{IPsTotal: 27, IPsAssigned: 25, IPsMax: 27}, ok: true},
+		{cfg: &eksconfig.CNIConfig{MinimumIPTarget: 10}, m: eksconfig.IPAMMetrics{IPsTotal: 8, IPsAssigned: 3, IPsMax: 27}, ok: false},
+		{cfg: &eksconfig.CNIConfig{MinimumIPTarget: 10}, m: eksconfig.IPAMMetrics{IPsTotal: 12, IPsAssigned: 13, IPsMax: 27}, ok: false},
+	}
+	for i, tv := range tt {
+		if err := checkIPAM(tv.cfg, tv.m); (err == nil) != tv.ok {
+			t.Fatalf("#%d: expected ok %v, got %v", i, tv.ok, err)
+		}
+	}
+}
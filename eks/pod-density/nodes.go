@@ -0,0 +1,138 @@
+package poddensity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	instanceTypeLabel = "node.kubernetes.io/instance-type"
+	unknownType       = "unknown"
+)
+
+// densityNode is a node to fill with the pod density Pods.
+type densityNode struct {
+	name         string
+	hostname     string
+	instanceType string
+	// maxPods is the kubelet max-pods of the node.
+	maxPods int64
+	// pods is the number of the pod density Pods to fill the node,
+	// excluding the Pods already running on the node.
+	pods int
+	// deployment is the name of the pod density Deployment of the node.
+	deployment string
+}
+
+// selectNodes returns the Ready nodes to fill, up to "NodesPerInstanceType"
+// per instance type, ordered by the instance type.
+func (ts *tester) selectNodes() ([]densityNode, error) {
+	cur := ts.cfg.EKSConfig.AddOnPodDensity
+	cli := ts.cfg.K8SClient.KubernetesClientSet()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	nodes, err := cli.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: "kubernetes.io/os=linux"})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes (%v)", err)
+	}
+	selected := pickNodes(nodes.Items, cur.NodesPerInstanceType)
+	if len(selected) == 0 {
+		return nil, errors.New("no ready node found")
+	}
+
+	var rs []densityNode
+	for i, node := range selected {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		pods, err := cli.CoreV1().Pods(v1.NamespaceAll).List(ctx, metav1.ListOptions{FieldSelector: "spec.nodeName=" + node.Name})
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Pods on node %q (%v)", node.Name, err)
+		}
+		running := 0
+		for _, pod := range pods.Items {
+			if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+				running++
+			}
+		}
+		dn := densityNode{
+			name:         node.Name,
+			hostname:     node.Labels["kubernetes.io/hostname"],
+			instanceType: instanceType(node),
+			maxPods:      node.Status.Allocatable.Pods().Value(),
+			deployment:   fmt.Sprintf("pod-density-%02d", i),
+		}
+		dn.pods = int(dn.maxPods) - running
+		if dn.hostname == "" {
+			dn.hostname = node.Name
+		}
+		ts.cfg.Logger.Info("selected node",
+			zap.String("node", dn.name),
+			zap.String("instance-type", dn.instanceType),
+			zap.Int64("max-pods", dn.maxPods),
+			zap.Int("running-pods", running),
+			zap.Int("pod-density-pods", dn.pods),
+		)
+		if dn.pods <= 0 {
+			ts.cfg.Logger.Warn("skipping full node", zap.String("node", dn.name))
+			continue
+		}
+		rs = append(rs, dn)
+	}
+	if len(rs) == 0 {
+		return nil, errors.New("no node with room for pod density Pods")
+	}
+	return rs, nil
+}
+
+// pickNodes returns the Ready nodes, up to "perType" per instance type,
+// ordered by the instance type and the node name.
+func pickNodes(nodes []v1.Node, perType int) []v1.Node {
+	sorted := make([]v1.Node, len(nodes))
+	copy(sorted, nodes)
+	sort.Slice(sorted, func(i, j int) bool {
+		ti, tj := instanceType(sorted[i]), instanceType(sorted[j])
+		if ti != tj {
+			return ti < tj
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	counts := make(map[string]int)
+	var rs []v1.Node
+	for _, node := range sorted {
+		if !isNodeReady(node) || node.Spec.Unschedulable {
+			continue
+		}
+		tp := instanceType(node)
+		if counts[tp] >= perType {
+			continue
+		}
+		counts[tp]++
+		rs = append(rs, node)
+	}
+	return rs
+}
+
+func instanceType(node v1.Node) string {
+	if tp := node.Labels[instanceTypeLabel]; tp != "" {
+		return tp
+	}
+	return unknownType
+}
+
+func isNodeReady(node v1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == v1.NodeReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+// Package poddensity schedules Pods up to the kubelet max-pods limit
+// on the nodes of each instance type, verifies the Pod IP assignment and
+// reachability under Pod churn, and records the VPC CNI ipamd metrics.
+package poddensity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	"go.uber.org/zap"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Config defines pod density configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+	EC2APIV2  *aws_ec2_v2.Client
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new pod density tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:         "pod-density",
+		Dependencies: []string{"custom-networking"},
+		Exclusive:    true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				EC2APIV2:  cfg.EC2APIV2,
+			}), cfg.EKSConfig.IsEnabledAddOnPodDensity)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnPodDensity() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnPodDensity.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnPodDensity.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnPodDensity.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	cur := ts.cfg.EKSConfig.AddOnPodDensity
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		cur.Namespace,
+	); err != nil {
+		return err
+	}
+
+	nodes, err := ts.selectNodes()
+	if err != nil {
+		return err
+	}
+	expected, err := ts.expectedMaxPodsByType(nodes)
+	if err != nil {
+		return err
+	}
+
+	pw := newPodWatcher(ts.cfg.Logger, nodes)
+	ctx, cancel := context.WithCancel(context.Background())
+	w, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(cur.Namespace).
+		Watch(ctx, metav1.ListOptions{LabelSelector: podSelector})
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to watch Pods (%v)", err)
+	}
+	go pw.run(ctx, w)
+	defer func() {
+		cancel()
+		<-pw.donec
+	}()
+
+	// the checks run on the Pods available so far, even if the wait fails,
+	// to report the per-instance type results
+	pw.setPhase(phaseFill)
+	waitErr := ts.createDeployments(nodes)
+	if waitErr == nil {
+		waitErr = ts.waitPods(nodes, nil)
+	}
+	for i := 0; waitErr == nil && i < cur.ChurnRounds; i++ {
+		fmt.Fprintf(ts.cfg.LogWriter, "\n[%d/%d] churning pod density Pods\n", i+1, cur.ChurnRounds)
+		pw.setPhase(phaseChurn)
+		var deleted map[types.UID]struct{}
+		deleted, waitErr = ts.deletePods()
+		if waitErr == nil {
+			waitErr = ts.waitPods(nodes, deleted)
+		}
+	}
+
+	results, err := ts.check(nodes, pw, expected)
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.Results = results
+	})
+	if err = ts.writeResults(); err != nil {
+		return err
+	}
+	if waitErr != nil {
+		return waitErr
+	}
+
+	var failed []string
+	for _, rs := range cur.Results {
+		if rs.Error != "" {
+			failed = append(failed, fmt.Sprintf("%q (%s)", rs.InstanceType, rs.Error))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d instance type(s) failed: %s", len(failed), len(cur.Results), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (ts *tester) writeResults() error {
+	cur := ts.cfg.EKSConfig.AddOnPodDensity
+	b, err := json.MarshalIndent(cur.Results, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(cur.ResultsJSONPath, b, 0600); err != nil {
+		return err
+	}
+	for _, rs := range cur.Results {
+		fmt.Fprintf(ts.cfg.LogWriter, "\n%q: %d node(s), max-pods %d (expected %d), %d/%d Pods ready, %d unique IPs, %d reachable %s\n",
+			rs.InstanceType, rs.Nodes, rs.MaxPods, rs.ExpectedMaxPods, rs.PodsReady, rs.Pods, rs.UniqueIPs, rs.PodsReachable, rs.Error)
+		for _, phase := range []string{phaseFill, phaseChurn} {
+			if s, ok := rs.TimeToIP[phase]; ok {
+				fmt.Fprintf(ts.cfg.LogWriter, "\n%q time to IP:\n%s\n", phase, s.Table())
+			}
+		}
+	}
+	ts.cfg.Logger.Info("wrote pod density results", zap.String("path", cur.ResultsJSONPath))
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnPodDensity() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnPodDensity.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnPodDensity.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnPodDensity.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil && !apierrs.IsNotFound(err) && !strings.Contains(err.Error(), "not found") {
+		errs = append(errs, fmt.Sprintf("failed to delete pod density namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnPodDensity.Created = false
+	})
+	return nil
+}
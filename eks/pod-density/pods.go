@@ -0,0 +1,364 @@
+package poddensity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/exec"
+)
+
+const (
+	appName         = "pod-density"
+	deploymentLabel = "pod-density-deployment"
+	// podSelector selects all pod density Pods.
+	podSelector = "app.kubernetes.io/name=" + appName
+	serverPort  = 8080
+)
+
+// createDeployments creates a pod density Deployment per node,
+// pinned to the node, with the replicas to fill the node.
+func (ts *tester) createDeployments(nodes []densityNode) error {
+	cur := ts.cfg.EKSConfig.AddOnPodDensity
+	img := ts.cfg.EKSConfig.Image(eksconfig.DefaultBusyboxImage)
+	// busybox "httpd" ignores SIGTERM, do not slow down the churn
+	gracePeriod := int64(1)
+	for _, dn := range nodes {
+		replicas := int32(dn.pods)
+		labels := map[string]string{
+			"app.kubernetes.io/name": appName,
+			deploymentLabel:          dn.deployment,
+		}
+		ts.cfg.Logger.Info("creating pod density Deployment",
+			zap.String("name", dn.deployment),
+			zap.String("node", dn.name),
+			zap.Int32("replicas", replicas),
+		)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		_, err := ts.cfg.K8SClient.KubernetesClientSet().AppsV1().Deployments(cur.Namespace).Create(ctx, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        dn.deployment,
+				Namespace:   cur.Namespace,
+				Labels:      labels,
+				Annotations: map[string]string{"pod-density-node": dn.name},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: v1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec: v1.PodSpec{
+						RestartPolicy:                 v1.RestartPolicyAlways,
+						TerminationGracePeriodSeconds: &gracePeriod,
+						Containers: []v1.Container{
+							{
+								Name:            appName,
+								Image:           img,
+								ImagePullPolicy: v1.PullIfNotPresent,
+								Command: []string{
+									"/bin/sh",
+									"-c",
+									fmt.Sprintf("mkdir -p /www && echo ok > /www/index.html && httpd -f -p %d -h /www", serverPort),
+								},
+								Ports: []v1.ContainerPort{{ContainerPort: serverPort}},
+								// kubelet probes the Pod IP from the node
+								ReadinessProbe: &v1.Probe{
+									ProbeHandler: v1.ProbeHandler{
+										HTTPGet: &v1.HTTPGetAction{Path: "/", Port: intstr.FromInt(serverPort)},
+									},
+									PeriodSeconds: 5,
+								},
+							},
+						},
+						NodeSelector: map[string]string{
+							"kubernetes.io/hostname": dn.hostname,
+						},
+						// the node is selected regardless of the taints
+						Tolerations: []v1.Toleration{{Operator: v1.TolerationOpExists}},
+					},
+				},
+			},
+		}, metav1.CreateOptions{})
+		cancel()
+		if err != nil && !apierrs.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create Deployment %q (%v)", dn.deployment, err)
+		}
+	}
+	return nil
+}
+
+// listPods returns the pod density Pods, excluding the terminating Pods.
+func (ts *tester) listPods() ([]v1.Pod, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	pods, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(ts.cfg.EKSConfig.AddOnPodDensity.Namespace).
+		List(ctx, metav1.ListOptions{LabelSelector: podSelector})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Pods (%v)", err)
+	}
+	var rs []v1.Pod
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp == nil {
+			rs = append(rs, pod)
+		}
+	}
+	return rs, nil
+}
+
+// waitPods waits for all pod density Pods to be Ready with IPs,
+// excluding the deleted Pods of the previous churn round.
+func (ts *tester) waitPods(nodes []densityNode, deleted map[types.UID]struct{}) error {
+	cur := ts.cfg.EKSConfig.AddOnPodDensity
+	target := 0
+	for _, dn := range nodes {
+		target += dn.pods
+	}
+
+	ready := 0
+	waitStart := time.Now()
+	for time.Since(waitStart) < cur.Timeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("pod density Pods wait aborted")
+		case <-time.After(10 * time.Second):
+		}
+		pods, err := ts.listPods()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list Pods", zap.Error(err))
+			continue
+		}
+		ready = 0
+		for _, pod := range pods {
+			if _, ok := deleted[pod.UID]; ok {
+				continue
+			}
+			if isPodReady(pod) {
+				ready++
+			}
+		}
+		ts.cfg.Logger.Info("polled pod density Pods",
+			zap.Int("ready", ready),
+			zap.Int("target", target),
+			zap.String("elapsed", time.Since(waitStart).String()),
+		)
+		if ready >= target {
+			return nil
+		}
+	}
+	return fmt.Errorf("%d of %d pod density Pods ready in %s", ready, target, cur.TimeoutString)
+}
+
+// deletePods deletes all pod density Pods to be recreated,
+// and returns the UIDs of the deleted Pods.
+func (ts *tester) deletePods() (map[types.UID]struct{}, error) {
+	pods, err := ts.listPods()
+	if err != nil {
+		return nil, err
+	}
+	deleted := make(map[types.UID]struct{}, len(pods))
+	for _, pod := range pods {
+		deleted[pod.UID] = struct{}{}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err = ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(ts.cfg.EKSConfig.AddOnPodDensity.Namespace).
+		DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{LabelSelector: podSelector})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete Pods (%v)", err)
+	}
+	ts.cfg.Logger.Info("deleted pod density Pods", zap.Int("pods", len(deleted)))
+	return deleted, nil
+}
+
+// check checks the Pod IPs and reachability, and returns
+// the per-instance type results with the ipamd metrics.
+func (ts *tester) check(nodes []densityNode, pw *podWatcher, expected map[string]int64) ([]eksconfig.PodDensityResult, error) {
+	pods, err := ts.listPods()
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []string
+	var prober string
+	for _, pod := range pods {
+		if isPodReady(pod) {
+			ips = append(ips, pod.Status.PodIP)
+			if prober == "" || pod.Name < prober {
+				prober = pod.Name
+			}
+		}
+	}
+	sort.Strings(ips)
+	unreachable, probeErr := ts.probe(prober, ips)
+
+	rs := buildResults(nodes, pods, unreachable, expected)
+	idx := make(map[string]int, len(rs))
+	for i := range rs {
+		idx[rs[i].InstanceType] = i
+		rs[i].TimeToIP = pw.summaries(rs[i].InstanceType)
+		rs[i].IPAM = make(map[string]eksconfig.IPAMMetrics)
+		if probeErr != nil && rs[i].Error == "" {
+			rs[i].Error = fmt.Sprintf("reachability check failed (%v)", probeErr)
+		}
+	}
+	for _, dn := range nodes {
+		m, err := ts.waitIPAM(dn.name)
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to fetch ipamd metrics", zap.String("node", dn.name), zap.Error(err))
+			continue
+		}
+		i := idx[dn.instanceType]
+		rs[i].IPAM[dn.name] = m
+		if err = checkIPAM(ts.cfg.EKSConfig.CNIConfig, m); err != nil && rs[i].Error == "" {
+			rs[i].Error = fmt.Sprintf("node %q %v", dn.name, err)
+		}
+	}
+	return rs, nil
+}
+
+// waitIPAM fetches the ipamd metrics of the node, until the allocation
+// settles with the "CNIConfig" after the churn.
+func (ts *tester) waitIPAM(node string) (m eksconfig.IPAMMetrics, err error) {
+	for i := 0; i < 6; i++ {
+		m, err = ts.fetchIPAM(node)
+		if err == nil && checkIPAM(ts.cfg.EKSConfig.CNIConfig, m) == nil {
+			return m, nil
+		}
+		ts.cfg.Logger.Info("waiting for ipamd allocation", zap.String("node", node), zap.Any("ipam", m), zap.Error(err))
+		select {
+		case <-ts.cfg.Stopc:
+			return m, errors.New("ipamd metrics wait aborted")
+		case <-time.After(10 * time.Second):
+		}
+	}
+	return m, err
+}
+
+// probe requests the IPs from the prober Pod,
+// and returns the unreachable IPs.
+func (ts *tester) probe(prober string, ips []string) (map[string]struct{}, error) {
+	unreachable := make(map[string]struct{})
+	if len(ips) == 0 {
+		return unreachable, nil
+	}
+	script := fmt.Sprintf(
+		"for ip in %s; do wget -q -T 2 -O /dev/null http://$ip:%d/ || echo unreachable $ip; done",
+		strings.Join(ips, " "),
+		serverPort,
+	)
+	args := []string{
+		"--kubeconfig=" + ts.cfg.EKSConfig.KubeConfigPath,
+		"--namespace=" + ts.cfg.EKSConfig.AddOnPodDensity.Namespace,
+		"exec",
+		prober,
+		"--",
+		"/bin/sh",
+		"-c",
+		script,
+	}
+
+	ts.cfg.Logger.Info("checking pod density Pods reachability", zap.String("prober", prober), zap.Int("ips", len(ips)))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute+time.Duration(len(ips))*3*time.Second)
+	output, err := exec.New().CommandContext(ctx, ts.cfg.EKSConfig.KubectlPath, args...).CombinedOutput()
+	cancel()
+	out := string(output)
+	if err != nil {
+		return nil, fmt.Errorf("%v (%q)", err, strings.TrimSpace(out))
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if ip := strings.TrimPrefix(strings.TrimSpace(line), "unreachable "); ip != strings.TrimSpace(line) {
+			unreachable[ip] = struct{}{}
+		}
+	}
+	ts.cfg.Logger.Info("checked pod density Pods reachability", zap.Int("unreachable", len(unreachable)))
+	return unreachable, nil
+}
+
+// buildResults returns the per-instance type results from the Pods,
+// in the order of the nodes, with the expected max-pods if any.
+func buildResults(nodes []densityNode, pods []v1.Pod, unreachable map[string]struct{}, expected map[string]int64) []eksconfig.PodDensityResult {
+	ipCounts := make(map[string]int)
+	for _, pod := range pods {
+		if isPodReady(pod) {
+			ipCounts[pod.Status.PodIP]++
+		}
+	}
+
+	nodeTypes := make(map[string]string, len(nodes))
+	idx := make(map[string]int)
+	var rs []eksconfig.PodDensityResult
+	for _, dn := range nodes {
+		nodeTypes[dn.name] = dn.instanceType
+		i, ok := idx[dn.instanceType]
+		if !ok {
+			i = len(rs)
+			idx[dn.instanceType] = i
+			rs = append(rs, eksconfig.PodDensityResult{
+				InstanceType:    dn.instanceType,
+				MaxPods:         dn.maxPods,
+				ExpectedMaxPods: expected[dn.instanceType],
+			})
+		}
+		rs[i].Nodes++
+		rs[i].Pods += dn.pods
+		if dn.maxPods < rs[i].MaxPods {
+			rs[i].MaxPods = dn.maxPods
+		}
+	}
+
+	for _, pod := range pods {
+		tp, ok := nodeTypes[pod.Spec.NodeName]
+		if !ok || !isPodReady(pod) {
+			continue
+		}
+		i := idx[tp]
+		rs[i].PodsReady++
+		if ipCounts[pod.Status.PodIP] == 1 {
+			rs[i].UniqueIPs++
+		}
+		if _, ok := unreachable[pod.Status.PodIP]; !ok {
+			rs[i].PodsReachable++
+		}
+	}
+
+	for i := range rs {
+		switch {
+		case rs[i].ExpectedMaxPods > 0 && rs[i].MaxPods != rs[i].ExpectedMaxPods:
+			rs[i].Error = fmt.Sprintf("max-pods %d, expected %d", rs[i].MaxPods, rs[i].ExpectedMaxPods)
+		case rs[i].PodsReady < rs[i].Pods:
+			rs[i].Error = fmt.Sprintf("%d of %d Pods ready", rs[i].PodsReady, rs[i].Pods)
+		case rs[i].UniqueIPs < rs[i].PodsReady:
+			rs[i].Error = fmt.Sprintf("%d Pod(s) with duplicate IPs", rs[i].PodsReady-rs[i].UniqueIPs)
+		case rs[i].PodsReachable < rs[i].PodsReady:
+			rs[i].Error = fmt.Sprintf("%d Pod(s) unreachable", rs[i].PodsReady-rs[i].PodsReachable)
+		}
+	}
+	return rs
+}
+
+func isPodReady(pod v1.Pod) bool {
+	if pod.Status.PodIP == "" {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == v1.PodReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package poddensity
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newNode(name string, tp string, ready bool) v1.Node {
+	st := v1.ConditionFalse
+	if ready {
+		st = v1.ConditionTrue
+	}
+	return v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{instanceTypeLabel: tp}},
+		Status:     v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: st}}},
+	}
+}
+
+func newPod(node string, ip string, ready bool) v1.Pod {
+	st := v1.ConditionFalse
+	if ready {
+		st = v1.ConditionTrue
+	}
+	return v1.Pod{
+		Spec:   v1.PodSpec{NodeName: node},
+		Status: v1.PodStatus{PodIP: ip, Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: st}}},
+	}
+}
+
+func TestPickNodes(t *testing.T) {
+	nodes := pickNodes([]v1.Node{
+		newNode("d", "m5.large", true),
+		newNode("c", "c5.xlarge", true),
+		newNode("b", "m5.large", true),
+		newNode("a", "m5.large", false),
+		newNode("e", "c5.xlarge", true),
+	}, 1)
+	var names []string
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	if len(names) != 2 || names[0] != "c" || names[1] != "b" {
+		t.Fatalf("unexpected nodes %v", names)
+	}
+}
+
+func TestBuildResults(t *testing.T) {
+	nodes := []densityNode{
+		{name: "a", instanceType: "c5.xlarge", maxPods: 58, pods: 3},
+		{name: "b", instanceType: "m5.large", maxPods: 29, pods: 2},
+		{name: "c", instanceType: "m5.large", maxPods: 20, pods: 2},
+	}
+	pods := []v1.Pod{
+		newPod("a", "10.0.0.1", true),
+		newPod("a", "10.0.0.2", true),
+		newPod("a", "10.0.0.3", true),
+		newPod("b", "10.0.1.1", true),
+		newPod("b", "10.0.1.2", true),
+		newPod("c", "10.0.1.2", true),
+		newPod("c", "", false),
+	}
+	rs := buildResults(nodes, pods, map[string]struct{}{"10.0.0.3": {}}, nil)
+	if len(rs) != 2 {
+		t.Fatalf("unexpected results %+v", rs)
+	}
+
+	if rs[0].InstanceType != "c5.xlarge" || rs[0].Nodes != 1 || rs[0].MaxPods != 58 {
+		t.Fatalf("unexpected result %+v", rs[0])
+	}
+	if rs[0].Pods != 3 || rs[0].PodsReady != 3 || rs[0].UniqueIPs != 3 || rs[0].PodsReachable != 2 {
+		t.Fatalf("unexpected result %+v", rs[0])
+	}
+	if rs[0].Error != "1 Pod(s) unreachable" {
+		t.Fatalf("unexpected error %q", rs[0].Error)
+	}
+
+	if rs[1].InstanceType != "m5.large" || rs[1].Nodes != 2 || rs[1].MaxPods != 20 {
+		t.Fatalf("unexpected result %+v", rs[1])
+	}
+	if rs[1].Pods != 4 || rs[1].PodsReady != 3 || rs[1].UniqueIPs != 1 {
+		t.Fatalf("unexpected result %+v", rs[1])
+	}
+	if rs[1].Error != "3 of 4 Pods ready" {
+		t.Fatalf("unexpected error %q", rs[1].Error)
+	}
+
+	rs = buildResults(nodes, pods, nil, map[string]int64{"c5.xlarge": 110})
+	if rs[0].ExpectedMaxPods != 110 || rs[0].Error != "max-pods 58, expected 110" {
+		t.Fatalf("unexpected result %+v", rs[0])
+	}
+	if rs[1].ExpectedMaxPods != 0 || rs[1].Error != "3 of 4 Pods ready" {
+		t.Fatalf("unexpected result %+v", rs[1])
+	}
+}
+
+func TestTimeToIP(t *testing.T) {
+	now := time.Now()
+	pod := &v1.Pod{
+		Status: v1.PodStatus{
+			PodIP: "10.0.0.1",
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodScheduled, Status: v1.ConditionTrue, LastTransitionTime: metav1.NewTime(now)},
+				{Type: podReadyToStartContainers, Status: v1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(3 * time.Second))},
+			},
+			ContainerStatuses: []v1.ContainerStatus{
+				{State: v1.ContainerState{Running: &v1.ContainerStateRunning{StartedAt: metav1.NewTime(now.Add(5 * time.Second))}}},
+			},
+		},
+	}
+	if took, ok := timeToIP(pod); !ok || took != 3*time.Second {
+		t.Fatalf("unexpected time to IP %v, %v", took, ok)
+	}
+
+	// fall back to the container start
+	pod.Status.Conditions = pod.Status.Conditions[:1]
+	if took, ok := timeToIP(pod); !ok || took != 5*time.Second {
+		t.Fatalf("unexpected time to IP %v, %v", took, ok)
+	}
+
+	pod.Status.PodIP = ""
+	if _, ok := timeToIP(pod); ok {
+		t.Fatal("expected no time to IP without Pod IP")
+	}
+}
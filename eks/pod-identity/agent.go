@@ -0,0 +1,157 @@
+package podidentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/version"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	aws_eks "github.com/aws/aws-sdk-go/service/eks"
+	"go.uber.org/zap"
+)
+
+// createAgent installs the agent add-on, unless already installed
+// (e.g. by the managed add-ons tester), and waits for it to be active.
+func (ts *tester) createAgent() error {
+	cur := ts.cfg.EKSConfig.AddOnPodIdentity
+	ts.cfg.Logger.Info("creating Pod Identity Agent add-on", zap.String("add-on-version", cur.AgentVersion))
+	input := &aws_eks.CreateAddonInput{
+		ClusterName: aws.String(ts.cfg.EKSConfig.Name),
+		AddonName:   aws.String(agentAddOnName),
+		Tags: map[string]*string{
+			"Kind":                   aws.String("aws-k8s-tester"),
+			"aws-k8s-tester-version": aws.String(version.ReleaseVersion),
+			eksconfig.RunTagKey:      aws.String(ts.cfg.EKSConfig.Name),
+		},
+	}
+	if cur.AgentVersion != "" {
+		input.AddonVersion = aws.String(cur.AgentVersion)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.EKSAPI.CreateAddonWithContext(ctx, input)
+	cancel()
+	switch {
+	case err == nil:
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.AgentCreated = true
+		})
+	case isErrCode(err, aws_eks.ErrCodeResourceInUseException):
+		ts.cfg.Logger.Info("Pod Identity Agent add-on already exists; not deleting with the tester")
+	default:
+		return fmt.Errorf("failed to create add-on %q (%v)", agentAddOnName, err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 15*time.Minute)
+	defer cancel()
+	for {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("add-on wait aborted")
+		case <-ctx.Done():
+			return fmt.Errorf("add-on %q not active (%v)", agentAddOnName, ctx.Err())
+		case <-time.After(15 * time.Second):
+		}
+
+		out, err := ts.cfg.EKSAPI.DescribeAddon(&aws_eks.DescribeAddonInput{
+			ClusterName: aws.String(ts.cfg.EKSConfig.Name),
+			AddonName:   aws.String(agentAddOnName),
+		})
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to describe add-on", zap.Error(err))
+			continue
+		}
+		status := aws.StringValue(out.Addon.Status)
+		ts.cfg.Logger.Info("polled add-on",
+			zap.String("add-on", agentAddOnName),
+			zap.String("add-on-version", aws.StringValue(out.Addon.AddonVersion)),
+			zap.String("status", status),
+		)
+		switch status {
+		case aws_eks.AddonStatusActive:
+			ts.cfg.Logger.Info("created Pod Identity Agent add-on")
+			return nil
+		case aws_eks.AddonStatusCreateFailed, aws_eks.AddonStatusDegraded:
+			return fmt.Errorf("add-on %q status %q", agentAddOnName, status)
+		}
+	}
+}
+
+func (ts *tester) deleteAgent() error {
+	if !ts.cfg.EKSConfig.AddOnPodIdentity.AgentCreated {
+		ts.cfg.Logger.Info("Pod Identity Agent add-on not created by the tester; skipping deletion")
+		return nil
+	}
+	ts.cfg.Logger.Info("deleting Pod Identity Agent add-on")
+	_, err := ts.cfg.EKSAPI.DeleteAddon(&aws_eks.DeleteAddonInput{
+		ClusterName: aws.String(ts.cfg.EKSConfig.Name),
+		AddonName:   aws.String(agentAddOnName),
+	})
+	if err != nil && !isErrCode(err, aws_eks.ErrCodeResourceNotFoundException) {
+		return fmt.Errorf("failed to delete add-on %q (%v)", agentAddOnName, err)
+	}
+	ts.cfg.Logger.Info("deleted Pod Identity Agent add-on")
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnPodIdentity.AgentCreated = false
+	})
+	return nil
+}
+
+// createAssociation associates the role with the service account.
+// The Pods created afterwards get the agent credentials injected.
+func (ts *tester) createAssociation() error {
+	cur := ts.cfg.EKSConfig.AddOnPodIdentity
+	if cur.AssociationID != "" {
+		ts.cfg.Logger.Info("Pod Identity association already created", zap.String("association-id", cur.AssociationID))
+		return nil
+	}
+	ts.cfg.Logger.Info("creating Pod Identity association",
+		zap.String("namespace", cur.Namespace),
+		zap.String("service-account", cur.ServiceAccountName),
+		zap.String("role-arn", cur.RoleARN),
+	)
+	id, err := createPodIdentityAssociation(ts.cfg.EKSAPI, &createPodIdentityAssociationInput{
+		ClusterName:    aws.String(ts.cfg.EKSConfig.Name),
+		Namespace:      aws.String(cur.Namespace),
+		ServiceAccount: aws.String(cur.ServiceAccountName),
+		RoleArn:        aws.String(cur.RoleARN),
+		Tags: map[string]*string{
+			"Kind":              aws.String("aws-k8s-tester"),
+			eksconfig.RunTagKey: aws.String(ts.cfg.EKSConfig.Name),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create Pod Identity association (%v)", err)
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.AssociationID = id
+	})
+	ts.cfg.Logger.Info("created Pod Identity association", zap.String("association-id", id))
+	return nil
+}
+
+func (ts *tester) deleteAssociation() error {
+	cur := ts.cfg.EKSConfig.AddOnPodIdentity
+	if cur.AssociationID == "" {
+		ts.cfg.Logger.Info("empty Pod Identity association ID; skipping deletion")
+		return nil
+	}
+	ts.cfg.Logger.Info("deleting Pod Identity association", zap.String("association-id", cur.AssociationID))
+	err := deletePodIdentityAssociation(ts.cfg.EKSAPI, ts.cfg.EKSConfig.Name, cur.AssociationID)
+	if err != nil && !isErrCode(err, aws_eks.ErrCodeResourceNotFoundException) {
+		return fmt.Errorf("failed to delete Pod Identity association %q (%v)", cur.AssociationID, err)
+	}
+	ts.cfg.Logger.Info("deleted Pod Identity association", zap.String("association-id", cur.AssociationID))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.AssociationID = ""
+	})
+	return nil
+}
+
+func isErrCode(err error, code string) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == code
+}
@@ -0,0 +1,69 @@
+package podidentity
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	aws_eks "github.com/aws/aws-sdk-go/service/eks"
+)
+
+// The pinned "aws-sdk-go" predates the EKS Pod Identity API, so the
+// operations are sent with the EKS client (same endpoint, signer, retries,
+// and throttling), using the REST-JSON shapes from the EKS API reference.
+// ref. https://docs.aws.amazon.com/eks/latest/APIReference/API_CreatePodIdentityAssociation.html
+
+type createPodIdentityAssociationInput struct {
+	_              struct{}           `type:"structure"`
+	ClusterName    *string            `location:"uri" locationName:"name" type:"string" required:"true"`
+	Namespace      *string            `locationName:"namespace" type:"string" required:"true"`
+	ServiceAccount *string            `locationName:"serviceAccount" type:"string" required:"true"`
+	RoleArn        *string            `locationName:"roleArn" type:"string" required:"true"`
+	Tags           map[string]*string `locationName:"tags" type:"map"`
+}
+
+type createPodIdentityAssociationOutput struct {
+	_           struct{} `type:"structure"`
+	Association *struct {
+		_             struct{} `type:"structure"`
+		AssociationID *string  `locationName:"associationId" type:"string"`
+	} `locationName:"association" type:"structure"`
+}
+
+type deletePodIdentityAssociationInput struct {
+	_             struct{} `type:"structure"`
+	ClusterName   *string  `location:"uri" locationName:"name" type:"string" required:"true"`
+	AssociationID *string  `location:"uri" locationName:"associationId" type:"string" required:"true"`
+}
+
+type deletePodIdentityAssociationOutput struct {
+	_ struct{} `type:"structure"`
+}
+
+// createPodIdentityAssociation associates the IAM role with the
+// service account, and returns the association ID.
+func createPodIdentityAssociation(api *aws_eks.EKS, input *createPodIdentityAssociationInput) (string, error) {
+	out := &createPodIdentityAssociationOutput{}
+	req := api.NewRequest(&request.Operation{
+		Name:       "CreatePodIdentityAssociation",
+		HTTPMethod: "POST",
+		HTTPPath:   "/clusters/{name}/pod-identity-associations",
+	}, input, out)
+	if err := req.Send(); err != nil {
+		return "", err
+	}
+	if out.Association == nil {
+		return "", nil
+	}
+	return aws.StringValue(out.Association.AssociationID), nil
+}
+
+func deletePodIdentityAssociation(api *aws_eks.EKS, clusterName string, associationID string) error {
+	req := api.NewRequest(&request.Operation{
+		Name:       "DeletePodIdentityAssociation",
+		HTTPMethod: "DELETE",
+		HTTPPath:   "/clusters/{name}/pod-identity-associations/{associationId}",
+	}, &deletePodIdentityAssociationInput{
+		ClusterName:   aws.String(clusterName),
+		AssociationID: aws.String(associationID),
+	}, &deletePodIdentityAssociationOutput{})
+	return req.Send()
+}
@@ -0,0 +1,170 @@
+// Package podidentity installs the EKS Pod Identity Agent, associates an IAM
+// role with a service account, and verifies that a Pod gets the role
+// credentials from the agent, without the IRSA service account annotation.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/pod-identities.html
+// ref. https://github.com/aws/eks-pod-identity-agent
+package podidentity
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	aws_iam_v2 "github.com/aws/aws-sdk-go-v2/service/iam"
+	aws_eks "github.com/aws/aws-sdk-go/service/eks"
+	"go.uber.org/zap"
+)
+
+// Config defines EKS Pod Identity configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+
+	// EKSAPI is the EKS client, also used to send the
+	// Pod Identity association operations not in the SDK.
+	EKSAPI   *aws_eks.EKS
+	IAMAPIV2 *aws_iam_v2.Client
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new EKS Pod Identity tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "pod-identity",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				EKSAPI:    cfg.EKSClient,
+				IAMAPIV2:  cfg.IAMAPIV2,
+			}), cfg.EKSConfig.IsEnabledAddOnPodIdentity)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+// agentAddOnName is the EKS managed add-on name of the Pod Identity Agent.
+const agentAddOnName = "eks-pod-identity-agent"
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnPodIdentity() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnPodIdentity.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnPodIdentity.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnPodIdentity.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	if err = ts.createAgent(); err != nil {
+		return err
+	}
+	if err = ts.createRole(); err != nil {
+		return err
+	}
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnPodIdentity.Namespace,
+	); err != nil {
+		return err
+	}
+	if err = ts.createServiceAccount(); err != nil {
+		return err
+	}
+	if err = ts.createAssociation(); err != nil {
+		return err
+	}
+	if err = ts.verify(); err != nil {
+		return err
+	}
+
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnPodIdentity() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnPodIdentity.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnPodIdentity.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+	if err := ts.deleteAssociation(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnPodIdentity.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Pod Identity namespace (%v)", err))
+	}
+	if err := ts.deleteRole(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := ts.deleteAgent(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnPodIdentity.Created = false
+	})
+	return nil
+}
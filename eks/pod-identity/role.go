@@ -0,0 +1,101 @@
+package podidentity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	aws_iam "github.com/aws/aws-k8s-tester/pkg/aws/iam"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_iam_v2 "github.com/aws/aws-sdk-go-v2/service/iam"
+	smithy "github.com/aws/smithy-go"
+	"go.uber.org/zap"
+)
+
+// podsServicePrincipal is the service principal of EKS Pod Identity,
+// assuming the role on behalf of the Pods.
+const podsServicePrincipal = "pods.eks.amazonaws.com"
+
+func isNotFound(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return strings.Contains(apiErr.ErrorCode(), "NotFound") || apiErr.ErrorCode() == "NoSuchEntity"
+	}
+	return false
+}
+
+// trustPolicyDocument returns the role trust policy for EKS Pod Identity,
+// which also tags the session with the Pod attributes (no OIDC provider).
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/pod-id-role.html
+func trustPolicyDocument() aws_iam.PolicyDocument {
+	return aws_iam.PolicyDocument{
+		Version: "2012-10-17",
+		Statement: []aws_iam.StatementEntry{
+			{
+				Effect:    "Allow",
+				Principal: &aws_iam.PrincipalEntry{Service: []string{podsServicePrincipal}},
+				Action:    []string{"sts:AssumeRole", "sts:TagSession"},
+			},
+		},
+	}
+}
+
+func (ts *tester) createRole() error {
+	cur := ts.cfg.EKSConfig.AddOnPodIdentity
+	if cur.RoleARN != "" {
+		ts.cfg.Logger.Info("Pod Identity role already created; no need to create a new one")
+		return nil
+	}
+
+	ts.cfg.Logger.Info("creating Pod Identity role", zap.String("name", cur.RoleName))
+	out, err := ts.cfg.IAMAPIV2.CreateRole(
+		context.Background(),
+		&aws_iam_v2.CreateRoleInput{
+			RoleName:                 aws_v2.String(cur.RoleName),
+			Path:                     aws_v2.String("/"),
+			AssumeRolePolicyDocument: aws_v2.String(toJSON(trustPolicyDocument())),
+		},
+	)
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.RoleARN = aws_v2.ToString(out.Role.Arn)
+	})
+
+	ts.cfg.Logger.Info("created Pod Identity role", zap.String("role-arn", cur.RoleARN))
+	return nil
+}
+
+func (ts *tester) deleteRole() error {
+	cur := ts.cfg.EKSConfig.AddOnPodIdentity
+	if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[cur.RoleName]; ok {
+		return nil
+	}
+	ts.cfg.Logger.Info("deleting Pod Identity role", zap.String("name", cur.RoleName))
+	_, err := ts.cfg.IAMAPIV2.DeleteRole(
+		context.Background(),
+		&aws_iam_v2.DeleteRoleInput{
+			RoleName: aws_v2.String(cur.RoleName),
+		},
+	)
+	if err != nil && !isNotFound(err) {
+		ts.cfg.Logger.Warn("failed to delete Pod Identity role", zap.Error(err))
+		return err
+	}
+
+	ts.cfg.Logger.Info("deleted Pod Identity role")
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.Status.DeletedResources[cur.RoleName] = "AddOnPodIdentity.RoleName"
+	})
+	return nil
+}
+
+func toJSON(p aws_iam.PolicyDocument) string {
+	b, err := json.Marshal(p)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,180 @@
+package podidentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const verifyPodName = "pod-identity-verify"
+
+// createServiceAccount creates the service account without the
+// "eks.amazonaws.com/role-arn" annotation (as opposed to IRSA).
+func (ts *tester) createServiceAccount() error {
+	cur := ts.cfg.EKSConfig.AddOnPodIdentity
+	ts.cfg.Logger.Info("creating ServiceAccount", zap.String("name", cur.ServiceAccountName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		ServiceAccounts(cur.Namespace).
+		Create(
+			ctx,
+			&v1.ServiceAccount{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "ServiceAccount",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      cur.ServiceAccountName,
+					Namespace: cur.Namespace,
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create ServiceAccount (%v)", err)
+	}
+	ts.cfg.Logger.Info("created ServiceAccount")
+	return nil
+}
+
+// verifyScript fails if the IRSA web identity is injected,
+// and prints the agent credentials URI and the caller identity.
+const verifyScript = `set -eo pipefail
+if [ -n "${AWS_ROLE_ARN}" ] || [ -n "${AWS_WEB_IDENTITY_TOKEN_FILE}" ]; then
+  echo "unexpected IRSA web identity AWS_ROLE_ARN=${AWS_ROLE_ARN}"
+  exit 1
+fi
+echo "CREDENTIALS_URI: ${AWS_CONTAINER_CREDENTIALS_FULL_URI}"
+echo "CALLER_ARN: $(aws sts get-caller-identity --query Arn --output text)"
+`
+
+// verify runs a Pod with the associated service account, and checks that
+// the caller identity is the assumed role from the agent credentials.
+func (ts *tester) verify() error {
+	cur := ts.cfg.EKSConfig.AddOnPodIdentity
+	ts.cfg.Logger.Info("creating Pod Identity verification Pod", zap.String("image", cur.PodImage))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(cur.Namespace).
+		Create(
+			ctx,
+			&v1.Pod{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Pod",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      verifyPodName,
+					Namespace: cur.Namespace,
+				},
+				Spec: v1.PodSpec{
+					ServiceAccountName: cur.ServiceAccountName,
+					RestartPolicy:      v1.RestartPolicyNever,
+					Containers: []v1.Container{
+						{
+							Name:            verifyPodName,
+							Image:           cur.PodImage,
+							ImagePullPolicy: v1.PullIfNotPresent,
+							Command:         []string{"/bin/bash", "-c", verifyScript},
+							Env: []v1.EnvVar{
+								{
+									Name:  "AWS_DEFAULT_REGION",
+									Value: ts.cfg.EKSConfig.Region,
+								},
+							},
+						},
+					},
+					NodeSelector: map[string]string{
+						"kubernetes.io/os": "linux",
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create verification Pod (%v)", err)
+	}
+
+	verifyStart := time.Now()
+	for time.Since(verifyStart) < 10*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("Pod Identity verification aborted")
+		case <-time.After(10 * time.Second):
+		}
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		pod, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Pods(cur.Namespace).
+			Get(ctx, verifyPodName, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get verification Pod", zap.Error(err))
+			continue
+		}
+		ts.cfg.Logger.Info("polled verification Pod", zap.String("phase", string(pod.Status.Phase)))
+		if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+			continue
+		}
+
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		b, err := ts.cfg.K8SClient.KubernetesClientSet().
+			CoreV1().
+			Pods(cur.Namespace).
+			GetLogs(verifyPodName, &v1.PodLogOptions{}).
+			DoRaw(ctx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to get verification Pod logs (%v)", err)
+		}
+		out := string(b)
+		fmt.Fprintf(ts.cfg.LogWriter, "\nPod Identity verification Pod output:\n%s\n", out)
+		if pod.Status.Phase == v1.PodFailed {
+			return fmt.Errorf("verification Pod failed (output %q)", out)
+		}
+
+		callerARN, err := checkOutput(out, cur.RoleName)
+		if err != nil {
+			return err
+		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.CallerARN = callerARN
+		})
+		ts.cfg.Logger.Info("verified Pod Identity credentials", zap.String("caller-arn", callerARN))
+		return nil
+	}
+	return errors.New("verification Pod not completed")
+}
+
+// checkOutput parses the verification Pod output, and returns the caller ARN
+// if the credentials are from the agent and the caller is the assumed role.
+func checkOutput(out string, roleName string) (string, error) {
+	var uri, callerARN string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "CREDENTIALS_URI: "):
+			uri = strings.TrimPrefix(line, "CREDENTIALS_URI: ")
+		case strings.HasPrefix(line, "CALLER_ARN: "):
+			callerARN = strings.TrimPrefix(line, "CALLER_ARN: ")
+		}
+	}
+	if uri == "" {
+		return "", errors.New("no AWS_CONTAINER_CREDENTIALS_FULL_URI injected (Pod Identity webhook not effective)")
+	}
+	if !strings.Contains(callerARN, ":assumed-role/"+roleName+"/") {
+		return "", fmt.Errorf("unexpected caller ARN %q (expected assumed role %q)", callerARN, roleName)
+	}
+	return callerARN, nil
+}
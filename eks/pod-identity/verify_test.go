@@ -0,0 +1,60 @@
+package podidentity
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckOutput(t *testing.T) {
+	tt := []struct {
+		out       string
+		callerARN string
+		err       string
+	}{
+		{
+			out: `CREDENTIALS_URI: http://169.254.170.23/v1/credentials
+CALLER_ARN: arn:aws:sts::123456789012:assumed-role/my-role/eks-my-cluster-pod-identi-1a2b3c
+`,
+			callerARN: "arn:aws:sts::123456789012:assumed-role/my-role/eks-my-cluster-pod-identi-1a2b3c",
+		},
+		{
+			out: `CREDENTIALS_URI:
+CALLER_ARN: arn:aws:sts::123456789012:assumed-role/my-node-role/i-0123456789abcdef0
+`,
+			err: "AWS_CONTAINER_CREDENTIALS_FULL_URI",
+		},
+		{
+			out: `CREDENTIALS_URI: http://169.254.170.23/v1/credentials
+CALLER_ARN: arn:aws:sts::123456789012:assumed-role/my-node-role/i-0123456789abcdef0
+`,
+			err: "unexpected caller ARN",
+		},
+	}
+	for i, tv := range tt {
+		callerARN, err := checkOutput(tv.out, "my-role")
+		if tv.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tv.err) {
+				t.Fatalf("#%d: expected error %q, got %v", i, tv.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("#%d: unexpected error %v", i, err)
+		}
+		if callerARN != tv.callerARN {
+			t.Fatalf("#%d: expected %q, got %q", i, tv.callerARN, callerARN)
+		}
+	}
+}
+
+func TestTrustPolicyDocument(t *testing.T) {
+	s := toJSON(trustPolicyDocument())
+	for _, v := range []string{`"Service":["pods.eks.amazonaws.com"]`, `"sts:AssumeRole"`, `"sts:TagSession"`} {
+		if !strings.Contains(s, v) {
+			t.Fatalf("expected %s in %s", v, s)
+		}
+	}
+	if strings.Contains(s, "Federated") {
+		t.Fatalf("unexpected federated principal in %s", s)
+	}
+}
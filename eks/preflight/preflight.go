@@ -0,0 +1,477 @@
+// Package preflight checks the AWS account and region for the problems
+// that would fail the cluster creation midway (e.g. exceeded quotas,
+// unavailable instance types, missing AMIs), before any resource is created.
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+	"github.com/aws/aws-sdk-go/service/servicequotas/servicequotasiface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	smithy "github.com/aws/smithy-go"
+	"go.uber.org/zap"
+)
+
+// Config defines preflight check configuration.
+type Config struct {
+	Logger    *zap.Logger
+	EKSConfig *eksconfig.Config
+
+	EC2APIV2  EC2APIV2
+	SSMAPI    ssmiface.SSMAPI
+	QuotasAPI servicequotasiface.ServiceQuotasAPI
+}
+
+// EC2APIV2 is the subset of the v2 EC2 client used by the checks.
+type EC2APIV2 interface {
+	DescribeVpcs(ctx context.Context, params *aws_ec2_v2.DescribeVpcsInput, optFns ...func(*aws_ec2_v2.Options)) (*aws_ec2_v2.DescribeVpcsOutput, error)
+	DescribeAddresses(ctx context.Context, params *aws_ec2_v2.DescribeAddressesInput, optFns ...func(*aws_ec2_v2.Options)) (*aws_ec2_v2.DescribeAddressesOutput, error)
+	DescribeInstanceTypeOfferings(ctx context.Context, params *aws_ec2_v2.DescribeInstanceTypeOfferingsInput, optFns ...func(*aws_ec2_v2.Options)) (*aws_ec2_v2.DescribeInstanceTypeOfferingsOutput, error)
+	DescribeImages(ctx context.Context, params *aws_ec2_v2.DescribeImagesInput, optFns ...func(*aws_ec2_v2.Options)) (*aws_ec2_v2.DescribeImagesOutput, error)
+	DescribeSubnets(ctx context.Context, params *aws_ec2_v2.DescribeSubnetsInput, optFns ...func(*aws_ec2_v2.Options)) (*aws_ec2_v2.DescribeSubnetsOutput, error)
+	DescribeAvailabilityZones(ctx context.Context, params *aws_ec2_v2.DescribeAvailabilityZonesInput, optFns ...func(*aws_ec2_v2.Options)) (*aws_ec2_v2.DescribeAvailabilityZonesOutput, error)
+}
+
+const (
+	// SeverityError is the problem that fails the creation.
+	SeverityError = "error"
+	// SeverityWarning is the risk that may fail the creation,
+	// or the check that could not be run (e.g. missing permissions).
+	SeverityWarning = "warning"
+)
+
+// Problem is the problem found by the check, with the fix.
+type Problem struct {
+	// Severity is either "error" or "warning".
+	Severity string `json:"severity"`
+	// Check is the name of the check (e.g. "config", "quota", "instance-type", "ami").
+	Check string `json:"check"`
+	// Field is the configuration field with the problem.
+	Field string `json:"field"`
+	// Message describes the problem.
+	Message string `json:"message"`
+	// Fix describes how to fix the problem.
+	Fix string `json:"fix"`
+}
+
+// HasError returns true if any problem fails the creation.
+func HasError(ps []Problem) bool {
+	for _, p := range ps {
+		if p.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// quota codes of the resources created per cluster
+// ref. https://docs.aws.amazon.com/servicequotas/latest/userguide/intro.html
+const (
+	quotaServiceVPC = "vpc"
+	quotaCodeVPCs   = "L-F678F1CE" // VPCs per Region
+	quotaServiceEC2 = "ec2"
+	quotaCodeEIPs   = "L-0263D0A3" // EC2-VPC Elastic IPs
+)
+
+// Check runs all checks, and returns the problems found.
+// The configuration must be validated with "ValidateAndSetDefaults".
+func Check(cfg Config) (ps []Problem) {
+	ps = append(ps, checkVPCQuota(cfg)...)
+	ps = append(ps, checkEIPQuota(cfg)...)
+	ps = append(ps, checkInstanceTypes(cfg)...)
+	ps = append(ps, checkAMIs(cfg)...)
+	ps = append(ps, checkPlacements(cfg)...)
+	return ps
+}
+
+func checkVPCQuota(cfg Config) []Problem {
+	if !cfg.EKSConfig.VPC.Create {
+		return nil
+	}
+	var used int
+	p := aws_ec2_v2.NewDescribeVpcsPaginator(cfg.EC2APIV2, &aws_ec2_v2.DescribeVpcsInput{})
+	for p.HasMorePages() {
+		out, err := p.NextPage(context.Background())
+		if err != nil {
+			return []Problem{warning("quota", "VPC.Create", fmt.Sprintf("failed to describe VPCs (%v)", err), "grant 'ec2:DescribeVpcs'")}
+		}
+		used += len(out.Vpcs)
+	}
+	return checkQuota(cfg, quotaServiceVPC, quotaCodeVPCs, "VPCs", "VPC.Create", used, 1,
+		"delete unused VPCs, set VPC.Create false with an existing VPC.ID, or request a quota increase")
+}
+
+func checkEIPQuota(cfg Config) []Problem {
+	vpc := cfg.EKSConfig.VPC
+	if !vpc.Create || vpc.DisableNATGateways {
+		return nil
+	}
+	out, err := cfg.EC2APIV2.DescribeAddresses(
+		context.Background(),
+		&aws_ec2_v2.DescribeAddressesInput{
+			Filters: []aws_ec2_v2_types.Filter{{Name: aws_v2.String("domain"), Values: []string{"vpc"}}},
+		},
+	)
+	if err != nil {
+		return []Problem{warning("quota", "VPC.PublicSubnetCIDRs", fmt.Sprintf("failed to describe addresses (%v)", err), "grant 'ec2:DescribeAddresses'")}
+	}
+	// one EIP per NAT gateway, one NAT gateway per public subnet
+	return checkQuota(cfg, quotaServiceEC2, quotaCodeEIPs, "Elastic IPs", "VPC.PublicSubnetCIDRs", len(out.Addresses), len(vpc.PublicSubnetCIDRs),
+		"release unused Elastic IPs, set VPC.DisableNATGateways true, or request a quota increase")
+}
+
+// checkQuota returns the problem if the usage plus the required exceeds the
+// applied quota, or the default quota if the applied quota is not set.
+func checkQuota(cfg Config, service, code, resource, field string, used, required int, fix string) []Problem {
+	var limit *float64
+	out, err := cfg.QuotasAPI.GetServiceQuota(&servicequotas.GetServiceQuotaInput{
+		ServiceCode: aws.String(service),
+		QuotaCode:   aws.String(code),
+	})
+	if err == nil && out.Quota != nil {
+		limit = out.Quota.Value
+	} else {
+		cfg.Logger.Info("failed to get applied quota; getting default", zap.String("quota-code", code), zap.Error(err))
+		dout, derr := cfg.QuotasAPI.GetAWSDefaultServiceQuota(&servicequotas.GetAWSDefaultServiceQuotaInput{
+			ServiceCode: aws.String(service),
+			QuotaCode:   aws.String(code),
+		})
+		if derr != nil {
+			return []Problem{warning("quota", field, fmt.Sprintf("failed to get %s quota %q (%v)", resource, code, derr), "grant 'servicequotas:GetServiceQuota' and 'servicequotas:GetAWSDefaultServiceQuota'")}
+		}
+		if dout.Quota != nil {
+			limit = dout.Quota.Value
+		}
+	}
+	if limit == nil {
+		return nil
+	}
+	cfg.Logger.Info("checked quota",
+		zap.String("resource", resource),
+		zap.Int("used", used),
+		zap.Int("required", required),
+		zap.Float64("limit", *limit),
+	)
+	if float64(used+required) > *limit {
+		return []Problem{{
+			Severity: SeverityError,
+			Check:    "quota",
+			Field:    field,
+			Message:  fmt.Sprintf("%d %s in use, %d more required, exceeds quota %.0f in %q", used, resource, required, *limit, cfg.EKSConfig.Region),
+			Fix:      fix,
+		}}
+	}
+	return nil
+}
+
+func checkInstanceTypes(cfg Config) (ps []Problem) {
+	// instance type to the fields that use it
+	fields := make(map[string][]string)
+	if cfg.EKSConfig.IsEnabledAddOnNodeGroups() {
+		for name, asg := range cfg.EKSConfig.AddOnNodeGroups.ASGs {
+			if asg.Placement != nil {
+				// checked in the placement zones
+				continue
+			}
+			its := asg.InstanceTypes
+			if len(its) == 0 {
+				its = []string{asg.InstanceType}
+			}
+			for _, it := range its {
+				fields[it] = append(fields[it], fmt.Sprintf("AddOnNodeGroups.ASGs[%q]", name))
+			}
+		}
+	}
+	if cfg.EKSConfig.IsEnabledAddOnManagedNodeGroups() {
+		for name, mng := range cfg.EKSConfig.AddOnManagedNodeGroups.MNGs {
+			for _, it := range mng.InstanceTypes {
+				fields[it] = append(fields[it], fmt.Sprintf("AddOnManagedNodeGroups.MNGs[%q]", name))
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	types := make([]string, 0, len(fields))
+	for it := range fields {
+		types = append(types, it)
+	}
+	sort.Strings(types)
+
+	offered, err := describeOfferings(cfg, []aws_ec2_v2_types.Filter{
+		{Name: aws_v2.String("instance-type"), Values: types},
+	})
+	if err != nil {
+		return []Problem{warning("instance-type", "InstanceTypes", fmt.Sprintf("failed to describe instance type offerings (%v)", err), "grant 'ec2:DescribeInstanceTypeOfferings'")}
+	}
+
+	for _, it := range types {
+		azs := offered[it]
+		sort.Strings(azs)
+		sort.Strings(fields[it])
+		cfg.Logger.Info("checked instance type", zap.String("instance-type", it), zap.Strings("availability-zones", azs))
+		switch {
+		case len(azs) == 0:
+			ps = append(ps, Problem{
+				Severity: SeverityError,
+				Check:    "instance-type",
+				Field:    strings.Join(fields[it], ", "),
+				Message:  fmt.Sprintf("instance type %q is not offered in %q", it, cfg.EKSConfig.Region),
+				Fix:      "choose an instance type offered in the region (see 'aws ec2 describe-instance-type-offerings')",
+			})
+		case len(azs) < 2:
+			// node groups span the subnets in multiple availability zones
+			ps = append(ps, Problem{
+				Severity: SeverityWarning,
+				Check:    "instance-type",
+				Field:    strings.Join(fields[it], ", "),
+				Message:  fmt.Sprintf("instance type %q is only offered in %v", it, azs),
+				Fix:      "add another instance type, or choose the subnets in the offered availability zones",
+			})
+		}
+	}
+	return ps
+}
+
+func checkAMIs(cfg Config) (ps []Problem) {
+	// image ID to the fields that use it
+	images := make(map[string][]string)
+	if cfg.EKSConfig.IsEnabledAddOnNodeGroups() {
+		names := make([]string, 0, len(cfg.EKSConfig.AddOnNodeGroups.ASGs))
+		for name := range cfg.EKSConfig.AddOnNodeGroups.ASGs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			asg := cfg.EKSConfig.AddOnNodeGroups.ASGs[name]
+			field := fmt.Sprintf("AddOnNodeGroups.ASGs[%q]", name)
+			if asg.ImageID != "" {
+				images[asg.ImageID] = append(images[asg.ImageID], field+".ImageID")
+				continue
+			}
+			if asg.ImageIDSSMParameter == "" {
+				continue
+			}
+			_, err := cfg.SSMAPI.GetParameter(&ssm.GetParameterInput{Name: aws.String(asg.ImageIDSSMParameter)})
+			if err == nil {
+				continue
+			}
+			p := Problem{
+				Severity: SeverityError,
+				Check:    "ami",
+				Field:    field + ".ImageIDSSMParameter",
+				Message:  fmt.Sprintf("SSM parameter %q not found in %q", asg.ImageIDSSMParameter, cfg.EKSConfig.Region),
+				Fix:      "check the Kubernetes version and the AMI type in the parameter name",
+			}
+			if !isCode(err, ssm.ErrCodeParameterNotFound) {
+				p = warning("ami", p.Field, fmt.Sprintf("failed to get SSM parameter %q (%v)", asg.ImageIDSSMParameter, err), "grant 'ssm:GetParameter'")
+			}
+			ps = append(ps, p)
+		}
+	}
+	if cfg.EKSConfig.IsEnabledAddOnManagedNodeGroups() {
+		for name, mng := range cfg.EKSConfig.AddOnManagedNodeGroups.MNGs {
+			if mng.LaunchTemplate != nil && mng.LaunchTemplate.ImageID != "" {
+				images[mng.LaunchTemplate.ImageID] = append(images[mng.LaunchTemplate.ImageID], fmt.Sprintf("AddOnManagedNodeGroups.MNGs[%q].LaunchTemplate.ImageID", name))
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(images))
+	for id := range images {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		// describe one by one, since the unknown ID fails the whole request
+		out, err := cfg.EC2APIV2.DescribeImages(context.Background(), &aws_ec2_v2.DescribeImagesInput{ImageIds: []string{id}})
+		if err != nil && !isCode(err, "InvalidAMIID.NotFound") && !isCode(err, "InvalidAMIID.Malformed") {
+			ps = append(ps, warning("ami", strings.Join(images[id], ", "), fmt.Sprintf("failed to describe image %q (%v)", id, err), "grant 'ec2:DescribeImages'"))
+			continue
+		}
+		if err == nil && len(out.Images) > 0 && out.Images[0].State == aws_ec2_v2_types.ImageStateAvailable {
+			continue
+		}
+		ps = append(ps, Problem{
+			Severity: SeverityError,
+			Check:    "ami",
+			Field:    strings.Join(images[id], ", "),
+			Message:  fmt.Sprintf("image %q not found or not available in %q", id, cfg.EKSConfig.Region),
+			Fix:      "AMI IDs are regional; use the image in the region, or share the image with the account",
+		})
+	}
+	return ps
+}
+
+// checkPlacements checks the node group subnets in the Local Zones,
+// Wavelength Zones, or Outposts, and the instance types offered there.
+func checkPlacements(cfg Config) (ps []Problem) {
+	if !cfg.EKSConfig.IsEnabledAddOnNodeGroups() {
+		return nil
+	}
+	names := make([]string, 0, len(cfg.EKSConfig.AddOnNodeGroups.ASGs))
+	for name, asg := range cfg.EKSConfig.AddOnNodeGroups.ASGs {
+		if asg.Placement != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ps = append(ps, checkPlacement(cfg, name, cfg.EKSConfig.AddOnNodeGroups.ASGs[name])...)
+	}
+	return ps
+}
+
+func checkPlacement(cfg Config, name string, asg eksconfig.ASG) (ps []Problem) {
+	p := asg.Placement
+	field := fmt.Sprintf("AddOnNodeGroups.ASGs[%q].Placement", name)
+	out, err := cfg.EC2APIV2.DescribeSubnets(context.Background(), &aws_ec2_v2.DescribeSubnetsInput{SubnetIds: p.SubnetIDs})
+	if err != nil {
+		if isCode(err, "InvalidSubnetID.NotFound") {
+			return []Problem{{
+				Severity: SeverityError,
+				Check:    "placement",
+				Field:    field + ".SubnetIDs",
+				Message:  fmt.Sprintf("subnets %v not found in %q (%v)", p.SubnetIDs, cfg.EKSConfig.Region, err),
+				Fix:      "create the subnets in the zone or the Outpost, in the cluster VPC",
+			}}
+		}
+		return []Problem{warning("placement", field+".SubnetIDs", fmt.Sprintf("failed to describe subnets (%v)", err), "grant 'ec2:DescribeSubnets'")}
+	}
+
+	var zones []string
+	for _, sn := range out.Subnets {
+		id := aws_v2.ToString(sn.SubnetId)
+		if vpcID := aws_v2.ToString(sn.VpcId); vpcID != cfg.EKSConfig.VPC.ID {
+			ps = append(ps, Problem{
+				Severity: SeverityError,
+				Check:    "placement",
+				Field:    field + ".SubnetIDs",
+				Message:  fmt.Sprintf("subnet %q is in VPC %q, not in VPC.ID %q", id, vpcID, cfg.EKSConfig.VPC.ID),
+				Fix:      "use the subnets in the cluster VPC",
+			})
+		}
+		if arn := aws_v2.ToString(sn.OutpostArn); p.Type == eksconfig.PlacementTypeOutpost && arn != p.OutpostARN {
+			ps = append(ps, Problem{
+				Severity: SeverityError,
+				Check:    "placement",
+				Field:    field + ".OutpostARN",
+				Message:  fmt.Sprintf("subnet %q is on Outpost %q, not on %q", id, arn, p.OutpostARN),
+				Fix:      "use the subnets on the Outpost",
+			})
+		}
+		zones = append(zones, aws_v2.ToString(sn.AvailabilityZone))
+	}
+	sort.Strings(zones)
+	if p.Type == eksconfig.PlacementTypeOutpost {
+		// Outpost subnets are in the parent availability zones,
+		// and the instance types are the capacity of the Outpost
+		return ps
+	}
+
+	azs, err := cfg.EC2APIV2.DescribeAvailabilityZones(
+		context.Background(),
+		&aws_ec2_v2.DescribeAvailabilityZonesInput{
+			AllAvailabilityZones: aws_v2.Bool(true),
+			ZoneNames:            zones,
+		},
+	)
+	if err != nil {
+		return append(ps, warning("placement", field+".Type", fmt.Sprintf("failed to describe availability zones (%v)", err), "grant 'ec2:DescribeAvailabilityZones'"))
+	}
+	for _, az := range azs.AvailabilityZones {
+		zone := aws_v2.ToString(az.ZoneName)
+		if tp := aws_v2.ToString(az.ZoneType); tp != p.Type {
+			ps = append(ps, Problem{
+				Severity: SeverityError,
+				Check:    "placement",
+				Field:    field + ".Type",
+				Message:  fmt.Sprintf("subnet zone %q is %q, not %q", zone, tp, p.Type),
+				Fix:      "use the subnets in the zone of Placement.Type",
+			})
+			continue
+		}
+		if az.OptInStatus == aws_ec2_v2_types.AvailabilityZoneOptInStatusNotOptedIn {
+			ps = append(ps, Problem{
+				Severity: SeverityError,
+				Check:    "placement",
+				Field:    field + ".SubnetIDs",
+				Message:  fmt.Sprintf("zone group %q of %q is not opted in", aws_v2.ToString(az.GroupName), zone),
+				Fix:      fmt.Sprintf("opt in with 'aws ec2 modify-availability-zone-group --group-name %s --opt-in-status opted-in'", aws_v2.ToString(az.GroupName)),
+			})
+		}
+	}
+
+	its := asg.InstanceTypes
+	if len(its) == 0 {
+		its = []string{asg.InstanceType}
+	}
+	offered, err := describeOfferings(cfg, []aws_ec2_v2_types.Filter{
+		{Name: aws_v2.String("instance-type"), Values: its},
+		{Name: aws_v2.String("location"), Values: zones},
+	})
+	if err != nil {
+		return append(ps, warning("placement", field, fmt.Sprintf("failed to describe instance type offerings (%v)", err), "grant 'ec2:DescribeInstanceTypeOfferings'"))
+	}
+	for _, it := range its {
+		if len(offered[it]) == 0 {
+			ps = append(ps, Problem{
+				Severity: SeverityError,
+				Check:    "placement",
+				Field:    fmt.Sprintf("AddOnNodeGroups.ASGs[%q].InstanceType", name),
+				Message:  fmt.Sprintf("instance type %q is not offered in %v", it, zones),
+				Fix:      "choose an instance type offered in the zone (see 'aws ec2 describe-instance-type-offerings --location-type availability-zone')",
+			})
+		}
+	}
+	return ps
+}
+
+// describeOfferings returns the availability zones of the instance types offered.
+func describeOfferings(cfg Config, filters []aws_ec2_v2_types.Filter) (map[string][]string, error) {
+	offered := make(map[string][]string)
+	p := aws_ec2_v2.NewDescribeInstanceTypeOfferingsPaginator(
+		cfg.EC2APIV2,
+		&aws_ec2_v2.DescribeInstanceTypeOfferingsInput{
+			LocationType: aws_ec2_v2_types.LocationTypeAvailabilityZone,
+			Filters:      filters,
+		},
+	)
+	for p.HasMorePages() {
+		out, err := p.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range out.InstanceTypeOfferings {
+			it := string(o.InstanceType)
+			offered[it] = append(offered[it], aws_v2.ToString(o.Location))
+		}
+	}
+	return offered, nil
+}
+
+func warning(check, field, msg, fix string) Problem {
+	return Problem{Severity: SeverityWarning, Check: check, Field: field, Message: msg, Fix: fix}
+}
+
+// isCode returns true if the v1 (e.g. SSM) or the v2 (e.g. EC2) API error
+// has the error code.
+func isCode(err error, code string) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code() == code
+	}
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == code
+}
@@ -0,0 +1,210 @@
+package preflight
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+	"github.com/aws/aws-sdk-go/service/servicequotas/servicequotasiface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	smithy "github.com/aws/smithy-go"
+	"go.uber.org/zap"
+)
+
+type fakeEC2 struct {
+	vpcs    int
+	eips    int
+	offered map[string][]string
+	images  map[string]aws_ec2_v2_types.ImageState
+	subnets map[string]aws_ec2_v2_types.Subnet
+	zones   map[string]aws_ec2_v2_types.AvailabilityZone
+}
+
+func (f *fakeEC2) DescribeVpcs(ctx context.Context, in *aws_ec2_v2.DescribeVpcsInput, optFns ...func(*aws_ec2_v2.Options)) (*aws_ec2_v2.DescribeVpcsOutput, error) {
+	return &aws_ec2_v2.DescribeVpcsOutput{Vpcs: make([]aws_ec2_v2_types.Vpc, f.vpcs)}, nil
+}
+
+func (f *fakeEC2) DescribeAddresses(ctx context.Context, in *aws_ec2_v2.DescribeAddressesInput, optFns ...func(*aws_ec2_v2.Options)) (*aws_ec2_v2.DescribeAddressesOutput, error) {
+	return &aws_ec2_v2.DescribeAddressesOutput{Addresses: make([]aws_ec2_v2_types.Address, f.eips)}, nil
+}
+
+func (f *fakeEC2) DescribeInstanceTypeOfferings(ctx context.Context, in *aws_ec2_v2.DescribeInstanceTypeOfferingsInput, optFns ...func(*aws_ec2_v2.Options)) (*aws_ec2_v2.DescribeInstanceTypeOfferingsOutput, error) {
+	out := &aws_ec2_v2.DescribeInstanceTypeOfferingsOutput{}
+	locations := make(map[string]bool)
+	if len(in.Filters) > 1 {
+		for _, l := range in.Filters[1].Values {
+			locations[l] = true
+		}
+	}
+	for _, it := range in.Filters[0].Values {
+		for _, az := range f.offered[it] {
+			if len(locations) > 0 && !locations[az] {
+				continue
+			}
+			out.InstanceTypeOfferings = append(out.InstanceTypeOfferings, aws_ec2_v2_types.InstanceTypeOffering{InstanceType: aws_ec2_v2_types.InstanceType(it), Location: aws_v2.String(az)})
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeEC2) DescribeImages(ctx context.Context, in *aws_ec2_v2.DescribeImagesInput, optFns ...func(*aws_ec2_v2.Options)) (*aws_ec2_v2.DescribeImagesOutput, error) {
+	state, ok := f.images[in.ImageIds[0]]
+	if !ok {
+		return nil, &smithy.GenericAPIError{Code: "InvalidAMIID.NotFound", Message: "not found"}
+	}
+	return &aws_ec2_v2.DescribeImagesOutput{Images: []aws_ec2_v2_types.Image{{State: state}}}, nil
+}
+
+func (f *fakeEC2) DescribeSubnets(ctx context.Context, in *aws_ec2_v2.DescribeSubnetsInput, optFns ...func(*aws_ec2_v2.Options)) (*aws_ec2_v2.DescribeSubnetsOutput, error) {
+	out := &aws_ec2_v2.DescribeSubnetsOutput{}
+	for _, id := range in.SubnetIds {
+		sn, ok := f.subnets[id]
+		if !ok {
+			return nil, &smithy.GenericAPIError{Code: "InvalidSubnetID.NotFound", Message: "not found"}
+		}
+		out.Subnets = append(out.Subnets, sn)
+	}
+	return out, nil
+}
+
+func (f *fakeEC2) DescribeAvailabilityZones(ctx context.Context, in *aws_ec2_v2.DescribeAvailabilityZonesInput, optFns ...func(*aws_ec2_v2.Options)) (*aws_ec2_v2.DescribeAvailabilityZonesOutput, error) {
+	out := &aws_ec2_v2.DescribeAvailabilityZonesOutput{}
+	for _, z := range in.ZoneNames {
+		out.AvailabilityZones = append(out.AvailabilityZones, f.zones[z])
+	}
+	return out, nil
+}
+
+type fakeSSM struct {
+	ssmiface.SSMAPI
+	params map[string]string
+}
+
+func (f *fakeSSM) GetParameter(in *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
+	if v, ok := f.params[aws.StringValue(in.Name)]; ok {
+		return &ssm.GetParameterOutput{Parameter: &ssm.Parameter{Value: aws.String(v)}}, nil
+	}
+	return nil, awserr.New(ssm.ErrCodeParameterNotFound, "not found", nil)
+}
+
+type fakeQuotas struct {
+	servicequotasiface.ServiceQuotasAPI
+	limits map[string]float64
+}
+
+func (f *fakeQuotas) GetServiceQuota(in *servicequotas.GetServiceQuotaInput) (*servicequotas.GetServiceQuotaOutput, error) {
+	return nil, awserr.New(servicequotas.ErrCodeNoSuchResourceException, "not applied", nil)
+}
+
+func (f *fakeQuotas) GetAWSDefaultServiceQuota(in *servicequotas.GetAWSDefaultServiceQuotaInput) (*servicequotas.GetAWSDefaultServiceQuotaOutput, error) {
+	return &servicequotas.GetAWSDefaultServiceQuotaOutput{Quota: &servicequotas.ServiceQuota{Value: aws.Float64(f.limits[aws.StringValue(in.QuotaCode)])}}, nil
+}
+
+func TestCheck(t *testing.T) {
+	cfg := eksconfig.NewDefault()
+	cfg.VPC.Create = true
+	cfg.VPC.PublicSubnetCIDRs = []string{"192.168.64.0/19", "192.168.96.0/19", "192.168.128.0/19"}
+	cfg.AddOnNodeGroups = &eksconfig.AddOnNodeGroups{
+		Enable: true,
+		ASGs: map[string]eksconfig.ASG{
+			"ng-1": {ASG: ec2config.ASG{InstanceType: "c5.xlarge", ImageIDSSMParameter: "/aws/service/eks/optimized-ami/1.99/amazon-linux-2/recommended/image_id"}},
+			"ng-2": {ASG: ec2config.ASG{InstanceType: "p4d.24xlarge", ImageID: "ami-unknown"}},
+		},
+	}
+
+	ps := Check(Config{
+		Logger:    zap.NewExample(),
+		EKSConfig: cfg,
+		EC2APIV2: &fakeEC2{
+			vpcs:    3,
+			eips:    4,
+			offered: map[string][]string{"c5.xlarge": {"us-west-2a", "us-west-2b"}, "p4d.24xlarge": {"us-west-2b"}},
+			images:  map[string]aws_ec2_v2_types.ImageState{},
+		},
+		SSMAPI:    &fakeSSM{},
+		QuotasAPI: &fakeQuotas{limits: map[string]float64{quotaCodeVPCs: 5, quotaCodeEIPs: 5}},
+	})
+
+	var got []string
+	for _, p := range ps {
+		got = append(got, p.Severity+" "+p.Check+" "+p.Field)
+	}
+	exp := []string{
+		`error quota VPC.PublicSubnetCIDRs`,
+		`warning instance-type AddOnNodeGroups.ASGs["ng-2"]`,
+		`error ami AddOnNodeGroups.ASGs["ng-1"].ImageIDSSMParameter`,
+		`error ami AddOnNodeGroups.ASGs["ng-2"].ImageID`,
+	}
+	if strings.Join(got, "\n") != strings.Join(exp, "\n") {
+		t.Fatalf("expected problems\n%s\ngot\n%s", strings.Join(exp, "\n"), strings.Join(got, "\n"))
+	}
+	if !HasError(ps) {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(ps[0].Message, "4 Elastic IPs in use, 3 more required, exceeds quota 5") {
+		t.Fatalf("unexpected quota message %q", ps[0].Message)
+	}
+}
+
+func TestCheckPlacement(t *testing.T) {
+	cfg := eksconfig.NewDefault()
+	cfg.VPC.Create = false
+	cfg.VPC.ID = "vpc-1"
+	cfg.AddOnNodeGroups = &eksconfig.AddOnNodeGroups{
+		Enable: true,
+		ASGs: map[string]eksconfig.ASG{
+			"lz": {
+				ASG:       ec2config.ASG{InstanceType: "c5.2xlarge", ImageID: "ami-1"},
+				Placement: &eksconfig.NGPlacement{Type: eksconfig.PlacementTypeLocalZone, SubnetIDs: []string{"subnet-lz"}},
+			},
+			"op": {
+				ASG:       ec2config.ASG{InstanceType: "m5.xlarge", ImageID: "ami-1"},
+				Placement: &eksconfig.NGPlacement{Type: eksconfig.PlacementTypeOutpost, SubnetIDs: []string{"subnet-op"}, OutpostARN: "arn:aws:outposts:us-west-2:123:outpost/op-1"},
+			},
+			"wl": {
+				ASG:       ec2config.ASG{InstanceType: "t3.medium", ImageID: "ami-1"},
+				Placement: &eksconfig.NGPlacement{Type: eksconfig.PlacementTypeWavelengthZone, SubnetIDs: []string{"subnet-other"}},
+			},
+		},
+	}
+
+	ps := checkPlacements(Config{
+		Logger:    zap.NewExample(),
+		EKSConfig: cfg,
+		EC2APIV2: &fakeEC2{
+			offered: map[string][]string{"c5.2xlarge": {"us-west-2a"}, "t3.medium": {"us-west-2-wl1-las-wlz-1"}},
+			subnets: map[string]aws_ec2_v2_types.Subnet{
+				"subnet-lz":    {SubnetId: aws_v2.String("subnet-lz"), VpcId: aws_v2.String("vpc-1"), AvailabilityZone: aws_v2.String("us-west-2-lax-1a")},
+				"subnet-op":    {SubnetId: aws_v2.String("subnet-op"), VpcId: aws_v2.String("vpc-1"), AvailabilityZone: aws_v2.String("us-west-2a"), OutpostArn: aws_v2.String("arn:aws:outposts:us-west-2:123:outpost/op-2")},
+				"subnet-other": {SubnetId: aws_v2.String("subnet-other"), VpcId: aws_v2.String("vpc-2"), AvailabilityZone: aws_v2.String("us-west-2-wl1-las-wlz-1")},
+			},
+			zones: map[string]aws_ec2_v2_types.AvailabilityZone{
+				"us-west-2-lax-1a":        {ZoneName: aws_v2.String("us-west-2-lax-1a"), ZoneType: aws_v2.String("local-zone"), GroupName: aws_v2.String("us-west-2-lax-1"), OptInStatus: aws_ec2_v2_types.AvailabilityZoneOptInStatusNotOptedIn},
+				"us-west-2-wl1-las-wlz-1": {ZoneName: aws_v2.String("us-west-2-wl1-las-wlz-1"), ZoneType: aws_v2.String("wavelength-zone"), OptInStatus: aws_ec2_v2_types.AvailabilityZoneOptInStatusOptedIn},
+			},
+		},
+	})
+
+	var got []string
+	for _, p := range ps {
+		got = append(got, p.Severity+" "+p.Field+" "+p.Message)
+	}
+	exp := []string{
+		`error AddOnNodeGroups.ASGs["lz"].Placement.SubnetIDs zone group "us-west-2-lax-1" of "us-west-2-lax-1a" is not opted in`,
+		`error AddOnNodeGroups.ASGs["lz"].InstanceType instance type "c5.2xlarge" is not offered in [us-west-2-lax-1a]`,
+		`error AddOnNodeGroups.ASGs["op"].Placement.OutpostARN subnet "subnet-op" is on Outpost "arn:aws:outposts:us-west-2:123:outpost/op-2", not on "arn:aws:outposts:us-west-2:123:outpost/op-1"`,
+		`error AddOnNodeGroups.ASGs["wl"].Placement.SubnetIDs subnet "subnet-other" is in VPC "vpc-2", not in VPC.ID "vpc-1"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(exp, "\n") {
+		t.Fatalf("expected problems\n%s\ngot\n%s", strings.Join(exp, "\n"), strings.Join(got, "\n"))
+	}
+}
@@ -0,0 +1,77 @@
+package eks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// verifyPrivateNodes verifies that the nodes joined the cluster from the
+// private subnets without NAT gateways, only reaching AWS services
+// through the VPC endpoints.
+func (ts *Tester) verifyPrivateNodes() error {
+	ts.lg.Info("verifying nodes in private subnets", zap.Strings("private-subnet-ids", ts.cfg.VPC.PrivateSubnetIDs))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	nodes, err := ts.k8sClient.KubernetesClientSet().CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: "kubernetes.io/os=linux"})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to list nodes (%v)", err)
+	}
+	if len(nodes.Items) == 0 {
+		return errors.New("no Linux node joined the cluster")
+	}
+
+	private := make(map[string]struct{})
+	for _, id := range ts.cfg.VPC.PrivateSubnetIDs {
+		private[id] = struct{}{}
+	}
+
+	var errs []string
+	instanceIDs := make([]string, 0, len(nodes.Items))
+	for _, node := range nodes.Items {
+		ready := false
+		for _, cond := range node.Status.Conditions {
+			if cond.Type == v1.NodeReady && cond.Status == v1.ConditionTrue {
+				ready = true
+				break
+			}
+		}
+		if !ready {
+			errs = append(errs, fmt.Sprintf("node %q not ready", node.Name))
+		}
+		instanceIDs = append(instanceIDs, node.Spec.ProviderID[strings.LastIndex(node.Spec.ProviderID, "/")+1:])
+	}
+
+	out, err := ts.ec2APIV2.DescribeInstances(
+		context.Background(),
+		&aws_ec2_v2.DescribeInstancesInput{InstanceIds: instanceIDs},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to describe node instances (%v)", err)
+	}
+	for _, rsv := range out.Reservations {
+		for _, inst := range rsv.Instances {
+			id, subnetID := aws_v2.ToString(inst.InstanceId), aws_v2.ToString(inst.SubnetId)
+			if _, ok := private[subnetID]; !ok {
+				errs = append(errs, fmt.Sprintf("node instance %q in non-private subnet %q", id, subnetID))
+			}
+			if aws_v2.ToString(inst.PublicIpAddress) != "" {
+				errs = append(errs, fmt.Sprintf("node instance %q has public IP %q", id, aws_v2.ToString(inst.PublicIpAddress)))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	ts.lg.Info("verified nodes in private subnets", zap.Int("nodes", len(nodes.Items)))
+	return nil
+}
@@ -12,10 +12,11 @@ import (
 	"time"
 
 	"github.com/aws/aws-k8s-tester/ec2config"
-	"github.com/aws/aws-k8s-tester/eks/helm"
 	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
 	"github.com/aws/aws-k8s-tester/eksconfig"
+	pkg_aws "github.com/aws/aws-k8s-tester/pkg/aws"
 	"github.com/aws/aws-k8s-tester/pkg/aws/elb"
+	"github.com/aws/aws-k8s-tester/pkg/helm"
 	"github.com/aws/aws-k8s-tester/pkg/httputil"
 	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
@@ -46,6 +47,22 @@ func New(cfg Config) eks_tester.Tester {
 	return &tester{cfg: cfg}
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "prometheus-grafana",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				ELB2API:   cfg.ELBV2API,
+			}), cfg.EKSConfig.IsEnabledAddOnPrometheusGrafana)
+		},
+	})
+}
+
 type tester struct {
 	cfg Config
 }
@@ -72,13 +89,15 @@ func (ts *tester) Create() error {
 	}
 
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
-	ts.cfg.EKSConfig.AddOnPrometheusGrafana.Created = true
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnPrometheusGrafana.Created = true
+	})
 	createStart := time.Now()
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnPrometheusGrafana.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnPrometheusGrafana.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
 	if err := k8s_client.CreateNamespace(
@@ -124,8 +143,9 @@ func (ts *tester) Delete() error {
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnPrometheusGrafana.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnPrometheusGrafana.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
 	}()
 
 	var errs []string
@@ -193,8 +213,9 @@ func (ts *tester) Delete() error {
 		return errors.New(strings.Join(errs, ", "))
 	}
 
-	ts.cfg.EKSConfig.AddOnPrometheusGrafana.Created = false
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnPrometheusGrafana.Created = false
+	})
 	return nil
 }
 
@@ -252,6 +273,7 @@ func (ts *tester) createHelmPrometheus() error {
 		ChartRepoURL:   chartRepoURL,
 		ChartName:      chartNamePrometheus,
 		ReleaseName:    chartNamePrometheus,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
 		Values:         values,
 		QueryFunc: func() {
 			fmt.Fprintf(ts.cfg.LogWriter, "\n")
@@ -281,6 +303,7 @@ func (ts *tester) deleteHelmPrometheus() error {
 		Namespace:      chartNamespacePrometheus,
 		ChartName:      chartNamePrometheus,
 		ReleaseName:    chartNamePrometheus,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
 	})
 }
 
@@ -372,6 +395,7 @@ func (ts *tester) createHelmGrafana() error {
 		ChartRepoURL:   chartRepoURL,
 		ChartName:      chartNameGrafana,
 		ReleaseName:    chartNameGrafana,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
 		Values:         values,
 		QueryFunc: func() {
 			getAllArgs := []string{
@@ -405,6 +429,7 @@ func (ts *tester) deleteHelmGrafana() error {
 		Namespace:      chartNamespaceGrafana,
 		ChartName:      chartNameGrafana,
 		ReleaseName:    chartNameGrafana,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
 	})
 }
 
@@ -483,18 +508,25 @@ func (ts *tester) waitServiceGrafana() error {
 		return errors.New("failed to find host name")
 	}
 
-	ts.cfg.EKSConfig.AddOnPrometheusGrafana.GrafanaURL = "http://" + hostName + "/login"
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnPrometheusGrafana.GrafanaURL = "http://" + hostName + "/login"
+	})
 
 	// TODO: is there any better way to find out the NLB name?
-	ts.cfg.EKSConfig.AddOnPrometheusGrafana.GrafanaNLBName = strings.Split(hostName, "-")[0]
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnPrometheusGrafana.GrafanaNLBName = strings.Split(hostName, "-")[0]
+	})
 	ss := strings.Split(hostName, ".")[0]
 	ss = strings.Replace(ss, "-", "/", -1)
-	ts.cfg.EKSConfig.AddOnPrometheusGrafana.GrafanaNLBARN = fmt.Sprintf(
-		"arn:aws:elasticloadbalancing:%s:%s:loadbalancer/net/%s",
-		ts.cfg.EKSConfig.Region,
-		ts.cfg.EKSConfig.Status.AWSAccountID,
-		ss,
-	)
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnPrometheusGrafana.GrafanaNLBARN = pkg_aws.ARN(
+			ts.cfg.EKSConfig.Partition,
+			"elasticloadbalancing",
+			ts.cfg.EKSConfig.Region,
+			ts.cfg.EKSConfig.Status.AWSAccountID,
+			"loadbalancer/net/"+ss,
+		)
+	})
 
 	fmt.Fprintf(ts.cfg.LogWriter, "\nNLB Grafana ARN: %s\n", ts.cfg.EKSConfig.AddOnPrometheusGrafana.GrafanaNLBARN)
 	fmt.Fprintf(ts.cfg.LogWriter, "NLB Grafana Name: %s\n", ts.cfg.EKSConfig.AddOnPrometheusGrafana.GrafanaNLBName)
@@ -0,0 +1,116 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// targetsResponse is the response of "/api/v1/targets".
+// ref. https://prometheus.io/docs/prometheus/latest/querying/api/#targets
+type targetsResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ActiveTargets []target `json:"activeTargets"`
+	} `json:"data"`
+}
+
+type target struct {
+	Labels    map[string]string `json:"labels"`
+	ScrapeURL string            `json:"scrapeUrl"`
+	Health    string            `json:"health"`
+	LastError string            `json:"lastError"`
+}
+
+// queryResponse is the response of "/api/v1/query".
+// ref. https://prometheus.io/docs/prometheus/latest/querying/api/#instant-queries
+type queryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string   `json:"resultType"`
+		Result     []sample `json:"result"`
+	} `json:"data"`
+}
+
+type sample struct {
+	Metric map[string]string `json:"metric"`
+	// Value is the [unix time, value string] pair.
+	Value []interface{} `json:"value"`
+}
+
+// get calls the Prometheus HTTP API through the kube-apiserver
+// Service proxy, which works with the private-only endpoint as well.
+func (ts *tester) get(path string, params map[string]string, v interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	b, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Services(ts.cfg.EKSConfig.AddOnPrometheus.Namespace).
+		ProxyGet("http", prometheusService, prometheusPort, path, params).
+		DoRaw(ctx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to get %q (%v)", path, err)
+	}
+	return json.Unmarshal(b, v)
+}
+
+// waitTargets waits until all targets of the scrape jobs are healthy.
+func (ts *tester) waitTargets() error {
+	cur := ts.cfg.EKSConfig.AddOnPrometheus
+	ts.cfg.Logger.Info("waiting for scrape targets", zap.Strings("scrape-jobs", cur.ScrapeJobs))
+
+	var unhealthy []string
+	waitStart := time.Now()
+	for time.Since(waitStart) < cur.TargetsTimeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("scrape targets wait aborted")
+		case <-time.After(15 * time.Second):
+		}
+
+		var resp targetsResponse
+		if err := ts.get("api/v1/targets", map[string]string{"state": "active"}, &resp); err != nil {
+			ts.cfg.Logger.Warn("failed to get scrape targets", zap.Error(err))
+			continue
+		}
+		unhealthy = checkTargets(cur.ScrapeJobs, resp.Data.ActiveTargets)
+		ts.cfg.Logger.Info("polled scrape targets",
+			zap.Int("targets", len(resp.Data.ActiveTargets)),
+			zap.Strings("unhealthy", unhealthy),
+		)
+		if len(unhealthy) == 0 {
+			ts.cfg.Logger.Info("scrape targets are healthy", zap.Strings("scrape-jobs", cur.ScrapeJobs))
+			return nil
+		}
+	}
+	return fmt.Errorf("scrape targets not healthy %v", unhealthy)
+}
+
+// checkTargets returns the unhealthy targets of the jobs,
+// or the jobs without any target.
+func checkTargets(jobs []string, targets []target) (unhealthy []string) {
+	byJob := make(map[string][]target)
+	for _, t := range targets {
+		byJob[t.Labels["job"]] = append(byJob[t.Labels["job"]], t)
+	}
+	for _, job := range jobs {
+		ts, ok := byJob[job]
+		if !ok {
+			unhealthy = append(unhealthy, fmt.Sprintf("job %q has no target", job))
+			continue
+		}
+		for _, t := range ts {
+			if t.Health != "up" {
+				unhealthy = append(unhealthy, fmt.Sprintf("job %q target %q is %q (%s)", job, t.ScrapeURL, t.Health, t.LastError))
+			}
+		}
+	}
+	sort.Strings(unhealthy)
+	return unhealthy
+}
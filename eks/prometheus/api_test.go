@@ -0,0 +1,64 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCheckTargets(t *testing.T) {
+	var resp targetsResponse
+	if err := json.Unmarshal([]byte(`{
+  "status": "success",
+  "data": {
+    "activeTargets": [
+      {"labels": {"job": "apiserver"}, "scrapeUrl": "https://10.0.0.1:443/metrics", "health": "up", "lastError": ""},
+      {"labels": {"job": "kubelet"}, "scrapeUrl": "https://10.0.1.1:10250/metrics", "health": "up", "lastError": ""},
+      {"labels": {"job": "kubelet"}, "scrapeUrl": "https://10.0.1.2:10250/metrics", "health": "down", "lastError": "timeout"}
+    ]
+  }
+}`), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	unhealthy := checkTargets([]string{"apiserver", "kubelet", "node-exporter"}, resp.Data.ActiveTargets)
+	expected := []string{
+		`job "kubelet" target "https://10.0.1.2:10250/metrics" is "down" (timeout)`,
+		`job "node-exporter" has no target`,
+	}
+	if !reflect.DeepEqual(unhealthy, expected) {
+		t.Fatalf("expected %q, got %q", expected, unhealthy)
+	}
+
+	if unhealthy = checkTargets([]string{"apiserver"}, resp.Data.ActiveTargets); len(unhealthy) > 0 {
+		t.Fatalf("unexpected unhealthy %q", unhealthy)
+	}
+}
+
+func TestToSnapshotSamples(t *testing.T) {
+	var resp queryResponse
+	if err := json.Unmarshal([]byte(`{
+  "status": "success",
+  "data": {
+    "resultType": "vector",
+    "result": [
+      {"metric": {"verb": "GET"}, "value": [1695000000.123, "0.045"]},
+      {"metric": {"verb": "LIST"}, "value": [1695000000.123, "NaN"]}
+    ]
+  }
+}`), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	ss := toSnapshotSamples(resp.Data.Result)
+	expected := []snapshotSample{
+		{Labels: map[string]string{"verb": "GET"}, Value: "0.045"},
+		{Labels: map[string]string{"verb": "LIST"}, Value: "NaN"},
+	}
+	if !reflect.DeepEqual(ss, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, ss)
+	}
+	if _, err := json.Marshal(ss); err != nil {
+		t.Fatal(err)
+	}
+}
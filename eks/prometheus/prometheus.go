@@ -0,0 +1,230 @@
+// Package prometheus installs kube-prometheus-stack, waits for the scrape
+// targets to be healthy, and snapshots key metrics at test end.
+// ref. https://github.com/prometheus-community/helm-charts/tree/main/charts/kube-prometheus-stack
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/pkg/helm"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"go.uber.org/zap"
+)
+
+// Config defines kube-prometheus-stack configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+}
+
+// Tester defines kube-prometheus-stack tester.
+type Tester interface {
+	eks_tester.Tester
+	// Snapshot queries the snapshot metrics, and writes them
+	// to the directory.
+	Snapshot(dir string) error
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new kube-prometheus-stack tester.
+func New(cfg Config) Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name: "prometheus",
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+			}), cfg.EKSConfig.IsEnabledAddOnPrometheus)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+const (
+	chartRepoName = "prometheus-community"
+	chartRepoURL  = "https://prometheus-community.github.io/helm-charts"
+	chartName     = "kube-prometheus-stack"
+
+	// releaseName is also the "fullnameOverride",
+	// so that the Prometheus Service name is fixed.
+	releaseName       = "kube-prometheus-stack"
+	prometheusService = releaseName + "-prometheus"
+	prometheusPort    = "9090"
+)
+
+func (ts *tester) Create() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnPrometheus() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnPrometheus.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnPrometheus.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnPrometheus.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	if err := k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnPrometheus.Namespace,
+	); err != nil {
+		return err
+	}
+	if err := helm.RepoAdd(ts.cfg.Logger, chartRepoName, chartRepoURL); err != nil {
+		return err
+	}
+	if err := ts.createHelmPrometheus(); err != nil {
+		return err
+	}
+	if err := ts.waitTargets(); err != nil {
+		return err
+	}
+
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnPrometheus() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnPrometheus.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnPrometheus.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+
+	// helm does not delete the CRDs, which are deleted with the cluster
+	if err := ts.deleteHelmPrometheus(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnPrometheus.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete Prometheus namespace (%v)", err))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnPrometheus.Created = false
+	})
+	return nil
+}
+
+// https://github.com/prometheus-community/helm-charts/blob/main/charts/kube-prometheus-stack/values.yaml
+func (ts *tester) createHelmPrometheus() error {
+	values := map[string]interface{}{
+		"fullnameOverride": releaseName,
+		"grafana": map[string]interface{}{
+			"enabled": false,
+		},
+		"alertmanager": map[string]interface{}{
+			"enabled": false,
+		},
+		// not reachable in the EKS managed control plane
+		"kubeEtcd": map[string]interface{}{
+			"enabled": false,
+		},
+		"kubeScheduler": map[string]interface{}{
+			"enabled": false,
+		},
+		"kubeControllerManager": map[string]interface{}{
+			"enabled": false,
+		},
+		// kube-proxy metrics listen on localhost only
+		"kubeProxy": map[string]interface{}{
+			"enabled": false,
+		},
+		"prometheus": map[string]interface{}{
+			"prometheusSpec": map[string]interface{}{
+				// emptyDir storage, enough for a test run
+				"retention":      "1d",
+				"scrapeInterval": "30s",
+			},
+		},
+	}
+
+	return helm.Install(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Stopc:          ts.cfg.Stopc,
+		Timeout:        15 * time.Minute,
+		KubeConfigPath: ts.cfg.EKSConfig.KubeConfigPath,
+		Namespace:      ts.cfg.EKSConfig.AddOnPrometheus.Namespace,
+		ChartRepoURL:   chartRepoURL,
+		ChartName:      chartName,
+		ChartVersion:   ts.cfg.EKSConfig.AddOnPrometheus.ChartVersion,
+		ReleaseName:    releaseName,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
+		Values:         values,
+	})
+}
+
+func (ts *tester) deleteHelmPrometheus() error {
+	return helm.Uninstall(helm.InstallConfig{
+		Logger:         ts.cfg.Logger,
+		LogWriter:      ts.cfg.LogWriter,
+		Timeout:        15 * time.Minute,
+		KubeConfigPath: ts.cfg.EKSConfig.KubeConfigPath,
+		Namespace:      ts.cfg.EKSConfig.AddOnPrometheus.Namespace,
+		ChartName:      chartName,
+		ReleaseName:    releaseName,
+		StatusFunc:     func(rs helm.Release) { ts.cfg.EKSConfig.RecordHelmRelease(eksconfig.HelmRelease(rs)) },
+	})
+}
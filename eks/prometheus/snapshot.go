@@ -0,0 +1,97 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// snapshot is the metrics snapshot written at test end,
+// to compare across runs.
+type snapshot struct {
+	ClusterName string                      `json:"cluster-name"`
+	Version     string                      `json:"version"`
+	Time        time.Time                   `json:"time"`
+	Metrics     map[string][]snapshotSample `json:"metrics"`
+	// Errors maps the metric name to its query error, if any.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+type snapshotSample struct {
+	Labels map[string]string `json:"labels,omitempty"`
+	// Value is kept as a string, since it may be "NaN".
+	Value string `json:"value"`
+}
+
+const snapshotFileName = "prometheus-snapshot.json"
+
+func (ts *tester) Snapshot(dir string) error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnPrometheus() || !ts.cfg.EKSConfig.AddOnPrometheus.Created {
+		ts.cfg.Logger.Info("skipping Prometheus snapshot", zap.String("tester", pkgName))
+		return nil
+	}
+	cur := ts.cfg.EKSConfig.AddOnPrometheus
+	ts.cfg.Logger.Info("snapshotting metrics", zap.Int("queries", len(cur.SnapshotQueries)))
+
+	names := make([]string, 0, len(cur.SnapshotQueries))
+	for name := range cur.SnapshotQueries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	snap := snapshot{
+		ClusterName: ts.cfg.EKSConfig.Name,
+		Version:     ts.cfg.EKSConfig.Version,
+		Time:        time.Now().UTC(),
+		Metrics:     make(map[string][]snapshotSample),
+		Errors:      make(map[string]string),
+	}
+	for _, name := range names {
+		var resp queryResponse
+		err := ts.get("api/v1/query", map[string]string{"query": cur.SnapshotQueries[name]}, &resp)
+		if err == nil && resp.Status != "success" {
+			err = fmt.Errorf("query status %q (%s)", resp.Status, resp.Error)
+		}
+		if err != nil {
+			// record, and keep the rest of the snapshot
+			ts.cfg.Logger.Warn("failed to query metric", zap.String("name", name), zap.Error(err))
+			snap.Errors[name] = err.Error()
+			continue
+		}
+		snap.Metrics[name] = toSnapshotSamples(resp.Data.Result)
+	}
+
+	b, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	p := filepath.Join(dir, snapshotFileName)
+	if err = ioutil.WriteFile(p, b, 0600); err != nil {
+		return err
+	}
+	cur.SnapshotPath = p
+	ts.cfg.EKSConfig.Sync()
+	ts.cfg.Logger.Info("wrote metrics snapshot", zap.String("path", p), zap.Int("errors", len(snap.Errors)))
+	return nil
+}
+
+func toSnapshotSamples(result []sample) []snapshotSample {
+	ss := make([]snapshotSample, 0, len(result))
+	for _, s := range result {
+		v := ""
+		if len(s.Value) == 2 {
+			v = fmt.Sprint(s.Value[1])
+		}
+		ss = append(ss, snapshotSample{Labels: s.Metric, Value: v})
+	}
+	return ss
+}
@@ -0,0 +1,83 @@
+package eks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eks/cost"
+	"github.com/olekukonko/tablewriter"
+	"go.uber.org/zap"
+)
+
+// writeReport writes the test phases recorded so far,
+// as JUnit XML and JSON summary, and the cost and duration summary.
+func (ts *Tester) writeReport() {
+	if ts.report == nil {
+		return
+	}
+	if err := ts.report.WriteJUnitXML(ts.cfg.ReportJUnitXMLPath); err != nil {
+		ts.lg.Warn("failed to write JUnit XML report", zap.Error(err))
+	}
+	if err := ts.report.WriteJSON(ts.cfg.ReportJSONPath); err != nil {
+		ts.lg.Warn("failed to write JSON report", zap.Error(err))
+	}
+	s := ts.report.Summary()
+	ts.lg.Info("wrote report",
+		zap.Int("tests", s.Tests),
+		zap.Int("failures", s.Failures),
+		zap.String("junit-xml-path", ts.cfg.ReportJUnitXMLPath),
+		zap.String("json-path", ts.cfg.ReportJSONPath),
+	)
+
+	buf := bytes.NewBuffer(nil)
+	tb := tablewriter.NewWriter(buf)
+	tb.SetAutoWrapText(false)
+	tb.SetAlignment(tablewriter.ALIGN_LEFT)
+	tb.SetCenterSeparator("*")
+	tb.SetHeader([]string{"class", "phase", "took", "failed"})
+	for _, c := range s.Cases {
+		tb.Append([]string{c.Class, c.Name, c.Took.Round(time.Second).String(), fmt.Sprintf("%v", c.Failure != "")})
+	}
+	tb.SetFooter([]string{"", "total", s.Took.Round(time.Second).String(), fmt.Sprintf("%d", s.Failures)})
+	tb.Render()
+	fmt.Fprintf(ts.logWriter, "\n\nphase durations:\n%s\n", buf.String())
+	for _, c := range s.Cases {
+		if len(c.Annotations) == 0 {
+			continue
+		}
+		fmt.Fprintf(ts.logWriter, "\ncluster health changes during %s/%s:\n", c.Class, c.Name)
+		for _, a := range c.Annotations {
+			fmt.Fprintf(ts.logWriter, "  %s\n", a)
+		}
+	}
+
+	ts.writeCostSummary()
+}
+
+// writeCostSummary estimates the cost of the created AWS resources,
+// and persists the summary for budget tracking.
+func (ts *Tester) writeCostSummary() {
+	ts.cfg.Status.Cost = cost.Estimate(cost.Config{
+		Logger:     ts.lg,
+		EKSConfig:  ts.cfg,
+		PricingAPI: ts.pricingAPI,
+	}, time.Now())
+	ts.cfg.Sync()
+
+	fmt.Fprintf(ts.logWriter, "\n\nestimated cost:\n%s\n", cost.Table(ts.cfg.Status.Cost))
+	b, err := json.MarshalIndent(ts.cfg.Status.Cost, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(ts.cfg.CostSummaryPath, b, 0600)
+	}
+	if err != nil {
+		ts.lg.Warn("failed to write cost summary", zap.Error(err))
+		return
+	}
+	ts.lg.Info("wrote cost summary",
+		zap.Float64("total-usd", ts.cfg.Status.Cost.TotalUSD),
+		zap.String("path", ts.cfg.CostSummaryPath),
+	)
+}
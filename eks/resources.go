@@ -0,0 +1,81 @@
+package eks
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-k8s-tester/eks/leak"
+	"go.uber.org/zap"
+)
+
+func (ts *Tester) leakConfig() leak.Config {
+	return leak.Config{
+		Logger:     ts.lg,
+		EKSConfig:  ts.cfg,
+		TaggingAPI: ts.taggingAPI,
+		EC2APIV2:   ts.ec2APIV2,
+		ELBV2API:   ts.elbv2API,
+		CFNAPIV2:   ts.cfnAPIV2,
+		IAMAPI:     ts.iamAPI,
+		S3API:      ts.s3API,
+		ELBAPI:     ts.elbAPI,
+	}
+}
+
+// recordResources records the inventory of the created AWS resources,
+// to be compared against the leak check after the cluster deletion.
+func (ts *Tester) recordResources() {
+	rs, err := leak.Scan(ts.leakConfig())
+	if err != nil {
+		ts.lg.Warn("failed to record created resources", zap.Error(err))
+		return
+	}
+	ts.cfg.Status.Resources = rs
+	ts.cfg.Sync()
+	ts.lg.Info("recorded created resources", zap.Int("resources", len(rs)))
+	fmt.Fprintf(ts.logWriter, "\n\ncreated resources:\n%s\n", leak.Table(rs))
+}
+
+// ForceDown runs "Down" on a best-effort basis, and then force-deletes
+// all the resources of the run that are left behind (see "eks/leak.ForceDelete"),
+// retrying with exponential backoff and deleting the stuck dependencies
+// (e.g. ENIs, load balancers created by Kubernetes Services).
+// It only fails if any resource is left behind.
+func (ts *Tester) ForceDown() error {
+	derr := ts.Down()
+	if derr != nil {
+		ts.lg.Warn("failed Down; force-deleting the remaining resources", zap.Error(derr))
+	}
+	if ts.cfg.SkipDeleteClusterAndNodes {
+		ts.lg.Warn("SkipDeleteClusterAndNodes 'true'; skipping forced deletion of the cluster resources")
+		return derr
+	}
+
+	fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
+	fmt.Fprintf(ts.logWriter, ts.color("[light_blue]leak.ForceDelete [default](%q)\n"), ts.cfg.ConfigPath)
+	lcfg := ts.leakConfig()
+	rs, err := leak.Scan(lcfg)
+	if err != nil {
+		return fmt.Errorf("failed to scan remaining resources (%v)", err)
+	}
+	if len(rs) == 0 {
+		ts.lg.Info("no resource left behind")
+		return nil
+	}
+	fmt.Fprintf(ts.logWriter, "\n\nremaining resources:\n%s\n", leak.Table(rs))
+
+	err = ts.report.Wrap("down", "leak.ForceDelete", func() error {
+		var ss []string
+		for _, err := range leak.ForceDelete(lcfg, rs) {
+			ss = append(ss, err.Error())
+		}
+		if len(ss) > 0 {
+			return errors.New(strings.Join(ss, ", "))
+		}
+		return nil
+	})()
+	ts.cfg.Sync()
+	ts.writeReport()
+	return err
+}
@@ -0,0 +1,22 @@
+package eks
+
+import "go.uber.org/zap"
+
+// resumable returns the "Up" step function that is skipped if completed
+// by the previous run, and that records the completion on success, so that
+// the "Up" interrupted (e.g. process crash) can be resumed with the same configuration.
+// Steps that set up in-memory states for the following steps (e.g. cluster
+// creation initializing the Kubernetes client) must be idempotent instead.
+func (ts *Tester) resumable(step string, fn func() error) func() error {
+	return func() error {
+		if ts.cfg.IsStepCompleted(step) {
+			ts.lg.Info("step already completed by the previous run; skipping", zap.String("step", step))
+			return nil
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+		ts.cfg.RecordStepCompleted(step)
+		return nil
+	}
+}
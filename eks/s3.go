@@ -1,10 +1,13 @@
 package eks
 
 import (
+	"context"
 	"errors"
+	"os"
 	"path"
 	"path/filepath"
 
+	"github.com/aws/aws-k8s-tester/eksconfig"
 	aws_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
 	"github.com/aws/aws-k8s-tester/pkg/fileutil"
 	"go.uber.org/zap"
@@ -15,7 +18,15 @@ func (ts *Tester) createS3() (err error) {
 		if ts.cfg.S3.BucketName == "" {
 			return errors.New("empty S3 bucket name")
 		}
-		if err = aws_s3.CreateBucket(ts.lg, ts.s3API, ts.cfg.S3.BucketName, ts.cfg.Region, ts.cfg.Name, ts.cfg.S3.BucketLifecycleExpirationDays); err != nil {
+		if err = ts.s3Client.CreateBucket(
+			context.Background(),
+			ts.cfg.S3.BucketName,
+			ts.cfg.Region,
+			ts.cfg.Name,
+			ts.cfg.S3.BucketLifecycleExpirationDays,
+			aws_s3.WithVersioning(ts.cfg.S3.BucketVersioning),
+			aws_s3.WithObjectLock(ts.cfg.S3.BucketObjectLock, ts.cfg.S3.BucketObjectLockRetentionDays),
+		); err != nil {
 			return err
 		}
 	} else {
@@ -37,10 +48,10 @@ func (ts *Tester) deleteS3() error {
 		ts.lg.Info("skipping S3 bucket deletion", zap.String("s3-bucket-name", ts.cfg.S3.BucketName), zap.Bool("s3-bucket-create-keep", ts.cfg.S3.BucketCreateKeep))
 		return nil
 	}
-	if err := aws_s3.EmptyBucket(ts.lg, ts.s3API, ts.cfg.S3.BucketName); err != nil {
+	if err := ts.s3Client.EmptyBucket(context.Background(), ts.cfg.S3.BucketName); err != nil {
 		return err
 	}
-	return aws_s3.DeleteBucket(ts.lg, ts.s3API, ts.cfg.S3.BucketName)
+	return ts.s3Client.DeleteBucket(context.Background(), ts.cfg.S3.BucketName)
 }
 
 func (ts *Tester) uploadToS3() (err error) {
@@ -50,12 +61,56 @@ func (ts *Tester) uploadToS3() (err error) {
 	}
 
 	if fileutil.Exist(ts.cfg.ConfigPath) {
-		if err = aws_s3.Upload(
-			ts.lg,
-			ts.s3API,
+		// upload a snapshot, since add-ons keep syncing the configuration
+		// file while deleting, which would tear the object and fail its checksum
+		snapshotPath := filepath.Join(os.TempDir(), ts.cfg.Name+"-config-snapshot"+filepath.Ext(ts.cfg.ConfigPath))
+		if err = ts.cfg.Snapshot(snapshotPath); err != nil {
+			return err
+		}
+		err = ts.s3Client.Upload(
+			context.Background(),
 			ts.cfg.S3.BucketName,
 			path.Join(ts.cfg.Name, "aws-k8s-tester-eks.config.yaml"),
-			ts.cfg.ConfigPath,
+			snapshotPath,
+		)
+		os.RemoveAll(snapshotPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	artifacts := [][2]string{
+		{ts.cfg.ReportJUnitXMLPath, "aws-k8s-tester-eks.junit.xml"},
+		{ts.cfg.ReportJSONPath, "aws-k8s-tester-eks.report.json"},
+		{ts.cfg.CostSummaryPath, "aws-k8s-tester-eks.cost.json"},
+		{ts.cfg.SoakResultsPath, "aws-k8s-tester-eks.soak.jsonl"},
+		{ts.cfg.Role.PolicyPath, "aws-k8s-tester-eks.role-policy.json"},
+	}
+	if ts.cfg.IsEnabledAddOnNodeGroups() {
+		artifacts = append(artifacts, [2]string{ts.cfg.AddOnNodeGroups.Role.PolicyPath, "aws-k8s-tester-eks.node-group-role-policy.json"})
+	}
+	if ts.cfg.IsEnabledAddOnManagedNodeGroups() {
+		artifacts = append(artifacts, [2]string{ts.cfg.AddOnManagedNodeGroups.Role.PolicyPath, "aws-k8s-tester-eks.managed-node-group-role-policy.json"})
+	}
+	for _, phase := range []string{
+		eksconfig.HookPhasePreCreate,
+		eksconfig.HookPhasePostCreate,
+		eksconfig.HookPhasePreDelete,
+		eksconfig.HookPhasePostDelete,
+	} {
+		for _, h := range ts.cfg.Hooks.Phase(phase) {
+			artifacts = append(artifacts, [2]string{h.OutputPath, path.Join("hooks", filepath.Base(h.OutputPath))})
+		}
+	}
+	for _, kv := range artifacts {
+		if !fileutil.Exist(kv[0]) {
+			continue
+		}
+		if err = ts.s3Client.Upload(
+			context.Background(),
+			ts.cfg.S3.BucketName,
+			path.Join(ts.cfg.Name, kv[1]),
+			kv[0],
 		); err != nil {
 			return err
 		}
@@ -69,9 +124,8 @@ func (ts *Tester) uploadToS3() (err error) {
 		}
 	}
 	if fileutil.Exist(logFilePath) {
-		if err = aws_s3.Upload(
-			ts.lg,
-			ts.s3API,
+		if err = ts.s3Client.Upload(
+			context.Background(),
 			ts.cfg.S3.BucketName,
 			path.Join(ts.cfg.Name, "aws-k8s-tester-eks.log"),
 			logFilePath,
@@ -0,0 +1,65 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+	"k8s.io/client-go/kubernetes"
+)
+
+// APIServerMetrics is the subset of kube-apiserver metrics
+// to measure the Secrets impact.
+type APIServerMetrics struct {
+	// ResidentMemoryBytes is the kube-apiserver resident memory in bytes.
+	ResidentMemoryBytes uint64
+	// DEKGenerations is the number of envelope encryption data key
+	// generations, which increases on every encrypted write
+	// when the KMS envelope encryption is enabled.
+	DEKGenerations uint64
+}
+
+const (
+	metricResidentMemory = "process_resident_memory_bytes"
+	// https://github.com/kubernetes/kubernetes/blob/master/CHANGELOG/CHANGELOG-1.17.md#deprecatedchanged-metrics
+	metricDEKGen             = "apiserver_storage_data_key_generation_duration_seconds"
+	metricDEKGenMicroSeconds = "apiserver_storage_data_key_generation_latencies_microseconds"
+)
+
+// FetchAPIServerMetrics scrapes the kube-apiserver "/metrics".
+// With multiple kube-apiserver instances, the metrics are from
+// the one that serves the request.
+func FetchAPIServerMetrics(cli kubernetes.Interface) (APIServerMetrics, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	b, err := cli.CoreV1().RESTClient().Get().RequestURI("/metrics").Do(ctx).Raw()
+	cancel()
+	if err != nil {
+		return APIServerMetrics{}, fmt.Errorf("failed to fetch /metrics (%v)", err)
+	}
+	return parseAPIServerMetrics(b)
+}
+
+func parseAPIServerMetrics(b []byte) (m APIServerMetrics, err error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(b))
+	if err != nil {
+		return APIServerMetrics{}, fmt.Errorf("failed to parse /metrics (%v)", err)
+	}
+	if mf, ok := families[metricResidentMemory]; ok {
+		for _, v := range mf.GetMetric() {
+			m.ResidentMemoryBytes += uint64(v.GetGauge().GetValue())
+		}
+	}
+	for _, name := range []string{metricDEKGen, metricDEKGenMicroSeconds} {
+		mf, ok := families[name]
+		if !ok {
+			continue
+		}
+		for _, v := range mf.GetMetric() {
+			m.DEKGenerations += v.GetHistogram().GetSampleCount()
+		}
+	}
+	return m, nil
+}
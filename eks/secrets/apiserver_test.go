@@ -0,0 +1,35 @@
+package secrets
+
+import "testing"
+
+func TestParseAPIServerMetrics(t *testing.T) {
+	m, err := parseAPIServerMetrics([]byte(`# HELP process_resident_memory_bytes Resident memory size in bytes.
+# TYPE process_resident_memory_bytes gauge
+process_resident_memory_bytes 1.073741824e+09
+# HELP apiserver_storage_data_key_generation_duration_seconds [ALPHA] Latencies in seconds of data encryption key(DEK) generation operations.
+# TYPE apiserver_storage_data_key_generation_duration_seconds histogram
+apiserver_storage_data_key_generation_duration_seconds_bucket{le="5e-06"} 0
+apiserver_storage_data_key_generation_duration_seconds_bucket{le="+Inf"} 42
+apiserver_storage_data_key_generation_duration_seconds_sum 0.01
+apiserver_storage_data_key_generation_duration_seconds_count 42
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.ResidentMemoryBytes != 1073741824 {
+		t.Fatalf("unexpected resident memory %d", m.ResidentMemoryBytes)
+	}
+	if m.DEKGenerations != 42 {
+		t.Fatalf("unexpected DEK generations %d", m.DEKGenerations)
+	}
+
+	m, err = parseAPIServerMetrics([]byte(`# TYPE process_resident_memory_bytes gauge
+process_resident_memory_bytes 5.24288e+08
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.ResidentMemoryBytes != 524288000 || m.DEKGenerations != 0 {
+		t.Fatalf("unexpected metrics %+v", m)
+	}
+}
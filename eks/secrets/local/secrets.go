@@ -26,6 +26,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/dustin/go-humanize"
 	"go.uber.org/zap"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 )
@@ -50,6 +51,24 @@ func New(cfg Config) eks_tester.Tester {
 	return &tester{cfg: cfg}
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:      "secrets-local",
+		Exclusive: true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				S3API:     cfg.S3API,
+				CWAPI:     cfg.CWAPI,
+			}), cfg.EKSConfig.IsEnabledAddOnSecretsLocal)
+		},
+	})
+}
+
 type tester struct {
 	cfg Config
 }
@@ -65,13 +84,15 @@ func (ts *tester) Create() (err error) {
 	}
 
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
-	ts.cfg.EKSConfig.AddOnSecretsLocal.Created = true
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnSecretsLocal.Created = true
+	})
 	createStart := time.Now()
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnSecretsLocal.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnSecretsLocal.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
 	if err := k8s_client.CreateNamespace(
@@ -107,19 +128,36 @@ func (ts *tester) Create() (err error) {
 		RequestsSummaryReadsTablePath:   ts.cfg.EKSConfig.AddOnSecretsLocal.RequestsSummaryReadsTablePath,
 		RequestsSummaryReadsTableS3Key:  ts.cfg.EKSConfig.AddOnSecretsLocal.RequestsSummaryReadsTableS3Key,
 	})
+
+	before, err := secrets.FetchAPIServerMetrics(ts.cfg.K8SClient.KubernetesClientSet())
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnSecretsLocal.APIServerMemoryBefore = before.ResidentMemoryBytes
+	})
+
 	loader.Start()
 	loader.Stop()
 
 	ts.cfg.Logger.Info("completing secrets local tester")
-	var curWriteLatencies metrics.Durations
-	var curReadLatencies metrics.Durations
-	curWriteLatencies, ts.cfg.EKSConfig.AddOnSecretsLocal.RequestsSummaryWrites, curReadLatencies, ts.cfg.EKSConfig.AddOnSecretsLocal.RequestsSummaryReads, err = loader.CollectMetrics()
-	ts.cfg.EKSConfig.Sync()
+	curWriteLatencies, writes, curReadLatencies, reads, err := loader.CollectMetrics()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnSecretsLocal.RequestsSummaryWrites = writes
+		ts.cfg.EKSConfig.AddOnSecretsLocal.RequestsSummaryReads = reads
+	})
 	if err != nil {
 		ts.cfg.Logger.Warn("failed to get metrics", zap.Error(err))
 		return err
 	}
 
+	if err = ts.checkAPIServer(before); err != nil {
+		return err
+	}
+	if err = ts.mountSecrets(); err != nil {
+		return err
+	}
+
 	if err = ts.checkResults(curWriteLatencies, curReadLatencies); err != nil {
 		return err
 	}
@@ -131,6 +169,76 @@ func (ts *tester) Create() (err error) {
 	return nil
 }
 
+// checkAPIServer records the kube-apiserver memory after the writes,
+// and verifies the writes generate envelope encryption data keys
+// when the cluster is created with the KMS CMK.
+func (ts *tester) checkAPIServer(before secrets.APIServerMetrics) error {
+	after, err := secrets.FetchAPIServerMetrics(ts.cfg.K8SClient.KubernetesClientSet())
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnSecretsLocal.APIServerMemoryAfter = after.ResidentMemoryBytes
+	})
+
+	delta := int64(after.ResidentMemoryBytes) - int64(before.ResidentMemoryBytes)
+	sign := "+"
+	if delta < 0 {
+		sign, delta = "-", -delta
+	}
+	ts.cfg.Logger.Info("kube-apiserver memory",
+		zap.String("before", humanize.Bytes(before.ResidentMemoryBytes)),
+		zap.String("after", humanize.Bytes(after.ResidentMemoryBytes)),
+		zap.String("delta", sign+humanize.Bytes(uint64(delta))),
+	)
+
+	if ts.cfg.EKSConfig.Encryption.CMKARN == "" {
+		ts.cfg.Logger.Info("skipping envelope encryption check; no KMS CMK")
+		return nil
+	}
+	if after.DEKGenerations <= before.DEKGenerations {
+		return fmt.Errorf("no envelope encryption data key generated for Secrets writes (before %d, after %d)", before.DEKGenerations, after.DEKGenerations)
+	}
+	ts.cfg.Logger.Info("verified envelope encryption",
+		zap.Uint64("dek-generations-before", before.DEKGenerations),
+		zap.Uint64("dek-generations-after", after.DEKGenerations),
+	)
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnSecretsLocal.EnvelopeEncryptionVerified = true
+	})
+	return nil
+}
+
+func (ts *tester) mountSecrets() error {
+	cur := ts.cfg.EKSConfig.AddOnSecretsLocal
+	if cur.MountPods == 0 {
+		ts.cfg.Logger.Info("skipping Secrets mount")
+		return nil
+	}
+	names := make([]string, cur.MountPods)
+	for i := range names {
+		names[i] = fmt.Sprintf("%s%d", cur.NamePrefix, i)
+	}
+	took, err := secrets.MountSecrets(secrets.MountConfig{
+		Logger:      ts.cfg.Logger,
+		Stopc:       ts.cfg.Stopc,
+		Client:      ts.cfg.K8SClient.KubernetesClientSet(),
+		Namespace:   cur.Namespace,
+		Image:       cur.MountPodImage,
+		SecretNames: names,
+		ObjectSize:  cur.ObjectSize,
+		Timeout:     10 * time.Minute,
+	})
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.MountLatency = took
+		cur.MountLatencyString = took.String()
+	})
+	return nil
+}
+
 func (ts *tester) Delete() error {
 	if !ts.cfg.EKSConfig.IsEnabledAddOnSecretsLocal() {
 		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
@@ -145,8 +253,9 @@ func (ts *tester) Delete() error {
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnSecretsLocal.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnSecretsLocal.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
 	}()
 
 	var errs []string
@@ -166,8 +275,9 @@ func (ts *tester) Delete() error {
 		return errors.New(strings.Join(errs, ", "))
 	}
 
-	ts.cfg.EKSConfig.AddOnSecretsLocal.Created = false
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnSecretsLocal.Created = false
+	})
 	return nil
 }
 
@@ -197,11 +307,15 @@ func (ts *tester) checkResults(curWriteLatencies metrics.Durations, curReadLaten
 			ts.cfg.Logger.Warn("failed to download results", zap.Error(err))
 			return err
 		}
-		ts.cfg.EKSConfig.AddOnSecretsLocal.RequestsSummaryWritesCompare, err = metrics.CompareRequestsSummary(prevSummary, ts.cfg.EKSConfig.AddOnSecretsLocal.RequestsSummaryWrites)
+		var compare metrics.RequestsCompare
+		compare, err = metrics.CompareRequestsSummary(prevSummary, ts.cfg.EKSConfig.AddOnSecretsLocal.RequestsSummaryWrites)
 		if err != nil {
 			ts.cfg.Logger.Warn("failed to compare results", zap.Error(err))
 			return err
 		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnSecretsLocal.RequestsSummaryWritesCompare = compare
+		})
 		if err = ioutil.WriteFile(ts.cfg.EKSConfig.AddOnSecretsLocal.RequestsSummaryWritesCompareJSONPath, []byte(ts.cfg.EKSConfig.AddOnSecretsLocal.RequestsSummaryWritesCompare.JSON()), 0600); err != nil {
 			ts.cfg.Logger.Warn("failed to write file", zap.Error(err))
 			return err
@@ -325,11 +439,15 @@ func (ts *tester) checkResults(curWriteLatencies metrics.Durations, curReadLaten
 			ts.cfg.Logger.Warn("failed to download results", zap.Error(err))
 			return err
 		}
-		ts.cfg.EKSConfig.AddOnSecretsLocal.RequestsSummaryReadsCompare, err = metrics.CompareRequestsSummary(prevSummary, ts.cfg.EKSConfig.AddOnSecretsLocal.RequestsSummaryReads)
+		var compare metrics.RequestsCompare
+		compare, err = metrics.CompareRequestsSummary(prevSummary, ts.cfg.EKSConfig.AddOnSecretsLocal.RequestsSummaryReads)
 		if err != nil {
 			ts.cfg.Logger.Warn("failed to compare results", zap.Error(err))
 			return err
 		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnSecretsLocal.RequestsSummaryReadsCompare = compare
+		})
 		if err = ioutil.WriteFile(ts.cfg.EKSConfig.AddOnSecretsLocal.RequestsSummaryReadsCompareJSONPath, []byte(ts.cfg.EKSConfig.AddOnSecretsLocal.RequestsSummaryReadsCompare.JSON()), 0600); err != nil {
 			ts.cfg.Logger.Warn("failed to write file", zap.Error(err))
 			return err
@@ -0,0 +1,128 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// MountConfig defines the Pods that mount the written Secrets.
+type MountConfig struct {
+	Logger *zap.Logger
+	Stopc  chan struct{}
+	Client kubernetes.Interface
+
+	Namespace string
+	Image     string
+	// SecretNames are the Secrets to mount, one Secret per Pod.
+	// The Secret data key is the Secret name.
+	SecretNames []string
+	// ObjectSize is the expected Secret value size in bytes.
+	ObjectSize int
+	Timeout    time.Duration
+}
+
+const mountPodLabel = "secrets-mount"
+
+// MountSecrets creates a Pod per Secret which verifies the mounted
+// Secret value size, and waits until all Pods succeed.
+// It returns the time for all Pods to succeed.
+func MountSecrets(cfg MountConfig) (time.Duration, error) {
+	cfg.Logger.Info("creating Secrets mount Pods", zap.Int("pods", len(cfg.SecretNames)))
+	start := time.Now()
+	for i, name := range cfg.SecretNames {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		_, err := cfg.Client.CoreV1().Pods(cfg.Namespace).Create(ctx, newMountPod(cfg, i, name), metav1.CreateOptions{})
+		cancel()
+		if err != nil {
+			return 0, fmt.Errorf("failed to create Secrets mount Pod (%v)", err)
+		}
+	}
+
+	for time.Since(start) < cfg.Timeout {
+		select {
+		case <-cfg.Stopc:
+			return 0, errors.New("Secrets mount Pods wait aborted")
+		case <-time.After(5 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		pods, err := cfg.Client.CoreV1().Pods(cfg.Namespace).List(ctx, metav1.ListOptions{LabelSelector: "app.kubernetes.io/name=" + mountPodLabel})
+		cancel()
+		if err != nil {
+			cfg.Logger.Warn("failed to list Secrets mount Pods", zap.Error(err))
+			continue
+		}
+		succeeded := 0
+		for _, pod := range pods.Items {
+			switch pod.Status.Phase {
+			case v1.PodFailed:
+				return 0, fmt.Errorf("Secrets mount Pod %q failed (unexpected Secret value)", pod.Name)
+			case v1.PodSucceeded:
+				succeeded++
+			}
+		}
+		cfg.Logger.Info("polled Secrets mount Pods", zap.Int("succeeded", succeeded), zap.Int("pods", len(cfg.SecretNames)))
+		if succeeded == len(cfg.SecretNames) {
+			took := time.Since(start)
+			cfg.Logger.Info("Secrets mount Pods succeeded", zap.Duration("took", took))
+			return took, nil
+		}
+	}
+	return 0, fmt.Errorf("Secrets mount Pods did not succeed in %v", cfg.Timeout)
+}
+
+func newMountPod(cfg MountConfig, idx int, secretName string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%d", mountPodLabel, idx),
+			Namespace: cfg.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name": mountPodLabel,
+			},
+		},
+		Spec: v1.PodSpec{
+			RestartPolicy: v1.RestartPolicyNever,
+			Containers: []v1.Container{
+				{
+					Name:  mountPodLabel,
+					Image: cfg.Image,
+					Command: []string{
+						"/bin/sh",
+						"-c",
+						fmt.Sprintf("[ $(wc -c < /secret/%s) -eq %d ]", secretName, cfg.ObjectSize),
+					},
+					VolumeMounts: []v1.VolumeMount{
+						{
+							Name:      "secret",
+							MountPath: "/secret",
+							ReadOnly:  true,
+						},
+					},
+				},
+			},
+			Volumes: []v1.Volume{
+				{
+					Name: "secret",
+					VolumeSource: v1.VolumeSource{
+						Secret: &v1.SecretVolumeSource{
+							SecretName:  secretName,
+							DefaultMode: aws.Int32(0400),
+						},
+					},
+				},
+			},
+			NodeSelector: map[string]string{
+				// do not deploy in fake nodes, obviously
+				"NodeType": "regular",
+			},
+		},
+	}
+}
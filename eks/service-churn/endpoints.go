@@ -0,0 +1,123 @@
+package servicechurn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// endpointCond returns true if the endpoints of the Service
+// reflect the change.
+type endpointCond func(svc string, eps []discoveryv1.Endpoint) bool
+
+// readyOnly returns the condition that the Service has the Ready endpoints,
+// all from the backends of the color.
+func readyOnly(color string) endpointCond {
+	return func(svc string, eps []discoveryv1.Endpoint) bool {
+		prefix := backendName(svc, color) + "-"
+		ready := 0
+		for _, ep := range eps {
+			if !isReady(ep) {
+				continue
+			}
+			if ep.TargetRef == nil || !strings.HasPrefix(ep.TargetRef.Name, prefix) {
+				return false
+			}
+			ready++
+		}
+		return ready > 0
+	}
+}
+
+// noneReady is the condition that the Service has no Ready endpoint.
+func noneReady(_ string, eps []discoveryv1.Endpoint) bool {
+	for _, ep := range eps {
+		if isReady(ep) {
+			return false
+		}
+	}
+	return true
+}
+
+// isReady returns true if the endpoint is Ready,
+// where nil is interpreted as Ready.
+func isReady(ep discoveryv1.Endpoint) bool {
+	return ep.Conditions.Ready == nil || *ep.Conditions.Ready
+}
+
+// endpointsByService groups the endpoints of the EndpointSlices by Service.
+func endpointsByService(slices map[string]*discoveryv1.EndpointSlice) map[string][]discoveryv1.Endpoint {
+	eps := make(map[string][]discoveryv1.Endpoint)
+	for _, s := range slices {
+		svc := s.Labels[discoveryv1.LabelServiceName]
+		if svc == "" {
+			continue
+		}
+		eps[svc] = append(eps[svc], s.Endpoints...)
+	}
+	return eps
+}
+
+// watchEndpoints watches the EndpointSlices until "cond" holds for all
+// Services, and returns when "cond" first held per Service.
+// "readyc" is closed once the watch is established, before the change.
+func (ts *tester) watchEndpoints(names []string, cond endpointCond, readyc chan<- struct{}, timeout time.Duration) (map[string]time.Time, error) {
+	cli := ts.cfg.K8SClient.KubernetesClientSet().DiscoveryV1().EndpointSlices(ts.cfg.EKSConfig.AddOnServiceChurn.Namespace)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	list, err := cli.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list EndpointSlices (%v)", err)
+	}
+	slices := make(map[string]*discoveryv1.EndpointSlice)
+	for i := range list.Items {
+		slices[list.Items[i].Name] = &list.Items[i]
+	}
+	w, err := cli.Watch(ctx, metav1.ListOptions{ResourceVersion: list.ResourceVersion})
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch EndpointSlices (%v)", err)
+	}
+	defer w.Stop()
+	close(readyc)
+
+	changed := make(map[string]time.Time)
+	for len(changed) < len(names) {
+		select {
+		case <-ts.cfg.Stopc:
+			return changed, errors.New("EndpointSlices watch aborted")
+		case <-ctx.Done():
+			return changed, fmt.Errorf("%d of %d Services' endpoints not changed in %v", len(names)-len(changed), len(names), timeout)
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				return changed, errors.New("EndpointSlices watch closed")
+			}
+			s, ok := ev.Object.(*discoveryv1.EndpointSlice)
+			if !ok {
+				continue
+			}
+			switch ev.Type {
+			case watch.Added, watch.Modified:
+				slices[s.Name] = s
+			case watch.Deleted:
+				delete(slices, s.Name)
+			default:
+				continue
+			}
+			now := time.Now()
+			eps := endpointsByService(slices)
+			for _, svc := range names {
+				if _, ok := changed[svc]; !ok && cond(svc, eps[svc]) {
+					changed[svc] = now
+				}
+			}
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,67 @@
+package servicechurn
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEndpointConds(t *testing.T) {
+	ready, notReady := true, false
+	ep := func(pod string, r *bool) discoveryv1.Endpoint {
+		return discoveryv1.Endpoint{
+			TargetRef:  &v1.ObjectReference{Kind: "Pod", Name: pod},
+			Conditions: discoveryv1.EndpointConditions{Ready: r},
+		}
+	}
+	svc := "churn-01-000"
+
+	tt := []struct {
+		eps   []discoveryv1.Endpoint
+		blue  bool
+		green bool
+		none  bool
+	}{
+		{eps: nil, none: true},
+		{eps: []discoveryv1.Endpoint{ep("churn-01-000-blue-abc-1", &ready)}, blue: true},
+		{eps: []discoveryv1.Endpoint{ep("churn-01-000-blue-abc-1", nil)}, blue: true},
+		{eps: []discoveryv1.Endpoint{ep("churn-01-000-blue-abc-1", &notReady)}, none: true},
+		{eps: []discoveryv1.Endpoint{ep("churn-01-000-blue-abc-1", &ready), ep("churn-01-000-green-def-1", &ready)}},
+		{eps: []discoveryv1.Endpoint{ep("churn-01-000-blue-abc-1", &notReady), ep("churn-01-000-green-def-1", &ready)}, green: true},
+	}
+	for i, tv := range tt {
+		if v := readyOnly(colorBlue)(svc, tv.eps); v != tv.blue {
+			t.Fatalf("#%d: expected blue %v, got %v", i, tv.blue, v)
+		}
+		if v := readyOnly(colorGreen)(svc, tv.eps); v != tv.green {
+			t.Fatalf("#%d: expected green %v, got %v", i, tv.green, v)
+		}
+		if v := noneReady(svc, tv.eps); v != tv.none {
+			t.Fatalf("#%d: expected none %v, got %v", i, tv.none, v)
+		}
+	}
+}
+
+func TestEndpointsByService(t *testing.T) {
+	slice := func(name string, svc string, n int) *discoveryv1.EndpointSlice {
+		s := &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}},
+			Endpoints:  make([]discoveryv1.Endpoint, n),
+		}
+		if svc != "" {
+			s.Labels[discoveryv1.LabelServiceName] = svc
+		}
+		return s
+	}
+	eps := endpointsByService(map[string]*discoveryv1.EndpointSlice{
+		"a-1": slice("a-1", "a", 2),
+		"a-2": slice("a-2", "a", 1),
+		"b-1": slice("b-1", "b", 0),
+		"x":   slice("x", "", 3),
+	})
+	if len(eps) != 2 || len(eps["a"]) != 3 || len(eps["b"]) != 0 {
+		t.Fatalf("unexpected endpoints %v", eps)
+	}
+}
@@ -0,0 +1,93 @@
+package servicechurn
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-k8s-tester/ssh"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// selectNodes selects the random subset of the Ready nodes
+// to probe the data-path, and returns their instance IDs.
+func (ts *tester) selectNodes() ([]string, error) {
+	cur := ts.cfg.EKSConfig.AddOnServiceChurn
+	opts := metav1.ListOptions{}
+	if cur.NodeGroupName != "" {
+		opts.LabelSelector = "NGName=" + cur.NodeGroupName
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	nodes, err := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Nodes().List(ctx, opts)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes (%v)", err)
+	}
+
+	var ids []string
+	for _, node := range nodes.Items {
+		if !isNodeReady(node) || node.Spec.ProviderID == "" {
+			continue
+		}
+		// e.g. "aws:///us-west-2a/i-0123456789abcdef0"
+		ids = append(ids, node.Spec.ProviderID[strings.LastIndex(node.Spec.ProviderID, "/")+1:])
+	}
+	if len(ids) < cur.Nodes {
+		return nil, fmt.Errorf("%d Ready node(s) found, fewer than %d", len(ids), cur.Nodes)
+	}
+	rand.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+	ids = ids[:cur.Nodes]
+	ts.cfg.Logger.Info("selected probe nodes", zap.Strings("instance-ids", ids))
+	return ids, nil
+}
+
+// newNode returns the node to run the probes on, with the configured transport.
+func (ts *tester) newNode(pool *ssh.Pool, instanceID string) (ssh.Node, error) {
+	if ts.cfg.EKSConfig.AddOnServiceChurn.Transport == eksconfig.NodeTransportSSM {
+		return ssh.NewSSMNode(ts.cfg.SSMAPIV2, instanceID), nil
+	}
+
+	inst, ok := ts.cfg.EKSConfig.Instance(instanceID)
+	if !ok {
+		return nil, fmt.Errorf("instance %q not found in the node groups", instanceID)
+	}
+	sh, err := pool.Get(instanceID, ssh.Config{
+		Logger:        ts.cfg.Logger,
+		KeyPath:       ts.cfg.EKSConfig.RemoteAccessPrivateKeyPath,
+		PublicIP:      inst.PublicIP,
+		PublicDNSName: inst.PublicDNSName,
+		PrivateIP:     inst.PrivateIP,
+		UserName:      inst.RemoteAccessUserName,
+		InstanceConnect: ssh.NewInstanceConnect(
+			ts.cfg.EC2InstanceConnectAPI,
+			instanceID,
+			inst.Placement.AvailabilityZone,
+		),
+		InstanceID:         instanceID,
+		HostKeys:           ts.cfg.SSHHostKeys,
+		InsecureSkipVerify: ts.cfg.SSHHostKeys == nil,
+		ProxyJump: ssh.NewProxyJump(
+			ts.cfg.EKSConfig.RemoteAccessBastionHost,
+			ts.cfg.EKSConfig.RemoteAccessBastionUserName,
+			ts.cfg.EKSConfig.RemoteAccessBastionPrivateKeyPath,
+		),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewSSHNode(instanceID, sh), nil
+}
+
+func isNodeReady(node v1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == v1.NodeReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package servicechurn
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/metrics"
+	"github.com/aws/aws-k8s-tester/ssh"
+	"go.uber.org/zap"
+)
+
+// probeDown is the expected probe result when the Service has no backend.
+const probeDown = "down"
+
+// probeScript returns the node shell script, which requests each ClusterIP
+// in the background until the response is "expect" (or fails for
+// "probeDown"), and prints:
+//
+//	started <epoch-ms>
+//	converged <cluster-ip> <epoch-ms>
+//	timeout <cluster-ip>
+func probeScript(ips []string, expect string, timeout time.Duration) string {
+	cond := `[ "$ok" = 0 ]`
+	if expect != probeDown {
+		cond = fmt.Sprintf(`[ "$ok" = 1 ] && [ "$body" = %q ]`, expect)
+	}
+	return fmt.Sprintf(`echo "started $(date +%%s%%3N)"
+end=$(( $(date +%%s) + %d ))
+probe() {
+  while [ "$(date +%%s)" -lt "$end" ]; do
+    if body=$(curl -s -m 1 "http://$1:%d/" 2>/dev/null); then ok=1; else ok=0; fi
+    if %s; then echo "converged $1 $(date +%%s%%3N)"; return; fi
+    sleep 0.05
+  done
+  echo "timeout $1"
+}
+for ip in %s; do probe "$ip" & done
+wait
+`,
+		int(timeout.Seconds()),
+		servicePort,
+		cond,
+		strings.Join(ips, " "),
+	)
+}
+
+type probeOutput struct {
+	started   time.Time
+	converged map[string]time.Time
+	timeouts  []string
+}
+
+func parseProbe(b []byte) (po probeOutput, err error) {
+	po.converged = make(map[string]time.Time)
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case len(fields) == 2 && fields[0] == "started":
+			ms, perr := strconv.ParseInt(fields[1], 10, 64)
+			if perr != nil {
+				return po, fmt.Errorf("invalid probe output %q (%v)", scanner.Text(), perr)
+			}
+			po.started = time.UnixMilli(ms)
+		case len(fields) == 3 && fields[0] == "converged":
+			ms, perr := strconv.ParseInt(fields[2], 10, 64)
+			if perr != nil {
+				return po, fmt.Errorf("invalid probe output %q (%v)", scanner.Text(), perr)
+			}
+			po.converged[fields[1]] = time.UnixMilli(ms)
+		case len(fields) == 2 && fields[0] == "timeout":
+			po.timeouts = append(po.timeouts, fields[1])
+		}
+	}
+	if po.started.IsZero() {
+		return po, errors.New("probe start not found in the output")
+	}
+	sort.Strings(po.timeouts)
+	return po, nil
+}
+
+type phaseSamples struct {
+	endpoint    metrics.Durations
+	convergence metrics.Durations
+	timeouts    int
+	errs        []string
+}
+
+// runPhase starts the node probes and the EndpointSlice watch, applies
+// the change after "ProbeStartDelay", and measures the endpoint and
+// the data-path convergence latencies.
+func (ts *tester) runPhase(phase string, names []string, ips map[string]string, nodes []ssh.Node, expect string, cond endpointCond, change func() error) (ps phaseSamples, err error) {
+	cur := ts.cfg.EKSConfig.AddOnServiceChurn
+	timeout := cur.ProbeStartDelay + cur.ConvergenceTimeout
+
+	type watchResult struct {
+		changed map[string]time.Time
+		err     error
+	}
+	readyc := make(chan struct{})
+	watchc := make(chan watchResult, 1)
+	go func() {
+		changed, err := ts.watchEndpoints(names, cond, readyc, timeout)
+		watchc <- watchResult{changed: changed, err: err}
+	}()
+	select {
+	case <-readyc:
+	case wr := <-watchc:
+		return ps, wr.err
+	}
+
+	svcByIP := make(map[string]string, len(ips))
+	probeIPs := make([]string, 0, len(names))
+	for _, svc := range names {
+		svcByIP[ips[svc]] = svc
+		probeIPs = append(probeIPs, ips[svc])
+	}
+	script := probeScript(probeIPs, expect, timeout)
+	type probeResult struct {
+		instanceID string
+		out        []byte
+		err        error
+	}
+	probec := make(chan probeResult, len(nodes))
+	for _, n := range nodes {
+		go func(n ssh.Node) {
+			out, err := n.Exec(script, ssh.WithTimeout(timeout+time.Minute))
+			probec <- probeResult{instanceID: n.InstanceID(), out: out, err: err}
+		}(n)
+	}
+
+	ts.cfg.Logger.Info("started node probes", zap.String("phase", phase), zap.String("expect", expect), zap.Int("nodes", len(nodes)))
+	select {
+	case <-ts.cfg.Stopc:
+		return ps, errors.New("service churn aborted")
+	case <-time.After(cur.ProbeStartDelay):
+	}
+	changeAt := time.Now()
+	if err = change(); err != nil {
+		return ps, err
+	}
+
+	wr := <-watchc
+	if wr.err != nil {
+		ps.errs = append(ps.errs, wr.err.Error())
+	}
+	for _, svc := range names {
+		if at, ok := wr.changed[svc]; ok {
+			ps.endpoint = append(ps.endpoint, at.Sub(changeAt))
+		}
+	}
+
+	for range nodes {
+		pr := <-probec
+		if pr.err != nil {
+			ps.errs = append(ps.errs, fmt.Sprintf("probe on %q failed (%v)", pr.instanceID, pr.err))
+			continue
+		}
+		po, perr := parseProbe(pr.out)
+		if perr != nil {
+			ps.errs = append(ps.errs, fmt.Sprintf("probe on %q failed (%v)", pr.instanceID, perr))
+			continue
+		}
+		if po.started.After(changeAt) {
+			ps.errs = append(ps.errs, fmt.Sprintf("probe on %q started %v after the change; increase ProbeStartDelay", pr.instanceID, po.started.Sub(changeAt)))
+			continue
+		}
+		for ip, at := range po.converged {
+			epAt, ok := wr.changed[svcByIP[ip]]
+			if !ok {
+				continue
+			}
+			d := at.Sub(epAt)
+			if d < 0 {
+				// data-path converged before the watch event arrived
+				d = 0
+			}
+			ps.convergence = append(ps.convergence, d)
+		}
+		ps.timeouts += len(po.timeouts)
+		if len(po.timeouts) > 0 {
+			ps.errs = append(ps.errs, fmt.Sprintf("%d Service(s) not converged on %q (%s)", len(po.timeouts), pr.instanceID, strings.Join(po.timeouts, ", ")))
+		}
+	}
+	ts.cfg.Logger.Info("finished phase",
+		zap.String("phase", phase),
+		zap.Int("endpoint-samples", len(ps.endpoint)),
+		zap.Int("convergence-samples", len(ps.convergence)),
+		zap.Int("timeouts", ps.timeouts),
+	)
+	return ps, nil
+}
+
+// summarize returns the latency summary, counting the timeouts as failures.
+func summarize(testID string, ds metrics.Durations, failures int) metrics.RequestsSummary {
+	sort.Sort(ds)
+	return metrics.RequestsSummary{
+		TestID:        testID,
+		SuccessTotal:  float64(len(ds)),
+		FailureTotal:  float64(failures),
+		LantencyP50:   ds.PickLantencyP50(),
+		LantencyP90:   ds.PickLantencyP90(),
+		LantencyP99:   ds.PickLantencyP99(),
+		LantencyP999:  ds.PickLantencyP999(),
+		LantencyP9999: ds.PickLantencyP9999(),
+	}
+}
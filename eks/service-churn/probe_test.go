@@ -0,0 +1,53 @@
+package servicechurn
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProbeScript(t *testing.T) {
+	script := probeScript([]string{"10.100.0.10", "10.100.0.11"}, "blue", 90*time.Second)
+	if !strings.Contains(script, "end=$(( $(date +%s) + 90 ))") {
+		t.Fatalf("unexpected timeout %q", script)
+	}
+	if !strings.Contains(script, `if [ "$ok" = 1 ] && [ "$body" = "blue" ]; then`) {
+		t.Fatalf("unexpected condition %q", script)
+	}
+	if !strings.Contains(script, `curl -s -m 1 "http://$1:80/"`) || !strings.Contains(script, "for ip in 10.100.0.10 10.100.0.11; do") {
+		t.Fatalf("unexpected probe %q", script)
+	}
+
+	script = probeScript([]string{"10.100.0.10"}, probeDown, time.Minute)
+	if !strings.Contains(script, `if [ "$ok" = 0 ]; then`) {
+		t.Fatalf("unexpected condition %q", script)
+	}
+}
+
+func TestParseProbe(t *testing.T) {
+	out := `started 1700000000000
+converged 10.100.0.11 1700000031250
+timeout 10.100.0.12
+converged 10.100.0.10 1700000030500
+`
+	po, err := parseProbe([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !po.started.Equal(time.UnixMilli(1700000000000)) {
+		t.Fatalf("unexpected started %v", po.started)
+	}
+	if len(po.converged) != 2 || po.converged["10.100.0.11"].Sub(po.started) != 31250*time.Millisecond {
+		t.Fatalf("unexpected converged %v", po.converged)
+	}
+	if len(po.timeouts) != 1 || po.timeouts[0] != "10.100.0.12" {
+		t.Fatalf("unexpected timeouts %v", po.timeouts)
+	}
+
+	if _, err = parseProbe([]byte("converged 10.100.0.10 1700000030500\n")); err == nil {
+		t.Fatal("expected error for missing probe start")
+	}
+	if _, err = parseProbe([]byte("started now\n")); err == nil {
+		t.Fatal("expected error for invalid probe start")
+	}
+}
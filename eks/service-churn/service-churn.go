@@ -0,0 +1,269 @@
+// Package servicechurn repeatedly creates the Services, switches their
+// backends, and deletes the backends, to stress kube-proxy and
+// the EndpointSlice controller, and measures the time from each
+// EndpointSlice update to the data-path convergence on the nodes,
+// probed with the node commands over SSM Run Command or SSH.
+package servicechurn
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"github.com/aws/aws-k8s-tester/ssh"
+	aws_ssm_v2 "github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ec2instanceconnect/ec2instanceconnectiface"
+	"go.uber.org/zap"
+)
+
+// Config defines Service churn configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+
+	SSMAPIV2              *aws_ssm_v2.Client
+	EC2InstanceConnectAPI ec2instanceconnectiface.EC2InstanceConnectAPI
+	SSHHostKeys           *ssh.HostKeys
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new Service churn tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:      "service-churn",
+		Exclusive: true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:                cfg.Logger,
+				LogWriter:             cfg.LogWriter,
+				Stopc:                 cfg.Stopc,
+				EKSConfig:             cfg.EKSConfig,
+				K8SClient:             cfg.K8SClient,
+				SSMAPIV2:              cfg.SSMAPIV2,
+				EC2InstanceConnectAPI: cfg.EC2InstanceConnectAPI,
+				SSHHostKeys:           cfg.SSHHostKeys,
+			}), cfg.EKSConfig.IsEnabledAddOnServiceChurn)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+var phases = []string{
+	eksconfig.ServiceChurnPhaseCreate,
+	eksconfig.ServiceChurnPhaseUpdate,
+	eksconfig.ServiceChurnPhaseDelete,
+}
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnServiceChurn() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnServiceChurn.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnServiceChurn.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnServiceChurn.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	cur := ts.cfg.EKSConfig.AddOnServiceChurn
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		cur.Namespace,
+	); err != nil {
+		return err
+	}
+	ids, err := ts.selectNodes()
+	if err != nil {
+		return err
+	}
+	pool := ssh.NewPool(ts.cfg.Logger)
+	defer pool.Close()
+	nodes := make([]ssh.Node, 0, len(ids))
+	for _, id := range ids {
+		n, err := ts.newNode(pool, id)
+		if err != nil {
+			return fmt.Errorf("failed to connect to %q (%v)", id, err)
+		}
+		nodes = append(nodes, n)
+	}
+
+	samples := make(map[string]*phaseSamples, len(phases))
+	for _, phase := range phases {
+		samples[phase] = &phaseSamples{}
+	}
+	for round := 1; round <= cur.Rounds; round++ {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("service churn aborted")
+		default:
+		}
+		fmt.Fprintf(ts.cfg.LogWriter, "\n[%d/%d] churning %d Services\n", round, cur.Rounds, cur.Services)
+		if err = ts.churn(round, nodes, samples); err != nil {
+			return err
+		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			cur.Results = buildResults(samples)
+		})
+		if err = ts.writeResults(); err != nil {
+			return err
+		}
+	}
+
+	var errs []string
+	for _, rs := range cur.Results {
+		fmt.Fprintf(ts.cfg.LogWriter, "\n%q endpoint latency:\n%s\n%q data-path convergence latency:\n%s\n",
+			rs.Phase, rs.EndpointLatency.Table(), rs.Phase, rs.ConvergenceLatency.Table())
+		for _, e := range rs.Errors {
+			errs = append(errs, fmt.Sprintf("%s: %s", rs.Phase, e))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// churn creates the Services of the round, runs the phases in order,
+// and deletes the Services.
+func (ts *tester) churn(round int, nodes []ssh.Node, samples map[string]*phaseSamples) error {
+	cur := ts.cfg.EKSConfig.AddOnServiceChurn
+	names, ips, err := ts.createRound(round)
+	if err != nil {
+		return err
+	}
+	if err = ts.waitBackends(round, colorGreen, 5*time.Minute); err != nil {
+		return err
+	}
+
+	backends := int32(cur.BackendsPerService)
+	for _, phase := range phases {
+		var (
+			expect string
+			cond   endpointCond
+			change func() error
+		)
+		switch phase {
+		case eksconfig.ServiceChurnPhaseCreate:
+			expect, cond = colorBlue, readyOnly(colorBlue)
+			change = func() error { return ts.scaleBackends(names, colorBlue, backends) }
+		case eksconfig.ServiceChurnPhaseUpdate:
+			expect, cond = colorGreen, readyOnly(colorGreen)
+			change = func() error { return ts.switchSelectors(names, colorGreen) }
+		case eksconfig.ServiceChurnPhaseDelete:
+			expect, cond = probeDown, noneReady
+			change = func() error { return ts.scaleBackends(names, colorGreen, 0) }
+		}
+		ps, err := ts.runPhase(phase, names, ips, nodes, expect, cond, change)
+		if err != nil {
+			return fmt.Errorf("round %d %q phase failed (%v)", round, phase, err)
+		}
+		all := samples[phase]
+		all.endpoint = append(all.endpoint, ps.endpoint...)
+		all.convergence = append(all.convergence, ps.convergence...)
+		all.timeouts += ps.timeouts
+		for _, e := range ps.errs {
+			all.errs = append(all.errs, fmt.Sprintf("round %d: %s", round, e))
+		}
+	}
+
+	return ts.deleteRound(round, names)
+}
+
+func buildResults(samples map[string]*phaseSamples) (rss []eksconfig.ServiceChurnResult) {
+	testID := time.Now().UTC().Format(time.RFC3339Nano)
+	for _, phase := range phases {
+		ps := samples[phase]
+		rss = append(rss, eksconfig.ServiceChurnResult{
+			Phase:              phase,
+			EndpointLatency:    summarize(testID, ps.endpoint, 0),
+			ConvergenceLatency: summarize(testID, ps.convergence, ps.timeouts),
+			Timeouts:           ps.timeouts,
+			Errors:             ps.errs,
+		})
+	}
+	return rss
+}
+
+func (ts *tester) writeResults() error {
+	cur := ts.cfg.EKSConfig.AddOnServiceChurn
+	b, err := json.MarshalIndent(cur.Results, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(cur.ResultsJSONPath, b, 0600); err != nil {
+		return err
+	}
+	ts.cfg.Logger.Info("wrote service churn results", zap.String("path", cur.ResultsJSONPath))
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnServiceChurn() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnServiceChurn.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnServiceChurn.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	if err := k8s_client.DeleteNamespaceAndWait(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnServiceChurn.Namespace,
+		k8s_client.DefaultNamespaceDeletionInterval,
+		k8s_client.DefaultNamespaceDeletionTimeout,
+		k8s_client.WithForceDelete(true),
+	); err != nil {
+		return fmt.Errorf("failed to delete service churn namespace (%v)", err)
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnServiceChurn.Created = false
+	})
+	return nil
+}
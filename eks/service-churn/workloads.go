@@ -0,0 +1,238 @@
+package servicechurn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"github.com/aws/aws-sdk-go/aws"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	serverPort  = 8080
+	servicePort = 80
+
+	// each Service switches from the "blue" to the "green" backends
+	colorBlue  = "blue"
+	colorGreen = "green"
+
+	labelName  = "app.kubernetes.io/name"
+	labelColor = "app.kubernetes.io/version"
+	labelRound = "service-churn-round"
+)
+
+func serviceName(round int, idx int) string {
+	return fmt.Sprintf("churn-%02d-%03d", round, idx)
+}
+
+func backendName(svc string, color string) string {
+	return svc + "-" + color
+}
+
+// createRound creates the Services selecting the "blue" backends,
+// the "blue" Deployments with no replica, and the "green" Deployments
+// (not selected yet), and returns the Service names and ClusterIPs.
+func (ts *tester) createRound(round int) (names []string, ips map[string]string, err error) {
+	cur := ts.cfg.EKSConfig.AddOnServiceChurn
+	cli := ts.cfg.K8SClient.KubernetesClientSet()
+	roundLabel := fmt.Sprintf("%02d", round)
+
+	ts.cfg.Logger.Info("creating Services", zap.Int("round", round), zap.Int("services", cur.Services))
+	ips = make(map[string]string)
+	for i := 0; i < cur.Services; i++ {
+		name := serviceName(round, i)
+		for _, color := range []string{colorBlue, colorGreen} {
+			replicas := int32(0)
+			if color == colorGreen {
+				replicas = int32(cur.BackendsPerService)
+			}
+			if err = ts.createBackend(name, color, roundLabel, replicas); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		svc, err := cli.CoreV1().Services(cur.Namespace).Create(ctx, &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: cur.Namespace,
+				Labels:    map[string]string{labelRound: roundLabel},
+			},
+			Spec: v1.ServiceSpec{
+				Type:     v1.ServiceTypeClusterIP,
+				Selector: map[string]string{labelName: name, labelColor: colorBlue},
+				Ports: []v1.ServicePort{
+					{
+						Protocol:   v1.ProtocolTCP,
+						Port:       servicePort,
+						TargetPort: intstr.FromInt(serverPort),
+					},
+				},
+			},
+		}, metav1.CreateOptions{})
+		cancel()
+		if apierrs.IsAlreadyExists(err) {
+			ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+			svc, err = cli.CoreV1().Services(cur.Namespace).Get(ctx, name, metav1.GetOptions{})
+			cancel()
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create Service %q (%v)", name, err)
+		}
+		names = append(names, name)
+		ips[name] = svc.Spec.ClusterIP
+	}
+	ts.cfg.Logger.Info("created Services", zap.Int("round", round), zap.Int("services", len(names)))
+	return names, ips, nil
+}
+
+// createBackend creates the Deployment serving its color.
+func (ts *tester) createBackend(svc string, color string, roundLabel string, replicas int32) error {
+	cur := ts.cfg.EKSConfig.AddOnServiceChurn
+	name := backendName(svc, color)
+	labels := map[string]string{labelName: svc, labelColor: color, labelRound: roundLabel}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().AppsV1().Deployments(cur.Namespace).Create(ctx, &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cur.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: v1.PodSpec{
+					// exit right away on scale down, so that the terminating
+					// endpoints do not delay the data-path convergence
+					TerminationGracePeriodSeconds: aws.Int64(0),
+					Containers: []v1.Container{
+						{
+							Name:            "backend",
+							Image:           ts.cfg.EKSConfig.Image(eksconfig.DefaultBusyboxImage),
+							ImagePullPolicy: v1.PullIfNotPresent,
+							Command: []string{
+								"/bin/sh",
+								"-c",
+								fmt.Sprintf("mkdir -p /www && echo %s > /www/index.html && exec httpd -f -p %d -h /www", color, serverPort),
+							},
+							Ports: []v1.ContainerPort{{ContainerPort: serverPort}},
+							ReadinessProbe: &v1.Probe{
+								ProbeHandler: v1.ProbeHandler{
+									HTTPGet: &v1.HTTPGetAction{Path: "/", Port: intstr.FromInt(serverPort)},
+								},
+								PeriodSeconds: 1,
+							},
+						},
+					},
+					NodeSelector: map[string]string{
+						"kubernetes.io/os": "linux",
+					},
+				},
+			},
+		},
+	}, metav1.CreateOptions{})
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Deployment %q (%v)", name, err)
+	}
+	return nil
+}
+
+// waitBackends waits until the Deployments of the color are all available.
+func (ts *tester) waitBackends(round int, color string, timeout time.Duration) error {
+	cur := ts.cfg.EKSConfig.AddOnServiceChurn
+	selector := fmt.Sprintf("%s=%02d,%s=%s", labelRound, round, labelColor, color)
+	waitStart := time.Now()
+	for time.Since(waitStart) < timeout {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("backends wait aborted")
+		case <-time.After(5 * time.Second):
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		dps, err := ts.cfg.K8SClient.KubernetesClientSet().AppsV1().Deployments(cur.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list Deployments", zap.Error(err))
+			continue
+		}
+		available := 0
+		for _, dp := range dps.Items {
+			if dp.Spec.Replicas != nil && dp.Status.AvailableReplicas == *dp.Spec.Replicas && dp.Status.ObservedGeneration >= dp.Generation {
+				available++
+			}
+		}
+		ts.cfg.Logger.Info("polled backends", zap.String("color", color), zap.Int("available", available), zap.Int("services", cur.Services))
+		if available == cur.Services {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s backends of round %d not available in %v", color, round, timeout)
+}
+
+// scaleBackends scales the Deployments of the color.
+func (ts *tester) scaleBackends(names []string, color string, replicas int32) error {
+	dpCli := ts.cfg.K8SClient.KubernetesClientSet().AppsV1().Deployments(ts.cfg.EKSConfig.AddOnServiceChurn.Namespace)
+	for _, svc := range names {
+		patch := fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		_, err := dpCli.Patch(ctx, backendName(svc, color), types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to scale Deployment %q (%v)", backendName(svc, color), err)
+		}
+	}
+	return nil
+}
+
+// switchSelectors switches the Service selectors to the color.
+func (ts *tester) switchSelectors(names []string, color string) error {
+	svcCli := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Services(ts.cfg.EKSConfig.AddOnServiceChurn.Namespace)
+	for _, svc := range names {
+		patch := fmt.Sprintf(`{"spec":{"selector":{%q:%q}}}`, labelColor, color)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		_, err := svcCli.Patch(ctx, svc, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to update Service %q selector (%v)", svc, err)
+		}
+	}
+	return nil
+}
+
+// deleteRound deletes the Services and the Deployments of the round.
+func (ts *tester) deleteRound(round int, names []string) error {
+	cur := ts.cfg.EKSConfig.AddOnServiceChurn
+	cli := ts.cfg.K8SClient.KubernetesClientSet()
+	ts.cfg.Logger.Info("deleting Services", zap.Int("round", round))
+	for _, svc := range names {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		err := cli.CoreV1().Services(cur.Namespace).Delete(ctx, svc, metav1.DeleteOptions{})
+		cancel()
+		if err != nil && !apierrs.IsNotFound(err) {
+			return fmt.Errorf("failed to delete Service %q (%v)", svc, err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := cli.AppsV1().Deployments(cur.Namespace).DeleteCollection(
+		ctx,
+		metav1.DeleteOptions{GracePeriodSeconds: aws.Int64(0)},
+		metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%02d", labelRound, round)},
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to delete Deployments of round %d (%v)", round, err)
+	}
+	return nil
+}
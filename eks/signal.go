@@ -0,0 +1,88 @@
+package eks
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// SignalHook is called on SIGINT or SIGTERM, before the clean up
+// (see "eksconfig.Config.OnSignalCleanup").
+type SignalHook func(sig os.Signal)
+
+// AddSignalHook registers the hook to be called on SIGINT or SIGTERM,
+// so that the embedders can clean up their own states (e.g. flush test
+// results) before the logs are collected and the resources are deleted.
+func (ts *Tester) AddSignalHook(h SignalHook) {
+	ts.signalMu.Lock()
+	ts.signalHooks = append(ts.signalHooks, h)
+	ts.signalMu.Unlock()
+}
+
+// Signaled returns the first signal received, or nil.
+func (ts *Tester) Signaled() os.Signal {
+	ts.signalMu.Lock()
+	defer ts.signalMu.Unlock()
+	return ts.signal
+}
+
+// CleanupOnSignal runs the signal hooks, collects logs, uploads artifacts,
+// and deletes all resources, the same as the tester does on SIGINT or SIGTERM
+// when "OnSignalCleanup" is true. The embedders that handle the signals
+// themselves call this before exiting.
+func (ts *Tester) CleanupOnSignal(sig os.Signal) error {
+	ts.recordSignal(sig)
+	ts.runSignalHooks(sig)
+	ts.recoverStopped()
+	return ts.Down()
+}
+
+// watchSignals stops the creation on the signals. The signal is forwarded
+// to the in-flight "Up" phase if any (see "catchInterrupt"), and "Up"
+// cleans up on return. Otherwise, it cleans up and exits if "OnSignalCleanup"
+// is true. The second signal exits immediately, without waiting for the clean up.
+func (ts *Tester) watchSignals(sigc <-chan os.Signal) {
+	for sig := range sigc {
+		if !ts.recordSignal(sig) {
+			ts.lg.Warn("received OS signal again; exiting without clean up", zap.String("signal", sig.String()))
+			os.Exit(1)
+		}
+		ts.lg.Warn("received OS signal; stopping creation", zap.String("signal", sig.String()))
+		select {
+		case ts.osSig <- sig:
+		default:
+			ts.stopCreation()
+		}
+
+		if atomic.LoadInt32(&ts.upRunning) == 1 || !ts.cfg.OnSignalCleanup {
+			continue
+		}
+		fmt.Fprintf(ts.logWriter, ts.color("\n\n[light_magenta]received %v; cleaning up [default](%q)\n"), sig, ts.cfg.ConfigPath)
+		if err := ts.CleanupOnSignal(sig); err != nil {
+			ts.lg.Warn("failed to clean up on signal", zap.Error(err))
+		}
+		os.Exit(1)
+	}
+}
+
+// recordSignal returns false if a signal has already been received.
+func (ts *Tester) recordSignal(sig os.Signal) bool {
+	ts.signalMu.Lock()
+	defer ts.signalMu.Unlock()
+	if ts.signal != nil {
+		return false
+	}
+	ts.signal = sig
+	return true
+}
+
+func (ts *Tester) runSignalHooks(sig os.Signal) {
+	ts.signalMu.Lock()
+	hooks := append([]SignalHook(nil), ts.signalHooks...)
+	ts.signalMu.Unlock()
+	for _, h := range hooks {
+		h(sig)
+	}
+}
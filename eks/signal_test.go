@@ -0,0 +1,27 @@
+package eks
+
+import (
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestSignalHooks(t *testing.T) {
+	ts := &Tester{}
+	var got []os.Signal
+	ts.AddSignalHook(func(sig os.Signal) { got = append(got, sig) })
+
+	if !ts.recordSignal(syscall.SIGTERM) {
+		t.Fatal("expected first signal recorded")
+	}
+	if ts.recordSignal(syscall.SIGINT) {
+		t.Fatal("expected second signal not recorded")
+	}
+	if sig := ts.Signaled(); sig != syscall.SIGTERM {
+		t.Fatalf("unexpected signal %v", sig)
+	}
+	ts.runSignalHooks(ts.Signaled())
+	if len(got) != 1 || got[0] != syscall.SIGTERM {
+		t.Fatalf("unexpected hook calls %v", got)
+	}
+}
@@ -0,0 +1,233 @@
+package eks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// soakWorkloadImage is the image of the lightweight soak workload Pod.
+const soakWorkloadImage = eksconfig.DefaultBusyboxImage
+
+// soakResult is the result of a single soak iteration.
+type soakResult struct {
+	Iteration  int                        `json:"iteration"`
+	StartUTC   time.Time                  `json:"start-utc"`
+	Took       time.Duration              `json:"took"`
+	TookString string                     `json:"took-string"`
+	Health     *k8s_client.HealthSnapshot `json:"health,omitempty"`
+	HealthDiff []string                   `json:"health-diff,omitempty"`
+	Errors     []string                   `json:"errors,omitempty"`
+}
+
+// Soak keeps the cluster for "SoakDuration" after "Up", and re-runs the health checks,
+// the lightweight workloads, and the metrics and log snapshots every "SoakInterval",
+// streaming the results to S3. Returns an error if any iteration failed,
+// or if interrupted by the OS signals.
+func (ts *Tester) Soak() error {
+	if ts.cfg.SoakDuration <= 0 {
+		return errors.New("SoakDuration not set")
+	}
+	if ts.k8sClient == nil {
+		return errors.New("no k8s client; cluster not created")
+	}
+	return catchInterrupt(
+		ts.lg,
+		ts.stopCreationCh,
+		ts.stopCreationChOnce,
+		ts.osSig,
+		ts.soak,
+		"soak",
+	)
+}
+
+func (ts *Tester) soak() error {
+	fmt.Fprint(ts.logWriter, ts.color("\n\n[yellow]*********************************\n"))
+	fmt.Fprintf(ts.logWriter, ts.color("[light_green]SOAK START [default](%q, %v every %v)\n"), ts.cfg.ConfigPath, ts.cfg.SoakDuration, ts.cfg.SoakInterval)
+
+	stopReadyz := ts.startReadyzSampler()
+	defer stopReadyz()
+
+	ns := ts.cfg.Name + "-soak"
+	if err := k8s_client.CreateNamespace(ts.lg, ts.k8sClient.KubernetesClientSet(), ns); err != nil {
+		return err
+	}
+	defer func() {
+		if err := k8s_client.DeleteNamespaceAndWait(
+			ts.lg,
+			ts.k8sClient.KubernetesClientSet(),
+			ns,
+			k8s_client.DefaultNamespaceDeletionInterval,
+			k8s_client.DefaultNamespaceDeletionTimeout,
+			k8s_client.WithForceDelete(true),
+		); err != nil {
+			ts.lg.Warn("failed to delete soak namespace", zap.String("namespace", ns), zap.Error(err))
+		}
+	}()
+
+	deadline := time.Now().Add(ts.cfg.SoakDuration)
+	prev := ts.cfg.Status.HealthSnapshot
+	for iter := ts.cfg.Status.SoakIterations + 1; ; iter++ {
+		rs := ts.runSoakIteration(iter, ns, prev)
+		if rs.Health != nil {
+			prev = rs.Health
+		}
+		ts.cfg.Status.SoakIterations = iter
+		if len(rs.Errors) > 0 {
+			ts.cfg.Status.SoakFailures++
+		}
+		ts.cfg.Sync()
+		ts.writeSoakResult(rs)
+		// uploads the soak results, report, and tester logs so far
+		if err := ts.uploadToS3(); err != nil {
+			ts.lg.Warn("failed to upload artifacts to S3", zap.Error(err))
+		}
+
+		if time.Now().Add(ts.cfg.SoakInterval).After(deadline) {
+			break
+		}
+		select {
+		case <-ts.stopCreationCh:
+			return errors.New("soak stopped")
+		case <-time.After(ts.cfg.SoakInterval):
+		}
+	}
+
+	fmt.Fprintf(ts.logWriter, ts.color("\n[light_green]SOAK DONE [default](%d iteration(s), %d failure(s))\n"), ts.cfg.Status.SoakIterations, ts.cfg.Status.SoakFailures)
+	if ts.cfg.Status.SoakFailures > 0 {
+		return fmt.Errorf("%d of %d soak iteration(s) failed", ts.cfg.Status.SoakFailures, ts.cfg.Status.SoakIterations)
+	}
+	return nil
+}
+
+// runSoakIteration runs the health checks and the workload,
+// and records the iteration as a test phase in the report.
+func (ts *Tester) runSoakIteration(iter int, ns string, prev *k8s_client.HealthSnapshot) soakResult {
+	name := fmt.Sprintf("soak-%04d", iter)
+	start := time.Now()
+	rs := soakResult{Iteration: iter, StartUTC: start.UTC()}
+	ts.lg.Info("starting soak iteration", zap.Int("iteration", iter))
+
+	rs.Health = ts.takeHealthSnapshot()
+	if prev != nil && rs.Health != nil {
+		rs.HealthDiff = k8s_client.HealthDiff(*prev, *rs.Health)
+	}
+	// also fetches kube-apiserver "/metrics" to S3
+	if err := ts.clusterTester.CheckHealth(); err != nil {
+		rs.Errors = append(rs.Errors, fmt.Sprintf("health check failed (%v)", err))
+	}
+	if err := ts.runSoakWorkload(ns, name); err != nil {
+		rs.Errors = append(rs.Errors, fmt.Sprintf("workload failed (%v)", err))
+	}
+	rs.Took = time.Since(start)
+	rs.TookString = rs.Took.String()
+	var err error
+	if len(rs.Errors) > 0 {
+		err = errors.New(rs.Errors[0])
+	}
+	ts.report.Record("soak", name, start, err)
+	ts.report.Annotate("soak", name, rs.HealthDiff...)
+	ts.writeReport()
+	ts.lg.Info("finished soak iteration",
+		zap.Int("iteration", iter),
+		zap.String("took", rs.TookString),
+		zap.Strings("health-diff", rs.HealthDiff),
+		zap.Strings("errors", rs.Errors),
+	)
+	return rs
+}
+
+// runSoakWorkload runs a short-lived Pod to completion,
+// to check the scheduling, image pulls, and the container runtime.
+func (ts *Tester) runSoakWorkload(ns string, name string) error {
+	cli := ts.k8sClient.KubernetesClientSet()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := cli.CoreV1().Pods(ns).Create(
+		ctx,
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Spec: v1.PodSpec{
+				RestartPolicy: v1.RestartPolicyNever,
+				Containers: []v1.Container{
+					{
+						Name:    "soak",
+						Image:   ts.cfg.Image(soakWorkloadImage),
+						Command: []string{"/bin/sh", "-c", "echo soak"},
+					},
+				},
+				NodeSelector: map[string]string{
+					"kubernetes.io/os": "linux",
+				},
+			},
+		},
+		metav1.CreateOptions{},
+	)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		err := cli.CoreV1().Pods(ns).Delete(ctx, name, metav1.DeleteOptions{})
+		cancel()
+		if err != nil && !apierrs.IsNotFound(err) {
+			ts.lg.Warn("failed to delete soak workload Pod", zap.String("name", name), zap.Error(err))
+		}
+	}()
+
+	waitStart := time.Now()
+	for time.Since(waitStart) < 5*time.Minute {
+		select {
+		case <-ts.stopCreationCh:
+			return errors.New("soak workload aborted")
+		case <-time.After(5 * time.Second):
+		}
+		ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+		pod, err := cli.CoreV1().Pods(ns).Get(ctx, name, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.lg.Warn("failed to get soak workload Pod", zap.Error(err))
+			continue
+		}
+		switch pod.Status.Phase {
+		case v1.PodSucceeded:
+			ts.lg.Info("soak workload Pod succeeded", zap.String("name", name), zap.Duration("took", time.Since(waitStart)))
+			return nil
+		case v1.PodFailed:
+			return fmt.Errorf("Pod %q failed (%s)", name, pod.Status.Message)
+		}
+	}
+	return fmt.Errorf("Pod %q not completed", name)
+}
+
+// writeSoakResult appends the iteration result to "SoakResultsPath".
+func (ts *Tester) writeSoakResult(rs soakResult) {
+	b, err := json.Marshal(rs)
+	if err != nil {
+		ts.lg.Warn("failed to encode soak result", zap.Error(err))
+		return
+	}
+	f, err := os.OpenFile(ts.cfg.SoakResultsPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		ts.lg.Warn("failed to open soak results", zap.Error(err))
+		return
+	}
+	_, err = f.Write(append(b, '\n'))
+	f.Close()
+	if err != nil {
+		ts.lg.Warn("failed to write soak result", zap.Error(err))
+	}
+}
@@ -0,0 +1,46 @@
+package eks
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	"go.uber.org/zap"
+)
+
+func TestWriteSoakResult(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "soak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ts := &Tester{
+		lg:  zap.NewNop(),
+		cfg: &eksconfig.Config{SoakResultsPath: filepath.Join(dir, "soak.jsonl")},
+	}
+	ts.writeSoakResult(soakResult{Iteration: 1})
+	ts.writeSoakResult(soakResult{Iteration: 2, Errors: []string{"workload failed"}})
+
+	f, err := os.Open(ts.cfg.SoakResultsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var rs []soakResult
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r soakResult
+		if err = json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+		rs = append(rs, r)
+	}
+	if len(rs) != 2 || rs[0].Iteration != 1 || rs[1].Errors[0] != "workload failed" {
+		t.Fatalf("unexpected soak results %+v", rs)
+	}
+}
@@ -0,0 +1,262 @@
+package spotinterruption
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	aws_iam "github.com/aws/aws-k8s-tester/pkg/aws/iam"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_iam_v2 "github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/fis"
+	smithy "github.com/aws/smithy-go"
+	"go.uber.org/zap"
+)
+
+const (
+	rolePolicyName = "spot-interruption"
+	// targetName is the experiment template target for the Spot instance.
+	targetName = "SpotInstances"
+)
+
+func isNotFound(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return strings.Contains(apiErr.ErrorCode(), "NotFound") || apiErr.ErrorCode() == "NoSuchEntity"
+	}
+	if awsErr, ok := err.(awserr.Error); ok {
+		return awsErr.Code() == fis.ErrCodeResourceNotFoundException
+	}
+	return false
+}
+
+// createRole creates the IAM role for FIS to send Spot interruptions.
+// ref. https://docs.aws.amazon.com/fis/latest/userguide/getting-started-iam-service-role.html
+func (ts *tester) createRole() error {
+	cur := ts.cfg.EKSConfig.AddOnSpotInterruption
+	if cur.RoleARN != "" {
+		ts.cfg.Logger.Info("FIS role already created; no need to create a new one")
+		return nil
+	}
+
+	ts.cfg.Logger.Info("creating FIS role", zap.String("name", cur.RoleName))
+	out, err := ts.cfg.IAMAPIV2.CreateRole(
+		context.Background(),
+		&aws_iam_v2.CreateRoleInput{
+			RoleName: aws_v2.String(cur.RoleName),
+			Path:     aws_v2.String("/"),
+			AssumeRolePolicyDocument: aws_v2.String(toJSON(aws_iam.PolicyDocument{
+				Version: "2012-10-17",
+				Statement: []aws_iam.StatementEntry{
+					{
+						Effect:    "Allow",
+						Principal: &aws_iam.PrincipalEntry{Service: []string{"fis.amazonaws.com"}},
+						Action:    []string{"sts:AssumeRole"},
+					},
+				},
+			})),
+		},
+	)
+	if err != nil {
+		return err
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.RoleARN = aws_v2.ToString(out.Role.Arn)
+	})
+
+	if _, err = ts.cfg.IAMAPIV2.PutRolePolicy(
+		context.Background(),
+		&aws_iam_v2.PutRolePolicyInput{
+			RoleName:   aws_v2.String(cur.RoleName),
+			PolicyName: aws_v2.String(rolePolicyName),
+			PolicyDocument: aws_v2.String(toJSON(aws_iam.PolicyDocument{
+				Version: "2012-10-17",
+				Statement: []aws_iam.StatementEntry{
+					{
+						Effect:   "Allow",
+						Action:   []string{"ec2:SendSpotInstanceInterruptions"},
+						Resource: fmt.Sprintf("arn:%s:ec2:*:*:instance/*", ts.cfg.EKSConfig.Partition),
+					},
+					{
+						Effect:   "Allow",
+						Action:   []string{"ec2:DescribeInstances"},
+						Resource: "*",
+					},
+				},
+			})),
+		},
+	); err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("created FIS role", zap.String("role-arn", cur.RoleARN))
+	return nil
+}
+
+func (ts *tester) deleteRole() error {
+	cur := ts.cfg.EKSConfig.AddOnSpotInterruption
+	if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[cur.RoleName]; ok {
+		return nil
+	}
+	ts.cfg.Logger.Info("deleting FIS role", zap.String("name", cur.RoleName))
+
+	_, err := ts.cfg.IAMAPIV2.DeleteRolePolicy(
+		context.Background(),
+		&aws_iam_v2.DeleteRolePolicyInput{
+			RoleName:   aws_v2.String(cur.RoleName),
+			PolicyName: aws_v2.String(rolePolicyName),
+		},
+	)
+	if err != nil && !isNotFound(err) {
+		ts.cfg.Logger.Warn("failed to delete FIS role policy", zap.Error(err))
+	}
+
+	_, err = ts.cfg.IAMAPIV2.DeleteRole(
+		context.Background(),
+		&aws_iam_v2.DeleteRoleInput{
+			RoleName: aws_v2.String(cur.RoleName),
+		},
+	)
+	if err != nil && !isNotFound(err) {
+		ts.cfg.Logger.Warn("failed to delete FIS role", zap.Error(err))
+		return err
+	}
+
+	ts.cfg.Logger.Info("deleted FIS role")
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.Status.DeletedResources[cur.RoleName] = "AddOnSpotInterruption.RoleName"
+	})
+	return nil
+}
+
+// createExperimentTemplate creates the experiment template to send
+// the Spot interruptions to the target instances.
+// ref. https://docs.aws.amazon.com/fis/latest/userguide/fis-actions-reference.html#send-spot-instance-interruptions
+func (ts *tester) createExperimentTemplate() error {
+	cur := ts.cfg.EKSConfig.AddOnSpotInterruption
+	instanceARNs := make([]string, 0, len(cur.Interruptions))
+	for _, rs := range cur.Interruptions {
+		instanceARNs = append(instanceARNs, fmt.Sprintf("arn:%s:ec2:%s:%s:instance/%s",
+			ts.cfg.EKSConfig.Partition,
+			ts.cfg.EKSConfig.Region,
+			ts.cfg.EKSConfig.Status.AWSAccountID,
+			rs.InstanceID,
+		))
+	}
+
+	ts.cfg.Logger.Info("creating FIS experiment template", zap.Strings("instance-arns", instanceARNs))
+	out, err := ts.cfg.FISAPI.CreateExperimentTemplate(&fis.CreateExperimentTemplateInput{
+		Description: aws.String(fmt.Sprintf("aws-k8s-tester Spot interruption for %s", ts.cfg.EKSConfig.Name)),
+		RoleArn:     aws.String(cur.RoleARN),
+		Targets: map[string]*fis.CreateExperimentTemplateTargetInput{
+			targetName: {
+				ResourceType:  aws.String("aws:ec2:spot-instance"),
+				ResourceArns:  aws.StringSlice(instanceARNs),
+				SelectionMode: aws.String("ALL"),
+			},
+		},
+		Actions: map[string]*fis.CreateExperimentTemplateActionInput{
+			"interrupt": {
+				ActionId: aws.String("aws:ec2:send-spot-instance-interruptions"),
+				Parameters: map[string]*string{
+					// ISO 8601 duration
+					"durationBeforeInterruption": aws.String(fmt.Sprintf("PT%dS", int(cur.DurationBeforeInterruption.Seconds()))),
+				},
+				Targets: map[string]*string{
+					targetName: aws.String(targetName),
+				},
+			},
+		},
+		StopConditions: []*fis.CreateExperimentTemplateStopConditionInput{
+			{Source: aws.String("none")},
+		},
+		Tags: map[string]*string{
+			"Name": aws.String(ts.cfg.EKSConfig.Name),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create FIS experiment template (%v)", err)
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.ExperimentTemplateID = aws.StringValue(out.ExperimentTemplate.Id)
+	})
+
+	ts.cfg.Logger.Info("created FIS experiment template", zap.String("experiment-template-id", cur.ExperimentTemplateID))
+	return nil
+}
+
+func (ts *tester) deleteExperimentTemplate() error {
+	cur := ts.cfg.EKSConfig.AddOnSpotInterruption
+	if cur.ExperimentTemplateID == "" {
+		return nil
+	}
+	if _, ok := ts.cfg.EKSConfig.Status.DeletedResources[cur.ExperimentTemplateID]; ok {
+		return nil
+	}
+
+	ts.cfg.Logger.Info("deleting FIS experiment template", zap.String("experiment-template-id", cur.ExperimentTemplateID))
+	_, err := ts.cfg.FISAPI.DeleteExperimentTemplate(&fis.DeleteExperimentTemplateInput{
+		Id: aws.String(cur.ExperimentTemplateID),
+	})
+	if err != nil && !isNotFound(err) {
+		ts.cfg.Logger.Warn("failed to delete FIS experiment template", zap.Error(err))
+		return err
+	}
+
+	ts.cfg.Logger.Info("deleted FIS experiment template")
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.Status.DeletedResources[cur.ExperimentTemplateID] = "AddOnSpotInterruption.ExperimentTemplateID"
+	})
+	return nil
+}
+
+func (ts *tester) startExperiment() error {
+	cur := ts.cfg.EKSConfig.AddOnSpotInterruption
+	out, err := ts.cfg.FISAPI.StartExperiment(&fis.StartExperimentInput{
+		ExperimentTemplateId: aws.String(cur.ExperimentTemplateID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start FIS experiment (%v)", err)
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.ExperimentID = aws.StringValue(out.Experiment.Id)
+	})
+
+	ts.cfg.Logger.Info("started FIS experiment",
+		zap.String("experiment-id", cur.ExperimentID),
+		zap.Int("instances", len(cur.Interruptions)),
+		zap.String("duration-before-interruption", cur.DurationBeforeInterruptionString),
+	)
+	return nil
+}
+
+// checkExperiment returns an error if the experiment failed or was stopped.
+func (ts *tester) checkExperiment() error {
+	out, err := ts.cfg.FISAPI.GetExperiment(&fis.GetExperimentInput{
+		Id: aws.String(ts.cfg.EKSConfig.AddOnSpotInterruption.ExperimentID),
+	})
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to get FIS experiment", zap.Error(err))
+		return nil
+	}
+	if out.Experiment == nil || out.Experiment.State == nil {
+		return nil
+	}
+	switch status := aws.StringValue(out.Experiment.State.Status); status {
+	case fis.ExperimentStatusFailed, fis.ExperimentStatusStopped:
+		return fmt.Errorf("FIS experiment %q %s (%s)", ts.cfg.EKSConfig.AddOnSpotInterruption.ExperimentID, status, aws.StringValue(out.Experiment.State.Reason))
+	}
+	return nil
+}
+
+func toJSON(p aws_iam.PolicyDocument) string {
+	b, err := json.Marshal(p)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,249 @@
+// Package spotinterruption verifies the Spot capacity of a node group, and
+// optionally sends simulated Spot interruptions via AWS Fault Injection
+// Simulator (FIS) to assert the interrupted nodes are gracefully drained,
+// recording the drain latency and the evicted Pod recovery times.
+// ref. https://docs.aws.amazon.com/fis/latest/userguide/fis-tutorial-spot-interruptions.html
+package spotinterruption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	aws_iam_v2 "github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/fis"
+	"github.com/aws/aws-sdk-go/service/fis/fisiface"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Config defines Spot interruption configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+
+	IAMAPIV2 *aws_iam_v2.Client
+	EC2APIV2 *aws_ec2_v2.Client
+	FISAPI   fisiface.FISAPI
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new Spot interruption tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:      "spot-interruption",
+		Exclusive: true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				IAMAPIV2:  cfg.IAMAPIV2,
+				EC2APIV2:  cfg.EC2APIV2,
+				FISAPI:    fis.New(cfg.AWSSession, aws.NewConfig().WithRegion(cfg.EKSConfig.Region)),
+			}), cfg.EKSConfig.IsEnabledAddOnSpotInterruption)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnSpotInterruption() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnSpotInterruption.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnSpotInterruption.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnSpotInterruption.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	spotNodes, err := ts.checkSpotCapacity()
+	if err != nil {
+		return err
+	}
+	if !ts.cfg.EKSConfig.AddOnSpotInterruption.SimulateInterruption {
+		ts.cfg.Logger.Info("skipping simulated Spot interruption")
+		return nil
+	}
+
+	if ts.cfg.EKSConfig.AddOnSpotInterruption.TerminationHandlerName != "" {
+		if err = ts.checkTerminationHandler(); err != nil {
+			return err
+		}
+	}
+
+	if err = k8s_client.CreateNamespace(
+		ts.cfg.Logger,
+		ts.cfg.K8SClient.KubernetesClientSet(),
+		ts.cfg.EKSConfig.AddOnSpotInterruption.Namespace,
+	); err != nil {
+		return err
+	}
+	if err = ts.createDeployment(); err != nil {
+		return err
+	}
+	if err = ts.waitDeployment(); err != nil {
+		return err
+	}
+	if err = ts.selectTargets(spotNodes); err != nil {
+		return err
+	}
+	if err = ts.createRole(); err != nil {
+		return err
+	}
+	if err = ts.createExperimentTemplate(); err != nil {
+		return err
+	}
+	if err = ts.startExperiment(); err != nil {
+		return err
+	}
+	if err = ts.checkDrain(); err != nil {
+		return err
+	}
+
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnSpotInterruption() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnSpotInterruption.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	deleteStart := time.Now()
+	defer func() {
+		deleteEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnSpotInterruption.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
+	}()
+
+	var errs []string
+	if ts.cfg.EKSConfig.AddOnSpotInterruption.SimulateInterruption {
+		if err := ts.deleteExperimentTemplate(); err != nil {
+			errs = append(errs, err.Error())
+		}
+		if err := ts.deleteRole(); err != nil {
+			errs = append(errs, err.Error())
+		}
+		if err := k8s_client.DeleteNamespaceAndWait(
+			ts.cfg.Logger,
+			ts.cfg.K8SClient.KubernetesClientSet(),
+			ts.cfg.EKSConfig.AddOnSpotInterruption.Namespace,
+			k8s_client.DefaultNamespaceDeletionInterval,
+			k8s_client.DefaultNamespaceDeletionTimeout,
+			k8s_client.WithForceDelete(true),
+		); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete Spot interruption namespace (%v)", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnSpotInterruption.Created = false
+	})
+	return nil
+}
+
+// checkSpotCapacity verifies the node group has Spot instances,
+// and returns the map of Spot node names to instance IDs.
+func (ts *tester) checkSpotCapacity() (map[string]string, error) {
+	ngName := ts.cfg.EKSConfig.AddOnSpotInterruption.NodeGroupName
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	nodes, err := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Nodes().List(ctx, metav1.ListOptions{
+		LabelSelector: "NGName=" + ngName,
+	})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes (%v)", err)
+	}
+	if len(nodes.Items) == 0 {
+		return nil, fmt.Errorf("no node found for node group %q", ngName)
+	}
+
+	nodeNames := make(map[string]string)
+	instanceIDs := make([]string, 0, len(nodes.Items))
+	for _, node := range nodes.Items {
+		// e.g. "aws:///us-west-2a/i-0123456789abcdef0"
+		id := node.Spec.ProviderID[strings.LastIndex(node.Spec.ProviderID, "/")+1:]
+		nodeNames[id] = node.Name
+		instanceIDs = append(instanceIDs, id)
+	}
+
+	out, err := ts.cfg.EC2APIV2.DescribeInstances(
+		context.Background(),
+		&aws_ec2_v2.DescribeInstancesInput{
+			InstanceIds: instanceIDs,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instances (%v)", err)
+	}
+	spotNodes := make(map[string]string)
+	for _, rsv := range out.Reservations {
+		for _, inst := range rsv.Instances {
+			id := aws_v2.ToString(inst.InstanceId)
+			if inst.InstanceLifecycle == aws_ec2_v2_types.InstanceLifecycleTypeSpot {
+				spotNodes[nodeNames[id]] = id
+			}
+		}
+	}
+	ts.cfg.Logger.Info("checked Spot capacity",
+		zap.String("node-group-name", ngName),
+		zap.Int("nodes", len(instanceIDs)),
+		zap.Int("spot-nodes", len(spotNodes)),
+	)
+	if len(spotNodes) == 0 {
+		return nil, fmt.Errorf("no Spot instance found for node group %q", ngName)
+	}
+	return spotNodes, nil
+}
@@ -0,0 +1,469 @@
+package spotinterruption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	deploymentName = "spot-interruption"
+	appName        = "spot-interruption"
+	pauseImage     = eksconfig.DefaultPauseImage
+)
+
+func (ts *tester) createDeployment() error {
+	cur := ts.cfg.EKSConfig.AddOnSpotInterruption
+	ts.cfg.Logger.Info("creating Deployment", zap.Int32("replicas", cur.DeploymentReplicas))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		Deployments(cur.Namespace).
+		Create(
+			ctx,
+			&appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      deploymentName,
+					Namespace: cur.Namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/name": appName,
+					},
+				},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: aws_v2.Int32(cur.DeploymentReplicas),
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							"app.kubernetes.io/name": appName,
+						},
+					},
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{
+								"app.kubernetes.io/name": appName,
+							},
+						},
+						Spec: v1.PodSpec{
+							RestartPolicy:                 v1.RestartPolicyAlways,
+							TerminationGracePeriodSeconds: aws_v2.Int64(0),
+							Containers: []v1.Container{
+								{
+									Name:            appName,
+									Image:           ts.cfg.EKSConfig.Image(pauseImage),
+									ImagePullPolicy: v1.PullIfNotPresent,
+								},
+							},
+							NodeSelector: map[string]string{
+								"NGName": cur.NodeGroupName,
+							},
+						},
+					},
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil && !apierrs.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create Deployment (%v)", err)
+	}
+
+	ts.cfg.Logger.Info("created Deployment")
+	return nil
+}
+
+func (ts *tester) waitDeployment() error {
+	cur := ts.cfg.EKSConfig.AddOnSpotInterruption
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	_, err := k8s_client.WaitForDeploymentCompletes(
+		ctx,
+		ts.cfg.Logger,
+		ts.cfg.LogWriter,
+		ts.cfg.Stopc,
+		ts.cfg.K8SClient,
+		20*time.Second,
+		10*time.Second,
+		cur.Namespace,
+		deploymentName,
+		cur.DeploymentReplicas,
+	)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("Deployment not ready (%v)", err)
+	}
+	return nil
+}
+
+func (ts *tester) listPods() ([]v1.Pod, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	pods, err := ts.cfg.K8SClient.KubernetesClientSet().
+		CoreV1().
+		Pods(ts.cfg.EKSConfig.AddOnSpotInterruption.Namespace).
+		List(ctx, metav1.ListOptions{
+			LabelSelector: "app.kubernetes.io/name=" + appName,
+		})
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+// selectTargets selects the Spot nodes of "InstanceIDs" to interrupt,
+// or the Spot node running the most workload Pods if not specified.
+func (ts *tester) selectTargets(spotNodes map[string]string) error {
+	cur := ts.cfg.EKSConfig.AddOnSpotInterruption
+	var interruptions []eksconfig.SpotInterruptionResult
+	if len(cur.InstanceIDs) > 0 {
+		nodeNames := make(map[string]string)
+		for name, id := range spotNodes {
+			nodeNames[id] = name
+		}
+		for _, id := range cur.InstanceIDs {
+			name, ok := nodeNames[id]
+			if !ok {
+				return fmt.Errorf("instance %q is not a Spot node in node group %q", id, cur.NodeGroupName)
+			}
+			interruptions = append(interruptions, eksconfig.SpotInterruptionResult{InstanceID: id, NodeName: name})
+		}
+	} else {
+		pods, err := ts.listPods()
+		if err != nil {
+			return fmt.Errorf("failed to list Pods (%v)", err)
+		}
+		counts := make(map[string]int)
+		for _, pod := range pods {
+			if _, ok := spotNodes[pod.Spec.NodeName]; ok {
+				counts[pod.Spec.NodeName]++
+			}
+		}
+		selected := ""
+		for name := range spotNodes {
+			if selected == "" || counts[name] > counts[selected] {
+				selected = name
+			}
+		}
+		interruptions = []eksconfig.SpotInterruptionResult{{InstanceID: spotNodes[selected], NodeName: selected}}
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		cur.Interruptions = interruptions
+		cur.InterruptedNodeName = cur.Interruptions[0].NodeName
+		cur.InterruptedInstanceID = cur.Interruptions[0].InstanceID
+	})
+
+	for _, rs := range cur.Interruptions {
+		ts.cfg.Logger.Info("selected Spot node to interrupt",
+			zap.String("node-name", rs.NodeName),
+			zap.String("instance-id", rs.InstanceID),
+		)
+	}
+	return nil
+}
+
+// checkTerminationHandler verifies the interruption handler is available,
+// which cordons and drains the node on the interruption notice.
+func (ts *tester) checkTerminationHandler() error {
+	name := ts.cfg.EKSConfig.AddOnSpotInterruption.TerminationHandlerName
+	cli := ts.cfg.K8SClient.KubernetesClientSet()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ds, err := cli.AppsV1().DaemonSets("kube-system").Get(ctx, name, metav1.GetOptions{})
+	cancel()
+	if err == nil {
+		if ds.Status.NumberAvailable == 0 {
+			return fmt.Errorf("termination handler DaemonSet %q not available", name)
+		}
+		ts.cfg.Logger.Info("checked termination handler DaemonSet", zap.String("name", name), zap.Int32("available", ds.Status.NumberAvailable))
+		return nil
+	}
+	if !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to get termination handler DaemonSet %q (%v)", name, err)
+	}
+
+	// e.g. queue processor mode runs as a Deployment
+	ctx, cancel = context.WithTimeout(context.Background(), 15*time.Second)
+	dp, err := cli.AppsV1().Deployments("kube-system").Get(ctx, name, metav1.GetOptions{})
+	cancel()
+	if apierrs.IsNotFound(err) {
+		return fmt.Errorf("termination handler %q not found in kube-system", name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get termination handler Deployment %q (%v)", name, err)
+	}
+	if dp.Status.AvailableReplicas == 0 {
+		return fmt.Errorf("termination handler Deployment %q not available", name)
+	}
+	ts.cfg.Logger.Info("checked termination handler Deployment", zap.String("name", name), zap.Int32("available", dp.Status.AvailableReplicas))
+	return nil
+}
+
+// drainState tracks the drain of an interrupted node.
+type drainState struct {
+	cordoned time.Time
+	drained  time.Time
+	// pods is the set of workload Pod UIDs ever seen on the node.
+	pods map[types.UID]struct{}
+	// evicted maps the evicted Pod UIDs to the eviction times.
+	evicted map[types.UID]time.Time
+}
+
+// checkDrain waits for the interrupted nodes to be cordoned and drained,
+// and the workload to be fully available on the other nodes.
+// Records the drain latency and the evicted Pod recovery times for each node,
+// and returns an error if any node was not drained before the interruption.
+func (ts *tester) checkDrain() error {
+	cur := ts.cfg.EKSConfig.AddOnSpotInterruption
+	ts.cfg.Logger.Info("waiting for interrupted node drain",
+		zap.Int("nodes", len(cur.Interruptions)),
+		zap.String("drain-timeout", cur.DrainTimeoutString),
+	)
+
+	drainStart := time.Now()
+	states := make(map[string]*drainState)
+	for _, rs := range cur.Interruptions {
+		states[rs.NodeName] = &drainState{
+			pods:    make(map[types.UID]struct{}),
+			evicted: make(map[types.UID]time.Time),
+		}
+	}
+	if pods, err := ts.listPods(); err == nil {
+		for _, pod := range pods {
+			if st, ok := states[pod.Spec.NodeName]; ok {
+				st.pods[pod.UID] = struct{}{}
+			}
+		}
+	}
+
+	deadline := drainStart.Add(cur.DrainTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("wait for drain aborted")
+		case <-time.After(10 * time.Second):
+		}
+
+		if err := ts.checkExperiment(); err != nil {
+			return err
+		}
+
+		for name, st := range states {
+			if !st.cordoned.IsZero() {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			node, err := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+			cancel()
+			switch {
+			case apierrs.IsNotFound(err):
+				return fmt.Errorf("node %q removed before it was cordoned", name)
+			case err != nil:
+				ts.cfg.Logger.Warn("failed to get node", zap.Error(err))
+			case isCordoned(node):
+				st.cordoned = time.Now()
+				ts.cfg.Logger.Info("interrupted node cordoned",
+					zap.String("node-name", name),
+					zap.String("elapsed", st.cordoned.Sub(drainStart).String()),
+				)
+			}
+		}
+
+		pods, err := ts.listPods()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to list Pods", zap.Error(err))
+			continue
+		}
+		now := time.Now()
+		listed := make(map[types.UID]struct{})
+		onNodes := make(map[string]int)
+		ready := int32(0)
+		for _, pod := range pods {
+			listed[pod.UID] = struct{}{}
+			if st, ok := states[pod.Spec.NodeName]; ok {
+				st.pods[pod.UID] = struct{}{}
+				if pod.DeletionTimestamp != nil {
+					if _, ok := st.evicted[pod.UID]; !ok {
+						st.evicted[pod.UID] = pod.DeletionTimestamp.Time
+					}
+					continue
+				}
+				onNodes[pod.Spec.NodeName]++
+				continue
+			}
+			if pod.DeletionTimestamp == nil && isReady(pod) {
+				ready++
+			}
+		}
+
+		drained := 0
+		for name, st := range states {
+			// Pods deleted between the polls
+			for uid := range st.pods {
+				_, ok := listed[uid]
+				if _, evicted := st.evicted[uid]; !ok && !evicted {
+					st.evicted[uid] = now
+				}
+			}
+			if st.drained.IsZero() && !st.cordoned.IsZero() && onNodes[name] == 0 {
+				st.drained = now
+				ts.cfg.Logger.Info("interrupted node drained",
+					zap.String("node-name", name),
+					zap.String("elapsed", st.drained.Sub(drainStart).String()),
+				)
+			}
+			if !st.drained.IsZero() {
+				drained++
+			}
+		}
+		ts.cfg.Logger.Info("checked workload",
+			zap.Int("drained-nodes", drained),
+			zap.Int("interrupted-nodes", len(states)),
+			zap.Int32("ready-pods", ready),
+			zap.Int32("replicas", cur.DeploymentReplicas),
+		)
+		if drained == len(states) && ready >= cur.DeploymentReplicas {
+			ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+				cur.TimeFrameDrain = timeutil.NewTimeFrame(drainStart, now)
+			})
+			return ts.recordDrain(drainStart, states, pods)
+		}
+	}
+	return fmt.Errorf("interrupted nodes not drained within %v", cur.DrainTimeout)
+}
+
+// recordDrain records the drain results for each interrupted node,
+// matching the evicted Pods to the replacement Pods in order of readiness.
+func (ts *tester) recordDrain(drainStart time.Time, states map[string]*drainState, pods []v1.Pod) error {
+	cur := ts.cfg.EKSConfig.AddOnSpotInterruption
+
+	var readyTimes []time.Time
+	for _, pod := range pods {
+		if _, ok := states[pod.Spec.NodeName]; ok || pod.DeletionTimestamp != nil || pod.CreationTimestamp.Time.Before(drainStart.Truncate(time.Second)) {
+			continue
+		}
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == v1.PodReady && cond.Status == v1.ConditionTrue {
+				readyTimes = append(readyTimes, cond.LastTransitionTime.Time)
+			}
+		}
+	}
+	var evictedTimes []time.Time
+	var evictedNodes []int
+	for i, rs := range cur.Interruptions {
+		for _, t := range states[rs.NodeName].evicted {
+			evictedTimes = append(evictedTimes, t)
+			evictedNodes = append(evictedNodes, i)
+		}
+	}
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		for j, took := range recoveryTimes(evictedTimes, readyTimes) {
+			if took < 0 {
+				continue
+			}
+			rs := &cur.Interruptions[evictedNodes[j]]
+			rs.PodRecoveryTimes = append(rs.PodRecoveryTimes, took)
+			if took > rs.PodRecoveryTimeMax {
+				rs.PodRecoveryTimeMax = took
+			}
+		}
+		for i := range cur.Interruptions {
+			rs := &cur.Interruptions[i]
+			st := states[rs.NodeName]
+			rs.CordonLatency = st.cordoned.Sub(drainStart)
+			rs.CordonLatencyString = rs.CordonLatency.String()
+			rs.DrainLatency = st.drained.Sub(drainStart)
+			rs.DrainLatencyString = rs.DrainLatency.String()
+			rs.Graceful = rs.DrainLatency <= cur.DurationBeforeInterruption
+			rs.EvictedPods = len(st.evicted)
+			rs.PodRecoveryTimeMaxString = rs.PodRecoveryTimeMax.String()
+		}
+	})
+
+	var errs []string
+	for _, rs := range cur.Interruptions {
+		ts.cfg.Logger.Info("recorded interrupted node drain",
+			zap.String("node-name", rs.NodeName),
+			zap.String("cordon-latency", rs.CordonLatencyString),
+			zap.String("drain-latency", rs.DrainLatencyString),
+			zap.Bool("graceful", rs.Graceful),
+			zap.Int("evicted-pods", rs.EvictedPods),
+			zap.String("pod-recovery-time-max", rs.PodRecoveryTimeMaxString),
+		)
+		if !rs.Graceful {
+			errs = append(errs, fmt.Sprintf("node %q drained in %v after the interruption notice (expected <=%v)", rs.NodeName, rs.DrainLatency, cur.DurationBeforeInterruption))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// recoveryTimes matches each eviction, in the order of the eviction times,
+// to the earliest replacement Pod ready at or after the eviction,
+// and returns the time to recover for each eviction in the input order.
+// The eviction without a replacement Pod is -1.
+func recoveryTimes(evicted []time.Time, ready []time.Time) []time.Duration {
+	idxs := make([]int, len(evicted))
+	for i := range idxs {
+		idxs[i] = i
+	}
+	sort.SliceStable(idxs, func(a, b int) bool { return evicted[idxs[a]].Before(evicted[idxs[b]]) })
+	sorted := append([]time.Time(nil), ready...)
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a].Before(sorted[b]) })
+
+	took := make([]time.Duration, len(evicted))
+	j := 0
+	for _, i := range idxs {
+		for j < len(sorted) && sorted[j].Before(evicted[i]) {
+			j++
+		}
+		if j == len(sorted) {
+			took[i] = -1
+			continue
+		}
+		took[i] = sorted[j].Sub(evicted[i])
+		j++
+	}
+	return took
+}
+
+func isCordoned(node *v1.Node) bool {
+	if node.Spec.Unschedulable {
+		return true
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == v1.TaintNodeUnschedulable {
+			return true
+		}
+	}
+	return false
+}
+
+func isReady(pod v1.Pod) bool {
+	if pod.Status.Phase != v1.PodRunning {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == v1.PodReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
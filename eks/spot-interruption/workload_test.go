@@ -0,0 +1,26 @@
+package spotinterruption
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecoveryTimes(t *testing.T) {
+	start := time.Now()
+	at := func(sec int) time.Time { return start.Add(time.Duration(sec) * time.Second) }
+
+	evicted := []time.Time{at(20), at(10), at(10), at(30)}
+	// the replacement ready before any eviction is not matched,
+	// and the last eviction has no replacement
+	ready := []time.Time{at(40), at(5), at(12), at(25)}
+	exp := []time.Duration{20 * time.Second, 2 * time.Second, 15 * time.Second, -1}
+	if took := recoveryTimes(evicted, ready); !reflect.DeepEqual(took, exp) {
+		t.Fatalf("expected %v, got %v", exp, took)
+	}
+
+	exp = []time.Duration{-1, 2 * time.Second, -1, -1}
+	if took := recoveryTimes(evicted, ready[2:3]); !reflect.DeepEqual(took, exp) {
+		t.Fatalf("expected %v, got %v", exp, took)
+	}
+}
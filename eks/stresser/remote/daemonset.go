@@ -0,0 +1,184 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	aws_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const stresserDaemonSetName = "stresser-remote-daemonset"
+
+// runDaemonSet runs one load generator per node, and waits until
+// every load generator uploads its results to S3.
+// The load generators sleep after the run, since the DaemonSet
+// Pods must not exit.
+func (ts *tester) runDaemonSet() (err error) {
+	testerCmd, err := ts.createTesterCmd()
+	if err != nil {
+		return err
+	}
+	// do not "-e", to keep the Pod running on failures,
+	// the missing results in S3 fail the test instead
+	cmd := testerCmd + `; echo "stresser exited with $?"; while true; do sleep 3600; done`
+	podSpec := ts.createPodTemplate(v1.RestartPolicyAlways, []string{"/bin/sh", "-c", cmd})
+
+	ts.cfg.Logger.Info("creating DaemonSet", zap.String("name", stresserDaemonSetName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_, err = ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		DaemonSets(ts.cfg.EKSConfig.AddOnStresserRemote.Namespace).
+		Create(
+			ctx,
+			&appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      stresserDaemonSetName,
+					Namespace: ts.cfg.EKSConfig.AddOnStresserRemote.Namespace,
+				},
+				Spec: appsv1.DaemonSetSpec{
+					Selector: &metav1.LabelSelector{
+						MatchLabels: podSpec.Labels,
+					},
+					Template: podSpec,
+				},
+			},
+			metav1.CreateOptions{},
+		)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to create DaemonSet (%v)", err)
+	}
+	ts.cfg.Logger.Info("created DaemonSet",
+		zap.String("stresser-duration", ts.cfg.EKSConfig.AddOnStresserRemote.Duration.String()),
+	)
+
+	workers, err := ts.waitDaemonSet()
+	if err != nil {
+		return err
+	}
+
+	ts.cfg.Logger.Info("waiting for load generators", zap.Int32("workers", workers))
+	select {
+	case <-ts.cfg.Stopc:
+		return errors.New("stresser aborted")
+	case <-time.After(ts.cfg.EKSConfig.AddOnStresserRemote.Duration):
+	}
+	return ts.waitResults(int(workers))
+}
+
+// waitDaemonSet waits until all DaemonSet Pods are ready,
+// and returns the number of load generators.
+func (ts *tester) waitDaemonSet() (int32, error) {
+	waitStart := time.Now()
+	for time.Since(waitStart) < 10*time.Minute {
+		select {
+		case <-ts.cfg.Stopc:
+			return 0, errors.New("DaemonSet wait aborted")
+		case <-time.After(15 * time.Second):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		ds, err := ts.cfg.K8SClient.KubernetesClientSet().
+			AppsV1().
+			DaemonSets(ts.cfg.EKSConfig.AddOnStresserRemote.Namespace).
+			Get(ctx, stresserDaemonSetName, metav1.GetOptions{})
+		cancel()
+		if err != nil {
+			ts.cfg.Logger.Warn("failed to get DaemonSet", zap.Error(err))
+			continue
+		}
+		ts.cfg.Logger.Info("polled DaemonSet",
+			zap.Int32("desired", ds.Status.DesiredNumberScheduled),
+			zap.Int32("ready", ds.Status.NumberReady),
+		)
+		if ds.Status.DesiredNumberScheduled > 0 && ds.Status.NumberReady == ds.Status.DesiredNumberScheduled {
+			return ds.Status.DesiredNumberScheduled, nil
+		}
+	}
+	return 0, errors.New("DaemonSet not ready")
+}
+
+// waitResults waits until every load generator uploads its
+// writes and reads summaries.
+func (ts *tester) waitResults(workers int) error {
+	dirs := map[string]string{
+		path.Dir(ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryWritesJSONS3Key): "-writes-summary.json",
+		path.Dir(ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryReadsJSONS3Key):  "-reads-summary.json",
+	}
+	waitStart := time.Now()
+	for time.Since(waitStart) < 15*time.Minute {
+		done := true
+		for dir, sfx := range dirs {
+			objs, err := aws_s3.ListInDescendingLastModified(
+				ts.cfg.Logger,
+				ts.cfg.S3API,
+				ts.cfg.EKSConfig.S3.BucketName,
+				dir,
+			)
+			if err != nil {
+				ts.cfg.Logger.Warn("failed to list results", zap.String("s3-dir", dir), zap.Error(err))
+				done = false
+				continue
+			}
+			cnt := countResults(objs, sfx)
+			ts.cfg.Logger.Info("listed results", zap.String("s3-dir", dir), zap.Int("results", cnt), zap.Int("workers", workers))
+			if cnt < workers {
+				done = false
+			}
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ts.cfg.Stopc:
+			return errors.New("results wait aborted")
+		case <-time.After(30 * time.Second):
+		}
+	}
+	return fmt.Errorf("not all %d load generators uploaded results", workers)
+}
+
+func countResults(objs []*s3.Object, sfx string) (cnt int) {
+	for _, obj := range objs {
+		if strings.HasSuffix(aws.StringValue(obj.Key), sfx) {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+func (ts *tester) deleteDaemonSet() error {
+	foreground := metav1.DeletePropagationForeground
+	ts.cfg.Logger.Info("deleting DaemonSet", zap.String("name", stresserDaemonSetName))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err := ts.cfg.K8SClient.KubernetesClientSet().
+		AppsV1().
+		DaemonSets(ts.cfg.EKSConfig.AddOnStresserRemote.Namespace).
+		Delete(
+			ctx,
+			stresserDaemonSetName,
+			metav1.DeleteOptions{
+				GracePeriodSeconds: aws.Int64(0),
+				PropagationPolicy:  &foreground,
+			},
+		)
+	cancel()
+	if err != nil && !apierrs.IsNotFound(err) {
+		ts.cfg.Logger.Warn("failed to delete DaemonSet", zap.Error(err))
+		return err
+	}
+	ts.cfg.Logger.Info("deleted DaemonSet", zap.String("name", stresserDaemonSetName))
+	return nil
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/ecr"
 	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
@@ -70,6 +71,25 @@ func New(cfg Config) eks_tester.Tester {
 	return &tester{cfg: cfg}
 }
 
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:      "stresser-remote",
+		Exclusive: true,
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+				S3API:     cfg.S3API,
+				CWAPI:     cfg.CWAPI,
+				ECRAPI:    ecr.New(cfg.AWSSession, aws.NewConfig().WithRegion(cfg.EKSConfig.GetAddOnStresserRemoteRepositoryRegion())),
+			}), cfg.EKSConfig.IsEnabledAddOnStresserRemote)
+		},
+	})
+}
+
 type tester struct {
 	cfg      Config
 	ecrImage string
@@ -86,13 +106,15 @@ func (ts *tester) Create() (err error) {
 	}
 
 	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
-	ts.cfg.EKSConfig.AddOnStresserRemote.Created = true
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnStresserRemote.Created = true
+	})
 	createStart := time.Now()
 	defer func() {
 		createEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnStresserRemote.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnStresserRemote.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
 	}()
 
 	if ts.ecrImage, _, err = aws_ecr.Check(
@@ -126,6 +148,29 @@ func (ts *tester) Create() (err error) {
 		return err
 	}
 
+	switch ts.cfg.EKSConfig.AddOnStresserRemote.WorkloadType {
+	case eksconfig.StresserRemoteWorkloadTypeDaemonSet:
+		err = ts.runDaemonSet()
+	default:
+		err = ts.runJob()
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = ts.checkResults(); err != nil {
+		return err
+	}
+	if err = ts.publishResults(); err != nil {
+		return err
+	}
+
+	ts.cfg.EKSConfig.Sync()
+	return nil
+}
+
+// runJob runs the load generators as a Job, and waits for its completion.
+func (ts *tester) runJob() (err error) {
 	if err = ts.createJob(); err != nil {
 		return err
 	}
@@ -239,15 +284,6 @@ func (ts *tester) Create() (err error) {
 		fmt.Fprintf(ts.cfg.LogWriter, "Job Pod %q: %q\n", item.Name, item.Status.Phase)
 	}
 	fmt.Fprintf(ts.cfg.LogWriter, "\n")
-
-	if err = ts.checkResults(); err == nil {
-		return err
-	}
-	if err = ts.publishResults(); err != nil {
-		return err
-	}
-
-	ts.cfg.EKSConfig.Sync()
 	return nil
 }
 
@@ -265,14 +301,22 @@ func (ts *tester) Delete() error {
 	deleteStart := time.Now()
 	defer func() {
 		deleteEnd := time.Now()
-		ts.cfg.EKSConfig.AddOnStresserRemote.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
-		ts.cfg.EKSConfig.Sync()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnStresserRemote.TimeFrameDelete = timeutil.NewTimeFrame(deleteStart, deleteEnd)
+		})
 	}()
 
 	var errs []string
 
-	if err := ts.deleteJob(); err != nil {
-		errs = append(errs, err.Error())
+	switch ts.cfg.EKSConfig.AddOnStresserRemote.WorkloadType {
+	case eksconfig.StresserRemoteWorkloadTypeDaemonSet:
+		if err := ts.deleteDaemonSet(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	default:
+		if err := ts.deleteJob(); err != nil {
+			errs = append(errs, err.Error())
+		}
 	}
 	time.Sleep(2 * time.Minute)
 
@@ -326,8 +370,9 @@ func (ts *tester) Delete() error {
 		return errors.New(strings.Join(errs, ", "))
 	}
 
-	ts.cfg.EKSConfig.AddOnStresserRemote.Created = false
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnStresserRemote.Created = false
+	})
 	return nil
 }
 
@@ -674,12 +719,43 @@ func (ts *tester) createJob() (err error) {
 }
 
 func (ts *tester) createObject() (batchv1.Job, string, error) {
+	testerCmd, err := ts.createTesterCmd()
+	if err != nil {
+		return batchv1.Job{}, "", err
+	}
+
+	// spec.template.spec.restartPolicy: Unsupported value: "Always": supported values: "OnFailure", "Never"
+	// ref. https://github.com/kubernetes/kubernetes/issues/54870
+	podSpec := ts.createPodTemplate(v1.RestartPolicyNever, []string{"/bin/sh", "-ec", testerCmd})
+
+	jobObj := batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "batch/v1",
+			Kind:       "Job",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      stresserJobName,
+			Namespace: ts.cfg.EKSConfig.AddOnStresserRemote.Namespace,
+		},
+		Spec: batchv1.JobSpec{
+			Completions: aws.Int32(int32(ts.cfg.EKSConfig.AddOnStresserRemote.Completes)),
+			Parallelism: aws.Int32(int32(ts.cfg.EKSConfig.AddOnStresserRemote.Parallels)),
+			Template:    podSpec,
+			// TODO: 'TTLSecondsAfterFinished' is still alpha
+			// https://kubernetes.io/docs/concepts/workloads/controllers/ttlafterfinished/
+		},
+	}
+	b, err := yaml.Marshal(jobObj)
+	return jobObj, string(b), err
+}
+
+func (ts *tester) createTesterCmd() (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 	nss, err := ts.cfg.K8SClient.KubernetesClientSet().CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
 	cancel()
 	if err != nil {
 		ts.cfg.Logger.Warn("list namespaces failed", zap.Error(err))
-		return batchv1.Job{}, "", err
+		return "", err
 	}
 	ns := make([]string, 0, len(nss.Items))
 	for _, nv := range nss.Items {
@@ -712,9 +788,12 @@ func (ts *tester) createObject() (batchv1.Job, string, error) {
 		ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryWritesOutputNamePrefix,
 		ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryReadsOutputNamePrefix,
 	)
+	return testerCmd, nil
+}
 
+func (ts *tester) createPodTemplate(restartPolicy v1.RestartPolicy, command []string) v1.PodTemplateSpec {
 	dirOrCreate := v1.HostPathDirectoryOrCreate
-	podSpec := v1.PodTemplateSpec{
+	return v1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
 			Labels: map[string]string{
 				"app.kubernetes.io/name": stresserAppName,
@@ -722,10 +801,7 @@ func (ts *tester) createObject() (batchv1.Job, string, error) {
 		},
 		Spec: v1.PodSpec{
 			ServiceAccountName: stresserServiceAccountName,
-
-			// spec.template.spec.restartPolicy: Unsupported value: "Always": supported values: "OnFailure", "Never"
-			// ref. https://github.com/kubernetes/kubernetes/issues/54870
-			RestartPolicy: v1.RestartPolicyNever,
+			RestartPolicy:      restartPolicy,
 
 			// TODO: set resource limits
 			Containers: []v1.Container{
@@ -734,11 +810,7 @@ func (ts *tester) createObject() (batchv1.Job, string, error) {
 					Image:           ts.ecrImage,
 					ImagePullPolicy: v1.PullAlways,
 
-					Command: []string{
-						"/bin/sh",
-						"-ec",
-						testerCmd,
-					},
+					Command: command,
 
 					// grant access "/dev/kmsg"
 					SecurityContext: &v1.SecurityContext{
@@ -790,26 +862,6 @@ func (ts *tester) createObject() (batchv1.Job, string, error) {
 			},
 		},
 	}
-
-	jobObj := batchv1.Job{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "batch/v1",
-			Kind:       "Job",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      stresserJobName,
-			Namespace: ts.cfg.EKSConfig.AddOnStresserRemote.Namespace,
-		},
-		Spec: batchv1.JobSpec{
-			Completions: aws.Int32(int32(ts.cfg.EKSConfig.AddOnStresserRemote.Completes)),
-			Parallelism: aws.Int32(int32(ts.cfg.EKSConfig.AddOnStresserRemote.Parallels)),
-			Template:    podSpec,
-			// TODO: 'TTLSecondsAfterFinished' is still alpha
-			// https://kubernetes.io/docs/concepts/workloads/controllers/ttlafterfinished/
-		},
-	}
-	b, err := yaml.Marshal(jobObj)
-	return jobObj, string(b), err
 }
 
 func (ts *tester) deleteJob() (err error) {
@@ -1042,8 +1094,9 @@ func (ts *tester) checkResults() (err error) {
 	writesSummary.LantencyP99 = curWriteLatencies.PickLantencyP99()
 	writesSummary.LantencyP999 = curWriteLatencies.PickLantencyP999()
 	writesSummary.LantencyP9999 = curWriteLatencies.PickLantencyP9999()
-	ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryWrites = writesSummary
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryWrites = writesSummary
+	})
 
 	sortStart = time.Now()
 	ts.cfg.Logger.Info("sorting read latencies", zap.Int("data", len(curReadLatencies)))
@@ -1054,8 +1107,9 @@ func (ts *tester) checkResults() (err error) {
 	readsSummary.LantencyP99 = curReadLatencies.PickLantencyP99()
 	readsSummary.LantencyP999 = curReadLatencies.PickLantencyP999()
 	readsSummary.LantencyP9999 = curReadLatencies.PickLantencyP9999()
-	ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryReads = readsSummary
-	ts.cfg.EKSConfig.Sync()
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryReads = readsSummary
+	})
 
 	wb, err := json.Marshal(curWriteLatencies)
 	if err != nil {
@@ -1169,11 +1223,15 @@ func (ts *tester) checkResults() (err error) {
 			ts.cfg.Logger.Warn("failed to download results", zap.Error(err))
 			return err
 		}
-		ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryWritesCompare, err = metrics.CompareRequestsSummary(prevSummary, ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryWrites)
+		var compare metrics.RequestsCompare
+		compare, err = metrics.CompareRequestsSummary(prevSummary, ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryWrites)
 		if err != nil {
 			ts.cfg.Logger.Warn("failed to compare results", zap.Error(err))
 			return err
 		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryWritesCompare = compare
+		})
 		if err = ioutil.WriteFile(ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryWritesCompareJSONPath, []byte(ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryWritesCompare.JSON()), 0600); err != nil {
 			ts.cfg.Logger.Warn("failed to write file", zap.Error(err))
 			return err
@@ -1301,11 +1359,15 @@ func (ts *tester) checkResults() (err error) {
 			ts.cfg.Logger.Warn("failed to download results", zap.Error(err))
 			return err
 		}
-		ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryReadsCompare, err = metrics.CompareRequestsSummary(prevSummary, ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryReads)
+		var compare metrics.RequestsCompare
+		compare, err = metrics.CompareRequestsSummary(prevSummary, ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryReads)
 		if err != nil {
 			ts.cfg.Logger.Warn("failed to compare results", zap.Error(err))
 			return err
 		}
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryReadsCompare = compare
+		})
 		if err = ioutil.WriteFile(ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryReadsCompareJSONPath, []byte(ts.cfg.EKSConfig.AddOnStresserRemote.RequestsSummaryReadsCompare.JSON()), 0600); err != nil {
 			ts.cfg.Logger.Warn("failed to write file", zap.Error(err))
 			return err
@@ -0,0 +1,207 @@
+package tester
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-k8s-tester/eks/access"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/ssh"
+	aws_cfn_v2 "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_elbv2_v2 "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	aws_iam_v2 "github.com/aws/aws-sdk-go-v2/service/iam"
+	aws_ssm_v2 "github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+	"github.com/aws/aws-sdk-go/service/ec2instanceconnect/ec2instanceconnectiface"
+	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
+	aws_eks "github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"go.uber.org/zap"
+)
+
+// AddonConfig is the configuration shared by the registered add-ons.
+type AddonConfig struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+
+	EKSAPI     eksiface.EKSAPI
+	EC2APIV2   *aws_ec2_v2.Client
+	IAMAPIV2   *aws_iam_v2.Client
+	ELBV2APIV2 *aws_elbv2_v2.Client
+
+	// AWSSession creates the clients in the other regions
+	// (e.g. the ECR repositories of the add-ons).
+	AWSSession *session.Session
+	// ECRAPI is the ECR client in the cluster region.
+	ECRAPI    ecriface.ECRAPI
+	S3API     s3iface.S3API
+	CWAPI     cloudwatchiface.CloudWatchAPI
+	CWLogsAPI cloudwatchlogsiface.CloudWatchLogsAPI
+	ELBV2API  elbv2iface.ELBV2API
+	IAMAPI    iamiface.IAMAPI
+	CFNAPIV2  *aws_cfn_v2.Client
+	// EKSClient sends the EKS operations not modeled in "EKSAPI".
+	EKSClient *aws_eks.EKS
+
+	// SSMAPIV2, EC2InstanceConnectAPI, and SSHHostKeys
+	// run the commands on the nodes (see "ssh.Node").
+	SSMAPIV2              *aws_ssm_v2.Client
+	EC2InstanceConnectAPI ec2instanceconnectiface.EC2InstanceConnectAPI
+	SSHHostKeys           *ssh.HostKeys
+
+	// Access maps the IAM principals to the cluster.
+	Access access.Manager
+	// MNGUpgrader upgrades the managed node groups
+	// (e.g. cascaded from the cluster version upgrade).
+	MNGUpgrader interface{ Upgrade() error }
+}
+
+// Registration defines an add-on in the registry.
+type Registration struct {
+	// Name is the unique name of the add-on.
+	Name string
+	// Dependencies is the names of the add-ons that must be applied
+	// before this add-on, and deleted after this add-on.
+	// Dependencies on the unregistered add-ons are errors, while the
+	// dependencies on the disabled add-ons are ignored.
+	Dependencies []string
+	// Exclusive is true to apply the add-on alone, since it disrupts the
+	// cluster (e.g. upgrade, fault injection) or measures the latencies
+	// that the concurrent add-ons would skew.
+	Exclusive bool
+	// New returns the add-on, enabled or disabled by its "IsEnabled".
+	New func(cfg AddonConfig) Addon
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Registration)
+)
+
+// Register registers the add-on, usually from the "init" function of
+// the add-on package, so that importing the package (including the
+// out-of-tree add-ons) is enough to apply the add-on.
+// It panics if the name is empty or already registered.
+func Register(r Registration) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if r.Name == "" || r.New == nil {
+		panic("add-on registration requires name and constructor")
+	}
+	if _, ok := registry[r.Name]; ok {
+		panic(fmt.Sprintf("add-on %q already registered", r.Name))
+	}
+	registry[r.Name] = r
+}
+
+// Registered returns the registered add-ons, sorted by name.
+func Registered() []Registration {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	rs := make([]Registration, 0, len(registry))
+	for _, r := range registry {
+		rs = append(rs, r)
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].Name < rs[j].Name })
+	return rs
+}
+
+// Order returns the add-ons in groups, in the dependency order.
+// Each group only depends on the previous groups, so that the add-ons
+// in the same group can be applied in parallel, and the groups are
+// deleted in the reverse order. The exclusive add-ons are in their own
+// groups, following the other add-ons of the same dependency level.
+func Order(rs []Registration) ([][]Registration, error) {
+	byName := make(map[string]Registration, len(rs))
+	indegree := make(map[string]int, len(rs))
+	for _, r := range rs {
+		byName[r.Name] = r
+		indegree[r.Name] = 0
+	}
+	dependents := make(map[string][]string)
+	for _, r := range rs {
+		for _, dep := range r.Dependencies {
+			if _, ok := byName[dep]; !ok {
+				return nil, fmt.Errorf("add-on %q depends on unregistered add-on %q", r.Name, dep)
+			}
+			indegree[r.Name]++
+			dependents[dep] = append(dependents[dep], r.Name)
+		}
+	}
+
+	var groups [][]Registration
+	var next []string
+	for name, n := range indegree {
+		if n == 0 {
+			next = append(next, name)
+		}
+	}
+	ordered := 0
+	for len(next) > 0 {
+		sort.Strings(next)
+		group := make([]Registration, 0, len(next))
+		var exclusive [][]Registration
+		var following []string
+		for _, name := range next {
+			if byName[name].Exclusive {
+				exclusive = append(exclusive, []Registration{byName[name]})
+			} else {
+				group = append(group, byName[name])
+			}
+			for _, d := range dependents[name] {
+				indegree[d]--
+				if indegree[d] == 0 {
+					following = append(following, d)
+				}
+			}
+		}
+		if len(group) > 0 {
+			groups = append(groups, group)
+		}
+		groups = append(groups, exclusive...)
+		ordered += len(next)
+		next = following
+	}
+
+	if ordered != len(byName) {
+		var cyclic []string
+		for name, n := range indegree {
+			if n > 0 {
+				cyclic = append(cyclic, name)
+			}
+		}
+		sort.Strings(cyclic)
+		return nil, fmt.Errorf("add-ons have cyclic dependencies %s", strings.Join(cyclic, ", "))
+	}
+	return groups, nil
+}
+
+// NewTesterAddon adapts the tester to the add-on interface, so that the
+// tester can be registered with its dependencies, and created concurrently
+// with the independent add-ons. The tester "Create" must be idempotent.
+func NewTesterAddon(t Tester, enabled func() bool) Addon {
+	return &testerAddon{Tester: t, enabled: enabled}
+}
+
+type testerAddon struct {
+	Tester
+	enabled func() bool
+}
+
+func (ta *testerAddon) Apply() error    { return ta.Create() }
+func (ta *testerAddon) IsEnabled() bool { return ta.enabled() }
@@ -0,0 +1,78 @@
+package tester
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOrder(t *testing.T) {
+	groups, err := Order([]Registration{
+		{Name: "overprovisioning", Dependencies: []string{"cluster-autoscaler"}},
+		{Name: "metrics-server"},
+		{Name: "cluster-loader", Dependencies: []string{"metrics-server", "overprovisioning"}},
+		{Name: "cluster-autoscaler"},
+		{Name: "version-upgrade", Exclusive: true},
+		{Name: "version-skew", Dependencies: []string{"version-upgrade"}},
+		{Name: "chaos", Exclusive: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names [][]string
+	for _, g := range groups {
+		var ns []string
+		for _, r := range g {
+			ns = append(ns, r.Name)
+		}
+		names = append(names, ns)
+	}
+	exp := [][]string{
+		{"cluster-autoscaler", "metrics-server"},
+		{"chaos"},
+		{"version-upgrade"},
+		{"overprovisioning", "version-skew"},
+		{"cluster-loader"},
+	}
+	if !reflect.DeepEqual(names, exp) {
+		t.Fatalf("expected %v, got %v", exp, names)
+	}
+}
+
+func TestOrderErrors(t *testing.T) {
+	_, err := Order([]Registration{{Name: "a", Dependencies: []string{"b"}}})
+	if err == nil || !strings.Contains(err.Error(), "unregistered") {
+		t.Fatalf("expected unregistered dependency error, got %v", err)
+	}
+	_, err = Order([]Registration{
+		{Name: "a", Dependencies: []string{"b"}},
+		{Name: "b", Dependencies: []string{"a"}},
+		{Name: "c"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "cyclic dependencies a, b") {
+		t.Fatalf("expected cyclic dependency error, got %v", err)
+	}
+}
+
+type fakeTester struct{ created int }
+
+func (ft *fakeTester) Name() string  { return "fake" }
+func (ft *fakeTester) Create() error { ft.created++; return nil }
+func (ft *fakeTester) Delete() error { return nil }
+
+func TestNewTesterAddon(t *testing.T) {
+	ft := &fakeTester{}
+	a := NewTesterAddon(ft, func() bool { return true })
+	if !a.IsEnabled() {
+		t.Fatal("expected enabled add-on")
+	}
+	if err := a.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if ft.created != 1 {
+		t.Fatalf("expected tester created once, got %d", ft.created)
+	}
+	if n, ok := a.(interface{ Name() string }); !ok || n.Name() != "fake" {
+		t.Fatal("expected add-on to keep the tester name")
+	}
+}
@@ -0,0 +1,189 @@
+package versionskew
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// Component kinds.
+const (
+	KindControlPlane = "control-plane"
+	KindKubelet      = "kubelet"
+	KindKubeProxy    = "kube-proxy"
+	KindCoreDNS      = "coredns"
+	KindVPCCNI       = "aws-node"
+)
+
+// Component is a versioned component in the cluster.
+type Component struct {
+	// Kind is the component kind (e.g. "kubelet").
+	Kind string
+	// Name is the node or workload name.
+	Name string
+	// Version is the raw version string (e.g. "v1.27.4-eks-8ccc7ba").
+	Version string
+	// Violation is non-empty if the component violates the skew policy.
+	Violation string
+}
+
+// Matrix is the collected versions of the cluster.
+type Matrix struct {
+	ControlPlane string
+	Components   []Component
+}
+
+// minCoreDNS is the minimum CoreDNS version for each Kubernetes minor version.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/managing-coredns.html
+var minCoreDNS = map[int]string{
+	20: "1.8.3",
+	21: "1.8.4",
+	22: "1.8.7",
+	23: "1.8.7",
+	24: "1.9.3",
+	25: "1.9.3",
+	26: "1.9.3",
+	27: "1.10.1",
+	28: "1.10.1",
+	29: "1.11.1",
+	30: "1.11.1",
+}
+
+// minVPCCNI is the minimum Amazon VPC CNI version for each Kubernetes minor version.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/managing-vpc-cni.html
+var minVPCCNI = map[int]string{
+	20: "1.7.5",
+	21: "1.7.5",
+	22: "1.10.1",
+	23: "1.10.4",
+	24: "1.11.4",
+	25: "1.12.0",
+	26: "1.12.5",
+	27: "1.12.6",
+	28: "1.13.4",
+	29: "1.15.5",
+	30: "1.16.0",
+}
+
+// Validate checks every component against the control plane version,
+// records the violations in the matrix, and returns the number of violations.
+// ref. https://kubernetes.io/releases/version-skew-policy/
+func (m *Matrix) Validate() (violations int) {
+	cpMajor, cpMinor, _, err := parseVersion(m.ControlPlane)
+	if err != nil {
+		m.Components = append([]Component{{
+			Kind:      KindControlPlane,
+			Version:   m.ControlPlane,
+			Violation: err.Error(),
+		}}, m.Components...)
+		return 1
+	}
+
+	// kubelet and kube-proxy may be up to three minor versions older
+	// since 1.28, and two minor versions older before
+	maxSkew := 2
+	if cpMajor > 1 || cpMinor >= 28 {
+		maxSkew = 3
+	}
+
+	for i, c := range m.Components {
+		major, minor, patch, err := parseVersion(c.Version)
+		if err != nil {
+			m.Components[i].Violation = err.Error()
+			violations++
+			continue
+		}
+		switch c.Kind {
+		case KindKubelet, KindKubeProxy:
+			switch {
+			case major != cpMajor:
+				m.Components[i].Violation = fmt.Sprintf("major version %d != control plane %d", major, cpMajor)
+			case minor > cpMinor:
+				m.Components[i].Violation = fmt.Sprintf("newer than control plane 1.%d", cpMinor)
+			case cpMinor-minor > maxSkew:
+				m.Components[i].Violation = fmt.Sprintf("older than control plane 1.%d by more than %d minor versions", cpMinor, maxSkew)
+			}
+
+		case KindCoreDNS:
+			m.Components[i].Violation = checkMinVersion(minCoreDNS, cpMinor, major, minor, patch)
+
+		case KindVPCCNI:
+			m.Components[i].Violation = checkMinVersion(minVPCCNI, cpMinor, major, minor, patch)
+		}
+		if m.Components[i].Violation != "" {
+			violations++
+		}
+	}
+	return violations
+}
+
+// checkMinVersion returns the violation if the version is older than
+// the minimum version required by the control plane minor version,
+// or empty if the control plane version has no minimum version.
+func checkMinVersion(mins map[int]string, cpMinor int, major int, minor int, patch int) string {
+	min, ok := mins[cpMinor]
+	if !ok {
+		return ""
+	}
+	wantMajor, wantMinor, wantPatch, _ := parseVersion(min)
+	if major < wantMajor ||
+		(major == wantMajor && minor < wantMinor) ||
+		(major == wantMajor && minor == wantMinor && patch < wantPatch) {
+		return fmt.Sprintf("older than %s required by control plane 1.%d", min, cpMinor)
+	}
+	return ""
+}
+
+// String returns the matrix in table format.
+func (m Matrix) String() string {
+	buf := bytes.NewBuffer(nil)
+	tb := tablewriter.NewWriter(buf)
+	tb.SetAutoWrapText(false)
+	tb.SetAlignment(tablewriter.ALIGN_LEFT)
+	tb.SetCenterSeparator("*")
+	tb.SetHeader([]string{"kind", "name", "version", "violation"})
+	tb.Append([]string{KindControlPlane, "", m.ControlPlane, ""})
+	for _, c := range m.Components {
+		tb.Append([]string{c.Kind, c.Name, c.Version, c.Violation})
+	}
+	tb.Render()
+	return buf.String()
+}
+
+// parseVersion parses versions such as "v1.27.4-eks-8ccc7ba"
+// and "v1.10.1-eksbuild.2".
+func parseVersion(v string) (major int, minor int, patch int, err error) {
+	s := strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if idx := strings.IndexAny(s, "-+"); idx >= 0 {
+		s = s[:idx]
+	}
+	ss := strings.Split(s, ".")
+	if len(ss) < 2 {
+		return 0, 0, 0, fmt.Errorf("cannot parse version %q", v)
+	}
+	if major, err = strconv.Atoi(ss[0]); err != nil {
+		return 0, 0, 0, fmt.Errorf("cannot parse version %q (%v)", v, err)
+	}
+	if minor, err = strconv.Atoi(ss[1]); err != nil {
+		return 0, 0, 0, fmt.Errorf("cannot parse version %q (%v)", v, err)
+	}
+	if len(ss) > 2 {
+		if patch, err = strconv.Atoi(ss[2]); err != nil {
+			return 0, 0, 0, fmt.Errorf("cannot parse version %q (%v)", v, err)
+		}
+	}
+	return major, minor, patch, nil
+}
+
+// imageTag returns the tag of the container image
+// (e.g. "v1.10.1-eksbuild.2" of ".../eks/coredns:v1.10.1-eksbuild.2").
+func imageTag(image string) string {
+	idx := strings.LastIndex(image, ":")
+	if idx < 0 || idx < strings.LastIndex(image, "/") {
+		return ""
+	}
+	return image[idx+1:]
+}
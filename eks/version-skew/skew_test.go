@@ -0,0 +1,95 @@
+package versionskew
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		m          Matrix
+		violations int
+	}{
+		{
+			m: Matrix{
+				ControlPlane: "v1.27.4-eks-2d98532",
+				Components: []Component{
+					{Kind: KindKubelet, Name: "a", Version: "v1.27.4-eks-8ccc7ba"},
+					{Kind: KindKubelet, Name: "b", Version: "v1.25.12-eks-8ccc7ba"},
+					{Kind: KindKubeProxy, Name: "kube-proxy", Version: "v1.27.4-minimal-eksbuild.2"},
+					{Kind: KindCoreDNS, Name: "coredns", Version: "v1.10.1-eksbuild.2"},
+					{Kind: KindVPCCNI, Name: "aws-node", Version: "v1.15.1-eksbuild.1"},
+				},
+			},
+			violations: 0,
+		},
+		{
+			m: Matrix{
+				ControlPlane: "v1.27.4-eks-2d98532",
+				Components: []Component{
+					{Kind: KindKubelet, Name: "newer", Version: "v1.28.1-eks-8ccc7ba"},
+					{Kind: KindKubelet, Name: "too-old", Version: "v1.24.16-eks-8ccc7ba"},
+					{Kind: KindCoreDNS, Name: "coredns", Version: "v1.9.3-eksbuild.6"},
+					{Kind: KindVPCCNI, Name: "aws-node", Version: "v1.12.5-eksbuild.2"},
+				},
+			},
+			violations: 4,
+		},
+		{
+			// three minor versions skew is allowed since 1.28
+			m: Matrix{
+				ControlPlane: "v1.28.2-eks-f8587cb",
+				Components: []Component{
+					{Kind: KindKubelet, Name: "a", Version: "v1.25.12-eks-8ccc7ba"},
+					{Kind: KindKubeProxy, Name: "kube-proxy", Version: "v1.24.0"},
+				},
+			},
+			violations: 1,
+		},
+		{
+			m: Matrix{
+				ControlPlane: "",
+				Components: []Component{
+					{Kind: KindKubelet, Name: "a", Version: "v1.25.12-eks-8ccc7ba"},
+				},
+			},
+			violations: 1,
+		},
+	}
+	for i, tv := range tests {
+		n := tv.m.Validate()
+		if n != tv.violations {
+			t.Fatalf("#%d: expected %d violations, got %d\n%s", i, tv.violations, n, tv.m)
+		}
+	}
+}
+
+func TestMatrixString(t *testing.T) {
+	m := Matrix{
+		ControlPlane: "v1.27.4-eks-2d98532",
+		Components: []Component{
+			{Kind: KindKubelet, Name: "ip-192-168-1-1", Version: "v1.28.1-eks-8ccc7ba"},
+		},
+	}
+	m.Validate()
+	s := m.String()
+	if !strings.Contains(s, "ip-192-168-1-1") || !strings.Contains(s, "newer than control plane 1.27") {
+		t.Fatalf("unexpected matrix\n%s", s)
+	}
+}
+
+func Test_imageTag(t *testing.T) {
+	tests := []struct {
+		image string
+		tag   string
+	}{
+		{"602401143452.dkr.ecr.us-west-2.amazonaws.com/eks/coredns:v1.10.1-eksbuild.2", "v1.10.1-eksbuild.2"},
+		{"localhost:5000/eks/kube-proxy", ""},
+		{"kube-proxy", ""},
+	}
+	for i, tv := range tests {
+		if tag := imageTag(tv.image); tag != tv.tag {
+			t.Fatalf("#%d: expected %q, got %q", i, tv.tag, tag)
+		}
+	}
+}
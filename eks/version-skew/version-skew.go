@@ -0,0 +1,183 @@
+// Package versionskew implements version skew validation between
+// the control plane, kubelets, and core add-ons.
+package versionskew
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"time"
+
+	eks_tester "github.com/aws/aws-k8s-tester/eks/tester"
+	"github.com/aws/aws-k8s-tester/eksconfig"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"go.uber.org/zap"
+)
+
+// Config defines version skew validation configuration.
+type Config struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+	Stopc     chan struct{}
+	EKSConfig *eksconfig.Config
+	K8SClient k8s_client.EKS
+}
+
+var pkgName = reflect.TypeOf(tester{}).PkgPath()
+
+func (ts *tester) Name() string { return pkgName }
+
+// New creates a new version skew tester.
+func New(cfg Config) eks_tester.Tester {
+	cfg.Logger.Info("creating tester", zap.String("tester", pkgName))
+	return &tester{cfg: cfg}
+}
+
+func init() {
+	eks_tester.Register(eks_tester.Registration{
+		Name:         "version-skew",
+		Dependencies: []string{"cluster-version-upgrade"},
+		New: func(cfg eks_tester.AddonConfig) eks_tester.Addon {
+			return eks_tester.NewTesterAddon(New(Config{
+				Logger:    cfg.Logger,
+				LogWriter: cfg.LogWriter,
+				Stopc:     cfg.Stopc,
+				EKSConfig: cfg.EKSConfig,
+				K8SClient: cfg.K8SClient,
+			}), cfg.EKSConfig.IsEnabledAddOnVersionSkew)
+		},
+	})
+}
+
+type tester struct {
+	cfg Config
+}
+
+func (ts *tester) Create() (err error) {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnVersionSkew() {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+	if ts.cfg.EKSConfig.AddOnVersionSkew.Created {
+		ts.cfg.Logger.Info("skipping tester.Create", zap.String("tester", pkgName))
+		return nil
+	}
+
+	ts.cfg.Logger.Info("starting tester.Create", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnVersionSkew.Created = true
+	})
+	createStart := time.Now()
+	defer func() {
+		createEnd := time.Now()
+		ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+			ts.cfg.EKSConfig.AddOnVersionSkew.TimeFrameCreate = timeutil.NewTimeFrame(createStart, createEnd)
+		})
+	}()
+
+	m, err := ts.collect()
+	if err != nil {
+		return err
+	}
+	violations := m.Validate()
+	fmt.Fprintf(ts.cfg.LogWriter, "\nversion skew matrix:\n%s\n", m)
+	if violations > 0 {
+		ts.cfg.Logger.Warn("version skew violations found", zap.Int("violations", violations))
+		return fmt.Errorf("found %d version skew violation(s)\n%s", violations, m)
+	}
+
+	ts.cfg.Logger.Info("validated version skew", zap.Int("components", len(m.Components)))
+	return nil
+}
+
+func (ts *tester) Delete() error {
+	if !ts.cfg.EKSConfig.IsEnabledAddOnVersionSkew() {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+	if !ts.cfg.EKSConfig.AddOnVersionSkew.Created {
+		ts.cfg.Logger.Info("skipping tester.Delete", zap.String("tester", pkgName))
+		return nil
+	}
+
+	// nothing to delete
+	ts.cfg.Logger.Info("starting tester.Delete", zap.String("tester", pkgName))
+	ts.cfg.EKSConfig.RecordAddOnStatus(func() {
+		ts.cfg.EKSConfig.AddOnVersionSkew.Created = false
+	})
+	return nil
+}
+
+// addOnContainers maps core add-on workloads in "kube-system"
+// to their component kinds.
+var addOnContainers = map[string]string{
+	"kube-proxy": KindKubeProxy,
+	"aws-node":   KindVPCCNI,
+	"coredns":    KindCoreDNS,
+}
+
+func (ts *tester) collect() (m Matrix, err error) {
+	ts.cfg.Logger.Info("collecting component versions")
+	sv, err := ts.cfg.K8SClient.FetchServerVersion()
+	if err != nil {
+		return Matrix{}, err
+	}
+	if sv.GitVersion == "" {
+		return Matrix{}, errors.New("empty control plane version")
+	}
+	m.ControlPlane = sv.GitVersion
+
+	nodes, err := ts.cfg.K8SClient.ListNodes(1000, 5*time.Second)
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to list nodes", zap.Error(err))
+		return Matrix{}, err
+	}
+	for _, node := range nodes {
+		m.Components = append(m.Components, Component{
+			Kind:    KindKubelet,
+			Name:    node.GetName(),
+			Version: node.Status.NodeInfo.KubeletVersion,
+		})
+	}
+
+	dss, err := ts.cfg.K8SClient.ListAppsV1DaemonSets("kube-system", 1000, 5*time.Second)
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to list daemon sets", zap.Error(err))
+		return Matrix{}, err
+	}
+	for _, ds := range dss {
+		for _, c := range ds.Spec.Template.Spec.Containers {
+			if kind, ok := addOnContainers[c.Name]; ok && c.Name == ds.GetName() {
+				m.Components = append(m.Components, Component{Kind: kind, Name: ds.GetName(), Version: imageTag(c.Image)})
+			}
+		}
+	}
+	dps, err := ts.cfg.K8SClient.ListAppsV1Deployments("kube-system", 1000, 5*time.Second)
+	if err != nil {
+		ts.cfg.Logger.Warn("failed to list deployments", zap.Error(err))
+		return Matrix{}, err
+	}
+	for _, dp := range dps {
+		for _, c := range dp.Spec.Template.Spec.Containers {
+			if kind, ok := addOnContainers[c.Name]; ok && c.Name == dp.GetName() {
+				m.Components = append(m.Components, Component{Kind: kind, Name: dp.GetName(), Version: imageTag(c.Image)})
+			}
+		}
+	}
+
+	sort.SliceStable(m.Components, func(i, j int) bool {
+		if m.Components[i].Kind != m.Components[j].Kind {
+			return m.Components[i].Kind < m.Components[j].Kind
+		}
+		return m.Components[i].Name < m.Components[j].Name
+	})
+	ts.cfg.Logger.Info("collected component versions",
+		zap.String("control-plane", m.ControlPlane),
+		zap.Int("nodes", len(nodes)),
+		zap.Int("components", len(m.Components)),
+	)
+	return m, nil
+}
@@ -0,0 +1,114 @@
+package eksconfig
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Access defines the cluster access management, mapping the IAM principals
+// (e.g. node roles, CI debugging roles) to the Kubernetes identities.
+type Access struct {
+	// Mode is the access management mode, one of "auto", "access-entry",
+	// or "aws-auth". "auto" uses EKS access entries if the cluster supports
+	// them, and falls back to the "aws-auth" ConfigMap otherwise
+	// (e.g. older Kubernetes versions).
+	// ref. https://docs.aws.amazon.com/eks/latest/userguide/access-entries.html
+	Mode string `json:"mode"`
+	// Entries maps the name of the entry to the additional IAM principal
+	// to grant the cluster access (e.g. "ci-debug").
+	Entries map[string]AccessEntry `json:"entries"`
+
+	// ResolvedMode is the access management mode in use for the cluster,
+	// either "access-entry" or "aws-auth".
+	ResolvedMode string `json:"resolved-mode" read-only:"true"`
+}
+
+// AccessEntry is the IAM principal mapped to the Kubernetes identity.
+type AccessEntry struct {
+	// PrincipalARN is the IAM role or user ARN.
+	PrincipalARN string `json:"principal-arn"`
+	// Username is the Kubernetes username.
+	// Defaults to the principal ARN for the "aws-auth" mode,
+	// and to the EKS generated one for the "access-entry" mode.
+	Username string `json:"username"`
+	// Groups is the Kubernetes groups for RBAC.
+	// Use the access policies for the "system:" groups in the
+	// "access-entry" mode, since EKS rejects them in access entries.
+	Groups []string `json:"groups"`
+	// AccessPolicyARNs is the list of the EKS access policies associated
+	// with the cluster scope (e.g. "arn:aws:eks::aws:cluster-access-policy/AmazonEKSAdminViewPolicy").
+	// Only used in the "access-entry" mode.
+	AccessPolicyARNs []string `json:"access-policy-arns"`
+	// Verify is true to assume the role and list the nodes as the principal,
+	// to check that the mapped identity can access the cluster.
+	// Only valid for IAM roles that the caller can assume.
+	Verify bool `json:"verify"`
+
+	// Verified is true once the principal listed the nodes.
+	Verified bool `json:"verified" read-only:"true"`
+}
+
+const (
+	// AccessModeAuto uses EKS access entries if supported, and "aws-auth" otherwise.
+	AccessModeAuto = "auto"
+	// AccessModeAccessEntry uses EKS access entries.
+	AccessModeAccessEntry = "access-entry"
+	// AccessModeAWSAuth uses the "aws-auth" ConfigMap.
+	AccessModeAWSAuth = "aws-auth"
+)
+
+// AccessEntryMinVersion is the minimum Kubernetes version
+// with the EKS access entry support.
+const AccessEntryMinVersion = 1.23
+
+func getDefaultAccess() *Access {
+	return &Access{
+		Mode: AccessModeAuto,
+	}
+}
+
+// IsRole returns true if the principal is an IAM role.
+func (e AccessEntry) IsRole() bool {
+	return strings.Contains(e.PrincipalARN, ":role/")
+}
+
+func (cfg *Config) validateAccess() error {
+	if cfg.Access == nil {
+		cfg.Access = getDefaultAccess()
+	}
+	switch cfg.Access.Mode {
+	case "":
+		cfg.Access.Mode = AccessModeAuto
+	case AccessModeAuto, AccessModeAWSAuth:
+	case AccessModeAccessEntry:
+		if cfg.VersionValue < AccessEntryMinVersion {
+			return fmt.Errorf("Access.Mode %q requires Kubernetes version >= %.2f, got %q", cfg.Access.Mode, AccessEntryMinVersion, cfg.Version)
+		}
+	default:
+		return fmt.Errorf("unknown Access.Mode %q", cfg.Access.Mode)
+	}
+	for name, e := range cfg.Access.Entries {
+		if !strings.HasPrefix(e.PrincipalARN, "arn:") ||
+			(!e.IsRole() && !strings.Contains(e.PrincipalARN, ":user/")) {
+			return fmt.Errorf("invalid Access.Entries[%q].PrincipalARN %q (expected IAM role or user ARN)", name, e.PrincipalARN)
+		}
+		if len(e.Groups) == 0 && len(e.AccessPolicyARNs) == 0 {
+			return fmt.Errorf("empty Access.Entries[%q].Groups and AccessPolicyARNs (no permission granted)", name)
+		}
+		if e.Verify && !e.IsRole() {
+			return fmt.Errorf("Access.Entries[%q].Verify requires an IAM role, got %q", name, e.PrincipalARN)
+		}
+		if cfg.Access.Mode == AccessModeAccessEntry {
+			for _, g := range e.Groups {
+				if strings.HasPrefix(g, "system:") {
+					return fmt.Errorf("Access.Entries[%q].Groups %q not allowed in access entries (use AccessPolicyARNs)", name, g)
+				}
+			}
+		}
+		if cfg.Access.Mode == AccessModeAWSAuth && len(e.AccessPolicyARNs) > 0 {
+			fmt.Fprintf(os.Stderr, "[WARN] Access.Entries[%q].AccessPolicyARNs is ignored in %q mode\n", name, AccessModeAWSAuth)
+		}
+	}
+	return nil
+}
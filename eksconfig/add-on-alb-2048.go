@@ -40,7 +40,6 @@ type AddOnALB2048 struct {
 const EnvironmentVariablePrefixAddOnALB2048 = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_ALB_2048_"
 
 // IsEnabledAddOnALB2048 returns true if "AddOnALB2048" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnALB2048() bool {
 	if cfg.AddOnALB2048 == nil {
 		return false
@@ -48,7 +47,6 @@ func (cfg *Config) IsEnabledAddOnALB2048() bool {
 	if cfg.AddOnALB2048.Enable {
 		return true
 	}
-	cfg.AddOnALB2048 = nil
 	return false
 }
 
@@ -63,6 +61,7 @@ func getDefaultAddOnALB2048() *AddOnALB2048 {
 
 func (cfg *Config) validateAddOnALB2048() error {
 	if !cfg.IsEnabledAddOnALB2048() {
+		cfg.AddOnALB2048 = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
@@ -0,0 +1,155 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnALB defines parameters for EKS cluster
+// add-on AWS Load Balancer Controller, with an Ingress (ALB)
+// and a Service of type LoadBalancer (NLB).
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/aws-load-balancer-controller.html
+// ref. https://github.com/kubernetes-sigs/aws-load-balancer-controller
+type AddOnALB struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// Namespace is the namespace to install the controller
+	// and to create the test objects in.
+	Namespace string `json:"namespace"`
+
+	// ChartRepoURL is the chart repo URL.
+	ChartRepoURL string `json:"chart-repo-url"`
+	// ChartVersion is the chart version.
+	ChartVersion string `json:"chart-version"`
+
+	// RoleName is the IAM role name for the controller service account (IRSA).
+	RoleName string `json:"role-name"`
+	// RoleARN is the IAM role ARN for the controller.
+	RoleARN string `json:"role-arn" read-only:"true"`
+	// OIDCProviderCreated is true when the tester created the cluster
+	// IAM OIDC provider, to be deleted with the add-on.
+	OIDCProviderCreated bool `json:"oidc-provider-created" read-only:"true"`
+
+	// DeploymentReplicas is the number of replicas of the backend Deployment.
+	DeploymentReplicas int32 `json:"deployment-replicas"`
+	// DeploymentImage is the backend image, serving HTTP on port 80.
+	DeploymentImage string `json:"deployment-image"`
+
+	// ProvisionTimeout is the timeout for each load balancer
+	// to be provisioned and to serve traffic.
+	ProvisionTimeout       time.Duration `json:"provision-timeout"`
+	ProvisionTimeoutString string        `json:"provision-timeout-string" read-only:"true"`
+
+	// ALB is the ALB created from the Ingress.
+	ALB ALBLoadBalancer `json:"alb" read-only:"true"`
+	// NLB is the NLB created from the Service.
+	NLB ALBLoadBalancer `json:"nlb" read-only:"true"`
+}
+
+// ALBLoadBalancer is the load balancer created by the AWS Load Balancer Controller.
+type ALBLoadBalancer struct {
+	// ARN is the load balancer ARN.
+	ARN string `json:"arn"`
+	// DNSName is the load balancer DNS name.
+	DNSName string `json:"dns-name"`
+	// URL is the HTTP endpoint of the load balancer.
+	URL string `json:"url"`
+	// TimeFrameProvision is the time taken from the Kubernetes object creation
+	// to the load balancer DNS name being assigned.
+	TimeFrameProvision timeutil.TimeFrame `json:"time-frame-provision"`
+	// TimeFrameServe is the time taken from the Kubernetes object creation
+	// to the load balancer serving the backend response.
+	TimeFrameServe timeutil.TimeFrame `json:"time-frame-serve"`
+	// Deleted is true when the load balancer is confirmed deleted.
+	Deleted bool `json:"deleted"`
+}
+
+// EnvironmentVariablePrefixAddOnALB is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnALB = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_ALB_"
+
+// IsEnabledAddOnALB returns true if "AddOnALB" is enabled.
+func (cfg *Config) IsEnabledAddOnALB() bool {
+	if cfg.AddOnALB == nil {
+		return false
+	}
+	if cfg.AddOnALB.Enable {
+		return true
+	}
+	return false
+}
+
+const (
+	// DefaultALBChartRepoURL is the default AWS Load Balancer Controller chart repo URL.
+	DefaultALBChartRepoURL = "https://aws.github.io/eks-charts"
+	// DefaultALBChartVersion is the default AWS Load Balancer Controller chart version.
+	DefaultALBChartVersion = "1.6.2"
+	// DefaultALBDeploymentImage is the default backend image.
+	DefaultALBDeploymentImage = "public.ecr.aws/nginx/nginx:1.25"
+)
+
+func getDefaultAddOnALB() *AddOnALB {
+	return &AddOnALB{
+		Enable:             false,
+		ChartRepoURL:       DefaultALBChartRepoURL,
+		ChartVersion:       DefaultALBChartVersion,
+		DeploymentReplicas: 2,
+		DeploymentImage:    DefaultALBDeploymentImage,
+		ProvisionTimeout:   10 * time.Minute,
+	}
+}
+
+func (cfg *Config) validateAddOnALB() error {
+	if !cfg.IsEnabledAddOnALB() {
+		cfg.AddOnALB = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnALB.Enable true but no node group is enabled")
+	}
+	if cfg.VersionValue < 1.19 {
+		return fmt.Errorf("Version %q not supported for AddOnALB", cfg.Version)
+	}
+	// both controllers reconcile the "alb" ingress class
+	if cfg.IsEnabledAddOnALB2048() {
+		return errors.New("AddOnALB.Enable true but AddOnALB2048.Enable true (ALB Ingress Controller conflicts with AWS Load Balancer Controller)")
+	}
+
+	if cfg.AddOnALB.Namespace == "" {
+		cfg.AddOnALB.Namespace = cfg.Name + "-alb"
+	}
+	if cfg.AddOnALB.ChartRepoURL == "" {
+		cfg.AddOnALB.ChartRepoURL = DefaultALBChartRepoURL
+	}
+	if cfg.AddOnALB.ChartVersion == "" {
+		cfg.AddOnALB.ChartVersion = DefaultALBChartVersion
+	}
+	if cfg.AddOnALB.RoleName == "" {
+		cfg.AddOnALB.RoleName = cfg.Name + "-add-on-alb-controller-role"
+	}
+
+	if cfg.AddOnALB.DeploymentReplicas == 0 {
+		cfg.AddOnALB.DeploymentReplicas = 2
+	}
+	if cfg.AddOnALB.DeploymentReplicas < 0 {
+		return fmt.Errorf("AddOnALB.DeploymentReplicas %d invalid", cfg.AddOnALB.DeploymentReplicas)
+	}
+	if cfg.AddOnALB.DeploymentImage == "" {
+		cfg.AddOnALB.DeploymentImage = DefaultALBDeploymentImage
+	}
+
+	if cfg.AddOnALB.ProvisionTimeout == time.Duration(0) {
+		cfg.AddOnALB.ProvisionTimeout = 10 * time.Minute
+	}
+	cfg.AddOnALB.ProvisionTimeoutString = cfg.AddOnALB.ProvisionTimeout.String()
+
+	return nil
+}
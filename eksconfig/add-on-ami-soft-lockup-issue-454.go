@@ -31,7 +31,6 @@ type AddOnAmiSoftLockupIssue454 struct {
 const EnvironmentVariablePrefixAddOnAmiSoftLockupIssue454 = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_AMI_SOFT_LOCKUP_ISSUE_454_"
 
 // IsEnabledAddOnAmiSoftLockupIssue454 returns true if "AddOnAmiSoftLockupIssue454" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnAmiSoftLockupIssue454() bool {
 	if cfg.AddOnAmiSoftLockupIssue454 == nil {
 		return false
@@ -39,7 +38,6 @@ func (cfg *Config) IsEnabledAddOnAmiSoftLockupIssue454() bool {
 	if cfg.AddOnAmiSoftLockupIssue454.Enable {
 		return true
 	}
-	cfg.AddOnAmiSoftLockupIssue454 = nil
 	return false
 }
 
@@ -53,6 +51,7 @@ func getDefaultAddOnAmiSoftLockupIssue454() *AddOnAmiSoftLockupIssue454 {
 
 func (cfg *Config) validateAddOnAmiSoftLockupIssue454() error {
 	if !cfg.IsEnabledAddOnAmiSoftLockupIssue454() {
+		cfg.AddOnAmiSoftLockupIssue454 = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
@@ -42,7 +42,6 @@ type AddOnAppMesh struct {
 const EnvironmentVariablePrefixAddOnAppMesh = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_APP_MESH_"
 
 // IsEnabledAddOnAppMesh returns true if "AddOnAppMesh" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnAppMesh() bool {
 	if cfg.AddOnAppMesh == nil {
 		return false
@@ -50,7 +49,6 @@ func (cfg *Config) IsEnabledAddOnAppMesh() bool {
 	if cfg.AddOnAppMesh.Enable {
 		return true
 	}
-	cfg.AddOnAppMesh = nil
 	return false
 }
 
@@ -62,6 +60,7 @@ func getDefaultAddOnAppMesh() *AddOnAppMesh {
 
 func (cfg *Config) validateAddOnAppMesh() error {
 	if !cfg.IsEnabledAddOnAppMesh() {
+		cfg.AddOnAppMesh = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
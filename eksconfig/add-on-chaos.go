@@ -0,0 +1,270 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnChaos defines parameters for EKS cluster
+// add-on chaos tests, which inject the node failures
+// while a canary workload runs, and measure the recovery.
+// Or, degrade the node network and probe the Pod network
+// connectivity and throughput, to validate the CNI behavior.
+// ref. https://docs.aws.amazon.com/fis/latest/userguide/fis-actions-reference.html#network-actions-reference
+type AddOnChaos struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// Mode is the failure to inject.
+	// "terminate-instances" terminates a random worker instance running the canary.
+	// "fis-az-impairment" disrupts the network connectivity of the node subnets
+	// in an availability zone via AWS Fault Injection Simulator (FIS).
+	// "network-degradation" applies "tc netem" rules on a worker node interface
+	// to inject the latency and the packet loss, and probes the Pods on the node.
+	Mode string `json:"mode"`
+	// NodeGroupName is the name of the node group to inject the failures,
+	// either in "AddOnNodeGroups.ASGs" or "AddOnManagedNodeGroups.MNGs".
+	// If empty, any worker node may be selected.
+	NodeGroupName string `json:"node-group-name"`
+	// AvailabilityZone is the availability zone to impair in "fis-az-impairment" mode.
+	// If empty, defaults to the zone running the most canary Pods.
+	AvailabilityZone string `json:"availability-zone"`
+
+	// Namespace is the namespace to run the canary workload in.
+	Namespace string `json:"namespace"`
+	// CanaryReplicas is the number of replicas of the canary workload.
+	CanaryReplicas int32 `json:"canary-replicas"`
+
+	// Injections is the number of failures to inject.
+	Injections int `json:"injections"`
+	// InjectionInterval is the interval between the injections, after the recovery.
+	InjectionInterval       time.Duration `json:"injection-interval"`
+	InjectionIntervalString string        `json:"injection-interval-string" read-only:"true"`
+	// ImpairmentDuration is the duration of the AZ impairment in "fis-az-impairment" mode,
+	// or of the "tc netem" rules in "network-degradation" mode.
+	ImpairmentDuration       time.Duration `json:"impairment-duration"`
+	ImpairmentDurationString string        `json:"impairment-duration-string" read-only:"true"`
+	// RecoveryTimeout is the timeout for the canary to be fully available
+	// on the healthy nodes after each injection.
+	RecoveryTimeout       time.Duration `json:"recovery-timeout"`
+	RecoveryTimeoutString string        `json:"recovery-timeout-string" read-only:"true"`
+
+	// NetemLatency is the latency to add to the node interface in "network-degradation" mode.
+	NetemLatency       time.Duration `json:"netem-latency"`
+	NetemLatencyString string        `json:"netem-latency-string" read-only:"true"`
+	// NetemLossPercent is the packet loss percentage in "network-degradation" mode.
+	NetemLossPercent float64 `json:"netem-loss-percent"`
+	// NetemInterface is the node interface to degrade in "network-degradation" mode.
+	// If empty, degrades all the ENI interfaces (e.g. "eth0", "eth1", or "ens5", "ens6"),
+	// since the VPC CNI routes the Pod traffic via the ENI owning the Pod IP.
+	NetemInterface string `json:"netem-interface"`
+	// Transport is how to run the "tc" commands on the nodes in "network-degradation" mode,
+	// either "ssm" (SSM Run Command) or "ssh".
+	Transport string `json:"transport"`
+
+	// RoleName is the IAM role name for FIS to run the experiment.
+	RoleName string `json:"role-name"`
+	// RoleARN is the IAM role ARN for FIS to run the experiment.
+	RoleARN string `json:"role-arn" read-only:"true"`
+	// ExperimentTemplateID is the FIS experiment template ID.
+	ExperimentTemplateID string `json:"experiment-template-id" read-only:"true"`
+
+	// Results is the recovery measured after each injection.
+	Results []ChaosResult `json:"results" read-only:"true"`
+}
+
+// ChaosResult is the recovery measured after a single injection.
+type ChaosResult struct {
+	// Target is the terminated instance ID or the impaired availability zone.
+	Target string `json:"target"`
+	// ExperimentID is the FIS experiment ID, empty in "terminate-instances" mode.
+	ExperimentID string `json:"experiment-id,omitempty"`
+	// InjectedUTC is the time of the injection.
+	InjectedUTC time.Time `json:"injected-utc"`
+	// RescheduleTime is the time from the injection until all canary replicas
+	// are ready on the healthy nodes.
+	RescheduleTime       time.Duration `json:"reschedule-time"`
+	RescheduleTimeString string        `json:"reschedule-time-string"`
+	// ServiceDowntime is the total time the canary Service had no ready endpoint.
+	ServiceDowntime       time.Duration `json:"service-downtime"`
+	ServiceDowntimeString string        `json:"service-downtime-string"`
+	// ProbeBaseline is the network probe to the node before the degradation,
+	// in "network-degradation" mode.
+	ProbeBaseline *ChaosProbe `json:"probe-baseline,omitempty"`
+	// ProbeDegraded is the network probe to the node during the degradation.
+	ProbeDegraded *ChaosProbe `json:"probe-degraded,omitempty"`
+	// Error is the error if the canary did not recover.
+	Error string `json:"error,omitempty"`
+}
+
+// ChaosProbe is the Pod network probe result, from a Pod on another node
+// to a Pod on the degraded node.
+type ChaosProbe struct {
+	// PacketLossPercent is the ping packet loss percentage.
+	PacketLossPercent float64 `json:"packet-loss-percent"`
+	// LatencyAvg is the average ping round-trip time.
+	LatencyAvg       time.Duration `json:"latency-avg"`
+	LatencyAvgString string        `json:"latency-avg-string"`
+	// ThroughputMbps is the HTTP download throughput in Mbit/s,
+	// zero if the download failed.
+	ThroughputMbps float64 `json:"throughput-mbps"`
+}
+
+const (
+	// ChaosModeTerminateInstances terminates a random worker instance.
+	ChaosModeTerminateInstances = "terminate-instances"
+	// ChaosModeFISAZImpairment disrupts the network of an availability zone via FIS.
+	ChaosModeFISAZImpairment = "fis-az-impairment"
+	// ChaosModeNetworkDegradation injects the latency and the packet loss on a node with "tc netem".
+	ChaosModeNetworkDegradation = "network-degradation"
+
+	// DefaultChaosCanaryReplicas is the default number of canary replicas.
+	DefaultChaosCanaryReplicas = 3
+)
+
+// netemInterfaceRegex matches the network interface names,
+// which are passed to the remote shell as is.
+var netemInterfaceRegex = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// EnvironmentVariablePrefixAddOnChaos is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnChaos = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CHAOS_"
+
+// IsEnabledAddOnChaos returns true if "AddOnChaos" is enabled.
+func (cfg *Config) IsEnabledAddOnChaos() bool {
+	if cfg.AddOnChaos == nil {
+		return false
+	}
+	if cfg.AddOnChaos.Enable {
+		return true
+	}
+	return false
+}
+
+// IsFIS returns true if the failures are injected via FIS.
+func (cur *AddOnChaos) IsFIS() bool {
+	return cur.Mode == ChaosModeFISAZImpairment
+}
+
+// IsNetem returns true if the node network is degraded with "tc netem".
+func (cur *AddOnChaos) IsNetem() bool {
+	return cur.Mode == ChaosModeNetworkDegradation
+}
+
+func getDefaultAddOnChaos() *AddOnChaos {
+	return &AddOnChaos{
+		Enable:             false,
+		Mode:               ChaosModeTerminateInstances,
+		CanaryReplicas:     DefaultChaosCanaryReplicas,
+		Injections:         1,
+		InjectionInterval:  5 * time.Minute,
+		ImpairmentDuration: 5 * time.Minute,
+		RecoveryTimeout:    15 * time.Minute,
+		NetemLatency:       100 * time.Millisecond,
+		Transport:          NodeTransportSSM,
+	}
+}
+
+func (cfg *Config) validateAddOnChaos() error {
+	if !cfg.IsEnabledAddOnChaos() {
+		cfg.AddOnChaos = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnChaos.Enable true but no node group is enabled")
+	}
+
+	cur := cfg.AddOnChaos
+	switch cur.Mode {
+	case "":
+		cur.Mode = ChaosModeTerminateInstances
+	case ChaosModeTerminateInstances, ChaosModeFISAZImpairment, ChaosModeNetworkDegradation:
+	default:
+		return fmt.Errorf("AddOnChaos.Mode %q unknown (must be %q, %q, or %q)", cur.Mode, ChaosModeTerminateInstances, ChaosModeFISAZImpairment, ChaosModeNetworkDegradation)
+	}
+	if !cur.IsFIS() && cur.AvailabilityZone != "" {
+		return fmt.Errorf("AddOnChaos.AvailabilityZone %q only valid with Mode %q", cur.AvailabilityZone, ChaosModeFISAZImpairment)
+	}
+
+	if cur.NodeGroupName != "" {
+		found := false
+		if cfg.IsEnabledAddOnNodeGroups() {
+			_, found = cfg.AddOnNodeGroups.ASGs[cur.NodeGroupName]
+		}
+		if !found && cfg.IsEnabledAddOnManagedNodeGroups() {
+			_, found = cfg.AddOnManagedNodeGroups.MNGs[cur.NodeGroupName]
+		}
+		if !found {
+			return fmt.Errorf("AddOnChaos.NodeGroupName %q not found", cur.NodeGroupName)
+		}
+	}
+
+	if cur.Namespace == "" {
+		cur.Namespace = cfg.Name + "-chaos"
+	}
+	if cur.CanaryReplicas == 0 {
+		cur.CanaryReplicas = DefaultChaosCanaryReplicas
+	}
+	if cur.CanaryReplicas < 2 {
+		return fmt.Errorf("AddOnChaos.CanaryReplicas %d too small (must be >=2 to measure the service downtime)", cur.CanaryReplicas)
+	}
+	if cur.Injections == 0 {
+		cur.Injections = 1
+	}
+	if cur.Injections < 0 {
+		return fmt.Errorf("AddOnChaos.Injections %d invalid", cur.Injections)
+	}
+
+	if cur.InjectionInterval == time.Duration(0) {
+		cur.InjectionInterval = 5 * time.Minute
+	}
+	cur.InjectionIntervalString = cur.InjectionInterval.String()
+	if cur.ImpairmentDuration == time.Duration(0) {
+		cur.ImpairmentDuration = 5 * time.Minute
+	}
+	if cur.ImpairmentDuration > 12*time.Hour {
+		return fmt.Errorf("AddOnChaos.ImpairmentDuration %v too long (must be <=12h)", cur.ImpairmentDuration)
+	}
+	cur.ImpairmentDurationString = cur.ImpairmentDuration.String()
+	if cur.RecoveryTimeout == time.Duration(0) {
+		cur.RecoveryTimeout = 15 * time.Minute
+	}
+	if cur.IsFIS() && cur.RecoveryTimeout < cur.ImpairmentDuration {
+		return fmt.Errorf("AddOnChaos.RecoveryTimeout %v must be >= ImpairmentDuration %v", cur.RecoveryTimeout, cur.ImpairmentDuration)
+	}
+	cur.RecoveryTimeoutString = cur.RecoveryTimeout.String()
+
+	if cur.IsNetem() {
+		if cur.NetemLatency < 0 {
+			return fmt.Errorf("AddOnChaos.NetemLatency %v invalid", cur.NetemLatency)
+		}
+		// 100% loss would cut off the "tc" commands to clean up the rules
+		if cur.NetemLossPercent < 0 || cur.NetemLossPercent >= 100 {
+			return fmt.Errorf("AddOnChaos.NetemLossPercent %v invalid (must be in [0, 100))", cur.NetemLossPercent)
+		}
+		if cur.NetemLatency == 0 && cur.NetemLossPercent == 0 {
+			return errors.New("AddOnChaos.NetemLatency and NetemLossPercent both zero")
+		}
+		if cur.NetemInterface != "" && !netemInterfaceRegex.MatchString(cur.NetemInterface) {
+			return fmt.Errorf("AddOnChaos.NetemInterface %q invalid", cur.NetemInterface)
+		}
+		if err := cfg.validateNodeTransport("AddOnChaos.Transport", &cur.Transport); err != nil {
+			return err
+		}
+	}
+	cur.NetemLatencyString = cur.NetemLatency.String()
+
+	if cur.IsFIS() && cur.RoleName == "" {
+		cur.RoleName = cfg.Name + "-add-on-chaos-fis-role"
+	}
+
+	return nil
+}
@@ -109,7 +109,6 @@ type AddOnClusterLoaderLocal struct {
 const EnvironmentVariablePrefixAddOnClusterLoaderLocal = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CLUSTER_LOADER_LOCAL_"
 
 // IsEnabledAddOnClusterLoaderLocal returns true if "AddOnClusterLoaderLocal" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnClusterLoaderLocal() bool {
 	if cfg.AddOnClusterLoaderLocal == nil {
 		return false
@@ -117,7 +116,6 @@ func (cfg *Config) IsEnabledAddOnClusterLoaderLocal() bool {
 	if cfg.AddOnClusterLoaderLocal.Enable {
 		return true
 	}
-	cfg.AddOnClusterLoaderLocal = nil
 	return false
 }
 
@@ -160,6 +158,7 @@ func getDefaultAddOnClusterLoaderLocal() *AddOnClusterLoaderLocal {
 
 func (cfg *Config) validateAddOnClusterLoaderLocal() error {
 	if !cfg.IsEnabledAddOnClusterLoaderLocal() {
+		cfg.AddOnClusterLoaderLocal = nil
 		return nil
 	}
 
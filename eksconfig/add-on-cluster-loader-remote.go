@@ -118,7 +118,6 @@ type AddOnClusterLoaderRemote struct {
 const EnvironmentVariablePrefixAddOnClusterLoaderRemote = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CLUSTER_LOADER_REMOTE_"
 
 // IsEnabledAddOnClusterLoaderRemote returns true if "AddOnClusterLoaderRemote" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnClusterLoaderRemote() bool {
 	if cfg.AddOnClusterLoaderRemote == nil {
 		return false
@@ -126,7 +125,6 @@ func (cfg *Config) IsEnabledAddOnClusterLoaderRemote() bool {
 	if cfg.AddOnClusterLoaderRemote.Enable {
 		return true
 	}
-	cfg.AddOnClusterLoaderRemote = nil
 	return false
 }
 
@@ -176,6 +174,7 @@ func (cfg *Config) GetAddOnClusterLoaderRemoteRepositoryRegion() string {
 
 func (cfg *Config) validateAddOnClusterLoaderRemote() error {
 	if !cfg.IsEnabledAddOnClusterLoaderRemote() {
+		cfg.AddOnClusterLoaderRemote = nil
 		return nil
 	}
 
@@ -0,0 +1,146 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/metrics"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnClusterLoader defines parameters for EKS cluster
+// add-on built-in cluster loader, which churns namespaces, Deployments,
+// Secrets, and ConfigMaps at the target QPS for a fixed duration, and
+// records the client-side latency percentiles per operation.
+// Unlike "AddOnClusterLoaderLocal", it does not require
+// the clusterloader2 binary.
+// ref. https://github.com/kubernetes/perf-tests/tree/master/clusterloader2
+type AddOnClusterLoader struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// NamespacePrefix is the prefix of the churned namespaces.
+	NamespacePrefix string `json:"namespace-prefix"`
+	// Namespaces is the number of namespaces churned concurrently,
+	// one worker per namespace.
+	Namespaces int `json:"namespaces"`
+	// DeploymentsPerNamespace is the number of Deployments
+	// created and deleted per namespace.
+	DeploymentsPerNamespace int `json:"deployments-per-namespace"`
+	// DeploymentReplicas is the number of replicas per Deployment.
+	DeploymentReplicas int32 `json:"deployment-replicas"`
+	// SecretsPerNamespace is the number of Secrets
+	// created and deleted per namespace.
+	SecretsPerNamespace int `json:"secrets-per-namespace"`
+	// ConfigMapsPerNamespace is the number of ConfigMaps
+	// created and deleted per namespace.
+	ConfigMapsPerNamespace int `json:"configmaps-per-namespace"`
+	// ObjectSize is the value size in bytes for Secrets and ConfigMaps.
+	ObjectSize int `json:"object-size"`
+	// PodImage is the Deployment container image.
+	PodImage string `json:"pod-image"`
+
+	// QPS is the target number of API requests per second,
+	// shared by all workers.
+	QPS float64 `json:"qps"`
+	// Duration is the duration to run the churn.
+	Duration       time.Duration `json:"duration"`
+	DurationString string        `json:"duration-string" read-only:"true"`
+
+	// Iterations is the number of completed namespace churn iterations.
+	Iterations int `json:"iterations" read-only:"true"`
+	// RequestsSummaries maps the operation (e.g. "create-secret")
+	// to its client-side request results.
+	RequestsSummaries map[string]metrics.RequestsSummary `json:"requests-summaries,omitempty" read-only:"true"`
+	// RequestsSummariesJSONPath is the path to write "RequestsSummaries".
+	RequestsSummariesJSONPath string `json:"requests-summaries-json-path" read-only:"true"`
+}
+
+// EnvironmentVariablePrefixAddOnClusterLoader is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnClusterLoader = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CLUSTER_LOADER_"
+
+// IsEnabledAddOnClusterLoader returns true if "AddOnClusterLoader" is enabled.
+func (cfg *Config) IsEnabledAddOnClusterLoader() bool {
+	if cfg.AddOnClusterLoader == nil {
+		return false
+	}
+	if cfg.AddOnClusterLoader.Enable {
+		return true
+	}
+	return false
+}
+
+const (
+	// DefaultClusterLoaderPodImage is the default Deployment container image.
+	DefaultClusterLoaderPodImage = "registry.k8s.io/pause:3.9"
+	// DefaultClusterLoaderDuration is the default churn duration.
+	DefaultClusterLoaderDuration = 5 * time.Minute
+)
+
+func getDefaultAddOnClusterLoader() *AddOnClusterLoader {
+	return &AddOnClusterLoader{
+		Enable:                  false,
+		Namespaces:              5,
+		DeploymentsPerNamespace: 2,
+		DeploymentReplicas:      1,
+		SecretsPerNamespace:     10,
+		ConfigMapsPerNamespace:  10,
+		ObjectSize:              1024,
+		PodImage:                DefaultClusterLoaderPodImage,
+		QPS:                     20,
+		Duration:                DefaultClusterLoaderDuration,
+	}
+}
+
+func (cfg *Config) validateAddOnClusterLoader() error {
+	if !cfg.IsEnabledAddOnClusterLoader() {
+		cfg.AddOnClusterLoader = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnClusterLoader.Enable true but no node group is enabled")
+	}
+
+	if cfg.AddOnClusterLoader.NamespacePrefix == "" {
+		cfg.AddOnClusterLoader.NamespacePrefix = cfg.Name + "-cl"
+	}
+	if cfg.AddOnClusterLoader.Namespaces <= 0 {
+		return fmt.Errorf("invalid AddOnClusterLoader.Namespaces %d", cfg.AddOnClusterLoader.Namespaces)
+	}
+	if cfg.AddOnClusterLoader.DeploymentsPerNamespace < 0 ||
+		cfg.AddOnClusterLoader.SecretsPerNamespace < 0 ||
+		cfg.AddOnClusterLoader.ConfigMapsPerNamespace < 0 {
+		return errors.New("AddOnClusterLoader objects per namespace must not be negative")
+	}
+	if cfg.AddOnClusterLoader.DeploymentReplicas < 0 {
+		return fmt.Errorf("invalid AddOnClusterLoader.DeploymentReplicas %d", cfg.AddOnClusterLoader.DeploymentReplicas)
+	}
+	// ConfigMaps and Secrets are limited to 1 MiB
+	if cfg.AddOnClusterLoader.ObjectSize < 0 || cfg.AddOnClusterLoader.ObjectSize > 900000 {
+		return fmt.Errorf("invalid AddOnClusterLoader.ObjectSize %d", cfg.AddOnClusterLoader.ObjectSize)
+	}
+	if cfg.AddOnClusterLoader.PodImage == "" {
+		cfg.AddOnClusterLoader.PodImage = DefaultClusterLoaderPodImage
+	}
+	if cfg.AddOnClusterLoader.QPS <= 0 {
+		return fmt.Errorf("invalid AddOnClusterLoader.QPS %v", cfg.AddOnClusterLoader.QPS)
+	}
+
+	if cfg.AddOnClusterLoader.Duration == time.Duration(0) {
+		cfg.AddOnClusterLoader.Duration = DefaultClusterLoaderDuration
+	}
+	cfg.AddOnClusterLoader.DurationString = cfg.AddOnClusterLoader.Duration.String()
+
+	if cfg.AddOnClusterLoader.RequestsSummariesJSONPath == "" {
+		cfg.AddOnClusterLoader.RequestsSummariesJSONPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + "-cluster-loader-requests-summaries.json"
+	}
+
+	return nil
+}
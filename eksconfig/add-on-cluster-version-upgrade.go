@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
@@ -27,13 +28,57 @@ type AddOnClusterVersionUpgrade struct {
 	// If empty, set default version.
 	Version      string  `json:"version"`
 	VersionValue float64 `json:"version-value" read-only:"true"`
+
+	// CascadeManagedNodeGroups is 'true' to upgrade all managed node groups
+	// to the target version after the control plane upgrade.
+	// Node groups without "VersionUpgrade" default to the target version.
+	CascadeManagedNodeGroups bool `json:"cascade-managed-node-groups"`
+	// CascadeAddOns is 'true' to upgrade the EKS managed add-ons in "AddOnNames"
+	// to the default add-on version of the target version.
+	CascadeAddOns bool `json:"cascade-add-ons"`
+	// AddOnNames is the list of EKS managed add-ons to upgrade.
+	// Add-ons not installed via EKS add-on API are skipped.
+	AddOnNames []string `json:"add-on-names"`
+
+	// Phases is the result of each upgrade phase, in order.
+	Phases []ClusterVersionUpgradePhase `json:"phases" read-only:"true"`
+	// RollbackReportPath is the path to write the rollback report,
+	// when any upgrade phase fails.
+	RollbackReportPath string `json:"rollback-report-path"`
+}
+
+// ClusterVersionUpgradePhase is the result of a cluster version upgrade phase.
+type ClusterVersionUpgradePhase struct {
+	// Name is the phase name (e.g. "control-plane", "add-on/coredns").
+	Name string `json:"name"`
+	// Status is one of "Successful", "Failed", or "Skipped".
+	Status string `json:"status"`
+	// Error is the error message when the phase failed or was skipped.
+	Error string `json:"error,omitempty"`
+	// From is the version before the upgrade.
+	From string `json:"from"`
+	// To is the version after the upgrade.
+	To        string             `json:"to"`
+	TimeFrame timeutil.TimeFrame `json:"time-frame"`
 }
 
+const (
+	// ClusterVersionUpgradePhaseStatusSuccessful is the status of a completed phase.
+	ClusterVersionUpgradePhaseStatusSuccessful = "Successful"
+	// ClusterVersionUpgradePhaseStatusFailed is the status of a failed phase.
+	ClusterVersionUpgradePhaseStatusFailed = "Failed"
+	// ClusterVersionUpgradePhaseStatusSkipped is the status of a phase not run.
+	ClusterVersionUpgradePhaseStatusSkipped = "Skipped"
+)
+
+// DefaultClusterVersionUpgradeAddOnNames is the list of core add-ons
+// upgraded after the control plane.
+var DefaultClusterVersionUpgradeAddOnNames = []string{"vpc-cni", "coredns", "kube-proxy"}
+
 // EnvironmentVariablePrefixAddOnClusterVersionUpgrade is the environment variable prefix used for "eksconfig".
 const EnvironmentVariablePrefixAddOnClusterVersionUpgrade = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CLUSTER_VERSION_UPGRADE_"
 
 // IsEnabledAddOnClusterVersionUpgrade returns true if "AddOnClusterVersionUpgrade" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnClusterVersionUpgrade() bool {
 	if cfg.AddOnClusterVersionUpgrade == nil {
 		return false
@@ -41,7 +86,6 @@ func (cfg *Config) IsEnabledAddOnClusterVersionUpgrade() bool {
 	if cfg.AddOnClusterVersionUpgrade.Enable {
 		return true
 	}
-	cfg.AddOnClusterVersionUpgrade = nil
 	return false
 }
 
@@ -50,11 +94,13 @@ func getDefaultAddOnClusterVersionUpgrade() *AddOnClusterVersionUpgrade {
 		Enable:            false,
 		Version:           "1.20",
 		WaitBeforeUpgrade: 3 * time.Minute,
+		AddOnNames:        append([]string{}, DefaultClusterVersionUpgradeAddOnNames...),
 	}
 }
 
 func (cfg *Config) validateAddOnClusterVersionUpgrade() error {
 	if !cfg.IsEnabledAddOnClusterVersionUpgrade() {
+		cfg.AddOnClusterVersionUpgrade = nil
 		return nil
 	}
 
@@ -73,5 +119,15 @@ func (cfg *Config) validateAddOnClusterVersionUpgrade() error {
 		return fmt.Errorf("AddOnClusterVersionUpgrade only supports one minor version upgrade but got %.2f [invalid: %q -> %q]", delta, cfg.Version, cfg.AddOnClusterVersionUpgrade.Version)
 	}
 
+	if cfg.AddOnClusterVersionUpgrade.CascadeManagedNodeGroups && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnClusterVersionUpgrade.CascadeManagedNodeGroups true but no managed node group is enabled")
+	}
+	if cfg.AddOnClusterVersionUpgrade.CascadeAddOns && len(cfg.AddOnClusterVersionUpgrade.AddOnNames) == 0 {
+		cfg.AddOnClusterVersionUpgrade.AddOnNames = append([]string{}, DefaultClusterVersionUpgradeAddOnNames...)
+	}
+	if cfg.AddOnClusterVersionUpgrade.RollbackReportPath == "" {
+		cfg.AddOnClusterVersionUpgrade.RollbackReportPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + "-cluster-version-upgrade-rollback-report.txt"
+	}
+
 	return nil
 }
@@ -0,0 +1,170 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnCNIVersionMatrix defines parameters for EKS cluster
+// add-on VPC CNI version matrix tests, which roll the "aws-node" DaemonSet
+// to each version in order, run the pod density and the pod-to-pod
+// connectivity checks, and report the pass or fail per version,
+// for the amazon-vpc-cni-k8s release qualification.
+// The original "aws-node" images are restored after the matrix.
+// ref. https://github.com/aws/amazon-vpc-cni-k8s/releases
+type AddOnCNIVersionMatrix struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// S3Dir is the S3 directory to store the test results.
+	S3Dir string `json:"s3-dir"`
+	// Namespace is the namespace of the check Pods.
+	Namespace string `json:"namespace"`
+
+	// Versions is the list of the amazon-vpc-cni-k8s image tags to test
+	// in order (e.g. "v1.15.1", "v1.16.0-eksbuild.1"),
+	// upgrading or downgrading from the previous one.
+	Versions []string `json:"versions"`
+	// ImageRegistry is the registry host of the "amazon-k8s-cni" and
+	// "amazon-k8s-cni-init" images. Leave empty to use the registry of
+	// the current "aws-node" images
+	// (e.g. "602401143452.dkr.ecr.us-west-2.amazonaws.com").
+	ImageRegistry string `json:"image-registry"`
+
+	// PodsPerNode is the number of the pod density Pods per Ready node,
+	// to exercise the IP address allocation of each version.
+	PodsPerNode int `json:"pods-per-node"`
+	// RolloutTimeout is the timeout of the "aws-node" rollout per version.
+	RolloutTimeout       time.Duration `json:"rollout-timeout"`
+	RolloutTimeoutString string        `json:"rollout-timeout-string" read-only:"true"`
+	// CheckTimeout is the timeout of each check per version.
+	CheckTimeout       time.Duration `json:"check-timeout"`
+	CheckTimeoutString string        `json:"check-timeout-string" read-only:"true"`
+
+	// OriginalImages maps the "aws-node" container names to the images
+	// before the matrix, restored after the matrix.
+	OriginalImages map[string]string `json:"original-images" read-only:"true"`
+	// Results is the list of the per-version results.
+	Results []CNIVersionResult `json:"results" read-only:"true"`
+
+	// ResultJUnitXMLPath is the JUnit XML output of the per-version results.
+	ResultJUnitXMLPath  string `json:"result-junit-xml-path" read-only:"true"`
+	ResultJUnitXMLS3Key string `json:"result-junit-xml-s3-key" read-only:"true"`
+}
+
+// CNIVersionResult is the result of the checks with a VPC CNI version.
+type CNIVersionResult struct {
+	Version string `json:"version"`
+	Passed  bool   `json:"passed"`
+
+	RolloutTime       time.Duration `json:"rollout-time"`
+	RolloutTimeString string        `json:"rollout-time-string"`
+	// PodDensityTime is the time until all pod density Pods are Ready.
+	PodDensityTime       time.Duration `json:"pod-density-time"`
+	PodDensityTimeString string        `json:"pod-density-time-string"`
+	// PodsReady is the number of the Ready pod density Pods.
+	PodsReady int `json:"pods-ready"`
+	// Connectivity is true if the client Pod reached the server Pod.
+	Connectivity bool `json:"connectivity"`
+
+	// Error is the first failed check, empty if passed.
+	Error string `json:"error"`
+}
+
+// EnvironmentVariablePrefixAddOnCNIVersionMatrix is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnCNIVersionMatrix = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CNI_VERSION_MATRIX_"
+
+// IsEnabledAddOnCNIVersionMatrix returns true if "AddOnCNIVersionMatrix" is enabled.
+func (cfg *Config) IsEnabledAddOnCNIVersionMatrix() bool {
+	if cfg.AddOnCNIVersionMatrix == nil {
+		return false
+	}
+	if cfg.AddOnCNIVersionMatrix.Enable {
+		return true
+	}
+	return false
+}
+
+const (
+	// DefaultCNIVersionMatrixPodsPerNode is the default number of the pod density Pods per node.
+	DefaultCNIVersionMatrixPodsPerNode = 20
+	// DefaultCNIVersionMatrixRolloutTimeout is the default "aws-node" rollout timeout.
+	DefaultCNIVersionMatrixRolloutTimeout = 10 * time.Minute
+	// DefaultCNIVersionMatrixCheckTimeout is the default check timeout.
+	DefaultCNIVersionMatrixCheckTimeout = 10 * time.Minute
+)
+
+func getDefaultAddOnCNIVersionMatrix() *AddOnCNIVersionMatrix {
+	return &AddOnCNIVersionMatrix{
+		Enable:         false,
+		PodsPerNode:    DefaultCNIVersionMatrixPodsPerNode,
+		RolloutTimeout: DefaultCNIVersionMatrixRolloutTimeout,
+		CheckTimeout:   DefaultCNIVersionMatrixCheckTimeout,
+	}
+}
+
+func (cfg *Config) validateAddOnCNIVersionMatrix() error {
+	if !cfg.IsEnabledAddOnCNIVersionMatrix() {
+		cfg.AddOnCNIVersionMatrix = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnCNIVersionMatrix.Enable true but no node group is enabled")
+	}
+
+	cur := cfg.AddOnCNIVersionMatrix
+	if len(cur.Versions) == 0 {
+		return errors.New("AddOnCNIVersionMatrix.Enable true but empty AddOnCNIVersionMatrix.Versions")
+	}
+	for _, v := range cur.Versions {
+		if !strings.HasPrefix(v, "v") || strings.ContainsAny(v, ":/@ ") {
+			return fmt.Errorf("AddOnCNIVersionMatrix.Versions %q invalid (must be an image tag with 'v' prefix)", v)
+		}
+	}
+	if strings.ContainsAny(cur.ImageRegistry, ":@ ") || strings.HasSuffix(cur.ImageRegistry, "/") {
+		return fmt.Errorf("AddOnCNIVersionMatrix.ImageRegistry %q invalid", cur.ImageRegistry)
+	}
+
+	if cur.S3Dir == "" {
+		cur.S3Dir = path.Join(cfg.Name, "add-on-cni-version-matrix")
+	}
+	if cur.Namespace == "" {
+		cur.Namespace = cfg.Name + "-cni-version-matrix"
+	}
+	if cur.PodsPerNode <= 0 {
+		cur.PodsPerNode = DefaultCNIVersionMatrixPodsPerNode
+	}
+	if cur.RolloutTimeout == time.Duration(0) {
+		cur.RolloutTimeout = DefaultCNIVersionMatrixRolloutTimeout
+	}
+	cur.RolloutTimeoutString = cur.RolloutTimeout.String()
+	if cur.CheckTimeout == time.Duration(0) {
+		cur.CheckTimeout = DefaultCNIVersionMatrixCheckTimeout
+	}
+	cur.CheckTimeoutString = cur.CheckTimeout.String()
+
+	if cur.ResultJUnitXMLPath == "" {
+		cur.ResultJUnitXMLPath = filepath.Join(
+			filepath.Dir(cfg.ConfigPath),
+			fmt.Sprintf("%s-cni-version-matrix.junit.xml", cfg.Name),
+		)
+		os.RemoveAll(cur.ResultJUnitXMLPath)
+	}
+	if cur.ResultJUnitXMLS3Key == "" {
+		cur.ResultJUnitXMLS3Key = path.Join(cur.S3Dir, filepath.Base(cur.ResultJUnitXMLPath))
+	}
+
+	return nil
+}
@@ -66,7 +66,6 @@ type AddOnCNIVPC struct {
 const AWS_K8S_TESTER_EKS_ADD_ON_CNI_VPC_PREFIX = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CNI_VPC_"
 
 // IsEnabledAddOnCNIVPC returns true if "AddOnCNIVPC" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnCNIVPC() bool {
 	if cfg.AddOnCNIVPC == nil {
 		return false
@@ -74,7 +73,6 @@ func (cfg *Config) IsEnabledAddOnCNIVPC() bool {
 	if cfg.AddOnCNIVPC.Enable {
 		return true
 	}
-	cfg.AddOnCNIVPC = nil
 	return false
 }
 
@@ -101,6 +99,7 @@ func (cfg *Config) GetAddOnCNIVPCRepositoryRegion() string {
 
 func (cfg *Config) validateAddOnCNIVPC() error {
 	if !cfg.IsEnabledAddOnCNIVPC() {
+		cfg.AddOnCNIVPC = nil
 		return nil
 	}
 
@@ -41,6 +41,18 @@ type AddOnConfigmapsLocal struct {
 	// CreatedNames is the list of created "ConfigMap" object names.
 	CreatedNames []string `json:"created-names" read-only:"true"`
 
+	// Watchers is the number of concurrent watchers on the namespace,
+	// to measure the latency from each write request to its watch event.
+	// Zero disables the watchers.
+	Watchers int `json:"watchers"`
+
+	// EtcdObjectsBefore is the number of "ConfigMap" objects in etcd
+	// before the writes, reported by kube-apiserver.
+	EtcdObjectsBefore int64 `json:"etcd-objects-before" read-only:"true"`
+	// EtcdObjectsAfter is the number of "ConfigMap" objects in etcd
+	// after the writes, reported by kube-apiserver.
+	EtcdObjectsAfter int64 `json:"etcd-objects-after" read-only:"true"`
+
 	//////////////////////////////////////////////////////////////////////////////
 
 	RequestsRawWritesJSONPath  string `json:"requests-raw-writes-json-path" read-only:"true"`
@@ -80,13 +92,27 @@ type AddOnConfigmapsLocal struct {
 	RequestsSummaryWritesCompareTableS3Key string                  `json:"requests-summary-writes-compare-table-s3-path" read-only:"true"`
 
 	//////////////////////////////////////////////////////////////////////////////
+
+	RequestsRawWatchesJSONPath  string `json:"requests-raw-watches-json-path" read-only:"true"`
+	RequestsRawWatchesJSONS3Key string `json:"requests-raw-watches-json-s3-key" read-only:"true"`
+	RequestsRawWatchesCSVPath   string `json:"requests-raw-watches-csv-path" read-only:"true"`
+	RequestsRawWatchesCSVS3Key  string `json:"requests-raw-watches-csv-s3-key" read-only:"true"`
+
+	// RequestsSummaryWatches is the watch latency results,
+	// from the write requests to the watch events.
+	RequestsSummaryWatches           metrics.RequestsSummary `json:"requests-summary-watches,omitempty" read-only:"true"`
+	RequestsSummaryWatchesJSONPath   string                  `json:"requests-summary-watches-json-path" read-only:"true"`
+	RequestsSummaryWatchesJSONS3Key  string                  `json:"requests-summary-watches-json-s3-key" read-only:"true"`
+	RequestsSummaryWatchesTablePath  string                  `json:"requests-summary-watches-table-path" read-only:"true"`
+	RequestsSummaryWatchesTableS3Key string                  `json:"requests-summary-watches-table-s3-path" read-only:"true"`
+
+	//////////////////////////////////////////////////////////////////////////////
 }
 
 // EnvironmentVariablePrefixAddOnConfigmapsLocal is the environment variable prefix used for "eksconfig".
 const EnvironmentVariablePrefixAddOnConfigmapsLocal = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CONFIGMAPS_LOCAL_"
 
 // IsEnabledAddOnConfigmapsLocal returns true if "AddOnConfigmapsLocal" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnConfigmapsLocal() bool {
 	if cfg.AddOnConfigmapsLocal == nil {
 		return false
@@ -94,7 +120,6 @@ func (cfg *Config) IsEnabledAddOnConfigmapsLocal() bool {
 	if cfg.AddOnConfigmapsLocal.Enable {
 		return true
 	}
-	cfg.AddOnConfigmapsLocal = nil
 	return false
 }
 
@@ -103,6 +128,7 @@ func getDefaultAddOnConfigmapsLocal() *AddOnConfigmapsLocal {
 		Enable:     false,
 		Objects:    10,
 		ObjectSize: 10 * 1024, // 10 KB
+		Watchers:   2,
 
 		// writes total 300 MB data to etcd
 		// Objects: 1000,
@@ -112,6 +138,7 @@ func getDefaultAddOnConfigmapsLocal() *AddOnConfigmapsLocal {
 
 func (cfg *Config) validateAddOnConfigmapsLocal() error {
 	if !cfg.IsEnabledAddOnConfigmapsLocal() {
+		cfg.AddOnConfigmapsLocal = nil
 		return nil
 	}
 
@@ -136,6 +163,9 @@ func (cfg *Config) validateAddOnConfigmapsLocal() error {
 	if cfg.AddOnConfigmapsLocal.ObjectSize > 900000 {
 		return fmt.Errorf("AddOnConfigmapsLocal.ObjectSize limit is 0.9 MB, got %d", cfg.AddOnConfigmapsLocal.ObjectSize)
 	}
+	if cfg.AddOnConfigmapsLocal.Watchers < 0 {
+		return fmt.Errorf("invalid AddOnConfigmapsLocal.Watchers %d", cfg.AddOnConfigmapsLocal.Watchers)
+	}
 
 	//////////////////////////////////////////////////////////////////////////////
 	if cfg.AddOnConfigmapsLocal.RequestsRawWritesJSONPath == "" {
@@ -225,5 +255,48 @@ func (cfg *Config) validateAddOnConfigmapsLocal() error {
 	}
 	//////////////////////////////////////////////////////////////////////////////
 
+	//////////////////////////////////////////////////////////////////////////////
+	if cfg.AddOnConfigmapsLocal.RequestsRawWatchesJSONPath == "" {
+		cfg.AddOnConfigmapsLocal.RequestsRawWatchesJSONPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + "-configmaps-local-requests-watches-raw.json"
+	}
+	if cfg.AddOnConfigmapsLocal.RequestsRawWatchesJSONS3Key == "" {
+		cfg.AddOnConfigmapsLocal.RequestsRawWatchesJSONS3Key = path.Join(
+			cfg.AddOnConfigmapsLocal.S3Dir,
+			"requests-raw-watches",
+			filepath.Base(cfg.AddOnConfigmapsLocal.RequestsRawWatchesJSONPath),
+		)
+	}
+	if cfg.AddOnConfigmapsLocal.RequestsRawWatchesCSVPath == "" {
+		cfg.AddOnConfigmapsLocal.RequestsRawWatchesCSVPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + "-configmaps-local-requests-watches-raw.csv"
+	}
+	if cfg.AddOnConfigmapsLocal.RequestsRawWatchesCSVS3Key == "" {
+		cfg.AddOnConfigmapsLocal.RequestsRawWatchesCSVS3Key = path.Join(
+			cfg.AddOnConfigmapsLocal.S3Dir,
+			"requests-raw-watches",
+			filepath.Base(cfg.AddOnConfigmapsLocal.RequestsRawWatchesCSVPath),
+		)
+	}
+	if cfg.AddOnConfigmapsLocal.RequestsSummaryWatchesJSONPath == "" {
+		cfg.AddOnConfigmapsLocal.RequestsSummaryWatchesJSONPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + "-configmaps-local-requests-summary-watches.json"
+	}
+	if cfg.AddOnConfigmapsLocal.RequestsSummaryWatchesJSONS3Key == "" {
+		cfg.AddOnConfigmapsLocal.RequestsSummaryWatchesJSONS3Key = path.Join(
+			cfg.AddOnConfigmapsLocal.S3Dir,
+			"requests-summary-watches",
+			filepath.Base(cfg.AddOnConfigmapsLocal.RequestsSummaryWatchesJSONPath),
+		)
+	}
+	if cfg.AddOnConfigmapsLocal.RequestsSummaryWatchesTablePath == "" {
+		cfg.AddOnConfigmapsLocal.RequestsSummaryWatchesTablePath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + "-configmaps-local-requests-summary-watches.txt"
+	}
+	if cfg.AddOnConfigmapsLocal.RequestsSummaryWatchesTableS3Key == "" {
+		cfg.AddOnConfigmapsLocal.RequestsSummaryWatchesTableS3Key = path.Join(
+			cfg.AddOnConfigmapsLocal.S3Dir,
+			"requests-summary-watches",
+			filepath.Base(cfg.AddOnConfigmapsLocal.RequestsSummaryWatchesTablePath),
+		)
+	}
+	//////////////////////////////////////////////////////////////////////////////
+
 	return nil
 }
@@ -114,7 +114,6 @@ type AddOnConfigmapsRemote struct {
 const EnvironmentVariablePrefixAddOnConfigmapsRemote = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CONFIGMAPS_REMOTE_"
 
 // IsEnabledAddOnConfigmapsRemote returns true if "AddOnConfigmapsRemote" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnConfigmapsRemote() bool {
 	if cfg.AddOnConfigmapsRemote == nil {
 		return false
@@ -122,7 +121,6 @@ func (cfg *Config) IsEnabledAddOnConfigmapsRemote() bool {
 	if cfg.AddOnConfigmapsRemote.Enable {
 		return true
 	}
-	cfg.AddOnConfigmapsRemote = nil
 	return false
 }
 
@@ -151,6 +149,7 @@ func (cfg *Config) GetAddOnConfigmapsRemoteRepositoryRegion() string {
 
 func (cfg *Config) validateAddOnConfigmapsRemote() error {
 	if !cfg.IsEnabledAddOnConfigmapsRemote() {
+		cfg.AddOnConfigmapsRemote = nil
 		return nil
 	}
 
@@ -17,6 +17,7 @@ import (
 // add-on Conformance.
 // ref. https://github.com/cncf/k8s-conformance/blob/master/instructions.md
 // ref. https://github.com/vmware-tanzu/sonobuoy
+// ref. https://github.com/kubernetes-sigs/hydrophone
 type AddOnConformance struct {
 	// Enable is 'true' to create this add-on.
 	Enable bool `json:"enable"`
@@ -33,6 +34,20 @@ type AddOnConformance struct {
 	// Namespace is the namespace to create objects in.
 	Namespace string `json:"namespace"`
 
+	// Runner is the conformance test runner,
+	// either "sonobuoy" (default) or "hydrophone".
+	// "hydrophone" uses the "SonobuoyRun*" options for the conformance
+	// image, focus, skip, and timeout, and writes the results to
+	// the same "SonobuoyResult*" paths.
+	Runner string `json:"runner"`
+	// HydrophonePath is the path to download the "hydrophone".
+	HydrophonePath string `json:"hydrophone-path,omitempty"`
+	// HydrophoneDownloadURL is the download URL to download "hydrophone" binary from.
+	// ref. https://github.com/kubernetes-sigs/hydrophone/releases
+	HydrophoneDownloadURL string `json:"hydrophone-download-url,omitempty"`
+	// HydrophoneParallel is the number of parallel test runners.
+	HydrophoneParallel int `json:"hydrophone-parallel"`
+
 	// SonobuoyPath is the path to download the "sonobuoy".
 	SonobuoyPath string `json:"sonobuoy-path,omitempty"`
 	// SonobuoyDownloadURL is the download URL to download "sonobuoy" binary from.
@@ -68,13 +83,17 @@ type AddOnConformance struct {
 	SonobuoyResultE2eLogS3Key   string `json:"sonobuoy-result-e2e-log-s3-key" read-only:"true"`
 	SonobuoyResultJunitXMLPath  string `json:"sonobuoy-result-junit-xml-path" read-only:"true"`
 	SonobuoyResultJunitXMLS3Key string `json:"sonobuoy-result-junit-xml-s3-key" read-only:"true"`
+
+	// ResultTests is the number of run tests in the JUnit results.
+	ResultTests int `json:"result-tests" read-only:"true"`
+	// ResultFailures is the number of failed tests in the JUnit results.
+	ResultFailures int `json:"result-failures" read-only:"true"`
 }
 
 // EnvironmentVariablePrefixAddOnConformance is the environment variable prefix used for "eksconfig".
 const EnvironmentVariablePrefixAddOnConformance = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CONFORMANCE_"
 
 // IsEnabledAddOnConformance returns true if "AddOnConformance" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnConformance() bool {
 	if cfg.AddOnConformance == nil {
 		return false
@@ -82,10 +101,16 @@ func (cfg *Config) IsEnabledAddOnConformance() bool {
 	if cfg.AddOnConformance.Enable {
 		return true
 	}
-	cfg.AddOnConformance = nil
 	return false
 }
 
+const (
+	// ConformanceRunnerSonobuoy runs the conformance tests with "sonobuoy".
+	ConformanceRunnerSonobuoy = "sonobuoy"
+	// ConformanceRunnerHydrophone runs the conformance tests with "hydrophone".
+	ConformanceRunnerHydrophone = "hydrophone"
+)
+
 func getDefaultAddOnConformance() *AddOnConformance {
 	addOn := &AddOnConformance{
 		Enable:                false,
@@ -94,9 +119,14 @@ func getDefaultAddOnConformance() *AddOnConformance {
 		SonobuoyImage:         "",
 		SystemdLogsImage:      "",
 		SonobuoyE2eRepoConfig: "",
+		Runner:                ConformanceRunnerSonobuoy,
+		HydrophonePath:        "/tmp/hydrophone",
+		HydrophoneDownloadURL: "https://github.com/kubernetes-sigs/hydrophone/releases/download/v0.6.0/hydrophone_Linux_x86_64.tar.gz",
+		HydrophoneParallel:    1,
 	}
 	if runtime.GOOS == "darwin" {
 		addOn.SonobuoyDownloadURL = strings.Replace(addOn.SonobuoyDownloadURL, "linux", "darwin", -1)
+		addOn.HydrophoneDownloadURL = strings.Replace(addOn.HydrophoneDownloadURL, "Linux", "Darwin", -1)
 	}
 	return addOn
 }
@@ -132,6 +162,7 @@ Skipped: 0
 
 func (cfg *Config) validateAddOnConformance() error {
 	if !cfg.IsEnabledAddOnConformance() {
+		cfg.AddOnConformance = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
@@ -151,6 +182,21 @@ func (cfg *Config) validateAddOnConformance() error {
 		cfg.AddOnConformance.Namespace = cfg.Name + "-conformance"
 	}
 
+	switch cfg.AddOnConformance.Runner {
+	case "":
+		cfg.AddOnConformance.Runner = ConformanceRunnerSonobuoy
+	case ConformanceRunnerSonobuoy:
+	case ConformanceRunnerHydrophone:
+		if cfg.AddOnConformance.HydrophonePath == "" {
+			return errors.New("empty AddOnConformance.HydrophonePath")
+		}
+		if cfg.AddOnConformance.HydrophoneParallel <= 0 {
+			cfg.AddOnConformance.HydrophoneParallel = 1
+		}
+	default:
+		return fmt.Errorf("unknown AddOnConformance.Runner %q", cfg.AddOnConformance.Runner)
+	}
+
 	if cfg.AddOnConformance.SonobuoyDeleteTimeout == time.Duration(0) {
 		cfg.AddOnConformance.SonobuoyDeleteTimeout = 5 * time.Minute
 	}
@@ -0,0 +1,144 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnContainerInsights defines parameters for EKS cluster
+// add-on CloudWatch Container Insights, which installs the CloudWatch
+// agent and Fluent Bit, generates log traffic, and verifies the logs
+// and metrics are ingested into CloudWatch.
+// Publishes to:
+//  - /aws/containerinsights/[CLUSTER-NAME]/application
+//  - /aws/containerinsights/[CLUSTER-NAME]/performance
+//  - "ContainerInsights" CloudWatch metrics namespace
+// ref. https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/Container-Insights-setup-EKS-quickstart.html
+type AddOnContainerInsights struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// Namespace is the namespace to create objects in.
+	Namespace string `json:"namespace"`
+
+	// CWAgentImage is the CloudWatch agent image.
+	CWAgentImage string `json:"cw-agent-image"`
+	// FluentBitImage is the AWS for Fluent Bit image.
+	FluentBitImage string `json:"fluent-bit-image"`
+
+	// LogGeneratorImage is the image for the log generator Job.
+	LogGeneratorImage string `json:"log-generator-image"`
+	// LogGeneratorLines is the number of log lines to write,
+	// each with a unique marker to query in CloudWatch Logs.
+	LogGeneratorLines int `json:"log-generator-lines"`
+
+	// VerifyTimeout is the timeout to wait for the logs and metrics
+	// to be queryable in CloudWatch.
+	VerifyTimeout       time.Duration `json:"verify-timeout"`
+	VerifyTimeoutString string        `json:"verify-timeout-string" read-only:"true"`
+
+	// DeleteLogGroups is true to delete the Container Insights
+	// log groups on deletion.
+	DeleteLogGroups bool `json:"delete-log-groups"`
+
+	// LogGroupNames are the log groups written by the agents.
+	LogGroupNames []string `json:"log-group-names" read-only:"true"`
+	// LogEventsFound is the number of generated log lines
+	// found in CloudWatch Logs.
+	LogEventsFound int `json:"log-events-found" read-only:"true"`
+	// MetricsFound is the number of "ContainerInsights" metrics
+	// found for the cluster.
+	MetricsFound int `json:"metrics-found" read-only:"true"`
+}
+
+// EnvironmentVariablePrefixAddOnContainerInsights is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnContainerInsights = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CONTAINER_INSIGHTS_"
+
+// IsEnabledAddOnContainerInsights returns true if "AddOnContainerInsights" is enabled.
+func (cfg *Config) IsEnabledAddOnContainerInsights() bool {
+	if cfg.AddOnContainerInsights == nil {
+		return false
+	}
+	if cfg.AddOnContainerInsights.Enable {
+		return true
+	}
+	return false
+}
+
+const (
+	// DefaultContainerInsightsNamespace is the namespace in the
+	// Container Insights quick start.
+	DefaultContainerInsightsNamespace = "amazon-cloudwatch"
+	// DefaultContainerInsightsCWAgentImage is the default CloudWatch agent image.
+	DefaultContainerInsightsCWAgentImage = "public.ecr.aws/cloudwatch-agent/cloudwatch-agent:1.247360.0b252689"
+	// DefaultContainerInsightsFluentBitImage is the default Fluent Bit image.
+	DefaultContainerInsightsFluentBitImage = "public.ecr.aws/aws-observability/aws-for-fluent-bit:stable"
+	// DefaultContainerInsightsLogGeneratorImage is the default log generator image.
+	DefaultContainerInsightsLogGeneratorImage = "busybox:1.36"
+	// DefaultContainerInsightsLogGeneratorLines is the default number of log lines.
+	DefaultContainerInsightsLogGeneratorLines = 100
+	// DefaultContainerInsightsVerifyTimeout is the default verify timeout,
+	// long enough for the first metrics collection interval.
+	DefaultContainerInsightsVerifyTimeout = 10 * time.Minute
+)
+
+func getDefaultAddOnContainerInsights() *AddOnContainerInsights {
+	return &AddOnContainerInsights{
+		Enable:            false,
+		Namespace:         DefaultContainerInsightsNamespace,
+		CWAgentImage:      DefaultContainerInsightsCWAgentImage,
+		FluentBitImage:    DefaultContainerInsightsFluentBitImage,
+		LogGeneratorImage: DefaultContainerInsightsLogGeneratorImage,
+		LogGeneratorLines: DefaultContainerInsightsLogGeneratorLines,
+		VerifyTimeout:     DefaultContainerInsightsVerifyTimeout,
+		DeleteLogGroups:   true,
+	}
+}
+
+func (cfg *Config) validateAddOnContainerInsights() error {
+	if !cfg.IsEnabledAddOnContainerInsights() {
+		cfg.AddOnContainerInsights = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnContainerInsights.Enable true but no node group is enabled")
+	}
+	// both write to the same log groups
+	if cfg.IsEnabledAddOnCWAgent() || cfg.IsEnabledAddOnFluentd() {
+		return errors.New("AddOnContainerInsights.Enable true conflicts with AddOnCWAgent or AddOnFluentd")
+	}
+
+	if cfg.AddOnContainerInsights.Namespace == "" {
+		cfg.AddOnContainerInsights.Namespace = DefaultContainerInsightsNamespace
+	}
+	if cfg.AddOnContainerInsights.CWAgentImage == "" {
+		cfg.AddOnContainerInsights.CWAgentImage = DefaultContainerInsightsCWAgentImage
+	}
+	if cfg.AddOnContainerInsights.FluentBitImage == "" {
+		cfg.AddOnContainerInsights.FluentBitImage = DefaultContainerInsightsFluentBitImage
+	}
+	if cfg.AddOnContainerInsights.LogGeneratorImage == "" {
+		cfg.AddOnContainerInsights.LogGeneratorImage = DefaultContainerInsightsLogGeneratorImage
+	}
+	if cfg.AddOnContainerInsights.LogGeneratorLines <= 0 {
+		return fmt.Errorf("invalid AddOnContainerInsights.LogGeneratorLines %d", cfg.AddOnContainerInsights.LogGeneratorLines)
+	}
+	if cfg.AddOnContainerInsights.VerifyTimeout == time.Duration(0) {
+		cfg.AddOnContainerInsights.VerifyTimeout = DefaultContainerInsightsVerifyTimeout
+	}
+	cfg.AddOnContainerInsights.VerifyTimeoutString = cfg.AddOnContainerInsights.VerifyTimeout.String()
+
+	cfg.AddOnContainerInsights.LogGroupNames = []string{
+		fmt.Sprintf("/aws/containerinsights/%s/application", cfg.Name),
+		fmt.Sprintf("/aws/containerinsights/%s/performance", cfg.Name),
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/metrics"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnCoreDNSScale defines parameters for EKS cluster
+// add-on CoreDNS scale and DNS latency tests, which generate DNS queries
+// at a fixed rate from the client Pods, record the resolution latencies
+// and the error rate, and optionally assert the CoreDNS Deployment
+// scales up under the load with a Horizontal Pod Autoscaler.
+// The summary is uploaded to "RequestsSummaryCompareS3Dir" and compared
+// with the one from the previous run, for the trend analysis.
+// ref. https://github.com/coredns/deployment/blob/master/kubernetes/Scaling_CoreDNS.md
+type AddOnCoreDNSScale struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// S3Dir is the S3 directory to store the test results.
+	S3Dir string `json:"s3-dir"`
+	// Namespace is the namespace of the client Pods.
+	Namespace string `json:"namespace"`
+
+	// Clients is the number of the client Pods.
+	Clients int `json:"clients"`
+	// QPSPerClient is the number of the DNS queries per second
+	// from each client Pod, sent regardless of the pending queries.
+	QPSPerClient int `json:"qps-per-client"`
+	// Duration is the duration of the load.
+	Duration       time.Duration `json:"duration"`
+	DurationString string        `json:"duration-string" read-only:"true"`
+	// QueryNames is the list of the names to query in rotation
+	// (e.g. "kubernetes.default.svc.cluster.local"), queried as fully
+	// qualified names to skip the search domains.
+	QueryNames []string `json:"query-names"`
+	// MaxErrorRate is the maximum ratio of the failed queries,
+	// between 0 and 1.
+	MaxErrorRate float64 `json:"max-error-rate"`
+
+	// AutoscaleMaxReplicas is the CoreDNS HPA maximum number of replicas.
+	// Zero to skip the autoscale test. Otherwise, requires metrics-server,
+	// so enables "AddOnMetricsServer".
+	AutoscaleMaxReplicas int32 `json:"autoscale-max-replicas"`
+	// AutoscaleTargetCPUUtilizationPercentage is the CoreDNS HPA target
+	// average CPU utilization, relative to the CPU requests.
+	AutoscaleTargetCPUUtilizationPercentage int32 `json:"autoscale-target-cpu-utilization-percentage"`
+	// OriginalReplicas is the number of the CoreDNS replicas before
+	// the test, restored after the test.
+	OriginalReplicas int32 `json:"original-replicas" read-only:"true"`
+	// MaxObservedReplicas is the maximum number of the CoreDNS replicas
+	// observed under load.
+	MaxObservedReplicas int32 `json:"max-observed-replicas" read-only:"true"`
+	// ScaleUpLatency is the time from the load start to the first scale up.
+	ScaleUpLatency       time.Duration `json:"scale-up-latency" read-only:"true"`
+	ScaleUpLatencyString string        `json:"scale-up-latency-string" read-only:"true"`
+
+	// ErrorRate is the ratio of the failed queries.
+	ErrorRate float64 `json:"error-rate" read-only:"true"`
+	// RequestsSummary is the DNS query latency summary.
+	// The latencies are measured in the client Pods, so include
+	// the client process overhead.
+	RequestsSummary          metrics.RequestsSummary `json:"requests-summary" read-only:"true"`
+	RequestsSummaryJSONPath  string                  `json:"requests-summary-json-path" read-only:"true"`
+	RequestsSummaryJSONS3Key string                  `json:"requests-summary-json-s3-key" read-only:"true"`
+
+	// RequestsSummaryCompareS3Dir is the S3 directory of previous/latest "RequestsSummary".
+	// Shared across the runs of the same Kubernetes version, so that
+	// the latest summary is compared with the previous one.
+	RequestsSummaryCompareS3Dir     string                  `json:"requests-summary-compare-s3-dir"`
+	RequestsSummaryCompare          metrics.RequestsCompare `json:"requests-summary-compare" read-only:"true"`
+	RequestsSummaryCompareJSONPath  string                  `json:"requests-summary-compare-json-path" read-only:"true"`
+	RequestsSummaryCompareJSONS3Key string                  `json:"requests-summary-compare-json-s3-key" read-only:"true"`
+}
+
+// EnvironmentVariablePrefixAddOnCoreDNSScale is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnCoreDNSScale = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_COREDNS_SCALE_"
+
+// IsEnabledAddOnCoreDNSScale returns true if "AddOnCoreDNSScale" is enabled.
+func (cfg *Config) IsEnabledAddOnCoreDNSScale() bool {
+	if cfg.AddOnCoreDNSScale == nil {
+		return false
+	}
+	if cfg.AddOnCoreDNSScale.Enable {
+		return true
+	}
+	return false
+}
+
+const (
+	// DefaultCoreDNSScaleClients is the default number of the client Pods.
+	DefaultCoreDNSScaleClients = 4
+	// DefaultCoreDNSScaleQPSPerClient is the default number of the DNS queries per second per client.
+	DefaultCoreDNSScaleQPSPerClient = 50
+	// DefaultCoreDNSScaleDuration is the default duration of the load.
+	DefaultCoreDNSScaleDuration = 3 * time.Minute
+	// DefaultCoreDNSScaleMaxErrorRate is the default maximum ratio of the failed queries.
+	DefaultCoreDNSScaleMaxErrorRate = 0.01
+	// DefaultCoreDNSScaleAutoscaleTargetCPUUtilizationPercentage is the default CoreDNS HPA target.
+	DefaultCoreDNSScaleAutoscaleTargetCPUUtilizationPercentage = 50
+)
+
+// DefaultCoreDNSScaleQueryNames is the default list of the names to query.
+var DefaultCoreDNSScaleQueryNames = []string{
+	"kubernetes.default.svc.cluster.local",
+	"kube-dns.kube-system.svc.cluster.local",
+}
+
+func getDefaultAddOnCoreDNSScale() *AddOnCoreDNSScale {
+	return &AddOnCoreDNSScale{
+		Enable:                                  false,
+		Clients:                                 DefaultCoreDNSScaleClients,
+		QPSPerClient:                            DefaultCoreDNSScaleQPSPerClient,
+		Duration:                                DefaultCoreDNSScaleDuration,
+		QueryNames:                              DefaultCoreDNSScaleQueryNames,
+		MaxErrorRate:                            DefaultCoreDNSScaleMaxErrorRate,
+		AutoscaleTargetCPUUtilizationPercentage: DefaultCoreDNSScaleAutoscaleTargetCPUUtilizationPercentage,
+	}
+}
+
+func (cfg *Config) validateAddOnCoreDNSScale() error {
+	if !cfg.IsEnabledAddOnCoreDNSScale() {
+		cfg.AddOnCoreDNSScale = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnCoreDNSScale.Enable true but no node group is enabled")
+	}
+
+	cur := cfg.AddOnCoreDNSScale
+	if cur.S3Dir == "" {
+		cur.S3Dir = path.Join(cfg.Name, "add-on-coredns-scale")
+	}
+	if cur.Namespace == "" {
+		cur.Namespace = cfg.Name + "-coredns-scale"
+	}
+	if cur.Clients <= 0 {
+		cur.Clients = DefaultCoreDNSScaleClients
+	}
+	if cur.QPSPerClient <= 0 {
+		cur.QPSPerClient = DefaultCoreDNSScaleQPSPerClient
+	}
+	if cur.Duration == time.Duration(0) {
+		cur.Duration = DefaultCoreDNSScaleDuration
+	}
+	if cur.Duration < 10*time.Second {
+		return fmt.Errorf("AddOnCoreDNSScale.Duration %v too short", cur.Duration)
+	}
+	cur.DurationString = cur.Duration.String()
+	if len(cur.QueryNames) == 0 {
+		cur.QueryNames = DefaultCoreDNSScaleQueryNames
+	}
+	for _, name := range cur.QueryNames {
+		if name == "" || strings.ContainsAny(name, " \"'$`;&|") {
+			return fmt.Errorf("AddOnCoreDNSScale.QueryNames %q invalid", name)
+		}
+	}
+	if cur.MaxErrorRate < 0 || cur.MaxErrorRate > 1 {
+		return fmt.Errorf("invalid AddOnCoreDNSScale.MaxErrorRate %v", cur.MaxErrorRate)
+	}
+
+	if cur.AutoscaleMaxReplicas < 0 {
+		return fmt.Errorf("invalid AddOnCoreDNSScale.AutoscaleMaxReplicas %d", cur.AutoscaleMaxReplicas)
+	}
+	if cur.AutoscaleMaxReplicas > 0 {
+		if !cfg.IsEnabledAddOnMetricsServer() {
+			cfg.AddOnMetricsServer = getDefaultAddOnMetricsServer()
+			cfg.AddOnMetricsServer.Enable = true
+		}
+		if cur.AutoscaleTargetCPUUtilizationPercentage == 0 {
+			cur.AutoscaleTargetCPUUtilizationPercentage = DefaultCoreDNSScaleAutoscaleTargetCPUUtilizationPercentage
+		}
+		if cur.AutoscaleTargetCPUUtilizationPercentage < 0 || cur.AutoscaleTargetCPUUtilizationPercentage > 100 {
+			return fmt.Errorf("invalid AddOnCoreDNSScale.AutoscaleTargetCPUUtilizationPercentage %d", cur.AutoscaleTargetCPUUtilizationPercentage)
+		}
+	}
+
+	if cur.RequestsSummaryJSONPath == "" {
+		cur.RequestsSummaryJSONPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + "-coredns-scale-requests-summary.json"
+		os.RemoveAll(cur.RequestsSummaryJSONPath)
+	}
+	if cur.RequestsSummaryJSONS3Key == "" {
+		cur.RequestsSummaryJSONS3Key = path.Join(
+			cur.S3Dir,
+			"requests-summary",
+			filepath.Base(cur.RequestsSummaryJSONPath),
+		)
+	}
+	if cur.RequestsSummaryCompareS3Dir == "" {
+		cur.RequestsSummaryCompareS3Dir = path.Join("add-on-coredns-scale", "requests-summary-compare", cfg.Version)
+	}
+	if cur.RequestsSummaryCompareJSONPath == "" {
+		cur.RequestsSummaryCompareJSONPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + "-coredns-scale-requests-summary-compare.json"
+		os.RemoveAll(cur.RequestsSummaryCompareJSONPath)
+	}
+	if cur.RequestsSummaryCompareJSONS3Key == "" {
+		cur.RequestsSummaryCompareJSONS3Key = path.Join(
+			cur.S3Dir,
+			"requests-summary-compare",
+			filepath.Base(cur.RequestsSummaryCompareJSONPath),
+		)
+	}
+
+	return nil
+}
@@ -58,7 +58,6 @@ type AddOnCronJobs struct {
 const EnvironmentVariablePrefixAddOnCronJobs = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CRON_JOBS_"
 
 // IsEnabledAddOnCronJobs returns true if "AddOnCronJobs" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnCronJobs() bool {
 	if cfg.AddOnCronJobs == nil {
 		return false
@@ -66,7 +65,6 @@ func (cfg *Config) IsEnabledAddOnCronJobs() bool {
 	if cfg.AddOnCronJobs.Enable {
 		return true
 	}
-	cfg.AddOnCronJobs = nil
 	return false
 }
 
@@ -91,6 +89,7 @@ func (cfg *Config) GetAddOnCronJobsRepositoryBusyboxRegion() string {
 
 func (cfg *Config) validateAddOnCronJobs() error {
 	if !cfg.IsEnabledAddOnCronJobs() {
+		cfg.AddOnCronJobs = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
@@ -0,0 +1,133 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/metrics"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnCSIEBSChurn defines parameters for EKS cluster
+// add-on EBS CSI volume churn, which repeatedly provisions, attaches,
+// detaches, and deletes EBS-backed PersistentVolumeClaims, and records
+// the latency percentiles per operation.
+// Use it to catch EBS CSI Driver and EC2 attach regressions.
+// Requires "AddOnCSIEBS".
+type AddOnCSIEBSChurn struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// Namespace is the namespace to create the PVCs and Pods in.
+	Namespace string `json:"namespace"`
+	// StorageClassName is the name of the StorageClass for the churned volumes.
+	StorageClassName string `json:"storage-class-name"`
+	// VolumeType is the EBS volume type for the StorageClass.
+	VolumeType string `json:"volume-type"`
+	// VolumeSizeGiB is the size of each churned volume.
+	VolumeSizeGiB int64 `json:"volume-size-gib"`
+	// PodImage is the image of the Pods that attach the volumes.
+	PodImage string `json:"pod-image"`
+
+	// Cycles is the total number of create, attach, detach,
+	// and delete cycles.
+	Cycles int `json:"cycles"`
+	// Concurrency is the number of cycles in flight.
+	Concurrency int `json:"concurrency"`
+	// CyclesPerMinute is the rate to start new cycles,
+	// shared by all workers.
+	CyclesPerMinute float64 `json:"cycles-per-minute"`
+	// CycleTimeout is the timeout for each step of a cycle.
+	CycleTimeout       time.Duration `json:"cycle-timeout"`
+	CycleTimeoutString string        `json:"cycle-timeout-string" read-only:"true"`
+
+	// CompletedCycles is the number of cycles without any failed step.
+	CompletedCycles int `json:"completed-cycles" read-only:"true"`
+	// LatencySummaries maps the operation (e.g. "attach")
+	// to its latency results.
+	LatencySummaries map[string]metrics.RequestsSummary `json:"latency-summaries,omitempty" read-only:"true"`
+	// LatencySummariesJSONPath is the path to write "LatencySummaries".
+	LatencySummariesJSONPath string `json:"latency-summaries-json-path" read-only:"true"`
+}
+
+// EnvironmentVariablePrefixAddOnCSIEBSChurn is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnCSIEBSChurn = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CSI_EBS_CHURN_"
+
+// IsEnabledAddOnCSIEBSChurn returns true if "AddOnCSIEBSChurn" is enabled.
+func (cfg *Config) IsEnabledAddOnCSIEBSChurn() bool {
+	if cfg.AddOnCSIEBSChurn == nil {
+		return false
+	}
+	if cfg.AddOnCSIEBSChurn.Enable {
+		return true
+	}
+	return false
+}
+
+func getDefaultAddOnCSIEBSChurn() *AddOnCSIEBSChurn {
+	return &AddOnCSIEBSChurn{
+		Enable:          false,
+		VolumeType:      "gp3",
+		VolumeSizeGiB:   1,
+		PodImage:        DefaultCSIEBSPodImage,
+		Cycles:          20,
+		Concurrency:     4,
+		CyclesPerMinute: 10,
+		CycleTimeout:    5 * time.Minute,
+	}
+}
+
+func (cfg *Config) validateAddOnCSIEBSChurn() error {
+	if !cfg.IsEnabledAddOnCSIEBSChurn() {
+		cfg.AddOnCSIEBSChurn = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnCSIEBS() {
+		return errors.New("AddOnCSIEBSChurn.Enable true but AddOnCSIEBS.Enable false")
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnCSIEBSChurn.Enable true but no node group is enabled")
+	}
+
+	if cfg.AddOnCSIEBSChurn.Namespace == "" {
+		cfg.AddOnCSIEBSChurn.Namespace = cfg.Name + "-csi-ebs-churn"
+	}
+	if cfg.AddOnCSIEBSChurn.StorageClassName == "" {
+		cfg.AddOnCSIEBSChurn.StorageClassName = cfg.Name + "-csi-ebs-churn"
+	}
+	if cfg.AddOnCSIEBSChurn.VolumeType == "" {
+		cfg.AddOnCSIEBSChurn.VolumeType = "gp3"
+	}
+	if cfg.AddOnCSIEBSChurn.VolumeSizeGiB <= 0 {
+		return fmt.Errorf("invalid AddOnCSIEBSChurn.VolumeSizeGiB %d", cfg.AddOnCSIEBSChurn.VolumeSizeGiB)
+	}
+	if cfg.AddOnCSIEBSChurn.PodImage == "" {
+		cfg.AddOnCSIEBSChurn.PodImage = DefaultCSIEBSPodImage
+	}
+	if cfg.AddOnCSIEBSChurn.Cycles <= 0 {
+		return fmt.Errorf("invalid AddOnCSIEBSChurn.Cycles %d", cfg.AddOnCSIEBSChurn.Cycles)
+	}
+	if cfg.AddOnCSIEBSChurn.Concurrency <= 0 {
+		return fmt.Errorf("invalid AddOnCSIEBSChurn.Concurrency %d", cfg.AddOnCSIEBSChurn.Concurrency)
+	}
+	if cfg.AddOnCSIEBSChurn.CyclesPerMinute <= 0 {
+		return fmt.Errorf("invalid AddOnCSIEBSChurn.CyclesPerMinute %v", cfg.AddOnCSIEBSChurn.CyclesPerMinute)
+	}
+	if cfg.AddOnCSIEBSChurn.CycleTimeout == time.Duration(0) {
+		cfg.AddOnCSIEBSChurn.CycleTimeout = 5 * time.Minute
+	}
+	cfg.AddOnCSIEBSChurn.CycleTimeoutString = cfg.AddOnCSIEBSChurn.CycleTimeout.String()
+
+	if cfg.AddOnCSIEBSChurn.LatencySummariesJSONPath == "" {
+		cfg.AddOnCSIEBSChurn.LatencySummariesJSONPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + "-csi-ebs-churn-latency-summaries.json"
+	}
+
+	return nil
+}
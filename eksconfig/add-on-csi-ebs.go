@@ -2,6 +2,8 @@ package eksconfig
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
 )
@@ -18,16 +20,58 @@ type AddOnCSIEBS struct {
 	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
 	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
 
+	// InstallMethod is either "helm" or "eks-add-on".
+	// "eks-add-on" installs the driver as an EKS managed add-on,
+	// using the node role for the EBS permissions.
+	// ref. https://docs.aws.amazon.com/eks/latest/userguide/managing-ebs-csi.html
+	InstallMethod string `json:"install-method"`
+	// AddOnVersion is the EKS managed add-on version.
+	// Leave empty to use the default version for the cluster version.
+	AddOnVersion string `json:"add-on-version"`
+
 	// ChartRepoURL is the chart repo URL.
+	// e.g. https://kubernetes-sigs.github.io/aws-ebs-csi-driver
 	// e.g. https://github.com/kubernetes-sigs/aws-ebs-csi-driver/releases/download/v0.5.0/helm-chart.tgz
 	ChartRepoURL string `json:"chart-repo-url"`
+	// ChartVersion is the chart version.
+	// Ignored when the chart repo URL is a ".tgz" archive.
+	ChartVersion string `json:"chart-version"`
+
+	// SkipVolumeTest is true to only install the driver,
+	// without the volume provisioning test.
+	SkipVolumeTest bool `json:"skip-volume-test"`
+	// Namespace is the namespace to create volume test objects in.
+	Namespace string `json:"namespace"`
+	// StorageClassName is the name of the StorageClass for the volume test.
+	StorageClassName string `json:"storage-class-name"`
+	// VolumeType is the EBS volume type for the StorageClass.
+	VolumeType string `json:"volume-type"`
+	// VolumeSizeGiB is the initial size of the test volume.
+	VolumeSizeGiB int64 `json:"volume-size-gib"`
+	// VolumeResizeGiB is the size to expand the test volume to.
+	VolumeResizeGiB int64 `json:"volume-resize-gib"`
+	// SnapshotControllerVersion is the external-snapshotter release
+	// to install the snapshot CRDs and controller from.
+	// ref. https://github.com/kubernetes-csi/external-snapshotter
+	SnapshotControllerVersion string `json:"snapshot-controller-version"`
+	// PodImage is the image for the volume test Pods.
+	PodImage string `json:"pod-image"`
+
+	// VolumeTestTimeout is the timeout for each step of the volume test.
+	VolumeTestTimeout       time.Duration `json:"volume-test-timeout"`
+	VolumeTestTimeoutString string        `json:"volume-test-timeout-string" read-only:"true"`
+
+	// VolumeIDs is the list of EBS volume IDs provisioned by the volume test,
+	// checked for deletion from EC2 on teardown.
+	VolumeIDs []string `json:"volume-ids" read-only:"true"`
+	// SnapshotHandle is the EBS snapshot ID taken by the volume test.
+	SnapshotHandle string `json:"snapshot-handle" read-only:"true"`
 }
 
 // EnvironmentVariablePrefixAddOnCSIEBS is the environment variable prefix used for "eksconfig".
 const EnvironmentVariablePrefixAddOnCSIEBS = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CSI_EBS_"
 
 // IsEnabledAddOnCSIEBS returns true if "AddOnCSIEBS" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnCSIEBS() bool {
 	if cfg.AddOnCSIEBS == nil {
 		return false
@@ -35,24 +79,94 @@ func (cfg *Config) IsEnabledAddOnCSIEBS() bool {
 	if cfg.AddOnCSIEBS.Enable {
 		return true
 	}
-	cfg.AddOnCSIEBS = nil
 	return false
 }
 
+const (
+	// CSIEBSInstallMethodHelm installs the driver with the Helm chart.
+	CSIEBSInstallMethodHelm = "helm"
+	// CSIEBSInstallMethodEKSAddOn installs the driver as an EKS managed add-on.
+	CSIEBSInstallMethodEKSAddOn = "eks-add-on"
+
+	// DefaultCSIEBSChartRepoURL is the default AWS EBS CSI Driver chart repo URL.
+	DefaultCSIEBSChartRepoURL = "https://kubernetes-sigs.github.io/aws-ebs-csi-driver"
+	// DefaultCSIEBSChartVersion is the default AWS EBS CSI Driver chart version.
+	DefaultCSIEBSChartVersion = "2.23.0"
+	// DefaultCSIEBSSnapshotControllerVersion is the default external-snapshotter release.
+	DefaultCSIEBSSnapshotControllerVersion = "v6.3.2"
+	// DefaultCSIEBSPodImage is the default image for the volume test Pods.
+	DefaultCSIEBSPodImage = "public.ecr.aws/docker/library/busybox:1.36"
+)
+
 func getDefaultAddOnCSIEBS() *AddOnCSIEBS {
 	return &AddOnCSIEBS{
-		Enable: false,
-		// https://github.com/kubernetes-sigs/aws-ebs-csi-driver#deploy-driver
-		ChartRepoURL: "https://github.com/kubernetes-sigs/aws-ebs-csi-driver/releases/download/v0.5.0/helm-chart.tgz",
+		Enable:                    false,
+		InstallMethod:             CSIEBSInstallMethodHelm,
+		ChartRepoURL:              DefaultCSIEBSChartRepoURL,
+		ChartVersion:              DefaultCSIEBSChartVersion,
+		VolumeType:                "gp3",
+		VolumeSizeGiB:             4,
+		VolumeResizeGiB:           8,
+		SnapshotControllerVersion: DefaultCSIEBSSnapshotControllerVersion,
+		PodImage:                  DefaultCSIEBSPodImage,
+		VolumeTestTimeout:         5 * time.Minute,
 	}
 }
 
 func (cfg *Config) validateAddOnCSIEBS() error {
 	if !cfg.IsEnabledAddOnCSIEBS() {
+		cfg.AddOnCSIEBS = nil
 		return nil
 	}
-	if cfg.AddOnCSIEBS.ChartRepoURL == "" {
+
+	switch cfg.AddOnCSIEBS.InstallMethod {
+	case "":
+		cfg.AddOnCSIEBS.InstallMethod = CSIEBSInstallMethodHelm
+	case CSIEBSInstallMethodHelm, CSIEBSInstallMethodEKSAddOn:
+	default:
+		return fmt.Errorf("unknown AddOnCSIEBS.InstallMethod %q", cfg.AddOnCSIEBS.InstallMethod)
+	}
+	if cfg.AddOnCSIEBS.InstallMethod == CSIEBSInstallMethodHelm && cfg.AddOnCSIEBS.ChartRepoURL == "" {
 		return errors.New("unexpected empty AddOnCSIEBS.ChartRepoURL")
 	}
+	if cfg.AddOnCSIEBS.InstallMethod == CSIEBSInstallMethodEKSAddOn && cfg.VersionValue < 1.18 {
+		return fmt.Errorf("Version %q not supported for AddOnCSIEBS.InstallMethod %q", cfg.Version, cfg.AddOnCSIEBS.InstallMethod)
+	}
+
+	if cfg.AddOnCSIEBS.SkipVolumeTest {
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnCSIEBS volume test enabled but no node group is enabled")
+	}
+	if cfg.AddOnCSIEBS.Namespace == "" {
+		cfg.AddOnCSIEBS.Namespace = cfg.Name + "-csi-ebs"
+	}
+	if cfg.AddOnCSIEBS.StorageClassName == "" {
+		cfg.AddOnCSIEBS.StorageClassName = cfg.Name + "-csi-ebs"
+	}
+	if cfg.AddOnCSIEBS.VolumeType == "" {
+		cfg.AddOnCSIEBS.VolumeType = "gp3"
+	}
+	if cfg.AddOnCSIEBS.VolumeSizeGiB == 0 {
+		cfg.AddOnCSIEBS.VolumeSizeGiB = 4
+	}
+	if cfg.AddOnCSIEBS.VolumeResizeGiB == 0 {
+		cfg.AddOnCSIEBS.VolumeResizeGiB = 2 * cfg.AddOnCSIEBS.VolumeSizeGiB
+	}
+	if cfg.AddOnCSIEBS.VolumeResizeGiB <= cfg.AddOnCSIEBS.VolumeSizeGiB {
+		return fmt.Errorf("AddOnCSIEBS.VolumeResizeGiB %d must be greater than AddOnCSIEBS.VolumeSizeGiB %d", cfg.AddOnCSIEBS.VolumeResizeGiB, cfg.AddOnCSIEBS.VolumeSizeGiB)
+	}
+	if cfg.AddOnCSIEBS.SnapshotControllerVersion == "" {
+		cfg.AddOnCSIEBS.SnapshotControllerVersion = DefaultCSIEBSSnapshotControllerVersion
+	}
+	if cfg.AddOnCSIEBS.PodImage == "" {
+		cfg.AddOnCSIEBS.PodImage = DefaultCSIEBSPodImage
+	}
+	if cfg.AddOnCSIEBS.VolumeTestTimeout == time.Duration(0) {
+		cfg.AddOnCSIEBS.VolumeTestTimeout = 5 * time.Minute
+	}
+	cfg.AddOnCSIEBS.VolumeTestTimeoutString = cfg.AddOnCSIEBS.VolumeTestTimeout.String()
+
 	return nil
 }
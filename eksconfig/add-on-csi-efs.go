@@ -0,0 +1,152 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnCSIEFS defines parameters for EKS cluster
+// add-on AWS EFS CSI Driver, with an EFS file system
+// in the cluster VPC and dynamically provisioned access points.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/efs-csi.html
+// ref. https://github.com/kubernetes-sigs/aws-efs-csi-driver
+type AddOnCSIEFS struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// Namespace is the namespace to create test objects in.
+	Namespace string `json:"namespace"`
+
+	// ChartRepoURL is the chart repo URL.
+	ChartRepoURL string `json:"chart-repo-url"`
+	// ChartVersion is the chart version.
+	ChartVersion string `json:"chart-version"`
+
+	// RoleName is the IAM role name for the controller service account (IRSA).
+	RoleName string `json:"role-name"`
+	// RoleARN is the IAM role ARN for the controller.
+	RoleARN string `json:"role-arn" read-only:"true"`
+	// OIDCProviderCreated is true when the tester created the cluster
+	// IAM OIDC provider, to be deleted with the add-on.
+	OIDCProviderCreated bool `json:"oidc-provider-created" read-only:"true"`
+
+	// PerformanceMode is the EFS file system performance mode.
+	// Either "generalPurpose" or "maxIO".
+	PerformanceMode string `json:"performance-mode"`
+	// FileSystemID is the EFS file system ID created for the test.
+	FileSystemID string `json:"file-system-id" read-only:"true"`
+	// SecurityGroupID is the security group for the mount targets,
+	// allowing NFS from the VPC.
+	SecurityGroupID string `json:"security-group-id" read-only:"true"`
+	// MountTargetIDs is the list of mount target IDs, one per subnet.
+	MountTargetIDs []string `json:"mount-target-ids" read-only:"true"`
+	// AccessPointIDs is the list of access points provisioned
+	// by the driver for the test volumes.
+	AccessPointIDs []string `json:"access-point-ids" read-only:"true"`
+
+	// StorageClassName is the name of the StorageClass for the volume test.
+	StorageClassName string `json:"storage-class-name"`
+	// DeploymentReplicas is the number of ReadWriteMany Pods,
+	// each scheduled to a different node.
+	DeploymentReplicas int32 `json:"deployment-replicas"`
+	// PodImage is the image for the volume test Pods.
+	PodImage string `json:"pod-image"`
+
+	// VolumeTestTimeout is the timeout for each step of the volume test.
+	VolumeTestTimeout       time.Duration `json:"volume-test-timeout"`
+	VolumeTestTimeoutString string        `json:"volume-test-timeout-string" read-only:"true"`
+}
+
+// EnvironmentVariablePrefixAddOnCSIEFS is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnCSIEFS = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CSI_EFS_"
+
+// IsEnabledAddOnCSIEFS returns true if "AddOnCSIEFS" is enabled.
+func (cfg *Config) IsEnabledAddOnCSIEFS() bool {
+	if cfg.AddOnCSIEFS == nil {
+		return false
+	}
+	if cfg.AddOnCSIEFS.Enable {
+		return true
+	}
+	return false
+}
+
+const (
+	// DefaultCSIEFSChartRepoURL is the default AWS EFS CSI Driver chart repo URL.
+	DefaultCSIEFSChartRepoURL = "https://kubernetes-sigs.github.io/aws-efs-csi-driver"
+	// DefaultCSIEFSChartVersion is the default AWS EFS CSI Driver chart version.
+	DefaultCSIEFSChartVersion = "2.4.9"
+	// DefaultCSIEFSPodImage is the default image for the volume test Pods.
+	DefaultCSIEFSPodImage = "public.ecr.aws/docker/library/busybox:1.36"
+)
+
+func getDefaultAddOnCSIEFS() *AddOnCSIEFS {
+	return &AddOnCSIEFS{
+		Enable:             false,
+		ChartRepoURL:       DefaultCSIEFSChartRepoURL,
+		ChartVersion:       DefaultCSIEFSChartVersion,
+		PerformanceMode:    "generalPurpose",
+		DeploymentReplicas: 2,
+		PodImage:           DefaultCSIEFSPodImage,
+		VolumeTestTimeout:  5 * time.Minute,
+	}
+}
+
+func (cfg *Config) validateAddOnCSIEFS() error {
+	if !cfg.IsEnabledAddOnCSIEFS() {
+		cfg.AddOnCSIEFS = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnCSIEFS.Enable true but no node group is enabled")
+	}
+
+	if cfg.AddOnCSIEFS.Namespace == "" {
+		cfg.AddOnCSIEFS.Namespace = cfg.Name + "-csi-efs"
+	}
+	if cfg.AddOnCSIEFS.ChartRepoURL == "" {
+		cfg.AddOnCSIEFS.ChartRepoURL = DefaultCSIEFSChartRepoURL
+	}
+	if cfg.AddOnCSIEFS.ChartVersion == "" {
+		cfg.AddOnCSIEFS.ChartVersion = DefaultCSIEFSChartVersion
+	}
+	if cfg.AddOnCSIEFS.RoleName == "" {
+		cfg.AddOnCSIEFS.RoleName = cfg.Name + "-add-on-csi-efs-controller-role"
+	}
+
+	switch cfg.AddOnCSIEFS.PerformanceMode {
+	case "":
+		cfg.AddOnCSIEFS.PerformanceMode = "generalPurpose"
+	case "generalPurpose", "maxIO":
+	default:
+		return fmt.Errorf("unknown AddOnCSIEFS.PerformanceMode %q", cfg.AddOnCSIEFS.PerformanceMode)
+	}
+
+	if cfg.AddOnCSIEFS.StorageClassName == "" {
+		cfg.AddOnCSIEFS.StorageClassName = cfg.Name + "-csi-efs"
+	}
+	if cfg.AddOnCSIEFS.DeploymentReplicas == 0 {
+		cfg.AddOnCSIEFS.DeploymentReplicas = 2
+	}
+	// at least two nodes to check the consistency across nodes
+	if cfg.AddOnCSIEFS.DeploymentReplicas < 2 {
+		return fmt.Errorf("AddOnCSIEFS.DeploymentReplicas %d invalid (expected at least 2)", cfg.AddOnCSIEFS.DeploymentReplicas)
+	}
+	if cfg.AddOnCSIEFS.PodImage == "" {
+		cfg.AddOnCSIEFS.PodImage = DefaultCSIEFSPodImage
+	}
+	if cfg.AddOnCSIEFS.VolumeTestTimeout == time.Duration(0) {
+		cfg.AddOnCSIEFS.VolumeTestTimeout = 5 * time.Minute
+	}
+	cfg.AddOnCSIEFS.VolumeTestTimeoutString = cfg.AddOnCSIEFS.VolumeTestTimeout.String()
+
+	return nil
+}
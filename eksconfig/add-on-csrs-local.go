@@ -93,7 +93,6 @@ type AddOnCSRsLocal struct {
 const EnvironmentVariablePrefixAddOnCSRsLocal = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CSRS_LOCAL_"
 
 // IsEnabledAddOnCSRsLocal returns true if "AddOnCSRsLocal" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnCSRsLocal() bool {
 	if cfg.AddOnCSRsLocal == nil {
 		return false
@@ -101,7 +100,6 @@ func (cfg *Config) IsEnabledAddOnCSRsLocal() bool {
 	if cfg.AddOnCSRsLocal.Enable {
 		return true
 	}
-	cfg.AddOnCSRsLocal = nil
 	return false
 }
 
@@ -115,6 +113,7 @@ func getDefaultAddOnCSRsLocal() *AddOnCSRsLocal {
 
 func (cfg *Config) validateAddOnCSRsLocal() error {
 	if !cfg.IsEnabledAddOnCSRsLocal() {
+		cfg.AddOnCSRsLocal = nil
 		return nil
 	}
 
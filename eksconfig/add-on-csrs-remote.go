@@ -119,7 +119,6 @@ type AddOnCSRsRemote struct {
 const EnvironmentVariablePrefixAddOnCSRsRemote = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CSRS_REMOTE_"
 
 // IsEnabledAddOnCSRsRemote returns true if "AddOnCSRsRemote" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnCSRsRemote() bool {
 	if cfg.AddOnCSRsRemote == nil {
 		return false
@@ -127,7 +126,6 @@ func (cfg *Config) IsEnabledAddOnCSRsRemote() bool {
 	if cfg.AddOnCSRsRemote.Enable {
 		return true
 	}
-	cfg.AddOnCSRsRemote = nil
 	return false
 }
 
@@ -151,6 +149,7 @@ func (cfg *Config) GetAddOnCSRsRemoteRepositoryRegion() string {
 
 func (cfg *Config) validateAddOnCSRsRemote() error {
 	if !cfg.IsEnabledAddOnCSRsRemote() {
+		cfg.AddOnCSRsRemote = nil
 		return nil
 	}
 
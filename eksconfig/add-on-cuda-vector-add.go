@@ -27,7 +27,6 @@ type AddOnCUDAVectorAdd struct {
 const EnvironmentVariablePrefixAddOnCUDAVectorAdd = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CUDA_VECTOR_ADD_"
 
 // IsEnabledAddOnCUDAVectorAdd returns true if "AddOnCUDAVectorAdd" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnCUDAVectorAdd() bool {
 	if cfg.AddOnCUDAVectorAdd == nil {
 		return false
@@ -35,7 +34,6 @@ func (cfg *Config) IsEnabledAddOnCUDAVectorAdd() bool {
 	if cfg.AddOnCUDAVectorAdd.Enable {
 		return true
 	}
-	cfg.AddOnCUDAVectorAdd = nil
 	return false
 }
 
@@ -48,6 +46,7 @@ func getDefaultAddOnCUDAVectorAdd() *AddOnCUDAVectorAdd {
 
 func (cfg *Config) validateAddOnCUDAVectorAdd() error {
 	if !cfg.IsEnabledAddOnCUDAVectorAdd() {
+		cfg.AddOnCUDAVectorAdd = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
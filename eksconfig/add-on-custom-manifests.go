@@ -0,0 +1,135 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnCustomManifests defines parameters for EKS cluster
+// add-on custom manifests, which apply the user-provided Kubernetes YAML
+// with server-side apply, wait for the workloads to be ready,
+// and delete the applied objects on teardown.
+type AddOnCustomManifests struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// Sources is the list of the Kubernetes YAML manifests to apply in order,
+	// each a local file path, an "http://" or "https://" URL,
+	// or an S3 object "s3://[BUCKET]/[KEY]".
+	Sources []string `json:"sources"`
+	// Namespace is the namespace of the namespaced objects without one,
+	// created before the apply and deleted on teardown.
+	Namespace string `json:"namespace"`
+	// FieldManager is the server-side apply field manager name.
+	FieldManager string `json:"field-manager"`
+	// ForceConflicts is true to take the ownership of the fields
+	// managed by the other field managers.
+	ForceConflicts bool `json:"force-conflicts"`
+
+	// WaitRollout is true to wait for the rollout of the applied
+	// Deployments, DaemonSets, and StatefulSets.
+	WaitRollout bool `json:"wait-rollout"`
+	// WaitJobComplete is true to wait for the applied Jobs to complete.
+	WaitJobComplete bool `json:"wait-job-complete"`
+	// ReadinessTimeout is the bound for all readiness waits.
+	ReadinessTimeout       time.Duration `json:"readiness-timeout"`
+	ReadinessTimeoutString string        `json:"readiness-timeout-string" read-only:"true"`
+
+	// Objects is the list of the applied objects, deleted in reverse on teardown.
+	Objects []CustomManifestObject `json:"objects" read-only:"true"`
+}
+
+// CustomManifestObject is a Kubernetes object applied from the custom manifests.
+type CustomManifestObject struct {
+	Source     string `json:"source"`
+	APIVersion string `json:"api-version"`
+	Kind       string `json:"kind"`
+	// Namespace is empty for the cluster-scoped objects,
+	// or the namespaced objects defaulting to "AddOnCustomManifests.Namespace".
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// EnvironmentVariablePrefixAddOnCustomManifests is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnCustomManifests = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CUSTOM_MANIFESTS_"
+
+// DefaultCustomManifestsFieldManager is the default server-side apply field manager.
+const DefaultCustomManifestsFieldManager = "aws-k8s-tester"
+
+// IsEnabledAddOnCustomManifests returns true if "AddOnCustomManifests" is enabled.
+func (cfg *Config) IsEnabledAddOnCustomManifests() bool {
+	if cfg.AddOnCustomManifests == nil {
+		return false
+	}
+	if cfg.AddOnCustomManifests.Enable {
+		return true
+	}
+	return false
+}
+
+func getDefaultAddOnCustomManifests() *AddOnCustomManifests {
+	return &AddOnCustomManifests{
+		Enable:           false,
+		FieldManager:     DefaultCustomManifestsFieldManager,
+		WaitRollout:      true,
+		WaitJobComplete:  true,
+		ReadinessTimeout: 10 * time.Minute,
+	}
+}
+
+func (cfg *Config) validateAddOnCustomManifests() error {
+	if !cfg.IsEnabledAddOnCustomManifests() {
+		cfg.AddOnCustomManifests = nil
+		return nil
+	}
+
+	cur := cfg.AddOnCustomManifests
+	if len(cur.Sources) == 0 {
+		return errors.New("AddOnCustomManifests.Enable true but empty AddOnCustomManifests.Sources")
+	}
+	for _, src := range cur.Sources {
+		switch {
+		case strings.HasPrefix(src, "http://"), strings.HasPrefix(src, "https://"):
+		case strings.HasPrefix(src, "s3://"):
+			if _, _, err := ParseS3URL(src); err != nil {
+				return fmt.Errorf("AddOnCustomManifests.Sources %v", err)
+			}
+		default:
+			if !fileutil.Exist(src) {
+				return fmt.Errorf("AddOnCustomManifests.Sources %q does not exist", src)
+			}
+		}
+	}
+
+	if cur.Namespace == "" {
+		cur.Namespace = cfg.Name + "-custom-manifests"
+	}
+	if cur.FieldManager == "" {
+		cur.FieldManager = DefaultCustomManifestsFieldManager
+	}
+	if cur.ReadinessTimeout == time.Duration(0) {
+		cur.ReadinessTimeout = 10 * time.Minute
+	}
+	cur.ReadinessTimeoutString = cur.ReadinessTimeout.String()
+
+	return nil
+}
+
+// ParseS3URL parses the "s3://[BUCKET]/[KEY]" URL.
+func ParseS3URL(u string) (bucket string, key string, err error) {
+	ss := strings.SplitN(strings.TrimPrefix(u, "s3://"), "/", 2)
+	if !strings.HasPrefix(u, "s3://") || len(ss) != 2 || ss[0] == "" || ss[1] == "" {
+		return "", "", fmt.Errorf("invalid S3 URL %q (expected \"s3://[BUCKET]/[KEY]\")", u)
+	}
+	return ss[0], ss[1], nil
+}
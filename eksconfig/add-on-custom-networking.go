@@ -0,0 +1,150 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnCustomNetworking defines parameters for EKS cluster
+// add-on VPC CNI custom networking, which attaches a secondary
+// VPC CIDR block and assigns Pod IPs from the secondary subnets
+// with ENIConfig resources.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/cni-custom-network.html
+type AddOnCustomNetworking struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// Namespace is the namespace to create test objects in.
+	Namespace string `json:"namespace"`
+
+	// SecondaryCIDR is the secondary CIDR block to associate with the VPC.
+	// Must not overlap with "VPC.CIDRs".
+	SecondaryCIDR string `json:"secondary-cidr"`
+	// SubnetCIDRs is the CIDR blocks for the Pod subnets, one for each
+	// availability zone of the node subnets, within "SecondaryCIDR".
+	SubnetCIDRs []string `json:"subnet-cidrs"`
+
+	// NodeCycleTimeout is the timeout to replace all nodes, so that
+	// the new nodes allocate Pod IPs with the custom network configuration.
+	NodeCycleTimeout       time.Duration `json:"node-cycle-timeout"`
+	NodeCycleTimeoutString string        `json:"node-cycle-timeout-string" read-only:"true"`
+
+	// DeploymentReplicas is the number of replicas to verify Pod IPs.
+	DeploymentReplicas int32 `json:"deployment-replicas"`
+	// PodImage is the image for the test Pods.
+	PodImage string `json:"pod-image"`
+
+	// CIDRAssociationID is the VPC CIDR block association ID.
+	CIDRAssociationID string `json:"cidr-association-id" read-only:"true"`
+	// SubnetIDs maps each availability zone to its Pod subnet ID.
+	SubnetIDs map[string]string `json:"subnet-ids" read-only:"true"`
+	// PodIPs is the list of test Pod IPs verified within "SecondaryCIDR".
+	PodIPs []string `json:"pod-ips" read-only:"true"`
+}
+
+// EnvironmentVariablePrefixAddOnCustomNetworking is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnCustomNetworking = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CUSTOM_NETWORKING_"
+
+// IsEnabledAddOnCustomNetworking returns true if "AddOnCustomNetworking" is enabled.
+func (cfg *Config) IsEnabledAddOnCustomNetworking() bool {
+	if cfg.AddOnCustomNetworking == nil {
+		return false
+	}
+	if cfg.AddOnCustomNetworking.Enable {
+		return true
+	}
+	return false
+}
+
+const (
+	// DefaultCustomNetworkingSecondaryCIDR is the default secondary CIDR block,
+	// from the shared address space commonly used for Pod IPs.
+	DefaultCustomNetworkingSecondaryCIDR = "100.64.0.0/16"
+	// DefaultCustomNetworkingPodImage is the default test Pod image.
+	DefaultCustomNetworkingPodImage = "busybox:1.36"
+)
+
+func getDefaultAddOnCustomNetworking() *AddOnCustomNetworking {
+	return &AddOnCustomNetworking{
+		Enable:        false,
+		SecondaryCIDR: DefaultCustomNetworkingSecondaryCIDR,
+		SubnetCIDRs: []string{
+			"100.64.0.0/19",
+			"100.64.32.0/19",
+			"100.64.64.0/19",
+		},
+		NodeCycleTimeout:   30 * time.Minute,
+		DeploymentReplicas: 6,
+		PodImage:           DefaultCustomNetworkingPodImage,
+	}
+}
+
+func (cfg *Config) validateAddOnCustomNetworking() error {
+	if !cfg.IsEnabledAddOnCustomNetworking() {
+		cfg.AddOnCustomNetworking = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnCustomNetworking.Enable true but no node group is enabled")
+	}
+	// custom networking only assigns IPv4 addresses from the secondary subnets
+	if cfg.IPFamily == IPFamilyIPv6 {
+		return fmt.Errorf("AddOnCustomNetworking.Enable true but IPFamily %q", cfg.IPFamily)
+	}
+
+	if cfg.AddOnCustomNetworking.Namespace == "" {
+		cfg.AddOnCustomNetworking.Namespace = cfg.Name + "-custom-networking"
+	}
+
+	if cfg.AddOnCustomNetworking.SecondaryCIDR == "" {
+		cfg.AddOnCustomNetworking.SecondaryCIDR = DefaultCustomNetworkingSecondaryCIDR
+	}
+	_, secondary, err := net.ParseCIDR(cfg.AddOnCustomNetworking.SecondaryCIDR)
+	if err != nil {
+		return fmt.Errorf("invalid AddOnCustomNetworking.SecondaryCIDR %q (%v)", cfg.AddOnCustomNetworking.SecondaryCIDR, err)
+	}
+	for _, cidr := range cfg.VPC.CIDRs {
+		_, primary, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid VPC.CIDRs %q (%v)", cidr, err)
+		}
+		if primary.Contains(secondary.IP) || secondary.Contains(primary.IP) {
+			return fmt.Errorf("AddOnCustomNetworking.SecondaryCIDR %q overlaps with VPC CIDR %q", cfg.AddOnCustomNetworking.SecondaryCIDR, cidr)
+		}
+	}
+	if len(cfg.AddOnCustomNetworking.SubnetCIDRs) < len(cfg.VPC.PublicSubnetCIDRs) {
+		return fmt.Errorf("unexpected number of AddOnCustomNetworking.SubnetCIDRs %v (expected at least %d)", cfg.AddOnCustomNetworking.SubnetCIDRs, len(cfg.VPC.PublicSubnetCIDRs))
+	}
+	for _, cidr := range cfg.AddOnCustomNetworking.SubnetCIDRs {
+		ip, _, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid AddOnCustomNetworking.SubnetCIDRs %q (%v)", cidr, err)
+		}
+		if !secondary.Contains(ip) {
+			return fmt.Errorf("AddOnCustomNetworking.SubnetCIDRs %q not within SecondaryCIDR %q", cidr, cfg.AddOnCustomNetworking.SecondaryCIDR)
+		}
+	}
+
+	if cfg.AddOnCustomNetworking.NodeCycleTimeout == time.Duration(0) {
+		cfg.AddOnCustomNetworking.NodeCycleTimeout = 30 * time.Minute
+	}
+	cfg.AddOnCustomNetworking.NodeCycleTimeoutString = cfg.AddOnCustomNetworking.NodeCycleTimeout.String()
+
+	if cfg.AddOnCustomNetworking.DeploymentReplicas == 0 {
+		cfg.AddOnCustomNetworking.DeploymentReplicas = 6
+	}
+	if cfg.AddOnCustomNetworking.PodImage == "" {
+		cfg.AddOnCustomNetworking.PodImage = DefaultCustomNetworkingPodImage
+	}
+
+	return nil
+}
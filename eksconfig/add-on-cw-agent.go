@@ -27,7 +27,6 @@ type AddOnCWAgent struct {
 const AWS_K8S_TESTER_EKS_ADD_ON_CW_AGENT_PREFIX = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_CW_AGENT_"
 
 // IsEnabledAddOnCWAgent returns true if "AddOnCWAgent" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnCWAgent() bool {
 	if cfg.AddOnCWAgent == nil {
 		return false
@@ -35,7 +34,6 @@ func (cfg *Config) IsEnabledAddOnCWAgent() bool {
 	if cfg.AddOnCWAgent.Enable {
 		return true
 	}
-	cfg.AddOnCWAgent = nil
 	return false
 }
 
@@ -47,6 +45,7 @@ func getDefaultAddOnCWAgent() *AddOnCWAgent {
 
 func (cfg *Config) validateAddOnCWAgent() error {
 	if !cfg.IsEnabledAddOnCWAgent() {
+		cfg.AddOnCWAgent = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
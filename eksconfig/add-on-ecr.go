@@ -0,0 +1,158 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"github.com/aws/aws-sdk-go/service/ecr"
+)
+
+// AddOnECR defines parameters for EKS cluster
+// add-on ECR tests, which create a private ECR repository,
+// push a test image (built locally or replicated from a public registry),
+// allow the worker nodes to pull, and verify the private pull path
+// and the image tag immutability settings.
+type AddOnECR struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// RepositoryName is the ECR repository name to create in the cluster region.
+	RepositoryName string `json:"repository-name"`
+	// ImageTag is the image tag to push.
+	ImageTag string `json:"image-tag"`
+	// ImageTagMutability is either "MUTABLE" or "IMMUTABLE".
+	ImageTagMutability string `json:"image-tag-mutability"`
+	// ImageScanOnPush is true to scan the images on push.
+	ImageScanOnPush bool `json:"image-scan-on-push"`
+
+	// SourceImage is the public image to replicate to the repository,
+	// with all its platforms, if "BuildContextDir" is empty.
+	SourceImage string `json:"source-image"`
+	// BuildContextDir is the local directory to build the image from
+	// with the "docker" CLI, instead of replicating "SourceImage".
+	BuildContextDir string `json:"build-context-dir"`
+
+	// ReferenceFromAddOns is true to pull the busybox images of
+	// "AddOnFluentd", "AddOnJobsEcho", and "AddOnCronJobs" from this repository,
+	// unless their repositories are configured. Requires the busybox image
+	// (e.g. the default "SourceImage").
+	ReferenceFromAddOns bool `json:"reference-from-add-ons"`
+
+	// Namespace is the namespace to run the pull verification Pod in.
+	Namespace string `json:"namespace"`
+
+	// RepositoryURI is the URI of the created repository.
+	RepositoryURI string `json:"repository-uri" read-only:"true"`
+	// Image is the pushed image (e.g. "[ACCOUNT_ID].dkr.ecr.[REGION].amazonaws.com/[NAME]:[TAG]").
+	Image string `json:"image" read-only:"true"`
+	// ImageDigest is the manifest digest of the replicated image.
+	ImageDigest string `json:"image-digest" read-only:"true"`
+	// PullTime is the time from the verification Pod creation until the image is pulled.
+	PullTime       time.Duration `json:"pull-time" read-only:"true"`
+	PullTimeString string        `json:"pull-time-string" read-only:"true"`
+}
+
+// EnvironmentVariablePrefixAddOnECR is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnECR = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_ECR_"
+
+// DefaultECRSourceImage is the default image to replicate.
+const DefaultECRSourceImage = "public.ecr.aws/docker/library/busybox:1.36"
+
+// IsEnabledAddOnECR returns true if "AddOnECR" is enabled.
+func (cfg *Config) IsEnabledAddOnECR() bool {
+	if cfg.AddOnECR == nil {
+		return false
+	}
+	if cfg.AddOnECR.Enable {
+		return true
+	}
+	return false
+}
+
+func getDefaultAddOnECR() *AddOnECR {
+	return &AddOnECR{
+		Enable:             false,
+		ImageTag:           "latest",
+		ImageTagMutability: ecr.ImageTagMutabilityMutable,
+		SourceImage:        DefaultECRSourceImage,
+	}
+}
+
+// ecrRepositoryNameRegex matches the ECR repository names.
+// ref. https://docs.aws.amazon.com/AmazonECR/latest/APIReference/API_CreateRepository.html
+var ecrRepositoryNameRegex = regexp.MustCompile(`^(?:[a-z0-9]+(?:[._-][a-z0-9]+)*/)*[a-z0-9]+(?:[._-][a-z0-9]+)*$`)
+
+func (cfg *Config) validateAddOnECR() error {
+	if !cfg.IsEnabledAddOnECR() {
+		cfg.AddOnECR = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnECR.Enable true but no node group is enabled")
+	}
+
+	cur := cfg.AddOnECR
+	if cur.RepositoryName == "" {
+		cur.RepositoryName = strings.ToLower(cfg.Name) + "-ecr"
+	}
+	if len(cur.RepositoryName) < 2 || len(cur.RepositoryName) > 256 || !ecrRepositoryNameRegex.MatchString(cur.RepositoryName) {
+		return fmt.Errorf("AddOnECR.RepositoryName %q invalid", cur.RepositoryName)
+	}
+	if cur.ImageTag == "" {
+		cur.ImageTag = "latest"
+	}
+	switch cur.ImageTagMutability {
+	case "":
+		cur.ImageTagMutability = ecr.ImageTagMutabilityMutable
+	case ecr.ImageTagMutabilityMutable, ecr.ImageTagMutabilityImmutable:
+	default:
+		return fmt.Errorf("AddOnECR.ImageTagMutability %q unknown (must be %q or %q)", cur.ImageTagMutability, ecr.ImageTagMutabilityMutable, ecr.ImageTagMutabilityImmutable)
+	}
+
+	switch {
+	case cur.BuildContextDir != "":
+		if !fileutil.Exist(cur.BuildContextDir) {
+			return fmt.Errorf("AddOnECR.BuildContextDir %q does not exist", cur.BuildContextDir)
+		}
+	case cur.SourceImage == "":
+		cur.SourceImage = DefaultECRSourceImage
+	}
+	if cur.ReferenceFromAddOns && cur.BuildContextDir != "" {
+		return errors.New("AddOnECR.ReferenceFromAddOns requires the busybox image replicated from SourceImage, not BuildContextDir")
+	}
+
+	if cur.Namespace == "" {
+		cur.Namespace = cfg.Name + "-ecr"
+	}
+
+	if cur.ReferenceFromAddOns {
+		// the account ID is set after the repository is created,
+		// since the caller identity is not known until then
+		if cfg.IsEnabledAddOnFluentd() && cfg.AddOnFluentd.RepositoryBusyboxName == "" {
+			cfg.AddOnFluentd.RepositoryBusyboxRegion = cfg.Region
+			cfg.AddOnFluentd.RepositoryBusyboxName = cur.RepositoryName
+			cfg.AddOnFluentd.RepositoryBusyboxImageTag = cur.ImageTag
+		}
+		if cfg.IsEnabledAddOnJobsEcho() && cfg.AddOnJobsEcho.RepositoryBusyboxName == "" {
+			cfg.AddOnJobsEcho.RepositoryBusyboxRegion = cfg.Region
+			cfg.AddOnJobsEcho.RepositoryBusyboxName = cur.RepositoryName
+			cfg.AddOnJobsEcho.RepositoryBusyboxImageTag = cur.ImageTag
+		}
+		if cfg.IsEnabledAddOnCronJobs() && cfg.AddOnCronJobs.RepositoryBusyboxName == "" {
+			cfg.AddOnCronJobs.RepositoryBusyboxRegion = cfg.Region
+			cfg.AddOnCronJobs.RepositoryBusyboxName = cur.RepositoryName
+			cfg.AddOnCronJobs.RepositoryBusyboxImageTag = cur.ImageTag
+		}
+	}
+	return nil
+}
@@ -58,15 +58,38 @@ type AddOnFargate struct {
 	// ProfileName is the profile name for Fargate.
 	ProfileName string `json:"profile-name"`
 
+	// ProfileARN is the ARN of the created Fargate profile.
+	ProfileARN string `json:"profile-arn" read-only:"true"`
+	// ProfileStatus is the current status of the Fargate profile.
+	ProfileStatus string `json:"profile-status" read-only:"true"`
+
 	// SecretName is the secret name for Fargate.
 	SecretName string `json:"secret-name"`
+
+	// PodReplicas is the number of Pods to run on Fargate.
+	// Each Pod is scheduled onto its own Fargate node.
+	PodReplicas int `json:"pod-replicas"`
+	// Pods maps each Pod name to its scheduling status.
+	Pods map[string]FargatePod `json:"pods" read-only:"true"`
+
+	// LogsDir is the directory to store Pod logs fetched via the Kubernetes API.
+	LogsDir string `json:"logs-dir,omitempty"`
+}
+
+// FargatePod is the status of a Pod selected into the Fargate profile.
+type FargatePod struct {
+	// NodeName is the name of the Fargate node the Pod is scheduled onto.
+	NodeName string `json:"node-name" read-only:"true"`
+	// Phase is the last observed Pod phase.
+	Phase string `json:"phase" read-only:"true"`
+	// LogsPath is the local file path of the Pod logs.
+	LogsPath string `json:"logs-path" read-only:"true"`
 }
 
 // EnvironmentVariablePrefixAddOnFargate is the environment variable prefix used for "eksconfig".
 const EnvironmentVariablePrefixAddOnFargate = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_FARGATE_"
 
 // IsEnabledAddOnFargate returns true if "AddOnFargate" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnFargate() bool {
 	if cfg.AddOnFargate == nil {
 		return false
@@ -74,14 +97,14 @@ func (cfg *Config) IsEnabledAddOnFargate() bool {
 	if cfg.AddOnFargate.Enable {
 		return true
 	}
-	cfg.AddOnFargate = nil
 	return false
 }
 
 func getDefaultAddOnFargate() *AddOnFargate {
 	return &AddOnFargate{
-		Enable:     false,
-		RoleCreate: true,
+		Enable:      false,
+		RoleCreate:  true,
+		PodReplicas: DefaultFargatePodReplicas,
 	}
 }
 
@@ -92,11 +115,19 @@ func (cfg *Config) GetAddOnFargateRepositoryRegion() string {
 	return cfg.AddOnFargate.RepositoryRegion
 }
 
+const (
+	// DefaultFargatePodReplicas is the default number of Fargate Pods.
+	DefaultFargatePodReplicas = 2
+	// FargatePodReplicasMaxLimit is the maximum number of Fargate Pods.
+	FargatePodReplicasMaxLimit = 20
+)
+
 // only letters and numbers for Secret key names
 var fargateSecretRegex = regexp.MustCompile("[^a-zA-Z0-9]+")
 
 func (cfg *Config) validateAddOnFargate() error {
 	if !cfg.IsEnabledAddOnFargate() {
+		cfg.AddOnFargate = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
@@ -140,6 +171,16 @@ func (cfg *Config) validateAddOnFargate() error {
 	}
 	cfg.AddOnFargate.SecretName = strings.ToLower(fargateSecretRegex.ReplaceAllString(cfg.AddOnFargate.SecretName, ""))
 
+	if cfg.AddOnFargate.PodReplicas == 0 {
+		cfg.AddOnFargate.PodReplicas = DefaultFargatePodReplicas
+	}
+	if cfg.AddOnFargate.PodReplicas < 0 || cfg.AddOnFargate.PodReplicas > FargatePodReplicasMaxLimit {
+		return fmt.Errorf("AddOnFargate.PodReplicas %d invalid (must be 1 to %d)", cfg.AddOnFargate.PodReplicas, FargatePodReplicasMaxLimit)
+	}
+	if cfg.AddOnFargate.LogsDir == "" {
+		cfg.AddOnFargate.LogsDir = filepath.Join(filepath.Dir(cfg.ConfigPath), cfg.Name+"-logs-fargate")
+	}
+
 	if cfg.AddOnFargate.RoleCFNStackYAMLPath == "" {
 		cfg.AddOnFargate.RoleCFNStackYAMLPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + ".add-on-fargate.role.cfn.yaml"
 	}
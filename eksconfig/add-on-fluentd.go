@@ -77,7 +77,6 @@ type AddOnFluentd struct {
 const AWS_K8S_TESTER_EKS_ADD_ON_FLUENTD_PREFIX = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_FLUENTD_"
 
 // IsEnabledAddOnFluentd returns true if "AddOnFluentd" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnFluentd() bool {
 	if cfg.AddOnFluentd == nil {
 		return false
@@ -85,7 +84,6 @@ func (cfg *Config) IsEnabledAddOnFluentd() bool {
 	if cfg.AddOnFluentd.Enable {
 		return true
 	}
-	cfg.AddOnFluentd = nil
 	return false
 }
 
@@ -113,6 +111,7 @@ func (cfg *Config) GetAddOnFluentdRepositoryBusyboxRegion() string {
 
 func (cfg *Config) validateAddOnFluentd() error {
 	if !cfg.IsEnabledAddOnFluentd() {
+		cfg.AddOnFluentd = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
@@ -0,0 +1,165 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnFSxLustre defines parameters for EKS cluster
+// add-on FSx for Lustre, with a file system linked to
+// the artifact S3 bucket and mounted with the FSx CSI driver.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/fsx-csi.html
+// ref. https://github.com/kubernetes-sigs/aws-fsx-csi-driver
+type AddOnFSxLustre struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// Namespace is the namespace to create test objects in.
+	Namespace string `json:"namespace"`
+
+	// ChartRepoURL is the chart repo URL.
+	ChartRepoURL string `json:"chart-repo-url"`
+	// ChartVersion is the chart version.
+	ChartVersion string `json:"chart-version"`
+
+	// DeploymentType is the Lustre deployment type.
+	// Either "SCRATCH_1" or "SCRATCH_2".
+	DeploymentType string `json:"deployment-type"`
+	// StorageCapacityGiB is the file system storage capacity.
+	// Must be 1200, 2400, or increments of 2400.
+	StorageCapacityGiB int64 `json:"storage-capacity-gib"`
+	// S3ImportPrefix is the prefix in the artifact S3 bucket
+	// to link to the file system.
+	S3ImportPrefix string `json:"s3-import-prefix"`
+
+	// BenchmarkImage is the image for the benchmark Pod,
+	// with GNU "dd" for direct I/O.
+	BenchmarkImage string `json:"benchmark-image"`
+	// BenchmarkSizeMiB is the size of the file to write and read.
+	BenchmarkSizeMiB int64 `json:"benchmark-size-mib"`
+
+	// FileSystemID is the FSx file system ID created for the test.
+	FileSystemID string `json:"file-system-id" read-only:"true"`
+	// DNSName is the file system DNS name.
+	DNSName string `json:"dns-name" read-only:"true"`
+	// MountName is the file system Lustre mount name.
+	MountName string `json:"mount-name" read-only:"true"`
+	// SecurityGroupID is the security group for the file system,
+	// allowing Lustre traffic from the VPC.
+	SecurityGroupID string `json:"security-group-id" read-only:"true"`
+}
+
+// FSxLustreStatus is the FSx for Lustre benchmark result.
+type FSxLustreStatus struct {
+	// FileSystemID is the benchmarked file system.
+	FileSystemID string `json:"file-system-id"`
+	// DeploymentType is the benchmarked Lustre deployment type.
+	DeploymentType string `json:"deployment-type"`
+	// StorageCapacityGiB is the benchmarked file system storage capacity.
+	StorageCapacityGiB int64 `json:"storage-capacity-gib"`
+	// BenchmarkSizeMiB is the size of the file written and read.
+	BenchmarkSizeMiB int64 `json:"benchmark-size-mib"`
+	// WriteThroughputMBps is the sequential direct I/O write throughput in MB/s.
+	WriteThroughputMBps float64 `json:"write-throughput-mbps"`
+	// ReadThroughputMBps is the sequential direct I/O read throughput in MB/s.
+	ReadThroughputMBps float64 `json:"read-throughput-mbps"`
+	// TimeFrame is the benchmark time frame.
+	TimeFrame timeutil.TimeFrame `json:"time-frame"`
+}
+
+// EnvironmentVariablePrefixAddOnFSxLustre is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnFSxLustre = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_FSX_LUSTRE_"
+
+// IsEnabledAddOnFSxLustre returns true if "AddOnFSxLustre" is enabled.
+func (cfg *Config) IsEnabledAddOnFSxLustre() bool {
+	if cfg.AddOnFSxLustre == nil {
+		return false
+	}
+	if cfg.AddOnFSxLustre.Enable {
+		return true
+	}
+	return false
+}
+
+const (
+	// DefaultFSxLustreChartRepoURL is the default FSx CSI Driver chart repo URL.
+	DefaultFSxLustreChartRepoURL = "https://kubernetes-sigs.github.io/aws-fsx-csi-driver"
+	// DefaultFSxLustreChartVersion is the default FSx CSI Driver chart version.
+	DefaultFSxLustreChartVersion = "1.7.0"
+	// DefaultFSxLustreBenchmarkImage is the default benchmark image.
+	DefaultFSxLustreBenchmarkImage = "public.ecr.aws/amazonlinux/amazonlinux:2023"
+)
+
+func getDefaultAddOnFSxLustre() *AddOnFSxLustre {
+	return &AddOnFSxLustre{
+		Enable:             false,
+		ChartRepoURL:       DefaultFSxLustreChartRepoURL,
+		ChartVersion:       DefaultFSxLustreChartVersion,
+		DeploymentType:     "SCRATCH_2",
+		StorageCapacityGiB: 1200,
+		BenchmarkImage:     DefaultFSxLustreBenchmarkImage,
+		BenchmarkSizeMiB:   1024,
+	}
+}
+
+func (cfg *Config) validateAddOnFSxLustre() error {
+	if !cfg.IsEnabledAddOnFSxLustre() {
+		cfg.AddOnFSxLustre = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnFSxLustre.Enable true but no node group is enabled")
+	}
+	if cfg.S3.BucketName == "" {
+		return errors.New("AddOnFSxLustre.Enable true but empty S3.BucketName")
+	}
+
+	if cfg.AddOnFSxLustre.Namespace == "" {
+		cfg.AddOnFSxLustre.Namespace = cfg.Name + "-fsx-lustre"
+	}
+	if cfg.AddOnFSxLustre.ChartRepoURL == "" {
+		cfg.AddOnFSxLustre.ChartRepoURL = DefaultFSxLustreChartRepoURL
+	}
+	if cfg.AddOnFSxLustre.ChartVersion == "" {
+		cfg.AddOnFSxLustre.ChartVersion = DefaultFSxLustreChartVersion
+	}
+
+	switch cfg.AddOnFSxLustre.DeploymentType {
+	case "":
+		cfg.AddOnFSxLustre.DeploymentType = "SCRATCH_2"
+	case "SCRATCH_1", "SCRATCH_2":
+	default:
+		return fmt.Errorf("unknown AddOnFSxLustre.DeploymentType %q", cfg.AddOnFSxLustre.DeploymentType)
+	}
+	if cfg.AddOnFSxLustre.StorageCapacityGiB == 0 {
+		cfg.AddOnFSxLustre.StorageCapacityGiB = 1200
+	}
+	// https://docs.aws.amazon.com/fsx/latest/APIReference/API_CreateFileSystem.html
+	if c := cfg.AddOnFSxLustre.StorageCapacityGiB; c != 1200 && (c < 2400 || c%2400 != 0) {
+		return fmt.Errorf("AddOnFSxLustre.StorageCapacityGiB %d invalid (expected 1200, 2400, or increments of 2400)", c)
+	}
+	if cfg.AddOnFSxLustre.S3ImportPrefix == "" {
+		cfg.AddOnFSxLustre.S3ImportPrefix = cfg.Name + "/fsx-lustre"
+	}
+	cfg.AddOnFSxLustre.S3ImportPrefix = strings.Trim(cfg.AddOnFSxLustre.S3ImportPrefix, "/")
+
+	if cfg.AddOnFSxLustre.BenchmarkImage == "" {
+		cfg.AddOnFSxLustre.BenchmarkImage = DefaultFSxLustreBenchmarkImage
+	}
+	if cfg.AddOnFSxLustre.BenchmarkSizeMiB == 0 {
+		cfg.AddOnFSxLustre.BenchmarkSizeMiB = 1024
+	}
+	if cfg.AddOnFSxLustre.BenchmarkSizeMiB < 0 {
+		return fmt.Errorf("AddOnFSxLustre.BenchmarkSizeMiB %d invalid", cfg.AddOnFSxLustre.BenchmarkSizeMiB)
+	}
+
+	return nil
+}
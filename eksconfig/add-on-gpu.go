@@ -0,0 +1,149 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	"github.com/aws/aws-sdk-go/service/eks"
+)
+
+// AddOnGPU defines parameters for EKS cluster
+// add-on GPU node group with NVIDIA device plugin validation.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/eks-optimized-ami.html#gpu-ami
+// ref. https://github.com/NVIDIA/k8s-device-plugin
+type AddOnGPU struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// MNGName is the name of the GPU managed node group.
+	// If not found in "AddOnManagedNodeGroups.MNGs", the node group
+	// is created with "AMIType", "InstanceType", and "ASGDesiredCapacity".
+	MNGName string `json:"mng-name"`
+	// AMIType is the AMI type of the GPU node group.
+	AMIType string `json:"ami-type"`
+	// InstanceType is the GPU instance type of the node group.
+	InstanceType string `json:"instance-type"`
+	// ASGDesiredCapacity is the number of GPU nodes.
+	ASGDesiredCapacity int `json:"asg-desired-capacity"`
+
+	// Namespace is the namespace to create objects in.
+	Namespace string `json:"namespace"`
+	// DevicePluginImage is the NVIDIA device plugin image.
+	DevicePluginImage string `json:"device-plugin-image"`
+	// SmokeTestImage is the CUDA image to run "nvidia-smi" in.
+	SmokeTestImage string `json:"smoke-test-image"`
+	// SmokeTestTimeout is the timeout for the GPU resources
+	// to be allocatable and the smoke test Job to complete.
+	SmokeTestTimeout       time.Duration `json:"smoke-test-timeout"`
+	SmokeTestTimeoutString string        `json:"smoke-test-timeout-string" read-only:"true"`
+
+	// AllocatableGPUs is the total number of allocatable GPUs in the node group.
+	AllocatableGPUs int64 `json:"allocatable-gpus" read-only:"true"`
+	// SmokeTestOutput is the "nvidia-smi" output from the smoke test Job.
+	SmokeTestOutput string `json:"smoke-test-output" read-only:"true"`
+	// TimeFrameSmokeTest is the time taken for the smoke test Job to complete.
+	TimeFrameSmokeTest timeutil.TimeFrame `json:"time-frame-smoke-test" read-only:"true"`
+}
+
+// EnvironmentVariablePrefixAddOnGPU is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnGPU = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_GPU_"
+
+// IsEnabledAddOnGPU returns true if "AddOnGPU" is enabled.
+func (cfg *Config) IsEnabledAddOnGPU() bool {
+	if cfg.AddOnGPU == nil {
+		return false
+	}
+	if cfg.AddOnGPU.Enable {
+		return true
+	}
+	return false
+}
+
+const (
+	// DefaultGPUInstanceType is the default GPU instance type.
+	DefaultGPUInstanceType = "g4dn.xlarge"
+	// DefaultGPUDevicePluginImage is the default NVIDIA device plugin image.
+	DefaultGPUDevicePluginImage = "nvcr.io/nvidia/k8s-device-plugin:v0.14.1"
+	// DefaultGPUSmokeTestImage is the default CUDA image for the smoke test.
+	DefaultGPUSmokeTestImage = "nvcr.io/nvidia/cuda:12.2.0-base-ubuntu22.04"
+)
+
+func getDefaultAddOnGPU() *AddOnGPU {
+	return &AddOnGPU{
+		Enable:             false,
+		AMIType:            eks.AMITypesAl2X8664Gpu,
+		InstanceType:       DefaultGPUInstanceType,
+		ASGDesiredCapacity: 1,
+		DevicePluginImage:  DefaultGPUDevicePluginImage,
+		SmokeTestImage:     DefaultGPUSmokeTestImage,
+		SmokeTestTimeout:   15 * time.Minute,
+	}
+}
+
+// validateAddOnGPU must be run before "validateAddOnManagedNodeGroups",
+// to validate the GPU managed node group created by this add-on.
+func (cfg *Config) validateAddOnGPU() error {
+	if !cfg.IsEnabledAddOnGPU() {
+		cfg.AddOnGPU = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnGPU.Enable true but AddOnManagedNodeGroups.Enable false")
+	}
+
+	if cfg.AddOnGPU.MNGName == "" {
+		cfg.AddOnGPU.MNGName = cfg.Name + "-mng-gpu"
+	}
+	if cfg.AddOnGPU.AMIType == "" {
+		cfg.AddOnGPU.AMIType = eks.AMITypesAl2X8664Gpu
+	}
+	if cfg.AddOnGPU.InstanceType == "" {
+		cfg.AddOnGPU.InstanceType = DefaultGPUInstanceType
+	}
+	if cfg.AddOnGPU.ASGDesiredCapacity == 0 {
+		cfg.AddOnGPU.ASGDesiredCapacity = 1
+	}
+	if cfg.AddOnGPU.ASGDesiredCapacity < 0 {
+		return fmt.Errorf("AddOnGPU.ASGDesiredCapacity %d invalid", cfg.AddOnGPU.ASGDesiredCapacity)
+	}
+
+	cur, ok := cfg.AddOnManagedNodeGroups.MNGs[cfg.AddOnGPU.MNGName]
+	if !ok {
+		if cfg.AddOnManagedNodeGroups.MNGs == nil {
+			cfg.AddOnManagedNodeGroups.MNGs = make(map[string]MNG)
+		}
+		cfg.AddOnManagedNodeGroups.MNGs[cfg.AddOnGPU.MNGName] = MNG{
+			Name:               cfg.AddOnGPU.MNGName,
+			AMIType:            cfg.AddOnGPU.AMIType,
+			InstanceTypes:      []string{cfg.AddOnGPU.InstanceType},
+			ASGMinSize:         cfg.AddOnGPU.ASGDesiredCapacity,
+			ASGMaxSize:         cfg.AddOnGPU.ASGDesiredCapacity,
+			ASGDesiredCapacity: cfg.AddOnGPU.ASGDesiredCapacity,
+		}
+	} else if cur.AMIType != eks.AMITypesAl2X8664Gpu {
+		return fmt.Errorf("AddOnGPU.MNGName %q has non-GPU AMIType %q", cfg.AddOnGPU.MNGName, cur.AMIType)
+	}
+
+	if cfg.AddOnGPU.Namespace == "" {
+		cfg.AddOnGPU.Namespace = cfg.Name + "-gpu"
+	}
+	if cfg.AddOnGPU.DevicePluginImage == "" {
+		cfg.AddOnGPU.DevicePluginImage = DefaultGPUDevicePluginImage
+	}
+	if cfg.AddOnGPU.SmokeTestImage == "" {
+		cfg.AddOnGPU.SmokeTestImage = DefaultGPUSmokeTestImage
+	}
+	if cfg.AddOnGPU.SmokeTestTimeout == time.Duration(0) {
+		cfg.AddOnGPU.SmokeTestTimeout = 15 * time.Minute
+	}
+	cfg.AddOnGPU.SmokeTestTimeoutString = cfg.AddOnGPU.SmokeTestTimeout.String()
+
+	return nil
+}
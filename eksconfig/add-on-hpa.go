@@ -0,0 +1,163 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnHPA defines parameters for EKS cluster
+// add-on Horizontal Pod Autoscaler functional test, which deploys
+// a CPU-bound workload with an HPA, generates load, and asserts the
+// replicas scale up and back down within the timeouts.
+// Requires metrics-server, so enables "AddOnMetricsServer".
+// ref. https://kubernetes.io/docs/tasks/run-application/horizontal-pod-autoscale-walkthrough/
+type AddOnHPA struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// Namespace is the namespace to create objects in.
+	Namespace string `json:"namespace"`
+
+	// Image is the CPU-bound workload image, which burns CPU
+	// on each HTTP request.
+	Image string `json:"image"`
+	// LoadGeneratorImage is the image for the load generator Pods.
+	LoadGeneratorImage string `json:"load-generator-image"`
+	// LoadGenerators is the number of load generator Pods.
+	LoadGenerators int32 `json:"load-generators"`
+
+	// MinReplicas is the HPA minimum number of replicas.
+	MinReplicas int32 `json:"min-replicas"`
+	// MaxReplicas is the HPA maximum number of replicas.
+	MaxReplicas int32 `json:"max-replicas"`
+	// TargetCPUUtilizationPercentage is the HPA target average CPU
+	// utilization, relative to the CPU requests.
+	TargetCPUUtilizationPercentage int32 `json:"target-cpu-utilization-percentage"`
+	// ScaleDownStabilizationWindow is the HPA scale down stabilization
+	// window, shorter than the default 5-minute to speed up the test.
+	ScaleDownStabilizationWindow       time.Duration `json:"scale-down-stabilization-window"`
+	ScaleDownStabilizationWindowString string        `json:"scale-down-stabilization-window-string" read-only:"true"`
+
+	// ScaleUpTimeout is the timeout for the replicas to scale up
+	// after the load starts.
+	ScaleUpTimeout       time.Duration `json:"scale-up-timeout"`
+	ScaleUpTimeoutString string        `json:"scale-up-timeout-string" read-only:"true"`
+	// ScaleDownTimeout is the timeout for the replicas to scale down
+	// to "MinReplicas" after the load stops.
+	ScaleDownTimeout       time.Duration `json:"scale-down-timeout"`
+	ScaleDownTimeoutString string        `json:"scale-down-timeout-string" read-only:"true"`
+
+	// ScaleUpLatency is the time from the load start to the first scale up.
+	ScaleUpLatency       time.Duration `json:"scale-up-latency" read-only:"true"`
+	ScaleUpLatencyString string        `json:"scale-up-latency-string" read-only:"true"`
+	// ScaleDownLatency is the time from the load stop to "MinReplicas".
+	ScaleDownLatency       time.Duration `json:"scale-down-latency" read-only:"true"`
+	ScaleDownLatencyString string        `json:"scale-down-latency-string" read-only:"true"`
+	// MaxObservedReplicas is the maximum number of replicas observed
+	// under load.
+	MaxObservedReplicas int32 `json:"max-observed-replicas" read-only:"true"`
+}
+
+// EnvironmentVariablePrefixAddOnHPA is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnHPA = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_HPA_"
+
+// IsEnabledAddOnHPA returns true if "AddOnHPA" is enabled.
+func (cfg *Config) IsEnabledAddOnHPA() bool {
+	if cfg.AddOnHPA == nil {
+		return false
+	}
+	if cfg.AddOnHPA.Enable {
+		return true
+	}
+	return false
+}
+
+const (
+	// DefaultHPAImage is the HPA walkthrough example image.
+	DefaultHPAImage = "registry.k8s.io/hpa-example"
+	// DefaultHPALoadGeneratorImage is the default load generator image.
+	DefaultHPALoadGeneratorImage = "busybox:1.36"
+	// DefaultHPAScaleDownStabilizationWindow is the default scale down stabilization window.
+	DefaultHPAScaleDownStabilizationWindow = time.Minute
+	// DefaultHPAScaleUpTimeout is the default scale up timeout.
+	DefaultHPAScaleUpTimeout = 5 * time.Minute
+	// DefaultHPAScaleDownTimeout is the default scale down timeout.
+	DefaultHPAScaleDownTimeout = 10 * time.Minute
+)
+
+func getDefaultAddOnHPA() *AddOnHPA {
+	return &AddOnHPA{
+		Enable:                         false,
+		Image:                          DefaultHPAImage,
+		LoadGeneratorImage:             DefaultHPALoadGeneratorImage,
+		LoadGenerators:                 2,
+		MinReplicas:                    1,
+		MaxReplicas:                    5,
+		TargetCPUUtilizationPercentage: 50,
+		ScaleDownStabilizationWindow:   DefaultHPAScaleDownStabilizationWindow,
+		ScaleUpTimeout:                 DefaultHPAScaleUpTimeout,
+		ScaleDownTimeout:               DefaultHPAScaleDownTimeout,
+	}
+}
+
+func (cfg *Config) validateAddOnHPA() error {
+	if !cfg.IsEnabledAddOnHPA() {
+		cfg.AddOnHPA = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnHPA.Enable true but no node group is enabled")
+	}
+	if !cfg.IsEnabledAddOnMetricsServer() {
+		cfg.AddOnMetricsServer = getDefaultAddOnMetricsServer()
+		cfg.AddOnMetricsServer.Enable = true
+	}
+
+	if cfg.AddOnHPA.Namespace == "" {
+		cfg.AddOnHPA.Namespace = cfg.Name + "-hpa"
+	}
+	if cfg.AddOnHPA.Image == "" {
+		cfg.AddOnHPA.Image = DefaultHPAImage
+	}
+	if cfg.AddOnHPA.LoadGeneratorImage == "" {
+		cfg.AddOnHPA.LoadGeneratorImage = DefaultHPALoadGeneratorImage
+	}
+	if cfg.AddOnHPA.LoadGenerators <= 0 {
+		return fmt.Errorf("invalid AddOnHPA.LoadGenerators %d", cfg.AddOnHPA.LoadGenerators)
+	}
+	if cfg.AddOnHPA.MinReplicas <= 0 {
+		return fmt.Errorf("invalid AddOnHPA.MinReplicas %d", cfg.AddOnHPA.MinReplicas)
+	}
+	if cfg.AddOnHPA.MaxReplicas <= cfg.AddOnHPA.MinReplicas {
+		return fmt.Errorf("AddOnHPA.MaxReplicas %d must be greater than AddOnHPA.MinReplicas %d", cfg.AddOnHPA.MaxReplicas, cfg.AddOnHPA.MinReplicas)
+	}
+	if cfg.AddOnHPA.TargetCPUUtilizationPercentage <= 0 || cfg.AddOnHPA.TargetCPUUtilizationPercentage > 100 {
+		return fmt.Errorf("invalid AddOnHPA.TargetCPUUtilizationPercentage %d", cfg.AddOnHPA.TargetCPUUtilizationPercentage)
+	}
+
+	if cfg.AddOnHPA.ScaleDownStabilizationWindow == time.Duration(0) {
+		cfg.AddOnHPA.ScaleDownStabilizationWindow = DefaultHPAScaleDownStabilizationWindow
+	}
+	cfg.AddOnHPA.ScaleDownStabilizationWindowString = cfg.AddOnHPA.ScaleDownStabilizationWindow.String()
+	if cfg.AddOnHPA.ScaleUpTimeout == time.Duration(0) {
+		cfg.AddOnHPA.ScaleUpTimeout = DefaultHPAScaleUpTimeout
+	}
+	cfg.AddOnHPA.ScaleUpTimeoutString = cfg.AddOnHPA.ScaleUpTimeout.String()
+	if cfg.AddOnHPA.ScaleDownTimeout == time.Duration(0) {
+		cfg.AddOnHPA.ScaleDownTimeout = DefaultHPAScaleDownTimeout
+	}
+	if cfg.AddOnHPA.ScaleDownTimeout <= cfg.AddOnHPA.ScaleDownStabilizationWindow {
+		return fmt.Errorf("AddOnHPA.ScaleDownTimeout %v must be greater than AddOnHPA.ScaleDownStabilizationWindow %v", cfg.AddOnHPA.ScaleDownTimeout, cfg.AddOnHPA.ScaleDownStabilizationWindow)
+	}
+	cfg.AddOnHPA.ScaleDownTimeoutString = cfg.AddOnHPA.ScaleDownTimeout.String()
+
+	return nil
+}
@@ -64,7 +64,6 @@ type AddOnIRSAFargate struct {
 const EnvironmentVariablePrefixAddOnIRSAFargate = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_IRSA_FARGATE_"
 
 // IsEnabledAddOnIRSAFargate returns true if "AddOnIRSAFargate" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnIRSAFargate() bool {
 	if cfg.AddOnIRSAFargate == nil {
 		return false
@@ -72,7 +71,6 @@ func (cfg *Config) IsEnabledAddOnIRSAFargate() bool {
 	if cfg.AddOnIRSAFargate.Enable {
 		return true
 	}
-	cfg.AddOnIRSAFargate = nil
 	return false
 }
 
@@ -91,6 +89,7 @@ func (cfg *Config) GetAddOnIRSAFargateRepositoryRegion() string {
 
 func (cfg *Config) validateAddOnIRSAFargate() error {
 	if !cfg.IsEnabledAddOnIRSAFargate() {
+		cfg.AddOnIRSAFargate = nil
 		return nil
 	}
 
@@ -66,7 +66,6 @@ type AddOnIRSA struct {
 const EnvironmentVariablePrefixAddOnIRSA = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_IRSA_"
 
 // IsEnabledAddOnIRSA returns true if "AddOnIRSA" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnIRSA() bool {
 	if cfg.AddOnIRSA == nil {
 		return false
@@ -74,7 +73,6 @@ func (cfg *Config) IsEnabledAddOnIRSA() bool {
 	if cfg.AddOnIRSA.Enable {
 		return true
 	}
-	cfg.AddOnIRSA = nil
 	return false
 }
 
@@ -94,6 +92,7 @@ func (cfg *Config) GetAddOnIRSARepositoryRegion() string {
 
 func (cfg *Config) validateAddOnIRSA() error {
 	if !cfg.IsEnabledAddOnIRSA() {
+		cfg.AddOnIRSA = nil
 		return nil
 	}
 
@@ -49,7 +49,6 @@ type AddOnJobsEcho struct {
 const EnvironmentVariablePrefixAddOnJobsEcho = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_JOBS_ECHO_"
 
 // IsEnabledAddOnJobsEcho returns true if "AddOnJobsEcho" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnJobsEcho() bool {
 	if cfg.AddOnJobsEcho == nil {
 		return false
@@ -57,7 +56,6 @@ func (cfg *Config) IsEnabledAddOnJobsEcho() bool {
 	if cfg.AddOnJobsEcho.Enable {
 		return true
 	}
-	cfg.AddOnJobsEcho = nil
 	return false
 }
 
@@ -84,6 +82,7 @@ func (cfg *Config) GetAddOnJobsEchoRepositoryBusyboxRegion() string {
 
 func (cfg *Config) validateAddOnJobsEcho() error {
 	if !cfg.IsEnabledAddOnJobsEcho() {
+		cfg.AddOnJobsEcho = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
@@ -31,7 +31,6 @@ type AddOnJobsPi struct {
 const EnvironmentVariablePrefixAddOnJobsPi = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_JOBS_PI_"
 
 // IsEnabledAddOnJobsPi returns true if "AddOnJobsPi" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnJobsPi() bool {
 	if cfg.AddOnJobsPi == nil {
 		return false
@@ -39,7 +38,6 @@ func (cfg *Config) IsEnabledAddOnJobsPi() bool {
 	if cfg.AddOnJobsPi.Enable {
 		return true
 	}
-	cfg.AddOnJobsPi = nil
 	return false
 }
 
@@ -53,6 +51,7 @@ func getDefaultAddOnJobsPi() *AddOnJobsPi {
 
 func (cfg *Config) validateAddOnJobsPi() error {
 	if !cfg.IsEnabledAddOnJobsPi() {
+		cfg.AddOnJobsPi = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
@@ -0,0 +1,132 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/metrics"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnJobsThroughput defines parameters for EKS cluster
+// add-on Job and CronJob throughput, which creates large numbers of
+// short-lived Jobs and CronJobs to stress kube-scheduler and
+// kube-controller-manager, and records the Pod startup latency
+// percentiles.
+type AddOnJobsThroughput struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// Namespace is the namespace to create the Jobs and CronJobs in.
+	Namespace string `json:"namespace"`
+	// Image is the Job container image.
+	Image string `json:"image"`
+
+	// Jobs is the number of single-completion Jobs to create.
+	Jobs int `json:"jobs"`
+	// CronJobs is the number of CronJobs to create,
+	// each scheduled every minute.
+	CronJobs int `json:"cron-jobs"`
+	// CronJobsDuration is the duration to keep the CronJobs running.
+	CronJobsDuration       time.Duration `json:"cron-jobs-duration"`
+	CronJobsDurationString string        `json:"cron-jobs-duration-string" read-only:"true"`
+	// QPS is the rate to create the Jobs and CronJobs.
+	QPS float64 `json:"qps"`
+	// Timeout is the timeout for all Job Pods to start.
+	Timeout       time.Duration `json:"timeout"`
+	TimeoutString string        `json:"timeout-string" read-only:"true"`
+
+	// StartedPods is the number of observed started Job Pods.
+	StartedPods int `json:"started-pods" read-only:"true"`
+	// LatencySummaries maps the Pod startup phase
+	// (e.g. "scheduled-to-running") to its latency results.
+	// The latencies are in seconds resolution, from the Pod status timestamps.
+	LatencySummaries map[string]metrics.RequestsSummary `json:"latency-summaries,omitempty" read-only:"true"`
+	// LatencySummariesJSONPath is the path to write "LatencySummaries".
+	LatencySummariesJSONPath string `json:"latency-summaries-json-path" read-only:"true"`
+}
+
+// EnvironmentVariablePrefixAddOnJobsThroughput is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnJobsThroughput = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_JOBS_THROUGHPUT_"
+
+// IsEnabledAddOnJobsThroughput returns true if "AddOnJobsThroughput" is enabled.
+func (cfg *Config) IsEnabledAddOnJobsThroughput() bool {
+	if cfg.AddOnJobsThroughput == nil {
+		return false
+	}
+	if cfg.AddOnJobsThroughput.Enable {
+		return true
+	}
+	return false
+}
+
+// DefaultJobsThroughputImage is the default Job container image.
+const DefaultJobsThroughputImage = "public.ecr.aws/docker/library/busybox:1.36"
+
+func getDefaultAddOnJobsThroughput() *AddOnJobsThroughput {
+	return &AddOnJobsThroughput{
+		Enable:           false,
+		Image:            DefaultJobsThroughputImage,
+		Jobs:             100,
+		CronJobs:         10,
+		CronJobsDuration: 5 * time.Minute,
+		QPS:              10,
+		Timeout:          15 * time.Minute,
+	}
+}
+
+func (cfg *Config) validateAddOnJobsThroughput() error {
+	if !cfg.IsEnabledAddOnJobsThroughput() {
+		cfg.AddOnJobsThroughput = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnJobsThroughput.Enable true but no node group is enabled")
+	}
+
+	if cfg.AddOnJobsThroughput.Namespace == "" {
+		cfg.AddOnJobsThroughput.Namespace = cfg.Name + "-jobs-throughput"
+	}
+	if cfg.AddOnJobsThroughput.Image == "" {
+		cfg.AddOnJobsThroughput.Image = DefaultJobsThroughputImage
+	}
+	if cfg.AddOnJobsThroughput.Jobs < 0 || cfg.AddOnJobsThroughput.CronJobs < 0 {
+		return errors.New("AddOnJobsThroughput.Jobs and AddOnJobsThroughput.CronJobs must not be negative")
+	}
+	if cfg.AddOnJobsThroughput.Jobs+cfg.AddOnJobsThroughput.CronJobs == 0 {
+		return errors.New("AddOnJobsThroughput.Enable true but no Job or CronJob")
+	}
+	// "batch/v1" CronJob
+	if cfg.AddOnJobsThroughput.CronJobs > 0 && cfg.VersionValue < 1.21 {
+		return fmt.Errorf("Version %q not supported for AddOnJobsThroughput.CronJobs", cfg.Version)
+	}
+	if cfg.AddOnJobsThroughput.QPS <= 0 {
+		return fmt.Errorf("invalid AddOnJobsThroughput.QPS %v", cfg.AddOnJobsThroughput.QPS)
+	}
+
+	if cfg.AddOnJobsThroughput.CronJobsDuration == time.Duration(0) {
+		cfg.AddOnJobsThroughput.CronJobsDuration = 5 * time.Minute
+	}
+	// CronJobs are scheduled every minute
+	if cfg.AddOnJobsThroughput.CronJobs > 0 && cfg.AddOnJobsThroughput.CronJobsDuration < 2*time.Minute {
+		return fmt.Errorf("AddOnJobsThroughput.CronJobsDuration %v too short, at least 2m", cfg.AddOnJobsThroughput.CronJobsDuration)
+	}
+	cfg.AddOnJobsThroughput.CronJobsDurationString = cfg.AddOnJobsThroughput.CronJobsDuration.String()
+	if cfg.AddOnJobsThroughput.Timeout == time.Duration(0) {
+		cfg.AddOnJobsThroughput.Timeout = 15 * time.Minute
+	}
+	cfg.AddOnJobsThroughput.TimeoutString = cfg.AddOnJobsThroughput.Timeout.String()
+
+	if cfg.AddOnJobsThroughput.LatencySummariesJSONPath == "" {
+		cfg.AddOnJobsThroughput.LatencySummariesJSONPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + "-jobs-throughput-latency-summaries.json"
+	}
+
+	return nil
+}
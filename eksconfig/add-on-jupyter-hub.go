@@ -42,7 +42,6 @@ type AddOnJupyterHub struct {
 const EnvironmentVariablePrefixAddOnJupyterHub = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_JUPYTER_HUB_"
 
 // IsEnabledAddOnJupyterHub returns true if "AddOnJupyterHub" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnJupyterHub() bool {
 	if cfg.AddOnJupyterHub == nil {
 		return false
@@ -50,7 +49,6 @@ func (cfg *Config) IsEnabledAddOnJupyterHub() bool {
 	if cfg.AddOnJupyterHub.Enable {
 		return true
 	}
-	cfg.AddOnJupyterHub = nil
 	return false
 }
 
@@ -62,6 +60,7 @@ func getDefaultAddOnJupyterHub() *AddOnJupyterHub {
 
 func (cfg *Config) validateAddOnJupyterHub() error {
 	if !cfg.IsEnabledAddOnJupyterHub() {
+		cfg.AddOnJupyterHub = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
@@ -0,0 +1,178 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnKarpenter defines parameters for EKS cluster
+// add-on Karpenter node provisioning.
+// ref. https://karpenter.sh/v0.16.3/getting-started/
+type AddOnKarpenter struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// Namespace is the namespace to install Karpenter and run the scale-out workload in.
+	Namespace string `json:"namespace"`
+
+	// ChartRepoURL is the chart repo URL, or the OCI registry path
+	// (e.g. "oci://public.ecr.aws/karpenter").
+	ChartRepoURL string `json:"chart-repo-url"`
+	// ChartVersion is the chart version.
+	ChartVersion string `json:"chart-version"`
+
+	// ControllerRoleName is the IAM role name for the Karpenter controller
+	// service account (IRSA).
+	ControllerRoleName string `json:"controller-role-name"`
+	// ControllerRoleARN is the IAM role ARN for the Karpenter controller.
+	ControllerRoleARN string `json:"controller-role-arn" read-only:"true"`
+	// NodeRoleName is the IAM role name for the nodes provisioned by Karpenter.
+	NodeRoleName string `json:"node-role-name"`
+	// NodeRoleARN is the IAM role ARN for the nodes provisioned by Karpenter.
+	NodeRoleARN string `json:"node-role-arn" read-only:"true"`
+	// NodeInstanceProfileName is the instance profile name for the nodes provisioned by Karpenter.
+	NodeInstanceProfileName string `json:"node-instance-profile-name"`
+	// OIDCProviderCreated is true when the tester created the cluster
+	// IAM OIDC provider, to be deleted with the add-on.
+	OIDCProviderCreated bool `json:"oidc-provider-created" read-only:"true"`
+
+	// ProvisionerName is the name of the Karpenter Provisioner
+	// and its AWSNodeTemplate.
+	ProvisionerName string `json:"provisioner-name"`
+	// InstanceTypes is the list of instance types the Provisioner may launch.
+	InstanceTypes []string `json:"instance-types"`
+	// CPULimit is the total CPU limit of the Provisioner.
+	CPULimit int `json:"cpu-limit"`
+
+	// DeploymentReplicas is the number of replicas of the scale-out workload,
+	// each requesting "DeploymentCPURequest" to force node provisioning.
+	DeploymentReplicas int32 `json:"deployment-replicas"`
+	// DeploymentCPURequest is the CPU request of each scale-out Pod.
+	DeploymentCPURequest string `json:"deployment-cpu-request"`
+
+	// ScaleOutTimeout is the timeout for the scale-out workload to be ready.
+	ScaleOutTimeout       time.Duration `json:"scale-out-timeout"`
+	ScaleOutTimeoutString string        `json:"scale-out-timeout-string" read-only:"true"`
+	// ConsolidationTimeout is the timeout for Karpenter to remove
+	// the empty nodes after the workload is scaled in.
+	ConsolidationTimeout       time.Duration `json:"consolidation-timeout"`
+	ConsolidationTimeoutString string        `json:"consolidation-timeout-string" read-only:"true"`
+
+	// NodesProvisioned is the list of node names provisioned by Karpenter
+	// for the scale-out workload.
+	NodesProvisioned []string `json:"nodes-provisioned" read-only:"true"`
+
+	// LogsDir is the directory to store the Karpenter controller logs.
+	LogsDir string `json:"logs-dir,omitempty"`
+}
+
+// EnvironmentVariablePrefixAddOnKarpenter is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnKarpenter = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_KARPENTER_"
+
+// IsEnabledAddOnKarpenter returns true if "AddOnKarpenter" is enabled.
+func (cfg *Config) IsEnabledAddOnKarpenter() bool {
+	if cfg.AddOnKarpenter == nil {
+		return false
+	}
+	if cfg.AddOnKarpenter.Enable {
+		return true
+	}
+	return false
+}
+
+func getDefaultAddOnKarpenter() *AddOnKarpenter {
+	return &AddOnKarpenter{
+		Enable:               false,
+		Namespace:            "karpenter",
+		ChartRepoURL:         "https://charts.karpenter.sh",
+		ChartVersion:         "v0.16.3",
+		ProvisionerName:      "aws-k8s-tester",
+		InstanceTypes:        []string{"c5.large", "c5.xlarge", "m5.large", "m5.xlarge"},
+		CPULimit:             100,
+		DeploymentReplicas:   DefaultKarpenterDeploymentReplicas,
+		DeploymentCPURequest: "1",
+		ScaleOutTimeout:      15 * time.Minute,
+		ConsolidationTimeout: 15 * time.Minute,
+	}
+}
+
+const (
+	// DefaultKarpenterDeploymentReplicas is the default number of scale-out Pods.
+	DefaultKarpenterDeploymentReplicas = 10
+	// KarpenterDeploymentReplicasMaxLimit is the maximum number of scale-out Pods.
+	KarpenterDeploymentReplicasMaxLimit = 500
+)
+
+func (cfg *Config) validateAddOnKarpenter() error {
+	if !cfg.IsEnabledAddOnKarpenter() {
+		cfg.AddOnKarpenter = nil
+		return nil
+	}
+	// the controller runs on the existing nodes, not the ones it provisions
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnKarpenter.Enable true but no node group is enabled")
+	}
+	if cfg.VersionValue < 1.19 {
+		return fmt.Errorf("Version %q not supported for AddOnKarpenter", cfg.Version)
+	}
+
+	if cfg.AddOnKarpenter.Namespace == "" {
+		cfg.AddOnKarpenter.Namespace = "karpenter"
+	}
+	if cfg.AddOnKarpenter.ChartRepoURL == "" {
+		return errors.New("unexpected empty AddOnKarpenter.ChartRepoURL")
+	}
+	if cfg.AddOnKarpenter.ControllerRoleName == "" {
+		cfg.AddOnKarpenter.ControllerRoleName = cfg.Name + "-add-on-karpenter-controller-role"
+	}
+	if cfg.AddOnKarpenter.NodeRoleName == "" {
+		cfg.AddOnKarpenter.NodeRoleName = cfg.Name + "-add-on-karpenter-node-role"
+	}
+	if cfg.AddOnKarpenter.NodeInstanceProfileName == "" {
+		cfg.AddOnKarpenter.NodeInstanceProfileName = cfg.Name + "-add-on-karpenter-node-instance-profile"
+	}
+
+	if cfg.AddOnKarpenter.ProvisionerName == "" {
+		cfg.AddOnKarpenter.ProvisionerName = "aws-k8s-tester"
+	}
+	if len(cfg.AddOnKarpenter.InstanceTypes) == 0 {
+		return errors.New("unexpected empty AddOnKarpenter.InstanceTypes")
+	}
+	if cfg.AddOnKarpenter.CPULimit <= 0 {
+		return fmt.Errorf("AddOnKarpenter.CPULimit %d invalid (must be >0)", cfg.AddOnKarpenter.CPULimit)
+	}
+
+	if cfg.AddOnKarpenter.DeploymentReplicas == 0 {
+		cfg.AddOnKarpenter.DeploymentReplicas = DefaultKarpenterDeploymentReplicas
+	}
+	if cfg.AddOnKarpenter.DeploymentReplicas < 0 || cfg.AddOnKarpenter.DeploymentReplicas > KarpenterDeploymentReplicasMaxLimit {
+		return fmt.Errorf("AddOnKarpenter.DeploymentReplicas %d invalid (must be 1 to %d)", cfg.AddOnKarpenter.DeploymentReplicas, KarpenterDeploymentReplicasMaxLimit)
+	}
+	if cfg.AddOnKarpenter.DeploymentCPURequest == "" {
+		cfg.AddOnKarpenter.DeploymentCPURequest = "1"
+	}
+
+	if cfg.AddOnKarpenter.ScaleOutTimeout == time.Duration(0) {
+		cfg.AddOnKarpenter.ScaleOutTimeout = 15 * time.Minute
+	}
+	cfg.AddOnKarpenter.ScaleOutTimeoutString = cfg.AddOnKarpenter.ScaleOutTimeout.String()
+	if cfg.AddOnKarpenter.ConsolidationTimeout == time.Duration(0) {
+		cfg.AddOnKarpenter.ConsolidationTimeout = 15 * time.Minute
+	}
+	cfg.AddOnKarpenter.ConsolidationTimeoutString = cfg.AddOnKarpenter.ConsolidationTimeout.String()
+
+	if cfg.AddOnKarpenter.LogsDir == "" {
+		cfg.AddOnKarpenter.LogsDir = filepath.Join(filepath.Dir(cfg.ConfigPath), cfg.Name+"-logs-karpenter")
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnKubeProxyModes defines parameters for EKS cluster
+// add-on kube-proxy mode matrix tests, which switch the kube-proxy mode
+// in the "kube-proxy-config" ConfigMap and restart the "kube-proxy"
+// DaemonSet for each mode in order, run the Service connectivity and
+// the load balancing distribution checks, and report the differences
+// between the modes. The original mode is restored after the matrix.
+// ref. https://kubernetes.io/docs/reference/networking/virtual-ips/
+type AddOnKubeProxyModes struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// Namespace is the namespace of the check Pods and Service.
+	Namespace string `json:"namespace"`
+	// Modes is the list of the kube-proxy modes to test in order
+	// ("iptables" or "ipvs").
+	Modes []string `json:"modes"`
+	// IPVSScheduler is the IPVS scheduler in "ipvs" mode
+	// (e.g. "rr", "lc", "sh"). Leave empty for the kube-proxy default "rr".
+	IPVSScheduler string `json:"ipvs-scheduler"`
+
+	// Backends is the number of the Service backend Pods.
+	Backends int `json:"backends"`
+	// Requests is the number of the requests to the Service per mode,
+	// each on a new connection.
+	Requests int `json:"requests"`
+	// MaxDeviation is the maximum deviation of the requests per backend
+	// from the even distribution (e.g. 0.5 for 50%).
+	MaxDeviation float64 `json:"max-deviation"`
+	// RolloutTimeout is the timeout of the "kube-proxy" rollout per mode.
+	RolloutTimeout       time.Duration `json:"rollout-timeout"`
+	RolloutTimeoutString string        `json:"rollout-timeout-string" read-only:"true"`
+
+	// OriginalMode is the kube-proxy mode before the matrix,
+	// restored after the matrix.
+	OriginalMode string `json:"original-mode" read-only:"true"`
+	// Results is the list of the per-mode results.
+	Results []KubeProxyModeResult `json:"results" read-only:"true"`
+	// Differences is the list of the differences between the modes.
+	Differences []string `json:"differences" read-only:"true"`
+	// ResultsJSONPath is the path to write "Results".
+	ResultsJSONPath string `json:"results-json-path" read-only:"true"`
+}
+
+// KubeProxyModeResult is the result of the checks with a kube-proxy mode.
+type KubeProxyModeResult struct {
+	Mode   string `json:"mode"`
+	Passed bool   `json:"passed"`
+
+	RolloutTime       time.Duration `json:"rollout-time"`
+	RolloutTimeString string        `json:"rollout-time-string"`
+	// Requests is the number of the requests to the Service.
+	Requests int `json:"requests"`
+	// Failures is the number of the failed requests.
+	Failures int `json:"failures"`
+	// BackendHits maps the backend Pod name to its number of requests.
+	BackendHits map[string]int `json:"backend-hits"`
+	// MaxDeviation is the maximum deviation of the requests per backend
+	// from the even distribution.
+	MaxDeviation float64 `json:"max-deviation"`
+
+	// Error is the first failed check, empty if passed.
+	Error string `json:"error"`
+}
+
+// EnvironmentVariablePrefixAddOnKubeProxyModes is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnKubeProxyModes = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_KUBE_PROXY_MODES_"
+
+// IsEnabledAddOnKubeProxyModes returns true if "AddOnKubeProxyModes" is enabled.
+func (cfg *Config) IsEnabledAddOnKubeProxyModes() bool {
+	if cfg.AddOnKubeProxyModes == nil {
+		return false
+	}
+	if cfg.AddOnKubeProxyModes.Enable {
+		return true
+	}
+	return false
+}
+
+// kube-proxy modes.
+const (
+	KubeProxyModeIPTables = "iptables"
+	KubeProxyModeIPVS     = "ipvs"
+)
+
+const (
+	// DefaultKubeProxyModesBackends is the default number of the Service backend Pods.
+	DefaultKubeProxyModesBackends = 5
+	// DefaultKubeProxyModesRequests is the default number of the requests per mode.
+	DefaultKubeProxyModesRequests = 500
+	// DefaultKubeProxyModesMaxDeviation is the default maximum deviation from the even distribution.
+	DefaultKubeProxyModesMaxDeviation = 0.5
+	// DefaultKubeProxyModesRolloutTimeout is the default "kube-proxy" rollout timeout.
+	DefaultKubeProxyModesRolloutTimeout = 10 * time.Minute
+)
+
+func getDefaultAddOnKubeProxyModes() *AddOnKubeProxyModes {
+	return &AddOnKubeProxyModes{
+		Enable:         false,
+		Modes:          []string{KubeProxyModeIPTables, KubeProxyModeIPVS},
+		Backends:       DefaultKubeProxyModesBackends,
+		Requests:       DefaultKubeProxyModesRequests,
+		MaxDeviation:   DefaultKubeProxyModesMaxDeviation,
+		RolloutTimeout: DefaultKubeProxyModesRolloutTimeout,
+	}
+}
+
+func (cfg *Config) validateAddOnKubeProxyModes() error {
+	if !cfg.IsEnabledAddOnKubeProxyModes() {
+		cfg.AddOnKubeProxyModes = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnKubeProxyModes.Enable true but no node group is enabled")
+	}
+
+	cur := cfg.AddOnKubeProxyModes
+	if cur.Namespace == "" {
+		cur.Namespace = cfg.Name + "-kube-proxy-modes"
+	}
+	if len(cur.Modes) == 0 {
+		cur.Modes = []string{KubeProxyModeIPTables, KubeProxyModeIPVS}
+	}
+	for _, mode := range cur.Modes {
+		switch mode {
+		case KubeProxyModeIPTables, KubeProxyModeIPVS:
+		default:
+			return fmt.Errorf("unknown AddOnKubeProxyModes.Modes %q", mode)
+		}
+	}
+	if strings.ContainsAny(cur.IPVSScheduler, " \"'") {
+		return fmt.Errorf("AddOnKubeProxyModes.IPVSScheduler %q invalid", cur.IPVSScheduler)
+	}
+	if cur.Backends <= 0 {
+		cur.Backends = DefaultKubeProxyModesBackends
+	}
+	if cur.Requests <= 0 {
+		cur.Requests = DefaultKubeProxyModesRequests
+	}
+	if cur.Requests < cur.Backends {
+		return fmt.Errorf("AddOnKubeProxyModes.Requests %d < AddOnKubeProxyModes.Backends %d", cur.Requests, cur.Backends)
+	}
+	if cur.MaxDeviation <= 0 {
+		cur.MaxDeviation = DefaultKubeProxyModesMaxDeviation
+	}
+	if cur.RolloutTimeout == time.Duration(0) {
+		cur.RolloutTimeout = DefaultKubeProxyModesRolloutTimeout
+	}
+	cur.RolloutTimeoutString = cur.RolloutTimeout.String()
+
+	if cur.ResultsJSONPath == "" {
+		cur.ResultsJSONPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + "-kube-proxy-modes-results.json"
+		os.RemoveAll(cur.ResultsJSONPath)
+	}
+
+	return nil
+}
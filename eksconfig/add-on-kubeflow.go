@@ -40,7 +40,6 @@ type AddOnKubeflow struct {
 const EnvironmentVariablePrefixAddOnKubeflow = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_KUBEFLOW_"
 
 // IsEnabledAddOnKubeflow returns true if "AddOnKubeflow" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnKubeflow() bool {
 	if cfg.AddOnKubeflow == nil {
 		return false
@@ -48,7 +47,6 @@ func (cfg *Config) IsEnabledAddOnKubeflow() bool {
 	if cfg.AddOnKubeflow.Enable {
 		return true
 	}
-	cfg.AddOnKubeflow = nil
 	return false
 }
 
@@ -66,6 +64,7 @@ func getDefaultAddOnKubeflow() *AddOnKubeflow {
 
 func (cfg *Config) validateAddOnKubeflow() error {
 	if !cfg.IsEnabledAddOnKubeflow() {
+		cfg.AddOnKubeflow = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
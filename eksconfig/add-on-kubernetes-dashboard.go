@@ -31,7 +31,6 @@ type AddOnKubernetesDashboard struct {
 const EnvironmentVariablePrefixAddOnKubernetesDashboard = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_KUBERNETES_DASHBOARD_"
 
 // IsEnabledAddOnKubernetesDashboard returns true if "AddOnKubernetesDashboard" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnKubernetesDashboard() bool {
 	if cfg.AddOnKubernetesDashboard == nil {
 		return false
@@ -39,7 +38,6 @@ func (cfg *Config) IsEnabledAddOnKubernetesDashboard() bool {
 	if cfg.AddOnKubernetesDashboard.Enable {
 		return true
 	}
-	cfg.AddOnKubernetesDashboard = nil
 	return false
 }
 
@@ -69,6 +67,7 @@ const defaultKubernetesDashboardURL = "http://localhost:8001/api/v1/namespaces/k
 
 func (cfg *Config) validateAddOnKubernetesDashboard() error {
 	if !cfg.IsEnabledAddOnKubernetesDashboard() {
+		cfg.AddOnKubernetesDashboard = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
@@ -0,0 +1,106 @@
+package eksconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnManagedAddOns defines parameters for EKS cluster
+// add-on EKS managed add-ons, installed, updated, and
+// removed with the EKS Addons API.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/eks-add-ons.html
+type AddOnManagedAddOns struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// AddOns maps each EKS managed add-on name (e.g. "vpc-cni")
+	// to its parameters.
+	AddOns map[string]ManagedAddOn `json:"add-ons"`
+}
+
+// ManagedAddOn defines an EKS managed add-on.
+type ManagedAddOn struct {
+	// Version is the add-on version to install.
+	// If empty, install the default version for the cluster version.
+	Version string `json:"version"`
+	// UpdateVersion is the add-on version to update to after install.
+	// If empty, skip the update. Set "latest" to update to the latest
+	// version compatible with the cluster version.
+	UpdateVersion string `json:"update-version"`
+	// ConfigurationValues is the add-on configuration in JSON.
+	// ref. https://docs.aws.amazon.com/eks/latest/APIReference/API_CreateAddon.html
+	ConfigurationValues string `json:"configuration-values"`
+	// Preserve is true to keep the add-on software on the cluster
+	// when the managed add-on is removed. Must be true for add-ons
+	// the cluster cannot run without (e.g. "vpc-cni"), since other
+	// testers run their deletes afterwards.
+	Preserve bool `json:"preserve"`
+
+	// InstalledVersion is the add-on version last observed as active.
+	InstalledVersion string `json:"installed-version" read-only:"true"`
+	// DefaultVersion is the default add-on version for the cluster version.
+	DefaultVersion string `json:"default-version" read-only:"true"`
+	// LatestVersion is the latest add-on version compatible with the cluster version.
+	LatestVersion string `json:"latest-version" read-only:"true"`
+	// Compatible is true if the installed version supports the cluster version.
+	Compatible bool `json:"compatible" read-only:"true"`
+}
+
+// EnvironmentVariablePrefixAddOnManagedAddOns is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnManagedAddOns = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_MANAGED_ADD_ONS_"
+
+// IsEnabledAddOnManagedAddOns returns true if "AddOnManagedAddOns" is enabled.
+func (cfg *Config) IsEnabledAddOnManagedAddOns() bool {
+	if cfg.AddOnManagedAddOns == nil {
+		return false
+	}
+	if cfg.AddOnManagedAddOns.Enable {
+		return true
+	}
+	return false
+}
+
+// ManagedAddOnLatestVersion is the "UpdateVersion" to update to
+// the latest compatible add-on version.
+const ManagedAddOnLatestVersion = "latest"
+
+func getDefaultAddOnManagedAddOns() *AddOnManagedAddOns {
+	return &AddOnManagedAddOns{
+		Enable: false,
+		AddOns: map[string]ManagedAddOn{
+			"vpc-cni":    {Preserve: true},
+			"coredns":    {Preserve: true},
+			"kube-proxy": {Preserve: true},
+		},
+	}
+}
+
+func (cfg *Config) validateAddOnManagedAddOns() error {
+	if !cfg.IsEnabledAddOnManagedAddOns() {
+		cfg.AddOnManagedAddOns = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnManagedAddOns.Enable true but no node group is enabled")
+	}
+	if len(cfg.AddOnManagedAddOns.AddOns) == 0 {
+		return errors.New("AddOnManagedAddOns.Enable true but empty AddOns")
+	}
+	for name, cur := range cfg.AddOnManagedAddOns.AddOns {
+		if cur.ConfigurationValues != "" && !json.Valid([]byte(cur.ConfigurationValues)) {
+			return fmt.Errorf("AddOnManagedAddOns.AddOns[%q].ConfigurationValues is not valid JSON (%q)", name, cur.ConfigurationValues)
+		}
+		if cur.UpdateVersion != "" && cur.UpdateVersion == cur.Version {
+			return fmt.Errorf("AddOnManagedAddOns.AddOns[%q].UpdateVersion %q same as Version", name, cur.UpdateVersion)
+		}
+	}
+	return nil
+}
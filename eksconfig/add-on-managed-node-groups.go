@@ -11,6 +11,7 @@ import (
 	"github.com/aws/aws-k8s-tester/ec2config"
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
 	"github.com/aws/aws-sdk-go/service/eks"
+	k8s_validation "k8s.io/apimachinery/pkg/util/validation"
 )
 
 // AddOnManagedNodeGroups defines parameters for EKS "Managed Node Group" creation.
@@ -27,6 +28,14 @@ type AddOnManagedNodeGroups struct {
 
 	// FetchLogs is true to fetch logs from remote nodes using SSH.
 	FetchLogs bool `json:"fetch-logs"`
+	// FetchLogsFanOut is the number of commands to run concurrently
+	// per node when fetching logs, multiplexed over one SSH connection.
+	// Must not exceed the sshd "MaxSessions" (10 by default).
+	FetchLogsFanOut int `json:"fetch-logs-fan-out"`
+	// DiagnoseOnFailure is true to collect bootstrap logs from the failed
+	// instances (using SSH or EC2 console output) when nodes fail to join,
+	// and to attach the failure classification to the returned error.
+	DiagnoseOnFailure bool `json:"diagnose-on-failure"`
 
 	Role *Role `json:"role"`
 
@@ -68,6 +77,13 @@ type MNG struct {
 	RemoteAccessUserName string `json:"remote-access-user-name,omitempty"`
 	// Tags defines EKS managed node group create tags.
 	Tags map[string]string `json:"tags,omitempty"`
+	// Labels is the additional Kubernetes labels to apply to the nodes.
+	// "NodeType", "AMIType", "NGType", and "NGName" are reserved.
+	// ref. https://docs.aws.amazon.com/eks/latest/APIReference/API_CreateNodegroup.html#AmazonEKS-CreateNodegroup-request-labels
+	Labels map[string]string `json:"labels,omitempty"`
+	// Taints is the Kubernetes taints to apply to the nodes.
+	// ref. https://docs.aws.amazon.com/eks/latest/userguide/node-taints-managed-node-groups.html
+	Taints []MNGTaint `json:"taints,omitempty"`
 	// ReleaseVersion is the AMI version of the Amazon EKS-optimized AMI for the node group.
 	// The version may differ from EKS "cluster" version.
 	// e.g. "1.16.8-20200609"
@@ -78,15 +94,26 @@ type MNG struct {
 	ReleaseVersionValue float64 `json:"release-version-value" read-only:"true"`
 
 	// AMIType is the AMI type for the node group.
-	// Allowed values are AL2_x86_64, AL2_x86_64_GPU and AL2_ARM_64.
+	// Allowed values are AL2_x86_64, AL2_x86_64_GPU, AL2_ARM_64,
+	// AL2023_x86_64_STANDARD, AL2023_ARM_64_STANDARD, BOTTLEROCKET_x86_64,
+	// BOTTLEROCKET_ARM_64, and the Windows AMI types.
 	// ref. https://docs.aws.amazon.com/eks/latest/userguide/create-managed-node-group.html
 	// ref. https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/aws-resource-eks-nodegroup.html#cfn-eks-nodegroup-amitype
 	AMIType string `json:"ami-type,omitempty"`
+	// AMIFamily is the AMI family, which determines the remote access user name,
+	// log collection, and default instance types. Derived from "AMIType" if empty.
+	AMIFamily string `json:"ami-family,omitempty"`
 
 	// InstanceTypes is the EC2 instance types for the node instances.
 	// ref. https://docs.aws.amazon.com/eks/latest/userguide/create-managed-node-group.html
 	// ref. https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/aws-resource-eks-nodegroup.html
 	InstanceTypes []string `json:"instance-types,omitempty"`
+	// CapacityType is the capacity type of the node instances,
+	// either "ON_DEMAND" or "SPOT". Defaults to "ON_DEMAND".
+	// Spot node groups use the capacity optimized allocation strategy
+	// and capacity rebalancing, with the nodes drained on interruption.
+	// ref. https://docs.aws.amazon.com/eks/latest/userguide/managed-node-groups.html#managed-node-group-capacity-types
+	CapacityType string `json:"capacity-type,omitempty"`
 	// VolumeSize is the node volume size.
 	// ref. https://docs.aws.amazon.com/eks/latest/userguide/create-managed-node-group.html
 	// ref. https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/aws-resource-eks-nodegroup.html
@@ -127,8 +154,86 @@ type MNG struct {
 
 	// VersionUpgrade configures MNG version upgarde.
 	VersionUpgrade *MNGVersionUpgrade `json:"version-upgrade,omitempty"`
+
+	// LaunchTemplate configures the custom launch template for the MNG.
+	LaunchTemplate *MNGLaunchTemplate `json:"launch-template,omitempty"`
+}
+
+// MNGTaint defines the Kubernetes taint of the managed node group nodes.
+type MNGTaint struct {
+	// Key is the taint key.
+	Key string `json:"key"`
+	// Value is the taint value.
+	Value string `json:"value,omitempty"`
+	// Effect is the Kubernetes taint effect,
+	// either "NoSchedule", "PreferNoSchedule", or "NoExecute".
+	Effect string `json:"effect"`
+}
+
+// mngTaintEffects maps the Kubernetes taint effect to the EKS API enum.
+var mngTaintEffects = map[string]string{
+	"NoSchedule":       eks.TaintEffectNoSchedule,
+	"PreferNoSchedule": eks.TaintEffectPreferNoSchedule,
+	"NoExecute":        eks.TaintEffectNoExecute,
+}
+
+// EKSEffect returns the EKS API taint effect (e.g. "NO_SCHEDULE").
+func (t MNGTaint) EKSEffect() string {
+	return mngTaintEffects[t.Effect]
 }
 
+// MNGLaunchTemplate defines the custom launch template
+// passed to the managed node group creation request.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/launch-templates.html
+type MNGLaunchTemplate struct {
+	// Enable is 'true' to create the launch template.
+	Enable bool `json:"enable"`
+	// Name is the launch template name.
+	Name string `json:"name"`
+	// ID is the launch template ID.
+	ID string `json:"id" read-only:"true"`
+	// Version is the launch template version.
+	Version string `json:"version" read-only:"true"`
+	// SecurityGroupID is the security group ID for the launch template.
+	// EKS does not create the remote access security group with a launch template,
+	// so this is used as the MNG "RemoteAccessSecurityGroupID".
+	SecurityGroupID string `json:"security-group-id" read-only:"true"`
+
+	// ImageID is the custom AMI ID. If non-empty, the node bootstrap is
+	// added to the user data, and "AMIType" is only used to determine the
+	// AMI family. Only supported for the AL2 and AL2023 AMI families.
+	ImageID string `json:"image-id"`
+	// UserData is the shell script to run before the node bootstrap.
+	UserData string `json:"user-data"`
+	// KubeletExtraArgs is the additional kubelet flags.
+	// Requires "ImageID" for the AL2 AMI family.
+	// e.g. "--max-pods=110 --kube-reserved=cpu=250m"
+	KubeletExtraArgs string `json:"kubelet-extra-args"`
+
+	// MetadataHTTPTokens is the IMDS token state, either "required" (IMDSv2) or "optional".
+	MetadataHTTPTokens string `json:"metadata-http-tokens"`
+	// MetadataHTTPPutResponseHopLimit is the IMDS PUT response hop limit.
+	// Must be at least 2 for Pods without host networking to reach IMDSv2.
+	MetadataHTTPPutResponseHopLimit int32 `json:"metadata-http-put-response-hop-limit"`
+
+	// VolumeType is the EBS volume type of the root volume.
+	// The volume size is set from the MNG "VolumeSize".
+	VolumeType string `json:"volume-type"`
+	// VolumeIOPS is the EBS volume IOPS, for "gp3", "io1", and "io2" volumes.
+	VolumeIOPS int32 `json:"volume-iops"`
+	// VolumeThroughput is the EBS volume throughput in MiB/s, for "gp3" volumes.
+	VolumeThroughput int32 `json:"volume-throughput"`
+}
+
+const (
+	// DefaultMNGLaunchTemplateMetadataHTTPTokens is the default IMDS token state.
+	DefaultMNGLaunchTemplateMetadataHTTPTokens = "required"
+	// DefaultMNGLaunchTemplateMetadataHTTPPutResponseHopLimit is the default IMDS hop limit.
+	DefaultMNGLaunchTemplateMetadataHTTPPutResponseHopLimit = 2
+	// DefaultMNGLaunchTemplateVolumeType is the default EBS volume type.
+	DefaultMNGLaunchTemplateVolumeType = "gp3"
+)
+
 // MNGScaleUpdate contains the minimum, maximum, and desired node counts for a nodegroup.
 // ref, https://docs.aws.amazon.com/cli/latest/reference/eks/update-nodegroup-config.html
 type MNGScaleUpdate struct {
@@ -173,8 +278,43 @@ type MNGVersionUpgrade struct {
 	// that has created "1.16" MNG by default.
 	Version      string  `json:"version"`
 	VersionValue float64 `json:"version-value" read-only:"true"`
+	// ReleaseVersion is the target AMI release version of EKS managed node group.
+	// Either "Version" or "ReleaseVersion" must be set.
+	// The value is passed via "aws eks update-nodegroup-version --release-version".
+	// e.g. "1.27.1-20230703" to roll the nodes to a newer AMI of the same version.
+	ReleaseVersion string `json:"release-version"`
+
+	// MaxUnavailable is the maximum number of nodes unavailable at once during the update.
+	// Leave zero with "MaxUnavailablePercentage" to keep the current update config.
+	// ref. https://docs.aws.amazon.com/eks/latest/APIReference/API_NodegroupUpdateConfig.html
+	MaxUnavailable int64 `json:"max-unavailable"`
+	// MaxUnavailablePercentage is the maximum percentage of nodes unavailable at once during the update.
+	MaxUnavailablePercentage int64 `json:"max-unavailable-percentage"`
+
+	// WorkloadReplicas is the number of replicas of the workload running
+	// on the node group, which must stay available during the update.
+	WorkloadReplicas int32 `json:"workload-replicas"`
+	// WorkloadMinAvailable is the minimum number of available workload replicas,
+	// enforced by a PodDisruptionBudget and verified throughout the update.
+	WorkloadMinAvailable int32 `json:"workload-min-available"`
+	// WorkloadMinAvailableObserved is the lowest number of available
+	// workload replicas observed during the update.
+	WorkloadMinAvailableObserved int32 `json:"workload-min-available-observed" read-only:"true"`
+
+	// TimeFrameUpdateConfig is the time taken to apply the update config.
+	TimeFrameUpdateConfig timeutil.TimeFrame `json:"time-frame-update-config" read-only:"true"`
+	// TimeFrameUpdate is the time taken for the version update to succeed.
+	TimeFrameUpdate timeutil.TimeFrame `json:"time-frame-update" read-only:"true"`
+	// TimeFrameActive is the time taken for the node group to be active after the update.
+	TimeFrameActive timeutil.TimeFrame `json:"time-frame-active" read-only:"true"`
+	// TimeFrameNodesReady is the time taken for the updated nodes to be ready.
+	TimeFrameNodesReady timeutil.TimeFrame `json:"time-frame-nodes-ready" read-only:"true"`
 }
 
+// DefaultMNGVersionUpgradeWorkloadReplicas is the default number of
+// workload replicas to keep available during the node group update.
+const DefaultMNGVersionUpgradeWorkloadReplicas = 2
+
 const (
 	// AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_PREFIX is the environment variable prefix used for "eksconfig".
 	AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_PREFIX      = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_MANAGED_NODE_GROUPS_"
@@ -182,7 +322,6 @@ const (
 )
 
 // IsEnabledAddOnManagedNodeGroups returns true if "AddOnManagedNodeGroups" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnManagedNodeGroups() bool {
 	if cfg.AddOnManagedNodeGroups == nil {
 		return false
@@ -190,23 +329,25 @@ func (cfg *Config) IsEnabledAddOnManagedNodeGroups() bool {
 	if cfg.AddOnManagedNodeGroups.Enable {
 		return len(cfg.AddOnManagedNodeGroups.MNGs) > 0
 	}
-	cfg.AddOnManagedNodeGroups = nil
 	return false
 }
 
 func getDefaultAddOnManagedNodeGroups(name string) *AddOnManagedNodeGroups {
 	return &AddOnManagedNodeGroups{
-		Enable:      false,
-		FetchLogs:   false,
-		SigningName: "eks",
-		Role:        getDefaultRole(),
-		LogsDir:     "", // to be auto-generated
+		Enable:            false,
+		FetchLogs:         false,
+		FetchLogsFanOut:   DefaultFetchLogsFanOut,
+		DiagnoseOnFailure: true,
+		SigningName:       "eks",
+		Role:              getDefaultRole(),
+		LogsDir:           "", // to be auto-generated
 		MNGs: map[string]MNG{
 			name + "-mng-cpu": {
 				Name:                 name + "-mng-cpu",
 				RemoteAccessUserName: "ec2-user", // assume Amazon Linux 2
 				ReleaseVersion:       "",         // to be auto-filled by EKS API
 				AMIType:              eks.AMITypesAl2X8664,
+				AMIFamily:            ec2config.AMIFamilyAL2,
 				InstanceTypes:        []string{DefaultNodeInstanceTypeCPU},
 				VolumeSize:           DefaultNodeVolumeSize,
 				ASGMinSize:           1,
@@ -218,8 +359,40 @@ func getDefaultAddOnManagedNodeGroups(name string) *AddOnManagedNodeGroups {
 	}
 }
 
+// Windows AMI types for EKS "Managed Node Group".
+// ref. https://docs.aws.amazon.com/eks/latest/APIReference/API_Nodegroup.html
+const (
+	MNGAMITypeWindowsCore2019X8664 = "WINDOWS_CORE_2019_x86_64"
+	MNGAMITypeWindowsFull2019X8664 = "WINDOWS_FULL_2019_x86_64"
+	MNGAMITypeWindowsCore2022X8664 = "WINDOWS_CORE_2022_x86_64"
+	MNGAMITypeWindowsFull2022X8664 = "WINDOWS_FULL_2022_x86_64"
+)
+
+// DefaultWindowsRemoteAccessUserName is the SSH user name for Windows nodes.
+// The node must run the OpenSSH server.
+var DefaultWindowsRemoteAccessUserName = ec2config.DefaultRemoteAccessUserName(ec2config.AMIFamilyWindows)
+
+// IsWindowsAMIType returns true if the AMI type is Windows,
+// for both managed and self-managed node groups.
+func IsWindowsAMIType(amiType string) bool {
+	return ec2config.AMIFamilyFromAMIType(amiType) == ec2config.AMIFamilyWindows
+}
+
+// defaultInstanceType returns the default instance type
+// for the AMI family and the architecture of the AMI type.
+func defaultInstanceType(amiFamily string, amiType string) string {
+	switch ec2config.DefaultInstanceType(amiFamily, amiType) {
+	case ec2config.DefaultNodeInstanceTypeCPUARM:
+		return DefaultNodeInstanceTypeARMCPU
+	case ec2config.DefaultNodeInstanceTypeGPU:
+		return DefaultNodeInstanceTypeGPU
+	}
+	return DefaultNodeInstanceTypeCPU
+}
+
 func (cfg *Config) validateAddOnManagedNodeGroups() error {
 	if !cfg.IsEnabledAddOnManagedNodeGroups() {
+		cfg.AddOnManagedNodeGroups = nil
 		return nil
 	}
 
@@ -244,6 +417,12 @@ func (cfg *Config) validateAddOnManagedNodeGroups() error {
 	if !strings.HasSuffix(cfg.AddOnManagedNodeGroups.LogsTarGzPath, ".tar.gz") {
 		return fmt.Errorf("AddOnManagedNodeGroups.LogsTarGzPath %q must end with .tar.gz", cfg.AddOnManagedNodeGroups.LogsTarGzPath)
 	}
+	if cfg.AddOnManagedNodeGroups.FetchLogsFanOut == 0 {
+		cfg.AddOnManagedNodeGroups.FetchLogsFanOut = DefaultFetchLogsFanOut
+	}
+	if cfg.AddOnManagedNodeGroups.FetchLogsFanOut < 1 || cfg.AddOnManagedNodeGroups.FetchLogsFanOut > FetchLogsFanOutMaxLimit {
+		return fmt.Errorf("AddOnManagedNodeGroups.FetchLogsFanOut %d must be between 1 and %d", cfg.AddOnManagedNodeGroups.FetchLogsFanOut, FetchLogsFanOutMaxLimit)
+	}
 
 	switch cfg.AddOnManagedNodeGroups.Role.Create {
 	case true: // need create one, or already created
@@ -285,6 +464,9 @@ func (cfg *Config) validateAddOnManagedNodeGroups() error {
 	if cfg.AddOnManagedNodeGroups.Role.PolicyName == "" {
 		cfg.AddOnManagedNodeGroups.Role.PolicyName = cfg.Name + "-managed-node-group-policy"
 	}
+	if err := cfg.AddOnManagedNodeGroups.Role.validatePolicy(cfg, "AddOnManagedNodeGroups.Role", ".managed-node-group-role-policy.json"); err != nil {
+		return err
+	}
 
 	names, processed := make(map[string]struct{}), make(map[string]MNG)
 	for k, cur := range cfg.AddOnManagedNodeGroups.MNGs {
@@ -354,15 +536,39 @@ func (cfg *Config) validateAddOnManagedNodeGroups() error {
 				}
 			}
 
+			if cur.VersionUpgrade.MaxUnavailable < 0 {
+				return fmt.Errorf("AddOnManagedNodeGroups.MNGs[%q] invalid VersionUpgrade.MaxUnavailable %d", cur.Name, cur.VersionUpgrade.MaxUnavailable)
+			}
+			if cur.VersionUpgrade.MaxUnavailablePercentage < 0 || cur.VersionUpgrade.MaxUnavailablePercentage > 100 {
+				return fmt.Errorf("AddOnManagedNodeGroups.MNGs[%q] invalid VersionUpgrade.MaxUnavailablePercentage %d (must be 0 to 100)", cur.Name, cur.VersionUpgrade.MaxUnavailablePercentage)
+			}
+			if cur.VersionUpgrade.MaxUnavailable > 0 && cur.VersionUpgrade.MaxUnavailablePercentage > 0 {
+				return fmt.Errorf("AddOnManagedNodeGroups.MNGs[%q] cannot set both VersionUpgrade.MaxUnavailable and VersionUpgrade.MaxUnavailablePercentage", cur.Name)
+			}
+			if cur.VersionUpgrade.WorkloadReplicas == 0 {
+				cur.VersionUpgrade.WorkloadReplicas = DefaultMNGVersionUpgradeWorkloadReplicas
+			}
+			if cur.VersionUpgrade.WorkloadMinAvailable == 0 {
+				cur.VersionUpgrade.WorkloadMinAvailable = cur.VersionUpgrade.WorkloadReplicas - 1
+			}
+			if cur.VersionUpgrade.WorkloadMinAvailable < 0 || cur.VersionUpgrade.WorkloadMinAvailable > cur.VersionUpgrade.WorkloadReplicas {
+				return fmt.Errorf("AddOnManagedNodeGroups.MNGs[%q] invalid VersionUpgrade.WorkloadMinAvailable %d (must be 0 to %d)", cur.Name, cur.VersionUpgrade.WorkloadMinAvailable, cur.VersionUpgrade.WorkloadReplicas)
+			}
+
 			// do not set any defaults
 			// hard to keep everything in sync and find right values:
 			// - original cluster version
 			// - cluster upgrade version
 			// - default mng version
 			// - custom mng version
-			if cur.VersionUpgrade.Version == "" {
-				return fmt.Errorf("AddOnManagedNodeGroups.MNGs[%q] VersionUpgrade.Enable but empty VersionUpgrade.Version", cur.Name)
+			if cur.VersionUpgrade.Version == "" && cur.VersionUpgrade.ReleaseVersion == "" {
+				return fmt.Errorf("AddOnManagedNodeGroups.MNGs[%q] VersionUpgrade.Enable but empty VersionUpgrade.Version and VersionUpgrade.ReleaseVersion", cur.Name)
 			}
+		}
+
+		// release version only upgrade rolls the nodes without changing the Kubernetes version
+		if cur.VersionUpgrade != nil && cur.VersionUpgrade.Enable && cur.VersionUpgrade.Version != "" {
+			var err error
 			cur.VersionUpgrade.VersionValue, err = strconv.ParseFloat(cur.VersionUpgrade.Version, 64)
 			if err != nil {
 				return fmt.Errorf("AddOnManagedNodeGroups.MNGs[%q] invalid VersionUpgrade.Version %q (%v)", cur.Name, cur.VersionUpgrade.Version, err)
@@ -391,42 +597,46 @@ func (cfg *Config) validateAddOnManagedNodeGroups() error {
 		if cur.VolumeSize == 0 {
 			cur.VolumeSize = DefaultNodeVolumeSize
 		}
-		if cur.RemoteAccessUserName == "" {
-			cur.RemoteAccessUserName = "ec2-user"
-		}
-
 		switch cur.AMIType {
-		case eks.AMITypesAl2X8664:
-			if cur.RemoteAccessUserName != "ec2-user" {
-				return fmt.Errorf("AMIType %q but unexpected RemoteAccessUserName %q", cur.AMIType, cur.RemoteAccessUserName)
-			}
-		case eks.AMITypesAl2X8664Gpu:
-			if cur.RemoteAccessUserName != "ec2-user" {
-				return fmt.Errorf("AMIType %q but unexpected RemoteAccessUserName %q", cur.AMIType, cur.RemoteAccessUserName)
-			}
-		case eks.AMITypesAl2Arm64:
-			if cur.RemoteAccessUserName != "ec2-user" {
-				return fmt.Errorf("AMIType %q but unexpected RemoteAccessUserName %q", cur.AMIType, cur.RemoteAccessUserName)
-			}
+		case eks.AMITypesAl2X8664,
+			eks.AMITypesAl2X8664Gpu,
+			eks.AMITypesAl2Arm64,
+			ec2config.AMITypeAL2023X8664,
+			ec2config.AMITypeAL2023ARM64,
+			eks.AMITypesBottlerocketX8664,
+			eks.AMITypesBottlerocketArm64,
+			MNGAMITypeWindowsCore2019X8664,
+			MNGAMITypeWindowsFull2019X8664,
+			MNGAMITypeWindowsCore2022X8664,
+			MNGAMITypeWindowsFull2022X8664:
 		default:
-			return fmt.Errorf("unknown ASGs[%q].AMIType %q", k, cur.AMIType)
+			return fmt.Errorf("unknown AddOnManagedNodeGroups.MNGs[%q].AMIType %q", k, cur.AMIType)
+		}
+		family, err := ec2config.ResolveAMIFamily(cur.AMIFamily, cur.AMIType)
+		if err != nil {
+			return fmt.Errorf("AddOnManagedNodeGroups.MNGs[%q] %v", k, err)
 		}
+		cur.AMIFamily = family
 
-		switch cur.AMIType {
-		case eks.AMITypesAl2X8664:
-			if len(cur.InstanceTypes) == 0 {
-				cur.InstanceTypes = []string{DefaultNodeInstanceTypeCPU}
-			}
-		case eks.AMITypesAl2X8664Gpu:
-			if len(cur.InstanceTypes) == 0 {
-				cur.InstanceTypes = []string{DefaultNodeInstanceTypeGPU}
-			}
-		case eks.AMITypesAl2Arm64:
-			if len(cur.InstanceTypes) == 0 {
-				cur.InstanceTypes = []string{DefaultNodeInstanceTypeARMCPU}
-			}
+		if cur.RemoteAccessUserName == "" {
+			cur.RemoteAccessUserName = ec2config.DefaultRemoteAccessUserName(cur.AMIFamily)
+		}
+		if cur.RemoteAccessUserName != ec2config.DefaultRemoteAccessUserName(cur.AMIFamily) {
+			return fmt.Errorf("AMIType %q but unexpected RemoteAccessUserName %q", cur.AMIType, cur.RemoteAccessUserName)
+		}
+		if len(cur.InstanceTypes) == 0 {
+			cur.InstanceTypes = []string{defaultInstanceType(cur.AMIFamily, cur.AMIType)}
+		}
+		switch cur.CapacityType {
+		case "", CapacityTypeOnDemand, CapacityTypeSpot: // empty defaults to On-Demand
 		default:
-			return fmt.Errorf("unknown AddOnManagedNodeGroups.MNGs[%q].AMIType %q", k, cur.AMIType)
+			return fmt.Errorf("AddOnManagedNodeGroups.MNGs[%q] unknown CapacityType %q", k, cur.CapacityType)
+		}
+		if err := cur.validateLaunchTemplate(); err != nil {
+			return fmt.Errorf("AddOnManagedNodeGroups.MNGs[%q] %v", k, err)
+		}
+		if err := cur.validateLabelsAndTaints(); err != nil {
+			return fmt.Errorf("AddOnManagedNodeGroups.MNGs[%q] %v", k, err)
 		}
 
 		if cfg.IsEnabledAddOnNLBHelloWorld() || cfg.IsEnabledAddOnALB2048() {
@@ -468,22 +678,152 @@ func (cfg *Config) validateAddOnManagedNodeGroups() error {
 			return fmt.Errorf("AddOnManagedNodeGroups.MNGs[%q].ASGDesiredCapacity %d > MNGMaxLimit %d", k, cur.ASGDesiredCapacity, MNGMaxLimit)
 		}
 
-		if cfg.IsEnabledAddOnNLBHelloWorld() && cfg.AddOnNLBHelloWorld.DeploymentReplicas < int32(cur.ASGDesiredCapacity) {
-			cfg.AddOnNLBHelloWorld.DeploymentReplicas = int32(cur.ASGDesiredCapacity)
+		// Linux add-on Deployments cannot be scheduled onto Windows nodes
+		if !IsWindowsAMIType(cur.AMIType) {
+			if cfg.IsEnabledAddOnNLBHelloWorld() && cfg.AddOnNLBHelloWorld.DeploymentReplicas < int32(cur.ASGDesiredCapacity) {
+				cfg.AddOnNLBHelloWorld.DeploymentReplicas = int32(cur.ASGDesiredCapacity)
+			}
+			if cfg.IsEnabledAddOnNLBGuestbook() && cfg.AddOnNLBGuestbook.DeploymentReplicas < int32(cur.ASGDesiredCapacity) {
+				cfg.AddOnNLBGuestbook.DeploymentReplicas = int32(cur.ASGDesiredCapacity)
+			}
+			if cfg.IsEnabledAddOnALB2048() && cfg.AddOnALB2048.DeploymentReplicasALB < int32(cur.ASGDesiredCapacity) {
+				cfg.AddOnALB2048.DeploymentReplicasALB = int32(cur.ASGDesiredCapacity)
+			}
+			if cfg.IsEnabledAddOnALB2048() && cfg.AddOnALB2048.DeploymentReplicas2048 < int32(cur.ASGDesiredCapacity) {
+				cfg.AddOnALB2048.DeploymentReplicas2048 = int32(cur.ASGDesiredCapacity)
+			}
+		}
+
+		processed[k] = cur
+	}
+
+	cfg.AddOnManagedNodeGroups.MNGs = processed
+
+	// Windows nodes require Linux nodes to run CoreDNS and the VPC resource controller
+	// ref. https://docs.aws.amazon.com/eks/latest/userguide/windows-support.html
+	windows, linux := 0, 0
+	for _, cur := range processed {
+		if IsWindowsAMIType(cur.AMIType) {
+			windows++
+		} else {
+			linux++
+		}
+	}
+	if cfg.AddOnNodeGroups != nil && cfg.AddOnNodeGroups.Enable {
+		for _, cur := range cfg.AddOnNodeGroups.ASGs {
+			if !IsWindowsAMIType(cur.AMIType) {
+				linux++
+			}
 		}
-		if cfg.IsEnabledAddOnNLBGuestbook() && cfg.AddOnNLBGuestbook.DeploymentReplicas < int32(cur.ASGDesiredCapacity) {
-			cfg.AddOnNLBGuestbook.DeploymentReplicas = int32(cur.ASGDesiredCapacity)
+	}
+	if windows > 0 && linux == 0 {
+		return fmt.Errorf("AddOnManagedNodeGroups has %d Windows MNG(s) but no Linux node group", windows)
+	}
+	return nil
+}
+
+// reservedMNGLabels is the node labels set by the tester for every MNG.
+var reservedMNGLabels = map[string]struct{}{
+	"NodeType": {},
+	"AMIType":  {},
+	"NGType":   {},
+	"NGName":   {},
+}
+
+// maxMNGTaints is the maximum number of taints per managed node group.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/node-taints-managed-node-groups.html
+const maxMNGTaints = 50
+
+func (cur *MNG) validateLabelsAndTaints() error {
+	for k, v := range cur.Labels {
+		if _, ok := reservedMNGLabels[k]; ok {
+			return fmt.Errorf("Labels key %q is reserved", k)
 		}
-		if cfg.IsEnabledAddOnALB2048() && cfg.AddOnALB2048.DeploymentReplicasALB < int32(cur.ASGDesiredCapacity) {
-			cfg.AddOnALB2048.DeploymentReplicasALB = int32(cur.ASGDesiredCapacity)
+		if errs := k8s_validation.IsQualifiedName(k); len(errs) > 0 {
+			return fmt.Errorf("invalid Labels key %q (%s)", k, strings.Join(errs, ", "))
 		}
-		if cfg.IsEnabledAddOnALB2048() && cfg.AddOnALB2048.DeploymentReplicas2048 < int32(cur.ASGDesiredCapacity) {
-			cfg.AddOnALB2048.DeploymentReplicas2048 = int32(cur.ASGDesiredCapacity)
+		if errs := k8s_validation.IsValidLabelValue(v); len(errs) > 0 {
+			return fmt.Errorf("invalid Labels value %q (%s)", v, strings.Join(errs, ", "))
 		}
+	}
 
-		processed[k] = cur
+	if len(cur.Taints) > maxMNGTaints {
+		return fmt.Errorf("too many Taints %d (limit %d)", len(cur.Taints), maxMNGTaints)
+	}
+	for _, t := range cur.Taints {
+		if errs := k8s_validation.IsQualifiedName(t.Key); len(errs) > 0 {
+			return fmt.Errorf("invalid Taints key %q (%s)", t.Key, strings.Join(errs, ", "))
+		}
+		if errs := k8s_validation.IsValidLabelValue(t.Value); len(errs) > 0 {
+			return fmt.Errorf("invalid Taints value %q (%s)", t.Value, strings.Join(errs, ", "))
+		}
+		if t.EKSEffect() == "" {
+			return fmt.Errorf("unknown Taints effect %q for key %q", t.Effect, t.Key)
+		}
 	}
+	return nil
+}
 
-	cfg.AddOnManagedNodeGroups.MNGs = processed
+func (cur *MNG) validateLaunchTemplate() error {
+	if cur.LaunchTemplate == nil {
+		return nil
+	}
+	if !cur.LaunchTemplate.Enable {
+		cur.LaunchTemplate = nil
+		return nil
+	}
+	lt := cur.LaunchTemplate
+
+	if lt.Name == "" {
+		lt.Name = cur.Name + "-launch-template"
+	}
+	switch cur.AMIFamily {
+	case ec2config.AMIFamilyAL2, ec2config.AMIFamilyAL2023:
+	default:
+		if lt.ImageID != "" || lt.UserData != "" || lt.KubeletExtraArgs != "" {
+			return fmt.Errorf("LaunchTemplate ImageID, UserData, and KubeletExtraArgs not supported for AMIFamily %q", cur.AMIFamily)
+		}
+	}
+	if lt.ImageID != "" {
+		if cur.ReleaseVersion != "" {
+			return fmt.Errorf("LaunchTemplate.ImageID %q cannot be set with ReleaseVersion %q", lt.ImageID, cur.ReleaseVersion)
+		}
+		if cur.VersionUpgrade != nil && cur.VersionUpgrade.Enable {
+			return fmt.Errorf("LaunchTemplate.ImageID %q cannot be set with VersionUpgrade", lt.ImageID)
+		}
+	}
+	if lt.KubeletExtraArgs != "" && lt.ImageID == "" && cur.AMIFamily == ec2config.AMIFamilyAL2 {
+		return fmt.Errorf("LaunchTemplate.KubeletExtraArgs requires LaunchTemplate.ImageID for AMIFamily %q", cur.AMIFamily)
+	}
+
+	if lt.MetadataHTTPTokens == "" {
+		lt.MetadataHTTPTokens = DefaultMNGLaunchTemplateMetadataHTTPTokens
+	}
+	switch lt.MetadataHTTPTokens {
+	case "required", "optional":
+	default:
+		return fmt.Errorf("unknown LaunchTemplate.MetadataHTTPTokens %q", lt.MetadataHTTPTokens)
+	}
+	if lt.MetadataHTTPPutResponseHopLimit == 0 {
+		lt.MetadataHTTPPutResponseHopLimit = DefaultMNGLaunchTemplateMetadataHTTPPutResponseHopLimit
+	}
+	if lt.MetadataHTTPPutResponseHopLimit < 1 || lt.MetadataHTTPPutResponseHopLimit > 64 {
+		return fmt.Errorf("invalid LaunchTemplate.MetadataHTTPPutResponseHopLimit %d (must be 1 to 64)", lt.MetadataHTTPPutResponseHopLimit)
+	}
+
+	if lt.VolumeType == "" {
+		lt.VolumeType = DefaultMNGLaunchTemplateVolumeType
+	}
+	switch lt.VolumeType {
+	case "gp2", "gp3", "io1", "io2", "st1", "sc1", "standard":
+	default:
+		return fmt.Errorf("unknown LaunchTemplate.VolumeType %q", lt.VolumeType)
+	}
+	if lt.VolumeIOPS < 0 || (lt.VolumeIOPS > 0 && lt.VolumeType != "gp3" && lt.VolumeType != "io1" && lt.VolumeType != "io2") {
+		return fmt.Errorf("invalid LaunchTemplate.VolumeIOPS %d for VolumeType %q", lt.VolumeIOPS, lt.VolumeType)
+	}
+	if lt.VolumeThroughput < 0 || (lt.VolumeThroughput > 0 && lt.VolumeType != "gp3") {
+		return fmt.Errorf("invalid LaunchTemplate.VolumeThroughput %d for VolumeType %q", lt.VolumeThroughput, lt.VolumeType)
+	}
 	return nil
 }
@@ -23,7 +23,6 @@ type AddOnMetricsServer struct {
 const EnvironmentVariablePrefixAddOnMetricsServer = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_METRICS_SERVER_"
 
 // IsEnabledAddOnMetricsServer returns true if "AddOnMetricsServer" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnMetricsServer() bool {
 	if cfg.AddOnMetricsServer == nil {
 		return false
@@ -31,7 +30,6 @@ func (cfg *Config) IsEnabledAddOnMetricsServer() bool {
 	if cfg.AddOnMetricsServer.Enable {
 		return true
 	}
-	cfg.AddOnMetricsServer = nil
 	return false
 }
 
@@ -43,6 +41,7 @@ func getDefaultAddOnMetricsServer() *AddOnMetricsServer {
 
 func (cfg *Config) validateAddOnMetricsServer() error {
 	if !cfg.IsEnabledAddOnMetricsServer() {
+		cfg.AddOnMetricsServer = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
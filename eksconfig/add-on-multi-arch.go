@@ -0,0 +1,129 @@
+package eksconfig
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-k8s-tester/ec2config"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnMultiArch defines parameters for EKS cluster
+// add-on multi-architecture (e.g. Graviton and x86_64) scheduling tests.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/eks-optimized-ami.html#arm-ami
+type AddOnMultiArch struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// Namespace is the namespace to create objects in.
+	Namespace string `json:"namespace"`
+	// Image is the multi-architecture image to run on every architecture.
+	Image string `json:"image"`
+	// Completes is the number of Pods to run on each architecture.
+	Completes int32 `json:"completes"`
+
+	// Architectures is the list of node architectures
+	// derived from the Linux node groups.
+	Architectures []string `json:"architectures" read-only:"true"`
+	// Results maps each architecture to the test result.
+	Results map[string]MultiArchResult `json:"results" read-only:"true"`
+}
+
+// MultiArchResult is the multi-architecture test result of an architecture.
+type MultiArchResult struct {
+	// Machine is the "uname -m" output from the Pods.
+	Machine string `json:"machine"`
+	// NodeNames is the list of nodes the Pods were scheduled on.
+	NodeNames []string `json:"node-names"`
+	// TimeFrame is the time taken for all Pods to complete.
+	TimeFrame timeutil.TimeFrame `json:"time-frame"`
+}
+
+// EnvironmentVariablePrefixAddOnMultiArch is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnMultiArch = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_MULTI_ARCH_"
+
+// IsEnabledAddOnMultiArch returns true if "AddOnMultiArch" is enabled.
+func (cfg *Config) IsEnabledAddOnMultiArch() bool {
+	if cfg.AddOnMultiArch == nil {
+		return false
+	}
+	if cfg.AddOnMultiArch.Enable {
+		return true
+	}
+	return false
+}
+
+// DefaultMultiArchImage is the default multi-architecture image.
+const DefaultMultiArchImage = "public.ecr.aws/docker/library/busybox:1.36"
+
+func getDefaultAddOnMultiArch() *AddOnMultiArch {
+	return &AddOnMultiArch{
+		Enable:    false,
+		Image:     DefaultMultiArchImage,
+		Completes: 2,
+	}
+}
+
+// NodeArchitectures returns the sorted list of architectures
+// of the Linux node groups and managed node groups.
+func (cfg *Config) NodeArchitectures() []string {
+	archs := make(map[string]struct{})
+	if cfg.IsEnabledAddOnNodeGroups() {
+		for _, cur := range cfg.AddOnNodeGroups.ASGs {
+			if cur.AMIFamily == ec2config.AMIFamilyWindows {
+				continue
+			}
+			archs[ec2config.ArchitectureFromAMIType(cur.AMIType)] = struct{}{}
+		}
+	}
+	if cfg.IsEnabledAddOnManagedNodeGroups() {
+		for _, cur := range cfg.AddOnManagedNodeGroups.MNGs {
+			if IsWindowsAMIType(cur.AMIType) {
+				continue
+			}
+			archs[ec2config.ArchitectureFromAMIType(cur.AMIType)] = struct{}{}
+		}
+	}
+	ss := make([]string, 0, len(archs))
+	for arch := range archs {
+		ss = append(ss, arch)
+	}
+	sort.Strings(ss)
+	return ss
+}
+
+func (cfg *Config) validateAddOnMultiArch() error {
+	if !cfg.IsEnabledAddOnMultiArch() {
+		cfg.AddOnMultiArch = nil
+		return nil
+	}
+
+	cfg.AddOnMultiArch.Architectures = cfg.NodeArchitectures()
+	if len(cfg.AddOnMultiArch.Architectures) < 2 {
+		return fmt.Errorf("AddOnMultiArch.Enable true but node groups have architectures %q (need both %q and %q)",
+			cfg.AddOnMultiArch.Architectures,
+			ec2config.ArchitectureAMD64,
+			ec2config.ArchitectureARM64,
+		)
+	}
+
+	if cfg.AddOnMultiArch.Namespace == "" {
+		cfg.AddOnMultiArch.Namespace = cfg.Name + "-multi-arch"
+	}
+	if cfg.AddOnMultiArch.Image == "" {
+		cfg.AddOnMultiArch.Image = DefaultMultiArchImage
+	}
+	if cfg.AddOnMultiArch.Completes == 0 {
+		cfg.AddOnMultiArch.Completes = 2
+	}
+	if cfg.AddOnMultiArch.Completes < 0 {
+		return fmt.Errorf("AddOnMultiArch.Completes %d invalid", cfg.AddOnMultiArch.Completes)
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnNetworkPolicy defines parameters for EKS cluster
+// add-on network policy enforcement tests, which enable a network
+// policy engine, apply allow/deny policies, and probe connectivity
+// between namespaces.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/cni-network-policy.html
+// ref. https://docs.tigera.io/calico/latest/getting-started/kubernetes/managed-public-cloud/eks
+type AddOnNetworkPolicy struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// S3Dir is the S3 directory to store the test results.
+	S3Dir string `json:"s3-dir"`
+
+	// Namespace is the namespace prefix for the test namespaces.
+	Namespace string `json:"namespace"`
+
+	// Provider is the network policy engine, either "vpc-cni"
+	// (VPC CNI network policy agent) or "calico".
+	Provider string `json:"provider"`
+	// CalicoVersion is the Calico release to install
+	// when "Provider" is "calico".
+	CalicoVersion string `json:"calico-version"`
+
+	// PodImage is the image for the server and client Pods.
+	PodImage string `json:"pod-image"`
+	// ProbeTimeout is the timeout for each connectivity probe to observe
+	// the expected result, since policies are enforced asynchronously.
+	ProbeTimeout       time.Duration `json:"probe-timeout"`
+	ProbeTimeoutString string        `json:"probe-timeout-string" read-only:"true"`
+
+	// ResultJUnitXMLPath is the JUnit XML output of the connectivity probes.
+	ResultJUnitXMLPath  string `json:"result-junit-xml-path" read-only:"true"`
+	ResultJUnitXMLS3Key string `json:"result-junit-xml-s3-key" read-only:"true"`
+
+	// ProbesPassed is the number of probes with the expected result.
+	ProbesPassed int `json:"probes-passed" read-only:"true"`
+	// ProbesFailed is the number of probes with an unexpected result.
+	ProbesFailed int `json:"probes-failed" read-only:"true"`
+}
+
+// EnvironmentVariablePrefixAddOnNetworkPolicy is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnNetworkPolicy = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_NETWORK_POLICY_"
+
+// IsEnabledAddOnNetworkPolicy returns true if "AddOnNetworkPolicy" is enabled.
+func (cfg *Config) IsEnabledAddOnNetworkPolicy() bool {
+	if cfg.AddOnNetworkPolicy == nil {
+		return false
+	}
+	if cfg.AddOnNetworkPolicy.Enable {
+		return true
+	}
+	return false
+}
+
+const (
+	// NetworkPolicyProviderVPCCNI enables the VPC CNI network policy agent.
+	NetworkPolicyProviderVPCCNI = "vpc-cni"
+	// NetworkPolicyProviderCalico installs Calico in policy-only mode.
+	NetworkPolicyProviderCalico = "calico"
+
+	// DefaultNetworkPolicyCalicoVersion is the default Calico release.
+	DefaultNetworkPolicyCalicoVersion = "v3.26.1"
+	// DefaultNetworkPolicyPodImage is the default test Pod image.
+	DefaultNetworkPolicyPodImage = "busybox:1.36"
+	// DefaultNetworkPolicyProbeTimeout is the default probe timeout.
+	DefaultNetworkPolicyProbeTimeout = 2 * time.Minute
+)
+
+func getDefaultAddOnNetworkPolicy() *AddOnNetworkPolicy {
+	return &AddOnNetworkPolicy{
+		Enable:        false,
+		Provider:      NetworkPolicyProviderVPCCNI,
+		CalicoVersion: DefaultNetworkPolicyCalicoVersion,
+		PodImage:      DefaultNetworkPolicyPodImage,
+		ProbeTimeout:  DefaultNetworkPolicyProbeTimeout,
+	}
+}
+
+func (cfg *Config) validateAddOnNetworkPolicy() error {
+	if !cfg.IsEnabledAddOnNetworkPolicy() {
+		cfg.AddOnNetworkPolicy = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnNetworkPolicy.Enable true but no node group is enabled")
+	}
+
+	if cfg.AddOnNetworkPolicy.S3Dir == "" {
+		cfg.AddOnNetworkPolicy.S3Dir = path.Join(cfg.Name, "add-on-network-policy")
+	}
+	if cfg.AddOnNetworkPolicy.Namespace == "" {
+		cfg.AddOnNetworkPolicy.Namespace = cfg.Name + "-network-policy"
+	}
+
+	switch cfg.AddOnNetworkPolicy.Provider {
+	case "":
+		cfg.AddOnNetworkPolicy.Provider = NetworkPolicyProviderVPCCNI
+	case NetworkPolicyProviderVPCCNI:
+	case NetworkPolicyProviderCalico:
+	default:
+		return fmt.Errorf("unknown AddOnNetworkPolicy.Provider %q", cfg.AddOnNetworkPolicy.Provider)
+	}
+	// the network policy controller runs in the EKS control plane since 1.25
+	if cfg.AddOnNetworkPolicy.Provider == NetworkPolicyProviderVPCCNI && cfg.VersionValue < 1.25 {
+		return fmt.Errorf("AddOnNetworkPolicy.Provider %q requires Version >= 1.25 (got %q)", cfg.AddOnNetworkPolicy.Provider, cfg.Version)
+	}
+	if cfg.AddOnNetworkPolicy.Provider == NetworkPolicyProviderCalico {
+		if cfg.AddOnNetworkPolicy.CalicoVersion == "" {
+			cfg.AddOnNetworkPolicy.CalicoVersion = DefaultNetworkPolicyCalicoVersion
+		}
+		if !strings.HasPrefix(cfg.AddOnNetworkPolicy.CalicoVersion, "v") {
+			return fmt.Errorf("AddOnNetworkPolicy.CalicoVersion %q must have 'v' prefix", cfg.AddOnNetworkPolicy.CalicoVersion)
+		}
+	}
+
+	if cfg.AddOnNetworkPolicy.PodImage == "" {
+		cfg.AddOnNetworkPolicy.PodImage = DefaultNetworkPolicyPodImage
+	}
+	if cfg.AddOnNetworkPolicy.ProbeTimeout == time.Duration(0) {
+		cfg.AddOnNetworkPolicy.ProbeTimeout = DefaultNetworkPolicyProbeTimeout
+	}
+	cfg.AddOnNetworkPolicy.ProbeTimeoutString = cfg.AddOnNetworkPolicy.ProbeTimeout.String()
+
+	if cfg.AddOnNetworkPolicy.ResultJUnitXMLPath == "" {
+		cfg.AddOnNetworkPolicy.ResultJUnitXMLPath = filepath.Join(
+			filepath.Dir(cfg.ConfigPath),
+			fmt.Sprintf("%s-network-policy.junit.xml", cfg.Name),
+		)
+		os.RemoveAll(cfg.AddOnNetworkPolicy.ResultJUnitXMLPath)
+	}
+	if !strings.HasSuffix(cfg.AddOnNetworkPolicy.ResultJUnitXMLPath, ".xml") {
+		return fmt.Errorf("AddOnNetworkPolicy.ResultJUnitXMLPath[%q] must have '.xml' extension", cfg.AddOnNetworkPolicy.ResultJUnitXMLPath)
+	}
+	if cfg.AddOnNetworkPolicy.ResultJUnitXMLS3Key == "" {
+		cfg.AddOnNetworkPolicy.ResultJUnitXMLS3Key = path.Join(
+			cfg.AddOnNetworkPolicy.S3Dir,
+			filepath.Base(cfg.AddOnNetworkPolicy.ResultJUnitXMLPath),
+		)
+	}
+
+	return nil
+}
@@ -40,7 +40,6 @@ type AddOnNLBGuestbook struct {
 const EnvironmentVariablePrefixAddOnNLBGuestbook = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_NLB_GUESTBOOK_"
 
 // IsEnabledAddOnNLBGuestbook returns true if "AddOnNLBGuestbook" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnNLBGuestbook() bool {
 	if cfg.AddOnNLBGuestbook == nil {
 		return false
@@ -48,7 +47,6 @@ func (cfg *Config) IsEnabledAddOnNLBGuestbook() bool {
 	if cfg.AddOnNLBGuestbook.Enable {
 		return true
 	}
-	cfg.AddOnNLBGuestbook = nil
 	return false
 }
 
@@ -62,6 +60,7 @@ func getDefaultAddOnNLBGuestbook() *AddOnNLBGuestbook {
 
 func (cfg *Config) validateAddOnNLBGuestbook() error {
 	if !cfg.IsEnabledAddOnNLBGuestbook() {
+		cfg.AddOnNLBGuestbook = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
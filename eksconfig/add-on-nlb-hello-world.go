@@ -38,7 +38,6 @@ type AddOnNLBHelloWorld struct {
 const EnvironmentVariablePrefixAddOnNLBHelloWorld = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_NLB_HELLO_WORLD_"
 
 // IsEnabledAddOnNLBHelloWorld returns true if "AddOnNLBHelloWorld" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnNLBHelloWorld() bool {
 	if cfg.AddOnNLBHelloWorld == nil {
 		return false
@@ -46,7 +45,6 @@ func (cfg *Config) IsEnabledAddOnNLBHelloWorld() bool {
 	if cfg.AddOnNLBHelloWorld.Enable {
 		return true
 	}
-	cfg.AddOnNLBHelloWorld = nil
 	return false
 }
 
@@ -60,6 +58,7 @@ func getDefaultAddOnNLBHelloWorld() *AddOnNLBHelloWorld {
 
 func (cfg *Config) validateAddOnNLBHelloWorld() error {
 	if !cfg.IsEnabledAddOnNLBHelloWorld() {
+		cfg.AddOnNLBHelloWorld = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
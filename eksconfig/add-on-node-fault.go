@@ -0,0 +1,157 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnNodeFault defines parameters for EKS cluster
+// add-on node fault tests, which restart the node services
+// (e.g. kubelet, containerd) on a subset of nodes, and verify
+// the Pods keep running and the nodes return Ready.
+type AddOnNodeFault struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// NodeGroupName is the name of the node group to inject the faults,
+	// either in "AddOnNodeGroups.ASGs" or "AddOnManagedNodeGroups.MNGs".
+	// If empty, any worker node may be selected.
+	NodeGroupName string `json:"node-group-name"`
+	// Nodes is the number of nodes to inject the faults.
+	Nodes int `json:"nodes"`
+	// Services is the list of systemd units to restart on each node, in order.
+	Services []string `json:"services"`
+	// Transport is how to run the commands on the nodes,
+	// either "ssm" (SSM Run Command) or "ssh".
+	// "ssm" requires the SSM agent on the nodes.
+	// "ssh" requires the remote access (e.g. "RemoteAccessPrivateKeyPath").
+	Transport string `json:"transport"`
+
+	// Namespace is the namespace to run the canary Pods in.
+	Namespace string `json:"namespace"`
+	// ReadyTimeout is the bound for the node to return Ready after each restart.
+	ReadyTimeout       time.Duration `json:"ready-timeout"`
+	ReadyTimeoutString string        `json:"ready-timeout-string" read-only:"true"`
+
+	// Results is the recovery measured after each restart.
+	Results []NodeFaultResult `json:"results" read-only:"true"`
+}
+
+// NodeFaultResult is the recovery measured after a single service restart.
+type NodeFaultResult struct {
+	InstanceID string `json:"instance-id"`
+	NodeName   string `json:"node-name"`
+	Service    string `json:"service"`
+	// RestartedUTC is the time of the restart.
+	RestartedUTC time.Time `json:"restarted-utc"`
+	// ReadyTime is the time from the restart until the node reported Ready again.
+	ReadyTime       time.Duration `json:"ready-time"`
+	ReadyTimeString string        `json:"ready-time-string"`
+	// Error is the error if the node did not recover, or the Pods did not keep running.
+	Error string `json:"error,omitempty"`
+}
+
+const (
+	// NodeFaultTransportSSM runs the commands with SSM Run Command.
+	NodeFaultTransportSSM = "ssm"
+	// NodeFaultTransportSSH runs the commands over SSH.
+	NodeFaultTransportSSH = "ssh"
+)
+
+// EnvironmentVariablePrefixAddOnNodeFault is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnNodeFault = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_NODE_FAULT_"
+
+// IsEnabledAddOnNodeFault returns true if "AddOnNodeFault" is enabled.
+func (cfg *Config) IsEnabledAddOnNodeFault() bool {
+	if cfg.AddOnNodeFault == nil {
+		return false
+	}
+	if cfg.AddOnNodeFault.Enable {
+		return true
+	}
+	return false
+}
+
+func getDefaultAddOnNodeFault() *AddOnNodeFault {
+	return &AddOnNodeFault{
+		Enable:       false,
+		Nodes:        1,
+		Services:     []string{"kubelet", "containerd"},
+		Transport:    NodeFaultTransportSSM,
+		ReadyTimeout: 5 * time.Minute,
+	}
+}
+
+// serviceNameRegex matches the systemd unit names,
+// which are passed to the remote shell as is.
+var serviceNameRegex = regexp.MustCompile(`^[a-zA-Z0-9@._-]+$`)
+
+func (cfg *Config) validateAddOnNodeFault() error {
+	if !cfg.IsEnabledAddOnNodeFault() {
+		cfg.AddOnNodeFault = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnNodeFault.Enable true but no node group is enabled")
+	}
+
+	cur := cfg.AddOnNodeFault
+	if cur.NodeGroupName != "" {
+		found := false
+		if cfg.IsEnabledAddOnNodeGroups() {
+			_, found = cfg.AddOnNodeGroups.ASGs[cur.NodeGroupName]
+		}
+		if !found && cfg.IsEnabledAddOnManagedNodeGroups() {
+			_, found = cfg.AddOnManagedNodeGroups.MNGs[cur.NodeGroupName]
+		}
+		if !found {
+			return fmt.Errorf("AddOnNodeFault.NodeGroupName %q not found", cur.NodeGroupName)
+		}
+	}
+	if cur.Nodes == 0 {
+		cur.Nodes = 1
+	}
+	if cur.Nodes < 0 {
+		return fmt.Errorf("AddOnNodeFault.Nodes %d invalid", cur.Nodes)
+	}
+
+	if len(cur.Services) == 0 {
+		cur.Services = []string{"kubelet", "containerd"}
+	}
+	for _, svc := range cur.Services {
+		if !serviceNameRegex.MatchString(svc) {
+			return fmt.Errorf("AddOnNodeFault.Services %q invalid", svc)
+		}
+	}
+
+	switch cur.Transport {
+	case "":
+		cur.Transport = NodeFaultTransportSSM
+	case NodeFaultTransportSSM:
+	case NodeFaultTransportSSH:
+		if cfg.RemoteAccessPrivateKeyPath == "" && !cfg.RemoteAccessInstanceConnect {
+			return errors.New("AddOnNodeFault.Transport \"ssh\" but no RemoteAccessPrivateKeyPath or RemoteAccessInstanceConnect")
+		}
+	default:
+		return fmt.Errorf("AddOnNodeFault.Transport %q unknown (must be %q or %q)", cur.Transport, NodeFaultTransportSSM, NodeFaultTransportSSH)
+	}
+
+	if cur.Namespace == "" {
+		cur.Namespace = cfg.Name + "-node-fault"
+	}
+	if cur.ReadyTimeout == time.Duration(0) {
+		cur.ReadyTimeout = 5 * time.Minute
+	}
+	cur.ReadyTimeoutString = cur.ReadyTimeout.String()
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/aws/aws-k8s-tester/ec2config"
 	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	aws_eks_v2_types "github.com/aws/aws-sdk-go-v2/service/eks/types"
 	"github.com/aws/aws-sdk-go/service/eks"
 )
@@ -28,6 +29,14 @@ type AddOnNodeGroups struct {
 
 	// FetchLogs is true to fetch logs from remote nodes using SSH.
 	FetchLogs bool `json:"fetch-logs"`
+	// FetchLogsFanOut is the number of commands to run concurrently
+	// per node when fetching logs, multiplexed over one SSH connection.
+	// Must not exceed the sshd "MaxSessions" (10 by default).
+	FetchLogsFanOut int `json:"fetch-logs-fan-out"`
+	// DiagnoseOnFailure is true to collect bootstrap logs from the failed
+	// instances (using SSH or EC2 console output) when nodes fail to join,
+	// and to attach the failure classification to the returned error.
+	DiagnoseOnFailure bool `json:"diagnose-on-failure"`
 
 	// LogsDir is set to specify the target directory to store all remote log files.
 	// If empty, it stores in the same directory as "ConfigPath".
@@ -69,6 +78,149 @@ type ASG struct {
 	// ClusterAutoscaler is enabled to run cluster auto-scaler per node group.
 	// ref. https://github.com/kubernetes/autoscaler/tree/master/cluster-autoscaler
 	ClusterAutoscaler *NGClusterAutoscaler `json:"cluster-autoscaler,omitempty"`
+
+	// CapacityType is the capacity type of the node group instances,
+	// either "ON_DEMAND" or "SPOT". Defaults to "ON_DEMAND".
+	CapacityType string `json:"capacity-type,omitempty"`
+	// InstanceTypes is the list of instance types for the mixed instances policy,
+	// in the order of priority. If empty, only "InstanceType" is used.
+	// ref. https://docs.aws.amazon.com/autoscaling/ec2/userguide/ec2-auto-scaling-mixed-instances-groups.html
+	InstanceTypes []string `json:"instance-types,omitempty"`
+	// OnDemandBaseCapacity is the minimum number of On-Demand instances
+	// in the mixed instances policy.
+	OnDemandBaseCapacity int32 `json:"on-demand-base-capacity,omitempty"`
+	// OnDemandPercentageAboveBaseCapacity is the percentage of On-Demand instances
+	// above "OnDemandBaseCapacity". Set to 100 with "ON_DEMAND", and defaults to 0 with "SPOT".
+	OnDemandPercentageAboveBaseCapacity int32 `json:"on-demand-percentage-above-base-capacity,omitempty"`
+	// OnDemandAllocationStrategy is the On-Demand allocation strategy.
+	// Only "prioritized" is supported, following the order of "InstanceTypes".
+	OnDemandAllocationStrategy string `json:"on-demand-allocation-strategy,omitempty"`
+	// SpotAllocationStrategy is the Spot allocation strategy
+	// (e.g. "capacity-optimized", "capacity-optimized-prioritized", "lowest-price").
+	SpotAllocationStrategy string `json:"spot-allocation-strategy,omitempty"`
+
+	// Placement launches the node group in the Local Zone, Wavelength Zone,
+	// or Outpost subnets, instead of the VPC subnets in the region.
+	Placement *NGPlacement `json:"placement,omitempty"`
+}
+
+// NGPlacement defines the node group placement outside the region's
+// Availability Zones. The control plane stays in the region.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/local-zones.html
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/eks-outposts.html
+type NGPlacement struct {
+	// Type is the placement type, either "local-zone", "wavelength-zone",
+	// or "outpost" (same as the EC2 zone types).
+	Type string `json:"type"`
+	// SubnetIDs are the existing subnets in the Local Zone, Wavelength Zone,
+	// or Outpost, in the cluster VPC. The zone must be opted in.
+	SubnetIDs []string `json:"subnet-ids"`
+	// OutpostARN is the Outpost ARN of the subnets, required for "outpost".
+	OutpostARN string `json:"outpost-arn,omitempty"`
+}
+
+const (
+	// PlacementTypeLocalZone is the Local Zone placement.
+	PlacementTypeLocalZone = "local-zone"
+	// PlacementTypeWavelengthZone is the Wavelength Zone placement.
+	PlacementTypeWavelengthZone = "wavelength-zone"
+	// PlacementTypeOutpost is the Outpost placement.
+	PlacementTypeOutpost = "outpost"
+)
+
+// placementVolumeTypes are the EBS volume types available in the placement.
+// gp3 is not available in all Local Zones, and only gp2 is available
+// in Wavelength Zones and on Outposts.
+var placementVolumeTypes = map[string][]aws_ec2_v2_types.VolumeType{
+	PlacementTypeLocalZone: {
+		aws_ec2_v2_types.VolumeTypeGp2,
+		aws_ec2_v2_types.VolumeTypeIo1,
+		aws_ec2_v2_types.VolumeTypeSt1,
+		aws_ec2_v2_types.VolumeTypeSc1,
+	},
+	PlacementTypeWavelengthZone: {aws_ec2_v2_types.VolumeTypeGp2},
+	PlacementTypeOutpost:        {aws_ec2_v2_types.VolumeTypeGp2},
+}
+
+// wavelengthInstanceTypes are the instance types available in Wavelength Zones.
+// ref. https://docs.aws.amazon.com/wavelength/latest/developerguide/wavelength-quotas.html
+var wavelengthInstanceTypes = map[string]struct{}{
+	"t3.medium":    {},
+	"t3.xlarge":    {},
+	"r5.2xlarge":   {},
+	"g4dn.2xlarge": {},
+}
+
+// SubnetIDs returns the subnets to launch the node group in.
+func (asg ASG) SubnetIDs(vpc *VPC) []string {
+	if asg.Placement != nil {
+		return asg.Placement.SubnetIDs
+	}
+	return vpc.NodeSubnetIDs()
+}
+
+// validatePlacement validates the placement after the instance and
+// volume types are defaulted. Instance type offerings and the zone
+// types of the subnets are checked by "eks validate".
+func (cfg *Config) validatePlacement(k string, cur ASG) error {
+	p := cur.Placement
+	vt, ok := placementVolumeTypes[p.Type]
+	if !ok {
+		return fmt.Errorf("AddOnNodeGroups.ASGs[%q] unknown Placement.Type %q", k, p.Type)
+	}
+	if len(p.SubnetIDs) == 0 {
+		return fmt.Errorf("AddOnNodeGroups.ASGs[%q] empty Placement.SubnetIDs", k)
+	}
+	if cfg.VPC.Create {
+		return fmt.Errorf("AddOnNodeGroups.ASGs[%q] Placement requires VPC.Create false with the existing VPC.ID of Placement.SubnetIDs", k)
+	}
+	switch {
+	case p.Type == PlacementTypeOutpost && p.OutpostARN == "":
+		return fmt.Errorf("AddOnNodeGroups.ASGs[%q] Placement.Type %q requires Placement.OutpostARN", k, p.Type)
+	case p.Type == PlacementTypeOutpost && !strings.Contains(p.OutpostARN, ":outpost/"):
+		return fmt.Errorf("AddOnNodeGroups.ASGs[%q] invalid Placement.OutpostARN %q", k, p.OutpostARN)
+	case p.Type != PlacementTypeOutpost && p.OutpostARN != "":
+		return fmt.Errorf("AddOnNodeGroups.ASGs[%q] Placement.OutpostARN %q is only supported with Placement.Type %q", k, p.OutpostARN, PlacementTypeOutpost)
+	}
+
+	found := false
+	for _, v := range vt {
+		if cur.VolumeType == v {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("AddOnNodeGroups.ASGs[%q] VolumeType %q not supported with Placement.Type %q (supported %v)", k, cur.VolumeType, p.Type, vt)
+	}
+	if p.Type != PlacementTypeLocalZone && cur.CapacityType == CapacityTypeSpot {
+		return fmt.Errorf("AddOnNodeGroups.ASGs[%q] CapacityType %q not supported with Placement.Type %q", k, cur.CapacityType, p.Type)
+	}
+	if p.Type == PlacementTypeWavelengthZone {
+		its := cur.InstanceTypes
+		if len(its) == 0 {
+			its = []string{cur.InstanceType}
+		}
+		for _, it := range its {
+			if _, ok := wavelengthInstanceTypes[it]; !ok {
+				return fmt.Errorf("AddOnNodeGroups.ASGs[%q] InstanceType %q not available with Placement.Type %q", k, it, p.Type)
+			}
+		}
+	}
+	return nil
+}
+
+const (
+	// CapacityTypeOnDemand is the On-Demand capacity type.
+	CapacityTypeOnDemand = "ON_DEMAND"
+	// CapacityTypeSpot is the Spot capacity type.
+	CapacityTypeSpot = "SPOT"
+)
+
+// IsMixedInstances returns true if the ASG should be created
+// with a mixed instances policy.
+func (asg ASG) IsMixedInstances() bool {
+	return asg.CapacityType == CapacityTypeSpot || len(asg.InstanceTypes) > 0
 }
 
 const (
@@ -78,7 +230,6 @@ const (
 )
 
 // IsEnabledAddOnNodeGroups returns true if "AddOnNodeGroups" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnNodeGroups() bool {
 	if cfg.AddOnNodeGroups == nil {
 		return false
@@ -86,16 +237,17 @@ func (cfg *Config) IsEnabledAddOnNodeGroups() bool {
 	if cfg.AddOnNodeGroups.Enable {
 		return len(cfg.AddOnNodeGroups.ASGs) > 0
 	}
-	cfg.AddOnNodeGroups = nil
 	return false
 }
 
 func getDefaultAddOnNodeGroups(name string) *AddOnNodeGroups {
 	return &AddOnNodeGroups{
-		Enable:    false,
-		Role:      getDefaultRole(),
-		FetchLogs: false,
-		LogsDir:   "", // to be auto-generated
+		Enable:            false,
+		Role:              getDefaultRole(),
+		FetchLogs:         false,
+		FetchLogsFanOut:   DefaultFetchLogsFanOut,
+		DiagnoseOnFailure: true,
+		LogsDir:           "", // to be auto-generated
 		ASGs: map[string]ASG{
 			name + "-ng-asg-cpu": {
 				ASG: ec2config.ASG{
@@ -128,6 +280,7 @@ func getDefaultAddOnNodeGroups(name string) *AddOnNodeGroups {
 
 func (cfg *Config) validateAddOnNodeGroups() error {
 	if !cfg.IsEnabledAddOnNodeGroups() {
+		cfg.AddOnNodeGroups = nil
 		return nil
 	}
 
@@ -154,6 +307,9 @@ func (cfg *Config) validateAddOnNodeGroups() error {
 	if cfg.AddOnNodeGroups.Role.InstanceProfileName == "" {
 		cfg.AddOnNodeGroups.Role.InstanceProfileName = cfg.Name + "-node-group-instance-profile"
 	}
+	if err := cfg.AddOnNodeGroups.Role.validatePolicy(cfg, "AddOnNodeGroups.Role", ".node-group-role-policy.json"); err != nil {
+		return err
+	}
 
 	n := len(cfg.AddOnNodeGroups.ASGs)
 	if n == 0 {
@@ -176,6 +332,12 @@ func (cfg *Config) validateAddOnNodeGroups() error {
 	if !strings.HasSuffix(cfg.AddOnNodeGroups.LogsTarGzPath, ".tar.gz") {
 		return fmt.Errorf("AddOnNodeGroups.LogsTarGzPath %q must end with .tar.gz", cfg.AddOnNodeGroups.LogsTarGzPath)
 	}
+	if cfg.AddOnNodeGroups.FetchLogsFanOut == 0 {
+		cfg.AddOnNodeGroups.FetchLogsFanOut = DefaultFetchLogsFanOut
+	}
+	if cfg.AddOnNodeGroups.FetchLogsFanOut < 1 || cfg.AddOnNodeGroups.FetchLogsFanOut > FetchLogsFanOutMaxLimit {
+		return fmt.Errorf("AddOnNodeGroups.FetchLogsFanOut %d must be between 1 and %d", cfg.AddOnNodeGroups.FetchLogsFanOut, FetchLogsFanOutMaxLimit)
+	}
 
 	names, processed := make(map[string]struct{}), make(map[string]ASG)
 	for k, cur := range cfg.AddOnNodeGroups.ASGs {
@@ -199,11 +361,10 @@ func (cfg *Config) validateAddOnNodeGroups() error {
 		}
 		if cur.VolumeType == "" {
 			cur.VolumeType = DefaultNodeVolumeType
+			if cur.Placement != nil {
+				cur.VolumeType = aws_ec2_v2_types.VolumeTypeGp2
+			}
 		}
-		if cur.RemoteAccessUserName == "" {
-			cur.RemoteAccessUserName = "ec2-user"
-		}
-
 		if cur.ImageID == "" && cur.ImageIDSSMParameter == "" {
 			return fmt.Errorf("%q both ImageID and ImageIDSSMParameter are empty", cur.Name)
 		}
@@ -216,10 +377,31 @@ func (cfg *Config) validateAddOnNodeGroups() error {
 		}
 
 		switch cur.AMIType {
-		case ec2config.AMITypeBottleRocketCPU:
-			if cur.RemoteAccessUserName != "ec2-user" {
-				return fmt.Errorf("AMIType %q but unexpected RemoteAccessUserName %q", cur.AMIType, cur.RemoteAccessUserName)
-			}
+		case fmt.Sprint(aws_eks_v2_types.AMITypesAl2X8664),
+			fmt.Sprint(aws_eks_v2_types.AMITypesAl2Arm64),
+			fmt.Sprint(aws_eks_v2_types.AMITypesAl2X8664Gpu),
+			ec2config.AMITypeAL2023X8664,
+			ec2config.AMITypeAL2023ARM64,
+			ec2config.AMITypeBottleRocketCPU,
+			ec2config.AMITypeWindowsServerCore2019X8664,
+			ec2config.AMITypeOther:
+		default:
+			return fmt.Errorf("unknown ASGs[%q].AMIType %q", k, cur.AMIType)
+		}
+		family, err := ec2config.ResolveAMIFamily(cur.AMIFamily, cur.AMIType)
+		if err != nil {
+			return fmt.Errorf("AddOnNodeGroups.ASGs[%q] %v", k, err)
+		}
+		cur.AMIFamily = family
+
+		if cur.RemoteAccessUserName == "" {
+			cur.RemoteAccessUserName = ec2config.DefaultRemoteAccessUserName(cur.AMIFamily)
+		}
+		if cur.RemoteAccessUserName != ec2config.DefaultRemoteAccessUserName(cur.AMIFamily) {
+			return fmt.Errorf("AMIType %q but unexpected RemoteAccessUserName %q", cur.AMIType, cur.RemoteAccessUserName)
+		}
+		switch cur.AMIFamily {
+		case ec2config.AMIFamilyBottlerocket:
 			if cur.SSM != nil {
 				if cur.SSM.DocumentName != "" && cfg.S3.BucketName == "" {
 					return fmt.Errorf("AMIType %q requires SSMDocumentName %q but no S3BucketName", cur.AMIType, cur.SSM.DocumentName)
@@ -228,41 +410,60 @@ func (cfg *Config) validateAddOnNodeGroups() error {
 			if cur.KubeletExtraArgs != "" {
 				return fmt.Errorf("AMIType %q but unexpected KubeletExtraArgs %q", cur.AMIType, cur.KubeletExtraArgs)
 			}
-		case fmt.Sprint(aws_eks_v2_types.AMITypesAl2X8664):
-			if cur.RemoteAccessUserName != "ec2-user" {
-				return fmt.Errorf("AMIType %q but unexpected RemoteAccessUserName %q", cur.AMIType, cur.RemoteAccessUserName)
+		case ec2config.AMIFamilyAL2023:
+			// "nodeadm" does not take "/etc/eks/bootstrap.sh" arguments
+			if cur.BootstrapArgs != "" {
+				return fmt.Errorf("AMIFamily %q but unexpected BootstrapArgs %q", cur.AMIFamily, cur.BootstrapArgs)
 			}
-		case fmt.Sprint(aws_eks_v2_types.AMITypesAl2Arm64):
-			if cur.RemoteAccessUserName != "ec2-user" {
-				return fmt.Errorf("AMIType %q but unexpected RemoteAccessUserName %q", cur.AMIType, cur.RemoteAccessUserName)
+		}
+		if cur.InstanceType == "" {
+			cur.InstanceType = defaultInstanceType(cur.AMIFamily, cur.AMIType)
+		}
+
+		switch cur.CapacityType {
+		case "", CapacityTypeOnDemand, CapacityTypeSpot: // empty defaults to On-Demand
+		default:
+			return fmt.Errorf("AddOnNodeGroups.ASGs[%q] unknown CapacityType %q", k, cur.CapacityType)
+		}
+		if cur.IsMixedInstances() {
+			if len(cur.InstanceTypes) == 0 {
+				cur.InstanceTypes = []string{cur.InstanceType}
 			}
-		case fmt.Sprint(aws_eks_v2_types.AMITypesAl2X8664Gpu):
-			if cur.RemoteAccessUserName != "ec2-user" {
-				return fmt.Errorf("AMIType %q but unexpected RemoteAccessUserName %q", cur.AMIType, cur.RemoteAccessUserName)
+			if cur.InstanceTypes[0] != cur.InstanceType {
+				// launch template instance type is overridden by the policy
+				cur.InstanceType = cur.InstanceTypes[0]
 			}
-		case ec2config.AMITypeWindowsServerCore2019X8664:
-			if cur.RemoteAccessUserName != "ec2-user" {
-				return fmt.Errorf("AMIType %q but unexpected RemoteAccessUserName %q", cur.AMIType, cur.RemoteAccessUserName)
+			if len(cur.InstanceTypes) > NGMixedInstanceTypesMaxLimit {
+				return fmt.Errorf("AddOnNodeGroups.ASGs[%q] too many InstanceTypes %d (limit %d)", k, len(cur.InstanceTypes), NGMixedInstanceTypesMaxLimit)
 			}
-		default:
-			return fmt.Errorf("unknown ASGs[%q].AMIType %q", k, cur.AMIType)
-		}
-
-		switch cur.AMIType {
-		case ec2config.AMITypeBottleRocketCPU:
-			if cur.InstanceType == "" {
-				cur.InstanceType = DefaultNodeInstanceTypeCPU
+			if cur.CapacityType != CapacityTypeSpot {
+				cur.OnDemandPercentageAboveBaseCapacity = 100
 			}
-		case fmt.Sprint(aws_eks_v2_types.AMITypesAl2X8664), ec2config.AMITypeWindowsServerCore2019X8664:
-			if cur.InstanceType == "" {
-				cur.InstanceType = DefaultNodeInstanceTypeCPU
+			if cur.OnDemandPercentageAboveBaseCapacity < 0 || cur.OnDemandPercentageAboveBaseCapacity > 100 {
+				return fmt.Errorf("AddOnNodeGroups.ASGs[%q] invalid OnDemandPercentageAboveBaseCapacity %d", k, cur.OnDemandPercentageAboveBaseCapacity)
 			}
-		case fmt.Sprint(aws_eks_v2_types.AMITypesAl2X8664Gpu):
-			if cur.InstanceType == "" {
-				cur.InstanceType = DefaultNodeInstanceTypeGPU
+			if cur.OnDemandBaseCapacity < 0 || cur.OnDemandBaseCapacity > cur.ASGMaxSize {
+				return fmt.Errorf("AddOnNodeGroups.ASGs[%q] invalid OnDemandBaseCapacity %d (ASGMaxSize %d)", k, cur.OnDemandBaseCapacity, cur.ASGMaxSize)
+			}
+			if cur.OnDemandAllocationStrategy == "" {
+				cur.OnDemandAllocationStrategy = "prioritized"
+			}
+			if cur.OnDemandAllocationStrategy != "prioritized" {
+				return fmt.Errorf("AddOnNodeGroups.ASGs[%q] unknown OnDemandAllocationStrategy %q", k, cur.OnDemandAllocationStrategy)
+			}
+			switch cur.SpotAllocationStrategy {
+			case "":
+				cur.SpotAllocationStrategy = "capacity-optimized"
+			case "capacity-optimized", "capacity-optimized-prioritized", "lowest-price":
+			default:
+				return fmt.Errorf("AddOnNodeGroups.ASGs[%q] unknown SpotAllocationStrategy %q", k, cur.SpotAllocationStrategy)
+			}
+		}
+
+		if cur.Placement != nil {
+			if err := cfg.validatePlacement(k, cur); err != nil {
+				return err
 			}
-		default:
-			return fmt.Errorf("unknown AddOnNodeGroups.ASGs[%q].AMIType %q", k, cur.AMIType)
 		}
 
 		if cfg.IsEnabledAddOnNLBHelloWorld() || cfg.IsEnabledAddOnALB2048() {
@@ -0,0 +1,136 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnOIDCIdentityProvider defines parameters for EKS cluster
+// add-on OIDC identity provider, associating an external OIDC issuer
+// with the cluster and verifying the "kubectl" access with its ID token.
+// If IssuerURL is empty, creates a Cognito user pool as the issuer.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/authenticate-oidc-identity-provider.html
+type AddOnOIDCIdentityProvider struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// ConfigName is the identity provider config name.
+	ConfigName string `json:"config-name"`
+
+	// IssuerURL is the external OIDC issuer URL (e.g. Dex, Okta).
+	// Leave empty to create a Cognito user pool as the issuer.
+	IssuerURL string `json:"issuer-url"`
+	// ClientID is the OIDC client ID for the external issuer.
+	// Required with IssuerURL.
+	ClientID string `json:"client-id"`
+	// IDTokenPath is the file path to the ID token issued by the
+	// external issuer to the test identity. Required with IssuerURL.
+	IDTokenPath string `json:"id-token-path"`
+
+	// UsernameClaim is the JWT claim to use as the username.
+	UsernameClaim string `json:"username-claim"`
+	// UsernamePrefix is the prefix prepended to the username claims.
+	UsernamePrefix string `json:"username-prefix"`
+	// GroupsClaim is the JWT claim to use as the user groups.
+	GroupsClaim string `json:"groups-claim"`
+	// GroupsPrefix is the prefix prepended to the group claims.
+	GroupsPrefix string `json:"groups-prefix"`
+	// Group is the test identity group, bound to the read-only
+	// ClusterRole for the verification (after GroupsPrefix).
+	Group string `json:"group"`
+
+	// UserPoolID is the Cognito user pool ID created for the test.
+	UserPoolID string `json:"user-pool-id" read-only:"true"`
+	// UserPoolClientID is the Cognito app client ID,
+	// used as the OIDC client ID.
+	UserPoolClientID string `json:"user-pool-client-id" read-only:"true"`
+	// Username is the Cognito test user name.
+	Username string `json:"username" read-only:"true"`
+	// Verified is true once the test identity listed the nodes.
+	Verified bool `json:"verified" read-only:"true"`
+}
+
+// EnvironmentVariablePrefixAddOnOIDCIdentityProvider is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnOIDCIdentityProvider = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_OIDC_IDENTITY_PROVIDER_"
+
+// IsEnabledAddOnOIDCIdentityProvider returns true if "AddOnOIDCIdentityProvider" is enabled.
+func (cfg *Config) IsEnabledAddOnOIDCIdentityProvider() bool {
+	if cfg.AddOnOIDCIdentityProvider == nil {
+		return false
+	}
+	if cfg.AddOnOIDCIdentityProvider.Enable {
+		return true
+	}
+	return false
+}
+
+func getDefaultAddOnOIDCIdentityProvider() *AddOnOIDCIdentityProvider {
+	return &AddOnOIDCIdentityProvider{
+		Enable:         false,
+		UsernameClaim:  "sub",
+		UsernamePrefix: "oidc:",
+		GroupsClaim:    "cognito:groups",
+		GroupsPrefix:   "oidc:",
+		Group:          "viewers",
+	}
+}
+
+// IsCognito returns true if the add-on creates a Cognito user pool as the issuer.
+func (a *AddOnOIDCIdentityProvider) IsCognito() bool {
+	return a.IssuerURL == ""
+}
+
+func (cfg *Config) validateAddOnOIDCIdentityProvider() error {
+	if !cfg.IsEnabledAddOnOIDCIdentityProvider() {
+		cfg.AddOnOIDCIdentityProvider = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnOIDCIdentityProvider.Enable true but no node group is enabled")
+	}
+
+	if cfg.AddOnOIDCIdentityProvider.ConfigName == "" {
+		cfg.AddOnOIDCIdentityProvider.ConfigName = cfg.Name + "-oidc"
+	}
+	if cfg.AddOnOIDCIdentityProvider.IssuerURL != "" {
+		if !strings.HasPrefix(cfg.AddOnOIDCIdentityProvider.IssuerURL, "https://") {
+			return fmt.Errorf("AddOnOIDCIdentityProvider.IssuerURL %q must be https", cfg.AddOnOIDCIdentityProvider.IssuerURL)
+		}
+		if cfg.AddOnOIDCIdentityProvider.ClientID == "" {
+			return errors.New("AddOnOIDCIdentityProvider.IssuerURL non-empty but empty ClientID")
+		}
+		if cfg.AddOnOIDCIdentityProvider.IDTokenPath == "" {
+			return errors.New("AddOnOIDCIdentityProvider.IssuerURL non-empty but empty IDTokenPath")
+		}
+	} else if cfg.AddOnOIDCIdentityProvider.ClientID != "" || cfg.AddOnOIDCIdentityProvider.IDTokenPath != "" {
+		return errors.New("AddOnOIDCIdentityProvider.ClientID and IDTokenPath require IssuerURL")
+	}
+
+	if cfg.AddOnOIDCIdentityProvider.UsernameClaim == "" {
+		cfg.AddOnOIDCIdentityProvider.UsernameClaim = "sub"
+	}
+	if cfg.AddOnOIDCIdentityProvider.GroupsClaim == "" {
+		cfg.AddOnOIDCIdentityProvider.GroupsClaim = "cognito:groups"
+	}
+	// EKS rejects the "system:" prefixes
+	// ref. https://docs.aws.amazon.com/eks/latest/APIReference/API_OidcIdentityProviderConfigRequest.html
+	if strings.HasPrefix(cfg.AddOnOIDCIdentityProvider.UsernamePrefix, "system:") {
+		return fmt.Errorf("AddOnOIDCIdentityProvider.UsernamePrefix %q not allowed", cfg.AddOnOIDCIdentityProvider.UsernamePrefix)
+	}
+	if strings.HasPrefix(cfg.AddOnOIDCIdentityProvider.GroupsPrefix, "system:") {
+		return fmt.Errorf("AddOnOIDCIdentityProvider.GroupsPrefix %q not allowed", cfg.AddOnOIDCIdentityProvider.GroupsPrefix)
+	}
+	if cfg.AddOnOIDCIdentityProvider.Group == "" {
+		cfg.AddOnOIDCIdentityProvider.Group = "viewers"
+	}
+
+	return nil
+}
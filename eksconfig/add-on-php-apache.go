@@ -43,7 +43,6 @@ type AddOnPHPApache struct {
 const EnvironmentVariablePrefixAddOnPHPApache = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_PHP_APACHE_"
 
 // IsEnabledAddOnPHPApache returns true if "AddOnPHPApache" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnPHPApache() bool {
 	if cfg.AddOnPHPApache == nil {
 		return false
@@ -51,7 +50,6 @@ func (cfg *Config) IsEnabledAddOnPHPApache() bool {
 	if cfg.AddOnPHPApache.Enable {
 		return true
 	}
-	cfg.AddOnPHPApache = nil
 	return false
 }
 
@@ -72,6 +70,7 @@ func (cfg *Config) GetAddOnPHPApacheRepositoryRegion() string {
 
 func (cfg *Config) validateAddOnPHPApache() error {
 	if !cfg.IsEnabledAddOnPHPApache() {
+		cfg.AddOnPHPApache = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
@@ -0,0 +1,160 @@
+package eksconfig
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/metrics"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnPodDensity defines parameters for EKS cluster
+// add-on pod density and IP address exhaustion tests, which schedule
+// Pods up to the kubelet max-pods limit on the nodes of each instance type,
+// verify every Pod is assigned a unique IP and is reachable, measure the
+// time to IP under Pod churn, and record the ENI and IP address allocation
+// from the VPC CNI ipamd metrics, to catch warm pool regressions.
+// With "CNIConfig" set, the max-pods and the ipamd allocation are checked
+// against the prefix delegation and the warm target configuration.
+// ref. https://github.com/aws/amazon-vpc-cni-k8s/blob/master/docs/eni-and-ip-target.md
+type AddOnPodDensity struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// Namespace is the namespace to create the pod density Pods in.
+	Namespace string `json:"namespace"`
+	// NodesPerInstanceType is the number of Ready nodes to fill
+	// per instance type.
+	NodesPerInstanceType int `json:"nodes-per-instance-type"`
+	// ChurnRounds is the number of times to delete and recreate
+	// all pod density Pods after the nodes are filled.
+	ChurnRounds int `json:"churn-rounds"`
+	// Timeout is the timeout for all pod density Pods to be available,
+	// for the initial fill and for each churn round.
+	Timeout       time.Duration `json:"timeout"`
+	TimeoutString string        `json:"timeout-string" read-only:"true"`
+
+	// Results is the list of the per-instance type results.
+	Results []PodDensityResult `json:"results" read-only:"true"`
+	// ResultsJSONPath is the path to write "Results".
+	ResultsJSONPath string `json:"results-json-path" read-only:"true"`
+}
+
+// PodDensityResult is the pod density result of an instance type.
+type PodDensityResult struct {
+	InstanceType string `json:"instance-type"`
+	// Nodes is the number of the filled nodes.
+	Nodes int `json:"nodes"`
+	// MaxPods is the minimum kubelet max-pods of the filled nodes.
+	MaxPods int64 `json:"max-pods"`
+	// ExpectedMaxPods is the kubelet max-pods expected with "CNIConfig",
+	// zero if not checked.
+	ExpectedMaxPods int64 `json:"expected-max-pods"`
+
+	// Pods is the number of the pod density Pods.
+	Pods int `json:"pods"`
+	// PodsReady is the number of the Ready pod density Pods
+	// after the last churn round.
+	PodsReady int `json:"pods-ready"`
+	// UniqueIPs is the number of the unique pod density Pod IPs.
+	UniqueIPs int `json:"unique-ips"`
+	// PodsReachable is the number of the pod density Pods
+	// reachable from another pod density Pod.
+	PodsReachable int `json:"pods-reachable"`
+
+	// TimeToIP maps the phase ("fill" or "churn") to the latencies from
+	// the Pod scheduling to the Pod sandbox network ready.
+	// The latencies are in seconds resolution, from the Pod status timestamps.
+	TimeToIP map[string]metrics.RequestsSummary `json:"time-to-ip,omitempty"`
+	// IPAM maps the node name to the ipamd metrics after the last churn round.
+	IPAM map[string]IPAMMetrics `json:"ipam,omitempty"`
+
+	// Error is the first failed check, empty if passed.
+	Error string `json:"error"`
+}
+
+// IPAMMetrics is the ENI and IP address allocation of a node,
+// reported by the VPC CNI ipamd "/metrics".
+type IPAMMetrics struct {
+	// ENIsAllocated is "awscni_eni_allocated".
+	ENIsAllocated int `json:"enis-allocated"`
+	// ENIsMax is "awscni_eni_max".
+	ENIsMax int `json:"enis-max"`
+	// IPsTotal is "awscni_total_ip_addresses", including the warm pool.
+	IPsTotal int `json:"ips-total"`
+	// IPsAssigned is "awscni_assigned_ip_addresses".
+	IPsAssigned int `json:"ips-assigned"`
+	// IPsMax is "awscni_ip_max".
+	IPsMax int `json:"ips-max"`
+}
+
+// EnvironmentVariablePrefixAddOnPodDensity is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnPodDensity = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_POD_DENSITY_"
+
+// IsEnabledAddOnPodDensity returns true if "AddOnPodDensity" is enabled.
+func (cfg *Config) IsEnabledAddOnPodDensity() bool {
+	if cfg.AddOnPodDensity == nil {
+		return false
+	}
+	if cfg.AddOnPodDensity.Enable {
+		return true
+	}
+	return false
+}
+
+const (
+	// DefaultPodDensityNodesPerInstanceType is the default number of the filled nodes per instance type.
+	DefaultPodDensityNodesPerInstanceType = 1
+	// DefaultPodDensityChurnRounds is the default number of the churn rounds.
+	DefaultPodDensityChurnRounds = 3
+	// DefaultPodDensityTimeout is the default timeout for all pod density Pods to be available.
+	DefaultPodDensityTimeout = 15 * time.Minute
+)
+
+func getDefaultAddOnPodDensity() *AddOnPodDensity {
+	return &AddOnPodDensity{
+		Enable:               false,
+		NodesPerInstanceType: DefaultPodDensityNodesPerInstanceType,
+		ChurnRounds:          DefaultPodDensityChurnRounds,
+		Timeout:              DefaultPodDensityTimeout,
+	}
+}
+
+func (cfg *Config) validateAddOnPodDensity() error {
+	if !cfg.IsEnabledAddOnPodDensity() {
+		cfg.AddOnPodDensity = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnPodDensity.Enable true but no node group is enabled")
+	}
+
+	cur := cfg.AddOnPodDensity
+	if cur.Namespace == "" {
+		cur.Namespace = cfg.Name + "-pod-density"
+	}
+	if cur.NodesPerInstanceType < 0 || cur.ChurnRounds < 0 {
+		return errors.New("AddOnPodDensity.NodesPerInstanceType and AddOnPodDensity.ChurnRounds must not be negative")
+	}
+	if cur.NodesPerInstanceType == 0 {
+		cur.NodesPerInstanceType = DefaultPodDensityNodesPerInstanceType
+	}
+	if cur.Timeout == time.Duration(0) {
+		cur.Timeout = DefaultPodDensityTimeout
+	}
+	cur.TimeoutString = cur.Timeout.String()
+
+	if cur.ResultsJSONPath == "" {
+		cur.ResultsJSONPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + "-pod-density-results.json"
+		os.RemoveAll(cur.ResultsJSONPath)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnPodIdentity defines parameters for EKS cluster
+// add-on "EKS Pod Identity", installing the Pod Identity Agent,
+// associating an IAM role with a service account, and verifying
+// that a Pod gets the role credentials without IRSA annotations.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/pod-identities.html
+type AddOnPodIdentity struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// Namespace is the namespace to create test objects in.
+	Namespace string `json:"namespace"`
+
+	// AgentVersion is the "eks-pod-identity-agent" add-on version.
+	// Leave empty to use the default version for the cluster.
+	AgentVersion string `json:"agent-version"`
+	// AgentCreated is true when the tester installed the agent add-on,
+	// to be deleted with the add-on (e.g. not via AddOnManagedAddOns).
+	AgentCreated bool `json:"agent-created" read-only:"true"`
+
+	// RoleName is the IAM role name for the Pod Identity association.
+	RoleName string `json:"role-name"`
+	// RoleARN is the IAM role ARN for the Pod Identity association.
+	RoleARN string `json:"role-arn" read-only:"true"`
+	// ServiceAccountName is the service account to associate with the role.
+	ServiceAccountName string `json:"service-account-name"`
+	// AssociationID is the Pod Identity association ID.
+	AssociationID string `json:"association-id" read-only:"true"`
+
+	// PodImage is the image for the verification Pod, with the AWS CLI.
+	PodImage string `json:"pod-image"`
+	// CallerARN is the caller identity ARN returned to the verification Pod.
+	CallerARN string `json:"caller-arn" read-only:"true"`
+}
+
+// EnvironmentVariablePrefixAddOnPodIdentity is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnPodIdentity = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_POD_IDENTITY_"
+
+// IsEnabledAddOnPodIdentity returns true if "AddOnPodIdentity" is enabled.
+func (cfg *Config) IsEnabledAddOnPodIdentity() bool {
+	if cfg.AddOnPodIdentity == nil {
+		return false
+	}
+	if cfg.AddOnPodIdentity.Enable {
+		return true
+	}
+	return false
+}
+
+// DefaultPodIdentityPodImage is the default verification Pod image,
+// with the AWS CLI that supports the Pod Identity credentials.
+const DefaultPodIdentityPodImage = "public.ecr.aws/aws-cli/aws-cli:2.15.30"
+
+// PodIdentityMinVersion is the minimum Kubernetes version
+// with the EKS Pod Identity support.
+const PodIdentityMinVersion = 1.24
+
+func getDefaultAddOnPodIdentity() *AddOnPodIdentity {
+	return &AddOnPodIdentity{
+		Enable:             false,
+		ServiceAccountName: "pod-identity",
+		PodImage:           DefaultPodIdentityPodImage,
+	}
+}
+
+func (cfg *Config) validateAddOnPodIdentity() error {
+	if !cfg.IsEnabledAddOnPodIdentity() {
+		cfg.AddOnPodIdentity = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnPodIdentity.Enable true but no node group is enabled")
+	}
+	if cfg.VersionValue < PodIdentityMinVersion {
+		return fmt.Errorf("Version %q not supported for AddOnPodIdentity (requires >= %.2f)", cfg.Version, PodIdentityMinVersion)
+	}
+
+	if cfg.AddOnPodIdentity.Namespace == "" {
+		cfg.AddOnPodIdentity.Namespace = cfg.Name + "-pod-identity"
+	}
+	if cfg.AddOnPodIdentity.RoleName == "" {
+		cfg.AddOnPodIdentity.RoleName = cfg.Name + "-pod-identity-role"
+	}
+	if cfg.AddOnPodIdentity.ServiceAccountName == "" {
+		cfg.AddOnPodIdentity.ServiceAccountName = "pod-identity"
+	}
+	if cfg.AddOnPodIdentity.PodImage == "" {
+		cfg.AddOnPodIdentity.PodImage = DefaultPodIdentityPodImage
+	}
+
+	return nil
+}
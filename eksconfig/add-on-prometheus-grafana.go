@@ -39,7 +39,6 @@ type AddOnPrometheusGrafana struct {
 const EnvironmentVariablePrefixAddOnPrometheusGrafana = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_PROMETHEUS_GRAFANA_"
 
 // IsEnabledAddOnPrometheusGrafana returns true if "AddOnPrometheusGrafana" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnPrometheusGrafana() bool {
 	if cfg.AddOnPrometheusGrafana == nil {
 		return false
@@ -47,7 +46,6 @@ func (cfg *Config) IsEnabledAddOnPrometheusGrafana() bool {
 	if cfg.AddOnPrometheusGrafana.Enable {
 		return true
 	}
-	cfg.AddOnPrometheusGrafana = nil
 	return false
 }
 
@@ -61,6 +59,7 @@ func getDefaultAddOnPrometheusGrafana() *AddOnPrometheusGrafana {
 
 func (cfg *Config) validateAddOnPrometheusGrafana() error {
 	if !cfg.IsEnabledAddOnPrometheusGrafana() {
+		cfg.AddOnPrometheusGrafana = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnCSIEBS() {
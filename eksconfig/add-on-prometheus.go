@@ -0,0 +1,133 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnPrometheus defines parameters for EKS cluster
+// add-on kube-prometheus-stack, which installs Prometheus,
+// node-exporter, and kube-state-metrics via Helm, and waits for
+// the scrape targets to be healthy. At test end, key metrics are
+// snapshotted into the artifact directory for cross-run comparison.
+// ref. https://github.com/prometheus-community/helm-charts/tree/main/charts/kube-prometheus-stack
+type AddOnPrometheus struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// Namespace is the namespace to install the chart in.
+	Namespace string `json:"namespace"`
+	// ChartVersion is the kube-prometheus-stack chart version.
+	ChartVersion string `json:"chart-version"`
+
+	// ScrapeJobs are the scrape jobs that must have all targets healthy.
+	ScrapeJobs []string `json:"scrape-jobs"`
+	// TargetsTimeout is the timeout to wait for the scrape targets
+	// to be healthy.
+	TargetsTimeout       time.Duration `json:"targets-timeout"`
+	TargetsTimeoutString string        `json:"targets-timeout-string" read-only:"true"`
+
+	// SnapshotQueries maps a metric name to the PromQL instant query
+	// to snapshot at test end.
+	SnapshotQueries map[string]string `json:"snapshot-queries"`
+	// SnapshotPath is the latest metrics snapshot written
+	// to the artifact directory.
+	SnapshotPath string `json:"snapshot-path" read-only:"true"`
+}
+
+// EnvironmentVariablePrefixAddOnPrometheus is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnPrometheus = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_PROMETHEUS_"
+
+// IsEnabledAddOnPrometheus returns true if "AddOnPrometheus" is enabled.
+func (cfg *Config) IsEnabledAddOnPrometheus() bool {
+	if cfg.AddOnPrometheus == nil {
+		return false
+	}
+	if cfg.AddOnPrometheus.Enable {
+		return true
+	}
+	return false
+}
+
+const (
+	// DefaultPrometheusNamespace is the default namespace for kube-prometheus-stack.
+	DefaultPrometheusNamespace = "monitoring"
+	// DefaultPrometheusChartVersion is the default kube-prometheus-stack chart version.
+	DefaultPrometheusChartVersion = "51.2.0"
+	// DefaultPrometheusTargetsTimeout is the default scrape targets timeout.
+	DefaultPrometheusTargetsTimeout = 10 * time.Minute
+)
+
+// DefaultPrometheusScrapeJobs are the scrape jobs that are reachable
+// on EKS; the managed control plane components other than
+// kube-apiserver are not scrapable.
+var DefaultPrometheusScrapeJobs = []string{
+	"apiserver",
+	"kubelet",
+	"node-exporter",
+	"kube-state-metrics",
+}
+
+// DefaultPrometheusSnapshotQueries are the metrics to snapshot by default.
+var DefaultPrometheusSnapshotQueries = map[string]string{
+	"apiserver-request-latency-p99-seconds": `histogram_quantile(0.99, sum(rate(apiserver_request_duration_seconds_bucket{verb!~"WATCH|CONNECT"}[5m])) by (le, verb))`,
+	"apiserver-request-rate":                `sum(rate(apiserver_request_total[5m])) by (code)`,
+	"node-count":                            `count(kube_node_info)`,
+	"node-ready-count":                      `sum(kube_node_status_condition{condition="Ready",status="true"})`,
+	"pod-count":                             `count(kube_pod_info)`,
+}
+
+func getDefaultAddOnPrometheus() *AddOnPrometheus {
+	return &AddOnPrometheus{
+		Enable:         false,
+		Namespace:      DefaultPrometheusNamespace,
+		ChartVersion:   DefaultPrometheusChartVersion,
+		ScrapeJobs:     append([]string(nil), DefaultPrometheusScrapeJobs...),
+		TargetsTimeout: DefaultPrometheusTargetsTimeout,
+	}
+}
+
+func (cfg *Config) validateAddOnPrometheus() error {
+	if !cfg.IsEnabledAddOnPrometheus() {
+		cfg.AddOnPrometheus = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnPrometheus.Enable true but no node group is enabled")
+	}
+
+	if cfg.AddOnPrometheus.Namespace == "" {
+		cfg.AddOnPrometheus.Namespace = DefaultPrometheusNamespace
+	}
+	if cfg.AddOnPrometheus.ChartVersion == "" {
+		cfg.AddOnPrometheus.ChartVersion = DefaultPrometheusChartVersion
+	}
+	if len(cfg.AddOnPrometheus.ScrapeJobs) == 0 {
+		cfg.AddOnPrometheus.ScrapeJobs = append([]string(nil), DefaultPrometheusScrapeJobs...)
+	}
+	if cfg.AddOnPrometheus.TargetsTimeout == time.Duration(0) {
+		cfg.AddOnPrometheus.TargetsTimeout = DefaultPrometheusTargetsTimeout
+	}
+	cfg.AddOnPrometheus.TargetsTimeoutString = cfg.AddOnPrometheus.TargetsTimeout.String()
+
+	if len(cfg.AddOnPrometheus.SnapshotQueries) == 0 {
+		cfg.AddOnPrometheus.SnapshotQueries = make(map[string]string, len(DefaultPrometheusSnapshotQueries))
+		for k, v := range DefaultPrometheusSnapshotQueries {
+			cfg.AddOnPrometheus.SnapshotQueries[k] = v
+		}
+	}
+	for k, v := range cfg.AddOnPrometheus.SnapshotQueries {
+		if v == "" {
+			return fmt.Errorf("empty AddOnPrometheus.SnapshotQueries[%q]", k)
+		}
+	}
+	return nil
+}
@@ -2,9 +2,11 @@ package eksconfig
 
 import (
 	"errors"
+	"fmt"
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-k8s-tester/pkg/metrics"
 	"github.com/aws/aws-k8s-tester/pkg/randutil"
@@ -44,6 +46,27 @@ type AddOnSecretsLocal struct {
 	// this must be unique per worker to avoid name conflicts.
 	NamePrefix string `json:"name-prefix"`
 
+	// MountPods is the number of Pods that mount the written Secrets,
+	// one Secret per Pod, to verify the Secret values through kubelet.
+	// 0 to skip.
+	MountPods int `json:"mount-pods"`
+	// MountPodImage is the image of the Pods that mount the Secrets.
+	MountPodImage string `json:"mount-pod-image"`
+	// MountLatency is the time for all "MountPods" to verify the Secrets.
+	MountLatency       time.Duration `json:"mount-latency" read-only:"true"`
+	MountLatencyString string        `json:"mount-latency-string" read-only:"true"`
+
+	// APIServerMemoryBefore is the kube-apiserver resident memory in bytes
+	// before writing the Secrets, scraped from the kube-apiserver instance
+	// that serves the "/metrics" request.
+	APIServerMemoryBefore uint64 `json:"apiserver-memory-before" read-only:"true"`
+	// APIServerMemoryAfter is the kube-apiserver resident memory in bytes
+	// after writing and reading the Secrets.
+	APIServerMemoryAfter uint64 `json:"apiserver-memory-after" read-only:"true"`
+	// EnvelopeEncryptionVerified is true when the KMS envelope encryption
+	// is enabled, and the writes generated the data encryption keys.
+	EnvelopeEncryptionVerified bool `json:"envelope-encryption-verified" read-only:"true"`
+
 	//////////////////////////////////////////////////////////////////////////////
 
 	RequestsRawWritesJSONPath  string `json:"requests-raw-writes-json-path" read-only:"true"`
@@ -127,7 +150,6 @@ type AddOnSecretsLocal struct {
 const EnvironmentVariablePrefixAddOnSecretsLocal = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_SECRETS_LOCAL_"
 
 // IsEnabledAddOnSecretsLocal returns true if "AddOnSecretsLocal" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnSecretsLocal() bool {
 	if cfg.AddOnSecretsLocal == nil {
 		return false
@@ -135,7 +157,6 @@ func (cfg *Config) IsEnabledAddOnSecretsLocal() bool {
 	if cfg.AddOnSecretsLocal.Enable {
 		return true
 	}
-	cfg.AddOnSecretsLocal = nil
 	return false
 }
 
@@ -155,11 +176,18 @@ func getDefaultAddOnSecretsLocal() *AddOnSecretsLocal {
 		// ObjectSize: 10 * 1024, // 10 KB
 
 		NamePrefix: "secret" + randutil.String(5),
+
+		MountPods:     3,
+		MountPodImage: DefaultSecretsMountPodImage,
 	}
 }
 
+// DefaultSecretsMountPodImage is the default image of the Pods that mount the Secrets.
+const DefaultSecretsMountPodImage = "busybox:1.36"
+
 func (cfg *Config) validateAddOnSecretsLocal() error {
 	if !cfg.IsEnabledAddOnSecretsLocal() {
+		cfg.AddOnSecretsLocal = nil
 		return nil
 	}
 
@@ -186,6 +214,13 @@ func (cfg *Config) validateAddOnSecretsLocal() error {
 		cfg.AddOnSecretsLocal.NamePrefix = "secret" + randutil.String(5)
 	}
 
+	if cfg.AddOnSecretsLocal.MountPods < 0 || cfg.AddOnSecretsLocal.MountPods > cfg.AddOnSecretsLocal.Objects {
+		return fmt.Errorf("invalid AddOnSecretsLocal.MountPods %d (objects %d)", cfg.AddOnSecretsLocal.MountPods, cfg.AddOnSecretsLocal.Objects)
+	}
+	if cfg.AddOnSecretsLocal.MountPodImage == "" {
+		cfg.AddOnSecretsLocal.MountPodImage = DefaultSecretsMountPodImage
+	}
+
 	//////////////////////////////////////////////////////////////////////////////
 	if cfg.AddOnSecretsLocal.RequestsRawWritesJSONPath == "" {
 		cfg.AddOnSecretsLocal.RequestsRawWritesJSONPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + "-secrets-local-requests-writes-raw.json"
@@ -154,7 +154,6 @@ type AddOnSecretsRemote struct {
 const EnvironmentVariablePrefixAddOnSecretsRemote = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_SECRETS_REMOTE_"
 
 // IsEnabledAddOnSecretsRemote returns true if "AddOnSecretsRemote" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnSecretsRemote() bool {
 	if cfg.AddOnSecretsRemote == nil {
 		return false
@@ -162,7 +161,6 @@ func (cfg *Config) IsEnabledAddOnSecretsRemote() bool {
 	if cfg.AddOnSecretsRemote.Enable {
 		return true
 	}
-	cfg.AddOnSecretsRemote = nil
 	return false
 }
 
@@ -199,6 +197,7 @@ func (cfg *Config) GetAddOnSecretsRemoteRepositoryRegion() string {
 
 func (cfg *Config) validateAddOnSecretsRemote() error {
 	if !cfg.IsEnabledAddOnSecretsRemote() {
+		cfg.AddOnSecretsRemote = nil
 		return nil
 	}
 
@@ -0,0 +1,200 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/metrics"
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnServiceChurn defines parameters for EKS cluster
+// add-on Service and endpoint churn benchmark, which repeatedly creates
+// the Services, switches their backends, and deletes the backends,
+// to stress kube-proxy and the EndpointSlice controller.
+// Each change is measured from the EndpointSlice update to the data-path
+// convergence on the nodes, probed with the node commands over
+// SSM Run Command or SSH. The latencies compare the tester and the node
+// clocks, so assume the clocks are synchronized (e.g. NTP).
+type AddOnServiceChurn struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// Namespace is the namespace of the Services and the backend Pods.
+	Namespace string `json:"namespace"`
+
+	// NodeGroupName is the name of the node group to probe the data-path,
+	// either in "AddOnNodeGroups.ASGs" or "AddOnManagedNodeGroups.MNGs".
+	// If empty, any worker node may be selected.
+	NodeGroupName string `json:"node-group-name"`
+	// Nodes is the number of the nodes to probe the data-path.
+	Nodes int `json:"nodes"`
+	// Transport is how to run the probes on the nodes,
+	// either "ssm" (SSM Run Command) or "ssh".
+	Transport string `json:"transport"`
+
+	// Rounds is the number of the churn rounds, each of which creates
+	// and deletes "Services".
+	Rounds int `json:"rounds"`
+	// Services is the number of the Services per round.
+	Services int `json:"services"`
+	// BackendsPerService is the number of the backend Pods per Service.
+	BackendsPerService int `json:"backends-per-service"`
+	// ProbeStartDelay is the delay between starting the node probes and
+	// applying each change, long enough for the probes to start
+	// (e.g. SSM Run Command dispatch).
+	ProbeStartDelay       time.Duration `json:"probe-start-delay"`
+	ProbeStartDelayString string        `json:"probe-start-delay-string" read-only:"true"`
+	// ConvergenceTimeout is the timeout for the endpoints and the data-path
+	// to converge after each change.
+	ConvergenceTimeout       time.Duration `json:"convergence-timeout"`
+	ConvergenceTimeoutString string        `json:"convergence-timeout-string" read-only:"true"`
+
+	// Results is the list of the per-phase results across the rounds.
+	Results []ServiceChurnResult `json:"results" read-only:"true"`
+	// ResultsJSONPath is the JSON output of "Results".
+	ResultsJSONPath string `json:"results-json-path" read-only:"true"`
+}
+
+// Service churn phases.
+const (
+	// ServiceChurnPhaseCreate scales up the backends of the new Services.
+	ServiceChurnPhaseCreate = "create"
+	// ServiceChurnPhaseUpdate switches the Service selectors
+	// to the other backends.
+	ServiceChurnPhaseUpdate = "update"
+	// ServiceChurnPhaseDelete scales down the backends.
+	ServiceChurnPhaseDelete = "delete"
+)
+
+// ServiceChurnResult is the result of a churn phase across the rounds.
+type ServiceChurnResult struct {
+	Phase string `json:"phase"`
+	// EndpointLatency is the time from the change to the EndpointSlice update,
+	// per Service.
+	EndpointLatency metrics.RequestsSummary `json:"endpoint-latency"`
+	// ConvergenceLatency is the time from the EndpointSlice update to
+	// the data-path convergence, per Service per node.
+	ConvergenceLatency metrics.RequestsSummary `json:"convergence-latency"`
+	// Timeouts is the number of the Service and node pairs
+	// not converged in "ConvergenceTimeout".
+	Timeouts int `json:"timeouts"`
+	// Errors is the list of the errors, empty if passed.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// EnvironmentVariablePrefixAddOnServiceChurn is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnServiceChurn = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_SERVICE_CHURN_"
+
+// IsEnabledAddOnServiceChurn returns true if "AddOnServiceChurn" is enabled.
+func (cfg *Config) IsEnabledAddOnServiceChurn() bool {
+	if cfg.AddOnServiceChurn == nil {
+		return false
+	}
+	if cfg.AddOnServiceChurn.Enable {
+		return true
+	}
+	return false
+}
+
+const (
+	// DefaultServiceChurnRounds is the default number of the churn rounds.
+	DefaultServiceChurnRounds = 3
+	// DefaultServiceChurnServices is the default number of the Services per round.
+	DefaultServiceChurnServices = 10
+	// DefaultServiceChurnBackendsPerService is the default number of the backend Pods per Service.
+	DefaultServiceChurnBackendsPerService = 2
+	// DefaultServiceChurnProbeStartDelay is the default delay for the node probes to start.
+	DefaultServiceChurnProbeStartDelay = 30 * time.Second
+	// DefaultServiceChurnConvergenceTimeout is the default convergence timeout.
+	DefaultServiceChurnConvergenceTimeout = 2 * time.Minute
+
+	// maxServiceChurnServices bounds the node probe output,
+	// since SSM Run Command truncates the output.
+	maxServiceChurnServices = 100
+)
+
+func getDefaultAddOnServiceChurn() *AddOnServiceChurn {
+	return &AddOnServiceChurn{
+		Enable:             false,
+		Nodes:              1,
+		Transport:          NodeTransportSSM,
+		Rounds:             DefaultServiceChurnRounds,
+		Services:           DefaultServiceChurnServices,
+		BackendsPerService: DefaultServiceChurnBackendsPerService,
+		ProbeStartDelay:    DefaultServiceChurnProbeStartDelay,
+		ConvergenceTimeout: DefaultServiceChurnConvergenceTimeout,
+	}
+}
+
+func (cfg *Config) validateAddOnServiceChurn() error {
+	if !cfg.IsEnabledAddOnServiceChurn() {
+		cfg.AddOnServiceChurn = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnServiceChurn.Enable true but no node group is enabled")
+	}
+
+	cur := cfg.AddOnServiceChurn
+	if cur.Namespace == "" {
+		cur.Namespace = cfg.Name + "-service-churn"
+	}
+	if cur.NodeGroupName != "" {
+		found := false
+		if cfg.IsEnabledAddOnNodeGroups() {
+			_, found = cfg.AddOnNodeGroups.ASGs[cur.NodeGroupName]
+		}
+		if !found && cfg.IsEnabledAddOnManagedNodeGroups() {
+			_, found = cfg.AddOnManagedNodeGroups.MNGs[cur.NodeGroupName]
+		}
+		if !found {
+			return fmt.Errorf("AddOnServiceChurn.NodeGroupName %q not found", cur.NodeGroupName)
+		}
+	}
+	if cur.Nodes == 0 {
+		cur.Nodes = 1
+	}
+	if cur.Nodes < 0 {
+		return fmt.Errorf("AddOnServiceChurn.Nodes %d invalid", cur.Nodes)
+	}
+	if err := cfg.validateNodeTransport("AddOnServiceChurn.Transport", &cur.Transport); err != nil {
+		return err
+	}
+
+	if cur.Rounds <= 0 {
+		cur.Rounds = DefaultServiceChurnRounds
+	}
+	if cur.Services <= 0 {
+		cur.Services = DefaultServiceChurnServices
+	}
+	if cur.Services > maxServiceChurnServices {
+		return fmt.Errorf("AddOnServiceChurn.Services %d > %d", cur.Services, maxServiceChurnServices)
+	}
+	if cur.BackendsPerService <= 0 {
+		cur.BackendsPerService = DefaultServiceChurnBackendsPerService
+	}
+	if cur.ProbeStartDelay == time.Duration(0) {
+		cur.ProbeStartDelay = DefaultServiceChurnProbeStartDelay
+	}
+	cur.ProbeStartDelayString = cur.ProbeStartDelay.String()
+	if cur.ConvergenceTimeout == time.Duration(0) {
+		cur.ConvergenceTimeout = DefaultServiceChurnConvergenceTimeout
+	}
+	cur.ConvergenceTimeoutString = cur.ConvergenceTimeout.String()
+
+	if cur.ResultsJSONPath == "" {
+		cur.ResultsJSONPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + "-service-churn-results.json"
+		os.RemoveAll(cur.ResultsJSONPath)
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnSpotInterruption defines parameters for EKS cluster
+// add-on Spot node group capacity and interruption handling tests.
+// ref. https://docs.aws.amazon.com/fis/latest/userguide/fis-actions-reference.html#send-spot-instance-interruptions
+type AddOnSpotInterruption struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+	TimeFrameDelete timeutil.TimeFrame `json:"time-frame-delete" read-only:"true"`
+
+	// NodeGroupName is the name of the "SPOT" node group to test,
+	// either in "AddOnNodeGroups.ASGs" or "AddOnManagedNodeGroups.MNGs".
+	// If empty, defaults to the first "SPOT" node group.
+	// Self-managed node groups require an interruption handler
+	// (e.g. aws-node-termination-handler) to drain the nodes.
+	NodeGroupName string `json:"node-group-name"`
+	// InstanceIDs is the IDs of the Spot instances in the node group to interrupt.
+	// If empty, interrupts the Spot node running the most workload Pods.
+	InstanceIDs []string `json:"instance-ids"`
+	// TerminationHandlerName is the name of the interruption handler
+	// DaemonSet or Deployment in "kube-system", verified to be available
+	// before the interruption. Defaults to "aws-node-termination-handler"
+	// for self-managed node groups. If empty, the handler is not verified.
+	TerminationHandlerName string `json:"termination-handler-name"`
+
+	// Namespace is the namespace to run the workload in.
+	Namespace string `json:"namespace"`
+	// DeploymentReplicas is the number of replicas of the workload
+	// running on the node group, which must be rescheduled on interruption.
+	DeploymentReplicas int32 `json:"deployment-replicas"`
+
+	// SimulateInterruption is 'true' to send a simulated Spot interruption
+	// to a node via AWS Fault Injection Simulator (FIS), and verify the node
+	// is gracefully drained. Otherwise, only verifies the Spot capacity.
+	SimulateInterruption bool `json:"simulate-interruption"`
+	// DurationBeforeInterruption is the time between the interruption notice
+	// and the instance interruption. Must be at least 2 minutes.
+	DurationBeforeInterruption       time.Duration `json:"duration-before-interruption"`
+	DurationBeforeInterruptionString string        `json:"duration-before-interruption-string" read-only:"true"`
+	// DrainTimeout is the timeout for the interrupted node to be drained,
+	// and the workload to be available on the other nodes.
+	DrainTimeout       time.Duration `json:"drain-timeout"`
+	DrainTimeoutString string        `json:"drain-timeout-string" read-only:"true"`
+
+	// RoleName is the IAM role name for FIS to run the experiment.
+	RoleName string `json:"role-name"`
+	// RoleARN is the IAM role ARN for FIS to run the experiment.
+	RoleARN string `json:"role-arn" read-only:"true"`
+	// ExperimentTemplateID is the FIS experiment template ID.
+	ExperimentTemplateID string `json:"experiment-template-id" read-only:"true"`
+	// ExperimentID is the FIS experiment ID.
+	ExperimentID string `json:"experiment-id" read-only:"true"`
+
+	// InterruptedInstanceID is the ID of the interrupted instance.
+	InterruptedInstanceID string `json:"interrupted-instance-id" read-only:"true"`
+	// InterruptedNodeName is the name of the interrupted node.
+	InterruptedNodeName string `json:"interrupted-node-name" read-only:"true"`
+	// TimeFrameDrain is the time taken from the interruption
+	// until the node is drained and the workload is available.
+	TimeFrameDrain timeutil.TimeFrame `json:"time-frame-drain" read-only:"true"`
+	// Interruptions is the drain measured for each interrupted instance.
+	Interruptions []SpotInterruptionResult `json:"interruptions" read-only:"true"`
+}
+
+// SpotInterruptionResult is the drain measured for an interrupted instance,
+// from the interruption notice.
+type SpotInterruptionResult struct {
+	InstanceID string `json:"instance-id"`
+	NodeName   string `json:"node-name"`
+	// CordonLatency is the time until the node was cordoned.
+	CordonLatency       time.Duration `json:"cordon-latency"`
+	CordonLatencyString string        `json:"cordon-latency-string"`
+	// DrainLatency is the time until no workload Pod was left on the node.
+	DrainLatency       time.Duration `json:"drain-latency"`
+	DrainLatencyString string        `json:"drain-latency-string"`
+	// Graceful is true if the node was drained before the instance interruption.
+	Graceful bool `json:"graceful"`
+	// EvictedPods is the number of workload Pods evicted from the node.
+	EvictedPods int `json:"evicted-pods"`
+	// PodRecoveryTimes is the time from each eviction until a replacement Pod was ready.
+	PodRecoveryTimes []time.Duration `json:"pod-recovery-times"`
+	// PodRecoveryTimeMax is the longest of "PodRecoveryTimes".
+	PodRecoveryTimeMax       time.Duration `json:"pod-recovery-time-max"`
+	PodRecoveryTimeMaxString string        `json:"pod-recovery-time-max-string"`
+}
+
+// EnvironmentVariablePrefixAddOnSpotInterruption is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnSpotInterruption = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_SPOT_INTERRUPTION_"
+
+// IsEnabledAddOnSpotInterruption returns true if "AddOnSpotInterruption" is enabled.
+func (cfg *Config) IsEnabledAddOnSpotInterruption() bool {
+	if cfg.AddOnSpotInterruption == nil {
+		return false
+	}
+	if cfg.AddOnSpotInterruption.Enable {
+		return true
+	}
+	return false
+}
+
+func getDefaultAddOnSpotInterruption() *AddOnSpotInterruption {
+	return &AddOnSpotInterruption{
+		Enable:                     false,
+		DeploymentReplicas:         DefaultSpotInterruptionDeploymentReplicas,
+		SimulateInterruption:       false,
+		DurationBeforeInterruption: 2 * time.Minute,
+		DrainTimeout:               10 * time.Minute,
+	}
+}
+
+const (
+	// DefaultSpotInterruptionDeploymentReplicas is the default number of workload replicas.
+	DefaultSpotInterruptionDeploymentReplicas = 2
+	// DefaultSpotInterruptionTerminationHandlerName is the default interruption handler
+	// for self-managed node groups.
+	// ref. https://github.com/aws/aws-node-termination-handler
+	DefaultSpotInterruptionTerminationHandlerName = "aws-node-termination-handler"
+)
+
+// SpotNodeGroupNames returns the sorted names of the node groups
+// with "SPOT" capacity type, and whether each is a managed node group.
+func (cfg *Config) SpotNodeGroupNames() (names []string, managed map[string]bool) {
+	managed = make(map[string]bool)
+	if cfg.IsEnabledAddOnNodeGroups() {
+		for name, cur := range cfg.AddOnNodeGroups.ASGs {
+			if cur.CapacityType == CapacityTypeSpot {
+				names = append(names, name)
+			}
+		}
+	}
+	if cfg.IsEnabledAddOnManagedNodeGroups() {
+		for name, cur := range cfg.AddOnManagedNodeGroups.MNGs {
+			if cur.CapacityType == CapacityTypeSpot {
+				names = append(names, name)
+				managed[name] = true
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, managed
+}
+
+func (cfg *Config) validateAddOnSpotInterruption() error {
+	if !cfg.IsEnabledAddOnSpotInterruption() {
+		cfg.AddOnSpotInterruption = nil
+		return nil
+	}
+
+	names, managed := cfg.SpotNodeGroupNames()
+	if len(names) == 0 {
+		return errors.New("AddOnSpotInterruption.Enable true but no node group with CapacityType SPOT")
+	}
+	if cfg.AddOnSpotInterruption.NodeGroupName == "" {
+		cfg.AddOnSpotInterruption.NodeGroupName = names[0]
+	}
+	found := false
+	for _, name := range names {
+		if name == cfg.AddOnSpotInterruption.NodeGroupName {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("AddOnSpotInterruption.NodeGroupName %q is not a SPOT node group (%q)", cfg.AddOnSpotInterruption.NodeGroupName, names)
+	}
+
+	seen := make(map[string]struct{})
+	for _, id := range cfg.AddOnSpotInterruption.InstanceIDs {
+		if !strings.HasPrefix(id, "i-") {
+			return fmt.Errorf("AddOnSpotInterruption.InstanceIDs %q invalid", id)
+		}
+		if _, ok := seen[id]; ok {
+			return fmt.Errorf("AddOnSpotInterruption.InstanceIDs %q duplicate", id)
+		}
+		seen[id] = struct{}{}
+	}
+	if len(cfg.AddOnSpotInterruption.InstanceIDs) > 0 && !cfg.AddOnSpotInterruption.SimulateInterruption {
+		return errors.New("AddOnSpotInterruption.InstanceIDs requires SimulateInterruption true")
+	}
+	if cfg.AddOnSpotInterruption.TerminationHandlerName == "" && !managed[cfg.AddOnSpotInterruption.NodeGroupName] {
+		cfg.AddOnSpotInterruption.TerminationHandlerName = DefaultSpotInterruptionTerminationHandlerName
+	}
+
+	if cfg.AddOnSpotInterruption.Namespace == "" {
+		cfg.AddOnSpotInterruption.Namespace = cfg.Name + "-spot-interruption"
+	}
+	if cfg.AddOnSpotInterruption.DeploymentReplicas == 0 {
+		cfg.AddOnSpotInterruption.DeploymentReplicas = DefaultSpotInterruptionDeploymentReplicas
+	}
+	if cfg.AddOnSpotInterruption.DeploymentReplicas < 0 {
+		return fmt.Errorf("AddOnSpotInterruption.DeploymentReplicas %d invalid", cfg.AddOnSpotInterruption.DeploymentReplicas)
+	}
+
+	if cfg.AddOnSpotInterruption.DurationBeforeInterruption == time.Duration(0) {
+		cfg.AddOnSpotInterruption.DurationBeforeInterruption = 2 * time.Minute
+	}
+	if cfg.AddOnSpotInterruption.DurationBeforeInterruption < 2*time.Minute {
+		return fmt.Errorf("AddOnSpotInterruption.DurationBeforeInterruption %v too short (must be >=2m)", cfg.AddOnSpotInterruption.DurationBeforeInterruption)
+	}
+	cfg.AddOnSpotInterruption.DurationBeforeInterruptionString = cfg.AddOnSpotInterruption.DurationBeforeInterruption.String()
+	if cfg.AddOnSpotInterruption.DrainTimeout == time.Duration(0) {
+		cfg.AddOnSpotInterruption.DrainTimeout = 10 * time.Minute
+	}
+	cfg.AddOnSpotInterruption.DrainTimeoutString = cfg.AddOnSpotInterruption.DrainTimeout.String()
+
+	if cfg.AddOnSpotInterruption.RoleName == "" {
+		cfg.AddOnSpotInterruption.RoleName = cfg.Name + "-add-on-spot-interruption-fis-role"
+	}
+
+	return nil
+}
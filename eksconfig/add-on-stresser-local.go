@@ -124,7 +124,6 @@ type AddOnStresserLocal struct {
 const EnvironmentVariablePrefixAddOnStresserLocal = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_STRESSER_LOCAL_"
 
 // IsEnabledAddOnStresserLocal returns true if "AddOnStresserLocal" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnStresserLocal() bool {
 	if cfg.AddOnStresserLocal == nil {
 		return false
@@ -132,7 +131,6 @@ func (cfg *Config) IsEnabledAddOnStresserLocal() bool {
 	if cfg.AddOnStresserLocal.Enable {
 		return true
 	}
-	cfg.AddOnStresserLocal = nil
 	return false
 }
 
@@ -147,6 +145,7 @@ func getDefaultAddOnStresserLocal() *AddOnStresserLocal {
 
 func (cfg *Config) validateAddOnStresserLocal() error {
 	if !cfg.IsEnabledAddOnStresserLocal() {
+		cfg.AddOnStresserLocal = nil
 		return nil
 	}
 
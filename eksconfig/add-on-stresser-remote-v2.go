@@ -70,7 +70,6 @@ type AddOnStresserRemoteV2 struct {
 const EnvironmentVariablePrefixAddOnStresserRemoteV2 = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_STRESSER_REMOTE_V2_"
 
 // IsEnabledAddOnStresserRemote returns true if "AddOnStresserRemote" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnStresserRemoteV2() bool {
 	if cfg.AddOnStresserRemoteV2 == nil {
 		return false
@@ -78,7 +77,6 @@ func (cfg *Config) IsEnabledAddOnStresserRemoteV2() bool {
 	if cfg.AddOnStresserRemoteV2.Enable {
 		return true
 	}
-	cfg.AddOnStresserRemoteV2 = nil
 	return false
 }
 
@@ -106,6 +104,7 @@ func (cfg *Config) GetAddOnStresserRemoteV2RepositoryRegion() string {
 
 func (cfg *Config) validateAddOnStresserRemoteV2() error {
 	if !cfg.IsEnabledAddOnStresserRemoteV2() {
+		cfg.AddOnStresserRemoteV2 = nil
 		return nil
 	}
 
@@ -2,6 +2,7 @@ package eksconfig
 
 import (
 	"errors"
+	"fmt"
 	"path"
 	"path/filepath"
 	"strings"
@@ -55,6 +56,11 @@ type AddOnStresserRemote struct {
 	// Write QPS will be client QPS * replicas.
 	// Read QPS will be client QPS * replicas.
 	Parallels int `json:"parallels"`
+	// WorkloadType is the load generator workload type, either "Job"
+	// or "DaemonSet". "DaemonSet" runs one load generator per node,
+	// so the load scales with the node groups, and ignores
+	// "Completes" and "Parallels".
+	WorkloadType string `json:"workload-type"`
 
 	// ObjectSize is the value size in bytes for write objects.
 	// If 0, do not write anything.
@@ -155,7 +161,6 @@ type AddOnStresserRemote struct {
 const EnvironmentVariablePrefixAddOnStresserRemote = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_STRESSER_REMOTE_"
 
 // IsEnabledAddOnStresserRemote returns true if "AddOnStresserRemote" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnStresserRemote() bool {
 	if cfg.AddOnStresserRemote == nil {
 		return false
@@ -163,15 +168,22 @@ func (cfg *Config) IsEnabledAddOnStresserRemote() bool {
 	if cfg.AddOnStresserRemote.Enable {
 		return true
 	}
-	cfg.AddOnStresserRemote = nil
 	return false
 }
 
+const (
+	// StresserRemoteWorkloadTypeJob runs the load generators as a Job.
+	StresserRemoteWorkloadTypeJob = "Job"
+	// StresserRemoteWorkloadTypeDaemonSet runs one load generator per node.
+	StresserRemoteWorkloadTypeDaemonSet = "DaemonSet"
+)
+
 func getDefaultAddOnStresserRemote() *AddOnStresserRemote {
 	return &AddOnStresserRemote{
 		Enable:                                false,
 		Completes:                             5,
 		Parallels:                             5,
+		WorkloadType:                          StresserRemoteWorkloadTypeJob,
 		ObjectSize:                            0,
 		ListLimit:                             0,
 		Duration:                              time.Minute,
@@ -189,6 +201,7 @@ func (cfg *Config) GetAddOnStresserRemoteRepositoryRegion() string {
 
 func (cfg *Config) validateAddOnStresserRemote() error {
 	if !cfg.IsEnabledAddOnStresserRemote() {
+		cfg.AddOnStresserRemote = nil
 		return nil
 	}
 
@@ -213,6 +226,14 @@ func (cfg *Config) validateAddOnStresserRemote() error {
 		return errors.New("AddOnStresserRemote.RepositoryImageTag empty")
 	}
 
+	switch cfg.AddOnStresserRemote.WorkloadType {
+	case "":
+		cfg.AddOnStresserRemote.WorkloadType = StresserRemoteWorkloadTypeJob
+	case StresserRemoteWorkloadTypeJob, StresserRemoteWorkloadTypeDaemonSet:
+	default:
+		return fmt.Errorf("unknown AddOnStresserRemote.WorkloadType %q", cfg.AddOnStresserRemote.WorkloadType)
+	}
+
 	if cfg.AddOnStresserRemote.Duration == time.Duration(0) {
 		cfg.AddOnStresserRemote.Duration = time.Minute
 	}
@@ -0,0 +1,52 @@
+package eksconfig
+
+import (
+	"errors"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
+)
+
+// AddOnVersionSkew defines parameters for EKS cluster
+// version skew validation add-on. It checks the control plane version,
+// every node's kubelet version, and core add-on image versions against
+// upstream version skew policy and EKS add-on compatibility tables.
+// ref. https://kubernetes.io/releases/version-skew-policy/
+type AddOnVersionSkew struct {
+	// Enable is 'true' to create this add-on.
+	Enable bool `json:"enable"`
+	// Created is true when the resource has been created.
+	// Used for delete operations.
+	Created         bool               `json:"created" read-only:"true"`
+	TimeFrameCreate timeutil.TimeFrame `json:"time-frame-create" read-only:"true"`
+}
+
+// EnvironmentVariablePrefixAddOnVersionSkew is the environment variable prefix used for "eksconfig".
+const EnvironmentVariablePrefixAddOnVersionSkew = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_VERSION_SKEW_"
+
+// IsEnabledAddOnVersionSkew returns true if "AddOnVersionSkew" is enabled.
+func (cfg *Config) IsEnabledAddOnVersionSkew() bool {
+	if cfg.AddOnVersionSkew == nil {
+		return false
+	}
+	if cfg.AddOnVersionSkew.Enable {
+		return true
+	}
+	return false
+}
+
+func getDefaultAddOnVersionSkew() *AddOnVersionSkew {
+	return &AddOnVersionSkew{
+		Enable: false,
+	}
+}
+
+func (cfg *Config) validateAddOnVersionSkew() error {
+	if !cfg.IsEnabledAddOnVersionSkew() {
+		cfg.AddOnVersionSkew = nil
+		return nil
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("AddOnVersionSkew.Enable true but no node group is enabled")
+	}
+	return nil
+}
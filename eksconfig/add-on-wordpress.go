@@ -47,7 +47,6 @@ type AddOnWordpress struct {
 const EnvironmentVariablePrefixAddOnWordpress = AWS_K8S_TESTER_EKS_PREFIX + "ADD_ON_WORDPRESS_"
 
 // IsEnabledAddOnWordpress returns true if "AddOnWordpress" is enabled.
-// Otherwise, nil the field for "omitempty".
 func (cfg *Config) IsEnabledAddOnWordpress() bool {
 	if cfg.AddOnWordpress == nil {
 		return false
@@ -55,7 +54,6 @@ func (cfg *Config) IsEnabledAddOnWordpress() bool {
 	if cfg.AddOnWordpress.Enable {
 		return true
 	}
-	cfg.AddOnWordpress = nil
 	return false
 }
 
@@ -69,6 +67,7 @@ func getDefaultAddOnWordpress() *AddOnWordpress {
 
 func (cfg *Config) validateAddOnWordpress() error {
 	if !cfg.IsEnabledAddOnWordpress() {
+		cfg.AddOnWordpress = nil
 		return nil
 	}
 	if !cfg.IsEnabledAddOnCSIEBS() {
@@ -0,0 +1,107 @@
+package eksconfig
+
+import (
+	"fmt"
+	"time"
+
+	pkg_aws "github.com/aws/aws-k8s-tester/pkg/aws"
+)
+
+// AssumeRole defines the IAM role assumed for all AWS API calls
+// (e.g. to run from a CI system that only vends a bootstrap role).
+// ref. https://docs.aws.amazon.com/IAM/latest/UserGuide/id_roles_use.html
+type AssumeRole struct {
+	// RoleARN is the IAM role to assume with the base credentials.
+	// Leave empty to use the base credentials as is.
+	RoleARN string `json:"role-arn"`
+	// ExternalID is the external ID required by the role trust policy.
+	ExternalID string `json:"external-id"`
+	// SessionName is the role session name.
+	// Defaults to the cluster name.
+	SessionName string `json:"session-name"`
+	// Duration is the role session duration.
+	// The credentials are refreshed before expiration.
+	Duration       time.Duration `json:"duration,omitempty"`
+	DurationString string        `json:"duration-string,omitempty" read-only:"true"`
+
+	// WebIdentityTokenFile is the path to the OIDC token file
+	// (e.g. vended by the CI system), exchanged for the "WebIdentityRoleARN"
+	// credentials. The "RoleARN" is then assumed from the web identity
+	// role, if set.
+	// ref. https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRoleWithWebIdentity.html
+	WebIdentityTokenFile string `json:"web-identity-token-file"`
+	// WebIdentityRoleARN is the IAM role to assume with the web identity token.
+	WebIdentityRoleARN string `json:"web-identity-role-arn"`
+}
+
+const (
+	// DefaultAssumeRoleDuration is the default role session duration.
+	DefaultAssumeRoleDuration = time.Hour
+	// MinAssumeRoleDuration is the minimum role session duration allowed by STS.
+	MinAssumeRoleDuration = 15 * time.Minute
+	// MaxAssumeRoleDuration is the maximum role session duration allowed by STS.
+	MaxAssumeRoleDuration = 12 * time.Hour
+
+	// role session name must be at most 64 characters
+	maxAssumeRoleSessionNameLength = 64
+)
+
+func getDefaultAssumeRole() *AssumeRole {
+	return &AssumeRole{
+		Duration: DefaultAssumeRoleDuration,
+	}
+}
+
+// IsEnabled returns true if any role is assumed.
+func (ar *AssumeRole) IsEnabled() bool {
+	return ar != nil && (ar.RoleARN != "" || ar.WebIdentityTokenFile != "")
+}
+
+// AWSAssumeRole returns the assumed role for "pkg/aws" sessions,
+// or nil if no role is assumed.
+func (ar *AssumeRole) AWSAssumeRole() *pkg_aws.AssumeRole {
+	if !ar.IsEnabled() {
+		return nil
+	}
+	return &pkg_aws.AssumeRole{
+		RoleARN:              ar.RoleARN,
+		ExternalID:           ar.ExternalID,
+		SessionName:          ar.SessionName,
+		Duration:             ar.Duration,
+		WebIdentityTokenFile: ar.WebIdentityTokenFile,
+		WebIdentityRoleARN:   ar.WebIdentityRoleARN,
+	}
+}
+
+func (cfg *Config) validateAssumeRole() error {
+	if cfg.AssumeRole == nil {
+		cfg.AssumeRole = getDefaultAssumeRole()
+	}
+	if cfg.AssumeRole.ExternalID != "" && cfg.AssumeRole.RoleARN == "" {
+		return fmt.Errorf("AssumeRole.ExternalID %q set but empty AssumeRole.RoleARN", cfg.AssumeRole.ExternalID)
+	}
+	if cfg.AssumeRole.WebIdentityTokenFile != "" && cfg.AssumeRole.WebIdentityRoleARN == "" {
+		return fmt.Errorf("AssumeRole.WebIdentityTokenFile %q set but empty AssumeRole.WebIdentityRoleARN", cfg.AssumeRole.WebIdentityTokenFile)
+	}
+	if cfg.AssumeRole.WebIdentityTokenFile == "" && cfg.AssumeRole.WebIdentityRoleARN != "" {
+		return fmt.Errorf("AssumeRole.WebIdentityRoleARN %q set but empty AssumeRole.WebIdentityTokenFile", cfg.AssumeRole.WebIdentityRoleARN)
+	}
+	if !cfg.AssumeRole.IsEnabled() {
+		return nil
+	}
+
+	if cfg.AssumeRole.SessionName == "" {
+		cfg.AssumeRole.SessionName = cfg.Name
+	}
+	if len(cfg.AssumeRole.SessionName) > maxAssumeRoleSessionNameLength {
+		cfg.AssumeRole.SessionName = cfg.AssumeRole.SessionName[:maxAssumeRoleSessionNameLength]
+	}
+	if cfg.AssumeRole.Duration == 0 {
+		cfg.AssumeRole.Duration = DefaultAssumeRoleDuration
+	}
+	if cfg.AssumeRole.Duration < MinAssumeRoleDuration || cfg.AssumeRole.Duration > MaxAssumeRoleDuration {
+		return fmt.Errorf("AssumeRole.Duration %v out of range [%v, %v]", cfg.AssumeRole.Duration, MinAssumeRoleDuration, MaxAssumeRoleDuration)
+	}
+	cfg.AssumeRole.DurationString = cfg.AssumeRole.Duration.String()
+	return nil
+}
@@ -1,6 +1,11 @@
 package eksconfig
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/timeutil"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 )
@@ -13,6 +18,27 @@ type ClusterAutoscalerSpec struct {
 	MaxNodes       int                         `json:"maxNodes,omitempty"`
 	Resources      corev1.ResourceRequirements `json:"resources,omitempty"`
 	ScaleDownDelay string                      `json:"scaleDownDelay,omitempty"`
+	// ScaleTest is non-nil to test the ASG scale-up and scale-down
+	// after the installation (only with the "aws" cloud provider).
+	ScaleTest *ClusterAutoscalerScaleTestSpec `json:"scaleTest,omitempty"`
+}
+
+// ClusterAutoscalerScaleTestSpec defines the scale test, which creates
+// a workload that cannot be scheduled on the current nodes, and then
+// scales it in to wait for the node count to return to the baseline.
+type ClusterAutoscalerScaleTestSpec struct {
+	// Namespace is the namespace to run the scale-out workload in.
+	Namespace string `json:"namespace,omitempty"`
+	// Replicas is the number of the scale-out Pods,
+	// each requesting "CPURequest".
+	Replicas int32 `json:"replicas,omitempty"`
+	// CPURequest is the CPU request of each scale-out Pod.
+	CPURequest string `json:"cpuRequest,omitempty"`
+	// ScaleUpTimeout is the timeout for the scale-out workload to be ready.
+	ScaleUpTimeout string `json:"scaleUpTimeout,omitempty"`
+	// ScaleDownTimeout is the window for the node count to return to
+	// the baseline after the workload is scaled in.
+	ScaleDownTimeout string `json:"scaleDownTimeout,omitempty"`
 }
 
 // CloudProvider enum for ClusterAutoscaler
@@ -27,10 +53,75 @@ const (
 // ClusterAutoscalerStatus defines the status for the Addon
 type ClusterAutoscalerStatus struct {
 	AddonStatus `json:",inline"`
+	// ScaleTest is the result of the scale test, if enabled.
+	ScaleTest *ClusterAutoscalerScaleTestStatus `json:"scaleTest,omitempty"`
+}
+
+// ClusterAutoscalerScaleTestStatus defines the result of the scale test.
+type ClusterAutoscalerScaleTestStatus struct {
+	// NodesBaseline is the number of nodes before the scale-out.
+	NodesBaseline int `json:"nodesBaseline"`
+	// NodesPeak is the number of nodes after the scale-out.
+	NodesPeak int `json:"nodesPeak"`
+	// TimeFrameScaleUp is the time taken for the scale-out workload to be ready.
+	TimeFrameScaleUp timeutil.TimeFrame `json:"timeFrameScaleUp"`
+	// TimeFrameScaleDown is the time taken for the node count to return to the baseline.
+	TimeFrameScaleDown timeutil.TimeFrame `json:"timeFrameScaleDown"`
+}
+
+const (
+	// DefaultClusterAutoscalerScaleTestReplicas is the default number of scale-out Pods.
+	DefaultClusterAutoscalerScaleTestReplicas = 10
+	// ClusterAutoscalerScaleTestReplicasMaxLimit is the maximum number of scale-out Pods.
+	ClusterAutoscalerScaleTestReplicasMaxLimit = 500
+)
+
+// ClusterAutoscalerImages maps the cluster version to the matching
+// Cluster Autoscaler release, since each minor release only supports
+// the same Kubernetes minor version.
+// ref. https://github.com/kubernetes/autoscaler/releases
+var ClusterAutoscalerImages = map[string]string{
+	"1.20": "registry.k8s.io/autoscaling/cluster-autoscaler:v1.20.3",
+	"1.21": "registry.k8s.io/autoscaling/cluster-autoscaler:v1.21.3",
+	"1.22": "registry.k8s.io/autoscaling/cluster-autoscaler:v1.22.3",
+	"1.23": "registry.k8s.io/autoscaling/cluster-autoscaler:v1.23.1",
+	"1.24": "registry.k8s.io/autoscaling/cluster-autoscaler:v1.24.3",
+	"1.25": "registry.k8s.io/autoscaling/cluster-autoscaler:v1.25.3",
+	"1.26": "registry.k8s.io/autoscaling/cluster-autoscaler:v1.26.8",
+	"1.27": "registry.k8s.io/autoscaling/cluster-autoscaler:v1.27.8",
+	"1.28": "registry.k8s.io/autoscaling/cluster-autoscaler:v1.28.5",
+	"1.29": "registry.k8s.io/autoscaling/cluster-autoscaler:v1.29.3",
+	"1.30": "registry.k8s.io/autoscaling/cluster-autoscaler:v1.30.1",
 }
 
 // Validate installs the addon
 func (spec *ClusterAutoscalerSpec) Validate(cfg *Config) error {
+	// both deploy "kube-system/cluster-autoscaler" with the same RBAC objects,
+	// so deleting one would remove the RBAC of the other
+	if cfg.IsEnabledAddOnNodeGroups() && cfg.AddOnNodeGroups.IsEnabledClusterAutoscaler() {
+		return errors.New("Spec.ClusterAutoscaler set but AddOnNodeGroups already enables cluster-autoscaler")
+	}
+	if spec.ScaleTest == nil {
+		return nil
+	}
+	if spec.CloudProvider != CloudProviderAWS {
+		return fmt.Errorf("Spec.ClusterAutoscaler.ScaleTest requires cloud provider %q, got %q", CloudProviderAWS, spec.CloudProvider)
+	}
+	if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+		return errors.New("Spec.ClusterAutoscaler.ScaleTest set but no node group is enabled")
+	}
+	if spec.ScaleTest.Replicas < 1 || spec.ScaleTest.Replicas > ClusterAutoscalerScaleTestReplicasMaxLimit {
+		return fmt.Errorf("Spec.ClusterAutoscaler.ScaleTest.Replicas %d invalid (must be 1 to %d)", spec.ScaleTest.Replicas, ClusterAutoscalerScaleTestReplicasMaxLimit)
+	}
+	if _, err := resource.ParseQuantity(spec.ScaleTest.CPURequest); err != nil {
+		return fmt.Errorf("invalid Spec.ClusterAutoscaler.ScaleTest.CPURequest %q (%v)", spec.ScaleTest.CPURequest, err)
+	}
+	if _, err := time.ParseDuration(spec.ScaleTest.ScaleUpTimeout); err != nil {
+		return fmt.Errorf("invalid Spec.ClusterAutoscaler.ScaleTest.ScaleUpTimeout %q (%v)", spec.ScaleTest.ScaleUpTimeout, err)
+	}
+	if _, err := time.ParseDuration(spec.ScaleTest.ScaleDownTimeout); err != nil {
+		return fmt.Errorf("invalid Spec.ClusterAutoscaler.ScaleTest.ScaleDownTimeout %q (%v)", spec.ScaleTest.ScaleDownTimeout, err)
+	}
 	return nil
 }
 
@@ -66,15 +157,37 @@ func (spec *ClusterAutoscalerSpec) Default(cfg *Config) {
 	if spec.ScaleDownDelay == "" {
 		spec.ScaleDownDelay = "30s"
 	}
-	spec.Image = spec.defaultImage()
+	spec.Image = spec.defaultImage(cfg)
+
+	if spec.ScaleTest != nil {
+		if spec.ScaleTest.Namespace == "" {
+			spec.ScaleTest.Namespace = cfg.Name + "-cluster-autoscaler"
+		}
+		if spec.ScaleTest.Replicas == 0 {
+			spec.ScaleTest.Replicas = DefaultClusterAutoscalerScaleTestReplicas
+		}
+		if spec.ScaleTest.CPURequest == "" {
+			spec.ScaleTest.CPURequest = "1"
+		}
+		if spec.ScaleTest.ScaleUpTimeout == "" {
+			spec.ScaleTest.ScaleUpTimeout = "15m"
+		}
+		if spec.ScaleTest.ScaleDownTimeout == "" {
+			spec.ScaleTest.ScaleDownTimeout = "20m"
+		}
+	}
 }
 
-func (spec *ClusterAutoscalerSpec) defaultImage() string {
+func (spec *ClusterAutoscalerSpec) defaultImage(cfg *Config) string {
 	if spec.Image != "" {
 		return spec.Image
 	}
 	if spec.CloudProvider == CloudProviderKubemark {
 		return "197575167141.dkr.ecr.us-west-2.amazonaws.com/cluster-autoscaler-kubemark:latest"
 	}
+	// pinned to the cluster version
+	if img, ok := ClusterAutoscalerImages[cfg.Version]; ok {
+		return img
+	}
 	return "k8s.gcr.io/cluster-autoscaler:v1.14.7"
 }
@@ -0,0 +1,96 @@
+package eksconfig
+
+import (
+	"errors"
+	"strconv"
+)
+
+// CNIConfig defines the VPC CNI ipamd configuration, set as the "aws-node"
+// environment variables before creating the node groups, so that the nodes
+// join with the max-pods of the configuration. The zero values are not set,
+// to keep the VPC CNI defaults.
+// ref. https://github.com/aws/amazon-vpc-cni-k8s#cni-configuration-variables
+type CNIConfig struct {
+	// EnablePrefixDelegation is 'true' to assign /28 IPv4 prefixes to the
+	// ENIs instead of the secondary IPs ("ENABLE_PREFIX_DELEGATION").
+	// Requires the Nitro instance types and VPC CNI v1.9.0 or later.
+	EnablePrefixDelegation bool `json:"enable-prefix-delegation"`
+	// WarmPrefixTarget is the number of free prefixes to keep
+	// ("WARM_PREFIX_TARGET"). Requires "EnablePrefixDelegation".
+	WarmPrefixTarget int `json:"warm-prefix-target"`
+	// WarmIPTarget is the number of free IPs to keep ("WARM_IP_TARGET").
+	WarmIPTarget int `json:"warm-ip-target"`
+	// MinimumIPTarget is the minimum number of IPs to allocate
+	// ("MINIMUM_IP_TARGET").
+	MinimumIPTarget int `json:"minimum-ip-target"`
+}
+
+func getDefaultCNIConfig() *CNIConfig {
+	return &CNIConfig{}
+}
+
+// IsSet returns true if any of the configuration is set.
+func (c *CNIConfig) IsSet() bool {
+	return c != nil && (c.EnablePrefixDelegation || c.WarmPrefixTarget > 0 || c.WarmIPTarget > 0 || c.MinimumIPTarget > 0)
+}
+
+// Envs returns the "aws-node" environment variables of the configuration.
+func (c *CNIConfig) Envs() map[string]string {
+	envs := make(map[string]string)
+	if c == nil {
+		return envs
+	}
+	if c.EnablePrefixDelegation {
+		envs["ENABLE_PREFIX_DELEGATION"] = "true"
+	}
+	if c.WarmPrefixTarget > 0 {
+		envs["WARM_PREFIX_TARGET"] = strconv.Itoa(c.WarmPrefixTarget)
+	}
+	if c.WarmIPTarget > 0 {
+		envs["WARM_IP_TARGET"] = strconv.Itoa(c.WarmIPTarget)
+	}
+	if c.MinimumIPTarget > 0 {
+		envs["MINIMUM_IP_TARGET"] = strconv.Itoa(c.MinimumIPTarget)
+	}
+	return envs
+}
+
+// ExpectedMaxPods returns the kubelet max-pods of the EKS optimized AMI.
+// Each ENI reserves its primary IP, and a /28 prefix replaces a secondary IP
+// with prefix delegation, capped at 110 for less than 30 vCPUs or 250.
+// The primary ENI is not used for the Pods with custom networking.
+// ref. https://github.com/awslabs/amazon-eks-ami/blob/master/files/max-pods-calculator.sh
+func ExpectedMaxPods(enis int64, ipsPerENI int64, vcpus int64, prefixDelegation bool, customNetworking bool) int64 {
+	if customNetworking {
+		enis--
+	}
+	if !prefixDelegation {
+		return enis*(ipsPerENI-1) + 2
+	}
+	maxPods := enis*((ipsPerENI-1)*16) + 2
+	limit := int64(250)
+	if vcpus < 30 {
+		limit = 110
+	}
+	if maxPods > limit {
+		maxPods = limit
+	}
+	return maxPods
+}
+
+func (cfg *Config) validateCNIConfig() error {
+	if cfg.CNIConfig == nil {
+		cfg.CNIConfig = getDefaultCNIConfig()
+	}
+	cur := cfg.CNIConfig
+	if cur.WarmPrefixTarget < 0 || cur.WarmIPTarget < 0 || cur.MinimumIPTarget < 0 {
+		return errors.New("CNIConfig targets must not be negative")
+	}
+	if cur.WarmPrefixTarget > 0 && !cur.EnablePrefixDelegation {
+		return errors.New("CNIConfig.WarmPrefixTarget requires CNIConfig.EnablePrefixDelegation")
+	}
+	if cur.IsSet() && cfg.IPFamily == IPFamilyIPv6 {
+		return errors.New("CNIConfig not supported with IPv6 (prefix delegation is always on)")
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package eksconfig
+
+import "testing"
+
+func TestExpectedMaxPods(t *testing.T) {
+	tt := []struct {
+		enis, ipsPerENI, vcpus int64
+		prefixDelegation       bool
+		customNetworking       bool
+		exp                    int64
+	}{
+		// m5.large
+		{enis: 3, ipsPerENI: 10, vcpus: 2, exp: 29},
+		{enis: 3, ipsPerENI: 10, vcpus: 2, prefixDelegation: true, exp: 110},
+		{enis: 3, ipsPerENI: 10, vcpus: 2, customNetworking: true, exp: 20},
+		// t3.micro
+		{enis: 2, ipsPerENI: 2, vcpus: 2, exp: 4},
+		{enis: 2, ipsPerENI: 2, vcpus: 2, prefixDelegation: true, exp: 34},
+		// m5.8xlarge
+		{enis: 8, ipsPerENI: 30, vcpus: 32, exp: 234},
+		{enis: 8, ipsPerENI: 30, vcpus: 32, prefixDelegation: true, exp: 250},
+	}
+	for i, tv := range tt {
+		if v := ExpectedMaxPods(tv.enis, tv.ipsPerENI, tv.vcpus, tv.prefixDelegation, tv.customNetworking); v != tv.exp {
+			t.Fatalf("#%d: expected %d, got %d", i, tv.exp, v)
+		}
+	}
+}
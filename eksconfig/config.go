@@ -19,7 +19,10 @@ import (
 	"time"
 
 	"github.com/aws/aws-k8s-tester/ec2config"
+	pkg_aws "github.com/aws/aws-k8s-tester/pkg/aws"
+	"github.com/aws/aws-k8s-tester/pkg/configfile"
 	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+	k8s_client "github.com/aws/aws-k8s-tester/pkg/k8s-client"
 	"github.com/aws/aws-k8s-tester/pkg/logutil"
 	"github.com/aws/aws-k8s-tester/pkg/randutil"
 	"github.com/aws/aws-k8s-tester/pkg/terminal"
@@ -30,9 +33,16 @@ import (
 )
 
 // Config defines EKS configuration.
+//
+// The add-ons in the same group are applied concurrently, so the status
+// must only be written through "RecordAddOnStatus" (or the other "Record*"
+// methods), which hold the lock while writing and persisting to disk.
+// The "IsEnabledAddOn*" getters are read-only and safe to call concurrently;
+// the validation nils the disabled add-on fields for "omitempty".
 type Config struct {
 	mu *sync.RWMutex
 
+	// TypeMeta is the schema version (see "APIVersion") and the kind.
 	// TODO, Migrate metadata fields to here
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -53,10 +63,29 @@ type Config struct {
 	// ConfigPath is the configuration file path.
 	// Deployer is expected to update this file with latest status.
 	ConfigPath string `json:"config-path,omitempty"`
+	// EnvStrict is true to fail "UpdateFromEnvs" on the unknown
+	// "AWS_K8S_TESTER_EKS_*" environment variables (e.g. typos),
+	// which are ignored otherwise. Run "aws-k8s-tester eks env-help"
+	// for the supported environment variables.
+	EnvStrict bool `json:"env-strict"`
 	// KubectlCommandsOutputPath is the output path for kubectl commands.
 	KubectlCommandsOutputPath string `json:"kubectl-commands-output-path,omitempty"`
 	// RemoteAccessCommandsOutputPath is the output path for ssh commands.
 	RemoteAccessCommandsOutputPath string `json:"remote-access-commands-output-path,omitempty"`
+	// ReportJUnitXMLPath is the output path for the JUnit XML report,
+	// with each test phase (e.g. create cluster, each add-on, delete) as a test case.
+	// Set to "$ARTIFACTS/junit_*.xml" for Prow to render the results.
+	ReportJUnitXMLPath string `json:"report-junit-xml-path,omitempty"`
+	// ReportJSONPath is the output path for the JSON summary of test phases.
+	ReportJSONPath string `json:"report-json-path,omitempty"`
+	// CostSummaryPath is the output path for the cost and duration summary
+	// of the created AWS resources, estimated at the end of each run.
+	CostSummaryPath string `json:"cost-summary-path,omitempty"`
+	// MetricsListenAddress is the address to serve the Prometheus metrics
+	// of the tester process at "/metrics" (e.g. "localhost:9090"),
+	// such as the phase durations, the AWS API calls and errors,
+	// the SSH failures, and the current phases. Leave empty to disable.
+	MetricsListenAddress string `json:"metrics-listen-address,omitempty"`
 
 	// LogColor is true to output logs in color.
 	LogColor bool `json:"log-color"`
@@ -101,6 +130,11 @@ type Config struct {
 	// OnFailureDeleteWaitSeconds is the seconds to wait before deleting
 	// all resources on creation fail.
 	OnFailureDeleteWaitSeconds uint64 `json:"on-failure-delete-wait-seconds"`
+	// OnSignalCleanup is true to collect logs, upload artifacts, and
+	// delete all resources on SIGINT or SIGTERM before exiting,
+	// instead of leaving the half-created resources behind.
+	// The second signal exits immediately.
+	OnSignalCleanup bool `json:"on-signal-cleanup"`
 
 	// CommandAfterCreateCluster is the command to execute after creating clusters.
 	// Currently supported variables are:
@@ -119,6 +153,36 @@ type Config struct {
 	CommandAfterCreateAddOnsTimeout       time.Duration `json:"command-after-create-add-ons-timeout"`
 	CommandAfterCreateAddOnsTimeoutString string        `json:"command-after-create-add-ons-timeout-string" read-only:"true"`
 
+	// RunTimeout is the deadline of the whole "Up", zero to disable.
+	// On expiry, the in-flight creation (e.g. AWS waits) is cancelled,
+	// and the tester collects logs and proceeds to "OnFailureDelete".
+	RunTimeout       time.Duration `json:"run-timeout"`
+	RunTimeoutString string        `json:"run-timeout-string" read-only:"true"`
+	// SoakDuration is the duration to keep the cluster after "Up",
+	// re-running the health checks and the lightweight workloads
+	// every "SoakInterval", before the tear down. Zero to disable.
+	SoakDuration       time.Duration `json:"soak-duration"`
+	SoakDurationString string        `json:"soak-duration-string" read-only:"true"`
+	// SoakInterval is the interval between the soak iterations.
+	SoakInterval       time.Duration `json:"soak-interval"`
+	SoakIntervalString string        `json:"soak-interval-string" read-only:"true"`
+	// SoakResultsPath is the output path for the soak iteration results,
+	// one JSON object per line, uploaded to S3 after each iteration.
+	SoakResultsPath string `json:"soak-results-path,omitempty"`
+	// ClusterCreateTimeout is the timeout of the EKS cluster creation
+	// (including VPC and role), zero to disable.
+	ClusterCreateTimeout       time.Duration `json:"cluster-create-timeout"`
+	ClusterCreateTimeoutString string        `json:"cluster-create-timeout-string" read-only:"true"`
+	// NodeGroupsCreateTimeout is the timeout of the node groups creation, zero to disable.
+	NodeGroupsCreateTimeout       time.Duration `json:"node-groups-create-timeout"`
+	NodeGroupsCreateTimeoutString string        `json:"node-groups-create-timeout-string" read-only:"true"`
+	// ManagedNodeGroupsCreateTimeout is the timeout of the managed node groups creation, zero to disable.
+	ManagedNodeGroupsCreateTimeout       time.Duration `json:"managed-node-groups-create-timeout"`
+	ManagedNodeGroupsCreateTimeoutString string        `json:"managed-node-groups-create-timeout-string" read-only:"true"`
+	// AddOnCreateTimeout is the timeout of each add-on creation, zero to disable.
+	AddOnCreateTimeout       time.Duration `json:"add-on-create-timeout"`
+	AddOnCreateTimeoutString string        `json:"add-on-create-timeout-string" read-only:"true"`
+
 	// CWNamespace is the CloudWatch namespace to put metric datum.
 	CWNamespace string `json:"cw-namespace"`
 
@@ -128,11 +192,45 @@ type Config struct {
 	// All node groups and managed node groups are kept.
 	// Use this to use existing clusters to create/delete add-ons.
 	SkipDeleteClusterAndNodes bool `json:"skip-delete-cluster-and-nodes"`
+	// DeleteConcurrency is the maximum number of add-ons and node groups
+	// to delete at the same time on "Down". Add-ons in the same dependency
+	// group are deleted in parallel, while the groups are deleted in the
+	// reverse order of creation. Set 1 to delete serially.
+	DeleteConcurrency int `json:"delete-concurrency"`
+	// CreateConcurrency is the maximum number of add-ons to create at the
+	// same time on "Up". Add-ons in the same dependency group are created
+	// in parallel, and the failures in a group are reported together
+	// before the following groups. Set 1 to create serially.
+	CreateConcurrency int `json:"create-concurrency"`
 
 	S3         *S3         `json:"s3"`
 	Encryption *Encryption `json:"encryption"`
 	Role       *Role       `json:"role"`
 	VPC        *VPC        `json:"vpc"`
+	Endpoint   *Endpoint   `json:"endpoint"`
+
+	ControlPlaneLogging *ControlPlaneLogging `json:"control-plane-logging"`
+
+	// Notifications defines the sinks of the run events.
+	Notifications *Notifications `json:"notifications"`
+	// Access defines the IAM principals mapped to the cluster.
+	Access *Access `json:"access"`
+	// ImageMirror defines the air-gapped image mirroring mode.
+	ImageMirror *ImageMirror `json:"image-mirror"`
+	// Hooks defines the commands to run at the points of the run lifecycle.
+	Hooks *Hooks `json:"hooks"`
+	// CNIConfig defines the VPC CNI ipamd configuration set before creating the node groups.
+	CNIConfig *CNIConfig `json:"cni-config"`
+
+	// AssumeRole defines the IAM role assumed for all AWS API calls.
+	AssumeRole *AssumeRole `json:"assume-role"`
+	// ServiceEndpoints defines the custom AWS service endpoints.
+	ServiceEndpoints *ServiceEndpoints `json:"service-endpoints"`
+	// CFNTemplateDir is the directory of the CloudFormation templates
+	// to use instead of the embedded ones, with the same relative paths
+	// as the rendered plan (e.g. "add-on-irsa/role.yaml").
+	// The templates not found in the directory default to the embedded ones.
+	CFNTemplateDir string `json:"cfn-template-dir,omitempty"`
 
 	// Tags defines EKS create cluster tags.
 	Tags map[string]string `json:"tags"`
@@ -152,6 +250,13 @@ type Config struct {
 	Version      string  `json:"version"`
 	VersionValue float64 `json:"version-value" read-only:"true"`
 
+	// IPFamily is the IP family of the cluster Pod and Service addresses,
+	// either "ipv4" or "ipv6". "ipv6" creates the cluster with an IPv6 service
+	// CIDR, assigns IPv6 CIDR blocks to the VPC and subnets (dual-stack),
+	// and bootstraps the nodes with IPv6 cluster DNS.
+	// ref. https://docs.aws.amazon.com/eks/latest/userguide/cni-ipv6.html
+	IPFamily string `json:"ip-family"`
+
 	// EKS internal only
 	// If empty, use default kube-controller-manager and kube-scheduler qps and burst
 	// ref. https://kubernetes.io/docs/reference/command-line-tools-reference/kube-controller-manager/
@@ -188,6 +293,35 @@ type Config struct {
 	// ref. https://docs.aws.amazon.com/eks/latest/userguide/create-managed-node-group.html
 	// ref. https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/aws-resource-eks-nodegroup.html
 	RemoteAccessPrivateKeyPath string `json:"remote-access-private-key-path,omitempty"`
+	// RemoteAccessInstanceConnect is true to push an ephemeral SSH public key
+	// via EC2 Instance Connect right before connecting to the nodes, instead of
+	// using "RemoteAccessPrivateKeyPath". Set "RemoteAccessKeyCreate" to false
+	// to not create any EC2 key pair (then, the node security group must allow
+	// SSH access for managed node groups).
+	// ref. https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/Connect-using-EC2-Instance-Connect.html
+	RemoteAccessInstanceConnect bool `json:"remote-access-instance-connect"`
+	// RemoteAccessHostKeyCheck is the node SSH host key verification mode.
+	// "console-output" to fetch the host keys from EC2 console output,
+	// "ssm" to fetch the host keys with SSM Run Command (requires SSM agent),
+	// then the keys are pinned on first connect, and mismatches are rejected.
+	// "insecure-skip-verify" to skip host key verification (default).
+	RemoteAccessHostKeyCheck string `json:"remote-access-host-key-check"`
+	// RemoteAccessKnownHostsPath is the "known_hosts" file path
+	// to persist the pinned node host keys.
+	RemoteAccessKnownHostsPath string `json:"remote-access-known-hosts-path,omitempty"`
+	// RemoteAccessBastionHost is the bastion (jump) host address to tunnel
+	// SSH connections through, to reach nodes in private subnets
+	// (e.g. "bastion.example.com" or "1.2.3.4:2222").
+	// Leave empty to connect to the node public IPs directly.
+	// Unless "RemoteAccessHostKeyCheck" is "insecure-skip-verify",
+	// the bastion host key must be pinned in "RemoteAccessKnownHostsPath".
+	RemoteAccessBastionHost string `json:"remote-access-bastion-host,omitempty"`
+	// RemoteAccessBastionUserName is the user name for the bastion host.
+	// Defaults to "ec2-user".
+	RemoteAccessBastionUserName string `json:"remote-access-bastion-user-name,omitempty"`
+	// RemoteAccessBastionPrivateKeyPath is the private key path for the bastion host.
+	// Defaults to "RemoteAccessPrivateKeyPath".
+	RemoteAccessBastionPrivateKeyPath string `json:"remote-access-bastion-private-key-path,omitempty"`
 
 	// Clients is the number of kubernetes clients to create.
 	// Default is 1.
@@ -227,6 +361,15 @@ type Config struct {
 	// ClientTimeout is the client timeout.
 	ClientTimeout       time.Duration `json:"client-timeout"`
 	ClientTimeoutString string        `json:"client-timeout-string,omitempty" read-only:"true"`
+	// ClientMaxRetries is the number of retries for the kubernetes client requests
+	// throttled by kube-apiserver (HTTP 429), with "Retry-After" or exponential backoff.
+	// Negative value disables the retries.
+	ClientMaxRetries int `json:"client-max-retries"`
+	// ClientReadyzInterval is the interval to sample kube-apiserver "/readyz",
+	// to record the availability over the run in "Status.APIServerAvailability".
+	// Negative value disables the sampling.
+	ClientReadyzInterval       time.Duration `json:"client-readyz-interval"`
+	ClientReadyzIntervalString string        `json:"client-readyz-interval-string,omitempty" read-only:"true"`
 
 	//
 	//
@@ -389,10 +532,123 @@ type Config struct {
 	// for EKS cluster version upgrade add-on.
 	AddOnClusterVersionUpgrade *AddOnClusterVersionUpgrade `json:"add-on-cluster-version-upgrade,omitempty"`
 
+	// AddOnVersionSkew defines parameters
+	// for validating version skew between control plane, kubelets, and add-ons.
+	AddOnVersionSkew *AddOnVersionSkew `json:"add-on-version-skew,omitempty"`
+
 	// AddOnAmiSoftLockupIssue454 defines parameters
 	// for testing the AMI soft lockup issue.
 	AddOnAmiSoftLockupIssue454 *AddOnAmiSoftLockupIssue454 `json:"add-on-ami-soft-lockup-issue-454,omitempty"`
 
+	// AddOnKarpenter defines parameters for EKS cluster
+	// add-on Karpenter node provisioning.
+	AddOnKarpenter *AddOnKarpenter `json:"add-on-karpenter,omitempty"`
+
+	// AddOnSpotInterruption defines parameters for EKS cluster
+	// add-on Spot node group interruption handling.
+	AddOnSpotInterruption *AddOnSpotInterruption `json:"add-on-spot-interruption,omitempty"`
+
+	// AddOnGPU defines parameters for EKS cluster
+	// add-on GPU node group with NVIDIA device plugin validation.
+	AddOnGPU *AddOnGPU `json:"add-on-gpu,omitempty"`
+
+	// AddOnMultiArch defines parameters for EKS cluster
+	// add-on multi-architecture scheduling tests.
+	AddOnMultiArch *AddOnMultiArch `json:"add-on-multi-arch,omitempty"`
+
+	// AddOnALB defines parameters for EKS cluster
+	// add-on AWS Load Balancer Controller.
+	AddOnALB *AddOnALB `json:"add-on-alb,omitempty"`
+
+	// AddOnCSIEFS defines parameters for EKS cluster
+	// add-on AWS EFS CSI Driver.
+	AddOnCSIEFS *AddOnCSIEFS `json:"add-on-csi-efs,omitempty"`
+
+	// AddOnFSxLustre defines parameters for EKS cluster
+	// add-on FSx for Lustre with I/O benchmark.
+	AddOnFSxLustre *AddOnFSxLustre `json:"add-on-fsx-lustre,omitempty"`
+
+	// AddOnOIDCIdentityProvider defines parameters for EKS cluster
+	// add-on OIDC identity provider association.
+	AddOnOIDCIdentityProvider *AddOnOIDCIdentityProvider `json:"add-on-oidc-identity-provider,omitempty"`
+
+	// AddOnPodIdentity defines parameters for EKS cluster
+	// add-on EKS Pod Identity with the Pod Identity Agent.
+	AddOnPodIdentity *AddOnPodIdentity `json:"add-on-pod-identity,omitempty"`
+
+	// AddOnChaos defines parameters for EKS cluster
+	// add-on node termination and AZ failure injection.
+	AddOnChaos *AddOnChaos `json:"add-on-chaos,omitempty"`
+
+	// AddOnNodeFault defines parameters for EKS cluster
+	// add-on kubelet and containerd restart fault tests.
+	AddOnNodeFault *AddOnNodeFault `json:"add-on-node-fault,omitempty"`
+
+	// AddOnECR defines parameters for EKS cluster
+	// add-on private ECR repository push and pull tests.
+	AddOnECR *AddOnECR `json:"add-on-ecr,omitempty"`
+
+	// AddOnCustomManifests defines parameters for EKS cluster
+	// add-on user-provided Kubernetes manifests.
+	AddOnCustomManifests *AddOnCustomManifests `json:"add-on-custom-manifests,omitempty"`
+
+	// AddOnCNIVersionMatrix defines parameters for EKS cluster
+	// add-on VPC CNI version matrix tests.
+	AddOnCNIVersionMatrix *AddOnCNIVersionMatrix `json:"add-on-cni-version-matrix,omitempty"`
+
+	// AddOnPodDensity defines parameters for EKS cluster
+	// add-on pod density and IP address exhaustion tests.
+	AddOnPodDensity *AddOnPodDensity `json:"add-on-pod-density,omitempty"`
+
+	// AddOnKubeProxyModes defines parameters for EKS cluster
+	// add-on kube-proxy mode matrix tests.
+	AddOnKubeProxyModes *AddOnKubeProxyModes `json:"add-on-kube-proxy-modes,omitempty"`
+
+	// AddOnCoreDNSScale defines parameters for EKS cluster
+	// add-on CoreDNS scale and DNS latency tests.
+	AddOnCoreDNSScale *AddOnCoreDNSScale `json:"add-on-coredns-scale,omitempty"`
+
+	// AddOnServiceChurn defines parameters for EKS cluster
+	// add-on Service and endpoint churn benchmark.
+	AddOnServiceChurn *AddOnServiceChurn `json:"add-on-service-churn,omitempty"`
+
+	// AddOnManagedAddOns defines parameters for EKS cluster
+	// add-on EKS managed add-ons (e.g. vpc-cni, coredns, kube-proxy).
+	AddOnManagedAddOns *AddOnManagedAddOns `json:"add-on-managed-add-ons,omitempty"`
+
+	// AddOnCustomNetworking defines parameters for EKS cluster
+	// add-on VPC CNI custom networking with a secondary VPC CIDR.
+	AddOnCustomNetworking *AddOnCustomNetworking `json:"add-on-custom-networking,omitempty"`
+
+	// AddOnNetworkPolicy defines parameters for EKS cluster
+	// add-on network policy enforcement tests.
+	AddOnNetworkPolicy *AddOnNetworkPolicy `json:"add-on-network-policy,omitempty"`
+
+	// AddOnContainerInsights defines parameters for EKS cluster
+	// add-on CloudWatch Container Insights with Fluent Bit.
+	AddOnContainerInsights *AddOnContainerInsights `json:"add-on-container-insights,omitempty"`
+
+	// AddOnPrometheus defines parameters for EKS cluster
+	// add-on kube-prometheus-stack.
+	AddOnPrometheus *AddOnPrometheus `json:"add-on-prometheus,omitempty"`
+
+	// AddOnHPA defines parameters for EKS cluster
+	// add-on Horizontal Pod Autoscaler functional test.
+	AddOnHPA *AddOnHPA `json:"add-on-hpa,omitempty"`
+
+	// AddOnClusterLoader defines parameters for EKS cluster
+	// add-on built-in cluster loader (namespaces, Deployments,
+	// Secrets, and ConfigMaps churn).
+	AddOnClusterLoader *AddOnClusterLoader `json:"add-on-cluster-loader,omitempty"`
+
+	// AddOnCSIEBSChurn defines parameters for EKS cluster
+	// add-on EBS CSI volume churn.
+	AddOnCSIEBSChurn *AddOnCSIEBSChurn `json:"add-on-csi-ebs-churn,omitempty"`
+
+	// AddOnJobsThroughput defines parameters for EKS cluster
+	// add-on Job and CronJob throughput.
+	AddOnJobsThroughput *AddOnJobsThroughput `json:"add-on-jobs-throughput,omitempty"`
+
 	// Spec contains addons and other configuration
 	// Note: New addons should be implemented inside spec
 	Spec Spec `json:"spec,omitempty"`
@@ -423,6 +679,36 @@ type S3 struct {
 	BucketName string `json:"bucket-name"`
 	// BucketLifecycleExpirationDays is expiration in days for the lifecycle of the object.
 	BucketLifecycleExpirationDays int64 `json:"bucket-lifecycle-expiration-days"`
+	// BucketVersioning is true to enable versioning on the auto-created S3 bucket,
+	// so artifacts overwritten by later runs remain retrievable.
+	BucketVersioning bool `json:"bucket-versioning"`
+	// BucketObjectLock is true to enable object lock on the auto-created S3 bucket.
+	// Object lock can only be enabled at bucket creation, and implies versioning.
+	BucketObjectLock bool `json:"bucket-object-lock"`
+	// BucketObjectLockRetentionDays is the default retention in days
+	// for new objects, in "GOVERNANCE" mode.
+	BucketObjectLockRetentionDays int64 `json:"bucket-object-lock-retention-days"`
+
+	// RequesterPays is true to pay for the requests to a requester pays bucket.
+	RequesterPays bool `json:"requester-pays"`
+	// ExpectedBucketOwner is the account ID of the bucket owner, for a bucket
+	// shared across accounts. Requests fail if the bucket is owned by a different
+	// account, and uploaded objects grant full control to the bucket owner.
+	ExpectedBucketOwner string `json:"expected-bucket-owner"`
+	// RoleARN is the IAM role to assume only for S3 requests
+	// (e.g. to write to a bucket in a central account),
+	// without changing the credentials of other clients.
+	RoleARN string `json:"role-arn"`
+	// RoleExternalID is the external ID to assume "RoleARN".
+	RoleExternalID string `json:"role-external-id"`
+
+	// RetryMaxAttempts is the maximum number of attempts of an S3 request,
+	// including the first one. Zero to keep the SDK default.
+	RetryMaxAttempts int `json:"retry-max-attempts"`
+	// RetryMaxBackoff is the maximum backoff between S3 request retries.
+	// Zero to keep the SDK default.
+	RetryMaxBackoff       time.Duration `json:"retry-max-backoff"`
+	RetryMaxBackoffString string        `json:"retry-max-backoff-string" read-only:"true"`
 }
 
 func getDefaultS3() *S3 {
@@ -442,11 +728,20 @@ type Encryption struct {
 	// If not empty, the cluster is created with encryption feature
 	// enabled.
 	CMKARN string `json:"cmk-arn"`
+	// CMKPendingWindowInDays is the waiting period before the auto-created
+	// CMK is deleted, scheduled on cluster deletion. Must be 7 to 30 days.
+	// ref. https://docs.aws.amazon.com/kms/latest/developerguide/deleting-keys.html
+	CMKPendingWindowInDays int `json:"cmk-pending-window-in-days"`
+
+	// SecretsEncryptionVerified is true once the cluster reports the CMK for
+	// "secrets" encryption, and a Secret is written and read back.
+	SecretsEncryptionVerified bool `json:"secrets-encryption-verified" read-only:"true"`
 }
 
 func getDefaultEncryption() *Encryption {
 	return &Encryption{
-		CMKCreate: true,
+		CMKCreate:              true,
+		CMKPendingWindowInDays: 7,
 	}
 }
 
@@ -468,6 +763,18 @@ type Role struct {
 	PolicyName string `json:"policy-name" read-only:"true"`
 	// PolicyARN is the attached policy ARN.
 	PolicyARN string `json:"policy-arn" read-only:"true"`
+	// PolicyLeastPrivilege is true to only grant the permissions of the
+	// enabled add-ons in the created policy (e.g. EBS volume actions only
+	// with the EBS CSI add-on), rather than the permissions of all add-ons.
+	// Only supported for the node group roles.
+	PolicyLeastPrivilege bool `json:"policy-least-privilege"`
+	// PolicyPath is the output path of the created policy document,
+	// uploaded to S3 with the other artifacts.
+	PolicyPath string `json:"policy-path" read-only:"true"`
+	// PermissionsBoundaryARN is the managed policy ARN to set as the
+	// permissions boundary of the created role, to cap its permissions
+	// (e.g. required by the account's security policy).
+	PermissionsBoundaryARN string `json:"permissions-boundary-arn,omitempty"`
 
 	// InstanceProfileName is the instance profile name for the node group.
 	InstanceProfileName string `json:"instance-profile-name" read-only:"true"`
@@ -475,6 +782,22 @@ type Role struct {
 	InstanceProfileARN string `json:"instance-profile-arn" read-only:"true"`
 }
 
+// validatePolicy sets the default policy document output path,
+// and validates the permissions boundary.
+func (r *Role) validatePolicy(cfg *Config, field string, pathSuffix string) error {
+	if r.PolicyPath == "" {
+		r.PolicyPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + pathSuffix
+	}
+	if r.PermissionsBoundaryARN != "" &&
+		(!strings.HasPrefix(r.PermissionsBoundaryARN, "arn:") || !strings.Contains(r.PermissionsBoundaryARN, ":policy/")) {
+		return fmt.Errorf("invalid %s.PermissionsBoundaryARN %q (expected a managed policy ARN)", field, r.PermissionsBoundaryARN)
+	}
+	if r.PermissionsBoundaryARN != "" && !r.Create {
+		return fmt.Errorf("%s.PermissionsBoundaryARN %q requires %s.Create 'true'", field, r.PermissionsBoundaryARN, field)
+	}
+	return nil
+}
+
 func getDefaultRole() *Role {
 	return &Role{
 		Create: true,
@@ -499,20 +822,32 @@ func getDefaultRole() *Role {
 
 type VPC struct {
 	// Create is true to auto-create and delete VPC.
+	// The VPC, subnets, internet and NAT gateways, and route tables are
+	// created directly with the EC2 API (not CloudFormation), each tagged
+	// with the run tag for the leak check.
+	// If false, the existing VPC, subnets, and security group are reused,
+	// and never deleted on cluster deletion.
 	Create bool `json:"create"`
 	// ID is the VPC ID for cluster creation.
 	// If not empty, VPC is reused and not deleted.
 	// If empty, VPC is created anew and deleted on cluster deletion.
-	ID              string `json:"id"`
-	SecurityGroupID string `json:"security-group-id" read-only:"true"`
+	ID string `json:"id"`
+	// SecurityGroupID is the control plane security group ID.
+	// Only set with "Create" false to reuse an existing security group.
+	// If empty, a non-default security group in the VPC is used.
+	SecurityGroupID string `json:"security-group-id"`
 
 	// CIDRs is the list of CIDR blocks with IP range (CIDR notation) for the primary VPC Block.
 	// Must be a valid RFC 1918 CIDR range.
 	CIDRs []string `json:"cidrs"`
 
 	// PublicSubnetCIDRs is the CIDR blocks for public subnets.
-	PublicSubnetCIDRs                    []string `json:"public-subnet-cidrs"`
-	PublicSubnetIDs                      []string `json:"public-subnet-ids" read-only:"true"`
+	PublicSubnetCIDRs []string `json:"public-subnet-cidrs"`
+	// PublicSubnetIDs is the list of public subnet IDs.
+	// Only set with "Create" false to reuse existing subnets.
+	// If both public and private subnet IDs are empty, the subnets
+	// in the VPC are discovered by the "Network" tag.
+	PublicSubnetIDs                      []string `json:"public-subnet-ids"`
 	InternetGatewayID                    string   `json:"internet-gateway-id" read-only:"true"`
 	PublicRouteTableID                   string   `json:"public-route-table-id" read-only:"true"`
 	PublicSubnetRouteTableAssociationIDs []string `json:"public-subnet-route-table-association-ids" read-only:"true"`
@@ -520,8 +855,10 @@ type VPC struct {
 	NATGatewayIDs                        []string `json:"nat-gateway-ids" read-only:"true"`
 
 	// PrivateSubnetCIDRs is the CIDR blocks for private subnets.
-	PrivateSubnetCIDRs                    []string `json:"private-subnet-cidrs,omitempty"`
-	PrivateSubnetIDs                      []string `json:"private-subnet-ids" read-only:"true"`
+	PrivateSubnetCIDRs []string `json:"private-subnet-cidrs,omitempty"`
+	// PrivateSubnetIDs is the list of private subnet IDs.
+	// Only set with "Create" false to reuse existing subnets.
+	PrivateSubnetIDs                      []string `json:"private-subnet-ids"`
 	PrivateRouteTableIDs                  []string `json:"private-route-table-ids" read-only:"true"`
 	PrivateSubnetRouteTableAssociationIDs []string `json:"private-subnet-route-table-association-ids" read-only:"true"`
 
@@ -540,11 +877,41 @@ type VPC struct {
 	NodeGroupSecurityGroupName string `json:"node-group-security-group-name" read-only:"true"`
 	// NodeGroupSecurityGroupID is the security group ID for the node group.
 	NodeGroupSecurityGroupID string `json:"node-group-security-group-id" read-only:"true"`
+
+	// IPv6CIDR is the Amazon-provided IPv6 CIDR block of the VPC,
+	// only associated when IPFamily is "ipv6".
+	IPv6CIDR string `json:"ipv6-cidr" read-only:"true"`
+	// EgressOnlyInternetGatewayID is the egress-only internet gateway
+	// for the IPv6 traffic from private subnets.
+	EgressOnlyInternetGatewayID string `json:"egress-only-internet-gateway-id" read-only:"true"`
+
+	// CreateEndpoints is true to create VPC endpoints (PrivateLink) for the
+	// "EndpointServices", so that nodes in the private subnets can pull images
+	// and register without internet access.
+	// ref. https://docs.aws.amazon.com/eks/latest/userguide/private-clusters.html
+	CreateEndpoints bool `json:"create-endpoints"`
+	// EndpointServices is the list of AWS services to create VPC endpoints for
+	// (e.g. "ecr.api" for "com.amazonaws.[REGION].ecr.api"). "s3" creates a
+	// gateway endpoint, and the others create interface endpoints.
+	EndpointServices        []string `json:"endpoint-services"`
+	EndpointSecurityGroupID string   `json:"endpoint-security-group-id" read-only:"true"`
+	EndpointIDs             []string `json:"endpoint-ids" read-only:"true"`
+	// DisableNATGateways is true to not create NAT gateways, leaving the private
+	// subnets without internet access, and to launch node groups in the private
+	// subnets. Requires "CreateEndpoints".
+	DisableNATGateways bool `json:"disable-nat-gateways"`
+
+	// SubnetMinAvailableIPs is the minimum number of available IP addresses
+	// in each existing subnet, when "Create" is false. EKS requires at least 6
+	// for the cluster network interfaces, and recommends 16.
+	// ref. https://docs.aws.amazon.com/eks/latest/userguide/network_reqs.html
+	SubnetMinAvailableIPs int `json:"subnet-min-available-ips"`
 }
 
 func getDefaultVPC() *VPC {
 	return &VPC{
-		Create: true,
+		Create:                true,
+		SubnetMinAvailableIPs: 16,
 		CIDRs: []string{
 			"10.0.0.0/16",
 			"10.1.0.0/16",
@@ -560,11 +927,108 @@ func getDefaultVPC() *VPC {
 			"10.3.0.0/17",
 			"10.3.128.0/17",
 		},
+		EndpointServices: []string{
+			"ecr.api",
+			"ecr.dkr",
+			"s3",
+			"ec2",
+			"sts",
+			"logs",
+		},
+	}
+}
+
+// NodeSubnetIDs returns the subnets to launch node groups in.
+func (v *VPC) NodeSubnetIDs() []string {
+	if v.DisableNATGateways {
+		return v.PrivateSubnetIDs
+	}
+	return v.PublicSubnetIDs
+}
+
+// Endpoint defines the cluster API server endpoint access.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/cluster-endpoint.html
+type Endpoint struct {
+	// PublicAccess is true to enable the public API server endpoint.
+	PublicAccess bool `json:"public-access"`
+	// PrivateAccess is true to enable the private API server endpoint,
+	// reachable only from within the VPC.
+	PrivateAccess bool `json:"private-access"`
+
+	// TunnelInstanceType is the instance type of the in-VPC runner, created
+	// when PublicAccess is false. All kubectl and Kubernetes client requests
+	// are forwarded to the private endpoint through an SSM port forwarding
+	// session to the runner, which requires "session-manager-plugin".
+	// ref. https://docs.aws.amazon.com/systems-manager/latest/userguide/session-manager-working-with-install-plugin.html
+	TunnelInstanceType string `json:"tunnel-instance-type"`
+	// TunnelImageIDSSMParameter is the SSM parameter to look up the runner AMI.
+	TunnelImageIDSSMParameter string `json:"tunnel-image-id-ssm-parameter"`
+	// TunnelLocalPort is the local port forwarded to the private endpoint.
+	TunnelLocalPort int `json:"tunnel-local-port"`
+
+	TunnelRoleName            string `json:"tunnel-role-name" read-only:"true"`
+	TunnelInstanceProfileName string `json:"tunnel-instance-profile-name" read-only:"true"`
+	TunnelInstanceID          string `json:"tunnel-instance-id" read-only:"true"`
+	// TunnelServer is the local address of the tunnel, written as the
+	// KUBECONFIG server.
+	TunnelServer string `json:"tunnel-server" read-only:"true"`
+}
+
+func getDefaultEndpoint() *Endpoint {
+	return &Endpoint{
+		PublicAccess:              true,
+		PrivateAccess:             false,
+		TunnelInstanceType:        "t3.micro",
+		TunnelImageIDSSMParameter: "/aws/service/ami-amazon-linux-latest/al2023-ami-kernel-default-x86_64",
+		TunnelLocalPort:           8443,
 	}
 }
 
-// Load loads configuration from YAML.
-// Useful when injecting shared configuration via ConfigMap.
+// IsPrivateOnly returns true if the API server endpoint is
+// only reachable from within the VPC.
+func (e *Endpoint) IsPrivateOnly() bool {
+	return e != nil && !e.PublicAccess && e.PrivateAccess
+}
+
+// ControlPlaneLogging defines the EKS control plane logs exported to CloudWatch.
+// ref. https://docs.aws.amazon.com/eks/latest/userguide/control-plane-logs.html
+type ControlPlaneLogging struct {
+	// Types is the list of control plane log types to enable.
+	// Valid types are "api", "audit", "authenticator", "controllerManager",
+	// and "scheduler". Leave empty to disable control plane logging.
+	Types []string `json:"types"`
+	// FetchLogs is true to download the log streams of the enabled types,
+	// from cluster creation to now, into the artifact directory.
+	FetchLogs bool `json:"fetch-logs"`
+
+	// LogGroupName is the CloudWatch log group that EKS writes to.
+	LogGroupName string `json:"log-group-name" read-only:"true"`
+}
+
+// ControlPlaneLogTypes is the set of valid control plane log types.
+var ControlPlaneLogTypes = map[string]struct{}{
+	"api":               {},
+	"audit":             {},
+	"authenticator":     {},
+	"controllerManager": {},
+	"scheduler":         {},
+}
+
+func getDefaultControlPlaneLogging() *ControlPlaneLogging {
+	return &ControlPlaneLogging{
+		FetchLogs: true,
+	}
+}
+
+// IsEnabled returns true if any control plane log type is enabled.
+func (l *ControlPlaneLogging) IsEnabled() bool {
+	return l != nil && len(l.Types) > 0
+}
+
+// Load loads configuration from YAML, JSON (".json"), or HCL (".hcl"),
+// with the same validation. Useful when injecting shared configuration
+// via ConfigMap. The HCL configuration is not written back, and the
+// configuration and status are written to "<path>.yaml" instead.
 //
 // Example usage:
 //
@@ -572,15 +1036,26 @@ func getDefaultVPC() *VPC {
 //	cfg := eksconfig.Load("test.yaml")
 //	err := cfg.ValidateAndSetDefaults()
 //
+// The configuration of the older "apiVersion" is migrated
+// to the current one (see "Migrate"), and written back.
+//
 // Do not set default values in this function.
 // "ValidateAndSetDefaults" must be called separately,
 // to prevent overwriting previous data when loaded from disks.
 func Load(p string) (cfg *Config, err error) {
 	var d []byte
-	d, err = ioutil.ReadFile(p)
+	d, err = configfile.Read(p)
+	if err != nil {
+		return nil, err
+	}
+	var applied []string
+	d, applied, err = Migrate(d)
 	if err != nil {
 		return nil, err
 	}
+	for _, a := range applied {
+		fmt.Fprintf(os.Stderr, "[WARN] migrated configuration %q (%s)\n", p, a)
+	}
 	cfg = new(Config)
 	if err = yaml.Unmarshal(d, cfg, yaml.DisallowUnknownFields); err != nil {
 		return nil, err
@@ -595,7 +1070,10 @@ func Load(p string) (cfg *Config, err error) {
 	if err != nil {
 		return nil, err
 	}
-	cfg.ConfigPath = ap
+	cfg.ConfigPath = configfile.SyncPath(ap)
+	if cfg.ConfigPath != ap {
+		fmt.Fprintf(os.Stderr, "[WARN] writing configuration %q to %q\n", p, cfg.ConfigPath)
+	}
 	if serr := cfg.unsafeSync(); serr != nil {
 		fmt.Fprintf(os.Stderr, "[WARN] failed to sync config files %v\n", serr)
 	}
@@ -661,6 +1139,22 @@ func (cfg *Config) Sync() (err error) {
 	return cfg.unsafeSync()
 }
 
+// Snapshot writes the configuration to "p", in the format of "ConfigPath",
+// so that it is not torn by the concurrent syncs (e.g. to upload while
+// the add-ons are being deleted).
+func (cfg *Config) Snapshot(p string) error {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+	d, err := configfile.Marshal(cfg.ConfigPath, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration %v", err)
+	}
+	if err = ioutil.WriteFile(p, d, 0600); err != nil {
+		return fmt.Errorf("failed to write file %q (%v)", p, err)
+	}
+	return nil
+}
+
 func (cfg *Config) unsafeSync() (err error) {
 	var p string
 	if cfg.ConfigPath != "" && !filepath.IsAbs(cfg.ConfigPath) {
@@ -679,9 +1173,9 @@ func (cfg *Config) unsafeSync() (err error) {
 	}
 
 	var d []byte
-	d, err = yaml.Marshal(cfg)
+	d, err = configfile.Marshal(cfg.ConfigPath, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to 'yaml.Marshal' %v", err)
+		return fmt.Errorf("failed to marshal configuration %v", err)
 	}
 	err = ioutil.WriteFile(cfg.ConfigPath, d, 0600)
 	if err != nil {
@@ -816,6 +1310,52 @@ func (cfg *Config) unsafeSSHCommands() (s string) {
 	return buf.String()
 }
 
+// Instance returns the node group instance by the instance ID,
+// from "AddOnNodeGroups.ASGs" or "AddOnManagedNodeGroups.MNGs".
+func (cfg *Config) Instance(instanceID string) (ec2config.Instance, bool) {
+	if cfg.IsEnabledAddOnNodeGroups() {
+		for _, cur := range cfg.AddOnNodeGroups.ASGs {
+			if inst, ok := cur.Instances[instanceID]; ok {
+				return inst, true
+			}
+		}
+	}
+	if cfg.IsEnabledAddOnManagedNodeGroups() {
+		for _, cur := range cfg.AddOnManagedNodeGroups.MNGs {
+			if inst, ok := cur.Instances[instanceID]; ok {
+				return inst, true
+			}
+		}
+	}
+	return ec2config.Instance{}, false
+}
+
+const (
+	// NodeTransportSSM runs the commands on the nodes with SSM Run Command.
+	// Requires the SSM agent on the nodes.
+	NodeTransportSSM = NodeFaultTransportSSM
+	// NodeTransportSSH runs the commands on the nodes over SSH.
+	// Requires the remote access (e.g. "RemoteAccessPrivateKeyPath").
+	NodeTransportSSH = NodeFaultTransportSSH
+)
+
+// validateNodeTransport defaults the transport to run the commands
+// on the nodes, and validates the remote access for SSH.
+func (cfg *Config) validateNodeTransport(field string, transport *string) error {
+	switch *transport {
+	case "":
+		*transport = NodeTransportSSM
+	case NodeTransportSSM:
+	case NodeTransportSSH:
+		if cfg.RemoteAccessPrivateKeyPath == "" && !cfg.RemoteAccessInstanceConnect {
+			return fmt.Errorf("%s %q but no RemoteAccessPrivateKeyPath or RemoteAccessInstanceConnect", field, *transport)
+		}
+	default:
+		return fmt.Errorf("%s %q unknown (must be %q or %q)", field, *transport, NodeTransportSSM, NodeTransportSSH)
+	}
+	return nil
+}
+
 const (
 	// DefaultClients is the default number of clients to create.
 	DefaultClients = 2
@@ -825,10 +1365,24 @@ const (
 	DefaultClientBurst = 20
 	// DefaultClientTimeout is the default client timeout.
 	DefaultClientTimeout = 15 * time.Second
+	// DefaultClientMaxRetries is the default number of retries for the throttled requests.
+	DefaultClientMaxRetries = k8s_client.DefaultClientMaxRetries
+	// DefaultClientReadyzInterval is the default interval to sample kube-apiserver "/readyz".
+	DefaultClientReadyzInterval = k8s_client.DefaultReadyzInterval
+
+	// DefaultSoakInterval is the default interval between the soak iterations.
+	DefaultSoakInterval = 10 * time.Minute
 
 	DefaultCommandAfterCreateClusterTimeout = 3 * time.Minute
 	DefaultCommandAfterCreateAddOnsTimeout  = 3 * time.Minute
 
+	// DefaultDeleteConcurrency is the default number of add-ons and node groups
+	// to delete concurrently.
+	DefaultDeleteConcurrency = 5
+	// DefaultCreateConcurrency is the default number of add-ons
+	// to create concurrently.
+	DefaultCreateConcurrency = 5
+
 	// DefaultNodeInstanceTypeCPU is the default EC2 instance type for CPU worker node.
 	DefaultNodeInstanceTypeCPU = "c5.xlarge"
 	// DefaultNodeInstanceTypeARMCPU is the default EC2 instance type for ARM CPU worker node.
@@ -846,11 +1400,33 @@ const (
 	NGsMaxLimit = 10
 	// NGMaxLimit is the maximum number of nodes per a "Node Group".
 	NGMaxLimit = 5000
+	// NGMixedInstanceTypesMaxLimit is the maximum number of instance types
+	// per a "Node Group" mixed instances policy.
+	NGMixedInstanceTypesMaxLimit = 40
 
 	// MNGsMaxLimit is the maximum number of "Managed Node Group"s per a EKS cluster.
 	MNGsMaxLimit = 10
 	// MNGMaxLimit is the maximum number of nodes per a "Managed Node Group".
 	MNGMaxLimit = 100
+
+	// RemoteAccessHostKeyCheckInsecureSkipVerify skips node SSH host key verification.
+	RemoteAccessHostKeyCheckInsecureSkipVerify = "insecure-skip-verify"
+	// RemoteAccessHostKeyCheckConsoleOutput pins node SSH host keys from EC2 console output.
+	RemoteAccessHostKeyCheckConsoleOutput = "console-output"
+	// RemoteAccessHostKeyCheckSSM pins node SSH host keys fetched with SSM Run Command.
+	RemoteAccessHostKeyCheckSSM = "ssm"
+
+	// IPFamilyIPv4 assigns IPv4 addresses to Pods and Services.
+	IPFamilyIPv4 = "ipv4"
+	// IPFamilyIPv6 assigns IPv6 addresses to Pods and Services.
+	IPFamilyIPv6 = "ipv6"
+
+	// DefaultFetchLogsFanOut is the default number of concurrent commands
+	// per node when fetching logs.
+	DefaultFetchLogsFanOut = 5
+	// FetchLogsFanOutMaxLimit is the maximum number of concurrent commands
+	// per node, limited by the sshd default "MaxSessions 10".
+	FetchLogsFanOutMaxLimit = 10
 )
 
 // NewDefault returns a default configuration.
@@ -866,6 +1442,11 @@ func NewDefault() *Config {
 	cfg := Config{
 		mu: new(sync.RWMutex),
 
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: APIVersion,
+			Kind:       Kind,
+		},
+
 		Name:      name,
 		Partition: "aws",
 		Region:    "us-west-2",
@@ -896,16 +1477,30 @@ func NewDefault() *Config {
 		CWNamespace: "aws-k8s-tester-eks",
 
 		SkipDeleteClusterAndNodes: false,
+		DeleteConcurrency:         DefaultDeleteConcurrency,
+		CreateConcurrency:         DefaultCreateConcurrency,
 
 		S3:         getDefaultS3(),
 		Encryption: getDefaultEncryption(),
 		Role:       getDefaultRole(),
 		VPC:        getDefaultVPC(),
+		Endpoint:   getDefaultEndpoint(),
+
+		ControlPlaneLogging: getDefaultControlPlaneLogging(),
+		Notifications:       getDefaultNotifications(),
+		Access:              getDefaultAccess(),
+		ImageMirror:         getDefaultImageMirror(),
+		Hooks:               getDefaultHooks(),
+		CNIConfig:           getDefaultCNIConfig(),
+		AssumeRole:          getDefaultAssumeRole(),
+		ServiceEndpoints:    getDefaultServiceEndpoints(),
 
 		SigningName: "eks",
 		Version:     "1.27",
+		IPFamily:    IPFamilyIPv4,
 
-		RemoteAccessKeyCreate: true,
+		RemoteAccessKeyCreate:    true,
+		RemoteAccessHostKeyCheck: RemoteAccessHostKeyCheckInsecureSkipVerify,
 		// keep in-sync with the default value in https://pkg.go.dev/k8s.io/kubernetes/test/e2e/framework#GetSigner
 		// RemoteAccessPrivateKeyPath: filepath.Join(homedir.HomeDir(), ".ssh", "kube_aws_rsa"),
 		RemoteAccessPrivateKeyPath: filepath.Join(os.TempDir(), randutil.String(15)+".insecure.key"),
@@ -923,6 +1518,9 @@ func NewDefault() *Config {
 		ClientQPS:   DefaultClientQPS,
 		ClientBurst: DefaultClientBurst,
 
+		ClientMaxRetries:     DefaultClientMaxRetries,
+		ClientReadyzInterval: DefaultClientReadyzInterval,
+
 		AddOnCNIVPC:            getDefaultAddOnCNIVPC(),
 		AddOnNodeGroups:        getDefaultAddOnNodeGroups(name),
 		AddOnManagedNodeGroups: getDefaultAddOnManagedNodeGroups(name),
@@ -963,7 +1561,35 @@ func NewDefault() *Config {
 		AddOnStresserRemote:        getDefaultAddOnStresserRemote(),
 		AddOnStresserRemoteV2:      getDefaultAddOnStresserRemoteV2(),
 		AddOnClusterVersionUpgrade: getDefaultAddOnClusterVersionUpgrade(),
+		AddOnVersionSkew:           getDefaultAddOnVersionSkew(),
 		AddOnAmiSoftLockupIssue454: getDefaultAddOnAmiSoftLockupIssue454(),
+		AddOnKarpenter:             getDefaultAddOnKarpenter(),
+		AddOnSpotInterruption:      getDefaultAddOnSpotInterruption(),
+		AddOnGPU:                   getDefaultAddOnGPU(),
+		AddOnMultiArch:             getDefaultAddOnMultiArch(),
+		AddOnALB:                   getDefaultAddOnALB(),
+		AddOnCSIEFS:                getDefaultAddOnCSIEFS(),
+		AddOnFSxLustre:             getDefaultAddOnFSxLustre(),
+		AddOnOIDCIdentityProvider:  getDefaultAddOnOIDCIdentityProvider(),
+		AddOnPodIdentity:           getDefaultAddOnPodIdentity(),
+		AddOnChaos:                 getDefaultAddOnChaos(),
+		AddOnNodeFault:             getDefaultAddOnNodeFault(),
+		AddOnECR:                   getDefaultAddOnECR(),
+		AddOnCustomManifests:       getDefaultAddOnCustomManifests(),
+		AddOnCNIVersionMatrix:      getDefaultAddOnCNIVersionMatrix(),
+		AddOnPodDensity:            getDefaultAddOnPodDensity(),
+		AddOnKubeProxyModes:        getDefaultAddOnKubeProxyModes(),
+		AddOnCoreDNSScale:          getDefaultAddOnCoreDNSScale(),
+		AddOnServiceChurn:          getDefaultAddOnServiceChurn(),
+		AddOnManagedAddOns:         getDefaultAddOnManagedAddOns(),
+		AddOnCustomNetworking:      getDefaultAddOnCustomNetworking(),
+		AddOnNetworkPolicy:         getDefaultAddOnNetworkPolicy(),
+		AddOnContainerInsights:     getDefaultAddOnContainerInsights(),
+		AddOnPrometheus:            getDefaultAddOnPrometheus(),
+		AddOnHPA:                   getDefaultAddOnHPA(),
+		AddOnClusterLoader:         getDefaultAddOnClusterLoader(),
+		AddOnCSIEBSChurn:           getDefaultAddOnCSIEBSChurn(),
+		AddOnJobsThroughput:        getDefaultAddOnJobsThroughput(),
 
 		// read-only
 		Status: &Status{
@@ -1023,6 +1649,10 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 	if err := cfg.validateAddOnNodeGroups(); err != nil {
 		return fmt.Errorf("validateAddOnNodeGroups failed [%v]", err)
 	}
+	// must be run before validating managed node groups
+	if err := cfg.validateAddOnGPU(); err != nil {
+		return fmt.Errorf("validateAddOnGPU failed [%v]", err)
+	}
 	if err := cfg.validateAddOnManagedNodeGroups(); err != nil {
 		return fmt.Errorf("validateAddOnManagedNodeGroups failed [%v]", err)
 	}
@@ -1157,10 +1787,93 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 		return fmt.Errorf("validateAddOnClusterVersionUpgrade failed [%v]", err)
 	}
 
+	if err := cfg.validateAddOnVersionSkew(); err != nil {
+		return fmt.Errorf("validateAddOnVersionSkew failed [%v]", err)
+	}
+
 	if err := cfg.validateAddOnAmiSoftLockupIssue454(); err != nil {
 		return fmt.Errorf("validateAddOnClusterVersionUpgrade failed [%v]", err)
 	}
 
+	if err := cfg.validateAddOnKarpenter(); err != nil {
+		return fmt.Errorf("validateAddOnKarpenter failed [%v]", err)
+	}
+	if err := cfg.validateAddOnSpotInterruption(); err != nil {
+		return fmt.Errorf("validateAddOnSpotInterruption failed [%v]", err)
+	}
+	if err := cfg.validateAddOnMultiArch(); err != nil {
+		return fmt.Errorf("validateAddOnMultiArch failed [%v]", err)
+	}
+	if err := cfg.validateAddOnALB(); err != nil {
+		return fmt.Errorf("validateAddOnALB failed [%v]", err)
+	}
+	if err := cfg.validateAddOnCSIEFS(); err != nil {
+		return fmt.Errorf("validateAddOnCSIEFS failed [%v]", err)
+	}
+	if err := cfg.validateAddOnFSxLustre(); err != nil {
+		return fmt.Errorf("validateAddOnFSxLustre failed [%v]", err)
+	}
+	if err := cfg.validateAddOnOIDCIdentityProvider(); err != nil {
+		return fmt.Errorf("validateAddOnOIDCIdentityProvider failed [%v]", err)
+	}
+	if err := cfg.validateAddOnPodIdentity(); err != nil {
+		return fmt.Errorf("validateAddOnPodIdentity failed [%v]", err)
+	}
+	if err := cfg.validateAddOnChaos(); err != nil {
+		return fmt.Errorf("validateAddOnChaos failed [%v]", err)
+	}
+	if err := cfg.validateAddOnNodeFault(); err != nil {
+		return fmt.Errorf("validateAddOnNodeFault failed [%v]", err)
+	}
+	if err := cfg.validateAddOnECR(); err != nil {
+		return fmt.Errorf("validateAddOnECR failed [%v]", err)
+	}
+	if err := cfg.validateAddOnCustomManifests(); err != nil {
+		return fmt.Errorf("validateAddOnCustomManifests failed [%v]", err)
+	}
+	if err := cfg.validateAddOnCNIVersionMatrix(); err != nil {
+		return fmt.Errorf("validateAddOnCNIVersionMatrix failed [%v]", err)
+	}
+	if err := cfg.validateAddOnPodDensity(); err != nil {
+		return fmt.Errorf("validateAddOnPodDensity failed [%v]", err)
+	}
+	if err := cfg.validateAddOnKubeProxyModes(); err != nil {
+		return fmt.Errorf("validateAddOnKubeProxyModes failed [%v]", err)
+	}
+	if err := cfg.validateAddOnCoreDNSScale(); err != nil {
+		return fmt.Errorf("validateAddOnCoreDNSScale failed [%v]", err)
+	}
+	if err := cfg.validateAddOnServiceChurn(); err != nil {
+		return fmt.Errorf("validateAddOnServiceChurn failed [%v]", err)
+	}
+	if err := cfg.validateAddOnManagedAddOns(); err != nil {
+		return fmt.Errorf("validateAddOnManagedAddOns failed [%v]", err)
+	}
+	if err := cfg.validateAddOnCustomNetworking(); err != nil {
+		return fmt.Errorf("validateAddOnCustomNetworking failed [%v]", err)
+	}
+	if err := cfg.validateAddOnNetworkPolicy(); err != nil {
+		return fmt.Errorf("validateAddOnNetworkPolicy failed [%v]", err)
+	}
+	if err := cfg.validateAddOnContainerInsights(); err != nil {
+		return fmt.Errorf("validateAddOnContainerInsights failed [%v]", err)
+	}
+	if err := cfg.validateAddOnPrometheus(); err != nil {
+		return fmt.Errorf("validateAddOnPrometheus failed [%v]", err)
+	}
+	if err := cfg.validateAddOnHPA(); err != nil {
+		return fmt.Errorf("validateAddOnHPA failed [%v]", err)
+	}
+	if err := cfg.validateAddOnClusterLoader(); err != nil {
+		return fmt.Errorf("validateAddOnClusterLoader failed [%v]", err)
+	}
+	if err := cfg.validateAddOnCSIEBSChurn(); err != nil {
+		return fmt.Errorf("validateAddOnCSIEBSChurn failed [%v]", err)
+	}
+	if err := cfg.validateAddOnJobsThroughput(); err != nil {
+		return fmt.Errorf("validateAddOnJobsThroughput failed [%v]", err)
+	}
+
 	return nil
 }
 
@@ -1169,6 +1882,19 @@ func (cfg *Config) ValidateAndSetDefaults() error {
 // the check will be done in "eks" with AWS API call
 // ref. https://aws.github.io/aws-sdk-go-v2/docs/migrating/
 func (cfg *Config) validateConfig() error {
+	if cfg.APIVersion == "" {
+		cfg.APIVersion = APIVersion
+	}
+	if cfg.APIVersion != APIVersion {
+		return fmt.Errorf("unknown apiVersion %q (expected %q)", cfg.APIVersion, APIVersion)
+	}
+	if cfg.Kind == "" {
+		cfg.Kind = Kind
+	}
+	if cfg.Kind != Kind {
+		return fmt.Errorf("unknown kind %q (expected %q)", cfg.Kind, Kind)
+	}
+
 	if len(cfg.Name) == 0 {
 		return errors.New("name is empty")
 	}
@@ -1176,6 +1902,13 @@ func (cfg *Config) validateConfig() error {
 		return fmt.Errorf("name %q must be in lower-case", cfg.Name)
 	}
 
+	if err := cfg.validatePartition(); err != nil {
+		return err
+	}
+	if err := cfg.validateServiceEndpoints(); err != nil {
+		return err
+	}
+
 	if cfg.LogColorOverride == "" {
 		_, cerr := terminal.IsColor()
 		if cfg.LogColor && cerr != nil {
@@ -1209,6 +1942,20 @@ func (cfg *Config) validateConfig() error {
 		cfg.ClientTimeout = DefaultClientTimeout
 	}
 	cfg.ClientTimeoutString = cfg.ClientTimeout.String()
+	if cfg.ClientMaxRetries == 0 {
+		cfg.ClientMaxRetries = DefaultClientMaxRetries
+	}
+	if cfg.ClientReadyzInterval == time.Duration(0) {
+		cfg.ClientReadyzInterval = DefaultClientReadyzInterval
+	}
+	cfg.ClientReadyzIntervalString = cfg.ClientReadyzInterval.String()
+
+	if cfg.DeleteConcurrency <= 0 {
+		cfg.DeleteConcurrency = DefaultDeleteConcurrency
+	}
+	if cfg.CreateConcurrency <= 0 {
+		cfg.CreateConcurrency = DefaultCreateConcurrency
+	}
 
 	if cfg.ConfigPath == "" {
 		rootDir, err := os.Getwd()
@@ -1256,6 +2003,18 @@ func (cfg *Config) validateConfig() error {
 		return fmt.Errorf("cannot parse Parameters.Version %q (%v)", cfg.Version, err)
 	}
 
+	switch cfg.IPFamily {
+	case "":
+		cfg.IPFamily = IPFamilyIPv4
+	case IPFamilyIPv4:
+	case IPFamilyIPv6:
+		if cfg.VersionValue < 1.21 {
+			return fmt.Errorf("IPFamily %q requires Version >= 1.21 (got %q)", cfg.IPFamily, cfg.Version)
+		}
+	default:
+		return fmt.Errorf("unknown IPFamily %q", cfg.IPFamily)
+	}
+
 	if len(cfg.Role.ServicePrincipals) == 0 {
 		return errors.New("empty Role.ServicePrincipals")
 	}
@@ -1279,14 +2038,15 @@ func (cfg *Config) validateConfig() error {
 		return fmt.Errorf("Role.ServicePrincipals missing 'eks.amazonaws.com' (%q)", cfg.Role.ServicePrincipals)
 	}
 	found = false
+	clusterPolicyARN := pkg_aws.PolicyARN(cfg.Partition, "AmazonEKSClusterPolicy")
 	for _, v := range cfg.Role.ManagedPolicyARNs {
-		if v == "arn:aws:iam::aws:policy/AmazonEKSClusterPolicy" {
+		if v == clusterPolicyARN {
 			found = true
 			break
 		}
 	}
 	if !found {
-		return fmt.Errorf("Role.ManagedPolicyARNs missing 'arn:aws:iam::aws:policy/AmazonEKSClusterPolicy' (%q)", cfg.Role.ManagedPolicyARNs)
+		return fmt.Errorf("Role.ManagedPolicyARNs missing '%s' (%q)", clusterPolicyARN, cfg.Role.ManagedPolicyARNs)
 	}
 
 	switch cfg.Role.Create {
@@ -1305,6 +2065,12 @@ func (cfg *Config) validateConfig() error {
 	if cfg.Role.PolicyName == "" {
 		cfg.Role.PolicyName = cfg.Name + "-policy"
 	}
+	if cfg.Role.PolicyLeastPrivilege {
+		return errors.New("Role.PolicyLeastPrivilege is only supported for the node group roles")
+	}
+	if err := cfg.Role.validatePolicy(cfg, "Role", ".role-policy.json"); err != nil {
+		return err
+	}
 
 	switch cfg.VPC.Create {
 	case true: // need create one, or already created
@@ -1315,6 +2081,16 @@ func (cfg *Config) validateConfig() error {
 		if cfg.VPC.ID == "" {
 			return fmt.Errorf("RoleCreate false; expect non-empty VPCID but got %q", cfg.VPC.ID)
 		}
+		if len(cfg.VPC.PublicSubnetIDs) == 0 && len(cfg.VPC.PrivateSubnetIDs) > 0 {
+			return fmt.Errorf("VPC.PrivateSubnetIDs %v requires non-empty VPC.PublicSubnetIDs", cfg.VPC.PrivateSubnetIDs)
+		}
+		if cfg.VPC.SubnetMinAvailableIPs < 6 {
+			return fmt.Errorf("VPC.SubnetMinAvailableIPs %d must be at least 6", cfg.VPC.SubnetMinAvailableIPs)
+		}
+	}
+	if cfg.VPC.Create && cfg.VPC.ID == "" &&
+		(len(cfg.VPC.PublicSubnetIDs) > 0 || len(cfg.VPC.PrivateSubnetIDs) > 0 || cfg.VPC.SecurityGroupID != "") {
+		return errors.New("VPC subnet and security group IDs are only supported with VPC.Create false and non-empty VPC.ID")
 	}
 
 	if cfg.VPC.NodeGroupSecurityGroupName == "" {
@@ -1324,11 +2100,108 @@ func (cfg *Config) validateConfig() error {
 		return fmt.Errorf("unexpected number of VPC.PublicSubnetCIDRs %v (expected at least 2)", cfg.VPC.PublicSubnetCIDRs)
 	}
 
+	if cfg.Endpoint == nil {
+		cfg.Endpoint = getDefaultEndpoint()
+	}
+	if !cfg.Endpoint.PublicAccess && !cfg.Endpoint.PrivateAccess {
+		return errors.New("Endpoint.PublicAccess and Endpoint.PrivateAccess cannot be both false")
+	}
+	if cfg.Endpoint.IsPrivateOnly() {
+		if cfg.AWSCLIPath == "" {
+			return errors.New("Endpoint.PublicAccess false requires AWSCLIPath for the SSM tunnel")
+		}
+		if cfg.Endpoint.TunnelInstanceType == "" {
+			cfg.Endpoint.TunnelInstanceType = "t3.micro"
+		}
+		if cfg.Endpoint.TunnelImageIDSSMParameter == "" {
+			return errors.New("empty Endpoint.TunnelImageIDSSMParameter")
+		}
+		if cfg.Endpoint.TunnelLocalPort <= 0 || cfg.Endpoint.TunnelLocalPort > 65535 {
+			return fmt.Errorf("invalid Endpoint.TunnelLocalPort %d", cfg.Endpoint.TunnelLocalPort)
+		}
+		if cfg.Endpoint.TunnelRoleName == "" {
+			cfg.Endpoint.TunnelRoleName = cfg.Name + "-endpoint-tunnel-role"
+		}
+		if cfg.Endpoint.TunnelInstanceProfileName == "" {
+			cfg.Endpoint.TunnelInstanceProfileName = cfg.Name + "-endpoint-tunnel-instance-profile"
+		}
+	}
+
+	if cfg.ControlPlaneLogging == nil {
+		cfg.ControlPlaneLogging = getDefaultControlPlaneLogging()
+	}
+	for _, typ := range cfg.ControlPlaneLogging.Types {
+		if _, ok := ControlPlaneLogTypes[typ]; !ok {
+			return fmt.Errorf("unknown ControlPlaneLogging.Types %q", typ)
+		}
+	}
+	if cfg.ControlPlaneLogging.IsEnabled() {
+		cfg.ControlPlaneLogging.LogGroupName = fmt.Sprintf("/aws/eks/%s/cluster", cfg.Name)
+	}
+
+	if err := cfg.validateNotifications(); err != nil {
+		return err
+	}
+	if err := cfg.validateAccess(); err != nil {
+		return err
+	}
+	if err := cfg.validateImageMirror(); err != nil {
+		return err
+	}
+	if err := cfg.validateCNIConfig(); err != nil {
+		return err
+	}
+	if err := cfg.validateAssumeRole(); err != nil {
+		return err
+	}
+
+	if cfg.VPC.CreateEndpoints {
+		if !cfg.VPC.Create {
+			return errors.New("VPC.CreateEndpoints requires VPC.Create true")
+		}
+		if len(cfg.VPC.EndpointServices) == 0 {
+			return errors.New("VPC.CreateEndpoints requires non-empty VPC.EndpointServices")
+		}
+	}
+	if cfg.VPC.DisableNATGateways {
+		if !cfg.VPC.CreateEndpoints {
+			return errors.New("VPC.DisableNATGateways requires VPC.CreateEndpoints true")
+		}
+		if len(cfg.VPC.PrivateSubnetCIDRs) < 2 {
+			return fmt.Errorf("VPC.DisableNATGateways requires at least 2 VPC.PrivateSubnetCIDRs (got %v)", cfg.VPC.PrivateSubnetCIDRs)
+		}
+		// nodes without internet access cannot reach the public endpoint
+		if !cfg.Endpoint.PrivateAccess {
+			return errors.New("VPC.DisableNATGateways requires Endpoint.PrivateAccess true")
+		}
+		// the endpoint tunnel instance registers with SSM from the private subnet
+		if cfg.Endpoint.IsPrivateOnly() {
+			for _, svc := range []string{"ssm", "ssmmessages", "ec2messages"} {
+				found := false
+				for _, v := range cfg.VPC.EndpointServices {
+					if v == svc {
+						found = true
+						break
+					}
+				}
+				if !found {
+					cfg.VPC.EndpointServices = append(cfg.VPC.EndpointServices, svc)
+				}
+			}
+		}
+	}
+
 	switch cfg.Encryption.CMKCreate {
 	case true: // need create one, or already created
 		// just ignore...
 		// could be populated from previous run
 		// do not error, so long as EncryptionCMKCreate false, CMK won't be deleted
+		if cfg.Encryption.CMKPendingWindowInDays == 0 {
+			cfg.Encryption.CMKPendingWindowInDays = 7
+		}
+		if cfg.Encryption.CMKPendingWindowInDays < 7 || cfg.Encryption.CMKPendingWindowInDays > 30 {
+			return fmt.Errorf("invalid Encryption.CMKPendingWindowInDays %d (expected 7 to 30)", cfg.Encryption.CMKPendingWindowInDays)
+		}
 	case false: // use existing one
 	}
 
@@ -1342,6 +2215,10 @@ func (cfg *Config) validateConfig() error {
 		}
 
 	case false: // use existing one
+		if cfg.RemoteAccessInstanceConnect {
+			// no key pair needed, ephemeral keys are pushed before connecting
+			break
+		}
 		if cfg.RemoteAccessKeyName == "" {
 			return fmt.Errorf("RemoteAccessKeyCreate false; expect non-empty RemoteAccessKeyName but got %q", cfg.RemoteAccessKeyName)
 		}
@@ -1352,6 +2229,17 @@ func (cfg *Config) validateConfig() error {
 			return fmt.Errorf("RemoteAccessPrivateKeyPath %q does not exist", cfg.RemoteAccessPrivateKeyPath)
 		}
 	}
+	if cfg.RemoteAccessBastionHost != "" {
+		if cfg.RemoteAccessBastionUserName == "" {
+			cfg.RemoteAccessBastionUserName = "ec2-user"
+		}
+		if cfg.RemoteAccessBastionPrivateKeyPath == "" {
+			cfg.RemoteAccessBastionPrivateKeyPath = cfg.RemoteAccessPrivateKeyPath
+		}
+		if cfg.RemoteAccessBastionPrivateKeyPath == "" {
+			return errors.New("RemoteAccessBastionHost requires non-empty RemoteAccessBastionPrivateKeyPath")
+		}
+	}
 	keyDir := filepath.Dir(cfg.RemoteAccessPrivateKeyPath)
 	if err := fileutil.IsDirWriteable(keyDir); err != nil {
 		return err
@@ -1369,6 +2257,18 @@ func (cfg *Config) validateConfig() error {
 	if cfg.RemoteAccessCommandsOutputPath == "" {
 		cfg.RemoteAccessCommandsOutputPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + ".ssh.sh"
 	}
+	switch cfg.RemoteAccessHostKeyCheck {
+	case "":
+		cfg.RemoteAccessHostKeyCheck = RemoteAccessHostKeyCheckInsecureSkipVerify
+	case RemoteAccessHostKeyCheckInsecureSkipVerify,
+		RemoteAccessHostKeyCheckConsoleOutput,
+		RemoteAccessHostKeyCheckSSM:
+	default:
+		return fmt.Errorf("unknown RemoteAccessHostKeyCheck %q", cfg.RemoteAccessHostKeyCheck)
+	}
+	if cfg.RemoteAccessKnownHostsPath == "" {
+		cfg.RemoteAccessKnownHostsPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + ".known_hosts"
+	}
 	if filepath.Ext(cfg.RemoteAccessCommandsOutputPath) != ".sh" {
 		cfg.RemoteAccessCommandsOutputPath = cfg.RemoteAccessCommandsOutputPath + ".sh"
 	}
@@ -1404,6 +2304,84 @@ func (cfg *Config) validateConfig() error {
 	}
 	cfg.CommandAfterCreateAddOnsTimeoutString = cfg.CommandAfterCreateAddOnsTimeout.String()
 
+	if err := cfg.validateHooks(); err != nil {
+		return err
+	}
+
+	for _, v := range []time.Duration{
+		cfg.RunTimeout,
+		cfg.ClusterCreateTimeout,
+		cfg.NodeGroupsCreateTimeout,
+		cfg.ManagedNodeGroupsCreateTimeout,
+		cfg.AddOnCreateTimeout,
+	} {
+		if v < 0 {
+			return fmt.Errorf("invalid negative timeout %v", v)
+		}
+	}
+	cfg.RunTimeoutString = cfg.RunTimeout.String()
+	if cfg.SoakDuration < 0 {
+		return fmt.Errorf("invalid negative SoakDuration %v", cfg.SoakDuration)
+	}
+	if cfg.SoakInterval <= 0 {
+		cfg.SoakInterval = DefaultSoakInterval
+	}
+	if cfg.SoakDuration > 0 && cfg.SoakInterval > cfg.SoakDuration {
+		return fmt.Errorf("SoakInterval %v must be <= SoakDuration %v", cfg.SoakInterval, cfg.SoakDuration)
+	}
+	cfg.SoakDurationString = cfg.SoakDuration.String()
+	cfg.SoakIntervalString = cfg.SoakInterval.String()
+	cfg.ClusterCreateTimeoutString = cfg.ClusterCreateTimeout.String()
+	cfg.NodeGroupsCreateTimeoutString = cfg.NodeGroupsCreateTimeout.String()
+	cfg.ManagedNodeGroupsCreateTimeoutString = cfg.ManagedNodeGroupsCreateTimeout.String()
+	cfg.AddOnCreateTimeoutString = cfg.AddOnCreateTimeout.String()
+
+	if cfg.ReportJUnitXMLPath == "" {
+		cfg.ReportJUnitXMLPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + ".junit.xml"
+	}
+	if filepath.Ext(cfg.ReportJUnitXMLPath) != ".xml" {
+		cfg.ReportJUnitXMLPath = cfg.ReportJUnitXMLPath + ".xml"
+	}
+	if err := fileutil.IsDirWriteable(filepath.Dir(cfg.ReportJUnitXMLPath)); err != nil {
+		return err
+	}
+	if cfg.ReportJSONPath == "" {
+		cfg.ReportJSONPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + ".report.json"
+	}
+	if filepath.Ext(cfg.ReportJSONPath) != ".json" {
+		cfg.ReportJSONPath = cfg.ReportJSONPath + ".json"
+	}
+	if err := fileutil.IsDirWriteable(filepath.Dir(cfg.ReportJSONPath)); err != nil {
+		return err
+	}
+
+	if cfg.SoakResultsPath == "" {
+		cfg.SoakResultsPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + ".soak.jsonl"
+	}
+	if err := fileutil.IsDirWriteable(filepath.Dir(cfg.SoakResultsPath)); err != nil {
+		return err
+	}
+
+	if cfg.CostSummaryPath == "" {
+		cfg.CostSummaryPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + ".cost.json"
+	}
+	if filepath.Ext(cfg.CostSummaryPath) != ".json" {
+		cfg.CostSummaryPath = cfg.CostSummaryPath + ".json"
+	}
+	if err := fileutil.IsDirWriteable(filepath.Dir(cfg.CostSummaryPath)); err != nil {
+		return err
+	}
+
+	if cfg.CFNTemplateDir != "" {
+		fi, err := os.Stat(cfg.CFNTemplateDir)
+		if err != nil {
+			return fmt.Errorf("invalid CFNTemplateDir %q (%v)", cfg.CFNTemplateDir, err)
+		}
+		if !fi.IsDir() {
+			return fmt.Errorf("CFNTemplateDir %q is not a directory", cfg.CFNTemplateDir)
+		}
+	}
+
 	if cfg.KubeConfigPath == "" {
 		cfg.KubeConfigPath = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + ".kubeconfig.yaml"
 	}
@@ -1430,11 +2408,40 @@ func (cfg *Config) validateConfig() error {
 		if cfg.S3.BucketLifecycleExpirationDays > 0 && cfg.S3.BucketLifecycleExpirationDays < 3 {
 			cfg.S3.BucketLifecycleExpirationDays = 3
 		}
+		if cfg.S3.BucketObjectLock {
+			// object lock requires versioning
+			cfg.S3.BucketVersioning = true
+		}
 	case false: // use existing one
 		if cfg.S3.BucketName == "" {
 			return errors.New("empty S3BucketName")
 		}
+		if cfg.S3.BucketVersioning || cfg.S3.BucketObjectLock {
+			return errors.New("S3 BucketVersioning or BucketObjectLock requires BucketCreate")
+		}
+	}
+	if cfg.S3.BucketObjectLockRetentionDays < 0 {
+		return fmt.Errorf("invalid S3 BucketObjectLockRetentionDays %d", cfg.S3.BucketObjectLockRetentionDays)
+	}
+	if !cfg.S3.BucketObjectLock && cfg.S3.BucketObjectLockRetentionDays > 0 {
+		return fmt.Errorf("S3 BucketObjectLockRetentionDays %d requires BucketObjectLock", cfg.S3.BucketObjectLockRetentionDays)
 	}
+	if cfg.S3.ExpectedBucketOwner != "" && !accountIDRegex.MatchString(cfg.S3.ExpectedBucketOwner) {
+		return fmt.Errorf("invalid S3 ExpectedBucketOwner %q (expected 12-digit account ID)", cfg.S3.ExpectedBucketOwner)
+	}
+	if cfg.S3.RoleARN != "" && !roleARNRegex.MatchString(cfg.S3.RoleARN) {
+		return fmt.Errorf("invalid S3 RoleARN %q", cfg.S3.RoleARN)
+	}
+	if cfg.S3.RoleARN == "" && cfg.S3.RoleExternalID != "" {
+		return errors.New("S3 RoleExternalID requires RoleARN")
+	}
+	if cfg.S3.RetryMaxAttempts < 0 {
+		return fmt.Errorf("invalid S3 RetryMaxAttempts %d", cfg.S3.RetryMaxAttempts)
+	}
+	if cfg.S3.RetryMaxBackoff < 0 {
+		return fmt.Errorf("invalid negative S3 RetryMaxBackoff %v", cfg.S3.RetryMaxBackoff)
+	}
+	cfg.S3.RetryMaxBackoffString = cfg.S3.RetryMaxBackoff.String()
 
 	if cfg.CWNamespace == "" {
 		cfg.CWNamespace = "aws-k8s-tester-eks"
@@ -1452,6 +2459,12 @@ func (cfg *Config) validateConfig() error {
 // only letters and numbers
 var regex = regexp.MustCompile("[^a-zA-Z0-9]+")
 
+// e.g. "123456789012"
+var accountIDRegex = regexp.MustCompile(`^[0-9]{12}$`)
+
+// e.g. "arn:aws:iam::123456789012:role/central-s3-writer"
+var roleARNRegex = regexp.MustCompile(`^arn:aws[a-zA-Z-]*:iam::[0-9]{12}:role/.+$`)
+
 // get "role-eks" from "arn:aws:iam::123:role/role-eks"
 func getNameFromARN(arn string) string {
 	if ss := strings.Split(arn, "/"); len(ss) > 0 {
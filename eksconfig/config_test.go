@@ -0,0 +1,97 @@
+package eksconfig
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFormats(t *testing.T) {
+	dir := t.TempDir()
+	for name, d := range map[string]string{
+		"config.yaml": "name: test\nregion: us-east-1\nvpc:\n  id: vpc-123\ntags:\n  team: test\n",
+		"config.json": `{"name": "test", "region": "us-east-1", "vpc": {"id": "vpc-123"}, "tags": {"team": "test"}}`,
+		"config.hcl":  "name = \"test\"\nregion = \"us-east-1\"\nvpc {\n  id = \"vpc-123\"\n}\ntags = {\n  team = \"test\"\n}\n",
+	} {
+		p := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(p, []byte(d), 0600); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := Load(p)
+		if err != nil {
+			t.Fatalf("%q: %v", name, err)
+		}
+		if cfg.Name != "test" || cfg.Region != "us-east-1" || cfg.VPC.ID != "vpc-123" || cfg.Tags["team"] != "test" {
+			t.Fatalf("%q: unexpected configuration %+v", name, cfg)
+		}
+	}
+
+	// JSON is written back in JSON
+	d, err := ioutil.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !json.Valid(d) {
+		t.Fatalf("expected JSON, got\n%s", string(d))
+	}
+	// HCL is not written back
+	d, err = ioutil.ReadFile(filepath.Join(dir, "config.hcl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(d) != "name = \"test\"\nregion = \"us-east-1\"\nvpc {\n  id = \"vpc-123\"\n}\ntags = {\n  team = \"test\"\n}\n" {
+		t.Fatalf("unexpected HCL\n%s", string(d))
+	}
+	if _, err = os.Stat(filepath.Join(dir, "config.hcl.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	// same validation for unknown fields
+	p := filepath.Join(dir, "unknown.hcl")
+	if err = ioutil.WriteFile(p, []byte("name = \"test\"\nunknown-field = true\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Load(p); err == nil {
+		t.Fatal("expected unknown field error")
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(p, []byte("name: test\nregion: us-east-1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// snapshots are consistent while other routines keep syncing
+	donec := make(chan struct{})
+	go func() {
+		defer close(donec)
+		for i := 0; i < 50; i++ {
+			if serr := cfg.Sync(); serr != nil {
+				t.Error(serr)
+				return
+			}
+		}
+	}()
+	sp := filepath.Join(dir, "snapshot.yaml")
+	for i := 0; i < 50; i++ {
+		if err = cfg.Snapshot(sp); err != nil {
+			t.Fatal(err)
+		}
+		snap, err := Load(sp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if snap.Name != "test" || snap.Region != "us-east-1" {
+			t.Fatalf("unexpected snapshot %+v", snap)
+		}
+	}
+	<-donec
+}
@@ -0,0 +1,36 @@
+package eksconfig
+
+import "time"
+
+// CostStatus is the estimated cost of the AWS resources created by the tester,
+// for budget tracking. Resources are billed from the start of their creation
+// until the end of their deletion, or until the estimate if not yet deleted.
+type CostStatus struct {
+	// Items is the estimated cost per resource.
+	Items []CostItem `json:"items"`
+	// TotalUSD is the total estimated cost in USD.
+	TotalUSD float64 `json:"total-usd"`
+	// EstimatedUTC is the time of the estimate.
+	EstimatedUTC time.Time `json:"estimated-utc"`
+}
+
+// CostItem is the estimated cost of a resource.
+type CostItem struct {
+	// Phase is the tester phase that created the resource
+	// (e.g. "cluster", "node-groups", "managed-node-groups").
+	Phase string `json:"phase"`
+	// Resource describes the billed resource (e.g. "EKS cluster", "c5.xlarge").
+	Resource string `json:"resource"`
+	// Units is the number of the billed resources (e.g. the desired capacity).
+	Units int `json:"units"`
+	// Hours is the number of billed hours per unit.
+	Hours float64 `json:"hours"`
+	// HourlyUSD is the on-demand price per unit-hour in USD.
+	HourlyUSD float64 `json:"hourly-usd"`
+	// PriceSource is "pricing-api" if the price is from the AWS Price List API,
+	// "default" if the lookup failed and the built-in price is used,
+	// or "unavailable" if the lookup failed without a built-in price.
+	PriceSource string `json:"price-source"`
+	// USD is the estimated cost in USD.
+	USD float64 `json:"usd"`
+}
@@ -0,0 +1,291 @@
+package eksconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EnvVar is the environment variable that overwrites the configuration field
+// (see "UpdateFromEnvs").
+type EnvVar struct {
+	// Name is the environment variable name (e.g. "AWS_K8S_TESTER_EKS_REGION").
+	Name string `json:"name"`
+	// Prefix is the environment variable prefix of the configuration section
+	// (e.g. "AWS_K8S_TESTER_EKS_VPC_").
+	Prefix string `json:"prefix"`
+	// Field is the configuration field (e.g. "*eksconfig.Config.Region").
+	Field string `json:"field"`
+	// Type is the Go type of the field (e.g. "string", "time.Duration").
+	Type string `json:"type"`
+	// Default is the default value, in the environment variable format
+	// (e.g. comma-separated list, JSON map, "10m0s").
+	Default string `json:"default"`
+	// ReadOnly is true if the field is set by the tester,
+	// and the environment variable fails the update.
+	ReadOnly bool `json:"read-only"`
+}
+
+// envSections lists the configuration sections with the environment
+// variable prefixes, in the same order as "UpdateFromEnvs".
+var envSections = []struct {
+	prefix  string
+	section func(cfg *Config) interface{}
+}{
+	{AWS_K8S_TESTER_EKS_PREFIX, func(cfg *Config) interface{} { return cfg }},
+	{AWS_K8S_TESTER_EKS_S3_PREFIX, func(cfg *Config) interface{} { return cfg.S3 }},
+	{AWS_K8S_TESTER_EKS_ENCRYPTION_PREFIX, func(cfg *Config) interface{} { return cfg.Encryption }},
+	{AWS_K8S_TESTER_EKS_ROLE_PREFIX, func(cfg *Config) interface{} { return cfg.Role }},
+	{AWS_K8S_TESTER_EKS_VPC_PREFIX, func(cfg *Config) interface{} { return cfg.VPC }},
+	{AWS_K8S_TESTER_EKS_ENDPOINT_PREFIX, func(cfg *Config) interface{} { return cfg.Endpoint }},
+	{AWS_K8S_TESTER_EKS_CONTROL_PLANE_LOGGING_PREFIX, func(cfg *Config) interface{} { return cfg.ControlPlaneLogging }},
+	{AWS_K8S_TESTER_EKS_NOTIFICATIONS_PREFIX, func(cfg *Config) interface{} { return cfg.Notifications }},
+	{AWS_K8S_TESTER_EKS_ACCESS_PREFIX, func(cfg *Config) interface{} { return cfg.Access }},
+	{AWS_K8S_TESTER_EKS_IMAGE_MIRROR_PREFIX, func(cfg *Config) interface{} { return cfg.ImageMirror }},
+	{AWS_K8S_TESTER_EKS_HOOKS_PREFIX, func(cfg *Config) interface{} { return cfg.Hooks }},
+	{AWS_K8S_TESTER_EKS_CNI_CONFIG_PREFIX, func(cfg *Config) interface{} { return cfg.CNIConfig }},
+	{AWS_K8S_TESTER_EKS_ASSUME_ROLE_PREFIX, func(cfg *Config) interface{} { return cfg.AssumeRole }},
+	{AWS_K8S_TESTER_EKS_SERVICE_ENDPOINTS_PREFIX, func(cfg *Config) interface{} { return cfg.ServiceEndpoints }},
+	{AWS_K8S_TESTER_EKS_ADD_ON_CNI_VPC_PREFIX, func(cfg *Config) interface{} { return cfg.AddOnCNIVPC }},
+	{AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_PREFIX, func(cfg *Config) interface{} { return cfg.AddOnNodeGroups }},
+	{AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ROLE_PREFIX, func(cfg *Config) interface{} {
+		if cfg.AddOnNodeGroups == nil {
+			return (*Role)(nil)
+		}
+		return cfg.AddOnNodeGroups.Role
+	}},
+	{AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_PREFIX, func(cfg *Config) interface{} { return cfg.AddOnManagedNodeGroups }},
+	{AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ROLE_PREFIX, func(cfg *Config) interface{} {
+		if cfg.AddOnManagedNodeGroups == nil {
+			return (*Role)(nil)
+		}
+		return cfg.AddOnManagedNodeGroups.Role
+	}},
+	{AWS_K8S_TESTER_EKS_ADD_ON_CW_AGENT_PREFIX, func(cfg *Config) interface{} { return cfg.AddOnCWAgent }},
+	{AWS_K8S_TESTER_EKS_ADD_ON_FLUENTD_PREFIX, func(cfg *Config) interface{} { return cfg.AddOnFluentd }},
+	{EnvironmentVariablePrefixAddOnMetricsServer, func(cfg *Config) interface{} { return cfg.AddOnMetricsServer }},
+	{EnvironmentVariablePrefixAddOnConformance, func(cfg *Config) interface{} { return cfg.AddOnConformance }},
+	{EnvironmentVariablePrefixAddOnAppMesh, func(cfg *Config) interface{} { return cfg.AddOnAppMesh }},
+	{EnvironmentVariablePrefixAddOnCSIEBS, func(cfg *Config) interface{} { return cfg.AddOnCSIEBS }},
+	{EnvironmentVariablePrefixAddOnKubernetesDashboard, func(cfg *Config) interface{} { return cfg.AddOnKubernetesDashboard }},
+	{EnvironmentVariablePrefixAddOnPrometheusGrafana, func(cfg *Config) interface{} { return cfg.AddOnPrometheusGrafana }},
+	{EnvironmentVariablePrefixAddOnPHPApache, func(cfg *Config) interface{} { return cfg.AddOnPHPApache }},
+	{EnvironmentVariablePrefixAddOnNLBHelloWorld, func(cfg *Config) interface{} { return cfg.AddOnNLBHelloWorld }},
+	{EnvironmentVariablePrefixAddOnNLBGuestbook, func(cfg *Config) interface{} { return cfg.AddOnNLBGuestbook }},
+	{EnvironmentVariablePrefixAddOnALB2048, func(cfg *Config) interface{} { return cfg.AddOnALB2048 }},
+	{EnvironmentVariablePrefixAddOnJobsPi, func(cfg *Config) interface{} { return cfg.AddOnJobsPi }},
+	{EnvironmentVariablePrefixAddOnJobsEcho, func(cfg *Config) interface{} { return cfg.AddOnJobsEcho }},
+	{EnvironmentVariablePrefixAddOnCronJobs, func(cfg *Config) interface{} { return cfg.AddOnCronJobs }},
+	{EnvironmentVariablePrefixAddOnCSRsLocal, func(cfg *Config) interface{} { return cfg.AddOnCSRsLocal }},
+	{EnvironmentVariablePrefixAddOnCSRsRemote, func(cfg *Config) interface{} { return cfg.AddOnCSRsRemote }},
+	{EnvironmentVariablePrefixAddOnConfigmapsLocal, func(cfg *Config) interface{} { return cfg.AddOnConfigmapsLocal }},
+	{EnvironmentVariablePrefixAddOnConfigmapsRemote, func(cfg *Config) interface{} { return cfg.AddOnConfigmapsRemote }},
+	{EnvironmentVariablePrefixAddOnSecretsLocal, func(cfg *Config) interface{} { return cfg.AddOnSecretsLocal }},
+	{EnvironmentVariablePrefixAddOnSecretsRemote, func(cfg *Config) interface{} { return cfg.AddOnSecretsRemote }},
+	{EnvironmentVariablePrefixAddOnFargate, func(cfg *Config) interface{} { return cfg.AddOnFargate }},
+	{EnvironmentVariablePrefixAddOnIRSA, func(cfg *Config) interface{} { return cfg.AddOnIRSA }},
+	{EnvironmentVariablePrefixAddOnIRSAFargate, func(cfg *Config) interface{} { return cfg.AddOnIRSAFargate }},
+	{EnvironmentVariablePrefixAddOnWordpress, func(cfg *Config) interface{} { return cfg.AddOnWordpress }},
+	{EnvironmentVariablePrefixAddOnJupyterHub, func(cfg *Config) interface{} { return cfg.AddOnJupyterHub }},
+	{EnvironmentVariablePrefixAddOnKubeflow, func(cfg *Config) interface{} { return cfg.AddOnKubeflow }},
+	{EnvironmentVariablePrefixAddOnCUDAVectorAdd, func(cfg *Config) interface{} { return cfg.AddOnCUDAVectorAdd }},
+	{EnvironmentVariablePrefixAddOnClusterLoaderLocal, func(cfg *Config) interface{} { return cfg.AddOnClusterLoaderLocal }},
+	{EnvironmentVariablePrefixAddOnClusterLoaderRemote, func(cfg *Config) interface{} { return cfg.AddOnClusterLoaderRemote }},
+	{EnvironmentVariablePrefixAddOnStresserLocal, func(cfg *Config) interface{} { return cfg.AddOnStresserLocal }},
+	{EnvironmentVariablePrefixAddOnStresserRemote, func(cfg *Config) interface{} { return cfg.AddOnStresserRemote }},
+	{EnvironmentVariablePrefixAddOnStresserRemoteV2, func(cfg *Config) interface{} { return cfg.AddOnStresserRemoteV2 }},
+	{EnvironmentVariablePrefixAddOnClusterVersionUpgrade, func(cfg *Config) interface{} { return cfg.AddOnClusterVersionUpgrade }},
+	{EnvironmentVariablePrefixAddOnVersionSkew, func(cfg *Config) interface{} { return cfg.AddOnVersionSkew }},
+	{EnvironmentVariablePrefixAddOnAmiSoftLockupIssue454, func(cfg *Config) interface{} { return cfg.AddOnAmiSoftLockupIssue454 }},
+	{EnvironmentVariablePrefixAddOnKarpenter, func(cfg *Config) interface{} { return cfg.AddOnKarpenter }},
+	{EnvironmentVariablePrefixAddOnSpotInterruption, func(cfg *Config) interface{} { return cfg.AddOnSpotInterruption }},
+	{EnvironmentVariablePrefixAddOnGPU, func(cfg *Config) interface{} { return cfg.AddOnGPU }},
+	{EnvironmentVariablePrefixAddOnMultiArch, func(cfg *Config) interface{} { return cfg.AddOnMultiArch }},
+	{EnvironmentVariablePrefixAddOnALB, func(cfg *Config) interface{} { return cfg.AddOnALB }},
+	{EnvironmentVariablePrefixAddOnCSIEFS, func(cfg *Config) interface{} { return cfg.AddOnCSIEFS }},
+	{EnvironmentVariablePrefixAddOnFSxLustre, func(cfg *Config) interface{} { return cfg.AddOnFSxLustre }},
+	{EnvironmentVariablePrefixAddOnOIDCIdentityProvider, func(cfg *Config) interface{} { return cfg.AddOnOIDCIdentityProvider }},
+	{EnvironmentVariablePrefixAddOnPodIdentity, func(cfg *Config) interface{} { return cfg.AddOnPodIdentity }},
+	{EnvironmentVariablePrefixAddOnChaos, func(cfg *Config) interface{} { return cfg.AddOnChaos }},
+	{EnvironmentVariablePrefixAddOnNodeFault, func(cfg *Config) interface{} { return cfg.AddOnNodeFault }},
+	{EnvironmentVariablePrefixAddOnECR, func(cfg *Config) interface{} { return cfg.AddOnECR }},
+	{EnvironmentVariablePrefixAddOnCustomManifests, func(cfg *Config) interface{} { return cfg.AddOnCustomManifests }},
+	{EnvironmentVariablePrefixAddOnCNIVersionMatrix, func(cfg *Config) interface{} { return cfg.AddOnCNIVersionMatrix }},
+	{EnvironmentVariablePrefixAddOnPodDensity, func(cfg *Config) interface{} { return cfg.AddOnPodDensity }},
+	{EnvironmentVariablePrefixAddOnKubeProxyModes, func(cfg *Config) interface{} { return cfg.AddOnKubeProxyModes }},
+	{EnvironmentVariablePrefixAddOnCoreDNSScale, func(cfg *Config) interface{} { return cfg.AddOnCoreDNSScale }},
+	{EnvironmentVariablePrefixAddOnServiceChurn, func(cfg *Config) interface{} { return cfg.AddOnServiceChurn }},
+	{EnvironmentVariablePrefixAddOnManagedAddOns, func(cfg *Config) interface{} { return cfg.AddOnManagedAddOns }},
+	{EnvironmentVariablePrefixAddOnCustomNetworking, func(cfg *Config) interface{} { return cfg.AddOnCustomNetworking }},
+	{EnvironmentVariablePrefixAddOnNetworkPolicy, func(cfg *Config) interface{} { return cfg.AddOnNetworkPolicy }},
+	{EnvironmentVariablePrefixAddOnContainerInsights, func(cfg *Config) interface{} { return cfg.AddOnContainerInsights }},
+	{EnvironmentVariablePrefixAddOnPrometheus, func(cfg *Config) interface{} { return cfg.AddOnPrometheus }},
+	{EnvironmentVariablePrefixAddOnHPA, func(cfg *Config) interface{} { return cfg.AddOnHPA }},
+	{EnvironmentVariablePrefixAddOnClusterLoader, func(cfg *Config) interface{} { return cfg.AddOnClusterLoader }},
+	{EnvironmentVariablePrefixAddOnCSIEBSChurn, func(cfg *Config) interface{} { return cfg.AddOnCSIEBSChurn }},
+	{EnvironmentVariablePrefixAddOnJobsThroughput, func(cfg *Config) interface{} { return cfg.AddOnJobsThroughput }},
+}
+
+// envExtraNames are the environment variables with the prefix,
+// read outside of the configuration fields.
+var envExtraNames = []string{
+	AWS_K8S_TESTER_EKS_PREFIX + "CONFIG",       // YAML overlay, see "UpdateFromEnvs"
+	AWS_K8S_TESTER_EKS_PREFIX + "CONFIG_INPUT", // kubetest2 deployer
+}
+
+// envMapFields are the map fields supported by "parseEnvs", in JSON.
+var envMapFields = map[string]struct{}{
+	"Tags":                       {},
+	"NodeSelector":               {},
+	"DeploymentNodeSelector":     {},
+	"DeploymentNodeSelector2048": {},
+	"SnapshotQueries":            {},
+	"ASGs":                       {},
+	"MNGs":                       {},
+	"AddOns":                     {},
+}
+
+// EnvVars returns all environment variables supported by "UpdateFromEnvs",
+// with the default values of "NewDefault". The generated values
+// (e.g. the cluster name) differ per call.
+func EnvVars() []EnvVar {
+	return envVars(NewDefault())
+}
+
+func envVars(cfg *Config) (evs []EnvVar) {
+	for _, sec := range envSections {
+		st := sec.section(cfg)
+		ts := reflect.TypeOf(st)
+		tp, vv := ts.Elem(), reflect.ValueOf(st)
+		if vv.IsNil() {
+			vv = reflect.New(tp)
+		}
+		vv = vv.Elem()
+		for i := 0; i < tp.NumField(); i++ {
+			jv := tp.Field(i).Tag.Get("json")
+			if jv == "" || tp.Field(i).Anonymous {
+				continue
+			}
+			switch vv.Field(i).Kind() {
+			case reflect.Ptr, reflect.Struct, reflect.Interface:
+				continue
+			case reflect.Map:
+				if _, ok := envMapFields[tp.Field(i).Name]; !ok {
+					continue
+				}
+			}
+			jv = strings.Replace(jv, ",omitempty", "", -1)
+			jv = strings.ToUpper(strings.Replace(jv, "-", "_", -1))
+			evs = append(evs, EnvVar{
+				Name:     sec.prefix + jv,
+				Prefix:   sec.prefix,
+				Field:    fmt.Sprintf("%s.%s", ts, tp.Field(i).Name),
+				Type:     vv.Field(i).Type().String(),
+				Default:  envDefault(vv.Field(i)),
+				ReadOnly: tp.Field(i).Tag.Get("read-only") == "true",
+			})
+		}
+	}
+	return evs
+}
+
+func envDefault(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Int64:
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			return time.Duration(v.Int()).String()
+		}
+	case reflect.Slice:
+		if ss, ok := v.Interface().([]string); ok {
+			return strings.Join(ss, ",")
+		}
+	case reflect.Map:
+		if v.Len() == 0 {
+			return ""
+		}
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// checkUnknownEnvs returns an error for the environment variables
+// with the "AWS_K8S_TESTER_EKS_" prefix that do not match any field,
+// suggesting the closest one.
+func checkUnknownEnvs() error {
+	known := make(map[string]struct{})
+	for _, ev := range envVars(&Config{}) {
+		known[ev.Name] = struct{}{}
+	}
+	for _, name := range envExtraNames {
+		known[name] = struct{}{}
+	}
+
+	var unknown []string
+	for _, kv := range os.Environ() {
+		name := strings.SplitN(kv, "=", 2)[0]
+		if !strings.HasPrefix(name, AWS_K8S_TESTER_EKS_PREFIX) {
+			continue
+		}
+		if _, ok := known[name]; ok {
+			continue
+		}
+		msg := fmt.Sprintf("%q", name)
+		if s := closestEnv(name, known); s != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", s)
+		}
+		unknown = append(unknown, msg)
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("unknown environment variables %s with EnvStrict; see 'aws-k8s-tester eks env-help'", strings.Join(unknown, ", "))
+}
+
+// closestEnv returns the known name within the edit distance 3, or empty.
+func closestEnv(name string, known map[string]struct{}) (closest string) {
+	min := 4
+	for k := range known {
+		if d := editDistance(name, k); d < min || (d == min && k < closest) {
+			min, closest = d, k
+		}
+	}
+	return closest
+}
+
+func editDistance(a, b string) int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(vs ...int) int {
+	m := vs[0]
+	for _, v := range vs[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
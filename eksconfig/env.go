@@ -19,10 +19,21 @@ const (
 	AWS_K8S_TESTER_EKS_ENCRYPTION_PREFIX = AWS_K8S_TESTER_EKS_PREFIX + "ENCRYPTION_"
 	AWS_K8S_TESTER_EKS_ROLE_PREFIX       = AWS_K8S_TESTER_EKS_PREFIX + "ROLE_"
 	AWS_K8S_TESTER_EKS_VPC_PREFIX        = AWS_K8S_TESTER_EKS_PREFIX + "VPC_"
+	AWS_K8S_TESTER_EKS_ENDPOINT_PREFIX   = AWS_K8S_TESTER_EKS_PREFIX + "ENDPOINT_"
+
+	AWS_K8S_TESTER_EKS_CONTROL_PLANE_LOGGING_PREFIX = AWS_K8S_TESTER_EKS_PREFIX + "CONTROL_PLANE_LOGGING_"
+	AWS_K8S_TESTER_EKS_NOTIFICATIONS_PREFIX         = AWS_K8S_TESTER_EKS_PREFIX + "NOTIFICATIONS_"
+	AWS_K8S_TESTER_EKS_ACCESS_PREFIX                = AWS_K8S_TESTER_EKS_PREFIX + "ACCESS_"
+	AWS_K8S_TESTER_EKS_IMAGE_MIRROR_PREFIX          = AWS_K8S_TESTER_EKS_PREFIX + "IMAGE_MIRROR_"
+	AWS_K8S_TESTER_EKS_HOOKS_PREFIX                 = AWS_K8S_TESTER_EKS_PREFIX + "HOOKS_"
+	AWS_K8S_TESTER_EKS_CNI_CONFIG_PREFIX            = AWS_K8S_TESTER_EKS_PREFIX + "CNI_CONFIG_"
+	AWS_K8S_TESTER_EKS_ASSUME_ROLE_PREFIX           = AWS_K8S_TESTER_EKS_PREFIX + "ASSUME_ROLE_"
+	AWS_K8S_TESTER_EKS_SERVICE_ENDPOINTS_PREFIX     = AWS_K8S_TESTER_EKS_PREFIX + "SERVICE_ENDPOINTS_"
 )
 
 // UpdateFromEnvs updates fields from environmental variables.
 // Empty values are ignored and do not overwrite fields with empty values.
+// Unknown variables are ignored, unless "EnvStrict" is true (see "EnvVars").
 // WARNING: The environmental variable value always overwrites current field
 // values if there's a conflict.
 func (cfg *Config) UpdateFromEnvs() (err error) {
@@ -58,6 +69,11 @@ func (cfg *Config) UpdateFromEnvs() (err error) {
 	} else {
 		return fmt.Errorf("expected *Config, got %T", vv)
 	}
+	if cfg.EnvStrict {
+		if err = checkUnknownEnvs(); err != nil {
+			return err
+		}
+	}
 
 	if cfg.S3 == nil {
 		cfg.S3 = &S3{}
@@ -111,6 +127,123 @@ func (cfg *Config) UpdateFromEnvs() (err error) {
 		return fmt.Errorf("expected *VPC, got %T", vv)
 	}
 
+	if cfg.Endpoint == nil {
+		cfg.Endpoint = &Endpoint{}
+	}
+	vv, err = parseEnvs(AWS_K8S_TESTER_EKS_ENDPOINT_PREFIX, cfg.Endpoint)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*Endpoint); ok {
+		cfg.Endpoint = av
+	} else {
+		return fmt.Errorf("expected *Endpoint, got %T", vv)
+	}
+
+	if cfg.ControlPlaneLogging == nil {
+		cfg.ControlPlaneLogging = &ControlPlaneLogging{}
+	}
+	vv, err = parseEnvs(AWS_K8S_TESTER_EKS_CONTROL_PLANE_LOGGING_PREFIX, cfg.ControlPlaneLogging)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*ControlPlaneLogging); ok {
+		cfg.ControlPlaneLogging = av
+	} else {
+		return fmt.Errorf("expected *ControlPlaneLogging, got %T", vv)
+	}
+
+	if cfg.Notifications == nil {
+		cfg.Notifications = &Notifications{}
+	}
+	vv, err = parseEnvs(AWS_K8S_TESTER_EKS_NOTIFICATIONS_PREFIX, cfg.Notifications)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*Notifications); ok {
+		cfg.Notifications = av
+	} else {
+		return fmt.Errorf("expected *Notifications, got %T", vv)
+	}
+
+	if cfg.Access == nil {
+		cfg.Access = &Access{}
+	}
+	vv, err = parseEnvs(AWS_K8S_TESTER_EKS_ACCESS_PREFIX, cfg.Access)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*Access); ok {
+		cfg.Access = av
+	} else {
+		return fmt.Errorf("expected *Access, got %T", vv)
+	}
+
+	if cfg.ImageMirror == nil {
+		cfg.ImageMirror = &ImageMirror{}
+	}
+	vv, err = parseEnvs(AWS_K8S_TESTER_EKS_IMAGE_MIRROR_PREFIX, cfg.ImageMirror)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*ImageMirror); ok {
+		cfg.ImageMirror = av
+	} else {
+		return fmt.Errorf("expected *ImageMirror, got %T", vv)
+	}
+
+	if cfg.Hooks == nil {
+		cfg.Hooks = &Hooks{}
+	}
+	vv, err = parseEnvs(AWS_K8S_TESTER_EKS_HOOKS_PREFIX, cfg.Hooks)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*Hooks); ok {
+		cfg.Hooks = av
+	} else {
+		return fmt.Errorf("expected *Hooks, got %T", vv)
+	}
+
+	if cfg.CNIConfig == nil {
+		cfg.CNIConfig = &CNIConfig{}
+	}
+	vv, err = parseEnvs(AWS_K8S_TESTER_EKS_CNI_CONFIG_PREFIX, cfg.CNIConfig)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*CNIConfig); ok {
+		cfg.CNIConfig = av
+	} else {
+		return fmt.Errorf("expected *CNIConfig, got %T", vv)
+	}
+
+	if cfg.AssumeRole == nil {
+		cfg.AssumeRole = &AssumeRole{}
+	}
+	vv, err = parseEnvs(AWS_K8S_TESTER_EKS_ASSUME_ROLE_PREFIX, cfg.AssumeRole)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AssumeRole); ok {
+		cfg.AssumeRole = av
+	} else {
+		return fmt.Errorf("expected *AssumeRole, got %T", vv)
+	}
+
+	if cfg.ServiceEndpoints == nil {
+		cfg.ServiceEndpoints = &ServiceEndpoints{}
+	}
+	vv, err = parseEnvs(AWS_K8S_TESTER_EKS_SERVICE_ENDPOINTS_PREFIX, cfg.ServiceEndpoints)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*ServiceEndpoints); ok {
+		cfg.ServiceEndpoints = av
+	} else {
+		return fmt.Errorf("expected *ServiceEndpoints, got %T", vv)
+	}
+
 	if cfg.AddOnCNIVPC == nil {
 		cfg.AddOnCNIVPC = &AddOnCNIVPC{}
 	}
@@ -612,6 +745,19 @@ func (cfg *Config) UpdateFromEnvs() (err error) {
 		return fmt.Errorf("expected *AddOnClusterVersionUpgrade, got %T", vv)
 	}
 
+	if cfg.AddOnVersionSkew == nil {
+		cfg.AddOnVersionSkew = &AddOnVersionSkew{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnVersionSkew, cfg.AddOnVersionSkew)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnVersionSkew); ok {
+		cfg.AddOnVersionSkew = av
+	} else {
+		return fmt.Errorf("expected *AddOnVersionSkew, got %T", vv)
+	}
+
 	if cfg.AddOnAmiSoftLockupIssue454 == nil {
 		cfg.AddOnAmiSoftLockupIssue454 = &AddOnAmiSoftLockupIssue454{}
 	}
@@ -625,6 +771,357 @@ func (cfg *Config) UpdateFromEnvs() (err error) {
 		return fmt.Errorf("expected *AddOnAmiSoftLockupIssue454, got %T", vv)
 	}
 
+	if cfg.AddOnKarpenter == nil {
+		cfg.AddOnKarpenter = &AddOnKarpenter{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnKarpenter, cfg.AddOnKarpenter)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnKarpenter); ok {
+		cfg.AddOnKarpenter = av
+	} else {
+		return fmt.Errorf("expected *AddOnKarpenter, got %T", vv)
+	}
+
+	if cfg.AddOnSpotInterruption == nil {
+		cfg.AddOnSpotInterruption = &AddOnSpotInterruption{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnSpotInterruption, cfg.AddOnSpotInterruption)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnSpotInterruption); ok {
+		cfg.AddOnSpotInterruption = av
+	} else {
+		return fmt.Errorf("expected *AddOnSpotInterruption, got %T", vv)
+	}
+
+	if cfg.AddOnGPU == nil {
+		cfg.AddOnGPU = &AddOnGPU{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnGPU, cfg.AddOnGPU)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnGPU); ok {
+		cfg.AddOnGPU = av
+	} else {
+		return fmt.Errorf("expected *AddOnGPU, got %T", vv)
+	}
+
+	if cfg.AddOnMultiArch == nil {
+		cfg.AddOnMultiArch = &AddOnMultiArch{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnMultiArch, cfg.AddOnMultiArch)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnMultiArch); ok {
+		cfg.AddOnMultiArch = av
+	} else {
+		return fmt.Errorf("expected *AddOnMultiArch, got %T", vv)
+	}
+
+	if cfg.AddOnALB == nil {
+		cfg.AddOnALB = &AddOnALB{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnALB, cfg.AddOnALB)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnALB); ok {
+		cfg.AddOnALB = av
+	} else {
+		return fmt.Errorf("expected *AddOnALB, got %T", vv)
+	}
+
+	if cfg.AddOnCSIEFS == nil {
+		cfg.AddOnCSIEFS = &AddOnCSIEFS{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnCSIEFS, cfg.AddOnCSIEFS)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnCSIEFS); ok {
+		cfg.AddOnCSIEFS = av
+	} else {
+		return fmt.Errorf("expected *AddOnCSIEFS, got %T", vv)
+	}
+
+	if cfg.AddOnFSxLustre == nil {
+		cfg.AddOnFSxLustre = &AddOnFSxLustre{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnFSxLustre, cfg.AddOnFSxLustre)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnFSxLustre); ok {
+		cfg.AddOnFSxLustre = av
+	} else {
+		return fmt.Errorf("expected *AddOnFSxLustre, got %T", vv)
+	}
+
+	if cfg.AddOnOIDCIdentityProvider == nil {
+		cfg.AddOnOIDCIdentityProvider = &AddOnOIDCIdentityProvider{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnOIDCIdentityProvider, cfg.AddOnOIDCIdentityProvider)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnOIDCIdentityProvider); ok {
+		cfg.AddOnOIDCIdentityProvider = av
+	} else {
+		return fmt.Errorf("expected *AddOnOIDCIdentityProvider, got %T", vv)
+	}
+
+	if cfg.AddOnPodIdentity == nil {
+		cfg.AddOnPodIdentity = &AddOnPodIdentity{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnPodIdentity, cfg.AddOnPodIdentity)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnPodIdentity); ok {
+		cfg.AddOnPodIdentity = av
+	} else {
+		return fmt.Errorf("expected *AddOnPodIdentity, got %T", vv)
+	}
+
+	if cfg.AddOnChaos == nil {
+		cfg.AddOnChaos = &AddOnChaos{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnChaos, cfg.AddOnChaos)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnChaos); ok {
+		cfg.AddOnChaos = av
+	} else {
+		return fmt.Errorf("expected *AddOnChaos, got %T", vv)
+	}
+
+	if cfg.AddOnNodeFault == nil {
+		cfg.AddOnNodeFault = &AddOnNodeFault{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnNodeFault, cfg.AddOnNodeFault)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnNodeFault); ok {
+		cfg.AddOnNodeFault = av
+	} else {
+		return fmt.Errorf("expected *AddOnNodeFault, got %T", vv)
+	}
+
+	if cfg.AddOnECR == nil {
+		cfg.AddOnECR = &AddOnECR{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnECR, cfg.AddOnECR)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnECR); ok {
+		cfg.AddOnECR = av
+	} else {
+		return fmt.Errorf("expected *AddOnECR, got %T", vv)
+	}
+
+	if cfg.AddOnCustomManifests == nil {
+		cfg.AddOnCustomManifests = &AddOnCustomManifests{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnCustomManifests, cfg.AddOnCustomManifests)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnCustomManifests); ok {
+		cfg.AddOnCustomManifests = av
+	} else {
+		return fmt.Errorf("expected *AddOnCustomManifests, got %T", vv)
+	}
+
+	if cfg.AddOnCNIVersionMatrix == nil {
+		cfg.AddOnCNIVersionMatrix = &AddOnCNIVersionMatrix{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnCNIVersionMatrix, cfg.AddOnCNIVersionMatrix)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnCNIVersionMatrix); ok {
+		cfg.AddOnCNIVersionMatrix = av
+	} else {
+		return fmt.Errorf("expected *AddOnCNIVersionMatrix, got %T", vv)
+	}
+
+	if cfg.AddOnPodDensity == nil {
+		cfg.AddOnPodDensity = &AddOnPodDensity{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnPodDensity, cfg.AddOnPodDensity)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnPodDensity); ok {
+		cfg.AddOnPodDensity = av
+	} else {
+		return fmt.Errorf("expected *AddOnPodDensity, got %T", vv)
+	}
+
+	if cfg.AddOnKubeProxyModes == nil {
+		cfg.AddOnKubeProxyModes = &AddOnKubeProxyModes{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnKubeProxyModes, cfg.AddOnKubeProxyModes)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnKubeProxyModes); ok {
+		cfg.AddOnKubeProxyModes = av
+	} else {
+		return fmt.Errorf("expected *AddOnKubeProxyModes, got %T", vv)
+	}
+
+	if cfg.AddOnCoreDNSScale == nil {
+		cfg.AddOnCoreDNSScale = &AddOnCoreDNSScale{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnCoreDNSScale, cfg.AddOnCoreDNSScale)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnCoreDNSScale); ok {
+		cfg.AddOnCoreDNSScale = av
+	} else {
+		return fmt.Errorf("expected *AddOnCoreDNSScale, got %T", vv)
+	}
+
+	if cfg.AddOnServiceChurn == nil {
+		cfg.AddOnServiceChurn = &AddOnServiceChurn{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnServiceChurn, cfg.AddOnServiceChurn)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnServiceChurn); ok {
+		cfg.AddOnServiceChurn = av
+	} else {
+		return fmt.Errorf("expected *AddOnServiceChurn, got %T", vv)
+	}
+
+	if cfg.AddOnManagedAddOns == nil {
+		cfg.AddOnManagedAddOns = &AddOnManagedAddOns{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnManagedAddOns, cfg.AddOnManagedAddOns)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnManagedAddOns); ok {
+		cfg.AddOnManagedAddOns = av
+	} else {
+		return fmt.Errorf("expected *AddOnManagedAddOns, got %T", vv)
+	}
+
+	if cfg.AddOnCustomNetworking == nil {
+		cfg.AddOnCustomNetworking = &AddOnCustomNetworking{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnCustomNetworking, cfg.AddOnCustomNetworking)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnCustomNetworking); ok {
+		cfg.AddOnCustomNetworking = av
+	} else {
+		return fmt.Errorf("expected *AddOnCustomNetworking, got %T", vv)
+	}
+
+	if cfg.AddOnNetworkPolicy == nil {
+		cfg.AddOnNetworkPolicy = &AddOnNetworkPolicy{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnNetworkPolicy, cfg.AddOnNetworkPolicy)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnNetworkPolicy); ok {
+		cfg.AddOnNetworkPolicy = av
+	} else {
+		return fmt.Errorf("expected *AddOnNetworkPolicy, got %T", vv)
+	}
+
+	if cfg.AddOnContainerInsights == nil {
+		cfg.AddOnContainerInsights = &AddOnContainerInsights{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnContainerInsights, cfg.AddOnContainerInsights)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnContainerInsights); ok {
+		cfg.AddOnContainerInsights = av
+	} else {
+		return fmt.Errorf("expected *AddOnContainerInsights, got %T", vv)
+	}
+
+	if cfg.AddOnPrometheus == nil {
+		cfg.AddOnPrometheus = &AddOnPrometheus{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnPrometheus, cfg.AddOnPrometheus)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnPrometheus); ok {
+		cfg.AddOnPrometheus = av
+	} else {
+		return fmt.Errorf("expected *AddOnPrometheus, got %T", vv)
+	}
+
+	if cfg.AddOnHPA == nil {
+		cfg.AddOnHPA = &AddOnHPA{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnHPA, cfg.AddOnHPA)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnHPA); ok {
+		cfg.AddOnHPA = av
+	} else {
+		return fmt.Errorf("expected *AddOnHPA, got %T", vv)
+	}
+
+	if cfg.AddOnClusterLoader == nil {
+		cfg.AddOnClusterLoader = &AddOnClusterLoader{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnClusterLoader, cfg.AddOnClusterLoader)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnClusterLoader); ok {
+		cfg.AddOnClusterLoader = av
+	} else {
+		return fmt.Errorf("expected *AddOnClusterLoader, got %T", vv)
+	}
+
+	if cfg.AddOnCSIEBSChurn == nil {
+		cfg.AddOnCSIEBSChurn = &AddOnCSIEBSChurn{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnCSIEBSChurn, cfg.AddOnCSIEBSChurn)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnCSIEBSChurn); ok {
+		cfg.AddOnCSIEBSChurn = av
+	} else {
+		return fmt.Errorf("expected *AddOnCSIEBSChurn, got %T", vv)
+	}
+
+	if cfg.AddOnJobsThroughput == nil {
+		cfg.AddOnJobsThroughput = &AddOnJobsThroughput{}
+	}
+	vv, err = parseEnvs(EnvironmentVariablePrefixAddOnJobsThroughput, cfg.AddOnJobsThroughput)
+	if err != nil {
+		return err
+	}
+	if av, ok := vv.(*AddOnJobsThroughput); ok {
+		cfg.AddOnJobsThroughput = av
+	} else {
+		return fmt.Errorf("expected *AddOnJobsThroughput, got %T", vv)
+	}
+
 	return nil
 }
 
@@ -687,7 +1184,25 @@ func parseEnvs(pfx string, addOn interface{}) (interface{}, error) {
 			}
 			vv.Field(i).SetFloat(fv)
 
-		case reflect.Slice: // only supports "[]string" for now
+		case reflect.Slice:
+			if vv.Field(i).Type().Elem().Kind() == reflect.Struct {
+				switch fieldName {
+				case "PreCreate", "PostCreate", "PreDelete", "PostDelete":
+					hooks := make([]Hook, 0)
+					if err := json.Unmarshal([]byte(sv), &hooks); err != nil {
+						return nil, fmt.Errorf("failed to parse %q (field name %q, environmental variable key %q, error %v)", sv, fieldName, env, err)
+					}
+					for k := range hooks {
+						// skip updating read-only fields
+						hooks[k].TimeoutString, hooks[k].OutputPath = "", ""
+					}
+					vv.Field(i).Set(reflect.ValueOf(hooks))
+				default:
+					return nil, fmt.Errorf("field %q not supported for reflect.Slice", fieldName)
+				}
+				continue
+			}
+			// otherwise, only supports "[]string" for now
 			ss := strings.Split(sv, ",")
 			if len(ss) < 1 {
 				continue
@@ -703,7 +1218,8 @@ func parseEnvs(pfx string, addOn interface{}) (interface{}, error) {
 			case "Tags",
 				"NodeSelector",
 				"DeploymentNodeSelector",
-				"DeploymentNodeSelector2048":
+				"DeploymentNodeSelector2048",
+				"SnapshotQueries":
 				vv.Field(i).Set(reflect.ValueOf(make(map[string]string)))
 				mm := make(map[string]string)
 				if err := json.Unmarshal([]byte(sv), &mm); err != nil {
@@ -761,6 +1277,30 @@ func parseEnvs(pfx string, addOn interface{}) (interface{}, error) {
 				}
 				vv.Field(i).Set(reflect.ValueOf(mngs))
 
+			case "AddOns":
+				addOns := make(map[string]ManagedAddOn)
+				if err := json.Unmarshal([]byte(sv), &addOns); err != nil {
+					return nil, fmt.Errorf("failed to parse %q (field name %q, environmental variable key %q, error %v)", sv, fieldName, env, err)
+				}
+				for k, v := range addOns {
+					// skip updating read-only fields
+					v.InstalledVersion, v.DefaultVersion, v.LatestVersion, v.Compatible = "", "", "", false
+					addOns[k] = v
+				}
+				vv.Field(i).Set(reflect.ValueOf(addOns))
+
+			case "Entries":
+				entries := make(map[string]AccessEntry)
+				if err := json.Unmarshal([]byte(sv), &entries); err != nil {
+					return nil, fmt.Errorf("failed to parse %q (field name %q, environmental variable key %q, error %v)", sv, fieldName, env, err)
+				}
+				for k, v := range entries {
+					// skip updating read-only field
+					v.Verified = false
+					entries[k] = v
+				}
+				vv.Field(i).Set(reflect.ValueOf(entries))
+
 			default:
 				return nil, fmt.Errorf("field %q not supported for reflect.Map", fieldName)
 			}
@@ -4,13 +4,17 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-k8s-tester/ec2config"
 	"github.com/aws/aws-k8s-tester/pkg/randutil"
+	aws_ec2_v2_types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go/service/eks"
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/assert"
@@ -40,6 +44,12 @@ spec:
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_LOG_COLOR_OVERRIDE")
 	os.Setenv("AWS_K8S_TESTER_EKS_KUBECTL_COMMANDS_OUTPUT_PATH", "hello-kubectl")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_KUBECTL_COMMANDS_OUTPUT_PATH")
+	os.Setenv("AWS_K8S_TESTER_EKS_REPORT_JUNIT_XML_PATH", "hello-junit.xml")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_REPORT_JUNIT_XML_PATH")
+	os.Setenv("AWS_K8S_TESTER_EKS_REPORT_JSON_PATH", "hello-report.json")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_REPORT_JSON_PATH")
+	os.Setenv("AWS_K8S_TESTER_EKS_COST_SUMMARY_PATH", "hello-cost.json")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_COST_SUMMARY_PATH")
 	os.Setenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_COMMANDS_OUTPUT_PATH", "hello-ssh")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_COMMANDS_OUTPUT_PATH")
 	os.Setenv("AWS_K8S_TESTER_EKS_REGION", "us-east-1")
@@ -60,6 +70,18 @@ spec:
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_COMMAND_AFTER_CREATE_CLUSTER")
 	os.Setenv("AWS_K8S_TESTER_EKS_COMMAND_AFTER_CREATE_CLUSTER_TIMEOUT", "7m")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_COMMAND_AFTER_CREATE_CLUSTER_TIMEOUT")
+	os.Setenv("AWS_K8S_TESTER_EKS_RUN_TIMEOUT", "5h")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_RUN_TIMEOUT")
+	os.Setenv("AWS_K8S_TESTER_EKS_SOAK_DURATION", "48h")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_SOAK_DURATION")
+	os.Setenv("AWS_K8S_TESTER_EKS_SOAK_INTERVAL", "30m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_SOAK_INTERVAL")
+	os.Setenv("AWS_K8S_TESTER_EKS_ON_SIGNAL_CLEANUP", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ON_SIGNAL_CLEANUP")
+	os.Setenv("AWS_K8S_TESTER_EKS_METRICS_LISTEN_ADDRESS", "localhost:9090")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_METRICS_LISTEN_ADDRESS")
+	os.Setenv("AWS_K8S_TESTER_EKS_MANAGED_NODE_GROUPS_CREATE_TIMEOUT", "40m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_MANAGED_NODE_GROUPS_CREATE_TIMEOUT")
 	os.Setenv("AWS_K8S_TESTER_EKS_COMMAND_AFTER_CREATE_ADD_ONS", "echo hello2")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_COMMAND_AFTER_CREATE_ADD_ONS")
 	os.Setenv("AWS_K8S_TESTER_EKS_COMMAND_AFTER_CREATE_ADD_ONS_TIMEOUT", "17m")
@@ -72,14 +94,34 @@ spec:
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_S3_BUCKET_NAME")
 	os.Setenv("AWS_K8S_TESTER_EKS_S3_BUCKET_LIFECYCLE_EXPIRATION_DAYS", `10`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_S3_BUCKET_LIFECYCLE_EXPIRATION_DAYS")
+	os.Setenv("AWS_K8S_TESTER_EKS_S3_BUCKET_OBJECT_LOCK", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_S3_BUCKET_OBJECT_LOCK")
+	os.Setenv("AWS_K8S_TESTER_EKS_S3_BUCKET_OBJECT_LOCK_RETENTION_DAYS", `7`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_S3_BUCKET_OBJECT_LOCK_RETENTION_DAYS")
+	os.Setenv("AWS_K8S_TESTER_EKS_S3_REQUESTER_PAYS", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_S3_REQUESTER_PAYS")
+	os.Setenv("AWS_K8S_TESTER_EKS_S3_EXPECTED_BUCKET_OWNER", `123456789012`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_S3_EXPECTED_BUCKET_OWNER")
+	os.Setenv("AWS_K8S_TESTER_EKS_S3_ROLE_ARN", `arn:aws:iam::123456789012:role/central-s3-writer`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_S3_ROLE_ARN")
+	os.Setenv("AWS_K8S_TESTER_EKS_S3_RETRY_MAX_ATTEMPTS", `10`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_S3_RETRY_MAX_ATTEMPTS")
+	os.Setenv("AWS_K8S_TESTER_EKS_S3_RETRY_MAX_BACKOFF", `30s`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_S3_RETRY_MAX_BACKOFF")
 	os.Setenv("AWS_K8S_TESTER_EKS_CLIENTS", `333`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_CLIENTS")
 	os.Setenv("AWS_K8S_TESTER_EKS_CLIENT_TIMEOUT", `10m`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_CLIENT_TIMEOUT")
 	os.Setenv("AWS_K8S_TESTER_EKS_CLIENT_QPS", `99555.77`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_CLIENT_QPS")
+	os.Setenv("AWS_K8S_TESTER_EKS_CREATE_CONCURRENCY", `3`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_CREATE_CONCURRENCY")
 	os.Setenv("AWS_K8S_TESTER_EKS_CLIENT_BURST", `177`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_CLIENT_BURST")
+	os.Setenv("AWS_K8S_TESTER_EKS_CLIENT_MAX_RETRIES", `-1`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_CLIENT_MAX_RETRIES")
+	os.Setenv("AWS_K8S_TESTER_EKS_CLIENT_READYZ_INTERVAL", `1m`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_CLIENT_READYZ_INTERVAL")
 
 	os.Setenv("AWS_K8S_TESTER_EKS_SKIP_DELETE_CLUSTER_AND_NODES", "true")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_SKIP_DELETE_CLUSTER_AND_NODES")
@@ -140,18 +182,38 @@ spec:
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CLUSTER_WAIT_BEFORE_UPGRADE_UPGRADE_VERSION")
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CLUSTER_VERSION_UPGRADE_VERSION", "1.19")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CLUSTER_VERSION_UPGRADE_VERSION")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CLUSTER_VERSION_UPGRADE_CASCADE_ADD_ONS", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CLUSTER_VERSION_UPGRADE_CASCADE_ADD_ONS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CLUSTER_VERSION_UPGRADE_ADD_ON_NAMES", "coredns,kube-proxy")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CLUSTER_VERSION_UPGRADE_ADD_ON_NAMES")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_VERSION_SKEW_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_VERSION_SKEW_ENABLE")
 
 	os.Setenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_KEY_CREATE", "true")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_KEY_CREATE")
+	os.Setenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_INSTANCE_CONNECT", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_INSTANCE_CONNECT")
+	os.Setenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_HOST_KEY_CHECK", "console-output")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_HOST_KEY_CHECK")
+	os.Setenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_KNOWN_HOSTS_PATH", "known_hosts")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_KNOWN_HOSTS_PATH")
 	os.Setenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_KEY_NAME", "a")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_KEY_NAME")
 	os.Setenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_PRIVATE_KEY_PATH", "a")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_PRIVATE_KEY_PATH")
+	os.Setenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_BASTION_HOST", "bastion.example.com:2222")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_BASTION_HOST")
+	os.Setenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_BASTION_USER_NAME", "ubuntu")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_BASTION_USER_NAME")
 
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE", "true")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE")
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_FETCH_LOGS", "false")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_FETCH_LOGS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_DIAGNOSE_ON_FAILURE", "false")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_DIAGNOSE_ON_FAILURE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_FETCH_LOGS_FAN_OUT", "8")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_FETCH_LOGS_FAN_OUT")
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ASGS", `{"ng-test-name-cpu":{"name":"ng-test-name-cpu","remote-access-user-name":"ec2-user","ami-type":"AL2_x86_64","image-id-ssm-parameter":"/aws/service/eks/optimized-ami/1.30/amazon-linux-2/recommended/image_id","asg-min-size":17,"kubelet-extra-args":"bbb qq","bootstrap-args":"--pause-container-account 012345678901", "cluster-autoscaler" : {"enable" : false}, "asg-max-size":99,"asg-desired-capacity":77,"instance-type":"type-cpu-2","volume-size":40,"volume-type":"gp2"},"ng-test-name-gpu":{"name":"ng-test-name-gpu","remote-access-user-name":"ec2-user","ami-type":"AL2_x86_64_GPU","asg-min-size":30,"asg-max-size":35,"asg-desired-capacity":34,"instance-type":"type-gpu-2","image-id":"my-gpu-ami","volume-size":500,"volume-type":"gp3","cluster-autoscaler": {"enable":false},"kubelet-extra-args":"aaa aa"}}`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ASGS")
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ROLE_NAME", "a")
@@ -163,6 +225,10 @@ spec:
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_FETCH_LOGS", "false")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_FETCH_LOGS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_DIAGNOSE_ON_FAILURE", "false")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_DIAGNOSE_ON_FAILURE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_FETCH_LOGS_FAN_OUT", "8")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_FETCH_LOGS_FAN_OUT")
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ROLE_CREATE", "true")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ROLE_CREATE")
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ROLE_NAME", "mng-role-name")
@@ -352,6 +418,8 @@ spec:
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CONFIGMAPS_LOCAL_OBJECTS")
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CONFIGMAPS_LOCAL_OBJECT_SIZE", "555")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CONFIGMAPS_LOCAL_OBJECT_SIZE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CONFIGMAPS_LOCAL_WATCHERS", "7")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CONFIGMAPS_LOCAL_WATCHERS")
 
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CONFIGMAPS_REMOTE_ENABLE", "true")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CONFIGMAPS_REMOTE_ENABLE")
@@ -376,6 +444,10 @@ spec:
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_SECRETS_LOCAL_OBJECTS")
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_SECRETS_LOCAL_OBJECT_SIZE", "10")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_SECRETS_LOCAL_OBJECT_SIZE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_SECRETS_LOCAL_MOUNT_PODS", "2")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_SECRETS_LOCAL_MOUNT_PODS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_SECRETS_LOCAL_MOUNT_POD_IMAGE", "busybox")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_SECRETS_LOCAL_MOUNT_POD_IMAGE")
 
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_SECRETS_REMOTE_ENABLE", "true")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_SECRETS_REMOTE_ENABLE")
@@ -414,6 +486,10 @@ spec:
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_FARGATE_REPOSITORY_IMAGE_TAG")
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_FARGATE_SECRET_NAME", "HELLO-SECRET")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_FARGATE_SECRET_NAME")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_FARGATE_POD_REPLICAS", "3")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_FARGATE_POD_REPLICAS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_FARGATE_LOGS_DIR", "fargate-logs")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_FARGATE_LOGS_DIR")
 
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_IRSA_ENABLE", "true")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_IRSA_ENABLE")
@@ -547,6 +623,8 @@ spec:
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_STRESSER_REMOTE_REPOSITORY_IMAGE_TAG")
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_STRESSER_REMOTE_COMPLETES", "500")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_STRESSER_REMOTE_COMPLETES")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_STRESSER_REMOTE_WORKLOAD_TYPE", "DaemonSet")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_STRESSER_REMOTE_WORKLOAD_TYPE")
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_STRESSER_REMOTE_OBJECT_SIZE", "512")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_STRESSER_REMOTE_OBJECT_SIZE")
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_STRESSER_REMOTE_LIST_LIMIT", "177")
@@ -617,6 +695,15 @@ spec:
 	if cfg.RemoteAccessCommandsOutputPath != "hello-ssh" {
 		t.Fatalf("unexpected %q", cfg.RemoteAccessCommandsOutputPath)
 	}
+	if cfg.ReportJUnitXMLPath != "hello-junit.xml" {
+		t.Fatalf("unexpected %q", cfg.ReportJUnitXMLPath)
+	}
+	if cfg.ReportJSONPath != "hello-report.json" {
+		t.Fatalf("unexpected %q", cfg.ReportJSONPath)
+	}
+	if cfg.CostSummaryPath != "hello-cost.json" {
+		t.Fatalf("unexpected %q", cfg.CostSummaryPath)
+	}
 	if cfg.Region != "us-east-1" {
 		t.Fatalf("unexpected %q", cfg.Region)
 	}
@@ -644,6 +731,24 @@ spec:
 	if cfg.CommandAfterCreateClusterTimeout != 7*time.Minute {
 		t.Fatalf("unexpected CommandAfterCreateClusterTimeout %v", cfg.CommandAfterCreateClusterTimeout)
 	}
+	if !cfg.OnSignalCleanup {
+		t.Fatalf("unexpected OnSignalCleanup %v", cfg.OnSignalCleanup)
+	}
+	if cfg.MetricsListenAddress != "localhost:9090" {
+		t.Fatalf("unexpected MetricsListenAddress %q", cfg.MetricsListenAddress)
+	}
+	if cfg.RunTimeout != 5*time.Hour {
+		t.Fatalf("unexpected RunTimeout %v", cfg.RunTimeout)
+	}
+	if cfg.SoakDuration != 48*time.Hour || cfg.SoakInterval != 30*time.Minute {
+		t.Fatalf("unexpected SoakDuration %v, SoakInterval %v", cfg.SoakDuration, cfg.SoakInterval)
+	}
+	if cfg.ManagedNodeGroupsCreateTimeout != 40*time.Minute {
+		t.Fatalf("unexpected ManagedNodeGroupsCreateTimeout %v", cfg.ManagedNodeGroupsCreateTimeout)
+	}
+	if cfg.AddOnCreateTimeout != 0 {
+		t.Fatalf("unexpected AddOnCreateTimeout %v", cfg.AddOnCreateTimeout)
+	}
 	if cfg.CommandAfterCreateAddOns != "echo hello2" {
 		t.Fatalf("unexpected CommandAfterCreateAddOns %q", cfg.CommandAfterCreateAddOns)
 	}
@@ -662,6 +767,27 @@ spec:
 	if cfg.S3.BucketLifecycleExpirationDays != 10 {
 		t.Fatalf("unexpected cfg.S3.BucketLifecycleExpirationDays %d", cfg.S3.BucketLifecycleExpirationDays)
 	}
+	if !cfg.S3.BucketObjectLock {
+		t.Fatalf("unexpected cfg.S3.BucketObjectLock %v", cfg.S3.BucketObjectLock)
+	}
+	if cfg.S3.BucketObjectLockRetentionDays != 7 {
+		t.Fatalf("unexpected cfg.S3.BucketObjectLockRetentionDays %d", cfg.S3.BucketObjectLockRetentionDays)
+	}
+	if !cfg.S3.RequesterPays {
+		t.Fatalf("unexpected cfg.S3.RequesterPays %v", cfg.S3.RequesterPays)
+	}
+	if cfg.S3.ExpectedBucketOwner != "123456789012" {
+		t.Fatalf("unexpected cfg.S3.ExpectedBucketOwner %q", cfg.S3.ExpectedBucketOwner)
+	}
+	if cfg.S3.RoleARN != "arn:aws:iam::123456789012:role/central-s3-writer" {
+		t.Fatalf("unexpected cfg.S3.RoleARN %q", cfg.S3.RoleARN)
+	}
+	if cfg.S3.RetryMaxAttempts != 10 {
+		t.Fatalf("unexpected cfg.S3.RetryMaxAttempts %d", cfg.S3.RetryMaxAttempts)
+	}
+	if cfg.S3.RetryMaxBackoff != 30*time.Second {
+		t.Fatalf("unexpected cfg.S3.RetryMaxBackoff %v", cfg.S3.RetryMaxBackoff)
+	}
 	if cfg.Clients != 333 {
 		t.Fatalf("unexpected cfg.Clients %d", cfg.Clients)
 	}
@@ -671,9 +797,18 @@ spec:
 	if cfg.ClientQPS != 99555.77 {
 		t.Fatalf("unexpected cfg.ClientQPS %f", cfg.ClientQPS)
 	}
+	if cfg.CreateConcurrency != 3 {
+		t.Fatalf("unexpected cfg.CreateConcurrency %d", cfg.CreateConcurrency)
+	}
 	if cfg.ClientBurst != 177 {
 		t.Fatalf("unexpected cfg.ClientBurst %d", cfg.ClientBurst)
 	}
+	if cfg.ClientMaxRetries != -1 {
+		t.Fatalf("unexpected cfg.ClientMaxRetries %d", cfg.ClientMaxRetries)
+	}
+	if cfg.ClientReadyzInterval != time.Minute {
+		t.Fatalf("unexpected cfg.ClientReadyzInterval %v", cfg.ClientReadyzInterval)
+	}
 
 	if !cfg.SkipDeleteClusterAndNodes {
 		t.Fatalf("unexpected SkipDeleteClusterAndNodes %v", cfg.SkipDeleteClusterAndNodes)
@@ -775,16 +910,41 @@ spec:
 	if cfg.AddOnClusterVersionUpgrade.Version != "1.19" {
 		t.Fatalf("unexpected AddOnClusterVersionUpgrade.Version %q", cfg.AddOnClusterVersionUpgrade.Version)
 	}
+	if !cfg.AddOnClusterVersionUpgrade.CascadeAddOns {
+		t.Fatalf("unexpected AddOnClusterVersionUpgrade.CascadeAddOns %v", cfg.AddOnClusterVersionUpgrade.CascadeAddOns)
+	}
+	if !reflect.DeepEqual(cfg.AddOnClusterVersionUpgrade.AddOnNames, []string{"coredns", "kube-proxy"}) {
+		t.Fatalf("unexpected AddOnClusterVersionUpgrade.AddOnNames %v", cfg.AddOnClusterVersionUpgrade.AddOnNames)
+	}
+
+	if !cfg.AddOnVersionSkew.Enable {
+		t.Fatalf("unexpected AddOnVersionSkew.Enable %v", cfg.AddOnVersionSkew.Enable)
+	}
 
 	if !cfg.RemoteAccessKeyCreate {
 		t.Fatalf("unexpected cfg.RemoteAccessKeyCreate %v", cfg.RemoteAccessKeyCreate)
 	}
+	if !cfg.RemoteAccessInstanceConnect {
+		t.Fatalf("unexpected cfg.RemoteAccessInstanceConnect %v", cfg.RemoteAccessInstanceConnect)
+	}
+	if cfg.RemoteAccessHostKeyCheck != RemoteAccessHostKeyCheckConsoleOutput {
+		t.Fatalf("unexpected cfg.RemoteAccessHostKeyCheck %q", cfg.RemoteAccessHostKeyCheck)
+	}
+	if cfg.RemoteAccessKnownHostsPath != "known_hosts" {
+		t.Fatalf("unexpected cfg.RemoteAccessKnownHostsPath %q", cfg.RemoteAccessKnownHostsPath)
+	}
 	if cfg.RemoteAccessKeyName != "a" {
 		t.Fatalf("unexpected cfg.RemoteAccessKeyName %q", cfg.RemoteAccessKeyName)
 	}
 	if cfg.RemoteAccessPrivateKeyPath != "a" {
 		t.Fatalf("unexpected cfg.RemoteAccessPrivateKeyPath %q", cfg.RemoteAccessPrivateKeyPath)
 	}
+	if cfg.RemoteAccessBastionHost != "bastion.example.com:2222" {
+		t.Fatalf("unexpected cfg.RemoteAccessBastionHost %q", cfg.RemoteAccessBastionHost)
+	}
+	if cfg.RemoteAccessBastionUserName != "ubuntu" {
+		t.Fatalf("unexpected cfg.RemoteAccessBastionUserName %q", cfg.RemoteAccessBastionUserName)
+	}
 
 	if !cfg.AddOnNodeGroups.Enable {
 		t.Fatalf("unexpected cfg.AddOnNodeGroups.Enable %v", cfg.AddOnNodeGroups.Enable)
@@ -795,6 +955,12 @@ spec:
 	if cfg.AddOnNodeGroups.FetchLogs {
 		t.Fatalf("unexpected cfg.AddOnNodeGroups.FetchLogs %v", cfg.AddOnNodeGroups.FetchLogs)
 	}
+	if cfg.AddOnNodeGroups.DiagnoseOnFailure {
+		t.Fatalf("unexpected cfg.AddOnNodeGroups.DiagnoseOnFailure %v", cfg.AddOnNodeGroups.DiagnoseOnFailure)
+	}
+	if cfg.AddOnNodeGroups.FetchLogsFanOut != 8 {
+		t.Fatalf("unexpected cfg.AddOnNodeGroups.FetchLogsFanOut %d", cfg.AddOnNodeGroups.FetchLogsFanOut)
+	}
 
 	cpuName, gpuName := "ng-test-name-cpu", "ng-test-name-gpu"
 	expectedASGs := map[string]ASG{
@@ -845,6 +1011,12 @@ spec:
 	if cfg.AddOnManagedNodeGroups.FetchLogs {
 		t.Fatalf("unexpected cfg.AddOnManagedNodeGroups.FetchLogs %v", cfg.AddOnManagedNodeGroups.FetchLogs)
 	}
+	if cfg.AddOnManagedNodeGroups.DiagnoseOnFailure {
+		t.Fatalf("unexpected cfg.AddOnManagedNodeGroups.DiagnoseOnFailure %v", cfg.AddOnManagedNodeGroups.DiagnoseOnFailure)
+	}
+	if cfg.AddOnManagedNodeGroups.FetchLogsFanOut != 8 {
+		t.Fatalf("unexpected cfg.AddOnManagedNodeGroups.FetchLogsFanOut %d", cfg.AddOnManagedNodeGroups.FetchLogsFanOut)
+	}
 	if !cfg.AddOnManagedNodeGroups.Role.Create {
 		t.Fatalf("unexpected AddOnManagedNodeGroups.RoleCreate %v", cfg.AddOnManagedNodeGroups.Role.Create)
 	}
@@ -1210,6 +1382,9 @@ spec:
 	if cfg.AddOnConfigmapsLocal.ObjectSize != 555 {
 		t.Fatalf("unexpected cfg.AddOnConfigmapsLocal.ObjectSize %d", cfg.AddOnConfigmapsLocal.ObjectSize)
 	}
+	if cfg.AddOnConfigmapsLocal.Watchers != 7 {
+		t.Fatalf("unexpected cfg.AddOnConfigmapsLocal.Watchers %d", cfg.AddOnConfigmapsLocal.Watchers)
+	}
 	if !cfg.AddOnConfigmapsRemote.Enable {
 		t.Fatalf("unexpected cfg.AddOnConfigmapsRemote.Enable %v", cfg.AddOnConfigmapsRemote.Enable)
 	}
@@ -1244,6 +1419,12 @@ spec:
 	if cfg.AddOnSecretsLocal.ObjectSize != 10 {
 		t.Fatalf("unexpected cfg.AddOnSecretsLocal.ObjectSize %v", cfg.AddOnSecretsLocal.ObjectSize)
 	}
+	if cfg.AddOnSecretsLocal.MountPods != 2 {
+		t.Fatalf("unexpected cfg.AddOnSecretsLocal.MountPods %v", cfg.AddOnSecretsLocal.MountPods)
+	}
+	if cfg.AddOnSecretsLocal.MountPodImage != "busybox" {
+		t.Fatalf("unexpected cfg.AddOnSecretsLocal.MountPodImage %v", cfg.AddOnSecretsLocal.MountPodImage)
+	}
 
 	if !cfg.AddOnSecretsRemote.Enable {
 		t.Fatalf("unexpected cfg.AddOnSecretsRemote.Enable %v", cfg.AddOnSecretsRemote.Enable)
@@ -1302,6 +1483,12 @@ spec:
 	if cfg.AddOnFargate.SecretName != "HELLO-SECRET" {
 		t.Fatalf("unexpected cfg.AddOnFargate.SecretName %q", cfg.AddOnFargate.SecretName)
 	}
+	if cfg.AddOnFargate.PodReplicas != 3 {
+		t.Fatalf("unexpected cfg.AddOnFargate.PodReplicas %d", cfg.AddOnFargate.PodReplicas)
+	}
+	if cfg.AddOnFargate.LogsDir != "fargate-logs" {
+		t.Fatalf("unexpected cfg.AddOnFargate.LogsDir %q", cfg.AddOnFargate.LogsDir)
+	}
 
 	if !cfg.AddOnIRSA.Enable {
 		t.Fatalf("unexpected cfg.AddOnIRSA.Enable %v", cfg.AddOnIRSA.Enable)
@@ -1492,6 +1679,9 @@ spec:
 	if cfg.AddOnStresserRemote.Completes != 500 {
 		t.Fatalf("unexpected cfg.AddOnStresserRemote.Completes %v", cfg.AddOnStresserRemote.Completes)
 	}
+	if cfg.AddOnStresserRemote.WorkloadType != StresserRemoteWorkloadTypeDaemonSet {
+		t.Fatalf("unexpected cfg.AddOnStresserRemote.WorkloadType %v", cfg.AddOnStresserRemote.WorkloadType)
+	}
 	if cfg.AddOnStresserRemote.ObjectSize != 512 {
 		t.Fatalf("unexpected cfg.AddOnStresserRemote.ObjectSize %v", cfg.AddOnStresserRemote.ObjectSize)
 	}
@@ -1654,6 +1844,7 @@ func TestEnvAddOnNodeGroupsGetRef(t *testing.T) {
 				},
 				ImageID:            "my-ami",
 				AMIType:            eks.AMITypesAl2X8664,
+				AMIFamily:          "AL2",
 				InstanceType:       "type-2",
 				VolumeSize:         500,
 				VolumeType:         "gp3",
@@ -1693,6 +1884,7 @@ func TestEnvAddOnNodeGroupsGetRef(t *testing.T) {
 			RemoteAccessUserName: "ec2-user",
 			Tags:                 map[string]string{"group": "amazon-vpc-cni-k8s"},
 			AMIType:              "AL2_x86_64",
+			AMIFamily:            "AL2",
 			ASGMinSize:           3,
 			ASGMaxSize:           3,
 			ASGDesiredCapacity:   3,
@@ -1722,6 +1914,12 @@ func TestEnvAddOnConformance(t *testing.T) {
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CONFORMANCE_ENABLE")
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CONFORMANCE_NAMESPACE", "conformance-test")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CONFORMANCE_NAMESPACE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CONFORMANCE_RUNNER", "hydrophone")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CONFORMANCE_RUNNER")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CONFORMANCE_HYDROPHONE_PATH", "bbbbb")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CONFORMANCE_HYDROPHONE_PATH")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CONFORMANCE_HYDROPHONE_PARALLEL", "4")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CONFORMANCE_HYDROPHONE_PARALLEL")
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CONFORMANCE_SONOBUOY_PATH", "aaaaa")
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CONFORMANCE_SONOBUOY_PATH")
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CONFORMANCE_SONOBUOY_DOWNLOAD_URL", "sonobuoy-download-here")
@@ -1791,6 +1989,15 @@ func TestEnvAddOnConformance(t *testing.T) {
 	if cfg.AddOnConformance.SonobuoyRunE2eSkip != "sig-network" {
 		t.Fatalf("unexpected cfg.AddOnConformance.SonobuoyRunE2eSkip %q", cfg.AddOnConformance.SonobuoyRunE2eSkip)
 	}
+	if cfg.AddOnConformance.Runner != ConformanceRunnerHydrophone {
+		t.Fatalf("unexpected cfg.AddOnConformance.Runner %q", cfg.AddOnConformance.Runner)
+	}
+	if cfg.AddOnConformance.HydrophonePath != "bbbbb" {
+		t.Fatalf("unexpected cfg.AddOnConformance.HydrophonePath %q", cfg.AddOnConformance.HydrophonePath)
+	}
+	if cfg.AddOnConformance.HydrophoneParallel != 4 {
+		t.Fatalf("unexpected cfg.AddOnConformance.HydrophoneParallel %d", cfg.AddOnConformance.HydrophoneParallel)
+	}
 }
 
 // TestEnvAddOnManagedNodeGroupsCNI tests CNI integration test MNG settings.
@@ -1828,6 +2035,7 @@ func TestEnvAddOnManagedNodeGroupsCNI(t *testing.T) {
 			RemoteAccessUserName: "ec2-user",
 			Tags:                 map[string]string{"group": "amazon-vpc-cni-k8s"},
 			AMIType:              "AL2_x86_64",
+			AMIFamily:            "AL2",
 			ASGMinSize:           3,
 			ASGMaxSize:           3,
 			ASGDesiredCapacity:   3,
@@ -1840,8 +2048,9 @@ func TestEnvAddOnManagedNodeGroupsCNI(t *testing.T) {
 	}
 }
 
-// TestEnvAddOnManagedNodeGroupsInvalidInstanceType tests invalid instance types.
-func TestEnvAddOnManagedNodeGroupsInvalidInstanceType(t *testing.T) {
+// TestEnvAddOnManagedNodeGroupsReleaseVersionUpgrade tests AMI release version
+// only upgrade, which does not change the Kubernetes version.
+func TestEnvAddOnManagedNodeGroupsReleaseVersionUpgrade(t *testing.T) {
 	cfg := NewDefault()
 	defer func() {
 		os.RemoveAll(cfg.ConfigPath)
@@ -1851,26 +2060,35 @@ func TestEnvAddOnManagedNodeGroupsInvalidInstanceType(t *testing.T) {
 
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
-	os.Setenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_PRIVATE_KEY_PATH", `a`)
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_PRIVATE_KEY_PATH")
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_MNGS", `{"test-mng-for-cni":{"name":"test-mng-for-cni","tags":{"group":"amazon-vpc-cni-k8s"},"ami-type":"AL2_x86_64","asg-min-size":3,"asg-max-size":3,"asg-desired-capacity":3,"instance-types":["m3.xlarge"]}}`)
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_MNGS", `{"test-mng-upgrade":{"name":"test-mng-upgrade","remote-access-user-name":"ec2-user","ami-type":"AL2_x86_64","asg-min-size":3,"asg-max-size":3,"asg-desired-capacity":3,"instance-types":["c5.xlarge"],"version-upgrade":{"enable":true,"release-version":"1.27.1-20230703","max-unavailable-percentage":50,"workload-replicas":4}}}`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_MNGS")
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NLB_HELLO_WORLD_ENABLE", `true`)
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NLB_HELLO_WORLD_ENABLE")
 
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
 	}
-	err := cfg.ValidateAndSetDefaults()
-	if err == nil {
-		t.Fatal("expected error")
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
 	}
-	if !strings.Contains(err.Error(), "older instance type InstanceTypes") {
-		t.Fatalf("unexpected error %v", err)
+
+	up := cfg.AddOnManagedNodeGroups.MNGs["test-mng-upgrade"].VersionUpgrade
+	if up.ReleaseVersion != "1.27.1-20230703" {
+		t.Fatalf("unexpected VersionUpgrade.ReleaseVersion %q", up.ReleaseVersion)
+	}
+	if up.MaxUnavailablePercentage != 50 {
+		t.Fatalf("unexpected VersionUpgrade.MaxUnavailablePercentage %d", up.MaxUnavailablePercentage)
+	}
+	if up.WorkloadReplicas != 4 || up.WorkloadMinAvailable != 3 {
+		t.Fatalf("unexpected VersionUpgrade.WorkloadReplicas %d, WorkloadMinAvailable %d", up.WorkloadReplicas, up.WorkloadMinAvailable)
+	}
+
+	up.MaxUnavailable = 1
+	if err := cfg.ValidateAndSetDefaults(); err == nil || !strings.Contains(err.Error(), "cannot set both") {
+		t.Fatalf("expected max-unavailable conflict error, got %v", err)
 	}
 }
 
-func TestEnvAddOnCSIEBS(t *testing.T) {
+// TestEnvAddOnManagedNodeGroupsLaunchTemplate tests managed node groups with a custom launch template.
+func TestEnvAddOnManagedNodeGroupsLaunchTemplate(t *testing.T) {
 	cfg := NewDefault()
 	defer func() {
 		os.RemoveAll(cfg.ConfigPath)
@@ -1880,27 +2098,51 @@ func TestEnvAddOnCSIEBS(t *testing.T) {
 
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
-
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_ENABLE", "true")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_ENABLE")
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_CHART_REPO_URL", "test-chart-repo")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_CHART_REPO_URL")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_MNGS", `{"test-mng-lt":{"name":"test-mng-lt","ami-type":"AL2_x86_64","asg-min-size":1,"asg-max-size":1,"asg-desired-capacity":1,"volume-size":100,"launch-template":{"enable":true,"image-id":"ami-123","user-data":"echo hello","kubelet-extra-args":"--max-pods=110","volume-iops":4000}},"test-mng-lt-disabled":{"name":"test-mng-lt-disabled","ami-type":"AL2_x86_64","asg-min-size":1,"asg-max-size":1,"asg-desired-capacity":1,"launch-template":{"enable":false}}}`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_MNGS")
 
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
 	}
-	err := cfg.ValidateAndSetDefaults()
-	assert.NoError(t, err)
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
 
-	if !cfg.AddOnCSIEBS.Enable {
-		t.Fatalf("unexpected cfg.AddOnCSIEBS.Enable %v", cfg.AddOnCSIEBS.Enable)
+	lt := cfg.AddOnManagedNodeGroups.MNGs["test-mng-lt"].LaunchTemplate
+	expected := &MNGLaunchTemplate{
+		Enable:                          true,
+		Name:                            "test-mng-lt-launch-template",
+		ImageID:                         "ami-123",
+		UserData:                        "echo hello",
+		KubeletExtraArgs:                "--max-pods=110",
+		MetadataHTTPTokens:              DefaultMNGLaunchTemplateMetadataHTTPTokens,
+		MetadataHTTPPutResponseHopLimit: DefaultMNGLaunchTemplateMetadataHTTPPutResponseHopLimit,
+		VolumeType:                      DefaultMNGLaunchTemplateVolumeType,
+		VolumeIOPS:                      4000,
 	}
-	if cfg.AddOnCSIEBS.ChartRepoURL != "test-chart-repo" {
-		t.Fatalf("unexpected cfg.AddOnCSIEBS.ChartRepoURL %q", cfg.AddOnCSIEBS.ChartRepoURL)
+	if !reflect.DeepEqual(lt, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, lt)
+	}
+	if lt := cfg.AddOnManagedNodeGroups.MNGs["test-mng-lt-disabled"].LaunchTemplate; lt != nil {
+		t.Fatalf("expected nil LaunchTemplate, got %+v", lt)
+	}
+
+	cur := cfg.AddOnManagedNodeGroups.MNGs["test-mng-lt"]
+	cur.LaunchTemplate.ImageID = ""
+	cfg.AddOnManagedNodeGroups.MNGs["test-mng-lt"] = cur
+	if err := cfg.ValidateAndSetDefaults(); err == nil || !strings.Contains(err.Error(), "requires LaunchTemplate.ImageID") {
+		t.Fatalf("expected kubelet extra args error, got %v", err)
+	}
+
+	cur.LaunchTemplate.KubeletExtraArgs = ""
+	cur.LaunchTemplate.VolumeType = "gp2"
+	if err := cfg.ValidateAndSetDefaults(); err == nil || !strings.Contains(err.Error(), "invalid LaunchTemplate.VolumeIOPS") {
+		t.Fatalf("expected volume IOPS error, got %v", err)
 	}
 }
 
-func TestEnvAddOnAppMesh(t *testing.T) {
+// TestEnvAddOnManagedNodeGroupsLabelsTaints tests managed node group labels and taints.
+func TestEnvAddOnManagedNodeGroupsLabelsTaints(t *testing.T) {
 	cfg := NewDefault()
 	defer func() {
 		os.RemoveAll(cfg.ConfigPath)
@@ -1910,77 +2152,139 @@ func TestEnvAddOnAppMesh(t *testing.T) {
 
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
-
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_IRSA_ENABLE", `false`)
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_IRSA_ENABLE")
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_APP_MESH_ENABLE", "true")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_APP_MESH_ENABLE")
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_APP_MESH_NAMESPACE", "custom-namespace")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_APP_MESH_NAMESPACE")
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_APP_MESH_CONTROLLER_IMAGE", "repo/controller:v1.1.3")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_APP_MESH_CONTROLLER_IMAGE")
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_APP_MESH_INJECTOR_IMAGE", "repo/injector:v1.1.3")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_APP_MESH_INJECTOR_IMAGE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_MNGS", `{"test-mng-taints":{"name":"test-mng-taints","ami-type":"AL2_x86_64","asg-min-size":1,"asg-max-size":1,"asg-desired-capacity":1,"labels":{"team":"infra","example.com/tier":"batch"},"taints":[{"key":"dedicated","value":"infra","effect":"NoSchedule"},{"key":"example.com/spot","effect":"NoExecute"}]}}`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_MNGS")
 
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
 	}
-	err := cfg.ValidateAndSetDefaults()
-	assert.NoError(t, err)
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
 
-	assert.True(t, cfg.AddOnAppMesh.Enable)
-	assert.Equal(t, cfg.AddOnAppMesh.Namespace, "custom-namespace")
-	assert.Equal(t, cfg.AddOnAppMesh.ControllerImage, "repo/controller:v1.1.3")
-	assert.Equal(t, cfg.AddOnAppMesh.InjectorImage, "repo/injector:v1.1.3")
+	cur := cfg.AddOnManagedNodeGroups.MNGs["test-mng-taints"]
+	if !reflect.DeepEqual(cur.Labels, map[string]string{"team": "infra", "example.com/tier": "batch"}) {
+		t.Fatalf("unexpected Labels %v", cur.Labels)
+	}
+	expectedTaints := []MNGTaint{
+		{Key: "dedicated", Value: "infra", Effect: "NoSchedule"},
+		{Key: "example.com/spot", Effect: "NoExecute"},
+	}
+	if !reflect.DeepEqual(cur.Taints, expectedTaints) {
+		t.Fatalf("expected %+v, got %+v", expectedTaints, cur.Taints)
+	}
+	if e := cur.Taints[0].EKSEffect(); e != eks.TaintEffectNoSchedule {
+		t.Fatalf("unexpected EKS taint effect %q", e)
+	}
 
-	if cfg.AddOnAppMesh.PolicyCFNStackID != "" {
-		t.Fatalf("read-only AddOnAppMesh.PolicyCFNStackID is set to %q", cfg.AddOnAppMesh.PolicyCFNStackID)
+	cur.Labels["NGName"] = "other"
+	if err := cfg.ValidateAndSetDefaults(); err == nil || !strings.Contains(err.Error(), "is reserved") {
+		t.Fatalf("expected reserved label error, got %v", err)
+	}
+	delete(cur.Labels, "NGName")
+
+	cur.Taints[0].Effect = "NO_SCHEDULE"
+	if err := cfg.ValidateAndSetDefaults(); err == nil || !strings.Contains(err.Error(), "unknown Taints effect") {
+		t.Fatalf("expected taint effect error, got %v", err)
 	}
 }
 
-func TestEnvAddOnWordpress(t *testing.T) {
+// TestEnvAddOnManagedNodeGroupsWindows tests Windows managed node groups.
+func TestEnvAddOnManagedNodeGroupsWindows(t *testing.T) {
 	cfg := NewDefault()
-	defer func() {
+	defer func(cfg *Config) {
 		os.RemoveAll(cfg.ConfigPath)
 		os.RemoveAll(cfg.KubectlCommandsOutputPath)
 		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
-	}()
+	}(cfg)
 
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_ENABLE", "true")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_PRIVATE_KEY_PATH", `a`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_PRIVATE_KEY_PATH")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_MNGS", `{"test-mng-linux":{"name":"test-mng-linux","ami-type":"AL2_x86_64","asg-min-size":1,"asg-max-size":1,"asg-desired-capacity":1},"test-mng-windows":{"name":"test-mng-windows","ami-type":"WINDOWS_CORE_2019_x86_64","asg-min-size":2,"asg-max-size":2,"asg-desired-capacity":2}}`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_MNGS")
 
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_WORDPRESS_ENABLE", "true")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_WORDPRESS_ENABLE")
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_WORDPRESS_NAMESPACE", "word-press")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_WORDPRESS_NAMESPACE")
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_WORDPRESS_USER_NAME", "my-user")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_WORDPRESS_USER_NAME")
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_WORDPRESS_PASSWORD", "my-password")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_WORDPRESS_PASSWORD")
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+
+	cur := cfg.AddOnManagedNodeGroups.MNGs["test-mng-windows"]
+	if cur.RemoteAccessUserName != DefaultWindowsRemoteAccessUserName {
+		t.Fatalf("unexpected RemoteAccessUserName %q", cur.RemoteAccessUserName)
+	}
+	if !reflect.DeepEqual(cur.InstanceTypes, []string{DefaultNodeInstanceTypeCPU}) {
+		t.Fatalf("unexpected InstanceTypes %q", cur.InstanceTypes)
+	}
+	if cur = cfg.AddOnManagedNodeGroups.MNGs["test-mng-linux"]; cur.RemoteAccessUserName != "ec2-user" {
+		t.Fatalf("unexpected RemoteAccessUserName %q", cur.RemoteAccessUserName)
+	}
 
+	cfg = NewDefault()
+	defer func(cfg *Config) {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}(cfg)
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_MNGS", `{"test-mng-windows":{"name":"test-mng-windows","ami-type":"WINDOWS_FULL_2022_x86_64","remote-access-user-name":"ec2-user","asg-min-size":1,"asg-max-size":1,"asg-desired-capacity":1}}`)
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
 	}
 	err := cfg.ValidateAndSetDefaults()
-	assert.NoError(t, err)
+	if err == nil || !strings.Contains(err.Error(), "unexpected RemoteAccessUserName") {
+		t.Fatalf("unexpected error %v", err)
+	}
 
-	if !cfg.AddOnWordpress.Enable {
-		t.Fatalf("unexpected cfg.AddOnWordpress.Enable %v", cfg.AddOnWordpress.Enable)
+	cfg = NewDefault()
+	defer func(cfg *Config) {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}(cfg)
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_MNGS", `{"test-mng-windows":{"name":"test-mng-windows","ami-type":"WINDOWS_FULL_2022_x86_64","asg-min-size":1,"asg-max-size":1,"asg-desired-capacity":1}}`)
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
 	}
-	if cfg.AddOnWordpress.Namespace != "word-press" {
-		t.Fatalf("unexpected cfg.AddOnWordpress.Namespace %q", cfg.AddOnWordpress.Namespace)
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "no Linux node group") {
+		t.Fatalf("unexpected error %v", err)
 	}
-	if cfg.AddOnWordpress.UserName != "my-user" {
-		t.Fatalf("unexpected cfg.AddOnWordpress.UserName %q", cfg.AddOnWordpress.UserName)
+}
+
+// TestEnvAddOnManagedNodeGroupsInvalidInstanceType tests invalid instance types.
+func TestEnvAddOnManagedNodeGroupsInvalidInstanceType(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_PRIVATE_KEY_PATH", `a`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_REMOTE_ACCESS_PRIVATE_KEY_PATH")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_MNGS", `{"test-mng-for-cni":{"name":"test-mng-for-cni","tags":{"group":"amazon-vpc-cni-k8s"},"ami-type":"AL2_x86_64","asg-min-size":3,"asg-max-size":3,"asg-desired-capacity":3,"instance-types":["m3.xlarge"]}}`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_MNGS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NLB_HELLO_WORLD_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NLB_HELLO_WORLD_ENABLE")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
 	}
-	if cfg.AddOnWordpress.Password != "my-password" {
-		t.Fatalf("unexpected cfg.AddOnWordpress.Password %q", cfg.AddOnWordpress.Password)
+	err := cfg.ValidateAndSetDefaults()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "older instance type InstanceTypes") {
+		t.Fatalf("unexpected error %v", err)
 	}
 }
 
-func TestEnvAddOnKubernetesDashboard(t *testing.T) {
+func TestEnvAddOnCSIEBS(t *testing.T) {
 	cfg := NewDefault()
 	defer func() {
 		os.RemoveAll(cfg.ConfigPath)
@@ -1991,10 +2295,16 @@ func TestEnvAddOnKubernetesDashboard(t *testing.T) {
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
 
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_METRICS_SERVER_ENABLE", "true")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_METRICS_SERVER_ENABLE")
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBERNETES_DASHBOARD_ENABLE", "true")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBERNETES_DASHBOARD_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_CHART_REPO_URL", "test-chart-repo")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_CHART_REPO_URL")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_VOLUME_SIZE_GIB", "10")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_VOLUME_SIZE_GIB")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_VOLUME_RESIZE_GIB", "0")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_VOLUME_RESIZE_GIB")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_VOLUME_TEST_TIMEOUT", "10m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_VOLUME_TEST_TIMEOUT")
 
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
@@ -2002,16 +2312,46 @@ func TestEnvAddOnKubernetesDashboard(t *testing.T) {
 	err := cfg.ValidateAndSetDefaults()
 	assert.NoError(t, err)
 
-	if !cfg.AddOnMetricsServer.Enable {
-		t.Fatalf("unexpected cfg.AddOnMetricsServer.Enable %v", cfg.AddOnMetricsServer.Enable)
+	if !cfg.AddOnCSIEBS.Enable {
+		t.Fatalf("unexpected cfg.AddOnCSIEBS.Enable %v", cfg.AddOnCSIEBS.Enable)
 	}
-	if !cfg.AddOnKubernetesDashboard.Enable {
-		t.Fatalf("unexpected cfg.AddOnKubernetesDashboard.Enable %v", cfg.AddOnKubernetesDashboard.Enable)
+	if cfg.AddOnCSIEBS.ChartRepoURL != "test-chart-repo" {
+		t.Fatalf("unexpected cfg.AddOnCSIEBS.ChartRepoURL %q", cfg.AddOnCSIEBS.ChartRepoURL)
+	}
+	if cfg.AddOnCSIEBS.InstallMethod != CSIEBSInstallMethodHelm {
+		t.Fatalf("unexpected cfg.AddOnCSIEBS.InstallMethod %q", cfg.AddOnCSIEBS.InstallMethod)
+	}
+	if cfg.AddOnCSIEBS.Namespace != cfg.Name+"-csi-ebs" {
+		t.Fatalf("unexpected cfg.AddOnCSIEBS.Namespace %q", cfg.AddOnCSIEBS.Namespace)
+	}
+	if cfg.AddOnCSIEBS.StorageClassName != cfg.Name+"-csi-ebs" {
+		t.Fatalf("unexpected cfg.AddOnCSIEBS.StorageClassName %q", cfg.AddOnCSIEBS.StorageClassName)
+	}
+	if cfg.AddOnCSIEBS.VolumeSizeGiB != 10 {
+		t.Fatalf("unexpected cfg.AddOnCSIEBS.VolumeSizeGiB %d", cfg.AddOnCSIEBS.VolumeSizeGiB)
+	}
+	if cfg.AddOnCSIEBS.VolumeResizeGiB != 20 {
+		t.Fatalf("unexpected cfg.AddOnCSIEBS.VolumeResizeGiB %d", cfg.AddOnCSIEBS.VolumeResizeGiB)
+	}
+	if cfg.AddOnCSIEBS.VolumeTestTimeoutString != "10m0s" {
+		t.Fatalf("unexpected cfg.AddOnCSIEBS.VolumeTestTimeoutString %q", cfg.AddOnCSIEBS.VolumeTestTimeoutString)
+	}
+
+	cfg.AddOnCSIEBS.VolumeResizeGiB = 5
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "must be greater than") {
+		t.Fatalf("expected resize size error, got %v", err)
+	}
+
+	cfg.AddOnCSIEBS.VolumeResizeGiB = 20
+	cfg.AddOnCSIEBS.InstallMethod = "unknown"
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "unknown AddOnCSIEBS.InstallMethod") {
+		t.Fatalf("expected install method error, got %v", err)
 	}
-	fmt.Println(cfg.KubectlCommands())
 }
 
-func TestEnvAddOnPrometheusGrafana(t *testing.T) {
+func TestEnvAddOnKarpenter(t *testing.T) {
 	cfg := NewDefault()
 	defer func() {
 		os.RemoveAll(cfg.ConfigPath)
@@ -2021,15 +2361,17 @@ func TestEnvAddOnPrometheusGrafana(t *testing.T) {
 
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_ENABLE", "true")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_ENABLE")
 
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_GRAFANA_ENABLE", "true")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_GRAFANA_ENABLE")
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_GRAFANA_GRAFANA_ADMIN_USER_NAME", "MY_ADMIN_USER_NAME")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_GRAFANA_GRAFANA_ADMIN_USER_NAME")
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_GRAFANA_GRAFANA_ADMIN_PASSWORD", "MY_ADMIN_PASSWORD")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_GRAFANA_GRAFANA_ADMIN_PASSWORD")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KARPENTER_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KARPENTER_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KARPENTER_CHART_VERSION", "v0.16.0")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KARPENTER_CHART_VERSION")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KARPENTER_INSTANCE_TYPES", "c5.large,m5.large")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KARPENTER_INSTANCE_TYPES")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KARPENTER_DEPLOYMENT_REPLICAS", "5")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KARPENTER_DEPLOYMENT_REPLICAS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KARPENTER_CONSOLIDATION_TIMEOUT", "7m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KARPENTER_CONSOLIDATION_TIMEOUT")
 
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
@@ -2037,18 +2379,27 @@ func TestEnvAddOnPrometheusGrafana(t *testing.T) {
 	err := cfg.ValidateAndSetDefaults()
 	assert.NoError(t, err)
 
-	if !cfg.AddOnPrometheusGrafana.Enable {
-		t.Fatalf("unexpected cfg.AddOnPrometheusGrafana.Enable %v", cfg.AddOnPrometheusGrafana.Enable)
+	if !cfg.AddOnKarpenter.Enable {
+		t.Fatalf("unexpected cfg.AddOnKarpenter.Enable %v", cfg.AddOnKarpenter.Enable)
 	}
-	if cfg.AddOnPrometheusGrafana.GrafanaAdminUserName != "MY_ADMIN_USER_NAME" {
-		t.Fatalf("unexpected cfg.AddOnPrometheusGrafana.GrafanaAdminUserName %q", cfg.AddOnPrometheusGrafana.GrafanaAdminUserName)
+	if cfg.AddOnKarpenter.ChartVersion != "v0.16.0" {
+		t.Fatalf("unexpected cfg.AddOnKarpenter.ChartVersion %q", cfg.AddOnKarpenter.ChartVersion)
 	}
-	if cfg.AddOnPrometheusGrafana.GrafanaAdminPassword != "MY_ADMIN_PASSWORD" {
-		t.Fatalf("unexpected cfg.AddOnPrometheusGrafana.GrafanaAdminPassword %q", cfg.AddOnPrometheusGrafana.GrafanaAdminPassword)
+	if !reflect.DeepEqual(cfg.AddOnKarpenter.InstanceTypes, []string{"c5.large", "m5.large"}) {
+		t.Fatalf("unexpected cfg.AddOnKarpenter.InstanceTypes %q", cfg.AddOnKarpenter.InstanceTypes)
+	}
+	if cfg.AddOnKarpenter.DeploymentReplicas != 5 {
+		t.Fatalf("unexpected cfg.AddOnKarpenter.DeploymentReplicas %d", cfg.AddOnKarpenter.DeploymentReplicas)
+	}
+	if cfg.AddOnKarpenter.ConsolidationTimeoutString != "7m0s" {
+		t.Fatalf("unexpected cfg.AddOnKarpenter.ConsolidationTimeoutString %q", cfg.AddOnKarpenter.ConsolidationTimeoutString)
+	}
+	if cfg.AddOnKarpenter.ControllerRoleName != cfg.Name+"-add-on-karpenter-controller-role" {
+		t.Fatalf("unexpected cfg.AddOnKarpenter.ControllerRoleName %q", cfg.AddOnKarpenter.ControllerRoleName)
 	}
 }
 
-func TestEnvAddOnKubeflow(t *testing.T) {
+func TestSpecClusterAutoscalerScaleTest(t *testing.T) {
 	cfg := NewDefault()
 	defer func() {
 		os.RemoveAll(cfg.ConfigPath)
@@ -2058,29 +2409,2409 @@ func TestEnvAddOnKubeflow(t *testing.T) {
 
 	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
 	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
-
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBEFLOW_ENABLE", "true")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBEFLOW_ENABLE")
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBEFLOW_NAMESPACE", "kubeflow")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBEFLOW_NAMESPACE")
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBEFLOW_KFCTL_DOWNLOAD_URL", "kubeflow-download-here")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBEFLOW_KFCTL_DOWNLOAD_URL")
-	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBEFLOW_BASE_DIR", "kubeflow-base-dir")
-	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBEFLOW_BASE_DIR")
-
 	if err := cfg.UpdateFromEnvs(); err != nil {
 		t.Fatal(err)
 	}
+
+	cfg.Spec.ClusterAutoscaler = &ClusterAutoscalerSpec{
+		CloudProvider: CloudProviderAWS,
+		ScaleTest: &ClusterAutoscalerScaleTestSpec{
+			Replicas:         20,
+			ScaleDownTimeout: "30m",
+		},
+	}
 	err := cfg.ValidateAndSetDefaults()
 	assert.NoError(t, err)
 
-	if !cfg.AddOnKubeflow.Enable {
-		t.Fatalf("unexpected cfg.AddOnKubeflow.Enable %v", cfg.AddOnKubeflow.Enable)
+	spec := cfg.Spec.ClusterAutoscaler
+	if spec.Image != ClusterAutoscalerImages[cfg.Version] {
+		t.Fatalf("unexpected Spec.ClusterAutoscaler.Image %q", spec.Image)
 	}
-	if cfg.AddOnKubeflow.KfctlDownloadURL != "kubeflow-download-here" {
-		t.Fatalf("unexpected cfg.AddOnKubeflow.KfctlDownloadURL %q", cfg.AddOnKubeflow.KfctlDownloadURL)
+	if spec.ScaleTest.Replicas != 20 {
+		t.Fatalf("unexpected Spec.ClusterAutoscaler.ScaleTest.Replicas %d", spec.ScaleTest.Replicas)
 	}
-	if cfg.AddOnKubeflow.BaseDir != "kubeflow-base-dir" {
-		t.Fatalf("unexpected cfg.AddOnKubeflow.BaseDir %q", cfg.AddOnKubeflow.BaseDir)
+	if spec.ScaleTest.ScaleUpTimeout != "15m" || spec.ScaleTest.ScaleDownTimeout != "30m" {
+		t.Fatalf("unexpected Spec.ClusterAutoscaler.ScaleTest timeouts %q, %q", spec.ScaleTest.ScaleUpTimeout, spec.ScaleTest.ScaleDownTimeout)
+	}
+	if spec.ScaleTest.Namespace != cfg.Name+"-cluster-autoscaler" {
+		t.Fatalf("unexpected Spec.ClusterAutoscaler.ScaleTest.Namespace %q", spec.ScaleTest.Namespace)
+	}
+
+	// the scale test sets the desired capacity of the ASGs
+	spec.CloudProvider = CloudProviderKubemark
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error with kubemark cloud provider")
+	}
+	spec.CloudProvider = CloudProviderAWS
+	spec.ScaleTest.ScaleUpTimeout = "bad"
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error with invalid scale-up timeout")
+	}
+}
+
+func TestEnvAddOnSpotInterruption(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ASGS", `{"ng-spot":{"name":"ng-spot","remote-access-user-name":"ec2-user","ami-type":"AL2_x86_64","image-id":"my-ami","asg-min-size":2,"asg-max-size":4,"asg-desired-capacity":2,"capacity-type":"SPOT","instance-types":["c5.xlarge","m5.xlarge"],"on-demand-base-capacity":1}}`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ASGS")
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_SPOT_INTERRUPTION_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_SPOT_INTERRUPTION_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_SPOT_INTERRUPTION_SIMULATE_INTERRUPTION", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_SPOT_INTERRUPTION_SIMULATE_INTERRUPTION")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_SPOT_INTERRUPTION_DURATION_BEFORE_INTERRUPTION", "3m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_SPOT_INTERRUPTION_DURATION_BEFORE_INTERRUPTION")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_SPOT_INTERRUPTION_INSTANCE_IDS", "i-0123,i-4567")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_SPOT_INTERRUPTION_INSTANCE_IDS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	cur := cfg.AddOnNodeGroups.ASGs["ng-spot"]
+	if !cur.IsMixedInstances() {
+		t.Fatalf("expected mixed instances for %+v", cur)
+	}
+	if cur.InstanceType != "c5.xlarge" {
+		t.Fatalf("unexpected InstanceType %q", cur.InstanceType)
+	}
+	if cur.OnDemandBaseCapacity != 1 || cur.OnDemandPercentageAboveBaseCapacity != 0 {
+		t.Fatalf("unexpected On-Demand capacity %d, %d", cur.OnDemandBaseCapacity, cur.OnDemandPercentageAboveBaseCapacity)
+	}
+	if cur.SpotAllocationStrategy != "capacity-optimized" {
+		t.Fatalf("unexpected SpotAllocationStrategy %q", cur.SpotAllocationStrategy)
+	}
+
+	if !cfg.AddOnSpotInterruption.SimulateInterruption {
+		t.Fatalf("unexpected cfg.AddOnSpotInterruption.SimulateInterruption %v", cfg.AddOnSpotInterruption.SimulateInterruption)
+	}
+	if cfg.AddOnSpotInterruption.NodeGroupName != "ng-spot" {
+		t.Fatalf("unexpected cfg.AddOnSpotInterruption.NodeGroupName %q", cfg.AddOnSpotInterruption.NodeGroupName)
+	}
+	if cfg.AddOnSpotInterruption.DurationBeforeInterruptionString != "3m0s" {
+		t.Fatalf("unexpected cfg.AddOnSpotInterruption.DurationBeforeInterruptionString %q", cfg.AddOnSpotInterruption.DurationBeforeInterruptionString)
+	}
+	if !reflect.DeepEqual(cfg.AddOnSpotInterruption.InstanceIDs, []string{"i-0123", "i-4567"}) {
+		t.Fatalf("unexpected cfg.AddOnSpotInterruption.InstanceIDs %q", cfg.AddOnSpotInterruption.InstanceIDs)
+	}
+	if cfg.AddOnSpotInterruption.TerminationHandlerName != DefaultSpotInterruptionTerminationHandlerName {
+		t.Fatalf("unexpected cfg.AddOnSpotInterruption.TerminationHandlerName %q", cfg.AddOnSpotInterruption.TerminationHandlerName)
+	}
+
+	cfg.AddOnSpotInterruption.DurationBeforeInterruption = time.Minute
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for DurationBeforeInterruption < 2m")
+	}
+}
+
+func TestEnvAddOnGPU(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_GPU_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_GPU_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_GPU_INSTANCE_TYPE", "g5.xlarge")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_GPU_INSTANCE_TYPE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_GPU_ASG_DESIRED_CAPACITY", "2")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_GPU_ASG_DESIRED_CAPACITY")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_GPU_SMOKE_TEST_TIMEOUT", "20m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_GPU_SMOKE_TEST_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.AddOnGPU.MNGName != cfg.Name+"-mng-gpu" {
+		t.Fatalf("unexpected cfg.AddOnGPU.MNGName %q", cfg.AddOnGPU.MNGName)
+	}
+	if cfg.AddOnGPU.Namespace != cfg.Name+"-gpu" {
+		t.Fatalf("unexpected cfg.AddOnGPU.Namespace %q", cfg.AddOnGPU.Namespace)
+	}
+	if cfg.AddOnGPU.SmokeTestTimeoutString != "20m0s" {
+		t.Fatalf("unexpected cfg.AddOnGPU.SmokeTestTimeoutString %q", cfg.AddOnGPU.SmokeTestTimeoutString)
+	}
+	cur, ok := cfg.AddOnManagedNodeGroups.MNGs[cfg.AddOnGPU.MNGName]
+	if !ok {
+		t.Fatalf("expected GPU MNG %q", cfg.AddOnGPU.MNGName)
+	}
+	if cur.AMIType != eks.AMITypesAl2X8664Gpu {
+		t.Fatalf("unexpected AMIType %q", cur.AMIType)
+	}
+	if !reflect.DeepEqual(cur.InstanceTypes, []string{"g5.xlarge"}) {
+		t.Fatalf("unexpected InstanceTypes %q", cur.InstanceTypes)
+	}
+	if cur.ASGMinSize != 2 || cur.ASGMaxSize != 2 || cur.ASGDesiredCapacity != 2 {
+		t.Fatalf("unexpected ASG sizes %d, %d, %d", cur.ASGMinSize, cur.ASGMaxSize, cur.ASGDesiredCapacity)
+	}
+
+	cur.AMIType = eks.AMITypesAl2X8664
+	cfg.AddOnManagedNodeGroups.MNGs[cfg.AddOnGPU.MNGName] = cur
+	if err = cfg.ValidateAndSetDefaults(); err == nil || !strings.Contains(err.Error(), "non-GPU AMIType") {
+		t.Fatalf("expected non-GPU AMIType error, got %v", err)
+	}
+}
+
+func TestEnvAddOnMultiArch(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_MNGS", `{"test-mng-x86":{"name":"test-mng-x86","remote-access-user-name":"ec2-user","ami-type":"AL2_x86_64","asg-min-size":1,"asg-max-size":1,"asg-desired-capacity":1,"instance-types":["c5.xlarge"]}}`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_MNGS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MULTI_ARCH_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MULTI_ARCH_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MULTI_ARCH_COMPLETES", "3")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MULTI_ARCH_COMPLETES")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "need both") {
+		t.Fatalf("expected single architecture error, got %v", err)
+	}
+
+	cfg.AddOnManagedNodeGroups.MNGs["test-mng-arm"] = MNG{
+		Name:                 "test-mng-arm",
+		RemoteAccessUserName: "ec2-user",
+		AMIType:              eks.AMITypesAl2Arm64,
+		ASGMinSize:           1,
+		ASGMaxSize:           1,
+		ASGDesiredCapacity:   1,
+		InstanceTypes:        []string{"m6g.xlarge"},
+	}
+	err = cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.AddOnMultiArch.Namespace != cfg.Name+"-multi-arch" {
+		t.Fatalf("unexpected cfg.AddOnMultiArch.Namespace %q", cfg.AddOnMultiArch.Namespace)
+	}
+	if cfg.AddOnMultiArch.Image != DefaultMultiArchImage {
+		t.Fatalf("unexpected cfg.AddOnMultiArch.Image %q", cfg.AddOnMultiArch.Image)
+	}
+	if cfg.AddOnMultiArch.Completes != 3 {
+		t.Fatalf("unexpected cfg.AddOnMultiArch.Completes %d", cfg.AddOnMultiArch.Completes)
+	}
+	if !reflect.DeepEqual(cfg.AddOnMultiArch.Architectures, []string{"amd64", "arm64"}) {
+		t.Fatalf("unexpected cfg.AddOnMultiArch.Architectures %q", cfg.AddOnMultiArch.Architectures)
+	}
+}
+
+func TestEnvAddOnALB(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_ALB_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_ALB_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_ALB_DEPLOYMENT_REPLICAS", "3")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_ALB_DEPLOYMENT_REPLICAS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_ALB_PROVISION_TIMEOUT", "15m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_ALB_PROVISION_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.AddOnALB.Namespace != cfg.Name+"-alb" {
+		t.Fatalf("unexpected cfg.AddOnALB.Namespace %q", cfg.AddOnALB.Namespace)
+	}
+	if cfg.AddOnALB.ChartVersion != DefaultALBChartVersion {
+		t.Fatalf("unexpected cfg.AddOnALB.ChartVersion %q", cfg.AddOnALB.ChartVersion)
+	}
+	if cfg.AddOnALB.RoleName != cfg.Name+"-add-on-alb-controller-role" {
+		t.Fatalf("unexpected cfg.AddOnALB.RoleName %q", cfg.AddOnALB.RoleName)
+	}
+	if cfg.AddOnALB.DeploymentReplicas != 3 {
+		t.Fatalf("unexpected cfg.AddOnALB.DeploymentReplicas %d", cfg.AddOnALB.DeploymentReplicas)
+	}
+	if cfg.AddOnALB.ProvisionTimeout != 15*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnALB.ProvisionTimeout %v", cfg.AddOnALB.ProvisionTimeout)
+	}
+	if cfg.AddOnALB.ProvisionTimeoutString != "15m0s" {
+		t.Fatalf("unexpected cfg.AddOnALB.ProvisionTimeoutString %q", cfg.AddOnALB.ProvisionTimeoutString)
+	}
+
+	cfg.AddOnALB2048 = getDefaultAddOnALB2048()
+	cfg.AddOnALB2048.Enable = true
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "AddOnALB2048.Enable true") {
+		t.Fatalf("expected AddOnALB2048 conflict error, got %v", err)
+	}
+}
+
+func TestEnvAddOnCSIEFS(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EFS_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EFS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EFS_PERFORMANCE_MODE", "maxIO")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EFS_PERFORMANCE_MODE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EFS_DEPLOYMENT_REPLICAS", "3")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EFS_DEPLOYMENT_REPLICAS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.AddOnCSIEFS.Namespace != cfg.Name+"-csi-efs" {
+		t.Fatalf("unexpected cfg.AddOnCSIEFS.Namespace %q", cfg.AddOnCSIEFS.Namespace)
+	}
+	if cfg.AddOnCSIEFS.ChartVersion != DefaultCSIEFSChartVersion {
+		t.Fatalf("unexpected cfg.AddOnCSIEFS.ChartVersion %q", cfg.AddOnCSIEFS.ChartVersion)
+	}
+	if cfg.AddOnCSIEFS.RoleName != cfg.Name+"-add-on-csi-efs-controller-role" {
+		t.Fatalf("unexpected cfg.AddOnCSIEFS.RoleName %q", cfg.AddOnCSIEFS.RoleName)
+	}
+	if cfg.AddOnCSIEFS.PerformanceMode != "maxIO" {
+		t.Fatalf("unexpected cfg.AddOnCSIEFS.PerformanceMode %q", cfg.AddOnCSIEFS.PerformanceMode)
+	}
+	if cfg.AddOnCSIEFS.StorageClassName != cfg.Name+"-csi-efs" {
+		t.Fatalf("unexpected cfg.AddOnCSIEFS.StorageClassName %q", cfg.AddOnCSIEFS.StorageClassName)
+	}
+	if cfg.AddOnCSIEFS.DeploymentReplicas != 3 {
+		t.Fatalf("unexpected cfg.AddOnCSIEFS.DeploymentReplicas %d", cfg.AddOnCSIEFS.DeploymentReplicas)
+	}
+
+	cfg.AddOnCSIEFS.DeploymentReplicas = 1
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "expected at least 2") {
+		t.Fatalf("expected replicas error, got %v", err)
+	}
+}
+
+func TestEnvAddOnFSxLustre(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_FSX_LUSTRE_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_FSX_LUSTRE_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_FSX_LUSTRE_DEPLOYMENT_TYPE", "SCRATCH_1")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_FSX_LUSTRE_DEPLOYMENT_TYPE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_FSX_LUSTRE_BENCHMARK_SIZE_MIB", "2048")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_FSX_LUSTRE_BENCHMARK_SIZE_MIB")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_FSX_LUSTRE_S3_IMPORT_PREFIX", "/my-prefix/")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_FSX_LUSTRE_S3_IMPORT_PREFIX")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.AddOnFSxLustre.Namespace != cfg.Name+"-fsx-lustre" {
+		t.Fatalf("unexpected cfg.AddOnFSxLustre.Namespace %q", cfg.AddOnFSxLustre.Namespace)
+	}
+	if cfg.AddOnFSxLustre.ChartVersion != DefaultFSxLustreChartVersion {
+		t.Fatalf("unexpected cfg.AddOnFSxLustre.ChartVersion %q", cfg.AddOnFSxLustre.ChartVersion)
+	}
+	if cfg.AddOnFSxLustre.DeploymentType != "SCRATCH_1" {
+		t.Fatalf("unexpected cfg.AddOnFSxLustre.DeploymentType %q", cfg.AddOnFSxLustre.DeploymentType)
+	}
+	if cfg.AddOnFSxLustre.StorageCapacityGiB != 1200 {
+		t.Fatalf("unexpected cfg.AddOnFSxLustre.StorageCapacityGiB %d", cfg.AddOnFSxLustre.StorageCapacityGiB)
+	}
+	if cfg.AddOnFSxLustre.S3ImportPrefix != "my-prefix" {
+		t.Fatalf("unexpected cfg.AddOnFSxLustre.S3ImportPrefix %q", cfg.AddOnFSxLustre.S3ImportPrefix)
+	}
+	if cfg.AddOnFSxLustre.BenchmarkSizeMiB != 2048 {
+		t.Fatalf("unexpected cfg.AddOnFSxLustre.BenchmarkSizeMiB %d", cfg.AddOnFSxLustre.BenchmarkSizeMiB)
+	}
+
+	cfg.AddOnFSxLustre.StorageCapacityGiB = 1500
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "StorageCapacityGiB") {
+		t.Fatalf("expected storage capacity error, got %v", err)
+	}
+}
+
+func TestEnvAddOnOIDCIdentityProvider(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_OIDC_IDENTITY_PROVIDER_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_OIDC_IDENTITY_PROVIDER_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_OIDC_IDENTITY_PROVIDER_GROUP", "readers")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_OIDC_IDENTITY_PROVIDER_GROUP")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if !cfg.AddOnOIDCIdentityProvider.IsCognito() {
+		t.Fatal("expected Cognito issuer")
+	}
+	if cfg.AddOnOIDCIdentityProvider.ConfigName != cfg.Name+"-oidc" {
+		t.Fatalf("unexpected cfg.AddOnOIDCIdentityProvider.ConfigName %q", cfg.AddOnOIDCIdentityProvider.ConfigName)
+	}
+	if cfg.AddOnOIDCIdentityProvider.UsernameClaim != "sub" {
+		t.Fatalf("unexpected cfg.AddOnOIDCIdentityProvider.UsernameClaim %q", cfg.AddOnOIDCIdentityProvider.UsernameClaim)
+	}
+	if cfg.AddOnOIDCIdentityProvider.Group != "readers" {
+		t.Fatalf("unexpected cfg.AddOnOIDCIdentityProvider.Group %q", cfg.AddOnOIDCIdentityProvider.Group)
+	}
+
+	cfg.AddOnOIDCIdentityProvider.IssuerURL = "https://dex.example.com"
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "ClientID") {
+		t.Fatalf("expected client ID error, got %v", err)
+	}
+	cfg.AddOnOIDCIdentityProvider.ClientID = "kubernetes"
+	cfg.AddOnOIDCIdentityProvider.IDTokenPath = "/tmp/id-token"
+	cfg.AddOnOIDCIdentityProvider.GroupsPrefix = "system:"
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "GroupsPrefix") {
+		t.Fatalf("expected groups prefix error, got %v", err)
+	}
+}
+
+func TestEnvAddOnPodIdentity(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_POD_IDENTITY_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_POD_IDENTITY_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_POD_IDENTITY_SERVICE_ACCOUNT_NAME", "my-sa")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_POD_IDENTITY_SERVICE_ACCOUNT_NAME")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.AddOnPodIdentity.Namespace != cfg.Name+"-pod-identity" {
+		t.Fatalf("unexpected cfg.AddOnPodIdentity.Namespace %q", cfg.AddOnPodIdentity.Namespace)
+	}
+	if cfg.AddOnPodIdentity.RoleName != cfg.Name+"-pod-identity-role" {
+		t.Fatalf("unexpected cfg.AddOnPodIdentity.RoleName %q", cfg.AddOnPodIdentity.RoleName)
+	}
+	if cfg.AddOnPodIdentity.ServiceAccountName != "my-sa" {
+		t.Fatalf("unexpected cfg.AddOnPodIdentity.ServiceAccountName %q", cfg.AddOnPodIdentity.ServiceAccountName)
+	}
+	if cfg.AddOnPodIdentity.PodImage != DefaultPodIdentityPodImage {
+		t.Fatalf("unexpected cfg.AddOnPodIdentity.PodImage %q", cfg.AddOnPodIdentity.PodImage)
+	}
+}
+
+func TestEnvAddOnChaos(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CHAOS_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CHAOS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CHAOS_MODE", "fis-az-impairment")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CHAOS_MODE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CHAOS_INJECTIONS", "3")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CHAOS_INJECTIONS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CHAOS_IMPAIRMENT_DURATION", "10m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CHAOS_IMPAIRMENT_DURATION")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if !cfg.AddOnChaos.IsFIS() {
+		t.Fatalf("unexpected cfg.AddOnChaos.Mode %q", cfg.AddOnChaos.Mode)
+	}
+	if cfg.AddOnChaos.Injections != 3 {
+		t.Fatalf("unexpected cfg.AddOnChaos.Injections %d", cfg.AddOnChaos.Injections)
+	}
+	if cfg.AddOnChaos.ImpairmentDuration != 10*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnChaos.ImpairmentDuration %v", cfg.AddOnChaos.ImpairmentDuration)
+	}
+	if cfg.AddOnChaos.Namespace != cfg.Name+"-chaos" {
+		t.Fatalf("unexpected cfg.AddOnChaos.Namespace %q", cfg.AddOnChaos.Namespace)
+	}
+	if cfg.AddOnChaos.RoleName != cfg.Name+"-add-on-chaos-fis-role" {
+		t.Fatalf("unexpected cfg.AddOnChaos.RoleName %q", cfg.AddOnChaos.RoleName)
+	}
+	if cfg.AddOnChaos.CanaryReplicas != DefaultChaosCanaryReplicas {
+		t.Fatalf("unexpected cfg.AddOnChaos.CanaryReplicas %d", cfg.AddOnChaos.CanaryReplicas)
+	}
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CHAOS_MODE", "unknown")
+	if err = cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for unknown mode")
+	}
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CHAOS_MODE", "network-degradation")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CHAOS_NETEM_LATENCY", "200ms")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CHAOS_NETEM_LATENCY")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CHAOS_NETEM_LOSS_PERCENT", "2.5")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CHAOS_NETEM_LOSS_PERCENT")
+	if err = cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	if err = cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.AddOnChaos.IsNetem() {
+		t.Fatalf("unexpected cfg.AddOnChaos.Mode %q", cfg.AddOnChaos.Mode)
+	}
+	if cfg.AddOnChaos.NetemLatency != 200*time.Millisecond {
+		t.Fatalf("unexpected cfg.AddOnChaos.NetemLatency %v", cfg.AddOnChaos.NetemLatency)
+	}
+	if cfg.AddOnChaos.NetemLossPercent != 2.5 {
+		t.Fatalf("unexpected cfg.AddOnChaos.NetemLossPercent %v", cfg.AddOnChaos.NetemLossPercent)
+	}
+	if cfg.AddOnChaos.Transport != NodeTransportSSM {
+		t.Fatalf("unexpected cfg.AddOnChaos.Transport %q", cfg.AddOnChaos.Transport)
+	}
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CHAOS_NETEM_LOSS_PERCENT", "100")
+	if err = cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for 100% packet loss")
+	}
+}
+
+func TestEnvAddOnNodeFault(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_FAULT_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_FAULT_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_FAULT_NODES", "2")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_FAULT_NODES")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_FAULT_SERVICES", "containerd")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_FAULT_SERVICES")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_FAULT_READY_TIMEOUT", "2m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_FAULT_READY_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.AddOnNodeFault.Nodes != 2 {
+		t.Fatalf("unexpected cfg.AddOnNodeFault.Nodes %d", cfg.AddOnNodeFault.Nodes)
+	}
+	if !reflect.DeepEqual(cfg.AddOnNodeFault.Services, []string{"containerd"}) {
+		t.Fatalf("unexpected cfg.AddOnNodeFault.Services %q", cfg.AddOnNodeFault.Services)
+	}
+	if cfg.AddOnNodeFault.Transport != NodeFaultTransportSSM {
+		t.Fatalf("unexpected cfg.AddOnNodeFault.Transport %q", cfg.AddOnNodeFault.Transport)
+	}
+	if cfg.AddOnNodeFault.ReadyTimeoutString != "2m0s" {
+		t.Fatalf("unexpected cfg.AddOnNodeFault.ReadyTimeoutString %q", cfg.AddOnNodeFault.ReadyTimeoutString)
+	}
+	if cfg.AddOnNodeFault.Namespace != cfg.Name+"-node-fault" {
+		t.Fatalf("unexpected cfg.AddOnNodeFault.Namespace %q", cfg.AddOnNodeFault.Namespace)
+	}
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_FAULT_SERVICES", "kubelet;reboot")
+	if err = cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for invalid service name")
+	}
+}
+
+func TestEnvAddOnECR(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_JOBS_ECHO_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_JOBS_ECHO_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_ECR_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_ECR_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_ECR_IMAGE_TAG", "v1")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_ECR_IMAGE_TAG")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_ECR_IMAGE_TAG_MUTABILITY", "IMMUTABLE")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_ECR_IMAGE_TAG_MUTABILITY")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_ECR_REFERENCE_FROM_ADD_ONS", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_ECR_REFERENCE_FROM_ADD_ONS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.AddOnECR.RepositoryName != strings.ToLower(cfg.Name)+"-ecr" {
+		t.Fatalf("unexpected cfg.AddOnECR.RepositoryName %q", cfg.AddOnECR.RepositoryName)
+	}
+	if cfg.AddOnECR.ImageTagMutability != "IMMUTABLE" {
+		t.Fatalf("unexpected cfg.AddOnECR.ImageTagMutability %q", cfg.AddOnECR.ImageTagMutability)
+	}
+	if cfg.AddOnECR.SourceImage != DefaultECRSourceImage {
+		t.Fatalf("unexpected cfg.AddOnECR.SourceImage %q", cfg.AddOnECR.SourceImage)
+	}
+	if cfg.AddOnJobsEcho.RepositoryBusyboxName != cfg.AddOnECR.RepositoryName ||
+		cfg.AddOnJobsEcho.RepositoryBusyboxImageTag != "v1" ||
+		cfg.AddOnJobsEcho.RepositoryBusyboxRegion != cfg.Region {
+		t.Fatalf("unexpected cfg.AddOnJobsEcho busybox repository %+v", cfg.AddOnJobsEcho)
+	}
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_ECR_IMAGE_TAG_MUTABILITY", "unknown")
+	if err = cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for unknown image tag mutability")
+	}
+}
+
+func TestEnvAddOnCNIVersionMatrix(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CNI_VERSION_MATRIX_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CNI_VERSION_MATRIX_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CNI_VERSION_MATRIX_VERSIONS", "v1.15.1,v1.16.0-eksbuild.1")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CNI_VERSION_MATRIX_VERSIONS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CNI_VERSION_MATRIX_PODS_PER_NODE", "30")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CNI_VERSION_MATRIX_PODS_PER_NODE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CNI_VERSION_MATRIX_ROLLOUT_TIMEOUT", "15m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CNI_VERSION_MATRIX_ROLLOUT_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	cur := cfg.AddOnCNIVersionMatrix
+	if !reflect.DeepEqual(cur.Versions, []string{"v1.15.1", "v1.16.0-eksbuild.1"}) {
+		t.Fatalf("unexpected cfg.AddOnCNIVersionMatrix.Versions %v", cur.Versions)
+	}
+	if cur.PodsPerNode != 30 {
+		t.Fatalf("unexpected cfg.AddOnCNIVersionMatrix.PodsPerNode %d", cur.PodsPerNode)
+	}
+	if cur.RolloutTimeout != 15*time.Minute || cur.CheckTimeout != DefaultCNIVersionMatrixCheckTimeout {
+		t.Fatalf("unexpected cfg.AddOnCNIVersionMatrix timeouts %v, %v", cur.RolloutTimeout, cur.CheckTimeout)
+	}
+	if cur.Namespace != cfg.Name+"-cni-version-matrix" {
+		t.Fatalf("unexpected cfg.AddOnCNIVersionMatrix.Namespace %q", cur.Namespace)
+	}
+	if cur.ResultJUnitXMLS3Key != path.Join(cfg.Name, "add-on-cni-version-matrix", filepath.Base(cur.ResultJUnitXMLPath)) {
+		t.Fatalf("unexpected cfg.AddOnCNIVersionMatrix.ResultJUnitXMLS3Key %q", cur.ResultJUnitXMLS3Key)
+	}
+
+	cur.Versions = []string{"1.15.1"}
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for version without 'v' prefix")
+	}
+}
+
+func TestEnvAddOnPodDensity(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_POD_DENSITY_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_POD_DENSITY_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_POD_DENSITY_NODES_PER_INSTANCE_TYPE", "2")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_POD_DENSITY_NODES_PER_INSTANCE_TYPE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_POD_DENSITY_CHURN_ROUNDS", "0")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_POD_DENSITY_CHURN_ROUNDS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_POD_DENSITY_TIMEOUT", "20m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_POD_DENSITY_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	cur := cfg.AddOnPodDensity
+	if cur.NodesPerInstanceType != 2 {
+		t.Fatalf("unexpected cfg.AddOnPodDensity.NodesPerInstanceType %d", cur.NodesPerInstanceType)
+	}
+	if cur.ChurnRounds != 0 {
+		t.Fatalf("unexpected cfg.AddOnPodDensity.ChurnRounds %d", cur.ChurnRounds)
+	}
+	if cur.Timeout != 20*time.Minute || cur.TimeoutString != "20m0s" {
+		t.Fatalf("unexpected cfg.AddOnPodDensity.Timeout %v", cur.Timeout)
+	}
+	if cur.Namespace != cfg.Name+"-pod-density" {
+		t.Fatalf("unexpected cfg.AddOnPodDensity.Namespace %q", cur.Namespace)
+	}
+
+	cur.ChurnRounds = -1
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for negative churn rounds")
+	}
+}
+
+func TestEnvAddOnKubeProxyModes(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBE_PROXY_MODES_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBE_PROXY_MODES_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBE_PROXY_MODES_MODES", "ipvs,iptables")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBE_PROXY_MODES_MODES")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBE_PROXY_MODES_IPVS_SCHEDULER", "lc")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBE_PROXY_MODES_IPVS_SCHEDULER")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBE_PROXY_MODES_REQUESTS", "1000")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBE_PROXY_MODES_REQUESTS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBE_PROXY_MODES_MAX_DEVIATION", "0.3")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBE_PROXY_MODES_MAX_DEVIATION")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	cur := cfg.AddOnKubeProxyModes
+	if !reflect.DeepEqual(cur.Modes, []string{"ipvs", "iptables"}) {
+		t.Fatalf("unexpected cfg.AddOnKubeProxyModes.Modes %v", cur.Modes)
+	}
+	if cur.IPVSScheduler != "lc" {
+		t.Fatalf("unexpected cfg.AddOnKubeProxyModes.IPVSScheduler %q", cur.IPVSScheduler)
+	}
+	if cur.Requests != 1000 || cur.Backends != DefaultKubeProxyModesBackends {
+		t.Fatalf("unexpected cfg.AddOnKubeProxyModes requests %d, backends %d", cur.Requests, cur.Backends)
+	}
+	if cur.MaxDeviation != 0.3 {
+		t.Fatalf("unexpected cfg.AddOnKubeProxyModes.MaxDeviation %v", cur.MaxDeviation)
+	}
+	if cur.Namespace != cfg.Name+"-kube-proxy-modes" {
+		t.Fatalf("unexpected cfg.AddOnKubeProxyModes.Namespace %q", cur.Namespace)
+	}
+
+	cur.Modes = []string{"userspace"}
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for unknown mode")
+	}
+}
+
+func TestEnvAddOnCoreDNSScale(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_COREDNS_SCALE_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_COREDNS_SCALE_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_COREDNS_SCALE_CLIENTS", "10")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_COREDNS_SCALE_CLIENTS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_COREDNS_SCALE_QPS_PER_CLIENT", "200")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_COREDNS_SCALE_QPS_PER_CLIENT")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_COREDNS_SCALE_DURATION", "5m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_COREDNS_SCALE_DURATION")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_COREDNS_SCALE_QUERY_NAMES", "kubernetes.default.svc.cluster.local,amazon.com")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_COREDNS_SCALE_QUERY_NAMES")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_COREDNS_SCALE_AUTOSCALE_MAX_REPLICAS", "6")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_COREDNS_SCALE_AUTOSCALE_MAX_REPLICAS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	cur := cfg.AddOnCoreDNSScale
+	if cur.Clients != 10 || cur.QPSPerClient != 200 {
+		t.Fatalf("unexpected cfg.AddOnCoreDNSScale clients %d, qps %d", cur.Clients, cur.QPSPerClient)
+	}
+	if cur.Duration != 5*time.Minute || cur.DurationString != "5m0s" {
+		t.Fatalf("unexpected cfg.AddOnCoreDNSScale.Duration %v", cur.Duration)
+	}
+	if !reflect.DeepEqual(cur.QueryNames, []string{"kubernetes.default.svc.cluster.local", "amazon.com"}) {
+		t.Fatalf("unexpected cfg.AddOnCoreDNSScale.QueryNames %v", cur.QueryNames)
+	}
+	if cur.AutoscaleMaxReplicas != 6 || cur.AutoscaleTargetCPUUtilizationPercentage != DefaultCoreDNSScaleAutoscaleTargetCPUUtilizationPercentage {
+		t.Fatalf("unexpected cfg.AddOnCoreDNSScale autoscale %d, %d", cur.AutoscaleMaxReplicas, cur.AutoscaleTargetCPUUtilizationPercentage)
+	}
+	if !cfg.IsEnabledAddOnMetricsServer() {
+		t.Fatal("expected AddOnMetricsServer enabled for the CoreDNS autoscale test")
+	}
+	if cur.RequestsSummaryCompareS3Dir != path.Join("add-on-coredns-scale", "requests-summary-compare", cfg.Version) {
+		t.Fatalf("unexpected cfg.AddOnCoreDNSScale.RequestsSummaryCompareS3Dir %q", cur.RequestsSummaryCompareS3Dir)
+	}
+
+	cur.QueryNames = []string{"a.com; rm -rf /"}
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for invalid query name")
+	}
+}
+
+func TestEnvAddOnServiceChurn(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_SERVICE_CHURN_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_SERVICE_CHURN_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_SERVICE_CHURN_NODES", "2")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_SERVICE_CHURN_NODES")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_SERVICE_CHURN_ROUNDS", "5")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_SERVICE_CHURN_ROUNDS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_SERVICE_CHURN_SERVICES", "20")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_SERVICE_CHURN_SERVICES")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_SERVICE_CHURN_PROBE_START_DELAY", "45s")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_SERVICE_CHURN_PROBE_START_DELAY")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	cur := cfg.AddOnServiceChurn
+	if cur.Nodes != 2 || cur.Rounds != 5 || cur.Services != 20 {
+		t.Fatalf("unexpected cfg.AddOnServiceChurn nodes %d, rounds %d, services %d", cur.Nodes, cur.Rounds, cur.Services)
+	}
+	if cur.BackendsPerService != DefaultServiceChurnBackendsPerService {
+		t.Fatalf("unexpected cfg.AddOnServiceChurn.BackendsPerService %d", cur.BackendsPerService)
+	}
+	if cur.ProbeStartDelayString != "45s" || cur.ConvergenceTimeout != DefaultServiceChurnConvergenceTimeout {
+		t.Fatalf("unexpected cfg.AddOnServiceChurn probe start delay %q, convergence timeout %v", cur.ProbeStartDelayString, cur.ConvergenceTimeout)
+	}
+	if cur.Transport != NodeTransportSSM {
+		t.Fatalf("unexpected cfg.AddOnServiceChurn.Transport %q", cur.Transport)
+	}
+
+	cur.Services = 1000
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for too many Services")
+	}
+}
+
+func TestEnvAddOnCustomManifests(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	fpath := filepath.Join(t.TempDir(), "app.yaml")
+	if err := ioutil.WriteFile(fpath, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CUSTOM_MANIFESTS_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CUSTOM_MANIFESTS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CUSTOM_MANIFESTS_SOURCES", fpath+",https://example.com/app.yaml,s3://bucket/manifests/app.yaml")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CUSTOM_MANIFESTS_SOURCES")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CUSTOM_MANIFESTS_WAIT_JOB_COMPLETE", "false")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CUSTOM_MANIFESTS_WAIT_JOB_COMPLETE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CUSTOM_MANIFESTS_READINESS_TIMEOUT", "3m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CUSTOM_MANIFESTS_READINESS_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if len(cfg.AddOnCustomManifests.Sources) != 3 {
+		t.Fatalf("unexpected cfg.AddOnCustomManifests.Sources %v", cfg.AddOnCustomManifests.Sources)
+	}
+	if cfg.AddOnCustomManifests.Namespace != cfg.Name+"-custom-manifests" {
+		t.Fatalf("unexpected cfg.AddOnCustomManifests.Namespace %q", cfg.AddOnCustomManifests.Namespace)
+	}
+	if cfg.AddOnCustomManifests.FieldManager != DefaultCustomManifestsFieldManager {
+		t.Fatalf("unexpected cfg.AddOnCustomManifests.FieldManager %q", cfg.AddOnCustomManifests.FieldManager)
+	}
+	if !cfg.AddOnCustomManifests.WaitRollout || cfg.AddOnCustomManifests.WaitJobComplete {
+		t.Fatalf("unexpected cfg.AddOnCustomManifests waits %+v", cfg.AddOnCustomManifests)
+	}
+	if cfg.AddOnCustomManifests.ReadinessTimeout != 3*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnCustomManifests.ReadinessTimeout %v", cfg.AddOnCustomManifests.ReadinessTimeout)
+	}
+
+	for _, src := range []string{"s3://bucket", "s3:///key", filepath.Join(t.TempDir(), "missing.yaml")} {
+		cfg.AddOnCustomManifests.Sources = []string{src}
+		if err = cfg.ValidateAndSetDefaults(); err == nil {
+			t.Fatalf("expected error for cfg.AddOnCustomManifests.Sources %q", src)
+		}
+	}
+}
+
+func TestEnvAddOnManagedAddOns(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_ADD_ONS_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_ADD_ONS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_ADD_ONS_ADD_ONS", `{"coredns":{"version":"v1.9.3-eksbuild.3","update-version":"latest","configuration-values":"{\"replicaCount\":3}","preserve":true,"installed-version":"v1"}}`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_ADD_ONS_ADD_ONS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	expected := map[string]ManagedAddOn{
+		"coredns": {
+			Version:             "v1.9.3-eksbuild.3",
+			UpdateVersion:       ManagedAddOnLatestVersion,
+			ConfigurationValues: `{"replicaCount":3}`,
+			Preserve:            true,
+		},
+	}
+	if !reflect.DeepEqual(cfg.AddOnManagedAddOns.AddOns, expected) {
+		t.Fatalf("unexpected cfg.AddOnManagedAddOns.AddOns %+v", cfg.AddOnManagedAddOns.AddOns)
+	}
+
+	cfg.AddOnManagedAddOns.AddOns["coredns"] = ManagedAddOn{ConfigurationValues: "replicaCount: 3"}
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "not valid JSON") {
+		t.Fatalf("expected configuration values error, got %v", err)
+	}
+}
+
+func TestEnvAddOnCustomNetworking(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CUSTOM_NETWORKING_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CUSTOM_NETWORKING_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CUSTOM_NETWORKING_SECONDARY_CIDR", "100.65.0.0/16")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CUSTOM_NETWORKING_SECONDARY_CIDR")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CUSTOM_NETWORKING_SUBNET_CIDRS", "100.65.0.0/18,100.65.64.0/18,100.65.128.0/18")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CUSTOM_NETWORKING_SUBNET_CIDRS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CUSTOM_NETWORKING_NODE_CYCLE_TIMEOUT", "45m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CUSTOM_NETWORKING_NODE_CYCLE_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.AddOnCustomNetworking.Namespace != cfg.Name+"-custom-networking" {
+		t.Fatalf("unexpected cfg.AddOnCustomNetworking.Namespace %q", cfg.AddOnCustomNetworking.Namespace)
+	}
+	if cfg.AddOnCustomNetworking.SecondaryCIDR != "100.65.0.0/16" {
+		t.Fatalf("unexpected cfg.AddOnCustomNetworking.SecondaryCIDR %q", cfg.AddOnCustomNetworking.SecondaryCIDR)
+	}
+	if !reflect.DeepEqual(cfg.AddOnCustomNetworking.SubnetCIDRs, []string{"100.65.0.0/18", "100.65.64.0/18", "100.65.128.0/18"}) {
+		t.Fatalf("unexpected cfg.AddOnCustomNetworking.SubnetCIDRs %v", cfg.AddOnCustomNetworking.SubnetCIDRs)
+	}
+	if cfg.AddOnCustomNetworking.NodeCycleTimeout != 45*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnCustomNetworking.NodeCycleTimeout %v", cfg.AddOnCustomNetworking.NodeCycleTimeout)
+	}
+	if cfg.AddOnCustomNetworking.DeploymentReplicas != 6 {
+		t.Fatalf("unexpected cfg.AddOnCustomNetworking.DeploymentReplicas %d", cfg.AddOnCustomNetworking.DeploymentReplicas)
+	}
+
+	cfg.AddOnCustomNetworking.SecondaryCIDR = "10.0.0.0/8"
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "overlaps") {
+		t.Fatalf("expected overlap error, got %v", err)
+	}
+	cfg.AddOnCustomNetworking.SecondaryCIDR = "100.65.0.0/16"
+	cfg.AddOnCustomNetworking.SubnetCIDRs = []string{"100.65.0.0/18", "100.65.64.0/18", "100.66.0.0/18"}
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "not within SecondaryCIDR") {
+		t.Fatalf("expected subnet CIDR error, got %v", err)
+	}
+}
+
+func TestEnvIPFamily(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	if cfg.IPFamily != IPFamilyIPv4 {
+		t.Fatalf("unexpected default cfg.IPFamily %q", cfg.IPFamily)
+	}
+
+	os.Setenv("AWS_K8S_TESTER_EKS_IP_FAMILY", "ipv6")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_IP_FAMILY")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.IPFamily != IPFamilyIPv6 {
+		t.Fatalf("unexpected cfg.IPFamily %q", cfg.IPFamily)
+	}
+
+	cfg.Version = "1.20"
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "requires Version") {
+		t.Fatalf("expected IPFamily version error, got %v", err)
+	}
+	cfg.Version = "1.27"
+
+	cfg.IPFamily = "dual"
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "unknown IPFamily") {
+		t.Fatalf("expected unknown IPFamily error, got %v", err)
+	}
+
+	// custom networking assigns IPv4 addresses only
+	cfg2 := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg2.ConfigPath)
+		os.RemoveAll(cfg2.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg2.RemoteAccessCommandsOutputPath)
+	}()
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CUSTOM_NETWORKING_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CUSTOM_NETWORKING_ENABLE")
+	if err = cfg2.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err = cfg2.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "IPFamily") {
+		t.Fatalf("expected custom networking IPFamily error, got %v", err)
+	}
+}
+
+func TestEnvEndpoint(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	if !cfg.Endpoint.PublicAccess || cfg.Endpoint.PrivateAccess || cfg.Endpoint.IsPrivateOnly() {
+		t.Fatalf("unexpected default cfg.Endpoint %+v", cfg.Endpoint)
+	}
+
+	os.Setenv("AWS_K8S_TESTER_EKS_AWS_CLI_PATH", "/usr/local/bin/aws")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_AWS_CLI_PATH")
+	os.Setenv("AWS_K8S_TESTER_EKS_ENDPOINT_PUBLIC_ACCESS", "false")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ENDPOINT_PUBLIC_ACCESS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ENDPOINT_PRIVATE_ACCESS", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ENDPOINT_PRIVATE_ACCESS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ENDPOINT_TUNNEL_INSTANCE_TYPE", "t3.small")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ENDPOINT_TUNNEL_INSTANCE_TYPE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ENDPOINT_TUNNEL_LOCAL_PORT", "9443")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ENDPOINT_TUNNEL_LOCAL_PORT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if !cfg.Endpoint.IsPrivateOnly() {
+		t.Fatalf("expected private-only cfg.Endpoint %+v", cfg.Endpoint)
+	}
+	if cfg.Endpoint.TunnelInstanceType != "t3.small" {
+		t.Fatalf("unexpected cfg.Endpoint.TunnelInstanceType %q", cfg.Endpoint.TunnelInstanceType)
+	}
+	if cfg.Endpoint.TunnelLocalPort != 9443 {
+		t.Fatalf("unexpected cfg.Endpoint.TunnelLocalPort %d", cfg.Endpoint.TunnelLocalPort)
+	}
+	if cfg.Endpoint.TunnelRoleName != cfg.Name+"-endpoint-tunnel-role" {
+		t.Fatalf("unexpected cfg.Endpoint.TunnelRoleName %q", cfg.Endpoint.TunnelRoleName)
+	}
+	if cfg.Endpoint.TunnelInstanceProfileName != cfg.Name+"-endpoint-tunnel-instance-profile" {
+		t.Fatalf("unexpected cfg.Endpoint.TunnelInstanceProfileName %q", cfg.Endpoint.TunnelInstanceProfileName)
+	}
+
+	cfg.Endpoint.PrivateAccess = false
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "cannot be both false") {
+		t.Fatalf("expected endpoint access error, got %v", err)
+	}
+}
+
+func TestEnvVPCExisting(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_VPC_CREATE", "false")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_VPC_CREATE")
+	os.Setenv("AWS_K8S_TESTER_EKS_VPC_ID", "vpc-id")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_VPC_ID")
+	os.Setenv("AWS_K8S_TESTER_EKS_VPC_SECURITY_GROUP_ID", "sg-id")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_VPC_SECURITY_GROUP_ID")
+	os.Setenv("AWS_K8S_TESTER_EKS_VPC_PUBLIC_SUBNET_IDS", "subnet-public1,subnet-public2")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_VPC_PUBLIC_SUBNET_IDS")
+	os.Setenv("AWS_K8S_TESTER_EKS_VPC_PRIVATE_SUBNET_IDS", "subnet-private1")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_VPC_PRIVATE_SUBNET_IDS")
+	os.Setenv("AWS_K8S_TESTER_EKS_VPC_SUBNET_MIN_AVAILABLE_IPS", "32")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_VPC_SUBNET_MIN_AVAILABLE_IPS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.VPC.Create {
+		t.Fatalf("unexpected cfg.VPC.Create %v", cfg.VPC.Create)
+	}
+	if cfg.VPC.SecurityGroupID != "sg-id" {
+		t.Fatalf("unexpected cfg.VPC.SecurityGroupID %q", cfg.VPC.SecurityGroupID)
+	}
+	if !reflect.DeepEqual(cfg.VPC.PublicSubnetIDs, []string{"subnet-public1", "subnet-public2"}) {
+		t.Fatalf("unexpected cfg.VPC.PublicSubnetIDs %q", cfg.VPC.PublicSubnetIDs)
+	}
+	if !reflect.DeepEqual(cfg.VPC.PrivateSubnetIDs, []string{"subnet-private1"}) {
+		t.Fatalf("unexpected cfg.VPC.PrivateSubnetIDs %q", cfg.VPC.PrivateSubnetIDs)
+	}
+	if cfg.VPC.SubnetMinAvailableIPs != 32 {
+		t.Fatalf("unexpected cfg.VPC.SubnetMinAvailableIPs %d", cfg.VPC.SubnetMinAvailableIPs)
+	}
+
+	cfg.VPC.SubnetMinAvailableIPs = 3
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "must be at least 6") {
+		t.Fatalf("expected SubnetMinAvailableIPs error, got %v", err)
+	}
+	cfg.VPC.SubnetMinAvailableIPs = 16
+
+	cfg.VPC.PublicSubnetIDs = nil
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "requires non-empty VPC.PublicSubnetIDs") {
+		t.Fatalf("expected PublicSubnetIDs error, got %v", err)
+	}
+
+	cfg.VPC.Create = true
+	cfg.VPC.ID = ""
+	cfg.VPC.PublicSubnetIDs = []string{"subnet-public1"}
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "only supported with VPC.Create false") {
+		t.Fatalf("expected VPC.Create error, got %v", err)
+	}
+}
+
+func TestEnvAddOnNodeGroupsPlacement(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_VPC_CREATE", "false")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_VPC_CREATE")
+	os.Setenv("AWS_K8S_TESTER_EKS_VPC_ID", "vpc-id")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_VPC_ID")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ASGS", `{"ng-lz":{"name":"ng-lz","remote-access-user-name":"ec2-user","ami-type":"AL2_x86_64","asg-min-size":1,"asg-max-size":1,"asg-desired-capacity":1,"instance-type":"c5.2xlarge","image-id":"my-ami","placement":{"type":"local-zone","subnet-ids":["subnet-lz"]}}}`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ASGS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.ValidateAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	cur := cfg.AddOnNodeGroups.ASGs["ng-lz"]
+	if cur.VolumeType != aws_ec2_v2_types.VolumeTypeGp2 {
+		t.Fatalf("unexpected VolumeType %q", cur.VolumeType)
+	}
+	if !reflect.DeepEqual(cur.SubnetIDs(cfg.VPC), []string{"subnet-lz"}) {
+		t.Fatalf("unexpected SubnetIDs %v", cur.SubnetIDs(cfg.VPC))
+	}
+
+	tt := []struct {
+		update func(cur *ASG)
+		err    string
+	}{
+		{func(cur *ASG) { cur.Placement.Type = "edge" }, `unknown Placement.Type "edge"`},
+		{func(cur *ASG) { cur.VolumeType = aws_ec2_v2_types.VolumeTypeGp3 }, `VolumeType "gp3" not supported with Placement.Type "local-zone"`},
+		{func(cur *ASG) { cur.Placement.Type = PlacementTypeOutpost }, `requires Placement.OutpostARN`},
+		{func(cur *ASG) { cur.Placement.OutpostARN = "arn:aws:outposts:us-west-2:123:outpost/op-1" }, `only supported with Placement.Type "outpost"`},
+		{func(cur *ASG) { cur.Placement.Type = PlacementTypeWavelengthZone }, `InstanceType "c5.2xlarge" not available with Placement.Type "wavelength-zone"`},
+		{func(cur *ASG) {
+			cur.Placement.Type = PlacementTypeOutpost
+			cur.Placement.OutpostARN = "arn:aws:outposts:us-west-2:123:outpost/op-1"
+			cur.CapacityType = CapacityTypeSpot
+		}, `CapacityType "SPOT" not supported with Placement.Type "outpost"`},
+	}
+	for i, tv := range tt {
+		c := cur
+		p := *cur.Placement
+		c.Placement = &p
+		tv.update(&c)
+		cfg.AddOnNodeGroups.ASGs = map[string]ASG{"ng-lz": c}
+		err := cfg.ValidateAndSetDefaults()
+		if err == nil || !strings.Contains(err.Error(), tv.err) {
+			t.Fatalf("#%d: expected %q, got %v", i, tv.err, err)
+		}
+	}
+
+	cur.Placement = &NGPlacement{Type: PlacementTypeLocalZone, SubnetIDs: []string{"subnet-lz"}}
+	cfg.AddOnNodeGroups.ASGs = map[string]ASG{"ng-lz": cur}
+	cfg.VPC.Create = true
+	cfg.VPC.ID = ""
+	err := cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "Placement requires VPC.Create false") {
+		t.Fatalf("expected VPC.Create error, got %v", err)
+	}
+}
+
+func TestEnvVPCEndpoints(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	if !reflect.DeepEqual(cfg.VPC.EndpointServices, []string{"ecr.api", "ecr.dkr", "s3", "ec2", "sts", "logs"}) {
+		t.Fatalf("unexpected default cfg.VPC.EndpointServices %q", cfg.VPC.EndpointServices)
+	}
+
+	os.Setenv("AWS_K8S_TESTER_EKS_AWS_CLI_PATH", "/usr/local/bin/aws")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_AWS_CLI_PATH")
+	os.Setenv("AWS_K8S_TESTER_EKS_VPC_CREATE_ENDPOINTS", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_VPC_CREATE_ENDPOINTS")
+	os.Setenv("AWS_K8S_TESTER_EKS_VPC_DISABLE_NAT_GATEWAYS", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_VPC_DISABLE_NAT_GATEWAYS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ENDPOINT_PUBLIC_ACCESS", "false")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ENDPOINT_PUBLIC_ACCESS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ENDPOINT_PRIVATE_ACCESS", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ENDPOINT_PRIVATE_ACCESS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if !cfg.VPC.CreateEndpoints || !cfg.VPC.DisableNATGateways {
+		t.Fatalf("unexpected cfg.VPC %+v", cfg.VPC)
+	}
+	// private-only endpoint tunnel registers with SSM through the endpoints
+	if !reflect.DeepEqual(cfg.VPC.EndpointServices, []string{"ecr.api", "ecr.dkr", "s3", "ec2", "sts", "logs", "ssm", "ssmmessages", "ec2messages"}) {
+		t.Fatalf("unexpected cfg.VPC.EndpointServices %q", cfg.VPC.EndpointServices)
+	}
+	cfg.VPC.PrivateSubnetIDs = []string{"subnet-private1"}
+	if !reflect.DeepEqual(cfg.VPC.NodeSubnetIDs(), []string{"subnet-private1"}) {
+		t.Fatalf("unexpected cfg.VPC.NodeSubnetIDs() %q", cfg.VPC.NodeSubnetIDs())
+	}
+	cfg.VPC.PrivateSubnetIDs = nil
+
+	cfg.Endpoint.PublicAccess, cfg.Endpoint.PrivateAccess = true, false
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "requires Endpoint.PrivateAccess true") {
+		t.Fatalf("expected Endpoint.PrivateAccess error, got %v", err)
+	}
+	cfg.Endpoint.PrivateAccess = true
+
+	cfg.VPC.CreateEndpoints = false
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "requires VPC.CreateEndpoints true") {
+		t.Fatalf("expected VPC.CreateEndpoints error, got %v", err)
+	}
+}
+
+func TestEnvEncryption(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	if !cfg.Encryption.CMKCreate || cfg.Encryption.CMKPendingWindowInDays != 7 {
+		t.Fatalf("unexpected default cfg.Encryption %+v", cfg.Encryption)
+	}
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ENCRYPTION_CMK_PENDING_WINDOW_IN_DAYS", "30")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ENCRYPTION_CMK_PENDING_WINDOW_IN_DAYS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.Encryption.CMKPendingWindowInDays != 30 {
+		t.Fatalf("unexpected cfg.Encryption.CMKPendingWindowInDays %d", cfg.Encryption.CMKPendingWindowInDays)
+	}
+
+	cfg.Encryption.CMKPendingWindowInDays = 31
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for invalid cfg.Encryption.CMKPendingWindowInDays")
+	}
+}
+
+func TestEnvControlPlaneLogging(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	if cfg.ControlPlaneLogging.IsEnabled() || !cfg.ControlPlaneLogging.FetchLogs {
+		t.Fatalf("unexpected default cfg.ControlPlaneLogging %+v", cfg.ControlPlaneLogging)
+	}
+
+	os.Setenv("AWS_K8S_TESTER_EKS_CONTROL_PLANE_LOGGING_TYPES", "api,audit,authenticator")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_CONTROL_PLANE_LOGGING_TYPES")
+	os.Setenv("AWS_K8S_TESTER_EKS_CONTROL_PLANE_LOGGING_FETCH_LOGS", "false")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_CONTROL_PLANE_LOGGING_FETCH_LOGS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if !reflect.DeepEqual(cfg.ControlPlaneLogging.Types, []string{"api", "audit", "authenticator"}) {
+		t.Fatalf("unexpected cfg.ControlPlaneLogging.Types %v", cfg.ControlPlaneLogging.Types)
+	}
+	if cfg.ControlPlaneLogging.FetchLogs {
+		t.Fatalf("unexpected cfg.ControlPlaneLogging.FetchLogs %v", cfg.ControlPlaneLogging.FetchLogs)
+	}
+	if cfg.ControlPlaneLogging.LogGroupName != "/aws/eks/"+cfg.Name+"/cluster" {
+		t.Fatalf("unexpected cfg.ControlPlaneLogging.LogGroupName %q", cfg.ControlPlaneLogging.LogGroupName)
+	}
+
+	cfg.ControlPlaneLogging.Types = []string{"kubelet"}
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for unknown cfg.ControlPlaneLogging.Types")
+	}
+}
+
+func TestEnvNotifications(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	if cfg.Notifications.IsEnabled() {
+		t.Fatalf("unexpected default cfg.Notifications %+v", cfg.Notifications)
+	}
+
+	os.Setenv("AWS_K8S_TESTER_EKS_NOTIFICATIONS_SLACK_WEBHOOK_URL", "https://hooks.slack.com/services/T0/B0/X")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_NOTIFICATIONS_SLACK_WEBHOOK_URL")
+	os.Setenv("AWS_K8S_TESTER_EKS_NOTIFICATIONS_SNS_TOPIC_ARN", "arn:aws:sns:us-west-2:123456789012:test")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_NOTIFICATIONS_SNS_TOPIC_ARN")
+	os.Setenv("AWS_K8S_TESTER_EKS_NOTIFICATIONS_EVENTS", "cluster-ready,teardown-complete")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_NOTIFICATIONS_EVENTS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.Notifications.SlackWebhookURL != "https://hooks.slack.com/services/T0/B0/X" {
+		t.Fatalf("unexpected cfg.Notifications.SlackWebhookURL %q", cfg.Notifications.SlackWebhookURL)
+	}
+	if cfg.Notifications.SNSTopicARN != "arn:aws:sns:us-west-2:123456789012:test" {
+		t.Fatalf("unexpected cfg.Notifications.SNSTopicARN %q", cfg.Notifications.SNSTopicARN)
+	}
+	if !cfg.Notifications.IsEventEnabled(NotificationEventClusterReady) || cfg.Notifications.IsEventEnabled(NotificationEventAddOnFailed) {
+		t.Fatalf("unexpected cfg.Notifications.Events %v", cfg.Notifications.Events)
+	}
+	if cfg.Notifications.MessageTemplate != DefaultNotificationMessageTemplate {
+		t.Fatalf("unexpected cfg.Notifications.MessageTemplate %q", cfg.Notifications.MessageTemplate)
+	}
+
+	cfg.Notifications.Events = []string{"cluster-deleted"}
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for unknown cfg.Notifications.Events")
+	}
+	cfg.Notifications.Events = nil
+	cfg.Notifications.MessageTemplate = "{{.Cluster"
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for invalid cfg.Notifications.MessageTemplate")
+	}
+}
+
+func TestEnvAccess(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	if cfg.Access.Mode != AccessModeAuto {
+		t.Fatalf("unexpected default cfg.Access.Mode %q", cfg.Access.Mode)
+	}
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ACCESS_MODE", "access-entry")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ACCESS_MODE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ACCESS_ENTRIES", `{"ci-debug":{"principal-arn":"arn:aws:iam::123456789012:role/ci-debug","access-policy-arns":["arn:aws:eks::aws:cluster-access-policy/AmazonEKSAdminViewPolicy"],"verify":true,"verified":true}}`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ACCESS_ENTRIES")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.Access.Mode != AccessModeAccessEntry {
+		t.Fatalf("unexpected cfg.Access.Mode %q", cfg.Access.Mode)
+	}
+	e, ok := cfg.Access.Entries["ci-debug"]
+	if !ok || e.PrincipalARN != "arn:aws:iam::123456789012:role/ci-debug" || !e.Verify || !e.IsRole() {
+		t.Fatalf("unexpected cfg.Access.Entries %+v", cfg.Access.Entries)
+	}
+	if e.Verified {
+		t.Fatal("read-only cfg.Access.Entries.Verified must not be set from env")
+	}
+
+	e.Groups = []string{"system:masters"}
+	cfg.Access.Entries["ci-debug"] = e
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for system: group in access entry")
+	}
+	cfg.Access.Entries["ci-debug"] = AccessEntry{PrincipalARN: "arn:aws:iam::123456789012:user/ci", Groups: []string{"ci"}, Verify: true}
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for Verify on IAM user")
+	}
+	cfg.Access.Mode = "rbac"
+	cfg.Access.Entries = nil
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for unknown cfg.Access.Mode")
+	}
+}
+
+func TestEnvCNIConfig(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	if cfg.CNIConfig.IsSet() {
+		t.Fatalf("unexpected default cfg.CNIConfig %+v", cfg.CNIConfig)
+	}
+
+	os.Setenv("AWS_K8S_TESTER_EKS_CNI_CONFIG_ENABLE_PREFIX_DELEGATION", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_CNI_CONFIG_ENABLE_PREFIX_DELEGATION")
+	os.Setenv("AWS_K8S_TESTER_EKS_CNI_CONFIG_WARM_PREFIX_TARGET", "1")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_CNI_CONFIG_WARM_PREFIX_TARGET")
+	os.Setenv("AWS_K8S_TESTER_EKS_CNI_CONFIG_MINIMUM_IP_TARGET", "30")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_CNI_CONFIG_MINIMUM_IP_TARGET")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if !cfg.CNIConfig.IsSet() {
+		t.Fatalf("expected cfg.CNIConfig set %+v", cfg.CNIConfig)
+	}
+	expected := map[string]string{
+		"ENABLE_PREFIX_DELEGATION": "true",
+		"WARM_PREFIX_TARGET":       "1",
+		"MINIMUM_IP_TARGET":        "30",
+	}
+	if envs := cfg.CNIConfig.Envs(); !reflect.DeepEqual(envs, expected) {
+		t.Fatalf("expected %v, got %v", expected, envs)
+	}
+
+	cfg.CNIConfig.EnablePrefixDelegation = false
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for WarmPrefixTarget without prefix delegation")
+	}
+}
+
+func TestEnvHooks(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_HOOKS_PRE_CREATE", `[{"command":"echo hello"}]`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_HOOKS_PRE_CREATE")
+	os.Setenv("AWS_K8S_TESTER_EKS_HOOKS_POST_CREATE", `[{"name":"check-ntp","node-script":"chronyc tracking","timeout":60000000000,"ignore-failure":true,"output-path":"/tmp/x"}]`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_HOOKS_POST_CREATE")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if len(cfg.Hooks.PreCreate) != 1 || len(cfg.Hooks.PostCreate) != 1 {
+		t.Fatalf("unexpected cfg.Hooks %+v", cfg.Hooks)
+	}
+	pre, post := cfg.Hooks.PreCreate[0], cfg.Hooks.PostCreate[0]
+	if pre.Name != "pre-create-00" || pre.Command != "echo hello" || pre.Timeout != DefaultHookTimeout {
+		t.Fatalf("unexpected cfg.Hooks.PreCreate[0] %+v", pre)
+	}
+	if pre.OutputPath != filepath.Join(cfg.Hooks.OutputDir, "pre-create-pre-create-00.out.log") {
+		t.Fatalf("unexpected cfg.Hooks.PreCreate[0].OutputPath %q", pre.OutputPath)
+	}
+	if post.Name != "check-ntp" || post.Transport != NodeTransportSSM || post.Timeout != time.Minute || !post.IgnoreFailure {
+		t.Fatalf("unexpected cfg.Hooks.PostCreate[0] %+v", post)
+	}
+	if post.OutputPath != filepath.Join(cfg.Hooks.OutputDir, "post-create-check-ntp.out.log") {
+		t.Fatalf("unexpected cfg.Hooks.PostCreate[0].OutputPath %q", post.OutputPath)
+	}
+	if !cfg.Hooks.HasNodeScript(NodeTransportSSM) || cfg.Hooks.HasNodeScript(NodeTransportSSH) {
+		t.Fatal("unexpected cfg.Hooks.HasNodeScript")
+	}
+
+	cfg.Hooks.PostDelete = []Hook{{NodeScript: "true"}}
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for NodeScript in post-delete")
+	}
+	cfg.Hooks.PostDelete = []Hook{{Command: "true", NodeScript: "true"}}
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for both Command and NodeScript")
+	}
+	cfg.Hooks.PostDelete = []Hook{{Name: "a", Command: "true"}, {Name: "a", Command: "true"}}
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for duplicate hook names")
+	}
+}
+
+func TestEnvImageMirror(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	if cfg.ImageMirror.Enable {
+		t.Fatalf("unexpected default cfg.ImageMirror %+v", cfg.ImageMirror)
+	}
+
+	os.Setenv("AWS_K8S_TESTER_EKS_IMAGE_MIRROR_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_IMAGE_MIRROR_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_HPA_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_HPA_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_HPA_IMAGE", "k8s.gcr.io/hpa-example")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_HPA_IMAGE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_JOBS_ECHO_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_JOBS_ECHO_ENABLE")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.ImageMirror.RepositoryPrefix != strings.ToLower(cfg.Name)+"-mirror" {
+		t.Fatalf("unexpected cfg.ImageMirror.RepositoryPrefix %q", cfg.ImageMirror.RepositoryPrefix)
+	}
+	imgs := cfg.Images()
+	for _, img := range []string{"busybox", "k8s.gcr.io/hpa-example", cfg.AddOnHPA.LoadGeneratorImage} {
+		found := false
+		for _, v := range imgs {
+			found = found || v == img
+		}
+		if !found {
+			t.Fatalf("expected %q in cfg.Images() %v", img, imgs)
+		}
+	}
+	if !sort.StringsAreSorted(imgs) {
+		t.Fatalf("expected sorted cfg.Images() %v", imgs)
+	}
+
+	mirrored := "123456789012.dkr.ecr.us-west-2.amazonaws.com/" + cfg.ImageMirror.RepositoryPrefix + "/hpa-example:latest"
+	cfg.ImageMirror.Images = map[string]string{
+		"k8s.gcr.io/hpa-example": mirrored,
+		"busybox":                "123456789012.dkr.ecr.us-west-2.amazonaws.com/" + cfg.ImageMirror.RepositoryPrefix + "/library/busybox:latest",
+	}
+	cfg.RewriteImages()
+	if cfg.AddOnHPA.Image != mirrored {
+		t.Fatalf("unexpected rewritten cfg.AddOnHPA.Image %q", cfg.AddOnHPA.Image)
+	}
+	if cfg.Image("busybox") != cfg.ImageMirror.Images["busybox"] || cfg.Image("centos:7") != "centos:7" {
+		t.Fatalf("unexpected mirrored images %q, %q", cfg.Image("busybox"), cfg.Image("centos:7"))
+	}
+	// the rewritten images are listed by their sources
+	if rimgs := cfg.Images(); !reflect.DeepEqual(rimgs, imgs) {
+		t.Fatalf("expected cfg.Images() %v, got %v", imgs, rimgs)
+	}
+
+	cfg.ImageMirror.RepositoryPrefix = "Invalid_"
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for invalid cfg.ImageMirror.RepositoryPrefix")
+	}
+}
+
+func TestEnvAssumeRole(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	if cfg.AssumeRole.IsEnabled() {
+		t.Fatalf("unexpected default cfg.AssumeRole %+v", cfg.AssumeRole)
+	}
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ASSUME_ROLE_ROLE_ARN", "arn:aws:iam::123456789012:role/tester")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ASSUME_ROLE_ROLE_ARN")
+	os.Setenv("AWS_K8S_TESTER_EKS_ASSUME_ROLE_EXTERNAL_ID", "ci")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ASSUME_ROLE_EXTERNAL_ID")
+	os.Setenv("AWS_K8S_TESTER_EKS_ASSUME_ROLE_DURATION", "2h")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ASSUME_ROLE_DURATION")
+	os.Setenv("AWS_K8S_TESTER_EKS_ASSUME_ROLE_WEB_IDENTITY_TOKEN_FILE", "/var/run/secrets/ci/token")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ASSUME_ROLE_WEB_IDENTITY_TOKEN_FILE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ASSUME_ROLE_WEB_IDENTITY_ROLE_ARN", "arn:aws:iam::123456789012:role/bootstrap")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ASSUME_ROLE_WEB_IDENTITY_ROLE_ARN")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.AssumeRole.RoleARN != "arn:aws:iam::123456789012:role/tester" {
+		t.Fatalf("unexpected cfg.AssumeRole.RoleARN %q", cfg.AssumeRole.RoleARN)
+	}
+	if cfg.AssumeRole.ExternalID != "ci" {
+		t.Fatalf("unexpected cfg.AssumeRole.ExternalID %q", cfg.AssumeRole.ExternalID)
+	}
+	if cfg.AssumeRole.Duration != 2*time.Hour {
+		t.Fatalf("unexpected cfg.AssumeRole.Duration %v", cfg.AssumeRole.Duration)
+	}
+	if cfg.AssumeRole.SessionName != cfg.Name {
+		t.Fatalf("unexpected cfg.AssumeRole.SessionName %q", cfg.AssumeRole.SessionName)
+	}
+	if cfg.AssumeRole.WebIdentityTokenFile != "/var/run/secrets/ci/token" {
+		t.Fatalf("unexpected cfg.AssumeRole.WebIdentityTokenFile %q", cfg.AssumeRole.WebIdentityTokenFile)
+	}
+	if cfg.AssumeRole.WebIdentityRoleARN != "arn:aws:iam::123456789012:role/bootstrap" {
+		t.Fatalf("unexpected cfg.AssumeRole.WebIdentityRoleARN %q", cfg.AssumeRole.WebIdentityRoleARN)
+	}
+
+	cfg.AssumeRole.Duration = 13 * time.Hour
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for cfg.AssumeRole.Duration out of range")
+	}
+	cfg.AssumeRole.Duration = time.Hour
+	cfg.AssumeRole.WebIdentityRoleARN = ""
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for empty cfg.AssumeRole.WebIdentityRoleARN")
+	}
+}
+
+func TestEnvServiceEndpoints(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_REGION", "cn-north-1")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_REGION")
+	os.Setenv("AWS_K8S_TESTER_EKS_SERVICE_ENDPOINTS_EKS", "http://localhost:4566")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_SERVICE_ENDPOINTS_EKS")
+	os.Setenv("AWS_K8S_TESTER_EKS_SERVICE_ENDPOINTS_S3", "http://localhost:4566")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_SERVICE_ENDPOINTS_S3")
+	os.Setenv("AWS_K8S_TESTER_EKS_SERVICE_ENDPOINTS_S3_FORCE_PATH_STYLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_SERVICE_ENDPOINTS_S3_FORCE_PATH_STYLE")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.Partition != "aws-cn" {
+		t.Fatalf("unexpected cfg.Partition %q", cfg.Partition)
+	}
+	if cfg.Role.ManagedPolicyARNs[0] != "arn:aws-cn:iam::aws:policy/AmazonEKSClusterPolicy" {
+		t.Fatalf("unexpected cfg.Role.ManagedPolicyARNs %q", cfg.Role.ManagedPolicyARNs)
+	}
+	if cfg.ResolverURL != "http://localhost:4566" {
+		t.Fatalf("unexpected cfg.ResolverURL %q", cfg.ResolverURL)
+	}
+	if !cfg.ServiceEndpoints.S3ForcePathStyle {
+		t.Fatalf("unexpected cfg.ServiceEndpoints.S3ForcePathStyle %v", cfg.ServiceEndpoints.S3ForcePathStyle)
+	}
+	expectedEndpoints := map[string]string{"eks": "http://localhost:4566", "s3": "http://localhost:4566"}
+	if !reflect.DeepEqual(cfg.ServiceEndpoints.AWSEndpoints(), expectedEndpoints) {
+		t.Fatalf("unexpected cfg.ServiceEndpoints.AWSEndpoints() %v", cfg.ServiceEndpoints.AWSEndpoints())
+	}
+
+	cfg.Partition = "aws-us-gov"
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for cfg.Partition not matching cfg.Region")
+	}
+	cfg.Partition = "aws-cn"
+	cfg.ServiceEndpoints.EC2 = "localhost:4566"
+	if err = cfg.ValidateAndSetDefaults(); err == nil {
+		t.Fatal("expected error for cfg.ServiceEndpoints.EC2 without scheme")
+	}
+}
+
+func TestEnvAddOnNetworkPolicy(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NETWORK_POLICY_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NETWORK_POLICY_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NETWORK_POLICY_PROVIDER", "calico")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NETWORK_POLICY_PROVIDER")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NETWORK_POLICY_CALICO_VERSION", "v3.25.2")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NETWORK_POLICY_CALICO_VERSION")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_NETWORK_POLICY_PROBE_TIMEOUT", "3m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_NETWORK_POLICY_PROBE_TIMEOUT")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.AddOnNetworkPolicy.Provider != NetworkPolicyProviderCalico {
+		t.Fatalf("unexpected cfg.AddOnNetworkPolicy.Provider %q", cfg.AddOnNetworkPolicy.Provider)
+	}
+	if cfg.AddOnNetworkPolicy.CalicoVersion != "v3.25.2" {
+		t.Fatalf("unexpected cfg.AddOnNetworkPolicy.CalicoVersion %q", cfg.AddOnNetworkPolicy.CalicoVersion)
+	}
+	if cfg.AddOnNetworkPolicy.ProbeTimeout != 3*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnNetworkPolicy.ProbeTimeout %v", cfg.AddOnNetworkPolicy.ProbeTimeout)
+	}
+	if cfg.AddOnNetworkPolicy.Namespace != cfg.Name+"-network-policy" {
+		t.Fatalf("unexpected cfg.AddOnNetworkPolicy.Namespace %q", cfg.AddOnNetworkPolicy.Namespace)
+	}
+	if !strings.HasSuffix(cfg.AddOnNetworkPolicy.ResultJUnitXMLPath, "-network-policy.junit.xml") {
+		t.Fatalf("unexpected cfg.AddOnNetworkPolicy.ResultJUnitXMLPath %q", cfg.AddOnNetworkPolicy.ResultJUnitXMLPath)
+	}
+	if cfg.AddOnNetworkPolicy.ResultJUnitXMLS3Key != cfg.Name+"/add-on-network-policy/"+cfg.Name+"-network-policy.junit.xml" {
+		t.Fatalf("unexpected cfg.AddOnNetworkPolicy.ResultJUnitXMLS3Key %q", cfg.AddOnNetworkPolicy.ResultJUnitXMLS3Key)
+	}
+
+	cfg.AddOnNetworkPolicy.Provider = "cilium"
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "unknown AddOnNetworkPolicy.Provider") {
+		t.Fatalf("expected provider error, got %v", err)
+	}
+	cfg.AddOnNetworkPolicy.Provider = NetworkPolicyProviderVPCCNI
+	cfg.Version = "1.24"
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "requires Version") {
+		t.Fatalf("expected version error, got %v", err)
+	}
+}
+
+func TestEnvAddOnContainerInsights(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CONTAINER_INSIGHTS_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CONTAINER_INSIGHTS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CONTAINER_INSIGHTS_FLUENT_BIT_IMAGE", "fluent-bit:2.31.12")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CONTAINER_INSIGHTS_FLUENT_BIT_IMAGE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CONTAINER_INSIGHTS_LOG_GENERATOR_LINES", "500")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CONTAINER_INSIGHTS_LOG_GENERATOR_LINES")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CONTAINER_INSIGHTS_VERIFY_TIMEOUT", "15m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CONTAINER_INSIGHTS_VERIFY_TIMEOUT")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CONTAINER_INSIGHTS_DELETE_LOG_GROUPS", "false")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CONTAINER_INSIGHTS_DELETE_LOG_GROUPS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.AddOnContainerInsights.Namespace != DefaultContainerInsightsNamespace {
+		t.Fatalf("unexpected cfg.AddOnContainerInsights.Namespace %q", cfg.AddOnContainerInsights.Namespace)
+	}
+	if cfg.AddOnContainerInsights.CWAgentImage != DefaultContainerInsightsCWAgentImage {
+		t.Fatalf("unexpected cfg.AddOnContainerInsights.CWAgentImage %q", cfg.AddOnContainerInsights.CWAgentImage)
+	}
+	if cfg.AddOnContainerInsights.FluentBitImage != "fluent-bit:2.31.12" {
+		t.Fatalf("unexpected cfg.AddOnContainerInsights.FluentBitImage %q", cfg.AddOnContainerInsights.FluentBitImage)
+	}
+	if cfg.AddOnContainerInsights.LogGeneratorLines != 500 {
+		t.Fatalf("unexpected cfg.AddOnContainerInsights.LogGeneratorLines %d", cfg.AddOnContainerInsights.LogGeneratorLines)
+	}
+	if cfg.AddOnContainerInsights.VerifyTimeout != 15*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnContainerInsights.VerifyTimeout %v", cfg.AddOnContainerInsights.VerifyTimeout)
+	}
+	if cfg.AddOnContainerInsights.DeleteLogGroups {
+		t.Fatalf("unexpected cfg.AddOnContainerInsights.DeleteLogGroups %v", cfg.AddOnContainerInsights.DeleteLogGroups)
+	}
+	expectedLogGroups := []string{
+		"/aws/containerinsights/" + cfg.Name + "/application",
+		"/aws/containerinsights/" + cfg.Name + "/performance",
+	}
+	if !reflect.DeepEqual(cfg.AddOnContainerInsights.LogGroupNames, expectedLogGroups) {
+		t.Fatalf("unexpected cfg.AddOnContainerInsights.LogGroupNames %v", cfg.AddOnContainerInsights.LogGroupNames)
+	}
+
+	cfg.AddOnCWAgent = getDefaultAddOnCWAgent()
+	cfg.AddOnCWAgent.Enable = true
+	err = cfg.ValidateAndSetDefaults()
+	if err == nil || !strings.Contains(err.Error(), "conflicts with AddOnCWAgent") {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+}
+
+func TestEnvAddOnPrometheus(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_CHART_VERSION", "48.3.1")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_CHART_VERSION")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_SCRAPE_JOBS", "apiserver,kubelet")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_SCRAPE_JOBS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_TARGETS_TIMEOUT", "20m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_TARGETS_TIMEOUT")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_SNAPSHOT_QUERIES", `{"node-count":"count(kube_node_info)"}`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_SNAPSHOT_QUERIES")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.AddOnPrometheus.Namespace != DefaultPrometheusNamespace {
+		t.Fatalf("unexpected cfg.AddOnPrometheus.Namespace %q", cfg.AddOnPrometheus.Namespace)
+	}
+	if cfg.AddOnPrometheus.ChartVersion != "48.3.1" {
+		t.Fatalf("unexpected cfg.AddOnPrometheus.ChartVersion %q", cfg.AddOnPrometheus.ChartVersion)
+	}
+	if !reflect.DeepEqual(cfg.AddOnPrometheus.ScrapeJobs, []string{"apiserver", "kubelet"}) {
+		t.Fatalf("unexpected cfg.AddOnPrometheus.ScrapeJobs %v", cfg.AddOnPrometheus.ScrapeJobs)
+	}
+	if cfg.AddOnPrometheus.TargetsTimeout != 20*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnPrometheus.TargetsTimeout %v", cfg.AddOnPrometheus.TargetsTimeout)
+	}
+	if !reflect.DeepEqual(cfg.AddOnPrometheus.SnapshotQueries, map[string]string{"node-count": "count(kube_node_info)"}) {
+		t.Fatalf("unexpected cfg.AddOnPrometheus.SnapshotQueries %v", cfg.AddOnPrometheus.SnapshotQueries)
+	}
+
+	cfg.AddOnPrometheus.SnapshotQueries = nil
+	err = cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+	if !reflect.DeepEqual(cfg.AddOnPrometheus.SnapshotQueries, DefaultPrometheusSnapshotQueries) {
+		t.Fatalf("unexpected cfg.AddOnPrometheus.SnapshotQueries %v", cfg.AddOnPrometheus.SnapshotQueries)
+	}
+}
+
+func TestEnvAddOnHPA(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_HPA_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_HPA_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_HPA_MAX_REPLICAS", "8")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_HPA_MAX_REPLICAS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_HPA_TARGET_CPU_UTILIZATION_PERCENTAGE", "30")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_HPA_TARGET_CPU_UTILIZATION_PERCENTAGE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_HPA_SCALE_UP_TIMEOUT", "7m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_HPA_SCALE_UP_TIMEOUT")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_HPA_SCALE_DOWN_STABILIZATION_WINDOW", "2m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_HPA_SCALE_DOWN_STABILIZATION_WINDOW")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.AddOnHPA.Namespace != cfg.Name+"-hpa" {
+		t.Fatalf("unexpected cfg.AddOnHPA.Namespace %q", cfg.AddOnHPA.Namespace)
+	}
+	if cfg.AddOnHPA.MinReplicas != 1 {
+		t.Fatalf("unexpected cfg.AddOnHPA.MinReplicas %d", cfg.AddOnHPA.MinReplicas)
+	}
+	if cfg.AddOnHPA.MaxReplicas != 8 {
+		t.Fatalf("unexpected cfg.AddOnHPA.MaxReplicas %d", cfg.AddOnHPA.MaxReplicas)
+	}
+	if cfg.AddOnHPA.TargetCPUUtilizationPercentage != 30 {
+		t.Fatalf("unexpected cfg.AddOnHPA.TargetCPUUtilizationPercentage %d", cfg.AddOnHPA.TargetCPUUtilizationPercentage)
+	}
+	if cfg.AddOnHPA.ScaleUpTimeout != 7*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnHPA.ScaleUpTimeout %v", cfg.AddOnHPA.ScaleUpTimeout)
+	}
+	if cfg.AddOnHPA.ScaleDownStabilizationWindow != 2*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnHPA.ScaleDownStabilizationWindow %v", cfg.AddOnHPA.ScaleDownStabilizationWindow)
+	}
+	if !cfg.IsEnabledAddOnMetricsServer() {
+		t.Fatal("expected AddOnMetricsServer enabled by AddOnHPA")
+	}
+
+	cfg.AddOnHPA.MaxReplicas = 1
+	err = cfg.ValidateAndSetDefaults()
+	assert.Error(t, err)
+	cfg.AddOnHPA.MaxReplicas = 8
+
+	cfg.AddOnHPA.ScaleDownTimeout = time.Minute
+	err = cfg.ValidateAndSetDefaults()
+	assert.Error(t, err)
+}
+
+func TestEnvAddOnClusterLoader(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CLUSTER_LOADER_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CLUSTER_LOADER_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CLUSTER_LOADER_NAMESPACES", "10")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CLUSTER_LOADER_NAMESPACES")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CLUSTER_LOADER_SECRETS_PER_NAMESPACE", "0")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CLUSTER_LOADER_SECRETS_PER_NAMESPACE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CLUSTER_LOADER_QPS", "50.5")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CLUSTER_LOADER_QPS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CLUSTER_LOADER_DURATION", "30m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CLUSTER_LOADER_DURATION")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.AddOnClusterLoader.NamespacePrefix != cfg.Name+"-cl" {
+		t.Fatalf("unexpected cfg.AddOnClusterLoader.NamespacePrefix %q", cfg.AddOnClusterLoader.NamespacePrefix)
+	}
+	if cfg.AddOnClusterLoader.Namespaces != 10 {
+		t.Fatalf("unexpected cfg.AddOnClusterLoader.Namespaces %d", cfg.AddOnClusterLoader.Namespaces)
+	}
+	if cfg.AddOnClusterLoader.SecretsPerNamespace != 0 {
+		t.Fatalf("unexpected cfg.AddOnClusterLoader.SecretsPerNamespace %d", cfg.AddOnClusterLoader.SecretsPerNamespace)
+	}
+	if cfg.AddOnClusterLoader.ConfigMapsPerNamespace != 10 {
+		t.Fatalf("unexpected cfg.AddOnClusterLoader.ConfigMapsPerNamespace %d", cfg.AddOnClusterLoader.ConfigMapsPerNamespace)
+	}
+	if cfg.AddOnClusterLoader.QPS != 50.5 {
+		t.Fatalf("unexpected cfg.AddOnClusterLoader.QPS %v", cfg.AddOnClusterLoader.QPS)
+	}
+	if cfg.AddOnClusterLoader.Duration != 30*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnClusterLoader.Duration %v", cfg.AddOnClusterLoader.Duration)
+	}
+	if cfg.AddOnClusterLoader.RequestsSummariesJSONPath == "" {
+		t.Fatal("empty cfg.AddOnClusterLoader.RequestsSummariesJSONPath")
+	}
+
+	cfg.AddOnClusterLoader.QPS = 0
+	err = cfg.ValidateAndSetDefaults()
+	assert.Error(t, err)
+}
+
+func TestEnvAddOnCSIEBSChurn(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_CHURN_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_CHURN_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_CHURN_CYCLES", "100")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_CHURN_CYCLES")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_CHURN_CONCURRENCY", "8")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_CHURN_CONCURRENCY")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_CHURN_CYCLES_PER_MINUTE", "30")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_CHURN_CYCLES_PER_MINUTE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_CHURN_VOLUME_TYPE", "gp2")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_CHURN_VOLUME_TYPE")
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_SKIP_VOLUME_TEST", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_SKIP_VOLUME_TEST")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.AddOnCSIEBSChurn.Namespace != cfg.Name+"-csi-ebs-churn" {
+		t.Fatalf("unexpected cfg.AddOnCSIEBSChurn.Namespace %q", cfg.AddOnCSIEBSChurn.Namespace)
+	}
+	if cfg.AddOnCSIEBSChurn.Cycles != 100 {
+		t.Fatalf("unexpected cfg.AddOnCSIEBSChurn.Cycles %d", cfg.AddOnCSIEBSChurn.Cycles)
+	}
+	if cfg.AddOnCSIEBSChurn.Concurrency != 8 {
+		t.Fatalf("unexpected cfg.AddOnCSIEBSChurn.Concurrency %d", cfg.AddOnCSIEBSChurn.Concurrency)
+	}
+	if cfg.AddOnCSIEBSChurn.CyclesPerMinute != 30 {
+		t.Fatalf("unexpected cfg.AddOnCSIEBSChurn.CyclesPerMinute %v", cfg.AddOnCSIEBSChurn.CyclesPerMinute)
+	}
+	if cfg.AddOnCSIEBSChurn.VolumeType != "gp2" {
+		t.Fatalf("unexpected cfg.AddOnCSIEBSChurn.VolumeType %q", cfg.AddOnCSIEBSChurn.VolumeType)
+	}
+	if cfg.AddOnCSIEBSChurn.CycleTimeout != 5*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnCSIEBSChurn.CycleTimeout %v", cfg.AddOnCSIEBSChurn.CycleTimeout)
+	}
+
+	cfg.AddOnCSIEBSChurn.Concurrency = 0
+	err = cfg.ValidateAndSetDefaults()
+	assert.Error(t, err)
+
+	// requires the EBS CSI Driver
+	cfg.AddOnCSIEBSChurn.Concurrency = 8
+	cfg.AddOnCSIEBS.Enable = false
+	err = cfg.ValidateAndSetDefaults()
+	assert.Error(t, err)
+}
+
+func TestEnvAddOnJobsThroughput(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_JOBS_THROUGHPUT_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_JOBS_THROUGHPUT_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_JOBS_THROUGHPUT_JOBS", "1000")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_JOBS_THROUGHPUT_JOBS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_JOBS_THROUGHPUT_CRON_JOBS", "50")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_JOBS_THROUGHPUT_CRON_JOBS")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_JOBS_THROUGHPUT_CRON_JOBS_DURATION", "10m")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_JOBS_THROUGHPUT_CRON_JOBS_DURATION")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_JOBS_THROUGHPUT_QPS", "25.5")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_JOBS_THROUGHPUT_QPS")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if cfg.AddOnJobsThroughput.Namespace != cfg.Name+"-jobs-throughput" {
+		t.Fatalf("unexpected cfg.AddOnJobsThroughput.Namespace %q", cfg.AddOnJobsThroughput.Namespace)
+	}
+	if cfg.AddOnJobsThroughput.Jobs != 1000 {
+		t.Fatalf("unexpected cfg.AddOnJobsThroughput.Jobs %d", cfg.AddOnJobsThroughput.Jobs)
+	}
+	if cfg.AddOnJobsThroughput.CronJobs != 50 {
+		t.Fatalf("unexpected cfg.AddOnJobsThroughput.CronJobs %d", cfg.AddOnJobsThroughput.CronJobs)
+	}
+	if cfg.AddOnJobsThroughput.CronJobsDuration != 10*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnJobsThroughput.CronJobsDuration %v", cfg.AddOnJobsThroughput.CronJobsDuration)
+	}
+	if cfg.AddOnJobsThroughput.QPS != 25.5 {
+		t.Fatalf("unexpected cfg.AddOnJobsThroughput.QPS %v", cfg.AddOnJobsThroughput.QPS)
+	}
+	if cfg.AddOnJobsThroughput.Timeout != 15*time.Minute {
+		t.Fatalf("unexpected cfg.AddOnJobsThroughput.Timeout %v", cfg.AddOnJobsThroughput.Timeout)
+	}
+
+	cfg.AddOnJobsThroughput.CronJobsDuration = time.Minute
+	err = cfg.ValidateAndSetDefaults()
+	assert.Error(t, err)
+}
+
+func TestEnvAddOnAppMesh(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_IRSA_ENABLE", `false`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_IRSA_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_APP_MESH_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_APP_MESH_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_APP_MESH_NAMESPACE", "custom-namespace")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_APP_MESH_NAMESPACE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_APP_MESH_CONTROLLER_IMAGE", "repo/controller:v1.1.3")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_APP_MESH_CONTROLLER_IMAGE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_APP_MESH_INJECTOR_IMAGE", "repo/injector:v1.1.3")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_APP_MESH_INJECTOR_IMAGE")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	assert.True(t, cfg.AddOnAppMesh.Enable)
+	assert.Equal(t, cfg.AddOnAppMesh.Namespace, "custom-namespace")
+	assert.Equal(t, cfg.AddOnAppMesh.ControllerImage, "repo/controller:v1.1.3")
+	assert.Equal(t, cfg.AddOnAppMesh.InjectorImage, "repo/injector:v1.1.3")
+
+	if cfg.AddOnAppMesh.PolicyCFNStackID != "" {
+		t.Fatalf("read-only AddOnAppMesh.PolicyCFNStackID is set to %q", cfg.AddOnAppMesh.PolicyCFNStackID)
+	}
+}
+
+func TestEnvAddOnWordpress(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_ENABLE")
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_WORDPRESS_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_WORDPRESS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_WORDPRESS_NAMESPACE", "word-press")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_WORDPRESS_NAMESPACE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_WORDPRESS_USER_NAME", "my-user")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_WORDPRESS_USER_NAME")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_WORDPRESS_PASSWORD", "my-password")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_WORDPRESS_PASSWORD")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if !cfg.AddOnWordpress.Enable {
+		t.Fatalf("unexpected cfg.AddOnWordpress.Enable %v", cfg.AddOnWordpress.Enable)
+	}
+	if cfg.AddOnWordpress.Namespace != "word-press" {
+		t.Fatalf("unexpected cfg.AddOnWordpress.Namespace %q", cfg.AddOnWordpress.Namespace)
+	}
+	if cfg.AddOnWordpress.UserName != "my-user" {
+		t.Fatalf("unexpected cfg.AddOnWordpress.UserName %q", cfg.AddOnWordpress.UserName)
+	}
+	if cfg.AddOnWordpress.Password != "my-password" {
+		t.Fatalf("unexpected cfg.AddOnWordpress.Password %q", cfg.AddOnWordpress.Password)
+	}
+}
+
+func TestEnvAddOnKubernetesDashboard(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_METRICS_SERVER_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_METRICS_SERVER_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBERNETES_DASHBOARD_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBERNETES_DASHBOARD_ENABLE")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if !cfg.AddOnMetricsServer.Enable {
+		t.Fatalf("unexpected cfg.AddOnMetricsServer.Enable %v", cfg.AddOnMetricsServer.Enable)
+	}
+	if !cfg.AddOnKubernetesDashboard.Enable {
+		t.Fatalf("unexpected cfg.AddOnKubernetesDashboard.Enable %v", cfg.AddOnKubernetesDashboard.Enable)
+	}
+	fmt.Println(cfg.KubectlCommands())
+}
+
+func TestEnvAddOnPrometheusGrafana(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_CSI_EBS_ENABLE")
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_GRAFANA_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_GRAFANA_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_GRAFANA_GRAFANA_ADMIN_USER_NAME", "MY_ADMIN_USER_NAME")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_GRAFANA_GRAFANA_ADMIN_USER_NAME")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_GRAFANA_GRAFANA_ADMIN_PASSWORD", "MY_ADMIN_PASSWORD")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_PROMETHEUS_GRAFANA_GRAFANA_ADMIN_PASSWORD")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if !cfg.AddOnPrometheusGrafana.Enable {
+		t.Fatalf("unexpected cfg.AddOnPrometheusGrafana.Enable %v", cfg.AddOnPrometheusGrafana.Enable)
+	}
+	if cfg.AddOnPrometheusGrafana.GrafanaAdminUserName != "MY_ADMIN_USER_NAME" {
+		t.Fatalf("unexpected cfg.AddOnPrometheusGrafana.GrafanaAdminUserName %q", cfg.AddOnPrometheusGrafana.GrafanaAdminUserName)
+	}
+	if cfg.AddOnPrometheusGrafana.GrafanaAdminPassword != "MY_ADMIN_PASSWORD" {
+		t.Fatalf("unexpected cfg.AddOnPrometheusGrafana.GrafanaAdminPassword %q", cfg.AddOnPrometheusGrafana.GrafanaAdminPassword)
+	}
+}
+
+func TestEnvAddOnKubeflow(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE", `true`)
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_MANAGED_NODE_GROUPS_ENABLE")
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBEFLOW_ENABLE", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBEFLOW_ENABLE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBEFLOW_NAMESPACE", "kubeflow")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBEFLOW_NAMESPACE")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBEFLOW_KFCTL_DOWNLOAD_URL", "kubeflow-download-here")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBEFLOW_KFCTL_DOWNLOAD_URL")
+	os.Setenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBEFLOW_BASE_DIR", "kubeflow-base-dir")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ADD_ON_KUBEFLOW_BASE_DIR")
+
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.ValidateAndSetDefaults()
+	assert.NoError(t, err)
+
+	if !cfg.AddOnKubeflow.Enable {
+		t.Fatalf("unexpected cfg.AddOnKubeflow.Enable %v", cfg.AddOnKubeflow.Enable)
+	}
+	if cfg.AddOnKubeflow.KfctlDownloadURL != "kubeflow-download-here" {
+		t.Fatalf("unexpected cfg.AddOnKubeflow.KfctlDownloadURL %q", cfg.AddOnKubeflow.KfctlDownloadURL)
+	}
+	if cfg.AddOnKubeflow.BaseDir != "kubeflow-base-dir" {
+		t.Fatalf("unexpected cfg.AddOnKubeflow.BaseDir %q", cfg.AddOnKubeflow.BaseDir)
+	}
+}
+
+func TestEnvStrict(t *testing.T) {
+	cfg := NewDefault()
+	defer func() {
+		os.RemoveAll(cfg.ConfigPath)
+		os.RemoveAll(cfg.KubectlCommandsOutputPath)
+		os.RemoveAll(cfg.RemoteAccessCommandsOutputPath)
+	}()
+
+	os.Setenv("AWS_K8S_TESTER_EKS_REGOIN", "us-east-1")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_REGOIN")
+
+	// unknown variables are ignored by default
+	if err := cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("AWS_K8S_TESTER_EKS_ENV_STRICT", "true")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_ENV_STRICT")
+	err := cfg.UpdateFromEnvs()
+	if err == nil || !strings.Contains(err.Error(), `"AWS_K8S_TESTER_EKS_REGOIN" (did you mean "AWS_K8S_TESTER_EKS_REGION"?)`) {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	os.Unsetenv("AWS_K8S_TESTER_EKS_REGOIN")
+	os.Setenv("AWS_K8S_TESTER_EKS_VPC_ID", "vpc-123")
+	defer os.Unsetenv("AWS_K8S_TESTER_EKS_VPC_ID")
+	if err = cfg.UpdateFromEnvs(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.VPC.ID != "vpc-123" {
+		t.Fatalf("unexpected cfg.VPC.ID %q", cfg.VPC.ID)
+	}
+}
+
+func TestEnvVars(t *testing.T) {
+	evs := EnvVars()
+	found := make(map[string]EnvVar)
+	for _, ev := range evs {
+		if _, ok := found[ev.Name]; ok {
+			t.Fatalf("duplicate environment variable %q", ev.Name)
+		}
+		found[ev.Name] = ev
+	}
+	for name, exp := range map[string]EnvVar{
+		"AWS_K8S_TESTER_EKS_REGION":                      {Type: "string", Default: "us-west-2"},
+		"AWS_K8S_TESTER_EKS_LOG_OUTPUTS":                 {Type: "[]string", Default: "stderr"},
+		"AWS_K8S_TESTER_EKS_ADD_ON_NODE_GROUPS_ROLE_ARN": {Type: "string"},
+		"AWS_K8S_TESTER_EKS_VPC_NAT_GATEWAY_IDS":         {Type: "[]string", ReadOnly: true},
+	} {
+		ev, ok := found[name]
+		if !ok {
+			t.Fatalf("%q not found", name)
+		}
+		if ev.Type != exp.Type || ev.Default != exp.Default || ev.ReadOnly != exp.ReadOnly {
+			t.Fatalf("%q: unexpected %+v", name, ev)
+		}
+	}
+	if _, ok := found["AWS_K8S_TESTER_EKS_VPC"]; ok {
+		t.Fatal("unexpected section as environment variable")
 	}
 }
@@ -0,0 +1,176 @@
+package eksconfig
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+)
+
+// Hooks defines the commands to run at the points of the run lifecycle,
+// to bolt on the custom validation without modifying the tester.
+// The hooks in a list run in order, and the output of each hook is written
+// to "Hook.OutputPath" and uploaded to S3 with the other artifacts.
+// A failed "PreCreate" or "PostCreate" hook fails "Up", unless "IgnoreFailure".
+// The failed "PreDelete" and "PostDelete" hooks are reported on "Down"
+// after deleting all resources.
+type Hooks struct {
+	// PreCreate runs before creating any resource.
+	// Only the local commands are supported.
+	PreCreate []Hook `json:"pre-create"`
+	// PostCreate runs after creating the cluster and all add-ons.
+	PostCreate []Hook `json:"post-create"`
+	// PreDelete runs before deleting any resource.
+	PreDelete []Hook `json:"pre-delete"`
+	// PostDelete runs after deleting all resources.
+	// Only the local commands are supported.
+	PostDelete []Hook `json:"post-delete"`
+
+	// OutputDir is the directory of the hook outputs.
+	OutputDir string `json:"output-dir"`
+}
+
+// Hook is a local command or a script to run on the worker nodes.
+type Hook struct {
+	// Name is the hook name, used in the report and the output file name.
+	// Defaults to "[PHASE]-[INDEX]" (e.g. "post-create-00").
+	Name string `json:"name"`
+
+	// Command is the local command to run with "sh -c", with the
+	// "KUBECONFIG", "AWS_K8S_TESTER_EKS_CONFIG_PATH", and
+	// "AWS_K8S_TESTER_EKS_CLUSTER_NAME" environment variables set.
+	Command string `json:"command"`
+
+	// NodeScript is the shell script to run as root on each Ready worker node.
+	NodeScript string `json:"node-script"`
+	// NodeGroupName is the node group of the nodes to run "NodeScript" on,
+	// selected by the "NGName" label. Leave empty to run on all Ready nodes.
+	NodeGroupName string `json:"node-group-name"`
+	// Transport is "ssm" (default) to run "NodeScript" with SSM Run Command,
+	// or "ssh" to run over SSH with the remote access key.
+	Transport string `json:"transport"`
+
+	// Timeout is the timeout of the command, or of the script on each node.
+	Timeout       time.Duration `json:"timeout"`
+	TimeoutString string        `json:"timeout-string" read-only:"true"`
+	// IgnoreFailure is true to continue when the hook fails.
+	// The failure is still recorded in the report.
+	IgnoreFailure bool `json:"ignore-failure"`
+
+	// OutputPath is the file path of the captured output.
+	OutputPath string `json:"output-path" read-only:"true"`
+}
+
+const (
+	// HookPhasePreCreate runs the hooks before creating any resource.
+	HookPhasePreCreate = "pre-create"
+	// HookPhasePostCreate runs the hooks after creating all resources.
+	HookPhasePostCreate = "post-create"
+	// HookPhasePreDelete runs the hooks before deleting any resource.
+	HookPhasePreDelete = "pre-delete"
+	// HookPhasePostDelete runs the hooks after deleting all resources.
+	HookPhasePostDelete = "post-delete"
+)
+
+// DefaultHookTimeout is the default hook timeout.
+const DefaultHookTimeout = 5 * time.Minute
+
+func getDefaultHooks() *Hooks {
+	return &Hooks{}
+}
+
+// Phase returns the hooks of the lifecycle phase.
+func (hs *Hooks) Phase(phase string) []Hook {
+	if hs == nil {
+		return nil
+	}
+	switch phase {
+	case HookPhasePreCreate:
+		return hs.PreCreate
+	case HookPhasePostCreate:
+		return hs.PostCreate
+	case HookPhasePreDelete:
+		return hs.PreDelete
+	case HookPhasePostDelete:
+		return hs.PostDelete
+	}
+	return nil
+}
+
+// HasNodeScript returns true if any hook runs a script on the nodes
+// with the transport.
+func (hs *Hooks) HasNodeScript(transport string) bool {
+	for _, phase := range []string{HookPhasePreCreate, HookPhasePostCreate, HookPhasePreDelete, HookPhasePostDelete} {
+		for _, h := range hs.Phase(phase) {
+			if h.NodeScript != "" && h.Transport == transport {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var hookNameRegex = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+func (cfg *Config) validateHooks() error {
+	if cfg.Hooks == nil {
+		cfg.Hooks = getDefaultHooks()
+	}
+	if cfg.Hooks.OutputDir == "" {
+		cfg.Hooks.OutputDir = strings.ReplaceAll(cfg.ConfigPath, ".yaml", "") + ".hooks"
+	}
+	if err := fileutil.IsDirWriteable(filepath.Dir(cfg.Hooks.OutputDir)); err != nil {
+		return err
+	}
+
+	names := make(map[string]struct{})
+	for _, phase := range []string{HookPhasePreCreate, HookPhasePostCreate, HookPhasePreDelete, HookPhasePostDelete} {
+		hooks := cfg.Hooks.Phase(phase)
+		for idx := range hooks {
+			h := &hooks[idx]
+			if h.Name == "" {
+				h.Name = fmt.Sprintf("%s-%02d", phase, idx)
+			}
+			field := fmt.Sprintf("Hooks %s %q", phase, h.Name)
+			if !hookNameRegex.MatchString(h.Name) {
+				return fmt.Errorf("%s invalid name", field)
+			}
+			if _, ok := names[phase+"/"+h.Name]; ok {
+				return fmt.Errorf("%s duplicate name", field)
+			}
+			names[phase+"/"+h.Name] = struct{}{}
+
+			switch {
+			case h.Command == "" && h.NodeScript == "":
+				return fmt.Errorf("%s has neither Command nor NodeScript", field)
+			case h.Command != "" && h.NodeScript != "":
+				return fmt.Errorf("%s has both Command and NodeScript", field)
+			case h.NodeScript != "":
+				if phase == HookPhasePreCreate || phase == HookPhasePostDelete {
+					return fmt.Errorf("%s NodeScript not supported without nodes", field)
+				}
+				if !cfg.IsEnabledAddOnNodeGroups() && !cfg.IsEnabledAddOnManagedNodeGroups() {
+					return fmt.Errorf("%s NodeScript but no node group is enabled", field)
+				}
+				if err := cfg.validateNodeTransport(field+" Transport", &h.Transport); err != nil {
+					return err
+				}
+			default:
+				if h.NodeGroupName != "" || h.Transport != "" {
+					return errors.New(field + " NodeGroupName or Transport set for Command")
+				}
+			}
+
+			if h.Timeout == time.Duration(0) {
+				h.Timeout = DefaultHookTimeout
+			}
+			h.TimeoutString = h.Timeout.String()
+			h.OutputPath = filepath.Join(cfg.Hooks.OutputDir, phase+"-"+h.Name+".out.log")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package eksconfig
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ImageMirror defines the air-gapped image mirroring mode, which replicates
+// every container image referenced by the enabled add-ons to the private
+// ECR repositories in the cluster region before any add-on is created,
+// and rewrites the add-on images to pull from them.
+// Use for the accounts or VPCs that block the public registries.
+// The images inside the Helm charts and the remote manifests are not mirrored.
+type ImageMirror struct {
+	// Enable is 'true' to mirror the images.
+	Enable bool `json:"enable"`
+	// RepositoryPrefix is the prefix of the ECR repository names, followed by
+	// the source repository path (e.g. "[PREFIX]/library/busybox").
+	RepositoryPrefix string `json:"repository-prefix"`
+
+	// Images maps the source images to the mirrored images.
+	Images map[string]string `json:"images" read-only:"true"`
+	// Repositories is the list of the created ECR repositories.
+	// Deleted with the images on "Down".
+	Repositories []string `json:"repositories" read-only:"true"`
+}
+
+const (
+	// DefaultPauseImage is the image of the placeholder workload Pods.
+	DefaultPauseImage = "public.ecr.aws/eks-distro/kubernetes/pause:3.2"
+	// DefaultBusyboxImage is the image of the lightweight workload and probe Pods.
+	DefaultBusyboxImage = "public.ecr.aws/docker/library/busybox:1.36"
+	// DefaultCurlImage is the image of the HTTP client Pods.
+	DefaultCurlImage = "curlimages/curl:8.4.0"
+)
+
+func getDefaultImageMirror() *ImageMirror {
+	return &ImageMirror{Enable: false}
+}
+
+func (cfg *Config) validateImageMirror() error {
+	if cfg.ImageMirror == nil {
+		cfg.ImageMirror = getDefaultImageMirror()
+	}
+	if !cfg.ImageMirror.Enable {
+		return nil
+	}
+	if cfg.ImageMirror.RepositoryPrefix == "" {
+		cfg.ImageMirror.RepositoryPrefix = strings.ToLower(cfg.Name) + "-mirror"
+	}
+	if !ecrRepositoryNameRegex.MatchString(cfg.ImageMirror.RepositoryPrefix) {
+		return fmt.Errorf("ImageMirror.RepositoryPrefix %q invalid", cfg.ImageMirror.RepositoryPrefix)
+	}
+	return nil
+}
+
+// Image returns the mirrored image of the source image,
+// or the source image if not mirrored.
+func (cfg *Config) Image(img string) string {
+	if cfg.ImageMirror == nil || !cfg.ImageMirror.Enable {
+		return img
+	}
+	if mirrored, ok := cfg.ImageMirror.Images[img]; ok {
+		return mirrored
+	}
+	return img
+}
+
+// Images returns the sorted list of the container images referenced by
+// the enabled add-ons: the add-on "*Image" fields and the images
+// hardcoded in the testers. The mirrored images are listed by their sources.
+// Call after "ValidateAndSetDefaults" to include the default images.
+func (cfg *Config) Images() []string {
+	sources := make(map[string]string)
+	if cfg.ImageMirror != nil {
+		for src, mirrored := range cfg.ImageMirror.Images {
+			sources[mirrored] = src
+		}
+	}
+	seen := make(map[string]struct{})
+	add := func(img string) {
+		if src, ok := sources[img]; ok {
+			img = src
+		}
+		seen[img] = struct{}{}
+	}
+	cfg.walkImageFields(func(fv reflect.Value) { add(fv.String()) })
+	for _, img := range cfg.testerImages() {
+		add(img)
+	}
+
+	imgs := make([]string, 0, len(seen))
+	for img := range seen {
+		imgs = append(imgs, img)
+	}
+	sort.Strings(imgs)
+	return imgs
+}
+
+// RewriteImages sets the add-on "*Image" fields to the mirrored images.
+// The testers resolve their hardcoded images with "Image".
+func (cfg *Config) RewriteImages() {
+	if cfg.ImageMirror == nil || !cfg.ImageMirror.Enable {
+		return
+	}
+	cfg.walkImageFields(func(fv reflect.Value) {
+		if mirrored, ok := cfg.ImageMirror.Images[fv.String()]; ok {
+			fv.SetString(mirrored)
+		}
+	})
+}
+
+// walkImageFields calls the function with each non-empty "*Image" string
+// field of the enabled add-ons, including the nested structs.
+// "AddOnECR" is skipped, since its source image is replicated by itself.
+func (cfg *Config) walkImageFields(fn func(fv reflect.Value)) {
+	cv := reflect.ValueOf(cfg).Elem()
+	ct := cv.Type()
+	for i := 0; i < ct.NumField(); i++ {
+		name := ct.Field(i).Name
+		if !strings.HasPrefix(name, "AddOn") || name == "AddOnECR" {
+			continue
+		}
+		fv := cv.Field(i)
+		if fv.Kind() != reflect.Ptr || fv.IsNil() || fv.Elem().Kind() != reflect.Struct {
+			continue
+		}
+		if en := fv.Elem().FieldByName("Enable"); !en.IsValid() || en.Kind() != reflect.Bool || !en.Bool() {
+			continue
+		}
+		walkImageStruct(fv.Elem(), fn)
+	}
+}
+
+func walkImageStruct(sv reflect.Value, fn func(fv reflect.Value)) {
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		sf, fv := st.Field(i), sv.Field(i)
+		if sf.PkgPath != "" || sf.Tag.Get("read-only") == "true" {
+			continue
+		}
+		switch fv.Kind() {
+		case reflect.String:
+			if strings.HasSuffix(sf.Name, "Image") && fv.String() != "" {
+				fn(fv)
+			}
+		case reflect.Struct:
+			walkImageStruct(fv, fn)
+		case reflect.Ptr:
+			if !fv.IsNil() && fv.Elem().Kind() == reflect.Struct {
+				walkImageStruct(fv.Elem(), fn)
+			}
+		case reflect.Map:
+			// e.g. "AddOnManagedNodeGroups.MNGs"
+			if fv.Type().Elem().Kind() != reflect.Struct {
+				continue
+			}
+			for _, k := range fv.MapKeys() {
+				// map values are not addressable, so copy and store back
+				ev := reflect.New(fv.Type().Elem()).Elem()
+				ev.Set(fv.MapIndex(k))
+				walkImageStruct(ev, fn)
+				fv.SetMapIndex(k, ev)
+			}
+		}
+	}
+}
+
+// testerImages returns the images hardcoded in the testers of the enabled add-ons.
+func (cfg *Config) testerImages() (imgs []string) {
+	if cfg.IsEnabledAddOnFluentd() && cfg.AddOnFluentd.RepositoryBusyboxName == "" {
+		imgs = append(imgs, "busybox")
+	}
+	if cfg.IsEnabledAddOnJobsEcho() && cfg.AddOnJobsEcho.RepositoryBusyboxName == "" {
+		imgs = append(imgs, "busybox")
+	}
+	if cfg.IsEnabledAddOnCronJobs() && cfg.AddOnCronJobs.RepositoryBusyboxName == "" {
+		imgs = append(imgs, "busybox")
+	}
+	if cfg.IsEnabledAddOnFargate() && cfg.AddOnFargate.RepositoryName == "" {
+		imgs = append(imgs, "amazonlinux:latest")
+	}
+	if cfg.IsEnabledAddOnCUDAVectorAdd() {
+		imgs = append(imgs, "k8s.gcr.io/cuda-vector-add:v0.1")
+	}
+	if cfg.IsEnabledAddOnAmiSoftLockupIssue454() {
+		imgs = append(imgs, "centos:7")
+	}
+	if (cfg.Spec.ClusterAutoscaler != nil && cfg.Spec.ClusterAutoscaler.ScaleTest != nil) ||
+		cfg.IsEnabledAddOnKarpenter() ||
+		cfg.IsEnabledAddOnChaos() ||
+		cfg.IsEnabledAddOnNodeFault() ||
+		cfg.IsEnabledAddOnSpotInterruption() {
+		imgs = append(imgs, DefaultPauseImage)
+	}
+	if cfg.IsEnabledAddOnChaos() && cfg.AddOnChaos.IsNetem() {
+		imgs = append(imgs, DefaultBusyboxImage)
+	}
+	if cfg.IsEnabledAddOnManagedNodeGroups() {
+		for _, cur := range cfg.AddOnManagedNodeGroups.MNGs {
+			if cur.VersionUpgrade != nil && cur.VersionUpgrade.Enable {
+				imgs = append(imgs, DefaultPauseImage)
+				break
+			}
+		}
+	}
+	if cfg.IPFamily == IPFamilyIPv6 {
+		imgs = append(imgs, DefaultBusyboxImage, DefaultCurlImage)
+	}
+	if cfg.SoakDuration > 0 {
+		imgs = append(imgs, DefaultBusyboxImage)
+	}
+	if cfg.IsEnabledAddOnCNIVersionMatrix() {
+		imgs = append(imgs, DefaultPauseImage, DefaultBusyboxImage)
+	}
+	if cfg.IsEnabledAddOnPodDensity() || cfg.IsEnabledAddOnKubeProxyModes() || cfg.IsEnabledAddOnCoreDNSScale() || cfg.IsEnabledAddOnServiceChurn() {
+		imgs = append(imgs, DefaultBusyboxImage)
+	}
+	return imgs
+}
@@ -0,0 +1,150 @@
+package eksconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// APIVersion is the schema version of the configuration,
+	// written as "apiVersion". Bump it with a new migration
+	// whenever the configuration changes incompatibly.
+	APIVersion = "eksconfig.aws-k8s-tester/v1"
+	// Kind is the kind of the configuration, written as "kind".
+	Kind = "Config"
+)
+
+// migration upgrades the decoded configuration from one schema version
+// to the next. The configuration without "apiVersion" is the legacy one,
+// written before the schema was versioned.
+type migration struct {
+	from    string
+	to      string
+	migrate func(m map[string]interface{}) error
+}
+
+// migrations are applied in order, from the oldest version.
+var migrations = []migration{
+	{from: "", to: APIVersion, migrate: migrateLegacy},
+}
+
+// Migrate upgrades the configuration YAML to the current "APIVersion".
+// It returns the input as it is if already current, and returns the
+// applied migrations (e.g. "(legacy) -> eksconfig.aws-k8s-tester/v1").
+// The configuration of the unknown or newer version is rejected,
+// rather than loaded with the unknown fields dropped.
+func Migrate(d []byte) (out []byte, applied []string, err error) {
+	var tm struct {
+		APIVersion string `json:"apiVersion"`
+	}
+	if err = yaml.Unmarshal(d, &tm); err != nil {
+		return nil, nil, err
+	}
+	if tm.APIVersion == APIVersion {
+		return d, nil, nil
+	}
+
+	idx := -1
+	for i, mg := range migrations {
+		if mg.from == tm.APIVersion {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return nil, nil, fmt.Errorf("unknown apiVersion %q (supported %q, upgrade aws-k8s-tester if the configuration is written by the newer version)", tm.APIVersion, APIVersion)
+	}
+
+	m := make(map[string]interface{})
+	if err = yaml.Unmarshal(d, &m); err != nil {
+		return nil, nil, err
+	}
+	for _, mg := range migrations[idx:] {
+		if err = mg.migrate(m); err != nil {
+			return nil, nil, fmt.Errorf("failed to migrate apiVersion %q to %q (%v)", versionString(mg.from), mg.to, err)
+		}
+		m["apiVersion"] = mg.to
+		applied = append(applied, versionString(mg.from)+" -> "+mg.to)
+	}
+	m["kind"] = Kind
+
+	out, err = yaml.Marshal(m)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, applied, nil
+}
+
+func versionString(v string) string {
+	if v == "" {
+		return "(legacy)"
+	}
+	return v
+}
+
+// legacyParameters maps the fields of the legacy "parameters" section
+// to their current paths.
+var legacyParameters = map[string]string{
+	"version":                          "version",
+	"tags":                             "tags",
+	"request-header-key":               "request-header-key",
+	"request-header-value":             "request-header-value",
+	"resolver-url":                     "resolver-url",
+	"signing-name":                     "signing-name",
+	"role-name":                        "role.name",
+	"role-create":                      "role.create",
+	"role-arn":                         "role.arn",
+	"role-service-principals":          "role.service-principals",
+	"role-managed-policy-arns":         "role.managed-policy-arns",
+	"vpc-create":                       "vpc.create",
+	"vpc-id":                           "vpc.id",
+	"vpc-cidrs":                        "vpc.cidrs",
+	"public-subnet-cidrs":              "vpc.public-subnet-cidrs",
+	"private-subnet-cidrs":             "vpc.private-subnet-cidrs",
+	"dhcp-options-domain-name":         "vpc.dhcp-options-domain-name",
+	"dhcp-options-domain-name-servers": "vpc.dhcp-options-domain-name-servers",
+	"encryption-cmk-create":            "encryption.cmk-create",
+	"encryption-cmk-arn":               "encryption.cmk-arn",
+}
+
+// migrateLegacy moves the fields of the legacy "parameters" section
+// to the top-level and the "role", "vpc" and "encryption" sections.
+// The field set in both places keeps the current one.
+func migrateLegacy(m map[string]interface{}) error {
+	v, ok := m["parameters"]
+	if !ok {
+		return nil
+	}
+	delete(m, "parameters")
+	if v == nil {
+		return nil
+	}
+	params, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected 'parameters' section, got %T", v)
+	}
+	for k, pv := range params {
+		to, ok := legacyParameters[k]
+		if !ok {
+			return fmt.Errorf("unknown legacy field 'parameters.%s'", k)
+		}
+		dst, key := m, to
+		if i := strings.Index(to, "."); i > 0 {
+			sec := to[:i]
+			if m[sec] == nil {
+				m[sec] = make(map[string]interface{})
+			}
+			dst, ok = m[sec].(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("expected %q section, got %T", sec, m[sec])
+			}
+			key = to[i+1:]
+		}
+		if _, ok = dst[key]; !ok {
+			dst[key] = pv
+		}
+	}
+	return nil
+}
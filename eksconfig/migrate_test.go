@@ -0,0 +1,84 @@
+package eksconfig
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMigrateLegacy(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "config.yaml")
+	legacy := `name: test
+region: us-west-2
+parameters:
+  version: "1.21"
+  role-create: false
+  role-arn: arn:aws:iam::123:role/test
+  vpc-create: false
+  vpc-id: vpc-123
+  public-subnet-cidrs: [192.168.64.0/19]
+  tags:
+    team: test
+vpc:
+  id: vpc-current
+`
+	if err := ioutil.WriteFile(p, []byte(legacy), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.APIVersion != APIVersion || cfg.Kind != Kind {
+		t.Fatalf("unexpected type meta %+v", cfg.TypeMeta)
+	}
+	if cfg.Version != "1.21" {
+		t.Fatalf("unexpected Version %q", cfg.Version)
+	}
+	if cfg.Role == nil || cfg.Role.Create || cfg.Role.ARN != "arn:aws:iam::123:role/test" {
+		t.Fatalf("unexpected Role %+v", cfg.Role)
+	}
+	// current field is kept
+	if cfg.VPC == nil || cfg.VPC.ID != "vpc-current" || !reflect.DeepEqual(cfg.VPC.PublicSubnetCIDRs, []string{"192.168.64.0/19"}) {
+		t.Fatalf("unexpected VPC %+v", cfg.VPC)
+	}
+	if cfg.Tags["team"] != "test" {
+		t.Fatalf("unexpected Tags %v", cfg.Tags)
+	}
+
+	// written back with the current version
+	d, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(d), "apiVersion: "+APIVersion) || strings.Contains(string(d), "parameters:") {
+		t.Fatalf("unexpected migrated configuration\n%s", string(d))
+	}
+	out, applied, err := Migrate(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 0 || string(out) != string(d) {
+		t.Fatalf("unexpected migration of current configuration %v", applied)
+	}
+}
+
+func TestMigrateErrors(t *testing.T) {
+	tt := []struct {
+		d   string
+		err string
+	}{
+		{"apiVersion: eksconfig.aws-k8s-tester/v99\nname: test\n", `unknown apiVersion "eksconfig.aws-k8s-tester/v99"`},
+		{"name: test\nparameters:\n  unknown-field: true\n", `unknown legacy field 'parameters.unknown-field'`},
+		{"name: test\nvpc: vpc-123\nparameters:\n  vpc-id: vpc-123\n", `expected "vpc" section`},
+	}
+	for i, tv := range tt {
+		_, _, err := Migrate([]byte(tv.d))
+		if err == nil || !strings.Contains(err.Error(), tv.err) {
+			t.Fatalf("#%d: expected error %q, got %v", i, tv.err, err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package eksconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+// Notifications defines the sinks that receive the run events
+// (e.g. cluster ready, add-on failed, teardown complete).
+// The events are not sent if no sink is configured.
+type Notifications struct {
+	// SlackWebhookURL is the Slack incoming webhook URL.
+	// The rendered "MessageTemplate" is posted as the message text.
+	SlackWebhookURL string `json:"slack-webhook-url"`
+	// SNSTopicARN is the SNS topic ARN to publish the rendered "MessageTemplate".
+	SNSTopicARN string `json:"sns-topic-arn"`
+	// HTTPEndpoint is the generic HTTP endpoint to POST
+	// the rendered "HTTPPayloadTemplate".
+	HTTPEndpoint string `json:"http-endpoint"`
+
+	// MessageTemplate is the Go text template of the Slack and SNS messages,
+	// executed with the event (e.g. "{{.Cluster}}", "{{.Type}}", "{{.Message}}",
+	// "{{.Error}}", "{{.Region}}", "{{.Time}}").
+	MessageTemplate string `json:"message-template"`
+	// HTTPPayloadTemplate is the Go text template of the HTTP request body,
+	// executed with the event. Use "{{json .}}" to encode the whole event.
+	HTTPPayloadTemplate string `json:"http-payload-template"`
+
+	// Events is the list of the event types to send.
+	// Leave empty to send all events.
+	Events []string `json:"events"`
+}
+
+const (
+	// NotificationEventClusterReady is sent once the cluster is created and reachable.
+	NotificationEventClusterReady = "cluster-ready"
+	// NotificationEventAddOnFailed is sent when an add-on fails to create.
+	NotificationEventAddOnFailed = "add-on-failed"
+	// NotificationEventUpSucceeded is sent when all resources are created.
+	NotificationEventUpSucceeded = "up-succeeded"
+	// NotificationEventUpFailed is sent when the creation fails.
+	NotificationEventUpFailed = "up-failed"
+	// NotificationEventTeardownComplete is sent when the deletion finishes,
+	// with the error if any resource failed to delete.
+	NotificationEventTeardownComplete = "teardown-complete"
+)
+
+// NotificationEventTypes is the set of valid notification event types.
+var NotificationEventTypes = map[string]struct{}{
+	NotificationEventClusterReady:     {},
+	NotificationEventAddOnFailed:      {},
+	NotificationEventUpSucceeded:      {},
+	NotificationEventUpFailed:         {},
+	NotificationEventTeardownComplete: {},
+}
+
+const (
+	// DefaultNotificationMessageTemplate is the default Slack and SNS message.
+	DefaultNotificationMessageTemplate = `[{{.Cluster}}] {{.Type}}: {{.Message}}{{if .Error}} ({{.Error}}){{end}}`
+	// DefaultNotificationHTTPPayloadTemplate is the default HTTP request body.
+	DefaultNotificationHTTPPayloadTemplate = `{{json .}}`
+)
+
+func getDefaultNotifications() *Notifications {
+	return &Notifications{
+		MessageTemplate:     DefaultNotificationMessageTemplate,
+		HTTPPayloadTemplate: DefaultNotificationHTTPPayloadTemplate,
+	}
+}
+
+// NotificationTemplateFuncs is the functions available to the notification templates.
+var NotificationTemplateFuncs = template.FuncMap{
+	// json encodes the value, or the event as a whole with "{{json .}}"
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// IsEnabled returns true if any notification sink is configured.
+func (n *Notifications) IsEnabled() bool {
+	return n != nil && (n.SlackWebhookURL != "" || n.SNSTopicARN != "" || n.HTTPEndpoint != "")
+}
+
+// IsEventEnabled returns true if the event type is sent.
+func (n *Notifications) IsEventEnabled(typ string) bool {
+	if !n.IsEnabled() {
+		return false
+	}
+	if len(n.Events) == 0 {
+		return true
+	}
+	for _, ev := range n.Events {
+		if ev == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func (cfg *Config) validateNotifications() error {
+	if cfg.Notifications == nil {
+		cfg.Notifications = getDefaultNotifications()
+	}
+	for _, ev := range cfg.Notifications.Events {
+		if _, ok := NotificationEventTypes[ev]; !ok {
+			return fmt.Errorf("unknown Notifications.Events %q", ev)
+		}
+	}
+	if cfg.Notifications.MessageTemplate == "" {
+		cfg.Notifications.MessageTemplate = DefaultNotificationMessageTemplate
+	}
+	if cfg.Notifications.HTTPPayloadTemplate == "" {
+		cfg.Notifications.HTTPPayloadTemplate = DefaultNotificationHTTPPayloadTemplate
+	}
+	// fail before the run rather than on the first event
+	if _, err := template.New("message").Funcs(NotificationTemplateFuncs).Parse(cfg.Notifications.MessageTemplate); err != nil {
+		return fmt.Errorf("invalid Notifications.MessageTemplate (%v)", err)
+	}
+	if _, err := template.New("http-payload").Funcs(NotificationTemplateFuncs).Parse(cfg.Notifications.HTTPPayloadTemplate); err != nil {
+		return fmt.Errorf("invalid Notifications.HTTPPayloadTemplate (%v)", err)
+	}
+	return nil
+}
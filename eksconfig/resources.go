@@ -0,0 +1,30 @@
+package eksconfig
+
+// RunTagKey is the tag key of the AWS resources created by the tester,
+// with the cluster name "Config.Name" as the value.
+// Used to track the created resources, and to find the leaked resources
+// after the cluster deletion.
+const RunTagKey = "aws-k8s-tester-run"
+
+// KindTagKey and KindTagValue tag the AWS resources created by the tester,
+// regardless of the run. Used by the janitor to find the stale resources.
+const (
+	KindTagKey   = "Kind"
+	KindTagValue = "aws-k8s-tester"
+)
+
+// CreatedTagKey is the tag key of the creation time in RFC3339 format,
+// for the resources without the creation timestamp (e.g. EC2 key pairs).
+const CreatedTagKey = "aws-k8s-tester-created"
+
+// AWSResource is an AWS resource created by the tester.
+type AWSResource struct {
+	// Service is the AWS service (e.g. "ec2", "elasticloadbalancing", "iam").
+	Service string `json:"service"`
+	// Type is the resource type (e.g. "vpc", "loadbalancer", "role").
+	Type string `json:"type"`
+	// ID is the resource ID or name.
+	ID string `json:"id"`
+	// ARN is the resource ARN, empty for S3 buckets.
+	ARN string `json:"arn,omitempty"`
+}
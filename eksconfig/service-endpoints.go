@@ -0,0 +1,109 @@
+package eksconfig
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	pkg_aws "github.com/aws/aws-k8s-tester/pkg/aws"
+)
+
+// ServiceEndpoints defines the custom AWS service endpoints, overriding
+// the partition endpoints (e.g. to smoke test the tester against LocalStack).
+// Leave empty to use the endpoints of the "Partition".
+type ServiceEndpoints struct {
+	// EKS is the EKS API endpoint.
+	// Same as "ResolverURL", which is set to this value if empty.
+	EKS string `json:"eks"`
+	// EC2 is the EC2 API endpoint.
+	EC2 string `json:"ec2"`
+	// S3 is the S3 API endpoint.
+	S3 string `json:"s3"`
+	// CloudFormation is the CloudFormation API endpoint.
+	CloudFormation string `json:"cloudformation"`
+	// STS is the STS API endpoint.
+	STS string `json:"sts"`
+
+	// S3ForcePathStyle is true to use the path-style S3 requests,
+	// required by most S3 compatible endpoints (e.g. LocalStack).
+	S3ForcePathStyle bool `json:"s3-force-path-style"`
+}
+
+func getDefaultServiceEndpoints() *ServiceEndpoints {
+	return &ServiceEndpoints{}
+}
+
+// AWSEndpoints returns the service ID to endpoint map for "pkg/aws" sessions,
+// or nil if no endpoint is overridden.
+func (se *ServiceEndpoints) AWSEndpoints() map[string]string {
+	if se == nil {
+		return nil
+	}
+	m := make(map[string]string)
+	for id, u := range map[string]string{
+		"eks":            se.EKS,
+		"ec2":            se.EC2,
+		"s3":             se.S3,
+		"cloudformation": se.CloudFormation,
+		"sts":            se.STS,
+	} {
+		if u != "" {
+			m[id] = u
+		}
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+// validatePartition checks the partition is consistent with the region,
+// and rewrites the default "aws" partition policy ARNs to the partition.
+func (cfg *Config) validatePartition() error {
+	if cfg.Partition == "" {
+		cfg.Partition = "aws"
+	}
+	if _, err := pkg_aws.Regions(cfg.Partition); err != nil {
+		return fmt.Errorf("invalid Partition (%v)", err)
+	}
+	// unknown regions (e.g. LocalStack) are not checked
+	if p, ok := pkg_aws.PartitionForRegion(cfg.Region); ok && p != cfg.Partition {
+		if cfg.Partition != "aws" {
+			return fmt.Errorf("Partition %q does not match Region %q (expected %q)", cfg.Partition, cfg.Region, p)
+		}
+		fmt.Fprintf(os.Stderr, "[WARN] Partition is overwritten with %q for Region %q\n", p, cfg.Region)
+		cfg.Partition = p
+	}
+
+	if cfg.Role != nil {
+		for i, arn := range cfg.Role.ManagedPolicyARNs {
+			cfg.Role.ManagedPolicyARNs[i] = pkg_aws.ToPartitionARN(cfg.Partition, arn)
+		}
+	}
+	return nil
+}
+
+func (cfg *Config) validateServiceEndpoints() error {
+	if cfg.ServiceEndpoints == nil {
+		cfg.ServiceEndpoints = getDefaultServiceEndpoints()
+	}
+	for id, u := range cfg.ServiceEndpoints.AWSEndpoints() {
+		pu, err := url.Parse(u)
+		if err != nil {
+			return fmt.Errorf("invalid ServiceEndpoints %q endpoint %q (%v)", id, u, err)
+		}
+		if pu.Scheme != "http" && pu.Scheme != "https" {
+			return fmt.Errorf("invalid ServiceEndpoints %q endpoint %q (expected 'http' or 'https' scheme)", id, u)
+		}
+	}
+	if cfg.ServiceEndpoints.EKS != "" {
+		switch cfg.ResolverURL {
+		case "":
+			cfg.ResolverURL = cfg.ServiceEndpoints.EKS
+		case cfg.ServiceEndpoints.EKS:
+		default:
+			return fmt.Errorf("ServiceEndpoints.EKS %q does not match ResolverURL %q", cfg.ServiceEndpoints.EKS, cfg.ResolverURL)
+		}
+	}
+	return nil
+}
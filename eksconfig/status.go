@@ -22,6 +22,17 @@ type Status struct {
 
 	// ServerVersionInfo is the server version from EKS kube-apiserver.
 	ServerVersionInfo k8s_client.ServerVersionInfo `json:"server-version-info" read-only:"true"`
+	// APIServerAvailability is the kube-apiserver availability sampled over the run,
+	// and the client requests throttled by kube-apiserver.
+	APIServerAvailability *k8s_client.Availability `json:"api-server-availability,omitempty" read-only:"true"`
+	// HealthSnapshot is the latest cluster health snapshot,
+	// taken before and after each add-on.
+	HealthSnapshot *k8s_client.HealthSnapshot `json:"health-snapshot,omitempty" read-only:"true"`
+
+	// SoakIterations is the number of soak iterations run after "Up".
+	SoakIterations int `json:"soak-iterations,omitempty" read-only:"true"`
+	// SoakFailures is the number of failed soak iterations.
+	SoakFailures int `json:"soak-failures,omitempty" read-only:"true"`
 
 	// AWSAccountID is the account ID of the eks tester caller session.
 	AWSAccountID string `json:"aws-account-id"`
@@ -54,6 +65,10 @@ type Status struct {
 	// ClusterCADecoded is the decoded EKS cluster CA, required for k8s.io/client-go.
 	ClusterCADecoded string `json:"cluster-ca-decoded"`
 
+	// ClusterServiceIPv6CIDR is the IPv6 CIDR block of the cluster Services,
+	// assigned by EKS when IPFamily is "ipv6".
+	ClusterServiceIPv6CIDR string `json:"cluster-service-ipv6-cidr"`
+
 	// ClusterStatusCurrent represents the current status of the cluster.
 	ClusterStatusCurrent string `json:"cluster-status-current"`
 	// ClusterStatus represents the status of the cluster.
@@ -67,6 +82,20 @@ type Status struct {
 	MetricsServer *MetricsServerStatus `json:"metricsServer,omitempty"`
 	// ClusterLoader defines the addon's status
 	ClusterLoader *ClusterLoaderStatus `json:"clusterLoader,omitempty"`
+	// FSxLustre is the FSx for Lustre benchmark result.
+	FSxLustre *FSxLustreStatus `json:"fsx-lustre,omitempty"`
+	// Cost is the estimated cost of the created AWS resources.
+	Cost *CostStatus `json:"cost,omitempty"`
+	// Resources is the inventory of the AWS resources created by the tester,
+	// recorded after the cluster creation.
+	Resources []AWSResource `json:"resources,omitempty"`
+	// CFNStackDrifts is the drift detection result of the CloudFormation
+	// stacks created by the tester, to find the manual changes
+	// (e.g. edited in the console while debugging).
+	CFNStackDrifts []CFNStackDrift `json:"cfn-stack-drifts,omitempty"`
+	// HelmReleases maps the "[NAMESPACE]/[NAME]" of the helm releases
+	// installed by the add-ons to the latest release status.
+	HelmReleases map[string]HelmRelease `json:"helm-releases,omitempty" read-only:"true"`
 
 	// PrivateDNSToNodeInfo maps each worker node's private IP to its public IP,
 	// public DNS, and SSH access user name.
@@ -75,6 +104,11 @@ type Status struct {
 	PrivateDNSToNodeInfo map[string]NodeInfo `json:"private-dns-to-node-info"`
 
 	DeletedResources map[string]string `json:"deleted-resources"`
+
+	// CompletedSteps maps the "Up" steps completed by the previous runs
+	// to the completion time, so that the interrupted "Up" is resumed
+	// by skipping the completed steps. Reset on the cluster deletion.
+	CompletedSteps map[string]time.Time `json:"completed-steps,omitempty" read-only:"true"`
 }
 
 // NodeInfo represents basic SSH access configuration for worker nodes.
@@ -94,6 +128,27 @@ func (sc NodeInfo) ToString() string {
 	return string(b)
 }
 
+// CFNStackDrift is the drift detection result of a CloudFormation stack.
+type CFNStackDrift struct {
+	StackID string `json:"stack-id"`
+	// DriftStatus is "DRIFTED", "IN_SYNC", "UNKNOWN", or "NOT_CHECKED".
+	DriftStatus string    `json:"drift-status"`
+	DetectedAt  time.Time `json:"detected-at"`
+	// DriftedResources is the list of modified or deleted stack resources.
+	DriftedResources []CFNResourceDrift `json:"drifted-resources,omitempty"`
+	// Error is the drift detection error, if failed.
+	Error string `json:"error,omitempty"`
+}
+
+// CFNResourceDrift is the drift of a CloudFormation stack resource.
+type CFNResourceDrift struct {
+	LogicalResourceID  string `json:"logical-resource-id"`
+	PhysicalResourceID string `json:"physical-resource-id"`
+	ResourceType       string `json:"resource-type"`
+	// DriftStatus is "MODIFIED" or "DELETED".
+	DriftStatus string `json:"drift-status"`
+}
+
 /*
 map all private IPs to public IP + public DNS
 map node name to internal ip, private ip
@@ -148,3 +203,81 @@ func (cfg *Config) RecordStatus(status string) {
 	cfg.Status.ClusterStatus = copied
 	cfg.unsafeSync()
 }
+
+// HelmRelease is the status of a helm release.
+type HelmRelease struct {
+	Name         string    `json:"name"`
+	Namespace    string    `json:"namespace"`
+	Chart        string    `json:"chart"`
+	ChartVersion string    `json:"chart-version"`
+	AppVersion   string    `json:"app-version"`
+	Revision     int       `json:"revision"`
+	Status       string    `json:"status"`
+	Updated      time.Time `json:"updated"`
+}
+
+// RecordHelmRelease records the helm release status, and persists to disk.
+func (cfg *Config) RecordHelmRelease(rs HelmRelease) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+
+	if cfg.Status == nil {
+		cfg.Status = &Status{}
+	}
+	if cfg.Status.HelmReleases == nil {
+		cfg.Status.HelmReleases = make(map[string]HelmRelease)
+	}
+	cfg.Status.HelmReleases[rs.Namespace+"/"+rs.Name] = rs
+	cfg.unsafeSync()
+}
+
+// RecordAddOnStatus runs "update" to record the add-on status
+// (e.g. "Created", "TimeFrameCreate", "Status.DeletedResources"),
+// and persists to disk. The add-ons in the same group are applied
+// concurrently, so the status must not be written outside of the lock.
+func (cfg *Config) RecordAddOnStatus(update func()) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+
+	update()
+	cfg.unsafeSync()
+}
+
+// IsStepCompleted returns true if the "Up" step is completed by the previous run.
+func (cfg *Config) IsStepCompleted(step string) bool {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+
+	if cfg.Status == nil {
+		return false
+	}
+	_, ok := cfg.Status.CompletedSteps[step]
+	return ok
+}
+
+// RecordStepCompleted records the "Up" step completion, and persists to disk.
+func (cfg *Config) RecordStepCompleted(step string) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+
+	if cfg.Status == nil {
+		cfg.Status = &Status{}
+	}
+	if cfg.Status.CompletedSteps == nil {
+		cfg.Status.CompletedSteps = make(map[string]time.Time)
+	}
+	cfg.Status.CompletedSteps[step] = time.Now().UTC()
+	cfg.unsafeSync()
+}
+
+// ResetCompletedSteps resets the "Up" step completions, and persists to disk.
+func (cfg *Config) ResetCompletedSteps() {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+
+	if cfg.Status == nil {
+		return
+	}
+	cfg.Status.CompletedSteps = nil
+	cfg.unsafeSync()
+}
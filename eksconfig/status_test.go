@@ -0,0 +1,56 @@
+package eksconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompletedSteps(t *testing.T) {
+	cfg := NewDefault()
+	cfg.ConfigPath = filepath.Join(t.TempDir(), "config.yaml")
+
+	if cfg.IsStepCompleted("createS3") {
+		t.Fatal("unexpected completed step")
+	}
+	cfg.RecordStepCompleted("createS3")
+	if !cfg.IsStepCompleted("createS3") {
+		t.Fatal("expected completed step")
+	}
+	if _, err := os.Stat(cfg.ConfigPath); err != nil {
+		t.Fatalf("expected synced configuration (%v)", err)
+	}
+
+	loaded, err := Load(cfg.ConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.IsStepCompleted("createS3") {
+		t.Fatal("expected completed step after reload")
+	}
+
+	cfg.ResetCompletedSteps()
+	if cfg.IsStepCompleted("createS3") {
+		t.Fatal("unexpected completed step after reset")
+	}
+}
+
+func TestHelmReleases(t *testing.T) {
+	cfg := NewDefault()
+	cfg.ConfigPath = filepath.Join(t.TempDir(), "config.yaml")
+
+	cfg.RecordHelmRelease(HelmRelease{Name: "karpenter", Namespace: "karpenter", Chart: "karpenter", Revision: 1, Status: "deployed"})
+	cfg.RecordHelmRelease(HelmRelease{Name: "karpenter", Namespace: "karpenter", Chart: "karpenter", Revision: 2, Status: "failed"})
+
+	loaded, err := Load(cfg.ConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, ok := loaded.Status.HelmReleases["karpenter/karpenter"]
+	if !ok || len(loaded.Status.HelmReleases) != 1 {
+		t.Fatalf("unexpected helm releases %+v", loaded.Status.HelmReleases)
+	}
+	if rs.Revision != 2 || rs.Status != "failed" {
+		t.Fatalf("expected the latest release status, got %+v", rs)
+	}
+}
@@ -0,0 +1,45 @@
+package aws
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+)
+
+// ARN returns the ARN in the partition.
+// e.g. "arn:aws-cn:elasticloadbalancing:cn-north-1:123:loadbalancer/net/name/123"
+// ref. https://docs.aws.amazon.com/general/latest/gr/aws-arns-and-namespaces.html
+func ARN(partition, service, region, accountID, resource string) string {
+	return strings.Join([]string{"arn", partition, service, region, accountID, resource}, ":")
+}
+
+// PolicyARN returns the AWS managed policy ARN in the partition.
+// e.g. "arn:aws-us-gov:iam::aws:policy/AmazonEKSClusterPolicy"
+func PolicyARN(partition, name string) string {
+	return ARN(partition, "iam", "", "aws", "policy/"+name)
+}
+
+// ToPartitionARN rewrites the "aws" partition ARN to the partition,
+// and returns the other ARNs as is.
+// e.g. "arn:aws:iam::aws:policy/AmazonEKSClusterPolicy" to
+// "arn:aws-cn:iam::aws:policy/AmazonEKSClusterPolicy"
+func ToPartitionARN(partition, arn string) string {
+	if partition == "" || partition == endpoints.AwsPartitionID {
+		return arn
+	}
+	if !strings.HasPrefix(arn, "arn:"+endpoints.AwsPartitionID+":") {
+		return arn
+	}
+	return "arn:" + partition + strings.TrimPrefix(arn, "arn:"+endpoints.AwsPartitionID)
+}
+
+// PartitionForRegion returns the partition ID of the region
+// (e.g. "aws-cn" for "cn-north-1"), or false if the region is unknown
+// (e.g. LocalStack).
+func PartitionForRegion(region string) (string, bool) {
+	p, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), region)
+	if !ok {
+		return "", false
+	}
+	return p.ID(), true
+}
@@ -0,0 +1,36 @@
+package aws
+
+import "testing"
+
+func TestARN(t *testing.T) {
+	if v := PolicyARN("aws-us-gov", "AmazonEKSClusterPolicy"); v != "arn:aws-us-gov:iam::aws:policy/AmazonEKSClusterPolicy" {
+		t.Fatalf("unexpected policy ARN %q", v)
+	}
+	if v := ARN("aws-cn", "elasticloadbalancing", "cn-north-1", "123", "loadbalancer/net/a/b"); v != "arn:aws-cn:elasticloadbalancing:cn-north-1:123:loadbalancer/net/a/b" {
+		t.Fatalf("unexpected ARN %q", v)
+	}
+	for i, tv := range []struct {
+		partition string
+		arn       string
+		exp       string
+	}{
+		{"aws", "arn:aws:iam::aws:policy/A", "arn:aws:iam::aws:policy/A"},
+		{"aws-cn", "arn:aws:iam::aws:policy/A", "arn:aws-cn:iam::aws:policy/A"},
+		{"aws-cn", "arn:aws-cn:iam::aws:policy/A", "arn:aws-cn:iam::aws:policy/A"},
+		{"aws-us-gov", "arn:aws-cn:iam::aws:policy/A", "arn:aws-cn:iam::aws:policy/A"},
+	} {
+		if v := ToPartitionARN(tv.partition, tv.arn); v != tv.exp {
+			t.Fatalf("#%d: expected %q, got %q", i, tv.exp, v)
+		}
+	}
+	for region, exp := range map[string]string{
+		"us-west-2":     "aws",
+		"cn-north-1":    "aws-cn",
+		"us-gov-west-1": "aws-us-gov",
+		"localstack":    "",
+	} {
+		if v, _ := PartitionForRegion(region); v != exp {
+			t.Fatalf("%q: expected %q, got %q", region, exp, v)
+		}
+	}
+}
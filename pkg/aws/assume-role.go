@@ -0,0 +1,147 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	stscreds_v2 "github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	aws_sts_v2 "github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"go.uber.org/zap"
+)
+
+// AssumeRole defines the IAM role assumed for all AWS API calls.
+// The base credentials are loaded from the default credential chain,
+// or exchanged from the web identity token if "WebIdentityTokenFile" is set.
+type AssumeRole struct {
+	// RoleARN is the IAM role to assume with the base credentials.
+	// Leave empty to use the base credentials as is.
+	RoleARN string
+	// ExternalID is the external ID required by the role trust policy.
+	ExternalID string
+	// SessionName is the role session name.
+	SessionName string
+	// Duration is the role session duration.
+	Duration time.Duration
+
+	// WebIdentityTokenFile is the path to the OIDC token file
+	// (e.g. vended by the CI system), exchanged for the "WebIdentityRoleARN"
+	// credentials by "AssumeRoleWithWebIdentity".
+	WebIdentityTokenFile string
+	// WebIdentityRoleARN is the IAM role to assume with the web identity token.
+	WebIdentityRoleARN string
+}
+
+// IsEnabled returns true if any role is assumed.
+func (ar *AssumeRole) IsEnabled() bool {
+	return ar != nil && (ar.RoleARN != "" || ar.WebIdentityTokenFile != "")
+}
+
+// Credentials returns the assumed role credentials, using the base
+// credentials of the config. The returned credentials are cached and
+// refreshed before expiration.
+func (ar *AssumeRole) Credentials(lg *zap.Logger, awsConfig aws.Config) (*credentials.Credentials, error) {
+	stsConfig := awsConfig
+	stsConfig.STSRegionalEndpoint = endpoints.RegionalSTSEndpoint
+	ss, err := session.NewSession(&stsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var creds *credentials.Credentials
+	if ar.WebIdentityTokenFile != "" {
+		lg.Info("assuming role with web identity",
+			zap.String("role-arn", ar.WebIdentityRoleARN),
+			zap.String("token-file", ar.WebIdentityTokenFile),
+		)
+		creds = stscreds.NewWebIdentityCredentials(ss, ar.WebIdentityRoleARN, ar.SessionName, ar.WebIdentityTokenFile)
+		if ar.RoleARN == "" {
+			return creds, nil
+		}
+		// chain the target role from the web identity role
+		stsConfig.Credentials = creds
+		ss, err = session.NewSession(&stsConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lg.Info("assuming role",
+		zap.String("role-arn", ar.RoleARN),
+		zap.String("session-name", ar.SessionName),
+		zap.String("duration", ar.Duration.String()),
+	)
+	return stscreds.NewCredentials(ss, ar.RoleARN, func(p *stscreds.AssumeRoleProvider) {
+		if ar.ExternalID != "" {
+			p.ExternalID = aws.String(ar.ExternalID)
+		}
+		if ar.SessionName != "" {
+			p.RoleSessionName = ar.SessionName
+		}
+		if ar.Duration > 0 {
+			p.Duration = ar.Duration
+		}
+	}), nil
+}
+
+// credentialsV2 returns the assumed role credentials for AWS SDK Go v2.
+func (ar *AssumeRole) credentialsV2(lg *zap.Logger, awsCfg aws_v2.Config) aws_v2.CredentialsProvider {
+	var provider aws_v2.CredentialsProvider
+	if ar.WebIdentityTokenFile != "" {
+		lg.Info("assuming role with web identity for AWS SDK Go v2",
+			zap.String("role-arn", ar.WebIdentityRoleARN),
+			zap.String("token-file", ar.WebIdentityTokenFile),
+		)
+		provider = aws_v2.NewCredentialsCache(stscreds_v2.NewWebIdentityRoleProvider(
+			aws_sts_v2.NewFromConfig(awsCfg),
+			ar.WebIdentityRoleARN,
+			stscreds_v2.IdentityTokenFile(ar.WebIdentityTokenFile),
+			func(o *stscreds_v2.WebIdentityRoleOptions) {
+				o.RoleSessionName = ar.SessionName
+			},
+		))
+		if ar.RoleARN == "" {
+			return provider
+		}
+		awsCfg.Credentials = provider
+	}
+
+	lg.Info("assuming role for AWS SDK Go v2",
+		zap.String("role-arn", ar.RoleARN),
+		zap.String("session-name", ar.SessionName),
+		zap.String("duration", ar.Duration.String()),
+	)
+	return aws_v2.NewCredentialsCache(stscreds_v2.NewAssumeRoleProvider(
+		aws_sts_v2.NewFromConfig(awsCfg),
+		ar.RoleARN,
+		func(o *stscreds_v2.AssumeRoleOptions) {
+			if ar.ExternalID != "" {
+				o.ExternalID = aws_v2.String(ar.ExternalID)
+			}
+			if ar.SessionName != "" {
+				o.RoleSessionName = ar.SessionName
+			}
+			if ar.Duration > 0 {
+				o.Duration = ar.Duration
+			}
+		},
+	))
+}
+
+func (ar *AssumeRole) validate() error {
+	if ar.WebIdentityTokenFile != "" && ar.WebIdentityRoleARN == "" {
+		return fmt.Errorf("got empty web identity role ARN for token file %q", ar.WebIdentityTokenFile)
+	}
+	if ar.WebIdentityTokenFile == "" && ar.WebIdentityRoleARN != "" {
+		return fmt.Errorf("got empty web identity token file for role %q", ar.WebIdentityRoleARN)
+	}
+	if ar.ExternalID != "" && ar.RoleARN == "" {
+		return fmt.Errorf("got external ID without role ARN")
+	}
+	return nil
+}
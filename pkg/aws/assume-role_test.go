@@ -0,0 +1,27 @@
+package aws
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAssumeRoleValidate(t *testing.T) {
+	for i, tv := range []struct {
+		ar  AssumeRole
+		err string
+	}{
+		{AssumeRole{RoleARN: "arn:aws:iam::123:role/a", ExternalID: "x"}, ""},
+		{AssumeRole{WebIdentityTokenFile: "/token", WebIdentityRoleARN: "arn:aws:iam::123:role/ci"}, ""},
+		{AssumeRole{WebIdentityTokenFile: "/token"}, "empty web identity role ARN"},
+		{AssumeRole{WebIdentityRoleARN: "arn:aws:iam::123:role/ci"}, "empty web identity token file"},
+		{AssumeRole{ExternalID: "x"}, "without role ARN"},
+	} {
+		err := tv.ar.validate()
+		if tv.err == "" && err != nil {
+			t.Fatalf("#%d: unexpected error %v", i, err)
+		}
+		if tv.err != "" && (err == nil || !strings.Contains(err.Error(), tv.err)) {
+			t.Fatalf("#%d: expected %q, got %v", i, tv.err, err)
+		}
+	}
+}
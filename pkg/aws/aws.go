@@ -37,8 +37,50 @@ type Config struct {
 	ResolverURL string
 	// SigningName is the API signing name.
 	SigningName string
+
+	// Endpoints maps the service ID (e.g. "ec2", "s3", "cloudformation", "sts")
+	// to the custom endpoint URL (e.g. LocalStack), overriding the partition
+	// endpoints. "ResolverURL" takes precedence for "eks".
+	Endpoints map[string]string
+	// S3ForcePathStyle is true to use the path-style S3 requests,
+	// required by most S3 compatible endpoints.
+	S3ForcePathStyle bool
+
+	// APICallObserver is called on the completion of every API call
+	// (including the retries), e.g. to export the API call metrics.
+	APICallObserver APICallObserver
+
+	// AssumeRole is the IAM role assumed for all API calls.
+	// Leave empty to use the default credential chain.
+	AssumeRole *AssumeRole
+
+	// Throttler is the client-side rate limiter shared with the other
+	// sessions and configs. Leave empty to disable.
+	Throttler *Throttler
+
+	// MaxAttempts is the maximum number of attempts of each AWS SDK Go v2
+	// API call, retried with the exponential backoff on the throttling
+	// and transient errors. Zero to use the SDK default.
+	MaxAttempts int
+	// RetryMode is the retry mode of the AWS SDK Go v2 API calls,
+	// either "standard" (default) or "adaptive" (see "RetryModeAdaptive").
+	RetryMode string
 }
 
+const (
+	// RetryModeStandard retries with the exponential backoff.
+	RetryModeStandard = "standard"
+	// RetryModeAdaptive retries with the exponential backoff, and rate-limits
+	// the attempts on the client side, backing off on the throttling errors.
+	// The pinned SDK predates "retry.NewAdaptiveMode", so the attempts are
+	// rate-limited by "Throttler", or by a new one for the config if empty.
+	// TODO: use "retry.NewAdaptiveMode" once aws-sdk-go-v2 is bumped past v1.7.0
+	RetryModeAdaptive = "adaptive"
+)
+
+// APICallObserver observes the completed API call, with the error if failed.
+type APICallObserver func(service string, operation string, err error)
+
 // New creates a new AWS session.
 // Specify a custom endpoint for tests.
 func New(cfg *Config) (ss *session.Session, stsOutput *sts.GetCallerIdentityOutput, awsCredsPath string, err error) {
@@ -99,6 +141,45 @@ func New(cfg *Config) (ss *session.Session, stsOutput *sts.GetCallerIdentityOutp
 		awsConfig.LogLevel = &lvl
 	}
 
+	resolver := endpoints.DefaultResolver()
+	if cfg.ResolverURL != "" || len(cfg.Endpoints) > 0 {
+		cfg.Logger.Info(
+			"setting custom resolver",
+			zap.String("resolver-url", cfg.ResolverURL),
+			zap.String("signing-name", cfg.SigningName),
+			zap.Any("endpoints", cfg.Endpoints),
+		)
+		resolver = endpoints.ResolverFunc(func(service, region string, optFns ...func(*endpoints.Options)) (endpoints.ResolvedEndpoint, error) {
+			if service == "eks" && cfg.ResolverURL != "" {
+				return endpoints.ResolvedEndpoint{
+					URL:         cfg.ResolverURL,
+					SigningName: cfg.SigningName,
+				}, nil
+			}
+			if u, ok := cfg.Endpoints[service]; ok {
+				return endpoints.ResolvedEndpoint{
+					URL:           u,
+					SigningRegion: region,
+				}, nil
+			}
+			return endpoints.DefaultResolver().EndpointFor(service, region, optFns...)
+		})
+	}
+	awsConfig.EndpointResolver = resolver
+	if cfg.S3ForcePathStyle {
+		awsConfig.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	if cfg.AssumeRole.IsEnabled() {
+		if err = cfg.AssumeRole.validate(); err != nil {
+			return nil, nil, "", err
+		}
+		awsConfig.Credentials, err = cfg.AssumeRole.Credentials(cfg.Logger, awsConfig)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to assume role (%v)", err)
+		}
+	}
+
 	var partition endpoints.Partition
 	switch cfg.Partition {
 	case endpoints.AwsPartitionID:
@@ -168,25 +249,6 @@ func New(cfg *Config) (ss *session.Session, stsOutput *sts.GetCallerIdentityOutp
 		}
 	}
 
-	resolver := endpoints.DefaultResolver()
-	if cfg.ResolverURL != "" {
-		cfg.Logger.Info(
-			"setting custom resolver",
-			zap.String("resolver-url", cfg.ResolverURL),
-			zap.String("signing-name", cfg.SigningName),
-		)
-		resolver = endpoints.ResolverFunc(func(service, region string, optFns ...func(*endpoints.Options)) (endpoints.ResolvedEndpoint, error) {
-			if service == "eks" {
-				return endpoints.ResolvedEndpoint{
-					URL:         cfg.ResolverURL,
-					SigningName: cfg.SigningName,
-				}, nil
-			}
-			return endpoints.DefaultResolver().EndpointFor(service, region, optFns...)
-		})
-	}
-	awsConfig.EndpointResolver = resolver
-
 	cfg.Logger.Info(
 		"creating AWS session",
 		zap.String("partition", cfg.Partition),
@@ -196,6 +258,17 @@ func New(cfg *Config) (ss *session.Session, stsOutput *sts.GetCallerIdentityOutp
 	if err != nil {
 		return nil, nil, "", err
 	}
+	if cfg.APICallObserver != nil {
+		ss.Handlers.Complete.PushBackNamed(request.NamedHandler{
+			Name: "aws-k8s-tester.APICallObserver",
+			Fn: func(r *request.Request) {
+				cfg.APICallObserver(r.ClientInfo.ServiceName, r.Operation.Name, r.Error)
+			},
+		})
+	}
+	if cfg.Throttler != nil {
+		cfg.Throttler.installV1(&ss.Handlers)
+	}
 	return ss, stsOutput, awsCredsPath, err
 }
 
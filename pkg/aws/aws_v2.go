@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	middleware_v2 "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	config_v2 "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/smithy-go/logging"
+	"github.com/aws/smithy-go/middleware"
 	"go.uber.org/zap"
 )
 
@@ -42,19 +46,57 @@ func NewV2(cfg *Config) (awsCfg aws_v2.Config, err error) {
 		optFns = append(optFns, (func(*config_v2.LoadOptions) error)(config_v2.WithClientLogMode(lvl)))
 	}
 
-	if cfg.ResolverURL != "" {
+	throttler := cfg.Throttler
+	switch cfg.RetryMode {
+	case "", RetryModeStandard:
+	case RetryModeAdaptive:
+		if throttler == nil {
+			throttler = NewThrottler(ThrottlerConfig{
+				Logger:  cfg.Logger,
+				MaxRate: DefaultAdaptiveMaxRate,
+				MinRate: DefaultAdaptiveMinRate,
+				Burst:   int(DefaultAdaptiveMaxRate),
+			})
+		}
+	default:
+		return aws_v2.Config{}, fmt.Errorf("unknown retry mode %q", cfg.RetryMode)
+	}
+	if cfg.MaxAttempts > 0 {
+		optFns = append(optFns, (func(*config_v2.LoadOptions) error)(config_v2.WithRetryer(func() aws_v2.Retryer {
+			return retry.NewStandard(func(o *retry.StandardOptions) {
+				o.MaxAttempts = cfg.MaxAttempts
+			})
+		})))
+	}
+
+	if cfg.ResolverURL != "" || len(cfg.Endpoints) > 0 {
 		cfg.Logger.Info(
-			"setting endpoint resolver for all services",
+			"setting endpoint resolver",
 			zap.String("resolver-url", cfg.ResolverURL),
 			zap.String("signing-name", cfg.SigningName),
+			zap.Any("endpoints", cfg.Endpoints),
 		)
 		opt := config_v2.WithEndpointResolver(aws_v2.EndpointResolverFunc(func(service string, region string) (aws_v2.Endpoint, error) {
+			// v2 service IDs are e.g. "EC2", "CloudFormation"
+			service = strings.ToLower(service)
+			if u, ok := cfg.Endpoints[service]; ok && (service != "eks" || cfg.ResolverURL == "") {
+				return aws_v2.Endpoint{
+					URL:           u,
+					SigningRegion: region,
+					PartitionID:   cfg.Partition,
+					Source:        aws_v2.EndpointSourceCustom,
+				}, nil
+			}
+			if cfg.ResolverURL == "" {
+				// fall back to the default resolver
+				return aws_v2.Endpoint{}, &aws_v2.EndpointNotFoundError{}
+			}
 			// v2 SDK does not support exported default resolver
 			return aws_v2.Endpoint{
 				URL:           cfg.ResolverURL,
 				SigningName:   cfg.SigningName,
 				SigningRegion: region,
-				PartitionID:   "aws",
+				PartitionID:   cfg.Partition,
 				SigningMethod: "",
 				Source:        aws_v2.EndpointSourceCustom,
 			}, nil
@@ -68,6 +110,28 @@ func NewV2(cfg *Config) (awsCfg aws_v2.Config, err error) {
 	if err != nil {
 		return aws_v2.Config{}, fmt.Errorf("failed to load config %v", err)
 	}
+	if cfg.AssumeRole.IsEnabled() {
+		if err = cfg.AssumeRole.validate(); err != nil {
+			return aws_v2.Config{}, err
+		}
+		awsCfg.Credentials = cfg.AssumeRole.credentialsV2(cfg.Logger, awsCfg)
+	}
+	if cfg.APICallObserver != nil {
+		awsCfg.APIOptions = append(awsCfg.APIOptions, func(stack *middleware.Stack) error {
+			// after the service metadata is set, and before the retries
+			return stack.Initialize.Add(middleware.InitializeMiddlewareFunc(
+				"aws-k8s-tester.APICallObserver",
+				func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+					out, md, err := next.HandleInitialize(ctx, in)
+					cfg.APICallObserver(middleware_v2.GetServiceID(ctx), middleware_v2.GetOperationName(ctx), err)
+					return out, md, err
+				},
+			), middleware.After)
+		})
+	}
+	if throttler != nil {
+		awsCfg.APIOptions = append(awsCfg.APIOptions, throttler.installV2)
+	}
 
 	return awsCfg, nil
 }
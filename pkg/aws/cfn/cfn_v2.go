@@ -0,0 +1,252 @@
+package cfn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/ctxutil"
+	"github.com/aws/aws-k8s-tester/pkg/spinner"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_cfn_v2 "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	aws_cfn_v2_types "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/dustin/go-humanize"
+	"go.uber.org/zap"
+)
+
+// APIV2 is the subset of the CloudFormation API (AWS SDK Go v2)
+// to manage the stack lifecycle, list the stacks (e.g. the janitor),
+// and detect the stack drift.
+// Implemented by "*cloudformation.Client".
+type APIV2 interface {
+	CreateStack(ctx context.Context, params *aws_cfn_v2.CreateStackInput, optFns ...func(*aws_cfn_v2.Options)) (*aws_cfn_v2.CreateStackOutput, error)
+	DescribeStacks(ctx context.Context, params *aws_cfn_v2.DescribeStacksInput, optFns ...func(*aws_cfn_v2.Options)) (*aws_cfn_v2.DescribeStacksOutput, error)
+	DeleteStack(ctx context.Context, params *aws_cfn_v2.DeleteStackInput, optFns ...func(*aws_cfn_v2.Options)) (*aws_cfn_v2.DeleteStackOutput, error)
+	ListStacks(ctx context.Context, params *aws_cfn_v2.ListStacksInput, optFns ...func(*aws_cfn_v2.Options)) (*aws_cfn_v2.ListStacksOutput, error)
+
+	DetectStackDrift(ctx context.Context, params *aws_cfn_v2.DetectStackDriftInput, optFns ...func(*aws_cfn_v2.Options)) (*aws_cfn_v2.DetectStackDriftOutput, error)
+	DescribeStackDriftDetectionStatus(ctx context.Context, params *aws_cfn_v2.DescribeStackDriftDetectionStatusInput, optFns ...func(*aws_cfn_v2.Options)) (*aws_cfn_v2.DescribeStackDriftDetectionStatusOutput, error)
+	DescribeStackResourceDrifts(ctx context.Context, params *aws_cfn_v2.DescribeStackResourceDriftsInput, optFns ...func(*aws_cfn_v2.Options)) (*aws_cfn_v2.DescribeStackResourceDriftsOutput, error)
+}
+
+var _ APIV2 = &aws_cfn_v2.Client{}
+
+// StackStatusV2 represents the CloudFormation status.
+type StackStatusV2 struct {
+	Stack *aws_cfn_v2_types.Stack
+	Error error
+}
+
+// PollV2 periodically fetches the stack status
+// until the stack becomes the desired state.
+// The ctx is propagated to each API call.
+func PollV2(
+	ctx context.Context,
+	stopc chan struct{},
+	lg *zap.Logger,
+	logWriter io.Writer,
+	cfnAPI APIV2,
+	stackID string,
+	desiredStackStatus aws_cfn_v2_types.StackStatus,
+	initialWait time.Duration,
+	pollInterval time.Duration,
+) <-chan StackStatusV2 {
+	now := time.Now()
+	sp := spinner.New(logWriter, "Waiting for CFN stack "+string(desiredStackStatus))
+
+	lg.Info("polling stack",
+		zap.String("stack-id", stackID),
+		zap.String("want", string(desiredStackStatus)),
+		zap.String("initial-wait", initialWait.String()),
+		zap.String("poll-interval", pollInterval.String()),
+		zap.String("ctx-time-left", ctxutil.TimeLeftTillDeadline(ctx)),
+	)
+	ch := make(chan StackStatusV2, 10)
+	go func() {
+		// very first poll should be no-wait
+		// in case stack has already reached desired status
+		interval := time.Duration(0)
+
+		prevStatusReason, first := "", true
+		for ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+				lg.Warn("wait aborted, ctx done", zap.Error(ctx.Err()))
+				ch <- StackStatusV2{Stack: nil, Error: ctx.Err()}
+				close(ch)
+				return
+
+			case <-stopc:
+				lg.Warn("wait stopped, stopc closed", zap.Error(ctx.Err()))
+				ch <- StackStatusV2{Stack: nil, Error: errors.New("wait stopped")}
+				close(ch)
+				return
+
+			case <-time.After(interval):
+				if interval == time.Duration(0) {
+					interval = pollInterval
+				}
+			}
+
+			output, err := cfnAPI.DescribeStacks(ctx, &aws_cfn_v2.DescribeStacksInput{
+				StackName: aws_v2.String(stackID),
+			})
+			if err != nil {
+				if StackNotExist(err) {
+					if desiredStackStatus == aws_cfn_v2_types.StackStatusDeleteComplete {
+						lg.Info("stack is already deleted as desired; exiting", zap.Error(err))
+						ch <- StackStatusV2{Stack: nil, Error: nil}
+						close(ch)
+						return
+					}
+
+					lg.Warn("stack does not exist; aborting", zap.Error(ctx.Err()))
+					ch <- StackStatusV2{Stack: nil, Error: err}
+					close(ch)
+					return
+				}
+
+				lg.Warn("describe stack failed; retrying", zap.Error(err))
+				ch <- StackStatusV2{Stack: nil, Error: err}
+				continue
+			}
+
+			if len(output.Stacks) != 1 {
+				lg.Warn("expected only 1 stack; retrying", zap.Int("stacks", len(output.Stacks)))
+				ch <- StackStatusV2{Stack: nil, Error: fmt.Errorf("unexpected stack response (%d stacks)", len(output.Stacks))}
+				continue
+			}
+
+			stack := output.Stacks[0]
+			currentStatus := stack.StackStatus
+			currentStatusReason := aws_v2.ToString(stack.StackStatusReason)
+			if prevStatusReason == "" {
+				prevStatusReason = currentStatusReason
+			} else if currentStatusReason != "" && prevStatusReason != currentStatusReason {
+				prevStatusReason = currentStatusReason
+			}
+
+			lg.Info("poll",
+				zap.String("name", aws_v2.ToString(stack.StackName)),
+				zap.String("desired", string(desiredStackStatus)),
+				zap.String("current", string(currentStatus)),
+				zap.String("current-reason", currentStatusReason),
+				zap.String("started", humanize.RelTime(now, time.Now(), "ago", "from now")),
+				zap.String("ctx-time-left", ctxutil.TimeLeftTillDeadline(ctx)),
+			)
+
+			if desiredStackStatus != aws_cfn_v2_types.StackStatusDeleteComplete &&
+				currentStatus == aws_cfn_v2_types.StackStatusDeleteComplete {
+				lg.Warn("create stack failed; aborting")
+				ch <- StackStatusV2{
+					Stack: &stack,
+					Error: fmt.Errorf("stack failed thus deleted (previous status reason %q, current stack status %q, current status reason %q)",
+						prevStatusReason,
+						currentStatus,
+						currentStatusReason,
+					)}
+				close(ch)
+				return
+			}
+
+			if desiredStackStatus == aws_cfn_v2_types.StackStatusDeleteComplete &&
+				currentStatus == aws_cfn_v2_types.StackStatusDeleteFailed {
+				lg.Warn("delete stack failed; aborting")
+				ch <- StackStatusV2{
+					Stack: &stack,
+					Error: fmt.Errorf("failed to delete stack (previous status reason %q, current stack status %q, current status reason %q)",
+						prevStatusReason,
+						currentStatus,
+						currentStatusReason,
+					)}
+				close(ch)
+				return
+			}
+
+			ch <- StackStatusV2{Stack: &stack, Error: nil}
+			if currentStatus == desiredStackStatus {
+				lg.Info("desired stack status; done", zap.String("current-stack-status", string(currentStatus)))
+				close(ch)
+				return
+			}
+
+			if first {
+				lg.Info("sleeping", zap.Duration("initial-wait", initialWait))
+				sp.Restart()
+				select {
+				case <-ctx.Done():
+					sp.Stop()
+					lg.Warn("wait aborted, ctx done", zap.Error(ctx.Err()))
+					ch <- StackStatusV2{Stack: nil, Error: ctx.Err()}
+					close(ch)
+					return
+				case <-stopc:
+					sp.Stop()
+					lg.Warn("wait stopped, stopc closed", zap.Error(ctx.Err()))
+					ch <- StackStatusV2{Stack: nil, Error: errors.New("wait stopped")}
+					close(ch)
+					return
+				case <-time.After(initialWait):
+					sp.Stop()
+				}
+				first = false
+			}
+		}
+		lg.Warn("wait aborted, ctx done", zap.Error(ctx.Err()))
+		ch <- StackStatusV2{Stack: nil, Error: ctx.Err()}
+		close(ch)
+	}()
+	return ch
+}
+
+// NewTagsV2 returns a list of default CloudFormation tags.
+func NewTagsV2(input map[string]string) (tags []aws_cfn_v2_types.Tag) {
+	for k, v := range input {
+		tags = append(tags, aws_cfn_v2_types.Tag{Key: aws_v2.String(k), Value: aws_v2.String(v)})
+	}
+	return tags
+}
+
+// StackAlreadyExistsV2 returns true if cloudformation error indicates
+// that the stack of the same name already exists.
+func StackAlreadyExistsV2(err error) bool {
+	var aerr *aws_cfn_v2_types.AlreadyExistsException
+	return errors.As(err, &aerr)
+}
+
+// CreateStackV2 creates the stack and returns the stack ID.
+// If the stack of the same name is already created or being created
+// (e.g. by the previous run that exited before persisting the stack ID),
+// it adopts the existing stack and returns its stack ID.
+func CreateStackV2(ctx context.Context, lg *zap.Logger, cfnAPI APIV2, input *aws_cfn_v2.CreateStackInput) (string, error) {
+	out, err := cfnAPI.CreateStack(ctx, input)
+	if err == nil {
+		return aws_v2.ToString(out.StackId), nil
+	}
+	if !StackAlreadyExistsV2(err) {
+		return "", err
+	}
+
+	stackName := aws_v2.ToString(input.StackName)
+	dout, err := cfnAPI.DescribeStacks(ctx, &aws_cfn_v2.DescribeStacksInput{StackName: input.StackName})
+	if err != nil {
+		return "", fmt.Errorf("stack %q already exists, but failed to describe (%v)", stackName, err)
+	}
+	if len(dout.Stacks) != 1 {
+		return "", fmt.Errorf("stack %q already exists, but got %d stacks", stackName, len(dout.Stacks))
+	}
+	st := dout.Stacks[0]
+	switch st.StackStatus {
+	case aws_cfn_v2_types.StackStatusCreateInProgress, aws_cfn_v2_types.StackStatusCreateComplete:
+		lg.Info("stack already exists; adopting instead of creating a new one",
+			zap.String("stack-name", stackName),
+			zap.String("stack-id", aws_v2.ToString(st.StackId)),
+			zap.String("stack-status", string(st.StackStatus)),
+		)
+		return aws_v2.ToString(st.StackId), nil
+	default:
+		return "", fmt.Errorf("stack %q already exists with unexpected status %q", stackName, st.StackStatus)
+	}
+}
@@ -0,0 +1,92 @@
+package cfn
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	aws_cfn_v2 "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	aws_cfn_v2_types "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"go.uber.org/zap"
+)
+
+// StackDrift is the drift detection result of a stack.
+type StackDrift struct {
+	StackID string
+	Status  aws_cfn_v2_types.StackDriftStatus
+	// DriftedResources is the list of modified or deleted resources.
+	DriftedResources []aws_cfn_v2_types.StackResourceDrift
+}
+
+// DetectDriftV2 starts the drift detection of the stack, and waits until
+// the detection completes. It returns the stack resources that differ
+// from the template (e.g. edited in the console).
+func DetectDriftV2(
+	ctx context.Context,
+	lg *zap.Logger,
+	cfnAPI APIV2,
+	stackID string,
+	pollInterval time.Duration,
+) (StackDrift, error) {
+	lg.Info("detecting stack drift", zap.String("stack-id", stackID))
+	out, err := cfnAPI.DetectStackDrift(ctx, &aws_cfn_v2.DetectStackDriftInput{
+		StackName: aws_v2.String(stackID),
+	})
+	if err != nil {
+		return StackDrift{}, err
+	}
+	detectionID := aws_v2.ToString(out.StackDriftDetectionId)
+
+	var status *aws_cfn_v2.DescribeStackDriftDetectionStatusOutput
+	for {
+		select {
+		case <-ctx.Done():
+			return StackDrift{}, fmt.Errorf("stack drift detection %q aborted (%v)", detectionID, ctx.Err())
+		case <-time.After(pollInterval):
+		}
+		status, err = cfnAPI.DescribeStackDriftDetectionStatus(ctx, &aws_cfn_v2.DescribeStackDriftDetectionStatusInput{
+			StackDriftDetectionId: aws_v2.String(detectionID),
+		})
+		if err != nil {
+			return StackDrift{}, err
+		}
+		if status.DetectionStatus != aws_cfn_v2_types.StackDriftDetectionStatusDetectionInProgress {
+			break
+		}
+	}
+	// "DETECTION_FAILED" still reports the drift of the checked resources
+	if status.DetectionStatus == aws_cfn_v2_types.StackDriftDetectionStatusDetectionFailed {
+		lg.Warn("stack drift detection failed",
+			zap.String("stack-id", stackID),
+			zap.String("reason", aws_v2.ToString(status.DetectionStatusReason)),
+		)
+	}
+
+	drift := StackDrift{StackID: stackID, Status: status.StackDriftStatus}
+	input := &aws_cfn_v2.DescribeStackResourceDriftsInput{
+		StackName: aws_v2.String(stackID),
+		StackResourceDriftStatusFilters: []aws_cfn_v2_types.StackResourceDriftStatus{
+			aws_cfn_v2_types.StackResourceDriftStatusModified,
+			aws_cfn_v2_types.StackResourceDriftStatusDeleted,
+		},
+	}
+	for {
+		rout, err := cfnAPI.DescribeStackResourceDrifts(ctx, input)
+		if err != nil {
+			return StackDrift{}, err
+		}
+		drift.DriftedResources = append(drift.DriftedResources, rout.StackResourceDrifts...)
+		if aws_v2.ToString(rout.NextToken) == "" {
+			break
+		}
+		input.NextToken = rout.NextToken
+	}
+
+	lg.Info("detected stack drift",
+		zap.String("stack-id", stackID),
+		zap.String("drift-status", string(drift.Status)),
+		zap.Int("drifted-resources", len(drift.DriftedResources)),
+	)
+	return drift, nil
+}
@@ -0,0 +1,25 @@
+package cfn
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// LoadTemplate returns the template at the relative path in the override
+// directory (e.g. "add-on-irsa/role.yaml"), or the embedded template if the
+// directory is empty or the file does not exist.
+func LoadTemplate(overrideDir string, path string, embedded string) (string, error) {
+	if overrideDir == "" {
+		return embedded, nil
+	}
+	b, err := ioutil.ReadFile(filepath.Join(overrideDir, path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return embedded, nil
+		}
+		return "", fmt.Errorf("failed to read template override %q (%v)", path, err)
+	}
+	return string(b), nil
+}
@@ -2,6 +2,7 @@
 package ecr
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -442,6 +443,121 @@ func SetPolicy(
 	return repoURI, nil
 }
 
-// TODO: get auth token
-// https://github.com/aws/amazon-ecs-agent/blob/master/agent/dockerclient/dockerauth/ecr.go
-// automated docker push
+// PullPolicy returns the repository policy to allow the principals
+// (e.g. the worker node roles) to pull the images.
+func PullPolicy(principalARNs []string) (string, error) {
+	if len(principalARNs) == 0 {
+		return "", errors.New("empty principals")
+	}
+	policy := map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{
+			{
+				"Sid":       "AllowPull",
+				"Effect":    "Allow",
+				"Principal": map[string]interface{}{"AWS": principalARNs},
+				"Action": []string{
+					"ecr:BatchCheckLayerAvailability",
+					"ecr:BatchGetImage",
+					"ecr:GetDownloadUrlForLayer",
+				},
+			},
+		},
+	}
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// CheckTagMutability checks that the image tag can be overwritten if the
+// repository is mutable, and cannot be if immutable. The tag is overwritten
+// with the same manifest in a different format (thus a different digest),
+// and restored to the original manifest if mutable.
+func CheckTagMutability(
+	lg *zap.Logger,
+	svc ecriface.ECRAPI,
+	repoAccountID string,
+	repoName string,
+	imageTag string,
+	imgTagMutability string) (err error) {
+	lg.Info("checking image tag mutability",
+		zap.String("repo-name", repoName),
+		zap.String("image-tag", imageTag),
+		zap.String("image-tag-mutability", imgTagMutability),
+	)
+	out, err := svc.BatchGetImage(&ecr.BatchGetImageInput{
+		RegistryId:         aws.String(repoAccountID),
+		RepositoryName:     aws.String(repoName),
+		ImageIds:           []*ecr.ImageIdentifier{{ImageTag: aws.String(imageTag)}},
+		AcceptedMediaTypes: aws.StringSlice(manifestMediaTypes),
+	})
+	if err != nil {
+		return err
+	}
+	if len(out.Images) != 1 {
+		return fmt.Errorf("expected 1 image for tag %q, got %d", imageTag, len(out.Images))
+	}
+	img := out.Images[0]
+	orig := aws.StringValue(img.ImageManifest)
+	reformatted, err := reformatManifest(orig)
+	if err != nil {
+		return err
+	}
+
+	_, err = svc.PutImage(&ecr.PutImageInput{
+		RegistryId:             aws.String(repoAccountID),
+		RepositoryName:         aws.String(repoName),
+		ImageTag:               aws.String(imageTag),
+		ImageManifest:          aws.String(reformatted),
+		ImageManifestMediaType: img.ImageManifestMediaType,
+	})
+	switch imgTagMutability {
+	case ecr.ImageTagMutabilityImmutable:
+		if err == nil {
+			return fmt.Errorf("image tag %q overwritten in the immutable repository %q", imageTag, repoName)
+		}
+		if ev, ok := err.(awserr.Error); !ok || ev.Code() != ecr.ErrCodeImageTagAlreadyExistsException {
+			return fmt.Errorf("unexpected error overwriting image tag %q (%v)", imageTag, err)
+		}
+	case ecr.ImageTagMutabilityMutable:
+		if err != nil {
+			return fmt.Errorf("failed to overwrite image tag %q in the mutable repository %q (%v)", imageTag, repoName, err)
+		}
+		if _, err = svc.PutImage(&ecr.PutImageInput{
+			RegistryId:             aws.String(repoAccountID),
+			RepositoryName:         aws.String(repoName),
+			ImageTag:               aws.String(imageTag),
+			ImageManifest:          aws.String(orig),
+			ImageManifestMediaType: img.ImageManifestMediaType,
+		}); err != nil {
+			return fmt.Errorf("failed to restore image tag %q (%v)", imageTag, err)
+		}
+	default:
+		return fmt.Errorf("invalid image tag mutability %q", imgTagMutability)
+	}
+
+	lg.Info("checked image tag mutability",
+		zap.String("repo-name", repoName),
+		zap.String("image-tag", imageTag),
+		zap.String("image-tag-mutability", imgTagMutability),
+	)
+	return nil
+}
+
+// reformatManifest returns the same manifest with the different whitespaces,
+// so that it has a different digest.
+func reformatManifest(m string) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(m), "", "  "); err != nil {
+		return "", fmt.Errorf("invalid manifest (%v)", err)
+	}
+	if buf.String() == m {
+		buf.Reset()
+		if err := json.Compact(&buf, []byte(m)); err != nil {
+			return "", fmt.Errorf("invalid manifest (%v)", err)
+		}
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,68 @@
+package ecr
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
+	"go.uber.org/zap"
+	"k8s.io/utils/exec"
+)
+
+// BuildAndPush builds the image from the local build context directory
+// with the "docker" CLI, and pushes it to the ECR repository.
+// Returns the pushed image (e.g. "[ACCOUNT_ID].dkr.ecr.[REGION].amazonaws.com/[NAME]:[TAG]").
+func BuildAndPush(
+	lg *zap.Logger,
+	svc ecriface.ECRAPI,
+	dockerPath string,
+	contextDir string,
+	repoAccountID string,
+	repoName string,
+	imageTag string) (img string, err error) {
+	host, token, err := GetAuthToken(svc, repoAccountID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get ECR authorization token (%v)", err)
+	}
+	b, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode ECR authorization token (%v)", err)
+	}
+	// "AWS:[PASSWORD]"
+	creds := strings.SplitN(string(b), ":", 2)
+	if len(creds) != 2 {
+		return "", fmt.Errorf("unexpected ECR authorization token format")
+	}
+	img = host + "/" + repoName + ":" + imageTag
+
+	lg.Info("logging in to ECR", zap.String("host", host))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	cmd := exec.New().CommandContext(ctx, dockerPath, "login", "--username", creds[0], "--password-stdin", host)
+	cmd.SetStdin(strings.NewReader(creds[1]))
+	out, err := cmd.CombinedOutput()
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("'docker login' failed (%v, output %q)", err, string(out))
+	}
+
+	lg.Info("building image", zap.String("context-dir", contextDir), zap.String("image", img))
+	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Minute)
+	out, err = exec.New().CommandContext(ctx, dockerPath, "build", "--network", "host", "-t", img, contextDir).CombinedOutput()
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("'docker build' failed (%v, output %q)", err, string(out))
+	}
+
+	lg.Info("pushing image", zap.String("image", img))
+	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Minute)
+	out, err = exec.New().CommandContext(ctx, dockerPath, "push", img).CombinedOutput()
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("'docker push' failed (%v, output %q)", err, string(out))
+	}
+	lg.Info("pushed image", zap.String("image", img))
+	return img, nil
+}
@@ -0,0 +1,511 @@
+package ecr
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
+	"go.uber.org/zap"
+)
+
+// Manifest media types to copy.
+// ref. https://github.com/opencontainers/image-spec/blob/main/media-types.md
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+
+	// non-distributable layers (e.g. Windows base layers) are not pushed
+	mediaTypeDockerForeignLayer = "application/vnd.docker.image.rootfs.foreign.diff.tar.gzip"
+)
+
+var manifestMediaTypes = []string{
+	MediaTypeDockerManifest,
+	MediaTypeDockerManifestList,
+	MediaTypeOCIManifest,
+	MediaTypeOCIIndex,
+}
+
+// Reference is the parsed image reference.
+type Reference struct {
+	// Host is the registry host (e.g. "public.ecr.aws").
+	Host string
+	// Repository is the repository name (e.g. "docker/library/busybox").
+	Repository string
+	// Tag is the image tag, empty if referenced by digest.
+	Tag string
+	// Digest is the manifest digest, empty if referenced by tag.
+	Digest string
+}
+
+// String returns the image reference.
+func (ref Reference) String() string {
+	if ref.Digest != "" {
+		return ref.Host + "/" + ref.Repository + "@" + ref.Digest
+	}
+	return ref.Host + "/" + ref.Repository + ":" + ref.Tag
+}
+
+// ParseReference parses the image reference, with the same defaults
+// as docker (e.g. "busybox" is "registry-1.docker.io/library/busybox:latest").
+func ParseReference(img string) (ref Reference, err error) {
+	if img == "" {
+		return ref, errors.New("empty image reference")
+	}
+	name := img
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+		if !strings.HasPrefix(ref.Digest, "sha256:") {
+			return ref, fmt.Errorf("invalid digest in %q", img)
+		}
+	}
+	// tag is after the last ":" not followed by "/" (e.g. "localhost:5000/a")
+	if i := strings.LastIndex(name, ":"); i >= 0 && !strings.Contains(name[i:], "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Host, ref.Repository = parts[0], parts[1]
+	} else {
+		ref.Host, ref.Repository = "docker.io", name
+	}
+	if ref.Host == "docker.io" {
+		ref.Host = "registry-1.docker.io"
+		if !strings.Contains(ref.Repository, "/") {
+			ref.Repository = "library/" + ref.Repository
+		}
+	}
+	if ref.Repository == "" || strings.ToLower(ref.Repository) != ref.Repository {
+		return ref, fmt.Errorf("invalid repository in %q", img)
+	}
+	return ref, nil
+}
+
+// GetAuthToken returns the ECR registry endpoint host
+// (e.g. "123456789012.dkr.ecr.us-west-2.amazonaws.com"),
+// and the basic authorization token for the registry.
+func GetAuthToken(svc ecriface.ECRAPI, repoAccountID string) (host string, token string, err error) {
+	out, err := svc.GetAuthorizationToken(&ecr.GetAuthorizationTokenInput{
+		RegistryIds: aws.StringSlice([]string{repoAccountID}),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if len(out.AuthorizationData) != 1 {
+		return "", "", fmt.Errorf("expected 1 authorization data, got %d", len(out.AuthorizationData))
+	}
+	data := out.AuthorizationData[0]
+	u, err := url.Parse(aws.StringValue(data.ProxyEndpoint))
+	if err != nil {
+		return "", "", fmt.Errorf("invalid proxy endpoint %q (%v)", aws.StringValue(data.ProxyEndpoint), err)
+	}
+	// base64 encoded "AWS:[PASSWORD]"
+	return u.Host, aws.StringValue(data.AuthorizationToken), nil
+}
+
+// Replicate copies the image from the source registry to the ECR repository,
+// including all platforms of the multi-platform image, and returns the
+// manifest digest. The source registry is accessed anonymously.
+func Replicate(
+	lg *zap.Logger,
+	svc ecriface.ECRAPI,
+	srcImage string,
+	repoAccountID string,
+	repoName string,
+	imageTag string) (digest string, err error) {
+	src, err := ParseReference(srcImage)
+	if err != nil {
+		return "", err
+	}
+	host, token, err := GetAuthToken(svc, repoAccountID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get ECR authorization token (%v)", err)
+	}
+	dst := Reference{Host: host, Repository: repoName, Tag: imageTag}
+
+	lg.Info("replicating image", zap.String("source", src.String()), zap.String("destination", dst.String()))
+	cli := &http.Client{Timeout: 15 * time.Minute}
+	c := &copier{
+		lg:  lg,
+		src: &registry{cli: cli, scheme: "https", host: src.Host},
+		dst: &registry{cli: cli, scheme: "https", host: dst.Host, basic: token},
+	}
+	digest, err = c.copy(src, dst)
+	if err != nil {
+		return "", fmt.Errorf("failed to replicate %q to %q (%v)", src, dst, err)
+	}
+	lg.Info("replicated image", zap.String("destination", dst.String()), zap.String("digest", digest))
+	return digest, nil
+}
+
+// registry is the minimal OCI distribution API client.
+// ref. https://github.com/opencontainers/distribution-spec/blob/main/spec.md
+type registry struct {
+	cli    *http.Client
+	scheme string
+	host   string
+	// basic is the base64 encoded basic authorization credentials, if any
+	basic string
+	// bearer is the token from the authorization challenge, if any
+	bearer string
+}
+
+func (r *registry) url(repo string, kind string, ref string) string {
+	return fmt.Sprintf("%s://%s/v2/%s/%s/%s", r.scheme, r.host, repo, kind, ref)
+}
+
+// do sends the request, and retries once with the bearer token
+// if the registry responds with the authorization challenge.
+func (r *registry) do(req *http.Request) (*http.Response, error) {
+	r.authorize(req)
+	resp, err := r.cli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized || r.bearer != "" {
+		return resp, nil
+	}
+	challenge := resp.Header.Get("Www-Authenticate")
+	resp.Body.Close()
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return nil, fmt.Errorf("unauthorized %s %s (challenge %q)", req.Method, req.URL, challenge)
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return nil, fmt.Errorf("unauthorized %s %s with non-replayable body", req.Method, req.URL)
+	}
+	if r.bearer, err = r.token(challenge); err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	r.authorize(retry)
+	return r.cli.Do(retry)
+}
+
+func (r *registry) authorize(req *http.Request) {
+	switch {
+	case r.bearer != "":
+		req.Header.Set("Authorization", "Bearer "+r.bearer)
+	case r.basic != "":
+		req.Header.Set("Authorization", "Basic "+r.basic)
+	}
+}
+
+// token fetches the bearer token for the challenge, e.g.
+// Bearer realm="https://public.ecr.aws/token/",service="public.ecr.aws",scope="aws"
+func (r *registry) token(challenge string) (string, error) {
+	params := parseChallenge(challenge)
+	realm := params["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("no realm in challenge %q", challenge)
+	}
+	u, err := url.Parse(realm)
+	if err != nil {
+		return "", fmt.Errorf("invalid realm %q (%v)", realm, err)
+	}
+	q := u.Query()
+	for _, k := range []string{"service", "scope"} {
+		if v := params[k]; v != "" {
+			q.Set(k, v)
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if r.basic != "" {
+		req.Header.Set("Authorization", "Basic "+r.basic)
+	}
+	resp, err := r.cli.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get token from %q (status %q)", realm, resp.Status)
+	}
+	var tok struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", fmt.Errorf("failed to decode token (%v)", err)
+	}
+	if tok.Token == "" {
+		tok.Token = tok.AccessToken
+	}
+	if tok.Token == "" {
+		return "", fmt.Errorf("empty token from %q", realm)
+	}
+	return tok.Token, nil
+}
+
+func parseChallenge(challenge string) map[string]string {
+	params := make(map[string]string)
+	s := strings.TrimSpace(challenge[len("bearer "):])
+	for s != "" {
+		eq := strings.Index(s, "=")
+		if eq < 0 {
+			break
+		}
+		k := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = s[eq+1:]
+		var v string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.Index(s[1:], `"`)
+			if end < 0 {
+				break
+			}
+			v, s = s[1:end+1], s[end+2:]
+		} else if comma := strings.Index(s, ","); comma >= 0 {
+			v, s = s[:comma], s[comma:]
+		} else {
+			v, s = s, ""
+		}
+		params[k] = v
+		s = strings.TrimLeft(s, ", ")
+	}
+	return params
+}
+
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+type manifest struct {
+	MediaType string `json:"mediaType"`
+	// for the image manifests
+	Config descriptor   `json:"config"`
+	Layers []descriptor `json:"layers"`
+	// for the manifest lists and image indexes
+	Manifests []descriptor `json:"manifests"`
+}
+
+// copier copies the images between the registries.
+type copier struct {
+	lg  *zap.Logger
+	src *registry
+	dst *registry
+}
+
+// copy copies the manifest (and its children or blobs), and returns its digest.
+func (c *copier) copy(src Reference, dst Reference) (string, error) {
+	ref := src.Tag
+	if src.Digest != "" {
+		ref = src.Digest
+	}
+	b, mediaType, err := c.getManifest(src.Repository, ref)
+	if err != nil {
+		return "", err
+	}
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(b))
+	if src.Digest != "" && src.Digest != digest {
+		return "", fmt.Errorf("manifest digest %q does not match %q", digest, src.Digest)
+	}
+
+	var m manifest
+	if err = json.Unmarshal(b, &m); err != nil {
+		return "", fmt.Errorf("failed to decode manifest %q (%v)", ref, err)
+	}
+	if mediaType == "" {
+		mediaType = m.MediaType
+	}
+	switch mediaType {
+	case MediaTypeDockerManifestList, MediaTypeOCIIndex:
+		for _, child := range m.Manifests {
+			if _, err = c.copy(
+				Reference{Host: src.Host, Repository: src.Repository, Digest: child.Digest},
+				Reference{Host: dst.Host, Repository: dst.Repository, Digest: child.Digest},
+			); err != nil {
+				return "", err
+			}
+		}
+	case MediaTypeDockerManifest, MediaTypeOCIManifest:
+		for _, desc := range append([]descriptor{m.Config}, m.Layers...) {
+			if desc.MediaType == mediaTypeDockerForeignLayer {
+				continue
+			}
+			if err = c.copyBlob(src.Repository, dst.Repository, desc); err != nil {
+				return "", err
+			}
+		}
+	default:
+		return "", fmt.Errorf("unsupported manifest media type %q", mediaType)
+	}
+
+	dstRef := dst.Tag
+	if dstRef == "" {
+		dstRef = digest
+	}
+	return digest, c.putManifest(dst.Repository, dstRef, mediaType, b, digest)
+}
+
+func (c *copier) getManifest(repo string, ref string) ([]byte, string, error) {
+	req, err := http.NewRequest(http.MethodGet, c.src.url(repo, "manifests", ref), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	resp, err := c.src.do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to get manifest %s:%s (status %q)", repo, ref, resp.Status)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	mediaType := resp.Header.Get("Content-Type")
+	if i := strings.Index(mediaType, ";"); i >= 0 {
+		mediaType = mediaType[:i]
+	}
+	return b, strings.TrimSpace(mediaType), nil
+}
+
+func (c *copier) putManifest(repo string, ref string, mediaType string, b []byte, digest string) error {
+	// the same manifest may be already pushed (e.g. to the immutable tag)
+	req, err := http.NewRequest(http.MethodHead, c.dst.url(repo, "manifests", ref), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", mediaType)
+	resp, err := c.dst.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK && resp.Header.Get("Docker-Content-Digest") == digest {
+		c.lg.Info("manifest already exists", zap.String("repo", repo), zap.String("ref", ref))
+		return nil
+	}
+
+	req, err = http.NewRequest(http.MethodPut, c.dst.url(repo, "manifests", ref), strings.NewReader(string(b)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mediaType)
+	resp, err = c.dst.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("failed to put manifest %s:%s (status %q, %q)", repo, ref, resp.Status, string(msg))
+	}
+	c.lg.Info("put manifest", zap.String("repo", repo), zap.String("ref", ref), zap.String("media-type", mediaType))
+	return nil
+}
+
+// copyBlob streams the blob from the source to the destination,
+// in a single chunk upload, unless it already exists.
+func (c *copier) copyBlob(srcRepo string, dstRepo string, desc descriptor) error {
+	req, err := http.NewRequest(http.MethodHead, c.dst.url(dstRepo, "blobs", desc.Digest), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.dst.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	req, err = http.NewRequest(http.MethodGet, c.src.url(srcRepo, "blobs", desc.Digest), nil)
+	if err != nil {
+		return err
+	}
+	// redirected to the storage (e.g. S3) without the authorization header
+	blob, err := c.src.do(req)
+	if err != nil {
+		return err
+	}
+	defer blob.Body.Close()
+	if blob.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get blob %s@%s (status %q)", srcRepo, desc.Digest, blob.Status)
+	}
+
+	req, err = http.NewRequest(http.MethodPost, c.dst.url(dstRepo, "blobs", "uploads/"), nil)
+	if err != nil {
+		return err
+	}
+	resp, err = c.dst.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("failed to start blob upload %s@%s (status %q)", dstRepo, desc.Digest, resp.Status)
+	}
+	loc, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return fmt.Errorf("invalid upload location %q (%v)", resp.Header.Get("Location"), err)
+	}
+
+	req, err = http.NewRequest(http.MethodPatch, loc.String(), blob.Body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = desc.Size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err = c.dst.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("failed to upload blob %s@%s (status %q)", dstRepo, desc.Digest, resp.Status)
+	}
+	if loc, err = resp.Request.URL.Parse(resp.Header.Get("Location")); err != nil {
+		return fmt.Errorf("invalid upload location %q (%v)", resp.Header.Get("Location"), err)
+	}
+	q := loc.Query()
+	q.Set("digest", desc.Digest)
+	loc.RawQuery = q.Encode()
+
+	req, err = http.NewRequest(http.MethodPut, loc.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err = c.dst.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("failed to complete blob upload %s@%s (status %q)", dstRepo, desc.Digest, resp.Status)
+	}
+	c.lg.Info("copied blob", zap.String("repo", dstRepo), zap.String("digest", desc.Digest), zap.Int64("size", desc.Size))
+	return nil
+}
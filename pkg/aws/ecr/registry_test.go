@@ -0,0 +1,238 @@
+package ecr
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestParseReference(t *testing.T) {
+	tt := []struct {
+		img string
+		exp Reference
+	}{
+		{"busybox", Reference{Host: "registry-1.docker.io", Repository: "library/busybox", Tag: "latest"}},
+		{"nginx/nginx:1.25", Reference{Host: "registry-1.docker.io", Repository: "nginx/nginx", Tag: "1.25"}},
+		{"public.ecr.aws/docker/library/busybox:1.36", Reference{Host: "public.ecr.aws", Repository: "docker/library/busybox", Tag: "1.36"}},
+		{"localhost:5000/a/b", Reference{Host: "localhost:5000", Repository: "a/b", Tag: "latest"}},
+		{"quay.io/a/b@sha256:abc", Reference{Host: "quay.io", Repository: "a/b", Digest: "sha256:abc"}},
+	}
+	for i, tv := range tt {
+		ref, err := ParseReference(tv.img)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if ref != tv.exp {
+			t.Fatalf("#%d: expected %+v, got %+v", i, tv.exp, ref)
+		}
+	}
+	for _, img := range []string{"", "Busybox", "busybox@md5:abc"} {
+		if _, err := ParseReference(img); err == nil {
+			t.Fatalf("expected error for %q", img)
+		}
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/busybox:pull"`)
+	if params["realm"] != "https://auth.docker.io/token" ||
+		params["service"] != "registry.docker.io" ||
+		params["scope"] != "repository:library/busybox:pull" {
+		t.Fatalf("unexpected params %v", params)
+	}
+}
+
+func TestReformatManifest(t *testing.T) {
+	for _, m := range []string{`{"a":1}`, "{\n  \"a\": 1\n}"} {
+		r, err := reformatManifest(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r == m {
+			t.Fatalf("expected reformatted %q", m)
+		}
+	}
+}
+
+// fakeRegistry is the in-memory registry, requiring the bearer token
+// if "token" is set, or the basic credentials if "basic" is set.
+type fakeRegistry struct {
+	mu        sync.Mutex
+	token     string
+	basic     string
+	blobs     map[string][]byte
+	manifests map[string][]byte
+	types     map[string]string
+	uploads   map[string][]byte
+	// realm is the token endpoint URL
+	realm string
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{
+		blobs:     make(map[string][]byte),
+		manifests: make(map[string][]byte),
+		types:     make(map[string]string),
+		uploads:   make(map[string][]byte),
+	}
+}
+
+func digestOf(b []byte) string { return fmt.Sprintf("sha256:%x", sha256.Sum256(b)) }
+
+func (f *fakeRegistry) putManifest(ref string, mediaType string, b []byte) string {
+	d := digestOf(b)
+	f.manifests[ref], f.types[ref] = b, mediaType
+	f.manifests[d], f.types[d] = b, mediaType
+	return d
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if req.URL.Path == "/token" {
+		json.NewEncoder(w).Encode(map[string]string{"token": f.token})
+		return
+	}
+	auth := req.Header.Get("Authorization")
+	switch {
+	case f.token != "" && auth != "Bearer "+f.token:
+		w.Header().Set("Www-Authenticate", fmt.Sprintf(`Bearer realm=%q,service="fake"`, f.realm))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	case f.basic != "" && auth != "Basic "+f.basic:
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	// e.g. "/v2/a/b/manifests/latest"
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	switch {
+	case strings.Contains(path, "/manifests/"):
+		ref := path[strings.LastIndex(path, "/")+1:]
+		switch req.Method {
+		case http.MethodGet, http.MethodHead:
+			b, ok := f.manifests[ref]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", f.types[ref])
+			w.Header().Set("Docker-Content-Digest", digestOf(b))
+			if req.Method == http.MethodGet {
+				w.Write(b)
+			}
+		case http.MethodPut:
+			b, _ := ioutil.ReadAll(req.Body)
+			f.putManifest(ref, req.Header.Get("Content-Type"), b)
+			w.WriteHeader(http.StatusCreated)
+		}
+	case strings.HasSuffix(path, "/blobs/uploads/") && req.Method == http.MethodPost:
+		id := fmt.Sprintf("%d", len(f.uploads))
+		f.uploads[id] = nil
+		w.Header().Set("Location", "/upload/"+id)
+		w.WriteHeader(http.StatusAccepted)
+	case strings.Contains(path, "/blobs/"):
+		d := path[strings.LastIndex(path, "/")+1:]
+		b, ok := f.blobs[d]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if req.Method == http.MethodGet {
+			w.Write(b)
+		}
+	case strings.HasPrefix(req.URL.Path, "/upload/"):
+		id := strings.TrimPrefix(req.URL.Path, "/upload/")
+		switch req.Method {
+		case http.MethodPatch:
+			b, _ := ioutil.ReadAll(req.Body)
+			f.uploads[id] = append(f.uploads[id], b...)
+			w.Header().Set("Location", req.URL.Path)
+			w.WriteHeader(http.StatusAccepted)
+		case http.MethodPut:
+			d := req.URL.Query().Get("digest")
+			if digestOf(f.uploads[id]) != d {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			f.blobs[d] = f.uploads[id]
+			w.WriteHeader(http.StatusCreated)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	src, dst := newFakeRegistry(), newFakeRegistry()
+	srcSrv, dstSrv := httptest.NewTLSServer(src), httptest.NewTLSServer(dst)
+	defer srcSrv.Close()
+	defer dstSrv.Close()
+	src.token, src.realm = "pull-token", srcSrv.URL+"/token"
+	dst.basic = "QVdTOnBhc3N3b3Jk"
+
+	config, layer := []byte(`{"architecture":"amd64"}`), []byte("layer")
+	src.blobs[digestOf(config)], src.blobs[digestOf(layer)] = config, layer
+	m, _ := json.Marshal(manifest{
+		MediaType: MediaTypeOCIManifest,
+		Config:    descriptor{MediaType: "application/vnd.oci.image.config.v1+json", Digest: digestOf(config), Size: int64(len(config))},
+		Layers:    []descriptor{{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: digestOf(layer), Size: int64(len(layer))}},
+	})
+	child := src.putManifest("child", MediaTypeOCIManifest, m)
+	idx, _ := json.Marshal(manifest{
+		MediaType: MediaTypeOCIIndex,
+		Manifests: []descriptor{{MediaType: MediaTypeOCIManifest, Digest: child, Size: int64(len(m))}},
+	})
+	idxDigest := src.putManifest("1.0", MediaTypeOCIIndex, idx)
+
+	host := func(s *httptest.Server) string {
+		u, _ := url.Parse(s.URL)
+		return u.Host
+	}
+	c := &copier{
+		lg:  zap.NewExample(),
+		src: &registry{cli: srcSrv.Client(), scheme: "https", host: host(srcSrv)},
+		dst: &registry{cli: dstSrv.Client(), scheme: "https", host: host(dstSrv), basic: dst.basic},
+	}
+	digest, err := c.copy(
+		Reference{Host: host(srcSrv), Repository: "a/b", Tag: "1.0"},
+		Reference{Host: host(dstSrv), Repository: "c", Tag: "test"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != idxDigest {
+		t.Fatalf("expected digest %q, got %q", idxDigest, digest)
+	}
+	if digestOf(dst.manifests["test"]) != idxDigest || dst.types["test"] != MediaTypeOCIIndex {
+		t.Fatalf("unexpected index %q", string(dst.manifests["test"]))
+	}
+	if _, ok := dst.manifests[child]; !ok {
+		t.Fatalf("child manifest %q not copied", child)
+	}
+	if string(dst.blobs[digestOf(config)]) != string(config) || string(dst.blobs[digestOf(layer)]) != string(layer) {
+		t.Fatalf("blobs not copied %v", dst.blobs)
+	}
+
+	// no-op if already copied
+	uploads := len(dst.uploads)
+	if _, err = c.copy(
+		Reference{Host: host(srcSrv), Repository: "a/b", Tag: "1.0"},
+		Reference{Host: host(dstSrv), Repository: "c", Tag: "test"},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(dst.uploads) != uploads {
+		t.Fatalf("expected no uploads, got %d", len(dst.uploads)-uploads)
+	}
+}
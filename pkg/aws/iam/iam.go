@@ -26,6 +26,9 @@ type StatementEntry struct {
 	Action    []string        `json:"Action,omitempty"`
 	Resource  string          `json:"Resource,omitempty"`
 	Principal *PrincipalEntry `json:"Principal,omitempty"`
+	// Condition maps a condition operator (e.g. "StringEquals")
+	// to the condition keys and values.
+	Condition map[string]map[string]string `json:"Condition,omitempty"`
 }
 
 type AssumeRolePolicyDocument struct {
@@ -46,6 +49,8 @@ type AssumeRolePolicyDocumentStatement struct {
 // PrincipalEntry represents the policy document Principal.
 type PrincipalEntry struct {
 	Service []string `json:"Service,omitempty"`
+	// Federated is the identity provider ARN (e.g. OIDC provider for IRSA).
+	Federated string `json:"Federated,omitempty"`
 }
 
 type AssumeRolePolicyDocumentStatementSingle struct {
@@ -0,0 +1,153 @@
+package s3
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	credentials_v2 "github.com/aws/aws-sdk-go-v2/credentials"
+	aws_s3_v2 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"go.uber.org/zap"
+)
+
+type fakeBucketS3API struct {
+	s3iface.S3API
+
+	versioning string
+	objectLock *s3.ObjectLockConfiguration
+}
+
+func (f *fakeBucketS3API) CreateBucket(*s3.CreateBucketInput) (*s3.CreateBucketOutput, error) {
+	return nil, awserr.New(s3.ErrCodeBucketAlreadyOwnedByYou, "already owned", nil)
+}
+
+func (f *fakeBucketS3API) GetBucketVersioning(*s3.GetBucketVersioningInput) (*s3.GetBucketVersioningOutput, error) {
+	out := &s3.GetBucketVersioningOutput{}
+	if f.versioning != "" {
+		out.Status = aws.String(f.versioning)
+	}
+	return out, nil
+}
+
+func (f *fakeBucketS3API) PutBucketVersioning(in *s3.PutBucketVersioningInput) (*s3.PutBucketVersioningOutput, error) {
+	f.versioning = aws.StringValue(in.VersioningConfiguration.Status)
+	return &s3.PutBucketVersioningOutput{}, nil
+}
+
+func (f *fakeBucketS3API) PutObjectLockConfiguration(in *s3.PutObjectLockConfigurationInput) (*s3.PutObjectLockConfigurationOutput, error) {
+	f.objectLock = in.ObjectLockConfiguration
+	return &s3.PutObjectLockConfigurationOutput{}, nil
+}
+
+func Test_createBucketAlreadyExists(t *testing.T) {
+	fake := &fakeBucketS3API{}
+	op := Op{versioning: true, objectLock: true, objectLockRetentionDays: 7}
+	retry, err := createBucket(zap.NewExample(), fake, "test-bucket", "us-west-2", "", 0, op)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retry {
+		t.Fatal("unexpected retry")
+	}
+	if fake.versioning != s3.BucketVersioningStatusEnabled {
+		t.Fatalf("expected versioning enabled, got %q", fake.versioning)
+	}
+	if fake.objectLock == nil || fake.objectLock.Rule == nil {
+		t.Fatalf("expected object lock rule, got %+v", fake.objectLock)
+	}
+	if days := aws.Int64Value(fake.objectLock.Rule.DefaultRetention.Days); days != 7 {
+		t.Fatalf("expected 7-day retention, got %d", days)
+	}
+}
+
+func TestWithBucketAccessHandlers(t *testing.T) {
+	ss := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+	s3API := s3.New(ss)
+	WithBucketAccessHandlers(s3API, WithRequestPayer(true), WithExpectedBucketOwner("123456789012"))
+
+	req, _ := s3API.PutObjectRequest(&s3.PutObjectInput{
+		Bucket: aws.String("test-bucket"),
+		Key:    aws.String("test-key"),
+		ACL:    aws.String("private"),
+	})
+	if err := req.Build(); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{
+		"X-Amz-Request-Payer":         "requester",
+		"X-Amz-Expected-Bucket-Owner": "123456789012",
+		"X-Amz-Acl":                   "bucket-owner-full-control",
+	} {
+		if got := req.HTTPRequest.Header.Get(k); got != v {
+			t.Fatalf("expected %q %q, got %q", k, v, got)
+		}
+	}
+
+	// bucket operations are not changed
+	req, _ = s3API.CreateBucketRequest(&s3.CreateBucketInput{Bucket: aws.String("test-bucket")})
+	if err := req.Build(); err != nil {
+		t.Fatal(err)
+	}
+	if got := req.HTTPRequest.Header.Get("X-Amz-Expected-Bucket-Owner"); got != "" {
+		t.Fatalf("unexpected expected bucket owner %q", got)
+	}
+}
+
+func Test_clientV2CreateBucketAlreadyExists(t *testing.T) {
+	var versioning, objectLock string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		q := r.URL.Query()
+		switch {
+		case r.Method == http.MethodPut && len(q) == 0:
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`<Error><Code>BucketAlreadyOwnedByYou</Code><Message>already owned</Message></Error>`))
+		case r.Method == http.MethodGet && q.Has("versioning"):
+			w.Write([]byte(`<VersioningConfiguration>` + versioning + `</VersioningConfiguration>`))
+		case r.Method == http.MethodPut && q.Has("versioning"):
+			b, _ := ioutil.ReadAll(r.Body)
+			if strings.Contains(string(b), "<Status>Enabled</Status>") {
+				versioning = "<Status>Enabled</Status>"
+			}
+		case r.Method == http.MethodPut && q.Has("object-lock"):
+			b, _ := ioutil.ReadAll(r.Body)
+			objectLock = string(b)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	s3APIV2 := aws_s3_v2.New(aws_s3_v2.Options{
+		Region:       "us-west-2",
+		Credentials:  credentials_v2.NewStaticCredentialsProvider("id", "secret", ""),
+		UsePathStyle: true,
+		EndpointResolver: aws_s3_v2.EndpointResolverFunc(func(region string, options aws_s3_v2.EndpointResolverOptions) (aws_v2.Endpoint, error) {
+			return aws_v2.Endpoint{URL: srv.URL, HostnameImmutable: true}, nil
+		}),
+	})
+	cli := NewClientV2(zap.NewExample(), s3APIV2)
+	if err := cli.CreateBucket(context.Background(), "test-bucket", "us-west-2", "", 0, WithVersioning(true), WithObjectLock(true, 7)); err != nil {
+		t.Fatal(err)
+	}
+	if versioning == "" {
+		t.Fatal("expected versioning enabled")
+	}
+	if !strings.Contains(objectLock, "<Mode>GOVERNANCE</Mode>") || !strings.Contains(objectLock, "<Days>7</Days>") {
+		t.Fatalf("expected 7-day retention, got %q", objectLock)
+	}
+}
@@ -0,0 +1,74 @@
+package s3
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ChecksumMetadataKey is the user-defined object metadata key
+// ("x-amz-meta-sha256") that stores the hex-encoded SHA-256 checksum
+// of the uploaded object.
+const ChecksumMetadataKey = "Sha256"
+
+// ErrChecksumMismatch is returned when the downloaded object does not match
+// the SHA-256 checksum recorded at upload.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+func sha256File(fpath string) (string, error) {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sha256Body computes the checksum of the body and rewinds it,
+// so that it can be uploaded afterwards.
+func sha256Body(body io.ReadSeeker) (string, error) {
+	start, err := body.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err = io.Copy(h, body); err != nil {
+		return "", err
+	}
+	if _, err = body.Seek(start, io.SeekStart); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// checksumFromMetadata returns the recorded checksum, if any.
+// The SDKs differ in how metadata keys are cased in responses.
+func checksumFromMetadata(md map[string]string) string {
+	for k, v := range md {
+		if strings.EqualFold(k, ChecksumMetadataKey) {
+			return v
+		}
+	}
+	return ""
+}
+
+// verifyChecksum returns an error if the computed checksum does not match
+// the recorded one. Objects without a recorded checksum (e.g. uploaded by
+// older versions) are not verified.
+func verifyChecksum(s3Key string, expected string, actual string) error {
+	if expected == "" {
+		return nil
+	}
+	if !strings.EqualFold(expected, actual) {
+		return fmt.Errorf("%w for %q (expected sha256 %s, got %s)", ErrChecksumMismatch, s3Key, expected, actual)
+	}
+	return nil
+}
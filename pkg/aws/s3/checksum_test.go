@@ -0,0 +1,47 @@
+package s3
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func Test_sha256Body(t *testing.T) {
+	body := strings.NewReader("hello world")
+	cs, err := sha256Body(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs != "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9" {
+		t.Fatalf("unexpected checksum %q", cs)
+	}
+	// body must be rewound for the upload
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello world" {
+		t.Fatalf("unexpected body %q", string(b))
+	}
+}
+
+func Test_verifyChecksum(t *testing.T) {
+	tests := []struct {
+		metadata map[string]string
+		actual   string
+		err      error
+	}{
+		{metadata: map[string]string{"Sha256": "abc"}, actual: "abc"},
+		{metadata: map[string]string{"sha256": "ABC"}, actual: "abc"},
+		{metadata: map[string]string{"sha256": "abc"}, actual: "def", err: ErrChecksumMismatch},
+		{metadata: map[string]string{"Kind": "aws-k8s-tester"}, actual: "def"},
+		{metadata: nil, actual: "def"},
+	}
+	for i, tv := range tests {
+		err := verifyChecksum("key", checksumFromMetadata(tv.metadata), tv.actual)
+		if !errors.Is(err, tv.err) {
+			t.Fatalf("#%d: expected error %v, got %v", i, tv.err, err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package s3
+
+import (
+	"context"
+	"io"
+
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"go.uber.org/zap"
+)
+
+// Client defines S3 operations that are shared by the aws-sdk-go (v1)
+// and aws-sdk-go-v2 implementations, so that testers can migrate
+// from one to the other incrementally.
+type Client interface {
+	// CreateBucket creates a S3 bucket.
+	CreateBucket(ctx context.Context, bucket string, region string, lifecyclePrefix string, lifecycleExpirationDays int64, opts ...OpOption) error
+	// Upload uploads a file to S3 bucket.
+	Upload(ctx context.Context, bucket string, s3Key string, fpath string) error
+	// UploadBody uploads the body reader to S3.
+	UploadBody(ctx context.Context, bucket string, s3Key string, body io.ReadSeeker) error
+	// Exist returns true if the object exists.
+	Exist(ctx context.Context, bucket string, s3Key string) (bool, error)
+	// Download downloads the file from the S3 bucket.
+	Download(ctx context.Context, bucket string, s3Key string, localPath string, opts ...OpOption) error
+	// DownloadDir downloads all files from the directory in the S3 bucket,
+	// and verifies the checksums recorded at upload.
+	DownloadDir(ctx context.Context, bucket string, s3Dir string, opts ...OpOption) (string, error)
+	// EmptyBucket empties S3 bucket, by deleting all objects and object versions.
+	EmptyBucket(ctx context.Context, bucket string) error
+	// DeleteBucket deletes S3 bucket.
+	DeleteBucket(ctx context.Context, bucket string) error
+}
+
+// NewClient wraps the aws-sdk-go (v1) S3 API with the common interface.
+// The v1 calls are not context-aware, so the context is only checked
+// before each operation.
+func NewClient(lg *zap.Logger, s3API s3iface.S3API) Client {
+	return &clientV1{lg: lg, s3API: s3API}
+}
+
+type clientV1 struct {
+	lg    *zap.Logger
+	s3API s3iface.S3API
+}
+
+func (c *clientV1) CreateBucket(ctx context.Context, bucket string, region string, lifecyclePrefix string, lifecycleExpirationDays int64, opts ...OpOption) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return CreateBucket(c.lg, c.s3API, bucket, region, lifecyclePrefix, lifecycleExpirationDays, opts...)
+}
+
+func (c *clientV1) Upload(ctx context.Context, bucket string, s3Key string, fpath string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return Upload(c.lg, c.s3API, bucket, s3Key, fpath)
+}
+
+func (c *clientV1) UploadBody(ctx context.Context, bucket string, s3Key string, body io.ReadSeeker) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return UploadBody(c.lg, c.s3API, bucket, s3Key, body)
+}
+
+func (c *clientV1) Exist(ctx context.Context, bucket string, s3Key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return Exist(c.lg, c.s3API, bucket, s3Key)
+}
+
+func (c *clientV1) Download(ctx context.Context, bucket string, s3Key string, localPath string, opts ...OpOption) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return Download(c.lg, c.s3API, bucket, s3Key, localPath, opts...)
+}
+
+func (c *clientV1) DownloadDir(ctx context.Context, bucket string, s3Dir string, opts ...OpOption) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return DownloadDir(c.lg, c.s3API, bucket, s3Dir, opts...)
+}
+
+func (c *clientV1) EmptyBucket(ctx context.Context, bucket string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return EmptyBucket(c.lg, c.s3API, bucket)
+}
+
+func (c *clientV1) DeleteBucket(ctx context.Context, bucket string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return DeleteBucket(c.lg, c.s3API, bucket)
+}
@@ -3,6 +3,8 @@ package s3
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -27,17 +29,22 @@ import (
 )
 
 // CreateBucket creates a S3 bucket.
+// Use "WithVersioning" and "WithObjectLock" to keep immutable artifacts.
 func CreateBucket(
 	lg *zap.Logger,
 	s3API s3iface.S3API,
 	bucket string,
 	region string,
 	lifecyclePrefix string,
-	lifecycleExpirationDays int64) (err error) {
+	lifecycleExpirationDays int64,
+	opts ...OpOption) (err error) {
+
+	ret := Op{verbose: false, overwrite: false}
+	ret.applyOpts(opts)
 
 	var retry bool
 	for i := 0; i < 5; i++ {
-		retry, err = createBucket(lg, s3API, bucket, region, lifecyclePrefix, lifecycleExpirationDays)
+		retry, err = createBucket(lg, s3API, bucket, region, lifecyclePrefix, lifecycleExpirationDays, ret)
 		if err == nil {
 			break
 		}
@@ -57,15 +64,25 @@ func createBucket(
 	bucket string,
 	region string,
 	lifecyclePrefix string,
-	lifecycleExpirationDays int64) (retry bool, err error) {
+	lifecycleExpirationDays int64,
+	op Op) (retry bool, err error) {
 
-	lg.Info("creating S3 bucket", zap.String("name", bucket))
+	lg.Info("creating S3 bucket",
+		zap.String("name", bucket),
+		zap.Bool("versioning", op.versioning),
+		zap.Bool("object-lock", op.objectLock),
+	)
 	createBucketInput := &s3.CreateBucketInput{
 		Bucket: aws.String(bucket),
 		// https://docs.aws.amazon.com/AmazonS3/latest/dev/acl-overview.html#canned-acl
 		// vs. "public-read"
 		ACL: aws.String("private"),
 	}
+	// object lock can only be enabled at bucket creation
+	// ref. https://docs.aws.amazon.com/AmazonS3/latest/userguide/object-lock-overview.html
+	if op.objectLock {
+		createBucketInput.ObjectLockEnabledForBucket = aws.Bool(true)
+	}
 	// Setting LocationConstraint to us-east-1 fails with InvalidLocationConstraint. This region is handled differerntly and must be omitted.
 	// https://github.com/boto/boto3/issues/125
 	if region != "us-east-1" {
@@ -101,6 +118,11 @@ func createBucket(
 		}
 	}
 	if alreadyExist {
+		// a retry after a partial failure, or a pre-existing bucket,
+		// still needs the requested versioning and object lock
+		if err = applyBucketVersioning(lg, s3API, bucket, op, false); err != nil {
+			return false, err
+		}
 		return false, nil
 	}
 	lg.Info("created S3 bucket", zap.String("s3-bucket", bucket))
@@ -140,10 +162,72 @@ func createBucket(
 		}
 	}
 
+	if err = applyBucketVersioning(lg, s3API, bucket, op, true); err != nil {
+		return true, err
+	}
+
 	return false, nil
 }
 
+// applyBucketVersioning enables the versioning and the object lock
+// on the bucket, if requested. Enabling object lock at the bucket creation
+// automatically enables versioning, while an existing bucket needs
+// versioning enabled before object lock.
+// ref. https://docs.aws.amazon.com/AmazonS3/latest/userguide/object-lock-configure.html
+func applyBucketVersioning(lg *zap.Logger, s3API s3iface.S3API, bucket string, op Op, created bool) error {
+	if op.versioning || (op.objectLock && !created) {
+		out, err := s3API.GetBucketVersioning(&s3.GetBucketVersioningInput{Bucket: aws.String(bucket)})
+		if err != nil {
+			return fmt.Errorf("failed to get bucket %q versioning (%v)", bucket, err)
+		}
+		if aws.StringValue(out.Status) == s3.BucketVersioningStatusEnabled {
+			lg.Info("bucket versioning already enabled", zap.String("s3-bucket", bucket))
+		} else {
+			lg.Info("enabling bucket versioning", zap.String("s3-bucket", bucket))
+			_, err = s3API.PutBucketVersioning(&s3.PutBucketVersioningInput{
+				Bucket: aws.String(bucket),
+				VersioningConfiguration: &s3.VersioningConfiguration{
+					Status: aws.String(s3.BucketVersioningStatusEnabled),
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to enable bucket %q versioning (%v)", bucket, err)
+			}
+		}
+	}
+
+	if !op.objectLock || (created && op.objectLockRetentionDays <= 0) {
+		return nil
+	}
+	cfg := &s3.ObjectLockConfiguration{
+		ObjectLockEnabled: aws.String(s3.ObjectLockEnabledEnabled),
+	}
+	if op.objectLockRetentionDays > 0 {
+		cfg.Rule = &s3.ObjectLockRule{
+			DefaultRetention: &s3.DefaultRetention{
+				// "GOVERNANCE" mode allows privileged users to bypass the retention
+				// (e.g. "EmptyBucket"), unlike "COMPLIANCE" mode
+				Mode: aws.String(s3.ObjectLockRetentionModeGovernance),
+				Days: aws.Int64(op.objectLockRetentionDays),
+			},
+		}
+	}
+	lg.Info("configuring bucket object lock",
+		zap.String("s3-bucket", bucket),
+		zap.String("mode", s3.ObjectLockRetentionModeGovernance),
+		zap.Int64("retention-days", op.objectLockRetentionDays),
+	)
+	if _, err := s3API.PutObjectLockConfiguration(&s3.PutObjectLockConfigurationInput{
+		Bucket:                  aws.String(bucket),
+		ObjectLockConfiguration: cfg,
+	}); err != nil {
+		return fmt.Errorf("failed to configure bucket %q object lock (%v)", bucket, err)
+	}
+	return nil
+}
+
 // Upload uploads a file to S3 bucket.
+// The SHA-256 checksum of the file is recorded in the object metadata.
 func Upload(
 	lg *zap.Logger,
 	s3API s3iface.S3API,
@@ -159,11 +243,16 @@ func Upload(
 		return err
 	}
 	size := humanize.Bytes(uint64(stat.Size()))
+	checksum, err := sha256File(fpath)
+	if err != nil {
+		return err
+	}
 
 	lg.Info("uploading",
 		zap.String("s3-bucket", bucket),
 		zap.String("remote-path", s3Key),
 		zap.String("file-size", size),
+		zap.String("sha256", checksum),
 	)
 
 	rf, err := os.OpenFile(fpath, os.O_RDONLY, 0444)
@@ -185,8 +274,9 @@ func Upload(
 			ACL: aws.String("private"),
 
 			Metadata: map[string]*string{
-				"Kind": aws.String("aws-k8s-tester"),
-				"User": aws.String(user.Get()),
+				"Kind":              aws.String("aws-k8s-tester"),
+				"User":              aws.String(user.Get()),
+				ChecksumMetadataKey: aws.String(checksum),
 			},
 		})
 		if err == nil {
@@ -220,6 +310,7 @@ func Upload(
 }
 
 // UploadBody uploads the body reader to S3.
+// The SHA-256 checksum of the body is recorded in the object metadata.
 func UploadBody(
 	lg *zap.Logger,
 	s3API s3iface.S3API,
@@ -227,9 +318,14 @@ func UploadBody(
 	s3Key string,
 	body io.ReadSeeker) (err error) {
 
+	checksum, err := sha256Body(body)
+	if err != nil {
+		return err
+	}
 	lg.Info("uploading",
 		zap.String("s3-bucket", bucket),
 		zap.String("remote-path", s3Key),
+		zap.String("sha256", checksum),
 	)
 	var output *s3.PutObjectOutput
 	output, err = s3API.PutObject(&s3.PutObjectInput{
@@ -243,8 +339,9 @@ func UploadBody(
 		ACL: aws.String("private"),
 
 		Metadata: map[string]*string{
-			"Kind": aws.String("aws-k8s-tester"),
-			"User": aws.String(user.Get()),
+			"Kind":              aws.String("aws-k8s-tester"),
+			"User":              aws.String(user.Get()),
+			ChecksumMetadataKey: aws.String(checksum),
 		},
 	})
 	if err == nil {
@@ -290,10 +387,66 @@ func EmptyBucket(lg *zap.Logger, s3API s3iface.S3API, bucket string) error {
 		lg.Warn("failed to empty bucket", zap.String("s3-bucket", bucket), zap.Error(err))
 		return err
 	}
+	if err = deleteVersions(lg, s3API, bucket); err != nil {
+		lg.Warn("failed to delete object versions", zap.String("s3-bucket", bucket), zap.Error(err))
+		return err
+	}
 	lg.Info("emptied bucket", zap.String("s3-bucket", bucket))
 	return nil
 }
 
+// deleteVersions deletes all object versions and delete markers
+// left over in a versioned bucket, bypassing "GOVERNANCE" mode retention.
+func deleteVersions(lg *zap.Logger, s3API s3iface.S3API, bucket string) error {
+	ids := make([]*s3.ObjectIdentifier, 0)
+	err := s3API.ListObjectVersionsPages(
+		&s3.ListObjectVersionsInput{
+			Bucket: aws.String(bucket),
+		},
+		func(page *s3.ListObjectVersionsOutput, lastPage bool) bool {
+			for _, v := range page.Versions {
+				ids = append(ids, &s3.ObjectIdentifier{Key: v.Key, VersionId: v.VersionId})
+			}
+			for _, v := range page.DeleteMarkers {
+				ids = append(ids, &s3.ObjectIdentifier{Key: v.Key, VersionId: v.VersionId})
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	lg.Info("deleting object versions", zap.String("s3-bucket", bucket), zap.Int("versions", len(ids)))
+	// DeleteObjects accepts up to 1,000 keys per request
+	for len(ids) > 0 {
+		n := len(ids)
+		if n > 1000 {
+			n = 1000
+		}
+		out, err := s3API.DeleteObjects(&s3.DeleteObjectsInput{
+			Bucket:                    aws.String(bucket),
+			BypassGovernanceRetention: aws.Bool(true),
+			Delete: &s3.Delete{
+				Objects: ids[:n],
+				Quiet:   aws.Bool(true),
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if len(out.Errors) > 0 {
+			return fmt.Errorf("failed to delete %d object versions (first error %q)", len(out.Errors), aws.StringValue(out.Errors[0].Message))
+		}
+		ids = ids[n:]
+	}
+	lg.Info("deleted object versions", zap.String("s3-bucket", bucket))
+	return nil
+}
+
 // DeleteBucket deletes S3 bucket.
 func DeleteBucket(lg *zap.Logger, s3API s3iface.S3API, bucket string) error {
 	lg.Info("deleting bucket", zap.String("s3-bucket", bucket))
@@ -355,6 +508,42 @@ func ListInDescendingLastModified(lg *zap.Logger, s3API s3iface.S3API, bucket st
 	return s3Objects, nil
 }
 
+// ListVersions returns all versions of the s3 objects under the prefix,
+// which are sorted in "descending" order of last modified timestamps.
+// Delete markers are not included. Useful to compare artifacts across
+// runs when the bucket is versioned.
+func ListVersions(lg *zap.Logger, s3API s3iface.S3API, bucket string, s3KeyPfx string) (versions []*s3.ObjectVersion, err error) {
+	lg.Info("listing object versions", zap.String("s3-bucket", bucket), zap.String("s3-key-prefix", s3KeyPfx))
+	versions = make([]*s3.ObjectVersion, 0)
+	err = s3API.ListObjectVersionsPages(
+		&s3.ListObjectVersionsInput{
+			Bucket: aws.String(bucket),
+			Prefix: aws.String(s3KeyPfx),
+		},
+		func(resp *s3.ListObjectVersionsOutput, lastPage bool) bool {
+			versions = append(versions, resp.Versions...)
+			return true
+		},
+	)
+	if err != nil {
+		lg.Warn("failed to list object versions", zap.String("s3-bucket", bucket), zap.String("s3-key-prefix", s3KeyPfx), zap.Error(err))
+		return nil, err
+	}
+
+	// sort in "LastModified" descending order
+	sort.Slice(versions, func(i, j int) bool {
+		t1 := aws.TimeValue(versions[i].LastModified)
+		t2 := aws.TimeValue(versions[j].LastModified)
+		return t1.After(t2)
+	})
+	lg.Info("listed object versions",
+		zap.String("s3-bucket", bucket),
+		zap.String("s3-key-prefix", s3KeyPfx),
+		zap.Int("s3-object-versions", len(versions)),
+	)
+	return versions, nil
+}
+
 // Exist returns true if the object exists.
 func Exist(lg *zap.Logger, s3API s3iface.S3API, bucket string, s3Key string, opts ...OpOption) (exist bool, err error) {
 	ret := Op{verbose: false, overwrite: false}
@@ -551,7 +740,8 @@ func download(lg *zap.Logger, s3API s3iface.S3API, bucket string, s3Key string,
 			return err
 		}
 	}
-	n, err := io.Copy(f, resp.Body)
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), resp.Body)
 	f.Close()
 	resp.Body.Close()
 	if err != nil {
@@ -562,6 +752,10 @@ func download(lg *zap.Logger, s3API s3iface.S3API, bucket string, s3Key string,
 		)
 		return err
 	}
+	if err = verifyChecksum(s3Key, checksumFromMetadata(aws.StringValueMap(resp.Metadata)), hex.EncodeToString(h.Sum(nil))); err != nil {
+		lg.Warn("corrupted object download", zap.String("s3-bucket", bucket), zap.String("s3-key", s3Key), zap.Error(err))
+		return err
+	}
 
 	lg.Info("downloaded object",
 		zap.String("s3-bucket", bucket),
@@ -573,6 +767,8 @@ func download(lg *zap.Logger, s3API s3iface.S3API, bucket string, s3Key string,
 }
 
 // DownloadDir downloads all files from the directory in the S3 bucket.
+// It returns "ErrChecksumMismatch" if any object does not match the checksum
+// recorded at upload.
 func DownloadDir(lg *zap.Logger, s3API s3iface.S3API, bucket string, s3Dir string, opts ...OpOption) (targetDir string, err error) {
 	ret := Op{verbose: false, overwrite: false}
 	ret.applyOpts(opts)
@@ -641,10 +837,17 @@ func DownloadDir(lg *zap.Logger, s3API s3iface.S3API, bucket string, s3Dir strin
 				continue
 			}
 		}
-		n, err := io.Copy(f, resp.Body)
+		h := sha256.New()
+		n, err := io.Copy(io.MultiWriter(f, h), resp.Body)
 		f.Close()
 		resp.Body.Close()
 		if err == nil {
+			// fail loudly, corrupted artifacts must not be silently skipped
+			if err = verifyChecksum(s3Key, checksumFromMetadata(aws.StringValueMap(resp.Metadata)), hex.EncodeToString(h.Sum(nil))); err != nil {
+				lg.Warn("corrupted object download", zap.String("s3-bucket", bucket), zap.String("s3-key", s3Key), zap.Error(err))
+				os.RemoveAll(targetDir)
+				return "", err
+			}
 			lg.Info("downloaded object",
 				zap.String("s3-key", s3Key),
 				zap.String("object-size", humanize.Bytes(uint64(aws.Int64Value(obj.Size)))),
@@ -672,6 +875,13 @@ type Op struct {
 	verbose   bool
 	overwrite bool
 	timeout   time.Duration
+
+	versioning              bool
+	objectLock              bool
+	objectLockRetentionDays int64
+
+	requestPayer        bool
+	expectedBucketOwner string
 }
 
 // OpOption configures archiver operations.
@@ -692,6 +902,88 @@ func WithTimeout(timeout time.Duration) OpOption {
 	return func(op *Op) { op.timeout = timeout }
 }
 
+// WithVersioning enables versioning on bucket creation.
+func WithVersioning(b bool) OpOption {
+	return func(op *Op) { op.versioning = b }
+}
+
+// WithObjectLock enables object lock on bucket creation, with the default
+// retention in "GOVERNANCE" mode. Object lock implies versioning.
+// Zero retention days only enables object lock without default retention.
+func WithObjectLock(b bool, retentionDays int64) OpOption {
+	return func(op *Op) {
+		op.objectLock = b
+		op.objectLockRetentionDays = retentionDays
+	}
+}
+
+// WithRequestPayer configures the requester to pay for the requests
+// and data transfer to requester pays buckets.
+// Supported by the aws-sdk-go-v2 client (see "NewClientV2"),
+// and by the aws-sdk-go client with "WithBucketAccessHandlers".
+func WithRequestPayer(b bool) OpOption {
+	return func(op *Op) { op.requestPayer = b }
+}
+
+// WithExpectedBucketOwner configures the account ID of the expected
+// bucket owner, so that requests fail if the bucket is owned by
+// a different account. Uploaded objects grant full control to the
+// bucket owner. Supported by the aws-sdk-go-v2 client (see "NewClientV2"),
+// and by the aws-sdk-go client with "WithBucketAccessHandlers".
+func WithExpectedBucketOwner(accountID string) OpOption {
+	return func(op *Op) { op.expectedBucketOwner = accountID }
+}
+
+// bucketAccessOperations are the object operations that accept
+// the request payer and the expected bucket owner headers.
+var bucketAccessOperations = map[string]bool{
+	"PutObject":          true,
+	"GetObject":          true,
+	"HeadObject":         true,
+	"CopyObject":         true,
+	"DeleteObject":       true,
+	"DeleteObjects":      true,
+	"ListObjects":        true,
+	"ListObjectsV2":      true,
+	"ListObjectVersions": true,
+
+	"CreateMultipartUpload":   true,
+	"UploadPart":              true,
+	"CompleteMultipartUpload": true,
+	"AbortMultipartUpload":    true,
+}
+
+// WithBucketAccessHandlers applies "WithRequestPayer" and
+// "WithExpectedBucketOwner" to every object request of the aws-sdk-go
+// client (e.g. "Upload", "UploadBody", "DownloadToTempFile"), the same
+// as the aws-sdk-go-v2 client, so that the artifacts uploaded by the
+// add-ons are readable by the bucket owner in cross-account runs.
+func WithBucketAccessHandlers(s3API *s3.S3, opts ...OpOption) {
+	op := Op{}
+	op.applyOpts(opts)
+	if !op.requestPayer && op.expectedBucketOwner == "" {
+		return
+	}
+	s3API.Handlers.Build.PushBackNamed(request.NamedHandler{
+		Name: "aws-k8s-tester.s3.BucketAccess",
+		Fn: func(r *request.Request) {
+			if !bucketAccessOperations[r.Operation.Name] {
+				return
+			}
+			if op.requestPayer {
+				r.HTTPRequest.Header.Set("X-Amz-Request-Payer", s3.RequestPayerRequester)
+			}
+			if op.expectedBucketOwner != "" {
+				r.HTTPRequest.Header.Set("X-Amz-Expected-Bucket-Owner", op.expectedBucketOwner)
+				if r.Operation.Name == "PutObject" || r.Operation.Name == "CreateMultipartUpload" {
+					// "private" would leave the object unreadable to the bucket owner
+					r.HTTPRequest.Header.Set("X-Amz-Acl", s3.ObjectCannedACLBucketOwnerFullControl)
+				}
+			}
+		},
+	})
+}
+
 func (op *Op) applyOpts(opts []OpOption) {
 	for _, opt := range opts {
 		opt(op)
@@ -0,0 +1,604 @@
+package s3
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-k8s-tester/pkg/fileutil"
+	"github.com/aws/aws-k8s-tester/pkg/user"
+	aws_v2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	aws_s3_v2 "github.com/aws/aws-sdk-go-v2/service/s3"
+	aws_s3_v2_types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	aws_sts_v2 "github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+	"github.com/dustin/go-humanize"
+	"go.uber.org/zap"
+)
+
+// WithRetryerV2 configures the standard retryer of the aws-sdk-go-v2 S3 client.
+// Zero "maxAttempts" or "maxBackoff" keeps the SDK default.
+// e.g. aws_s3_v2.NewFromConfig(cfg, WithRetryerV2(10, 30*time.Second))
+func WithRetryerV2(maxAttempts int, maxBackoff time.Duration) func(*aws_s3_v2.Options) {
+	return func(o *aws_s3_v2.Options) {
+		o.Retryer = retry.NewStandard(func(so *retry.StandardOptions) {
+			if maxAttempts > 0 {
+				so.MaxAttempts = maxAttempts
+			}
+			if maxBackoff > 0 {
+				so.MaxBackoff = maxBackoff
+			}
+		})
+	}
+}
+
+// WithAssumeRoleV2 configures the aws-sdk-go-v2 S3 client to use the
+// credentials of the assumed role, without changing the credentials of
+// other clients (e.g. to write to a bucket in a central account).
+// e.g. aws_s3_v2.NewFromConfig(cfg, WithAssumeRoleV2(aws_sts_v2.NewFromConfig(cfg), roleARN, ""))
+func WithAssumeRoleV2(stsAPIV2 *aws_sts_v2.Client, roleARN string, externalID string) func(*aws_s3_v2.Options) {
+	return func(o *aws_s3_v2.Options) {
+		o.Credentials = aws_v2.NewCredentialsCache(stscreds.NewAssumeRoleProvider(stsAPIV2, roleARN, func(ao *stscreds.AssumeRoleOptions) {
+			ao.RoleSessionName = "aws-k8s-tester-s3-" + user.Get()
+			if externalID != "" {
+				ao.ExternalID = aws_v2.String(externalID)
+			}
+		}))
+	}
+}
+
+// NewClientV2 implements the common interface with the aws-sdk-go-v2 S3 client.
+// Every API call is bound to the context of the operation. Retries on
+// transient errors are handled by the retryer of the client
+// (see "WithRetryerV2"). Use "WithRequestPayer" and "WithExpectedBucketOwner"
+// to access requester pays or cross-account buckets.
+func NewClientV2(lg *zap.Logger, s3APIV2 *aws_s3_v2.Client, opts ...OpOption) Client {
+	ret := Op{}
+	ret.applyOpts(opts)
+	return &clientV2{lg: lg, s3APIV2: s3APIV2, op: ret}
+}
+
+type clientV2 struct {
+	lg      *zap.Logger
+	s3APIV2 *aws_s3_v2.Client
+	// op is the client-wide options (e.g. request payer).
+	op Op
+}
+
+func (c *clientV2) requestPayer() aws_s3_v2_types.RequestPayer {
+	if c.op.requestPayer {
+		return aws_s3_v2_types.RequestPayerRequester
+	}
+	return ""
+}
+
+func (c *clientV2) expectedBucketOwner() *string {
+	if c.op.expectedBucketOwner == "" {
+		return nil
+	}
+	return aws_v2.String(c.op.expectedBucketOwner)
+}
+
+func (c *clientV2) CreateBucket(ctx context.Context, bucket string, region string, lifecyclePrefix string, lifecycleExpirationDays int64, opts ...OpOption) (err error) {
+	ret := Op{verbose: false, overwrite: false}
+	ret.applyOpts(opts)
+
+	for i := 0; i < 5; i++ {
+		err = c.createBucket(ctx, bucket, region, lifecyclePrefix, lifecycleExpirationDays, ret)
+		if err == nil {
+			break
+		}
+		// the SDK retryer does not retry conflicting operations
+		var apiErr smithy.APIError
+		if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "OperationAborted" {
+			return err
+		}
+		c.lg.Warn("failed to create bucket; retrying", zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+	}
+	return err
+}
+
+func (c *clientV2) createBucket(ctx context.Context, bucket string, region string, lifecyclePrefix string, lifecycleExpirationDays int64, op Op) (err error) {
+	c.lg.Info("creating S3 bucket",
+		zap.String("name", bucket),
+		zap.Bool("versioning", op.versioning),
+		zap.Bool("object-lock", op.objectLock),
+	)
+	createBucketInput := &aws_s3_v2.CreateBucketInput{
+		Bucket: aws_v2.String(bucket),
+		// https://docs.aws.amazon.com/AmazonS3/latest/dev/acl-overview.html#canned-acl
+		// vs. "public-read"
+		ACL: aws_s3_v2_types.BucketCannedACLPrivate,
+		// object lock can only be enabled at bucket creation
+		// ref. https://docs.aws.amazon.com/AmazonS3/latest/userguide/object-lock-overview.html
+		ObjectLockEnabledForBucket: op.objectLock,
+	}
+	// Setting LocationConstraint to us-east-1 fails with InvalidLocationConstraint. This region is handled differerntly and must be omitted.
+	// https://github.com/boto/boto3/issues/125
+	if region != "us-east-1" {
+		createBucketInput.CreateBucketConfiguration = &aws_s3_v2_types.CreateBucketConfiguration{
+			LocationConstraint: aws_s3_v2_types.BucketLocationConstraint(region),
+		}
+	}
+	_, err = c.s3APIV2.CreateBucket(ctx, createBucketInput)
+	if err != nil {
+		var alreadyExists *aws_s3_v2_types.BucketAlreadyExists
+		var alreadyOwned *aws_s3_v2_types.BucketAlreadyOwnedByYou
+		switch {
+		case errors.As(err, &alreadyExists):
+			c.lg.Warn("bucket already exists", zap.String("s3-bucket", bucket), zap.Error(err))
+		case errors.As(err, &alreadyOwned):
+			c.lg.Warn("bucket already owned by me", zap.String("s3-bucket", bucket), zap.Error(err))
+		default:
+			c.lg.Warn("failed to create bucket", zap.String("s3-bucket", bucket), zap.Error(err))
+			return err
+		}
+		// a retry after a partial failure, or a pre-existing bucket,
+		// still needs the requested versioning and object lock
+		return c.applyBucketVersioning(ctx, bucket, op, false)
+	}
+	c.lg.Info("created S3 bucket", zap.String("s3-bucket", bucket))
+
+	_, err = c.s3APIV2.PutBucketTagging(ctx, &aws_s3_v2.PutBucketTaggingInput{
+		Bucket:              aws_v2.String(bucket),
+		ExpectedBucketOwner: c.expectedBucketOwner(),
+		Tagging: &aws_s3_v2_types.Tagging{TagSet: []aws_s3_v2_types.Tag{
+			{Key: aws_v2.String("Kind"), Value: aws_v2.String("aws-k8s-tester")},
+			{Key: aws_v2.String("Creation"), Value: aws_v2.String(time.Now().String())},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	if lifecyclePrefix != "" && lifecycleExpirationDays > 0 {
+		_, err = c.s3APIV2.PutBucketLifecycleConfiguration(ctx, &aws_s3_v2.PutBucketLifecycleConfigurationInput{
+			Bucket:              aws_v2.String(bucket),
+			ExpectedBucketOwner: c.expectedBucketOwner(),
+			LifecycleConfiguration: &aws_s3_v2_types.BucketLifecycleConfiguration{
+				Rules: []aws_s3_v2_types.LifecycleRule{
+					{
+						Filter: &aws_s3_v2_types.LifecycleRuleFilterMemberPrefix{Value: lifecyclePrefix},
+						AbortIncompleteMultipartUpload: &aws_s3_v2_types.AbortIncompleteMultipartUpload{
+							DaysAfterInitiation: int32(lifecycleExpirationDays),
+						},
+						Expiration: &aws_s3_v2_types.LifecycleExpiration{
+							Days: int32(lifecycleExpirationDays),
+						},
+						ID:     aws_v2.String(fmt.Sprintf("ObjectLifecycleOf%vDays", lifecycleExpirationDays)),
+						Status: aws_s3_v2_types.ExpirationStatusEnabled,
+					},
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.applyBucketVersioning(ctx, bucket, op, true)
+}
+
+// applyBucketVersioning enables the versioning and the object lock
+// on the bucket, if requested. Enabling object lock at the bucket creation
+// automatically enables versioning, while an existing bucket needs
+// versioning enabled before object lock.
+// ref. https://docs.aws.amazon.com/AmazonS3/latest/userguide/object-lock-configure.html
+func (c *clientV2) applyBucketVersioning(ctx context.Context, bucket string, op Op, created bool) error {
+	if op.versioning || (op.objectLock && !created) {
+		out, err := c.s3APIV2.GetBucketVersioning(ctx, &aws_s3_v2.GetBucketVersioningInput{
+			Bucket:              aws_v2.String(bucket),
+			ExpectedBucketOwner: c.expectedBucketOwner(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get bucket %q versioning (%v)", bucket, err)
+		}
+		if out.Status == aws_s3_v2_types.BucketVersioningStatusEnabled {
+			c.lg.Info("bucket versioning already enabled", zap.String("s3-bucket", bucket))
+		} else {
+			c.lg.Info("enabling bucket versioning", zap.String("s3-bucket", bucket))
+			_, err = c.s3APIV2.PutBucketVersioning(ctx, &aws_s3_v2.PutBucketVersioningInput{
+				Bucket:              aws_v2.String(bucket),
+				ExpectedBucketOwner: c.expectedBucketOwner(),
+				VersioningConfiguration: &aws_s3_v2_types.VersioningConfiguration{
+					Status: aws_s3_v2_types.BucketVersioningStatusEnabled,
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to enable bucket %q versioning (%v)", bucket, err)
+			}
+		}
+	}
+
+	if !op.objectLock || (created && op.objectLockRetentionDays <= 0) {
+		return nil
+	}
+	cfg := &aws_s3_v2_types.ObjectLockConfiguration{
+		ObjectLockEnabled: aws_s3_v2_types.ObjectLockEnabledEnabled,
+	}
+	if op.objectLockRetentionDays > 0 {
+		cfg.Rule = &aws_s3_v2_types.ObjectLockRule{
+			DefaultRetention: &aws_s3_v2_types.DefaultRetention{
+				// "GOVERNANCE" mode allows privileged users to bypass the retention
+				// (e.g. "EmptyBucket"), unlike "COMPLIANCE" mode
+				Mode: aws_s3_v2_types.ObjectLockRetentionModeGovernance,
+				Days: int32(op.objectLockRetentionDays),
+			},
+		}
+	}
+	c.lg.Info("configuring bucket object lock",
+		zap.String("s3-bucket", bucket),
+		zap.String("mode", string(aws_s3_v2_types.ObjectLockRetentionModeGovernance)),
+		zap.Int64("retention-days", op.objectLockRetentionDays),
+	)
+	if _, err := c.s3APIV2.PutObjectLockConfiguration(ctx, &aws_s3_v2.PutObjectLockConfigurationInput{
+		Bucket:                  aws_v2.String(bucket),
+		ExpectedBucketOwner:     c.expectedBucketOwner(),
+		RequestPayer:            c.requestPayer(),
+		ObjectLockConfiguration: cfg,
+	}); err != nil {
+		return fmt.Errorf("failed to configure bucket %q object lock (%v)", bucket, err)
+	}
+	return nil
+}
+
+func (c *clientV2) Upload(ctx context.Context, bucket string, s3Key string, fpath string) error {
+	if !fileutil.Exist(fpath) {
+		return fmt.Errorf("file %q does not exist; failed to upload to %s/%s", fpath, bucket, s3Key)
+	}
+	stat, err := os.Stat(fpath)
+	if err != nil {
+		return err
+	}
+	size := humanize.Bytes(uint64(stat.Size()))
+
+	rf, err := os.OpenFile(fpath, os.O_RDONLY, 0444)
+	if err != nil {
+		c.lg.Warn("failed to read a file", zap.String("file-path", fpath), zap.Error(err))
+		return err
+	}
+	defer rf.Close()
+
+	c.lg.Info("uploading",
+		zap.String("s3-bucket", bucket),
+		zap.String("remote-path", s3Key),
+		zap.String("file-size", size),
+	)
+	if err = c.putObject(ctx, bucket, s3Key, rf); err != nil {
+		return err
+	}
+	c.lg.Info("uploaded",
+		zap.String("s3-bucket", bucket),
+		zap.String("remote-path", s3Key),
+		zap.String("file-size", size),
+	)
+	return nil
+}
+
+func (c *clientV2) UploadBody(ctx context.Context, bucket string, s3Key string, body io.ReadSeeker) error {
+	c.lg.Info("uploading",
+		zap.String("s3-bucket", bucket),
+		zap.String("remote-path", s3Key),
+	)
+	if err := c.putObject(ctx, bucket, s3Key, body); err != nil {
+		return err
+	}
+	c.lg.Info("uploaded",
+		zap.String("s3-bucket", bucket),
+		zap.String("remote-path", s3Key),
+	)
+	return nil
+}
+
+func (c *clientV2) putObject(ctx context.Context, bucket string, s3Key string, body io.ReadSeeker) error {
+	checksum, err := sha256Body(body)
+	if err != nil {
+		return err
+	}
+	// https://docs.aws.amazon.com/AmazonS3/latest/dev/acl-overview.html#canned-acl
+	// vs. "public-read"
+	acl := aws_s3_v2_types.ObjectCannedACLPrivate
+	if c.op.expectedBucketOwner != "" {
+		// otherwise, the bucket owner cannot read objects written cross-account
+		acl = aws_s3_v2_types.ObjectCannedACLBucketOwnerFullControl
+	}
+	_, err = c.s3APIV2.PutObject(ctx, &aws_s3_v2.PutObjectInput{
+		Bucket: aws_v2.String(bucket),
+		Key:    aws_v2.String(s3Key),
+
+		Body: body,
+
+		ACL: acl,
+
+		RequestPayer:        c.requestPayer(),
+		ExpectedBucketOwner: c.expectedBucketOwner(),
+
+		Metadata: map[string]string{
+			"Kind":              "aws-k8s-tester",
+			"User":              user.Get(),
+			ChecksumMetadataKey: checksum,
+		},
+	})
+	if err != nil {
+		c.lg.Warn("failed to upload",
+			zap.String("s3-bucket", bucket),
+			zap.String("remote-path", s3Key),
+			zap.Error(err),
+		)
+	}
+	return err
+}
+
+func (c *clientV2) Exist(ctx context.Context, bucket string, s3Key string) (bool, error) {
+	c.lg.Info("checking object", zap.String("s3-bucket", bucket), zap.String("s3-key", s3Key))
+	resp, err := c.s3APIV2.HeadObject(ctx, &aws_s3_v2.HeadObjectInput{
+		Bucket:              aws_v2.String(bucket),
+		Key:                 aws_v2.String(s3Key),
+		RequestPayer:        c.requestPayer(),
+		ExpectedBucketOwner: c.expectedBucketOwner(),
+	})
+	if err != nil {
+		c.lg.Warn("failed to head object", zap.String("s3-bucket", bucket), zap.String("s3-key", s3Key), zap.Error(err))
+		return false, err
+	}
+	c.lg.Info("checked object",
+		zap.String("s3-bucket", bucket),
+		zap.String("s3-key", s3Key),
+		zap.String("size", humanize.Bytes(uint64(resp.ContentLength))),
+	)
+	return true, nil
+}
+
+func (c *clientV2) Download(ctx context.Context, bucket string, s3Key string, localPath string, opts ...OpOption) (err error) {
+	ret := Op{verbose: false, overwrite: false}
+	ret.applyOpts(opts)
+
+	c.lg.Info("downloading object",
+		zap.String("s3-bucket", bucket),
+		zap.String("s3-key", s3Key),
+		zap.String("timeout", ret.timeout.String()),
+	)
+	if ret.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ret.timeout)
+		defer cancel()
+	}
+
+	if err = os.MkdirAll(filepath.Dir(localPath), 0700); err != nil {
+		c.lg.Warn("failed to mkdir", zap.String("s3-key", s3Key), zap.Error(err))
+		return err
+	}
+	if fileutil.Exist(localPath) {
+		c.lg.Warn("local file path already exists", zap.String("local-path", localPath))
+		if !ret.overwrite {
+			return fmt.Errorf("local file %q already exists; can't overwrite", localPath)
+		}
+	}
+	n, err := c.getObject(ctx, bucket, s3Key, localPath)
+	if err != nil {
+		return err
+	}
+	c.lg.Info("downloaded object",
+		zap.String("s3-bucket", bucket),
+		zap.String("s3-key", s3Key),
+		zap.String("object-size", humanize.Bytes(uint64(n))),
+		zap.String("local-path", localPath),
+	)
+	return nil
+}
+
+func (c *clientV2) getObject(ctx context.Context, bucket string, s3Key string, localPath string) (n int64, err error) {
+	resp, err := c.s3APIV2.GetObject(ctx, &aws_s3_v2.GetObjectInput{
+		Bucket:              aws_v2.String(bucket),
+		Key:                 aws_v2.String(s3Key),
+		RequestPayer:        c.requestPayer(),
+		ExpectedBucketOwner: c.expectedBucketOwner(),
+	})
+	if err != nil {
+		c.lg.Warn("failed to get object", zap.String("s3-bucket", bucket), zap.String("s3-key", s3Key), zap.Error(err))
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	f, err := os.OpenFile(localPath, os.O_RDWR|os.O_TRUNC, 0777)
+	if err != nil {
+		f, err = os.Create(localPath)
+		if err != nil {
+			c.lg.Warn("failed to write file", zap.String("s3-bucket", bucket), zap.String("s3-key", s3Key), zap.Error(err))
+			return 0, err
+		}
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err = io.Copy(io.MultiWriter(f, h), resp.Body)
+	if err != nil {
+		c.lg.Warn("failed to download object",
+			zap.String("s3-bucket", bucket),
+			zap.String("s3-key", s3Key),
+			zap.Error(err),
+		)
+		return n, err
+	}
+	if err = verifyChecksum(s3Key, checksumFromMetadata(resp.Metadata), hex.EncodeToString(h.Sum(nil))); err != nil {
+		c.lg.Warn("corrupted object download", zap.String("s3-bucket", bucket), zap.String("s3-key", s3Key), zap.Error(err))
+	}
+	return n, err
+}
+
+func (c *clientV2) DownloadDir(ctx context.Context, bucket string, s3Dir string, opts ...OpOption) (targetDir string, err error) {
+	ret := Op{verbose: false, overwrite: false}
+	ret.applyOpts(opts)
+
+	s3Dir = path.Clean(s3Dir) + "/"
+	dirPfx := "download-s3-bucket-dir-" + bucket + s3Dir
+	dirPfx = strings.Replace(dirPfx, "/", "", -1)
+	c.lg.Info("creating temp dir", zap.String("dir-prefix", dirPfx))
+	targetDir = fileutil.MkTmpDir(os.TempDir(), dirPfx)
+
+	c.lg.Info("downloading directory from bucket",
+		zap.String("s3-bucket", bucket),
+		zap.String("s3-dir", s3Dir),
+		zap.String("target-dir", targetDir),
+	)
+	objects := make([]aws_s3_v2_types.Object, 0, 100)
+	pageNum := 0
+	paginator := aws_s3_v2.NewListObjectsV2Paginator(c.s3APIV2, &aws_s3_v2.ListObjectsV2Input{
+		Bucket:              aws_v2.String(bucket),
+		Prefix:              aws_v2.String(s3Dir),
+		RequestPayer:        c.requestPayer(),
+		ExpectedBucketOwner: c.expectedBucketOwner(),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			os.RemoveAll(targetDir)
+			return "", err
+		}
+		objects = append(objects, page.Contents...)
+		pageNum++
+		c.lg.Info("listing",
+			zap.String("s3-bucket", bucket),
+			zap.Int("page-num", pageNum),
+			zap.Bool("last-page", !paginator.HasMorePages()),
+			zap.Int("returned-objects", len(page.Contents)),
+			zap.Int("total-objects", len(objects)),
+		)
+	}
+
+	for _, obj := range objects {
+		select {
+		case <-ctx.Done():
+			return targetDir, ctx.Err()
+		case <-time.After(300 * time.Millisecond):
+		}
+
+		s3Key := aws_v2.ToString(obj.Key)
+		size := humanize.Bytes(uint64(obj.Size))
+		c.lg.Info("downloading object",
+			zap.String("s3-key", s3Key),
+			zap.String("object-size", size),
+		)
+		fpath := filepath.Join(targetDir, s3Key)
+		if err = os.MkdirAll(filepath.Dir(fpath), 0700); err != nil {
+			c.lg.Warn("failed to mkdir", zap.String("s3-key", s3Key), zap.Error(err))
+			continue
+		}
+		n, err := c.getObject(ctx, bucket, s3Key, fpath)
+		if err != nil {
+			// fail loudly, corrupted artifacts must not be silently skipped
+			if errors.Is(err, ErrChecksumMismatch) {
+				os.RemoveAll(targetDir)
+				return "", err
+			}
+			continue
+		}
+		c.lg.Info("downloaded object",
+			zap.String("s3-key", s3Key),
+			zap.String("object-size", size),
+			zap.String("copied-size", humanize.Bytes(uint64(n))),
+		)
+	}
+	c.lg.Info("downloaded directory from bucket",
+		zap.String("s3-bucket", bucket),
+		zap.String("s3-dir", s3Dir),
+		zap.String("target-dir", targetDir),
+	)
+	return targetDir, nil
+}
+
+// EmptyBucket deletes every object version and delete marker, which also
+// covers the current objects in an unversioned bucket ("null" version ID).
+func (c *clientV2) EmptyBucket(ctx context.Context, bucket string) error {
+	c.lg.Info("emptying bucket", zap.String("s3-bucket", bucket))
+	ids := make([]aws_s3_v2_types.ObjectIdentifier, 0)
+	input := &aws_s3_v2.ListObjectVersionsInput{
+		Bucket:              aws_v2.String(bucket),
+		ExpectedBucketOwner: c.expectedBucketOwner(),
+	}
+	for {
+		out, err := c.s3APIV2.ListObjectVersions(ctx, input)
+		if err != nil {
+			var noSuchBucket *aws_s3_v2_types.NoSuchBucket
+			if errors.As(err, &noSuchBucket) {
+				c.lg.Info("no such bucket", zap.String("s3-bucket", bucket), zap.Error(err))
+				return nil
+			}
+			c.lg.Warn("failed to empty bucket", zap.String("s3-bucket", bucket), zap.Error(err))
+			return err
+		}
+		for _, v := range out.Versions {
+			ids = append(ids, aws_s3_v2_types.ObjectIdentifier{Key: v.Key, VersionId: v.VersionId})
+		}
+		for _, v := range out.DeleteMarkers {
+			ids = append(ids, aws_s3_v2_types.ObjectIdentifier{Key: v.Key, VersionId: v.VersionId})
+		}
+		if !out.IsTruncated {
+			break
+		}
+		input.KeyMarker, input.VersionIdMarker = out.NextKeyMarker, out.NextVersionIdMarker
+	}
+
+	c.lg.Info("deleting object versions", zap.String("s3-bucket", bucket), zap.Int("versions", len(ids)))
+	// DeleteObjects accepts up to 1,000 keys per request
+	for len(ids) > 0 {
+		n := len(ids)
+		if n > 1000 {
+			n = 1000
+		}
+		out, err := c.s3APIV2.DeleteObjects(ctx, &aws_s3_v2.DeleteObjectsInput{
+			Bucket:                    aws_v2.String(bucket),
+			BypassGovernanceRetention: true,
+			RequestPayer:              c.requestPayer(),
+			ExpectedBucketOwner:       c.expectedBucketOwner(),
+			Delete: &aws_s3_v2_types.Delete{
+				Objects: ids[:n],
+				Quiet:   true,
+			},
+		})
+		if err != nil {
+			c.lg.Warn("failed to empty bucket", zap.String("s3-bucket", bucket), zap.Error(err))
+			return err
+		}
+		if len(out.Errors) > 0 {
+			return fmt.Errorf("failed to delete %d object versions (first error %q)", len(out.Errors), aws_v2.ToString(out.Errors[0].Message))
+		}
+		ids = ids[n:]
+	}
+	c.lg.Info("emptied bucket", zap.String("s3-bucket", bucket))
+	return nil
+}
+
+func (c *clientV2) DeleteBucket(ctx context.Context, bucket string) error {
+	c.lg.Info("deleting bucket", zap.String("s3-bucket", bucket))
+	_, err := c.s3APIV2.DeleteBucket(ctx, &aws_s3_v2.DeleteBucketInput{
+		Bucket:              aws_v2.String(bucket),
+		ExpectedBucketOwner: c.expectedBucketOwner(),
+	})
+	if err != nil {
+		var noSuchBucket *aws_s3_v2_types.NoSuchBucket
+		if errors.As(err, &noSuchBucket) {
+			c.lg.Info("no such bucket", zap.String("s3-bucket", bucket), zap.Error(err))
+			return nil
+		}
+		c.lg.Warn("failed to delete bucket", zap.String("s3-bucket", bucket), zap.Error(err))
+		return err
+	}
+	c.lg.Info("deleted bucket", zap.String("s3-bucket", bucket))
+	return nil
+}
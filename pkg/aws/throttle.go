@@ -0,0 +1,218 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	middleware_v2 "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultAdaptiveMaxRate is the default maximum requests per second
+	// to each service in the "adaptive" retry mode.
+	DefaultAdaptiveMaxRate = 20.0
+	// DefaultAdaptiveMinRate is the default lowest requests per second
+	// to each service in the "adaptive" retry mode.
+	DefaultAdaptiveMinRate = 1.0
+)
+
+// ThrottlerConfig defines the client-side rate limits of each AWS service.
+type ThrottlerConfig struct {
+	// Logger is the log object.
+	Logger *zap.Logger
+	// MaxRate is the maximum requests per second to each service,
+	// and the initial rate before any throttling.
+	MaxRate float64
+	// MinRate is the lowest requests per second the rate backs off to.
+	MinRate float64
+	// Burst is the maximum burst size of each service.
+	Burst int
+	// Observer is called on every throttled API call attempt,
+	// with the reduced rate, e.g. to export the throttling metrics.
+	Observer ThrottleObserver
+}
+
+// ThrottleObserver observes the throttled API call, with the new rate limit.
+type ThrottleObserver func(service string, limit float64)
+
+// Throttler is a client-side rate limiter shared across all the AWS
+// clients (both SDK v1 sessions and v2 configs) it is set in, so that
+// the sub-testers running concurrently back off together rather than
+// retrying independently. Each service has its own token bucket, that
+// halves its rate on a throttling error and gradually recovers on
+// successful calls (additive-increase/multiplicative-decrease).
+type Throttler struct {
+	cfg ThrottlerConfig
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// NewThrottler creates a new Throttler.
+func NewThrottler(cfg ThrottlerConfig) *Throttler {
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	if cfg.Burst < 1 {
+		cfg.Burst = 1
+	}
+	if cfg.MinRate <= 0 || cfg.MinRate > cfg.MaxRate {
+		cfg.MinRate = cfg.MaxRate
+	}
+	return &Throttler{
+		cfg:      cfg,
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+// service IDs differ in SDK v1 and v2 (e.g. "cloudformation" and "CloudFormation")
+func (t *Throttler) limiter(service string) *rate.Limiter {
+	service = strings.ToLower(service)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lim, ok := t.limiters[service]
+	if !ok {
+		lim = rate.NewLimiter(rate.Limit(t.cfg.MaxRate), t.cfg.Burst)
+		t.limiters[service] = lim
+	}
+	return lim
+}
+
+// Wait blocks until the service allows the next API call attempt,
+// or the context is canceled.
+func (t *Throttler) Wait(ctx context.Context, service string) error {
+	return t.limiter(service).Wait(ctx)
+}
+
+// Limit returns the current rate limit of the service.
+func (t *Throttler) Limit(service string) float64 {
+	return float64(t.limiter(service).Limit())
+}
+
+// Observe adapts the rate limit of the service to the result of an API call attempt.
+func (t *Throttler) Observe(service string, err error) {
+	lim := t.limiter(service)
+
+	t.mu.Lock()
+	cur := float64(lim.Limit())
+	next := cur
+	throttled := isErrorThrottle(err)
+	switch {
+	case throttled:
+		next = cur / 2
+		if next < t.cfg.MinRate {
+			next = t.cfg.MinRate
+		}
+	case err == nil && cur < t.cfg.MaxRate:
+		next = cur + 1
+		if next > t.cfg.MaxRate {
+			next = t.cfg.MaxRate
+		}
+	}
+	if next != cur {
+		lim.SetLimit(rate.Limit(next))
+	}
+	t.mu.Unlock()
+
+	if throttled {
+		t.cfg.Logger.Warn("AWS API call throttled; reducing client-side rate",
+			zap.String("service", strings.ToLower(service)),
+			zap.Float64("rate-from", cur),
+			zap.Float64("rate-to", next),
+			zap.Error(err),
+		)
+		if t.cfg.Observer != nil {
+			t.cfg.Observer(strings.ToLower(service), next)
+		}
+	}
+}
+
+// same codes as the SDK v1 "request.IsErrorThrottle"
+var throttleCodes = map[string]struct{}{
+	"ProvisionedThroughputExceededException": {},
+	"ThrottledException":                     {},
+	"Throttling":                             {},
+	"ThrottlingException":                    {},
+	"RequestLimitExceeded":                   {},
+	"RequestThrottled":                       {},
+	"RequestThrottledException":              {},
+	"TooManyRequestsException":               {},
+	"PriorRequestNotComplete":                {},
+	"TransactionInProgressException":         {},
+	"EC2ThrottledException":                  {},
+	"SlowDown":                               {},
+}
+
+func isErrorThrottle(err error) bool {
+	if err == nil {
+		return false
+	}
+	if request.IsErrorThrottle(err) {
+		return true
+	}
+	var aerr awserr.Error
+	if errors.As(err, &aerr) {
+		_, ok := throttleCodes[aerr.Code()]
+		return ok
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		_, ok := throttleCodes[apiErr.ErrorCode()]
+		return ok
+	}
+	return false
+}
+
+// installV1 rate-limits every attempt of the SDK v1 requests,
+// including the retries, before they are signed.
+func (t *Throttler) installV1(handlers *request.Handlers) {
+	handlers.Sign.PushFrontNamed(request.NamedHandler{
+		Name: "aws-k8s-tester.Throttler.Wait",
+		Fn: func(r *request.Request) {
+			if err := t.Wait(r.Context(), r.ClientInfo.ServiceName); err != nil {
+				r.Error = awserr.New(request.CanceledErrorCode, "client-side rate limiter wait canceled", err)
+			}
+		},
+	})
+	// "Retry" handlers run after every failed attempt
+	handlers.Retry.PushFrontNamed(request.NamedHandler{
+		Name: "aws-k8s-tester.Throttler.ObserveError",
+		Fn: func(r *request.Request) {
+			t.Observe(r.ClientInfo.ServiceName, r.Error)
+		},
+	})
+	handlers.Complete.PushBackNamed(request.NamedHandler{
+		Name: "aws-k8s-tester.Throttler.ObserveSuccess",
+		Fn: func(r *request.Request) {
+			if r.Error == nil {
+				t.Observe(r.ClientInfo.ServiceName, nil)
+			}
+		},
+	})
+}
+
+// installV2 rate-limits every attempt of the SDK v2 requests,
+// inserted right after the retry middleware to run once per attempt,
+// before the request is signed.
+func (t *Throttler) installV2(stack *middleware.Stack) error {
+	return stack.Finalize.Insert(middleware.FinalizeMiddlewareFunc(
+		"aws-k8s-tester.Throttler",
+		func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			service := middleware_v2.GetServiceID(ctx)
+			if err := t.Wait(ctx, service); err != nil {
+				return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+			}
+			out, md, err := next.HandleFinalize(ctx, in)
+			t.Observe(service, err)
+			return out, md, err
+		},
+	), "Retry", middleware.After)
+}
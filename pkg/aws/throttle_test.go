@@ -0,0 +1,123 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	aws_cfn_v2 "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/smithy-go"
+	"go.uber.org/zap"
+)
+
+func TestThrottler(t *testing.T) {
+	var observed []float64
+	th := NewThrottler(ThrottlerConfig{
+		MaxRate: 8,
+		MinRate: 1,
+		Burst:   1,
+		Observer: func(service string, limit float64) {
+			if service != "cloudformation" {
+				t.Fatalf("unexpected service %q", service)
+			}
+			observed = append(observed, limit)
+		},
+	})
+
+	throttled := awserr.New("Throttling", "Rate exceeded", nil)
+	for _, exp := range []float64{4, 2, 1, 1} {
+		th.Observe("cloudformation", throttled)
+		if v := th.Limit("CloudFormation"); v != exp {
+			t.Fatalf("expected limit %v, got %v", exp, v)
+		}
+	}
+	if len(observed) != 4 {
+		t.Fatalf("expected 4 throttle events, got %v", observed)
+	}
+
+	// non-throttling errors do not change the rate
+	th.Observe("cloudformation", awserr.New("ValidationError", "", nil))
+	if v := th.Limit("cloudformation"); v != 1 {
+		t.Fatalf("expected limit 1, got %v", v)
+	}
+	for i := 0; i < 10; i++ {
+		th.Observe("cloudformation", nil)
+	}
+	if v := th.Limit("cloudformation"); v != 8 {
+		t.Fatalf("expected limit 8, got %v", v)
+	}
+
+	// other services are not affected
+	if v := th.Limit("ec2"); v != 8 {
+		t.Fatalf("expected limit 8, got %v", v)
+	}
+}
+
+func TestIsErrorThrottle(t *testing.T) {
+	tests := []struct {
+		err error
+		exp bool
+	}{
+		{nil, false},
+		{errors.New("Throttling"), false},
+		{awserr.New("RequestLimitExceeded", "", nil), true},
+		{awserr.New("ResourceNotFoundException", "", nil), false},
+		{&smithy.GenericAPIError{Code: "ThrottlingException"}, true},
+		{&smithy.GenericAPIError{Code: "ValidationError"}, false},
+	}
+	for i, tv := range tests {
+		if v := isErrorThrottle(tv.err); v != tv.exp {
+			t.Errorf("#%d: expected %v, got %v", i, tv.exp, v)
+		}
+	}
+}
+
+func TestNewV2RetryModeAdaptive(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`<ErrorResponse><Error><Type>Sender</Type><Code>Throttling</Code><Message>Rate exceeded</Message></Error><RequestId>1</RequestId></ErrorResponse>`))
+			return
+		}
+		w.Write([]byte(`<DescribeStacksResponse><DescribeStacksResult><Stacks></Stacks></DescribeStacksResult><ResponseMetadata><RequestId>2</RequestId></ResponseMetadata></DescribeStacksResponse>`))
+	}))
+	defer srv.Close()
+
+	cfg := &Config{
+		Logger:      zap.NewNop(),
+		Partition:   "aws",
+		Region:      "us-west-2",
+		Endpoints:   map[string]string{"cloudformation": srv.URL},
+		MaxAttempts: 3,
+		RetryMode:   "unknown",
+	}
+	if _, err := NewV2(cfg); err == nil {
+		t.Fatal("expected unknown retry mode error")
+	}
+
+	th := NewThrottler(ThrottlerConfig{MaxRate: 8, MinRate: 1, Burst: 8})
+	cfg.RetryMode, cfg.Throttler = RetryModeAdaptive, th
+	awsCfg, err := NewV2(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = aws_cfn_v2.NewFromConfig(awsCfg).DescribeStacks(context.Background(), &aws_cfn_v2.DescribeStacksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Fatalf("expected 2 attempts, got %d", n)
+	}
+	// halved on the throttling error, and recovered by one on the success
+	if v := th.Limit("cloudformation"); v != 5 {
+		t.Fatalf("expected limit 5, got %v", v)
+	}
+}
@@ -14,6 +14,7 @@ import (
 )
 
 // EC2 is an wrapper around original EC2API with additional convenient APIs.
+// TODO: move to aws-sdk-go-v2 like the other EC2 callers
 type EC2 interface {
 	ec2iface.EC2API
 
@@ -0,0 +1,156 @@
+// Package configfile reads and writes the configuration files
+// in YAML, JSON, or HCL, by the file extension.
+package configfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/parser"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatHCL  = "hcl"
+)
+
+// Format returns the configuration format by the file extension,
+// "json" for ".json", "hcl" for ".hcl", and "yaml" otherwise.
+func Format(p string) string {
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".json":
+		return FormatJSON
+	case ".hcl":
+		return FormatHCL
+	default:
+		return FormatYAML
+	}
+}
+
+// Read reads the configuration file, and converts HCL to JSON,
+// so that the returned data can be decoded with "sigs.k8s.io/yaml"
+// (a superset of JSON) with the same validation for all formats.
+func Read(p string) ([]byte, error) {
+	d, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	if Format(p) != FormatHCL {
+		return d, nil
+	}
+	d, err = HCLToJSON(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HCL %q (%v)", p, err)
+	}
+	return d, nil
+}
+
+// SyncPath returns the path to write the configuration back to.
+// HCL is only read, since it is often templated (e.g. by Terraform),
+// so the configuration is written to "<path>.yaml".
+func SyncPath(p string) string {
+	if Format(p) == FormatHCL {
+		return p + ".yaml"
+	}
+	return p
+}
+
+// Marshal encodes the configuration for the path,
+// in JSON for ".json", and in YAML otherwise.
+func Marshal(p string, v interface{}) ([]byte, error) {
+	if Format(p) == FormatJSON {
+		return json.MarshalIndent(v, "", "  ")
+	}
+	return yaml.Marshal(v)
+}
+
+// HCLToJSON converts HCL to JSON, preserving the blocks and objects
+// as JSON objects, and the lists as JSON arrays. Repeated blocks
+// (e.g. 'asgs "ng-1" {...}' and 'asgs "ng-2" {...}') are merged.
+func HCLToJSON(d []byte) ([]byte, error) {
+	f, err := parser.Parse(d)
+	if err != nil {
+		return nil, err
+	}
+	ol, ok := f.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("expected objects, got %T", f.Node)
+	}
+	m, err := convertObjectList(ol)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
+func convertObjectList(ol *ast.ObjectList) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	for _, item := range ol.Items {
+		v, err := convertNode(item.Val)
+		if err != nil {
+			return nil, err
+		}
+		// 'a "b" { ... }' is '{"a": {"b": { ... }}}'
+		for i := len(item.Keys) - 1; i > 0; i-- {
+			v = map[string]interface{}{keyString(item.Keys[i]): v}
+		}
+		k := keyString(item.Keys[0])
+		if err = merge(m, k, v); err != nil {
+			return nil, fmt.Errorf("%s (line %d)", err, item.Pos().Line)
+		}
+	}
+	return m, nil
+}
+
+func convertNode(n ast.Node) (interface{}, error) {
+	switch v := n.(type) {
+	case *ast.LiteralType:
+		return v.Token.Value(), nil
+	case *ast.ListType:
+		vs := make([]interface{}, 0, len(v.List))
+		for _, e := range v.List {
+			ev, err := convertNode(e)
+			if err != nil {
+				return nil, err
+			}
+			vs = append(vs, ev)
+		}
+		return vs, nil
+	case *ast.ObjectType:
+		return convertObjectList(v.List)
+	default:
+		return nil, fmt.Errorf("unsupported HCL node %T (line %d)", n, n.Pos().Line)
+	}
+}
+
+func keyString(k *ast.ObjectKey) string {
+	if s, ok := k.Token.Value().(string); ok {
+		return s
+	}
+	return k.Token.Text
+}
+
+func merge(m map[string]interface{}, k string, v interface{}) error {
+	cur, ok := m[k]
+	if !ok {
+		m[k] = v
+		return nil
+	}
+	cm, ok1 := cur.(map[string]interface{})
+	vm, ok2 := v.(map[string]interface{})
+	if !ok1 || !ok2 {
+		return fmt.Errorf("duplicate key %q", k)
+	}
+	for vk, vv := range vm {
+		if err := merge(cm, vk, vv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
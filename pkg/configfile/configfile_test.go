@@ -0,0 +1,81 @@
+package configfile
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestHCLToJSON(t *testing.T) {
+	d, err := HCLToJSON([]byte(`
+name = "test"
+log-outputs = ["stderr", "test.log"]
+on-failure-delete = true
+delete-concurrency = 5
+
+vpc {
+  create = false
+  id = "vpc-123"
+}
+
+add-on-node-groups {
+  enable = true
+  asgs "ng-1" {
+    asg-min-size = 1
+  }
+  asgs "ng-2" {
+    asg-min-size = 2
+  }
+}
+
+tags = {
+  team = "test"
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err = json.Unmarshal(d, &got); err != nil {
+		t.Fatal(err)
+	}
+	var exp map[string]interface{}
+	if err = json.Unmarshal([]byte(`{
+  "name": "test",
+  "log-outputs": ["stderr", "test.log"],
+  "on-failure-delete": true,
+  "delete-concurrency": 5,
+  "vpc": {"create": false, "id": "vpc-123"},
+  "add-on-node-groups": {"enable": true, "asgs": {"ng-1": {"asg-min-size": 1}, "ng-2": {"asg-min-size": 2}}},
+  "tags": {"team": "test"}
+}`), &exp); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+
+	if _, err = HCLToJSON([]byte("name = \"a\"\nname = \"b\"\n")); err == nil {
+		t.Fatal("expected duplicate key error")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for p, exp := range map[string]string{
+		"config.yaml": FormatYAML,
+		"config.yml":  FormatYAML,
+		"config":      FormatYAML,
+		"config.JSON": FormatJSON,
+		"config.hcl":  FormatHCL,
+	} {
+		if f := Format(p); f != exp {
+			t.Fatalf("%q: expected %q, got %q", p, exp, f)
+		}
+	}
+	if p := SyncPath("/tmp/config.hcl"); p != "/tmp/config.hcl.yaml" {
+		t.Fatalf("unexpected sync path %q", p)
+	}
+	if p := SyncPath("/tmp/config.json"); p != "/tmp/config.json" {
+		t.Fatalf("unexpected sync path %q", p)
+	}
+}
@@ -0,0 +1,604 @@
+// Package helm implements helm utilities to install, upgrade, and uninstall
+// the add-on charts from the chart repositories, the ".tgz" archives,
+// or the OCI registries.
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gofrs/flock"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+/*
+helm repo add stable https://kubernetes-charts.storage.googleapis.com
+helm repo update
+helm search repo stable
+
+helm repo add bitnami https://charts.bitnami.com/bitnami
+helm repo update
+helm search repo bitnami
+
+helm repo add eks https://aws.github.io/eks-charts
+helm repo update
+helm search repo eks
+
+helm repo add jupyterhub https://jupyterhub.github.io/helm-chart/
+helm repo update
+helm search repo jupyterhub
+
+https://github.com/jupyterhub/zero-to-jupyterhub-k8s/blob/master/jupyterhub/values.yaml
+*/
+
+var settings *cli.EnvSettings
+
+func init() {
+	settings = cli.New()
+}
+
+// RepoAdd adds repo with given name and url.
+// No-op for the OCI registries, which are not added as repos.
+func RepoAdd(lg *zap.Logger, name, url string) error {
+	if registry.IsOCI(url) {
+		lg.Info("skipping adding OCI registry as repo", zap.String("name", name), zap.String("url", url))
+		return nil
+	}
+	repoFile := settings.RepositoryConfig
+
+	err := os.MkdirAll(filepath.Dir(repoFile), os.ModePerm)
+	if err != nil && !os.IsExist(err) {
+		return err
+	}
+
+	fck := flock.New(strings.Replace(repoFile, filepath.Ext(repoFile), ".lock", 1))
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	locked, err := fck.TryLockContext(ctx, time.Second)
+	defer cancel()
+	if err == nil && locked {
+		defer fck.Unlock()
+	}
+	if err != nil {
+		return err
+	}
+
+	lg.Info("acquired flock; adding repo", zap.String("repo-file", repoFile), zap.String("name", name))
+	b, err := ioutil.ReadFile(repoFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	var f repo.File
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return err
+	}
+	if f.Has(name) {
+		lg.Info("repository name already exists", zap.String("name", name))
+		return nil
+	}
+
+	c := repo.Entry{
+		Name: name,
+		URL:  url,
+	}
+	r, err := repo.NewChartRepository(&c, getter.All(settings))
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.DownloadIndexFile(); err != nil {
+		lg.Warn("failed to download index file", zap.String("url", url), zap.Error(err))
+		return err
+	}
+
+	f.Update(&c)
+
+	if err := f.WriteFile(repoFile, 0644); err != nil {
+		return err
+	}
+
+	lg.Info("added repo", zap.String("name", name))
+	return nil
+}
+
+// InstallConfig defines helm installation configuration.
+type InstallConfig struct {
+	Logger    *zap.Logger
+	LogWriter io.Writer
+
+	Stopc   chan struct{}
+	Timeout time.Duration
+
+	KubeConfigPath string
+	Namespace      string
+	// ChartRepoURL is the chart repository URL, the ".tgz" chart archive URL,
+	// or the OCI registry path (e.g. "oci://public.ecr.aws/karpenter").
+	ChartRepoURL string
+	ChartName    string
+	ChartVersion string
+	ReleaseName  string
+	// ValuesFiles is the list of the values files (local paths or URLs),
+	// merged in order, overridden by "Values".
+	ValuesFiles []string
+	Values      map[string]interface{}
+
+	// RegistryUsername and RegistryPassword are the OCI registry credentials,
+	// if any. Otherwise, the credentials from "helm registry login" are used.
+	RegistryUsername string
+	RegistryPassword string
+
+	LogFunc       action.DebugLog
+	QueryFunc     func()
+	QueryInterval time.Duration
+	// StatusFunc is called with the release status after each
+	// install, upgrade, and uninstall (e.g. to persist in the configuration).
+	StatusFunc func(Release)
+}
+
+// Release is the status of a helm release.
+type Release struct {
+	Name         string    `json:"name"`
+	Namespace    string    `json:"namespace"`
+	Chart        string    `json:"chart"`
+	ChartVersion string    `json:"chart-version"`
+	AppVersion   string    `json:"app-version"`
+	Revision     int       `json:"revision"`
+	Status       string    `json:"status"`
+	Updated      time.Time `json:"updated"`
+}
+
+// Op represents a helm operation.
+type Op struct {
+	wait   bool
+	atomic bool
+}
+
+// OpOption configures helm operations.
+type OpOption func(*Op)
+
+// WithWait configures whether to wait until all resources are ready
+// (or deleted on uninstall). Default is true.
+func WithWait(b bool) OpOption {
+	return func(op *Op) { op.wait = b }
+}
+
+// WithAtomic configures whether to roll back the failed upgrade
+// (or uninstall the failed install). Implies "WithWait(true)".
+func WithAtomic(b bool) OpOption {
+	return func(op *Op) { op.atomic = b }
+}
+
+func (op *Op) applyOpts(opts []OpOption) {
+	for _, opt := range opts {
+		opt(op)
+	}
+	if op.atomic {
+		op.wait = true
+	}
+}
+
+const defaultQueryInterval = 30 * time.Second
+
+// Install installs a helm chart, or upgrades the release if it already exists.
+func Install(cfg InstallConfig, opts ...OpOption) (err error) {
+	act, err := cfg.init("install")
+	if err != nil {
+		return err
+	}
+	last, err := act.Releases.Last(cfg.ReleaseName)
+	switch {
+	case err == nil:
+		cfg.Logger.Info("found release; upgrading",
+			zap.String("release-name", cfg.ReleaseName),
+			zap.Int("revision", last.Version),
+			zap.String("status", last.Info.Status.String()),
+		)
+		return cfg.upgrade(act, opts...)
+	case errors.Is(err, driver.ErrReleaseNotFound):
+	default:
+		return err
+	}
+
+	ret := &Op{wait: true}
+	ret.applyOpts(opts)
+
+	install := action.NewInstall(act)
+	install.Namespace = cfg.Namespace
+	install.ReleaseName = cfg.ReleaseName
+	install.Wait = ret.wait
+	install.Atomic = ret.atomic
+	install.Timeout = cfg.Timeout
+
+	cfg.Logger.Info("installing chart",
+		zap.String("namespace", cfg.Namespace),
+		zap.String("chart-repo-url", cfg.ChartRepoURL),
+		zap.String("chart-name", cfg.ChartName),
+		zap.String("release-name", cfg.ReleaseName),
+		zap.Bool("wait", ret.wait),
+		zap.Bool("atomic", ret.atomic),
+	)
+	chart, err := cfg.loadChart(&install.ChartPathOptions)
+	if err != nil {
+		return err
+	}
+	vals, err := cfg.values()
+	if err != nil {
+		return err
+	}
+
+	var rs *release.Release
+	cfg.query(func() { rs, err = install.Run(chart, vals) })
+	if err != nil {
+		cfg.Logger.Warn("failed to install chart", zap.String("release-name", cfg.ReleaseName), zap.Error(err))
+		cfg.status(act)
+		return fmt.Errorf("failed to install chart %q (version %q) with error %v", chart.Name(), chart.AppVersion(), err)
+	}
+	cfg.Logger.Info("installed chart",
+		zap.String("namespace", rs.Namespace),
+		zap.String("name", rs.Name),
+		zap.String("version", fmt.Sprintf("%v", rs.Version)),
+	)
+	cfg.record(rs)
+	return nil
+}
+
+// Upgrade upgrades the helm release, which must exist.
+func Upgrade(cfg InstallConfig, opts ...OpOption) error {
+	act, err := cfg.init("upgrade")
+	if err != nil {
+		return err
+	}
+	return cfg.upgrade(act, opts...)
+}
+
+func (cfg InstallConfig) upgrade(act *action.Configuration, opts ...OpOption) (err error) {
+	ret := &Op{wait: true}
+	ret.applyOpts(opts)
+
+	upgrade := action.NewUpgrade(act)
+	upgrade.Namespace = cfg.Namespace
+	upgrade.Wait = ret.wait
+	upgrade.Atomic = ret.atomic
+	upgrade.Timeout = cfg.Timeout
+
+	cfg.Logger.Info("upgrading chart",
+		zap.String("namespace", cfg.Namespace),
+		zap.String("chart-repo-url", cfg.ChartRepoURL),
+		zap.String("chart-name", cfg.ChartName),
+		zap.String("release-name", cfg.ReleaseName),
+		zap.Bool("wait", ret.wait),
+		zap.Bool("atomic", ret.atomic),
+	)
+	chart, err := cfg.loadChart(&upgrade.ChartPathOptions)
+	if err != nil {
+		return err
+	}
+	vals, err := cfg.values()
+	if err != nil {
+		return err
+	}
+
+	var rs *release.Release
+	cfg.query(func() { rs, err = upgrade.Run(cfg.ReleaseName, chart, vals) })
+	if err != nil {
+		cfg.Logger.Warn("failed to upgrade chart", zap.String("release-name", cfg.ReleaseName), zap.Error(err))
+		cfg.status(act)
+		return fmt.Errorf("failed to upgrade chart %q (version %q) with error %v", chart.Name(), chart.AppVersion(), err)
+	}
+	cfg.Logger.Info("upgraded chart",
+		zap.String("namespace", rs.Namespace),
+		zap.String("name", rs.Name),
+		zap.String("version", fmt.Sprintf("%v", rs.Version)),
+	)
+	cfg.record(rs)
+	return nil
+}
+
+// Uninstall uninstalls a helm chart.
+func Uninstall(cfg InstallConfig, opts ...OpOption) error {
+	ret := &Op{wait: true}
+	ret.applyOpts(opts)
+
+	cfg.Logger.Info("uninstalling chart",
+		zap.String("namespace", cfg.Namespace),
+		zap.String("release-name", cfg.ReleaseName),
+	)
+	act, err := cfg.init("uninstall")
+	if err != nil {
+		return err
+	}
+
+	uninstall := action.NewUninstall(act)
+	uninstall.Timeout = cfg.Timeout
+	uninstall.Wait = ret.wait
+
+	rs, err := uninstall.Run(cfg.ReleaseName)
+	if err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			cfg.Logger.Warn("failed to uninstall chart", zap.String("release-name", cfg.ReleaseName), zap.Error(err))
+			return err
+		}
+		cfg.Logger.Info("uninstalled chart", zap.Error(err))
+		return nil
+	}
+	cfg.Logger.Info("uninstalled chart",
+		zap.String("namespace", rs.Release.Namespace),
+		zap.String("name", rs.Release.Name),
+		zap.String("version", fmt.Sprintf("%v", rs.Release.Version)),
+		zap.Error(err),
+	)
+	cfg.record(rs.Release)
+	return nil
+}
+
+// Status returns the status of the latest helm release.
+func Status(cfg InstallConfig) (Release, error) {
+	act, err := cfg.init("status")
+	if err != nil {
+		return Release{}, err
+	}
+	rs, err := action.NewStatus(act).Run(cfg.ReleaseName)
+	if err != nil {
+		return Release{}, err
+	}
+	return toRelease(rs), nil
+}
+
+// init initializes the action configuration with the "secrets" storage
+// driver and the OCI registry client.
+func (cfg InstallConfig) init(prefix string) (*action.Configuration, error) {
+	cfgFlags := genericclioptions.NewConfigFlags(false)
+	cfgFlags.KubeConfig = &cfg.KubeConfigPath
+	cfgFlags.Namespace = &cfg.Namespace
+
+	logFunc := func(format string, v ...interface{}) {
+		cfg.Logger.Info(fmt.Sprintf("["+prefix+"] "+format, v...))
+	}
+	if cfg.LogFunc != nil {
+		logFunc = cfg.LogFunc
+	}
+	act := new(action.Configuration)
+	if err := act.Init(
+		cfgFlags,
+		cfg.Namespace,
+		"secrets",
+		logFunc,
+	); err != nil {
+		return nil, err
+	}
+
+	w := cfg.LogWriter
+	if w == nil {
+		w = ioutil.Discard
+	}
+	rc, err := registry.NewClient(
+		registry.ClientOptWriter(w),
+		registry.ClientOptCredentialsFile(settings.RegistryConfig),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create registry client (%v)", err)
+	}
+	if registry.IsOCI(cfg.ChartRepoURL) && cfg.RegistryUsername != "" {
+		host := ociHost(cfg.ChartRepoURL)
+		cfg.Logger.Info("logging in to OCI registry", zap.String("host", host))
+		if err = rc.Login(host, registry.LoginOptBasicAuth(cfg.RegistryUsername, cfg.RegistryPassword)); err != nil {
+			return nil, fmt.Errorf("failed to log in to OCI registry %q (%v)", host, err)
+		}
+	}
+	act.RegistryClient = rc
+	return act, nil
+}
+
+// loadChart downloads and loads the chart from the ".tgz" archive URL,
+// the OCI registry, or the chart repository.
+func (cfg InstallConfig) loadChart(opts *action.ChartPathOptions) (chrt *chart.Chart, err error) {
+	if strings.HasSuffix(cfg.ChartRepoURL, ".tgz") {
+		// https://github.com/kubernetes-sigs/aws-ebs-csi-driver#deploy-driver
+		var rd io.ReadCloser
+		retryStart, waitDur := time.Now(), 3*time.Minute
+		for time.Since(retryStart) < waitDur {
+			var resp *http.Response
+			resp, err = http.Get(cfg.ChartRepoURL)
+			if err != nil {
+				cfg.Logger.Warn("failed to download tar", zap.Error(err))
+				time.Sleep(5 * time.Second)
+				continue
+			}
+			rd = resp.Body
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer rd.Close()
+		cfg.Logger.Info("downloading chart .tgz", zap.String("url", cfg.ChartRepoURL))
+		chrt, err = loader.LoadArchive(rd)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Logger.Info("loaded chart via .tgz",
+			zap.String("namespace", cfg.Namespace),
+			zap.String("chart-repo", cfg.ChartRepoURL),
+			zap.String("release-name", cfg.ReleaseName),
+			zap.String("chart-full-path", chrt.ChartFullPath()),
+			zap.String("chart-name", chrt.Name()),
+			zap.String("chart-app-version", chrt.AppVersion()),
+		)
+		return chrt, nil
+	}
+
+	name := chartRef(cfg.ChartRepoURL, cfg.ChartName)
+	if !registry.IsOCI(name) {
+		opts.RepoURL = cfg.ChartRepoURL
+	}
+	opts.Version = cfg.ChartVersion
+	cfg.Logger.Info("locating chart",
+		zap.String("namespace", cfg.Namespace),
+		zap.String("chart-repo", cfg.ChartRepoURL),
+		zap.String("chart-name", name),
+		zap.String("release-name", cfg.ReleaseName),
+	)
+	chartPath, err := opts.LocateChart(name, settings)
+	if err != nil {
+		cfg.Logger.Warn("failed to locate chart",
+			zap.String("chart-repo", cfg.ChartRepoURL),
+			zap.String("chart-name", name),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	chrt, err = loader.Load(chartPath)
+	if err != nil {
+		cfg.Logger.Warn("failed to load chart",
+			zap.String("chart-repo", cfg.ChartRepoURL),
+			zap.String("chart-name", name),
+			zap.String("chart-path", chartPath),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	cfg.Logger.Info("loaded chart",
+		zap.String("namespace", cfg.Namespace),
+		zap.String("chart-repo", cfg.ChartRepoURL),
+		zap.String("release-name", cfg.ReleaseName),
+		zap.String("chart-path", chartPath),
+		zap.String("chart-full-path", chrt.ChartFullPath()),
+		zap.String("chart-name", chrt.Name()),
+		zap.String("chart-app-version", chrt.AppVersion()),
+	)
+	return chrt, nil
+}
+
+// chartRef returns the chart reference to locate, which is the full
+// chart path for the OCI registry (e.g. "oci://public.ecr.aws/karpenter/karpenter").
+func chartRef(repoURL string, chartName string) string {
+	if !registry.IsOCI(repoURL) || registry.IsOCI(chartName) {
+		return chartName
+	}
+	return strings.TrimSuffix(repoURL, "/") + "/" + chartName
+}
+
+// ociHost returns the registry host of the OCI path.
+func ociHost(repoURL string) string {
+	host := strings.TrimPrefix(repoURL, registry.OCIScheme+"://")
+	if idx := strings.Index(host, "/"); idx != -1 {
+		host = host[:idx]
+	}
+	return host
+}
+
+// values merges the values files in order, and then "Values".
+func (cfg InstallConfig) values() (map[string]interface{}, error) {
+	if len(cfg.ValuesFiles) == 0 {
+		return cfg.Values, nil
+	}
+	base := make(map[string]interface{})
+	for _, fpath := range cfg.ValuesFiles {
+		vals, err := (&values.Options{ValueFiles: []string{fpath}}).MergeValues(getter.All(settings))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values file %q (%v)", fpath, err)
+		}
+		base = chartutil.CoalesceTables(vals, base)
+	}
+	return chartutil.CoalesceTables(copyValues(cfg.Values), base), nil
+}
+
+// copyValues deep-copies the values, since coalescing modifies the destination.
+func copyValues(src map[string]interface{}) map[string]interface{} {
+	dst := make(map[string]interface{}, len(src))
+	for k, v := range src {
+		if m, ok := v.(map[string]interface{}); ok {
+			v = copyValues(m)
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
+// query runs the function while calling "QueryFunc" every "QueryInterval".
+func (cfg InstallConfig) query(fn func()) {
+	if cfg.QueryFunc == nil {
+		fn()
+		return
+	}
+	if cfg.QueryInterval == 0 {
+		cfg.QueryInterval = defaultQueryInterval
+	}
+	donec1, donec2 := make(chan struct{}), make(chan struct{})
+	go func() {
+		cfg.Logger.Info("starting query function for-loop", zap.Duration("interval", cfg.QueryInterval))
+		for {
+			select {
+			case <-donec1:
+				cfg.Logger.Warn("closing goroutine")
+				close(donec2)
+				return
+			case <-cfg.Stopc:
+				cfg.Logger.Warn("stopping goroutine")
+				return
+			case <-time.After(cfg.QueryInterval):
+			}
+			fmt.Fprintf(cfg.LogWriter, "\n")
+			cfg.QueryFunc()
+			fmt.Fprintf(cfg.LogWriter, "\n")
+		}
+	}()
+	fn()
+	close(donec1)
+	select {
+	case <-donec2:
+	case <-cfg.Stopc:
+	}
+}
+
+// status records the latest release status, if any (e.g. failed).
+func (cfg InstallConfig) status(act *action.Configuration) {
+	if rs, err := act.Releases.Last(cfg.ReleaseName); err == nil {
+		cfg.record(rs)
+	}
+}
+
+func (cfg InstallConfig) record(rs *release.Release) {
+	if cfg.StatusFunc == nil || rs == nil {
+		return
+	}
+	cfg.StatusFunc(toRelease(rs))
+}
+
+func toRelease(rs *release.Release) Release {
+	r := Release{
+		Name:      rs.Name,
+		Namespace: rs.Namespace,
+		Revision:  rs.Version,
+	}
+	if rs.Chart != nil && rs.Chart.Metadata != nil {
+		r.Chart = rs.Chart.Metadata.Name
+		r.ChartVersion = rs.Chart.Metadata.Version
+		r.AppVersion = rs.Chart.Metadata.AppVersion
+	}
+	if rs.Info != nil {
+		r.Status = rs.Info.Status.String()
+		r.Updated = rs.Info.LastDeployed.Time
+	}
+	return r
+}
@@ -0,0 +1,67 @@
+package helm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestChartRef(t *testing.T) {
+	tt := []struct {
+		repoURL   string
+		chartName string
+		exp       string
+	}{
+		{"https://aws.github.io/eks-charts", "aws-load-balancer-controller", "aws-load-balancer-controller"},
+		{"oci://public.ecr.aws/karpenter", "karpenter", "oci://public.ecr.aws/karpenter/karpenter"},
+		{"oci://public.ecr.aws/karpenter/", "karpenter", "oci://public.ecr.aws/karpenter/karpenter"},
+		{"", "oci://public.ecr.aws/karpenter/karpenter", "oci://public.ecr.aws/karpenter/karpenter"},
+	}
+	for i, tv := range tt {
+		if ref := chartRef(tv.repoURL, tv.chartName); ref != tv.exp {
+			t.Fatalf("#%d: expected %q, got %q", i, tv.exp, ref)
+		}
+	}
+	if host := ociHost("oci://123456789012.dkr.ecr.us-west-2.amazonaws.com/charts"); host != "123456789012.dkr.ecr.us-west-2.amazonaws.com" {
+		t.Fatalf("unexpected host %q", host)
+	}
+}
+
+func TestValues(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "helm-values")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f1, f2 := filepath.Join(dir, "1.yaml"), filepath.Join(dir, "2.yaml")
+	if err = ioutil.WriteFile(f1, []byte("a: 1\nb:\n  c: 1\n  d: 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(f2, []byte("b:\n  c: 2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	given := map[string]interface{}{"b": map[string]interface{}{"d": 3}}
+	cfg := InstallConfig{ValuesFiles: []string{f1, f2}, Values: given}
+	vals, err := cfg.values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]interface{}{
+		"a": float64(1),
+		"b": map[string]interface{}{"c": float64(2), "d": 3},
+	}
+	if !reflect.DeepEqual(vals, exp) {
+		t.Fatalf("expected %v, got %v", exp, vals)
+	}
+	if len(given["b"].(map[string]interface{})) != 1 {
+		t.Fatalf("given values modified %v", given)
+	}
+
+	cfg.ValuesFiles = []string{filepath.Join(dir, "missing.yaml")}
+	if _, err = cfg.values(); err == nil {
+		t.Fatal("expected error for missing values file")
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"sync"
 	"time"
 
+	pkg_aws "github.com/aws/aws-k8s-tester/pkg/aws"
 	aws_s3 "github.com/aws/aws-k8s-tester/pkg/aws/s3"
 	"github.com/aws/aws-k8s-tester/pkg/fileutil"
 	"github.com/aws/aws-k8s-tester/pkg/httputil"
@@ -60,6 +61,10 @@ type EKSConfig struct {
 	// ClusterAPIServerEndpoint is the EKS kube-apiserver endpoint.
 	// Use for kubeconfig.
 	ClusterAPIServerEndpoint string
+	// ServerName overrides the TLS server name to verify the API server
+	// certificate against, when ClusterAPIServerEndpoint is a tunnel
+	// to the private endpoint.
+	ServerName string
 	// ClusterCADecoded is the cluster CA base64-decoded.
 	// Use for kubeconfig.
 	ClusterCADecoded string
@@ -120,6 +125,15 @@ type EKSConfig struct {
 	ClientBurst int
 	// ClientTimeout is the client timeout.
 	ClientTimeout time.Duration
+	// ClientMaxRetries is the number of retries for the requests
+	// throttled by kube-apiserver (HTTP 429).
+	// Zero defaults to "DefaultClientMaxRetries".
+	// Negative value disables the retries.
+	ClientMaxRetries int
+
+	// AssumeRole is the IAM role to sign the EKS auth tokens,
+	// if the cluster was created with an assumed role.
+	AssumeRole *pkg_aws.AssumeRole
 }
 
 // EKS defines EKS client operations.
@@ -199,6 +213,17 @@ type EKS interface {
 
 	// Deprecate checks deprecated API groups based on the current kube-apiserver version.
 	Deprecate(batchLimit int64, batchInterval time.Duration) error
+
+	// SampleReadyz samples the kube-apiserver "/readyz" endpoint every interval
+	// until stopc is closed.
+	SampleReadyz(stopc <-chan struct{}, interval time.Duration)
+	// Availability returns the sampled kube-apiserver availability
+	// and the throttled requests.
+	Availability() Availability
+
+	// HealthCheck takes the cluster health snapshot of the nodes,
+	// pending Pods, crash-looping "kube-system" Pods, and component statuses.
+	HealthCheck() HealthSnapshot
 }
 
 type eks struct {
@@ -208,6 +233,9 @@ type eks struct {
 	clients          []*kubernetes.Clientset
 	extensionClients []*apiextensions_client.Clientset
 	cur              int
+	readyz           readyzStats
+
+	throttles *throttleStats
 }
 
 // NewEKS returns a new EKS client.
@@ -243,9 +271,10 @@ func NewEKS(cfg *EKSConfig) (e EKS, err error) {
 		cfg:              cfg,
 		clients:          make([]*kubernetes.Clientset, cfg.Clients),
 		extensionClients: make([]*apiextensions_client.Clientset, cfg.Clients),
+		throttles:        &throttleStats{},
 	}
 	for i := 0; i < cfg.Clients; i++ {
-		ek.clients[i], ek.extensionClients[i], err = createClient(cfg)
+		ek.clients[i], ek.extensionClients[i], err = createClient(cfg, ek.throttles)
 		if err != nil {
 			cfg.Logger.Warn("failed to create client", zap.Int("index", i), zap.Error(err))
 			return nil, err
@@ -255,7 +284,7 @@ func NewEKS(cfg *EKSConfig) (e EKS, err error) {
 	return ek, nil
 }
 
-func createClient(cfg *EKSConfig) (cli *kubernetes.Clientset, ext *apiextensions_client.Clientset, err error) {
+func createClient(cfg *EKSConfig, throttles *throttleStats) (cli *kubernetes.Clientset, ext *apiextensions_client.Clientset, err error) {
 	var kcfg *restclient.Config
 	if cfg.KubeConfigPath != "" {
 		switch {
@@ -333,6 +362,9 @@ func createClient(cfg *EKSConfig) (cli *kubernetes.Clientset, ext *apiextensions
 	if cfg.ClusterAPIServerEndpoint == "" {
 		return nil, nil, errors.New("empty ClusterAPIServerEndpoint")
 	}
+	if cfg.ServerName != "" {
+		kcfg.TLSClientConfig.ServerName = cfg.ServerName
+	}
 
 	if cfg.ClusterCADecoded == "" {
 		cfg.ClusterCADecoded = string(kcfg.TLSClientConfig.CAData)
@@ -383,6 +415,15 @@ func createClient(cfg *EKSConfig) (cli *kubernetes.Clientset, ext *apiextensions
 	if cfg.ClientTimeout > 0 {
 		kcfg.Timeout = cfg.ClientTimeout
 	}
+	maxRetries := cfg.ClientMaxRetries
+	if maxRetries == 0 {
+		maxRetries = DefaultClientMaxRetries
+	}
+	if maxRetries > 0 {
+		kcfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return newRetryTransport(cfg.Logger, rt, maxRetries, throttles)
+		})
+	}
 
 	cfg.Logger.Info("successfully created restclient.Config",
 		zap.String("cluster-api-server-endpoint", cfg.ClusterAPIServerEndpoint),
@@ -401,7 +442,11 @@ func createClient(cfg *EKSConfig) (cli *kubernetes.Clientset, ext *apiextensions
 		cfg.Logger.Warn("failed to create apiextensions_client.ClientSet", zap.Error(err))
 		return nil, nil, err
 	}
-	cfg.Logger.Info("successfully created ClientSet", zap.Float32("qps", kcfg.QPS), zap.Int("burst", kcfg.Burst))
+	cfg.Logger.Info("successfully created ClientSet",
+		zap.Float32("qps", kcfg.QPS),
+		zap.Int("burst", kcfg.Burst),
+		zap.Int("max-retries", maxRetries),
+	)
 	return cli, ext, nil
 }
 
@@ -469,17 +514,27 @@ func createClientConfigEKS(cfg *EKSConfig) *restclient.Config {
 		return nil
 	}
 	cfg.Logger.Info("created restclient.Config from previous cluster status")
+	authConfig := map[string]string{
+		"region":       cfg.Region,
+		"cluster-name": cfg.ClusterName,
+	}
+	if cfg.AssumeRole.IsEnabled() {
+		authConfig["role-arn"] = cfg.AssumeRole.RoleARN
+		authConfig["external-id"] = cfg.AssumeRole.ExternalID
+		authConfig["session-name"] = cfg.AssumeRole.SessionName
+		authConfig["duration"] = cfg.AssumeRole.Duration.String()
+		authConfig["web-identity-token-file"] = cfg.AssumeRole.WebIdentityTokenFile
+		authConfig["web-identity-role-arn"] = cfg.AssumeRole.WebIdentityRoleARN
+	}
 	return &restclient.Config{
 		Host: cfg.ClusterAPIServerEndpoint,
 		TLSClientConfig: restclient.TLSClientConfig{
-			CAData: []byte(cfg.ClusterCADecoded),
+			CAData:     []byte(cfg.ClusterCADecoded),
+			ServerName: cfg.ServerName,
 		},
 		AuthProvider: &clientcmdapi.AuthProviderConfig{
-			Name: authProviderName,
-			Config: map[string]string{
-				"region":       cfg.Region,
-				"cluster-name": cfg.ClusterName,
-			},
+			Name:   authProviderName,
+			Config: authConfig,
 		},
 	}
 }
@@ -498,7 +553,29 @@ func newAuthProviderEKS(_ string, config map[string]string, _ restclient.AuthPro
 		return nil, fmt.Errorf("'clientcmdapi.AuthProviderConfig' does not include 'cluster-name' key %+v", config)
 	}
 
-	sess := session.Must(session.NewSession(aws.NewConfig().WithRegion(awsRegion)))
+	awsConfig := aws.NewConfig().WithRegion(awsRegion)
+	ar := &pkg_aws.AssumeRole{
+		RoleARN:              config["role-arn"],
+		ExternalID:           config["external-id"],
+		SessionName:          config["session-name"],
+		WebIdentityTokenFile: config["web-identity-token-file"],
+		WebIdentityRoleARN:   config["web-identity-role-arn"],
+	}
+	if ar.IsEnabled() {
+		if d, ok := config["duration"]; ok {
+			dur, err := time.ParseDuration(d)
+			if err != nil {
+				return nil, fmt.Errorf("invalid 'duration' %q in 'clientcmdapi.AuthProviderConfig' (%v)", d, err)
+			}
+			ar.Duration = dur
+		}
+		creds, err := ar.Credentials(zap.NewNop(), *awsConfig)
+		if err != nil {
+			return nil, err
+		}
+		awsConfig = awsConfig.WithCredentials(creds)
+	}
+	sess := session.Must(session.NewSession(awsConfig))
 	return &eksAuthProvider{ts: newTokenSourceEKS(sess, clusterName)}, nil
 }
 
@@ -0,0 +1,190 @@
+package k8sclient
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HealthSnapshot is the cluster health at a point in time,
+// to compare before and after each test phase.
+type HealthSnapshot struct {
+	// TakenUTC is the time when the snapshot was taken.
+	TakenUTC time.Time `json:"taken-utc"`
+	// Nodes is the total number of nodes.
+	Nodes int `json:"nodes"`
+	// NotReadyNodes is the list of nodes not in "Ready" condition.
+	NotReadyNodes []string `json:"not-ready-nodes,omitempty"`
+	// NodeConditions maps the node name to its abnormal conditions
+	// (e.g. "MemoryPressure", "DiskPressure").
+	NodeConditions map[string][]string `json:"node-conditions,omitempty"`
+	// UnschedulableNodes is the list of cordoned nodes.
+	UnschedulableNodes []string `json:"unschedulable-nodes,omitempty"`
+	// PendingPods is the list of pending Pods in "namespace/name".
+	PendingPods []string `json:"pending-pods,omitempty"`
+	// CrashLoopingPods is the list of "kube-system" Pods in "CrashLoopBackOff".
+	CrashLoopingPods []string `json:"crash-looping-pods,omitempty"`
+	// UnhealthyComponents is the list of unhealthy control plane components.
+	UnhealthyComponents []string `json:"unhealthy-components,omitempty"`
+	// Errors is the list of errors while taking the snapshot,
+	// in which case the snapshot is partial.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// HealthCheck takes the cluster health snapshot.
+// Returns a partial snapshot with the errors if any of the queries fail.
+func (e *eks) HealthCheck() HealthSnapshot {
+	s := HealthSnapshot{TakenUTC: time.Now().UTC()}
+
+	nodes, err := e.ListNodes(1000, 5*time.Second)
+	if err != nil {
+		s.Errors = append(s.Errors, fmt.Sprintf("failed to list nodes (%v)", err))
+	}
+	s.Nodes = len(nodes)
+	for _, node := range nodes {
+		ready := false
+		for _, cond := range node.Status.Conditions {
+			switch cond.Type {
+			case v1.NodeReady:
+				ready = cond.Status == v1.ConditionTrue
+			default:
+				if cond.Status == v1.ConditionTrue {
+					if s.NodeConditions == nil {
+						s.NodeConditions = make(map[string][]string)
+					}
+					s.NodeConditions[node.Name] = append(s.NodeConditions[node.Name], string(cond.Type))
+				}
+			}
+		}
+		if !ready {
+			s.NotReadyNodes = append(s.NotReadyNodes, node.Name)
+		}
+		if node.Spec.Unschedulable {
+			s.UnschedulableNodes = append(s.UnschedulableNodes, node.Name)
+		}
+	}
+
+	pending, err := e.ListPods(v1.NamespaceAll, 1000, 5*time.Second, WithFieldSelector("status.phase=Pending"))
+	if err != nil {
+		s.Errors = append(s.Errors, fmt.Sprintf("failed to list pending pods (%v)", err))
+	}
+	for _, pod := range pending {
+		s.PendingPods = append(s.PendingPods, pod.Namespace+"/"+pod.Name)
+	}
+
+	pods, err := e.ListPods("kube-system", 1000, 5*time.Second)
+	if err != nil {
+		s.Errors = append(s.Errors, fmt.Sprintf("failed to list kube-system pods (%v)", err))
+	}
+	for _, pod := range pods {
+		if isCrashLooping(pod) {
+			s.CrashLoopingPods = append(s.CrashLoopingPods, pod.Namespace+"/"+pod.Name)
+		}
+	}
+
+	if cli := e.getClient(); cli != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		cs, err := cli.CoreV1().ComponentStatuses().List(ctx, metav1.ListOptions{})
+		cancel()
+		if err != nil {
+			// deprecated in v1.19, may not be served
+			e.cfg.Logger.Warn("failed to list component statuses", zap.Error(err))
+		} else {
+			for _, c := range cs.Items {
+				if !isComponentHealthy(c) {
+					s.UnhealthyComponents = append(s.UnhealthyComponents, c.Name)
+				}
+			}
+		}
+	}
+
+	sort.Strings(s.NotReadyNodes)
+	sort.Strings(s.UnschedulableNodes)
+	sort.Strings(s.PendingPods)
+	sort.Strings(s.CrashLoopingPods)
+	sort.Strings(s.UnhealthyComponents)
+	e.cfg.Logger.Info("took cluster health snapshot",
+		zap.Int("nodes", s.Nodes),
+		zap.Int("not-ready-nodes", len(s.NotReadyNodes)),
+		zap.Int("unschedulable-nodes", len(s.UnschedulableNodes)),
+		zap.Int("pending-pods", len(s.PendingPods)),
+		zap.Int("crash-looping-pods", len(s.CrashLoopingPods)),
+		zap.Int("unhealthy-components", len(s.UnhealthyComponents)),
+		zap.Strings("errors", s.Errors),
+	)
+	return s
+}
+
+func isCrashLooping(pod v1.Pod) bool {
+	for _, st := range pod.Status.ContainerStatuses {
+		if st.State.Waiting != nil && st.State.Waiting.Reason == "CrashLoopBackOff" {
+			return true
+		}
+	}
+	return false
+}
+
+func isComponentHealthy(c v1.ComponentStatus) bool {
+	for _, cond := range c.Conditions {
+		if cond.Type == v1.ComponentHealthy {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// HealthDiff returns the health regressions and recoveries from "before" to "after",
+// one per line, empty if the health has not changed.
+func HealthDiff(before HealthSnapshot, after HealthSnapshot) []string {
+	var ds []string
+	if after.Nodes != before.Nodes {
+		ds = append(ds, fmt.Sprintf("nodes: %d -> %d", before.Nodes, after.Nodes))
+	}
+	ds = append(ds, diffList("not-ready node", before.NotReadyNodes, after.NotReadyNodes)...)
+	ds = append(ds, diffList("unschedulable node", before.UnschedulableNodes, after.UnschedulableNodes)...)
+	ds = append(ds, diffList("node condition", flattenConditions(before.NodeConditions), flattenConditions(after.NodeConditions))...)
+	ds = append(ds, diffList("pending pod", before.PendingPods, after.PendingPods)...)
+	ds = append(ds, diffList("crash-looping pod", before.CrashLoopingPods, after.CrashLoopingPods)...)
+	ds = append(ds, diffList("unhealthy component", before.UnhealthyComponents, after.UnhealthyComponents)...)
+	return ds
+}
+
+// diffList returns the added ("+") and removed ("-") items.
+func diffList(kind string, before []string, after []string) []string {
+	bm := make(map[string]struct{}, len(before))
+	for _, v := range before {
+		bm[v] = struct{}{}
+	}
+	am := make(map[string]struct{}, len(after))
+	for _, v := range after {
+		am[v] = struct{}{}
+	}
+	var ds []string
+	for _, v := range after {
+		if _, ok := bm[v]; !ok {
+			ds = append(ds, fmt.Sprintf("+ %s %s", kind, v))
+		}
+	}
+	for _, v := range before {
+		if _, ok := am[v]; !ok {
+			ds = append(ds, fmt.Sprintf("- %s %s", kind, v))
+		}
+	}
+	return ds
+}
+
+func flattenConditions(m map[string][]string) []string {
+	var rs []string
+	for node, conds := range m {
+		for _, cond := range conds {
+			rs = append(rs, node+"/"+cond)
+		}
+	}
+	sort.Strings(rs)
+	return rs
+}
@@ -0,0 +1,35 @@
+package k8sclient
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHealthDiff(t *testing.T) {
+	before := HealthSnapshot{
+		Nodes:          3,
+		NotReadyNodes:  []string{"node-1"},
+		NodeConditions: map[string][]string{"node-2": {"DiskPressure"}},
+		PendingPods:    []string{"default/a"},
+	}
+	after := HealthSnapshot{
+		Nodes:            2,
+		NodeConditions:   map[string][]string{"node-2": {"DiskPressure", "MemoryPressure"}},
+		PendingPods:      []string{"default/a", "default/b"},
+		CrashLoopingPods: []string{"kube-system/coredns-1"},
+	}
+	ds := HealthDiff(before, after)
+	expected := []string{
+		"nodes: 3 -> 2",
+		"- not-ready node node-1",
+		"+ node condition node-2/MemoryPressure",
+		"+ pending pod default/b",
+		"+ crash-looping pod kube-system/coredns-1",
+	}
+	if !reflect.DeepEqual(ds, expected) {
+		t.Fatalf("expected %v, got %v", expected, ds)
+	}
+	if ds = HealthDiff(after, after); len(ds) != 0 {
+		t.Fatalf("unexpected diff %v", ds)
+	}
+}
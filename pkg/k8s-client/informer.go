@@ -0,0 +1,73 @@
+package k8sclient
+
+import (
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// NewInformerFactory returns a new shared informer factory with the EKS client,
+// limited to the namespace if not empty.
+// Informers share one watch per resource across the add-ons,
+// instead of polling with the List calls.
+func NewInformerFactory(e EKS, namespace string, resync time.Duration) informers.SharedInformerFactory {
+	if namespace == "" {
+		return informers.NewSharedInformerFactory(e.KubernetesClientSet(), resync)
+	}
+	return informers.NewSharedInformerFactoryWithOptions(
+		e.KubernetesClientSet(),
+		resync,
+		informers.WithNamespace(namespace),
+	)
+}
+
+// StartInformers starts the informers requested from the factory,
+// and waits for their caches to sync until stopc is closed or timeout.
+// The informers keep running until stopc is closed.
+func StartInformers(lg *zap.Logger, factory informers.SharedInformerFactory, stopc <-chan struct{}, timeout time.Duration) error {
+	factory.Start(stopc)
+
+	waitc, cancel := stopOrTimeout(stopc, timeout)
+	defer cancel()
+
+	lg.Info("waiting for informer caches to sync", zap.Duration("timeout", timeout))
+	synced := factory.WaitForCacheSync(waitc)
+	for typ, ok := range synced {
+		if !ok {
+			lg.Warn("informer cache not synced", zap.String("type", typ.String()))
+			return errors.New("informer caches not synced")
+		}
+	}
+	lg.Info("informer caches synced", zap.Int("informers", len(synced)))
+	return nil
+}
+
+// WaitForCacheSync waits for the informer caches to sync
+// until stopc is closed or timeout.
+func WaitForCacheSync(stopc <-chan struct{}, timeout time.Duration, synced ...cache.InformerSynced) error {
+	waitc, cancel := stopOrTimeout(stopc, timeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(waitc, synced...) {
+		return errors.New("informer caches not synced")
+	}
+	return nil
+}
+
+// stopOrTimeout returns a channel closed when stopc is closed,
+// the timeout elapses, or cancel is called.
+func stopOrTimeout(stopc <-chan struct{}, timeout time.Duration) (<-chan struct{}, func()) {
+	waitc := make(chan struct{})
+	cancelc := make(chan struct{})
+	go func() {
+		defer close(waitc)
+		select {
+		case <-stopc:
+		case <-time.After(timeout):
+		case <-cancelc:
+		}
+	}()
+	return waitc, func() { close(cancelc) }
+}
@@ -0,0 +1,175 @@
+package k8sclient
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultReadyzInterval is the default interval to sample "/readyz".
+const DefaultReadyzInterval = 30 * time.Second
+
+// Availability is the kube-apiserver availability sampled over the run,
+// and the requests throttled by kube-apiserver.
+type Availability struct {
+	// Samples is the number of "/readyz" samples.
+	Samples int64 `json:"samples"`
+	// Failures is the number of failed "/readyz" samples.
+	Failures int64 `json:"failures"`
+	// AvailabilityPercent is the percentage of the successful samples.
+	AvailabilityPercent float64 `json:"availability-percent"`
+	// LongestOutage is the longest consecutive failure duration.
+	LongestOutage       time.Duration `json:"longest-outage"`
+	LongestOutageString string        `json:"longest-outage-string"`
+	// LastError is the error of the last failed sample.
+	LastError string `json:"last-error,omitempty"`
+
+	// ThrottledRequests is the number of HTTP 429 responses, including the retries.
+	ThrottledRequests int64 `json:"throttled-requests"`
+	// ThrottledRequestsExhausted is the number of throttled requests
+	// that failed after the retries.
+	ThrottledRequestsExhausted int64 `json:"throttled-requests-exhausted"`
+	// ThrottledByPriorityLevel maps the API Priority and Fairness
+	// priority level to the number of throttled requests.
+	// The priority level name is resolved if the client can list
+	// the "PriorityLevelConfiguration" objects, otherwise the UID.
+	ThrottledByPriorityLevel map[string]int64 `json:"throttled-by-priority-level,omitempty"`
+}
+
+// readyzStats is the "/readyz" sample state.
+type readyzStats struct {
+	samples       int64
+	failures      int64
+	outageStart   time.Time
+	longestOutage time.Duration
+	lastError     string
+}
+
+func (s *readyzStats) record(ok bool, errMsg string, now time.Time) {
+	s.samples++
+	if ok {
+		s.outageStart = time.Time{}
+		return
+	}
+	s.failures++
+	s.lastError = errMsg
+	if s.outageStart.IsZero() {
+		s.outageStart = now
+	}
+	if d := now.Sub(s.outageStart); d > s.longestOutage {
+		s.longestOutage = d
+	}
+}
+
+// SampleReadyz samples the kube-apiserver "/readyz" endpoint every interval
+// until stopc is closed.
+func (e *eks) SampleReadyz(stopc <-chan struct{}, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReadyzInterval
+	}
+	e.cfg.Logger.Info("sampling kube-apiserver /readyz", zap.Duration("interval", interval))
+	for {
+		cli := e.getClient()
+		if cli == nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		_, err := cli.Discovery().RESTClient().Get().AbsPath("/readyz").DoRaw(ctx)
+		cancel()
+
+		errMsg := ""
+		if err != nil {
+			errMsg = err.Error()
+			e.cfg.Logger.Warn("kube-apiserver /readyz failed", zap.Error(err))
+		}
+		e.mu.Lock()
+		e.readyz.record(err == nil, errMsg, time.Now())
+		e.mu.Unlock()
+
+		select {
+		case <-stopc:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Availability returns the sampled kube-apiserver availability
+// and the throttled requests.
+func (e *eks) Availability() Availability {
+	e.mu.Lock()
+	av := Availability{
+		Samples:       e.readyz.samples,
+		Failures:      e.readyz.failures,
+		LongestOutage: e.readyz.longestOutage,
+		LastError:     e.readyz.lastError,
+	}
+	e.mu.Unlock()
+	if av.Samples > 0 {
+		av.AvailabilityPercent = float64(av.Samples-av.Failures) / float64(av.Samples) * 100
+	}
+	av.LongestOutageString = av.LongestOutage.String()
+
+	e.throttles.mu.Lock()
+	av.ThrottledRequests = e.throttles.total
+	av.ThrottledRequestsExhausted = e.throttles.exhausted
+	byUID := make(map[string]int64, len(e.throttles.byPriorityLevel))
+	for k, v := range e.throttles.byPriorityLevel {
+		byUID[k] = v
+	}
+	e.throttles.mu.Unlock()
+
+	if len(byUID) > 0 {
+		av.ThrottledByPriorityLevel = e.resolvePriorityLevels(byUID)
+	}
+	return av
+}
+
+// resolvePriorityLevels maps the priority level UIDs to the names,
+// keeping the UIDs that cannot be resolved.
+func (e *eks) resolvePriorityLevels(byUID map[string]int64) map[string]int64 {
+	names, err := e.listPriorityLevels()
+	if err != nil {
+		e.cfg.Logger.Warn("failed to list priority levels; keeping UIDs", zap.Error(err))
+	}
+	rs := make(map[string]int64, len(byUID))
+	for uid, n := range byUID {
+		k := uid
+		if name, ok := names[uid]; ok {
+			k = name
+		}
+		rs[k] += n
+	}
+	return rs
+}
+
+// listPriorityLevels returns the priority level names by UID,
+// with "flowcontrol.apiserver.k8s.io/v1beta2" (>= 1.23),
+// falling back to "v1beta1" for the older servers.
+func (e *eks) listPriorityLevels() (map[string]string, error) {
+	cli := e.getClient()
+	if cli == nil {
+		return nil, errors.New("no client")
+	}
+	names := make(map[string]string)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	v2, err := cli.FlowcontrolV1beta2().PriorityLevelConfigurations().List(ctx, metav1.ListOptions{})
+	if err == nil {
+		for _, pl := range v2.Items {
+			names[string(pl.UID)] = pl.Name
+		}
+		return names, nil
+	}
+	v1, err := cli.FlowcontrolV1beta1().PriorityLevelConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, pl := range v1.Items {
+		names[string(pl.UID)] = pl.Name
+	}
+	return names, nil
+}
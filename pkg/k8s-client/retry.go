@@ -0,0 +1,130 @@
+package k8sclient
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultClientMaxRetries is the default number of retries
+	// for the requests throttled by kube-apiserver.
+	DefaultClientMaxRetries = 5
+
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+
+	// API Priority and Fairness response headers, to attribute the
+	// throttled requests to the priority level that rejected them.
+	// ref. https://kubernetes.io/docs/concepts/cluster-administration/flow-control/#diagnostics
+	headerPriorityLevelUID = "X-Kubernetes-PF-PriorityLevel-UID"
+	headerFlowSchemaUID    = "X-Kubernetes-PF-FlowSchema-UID"
+)
+
+// throttleStats counts the throttled requests, including the retries.
+type throttleStats struct {
+	mu              sync.Mutex
+	total           int64
+	exhausted       int64
+	byPriorityLevel map[string]int64
+}
+
+func (s *throttleStats) record(priorityLevel string, exhausted bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.total++
+	if exhausted {
+		s.exhausted++
+	}
+	if priorityLevel != "" {
+		if s.byPriorityLevel == nil {
+			s.byPriorityLevel = make(map[string]int64)
+		}
+		s.byPriorityLevel[priorityLevel]++
+	}
+}
+
+// retryTransport retries the requests throttled by kube-apiserver
+// (HTTP 429 from the max-inflight or API Priority and Fairness filters),
+// waiting for the "Retry-After" delay or with exponential backoff.
+// "client-go" only retries the responses with "Retry-After" (at the same
+// delay), so the 429s without it would fail the add-on operations.
+type retryTransport struct {
+	lg         *zap.Logger
+	base       http.RoundTripper
+	maxRetries int
+	stats      *throttleStats
+	// after is overridden in tests
+	after func(time.Duration) <-chan time.Time
+}
+
+func newRetryTransport(lg *zap.Logger, base http.RoundTripper, maxRetries int, stats *throttleStats) http.RoundTripper {
+	return &retryTransport{
+		lg:         lg,
+		base:       base,
+		maxRetries: maxRetries,
+		stats:      stats,
+		after:      time.After,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+			return resp, err
+		}
+
+		priorityLevel := resp.Header.Get(headerPriorityLevelUID)
+		exhausted := attempt >= t.maxRetries || (req.Body != nil && req.GetBody == nil)
+		t.stats.record(priorityLevel, exhausted)
+		if exhausted {
+			return resp, nil
+		}
+
+		delay := retryDelay(resp.Header.Get("Retry-After"), attempt)
+		t.lg.Warn("kube-apiserver throttled request; retrying",
+			zap.String("method", req.Method),
+			zap.String("path", req.URL.Path),
+			zap.String("priority-level-uid", priorityLevel),
+			zap.String("flow-schema-uid", resp.Header.Get(headerFlowSchemaUID)),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("delay", delay),
+		)
+		resp.Body.Close()
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-t.after(delay):
+		}
+	}
+}
+
+// retryDelay returns the "Retry-After" delay in seconds if any,
+// otherwise the exponential backoff delay for the attempt.
+func retryDelay(retryAfter string, attempt int) time.Duration {
+	if secs, err := strconv.Atoi(retryAfter); err == nil && secs > 0 {
+		d := time.Duration(secs) * time.Second
+		if d > retryMaxDelay {
+			d = retryMaxDelay
+		}
+		return d
+	}
+	d := retryBaseDelay << uint(attempt)
+	if d <= 0 || d > retryMaxDelay {
+		d = retryMaxDelay
+	}
+	return d
+}
@@ -0,0 +1,142 @@
+package k8sclient
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type fakeRoundTripper struct {
+	codes  []int
+	bodies []string
+}
+
+func (f *fakeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		f.bodies = append(f.bodies, string(b))
+	}
+	code := f.codes[0]
+	if len(f.codes) > 1 {
+		f.codes = f.codes[1:]
+	}
+	resp := &http.Response{
+		StatusCode: code,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}
+	if code == http.StatusTooManyRequests {
+		resp.Header.Set(headerPriorityLevelUID, "uid-1")
+		resp.Header.Set("Retry-After", "1")
+	}
+	return resp, nil
+}
+
+func newTestRetryTransport(base http.RoundTripper, maxRetries int) (*retryTransport, *[]time.Duration) {
+	var delays []time.Duration
+	rt := newRetryTransport(zap.NewExample(), base, maxRetries, &throttleStats{}).(*retryTransport)
+	rt.after = func(d time.Duration) <-chan time.Time {
+		delays = append(delays, d)
+		ch := make(chan time.Time, 1)
+		ch <- time.Now()
+		return ch
+	}
+	return rt, &delays
+}
+
+func TestRetryTransport(t *testing.T) {
+	base := &fakeRoundTripper{codes: []int{429, 429, 200}}
+	rt, delays := newTestRetryTransport(base, 5)
+
+	req, err := http.NewRequest(http.MethodPost, "https://localhost/api/v1/namespaces", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if len(*delays) != 2 || (*delays)[0] != time.Second {
+		t.Fatalf("unexpected delays %v", *delays)
+	}
+	for i, b := range base.bodies {
+		if b != "hello" {
+			t.Fatalf("#%d: expected rewound body, got %q", i, b)
+		}
+	}
+	if rt.stats.total != 2 || rt.stats.exhausted != 0 || rt.stats.byPriorityLevel["uid-1"] != 2 {
+		t.Fatalf("unexpected stats %+v", rt.stats)
+	}
+}
+
+func TestRetryTransportExhausted(t *testing.T) {
+	base := &fakeRoundTripper{codes: []int{429}}
+	rt, delays := newTestRetryTransport(base, 2)
+
+	req, err := http.NewRequest(http.MethodGet, "https://localhost/api/v1/nodes", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", resp.StatusCode)
+	}
+	if len(*delays) != 2 {
+		t.Fatalf("expected 2 retries, got %v", *delays)
+	}
+	if rt.stats.total != 3 || rt.stats.exhausted != 1 {
+		t.Fatalf("unexpected stats %+v", rt.stats)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tt := []struct {
+		retryAfter string
+		attempt    int
+		delay      time.Duration
+	}{
+		{"3", 0, 3 * time.Second},
+		{"120", 0, retryMaxDelay},
+		{"", 0, retryBaseDelay},
+		{"", 2, 4 * retryBaseDelay},
+		{"invalid", 1, 2 * retryBaseDelay},
+		{"", 100, retryMaxDelay},
+	}
+	for i, tv := range tt {
+		if d := retryDelay(tv.retryAfter, tv.attempt); d != tv.delay {
+			t.Fatalf("#%d: expected %v, got %v", i, tv.delay, d)
+		}
+	}
+}
+
+func TestReadyzStats(t *testing.T) {
+	now := time.Now()
+	var s readyzStats
+	s.record(true, "", now)
+	s.record(false, "a", now.Add(time.Minute))
+	s.record(false, "b", now.Add(3*time.Minute))
+	s.record(true, "", now.Add(4*time.Minute))
+	s.record(false, "c", now.Add(5*time.Minute))
+	if s.samples != 5 || s.failures != 3 {
+		t.Fatalf("unexpected samples %d, failures %d", s.samples, s.failures)
+	}
+	if s.longestOutage != 2*time.Minute {
+		t.Fatalf("expected longest outage 2m, got %v", s.longestOutage)
+	}
+	if s.lastError != "c" {
+		t.Fatalf("unexpected last error %q", s.lastError)
+	}
+}
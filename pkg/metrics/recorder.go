@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Recorder records the client-side request latencies per operation.
+// It is safe for concurrent use.
+type Recorder struct {
+	mu        sync.Mutex
+	latencies map[string]Durations
+	failures  map[string]int
+}
+
+// NewRecorder creates a new Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{
+		latencies: make(map[string]Durations),
+		failures:  make(map[string]int),
+	}
+}
+
+// Observe records the request latency, only for successful requests.
+func (r *Recorder) Observe(op string, took time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.failures[op]++
+		return
+	}
+	r.latencies[op] = append(r.latencies[op], took)
+}
+
+// Summaries returns the request results per operation.
+func (r *Recorder) Summaries(testID string) map[string]RequestsSummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ops := make(map[string]struct{})
+	for op := range r.latencies {
+		ops[op] = struct{}{}
+	}
+	for op := range r.failures {
+		ops[op] = struct{}{}
+	}
+
+	rs := make(map[string]RequestsSummary, len(ops))
+	for op := range ops {
+		ds := make(Durations, len(r.latencies[op]))
+		copy(ds, r.latencies[op])
+		sort.Sort(ds)
+		rs[op] = RequestsSummary{
+			TestID:        testID,
+			SuccessTotal:  float64(len(ds)),
+			FailureTotal:  float64(r.failures[op]),
+			LantencyP50:   ds.PickLantencyP50(),
+			LantencyP90:   ds.PickLantencyP90(),
+			LantencyP99:   ds.PickLantencyP99(),
+			LantencyP999:  ds.PickLantencyP999(),
+			LantencyP9999: ds.PickLantencyP9999(),
+		}
+	}
+	return rs
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder()
+	for i := 10; i > 0; i-- {
+		r.Observe("create-secret", time.Duration(i)*time.Millisecond, nil)
+	}
+	r.Observe("create-secret", time.Second, errors.New("timeout"))
+	r.Observe("delete-namespace", 0, errors.New("forbidden"))
+
+	rs := r.Summaries("test")
+	if len(rs) != 2 {
+		t.Fatalf("expected 2 operations, got %d", len(rs))
+	}
+
+	s := rs["create-secret"]
+	if s.TestID != "test" || s.SuccessTotal != 10 || s.FailureTotal != 1 {
+		t.Fatalf("unexpected summary %+v", s)
+	}
+	if s.LantencyP50 != 6*time.Millisecond {
+		t.Fatalf("unexpected p50 %v", s.LantencyP50)
+	}
+	if s.LantencyP99 != 10*time.Millisecond {
+		t.Fatalf("unexpected p99 %v", s.LantencyP99)
+	}
+
+	s = rs["delete-namespace"]
+	if s.SuccessTotal != 0 || s.FailureTotal != 1 || s.LantencyP50 != 0 {
+		t.Fatalf("unexpected summary %+v", s)
+	}
+}
@@ -0,0 +1,245 @@
+// Package report records test phases as test cases, and writes them
+// as JUnit XML and JSON summary, to be rendered by CI systems (e.g. Prow Spyglass).
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Case is a single test phase (e.g. create cluster, create add-on).
+type Case struct {
+	// Class groups the test cases (e.g. "up", "down").
+	Class string `json:"class"`
+	// Name is the test phase name.
+	Name string `json:"name"`
+	// StartUTC is the time when the phase started.
+	StartUTC time.Time `json:"start-utc"`
+	// Took is the duration of the phase.
+	Took time.Duration `json:"took"`
+	// TookString is the duration of the phase in string.
+	TookString string `json:"took-string"`
+	// Failure is the error message, empty if the phase succeeded.
+	Failure string `json:"failure,omitempty"`
+	// Annotations are the notes attached to the phase
+	// (e.g. the cluster health changes during the phase).
+	Annotations []string `json:"annotations,omitempty"`
+}
+
+// Summary is the JSON summary of all test phases.
+type Summary struct {
+	Name     string `json:"name"`
+	Tests    int    `json:"tests"`
+	Failures int    `json:"failures"`
+	// Took is the sum of all test phase durations.
+	Took       time.Duration `json:"took"`
+	TookString string        `json:"took-string"`
+	Cases      []Case        `json:"cases"`
+}
+
+// Observer is notified of the test phases, e.g. to export the phase metrics.
+type Observer interface {
+	// Started is called when the phase starts.
+	Started(class string, name string)
+	// Finished is called when the phase ends, with the error if failed.
+	Finished(class string, name string, took time.Duration, err error)
+}
+
+// Report records test phases. Safe for concurrent use.
+type Report struct {
+	mu       sync.Mutex
+	name     string
+	cases    []Case
+	observer Observer
+}
+
+// New creates a new report with the test suite name.
+func New(name string) *Report {
+	return &Report{name: name}
+}
+
+// Load loads the previous report from the JSON summary, so that
+// the phases of "Down" are appended to the phases of "Up" run in a
+// different process. Returns an empty report if the file does not exist.
+func Load(name string, jsonPath string) (*Report, error) {
+	rp := New(name)
+	b, err := ioutil.ReadFile(jsonPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return rp, nil
+		}
+		return nil, err
+	}
+	var s Summary
+	if err = json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse report %q (%v)", jsonPath, err)
+	}
+	rp.cases = s.Cases
+	return rp, nil
+}
+
+// SetObserver sets the observer of the test phases.
+func (rp *Report) SetObserver(o Observer) {
+	rp.mu.Lock()
+	rp.observer = o
+	rp.mu.Unlock()
+}
+
+func (rp *Report) getObserver() Observer {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return rp.observer
+}
+
+// Record records a test phase that started at "start" and ends now.
+func (rp *Report) Record(class string, name string, start time.Time, err error) {
+	took := time.Since(start)
+	c := Case{
+		Class:      class,
+		Name:       name,
+		StartUTC:   start.UTC(),
+		Took:       took,
+		TookString: took.String(),
+	}
+	if err != nil {
+		c.Failure = err.Error()
+	}
+	rp.mu.Lock()
+	rp.cases = append(rp.cases, c)
+	o := rp.observer
+	rp.mu.Unlock()
+	if o != nil {
+		o.Finished(class, name, took, err)
+	}
+}
+
+// Wrap returns a function that runs "fn" and records its result.
+func (rp *Report) Wrap(class string, name string, fn func() error) func() error {
+	return func() error {
+		if o := rp.getObserver(); o != nil {
+			o.Started(class, name)
+		}
+		start := time.Now()
+		err := fn()
+		rp.Record(class, name, start, err)
+		return err
+	}
+}
+
+// Annotate attaches the notes to the most recent test phase
+// of the class and name. No-op if the phase has not been recorded.
+func (rp *Report) Annotate(class string, name string, notes ...string) {
+	if len(notes) == 0 {
+		return
+	}
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	for i := len(rp.cases) - 1; i >= 0; i-- {
+		if rp.cases[i].Class == class && rp.cases[i].Name == name {
+			rp.cases[i].Annotations = append(rp.cases[i].Annotations, notes...)
+			return
+		}
+	}
+}
+
+// Summary returns the summary of all recorded test phases.
+func (rp *Report) Summary() Summary {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	s := Summary{
+		Name:  rp.name,
+		Tests: len(rp.cases),
+		Cases: make([]Case, len(rp.cases)),
+	}
+	copy(s.Cases, rp.cases)
+	for _, c := range s.Cases {
+		if c.Failure != "" {
+			s.Failures++
+		}
+		s.Took += c.Took
+	}
+	s.TookString = s.Took.String()
+	return s
+}
+
+// WriteJSON writes the JSON summary.
+func (rp *Report) WriteJSON(p string) error {
+	b, err := json.MarshalIndent(rp.Summary(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p, b, 0600)
+}
+
+// ref. https://github.com/jstemmer/go-junit-report
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Type     string `xml:"type,attr"`
+	Contents string `xml:",chardata"`
+}
+
+// WriteJUnitXML writes the test phases in JUnit XML format.
+func (rp *Report) WriteJUnitXML(p string) error {
+	s := rp.Summary()
+	ts := junitTestSuite{
+		Name:      s.Name,
+		Tests:     s.Tests,
+		Failures:  s.Failures,
+		Time:      fmt.Sprintf("%.3f", s.Took.Seconds()),
+		TestCases: make([]junitTestCase, 0, len(s.Cases)),
+	}
+	if len(s.Cases) > 0 {
+		ts.Timestamp = s.Cases[0].StartUTC.Format(time.RFC3339)
+	}
+	for _, c := range s.Cases {
+		tc := junitTestCase{
+			ClassName: c.Class,
+			Name:      c.Name,
+			Time:      fmt.Sprintf("%.3f", c.Took.Seconds()),
+		}
+		if c.Failure != "" {
+			tc.Failure = &junitFailure{
+				Message:  c.Failure,
+				Type:     "Failure",
+				Contents: c.Failure,
+			}
+		}
+		if len(c.Annotations) > 0 {
+			tc.SystemOut = strings.Join(c.Annotations, "\n")
+		}
+		ts.TestCases = append(ts.TestCases, tc)
+	}
+	b, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{ts}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p, append([]byte(xml.Header), b...), 0600)
+}
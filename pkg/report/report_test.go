@@ -0,0 +1,133 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReport(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	rp := New("test-cluster")
+	if err = rp.Wrap("up", "createCluster", func() error { return nil })(); err != nil {
+		t.Fatal(err)
+	}
+	if err = rp.Wrap("up", "createAddOn", func() error { return errors.New("timed out") })(); err == nil {
+		t.Fatal("expected error")
+	}
+
+	jsonPath := filepath.Join(dir, "report.json")
+	if err = rp.WriteJSON(jsonPath); err != nil {
+		t.Fatal(err)
+	}
+	rp, err = Load("test-cluster", jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp.Wrap("down", "deleteCluster", func() error { return nil })()
+
+	s := rp.Summary()
+	if s.Tests != 3 || s.Failures != 1 {
+		t.Fatalf("unexpected summary %+v", s)
+	}
+	if s.Cases[1].Failure != "timed out" || s.Cases[2].Class != "down" {
+		t.Fatalf("unexpected cases %+v", s.Cases)
+	}
+
+	xmlPath := filepath.Join(dir, "junit.xml")
+	if err = rp.WriteJUnitXML(xmlPath); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(xmlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{
+		`<testsuite name="test-cluster" tests="3" failures="1"`,
+		`<testcase classname="up" name="createAddOn"`,
+		`<failure message="timed out" type="Failure">timed out</failure>`,
+	} {
+		if !strings.Contains(string(b), v) {
+			t.Fatalf("expected %q in\n%s", v, string(b))
+		}
+	}
+
+	if rp, err = Load("test-cluster", filepath.Join(dir, "not-exist.json")); err != nil {
+		t.Fatal(err)
+	}
+	if s = rp.Summary(); s.Tests != 0 {
+		t.Fatalf("unexpected summary %+v", s)
+	}
+}
+
+type phases struct {
+	started  []string
+	finished []string
+}
+
+func (p *phases) Started(class string, name string) {
+	p.started = append(p.started, class+"/"+name)
+}
+
+func (p *phases) Finished(class string, name string, took time.Duration, err error) {
+	p.finished = append(p.finished, fmt.Sprintf("%s/%s %v", class, name, err))
+}
+
+func TestReportAnnotate(t *testing.T) {
+	rp := New("test-cluster")
+	rp.Wrap("up", "createAddOn", func() error { return nil })()
+	rp.Wrap("up", "createAddOn", func() error { return nil })()
+	rp.Annotate("up", "createAddOn", "+ pending pod default/a")
+	rp.Annotate("up", "not-exist", "ignored")
+
+	s := rp.Summary()
+	if len(s.Cases[0].Annotations) != 0 {
+		t.Fatalf("unexpected annotations %v", s.Cases[0].Annotations)
+	}
+	if !reflect.DeepEqual(s.Cases[1].Annotations, []string{"+ pending pod default/a"}) {
+		t.Fatalf("unexpected annotations %v", s.Cases[1].Annotations)
+	}
+
+	dir, err := ioutil.TempDir(os.TempDir(), "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	xmlPath := filepath.Join(dir, "junit.xml")
+	if err = rp.WriteJUnitXML(xmlPath); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(xmlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "<system-out>+ pending pod default/a</system-out>") {
+		t.Fatalf("expected system-out in\n%s", string(b))
+	}
+}
+
+func TestReportObserver(t *testing.T) {
+	rp := New("test-cluster")
+	p := &phases{}
+	rp.SetObserver(p)
+	rp.Wrap("up", "createCluster", func() error { return nil })()
+	rp.Wrap("up", "createAddOn", func() error { return errors.New("timed out") })()
+
+	if !reflect.DeepEqual(p.started, []string{"up/createCluster", "up/createAddOn"}) {
+		t.Fatalf("unexpected started phases %v", p.started)
+	}
+	if !reflect.DeepEqual(p.finished, []string{"up/createCluster <nil>", "up/createAddOn timed out"}) {
+		t.Fatalf("unexpected finished phases %v", p.finished)
+	}
+}
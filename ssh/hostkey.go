@@ -0,0 +1,300 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aws_ec2_v2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	aws_ssm_v2 "github.com/aws/aws-sdk-go-v2/service/ssm"
+	"go.uber.org/zap"
+	cryptossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// HostKeyFetcher fetches the host public keys of an instance
+// through a trusted channel other than the SSH connection itself.
+type HostKeyFetcher interface {
+	FetchHostKeys(instanceID string) ([]cryptossh.PublicKey, error)
+}
+
+// NewConsoleOutputHostKeyFetcher returns a fetcher that reads the host keys
+// printed by cloud-init to the EC2 console output. The console output may
+// take a few minutes after boot to be available.
+func NewConsoleOutputHostKeyFetcher(api *aws_ec2_v2.Client) HostKeyFetcher {
+	return &consoleOutputFetcher{api: api}
+}
+
+type consoleOutputFetcher struct {
+	api *aws_ec2_v2.Client
+}
+
+const (
+	hostKeysBegin = "-----BEGIN SSH HOST KEY KEYS-----"
+	hostKeysEnd   = "-----END SSH HOST KEY KEYS-----"
+)
+
+func (f *consoleOutputFetcher) FetchHostKeys(instanceID string) ([]cryptossh.PublicKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	out, err := f.api.GetConsoleOutput(
+		ctx,
+		&aws_ec2_v2.GetConsoleOutputInput{
+			InstanceId: aws.String(instanceID),
+		},
+	)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	b, err := base64.StdEncoding.DecodeString(aws.ToString(out.Output))
+	if err != nil {
+		return nil, err
+	}
+	i := bytes.LastIndex(b, []byte(hostKeysBegin))
+	if i < 0 {
+		return nil, fmt.Errorf("no host keys in %q console output (instance might not be ready yet)", instanceID)
+	}
+	b = b[i+len(hostKeysBegin):]
+	j := bytes.Index(b, []byte(hostKeysEnd))
+	if j < 0 {
+		return nil, fmt.Errorf("incomplete host keys in %q console output", instanceID)
+	}
+	return parseHostKeys(b[:j])
+}
+
+// NewSSMHostKeyFetcher returns a fetcher that reads the host keys
+// on the instance with SSM Run Command. The instance must be
+// managed by SSM.
+func NewSSMHostKeyFetcher(api *aws_ssm_v2.Client) HostKeyFetcher {
+	return &ssmFetcher{api: api}
+}
+
+type ssmFetcher struct {
+	api *aws_ssm_v2.Client
+}
+
+func (f *ssmFetcher) FetchHostKeys(instanceID string) ([]cryptossh.PublicKey, error) {
+	out, err := NewSSMNode(f.api, instanceID).Exec("cat /etc/ssh/ssh_host_*_key.pub", WithTimeout(time.Minute))
+	if err != nil {
+		return nil, err
+	}
+	return parseHostKeys(out)
+}
+
+func parseHostKeys(b []byte) (keys []cryptossh.PublicKey, err error) {
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		key, _, _, _, perr := cryptossh.ParseAuthorizedKey([]byte(line))
+		if perr != nil {
+			// e.g. cloud-init log prefix, "ec2:" fingerprint lines
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no host key found")
+	}
+	return keys, nil
+}
+
+// HostKeyMismatchError is returned when the host key
+// does not match the pinned host keys.
+type HostKeyMismatchError struct {
+	InstanceID  string
+	Fingerprint string
+}
+
+func (e *HostKeyMismatchError) Error() string {
+	return fmt.Sprintf("host key %s for %q does not match pinned host keys (possible man-in-the-middle attack, or instance ID reused)", e.Fingerprint, e.InstanceID)
+}
+
+// HostKeys pins the host keys of instances on first connect, fetched
+// through a trusted channel, and rejects connections whose host key does
+// not match the pinned keys. The pinned keys are persisted in a
+// "known_hosts" file, keyed by the instance ID and its addresses,
+// so that "scp" and "ssh" commands verify the same keys.
+type HostKeys struct {
+	lg      *zap.Logger
+	fetcher HostKeyFetcher
+	path    string
+
+	mu   sync.Mutex
+	pins map[string][]cryptossh.PublicKey
+}
+
+// NewHostKeys loads the pinned host keys from the "known_hosts" file,
+// if the file exists.
+func NewHostKeys(lg *zap.Logger, fetcher HostKeyFetcher, knownHostsPath string) (*HostKeys, error) {
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	if knownHostsPath == "" {
+		return nil, errors.New("empty known hosts path")
+	}
+	hk := &HostKeys{lg: lg, fetcher: fetcher, path: knownHostsPath, pins: make(map[string][]cryptossh.PublicKey)}
+
+	b, err := ioutil.ReadFile(knownHostsPath)
+	if os.IsNotExist(err) {
+		return hk, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for len(b) > 0 {
+		var hosts []string
+		var key cryptossh.PublicKey
+		_, hosts, key, _, b, err = cryptossh.ParseKnownHosts(b)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse %q (%v)", knownHostsPath, err)
+		}
+		if len(hosts) > 0 {
+			hk.pins[hosts[0]] = append(hk.pins[hosts[0]], key)
+		}
+	}
+	lg.Info("loaded pinned host keys", zap.String("path", knownHostsPath), zap.Int("instances", len(hk.pins)))
+	return hk, nil
+}
+
+// Path returns the "known_hosts" file path.
+func (hk *HostKeys) Path() string {
+	return hk.path
+}
+
+// hostKeyCallback returns the host key callback for the configured
+// verification mode, with the host key algorithms to negotiate.
+func (sh *ssh) hostKeyCallback() (cryptossh.HostKeyCallback, []string, error) {
+	switch {
+	case sh.cfg.InsecureSkipVerify:
+		return cryptossh.InsecureIgnoreHostKey(), nil, nil
+	case sh.cfg.HostKeys != nil:
+		if sh.cfg.InstanceID == "" {
+			return nil, nil, errors.New("empty instance ID to verify host keys")
+		}
+		return sh.cfg.HostKeys.callback(sh.cfg), sh.cfg.HostKeys.algorithms(sh.cfg.InstanceID), nil
+	}
+	return nil, nil, errors.New("no host key verification configured (set HostKeys, or InsecureSkipVerify)")
+}
+
+// bastionHostKeyCallback returns the host key callback for the bastion
+// host at "addr", in the same verification mode as the target host.
+func (sh *ssh) bastionHostKeyCallback(addr string) (cryptossh.HostKeyCallback, []string, error) {
+	switch {
+	case sh.cfg.InsecureSkipVerify:
+		return cryptossh.InsecureIgnoreHostKey(), nil, nil
+	case sh.cfg.HostKeys != nil:
+		cfg := sh.bastionConfig(addr)
+		return sh.cfg.HostKeys.callback(cfg), sh.cfg.HostKeys.algorithms(cfg.InstanceID), nil
+	}
+	return nil, nil, errors.New("no host key verification configured (set HostKeys, or InsecureSkipVerify)")
+}
+
+// bastionConfig returns the configuration to look up the pinned
+// host keys of the bastion host at "addr".
+func (sh *ssh) bastionConfig(addr string) Config {
+	cfg := Config{InstanceID: sh.cfg.ProxyJump.InstanceID, PublicDNSName: knownhosts.Normalize(addr)}
+	if cfg.InstanceID == "" {
+		// not fetched, only verified against the keys pinned for the address
+		cfg.InstanceID = cfg.PublicDNSName
+	}
+	return cfg
+}
+
+// callback returns the host key callback for the instance, fetching
+// and pinning its host keys on first use.
+func (hk *HostKeys) callback(cfg Config) cryptossh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key cryptossh.PublicKey) error {
+		keys, err := hk.get(cfg)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if bytes.Equal(k.Marshal(), key.Marshal()) {
+				return nil
+			}
+		}
+		return &HostKeyMismatchError{InstanceID: cfg.InstanceID, Fingerprint: cryptossh.FingerprintSHA256(key)}
+	}
+}
+
+// algorithms returns the pinned host key algorithms, to negotiate
+// a host key type that has been pinned.
+func (hk *HostKeys) algorithms(instanceID string) []string {
+	hk.mu.Lock()
+	defer hk.mu.Unlock()
+	var algos []string
+	for _, k := range hk.pins[instanceID] {
+		switch k.Type() {
+		case cryptossh.KeyAlgoRSA:
+			algos = append(algos, cryptossh.KeyAlgoRSASHA512, cryptossh.KeyAlgoRSASHA256, cryptossh.KeyAlgoRSA)
+		default:
+			algos = append(algos, k.Type())
+		}
+	}
+	return algos
+}
+
+func (hk *HostKeys) get(cfg Config) ([]cryptossh.PublicKey, error) {
+	hk.mu.Lock()
+	defer hk.mu.Unlock()
+	if keys, ok := hk.pins[cfg.InstanceID]; ok {
+		return keys, nil
+	}
+	if hk.fetcher == nil || !strings.HasPrefix(cfg.InstanceID, "i-") {
+		return nil, fmt.Errorf("no pinned host key for %q", cfg.InstanceID)
+	}
+
+	keys, err := hk.fetcher.FetchHostKeys(cfg.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch host keys for %q (%v)", cfg.InstanceID, err)
+	}
+	hosts := []string{cfg.InstanceID}
+	for _, h := range []string{cfg.PublicIP, cfg.PublicDNSName, cfg.PrivateIP} {
+		if h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	buf := bytes.NewBuffer(nil)
+	for _, k := range keys {
+		buf.WriteString(knownhosts.Line(hosts, k) + "\n")
+	}
+	if err = os.MkdirAll(filepath.Dir(hk.path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(hk.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	_, err = f.Write(buf.Bytes())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write %q (%v)", hk.path, err)
+	}
+
+	hk.pins[cfg.InstanceID] = keys
+	hk.lg.Info("pinned host keys", zap.String("instance-id", cfg.InstanceID), zap.Int("keys", len(keys)), zap.String("path", hk.path))
+	return keys, nil
+}
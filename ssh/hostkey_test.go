@@ -0,0 +1,169 @@
+package ssh
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+	cryptossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestConnectBastionHostKeyMismatch(t *testing.T) {
+	dir := t.TempDir()
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := cryptossh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinnedPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned, err := cryptossh.NewPublicKey(pinnedPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	srvCfg := &cryptossh.ServerConfig{NoClientAuth: true}
+	srvCfg.AddHostKey(hostSigner)
+	go func() {
+		conn, aerr := ln.Accept()
+		if aerr != nil {
+			return
+		}
+		defer conn.Close()
+		cryptossh.NewServerConn(conn, srvCfg)
+	}()
+
+	// bastion host key differs from the one pinned for its address
+	addr := ln.Addr().String()
+	knownHostsPath := filepath.Join(dir, "known_hosts")
+	if err = ioutil.WriteFile(knownHostsPath, []byte(knownhosts.Line([]string{knownhosts.Normalize(addr)}, pinned)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	hk, err := NewHostKeys(zap.NewNop(), nil, knownHostsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, clientPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := cryptossh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(dir, "bastion.key")
+	if err = ioutil.WriteFile(keyPath, pem.EncodeToMemory(b), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	sh := &ssh{
+		cfg: Config{
+			InstanceID: "i-target",
+			HostKeys:   hk,
+			ProxyJump:  NewProxyJump(addr, "ec2-user", keyPath),
+		},
+		lg: zap.NewNop(),
+	}
+	cli, err := sh.connectBastion(context.Background())
+	if err == nil {
+		cli.Close()
+		t.Fatal("expected bastion host key mismatch")
+	}
+	if _, ok := err.(*HostKeyMismatchError); !ok {
+		t.Fatalf("expected *HostKeyMismatchError, got %T (%v)", err, err)
+	}
+}
+
+func TestSCPArgsBastionHostKeys(t *testing.T) {
+	dir := t.TempDir()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned, err := cryptossh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	knownHostsPath := filepath.Join(dir, "known_hosts")
+	if err = ioutil.WriteFile(knownHostsPath, []byte(knownhosts.Line([]string{"10.0.0.1"}, pinned)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	hk, err := NewHostKeys(zap.NewNop(), nil, knownHostsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{
+		UserName:  "ec2-user",
+		PrivateIP: "10.0.1.2",
+		ProxyJump: NewProxyJump("10.0.0.1", "ec2-user", "bastion.key"),
+	}
+	tt := []struct {
+		insecure bool
+		exp      []string
+	}{
+		{
+			insecure: false,
+			exp: []string{
+				"scp", "-i", "target.key",
+				"-oStrictHostKeyChecking=yes", "-oUserKnownHostsFile=" + knownHostsPath,
+				"-oProxyCommand=ssh -oStrictHostKeyChecking=yes -oUserKnownHostsFile=" + knownHostsPath + " -i bastion.key -p 22 -W %h:%p ec2-user@10.0.0.1",
+				"local.txt", "ec2-user@10.0.1.2:remote.txt",
+			},
+		},
+		{
+			insecure: true,
+			exp: []string{
+				"scp", "-i", "target.key",
+				"-oStrictHostKeyChecking=no",
+				"-oProxyCommand=ssh -oStrictHostKeyChecking=no -i bastion.key -p 22 -W %h:%p ec2-user@10.0.0.1",
+				"local.txt", "ec2-user@10.0.1.2:remote.txt",
+			},
+		},
+	}
+	for i, tv := range tt {
+		c := cfg
+		if tv.insecure {
+			c.InsecureSkipVerify = true
+		} else {
+			c.HostKeys = hk
+		}
+		sh := &ssh{cfg: c, lg: zap.NewNop()}
+		args, err := sh.scpArgs("scp", "target.key", "local.txt", "remote.txt")
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if !reflect.DeepEqual(args, tv.exp) {
+			t.Fatalf("#%d: expected %q, got %q", i, tv.exp, args)
+		}
+	}
+
+	// bastion host keys are neither pinned nor fetchable
+	c := cfg
+	c.HostKeys = hk
+	c.ProxyJump = NewProxyJump("10.0.0.9", "ec2-user", "bastion.key")
+	sh := &ssh{cfg: c, lg: zap.NewNop()}
+	if _, err = sh.scpArgs("scp", "target.key", "local.txt", "remote.txt"); err == nil {
+		t.Fatal("expected error for the unpinned bastion host")
+	}
+}
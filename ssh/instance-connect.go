@@ -0,0 +1,142 @@
+package ssh
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2instanceconnect"
+	"github.com/aws/aws-sdk-go/service/ec2instanceconnect/ec2instanceconnectiface"
+	"go.uber.org/zap"
+	cryptossh "golang.org/x/crypto/ssh"
+)
+
+// InstanceConnect defines EC2 Instance Connect configuration, to push
+// an ephemeral public key right before connecting, instead of using
+// a long-lived EC2 key pair private key.
+// The instance must have "ec2-instance-connect" package installed.
+// ref. https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/Connect-using-EC2-Instance-Connect.html
+type InstanceConnect struct {
+	API        ec2instanceconnectiface.EC2InstanceConnectAPI
+	InstanceID string
+	// AvailabilityZone is the availability zone of the instance (optional).
+	AvailabilityZone string
+}
+
+// NewInstanceConnect returns nil if the API is nil,
+// to fall back to the private key in "Config.KeyPath".
+func NewInstanceConnect(api ec2instanceconnectiface.EC2InstanceConnectAPI, instanceID string, availabilityZone string) *InstanceConnect {
+	if api == nil {
+		return nil
+	}
+	return &InstanceConnect{API: api, InstanceID: instanceID, AvailabilityZone: availabilityZone}
+}
+
+// instanceConnectKeyTTL is how long the pushed public key is valid.
+// The key only needs to be valid at authentication, and the
+// established connection is kept after the key expires.
+const instanceConnectKeyTTL = 60 * time.Second
+
+// loadKey reads the private key, or generates an ephemeral key
+// in EC2 Instance Connect mode. The ephemeral key is reused
+// for reconnects.
+func (sh *ssh) loadKey() (err error) {
+	if sh.cfg.InstanceConnect == nil {
+		sh.key, err = ioutil.ReadFile(sh.cfg.KeyPath)
+		if err != nil {
+			return fmt.Errorf("failed to read private key %v", err)
+		}
+		sh.signer, err = cryptossh.ParsePrivateKey(sh.key)
+		if err != nil {
+			return fmt.Errorf("failed to parse private key %v", err)
+		}
+		return nil
+	}
+
+	if sh.signer != nil {
+		return nil
+	}
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate ephemeral key %v", err)
+	}
+	blk, err := cryptossh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		return fmt.Errorf("failed to marshal ephemeral key %v", err)
+	}
+	sh.signer, err = cryptossh.NewSignerFromKey(priv)
+	if err != nil {
+		return fmt.Errorf("failed to create signer %v", err)
+	}
+	sh.key = pem.EncodeToMemory(blk)
+	sh.lg.Info("generated ephemeral key for EC2 Instance Connect", zap.String("instance-id", sh.cfg.InstanceConnect.InstanceID))
+	return nil
+}
+
+// pushPublicKey pushes the public key to the instance metadata,
+// valid for "instanceConnectKeyTTL". The key is only pushed
+// if the previously pushed key has expired or is about to expire.
+func (sh *ssh) pushPublicKey(signer cryptossh.Signer) error {
+	ic := sh.cfg.InstanceConnect
+
+	sh.pushMu.Lock()
+	defer sh.pushMu.Unlock()
+	if time.Since(sh.pushedAt) < instanceConnectKeyTTL-15*time.Second {
+		return nil
+	}
+
+	input := &ec2instanceconnect.SendSSHPublicKeyInput{
+		InstanceId:     aws.String(ic.InstanceID),
+		InstanceOSUser: aws.String(sh.cfg.UserName),
+		SSHPublicKey:   aws.String(string(cryptossh.MarshalAuthorizedKey(signer.PublicKey()))),
+	}
+	if ic.AvailabilityZone != "" {
+		input.AvailabilityZone = aws.String(ic.AvailabilityZone)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	out, err := ic.API.SendSSHPublicKeyWithContext(ctx, input)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to send SSH public key to %q (%v)", ic.InstanceID, err)
+	}
+	if !aws.BoolValue(out.Success) {
+		return fmt.Errorf("failed to send SSH public key to %q (request ID %q)", ic.InstanceID, aws.StringValue(out.RequestId))
+	}
+	sh.pushedAt = time.Now()
+	sh.lg.Debug("sent SSH public key", zap.String("instance-id", ic.InstanceID), zap.String("user-name", sh.cfg.UserName))
+	return nil
+}
+
+// writeEphemeralKey writes the ephemeral key to a temporary file
+// for external commands (e.g. "scp").
+func (sh *ssh) writeEphemeralKey() (keyPath string, cleanup func(), err error) {
+	sh.mu.RLock()
+	key := sh.key
+	sh.mu.RUnlock()
+	if len(key) == 0 {
+		return "", nil, fmt.Errorf("no ephemeral key (not connected)")
+	}
+	f, err := ioutil.TempFile(os.TempDir(), "ssh-ephemeral-key")
+	if err != nil {
+		return "", nil, err
+	}
+	keyPath = f.Name()
+	cleanup = func() { os.RemoveAll(keyPath) }
+	if err = f.Chmod(0400); err == nil {
+		_, err = f.Write(key)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return keyPath, cleanup, nil
+}
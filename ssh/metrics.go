@@ -0,0 +1,32 @@
+package ssh
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	cryptossh "golang.org/x/crypto/ssh"
+)
+
+var failuresTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "aws_k8s_tester",
+		Subsystem: "ssh",
+		Name:      "failures_total",
+		Help:      "Total number of failed SSH operations, including the retried attempts, excluding the remote commands that exited with non-zero status.",
+	},
+	[]string{"operation"},
+)
+
+func init() {
+	prometheus.MustRegister(failuresTotal)
+}
+
+// observeFailure counts the failed operation, if the SSH session
+// or the transfer failed (e.g. connection timed out).
+func observeFailure(op string, err error) {
+	if err == nil {
+		return
+	}
+	if _, ok := err.(*cryptossh.ExitError); ok {
+		return
+	}
+	failuresTotal.WithLabelValues(op).Inc()
+}
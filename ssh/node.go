@@ -0,0 +1,142 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aws_ssm_v2 "github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// Node executes commands on a remote node over SSH or SSM Run Command,
+// for example, to inject the faults:
+//
+//	node.Exec("systemctl restart kubelet", ssh.WithSudo(true))
+type Node interface {
+	// InstanceID returns the EC2 instance ID of the node.
+	InstanceID() string
+	// Exec runs the command and returns the output.
+	// Runs the command as root with "WithSudo".
+	Exec(cmd string, opts ...OpOption) (out []byte, err error)
+}
+
+// NewSSHNode returns the node executing commands over the SSH connection.
+func NewSSHNode(instanceID string, sh SSH) Node {
+	return &sshNode{instanceID: instanceID, sh: sh}
+}
+
+type sshNode struct {
+	instanceID string
+	sh         SSH
+}
+
+func (n *sshNode) InstanceID() string { return n.instanceID }
+
+func (n *sshNode) Exec(cmd string, opts ...OpOption) ([]byte, error) {
+	ret := Op{envs: make(map[string]string)}
+	ret.applyOpts(opts)
+	if ret.sudo {
+		cmd = "sudo " + cmd
+	}
+	return n.sh.Run(cmd, opts...)
+}
+
+// NewSSMNode returns the node executing commands with SSM Run Command
+// ("AWS-RunShellScript"), which always runs the commands as root.
+// The instance must be managed by SSM.
+func NewSSMNode(api *aws_ssm_v2.Client, instanceID string) Node {
+	return &ssmNode{api: api, instanceID: instanceID}
+}
+
+type ssmNode struct {
+	api        *aws_ssm_v2.Client
+	instanceID string
+}
+
+func (n *ssmNode) InstanceID() string { return n.instanceID }
+
+// Exec sends the command and polls the invocation until completed or
+// "WithTimeout" (default 1 minute). "WithRetry" retries sending the command.
+func (n *ssmNode) Exec(cmd string, opts ...OpOption) ([]byte, error) {
+	ret := Op{timeout: time.Minute, envs: make(map[string]string)}
+	ret.applyOpts(opts)
+	if ret.timeout == 0 {
+		ret.timeout = time.Minute
+	}
+
+	params := map[string][]string{
+		"commands":         {cmd},
+		"executionTimeout": {fmt.Sprintf("%d", int(ret.timeout.Seconds()))},
+	}
+	var cmdID string
+	for retries := ret.retriesLeft; ; retries-- {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		sent, serr := n.api.SendCommand(
+			ctx,
+			&aws_ssm_v2.SendCommandInput{
+				DocumentName: aws.String("AWS-RunShellScript"),
+				InstanceIds:  []string{n.instanceID},
+				Parameters:   params,
+			},
+		)
+		cancel()
+		if serr == nil {
+			cmdID = aws.ToString(sent.Command.CommandId)
+			break
+		}
+		if retries == 0 {
+			return nil, serr
+		}
+		time.Sleep(ret.retryInterval)
+	}
+
+	deadline := time.Now().Add(ret.timeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(3 * time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		inv, ierr := n.api.GetCommandInvocation(
+			ctx,
+			&aws_ssm_v2.GetCommandInvocationInput{
+				CommandId:  aws.String(cmdID),
+				InstanceId: aws.String(n.instanceID),
+			},
+		)
+		cancel()
+		if ierr != nil {
+			// "InvocationDoesNotExist" right after sending the command
+			continue
+		}
+		switch status := string(inv.Status); status {
+		case "Pending", "InProgress", "Delayed":
+			continue
+		case "Success":
+			return []byte(aws.ToString(inv.StandardOutputContent)), nil
+		default:
+			return []byte(aws.ToString(inv.StandardOutputContent) + aws.ToString(inv.StandardErrorContent)),
+				fmt.Errorf("SSM command %q on %q %s (%s)", cmdID, n.instanceID, status, strings.TrimSpace(aws.ToString(inv.StandardErrorContent)))
+		}
+	}
+	return nil, fmt.Errorf("SSM command %q on %q timed out", cmdID, n.instanceID)
+}
+
+// RestartService restarts the systemd unit on the node
+// (e.g. "kubelet", "containerd").
+func RestartService(n Node, unit string, opts ...OpOption) error {
+	out, err := n.Exec("systemctl restart "+unit, append(opts, WithSudo(true))...)
+	if err != nil {
+		return fmt.Errorf("failed to restart %q on %q (%v, output %q)", unit, n.InstanceID(), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// IsServiceActive returns true if the systemd unit on the node is active.
+func IsServiceActive(n Node, unit string, opts ...OpOption) (bool, error) {
+	// "systemctl is-active" exits non-zero if inactive
+	out, err := n.Exec("systemctl is-active "+unit+" || true", append(opts, WithSudo(true))...)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(out)) == "active", nil
+}
@@ -0,0 +1,104 @@
+package ssh
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Pool is a pool of SSH connections keyed by instance ID,
+// to reuse one connection for all commands on the same instance.
+// Commands on the same connection run in multiplexed sessions.
+type Pool struct {
+	lg *zap.Logger
+
+	mu    sync.Mutex
+	conns map[string]*ssh
+}
+
+// NewPool returns a new SSH connection pool.
+func NewPool(lg *zap.Logger) *Pool {
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	return &Pool{lg: lg, conns: make(map[string]*ssh)}
+}
+
+// Get returns the connection to the instance, or connects with
+// the given configuration if not connected or the connection is dead.
+func (p *Pool) Get(instanceID string, cfg Config) (SSH, error) {
+	p.mu.Lock()
+	sh, ok := p.conns[instanceID]
+	p.mu.Unlock()
+	if ok {
+		if sh.alive() {
+			return sh, nil
+		}
+		p.lg.Warn("pooled connection is dead; reconnecting", zap.String("instance-id", instanceID))
+		p.Remove(instanceID)
+	}
+
+	// connect outside the lock, to connect to instances in parallel
+	sh = &ssh{cfg: cfg, lg: cfg.Logger, retryCounter: make(map[string]int)}
+	if sh.lg == nil {
+		sh.lg = zap.NewNop()
+	}
+	if err := sh.Connect(); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if prev, ok := p.conns[instanceID]; ok {
+		// connected concurrently
+		sh.Close()
+		return prev, nil
+	}
+	p.conns[instanceID] = sh
+	p.lg.Debug("added connection to pool", zap.String("instance-id", instanceID), zap.Int("connections", len(p.conns)))
+	return sh, nil
+}
+
+// Remove closes and removes the connection to the instance
+// (e.g. instance is terminated).
+func (p *Pool) Remove(instanceID string) {
+	p.mu.Lock()
+	sh, ok := p.conns[instanceID]
+	delete(p.conns, instanceID)
+	p.mu.Unlock()
+	if ok {
+		sh.Close()
+	}
+}
+
+// Close closes all connections in the pool.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	conns := p.conns
+	p.conns = make(map[string]*ssh)
+	p.mu.Unlock()
+	for _, sh := range conns {
+		sh.Close()
+	}
+	p.lg.Debug("closed connection pool", zap.Int("connections", len(conns)))
+}
+
+// alive returns true if the connection responds to keepalive requests.
+func (sh *ssh) alive() bool {
+	cli, _, _ := sh.client()
+	if cli == nil {
+		return false
+	}
+	donec := make(chan error, 1)
+	go func() {
+		_, _, err := cli.SendRequest("keepalive@openssh.com", true, nil)
+		donec <- err
+	}()
+	select {
+	case err := <-donec:
+		return err == nil
+	case <-time.After(10 * time.Second):
+		return false
+	}
+}
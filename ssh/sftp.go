@@ -0,0 +1,365 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/sftp"
+	"go.uber.org/zap"
+	cryptossh "golang.org/x/crypto/ssh"
+)
+
+// sftpServerSudoCmd starts the SFTP server as root, to transfer files
+// only accessible by root (e.g. "/var/log/messages").
+// The server path varies by distribution.
+const sftpServerSudoCmd = `sudo -n sh -c 'for p in /usr/libexec/openssh/sftp-server /usr/lib/openssh/sftp-server /usr/lib/ssh/sftp-server; do if [ -x "$p" ]; then exec "$p"; fi; done; echo "sftp-server not found" >&2; exit 127'`
+
+// sftpClient returns the SFTP client over the current connection.
+// The client is reused until "Close".
+func (sh *ssh) sftpClient(sudo bool) (cli *sftp.Client, err error) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if sh.cli == nil {
+		return nil, errors.New("not connected")
+	}
+	if !sudo {
+		if sh.sftpCli == nil {
+			sh.sftpCli, err = sftp.NewClient(sh.cli)
+		}
+		return sh.sftpCli, err
+	}
+
+	if sh.sftpSudoCli != nil {
+		return sh.sftpSudoCli, nil
+	}
+	ss, err := sh.cli.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	w, err := ss.StdinPipe()
+	if err != nil {
+		ss.Close()
+		return nil, err
+	}
+	r, err := ss.StdoutPipe()
+	if err != nil {
+		ss.Close()
+		return nil, err
+	}
+	if err = ss.Start(sftpServerSudoCmd); err != nil {
+		ss.Close()
+		return nil, err
+	}
+	cli, err = sftp.NewClientPipe(r, w)
+	if err != nil {
+		ss.Close()
+		return nil, fmt.Errorf("failed to start sftp-server with sudo (%v)", err)
+	}
+	sh.sftpSudoCli, sh.sftpSudoSession = cli, ss
+	return cli, nil
+}
+
+func (sh *ssh) closeSFTP() {
+	if sh.sftpCli != nil {
+		sh.sftpCli.Close()
+		sh.sftpCli = nil
+	}
+	if sh.sftpSudoCli != nil {
+		sh.sftpSudoCli.Close()
+		sh.sftpSudoCli = nil
+	}
+	if sh.sftpSudoSession != nil {
+		sh.sftpSudoSession.Close()
+		sh.sftpSudoSession = nil
+	}
+}
+
+// Download downloads a file from the remote host using SFTP.
+// If the local file is a partial download (i.e. smaller than the remote
+// file), the download resumes from the end of the local file.
+func (sh *ssh) Download(remotePath, localPath string, opts ...OpOption) (err error) {
+	defer func() { observeFailure("download", err) }()
+	ret := Op{verbose: false, retriesLeft: 0, retryInterval: time.Duration(0), timeout: 0, envs: make(map[string]string)}
+	ret.applyOpts(opts)
+
+	key := fmt.Sprintf("%s%s%s-download", sh.cfg.PublicDNSName, remotePath, localPath)
+	sh.initRetry(key, ret.retriesLeft)
+
+	now := time.Now()
+	var n int64
+	_, _, gen := sh.client()
+	cli, err := sh.sftpClient(ret.sudo)
+	if err == nil {
+		err = sh.transfer(ret.timeout, func() (terr error) {
+			n, terr = sh.download(cli, remotePath, localPath, ret)
+			return terr
+		})
+	}
+	if err != nil {
+		sh.lg.Warn("sftp download failed",
+			zap.String("remote-path", remotePath),
+			zap.String("local-path", localPath),
+			zap.String("error-type", reflect.TypeOf(err).String()),
+			zap.String("started", humanize.RelTime(now, time.Now(), "ago", "from now")),
+			zap.Error(err),
+		)
+		if shouldRetryTransfer(err) && sh.retriesLeft(key) > 0 {
+			sh.lg.Warn("retrying sftp download", zap.Int("retries", sh.retriesLeft(key)))
+			sh.reconnect(gen, key)
+			time.Sleep(ret.retryInterval)
+
+			// recursively retry, resuming from the partial download
+			err = sh.Download(remotePath, localPath, opts...)
+		}
+	}
+	if err == nil {
+		if ret.verbose {
+			sh.lg.Info("downloaded",
+				zap.String("remote-path", remotePath),
+				zap.String("local-path", localPath),
+				zap.String("size", humanize.Bytes(uint64(n))),
+				zap.String("started", humanize.RelTime(now, time.Now(), "ago", "from now")),
+			)
+		}
+		sh.resetRetry(key)
+	}
+	return err
+}
+
+func (sh *ssh) download(cli *sftp.Client, remotePath, localPath string, ret Op) (n int64, err error) {
+	rf, err := cli.Open(remotePath)
+	if err != nil {
+		return 0, err
+	}
+	defer rf.Close()
+	rfi, err := rf.Stat()
+	if err != nil {
+		return 0, err
+	}
+	total := rfi.Size()
+
+	if err = os.MkdirAll(filepath.Dir(localPath), 0700); err != nil {
+		return 0, err
+	}
+	offset := int64(0)
+	if fi, ferr := os.Stat(localPath); ferr == nil && fi.Size() <= total {
+		offset = fi.Size()
+	}
+	if offset > 0 && offset == total {
+		sh.lg.Debug("already downloaded", zap.String("remote-path", remotePath), zap.String("local-path", localPath))
+		return total, nil
+	}
+	flag := os.O_CREATE | os.O_WRONLY
+	if offset == 0 {
+		flag |= os.O_TRUNC
+	}
+	lf, err := os.OpenFile(localPath, flag, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer lf.Close()
+	if offset > 0 {
+		sh.lg.Info("resuming download",
+			zap.String("remote-path", remotePath),
+			zap.String("offset", humanize.Bytes(uint64(offset))),
+			zap.String("total", humanize.Bytes(uint64(total))),
+		)
+		if _, err = rf.Seek(offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+		if _, err = lf.Seek(offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+	}
+
+	pw := &progressWriter{lg: sh.lg, op: "downloading", path: remotePath, n: offset, total: total, last: time.Now()}
+	copied, err := io.Copy(io.MultiWriter(lf, pw), rf)
+	return offset + copied, err
+}
+
+// Upload uploads a file to the remote host using SFTP.
+// If the remote file is a partial upload (i.e. smaller than the local
+// file), the upload resumes from the end of the remote file.
+func (sh *ssh) Upload(localPath, remotePath string, opts ...OpOption) (err error) {
+	defer func() { observeFailure("upload", err) }()
+	ret := Op{verbose: false, retriesLeft: 0, retryInterval: time.Duration(0), timeout: 0, envs: make(map[string]string)}
+	ret.applyOpts(opts)
+
+	if _, ferr := os.Stat(localPath); ferr != nil {
+		return fmt.Errorf("%q does not exist (%v)", localPath, ferr)
+	}
+
+	key := fmt.Sprintf("%s%s%s-upload", sh.cfg.PublicDNSName, localPath, remotePath)
+	sh.initRetry(key, ret.retriesLeft)
+
+	now := time.Now()
+	var n int64
+	_, _, gen := sh.client()
+	cli, err := sh.sftpClient(ret.sudo)
+	if err == nil {
+		err = sh.transfer(ret.timeout, func() (terr error) {
+			n, terr = sh.upload(cli, localPath, remotePath)
+			return terr
+		})
+	}
+	if err != nil {
+		sh.lg.Warn("sftp upload failed",
+			zap.String("local-path", localPath),
+			zap.String("remote-path", remotePath),
+			zap.String("error-type", reflect.TypeOf(err).String()),
+			zap.String("started", humanize.RelTime(now, time.Now(), "ago", "from now")),
+			zap.Error(err),
+		)
+		if shouldRetryTransfer(err) && sh.retriesLeft(key) > 0 {
+			sh.lg.Warn("retrying sftp upload", zap.Int("retries", sh.retriesLeft(key)))
+			sh.reconnect(gen, key)
+			time.Sleep(ret.retryInterval)
+
+			// recursively retry, resuming from the partial upload
+			err = sh.Upload(localPath, remotePath, opts...)
+		}
+	}
+	if err == nil {
+		sh.lg.Info("uploaded",
+			zap.String("local-path", localPath),
+			zap.String("remote-path", remotePath),
+			zap.String("size", humanize.Bytes(uint64(n))),
+			zap.String("started", humanize.RelTime(now, time.Now(), "ago", "from now")),
+		)
+		sh.resetRetry(key)
+	}
+	return err
+}
+
+func (sh *ssh) upload(cli *sftp.Client, localPath, remotePath string) (n int64, err error) {
+	lf, err := os.Open(localPath)
+	if err != nil {
+		return 0, err
+	}
+	defer lf.Close()
+	lfi, err := lf.Stat()
+	if err != nil {
+		return 0, err
+	}
+	total := lfi.Size()
+
+	if err = cli.MkdirAll(path.Dir(remotePath)); err != nil {
+		return 0, err
+	}
+	offset := int64(0)
+	if rfi, rerr := cli.Stat(remotePath); rerr == nil && rfi.Size() <= total {
+		offset = rfi.Size()
+	}
+	if offset > 0 && offset == total {
+		sh.lg.Debug("already uploaded", zap.String("local-path", localPath), zap.String("remote-path", remotePath))
+		return total, nil
+	}
+	flag := os.O_CREATE | os.O_WRONLY
+	if offset == 0 {
+		flag |= os.O_TRUNC
+	}
+	rf, err := cli.OpenFile(remotePath, flag)
+	if err != nil {
+		return 0, err
+	}
+	defer rf.Close()
+	if offset > 0 {
+		sh.lg.Info("resuming upload",
+			zap.String("remote-path", remotePath),
+			zap.String("offset", humanize.Bytes(uint64(offset))),
+			zap.String("total", humanize.Bytes(uint64(total))),
+		)
+		if _, err = lf.Seek(offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+		if _, err = rf.Seek(offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+	}
+
+	pw := &progressWriter{lg: sh.lg, op: "uploading", path: localPath, n: offset, total: total, last: time.Now()}
+	copied, err := io.Copy(rf, io.TeeReader(lf, pw))
+	return offset + copied, err
+}
+
+// transfer runs the file transfer, and closes the SFTP clients
+// to abort the transfer on timeout.
+func (sh *ssh) transfer(timeout time.Duration, f func() error) error {
+	_, sctx, _ := sh.client()
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout == 0 {
+		ctx, cancel = context.WithCancel(sctx)
+	} else {
+		ctx, cancel = context.WithTimeout(sctx, timeout)
+	}
+	defer cancel()
+
+	donec := make(chan error, 1)
+	go func() {
+		donec <- f()
+	}()
+	select {
+	case <-ctx.Done():
+		sh.mu.Lock()
+		sh.closeSFTP()
+		sh.mu.Unlock()
+		<-donec
+		return ctx.Err()
+	case err := <-donec:
+		return err
+	}
+}
+
+// shouldRetryTransfer returns false if the transfer would fail again
+// (e.g. file not found).
+func shouldRetryTransfer(err error) bool {
+	if os.IsNotExist(err) || os.IsPermission(err) {
+		return false
+	}
+	var serr *sftp.StatusError
+	if errors.As(err, &serr) {
+		return false
+	}
+	var xerr *cryptossh.ExitError
+	return !errors.As(err, &xerr)
+}
+
+// progressInterval is the interval to log transfer progress.
+const progressInterval = 5 * time.Second
+
+// progressWriter logs the progress of long file transfers.
+type progressWriter struct {
+	lg    *zap.Logger
+	op    string
+	path  string
+	n     int64
+	total int64
+	last  time.Time
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	pw.n += int64(len(p))
+	if time.Since(pw.last) >= progressInterval {
+		pw.last = time.Now()
+		pct := 100.0
+		if pw.total > 0 {
+			pct = float64(pw.n) / float64(pw.total) * 100
+		}
+		pw.lg.Info(pw.op,
+			zap.String("path", pw.path),
+			zap.String("transferred", humanize.Bytes(uint64(pw.n))),
+			zap.String("total", humanize.Bytes(uint64(pw.total))),
+			zap.String("progress", fmt.Sprintf("%.1f%%", pct)),
+		)
+	}
+	return len(p), nil
+}
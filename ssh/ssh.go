@@ -5,15 +5,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/dustin/go-humanize"
+	"github.com/pkg/sftp"
 	"go.uber.org/zap"
 	cryptossh "golang.org/x/crypto/ssh"
 	"k8s.io/utils/exec"
@@ -23,9 +26,20 @@ import (
 type Config struct {
 	Logger  *zap.Logger
 	KeyPath string
+	// InstanceConnect is set to push an ephemeral public key via
+	// EC2 Instance Connect before connecting, in which case
+	// "KeyPath" is ignored.
+	InstanceConnect *InstanceConnect
 
 	PublicIP      string
 	PublicDNSName string
+	// PrivateIP is the private IP of the remote host, to connect
+	// through "ProxyJump" (e.g. nodes in private subnets).
+	PrivateIP string
+
+	// ProxyJump is the bastion host to tunnel the connection through.
+	// Leave nil to connect to "PublicIP" directly.
+	ProxyJump *ProxyJump
 
 	// UserName is the user name to use for log-in.
 	// "ec2-user" for Amazon Linux 2
@@ -35,6 +49,41 @@ type Config struct {
 	// Envs is the set of environmental variables to use
 	// in the SSH session.
 	Envs map[string]string
+
+	// InstanceID is the EC2 instance ID of the remote host,
+	// to look up its pinned host keys.
+	InstanceID string
+	// HostKeys verifies the remote host key against the keys pinned
+	// for "InstanceID". Required unless "InsecureSkipVerify" is true.
+	HostKeys *HostKeys
+	// InsecureSkipVerify is true to skip host key verification,
+	// which is vulnerable to man-in-the-middle attacks.
+	InsecureSkipVerify bool
+}
+
+// ProxyJump defines the bastion (jump) host configuration,
+// equivalent to "ssh -J [UserName]@[Host]".
+type ProxyJump struct {
+	// Host is the bastion host address with an optional port
+	// (e.g. "bastion.example.com" or "1.2.3.4:2222").
+	Host string
+	// UserName is the user name to log in to the bastion host.
+	UserName string
+	// KeyPath is the private key path for the bastion host.
+	KeyPath string
+	// InstanceID is the EC2 instance ID of the bastion host, to fetch
+	// and pin its host keys. Leave empty to verify against the keys
+	// pinned for "Host" in the "known_hosts" file.
+	InstanceID string
+}
+
+// NewProxyJump returns the bastion host configuration,
+// or nil if the host is empty (i.e. connect directly).
+func NewProxyJump(host string, userName string, keyPath string) *ProxyJump {
+	if host == "" {
+		return nil
+	}
+	return &ProxyJump{Host: host, UserName: userName, KeyPath: keyPath}
 }
 
 // SSH defines SSH operations.
@@ -69,11 +118,20 @@ type SSH interface {
 	// Close closes the session and connection to a remote server.
 	Close()
 	// Run runs the command and returns the output.
+	// Safe to call concurrently, each command in its own session.
 	Run(cmd string, opts ...OpOption) (out []byte, err error)
+	// Stream runs the command and writes the outputs to the writers
+	// as they are produced, until the command exits or the context
+	// is canceled.
+	Stream(ctx context.Context, cmd string, stdout io.Writer, stderr io.Writer, opts ...OpOption) error
 	// Send sends a file to the remote host using SCP protocol.
 	Send(localPath, remotePath string, opts ...OpOption) (out []byte, err error)
-	// Download downloads a file from the remote host using SCP protocol.
-	Download(remotePath, localPath string, opts ...OpOption) (out []byte, err error)
+	// Upload uploads a file to the remote host using SFTP,
+	// resuming partial uploads.
+	Upload(localPath, remotePath string, opts ...OpOption) error
+	// Download downloads a file from the remote host using SFTP,
+	// resuming partial downloads.
+	Download(remotePath, localPath string, opts ...OpOption) error
 }
 
 type ssh struct {
@@ -84,13 +142,34 @@ type ssh struct {
 	key    []byte
 	signer cryptossh.Signer
 
+	// pushedAt is the last time the public key was pushed
+	// via EC2 Instance Connect
+	pushMu   sync.Mutex
+	pushedAt time.Time
+
 	ctx    context.Context
 	cancel context.CancelFunc
 
 	conn net.Conn
 	cli  *cryptossh.Client
 
+	// bastion is the client connected to "ProxyJump" host
+	bastion *cryptossh.Client
+
+	// SFTP clients for file transfers, reused until "Close"
+	sftpCli         *sftp.Client
+	sftpSudoCli     *sftp.Client
+	sftpSudoSession *cryptossh.Session
+
+	// mu protects the connection from reconnects while in use,
+	// so that commands can run concurrently in multiplexed sessions
+	mu sync.RWMutex
+	// gen is incremented on every connect, to reconnect only once
+	// when concurrent commands fail on the same connection
+	gen uint64
+
 	// retry counter per instance + command
+	counterMu    sync.Mutex
 	retryCounter map[string]int
 }
 
@@ -108,14 +187,29 @@ func New(cfg Config) (s SSH, err error) {
 }
 
 func (sh *ssh) Connect() (err error) {
+	defer func() { observeFailure("connect", err) }()
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	return sh.connect()
+}
+
+func (sh *ssh) connect() (err error) {
 	sh.ctx, sh.cancel = context.WithCancel(context.Background())
-	sh.key, err = ioutil.ReadFile(sh.cfg.KeyPath)
-	if err != nil {
-		return fmt.Errorf("failed to read private key %v", err)
+	if err = sh.loadKey(); err != nil {
+		return err
 	}
-	sh.signer, err = cryptossh.ParsePrivateKey(sh.key)
+	hostKeyCallback, hostKeyAlgos, err := sh.hostKeyCallback()
 	if err != nil {
-		return fmt.Errorf("failed to parse private key %v", err)
+		return err
+	}
+	// host key mismatch is not retried
+	var mismatchErr error
+	verifyHostKey := func(hostname string, remote net.Addr, key cryptossh.PublicKey) error {
+		herr := hostKeyCallback(hostname, remote, key)
+		if _, ok := herr.(*HostKeyMismatchError); ok {
+			mismatchErr = herr
+		}
+		return herr
 	}
 
 	var (
@@ -133,11 +227,15 @@ func (sh *ssh) Connect() (err error) {
 		sh.lg.Debug("dialing",
 			zap.String("public-ip", sh.cfg.PublicIP),
 			zap.String("public-dns-name", sh.cfg.PublicDNSName),
+			zap.String("addr", sh.addr()),
 		)
-		d := net.Dialer{}
 		ctx, cancel := context.WithTimeout(sh.ctx, 15*time.Second)
-		sh.conn, err = d.DialContext(ctx, "tcp", sh.cfg.PublicIP+":22")
+		sh.conn, err = sh.dial(ctx)
 		cancel()
+		if _, ok := err.(*HostKeyMismatchError); ok {
+			// bastion host key mismatch
+			return err
+		}
 		if err != nil {
 			oerr, ok := err.(*net.OpError)
 			if ok {
@@ -167,21 +265,38 @@ func (sh *ssh) Connect() (err error) {
 			zap.String("public-dns-name", sh.cfg.PublicDNSName),
 		)
 
+		if sh.cfg.InstanceConnect != nil {
+			if err = sh.pushPublicKey(sh.signer); err != nil {
+				sh.lg.Warn("failed to push public key", zap.Error(err))
+				sh.conn.Close()
+				time.Sleep(5 * time.Second)
+				continue
+			}
+		}
+
 		sshConfig := &cryptossh.ClientConfig{
 			User: sh.cfg.UserName,
 			Auth: []cryptossh.AuthMethod{
 				cryptossh.PublicKeys(sh.signer),
 			},
-			HostKeyCallback: cryptossh.InsecureIgnoreHostKey(),
+			HostKeyCallback:   verifyHostKey,
+			HostKeyAlgorithms: hostKeyAlgos,
+		}
+		c, chans, reqs, err = cryptossh.NewClientConn(sh.conn, sh.addr(), sshConfig)
+		if mismatchErr != nil {
+			sh.conn.Close()
+			return mismatchErr
 		}
-		c, chans, reqs, err = cryptossh.NewClientConn(sh.conn, sh.cfg.PublicIP+":22", sshConfig)
 		if err != nil {
-			fi, _ := os.Stat(sh.cfg.KeyPath)
+			fileMode := ""
+			if fi, ferr := os.Stat(sh.cfg.KeyPath); ferr == nil {
+				fileMode = fi.Mode().String()
+			}
 			sh.lg.Warn(
 				"failed to connect",
 				zap.String("public-ip", sh.cfg.PublicIP),
 				zap.String("public-dns-name", sh.cfg.PublicDNSName),
-				zap.String("file-mode", fi.Mode().String()),
+				zap.String("file-mode", fileMode),
 				zap.String("error-type", fmt.Sprintf("%v", reflect.TypeOf(err))),
 				zap.Error(err),
 			)
@@ -195,6 +310,7 @@ func (sh *ssh) Connect() (err error) {
 	}
 
 	sh.cli = cryptossh.NewClient(c, chans, reqs)
+	sh.gen++
 	sh.lg.Debug("created client",
 		zap.String("public-ip", sh.cfg.PublicIP),
 		zap.String("public-dns-name", sh.cfg.PublicDNSName),
@@ -202,8 +318,127 @@ func (sh *ssh) Connect() (err error) {
 	return nil
 }
 
+// addr returns the address of the remote host. The private IP
+// is only reachable through the bastion host.
+func (sh *ssh) addr() string {
+	if sh.cfg.ProxyJump != nil && sh.cfg.PrivateIP != "" {
+		return net.JoinHostPort(sh.cfg.PrivateIP, "22")
+	}
+	return net.JoinHostPort(sh.cfg.PublicIP, "22")
+}
+
+// dial connects to the remote host, directly or through the bastion host.
+func (sh *ssh) dial(ctx context.Context) (net.Conn, error) {
+	if sh.cfg.ProxyJump == nil {
+		d := net.Dialer{}
+		return d.DialContext(ctx, "tcp", sh.addr())
+	}
+
+	if sh.bastion == nil {
+		bastion, err := sh.connectBastion(ctx)
+		if err != nil {
+			return nil, err
+		}
+		sh.bastion = bastion
+	}
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	// bastion client dial is not context-aware
+	donec := make(chan result, 1)
+	go func() {
+		conn, err := sh.bastion.Dial("tcp", sh.addr())
+		donec <- result{conn: conn, err: err}
+	}()
+	select {
+	case <-ctx.Done():
+		// reconnect to the bastion host on next dial
+		sh.bastion.Close()
+		sh.bastion = nil
+		return nil, ctx.Err()
+	case rs := <-donec:
+		if rs.err != nil {
+			sh.bastion.Close()
+			sh.bastion = nil
+			return nil, fmt.Errorf("failed to dial %q through bastion %q (%v)", sh.addr(), sh.cfg.ProxyJump.Host, rs.err)
+		}
+		return rs.conn, nil
+	}
+}
+
+func (sh *ssh) connectBastion(ctx context.Context) (*cryptossh.Client, error) {
+	pj := sh.cfg.ProxyJump
+	key, err := ioutil.ReadFile(pj.KeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bastion private key %v", err)
+	}
+	signer, err := cryptossh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bastion private key %v", err)
+	}
+	addr := pj.Host
+	if _, _, serr := net.SplitHostPort(addr); serr != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+
+	hostKeyCallback, hostKeyAlgos, err := sh.bastionHostKeyCallback(addr)
+	if err != nil {
+		return nil, err
+	}
+	// host key mismatch is not retried
+	var mismatchErr error
+	verifyHostKey := func(hostname string, remote net.Addr, key cryptossh.PublicKey) error {
+		herr := hostKeyCallback(hostname, remote, key)
+		if _, ok := herr.(*HostKeyMismatchError); ok {
+			mismatchErr = herr
+		}
+		return herr
+	}
+
+	sh.lg.Info("dialing bastion", zap.String("bastion", addr), zap.String("user-name", pj.UserName))
+	d := net.Dialer{}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial bastion %q (%v)", addr, err)
+	}
+	c, chans, reqs, err := cryptossh.NewClientConn(conn, addr, &cryptossh.ClientConfig{
+		User: pj.UserName,
+		Auth: []cryptossh.AuthMethod{
+			cryptossh.PublicKeys(signer),
+		},
+		HostKeyCallback:   verifyHostKey,
+		HostKeyAlgorithms: hostKeyAlgos,
+	})
+	if mismatchErr != nil {
+		conn.Close()
+		return nil, mismatchErr
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to bastion %q (%v)", addr, err)
+	}
+	sh.lg.Info("connected to bastion", zap.String("bastion", addr))
+	return cryptossh.NewClient(c, chans, reqs), nil
+}
+
 func (sh *ssh) Close() {
-	sh.cancel()
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.close()
+}
+
+func (sh *ssh) close() {
+	if sh.cancel != nil {
+		sh.cancel()
+	}
+	sh.closeSFTP()
+	// close the bastion even without the target connection,
+	// in case the bastion dial succeeded but the target dial failed
+	if sh.bastion != nil {
+		sh.bastion.Close()
+		sh.bastion = nil
+	}
 	if sh.conn != nil {
 		cerr := sh.conn.Close()
 		if cerr != nil {
@@ -221,20 +456,85 @@ func (sh *ssh) Close() {
 	)
 }
 
+// client returns the current client and its context,
+// with the connection generation to pass to "reconnect".
+func (sh *ssh) client() (*cryptossh.Client, context.Context, uint64) {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	ctx := sh.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return sh.cli, ctx, sh.gen
+}
+
+// reconnect closes and re-establishes the connection, unless another
+// operation already reconnected since the connection generation "gen".
+func (sh *ssh) reconnect(gen uint64, key string) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if sh.gen != gen {
+		sh.decrRetry(key)
+		return
+	}
+	sh.close()
+	for {
+		sh.decrRetry(key)
+		if connErr := sh.connect(); connErr == nil {
+			break
+		}
+		time.Sleep(3 * time.Second)
+	}
+}
+
+func (sh *ssh) initRetry(key string, retries int) {
+	sh.counterMu.Lock()
+	defer sh.counterMu.Unlock()
+	if _, ok := sh.retryCounter[key]; !ok {
+		sh.retryCounter[key] = retries
+	}
+}
+
+func (sh *ssh) retriesLeft(key string) int {
+	sh.counterMu.Lock()
+	defer sh.counterMu.Unlock()
+	return sh.retryCounter[key]
+}
+
+func (sh *ssh) decrRetry(key string) {
+	sh.counterMu.Lock()
+	defer sh.counterMu.Unlock()
+	sh.retryCounter[key]--
+}
+
+func (sh *ssh) resetRetry(key string) {
+	sh.counterMu.Lock()
+	defer sh.counterMu.Unlock()
+	delete(sh.retryCounter, key)
+}
+
+// Run runs the command in a new session. It is safe to call
+// concurrently, multiplexing the sessions over one connection.
+// The number of concurrent sessions is limited by the server
+// (e.g. "MaxSessions 10" by default in OpenSSH).
 func (sh *ssh) Run(cmd string, opts ...OpOption) (out []byte, err error) {
+	defer func() { observeFailure("run", err) }()
 	ret := Op{verbose: false, retriesLeft: 0, retryInterval: time.Duration(0), timeout: 0, envs: make(map[string]string)}
 	ret.applyOpts(opts)
 
 	key := fmt.Sprintf("%s%s-run", sh.cfg.PublicDNSName, cmd)
-	if _, ok := sh.retryCounter[key]; !ok {
-		sh.retryCounter[key] = ret.retriesLeft
-	}
+	sh.initRetry(key, ret.retriesLeft)
 
 	now := time.Now()
 
+	cli, sctx, gen := sh.client()
+	if cli == nil {
+		return nil, errors.New("not connected")
+	}
+
 	// session only accepts one call to Run, Start, Shell, Output, or CombinedOutput
 	var ss *cryptossh.Session
-	ss, err = sh.cli.NewSession()
+	ss, err = cli.NewSession()
 	if err != nil {
 		return nil, err
 	}
@@ -262,9 +562,9 @@ func (sh *ssh) Run(cmd string, opts ...OpOption) (out []byte, err error) {
 	var ctx context.Context
 	var cancel context.CancelFunc
 	if ret.timeout == 0 {
-		ctx, cancel = context.WithCancel(sh.ctx)
+		ctx, cancel = context.WithCancel(sctx)
 	} else {
-		ctx, cancel = context.WithTimeout(sh.ctx, ret.timeout)
+		ctx, cancel = context.WithTimeout(sctx, ret.timeout)
 	}
 
 	donec := make(chan error)
@@ -325,17 +625,10 @@ func (sh *ssh) Run(cmd string, opts ...OpOption) (out []byte, err error) {
 			}
 		}
 
-		if shouldRetry && sh.retryCounter[key] > 0 {
+		if shouldRetry && sh.retriesLeft(key) > 0 {
 			// e.g. "read tcp 10.119.223.210:58688->54.184.39.156:22: read: connection timed out"
-			sh.lg.Warn("retrying command run", zap.Int("retries", sh.retryCounter[key]))
-			sh.Close()
-			for {
-				sh.retryCounter[key]--
-				if connErr := sh.Connect(); connErr == nil {
-					break
-				}
-				time.Sleep(3 * time.Second)
-			}
+			sh.lg.Warn("retrying command run", zap.Int("retries", sh.retriesLeft(key)))
+			sh.reconnect(gen, key)
 			time.Sleep(ret.retryInterval)
 
 			// recursively retry
@@ -343,7 +636,7 @@ func (sh *ssh) Run(cmd string, opts ...OpOption) (out []byte, err error) {
 		}
 	}
 	if err == nil {
-		delete(sh.retryCounter, key)
+		sh.resetRetry(key)
 	}
 	return out, err
 }
@@ -376,6 +669,7 @@ scp -oStrictHostKeyChecking=no \
 */
 
 func (sh *ssh) Send(localPath, remotePath string, opts ...OpOption) (out []byte, err error) {
+	defer func() { observeFailure("send", err) }()
 	ret := Op{verbose: false, retriesLeft: 0, retryInterval: time.Duration(0), timeout: 0, envs: make(map[string]string)}
 	ret.applyOpts(opts)
 
@@ -390,31 +684,41 @@ func (sh *ssh) Send(localPath, remotePath string, opts ...OpOption) (out []byte,
 	if err != nil {
 		return nil, err
 	}
-	if err = os.Chmod(sh.cfg.KeyPath, 0400); err != nil {
+	keyPath := sh.cfg.KeyPath
+	if sh.cfg.InstanceConnect != nil {
+		var cleanup func()
+		keyPath, cleanup, err = sh.writeEphemeralKey()
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		sh.mu.RLock()
+		signer := sh.signer
+		sh.mu.RUnlock()
+		if err = sh.pushPublicKey(signer); err != nil {
+			return nil, err
+		}
+	} else if err = os.Chmod(sh.cfg.KeyPath, 0400); err != nil {
 		return nil, err
 	}
 
 	key := fmt.Sprintf("%s%s%s-send", sh.cfg.PublicDNSName, localPath, remotePath)
-	if _, ok := sh.retryCounter[key]; !ok {
-		sh.retryCounter[key] = ret.retriesLeft
-	}
+	sh.initRetry(key, ret.retriesLeft)
 
-	scpArgs := []string{
-		scpPath,
-		"-oStrictHostKeyChecking=no",
-		"-i", sh.cfg.KeyPath,
-		localPath,
-		fmt.Sprintf("%s@%s:%s", sh.cfg.UserName, sh.cfg.PublicDNSName, remotePath),
+	scpArgs, err := sh.scpArgs(scpPath, keyPath, localPath, remotePath)
+	if err != nil {
+		return nil, err
 	}
 
 	now := time.Now()
 
+	_, sctx, gen := sh.client()
 	var ctx context.Context
 	var cancel context.CancelFunc
 	if ret.timeout == 0 {
-		ctx, cancel = context.WithCancel(sh.ctx)
+		ctx, cancel = context.WithCancel(sctx)
 	} else {
-		ctx, cancel = context.WithTimeout(sh.ctx, ret.timeout)
+		ctx, cancel = context.WithTimeout(sctx, ret.timeout)
 	}
 	cmd := scpCmd.CommandContext(ctx, scpArgs[0], scpArgs[1:]...)
 	out, err = cmd.CombinedOutput()
@@ -426,16 +730,9 @@ func (sh *ssh) Send(localPath, remotePath string, opts ...OpOption) (out []byte,
 		} else {
 			sh.lg.Warn("command scp send failed", zap.String("error-type", reflect.TypeOf(err).String()), zap.Error(err))
 		}
-		if sh.retryCounter[key] > 0 {
-			sh.lg.Warn("retrying scp send", zap.Int("retries", sh.retryCounter[key]))
-			sh.Close()
-			for {
-				sh.retryCounter[key]--
-				if connErr := sh.Connect(); connErr == nil {
-					break
-				}
-				time.Sleep(3 * time.Second)
-			}
+		if sh.retriesLeft(key) > 0 {
+			sh.lg.Warn("retrying scp send", zap.Int("retries", sh.retriesLeft(key)))
+			sh.reconnect(gen, key)
 			time.Sleep(ret.retryInterval)
 
 			// recursively retry
@@ -447,100 +744,56 @@ func (sh *ssh) Send(localPath, remotePath string, opts ...OpOption) (out []byte,
 			zap.String("size", humanize.Bytes(uint64(fi.Size()))),
 			zap.String("started", humanize.RelTime(now, time.Now(), "ago", "from now")),
 		)
-		delete(sh.retryCounter, key)
+		sh.resetRetry(key)
 	}
 	return out, err
 }
 
-func (sh *ssh) Download(remotePath, localPath string, opts ...OpOption) (out []byte, err error) {
-	ret := Op{verbose: false, retriesLeft: 0, retryInterval: time.Duration(0), timeout: 0, envs: make(map[string]string)}
-	ret.applyOpts(opts)
-
-	scpCmd := exec.New()
-	var scpPath string
-	scpPath, err = scpCmd.LookPath("scp")
-	if err != nil {
-		return nil, err
-	}
-	if err = os.Chmod(sh.cfg.KeyPath, 0400); err != nil {
-		return nil, err
-	}
-
-	key := fmt.Sprintf("%s%s%s-download", sh.cfg.PublicDNSName, remotePath, localPath)
-	if _, ok := sh.retryCounter[key]; !ok {
-		sh.retryCounter[key] = ret.retriesLeft
-	}
-
-	now := time.Now()
-
-	var ctx context.Context
-	var cancel context.CancelFunc
-	if ret.timeout == 0 {
-		ctx, cancel = context.WithCancel(sh.ctx)
-	} else {
-		ctx, cancel = context.WithTimeout(sh.ctx, ret.timeout)
-	}
-
-	scpArgs := []string{
-		scpPath,
-		"-oStrictHostKeyChecking=no",
-		"-i", sh.cfg.KeyPath,
-		fmt.Sprintf("%s@%s:%s", sh.cfg.UserName, sh.cfg.PublicDNSName, remotePath),
-		localPath,
-	}
-	cmd := scpCmd.CommandContext(ctx, scpArgs[0], scpArgs[1:]...)
-	out, err = cmd.CombinedOutput()
-	cancel()
-
-	fi, ferr := os.Stat(localPath)
-	if ferr == nil {
-		sh.lg.Info("downloaded",
-			zap.String("size", humanize.Bytes(uint64(fi.Size()))),
-			zap.String("output", string(out)),
-			zap.String("started", humanize.RelTime(now, time.Now(), "ago", "from now")),
-		)
-	}
-
-	if err != nil {
-		oerr, ok := err.(*net.OpError)
-		if ok {
-			sh.lg.Warn("command scp download failed",
-				zap.String("output", string(out)),
-				zap.Bool("op-error-temporary", oerr.Temporary()),
-				zap.Bool("op-error-timeout", oerr.Timeout()),
-				zap.String("started", humanize.RelTime(now, time.Now(), "ago", "from now")),
-				zap.String("os-stat-error", fmt.Sprintf("%v", ferr)),
-				zap.Error(err),
-			)
-		} else {
-			sh.lg.Warn("command scp download failed",
-				zap.String("output", string(out)),
-				zap.String("error-type", reflect.TypeOf(err).String()),
-				zap.String("started", humanize.RelTime(now, time.Now(), "ago", "from now")),
-				zap.String("os-stat-error", fmt.Sprintf("%v", ferr)),
-				zap.Error(err),
-			)
+// scpArgs returns the "scp" command arguments to send the local file,
+// verifying the host keys of the remote host and the bastion host
+// in the same mode as the native connection.
+func (sh *ssh) scpArgs(scpPath, keyPath, localPath, remotePath string) ([]string, error) {
+	host := sh.cfg.PublicDNSName
+	scpArgs := []string{scpPath, "-i", keyPath}
+	hostKeyArgs := ""
+	if sh.cfg.InsecureSkipVerify {
+		hostKeyArgs = "-oStrictHostKeyChecking=no"
+	} else if sh.cfg.HostKeys != nil {
+		// host keys are pinned on connect
+		hostKeyArgs = "-oStrictHostKeyChecking=yes -oUserKnownHostsFile=" + sh.cfg.HostKeys.Path()
+	}
+	if hostKeyArgs != "" {
+		scpArgs = append(scpArgs, strings.Fields(hostKeyArgs)...)
+	}
+	if pj := sh.cfg.ProxyJump; pj != nil {
+		// "-J" cannot take a separate key for the bastion host
+		bastionHost, bastionPort, serr := net.SplitHostPort(pj.Host)
+		if serr != nil {
+			bastionHost, bastionPort = pj.Host, "22"
 		}
-		if sh.retryCounter[key] > 0 {
-			sh.lg.Warn("retrying scp download", zap.Int("retries", sh.retryCounter[key]))
-			sh.Close()
-			for {
-				sh.retryCounter[key]--
-				if connErr := sh.Connect(); connErr == nil {
-					break
-				}
-				time.Sleep(3 * time.Second)
+		if !sh.cfg.InsecureSkipVerify && sh.cfg.HostKeys != nil {
+			// pin the bastion host keys in the "known_hosts" file,
+			// for the proxy command to verify
+			if _, err := sh.cfg.HostKeys.get(sh.bastionConfig(net.JoinHostPort(bastionHost, bastionPort))); err != nil {
+				return nil, fmt.Errorf("failed to pin bastion host keys (%v)", err)
 			}
-			time.Sleep(ret.retryInterval)
-
-			// recursively retry
-			out, err = sh.Download(remotePath, localPath, opts...)
+		}
+		proxyCmd := "ssh"
+		if hostKeyArgs != "" {
+			proxyCmd += " " + hostKeyArgs
+		}
+		scpArgs = append(scpArgs, fmt.Sprintf(
+			"-oProxyCommand=%s -i %s -p %s -W %%h:%%p %s@%s",
+			proxyCmd, pj.KeyPath, bastionPort, pj.UserName, bastionHost,
+		))
+		if sh.cfg.PrivateIP != "" {
+			host = sh.cfg.PrivateIP
 		}
 	}
-	if err == nil {
-		delete(sh.retryCounter, key)
-	}
-	return out, err
+	return append(scpArgs,
+		localPath,
+		fmt.Sprintf("%s@%s:%s", sh.cfg.UserName, host, remotePath),
+	), nil
 }
 
 // Op represents a SSH operation.
@@ -550,6 +803,8 @@ type Op struct {
 	retryInterval time.Duration
 	timeout       time.Duration
 	envs          map[string]string
+	sudo          bool
+	pty           bool
 }
 
 // OpOption configures archiver operations.
@@ -583,6 +838,20 @@ func WithEnv(k, v string) OpOption {
 	return func(op *Op) { op.envs[k] = v }
 }
 
+// WithPTY requests a pseudo terminal for the streamed command,
+// to terminate the remote command (e.g. "journalctl -f") on hang-up
+// when the stream is canceled.
+func WithPTY(b bool) OpOption {
+	return func(op *Op) { op.pty = b }
+}
+
+// WithSudo transfers files as root in "Upload" and "Download"
+// (e.g. to download "/var/log/messages").
+// Requires password-less sudo on the remote host.
+func WithSudo(b bool) OpOption {
+	return func(op *Op) { op.sudo = b }
+}
+
 func (op *Op) applyOpts(opts []OpOption) {
 	for _, opt := range opts {
 		opt(op)
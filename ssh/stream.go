@@ -0,0 +1,108 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"go.uber.org/zap"
+	cryptossh "golang.org/x/crypto/ssh"
+)
+
+// Stream runs the command and writes its outputs to the writers as
+// they are produced, instead of buffering the whole output in memory
+// (e.g. "journalctl -f", long-running soak scripts).
+// It returns when the command exits, or when the context is canceled
+// or timed out, in which case the remote command is terminated.
+// Nil writers discard the output. The writers are written to from
+// separate goroutines, so the same writer must be safe for concurrent
+// writes when used for both stdout and stderr.
+// The command is never retried, since the outputs cannot be replayed.
+func (sh *ssh) Stream(ctx context.Context, cmd string, stdout io.Writer, stderr io.Writer, opts ...OpOption) (err error) {
+	defer func() { observeFailure("stream", err) }()
+	ret := Op{verbose: false, retriesLeft: 0, retryInterval: time.Duration(0), timeout: 0, envs: make(map[string]string)}
+	ret.applyOpts(opts)
+
+	cli, sctx, _ := sh.client()
+	if cli == nil {
+		return errors.New("not connected")
+	}
+	if ret.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ret.timeout)
+		defer cancel()
+	}
+
+	ss, err := cli.NewSession()
+	if err != nil {
+		return err
+	}
+	defer ss.Close()
+	ss.Stdout, ss.Stderr = stdout, stderr
+
+	for k, v := range sh.cfg.Envs {
+		if err = ss.Setenv(k, v); err != nil {
+			return err
+		}
+	}
+	for k, v := range ret.envs {
+		if err = ss.Setenv(k, v); err != nil {
+			return err
+		}
+	}
+	if ret.pty {
+		// remote process gets SIGHUP when the session is closed,
+		// even if the server does not support signals
+		if err = ss.RequestPty("xterm", 80, 200, cryptossh.TerminalModes{cryptossh.ECHO: 0}); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	if err = ss.Start(cmd); err != nil {
+		return err
+	}
+	if ret.verbose {
+		sh.lg.Info("streaming command", zap.String("cmd", cmd))
+	}
+
+	donec := make(chan error, 1)
+	go func() {
+		donec <- ss.Wait()
+	}()
+	select {
+	case err = <-donec:
+	case <-ctx.Done():
+		sh.terminate(ss, donec)
+		err = ctx.Err()
+	case <-sctx.Done():
+		sh.terminate(ss, donec)
+		err = errors.New("connection closed")
+	}
+
+	if ret.verbose || err != nil {
+		sh.lg.Info("streamed command",
+			zap.String("cmd", cmd),
+			zap.String("started", humanize.RelTime(now, time.Now(), "ago", "from now")),
+			zap.Error(err),
+		)
+	}
+	return err
+}
+
+// terminate signals the remote command to exit, and closes
+// the session if it does not exit in time.
+func (sh *ssh) terminate(ss *cryptossh.Session, donec <-chan error) {
+	if err := ss.Signal(cryptossh.SIGTERM); err != nil {
+		sh.lg.Debug("failed to signal remote command", zap.Error(err))
+	}
+	select {
+	case <-donec:
+		return
+	case <-time.After(5 * time.Second):
+	}
+	ss.Close()
+	<-donec
+}